@@ -1,14 +1,19 @@
 package main
 
 import (
+	"errors"
 	"log"
 	"os"
+	"runtime"
 
 	gioapp "gioui.org/app"
 	"gioui.org/unit"
 
 	"github.com/vocdoni/gofirma/vocsign/internal/app"
 	"github.com/vocdoni/gofirma/vocsign/internal/crypto/systemstore"
+	"github.com/vocdoni/gofirma/vocsign/internal/launch"
+	"github.com/vocdoni/gofirma/vocsign/internal/platform"
+	"github.com/vocdoni/gofirma/vocsign/internal/storage"
 	"github.com/vocdoni/gofirma/vocsign/internal/ui"
 )
 
@@ -16,6 +21,8 @@ var (
 	version   = "dev"
 	commit    = "unknown"
 	buildDate = "unknown"
+	// vcsModified is "true" or "false", set from the git status at build time.
+	vcsModified = ""
 )
 
 func main() {
@@ -23,22 +30,49 @@ func main() {
 		os.Exit(systemstore.RunNSSScanWorker(os.Args[2:]))
 	}
 
+	budget := platform.ApplyMemoryBudget()
+	log.Printf("DEBUG: memory budget %d MiB", budget>>20)
+
+	args, err := launch.Parse(os.Args[1:])
+	if err != nil {
+		log.Printf("WARNING: ignoring command line: %v", err)
+	}
+
 	vocsignApp, err := app.NewApp(app.BuildInfo{
-		Version:   version,
-		Commit:    commit,
-		BuildDate: buildDate,
+		Version:     version,
+		Commit:      commit,
+		BuildDate:   buildDate,
+		VCSModified: vcsModified,
 	})
 	if err != nil {
 		log.Fatalf("Failed to initialize app: %v", err)
 	}
+	vocsignApp.SetLaunch(args)
+	if p := vocsignApp.Platform; p.Backend != p.Session {
+		log.Printf("DEBUG: using %s in a %s session: %s", p.Backend, p.Session, p.BackendReason)
+	}
+	vocsignApp.Platform.Apply()
 
 	go func() {
+		ws := vocsignApp.WindowState()
 		w := new(gioapp.Window)
 		w.Option(
 			gioapp.Title("VocSign"),
-			gioapp.Size(unit.Dp(1280), unit.Dp(920)),
+			gioapp.Size(unit.Dp(ws.Width), unit.Dp(ws.Height)),
+			gioapp.MinSize(unit.Dp(storage.MinWindowWidth), unit.Dp(storage.MinWindowHeight)),
 		)
+		if vocsignApp.Managed.Kiosk {
+			w.Option(gioapp.Fullscreen.Option())
+		} else if ws.Maximized {
+			w.Option(gioapp.Maximized.Option())
+		}
+		if args.Autostart && !vocsignApp.Managed.Kiosk {
+			w.Option(gioapp.Minimized.Option())
+		}
 		if err := ui.Run(w, vocsignApp); err != nil {
+			if errors.Is(err, ui.ErrRender) {
+				renderFailed(err)
+			}
 			log.Fatalf("UI failed: %v", err)
 		}
 		os.Exit(0)
@@ -46,3 +80,32 @@ func main() {
 
 	gioapp.Main()
 }
+
+// renderFailed handles a window that could not be drawn. On Linux VocSign
+// starts again with software rendering; otherwise, or if that fails too,
+// the user is told why it closes.
+func renderFailed(err error) {
+	if platform.CurrentRenderMode().CanFallBack() {
+		log.Printf("WARNING: %v; starting again with software rendering", err)
+		code, rerr := platform.RelaunchSoftware(err)
+		if rerr == nil {
+			os.Exit(code)
+		}
+		log.Printf("ERROR: failed to start with software rendering: %v", rerr)
+	}
+	msg := "VocSign could not draw its window: " + err.Error() + "\n\n" + renderHint()
+	if derr := platform.ShowError("VocSign cannot start", msg); derr != nil {
+		log.Printf("WARNING: failed to show the error: %v", derr)
+	}
+}
+
+func renderHint() string {
+	switch runtime.GOOS {
+	case "windows":
+		return "VocSign needs Direct3D 11. Update the graphics driver; in a Remote Desktop session, ask the administrator to allow the Microsoft Basic Render Driver."
+	case "darwin":
+		return "VocSign needs a Mac that supports Metal."
+	default:
+		return "Software rendering needs Mesa's llvmpipe driver (the libegl-mesa0 and libgl1-mesa-dri packages on Debian and Ubuntu, mesa-dri-drivers on Fedora)."
+	}
+}
@@ -7,10 +7,12 @@ require (
 	gioui.org/x v0.9.0
 	github.com/github/smimesign v0.2.0
 	github.com/google/uuid v1.6.0
+	github.com/jackc/pgx/v5 v5.11.0
 	github.com/miekg/pkcs11 v1.1.2
 	github.com/smallstep/pkcs7 v0.2.1
 	golang.org/x/crypto v0.48.0
 	golang.org/x/exp/shiny v0.0.0-20260212183809-81e46e3db34a
+	golang.org/x/sys v0.41.0
 	golang.org/x/text v0.34.0
 	software.sslmate.com/src/go-pkcs12 v0.7.0
 )
@@ -20,8 +22,11 @@ require (
 	git.wow.st/gmp/jni v0.0.0-20210610011705-34026c7e22d0 // indirect
 	github.com/go-text/typesetting v0.3.0 // indirect
 	github.com/godbus/dbus/v5 v5.0.6 // indirect
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20240606120523-5a60cdf6a761 // indirect
+	github.com/jackc/puddle/v2 v2.2.2 // indirect
 	github.com/pkg/errors v0.8.1 // indirect
 	golang.org/x/exp v0.0.0-20251023183803-a4bb9ffd2546 // indirect
 	golang.org/x/image v0.36.0 // indirect
-	golang.org/x/sys v0.41.0 // indirect
+	golang.org/x/sync v0.19.0 // indirect
 )
@@ -2,21 +2,49 @@ package app
 
 import (
 	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
+	"runtime"
 	"runtime/debug"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"gioui.org/x/explorer"
+	"github.com/vocdoni/gofirma/vocsign/internal/buildinfo"
+	"github.com/vocdoni/gofirma/vocsign/internal/changelog"
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/cades"
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/jwsverify"
 	"github.com/vocdoni/gofirma/vocsign/internal/crypto/pkcs12store"
 	"github.com/vocdoni/gofirma/vocsign/internal/crypto/systemstore"
+	"github.com/vocdoni/gofirma/vocsign/internal/datadir"
+	"github.com/vocdoni/gofirma/vocsign/internal/demo"
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+	"github.com/vocdoni/gofirma/vocsign/internal/launch"
+	"github.com/vocdoni/gofirma/vocsign/internal/locale"
+	"github.com/vocdoni/gofirma/vocsign/internal/managed"
 	"github.com/vocdoni/gofirma/vocsign/internal/model"
 	appnet "github.com/vocdoni/gofirma/vocsign/internal/net"
+	"github.com/vocdoni/gofirma/vocsign/internal/perf"
+	"github.com/vocdoni/gofirma/vocsign/internal/platform"
+	"github.com/vocdoni/gofirma/vocsign/internal/presign"
+	"github.com/vocdoni/gofirma/vocsign/internal/sandbox"
+	"github.com/vocdoni/gofirma/vocsign/internal/selfcheck"
+	"github.com/vocdoni/gofirma/vocsign/internal/settings"
 	"github.com/vocdoni/gofirma/vocsign/internal/storage"
+	"github.com/vocdoni/gofirma/vocsign/internal/telemetry"
+	"github.com/vocdoni/gofirma/vocsign/internal/update"
 	"github.com/vocdoni/gofirma/vocsign/internal/version"
 )
 
@@ -29,8 +57,29 @@ const (
 	ScreenAbout
 	ScreenRequestDetails
 	ScreenWizard
+	ScreenSettings
+	ScreenSecurity
 )
 
+var screenNames = [...]string{
+	ScreenOpenRequest:    "open_request",
+	ScreenCertificates:   "certificates",
+	ScreenAudit:          "audit",
+	ScreenAbout:          "about",
+	ScreenRequestDetails: "request_details",
+	ScreenWizard:         "wizard",
+	ScreenSettings:       "settings",
+	ScreenSecurity:       "security",
+}
+
+// String names the screen in logs and the performance overlay.
+func (s Screen) String() string {
+	if s < 0 || int(s) >= len(screenNames) {
+		return fmt.Sprintf("screen(%d)", int(s))
+	}
+	return screenNames[s]
+}
+
 type App struct {
 	mu            sync.RWMutex
 	CurrentScreen Screen
@@ -40,7 +89,35 @@ type App struct {
 	// Services
 	Store       pkcs12store.Store
 	AuditLogger *storage.AuditLogger
-	Explorer    *explorer.Explorer
+	// SecurityLog records unlocks, failed PINs and passwords, settings
+	// changes and trust decisions, apart from the signing history.
+	SecurityLog *storage.SecurityLog
+	Requests    *storage.RequestStore
+	Receipts    *storage.ReceiptStore
+	Organizers  *storage.OrganizerStore
+	Policies    *storage.PolicyCache
+	Sessions    *storage.SessionStore
+	CertAcks    *storage.CertAckStore
+	Journal     *storage.Journal
+	Window      *storage.WindowStore
+	WalletPIN   *storage.WalletPINStore
+	Settings    *settings.Store
+	Telemetry   *telemetry.Client
+	// Managed is the administrator's policy, zero if there is none.
+	Managed  managed.Policy
+	Explorer *explorer.Explorer
+	// Platform is what the display session offers, detected before the
+	// window is created.
+	Platform platform.Caps
+	// Perf times the UI's frames. VOCSIGN_JANK_LOG=1 logs every jank;
+	// otherwise they are only logged while the overlay is open.
+	Perf         *perf.Recorder
+	jankLog      bool
+	jankReported map[string]bool
+	// dualControlLimit counts wrong dual-control codes across signatures.
+	dualControlLimit presign.Limiter
+	// walletPINLimit counts wrong wallet PINs, with the same backoff.
+	walletPINLimit presign.Limiter
 
 	// State
 	Identities       []pkcs12store.Identity
@@ -50,29 +127,143 @@ type App struct {
 	// Current Action State
 	CurrentReq   *model.SignRequest
 	RawReq       []byte
+	ReqWarnings  []string
+	ReqLabels    model.Labels
+	ReqDiff      []model.FieldChange
 	ReqError     error
 	FetchStatus  string
 	SignStatus   string
 	SignResponse *model.SignResponse
 
+	// Open campaigns listed by pinned organizers
+	PinnedCampaigns  []PinnedCampaign
+	campaignsLoading bool
+
+	// Pending hardware token PIN prompt, answered by the UI
+	pinRequest *PINRequest
+
+	// Pending wallet PIN prompt, answered by the quick unlock dialog.
+	// unlockMu lets one UnlockWallet ask at a time.
+	unlockRequest *PINRequest
+	unlockMu      sync.Mutex
+	// lastActivity is when the user last used the window, in Unix
+	// nanoseconds, for the inactivity auto-lock. sessionLockFailed is set
+	// once a failure to read the session's lock state was logged.
+	lastActivity      atomic.Int64
+	sessionLockFailed bool
+	// busy counts the signings and agent batches running, which keep the
+	// wallet from locking itself.
+	busy atomic.Int32
+
+	// ResumeSession is a signing interrupted by a crash or close, offered on
+	// the Open Request screen. sessionRestore is the one being resumed,
+	// applied by the request screen once the request is loaded again.
+	ResumeSession  *storage.Session
+	sessionRestore *storage.Session
+
+	// interrupted are the signings the journal shows were cut short by a
+	// crash or a forced close, until the user dismisses them or signs again.
+	interrupted []storage.JournalEntry
+
+	// demoIdentity signs the requests of demo campaigns. It is made on
+	// first use and forgotten when the app quits.
+	demoIdentity *pkcs12store.Identity
+
+	// Set when the window gains focus so the Open Request screen looks for a
+	// signing URL in the clipboard.
+	clipboardCheck bool
+
+	// Token identities whose browser profile moved, with the profile that
+	// holds the same certificate now. Dismissed offers are not made again
+	// this session.
+	relinkOffers    []RelinkOffer
+	relinkDismissed map[string]bool
+
+	// Signatures collected in agent mode for the current request
+	batch AgentBatch
+
 	// UI Actions
 	RequestURL string
 	Invalidate func()
 
+	// reloadURL is a request to fetch again on the Open Request screen,
+	// e.g. because the organizer amended the proposal text.
+	reloadURL string
+
+	// launchURL and launchCertificate are what the command line asked to
+	// open, offered by the Open Request screen and the wizard.
+	launchURL         string
+	launchCertificate string
+
 	LatestVersion   string
 	ReleasePageURL  string
 	UpdateAvailable bool
 	UpdateChecked   bool
 	UpdateCheckErr  string
 	UpdateMessage   string
+	// ReleaseNotes and ReleaseDate describe LatestVersion.
+	ReleaseNotes changelog.Notes
+	ReleaseDate  time.Time
+
+	releaseAssets []update.Asset
+	// download is the in-app download of LatestVersion.
+	download updateDownload
 
 	updateChecking bool
+	updateRecheck  bool
+	// updateChannel is the channel LatestVersion was found on, and
+	// latestPrerelease whether it is a pre-release.
+	updateChannel    string
+	latestPrerelease bool
+
+	// Result of verifying the running binary against its release manifest
+	integrity selfcheck.Result
+
+	// Outcome of migrating and checking the data directory at startup, and
+	// whether the user dismissed it.
+	DataDir          datadir.Report
+	DataDirDismissed bool
+	// dataDir is the data directory, ~/.vocsign.
+	dataDir string
 }
 
 type BuildInfo struct {
 	Version   string
 	Commit    string
 	BuildDate string
+	// VCSModified is "true" when built from a checkout with uncommitted
+	// changes. Set at link time since release builds disable -buildvcs.
+	VCSModified string
+
+	// Details is read from the metadata the Go linker embeds in the binary.
+	Details buildinfo.Details
+}
+
+// PinnedCampaign is an open request listed by a pinned organizer whose
+// organizer signature has been verified.
+type PinnedCampaign struct {
+	Organizer string
+	RequestID string
+	Title     string
+	Promoter  string
+	ExpiresAt string
+	URL       string
+}
+
+// PINRequest is a prompt for a short secret (a token PIN or a confirmation
+// code) that a signing goroutine is waiting on.
+type PINRequest struct {
+	Message string
+	Hint    string
+	reply   chan []byte
+}
+
+// Respond hands pin to the waiting signer. A nil pin cancels the prompt.
+func (r *PINRequest) Respond(pin []byte) {
+	select {
+	case r.reply <- pin:
+	default:
+	}
 }
 
 type UpdateStatus struct {
@@ -84,6 +275,47 @@ type UpdateStatus struct {
 	Checking       bool
 	Error          string
 	Message        string
+	// Notes are the release notes of LatestVersion; Security reports
+	// whether they list security fixes.
+	Notes       changelog.Notes
+	Security    bool
+	PublishedAt time.Time
+
+	// Channel is the update channel LatestVersion was found on, and
+	// Prerelease whether it is a pre-release. CurrentPrerelease is set when
+	// the running build is one.
+	Channel           string
+	Prerelease        bool
+	CurrentPrerelease bool
+
+	// CanDownload reports whether the release has an executable for this
+	// platform that DownloadUpdate can fetch, and the build has the release
+	// key to verify it with. Otherwise the release page is opened.
+	CanDownload bool
+	// Downloading is set while DownloadUpdate runs; DownloadDone of
+	// DownloadTotal bytes have arrived.
+	Downloading   bool
+	DownloadDone  int64
+	DownloadTotal int64
+	DownloadErr   string
+	// Downloaded describes the finished download, ready to install.
+	Downloaded *update.Result
+	// InstallBlocked says why the download cannot replace the running
+	// executable; the user installs Downloaded.Path instead.
+	InstallBlocked string
+	Installed      bool
+	InstallErr     string
+}
+
+// updateDownload is the state of DownloadUpdate and InstallUpdate.
+type updateDownload struct {
+	cancel      context.CancelFunc
+	done, total int64
+	err         string
+	result      *update.Result
+	blocked     string
+	installed   bool
+	installErr  string
 }
 
 func (a *App) SystemIdentitiesSnapshot() []pkcs12store.Identity {
@@ -118,18 +350,46 @@ func (a *App) SetIdentities(ids []pkcs12store.Identity) {
 	a.Identities = out
 }
 
+// reloadIdentities refreshes the wallet after the store changed.
+func (a *App) reloadIdentities() {
+	ids, err := a.Store.List(context.Background())
+	if err != nil {
+		log.Printf("WARNING: failed to reload identities: %v", err)
+		return
+	}
+	a.SetIdentities(ids)
+	if a.Invalidate != nil {
+		a.Invalidate()
+	}
+}
+
 func (a *App) UpdateStatusSnapshot() UpdateStatus {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 	return UpdateStatus{
-		CurrentVersion: a.BuildInfo.Version,
-		LatestVersion:  a.LatestVersion,
-		ReleasePageURL: a.ReleasePageURL,
-		Available:      a.UpdateAvailable,
-		Checked:        a.UpdateChecked,
-		Checking:       a.updateChecking,
-		Error:          a.UpdateCheckErr,
-		Message:        a.UpdateMessage,
+		CurrentVersion:    a.BuildInfo.Version,
+		LatestVersion:     a.LatestVersion,
+		ReleasePageURL:    a.ReleasePageURL,
+		Available:         a.UpdateAvailable,
+		Checked:           a.UpdateChecked,
+		Checking:          a.updateChecking,
+		Error:             a.UpdateCheckErr,
+		Message:           a.UpdateMessage,
+		Notes:             a.ReleaseNotes,
+		Security:          a.ReleaseNotes.Security(),
+		PublishedAt:       a.ReleaseDate,
+		Channel:           nonEmpty(a.updateChannel, a.Settings.Get().Channel()),
+		Prerelease:        a.latestPrerelease,
+		CurrentPrerelease: version.IsPrerelease(a.BuildInfo.Version),
+		CanDownload:       selfcheck.Signed() && slices.ContainsFunc(a.releaseAssets, func(as update.Asset) bool { return as.Name == updateBinary }),
+		Downloading:       a.download.cancel != nil,
+		DownloadDone:      a.download.done,
+		DownloadTotal:     a.download.total,
+		DownloadErr:       a.download.err,
+		Downloaded:        a.download.result,
+		InstallBlocked:    a.download.blocked,
+		Installed:         a.download.installed,
+		InstallErr:        a.download.installErr,
 	}
 }
 
@@ -144,6 +404,8 @@ func (a *App) CheckUpdatesNow() {
 func (a *App) runUpdateCheck(force bool) {
 	a.mu.Lock()
 	if a.updateChecking {
+		// The channel may have changed since the running check started.
+		a.updateRecheck = a.updateRecheck || force
 		a.mu.Unlock()
 		return
 	}
@@ -155,17 +417,25 @@ func (a *App) runUpdateCheck(force bool) {
 	a.updateChecking = true
 	a.UpdateMessage = "Checking for updates..."
 	a.mu.Unlock()
-	log.Printf("DEBUG: update check started (current=%s force=%v)", a.BuildInfo.Version, force)
+	channel := a.Settings.Get().Channel()
+	log.Printf("DEBUG: update check started (current=%s channel=%s force=%v)", a.BuildInfo.Version, channel, force)
 
 	go func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
 		defer cancel()
 
-		latest, releaseURL, err := appnet.FetchLatestRelease(ctx)
+		rel, err := appnet.FetchLatestRelease(ctx, channel == settings.ChannelBeta)
 
 		a.mu.Lock()
 		a.updateChecking = false
 		a.UpdateChecked = true
+		recheck := a.updateRecheck
+		a.updateRecheck = false
+		defer func() {
+			if recheck {
+				a.runUpdateCheck(true)
+			}
+		}()
 		if err != nil {
 			log.Printf("DEBUG: update check failed: %v", err)
 			a.UpdateCheckErr = err.Error()
@@ -177,15 +447,34 @@ func (a *App) runUpdateCheck(force bool) {
 			return
 		}
 		a.UpdateCheckErr = ""
+		latest := rel.Tag
+		a.updateChannel = channel
 		a.LatestVersion = latest
-		if releaseURL != "" {
-			a.ReleasePageURL = releaseURL
+		a.latestPrerelease = rel.Prerelease
+		a.ReleasePageURL = rel.URL
+		a.ReleaseNotes = rel.Notes
+		a.ReleaseDate = rel.PublishedAt
+		a.releaseAssets = rel.Assets
+		if r := a.download.result; r != nil && r.Version != latest {
+			a.download = updateDownload{}
 		}
 		a.UpdateAvailable = version.IsOutdated(a.BuildInfo.Version, latest)
-		if a.UpdateAvailable {
+		switch {
+		case a.UpdateAvailable && rel.Notes.Security():
+			a.UpdateMessage = "Security update available: " + latest
+			log.Printf("DEBUG: update check result: outdated with security fixes current=%s latest=%s", a.BuildInfo.Version, latest)
+		case a.UpdateAvailable && rel.Prerelease:
+			a.UpdateMessage = "New beta available: " + latest
+			log.Printf("DEBUG: update check result: outdated current=%s latest=%s (pre-release)", a.BuildInfo.Version, latest)
+		case a.UpdateAvailable:
 			a.UpdateMessage = "New version available: " + latest
 			log.Printf("DEBUG: update check result: outdated current=%s latest=%s", a.BuildInfo.Version, latest)
-		} else {
+		case version.Compare(a.BuildInfo.Version, latest) > 0:
+			// A tester back on the stable channel keeps the newer beta
+			// until a release supersedes it.
+			a.UpdateMessage = "You are using a pre-release newer than " + latest
+			log.Printf("DEBUG: update check result: ahead of channel current=%s latest=%s", a.BuildInfo.Version, latest)
+		default:
 			a.UpdateMessage = "You are using the latest version"
 			log.Printf("DEBUG: update check result: up-to-date current=%s latest=%s", a.BuildInfo.Version, latest)
 		}
@@ -197,147 +486,2121 @@ func (a *App) runUpdateCheck(force bool) {
 	}()
 }
 
-func (a *App) ScanSystemStores(ctx context.Context) {
-	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
-	defer cancel()
-	start := time.Now()
-	log.Printf("DEBUG: ScanSystemStores started")
-	var all []pkcs12store.Identity
+// updateBinary is the release asset this build updates from.
+var updateBinary = update.BinaryName(runtime.GOOS, runtime.GOARCH)
 
-	// 1. OS-Native Store
-	osStore := &systemstore.OSStore{Label: "System"}
-	log.Printf("DEBUG: ScanSystemStores: scanning OS store %q", osStore.Label)
-	ids, err := safeList(osStore.List, ctx, "OS store")
-	if err == nil {
-		all = append(all, ids...)
-		log.Printf("DEBUG: ScanSystemStores: OS store returned %d identities", len(ids))
-	} else {
-		log.Printf("DEBUG: ScanSystemStores: OS store error: %v", err)
+// runningExecutable is the path of the executable, with symlinks resolved.
+func runningExecutable() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
 	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	return exe, nil
+}
 
-	// 2. NSS Stores
-	nssStores := systemstore.DiscoverNSSStores(ctx)
-	log.Printf("DEBUG: ScanSystemStores: discovered %d NSS stores", len(nssStores))
-	var nssMu sync.Mutex
-	sem := make(chan struct{}, 4)
-	var wg sync.WaitGroup
-	for _, s := range nssStores {
-		s := s
-		sem <- struct{}{}
-		wg.Add(1)
-		go func() {
-			defer func() {
-				<-sem
-				wg.Done()
-			}()
-			log.Printf("DEBUG: ScanSystemStores: scanning NSS store label=%q profile=%q", s.Label, s.ProfileDir)
-			ids, err := safeList(s.List, ctx, "NSS store "+s.Label)
-			if err == nil {
-				nssMu.Lock()
-				all = append(all, ids...)
-				nssMu.Unlock()
-				log.Printf("DEBUG: ScanSystemStores: NSS store %q returned %d identities", s.Label, len(ids))
-			} else {
-				log.Printf("DEBUG: ScanSystemStores: NSS store %q error: %v", s.Label, err)
-			}
-		}()
+// DownloadUpdate downloads LatestVersion in the background, as a patch of
+// the running executable when the release has one, and checks it against
+// its release manifest.
+func (a *App) DownloadUpdate() {
+	a.mu.Lock()
+	if a.download.cancel != nil || !a.UpdateAvailable || !selfcheck.Signed() {
+		a.mu.Unlock()
+		return
 	}
-	wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	version, assets, current := a.LatestVersion, a.releaseAssets, a.BuildInfo.Version
+	a.download = updateDownload{cancel: cancel}
+	a.mu.Unlock()
 
-	// 3. PKCS#12 files (passwordless only)
-	var lockedP12 []string
-	p12Paths := systemstore.FindPKCS12Candidates(ctx, 5, 200)
-	log.Printf("DEBUG: ScanSystemStores: discovered %d candidate PKCS#12 files", len(p12Paths))
-	for _, p := range p12Paths {
-		id, err := systemstore.ParsePKCS12Metadata(p, "")
-		if err != nil {
-			if errors.Is(err, systemstore.ErrPKCS12PasswordRequired) {
-				log.Printf("DEBUG: PKCS#12 file requires password, skipping auto-import: %s", p)
-				lockedP12 = append(lockedP12, p)
-			} else {
-				log.Printf("DEBUG: PKCS#12 parse skipped for %s: %v", p, err)
+	go func() {
+		defer cancel()
+		res, err := a.downloadUpdate(ctx, version, assets, current)
+		var blocked string
+		if err == nil {
+			blocked = installBlocked()
+		}
+		a.mu.Lock()
+		a.download.cancel = nil
+		switch {
+		case err != nil && ctx.Err() != nil:
+			log.Printf("DEBUG: update download canceled")
+		case err != nil:
+			log.Printf("ERROR: update download failed: %v", err)
+			a.download.err = err.Error()
+		default:
+			a.download.result = res
+			a.download.blocked = blocked
+		}
+		a.mu.Unlock()
+		if a.Invalidate != nil {
+			a.Invalidate()
+		}
+	}()
+}
+
+func (a *App) downloadUpdate(ctx context.Context, version string, assets []update.Asset, current string) (*update.Result, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	exe, err := runningExecutable()
+	if err != nil {
+		return nil, err
+	}
+	d := &update.Downloader{
+		Client: appnet.NewDownloadClient(),
+		Dir:    filepath.Join(home, ".vocsign", "updates"),
+		Binary: updateBinary,
+		Verify: func(path, version string) error {
+			// Only a download that matches its signed manifest replaces
+			// the running executable; development builds have no key to
+			// check it with.
+			if res := selfcheck.CheckFile(path, version); res.Status != selfcheck.StatusVerified {
+				return errors.New(res.Detail)
 			}
-			continue
+			return nil
+		},
+	}
+	return d.Download(ctx, version, assets, current, exe, func(done, total int64) {
+		a.mu.Lock()
+		a.download.done, a.download.total = done, total
+		a.mu.Unlock()
+		if a.Invalidate != nil {
+			a.Invalidate()
 		}
-		all = append(all, id)
+	})
+}
+
+// installBlocked says why an update cannot replace the running executable.
+func installBlocked() string {
+	if sb := sandbox.Detect(); sb.Confined() {
+		return "VocSign runs as a " + string(sb.Kind) + " package, which is updated by its package manager"
 	}
+	exe, err := runningExecutable()
+	if err != nil {
+		return err.Error()
+	}
+	return update.InstallBlocked(exe)
+}
 
+// CancelUpdateDownload stops DownloadUpdate. A partial download of the full
+// executable is kept and resumed by the next DownloadUpdate.
+func (a *App) CancelUpdateDownload() {
 	a.mu.Lock()
 	defer a.mu.Unlock()
-	a.LockedP12 = lockedP12
+	if a.download.cancel != nil {
+		a.download.cancel()
+	}
+}
 
-	// Deduplicate based on Fingerprint
-	seen := make(map[string]bool)
-	for _, id := range a.Identities {
-		seen[fmt.Sprintf("%x", id.Fingerprint256)] = true
+// InstallUpdate replaces the running executable with the downloaded
+// release. The new version runs from the next start.
+func (a *App) InstallUpdate() {
+	a.mu.Lock()
+	res := a.download.result
+	a.mu.Unlock()
+	if res == nil {
+		return
+	}
+	err := func() error {
+		exe, err := runningExecutable()
+		if err != nil {
+			return err
+		}
+		return update.Install(res, exe)
+	}()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err != nil {
+		log.Printf("ERROR: update install failed: %v", err)
+		a.download.installErr = err.Error()
+		return
 	}
+	a.download.installed, a.download.installErr = true, ""
+	a.UpdateMessage = "Restart VocSign to use " + res.Version
+	a.RecordSecurityEvent(storage.SecurityUpdate, "Installed version "+res.Version)
+}
 
-	var filtered []pkcs12store.Identity
-	for _, sid := range all {
-		fp := fmt.Sprintf("%x", sid.Fingerprint256)
-		if !seen[fp] {
-			filtered = append(filtered, sid)
-			seen[fp] = true
+// StartSelfCheck verifies the running executable against its signed release
+// manifest in the background.
+func (a *App) StartSelfCheck() {
+	go func() {
+		res := selfcheck.Check(a.BuildInfo.Version)
+		if res.Warning() {
+			log.Printf("WARNING: binary integrity check: %s: %s", res.Status, res.Detail)
+		} else {
+			log.Printf("DEBUG: binary integrity check: %s: %s", res.Status, res.Detail)
+		}
+		a.mu.Lock()
+		a.integrity = res
+		a.mu.Unlock()
+		if a.Invalidate != nil {
+			a.Invalidate()
+		}
+	}()
+}
+
+// IntegritySnapshot returns the result of the binary self-check. Status is
+// empty while the check is still running.
+func (a *App) IntegritySnapshot() selfcheck.Result {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.integrity
+}
+
+// Diagnostic is a line of the environment report on the About screen.
+type Diagnostic struct {
+	Label string
+	Value string
+	// Hint tells the user how to solve a problem, such as the command that
+	// grants the sandbox access to a folder.
+	Hint    string
+	Problem bool
+}
+
+// Diagnostics reports what VocSign can reach from where it runs: the
+// sandbox it is confined in, the folders certificates are searched in and
+// the library browser certificate databases are read with, and the display
+// session and renderer the window runs with. It reads the file system, so
+// it is not meant to be called on every frame.
+func (a *App) Diagnostics() []Diagnostic {
+	sb := sandbox.Detect()
+	out := []Diagnostic{{Label: "SANDBOX", Value: sb.String()}}
+	if sb.Kind == sandbox.Flatpak {
+		perms := strings.Join(sb.Filesystems, ", ")
+		if perms == "" {
+			perms = "no folders granted"
 		}
+		out = append(out, Diagnostic{Label: "FOLDER PERMISSIONS", Value: perms})
 	}
 
-	a.SystemIdentities = filtered
-	log.Printf("DEBUG: ScanSystemStores finished in %s, total=%d, new=%d", time.Since(start), len(all), len(filtered))
+	home := Diagnostic{Label: "HOME FOLDER", Value: sb.Home + " (readable)"}
+	if !sandbox.Readable(sb.Home) {
+		home.Value = sb.Home + " (not readable)"
+		home.Problem = true
+		if hint := sb.GrantHint(sb.Home); hint != "" {
+			home.Hint = "Certificate files there are not found by the scan. To allow it, run: " + hint
+		}
+	}
+	out = append(out, home)
+
+	if runtime.GOOS == "linux" {
+		nssdb := filepath.Join(sb.Home, ".pki", "nssdb")
+		d := Diagnostic{Label: "SYSTEM NSS DATABASE", Value: nssdb}
+		if sandbox.Readable(nssdb) {
+			d.Value += " (readable)"
+		} else {
+			d.Value += " (not found)"
+			if hint := sb.GrantHint(nssdb); hint != "" {
+				d.Hint = "If it exists, allow reading it with: " + hint
+			}
+		}
+		out = append(out, d)
+	}
+
+	lib := Diagnostic{Label: "NSS LIBRARY", Value: systemstore.NSSLibrary()}
+	if lib.Value == "" {
+		lib.Value = "not found, browser certificate databases cannot be read"
+		lib.Problem = runtime.GOOS == "linux"
+	}
+	out = append(out, lib)
+	out = append(out, a.displayDiagnostics()...)
+
+	mode := platform.CurrentRenderMode()
+	render := Diagnostic{Label: "RENDERER", Value: "GPU"}
+	if mode.Renderer == platform.Software {
+		render.Value = "software (Mesa llvmpipe)"
+		if mode.GPUError != "" {
+			render.Value += ", the GPU failed: " + mode.GPUError
+			render.Problem = true
+			render.Hint = "Drawing is slower without the GPU. Updating the graphics driver may let VocSign use it again."
+		}
+	}
+	return append(out, render)
 }
 
-func safeList(fn func(context.Context) ([]pkcs12store.Identity, error), ctx context.Context, label string) (ids []pkcs12store.Identity, err error) {
-	defer func() {
-		if r := recover(); r != nil {
-			log.Printf("ERROR: panic while listing %s: %v\n%s", label, r, string(debug.Stack()))
-			ids = nil
-			err = fmt.Errorf("panic while listing %s", label)
+// reportJank logs a slow frame when asked to, and reports the first slow
+// and the first frozen frame of a session as telemetry.
+func (a *App) reportJank(j perf.Jank) {
+	if a.jankLog || a.Perf.Detail() {
+		msg := fmt.Sprintf("DEBUG: jank: %s frame took %v", j.Screen, j.Duration.Round(time.Millisecond))
+		if j.Hot != "" {
+			msg += fmt.Sprintf(", most in %s (%v)", j.Hot, j.HotDuration.Round(time.Millisecond))
 		}
-	}()
-	return fn(ctx)
+		log.Print(msg)
+	}
+	var outcome string
+	switch {
+	case j.Duration >= time.Second:
+		outcome = telemetry.OutcomeJankFrozen
+	case j.Duration >= 250*time.Millisecond:
+		outcome = telemetry.OutcomeJankSlow
+	default:
+		return
+	}
+	if !a.jankReported[outcome] {
+		a.jankReported[outcome] = true
+		a.Telemetry.Record(telemetry.EventJank, outcome, "")
+	}
 }
 
-func NewApp(build BuildInfo) (*App, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get home dir: %w", err)
+// MemoryDiagnostic reports the memory VocSign uses against its budget and,
+// where it is known, the memory the machine has left. Unlike Diagnostics it
+// is cheap enough to refresh while the About screen is open.
+func (a *App) MemoryDiagnostic() Diagnostic {
+	m := platform.ReadMemory()
+	d := Diagnostic{
+		Label: "MEMORY",
+		Value: fmt.Sprintf("%d MiB in use, %d MiB from the system, budget %d MiB", m.HeapInUse>>20, m.FromSystem>>20, m.Budget>>20),
 	}
-	appDataDir := filepath.Join(home, ".vocsign")
-	if err := os.MkdirAll(appDataDir, 0700); err != nil {
-		return nil, fmt.Errorf("failed to create app data dir: %w", err)
+	if m.SystemTotal > 0 {
+		d.Value += fmt.Sprintf("; machine %.1f GiB, %d MiB available", float64(m.SystemTotal)/(1<<30), m.SystemAvailable>>20)
+	}
+	if m.Low() {
+		d.Problem = true
+		d.Hint = "The machine is short of memory. Close other programs before signing."
+	}
+	return d
+}
+
+// displayDiagnostics reports the display session and how VocSign adapts to
+// it. Outside Linux there is nothing to report.
+func (a *App) displayDiagnostics() []Diagnostic {
+	p := a.Platform
+	if p.Session == "" {
+		return nil
 	}
+	session := string(p.Session)
+	if p.Desktop != "" {
+		session += " (" + p.Desktop + ")"
+	}
+	out := []Diagnostic{{Label: "DISPLAY SESSION", Value: session}}
 
-	logger, err := storage.NewAuditLogger(appDataDir)
+	backend := Diagnostic{Label: "DISPLAY BACKEND", Value: string(p.Backend)}
+	if p.BackendReason != "" {
+		backend.Value += ", " + p.BackendReason
+	}
+	if p.Backend == platform.Wayland && p.Fractional() {
+		backend.Problem = true
+		backend.Hint = "Text may look blurry at a fractional scale."
+		if p.XWayland {
+			backend.Hint += " Start VocSign with " + platform.BackendEnv + "=x11 for sharper text."
+		}
+	}
+	out = append(out, backend)
+
+	if p.Scale > 0 {
+		out = append(out, Diagnostic{Label: "MONITOR SCALE", Value: fmt.Sprintf("%g%%", p.Scale*100)})
+	}
+
+	clip := Diagnostic{Label: "CLIPBOARD", Value: "system"}
+	switch {
+	case p.ClipboardReader != nil:
+		clip.Value = p.ClipboardReader[0]
+	case p.Backend == platform.Wayland:
+		clip.Value = "Gio, not read on focus"
+		clip.Problem = true
+		clip.Hint = "Install wl-clipboard (wl-paste) for reliable paste and clipboard suggestions."
+	}
+	out = append(out, clip)
+
+	decorations := "drawn by the desktop"
+	if !p.ServerDecorations {
+		decorations = "drawn by VocSign"
+	}
+	return append(out, Diagnostic{Label: "WINDOW DECORATIONS", Value: decorations})
+}
+
+// DiffWithPrevious compares req against the copy stored from an earlier
+// session. It returns nil when the request was never seen or is unchanged.
+func (a *App) DiffWithPrevious(req *model.SignRequest) []model.FieldChange {
+	prev, err := a.Requests.Load(req.RequestID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create audit logger: %w", err)
+		log.Printf("WARNING: failed to load stored request %s: %v", req.RequestID, err)
+		return nil
 	}
+	if prev == nil {
+		return nil
+	}
+	var prevReq model.SignRequest
+	if err := json.Unmarshal(prev.Raw, &prevReq); err != nil {
+		log.Printf("WARNING: failed to decode stored request %s: %v", req.RequestID, err)
+		return nil
+	}
+	diff, err := model.DiffRequests(&prevReq, req)
+	if err != nil {
+		log.Printf("WARNING: failed to diff request %s: %v", req.RequestID, err)
+		return nil
+	}
+	return diff
+}
 
-	storeDir := filepath.Join(appDataDir, "store")
-	vaultPW := []byte("default-vault-password")
-	store, err := pkcs12store.NewFileStore(storeDir, vaultPW)
+// RememberCurrentRequest stores the current raw request as the baseline for
+// future comparisons.
+func (a *App) RememberCurrentRequest() {
+	if a.CurrentReq == nil {
+		return
+	}
+	if err := a.Requests.Save(a.CurrentReq.RequestID, a.RequestURL, a.RawReq); err != nil {
+		log.Printf("WARNING: failed to store request %s: %v", a.CurrentReq.RequestID, err)
+	}
+}
+
+// KeepRawRequest stores the current request byte for byte under its SHA-256
+// and returns the hash, for the audit entries of its signatures. It returns
+// "" if the request could not be stored.
+func (a *App) KeepRawRequest() string {
+	if len(a.RawReq) == 0 {
+		return ""
+	}
+	sum, err := a.Requests.PutRaw(a.RawReq)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create store: %w", err)
+		log.Printf("WARNING: failed to store raw request: %v", err)
+		return ""
 	}
+	return sum
+}
 
-	app := &App{
-		CurrentScreen: ScreenOpenRequest,
-		AuditLogger:   logger,
-		Store:         store,
-		BuildInfo: BuildInfo{
-			Version:   nonEmpty(build.Version, "dev"),
-			Commit:    nonEmpty(build.Commit, "unknown"),
-			BuildDate: nonEmpty(build.BuildDate, "unknown"),
-		},
-		ReleasePageURL: appnet.LatestReleasePageURL,
+// RawRequest returns the request bytes an audit entry recorded as
+// RawRequestSHA256, checked against the hash, or nil if they are not on
+// file.
+func (a *App) RawRequest(sum string) ([]byte, error) {
+	if sum == "" {
+		return nil, nil
 	}
+	return a.Requests.Raw(sum)
+}
 
-	// Initial load
-	ids, _ := store.List(context.Background())
-	app.SetIdentities(ids)
+// purgeRawRequests removes the raw requests no audit entry or comparison
+// baseline refers to any more. An unreadable audit log keeps them all.
+func (a *App) purgeRawRequests() {
+	entries, err := a.AuditLogger.ReadAll()
+	if err != nil {
+		log.Printf("WARNING: not purging raw requests: failed to read audit log: %v", err)
+		return
+	}
+	keep := make(map[string]bool)
+	for _, e := range entries {
+		if e.RawRequestSHA256 != "" {
+			keep[e.RawRequestSHA256] = true
+		}
+	}
+	if n, err := a.Requests.PurgeRaw(keep); err != nil {
+		log.Printf("WARNING: failed to purge raw requests: %v", err)
+	} else if n > 0 {
+		log.Printf("DEBUG: purged %d raw requests no longer referenced", n)
+	}
+}
+
+// ScheduleRescanReminder asks the wizard to remind the user to scan for
+// certificates again after d, once the one they requested has been issued.
+func (a *App) ScheduleRescanReminder(d time.Duration) error {
+	at := time.Now().Add(d).UTC().Format(time.RFC3339)
+	return a.Settings.Update(func(st *settings.Settings) { st.RescanReminderAt = at })
+}
+
+// RescanReminder returns the scheduled reminder time and whether it is due.
+func (a *App) RescanReminder() (at time.Time, due bool) {
+	raw := a.Settings.Get().RescanReminderAt
+	if raw == "" {
+		return time.Time{}, false
+	}
+	at, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return at, !time.Now().Before(at)
+}
+
+func (a *App) ClearRescanReminder() {
+	if a.Settings.Get().RescanReminderAt == "" {
+		return
+	}
+	if err := a.Settings.Update(func(st *settings.Settings) { st.RescanReminderAt = "" }); err != nil {
+		log.Printf("WARNING: failed to clear rescan reminder: %v", err)
+	}
+}
+
+// LinkHostKnown reports whether the user already agreed to open request
+// links to host.
+func (a *App) LinkHostKnown(host string) bool {
+	return slices.Contains(a.Settings.Get().LinkHosts, host)
+}
+
+// RememberLinkHost records that the user agreed to open request links to
+// host.
+func (a *App) RememberLinkHost(host string) error {
+	if a.LinkHostKnown(host) {
+		return nil
+	}
+	err := a.Settings.Update(func(st *settings.Settings) {
+		st.LinkHosts = append(slices.Clone(st.LinkHosts), host)
+	})
+	if err == nil {
+		a.RecordSecurityEvent(storage.SecurityTrust, "Allowed request links to "+host)
+	}
+	return err
+}
+
+// RequestClipboardCheck asks the Open Request screen to look for a signing
+// URL in the clipboard, if the user enabled it. Without a clipboard command
+// on Wayland it is skipped: an empty clipboard would leave Gio's reader
+// unable to paste for the rest of the session.
+func (a *App) RequestClipboardCheck() {
+	if a.Settings.Get().ClipboardDetect && a.Platform.ClipboardOnFocus() {
+		a.clipboardCheck = true
+	}
+}
+
+// ReloadRequest leaves the current request and fetches it again, so a
+// changed proposal is reviewed from scratch.
+func (a *App) ReloadRequest() {
+	if a.RequestURL == "" {
+		return
+	}
+	a.reloadURL = a.RequestURL
+	a.SignStatus = ""
+	a.CurrentReq = nil
+	a.CurrentScreen = ScreenOpenRequest
+}
+
+// TakeReload returns the request URL to fetch again, if any, and clears it.
+func (a *App) TakeReload() string {
+	url := a.reloadURL
+	a.reloadURL = ""
+	return url
+}
+
+// SetLaunch records what VocSign was started to open. A certificate file is
+// ignored when the administrator disabled file import.
+func (a *App) SetLaunch(l launch.Args) {
+	a.launchURL = l.RequestURL
+	if l.CertificateFile == "" {
+		return
+	}
+	if a.Managed.DisableFileImport {
+		log.Printf("WARNING: not importing %s: file import is disabled by policy", l.CertificateFile)
+		return
+	}
+	a.launchCertificate = l.CertificateFile
+	a.CurrentScreen = ScreenWizard
+}
+
+// TakeLaunchURL returns the request URL of the link VocSign was started
+// with, if any, and clears it.
+func (a *App) TakeLaunchURL() string {
+	url := a.launchURL
+	a.launchURL = ""
+	return url
+}
+
+// TakeLaunchCertificate returns the certificate file VocSign was started
+// with, if any, and clears it.
+func (a *App) TakeLaunchCertificate() string {
+	path := a.launchCertificate
+	a.launchCertificate = ""
+	return path
+}
+
+// TakeClipboardCheck reports whether a clipboard check is pending and clears it.
+func (a *App) TakeClipboardCheck() bool {
+	pending := a.clipboardCheck
+	a.clipboardCheck = false
+	return pending
+}
+
+// SaveSession records the request being signed and the selected
+// certificate so they can be offered for resuming after a restart. Typed
+// signer data, such as a birth date, is not saved.
+func (a *App) SaveSession(certID string) {
+	req := a.CurrentReq
+	if req == nil || a.RequestURL == "" {
+		return
+	}
+	err := a.Sessions.Save(storage.Session{
+		Screen:     int(a.CurrentScreen),
+		RequestURL: a.RequestURL,
+		RequestID:  req.RequestID,
+		Title:      req.Proposal.Title,
+		CertID:     certID,
+	})
+	if err != nil {
+		log.Printf("WARNING: failed to save session: %v", err)
+	}
+}
+
+// WindowState returns the main window state to restore at startup, fitted
+// to the monitor's work area where it can be read.
+func (a *App) WindowState() storage.WindowState {
+	ws, err := a.Window.Load()
+	if err != nil {
+		log.Printf("WARNING: failed to load window state: %v", err)
+	}
+	if w, h, ok := platform.WorkArea(); ok {
+		ws = ws.Fit(w, h)
+	}
+	return ws
+}
+
+// SaveWindowState remembers the main window state for the next launch.
+func (a *App) SaveWindowState(ws storage.WindowState) {
+	if err := a.Window.Save(ws); err != nil {
+		log.Printf("WARNING: failed to save window state: %v", err)
+	}
+}
+
+// RememberedSignerData returns the signer data remembered for cert, if
+// remembering is enabled in Settings.
+func (a *App) RememberedSignerData(cert *x509.Certificate) (pkcs12store.SignerData, bool) {
+	if !a.Settings.Get().RememberSignerData || cert == nil || a.Store.Locked() {
+		return pkcs12store.SignerData{}, false
+	}
+	d, ok, err := a.Store.SignerData(pkcs12store.Fingerprint(cert))
+	if err != nil {
+		log.Printf("WARNING: failed to read remembered signer data: %v", err)
+		return pkcs12store.SignerData{}, false
+	}
+	return d, ok
+}
+
+// RememberSignerData stores d for cert if remembering is enabled in
+// Settings. A zero d forgets what was stored for cert.
+func (a *App) RememberSignerData(cert *x509.Certificate, d pkcs12store.SignerData) {
+	if !a.Settings.Get().RememberSignerData || cert == nil {
+		return
+	}
+	if err := a.Store.SaveSignerData(pkcs12store.Fingerprint(cert), d); err != nil {
+		log.Printf("WARNING: failed to remember signer data: %v", err)
+	}
+}
+
+// Locale is the locale chosen in Settings for writing dates and numbers.
+func (a *App) Locale() locale.Locale {
+	return locale.Get(a.Settings.Get().Locale)
+}
+
+// ClearSignerData forgets the remembered signer data of every certificate.
+func (a *App) ClearSignerData() error {
+	return a.Store.ClearSignerData()
+}
+
+// CertWarningAcknowledged reports whether the user already acknowledged the
+// warning of kind about the certificate with the given fingerprint, with the
+// same digest. A store failure counts as not acknowledged, so the warning is
+// shown again.
+func (a *App) CertWarningAcknowledged(fingerprint, kind, digest string) bool {
+	_, ok, err := a.CertAcks.Acknowledged(fingerprint, kind, digest)
+	if err != nil {
+		log.Printf("WARNING: failed to read certificate acknowledgements: %v", err)
+	}
+	return ok
+}
+
+// AcknowledgeCertWarning remembers that the user acknowledged the warning of
+// kind about the certificate, until the digest changes.
+func (a *App) AcknowledgeCertWarning(fingerprint, kind, digest string) {
+	err := a.CertAcks.Record(storage.CertAck{CertFingerprint: fingerprint, Kind: kind, Digest: digest})
+	if err != nil {
+		log.Printf("WARNING: failed to save certificate acknowledgement: %v", err)
+		return
+	}
+	a.RecordSecurityEvent(storage.SecurityTrust, fmt.Sprintf("Acknowledged the %s warning of certificate %s", kind, fingerprint))
+}
+
+// ClearSession forgets the saved session once signing finished or the user
+// left the request.
+func (a *App) ClearSession() {
+	a.ResumeSession = nil
+	if err := a.Sessions.Clear(); err != nil {
+		log.Printf("WARNING: failed to clear session: %v", err)
+	}
+}
+
+// BeginResume marks the offered session as being resumed and returns the
+// request URL to fetch.
+func (a *App) BeginResume() string {
+	sess := a.ResumeSession
+	if sess == nil {
+		return ""
+	}
+	a.ResumeSession = nil
+	a.sessionRestore = sess
+	return sess.RequestURL
+}
+
+// TakeSessionRestore returns the session being resumed if it belongs to
+// requestID, and forgets it so it is applied only once.
+func (a *App) TakeSessionRestore(requestID string) *storage.Session {
+	sess := a.sessionRestore
+	if sess == nil || sess.RequestID != requestID {
+		return nil
+	}
+	a.sessionRestore = nil
+	return sess
+}
+
+// RecordSigning journals that a signing is about to reach e.Stage, so a
+// crash during the step is noticed at the next start.
+func (a *App) RecordSigning(e storage.JournalEntry) {
+	if err := a.Journal.Record(e); err != nil {
+		log.Printf("ERROR: failed to write signing journal: %v", err)
+	}
+}
+
+// FinishSigning removes the journal entry of a signing that ended, whatever
+// its outcome, and forgets an earlier interrupted attempt at it.
+func (a *App) FinishSigning(e storage.JournalEntry) {
+	if err := a.Journal.Remove(e); err != nil {
+		log.Printf("ERROR: failed to update signing journal: %v", err)
+	}
+	a.forgetInterrupted(e)
+}
+
+// InterruptedSignings returns the signings cut short by a crash, oldest
+// first.
+func (a *App) InterruptedSignings() []storage.JournalEntry {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return append([]storage.JournalEntry(nil), a.interrupted...)
+}
+
+// InterruptedSubmission returns the interrupted signing of the same request,
+// certificate and signer as e if it may have reached the collector.
+func (a *App) InterruptedSubmission(e storage.JournalEntry) (storage.JournalEntry, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for _, o := range a.interrupted {
+		if o.Same(e) && o.Stage == storage.StageSubmitting {
+			return o, true
+		}
+	}
+	return storage.JournalEntry{}, false
+}
+
+// DismissInterrupted forgets an interrupted signing once the user has seen
+// it.
+func (a *App) DismissInterrupted(e storage.JournalEntry) {
+	if err := a.Journal.Remove(e); err != nil {
+		log.Printf("ERROR: failed to update signing journal: %v", err)
+	}
+	a.forgetInterrupted(e)
+}
+
+func (a *App) forgetInterrupted(e storage.JournalEntry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	kept := a.interrupted[:0]
+	for _, o := range a.interrupted {
+		if !o.Same(e) {
+			kept = append(kept, o)
+		}
+	}
+	a.interrupted = kept
+}
+
+// PolicyDocument returns the local path of the request's signature policy
+// document, downloading and verifying it against Policy.Hash the first time.
+func (a *App) PolicyDocument(ctx context.Context, p *model.SignPolicy) (string, error) {
+	sum, err := p.Digest()
+	if err != nil {
+		return "", err
+	}
+	if path, ok := a.Policies.Lookup(sum, p.URI); ok {
+		return path, nil
+	}
+	data, err := appnet.FetchPolicyDocument(ctx, p)
+	if err != nil {
+		return "", err
+	}
+	return a.Policies.Store(data, p.URI)
+}
+
+// CheckTransparencyLog checks req against its organizer's transparency log.
+// For a pinned organizer the request must name the log recorded at pinning,
+// or the first one seen since, and that log must extend the checkpoint last
+// verified, which is then moved forward. A pinned organizer's request without
+// a log returns a warning rather than an error, since logs are optional.
+func (a *App) CheckTransparencyLog(ctx context.Context, req *model.SignRequest) (warning string, err error) {
+	pinned, ok := a.Organizers.Pinned(req.Organizer.JWKSetURL)
+	if !ok {
+		_, _, err := appnet.CheckTransparencyLog(ctx, req, nil)
+		return "", err
+	}
+	if req.TransparencyLog == nil {
+		if pinned.TransparencyLogURL != "" {
+			return "", errcode.Errorf(errcode.TransparencyLogChanged, "request of pinned organizer %s names no transparency log", pinned.Name)
+		}
+		return "Transparency log: this request of a pinned organizer is not published in a transparency log", nil
+	}
+	if pinned.TransparencyLogURL != "" && pinned.TransparencyLogURL != req.TransparencyLog.URL {
+		return "", errcode.Errorf(errcode.TransparencyLogChanged, "request names transparency log %s, pinned organizer %s uses %s", req.TransparencyLog.URL, pinned.Name, pinned.TransparencyLogURL)
+	}
+	_, head, err := appnet.CheckTransparencyLog(ctx, req, pinned.TransparencyLogHead)
+	if err != nil {
+		return "", err
+	}
+	if err := a.Organizers.SetTransparencyLog(pinned.JWKSetURL, req.TransparencyLog.URL, head); err != nil {
+		log.Printf("WARNING: failed to record transparency log of %s: %v", pinned.Name, err)
+	}
+	return "", nil
+}
+
+func (a *App) PinnedCampaignsSnapshot() ([]PinnedCampaign, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	out := make([]PinnedCampaign, len(a.PinnedCampaigns))
+	copy(out, a.PinnedCampaigns)
+	return out, a.campaignsLoading
+}
+
+// RefreshPinnedCampaigns fetches the campaign index of every pinned organizer
+// in the background. Each listed request is fetched, validated and JWS
+// verified; only requests signed under the pinned organizer's JWKS are kept.
+func (a *App) RefreshPinnedCampaigns() {
+	a.mu.Lock()
+	if a.campaignsLoading {
+		a.mu.Unlock()
+		return
+	}
+	a.campaignsLoading = true
+	a.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+
+		var found []PinnedCampaign
+		pinned, err := a.Organizers.List()
+		if err != nil {
+			log.Printf("WARNING: failed to load pinned organizers: %v", err)
+		}
+		for _, o := range pinned {
+			if !a.Managed.AllowsOrganizer(o.JWKSetURL) {
+				log.Printf("WARNING: pinned organizer %q is not allowed by the policy", o.Name)
+				continue
+			}
+			urls, err := appnet.FetchCampaignIndex(ctx, o.CampaignIndexURL)
+			if err != nil {
+				log.Printf("WARNING: campaign index for %q failed: %v", o.Name, err)
+				continue
+			}
+			for _, u := range urls {
+				req, _, err := appnet.Fetch(ctx, u)
+				if err != nil {
+					log.Printf("WARNING: campaign request %s failed: %v", u, err)
+					continue
+				}
+				if req.Organizer.JWKSetURL != o.JWKSetURL {
+					log.Printf("WARNING: campaign request %s is not signed by pinned organizer %q", u, o.Name)
+					continue
+				}
+				if err := req.Validate(); err != nil {
+					log.Printf("DEBUG: skipping campaign request %s: %v", u, err)
+					continue
+				}
+				if _, err := jwsverify.Verify(req); err != nil {
+					log.Printf("WARNING: campaign request %s failed verification: %v", u, err)
+					continue
+				}
+				found = append(found, PinnedCampaign{
+					Organizer: o.Name,
+					RequestID: req.RequestID,
+					Title:     req.Proposal.Title,
+					Promoter:  req.Proposal.Promoter,
+					ExpiresAt: req.ExpiresAt,
+					URL:       u,
+				})
+			}
+		}
+
+		a.mu.Lock()
+		a.PinnedCampaigns = found
+		a.campaignsLoading = false
+		a.mu.Unlock()
+		log.Printf("DEBUG: pinned campaigns refreshed: %d organizers, %d open requests", len(pinned), len(found))
+		if a.Invalidate != nil {
+			a.Invalidate()
+		}
+	}()
+}
+
+func (a *App) ScanSystemStores(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+	start := time.Now()
+	log.Printf("DEBUG: ScanSystemStores started")
+	var all []pkcs12store.Identity
+
+	// 1. OS-Native Store
+	osStore := &systemstore.OSStore{Label: "System"}
+	log.Printf("DEBUG: ScanSystemStores: scanning OS store %q", osStore.Label)
+	ids, err := safeList(osStore.List, ctx, "OS store")
+	if err == nil {
+		all = append(all, ids...)
+		log.Printf("DEBUG: ScanSystemStores: OS store returned %d identities", len(ids))
+	} else {
+		log.Printf("DEBUG: ScanSystemStores: OS store error: %v", err)
+	}
+	a.Telemetry.Record(telemetry.EventScan, scanOutcome(ctx, len(ids), err), telemetry.StoreOS)
+
+	// 2. NSS Stores
+	nssStores := systemstore.DiscoverNSSStores(ctx)
+	log.Printf("DEBUG: ScanSystemStores: discovered %d NSS stores", len(nssStores))
+	var nssMu sync.Mutex
+	var nssFound int
+	var nssErr error
+	sem := make(chan struct{}, 4)
+	var wg sync.WaitGroup
+	for _, s := range nssStores {
+		s := s
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			log.Printf("DEBUG: ScanSystemStores: scanning NSS store label=%q profile=%q", s.Label, s.ProfileDir)
+			ids, err := safeList(s.List, ctx, "NSS store "+s.Label)
+			if err == nil {
+				nssMu.Lock()
+				all = append(all, ids...)
+				nssFound += len(ids)
+				nssMu.Unlock()
+				log.Printf("DEBUG: ScanSystemStores: NSS store %q returned %d identities", s.Label, len(ids))
+			} else {
+				nssMu.Lock()
+				nssErr = err
+				nssMu.Unlock()
+				log.Printf("DEBUG: ScanSystemStores: NSS store %q error: %v", s.Label, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if len(nssStores) > 0 {
+		if nssFound > 0 {
+			nssErr = nil
+		}
+		a.Telemetry.Record(telemetry.EventScan, scanOutcome(ctx, nssFound, nssErr), telemetry.StoreNSS)
+	}
+
+	// 3. PKCS#12 files (passwordless only)
+	var lockedP12 []string
+	var p12Found int
+	p12Paths := systemstore.FindPKCS12Candidates(ctx, 5, 200)
+	log.Printf("DEBUG: ScanSystemStores: discovered %d candidate PKCS#12 files", len(p12Paths))
+	for _, p := range p12Paths {
+		id, err := systemstore.ParsePKCS12Metadata(p, "")
+		if err != nil {
+			if errors.Is(err, systemstore.ErrPKCS12PasswordRequired) {
+				log.Printf("DEBUG: PKCS#12 file requires password, skipping auto-import: %s", p)
+				lockedP12 = append(lockedP12, p)
+			} else {
+				log.Printf("DEBUG: PKCS#12 parse skipped for %s: %v", p, err)
+			}
+			continue
+		}
+		all = append(all, id)
+		p12Found++
+	}
+	if len(p12Paths) > 0 {
+		a.Telemetry.Record(telemetry.EventScan, scanOutcome(ctx, p12Found, nil), telemetry.StorePKCS12)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.LockedP12 = lockedP12
+
+	// Deduplicate based on Fingerprint
+	seen := make(map[string]bool)
+	for _, id := range a.Identities {
+		seen[fmt.Sprintf("%x", id.Fingerprint256)] = true
+	}
+
+	var filtered []pkcs12store.Identity
+	for _, sid := range all {
+		fp := fmt.Sprintf("%x", sid.Fingerprint256)
+		if !seen[fp] {
+			filtered = append(filtered, sid)
+			seen[fp] = true
+		}
+	}
+
+	a.SystemIdentities = filtered
+	log.Printf("DEBUG: ScanSystemStores finished in %s, total=%d, new=%d", time.Since(start), len(all), len(filtered))
+}
+
+// ApplyPINCacheTTL updates the token PIN cache from the current settings.
+func (a *App) ApplyPINCacheTTL() {
+	pkcs12store.DefaultPINCache.SetTTL(time.Duration(a.Settings.Get().PINCacheMinutes) * time.Minute)
+}
+
+// hardwareSealer seals the vault key with the TPM or Secure Enclave.
+type hardwareSealer struct{ name string }
+
+func (h hardwareSealer) Name() string                       { return h.name }
+func (hardwareSealer) Seal(key []byte) ([]byte, error)      { return platform.SealKey(key) }
+func (hardwareSealer) Unseal(sealed []byte) ([]byte, error) { return platform.UnsealKey(sealed) }
+
+// vaultSealer returns the hardware the vault key can be sealed by, nil if
+// there is none.
+func vaultSealer() pkcs12store.KeySealer {
+	if name := platform.SealingHardware(); name != "" {
+		return hardwareSealer{name: name}
+	}
+	return nil
+}
+
+// openVault unseals the vault key of store and, unless the user wants a
+// portable wallet, binds an unbound one to the hardware where available.
+// Binding runs in the background, since sealing can take seconds; the
+// store stays usable with the portable key meanwhile.
+func (a *App) openVault(store *pkcs12store.FileStore) {
+	sealer := vaultSealer()
+	st := store.OpenVault(sealer)
+	switch {
+	case st.Err != nil:
+		log.Printf("WARNING: vault key cannot be unsealed: %v", st.Err)
+	case st.Hardware != "":
+		log.Printf("DEBUG: vault key sealed by the %s", st.Hardware)
+		// Backups taken by an older version may still hold keys under
+		// the portable key.
+		a.purgePortableBackups()
+	case sealer != nil && !a.Settings.Get().PortableVault:
+		go func() {
+			if err := a.bindVault(sealer); err != nil {
+				log.Printf("WARNING: failed to bind the vault key to the %s: %v", sealer.Name(), err)
+				return
+			}
+			log.Printf("DEBUG: vault key bound to the %s", sealer.Name())
+			if a.Invalidate != nil {
+				a.Invalidate()
+			}
+		}()
+	}
+}
+
+// bindVault binds the vault key to sealer and removes the copies of the
+// stored keys, still under the portable key, from the data directory
+// backups.
+func (a *App) bindVault(sealer pkcs12store.KeySealer) error {
+	if err := a.Store.BindVault(sealer); err != nil {
+		return err
+	}
+	a.RecordSecurityEvent(storage.SecurityVault, "Vault key bound to "+sealer.Name())
+	a.purgePortableBackups()
+	return nil
+}
+
+// purgePortableBackups deletes the vault files from the data directory
+// backups. A failure is logged: the backups are not needed to run.
+func (a *App) purgePortableBackups() {
+	err := datadir.RemoveFromBackups(a.dataDir, func(rel string) bool {
+		dir, name := path.Split(rel)
+		return (dir == "store/" || dir == "store/trash/") && pkcs12store.IsVaultFile(name)
+	})
+	if err != nil {
+		log.Printf("WARNING: failed to remove keys from the data backups: %v", err)
+	}
+}
+
+// VaultHardware names the TPM or Secure Enclave the vault key can be sealed
+// by, "" if there is none.
+func (a *App) VaultHardware() string {
+	return platform.SealingHardware()
+}
+
+// SetPortableVault saves the portableVault setting and binds the vault key
+// to the hardware, or unbinds it so the wallet can be copied to another
+// computer. It blocks while every stored key is encrypted again.
+func (a *App) SetPortableVault(portable bool) error {
+	st := a.Store.VaultStatus()
+	switch {
+	case portable && st.Bound():
+		if err := a.Store.UnbindVault(); err != nil {
+			return err
+		}
+		a.RecordSecurityEvent(storage.SecurityVault, "Vault key unbound from "+st.Hardware)
+	case !portable && st.Hardware == "" && st.Err == nil:
+		sealer := vaultSealer()
+		if sealer == nil {
+			return platform.ErrNoSealingHardware
+		}
+		if err := a.bindVault(sealer); err != nil {
+			return err
+		}
+	}
+	return a.Settings.Update(func(st *settings.Settings) { st.PortableVault = portable })
+}
+
+// ForgetVaultBinding drops a vault key that cannot be unsealed here and,
+// unless the user wants a portable wallet, binds a new one. Certificates
+// stored with the old key stay unusable.
+func (a *App) ForgetVaultBinding() error {
+	if err := a.Store.ForgetVaultBinding(); err != nil {
+		return err
+	}
+	a.RecordSecurityEvent(storage.SecurityVault, "Forgot a vault key that could not be unsealed")
+	if sealer := vaultSealer(); sealer != nil && !a.Settings.Get().PortableVault {
+		return a.bindVault(sealer)
+	}
+	return nil
+}
+
+// RecordSecurityEvent appends an event to the security log. A failure is
+// logged and otherwise ignored, like other bookkeeping.
+func (a *App) RecordSecurityEvent(kind, detail string) {
+	if err := a.SecurityLog.Record(kind, detail); err != nil {
+		log.Printf("WARNING: failed to record security event: %v", err)
+	}
+}
+
+// unloggedSettings are settings whose changes are not security events, or
+// are recorded with more detail where they are made.
+var unloggedSettings = map[string]bool{
+	"rescanReminderAt": true,
+	"linkHosts":        true,
+	"portableVault":    true,
+}
+
+func (a *App) recordSettingsChange(old, next settings.Settings) {
+	var names []string
+	for _, name := range settings.ChangedFields(old, next) {
+		if !unloggedSettings[name] {
+			names = append(names, name)
+		}
+	}
+	if len(names) > 0 {
+		a.RecordSecurityEvent(storage.SecuritySettings, "Changed "+strings.Join(names, ", "))
+	}
+}
+
+// ApplyIPFSGateways updates the gateways used for ipfs:// URIs from the
+// current settings.
+func (a *App) ApplyIPFSGateways() {
+	appnet.SetIPFSGateways(a.Settings.Get().IPFSGateways)
+}
+
+// PendingPINRequest returns the PIN prompt the UI should show, if any.
+func (a *App) PendingPINRequest() *PINRequest {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.pinRequest
+}
+
+// PendingUnlockRequest returns the wallet PIN prompt the quick unlock
+// dialog should show, if any.
+func (a *App) PendingUnlockRequest() *PINRequest {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.unlockRequest
+}
+
+// autoLockInterval is how often the auto-lock looks for inactivity and a
+// locked session.
+const autoLockInterval = 10 * time.Second
+
+// StartAutoLock locks the wallet in the background after the idle time
+// chosen in Settings, or when the computer's session is locked.
+func (a *App) StartAutoLock() {
+	a.NoteActivity()
+	go func() {
+		for now := range time.Tick(autoLockInterval) {
+			a.checkAutoLock(now)
+		}
+	}()
+}
+
+func (a *App) checkAutoLock(now time.Time) {
+	if !a.WalletPIN.IsSet() || a.Store.Locked() || a.busy.Load() > 0 {
+		return
+	}
+	st := a.Settings.Get()
+	idle := now.Sub(time.Unix(0, a.lastActivity.Load()))
+	if st.AutoLockMinutes > 0 && idle >= time.Duration(st.AutoLockMinutes)*time.Minute {
+		a.LockWallet(fmt.Sprintf("idle for %s", idle.Round(time.Second)))
+		return
+	}
+	if !st.LockOnSessionLock {
+		return
+	}
+	locked, err := platform.SessionLocked()
+	switch {
+	case err != nil:
+		if !a.sessionLockFailed && !errors.Is(err, errors.ErrUnsupported) {
+			log.Printf("WARNING: cannot tell whether the session is locked: %v", err)
+		}
+		a.sessionLockFailed = true
+	case locked:
+		a.LockWallet("session locked")
+	}
+}
+
+// NoteActivity records that the user used the window, which restarts the
+// inactivity auto-lock.
+func (a *App) NoteActivity() {
+	a.lastActivity.Store(time.Now().UnixNano())
+}
+
+// BeginBusy keeps the wallet from locking itself, for being idle or for
+// the session locking, until the returned function is called. Signing and
+// agent batches hold it, since locking would fail them halfway. The idle
+// time counts from when they end.
+func (a *App) BeginBusy() (end func()) {
+	a.busy.Add(1)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			a.NoteActivity()
+			a.busy.Add(-1)
+		})
+	}
+}
+
+// WalletLocked reports whether the wallet is locked.
+func (a *App) WalletLocked() bool {
+	return a.Store.Locked()
+}
+
+// LockWallet drops the vault key, and every cached PIN and certificate
+// password, from memory until the wallet is unlocked with its PIN. Without
+// a wallet PIN it does nothing. reason is logged. The wallet PIN only
+// guards this session: restarting the app opens the wallet unlocked.
+func (a *App) LockWallet(reason string) {
+	if !a.WalletPIN.IsSet() || a.Store.Locked() {
+		return
+	}
+	a.Store.LockVault()
+	log.Printf("DEBUG: wallet locked: %s", reason)
+	a.RecordSecurityEvent(storage.SecurityWalletLocked, "Locked: "+reason)
+	if a.Invalidate != nil {
+		a.Invalidate()
+	}
+}
+
+// walletPINKey is the key of wrong wallet PINs in walletPINLimit.
+const walletPINKey = "wallet"
+
+// UnlockWallet asks for the wallet PIN until it is entered right and loads
+// the vault key again. It does nothing if the wallet is not locked, and
+// returns pkcs12store.ErrWalletLocked if the user cancels. It blocks until
+// the user answers. After presign.MaxAttempts wrong PINs in a row, PINs are
+// refused for a while, as dual-control codes are.
+func (a *App) UnlockWallet() error {
+	a.unlockMu.Lock()
+	defer a.unlockMu.Unlock()
+	message := "The wallet is locked. Enter its PIN to unlock it."
+	for a.Store.Locked() {
+		pin, err := a.prompt(&a.unlockRequest, message, "Wallet PIN")
+		if err != nil {
+			return pkcs12store.ErrWalletLocked
+		}
+		if wait := a.walletPINLimit.Wait(walletPINKey, time.Now()); wait > 0 {
+			clear(pin)
+			message = fmt.Sprintf("Too many wrong PINs. Try again in %s.", wait.Round(time.Second))
+			continue
+		}
+		ok, err := a.WalletPIN.Verify(pin)
+		clear(pin)
+		if err != nil {
+			return fmt.Errorf("failed to check the wallet PIN: %w", err)
+		}
+		if !ok {
+			a.walletPINLimit.Fail(walletPINKey, time.Now())
+			a.RecordSecurityEvent(storage.SecurityAuthFailed, "Wrong wallet PIN")
+			message = "Wrong PIN. Enter the wallet PIN to unlock it."
+			if wait := a.walletPINLimit.Wait(walletPINKey, time.Now()); wait > 0 {
+				log.Printf("WARNING: too many wrong wallet PINs, refusing PINs for %s", wait.Round(time.Second))
+				message = fmt.Sprintf("Too many wrong PINs. Try again in %s.", wait.Round(time.Second))
+			}
+			continue
+		}
+		a.walletPINLimit.Reset(walletPINKey)
+		if st := a.Store.UnlockVault(vaultSealer()); st.Err != nil {
+			log.Printf("WARNING: vault key cannot be unsealed: %v", st.Err)
+		}
+		a.NoteActivity()
+		log.Printf("DEBUG: wallet unlocked")
+		a.RecordSecurityEvent(storage.SecurityWalletUnlocked, "Unlocked with the wallet PIN")
+	}
+	return nil
+}
+
+// SetWalletPIN sets the PIN that unlocks the wallet after it locks itself.
+// A locked wallet is unlocked with the old PIN first.
+func (a *App) SetWalletPIN(pin []byte) error {
+	defer clear(pin)
+	if err := a.UnlockWallet(); err != nil {
+		return err
+	}
+	if err := a.WalletPIN.Set(pin); err != nil {
+		return err
+	}
+	a.RecordSecurityEvent(storage.SecuritySettings, "Wallet PIN set")
+	return nil
+}
+
+// ClearWalletPIN removes the wallet PIN, which turns the auto-lock off. A
+// locked wallet is unlocked first, so it cannot stay locked without a PIN.
+func (a *App) ClearWalletPIN() error {
+	if err := a.UnlockWallet(); err != nil {
+		return err
+	}
+	if err := a.WalletPIN.Clear(); err != nil {
+		return err
+	}
+	a.RecordSecurityEvent(storage.SecuritySettings, "Wallet PIN removed")
+	return nil
+}
+
+// promptPIN blocks the signing goroutine until the user enters the token PIN
+// or cancels.
+func (a *App) promptPIN(label string) ([]byte, error) {
+	pin, err := a.promptSecret("Enter the PIN of "+label+" to sign", "PIN")
+	if err != nil {
+		return nil, pkcs12store.ErrPINCanceled
+	}
+	return pin, nil
+}
+
+// promptCertPassword blocks the signing goroutine until the user enters the
+// password of a certificate file kept with deferred decryption, or cancels.
+func (a *App) promptCertPassword(name string) ([]byte, error) {
+	pass, err := a.promptSecret("Enter the password of the certificate file of "+name+" to sign", "Certificate password")
+	if err != nil {
+		return nil, pkcs12store.ErrPasswordCanceled
+	}
+	return pass, nil
+}
+
+// PromptCode asks the user for a confirmation code required by a pre-sign
+// hook. It blocks until the user answers.
+func (a *App) PromptCode(message string) ([]byte, error) {
+	return a.promptSecret(message, "Confirmation code")
+}
+
+// PreSignHooks returns the checks to run before every signature.
+func (a *App) PreSignHooks() []presign.Hook {
+	var hooks []presign.Hook
+	rules := a.DualControlRules()
+	if n := len(a.Settings.Get().DualControl) - len(rules); n > 0 {
+		log.Printf("WARNING: ignoring %d TOTP dual control rules not set by the administrator's policy", n)
+	}
+	if len(rules) > 0 {
+		hooks = append(hooks, &presign.DualControl{Rules: rules, Limit: &a.dualControlLimit})
+	}
+	return hooks
+}
+
+// DualControlRules returns the dual-control rules in force. TOTP rules keep
+// their secret on this computer, so unless the administrator's policy sets
+// them the signer could have written them, and they are ignored.
+func (a *App) DualControlRules() []presign.DualControlRule {
+	rules := a.Settings.Get().DualControl
+	if a.Managed.Locked("dualControl") {
+		return rules
+	}
+	return slices.DeleteFunc(slices.Clone(rules), func(r presign.DualControlRule) bool {
+		return r.Method == presign.MethodTOTP
+	})
+}
+
+var errPromptCanceled = errors.New("canceled by user")
+
+func (a *App) promptSecret(message, hint string) ([]byte, error) {
+	return a.prompt(&a.pinRequest, message, hint)
+}
+
+// prompt shows a PINRequest in slot, one of the prompts the UI watches, and
+// waits for the answer.
+func (a *App) prompt(slot **PINRequest, message, hint string) ([]byte, error) {
+	req := &PINRequest{Message: message, Hint: hint, reply: make(chan []byte, 1)}
+	a.mu.Lock()
+	*slot = req
+	a.mu.Unlock()
+	if a.Invalidate != nil {
+		a.Invalidate()
+	}
+
+	var pin []byte
+	select {
+	case pin = <-req.reply:
+	case <-time.After(2 * time.Minute):
+	}
+
+	a.mu.Lock()
+	if *slot == req {
+		*slot = nil
+	}
+	a.mu.Unlock()
+	if a.Invalidate != nil {
+		a.Invalidate()
+	}
+	if pin == nil {
+		return nil, errPromptCanceled
+	}
+	return pin, nil
+}
+
+// scanOutcome maps a store scan result to a coarse telemetry outcome.
+func scanOutcome(ctx context.Context, found int, err error) string {
+	switch {
+	case found > 0:
+		return telemetry.OutcomeSuccess
+	case ctx.Err() != nil:
+		return telemetry.OutcomeFailStoreTimeout
+	case err != nil:
+		return telemetry.OutcomeFailStoreError
+	default:
+		return telemetry.OutcomeEmpty
+	}
+}
+
+// ErrReferenceNotFound means no browser profile holds a token certificate
+// with the requested fingerprint.
+var ErrReferenceNotFound = errors.New("certificate not found in any browser profile")
+
+// FindPKCS11Reference searches the discovered NSS stores for a token
+// certificate with the given fingerprint and returns where it lives now.
+func (a *App) FindPKCS11Reference(ctx context.Context, fp [32]byte) (*pkcs12store.PKCS11Ref, error) {
+	found, err := findPKCS11References(ctx, map[[32]byte]bool{fp: true})
+	if err != nil {
+		return nil, err
+	}
+	ref, ok := found[fp]
+	if !ok {
+		return nil, ErrReferenceNotFound
+	}
+	return &ref, nil
+}
+
+// findPKCS11References scans the NSS stores once for all wanted
+// fingerprints and returns the location of those found.
+func findPKCS11References(ctx context.Context, want map[[32]byte]bool) (map[[32]byte]pkcs12store.PKCS11Ref, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+	found := make(map[[32]byte]pkcs12store.PKCS11Ref)
+	for _, st := range systemstore.DiscoverNSSStores(ctx) {
+		ids, err := safeList(st.List, ctx, "NSS store "+st.Label)
+		if err != nil {
+			log.Printf("DEBUG: findPKCS11References: NSS store %q error: %v", st.Label, err)
+			continue
+		}
+		for _, id := range ids {
+			p11, ok := id.Signer.(*pkcs12store.PKCS11Signer)
+			if !ok || !want[id.Fingerprint256] {
+				continue
+			}
+			if _, dup := found[id.Fingerprint256]; dup {
+				continue
+			}
+			found[id.Fingerprint256] = pkcs12store.PKCS11Ref{
+				LibPath:    p11.LibPath,
+				ProfileDir: p11.ProfileDir,
+				Slot:       p11.Slot,
+				CKAIDHex:   hex.EncodeToString(p11.ID),
+			}
+		}
+		if len(found) == len(want) {
+			break
+		}
+	}
+	if len(found) == 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return found, nil
+}
+
+// RelinkOffer proposes pointing a token identity whose browser profile moved
+// at the profile that holds the same certificate now.
+type RelinkOffer struct {
+	Identity pkcs12store.Identity
+	Ref      pkcs12store.PKCS11Ref
+}
+
+// StartRelinkCheck looks for stored token identities whose browser profile
+// no longer exists and offers to relink those found in another profile.
+func (a *App) StartRelinkCheck() {
+	go func() {
+		ctx := context.Background()
+		stale, err := a.Store.StaleReferences(ctx)
+		if err != nil {
+			log.Printf("WARNING: failed to check token references: %v", err)
+			return
+		}
+		if len(stale) == 0 {
+			return
+		}
+		want := make(map[[32]byte]bool, len(stale))
+		for _, id := range stale {
+			want[id.Fingerprint256] = true
+		}
+		found, err := findPKCS11References(ctx, want)
+		if err != nil {
+			log.Printf("WARNING: failed to search browser profiles for moved tokens: %v", err)
+			return
+		}
+		for _, id := range stale {
+			if ref, ok := found[id.Fingerprint256]; ok {
+				a.addRelinkOffer(RelinkOffer{Identity: id, Ref: ref})
+			} else {
+				log.Printf("DEBUG: token identity %s has a stale reference and was not found in any profile", id.ID)
+			}
+		}
+		if a.Invalidate != nil {
+			a.Invalidate()
+		}
+	}()
+}
+
+// auditAnchorInterval is how often the audit and security chain heads are
+// timestamped.
+const auditAnchorInterval = 24 * time.Hour
+
+// StartAuditAnchoring timestamps the audit and security chain heads with
+// the TSA set in the settings once a day. It checks every hour, so a
+// computer that was asleep or off catches up soon after it is back.
+func (a *App) StartAuditAnchoring() {
+	go func() {
+		for {
+			if err := a.AnchorAuditLog(time.Now()); err != nil {
+				log.Printf("WARNING: failed to anchor audit log: %v", err)
+			}
+			if err := a.AnchorSecurityLog(time.Now()); err != nil {
+				log.Printf("WARNING: failed to anchor security log: %v", err)
+			}
+			time.Sleep(time.Hour)
+		}
+	}()
+}
+
+// anchoredLog is a hash-chained log whose head can be timestamped.
+type anchoredLog interface {
+	Head() (string, int)
+	Anchors() ([]storage.AuditAnchor, error)
+	AddAnchor(storage.AuditAnchor) error
+}
+
+// AnchorAuditLog timestamps the audit chain head unless anchoring is off,
+// the head is already anchored, or the last anchor is less than a day old
+// at now.
+func (a *App) AnchorAuditLog(now time.Time) error {
+	return a.anchorLog("audit", a.AuditLogger, now)
+}
+
+// AnchorSecurityLog timestamps the security chain head like AnchorAuditLog,
+// so removing or rewriting anchored events shows.
+func (a *App) AnchorSecurityLog(now time.Time) error {
+	return a.anchorLog("security", a.SecurityLog, now)
+}
+
+func (a *App) anchorLog(name string, l anchoredLog, now time.Time) error {
+	tsaURL := a.Settings.Get().AuditAnchorTSAURL
+	if tsaURL == "" {
+		return nil
+	}
+	head, entries := l.Head()
+	if head == "" {
+		return nil
+	}
+	anchors, err := l.Anchors()
+	if err != nil {
+		return err
+	}
+	if len(anchors) > 0 {
+		last := anchors[len(anchors)-1]
+		at, err := time.Parse(time.RFC3339, last.Time)
+		if last.Head == head || (err == nil && now.Sub(at) < auditAnchorInterval) {
+			return nil
+		}
+	}
+
+	digest, err := hex.DecodeString(head)
+	if err != nil {
+		return fmt.Errorf("invalid %s chain head: %w", name, err)
+	}
+	token, err := cades.RequestTimestampDigest(tsaURL, digest)
+	if err != nil {
+		return err
+	}
+	at, err := cades.VerifyTimestampDigest(token, digest)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp from %s: %w", tsaURL, err)
+	}
+	log.Printf("DEBUG: %s log anchored at %d entries by %s (%s)", name, entries, tsaURL, at.UTC().Format(time.RFC3339))
+	return l.AddAnchor(storage.AuditAnchor{
+		Head:        head,
+		Entries:     entries,
+		TSAURL:      tsaURL,
+		Time:        at.UTC().Format(time.RFC3339),
+		TokenBase64: base64.StdEncoding.EncodeToString(token),
+	})
+}
+
+// OfferRelink searches the browser profiles for a token identity whose
+// reference went stale and records an offer to relink it. It is used when
+// signing fails with pkcs12store.ErrStaleReference.
+func (a *App) OfferRelink(ctx context.Context, id pkcs12store.Identity) (RelinkOffer, error) {
+	ref, err := a.FindPKCS11Reference(ctx, id.Fingerprint256)
+	if err != nil {
+		return RelinkOffer{}, err
+	}
+	offer := RelinkOffer{Identity: id, Ref: *ref}
+	a.mu.Lock()
+	delete(a.relinkDismissed, id.ID)
+	a.mu.Unlock()
+	a.addRelinkOffer(offer)
+	return offer, nil
+}
+
+func (a *App) addRelinkOffer(offer RelinkOffer) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.relinkDismissed[offer.Identity.ID] {
+		return
+	}
+	for i, o := range a.relinkOffers {
+		if o.Identity.ID == offer.Identity.ID {
+			a.relinkOffers[i] = offer
+			return
+		}
+	}
+	a.relinkOffers = append(a.relinkOffers, offer)
+}
+
+// RelinkOffersSnapshot returns the pending relink offers.
+func (a *App) RelinkOffersSnapshot() []RelinkOffer {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return append([]RelinkOffer(nil), a.relinkOffers...)
+}
+
+// AcceptRelink stores the new reference of an offered identity.
+func (a *App) AcceptRelink(ctx context.Context, id string) error {
+	a.mu.RLock()
+	var offer *RelinkOffer
+	for _, o := range a.relinkOffers {
+		if o.Identity.ID == id {
+			offer = &o
+			break
+		}
+	}
+	a.mu.RUnlock()
+	if offer == nil {
+		return pkcs12store.ErrNotFound
+	}
+	if err := a.Store.Relink(ctx, id, offer.Ref); err != nil {
+		return err
+	}
+	log.Printf("DEBUG: relinked token identity %s to %s", id, offer.Ref.ProfileDir)
+	a.RecordSecurityEvent(storage.SecurityTrust, fmt.Sprintf("Relinked %s to the token in %s", offer.Identity.FriendlyName, offer.Ref.ProfileDir))
+	a.removeRelinkOffer(id)
+	return nil
+}
+
+// DismissRelink drops an offer and does not make it again this session.
+func (a *App) DismissRelink(id string) {
+	a.mu.Lock()
+	if a.relinkDismissed == nil {
+		a.relinkDismissed = make(map[string]bool)
+	}
+	a.relinkDismissed[id] = true
+	a.mu.Unlock()
+	a.removeRelinkOffer(id)
+}
+
+func (a *App) removeRelinkOffer(id string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for i, o := range a.relinkOffers {
+		if o.Identity.ID == id {
+			a.relinkOffers = append(a.relinkOffers[:i], a.relinkOffers[i+1:]...)
+			return
+		}
+	}
+}
+
+// AgentBatch tallies the signatures a certifying agent collected for one
+// request since the batch was started.
+type AgentBatch struct {
+	RequestID string
+	Started   time.Time
+	Signed    int
+	Failed    int
+	Canceled  int
+}
+
+// AgentIdentity returns the certificate chosen for agent mode, if it is
+// still in the wallet or a system store.
+func (a *App) AgentIdentity() (pkcs12store.Identity, bool) {
+	id := a.Settings.Get().AgentCertID
+	if id == "" {
+		return pkcs12store.Identity{}, false
+	}
+	return a.findIdentity(id)
+}
+
+// DemoIdentity returns the throwaway identity that signs demo requests,
+// making it on first use.
+func (a *App) DemoIdentity() (pkcs12store.Identity, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.demoIdentity == nil {
+		id, err := demo.NewIdentity()
+		if err != nil {
+			return pkcs12store.Identity{}, err
+		}
+		a.demoIdentity = &id
+	}
+	return *a.demoIdentity, nil
+}
+
+// RecordBatchResult counts a signing attempt with the given audit status in
+// the batch for requestID, starting a new batch if the request changed.
+func (a *App) RecordBatchResult(requestID, status string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.batch.RequestID != requestID {
+		a.batch = AgentBatch{RequestID: requestID, Started: time.Now()}
+	}
+	switch status {
+	case "success":
+		a.batch.Signed++
+	case "canceled":
+		a.batch.Canceled++
+	default:
+		a.batch.Failed++
+	}
+}
+
+// BatchSnapshot returns the batch for requestID, which is empty if no
+// signature was collected for it yet.
+func (a *App) BatchSnapshot(requestID string) AgentBatch {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.batch.RequestID != requestID {
+		return AgentBatch{RequestID: requestID}
+	}
+	return a.batch
+}
+
+// ResetBatch starts a new batch for the same request.
+func (a *App) ResetBatch() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.batch = AgentBatch{}
+}
+
+// AuditSyncResult summarizes an upload of the audit log to the collectors.
+type AuditSyncResult struct {
+	Requests   int // requests whose collector received entries
+	Accepted   int
+	Duplicates int
+	// Unsupported counts requests with entries that declare no auditSync
+	// endpoint or are no longer stored locally.
+	Unsupported int
+}
+
+// enforcePolicy returns st with the settings policy sets. If they do not
+// combine into valid settings, because st itself holds invalid values, the
+// policy is applied to the defaults instead, which Parse already checked,
+// keeping only the settings a profile does not carry.
+func enforcePolicy(policy managed.Policy, st settings.Settings) settings.Settings {
+	out, err := policy.ApplySettings(st)
+	if err == nil {
+		return out
+	}
+	log.Printf("WARNING: settings do not combine with the managed policy, resetting them: %v", err)
+	out, _ = policy.ApplySettings(settings.Default())
+	return settings.Profile{Settings: out}.Apply(st)
+}
+
+// findIdentity looks up a wallet or system store identity by ID.
+func (a *App) findIdentity(id string) (pkcs12store.Identity, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for _, list := range [][]pkcs12store.Identity{a.Identities, a.SystemIdentities} {
+		for _, identity := range list {
+			if identity.ID == id {
+				return identity, true
+			}
+		}
+	}
+	return pkcs12store.Identity{}, false
+}
+
+// KeepCounterSignature verifies the countersignature a collector returned
+// in receipt over the signature in resp, checks that it was made with one of
+// the keys the organizer of req publishes in its JWKS, stores the
+// counter-signed CMS and returns the countersigner's certificate
+// fingerprint. It returns "" if the receipt carries no countersignature.
+func (a *App) KeepCounterSignature(req *model.SignRequest, resp *model.SignResponse, receipt *model.SubmitReceipt) (string, error) {
+	if receipt == nil || receipt.CounterSignatureDerBase64 == "" {
+		return "", nil
+	}
+	original, err := base64.StdEncoding.DecodeString(resp.SignatureDerBase64)
+	if err != nil {
+		return "", fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	countersigned, err := base64.StdEncoding.DecodeString(receipt.CounterSignatureDerBase64)
+	if err != nil {
+		return "", fmt.Errorf("invalid countersignature encoding: %w", err)
+	}
+	cs, err := cades.VerifyCounterSignature(original, countersigned)
+	if err != nil {
+		return "", fmt.Errorf("invalid countersignature: %w", err)
+	}
+	jwks, err := jwsverify.FetchJWKS(req.Organizer.JWKSetURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch organizer keys: %w", err)
+	}
+	if !jwks.Holds(cs.Certificate.PublicKey) {
+		return "", fmt.Errorf("countersigner %s is not an organizer key", cs.Certificate.Subject.CommonName)
+	}
+	if err := a.Receipts.Save(original, countersigned); err != nil {
+		return "", fmt.Errorf("failed to store countersignature: %w", err)
+	}
+	return fmt.Sprintf("%x", pkcs12store.Fingerprint(cs.Certificate)), nil
+}
+
+// signAuditManifest signs manifest with the agent's certificate. Token
+// certificates ask for their PIN through the usual prompt.
+func (a *App) signAuditManifest(ctx context.Context, agentID string, manifest func(fingerprint string) storage.AuditManifest) (*appnet.AuditBundle, error) {
+	identity, ok := a.findIdentity(agentID)
+	if !ok {
+		return nil, pkcs12store.ErrNotFound
+	}
+	if err := a.UnlockWallet(); err != nil {
+		return nil, err
+	}
+	signer := identity.Signer
+	if signer == nil {
+		var err error
+		if signer, err = a.Store.Unlock(ctx, agentID); err != nil {
+			return nil, fmt.Errorf("failed to unlock certificate: %w", err)
+		}
+	}
+	fp := pkcs12store.Fingerprint(identity.Cert)
+	data, err := json.Marshal(manifest(hex.EncodeToString(fp[:])))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit manifest: %w", err)
+	}
+	sig, err := cades.SignDetached(ctx, signer, identity.Cert, identity.Chain, data, cades.SignOpts{SigningTime: time.Now()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign audit manifest: %w", err)
+	}
+	return &appnet.AuditBundle{Manifest: data, Signature: sig}, nil
+}
+
+// SyncAuditLog uploads to each request's collector the audit entries for
+// that request not uploaded there yet, signed by the certifying agent's
+// certificate agentID. Requests that declare no auditSync endpoint are
+// counted but otherwise skipped.
+func (a *App) SyncAuditLog(ctx context.Context, agentID string) (AuditSyncResult, error) {
+	var result AuditSyncResult
+	records, _, err := a.AuditLogger.Records()
+	if err != nil {
+		return result, err
+	}
+	var requestIDs []string
+	seen := make(map[string]bool)
+	for _, r := range records {
+		if !seen[r.Entry.RequestID] {
+			seen[r.Entry.RequestID] = true
+			requestIDs = append(requestIDs, r.Entry.RequestID)
+		}
+	}
+
+	for _, requestID := range requestIDs {
+		stored, err := a.Requests.Load(requestID)
+		if err != nil {
+			return result, fmt.Errorf("failed to load request %s: %w", requestID, err)
+		}
+		var req model.SignRequest
+		if stored == nil || json.Unmarshal(stored.Raw, &req) != nil || req.AuditSync == nil {
+			result.Unsupported++
+			continue
+		}
+		endpoint := req.AuditSync.URL
+		pending, head, err := a.AuditLogger.PendingSync(endpoint, requestID)
+		if err != nil {
+			return result, err
+		}
+		if len(pending) == 0 {
+			continue
+		}
+
+		bundle, err := a.signAuditManifest(ctx, agentID, func(fingerprint string) storage.AuditManifest {
+			return storage.NewAuditManifest(pending, requestID, fingerprint, head)
+		})
+		if err != nil {
+			return result, err
+		}
+		receipt, err := appnet.SyncAudit(ctx, endpoint, bundle)
+		if err != nil {
+			return result, fmt.Errorf("failed to sync %s: %w", requestID, err)
+		}
+		hashes := make([]string, len(pending))
+		for i, r := range pending {
+			hashes[i] = r.Hash
+		}
+		if err := a.AuditLogger.MarkSynced(endpoint, hashes); err != nil {
+			return result, fmt.Errorf("failed to record audit sync: %w", err)
+		}
+		log.Printf("DEBUG: synced %d audit entries for %s (%d accepted, %d duplicates)", len(pending), requestID, receipt.Accepted, receipt.Duplicates)
+		result.Requests++
+		result.Accepted += receipt.Accepted
+		result.Duplicates += receipt.Duplicates
+	}
+	return result, nil
+}
+
+// ExportProfile writes the settings and pinned organizers to w, to set up
+// other computers the same way with ImportProfile.
+func (a *App) ExportProfile(w io.Writer) error {
+	organizers, err := a.Organizers.List()
+	if err != nil {
+		return err
+	}
+	return settings.WriteProfile(w, settings.NewProfile(a.Settings.Get(), organizers, time.Now()))
+}
+
+// ImportProfile replaces the settings and the pinned organizers with those
+// of the profile read from r. Settings the profile does not carry, such as
+// the agent certificate, are kept.
+func (a *App) ImportProfile(r io.Reader) (settings.Profile, error) {
+	p, err := settings.ReadProfile(r)
+	if err != nil {
+		return settings.Profile{}, err
+	}
+	for _, o := range p.Organizers {
+		if err := a.Managed.CheckOrganizer(o.JWKSetURL); err != nil {
+			return settings.Profile{}, err
+		}
+	}
+	if err := a.Settings.Update(func(st *settings.Settings) { *st = p.Apply(*st) }); err != nil {
+		return settings.Profile{}, err
+	}
+	if err := a.Organizers.Replace(p.Organizers); err != nil {
+		return settings.Profile{}, err
+	}
+	a.ApplyPINCacheTTL()
+	a.ApplyIPFSGateways()
+	a.RefreshPinnedCampaigns()
+	return p, nil
+}
+
+// ExportAuditLog writes the whole audit log to w as a bundle signed by the
+// certifying agent's certificate agentID, for organizers whose collector
+// has no auditSync endpoint.
+func (a *App) ExportAuditLog(ctx context.Context, agentID string, w io.Writer) error {
+	records, head, err := a.AuditLogger.Records()
+	if err != nil {
+		return err
+	}
+	bundle, err := a.signAuditManifest(ctx, agentID, func(fingerprint string) storage.AuditManifest {
+		return storage.NewAuditManifest(records, "", fingerprint, head)
+	})
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(bundle)
+}
+
+func safeList(fn func(context.Context) ([]pkcs12store.Identity, error), ctx context.Context, label string) (ids []pkcs12store.Identity, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("ERROR: panic while listing %s: %v\n%s", label, r, string(debug.Stack()))
+			ids = nil
+			err = fmt.Errorf("panic while listing %s", label)
+		}
+	}()
+	return fn(ctx)
+}
+
+func NewApp(build BuildInfo) (*App, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home dir: %w", err)
+	}
+	appDataDir := filepath.Join(home, ".vocsign")
+	if err := os.MkdirAll(appDataDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create app data dir: %w", err)
+	}
+	// Before any store opens its files.
+	dataDir := datadir.Prepare(appDataDir)
+
+	policy, err := managed.Load()
+	if err != nil {
+		return nil, err
+	}
+	if policy.Managed() {
+		log.Printf("DEBUG: managed by the policy in %s", policy.Source)
+	}
+	if policy.Proxy != "" {
+		if err := appnet.SetProxy(policy.Proxy); err != nil {
+			return nil, err
+		}
+	}
+
+	logger, err := storage.NewAuditLogger(appDataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audit logger: %w", err)
+	}
+
+	securityLog, err := storage.NewSecurityLog(appDataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create security log: %w", err)
+	}
+
+	requests, err := storage.NewRequestStore(filepath.Join(appDataDir, "requests"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request store: %w", err)
+	}
+
+	receipts, err := storage.NewReceiptStore(filepath.Join(appDataDir, "receipts"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create receipt store: %w", err)
+	}
+
+	organizers, err := storage.NewOrganizerStore(appDataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create organizer store: %w", err)
+	}
+
+	policies, err := storage.NewPolicyCache(filepath.Join(appDataDir, "policies"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create policy cache: %w", err)
+	}
+
+	sessions, err := storage.NewSessionStore(appDataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session store: %w", err)
+	}
+
+	certAcks, err := storage.NewCertAckStore(appDataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create acknowledgement store: %w", err)
+	}
+
+	journal, err := storage.NewJournal(appDataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create signing journal: %w", err)
+	}
+
+	window, err := storage.NewWindowStore(appDataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create window state store: %w", err)
+	}
+
+	walletPIN, err := storage.NewWalletPINStore(appDataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create wallet PIN store: %w", err)
+	}
+
+	prefs, err := settings.NewStore(appDataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+	if len(policy.Settings) > 0 {
+		if err := prefs.Enforce(func(st *settings.Settings) { *st = enforcePolicy(policy, *st) }); err != nil {
+			return nil, fmt.Errorf("failed to apply policy settings: %w", err)
+		}
+	}
+
+	storeDir := filepath.Join(appDataDir, "store")
+	store, err := pkcs12store.NewFileStore(storeDir, pkcs12store.PortableVaultKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create store: %w", err)
+	}
+	journal.SetVaultCipher(store)
+
+	app := &App{
+		CurrentScreen: ScreenOpenRequest,
+		AuditLogger:   logger,
+		SecurityLog:   securityLog,
+		Requests:      requests,
+		Receipts:      receipts,
+		Organizers:    organizers,
+		Policies:      policies,
+		Sessions:      sessions,
+		CertAcks:      certAcks,
+		Journal:       journal,
+		Window:        window,
+		WalletPIN:     walletPIN,
+		Settings:      prefs,
+		Managed:       policy,
+		Platform:      platform.Detect(),
+		Store:         store,
+		DataDir:       dataDir,
+		dataDir:       appDataDir,
+		BuildInfo: BuildInfo{
+			Version:     nonEmpty(build.Version, "dev"),
+			Commit:      nonEmpty(build.Commit, "unknown"),
+			BuildDate:   nonEmpty(build.BuildDate, "unknown"),
+			VCSModified: nonEmpty(build.VCSModified, "unknown"),
+			Details:     buildinfo.Read(),
+		},
+		ReleasePageURL: appnet.LatestReleasePageURL,
+	}
+	app.Telemetry = telemetry.New(telemetry.DefaultEndpoint, app.BuildInfo.Version, func() bool {
+		return prefs.Get().TelemetryEnabled
+	})
+	app.Telemetry.Record(telemetry.EventAppStart, "", "")
+	app.Perf = perf.New()
+	app.Perf.OnJank = app.reportJank
+	app.jankLog = os.Getenv("VOCSIGN_JANK_LOG") == "1"
+	app.jankReported = make(map[string]bool)
+
+	app.ApplyPINCacheTTL()
+	app.ApplyIPFSGateways()
+	app.openVault(store)
+	if n, err := store.PurgeExpiredTrash(context.Background()); err != nil {
+		log.Printf("WARNING: failed to purge the trash: %v", err)
+	} else if n > 0 {
+		log.Printf("DEBUG: purged %d expired identities from the trash", n)
+	}
+	app.purgeRawRequests()
+	pkcs12store.SetPINPrompt(app.promptPIN)
+	pkcs12store.SetPasswordPrompt(app.promptCertPassword)
+	pkcs12store.SetAuthFailureHook(func(label string) {
+		app.RecordSecurityEvent(storage.SecurityAuthFailed, "Wrong PIN or password for "+label)
+	})
+	prefs.OnChange(app.recordSettingsChange)
+
+	if sess, err := sessions.Load(); err != nil {
+		log.Printf("WARNING: failed to load previous session: %v", err)
+	} else {
+		app.ResumeSession = sess
+	}
+	if entries, err := journal.Entries(); err != nil {
+		log.Printf("WARNING: failed to read signing journal: %v", err)
+	} else {
+		for _, e := range entries {
+			log.Printf("WARNING: signing of %s with certificate %s was interrupted at stage %s", e.RequestID, e.CertFingerprint, e.Stage)
+		}
+		app.interrupted = entries
+	}
+
+	// Initial load
+	ids, _ := store.List(context.Background())
+	app.SetIdentities(ids)
+	store.OnChange(app.reloadIdentities)
 
 	if len(ids) == 0 {
 		app.ShowWizard = true
 		app.CurrentScreen = ScreenWizard
 	}
+	if _, due := app.RescanReminder(); due {
+		app.CurrentScreen = ScreenWizard
+	}
 
 	return app, nil
 }
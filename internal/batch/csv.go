@@ -0,0 +1,250 @@
+// Package batch reads the signer rows a certifying agent transcribed from
+// paper forms, so they can be signed and submitted in one go.
+package batch
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/certs"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+// MaxRows bounds a single import. Larger collections are split in files.
+const MaxRows = 1000
+
+// maxFileSize bounds the CSV file read into memory.
+const maxFileSize = 4 << 20
+
+var ErrNoRows = errors.New("the file has no signer rows")
+
+// Row is one signer read from the file. Rows that fail validation are
+// returned with Err set so the agent can see and fix them.
+type Row struct {
+	Line   int // 1-based line in the file
+	Signer model.Signant
+	Err    error
+}
+
+type column int
+
+const (
+	colName column = iota
+	colSurname1
+	colSurname2
+	colSurnames // both surnames in one column
+	colID
+	colBirth
+)
+
+// defaultColumns is the order assumed for files without a header row.
+var defaultColumns = []column{colName, colSurname1, colSurname2, colID, colBirth}
+
+// headerNames maps normalized header cells to columns, in English, Catalan
+// and Spanish.
+var headerNames = map[string]column{
+	"name": colName, "first name": colName, "nom": colName, "nombre": colName,
+	"surname1": colSurname1, "surname 1": colSurname1, "first surname": colSurname1,
+	"cognom1": colSurname1, "cognom 1": colSurname1, "primer cognom": colSurname1,
+	"apellido1": colSurname1, "apellido 1": colSurname1, "primer apellido": colSurname1,
+	"surname2": colSurname2, "surname 2": colSurname2, "second surname": colSurname2,
+	"cognom2": colSurname2, "cognom 2": colSurname2, "segon cognom": colSurname2,
+	"apellido2": colSurname2, "apellido 2": colSurname2, "segundo apellido": colSurname2,
+	"surnames": colSurnames, "cognoms": colSurnames, "apellidos": colSurnames,
+	"dni": colID, "nie": colID, "dni/nie": colID, "dni nie": colID, "id": colID,
+	"document": colID, "documento": colID,
+	"birth date": colBirth, "birthdate": colBirth, "date of birth": colBirth,
+	"data naixement": colBirth, "data de naixement": colBirth,
+	"fecha nacimiento": colBirth, "fecha de nacimiento": colBirth,
+}
+
+// ParseCSV reads signer rows from a CSV file with the columns name,
+// surname 1, surname 2, DNI/NIE and birth date. A header row may name the
+// columns in any order; without one the order above is assumed. Both comma
+// and semicolon separated files are accepted, as spreadsheets in Spain
+// usually export the latter. Birth dates may be YYYY-MM-DD or DD/MM/YYYY.
+// A single surnames column is split only where that is unambiguous, and a
+// DNI/NIE must have the control letter of its number.
+// Rows are checked against the request's signer fields, which may be nil;
+// omitted fields are dropped even if the file has them.
+func ParseCSV(r io.Reader, fields *model.SignerFields) ([]Row, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if len(data) > maxFileSize {
+		return nil, fmt.Errorf("file exceeds %d bytes", maxFileSize)
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	cr := csv.NewReader(bytes.NewReader(data))
+	cr.Comma = detectDelimiter(data)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	var rows []Row
+	var cols []column
+	seen := make(map[string]int)
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		if blank(record) {
+			continue
+		}
+		if cols == nil {
+			if header, ok := parseHeader(record); ok {
+				cols = header
+				continue
+			}
+			cols = defaultColumns
+		}
+		if len(rows) == MaxRows {
+			return nil, fmt.Errorf("the file has more than %d signer rows", MaxRows)
+		}
+
+		row := Row{Line: line}
+		row.Signer, row.Err = parseRow(record, cols, fields)
+		if row.Err == nil {
+			if prev, dup := seen[row.Signer.NumIdentifica]; dup {
+				row.Err = fmt.Errorf("same DNI/NIE as line %d", prev)
+			} else {
+				seen[row.Signer.NumIdentifica] = line
+			}
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil, ErrNoRows
+	}
+	return rows, nil
+}
+
+// detectDelimiter picks ';' when the first line has more semicolons than
+// commas.
+func detectDelimiter(data []byte) rune {
+	first := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		first = data[:i]
+	}
+	if bytes.Count(first, []byte(";")) > bytes.Count(first, []byte(",")) {
+		return ';'
+	}
+	return ','
+}
+
+func blank(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// parseHeader returns the column layout if record is a header row, i.e.
+// if it names an ID column.
+func parseHeader(record []string) ([]column, bool) {
+	cols := make([]column, len(record))
+	hasID := false
+	for i, v := range record {
+		key := strings.ToLower(strings.TrimSpace(v))
+		key = strings.NewReplacer("_", " ", "-", " ").Replace(key)
+		c, ok := headerNames[key]
+		if !ok {
+			c = -1
+		}
+		if c == colID {
+			hasID = true
+		}
+		cols[i] = c
+	}
+	return cols, hasID
+}
+
+func parseRow(record []string, cols []column, fields *model.SignerFields) (model.Signant, error) {
+	var s model.Signant
+	var rawID, rawBirth, surnames string
+	for i, v := range record {
+		if i >= len(cols) {
+			break
+		}
+		v = strings.Join(strings.Fields(v), " ")
+		switch cols[i] {
+		case colName:
+			s.Nom = v
+		case colSurname1:
+			s.Cognom1 = v
+		case colSurname2:
+			s.Cognom2 = v
+		case colSurnames:
+			surnames = v
+		case colID:
+			rawID = v
+		case colBirth:
+			rawBirth = v
+		}
+	}
+
+	if surnames != "" {
+		var ok bool
+		if s.Cognom1, s.Cognom2, ok = splitSurnames(surnames); !ok {
+			return s, fmt.Errorf("cannot tell the two surnames apart in %q; put each in its own column", surnames)
+		}
+	}
+	if s.Nom == "" && s.Cognom1 == "" {
+		return s, errors.New("name is missing")
+	}
+	s.NumIdentifica, s.TipusIdentifica = certs.ParsePersonalID(rawID)
+	if s.NumIdentifica == "" {
+		return s, fmt.Errorf("%q is not a valid DNI or NIE", rawID)
+	}
+	if !certs.ValidControlLetter(s.NumIdentifica) {
+		return s, fmt.Errorf("the letter of %s does not match its number; check the digits", s.NumIdentifica)
+	}
+	s.DataNaixement = normalizeDate(rawBirth)
+	return fields.Check(s)
+}
+
+// surnameParticles start or join compound surnames, as in "de la Torre".
+var surnameParticles = map[string]bool{
+	"de": true, "del": true, "la": true, "las": true, "los": true,
+	"i": true, "y": true, "da": true, "das": true, "do": true, "dos": true,
+	"van": true, "von": true, "der": true, "di": true,
+}
+
+// splitSurnames splits a cell with both surnames. Only one word, or two
+// that are not particles, split unambiguously; for compound surnames such
+// as "de la Torre Pérez" ok is false and the agent must split them.
+func splitSurnames(v string) (first, second string, ok bool) {
+	words := strings.Fields(v)
+	switch {
+	case len(words) == 1:
+		return words[0], "", true
+	case len(words) == 2 && !surnameParticles[strings.ToLower(words[0])] && !surnameParticles[strings.ToLower(words[1])]:
+		return words[0], words[1], true
+	default:
+		return "", "", false
+	}
+}
+
+// normalizeDate converts DD/MM/YYYY, as written on paper forms, to
+// YYYY-MM-DD. Other values are returned unchanged for validation.
+func normalizeDate(v string) string {
+	for _, layout := range []string{"02/01/2006", "2/1/2006", "02-01-2006"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t.Format("2006-01-02")
+		}
+	}
+	return v
+}
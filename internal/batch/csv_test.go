@@ -0,0 +1,112 @@
+package batch
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		fields   *model.SignerFields
+		wantRows int
+		wantErrs []int // lines of rows that must fail validation
+		check    func(t *testing.T, rows []Row)
+	}{
+		{
+			name:     "no header, comma separated",
+			in:       "Joan,Garcia,Lopez,12345678Z,1990-05-15\nAnna,Puig,,X1234567L,15/05/1985\n",
+			wantRows: 2,
+			check: func(t *testing.T, rows []Row) {
+				if s := rows[1].Signer; s.TipusIdentifica != "NIE" || s.DataNaixement != "1985-05-15" || s.Cognom2 != "" {
+					t.Errorf("row 2 = %+v", s)
+				}
+			},
+		},
+		{
+			name:     "header in another order, semicolons and BOM",
+			in:       "\xef\xbb\xbfDNI;Data de naixement;Nom;Cognoms\n12345678-z;1990-05-15;Joan;Garcia  Lopez\n",
+			wantRows: 1,
+			check: func(t *testing.T, rows []Row) {
+				s := rows[0].Signer
+				if s.Nom != "Joan" || s.Cognom1 != "Garcia" || s.Cognom2 != "Lopez" || s.NumIdentifica != "12345678Z" {
+					t.Errorf("row = %+v", s)
+				}
+				if rows[0].Line != 2 {
+					t.Errorf("Line = %d, want 2", rows[0].Line)
+				}
+			},
+		},
+		{
+			name:     "invalid rows are kept with an error",
+			in:       "Joan,Garcia,Lopez,12345678Z,1990-05-15\n\nAnna,Puig,,B1234567,1990-05-15\n,,,23456789D,1990-05-15\nPere,Vila,,23456789D,1990-02-30\nMarta,Roca,,12345678Z,1991-01-01\n",
+			wantRows: 5,
+			wantErrs: []int{3, 4, 5, 6},
+		},
+		{
+			name:     "wrong control letter",
+			in:       "Joan,Garcia,Lopez,12345679Z,1990-05-15\nAnna,Puig,,X1234567A,1985-05-15\n",
+			wantRows: 2,
+			wantErrs: []int{1, 2},
+		},
+		{
+			name:     "compound surnames in one column",
+			in:       "Nom;Cognoms;DNI;Data naixement\nJoan;Puig;12345678Z;1990-05-15\nAnna;de la Torre Pérez;X1234567L;1985-05-15\nPere;Vila de Mar;23456789D;1990-02-03\n",
+			wantRows: 3,
+			wantErrs: []int{3, 4},
+			check: func(t *testing.T, rows []Row) {
+				if s := rows[0].Signer; s.Cognom1 != "Puig" || s.Cognom2 != "" {
+					t.Errorf("row 1 = %+v", s)
+				}
+			},
+		},
+		{
+			name:     "signer fields policy",
+			in:       "Nom;Cognom1;Cognom2;DNI;Data naixement\nJoan;Garcia;Lopez;12345678Z;1990-05-15\nAnna;Puig;;X1234567L;\n",
+			fields:   &model.SignerFields{BirthDate: model.SignerFieldOmitted, SecondSurname: model.SignerFieldRequired},
+			wantRows: 2,
+			wantErrs: []int{3},
+			check: func(t *testing.T, rows []Row) {
+				if s := rows[0].Signer; s.DataNaixement != "" || s.Cognom2 != "Lopez" {
+					t.Errorf("row 1 = %+v", s)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ParseCSV(strings.NewReader(tt.in), tt.fields)
+			if err != nil {
+				t.Fatalf("ParseCSV: %v", err)
+			}
+			if len(rows) != tt.wantRows {
+				t.Fatalf("got %d rows, want %d", len(rows), tt.wantRows)
+			}
+			var errLines []int
+			for _, r := range rows {
+				if r.Err != nil {
+					errLines = append(errLines, r.Line)
+				}
+			}
+			if len(errLines) != len(tt.wantErrs) {
+				t.Fatalf("rows with errors at lines %v, want %v", errLines, tt.wantErrs)
+			}
+			for i := range errLines {
+				if errLines[i] != tt.wantErrs[i] {
+					t.Fatalf("rows with errors at lines %v, want %v", errLines, tt.wantErrs)
+				}
+			}
+			if tt.check != nil {
+				tt.check(t, rows)
+			}
+		})
+	}
+
+	if _, err := ParseCSV(strings.NewReader("Nom;DNI\n\n"), nil); !errors.Is(err, ErrNoRows) {
+		t.Errorf("header only: err = %v, want ErrNoRows", err)
+	}
+}
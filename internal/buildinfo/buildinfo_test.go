@@ -0,0 +1,55 @@
+package buildinfo
+
+import (
+	"encoding/json"
+	"runtime/debug"
+	"testing"
+)
+
+func TestFromBuildInfoAndSBOM(t *testing.T) {
+	bi := &debug.BuildInfo{
+		GoVersion: "go1.25.6",
+		Main:      debug.Module{Path: "github.com/vocdoni/gofirma/vocsign", Version: "(devel)"},
+		Deps: []*debug.Module{
+			{Path: "gioui.org", Version: "v0.9.0", Sum: "h1:abc="},
+			{Path: "example.com/old", Version: "v1.0.0", Replace: &debug.Module{Path: "example.com/fork", Version: "v1.0.1", Sum: "h1:def="}},
+		},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0123abc"},
+			{Key: "vcs.modified", Value: "false"},
+			{Key: "-trimpath", Value: "true"},
+			{Key: "-ldflags", Value: "-X main.version=v1"},
+		},
+	}
+	d := fromBuildInfo(bi)
+	if d.VCSRevision != "0123abc" || d.VCSModified != "false" {
+		t.Fatalf("unexpected VCS state: %+v", d)
+	}
+	if d.Settings["-trimpath"] != "true" {
+		t.Errorf("expected -trimpath setting, got %v", d.Settings)
+	}
+	if _, ok := d.Settings["-ldflags"]; ok {
+		t.Error("-ldflags should not be reported")
+	}
+	if len(d.Deps) != 2 || d.Deps[0].Path != "example.com/fork" || d.Deps[1].Path != "gioui.org" {
+		t.Fatalf("unexpected deps: %+v", d.Deps)
+	}
+
+	out, err := d.SBOM("v1.4.0")
+	if err != nil {
+		t.Fatalf("SBOM: %v", err)
+	}
+	var doc cdxDocument
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("SBOM is not valid JSON: %v", err)
+	}
+	if doc.BOMFormat != "CycloneDX" || doc.Metadata.Component.Version != "v1.4.0" {
+		t.Fatalf("unexpected SBOM header: %+v", doc)
+	}
+	if len(doc.Components) != 2 || doc.Components[1].PURL != "pkg:golang/gioui.org@v0.9.0" {
+		t.Fatalf("unexpected components: %+v", doc.Components)
+	}
+	if h := doc.Components[1].Hashes; len(h) != 1 || h[0].Content != "abc=" {
+		t.Fatalf("unexpected hashes: %+v", h)
+	}
+}
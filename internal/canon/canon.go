@@ -11,6 +11,7 @@ import (
 // - Struct fields in Go declaration order (NOT alphabetical)
 // - No insignificant whitespace (Go's default for Marshal)
 // - No HTML escaping (SetEscapeHTML(false))
+// - U+2028 and U+2029 written as is, like JSON.stringify and RFC 8785
 //
 // IMPORTANT: The organizer (TypeScript portal) must produce JSON with the same
 // field ordering. Go's encoding/json outputs struct fields in declaration order,
@@ -32,5 +33,34 @@ func Encode(v any) ([]byte, error) {
 		bytes = bytes[:len(bytes)-1]
 	}
 
-	return bytes, nil
+	return unescapeLineSeparators(bytes), nil
+}
+
+// unescapeLineSeparators undoes encoding/json's \u2028 and \u2029 escapes,
+// which it applies even with HTML escaping disabled. Escaped backslashes
+// are skipped as a pair so a literal "\\u2028" in a string is kept.
+func unescapeLineSeparators(b []byte) []byte {
+	if !bytes.Contains(b, []byte(`\u202`)) {
+		return b
+	}
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		if b[i] != '\\' || i+1 >= len(b) {
+			out = append(out, b[i])
+			continue
+		}
+		switch string(b[i+1 : min(i+6, len(b))]) {
+		case "u2028":
+			out = append(out, "\u2028"...)
+			i += 5
+			continue
+		case "u2029":
+			out = append(out, "\u2029"...)
+			i += 5
+			continue
+		}
+		out = append(out, b[i], b[i+1])
+		i++
+	}
+	return out
 }
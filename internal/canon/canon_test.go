@@ -144,3 +144,19 @@ func TestEncodeDeterministic(t *testing.T) {
 		t.Errorf("Determinism broken: first=%q, second=%q", first, second)
 	}
 }
+
+func TestEncodeLineSeparators(t *testing.T) {
+	// JSON.stringify leaves U+2028 and U+2029 unescaped, and so must we for
+	// the organizer's JWS payload to match.
+	input := map[string]string{"text": "a\u2028b\u2029c", "raw": `\u2028`}
+	expected := `{"raw":"\\u2028","text":"a` + "\u2028" + `b` + "\u2029" + `c"}`
+
+	encoded, err := Encode(input)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	if string(encoded) != expected {
+		t.Errorf("Expected %q, got %q", expected, string(encoded))
+	}
+}
@@ -0,0 +1,220 @@
+package canon
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// jcs is a reference RFC 8785 (JSON Canonicalization Scheme) serializer for
+// the values encoding/json decodes into an any. It is written from the RFC,
+// independently of encoding/json, so the fuzz target below can compare the
+// two.
+func jcs(v any) string {
+	var b strings.Builder
+	jcsValue(&b, v)
+	return b.String()
+}
+
+func jcsValue(b *strings.Builder, v any) {
+	switch v := v.(type) {
+	case nil:
+		b.WriteString("null")
+	case bool:
+		b.WriteString(strconv.FormatBool(v))
+	case float64:
+		b.WriteString(jcsNumber(v))
+	case string:
+		jcsString(b, v)
+	case []any:
+		b.WriteByte('[')
+		for i, e := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			jcsValue(b, e)
+		}
+		b.WriteByte(']')
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.SortFunc(keys, func(a, b string) int {
+			return slices.Compare(utf16.Encode([]rune(a)), utf16.Encode([]rune(b)))
+		})
+		b.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			jcsString(b, k)
+			b.WriteByte(':')
+			jcsValue(b, v[k])
+		}
+		b.WriteByte('}')
+	default:
+		panic(fmt.Sprintf("jcs: unexpected %T", v))
+	}
+}
+
+func jcsString(b *strings.Builder, s string) {
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+}
+
+// jcsNumber follows ECMAScript's Number.prototype.toString, which RFC 8785
+// adopts for numbers.
+func jcsNumber(f float64) string {
+	if f == 0 {
+		return "0"
+	}
+	if f < 0 {
+		return "-" + jcsNumber(-f)
+	}
+	// Shortest round-tripping digits d1.d2...dk and the exponent n such
+	// that the value is 0.d1d2...dk × 10^n.
+	mantissa, exp, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	e, _ := strconv.Atoi(exp)
+	k, n := len(digits), e+1
+	switch {
+	case k <= n && n <= 21:
+		return digits + strings.Repeat("0", n-k)
+	case 0 < n && n <= 21:
+		return digits[:n] + "." + digits[n:]
+	case -6 < n && n <= 0:
+		return "0." + strings.Repeat("0", -n) + digits
+	}
+	sign := "+"
+	if n-1 < 0 {
+		sign = "-"
+	}
+	exponent := "e" + sign + strconv.Itoa(int(math.Abs(float64(n-1))))
+	if k == 1 {
+		return digits + exponent
+	}
+	return digits[:1] + "." + digits[1:] + exponent
+}
+
+// jcsComparable reports whether v avoids the two places where Encode is
+// knowingly not JCS: negative zero keeps its sign, and map keys are sorted
+// by UTF-8 bytes, which differs from UTF-16 order only for keys holding
+// characters outside the Basic Multilingual Plane. Neither occurs in sign
+// requests, which carry no floats and only ASCII keys.
+func jcsComparable(v any) bool {
+	switch v := v.(type) {
+	case float64:
+		return v != 0 || !math.Signbit(v)
+	case []any:
+		for _, e := range v {
+			if !jcsComparable(e) {
+				return false
+			}
+		}
+	case map[string]any:
+		for k, e := range v {
+			for _, r := range k {
+				if r > 0xFFFF {
+					return false
+				}
+			}
+			if !jcsComparable(e) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestJCSNumber(t *testing.T) {
+	tests := map[float64]string{
+		1:                     "1",
+		-1.5:                  "-1.5",
+		100:                   "100",
+		1e21:                  "1e+21",
+		1e20:                  "100000000000000000000",
+		123456789012345680000: "123456789012345680000",
+		0.000001:              "0.000001",
+		1.5e-7:                "1.5e-7",
+		5e-324:                "5e-324",
+		math.MaxFloat64:       "1.7976931348623157e+308",
+	}
+	for f, want := range tests {
+		if got := jcsNumber(f); got != want {
+			t.Errorf("jcsNumber(%v) = %q, want %q", f, got, want)
+		}
+	}
+}
+
+func FuzzEncodeMatchesJCS(f *testing.F) {
+	for _, seed := range []string{
+		`{"b":1,"a":"hello","c":[2,1,3],"d":{"y":"foo","x":"bar"}}`,
+		`{"html":"<b>bold</b> & \"quoted\"","ctl":"\u0000\b\f\n\r\t\u001f\u007f"}`,
+		`{"sep":"line\u2028para\u2029","esc":"\\u2028"}`,
+		`[1e21,1e-7,0.1,-0.000001,123456789012345678901,1.7976931348623157e308]`,
+		`{"é":1,"e":2,"\uffff":3,"\ue000":4,"\u00e9\u0301":5}`,
+		`{"proposal":{"title":"Iniciativa legislativa popular","promoter":"Comissió Promotora"}}`,
+		`[null,true,false,"",{},[]]`,
+	} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, in []byte) {
+		var v any
+		if err := json.Unmarshal(in, &v); err != nil {
+			return
+		}
+		if !jcsComparable(v) {
+			return
+		}
+		encoded, err := Encode(v)
+		if err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+		if want := jcs(v); string(encoded) != want {
+			t.Fatalf("Encode differs from JCS:\n got  %q\n want %q", encoded, want)
+		}
+
+		var again any
+		if err := json.Unmarshal(encoded, &again); err != nil {
+			t.Fatalf("Encode output does not parse: %v", err)
+		}
+		reencoded, err := Encode(again)
+		if err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+		if string(reencoded) != string(encoded) {
+			t.Fatalf("Encode is not stable across a round trip:\n first  %q\n second %q", encoded, reencoded)
+		}
+	})
+}
@@ -0,0 +1,159 @@
+// Package changelog reads the Markdown release notes of a VocSign release
+// into blocks the About screen can lay out, and finds the security fixes
+// among them.
+//
+// Only what release notes use is understood: headings, bullet and numbered
+// lists, paragraphs and code blocks. Inline markup is reduced to its text;
+// links keep their label.
+package changelog
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	// MaxBlocks bounds the notes shown; the rest is on the release page.
+	MaxBlocks = 200
+	// MaxBytes bounds the Markdown read.
+	MaxBytes = 64 << 10
+)
+
+// Kind is the kind of a block.
+type Kind int
+
+const (
+	Paragraph Kind = iota
+	Heading
+	Bullet
+	Code
+)
+
+// Block is a heading, list item, paragraph or code block.
+type Block struct {
+	Kind Kind
+	Text string
+	// Level is the heading level, 1 to 6, or the nesting of a list item,
+	// from 0.
+	Level int
+	// Security is set on security fixes: blocks that mention a
+	// vulnerability or a CVE or GHSA advisory, and everything under a
+	// heading that mentions security.
+	Security bool
+}
+
+// Notes are parsed release notes.
+type Notes struct {
+	Blocks []Block
+	// Truncated reports whether blocks past MaxBlocks were dropped.
+	Truncated bool
+}
+
+// Security reports whether the release fixes security issues.
+func (n Notes) Security() bool {
+	for _, b := range n.Blocks {
+		if b.Security {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	headingRe  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*$`)
+	bulletRe   = regexp.MustCompile(`^(\s*)(?:[-*+]|\d+[.)])\s+(.*)$`)
+	securityRe = regexp.MustCompile(`(?i)\bsecurity\b|\bCVE-\d{4}-\d+|\bGHSA-|\bvulnerab`)
+	imageRe    = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)`)
+	linkRe     = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	commentRe  = regexp.MustCompile(`(?s)<!--.*?-->`)
+	markupRe   = regexp.MustCompile("\\*\\*|__|`")
+)
+
+// Parse reads Markdown release notes.
+func Parse(md string) Notes {
+	if len(md) > MaxBytes {
+		md = md[:MaxBytes]
+	}
+	md = commentRe.ReplaceAllString(strings.ReplaceAll(md, "\r\n", "\n"), "")
+
+	var n Notes
+	var para []string
+	var code []string
+	inCode := false
+	// securityLevel is the level of the heading that mentions security,
+	// 0 outside one.
+	securityLevel := 0
+	add := func(b Block) {
+		if len(n.Blocks) == MaxBlocks {
+			n.Truncated = true
+			return
+		}
+		b.Security = b.Security || securityLevel > 0 || securityRe.MatchString(b.Text)
+		n.Blocks = append(n.Blocks, b)
+	}
+	flush := func() {
+		if len(para) > 0 {
+			add(Block{Kind: Paragraph, Text: inline(strings.Join(para, " "))})
+			para = nil
+		}
+	}
+
+	for _, line := range strings.Split(md, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			flush()
+			if inCode {
+				add(Block{Kind: Code, Text: strings.Join(code, "\n")})
+				code = nil
+			}
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			code = append(code, line)
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		if m := headingRe.FindStringSubmatch(line); m != nil {
+			flush()
+			level := len(m[1])
+			if securityLevel > 0 && level <= securityLevel {
+				securityLevel = 0
+			}
+			text := inline(m[2])
+			if securityRe.MatchString(text) {
+				securityLevel = level
+			}
+			add(Block{Kind: Heading, Text: text, Level: level})
+			continue
+		}
+		if m := bulletRe.FindStringSubmatch(line); m != nil {
+			flush()
+			indent := len(strings.ReplaceAll(m[1], "\t", "  "))
+			add(Block{Kind: Bullet, Text: inline(m[2]), Level: indent / 2})
+			continue
+		}
+		if k := len(n.Blocks); len(para) == 0 && k > 0 && n.Blocks[k-1].Kind == Bullet && strings.HasPrefix(line, " ") {
+			// A continuation line of a list item.
+			n.Blocks[k-1].Text += " " + inline(line)
+			n.Blocks[k-1].Security = n.Blocks[k-1].Security || securityRe.MatchString(line)
+			continue
+		}
+		para = append(para, strings.TrimSpace(line))
+	}
+	flush()
+	if inCode && len(code) > 0 {
+		add(Block{Kind: Code, Text: strings.Join(code, "\n")})
+	}
+	return n
+}
+
+// inline reduces inline Markdown to its text.
+func inline(s string) string {
+	s = imageRe.ReplaceAllString(s, "")
+	s = linkRe.ReplaceAllString(s, "$1")
+	s = markupRe.ReplaceAllString(s, "")
+	return strings.Join(strings.Fields(s), " ")
+}
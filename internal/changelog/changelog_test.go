@@ -0,0 +1,80 @@
+package changelog
+
+import (
+	"strings"
+	"testing"
+)
+
+const notes = `## Highlights
+
+VocSign now **prints** receipts.
+See [the docs](https://example.org/docs) for details.
+
+## Security fixes
+
+- Reject requests with an oversized ` + "`nonce`" + `
+- Update Go to 1.25.7
+
+### Other
+
+* Faster certificate list
+  with thousands of entries
+* Fix for CVE-2026-12345 in the QR decoder
+  1. nested step
+
+<!-- generated -->
+` + "```" + `
+vocsign --version
+` + "```" + `
+`
+
+func TestParse(t *testing.T) {
+	n := Parse(notes)
+	want := []Block{
+		{Kind: Heading, Text: "Highlights", Level: 2},
+		{Kind: Paragraph, Text: "VocSign now prints receipts. See the docs for details."},
+		{Kind: Heading, Text: "Security fixes", Level: 2, Security: true},
+		{Kind: Bullet, Text: "Reject requests with an oversized nonce", Security: true},
+		{Kind: Bullet, Text: "Update Go to 1.25.7", Security: true},
+		{Kind: Heading, Text: "Other", Level: 3, Security: true},
+		{Kind: Bullet, Text: "Faster certificate list with thousands of entries", Security: true},
+		{Kind: Bullet, Text: "Fix for CVE-2026-12345 in the QR decoder", Security: true},
+		{Kind: Bullet, Text: "nested step", Level: 1, Security: true},
+		{Kind: Code, Text: "vocsign --version", Security: true},
+	}
+	if len(n.Blocks) != len(want) {
+		t.Fatalf("got %d blocks: %+v", len(n.Blocks), n.Blocks)
+	}
+	for i := range want {
+		if n.Blocks[i] != want[i] {
+			t.Errorf("block %d = %+v, want %+v", i, n.Blocks[i], want[i])
+		}
+	}
+	if !n.Security() || n.Truncated {
+		t.Fatalf("Security = %v, Truncated = %v", n.Security(), n.Truncated)
+	}
+}
+
+func TestParseSecuritySectionEnds(t *testing.T) {
+	n := Parse("## Security\n\n- Fix A\n\n## Features\n\n- Dark mode\n- Patch a vulnerability in import\n")
+	got := map[string]bool{}
+	for _, b := range n.Blocks {
+		got[b.Text] = b.Security
+	}
+	if !got["Fix A"] || got["Features"] || got["Dark mode"] || !got["Patch a vulnerability in import"] {
+		t.Fatalf("security = %v", got)
+	}
+	if Parse("## Features\n\n- Dark mode\n").Security() {
+		t.Fatal("release without security fixes flagged")
+	}
+}
+
+func TestParseBounds(t *testing.T) {
+	n := Parse(strings.Repeat("- item\n", MaxBlocks+10))
+	if len(n.Blocks) != MaxBlocks || !n.Truncated {
+		t.Fatalf("%d blocks, truncated %v", len(n.Blocks), n.Truncated)
+	}
+	if n := Parse(""); len(n.Blocks) != 0 {
+		t.Fatalf("empty notes = %+v", n.Blocks)
+	}
+}
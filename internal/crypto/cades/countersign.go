@@ -0,0 +1,243 @@
+package cades
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/smallstep/pkcs7"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+)
+
+// OID for id-countersignature (RFC 5652 section 11.4).
+var OidCounterSignature = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 6}
+
+// The structures below decode an existing SignedData just enough to add an
+// unsigned attribute and certificates to it. Everything the citizen signed
+// is kept as raw bytes so re-encoding cannot alter it.
+
+type rawSignedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	ContentInfo      asn1.RawValue
+	Certificates     asn1.RawValue   `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue   `asn1:"optional,tag:1"`
+	SignerInfos      []rawSignerInfo `asn1:"set"`
+}
+
+type rawSignerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    asn1.RawValue
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm asn1.RawValue
+	Signature          []byte
+	UnsignedAttrs      []attribute `asn1:"optional,omitempty,tag:1"`
+}
+
+// CounterSignature describes a verified countersignature.
+type CounterSignature struct {
+	Certificate *x509.Certificate
+	SigningTime time.Time
+}
+
+// CounterSign adds a countersignature by signer over the signature value of
+// the first SignerInfo in pkcs7DER, as a collector does to acknowledge a
+// received signature. The citizen's SignerInfo is left untouched apart from
+// the new unsigned attribute, and cert and chain are added to the
+// certificates.
+func CounterSign(signer crypto.Signer, cert *x509.Certificate, chain []*x509.Certificate, pkcs7DER []byte, signingTime time.Time) ([]byte, error) {
+	sd, err := parseRawSignedData(pkcs7DER)
+	if err != nil {
+		return nil, errcode.Errorf(errcode.SignatureBuild, "failed to parse signature to counter-sign: %w", err)
+	}
+
+	signingCert, err := signingCertificateAttribute(cert)
+	if err != nil {
+		return nil, err
+	}
+	if signingTime.IsZero() {
+		signingTime = time.Now()
+	}
+	// RFC 5652 section 11.4: a countersignature covers the signature value
+	// and has no content-type attribute.
+	digest := sha256.Sum256(sd.SignerInfos[0].Signature)
+	si, err := buildSignerInfo(signer, cert, []pkcs7.Attribute{
+		signingCert,
+		{Type: pkcs7.OIDAttributeMessageDigest, Value: digest[:]},
+		{Type: pkcs7.OIDAttributeSigningTime, Value: signingTime.UTC()},
+	})
+	if err != nil {
+		return nil, errcode.Errorf(errcode.SignatureBuild, "failed to counter-sign: %w", err)
+	}
+	siBytes, err := asn1.Marshal(si)
+	if err != nil {
+		return nil, errcode.Errorf(errcode.SignatureBuild, "failed to marshal countersignature: %w", err)
+	}
+	sd.SignerInfos[0].UnsignedAttrs = append(sd.SignerInfos[0].UnsignedAttrs, attribute{
+		Type:  OidCounterSignature,
+		Value: asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: siBytes},
+	})
+
+	out, err := sd.marshal(append([]*x509.Certificate{cert}, chain...))
+	if err != nil {
+		return nil, errcode.Errorf(errcode.SignatureBuild, "failed to marshal counter-signed data: %w", err)
+	}
+	log.Printf("DEBUG: Counter-signed by %s, signature size: %d", cert.Subject.CommonName, len(out))
+	return out, nil
+}
+
+// VerifyCounterSignature checks that countersigned is original with a valid
+// countersignature over its first signature value, and returns the
+// countersigner. Whether the countersigner is trusted is left to the
+// caller.
+func VerifyCounterSignature(original, countersigned []byte) (*CounterSignature, error) {
+	orig, err := parseRawSignedData(original)
+	if err != nil {
+		return nil, fmt.Errorf("parse original signature: %w", err)
+	}
+	sd, err := parseRawSignedData(countersigned)
+	if err != nil {
+		return nil, fmt.Errorf("parse counter-signed data: %w", err)
+	}
+	first := sd.SignerInfos[0]
+	if !bytes.Equal(first.SignedAttrs.FullBytes, orig.SignerInfos[0].SignedAttrs.FullBytes) ||
+		!bytes.Equal(first.Signature, orig.SignerInfos[0].Signature) {
+		return nil, fmt.Errorf("counter-signed data does not contain the original signature")
+	}
+
+	var cs *rawSignerInfo
+	for _, attr := range first.UnsignedAttrs {
+		if attr.Type.Equal(OidCounterSignature) {
+			var si rawSignerInfo
+			if _, err := asn1.Unmarshal(attr.Value.Bytes, &si); err != nil {
+				return nil, fmt.Errorf("unmarshal countersignature: %w", err)
+			}
+			cs = &si
+		}
+	}
+	if cs == nil {
+		return nil, fmt.Errorf("no countersignature found")
+	}
+
+	certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse certificates: %w", err)
+	}
+	var cert *x509.Certificate
+	for _, c := range certs {
+		if sidMatches(cs.SID, c) {
+			cert = c
+			break
+		}
+	}
+	if cert == nil {
+		return nil, fmt.Errorf("countersigner certificate not found")
+	}
+
+	if len(cs.SignedAttrs.FullBytes) == 0 {
+		return nil, fmt.Errorf("countersignature has no signed attributes")
+	}
+	var attrs []attribute
+	if _, err := asn1.UnmarshalWithParams(cs.SignedAttrs.FullBytes, &attrs, "set,tag:0"); err != nil {
+		return nil, fmt.Errorf("unmarshal countersignature attributes: %w", err)
+	}
+	result := &CounterSignature{Certificate: cert}
+	var digest []byte
+	for _, attr := range attrs {
+		switch {
+		case attr.Type.Equal(pkcs7.OIDAttributeMessageDigest):
+			_, err = asn1.Unmarshal(attr.Value.Bytes, &digest)
+		case attr.Type.Equal(pkcs7.OIDAttributeSigningTime):
+			_, err = asn1.Unmarshal(attr.Value.Bytes, &result.SigningTime)
+		case attr.Type.Equal(pkcs7.OIDAttributeContentType):
+			err = fmt.Errorf("countersignature must not have a content type")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("countersignature attribute %s: %w", attr.Type, err)
+		}
+	}
+	want := sha256.Sum256(first.Signature)
+	if !bytes.Equal(digest, want[:]) {
+		return nil, fmt.Errorf("countersignature does not cover this signature")
+	}
+
+	// The signature is over the attributes encoded as a SET OF rather than
+	// with their [0] IMPLICIT tag.
+	signed := append([]byte{0x31}, cs.SignedAttrs.FullBytes[1:]...)
+	var alg x509.SignatureAlgorithm
+	switch cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		alg = x509.SHA256WithRSA
+	case *ecdsa.PublicKey:
+		alg = x509.ECDSAWithSHA256
+	default:
+		return nil, fmt.Errorf("unsupported countersigner key type %T", cert.PublicKey)
+	}
+	if err := cert.CheckSignature(alg, signed, cs.Signature); err != nil {
+		return nil, fmt.Errorf("countersignature: %w", err)
+	}
+	return result, nil
+}
+
+func parseRawSignedData(der []byte) (*rawSignedData, error) {
+	var ci contentInfo
+	if _, err := asn1.Unmarshal(der, &ci); err != nil {
+		return nil, fmt.Errorf("unmarshal ContentInfo: %w", err)
+	}
+	if !ci.ContentType.Equal(pkcs7.OIDSignedData) {
+		return nil, fmt.Errorf("content type %s is not signedData", ci.ContentType)
+	}
+	var sd rawSignedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, fmt.Errorf("unmarshal SignedData: %w", err)
+	}
+	if len(sd.SignerInfos) == 0 {
+		return nil, fmt.Errorf("SignedData has no signers")
+	}
+	return &sd, nil
+}
+
+// marshal encodes sd as a ContentInfo, adding those of certs it does not
+// carry yet.
+func (sd *rawSignedData) marshal(certs []*x509.Certificate) ([]byte, error) {
+	have, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse certificates: %w", err)
+	}
+	raw := bytes.Clone(sd.Certificates.Bytes)
+	for _, c := range certs {
+		if !slices.ContainsFunc(have, c.Equal) {
+			raw = append(raw, c.Raw...)
+			have = append(have, c)
+		}
+	}
+	sd.Certificates = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: raw}
+
+	inner, err := asn1.Marshal(*sd)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(contentInfo{
+		ContentType: pkcs7.OIDSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: inner},
+	})
+}
+
+// sidMatches reports whether sid, an IssuerAndSerialNumber, names cert.
+func sidMatches(sid asn1.RawValue, cert *x509.Certificate) bool {
+	var ias issuerAndSerial
+	if _, err := asn1.Unmarshal(sid.FullBytes, &ias); err != nil {
+		return false
+	}
+	return bytes.Equal(ias.IssuerName.FullBytes, cert.RawIssuer) && ias.SerialNumber.Cmp(cert.SerialNumber) == 0
+}
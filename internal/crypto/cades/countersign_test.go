@@ -0,0 +1,120 @@
+package cades
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smallstep/pkcs7"
+)
+
+func TestCounterSign(t *testing.T) {
+	citizenKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collectorKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	citizenCert := selfSignedCert(t, citizenKey, "Citizen")
+	collectorCert := selfSignedCert(t, collectorKey, "Collector")
+
+	content := []byte("<signatura/>")
+	original, err := SignDetached(context.Background(), citizenKey, citizenCert, nil, content, SignOpts{SigningTime: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingTime := time.Now().Truncate(time.Second)
+	countersigned, err := CounterSign(collectorKey, collectorCert, nil, original, signingTime)
+	if err != nil {
+		t.Fatalf("CounterSign failed: %v", err)
+	}
+
+	cs, err := VerifyCounterSignature(original, countersigned)
+	if err != nil {
+		t.Fatalf("VerifyCounterSignature failed: %v", err)
+	}
+	if !cs.Certificate.Equal(collectorCert) {
+		t.Errorf("countersigner = %s, want %s", cs.Certificate.Subject, collectorCert.Subject)
+	}
+	if !cs.SigningTime.Equal(signingTime) {
+		t.Errorf("signing time = %v, want %v", cs.SigningTime, signingTime)
+	}
+
+	// The citizen's signature must still verify in the counter-signed form.
+	p7, err := pkcs7.Parse(countersigned)
+	if err != nil {
+		t.Fatalf("pkcs7.Parse failed: %v", err)
+	}
+	p7.Content = content
+	if err := p7.Verify(); err != nil {
+		t.Fatalf("citizen signature no longer verifies: %v", err)
+	}
+	sum, err := Inspect(countersigned)
+	if err != nil {
+		t.Fatalf("Inspect failed: %v", err)
+	}
+	if !sum.HasCounterSignature || len(sum.CertificateSubjects) != 2 {
+		t.Errorf("unexpected summary: %+v", sum)
+	}
+
+	t.Run("other signature", func(t *testing.T) {
+		other, err := SignDetached(context.Background(), citizenKey, citizenCert, nil, []byte("other"), SignOpts{SigningTime: time.Now()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := VerifyCounterSignature(other, countersigned); err == nil || !strings.Contains(err.Error(), "original signature") {
+			t.Fatalf("expected original signature mismatch, got %v", err)
+		}
+	})
+
+	t.Run("not counter-signed", func(t *testing.T) {
+		if _, err := VerifyCounterSignature(original, original); err == nil || !strings.Contains(err.Error(), "no countersignature") {
+			t.Fatalf("expected missing countersignature, got %v", err)
+		}
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		tampered := append([]byte(nil), countersigned...)
+		tampered[len(tampered)-1] ^= 0xFF
+		if _, err := VerifyCounterSignature(original, tampered); err == nil {
+			t.Fatal("expected error for a tampered countersignature")
+		}
+	})
+}
+
+func TestCounterSign_ChainAdded(t *testing.T) {
+	citizenKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	agentKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	citizenCert := selfSignedCert(t, citizenKey, "Citizen")
+	agentCert := selfSignedCert(t, agentKey, "Agent")
+
+	original, err := SignDetached(context.Background(), citizenKey, citizenCert, nil, []byte("x"), SignOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	countersigned, err := CounterSign(agentKey, agentCert, []*x509.Certificate{citizenCert}, original, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs, err := VerifyCounterSignature(original, countersigned)
+	if err != nil {
+		t.Fatalf("VerifyCounterSignature failed: %v", err)
+	}
+	if !cs.Certificate.Equal(agentCert) {
+		t.Errorf("countersigner = %s", cs.Certificate.Subject)
+	}
+}
@@ -0,0 +1,111 @@
+package cades
+
+import (
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/smallstep/pkcs7"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+)
+
+// Summary is a human-readable decoding of a CAdES signature, used to let
+// users and auditors inspect what was produced before it is submitted.
+type Summary struct {
+	DigestAlgorithms    []string
+	SignatureAlgorithm  string
+	SigningTime         time.Time
+	PolicyOID           string
+	PolicyHash          string   // hex
+	SigningCertHash     string   // hex, from signingCertificateV2
+	SignerSubject       string   // empty when there are several signers
+	SignerSubjects      []string // every signer, in SignerInfo order
+	CertificateSubjects []string
+	HasTimestamp        bool
+	HasCounterSignature bool
+}
+
+var algorithmNames = map[string]string{
+	pkcs7.OIDDigestAlgorithmSHA1.String():          "SHA-1",
+	pkcs7.OIDDigestAlgorithmSHA256.String():        "SHA-256",
+	pkcs7.OIDDigestAlgorithmSHA384.String():        "SHA-384",
+	pkcs7.OIDDigestAlgorithmSHA512.String():        "SHA-512",
+	pkcs7.OIDEncryptionAlgorithmRSA.String():       "RSA",
+	pkcs7.OIDEncryptionAlgorithmRSASHA256.String(): "RSA with SHA-256",
+	pkcs7.OIDEncryptionAlgorithmRSASHA384.String(): "RSA with SHA-384",
+	pkcs7.OIDEncryptionAlgorithmRSASHA512.String(): "RSA with SHA-512",
+	pkcs7.OIDDigestAlgorithmECDSASHA256.String():   "ECDSA with SHA-256",
+	pkcs7.OIDDigestAlgorithmECDSASHA384.String():   "ECDSA with SHA-384",
+	pkcs7.OIDDigestAlgorithmECDSASHA512.String():   "ECDSA with SHA-512",
+	"1.2.840.10045.2.1":                            "ECDSA",
+}
+
+func algorithmName(oid asn1.ObjectIdentifier) string {
+	if name, ok := algorithmNames[oid.String()]; ok {
+		return fmt.Sprintf("%s (%s)", name, oid)
+	}
+	return oid.String()
+}
+
+// Inspect decodes a DER-encoded CAdES signature produced by SignDetached.
+// It does not verify the signature.
+func Inspect(der []byte) (*Summary, error) {
+	p7, err := pkcs7.Parse(der)
+	if err != nil {
+		return nil, errcode.Errorf(errcode.InvalidCMS, "failed to parse CMS: %w", err)
+	}
+	if len(p7.Signers) == 0 {
+		return nil, errcode.Errorf(errcode.InvalidCMS, "signature has no signers")
+	}
+
+	sum := &Summary{}
+	seen := map[string]bool{}
+	for _, si := range p7.Signers {
+		name := algorithmName(si.DigestAlgorithm.Algorithm)
+		if !seen[name] {
+			seen[name] = true
+			sum.DigestAlgorithms = append(sum.DigestAlgorithms, name)
+		}
+	}
+	first := p7.Signers[0]
+	sum.SignatureAlgorithm = algorithmName(first.DigestEncryptionAlgorithm.Algorithm)
+	for _, attr := range first.UnauthenticatedAttributes {
+		if attr.Type.Equal(OidSignatureTimeStampToken) {
+			sum.HasTimestamp = true
+		}
+		if attr.Type.Equal(OidCounterSignature) {
+			sum.HasCounterSignature = true
+		}
+	}
+
+	var signingTime time.Time
+	if err := p7.UnmarshalSignedAttribute(pkcs7.OIDAttributeSigningTime, &signingTime); err == nil {
+		sum.SigningTime = signingTime
+	}
+
+	var signingCert SigningCertificateV2
+	if err := p7.UnmarshalSignedAttribute(OidSigningCertificateV2, &signingCert); err == nil && len(signingCert.Certs) > 0 {
+		sum.SigningCertHash = hex.EncodeToString(signingCert.Certs[0].CertHash)
+	}
+
+	var policy SignaturePolicyIdentifier
+	if err := p7.UnmarshalSignedAttribute(OidSignaturePolicyIdentifier, &policy); err == nil {
+		sum.PolicyOID = policy.SigPolicyID.String()
+		sum.PolicyHash = hex.EncodeToString(policy.SigPolicyHash.HashValue)
+	}
+
+	if signer := p7.GetOnlySigner(); signer != nil {
+		sum.SignerSubject = signer.Subject.String()
+	}
+	for _, c := range SignerCertificates(p7) {
+		if c != nil {
+			sum.SignerSubjects = append(sum.SignerSubjects, c.Subject.String())
+		}
+	}
+	for _, c := range p7.Certificates {
+		sum.CertificateSubjects = append(sum.CertificateSubjects, c.Subject.String())
+	}
+	return sum, nil
+}
@@ -0,0 +1,93 @@
+package cades
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+func TestInspect(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(7),
+		Subject:      pkix.Name{CommonName: "Inspect Signer"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policyHash := sha256.Sum256([]byte("policy"))
+	der, err := SignDetached(context.Background(), key, cert, nil, []byte("content"), SignOpts{
+		SigningTime: time.Now(),
+		Policy: &model.SignPolicy{
+			Mode: "EPES",
+			OID:  "2.16.724.1.3.1.1.2.1.9",
+			Hash: base64.StdEncoding.EncodeToString(policyHash[:]),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum, err := Inspect(der)
+	if err != nil {
+		t.Fatalf("Inspect failed: %v", err)
+	}
+	if len(sum.DigestAlgorithms) != 1 || !strings.HasPrefix(sum.DigestAlgorithms[0], "SHA-256") {
+		t.Errorf("unexpected digest algorithms: %v", sum.DigestAlgorithms)
+	}
+	if !strings.HasPrefix(sum.SignatureAlgorithm, "ECDSA") {
+		t.Errorf("unexpected signature algorithm: %s", sum.SignatureAlgorithm)
+	}
+	if sum.SigningTime.IsZero() {
+		t.Error("expected signing time")
+	}
+	if sum.PolicyOID != "2.16.724.1.3.1.1.2.1.9" {
+		t.Errorf("unexpected policy OID: %s", sum.PolicyOID)
+	}
+	if sum.PolicyHash != hex.EncodeToString(policyHash[:]) {
+		t.Errorf("unexpected policy hash: %s", sum.PolicyHash)
+	}
+	certHash := sha256.Sum256(cert.Raw)
+	if sum.SigningCertHash != hex.EncodeToString(certHash[:]) {
+		t.Errorf("unexpected signing certificate hash: %s", sum.SigningCertHash)
+	}
+	if sum.SignerSubject != "CN=Inspect Signer" {
+		t.Errorf("unexpected signer subject: %s", sum.SignerSubject)
+	}
+	if len(sum.CertificateSubjects) != 1 {
+		t.Errorf("expected one certificate, got %v", sum.CertificateSubjects)
+	}
+	if sum.HasTimestamp {
+		t.Error("did not expect a timestamp")
+	}
+}
+
+func TestInspect_InvalidDER(t *testing.T) {
+	if _, err := Inspect([]byte("not a signature")); err == nil {
+		t.Fatal("expected error for invalid DER")
+	}
+}
@@ -0,0 +1,106 @@
+package cades
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"log"
+
+	"github.com/smallstep/pkcs7"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+)
+
+// AddSigner adds a parallel signature by signer over content to pkcs7DER, a
+// detached signature over the same content, as when a legal guardian signs
+// along with a citizen or two joint representatives sign together. The
+// existing SignerInfos are left untouched.
+func AddSigner(ctx context.Context, signer crypto.Signer, cert *x509.Certificate, chain []*x509.Certificate, pkcs7DER, content []byte, opts SignOpts) ([]byte, error) {
+	digest := sha256.Sum256(content)
+	return AddSignerDigest(ctx, signer, cert, chain, pkcs7DER, digest[:], opts)
+}
+
+// AddSignerDigest is AddSigner for content whose SHA-256 digest has already
+// been computed. It fails if digest is not the one the existing signers
+// signed.
+func AddSignerDigest(ctx context.Context, signer crypto.Signer, cert *x509.Certificate, chain []*x509.Certificate, pkcs7DER, digest []byte, opts SignOpts) ([]byte, error) {
+	sd, err := parseRawSignedData(pkcs7DER)
+	if err != nil {
+		return nil, errcode.Errorf(errcode.SignatureBuild, "failed to parse signature to add a signer to: %w", err)
+	}
+	signed, err := messageDigest(sd.SignerInfos[0])
+	if err != nil {
+		return nil, errcode.Errorf(errcode.SignatureBuild, "failed to read existing signature: %w", err)
+	}
+	if !bytes.Equal(signed, digest) {
+		return nil, errcode.Errorf(errcode.SignatureBuild, "content differs from the content already signed")
+	}
+	for _, si := range sd.SignerInfos {
+		if sidMatches(si.SID, cert) {
+			return nil, errcode.Errorf(errcode.SignatureBuild, "%s has already signed", cert.Subject.CommonName)
+		}
+	}
+
+	attrs, err := signedAttributes(cert, digest, opts)
+	if err != nil {
+		return nil, err
+	}
+	si, err := buildSignerInfo(signer, cert, attrs)
+	if err != nil {
+		return nil, errcode.Errorf(errcode.SignatureBuild, "failed to add signer: %w", err)
+	}
+	siBytes, err := asn1.Marshal(si)
+	if err != nil {
+		return nil, errcode.Errorf(errcode.SignatureBuild, "failed to marshal signer: %w", err)
+	}
+	var raw rawSignerInfo
+	if _, err := asn1.Unmarshal(siBytes, &raw); err != nil {
+		return nil, errcode.Errorf(errcode.SignatureBuild, "failed to marshal signer: %w", err)
+	}
+	sd.SignerInfos = append(sd.SignerInfos, raw)
+
+	out, err := sd.marshal(append([]*x509.Certificate{cert}, chain...))
+	if err != nil {
+		return nil, errcode.Errorf(errcode.SignatureBuild, "failed to marshal signed data: %w", err)
+	}
+	log.Printf("DEBUG: Added signer %s, %d signers, signature size: %d", cert.Subject.CommonName, len(sd.SignerInfos), len(out))
+	return out, nil
+}
+
+// SignerCertificates returns the certificate of each signer of p7, in
+// SignerInfo order, or nil for a signer whose certificate is not included.
+func SignerCertificates(p7 *pkcs7.PKCS7) []*x509.Certificate {
+	out := make([]*x509.Certificate, len(p7.Signers))
+	for i, si := range p7.Signers {
+		for _, c := range p7.Certificates {
+			if bytes.Equal(si.IssuerAndSerialNumber.IssuerName.FullBytes, c.RawIssuer) &&
+				si.IssuerAndSerialNumber.SerialNumber.Cmp(c.SerialNumber) == 0 {
+				out[i] = c
+				break
+			}
+		}
+	}
+	return out
+}
+
+// messageDigest returns the message-digest signed attribute of si.
+func messageDigest(si rawSignerInfo) ([]byte, error) {
+	var attrs []attribute
+	if _, err := asn1.UnmarshalWithParams(si.SignedAttrs.FullBytes, &attrs, "set,tag:0"); err != nil {
+		return nil, fmt.Errorf("unmarshal signed attributes: %w", err)
+	}
+	for _, attr := range attrs {
+		if attr.Type.Equal(pkcs7.OIDAttributeMessageDigest) {
+			var digest []byte
+			if _, err := asn1.Unmarshal(attr.Value.Bytes, &digest); err != nil {
+				return nil, fmt.Errorf("unmarshal message digest: %w", err)
+			}
+			return digest, nil
+		}
+	}
+	return nil, fmt.Errorf("no message digest attribute")
+}
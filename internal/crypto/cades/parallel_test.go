@@ -0,0 +1,86 @@
+package cades
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/smallstep/pkcs7"
+)
+
+func TestAddSigner(t *testing.T) {
+	citizenKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	guardianKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	citizenCert := selfSignedCert(t, citizenKey, "Citizen")
+	guardianCert := selfSignedCert(t, guardianKey, "Guardian")
+	content := []byte("<ILP>signed by two</ILP>")
+	opts := SignOpts{SigningTime: time.Now()}
+
+	citizenSig, err := SignDetached(context.Background(), citizenKey, citizenCert, nil, content, opts)
+	if err != nil {
+		t.Fatalf("SignDetached failed: %v", err)
+	}
+	der, err := AddSigner(context.Background(), guardianKey, guardianCert, nil, citizenSig, content, opts)
+	if err != nil {
+		t.Fatalf("AddSigner failed: %v", err)
+	}
+
+	p7, err := pkcs7.Parse(der)
+	if err != nil {
+		t.Fatalf("pkcs7.Parse failed: %v", err)
+	}
+	if len(p7.Signers) != 2 {
+		t.Fatalf("got %d signers, want 2", len(p7.Signers))
+	}
+	p7.Content = content
+	if err := p7.Verify(); err != nil {
+		t.Fatalf("multi-signer signature does not verify: %v", err)
+	}
+	signers := SignerCertificates(p7)
+	if !signers[0].Equal(citizenCert) || !signers[1].Equal(guardianCert) {
+		t.Errorf("signer certificates out of order: %v", signers)
+	}
+
+	sum, err := Inspect(der)
+	if err != nil {
+		t.Fatalf("Inspect failed: %v", err)
+	}
+	if sum.SignerSubject != "" || len(sum.SignerSubjects) != 2 {
+		t.Errorf("SignerSubject = %q, SignerSubjects = %v", sum.SignerSubject, sum.SignerSubjects)
+	}
+
+	t.Run("different content", func(t *testing.T) {
+		if _, err := AddSigner(context.Background(), guardianKey, guardianCert, nil, citizenSig, []byte("other"), opts); err == nil {
+			t.Fatal("expected error adding a signer over different content")
+		}
+	})
+	t.Run("same signer twice", func(t *testing.T) {
+		if _, err := AddSigner(context.Background(), citizenKey, citizenCert, nil, citizenSig, content, opts); err == nil {
+			t.Fatal("expected error adding the same signer twice")
+		}
+	})
+	t.Run("certificates not duplicated", func(t *testing.T) {
+		again, err := AddSigner(context.Background(), guardianKey, guardianCert, []*x509.Certificate{citizenCert}, citizenSig, content, opts)
+		if err != nil {
+			t.Fatalf("AddSigner failed: %v", err)
+		}
+		p7, err := pkcs7.Parse(again)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(p7.Certificates) != 2 {
+			t.Errorf("got %d certificates, want 2", len(p7.Certificates))
+		}
+	})
+}
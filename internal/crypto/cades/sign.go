@@ -9,12 +9,14 @@ import (
 	"encoding/asn1"
 	"encoding/base64"
 	"fmt"
+	"io"
 	"log"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/smallstep/pkcs7"
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
 	"github.com/vocdoni/gofirma/vocsign/internal/model"
 )
 
@@ -40,64 +42,71 @@ type SignOpts struct {
 // SignDetached creates a CAdES detached signature
 func SignDetached(ctx context.Context, signer crypto.Signer, cert *x509.Certificate, chain []*x509.Certificate, content []byte, opts SignOpts) ([]byte, error) {
 	log.Printf("DEBUG: Starting CAdES detached signing (content len: %d)", len(content))
-	// 1. Initialize SignedData
-	sd, err := pkcs7.NewSignedData(content)
+	digest := sha256.Sum256(content)
+	return SignDetachedDigest(ctx, signer, cert, chain, digest[:], opts)
+}
+
+// SignDetachedReader is SignDetached for content read from r, which is
+// hashed as it streams so large payloads are never held in memory.
+func SignDetachedReader(ctx context.Context, signer crypto.Signer, cert *x509.Certificate, chain []*x509.Certificate, r io.Reader, opts SignOpts) ([]byte, error) {
+	h := sha256.New()
+	n, err := io.Copy(h, r)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create signed data: %w", err)
+		return nil, errcode.Errorf(errcode.SignatureBuild, "failed to read content: %w", err)
 	}
-	sd.SetDigestAlgorithm(pkcs7.OIDDigestAlgorithmSHA256)
-
-	// 2. Prepare SigningCertificateV2 Attribute
-	certHash := sha256.Sum256(cert.Raw)
-	log.Printf("DEBUG: Signer Cert: %s (%x)", cert.Subject.CommonName, certHash[:8])
+	log.Printf("DEBUG: Starting CAdES detached signing (streamed content len: %d)", n)
+	return SignDetachedDigest(ctx, signer, cert, chain, h.Sum(nil), opts)
+}
 
-	// RFC 5035: IssuerSerial SHOULD be present to bind the certificate identity.
-	issuerRDN, err := asn1.Marshal(cert.Issuer.ToRDNSequence())
+// SignDetachedDigest creates a CAdES detached signature over content whose
+// SHA-256 digest has already been computed. The content itself is never
+// needed, and the signer (a PKCS#11 token included) only receives the hash
+// of the signed attributes.
+func SignDetachedDigest(ctx context.Context, signer crypto.Signer, cert *x509.Certificate, chain []*x509.Certificate, digest []byte, opts SignOpts) ([]byte, error) {
+	attrs, err := signedAttributes(cert, digest, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal issuer DN: %w", err)
+		return nil, err
 	}
-	serialBytes, err := asn1.Marshal(cert.SerialNumber)
+	si, err := buildSignerInfo(signer, cert, attrs)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal serial number: %w", err)
+		log.Printf("DEBUG: AddSigner failed: %v", err)
+		return nil, errcode.Errorf(errcode.SignatureBuild, "failed to add signer: %w", err)
 	}
 
-	essCertV2 := ESSCertIDv2{
-		HashAlgorithm: pkix.AlgorithmIdentifier{
-			Algorithm:  OidSHA256,
-			Parameters: asn1.RawValue{Tag: asn1.TagNull}, // Explicit NULL
-		},
-		CertHash: certHash[:],
-		IssuerSerial: IssuerSerial{
-			Issuer: asn1.RawValue{FullBytes: issuerRDN},
-			Serial: asn1.RawValue{FullBytes: serialBytes},
-		},
+	log.Printf("DEBUG: Adding %d certs to chain", len(chain))
+	sigBytes, err := marshalSignedData(si, append([]*x509.Certificate{cert}, chain...))
+	if err != nil {
+		log.Printf("DEBUG: SignedData marshal failed: %v", err)
+		return nil, errcode.Errorf(errcode.SignatureBuild, "failed to finish signature: %w", err)
 	}
 
-	signingCertV2 := SigningCertificateV2{
-		Certs: []ESSCertIDv2{essCertV2},
-	}
+	log.Printf("DEBUG: Signing complete, signature size: %d", len(sigBytes))
+	return sigBytes, nil
+}
 
-	signingCertV2Bytes, err := asn1.Marshal(signingCertV2)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal signingCertificateV2: %w", err)
+// signedAttributes returns the CAdES-EPES signed attributes for a signature
+// by cert over content with the given SHA-256 digest.
+func signedAttributes(cert *x509.Certificate, digest []byte, opts SignOpts) ([]pkcs7.Attribute, error) {
+	if len(digest) != sha256.Size {
+		return nil, errcode.Errorf(errcode.SignatureBuild, "invalid SHA-256 digest length: %d", len(digest))
 	}
 
-	attrs := []pkcs7.Attribute{
-		{
-			Type:  OidSigningCertificateV2,
-			Value: asn1.RawValue{FullBytes: signingCertV2Bytes},
-		},
+	// 1. Prepare SigningCertificateV2 Attribute
+	signingCert, err := signingCertificateAttribute(cert)
+	if err != nil {
+		return nil, err
 	}
+	attrs := []pkcs7.Attribute{signingCert}
 
-	// 2.5 Add SignaturePolicyIdentifier if present
+	// 2. Add SignaturePolicyIdentifier if present
 	if opts.Policy != nil && opts.Policy.OID != "" {
 		policyOID, err := parseOID(opts.Policy.OID)
 		if err != nil {
-			return nil, fmt.Errorf("invalid policy OID %q: %w", opts.Policy.OID, err)
+			return nil, errcode.Errorf(errcode.InvalidPolicy, "invalid policy OID %q: %w", opts.Policy.OID, err)
 		}
 		hashBytes, err := base64.StdEncoding.DecodeString(opts.Policy.Hash)
 		if err != nil {
-			return nil, fmt.Errorf("invalid policy hash base64: %w", err)
+			return nil, errcode.Errorf(errcode.InvalidPolicy, "invalid policy hash base64: %w", err)
 		}
 
 		sigPolicyID := SignaturePolicyIdentifier{
@@ -130,32 +139,58 @@ func SignDetached(ctx context.Context, signer crypto.Signer, cert *x509.Certific
 		})
 	}
 
-	// 3. Add Signer with Attributes
-	config := pkcs7.SignerInfoConfig{
-		ExtraSignedAttributes: attrs,
+	signingTime := opts.SigningTime
+	if signingTime.IsZero() {
+		signingTime = time.Now()
 	}
 
-	if err := sd.AddSigner(cert, signer, config); err != nil {
-		log.Printf("DEBUG: AddSigner failed: %v", err)
-		return nil, fmt.Errorf("failed to add signer: %w", err)
+	// 3. Content type, digest and signing time
+	return append(attrs,
+		pkcs7.Attribute{Type: pkcs7.OIDAttributeContentType, Value: pkcs7.OIDData},
+		pkcs7.Attribute{Type: pkcs7.OIDAttributeMessageDigest, Value: digest},
+		pkcs7.Attribute{Type: pkcs7.OIDAttributeSigningTime, Value: signingTime.UTC()},
+	), nil
+}
+
+// signingCertificateAttribute returns the SigningCertificateV2 attribute
+// binding a signature to cert.
+func signingCertificateAttribute(cert *x509.Certificate) (pkcs7.Attribute, error) {
+	certHash := sha256.Sum256(cert.Raw)
+	log.Printf("DEBUG: Signer Cert: %s (%x)", cert.Subject.CommonName, certHash[:8])
+
+	// RFC 5035: IssuerSerial SHOULD be present to bind the certificate identity.
+	issuerRDN, err := asn1.Marshal(cert.Issuer.ToRDNSequence())
+	if err != nil {
+		return pkcs7.Attribute{}, fmt.Errorf("failed to marshal issuer DN: %w", err)
+	}
+	serialBytes, err := asn1.Marshal(cert.SerialNumber)
+	if err != nil {
+		return pkcs7.Attribute{}, fmt.Errorf("failed to marshal serial number: %w", err)
 	}
 
-	// 4. Add Certificates (Chain)
-	log.Printf("DEBUG: Adding %d certs to chain", len(chain))
-	for _, c := range chain {
-		sd.AddCertificate(c)
+	essCertV2 := ESSCertIDv2{
+		HashAlgorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  OidSHA256,
+			Parameters: asn1.RawValue{Tag: asn1.TagNull}, // Explicit NULL
+		},
+		CertHash: certHash[:],
+		IssuerSerial: IssuerSerial{
+			Issuer: asn1.RawValue{FullBytes: issuerRDN},
+			Serial: asn1.RawValue{FullBytes: serialBytes},
+		},
 	}
 
-	// 5. Detach Content
-	sd.Detach()
+	signingCertV2 := SigningCertificateV2{
+		Certs: []ESSCertIDv2{essCertV2},
+	}
 
-	// 6. Finish (Sign)
-	sigBytes, err := sd.Finish()
+	signingCertV2Bytes, err := asn1.Marshal(signingCertV2)
 	if err != nil {
-		log.Printf("DEBUG: pkcs7.Finish failed: %v", err)
-		return nil, fmt.Errorf("failed to finish signature: %w", err)
+		return pkcs7.Attribute{}, fmt.Errorf("failed to marshal signingCertificateV2: %w", err)
 	}
 
-	log.Printf("DEBUG: Signing complete, signature size: %d", len(sigBytes))
-	return sigBytes, nil
+	return pkcs7.Attribute{
+		Type:  OidSigningCertificateV2,
+		Value: asn1.RawValue{FullBytes: signingCertV2Bytes},
+	}, nil
 }
@@ -0,0 +1,103 @@
+package cades
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/smallstep/pkcs7"
+)
+
+func selfSignedCert(t *testing.T, key crypto.Signer, commonName string) *x509.Certificate {
+	t.Helper()
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestSignDetachedDigest(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := bytes.Repeat([]byte("large batch payload "), 1<<12)
+	digest := sha256.Sum256(content)
+	signingTime := time.Now().Add(-time.Minute).Truncate(time.Second)
+
+	for name, key := range map[string]crypto.Signer{"ECDSA": ecKey, "RSA": rsaKey} {
+		cert := selfSignedCert(t, key, "Digest Signer")
+		sign := map[string]func() ([]byte, error){
+			"digest": func() ([]byte, error) {
+				return SignDetachedDigest(context.Background(), key, cert, nil, digest[:], SignOpts{SigningTime: signingTime})
+			},
+			"reader": func() ([]byte, error) {
+				return SignDetachedReader(context.Background(), key, cert, nil, bytes.NewReader(content), SignOpts{SigningTime: signingTime})
+			},
+		}
+		for path, fn := range sign {
+			t.Run(name+"/"+path, func(t *testing.T) {
+				der, err := fn()
+				if err != nil {
+					t.Fatalf("sign failed: %v", err)
+				}
+				p7, err := pkcs7.Parse(der)
+				if err != nil {
+					t.Fatalf("pkcs7.Parse failed: %v", err)
+				}
+				p7.Content = content
+				if err := p7.Verify(); err != nil {
+					t.Fatalf("signature does not verify against the content: %v", err)
+				}
+				sum, err := Inspect(der)
+				if err != nil {
+					t.Fatalf("Inspect failed: %v", err)
+				}
+				if !sum.SigningTime.Equal(signingTime) {
+					t.Errorf("signing time = %v, want %v", sum.SigningTime, signingTime)
+				}
+			})
+		}
+	}
+}
+
+func TestSignDetachedDigest_InvalidLength(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := selfSignedCert(t, key, "Digest Signer")
+	if _, err := SignDetachedDigest(context.Background(), key, cert, nil, []byte("short"), SignOpts{}); err == nil {
+		t.Fatal("expected error for a digest that is not SHA-256")
+	}
+}
@@ -0,0 +1,162 @@
+package cades
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/smallstep/pkcs7"
+)
+
+// The CMS structures below mirror what pkcs7.SignedData produces for a
+// detached SHA-256 signature. They are built by hand because pkcs7 always
+// hashes the content itself, and SignDetachedDigest only has the digest.
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"optional"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	ContentInfo      contentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type signerInfo struct {
+	Version                   int
+	IssuerAndSerialNumber     issuerAndSerial
+	DigestAlgorithm           pkix.AlgorithmIdentifier
+	AuthenticatedAttributes   []attribute `asn1:"optional,omitempty,tag:0"`
+	DigestEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedDigest           []byte
+}
+
+type issuerAndSerial struct {
+	IssuerName   asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type attribute struct {
+	Type  asn1.ObjectIdentifier
+	Value asn1.RawValue `asn1:"set"`
+}
+
+// buildSignerInfo signs attrs with signer, whose certificate is cert.
+func buildSignerInfo(signer crypto.Signer, cert *x509.Certificate, attrs []pkcs7.Attribute) (signerInfo, error) {
+	sigAlg, err := signatureAlgorithmOID(signer.Public())
+	if err != nil {
+		return signerInfo{}, err
+	}
+
+	sorted, err := sortedAttributes(attrs)
+	if err != nil {
+		return signerInfo{}, err
+	}
+
+	// The signature covers the DER encoding of the attributes as a SET OF,
+	// not the [0] IMPLICIT form they take inside the SignerInfo.
+	encoded, err := asn1.Marshal(struct {
+		A []attribute `asn1:"set"`
+	}{A: sorted})
+	if err != nil {
+		return signerInfo{}, err
+	}
+	var set asn1.RawValue
+	if _, err := asn1.Unmarshal(encoded, &set); err != nil {
+		return signerInfo{}, err
+	}
+	hash := sha256.Sum256(set.Bytes)
+	signature, err := signer.Sign(rand.Reader, hash[:], crypto.SHA256)
+	if err != nil {
+		return signerInfo{}, err
+	}
+
+	return signerInfo{
+		Version: 1,
+		IssuerAndSerialNumber: issuerAndSerial{
+			IssuerName:   asn1.RawValue{FullBytes: cert.RawIssuer},
+			SerialNumber: cert.SerialNumber,
+		},
+		DigestAlgorithm:           pkix.AlgorithmIdentifier{Algorithm: pkcs7.OIDDigestAlgorithmSHA256},
+		AuthenticatedAttributes:   sorted,
+		DigestEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: sigAlg},
+		EncryptedDigest:           signature,
+	}, nil
+}
+
+// sortedAttributes encodes attrs in DER order, as a SET OF requires.
+func sortedAttributes(attrs []pkcs7.Attribute) ([]attribute, error) {
+	type sortable struct {
+		key  []byte
+		attr attribute
+	}
+	out := make([]sortable, 0, len(attrs))
+	for _, a := range attrs {
+		value, err := asn1.Marshal(a.Value)
+		if err != nil {
+			return nil, fmt.Errorf("marshal attribute %s: %w", a.Type, err)
+		}
+		attr := attribute{
+			Type:  a.Type,
+			Value: asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: value},
+		}
+		key, err := asn1.Marshal(attr)
+		if err != nil {
+			return nil, fmt.Errorf("marshal attribute %s: %w", a.Type, err)
+		}
+		out = append(out, sortable{key: key, attr: attr})
+	}
+	slices.SortFunc(out, func(a, b sortable) int { return bytes.Compare(a.key, b.key) })
+
+	sorted := make([]attribute, len(out))
+	for i, s := range out {
+		sorted[i] = s.attr
+	}
+	return sorted, nil
+}
+
+func signatureAlgorithmOID(pub crypto.PublicKey) (asn1.ObjectIdentifier, error) {
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		return pkcs7.OIDEncryptionAlgorithmRSASHA256, nil
+	case *ecdsa.PublicKey:
+		return pkcs7.OIDDigestAlgorithmECDSASHA256, nil
+	}
+	return nil, fmt.Errorf("unsupported signer key type %T", pub)
+}
+
+// marshalSignedData wraps si in a detached SignedData ContentInfo carrying
+// certs.
+func marshalSignedData(si signerInfo, certs []*x509.Certificate) ([]byte, error) {
+	var raw []byte
+	for _, c := range certs {
+		raw = append(raw, c.Raw...)
+	}
+	sd := signedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{{Algorithm: pkcs7.OIDDigestAlgorithmSHA256}},
+		ContentInfo:      contentInfo{ContentType: pkcs7.OIDData},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: raw},
+		SignerInfos:      []signerInfo{si},
+	}
+	inner, err := asn1.Marshal(sd)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(contentInfo{
+		ContentType: pkcs7.OIDSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: inner},
+	})
+}
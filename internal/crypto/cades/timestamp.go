@@ -3,11 +3,19 @@ package cades
 import (
 	"bytes"
 	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/asn1"
+	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"net/http"
 	"time"
+
+	"github.com/smallstep/pkcs7"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
 )
 
 // OID for id-aa-signatureTimeStampToken (RFC 3161 / CAdES-T).
@@ -53,6 +61,15 @@ func RequestTimestamp(tsaURL string, pkcs7DER []byte) ([]byte, error) {
 
 	// Hash the signature value per ETSI EN 319 122-1 section 5.4.
 	hash := sha256.Sum256(sigValue)
+	return RequestTimestampDigest(tsaURL, hash[:])
+}
+
+// RequestTimestampDigest sends an RFC 3161 timestamp request for data whose
+// SHA-256 digest is given and returns the raw TimeStampToken.
+func RequestTimestampDigest(tsaURL string, digest []byte) ([]byte, error) {
+	if len(digest) != sha256.Size {
+		return nil, fmt.Errorf("invalid SHA-256 digest length: %d", len(digest))
+	}
 
 	// Build the SHA-256 AlgorithmIdentifier as raw ASN.1.
 	algID, err := asn1.Marshal(OidSHA256)
@@ -78,7 +95,7 @@ func RequestTimestamp(tsaURL string, pkcs7DER []byte) ([]byte, error) {
 		Version: 1,
 		MessageImprint: messageImprint{
 			HashAlgorithm: asn1.RawValue{FullBytes: hashAlgBytes},
-			HashedMessage: hash[:],
+			HashedMessage: digest,
 		},
 		CertReq: true,
 	}
@@ -92,12 +109,12 @@ func RequestTimestamp(tsaURL string, pkcs7DER []byte) ([]byte, error) {
 	client := &http.Client{Timeout: 15 * time.Second}
 	resp, err := client.Post(tsaURL, "application/timestamp-query", bytes.NewReader(reqDER))
 	if err != nil {
-		return nil, fmt.Errorf("TSA request failed: %w", err)
+		return nil, errcode.Errorf(errcode.TSAFailed, "TSA request failed: %w", errcode.Network(err))
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("TSA returned status %d", resp.StatusCode)
+		return nil, errcode.Errorf(errcode.TSAFailed, "TSA returned status %d", resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20)) // 1 MB limit
@@ -108,21 +125,102 @@ func RequestTimestamp(tsaURL string, pkcs7DER []byte) ([]byte, error) {
 	// Parse the TimeStampResp to extract the TimeStampToken.
 	var tsResp timeStampResp
 	if _, err := asn1.Unmarshal(body, &tsResp); err != nil {
-		return nil, fmt.Errorf("unmarshal timestamp response: %w", err)
+		return nil, errcode.Errorf(errcode.TSAFailed, "unmarshal timestamp response: %w", err)
 	}
 
 	// Status 0 = granted, 1 = grantedWithMods.
 	if tsResp.Status.Status > 1 {
-		return nil, fmt.Errorf("TSA rejected request with status %d", tsResp.Status.Status)
+		return nil, errcode.Errorf(errcode.TSAFailed, "TSA rejected request with status %d", tsResp.Status.Status)
 	}
 
 	if len(tsResp.TimeStampToken.FullBytes) == 0 {
-		return nil, fmt.Errorf("TSA response contains no timestamp token")
+		return nil, errcode.Errorf(errcode.TSAFailed, "TSA response contains no timestamp token")
 	}
 
 	return tsResp.TimeStampToken.FullBytes, nil
 }
 
+// tstInfo is the RFC 3161 TSTInfo signed by the TSA.
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint tstMessageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time     `asn1:"generalized"`
+	Accuracy       asn1.RawValue `asn1:"optional"`
+	Ordering       bool          `asn1:"optional,default:false"`
+	Nonce          *big.Int      `asn1:"optional"`
+	TSA            asn1.RawValue `asn1:"optional,explicit,tag:0"`
+	Extensions     asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type tstMessageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+// VerifyTimestamp checks that token, an RFC 3161 TimeStampToken, is signed
+// by the TSA certificate it carries and covers the signature value of the
+// CAdES signature pkcs7DER, as produced by RequestTimestamp. It returns the
+// time asserted by the TSA. Whether the TSA itself is trusted is left to the
+// caller.
+func VerifyTimestamp(token, pkcs7DER []byte) (time.Time, error) {
+	sigValue, err := extractSignatureValue(pkcs7DER)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("extract signature value: %w", err)
+	}
+	hash := sha256.Sum256(sigValue)
+	return VerifyTimestampDigest(token, hash[:])
+}
+
+// VerifyTimestampDigest is VerifyTimestamp for a token requested with
+// RequestTimestampDigest over digest.
+func VerifyTimestampDigest(token, digest []byte) (time.Time, error) {
+	p7, err := pkcs7.Parse(token)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse timestamp token: %w", err)
+	}
+	if err := p7.Verify(); err != nil {
+		return time.Time{}, fmt.Errorf("timestamp token signature: %w", err)
+	}
+	tsa := p7.GetOnlySigner()
+	if tsa == nil {
+		return time.Time{}, errors.New("timestamp token must have exactly one signer")
+	}
+	if err := checkTSACert(tsa); err != nil {
+		return time.Time{}, err
+	}
+	var info tstInfo
+	if _, err := asn1.Unmarshal(p7.Content, &info); err != nil {
+		return time.Time{}, fmt.Errorf("unmarshal TSTInfo: %w", err)
+	}
+	if !info.MessageImprint.HashAlgorithm.Algorithm.Equal(OidSHA256) {
+		return time.Time{}, fmt.Errorf("unsupported timestamp hash algorithm %s", info.MessageImprint.HashAlgorithm.Algorithm)
+	}
+	if !bytes.Equal(info.MessageImprint.HashedMessage, digest) {
+		return time.Time{}, fmt.Errorf("timestamp does not cover this data")
+	}
+	return info.GenTime, nil
+}
+
+// oidExtKeyUsage is the extended key usage certificate extension.
+var oidExtKeyUsage = asn1.ObjectIdentifier{2, 5, 29, 37}
+
+// checkTSACert checks that cert may sign timestamps: RFC 3161 section 2.3
+// requires its extended key usage extension to be critical and to hold
+// timeStamping only.
+func checkTSACert(cert *x509.Certificate) error {
+	if len(cert.ExtKeyUsage) != 1 || cert.ExtKeyUsage[0] != x509.ExtKeyUsageTimeStamping || len(cert.UnknownExtKeyUsage) != 0 {
+		return errors.New("timestamp signer certificate is not a TSA certificate: extended key usage must be timeStamping only")
+	}
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oidExtKeyUsage) && !ext.Critical {
+			return errors.New("timestamp signer certificate is not a TSA certificate: extended key usage is not critical")
+		}
+	}
+	return nil
+}
+
 // extractSignatureValue parses a PKCS#7 DER structure and returns the
 // EncryptedDigest (signature value) from the first SignerInfo.
 //
@@ -5,11 +5,16 @@ import (
 	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
 	"math/big"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/smallstep/pkcs7"
 )
 
 func TestExtractSignatureValue(t *testing.T) {
@@ -63,3 +68,120 @@ func TestExtractSignatureValue_InvalidDER(t *testing.T) {
 		t.Fatal("expected error for invalid DER, got nil")
 	}
 }
+
+func TestVerifyTimestamp(t *testing.T) {
+	// timeStamping is the extended key usage of a TSA certificate, marked
+	// critical as RFC 3161 requires; Go writes ExtKeyUsage as non-critical.
+	ekuValue, err := asn1.Marshal([]asn1.ObjectIdentifier{{1, 3, 6, 1, 5, 5, 7, 3, 8}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	timeStamping := pkix.Extension{Id: oidExtKeyUsage, Critical: true, Value: ekuValue}
+	newCert := func(cn string, exts ...pkix.Extension) (*rsa.PrivateKey, *x509.Certificate) {
+		t.Helper()
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tmpl := &x509.Certificate{
+			SerialNumber:    big.NewInt(1),
+			Subject:         pkix.Name{CommonName: cn},
+			NotBefore:       time.Now().Add(-time.Hour),
+			NotAfter:        time.Now().Add(time.Hour),
+			KeyUsage:        x509.KeyUsageDigitalSignature,
+			ExtraExtensions: exts,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key, cert
+	}
+	signerKey, signerCert := newCert("Signer")
+	tsaKey, tsaCert := newCert("TSA", timeStamping)
+	plainKey, plainCert := newCert("Not a TSA")
+	nonCritical := timeStamping
+	nonCritical.Critical = false
+	laxKey, laxCert := newCert("Lax TSA", nonCritical)
+
+	sign := func(content string) []byte {
+		t.Helper()
+		der, err := SignDetached(context.Background(), signerKey, signerCert, nil, []byte(content), SignOpts{SigningTime: time.Now()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der
+	}
+	signature := sign("signed content")
+	other := sign("other content")
+
+	genTime := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tokenBy := func(key *rsa.PrivateKey, cert *x509.Certificate, imprintOf []byte) []byte {
+		t.Helper()
+		sigValue, err := extractSignatureValue(imprintOf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hash := sha256.Sum256(sigValue)
+		info, err := asn1.Marshal(tstInfo{
+			Version:        1,
+			Policy:         asn1.ObjectIdentifier{1, 2, 3, 4},
+			MessageImprint: tstMessageImprint{HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: OidSHA256}, HashedMessage: hash[:]},
+			SerialNumber:   big.NewInt(42),
+			GenTime:        genTime,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sd, err := pkcs7.NewSignedData(info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sd.AddSigner(cert, key, pkcs7.SignerInfoConfig{}); err != nil {
+			t.Fatal(err)
+		}
+		der, err := sd.Finish()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der
+	}
+	token := func(imprintOf []byte) []byte {
+		t.Helper()
+		return tokenBy(tsaKey, tsaCert, imprintOf)
+	}
+
+	tests := []struct {
+		name      string
+		token     []byte
+		signature []byte
+		wantErr   string
+	}{
+		{"valid", token(signature), signature, ""},
+		{"other signature", token(other), signature, "does not cover"},
+		{"garbage token", []byte("not a token"), signature, "parse timestamp token"},
+		{"signer without timeStamping", tokenBy(plainKey, plainCert, signature), signature, "not a TSA certificate"},
+		{"non-critical timeStamping", tokenBy(laxKey, laxCert, signature), signature, "not critical"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := VerifyTimestamp(tt.token, tt.signature)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(genTime) {
+				t.Errorf("time = %v, want %v", got, genTime)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package certs
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"sync"
+)
+
+// maxCachedCerts bounds the parse cache. A wallet holds a handful of
+// certificates, but system store scans can see many more, so the cache is
+// simply emptied when it fills up.
+const maxCachedCerts = 512
+
+var parseCache = struct {
+	sync.Mutex
+	certs map[[32]byte]*x509.Certificate
+}{certs: make(map[[32]byte]*x509.Certificate)}
+
+var extractCache = struct {
+	sync.Mutex
+	infos map[[32]byte]ExtractedInfo
+}{infos: make(map[[32]byte]ExtractedInfo)}
+
+// ParseCertificate is x509.ParseCertificate with a process-wide cache keyed
+// by the SHA-256 fingerprint of der. The same certificate is parsed by the
+// wallet, the system store scans and the UI, so parsing it once saves
+// noticeable CPU on slow machines. The returned certificate is shared and
+// must not be modified.
+func ParseCertificate(der []byte) (*x509.Certificate, error) {
+	fp := sha256.Sum256(der)
+
+	parseCache.Lock()
+	cert, ok := parseCache.certs[fp]
+	parseCache.Unlock()
+	if ok {
+		return cert, nil
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	parseCache.Lock()
+	if len(parseCache.certs) >= maxCachedCerts {
+		parseCache.certs = make(map[[32]byte]*x509.Certificate)
+	}
+	parseCache.certs[fp] = cert
+	parseCache.Unlock()
+	return cert, nil
+}
+
+// CachedSpanishIdentity is ExtractSpanishIdentity memoized by certificate
+// fingerprint, for layout code that needs the holder details every frame.
+// Certificates without a DER encoding are extracted without caching.
+func CachedSpanishIdentity(cert *x509.Certificate) ExtractedInfo {
+	if len(cert.Raw) == 0 {
+		return ExtractSpanishIdentity(cert)
+	}
+	fp := sha256.Sum256(cert.Raw)
+
+	extractCache.Lock()
+	info, ok := extractCache.infos[fp]
+	extractCache.Unlock()
+	if !ok {
+		info = ExtractSpanishIdentity(cert)
+		extractCache.Lock()
+		if len(extractCache.infos) >= maxCachedCerts {
+			extractCache.infos = make(map[[32]byte]ExtractedInfo)
+		}
+		extractCache.infos[fp] = info
+		extractCache.Unlock()
+	}
+	// Callers may modify the returned value.
+	info.Cognoms = append([]string(nil), info.Cognoms...)
+	return info
+}
@@ -0,0 +1,54 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestCertificateCaches(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Cache Test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := ParseCertificate(append([]byte(nil), der...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Fatal("same DER parsed twice")
+	}
+	if first.Subject.CommonName != "Cache Test" {
+		t.Fatalf("CommonName = %q", first.Subject.CommonName)
+	}
+	if _, err := ParseCertificate([]byte("not a certificate")); err == nil {
+		t.Fatal("expected error for invalid DER")
+	}
+
+	info := CachedSpanishIdentity(first)
+	info.Cognoms[0] = "MODIFIED"
+	again := CachedSpanishIdentity(first)
+	if len(again.Cognoms) != 1 || again.Cognoms[0] != "Test" || again.Nom != "Cache" {
+		t.Fatalf("cached extraction = %+v", again)
+	}
+}
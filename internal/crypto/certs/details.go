@@ -0,0 +1,238 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Details describes a certificate field by field, for the user to send to
+// the CA's support when a certificate does not work. It holds only public
+// data: the certificate, not its key.
+type Details struct {
+	Subject            string      `json:"subject"`
+	SubjectAttributes  []Attribute `json:"subjectAttributes"`
+	Issuer             string      `json:"issuer"`
+	SerialNumber       string      `json:"serialNumber"` // hex
+	NotBefore          string      `json:"notBefore"`    // RFC 3339
+	NotAfter           string      `json:"notAfter"`     // RFC 3339
+	SignatureAlgorithm string      `json:"signatureAlgorithm"`
+	PublicKeyAlgorithm string      `json:"publicKeyAlgorithm"`
+	PublicKeyBits      int         `json:"publicKeyBits,omitempty"`
+	KeyUsage           []string    `json:"keyUsage,omitempty"`
+	ExtKeyUsage        []string    `json:"extKeyUsage,omitempty"`
+	Policies           []string    `json:"policies,omitempty"`
+	EmailAddresses     []string    `json:"emailAddresses,omitempty"`
+	CRLDistribution    []string    `json:"crlDistributionPoints,omitempty"`
+	OCSPServers        []string    `json:"ocspServers,omitempty"`
+	IssuerURLs         []string    `json:"issuingCertificateUrls,omitempty"`
+	SubjectKeyID       string      `json:"subjectKeyId,omitempty"`   // hex
+	AuthorityKeyID     string      `json:"authorityKeyId,omitempty"` // hex
+	Extensions         []Extension `json:"extensions"`
+	// Representative is how VocSign classified the certificate.
+	Representative bool   `json:"representative"`
+	SHA256         string `json:"sha256Fingerprint"`
+	SHA1           string `json:"sha1Fingerprint"`
+	// Chain lists the certificates above it as found with it, nearest
+	// first.
+	Chain []ChainEntry `json:"chain,omitempty"`
+}
+
+// Attribute is a subject attribute, with the name of its type when known.
+type Attribute struct {
+	OID   string `json:"oid"`
+	Name  string `json:"name,omitempty"`
+	Value string `json:"value"`
+}
+
+// Extension is a certificate extension, with its name when known.
+type Extension struct {
+	OID      string `json:"oid"`
+	Name     string `json:"name,omitempty"`
+	Critical bool   `json:"critical"`
+}
+
+// ChainEntry is a certificate of the chain.
+type ChainEntry struct {
+	Subject  string `json:"subject"`
+	Issuer   string `json:"issuer"`
+	NotAfter string `json:"notAfter"`
+	SHA256   string `json:"sha256Fingerprint"`
+}
+
+var attributeNames = map[string]string{
+	"2.5.4.3":                    "commonName",
+	"2.5.4.4":                    "surname",
+	"2.5.4.5":                    "serialNumber",
+	"2.5.4.6":                    "country",
+	"2.5.4.7":                    "locality",
+	"2.5.4.8":                    "state",
+	"2.5.4.10":                   "organization",
+	"2.5.4.11":                   "organizationalUnit",
+	"2.5.4.12":                   "title",
+	"2.5.4.13":                   "description",
+	"2.5.4.42":                   "givenName",
+	"2.5.4.97":                   "organizationIdentifier",
+	"1.2.840.113549.1.9.1":       "emailAddress",
+	"1.3.6.1.4.1.18838.1.1":      "idCAT",
+	"2.5.4.65":                   "pseudonym",
+	"1.3.6.1.5.5.7.9.1":          "dateOfBirth",
+	"0.9.2342.19200300.100.1.25": "domainComponent",
+}
+
+var extensionNames = map[string]string{
+	"2.5.29.9":          "subjectDirectoryAttributes",
+	"2.5.29.14":         "subjectKeyIdentifier",
+	"2.5.29.15":         "keyUsage",
+	"2.5.29.17":         "subjectAltName",
+	"2.5.29.19":         "basicConstraints",
+	"2.5.29.31":         "cRLDistributionPoints",
+	"2.5.29.32":         "certificatePolicies",
+	"2.5.29.35":         "authorityKeyIdentifier",
+	"2.5.29.37":         "extKeyUsage",
+	"1.3.6.1.5.5.7.1.1": "authorityInfoAccess",
+	"1.3.6.1.5.5.7.1.3": "qcStatements",
+}
+
+// Describe returns the details of cert, with chain as found with it.
+func Describe(cert *x509.Certificate, chain []*x509.Certificate) Details {
+	d := Details{
+		Subject:            cert.Subject.String(),
+		Issuer:             cert.Issuer.String(),
+		SerialNumber:       cert.SerialNumber.Text(16),
+		NotBefore:          cert.NotBefore.UTC().Format(time.RFC3339),
+		NotAfter:           cert.NotAfter.UTC().Format(time.RFC3339),
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+		PublicKeyAlgorithm: cert.PublicKeyAlgorithm.String(),
+		PublicKeyBits:      publicKeyBits(cert.PublicKey),
+		KeyUsage:           KeyUsageNames(cert),
+		ExtKeyUsage:        ExtKeyUsageNames(cert),
+		EmailAddresses:     cert.EmailAddresses,
+		CRLDistribution:    cert.CRLDistributionPoints,
+		OCSPServers:        cert.OCSPServer,
+		IssuerURLs:         cert.IssuingCertificateURL,
+		SubjectKeyID:       hex.EncodeToString(cert.SubjectKeyId),
+		AuthorityKeyID:     hex.EncodeToString(cert.AuthorityKeyId),
+		Representative:     CachedSpanishIdentity(cert).IsRepresentative,
+		SHA256:             sha256Hex(cert.Raw),
+		SHA1:               sha1Hex(cert.Raw),
+		Extensions:         []Extension{},
+		SubjectAttributes:  []Attribute{},
+	}
+	for _, n := range cert.Subject.Names {
+		oid := n.Type.String()
+		d.SubjectAttributes = append(d.SubjectAttributes, Attribute{OID: oid, Name: attributeNames[oid], Value: fmt.Sprint(n.Value)})
+	}
+	for _, p := range cert.PolicyIdentifiers {
+		d.Policies = append(d.Policies, p.String())
+	}
+	for _, e := range cert.Extensions {
+		oid := e.Id.String()
+		d.Extensions = append(d.Extensions, Extension{OID: oid, Name: extensionNames[oid], Critical: e.Critical})
+	}
+	for _, c := range chain {
+		d.Chain = append(d.Chain, ChainEntry{
+			Subject:  c.Subject.String(),
+			Issuer:   c.Issuer.String(),
+			NotAfter: c.NotAfter.UTC().Format(time.RFC3339),
+			SHA256:   sha256Hex(c.Raw),
+		})
+	}
+	return d
+}
+
+func publicKeyBits(pub any) int {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return k.N.BitLen()
+	case *ecdsa.PublicKey:
+		return k.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return 256
+	}
+	return 0
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func sha1Hex(b []byte) string {
+	sum := sha1.Sum(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// Text writes d as "Field: value" lines, for pasting into an email.
+func (d Details) Text() string {
+	var b strings.Builder
+	line := func(label, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%s: %s\n", label, value)
+		}
+	}
+	list := func(label string, values []string) {
+		line(label, strings.Join(values, ", "))
+	}
+	line("Subject", d.Subject)
+	for _, a := range d.SubjectAttributes {
+		name := a.Name
+		if name == "" {
+			name = a.OID
+		}
+		line("  "+name, a.Value)
+	}
+	line("Issuer", d.Issuer)
+	line("Serial number", d.SerialNumber)
+	line("Valid from", d.NotBefore)
+	line("Valid until", d.NotAfter)
+	line("Signature algorithm", d.SignatureAlgorithm)
+	key := d.PublicKeyAlgorithm
+	if d.PublicKeyBits > 0 {
+		key += fmt.Sprintf(" (%d bits)", d.PublicKeyBits)
+	}
+	line("Public key", key)
+	list("Key usage", d.KeyUsage)
+	list("Extended key usage", d.ExtKeyUsage)
+	list("Policies", d.Policies)
+	list("Email addresses", d.EmailAddresses)
+	list("CRL distribution points", d.CRLDistribution)
+	list("OCSP servers", d.OCSPServers)
+	list("Issuer certificate URLs", d.IssuerURLs)
+	line("Subject key ID", d.SubjectKeyID)
+	line("Authority key ID", d.AuthorityKeyID)
+	if len(d.Extensions) > 0 {
+		b.WriteString("Extensions:\n")
+		for _, e := range d.Extensions {
+			name := e.OID
+			if e.Name != "" {
+				name = e.Name + " (" + e.OID + ")"
+			}
+			if e.Critical {
+				name += ", critical"
+			}
+			b.WriteString("  " + name + "\n")
+		}
+	}
+	kind := "personal"
+	if d.Representative {
+		kind = "representative"
+	}
+	line("Type (as read by VocSign)", kind)
+	line("SHA-256 fingerprint", d.SHA256)
+	line("SHA-1 fingerprint", d.SHA1)
+	for i, c := range d.Chain {
+		fmt.Fprintf(&b, "Chain %d: %s\n", i+1, c.Subject)
+		line("  Issuer", c.Issuer)
+		line("  Valid until", c.NotAfter)
+		line("  SHA-256 fingerprint", c.SHA256)
+	}
+	return b.String()
+}
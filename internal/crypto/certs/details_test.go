@@ -0,0 +1,65 @@
+package certs
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/testutil/certfixtures"
+)
+
+func TestDescribe(t *testing.T) {
+	id := certfixtures.New(t, certfixtures.Options{
+		Key:                   certfixtures.ECDSA,
+		Subject:               certfixtures.CitizenSubject(certfixtures.Person{GivenName: "MARIA", Surname1: "PUIG", Surname2: "SOLER", ID: "12345678Z"}),
+		NotBefore:             time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:              time.Date(2029, 1, 1, 0, 0, 0, 0, time.UTC),
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageEmailProtection, x509.ExtKeyUsageClientAuth},
+		CRLDistributionPoints: []string{"http://crl.example.com/ca.crl"},
+		OCSPServer:            []string{"http://ocsp.example.com"},
+	})
+
+	d := Describe(id.Cert, id.Chain)
+	if d.SerialNumber != id.Cert.SerialNumber.Text(16) || d.NotAfter != "2029-01-01T00:00:00Z" || d.PublicKeyBits != 256 {
+		t.Errorf("Describe = %+v", d)
+	}
+	if strings.Join(d.KeyUsage, ",") != "digitalSignature,contentCommitment" {
+		t.Errorf("KeyUsage = %v", d.KeyUsage)
+	}
+	if strings.Join(d.ExtKeyUsage, ",") != "emailProtection,clientAuth" {
+		t.Errorf("ExtKeyUsage = %v", d.ExtKeyUsage)
+	}
+	if d.Representative {
+		t.Error("personal certificate described as representative")
+	}
+	if len(d.SHA256) != 64 || len(d.SHA1) != 40 || len(d.Chain) != 1 || d.Chain[0].SHA256 != sha256Hex(id.Chain[0].Raw) {
+		t.Errorf("fingerprints or chain: %+v", d)
+	}
+	var named bool
+	for _, e := range d.Extensions {
+		if e.Name == "keyUsage" && e.Critical {
+			named = true
+		}
+	}
+	if !named {
+		t.Errorf("keyUsage extension not named or not critical: %+v", d.Extensions)
+	}
+
+	text := d.Text()
+	for _, want := range []string{"givenName: MARIA", "serialNumber: IDCES-12345678Z", "Valid until: 2029-01-01T00:00:00Z", "OCSP servers: http://ocsp.example.com", "SHA-256 fingerprint: " + d.SHA256, "Chain 1:"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Text missing %q:\n%s", want, text)
+		}
+	}
+
+	raw, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back Details
+	if err := json.Unmarshal(raw, &back); err != nil || back.SHA256 != d.SHA256 || len(back.SubjectAttributes) != len(d.SubjectAttributes) {
+		t.Errorf("JSON round trip = %+v, %v", back, err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/x509"
 	"encoding/asn1"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -199,6 +200,37 @@ func extractID(s string) (id string, idType string) {
 	}
 }
 
+// ParsePersonalID normalizes a DNI or NIE typed by hand and returns it with
+// its type, or empty strings if s is neither.
+func ParsePersonalID(s string) (id string, idType string) {
+	id, idType = extractID(strings.ReplaceAll(s, "-", ""))
+	if !isPersonalID(id) {
+		return "", ""
+	}
+	return id, idType
+}
+
+// dniLetters maps a DNI number modulo 23 to its control letter.
+const dniLetters = "TRWAGMYFPDXBNJZSQVHLCKE"
+
+// DNILetter returns the control letter of the DNI number n.
+func DNILetter(n int) byte {
+	return dniLetters[n%23]
+}
+
+// ValidControlLetter reports whether id, a DNI or NIE as returned by
+// ParsePersonalID, ends in the control letter of its number. A NIE's X, Y
+// or Z counts as the digit 0, 1 or 2. A digit mistyped while transcribing
+// an ID almost always changes the letter it needs.
+func ValidControlLetter(id string) bool {
+	if len(id) != 9 || !isPersonalID(id) {
+		return false
+	}
+	digits := strings.NewReplacer("X", "0", "Y", "1", "Z", "2").Replace(id[:1]) + id[1:len(id)-1]
+	n, err := strconv.Atoi(digits)
+	return err == nil && DNILetter(n) == id[len(id)-1]
+}
+
 func isPersonalID(id string) bool {
 	return reDNI.MatchString(id) || reNIE.MatchString(id)
 }
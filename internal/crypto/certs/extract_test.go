@@ -184,3 +184,45 @@ func buildTestSDA(t *testing.T, dateYYYYMMDD string) []byte {
 	}
 	return result
 }
+
+func TestParsePersonalID(t *testing.T) {
+	tests := []struct {
+		in, wantID, wantType string
+	}{
+		{"12345678Z", "12345678Z", "DNI"},
+		{" 12345678-z ", "12345678Z", "DNI"},
+		{"x1234567l", "X1234567L", "NIE"},
+		{"B12345678", "", ""},
+		{"1234", "", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		id, idType := ParsePersonalID(tt.in)
+		if id != tt.wantID || idType != tt.wantType {
+			t.Errorf("ParsePersonalID(%q) = %q, %q; want %q, %q", tt.in, id, idType, tt.wantID, tt.wantType)
+		}
+	}
+}
+
+func TestValidControlLetter(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"12345678Z", true},
+		{"00000000T", true},
+		{"X1234567L", true},
+		{"Y9876543N", true},
+		{"Z9876543A", true},
+		{"12345679Z", false}, // one digit mistyped
+		{"12345678A", false},
+		{"X1234567A", false},
+		{"B12345678", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := ValidControlLetter(tt.id); got != tt.want {
+			t.Errorf("ValidControlLetter(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package certs
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+)
+
+// ErrNoContentCommitment is returned by LegalSignatureUsage for certificates
+// whose key usage does not include nonRepudiation (contentCommitment), which
+// qualified signatures of a legal statement require.
+var ErrNoContentCommitment = errors.New("key usage lacks nonRepudiation (contentCommitment)")
+
+var keyUsageNames = []struct {
+	bit  x509.KeyUsage
+	name string
+}{
+	{x509.KeyUsageDigitalSignature, "digitalSignature"},
+	{x509.KeyUsageContentCommitment, "contentCommitment"},
+	{x509.KeyUsageKeyEncipherment, "keyEncipherment"},
+	{x509.KeyUsageDataEncipherment, "dataEncipherment"},
+	{x509.KeyUsageKeyAgreement, "keyAgreement"},
+	{x509.KeyUsageCertSign, "keyCertSign"},
+	{x509.KeyUsageCRLSign, "cRLSign"},
+	{x509.KeyUsageEncipherOnly, "encipherOnly"},
+	{x509.KeyUsageDecipherOnly, "decipherOnly"},
+}
+
+var extKeyUsageNames = map[x509.ExtKeyUsage]string{
+	x509.ExtKeyUsageAny:             "any",
+	x509.ExtKeyUsageServerAuth:      "serverAuth",
+	x509.ExtKeyUsageClientAuth:      "clientAuth",
+	x509.ExtKeyUsageCodeSigning:     "codeSigning",
+	x509.ExtKeyUsageEmailProtection: "emailProtection",
+	x509.ExtKeyUsageTimeStamping:    "timeStamping",
+	x509.ExtKeyUsageOCSPSigning:     "OCSPSigning",
+}
+
+// KeyUsageNames returns the key usage bits of cert by their RFC 5280 names.
+func KeyUsageNames(cert *x509.Certificate) []string {
+	var names []string
+	for _, u := range keyUsageNames {
+		if cert.KeyUsage&u.bit != 0 {
+			names = append(names, u.name)
+		}
+	}
+	return names
+}
+
+// ExtKeyUsageNames returns the extended key usages of cert by name, and
+// unknown ones by OID.
+func ExtKeyUsageNames(cert *x509.Certificate) []string {
+	var names []string
+	for _, u := range cert.ExtKeyUsage {
+		name, ok := extKeyUsageNames[u]
+		if !ok {
+			name = fmt.Sprintf("extKeyUsage(%d)", u)
+		}
+		names = append(names, name)
+	}
+	for _, u := range cert.UnknownExtKeyUsage {
+		names = append(names, u.String())
+	}
+	return names
+}
+
+// LegalSignatureUsage checks that the key usage of cert allows signing a
+// legal statement. A certificate without the key usage extension is not
+// restricted, as in ValidateForSigning; one that has it must include
+// nonRepudiation (contentCommitment). Certificates that fail this can still
+// sign, but their signature may be refused as a legal signature.
+func LegalSignatureUsage(cert *x509.Certificate) error {
+	if cert.KeyUsage != 0 && cert.KeyUsage&x509.KeyUsageContentCommitment == 0 {
+		return ErrNoContentCommitment
+	}
+	return nil
+}
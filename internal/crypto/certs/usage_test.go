@@ -0,0 +1,44 @@
+package certs
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestKeyUsageNames(t *testing.T) {
+	cert := &x509.Certificate{
+		KeyUsage:           x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:        []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		UnknownExtKeyUsage: []asn1.ObjectIdentifier{{1, 3, 6, 1, 4, 1, 311, 10, 3, 12}},
+	}
+	if got := strings.Join(KeyUsageNames(cert), ","); got != "digitalSignature,keyEncipherment" {
+		t.Errorf("KeyUsageNames = %s", got)
+	}
+	if got := strings.Join(ExtKeyUsageNames(cert), ","); got != "clientAuth,1.3.6.1.4.1.311.10.3.12" {
+		t.Errorf("ExtKeyUsageNames = %s", got)
+	}
+}
+
+func TestLegalSignatureUsage(t *testing.T) {
+	tests := []struct {
+		name  string
+		usage x509.KeyUsage
+		want  error
+	}{
+		{"no key usage extension", 0, nil},
+		{"nonRepudiation", x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment, nil},
+		{"nonRepudiation only", x509.KeyUsageContentCommitment, nil},
+		{"digitalSignature only", x509.KeyUsageDigitalSignature, ErrNoContentCommitment},
+		{"encipherment", x509.KeyUsageKeyEncipherment, ErrNoContentCommitment},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := LegalSignatureUsage(&x509.Certificate{KeyUsage: tt.usage}); !errors.Is(err, tt.want) {
+				t.Errorf("LegalSignatureUsage = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -5,28 +5,17 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
-	"encoding/pem"
 	"math/big"
-	"os"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/testutil/certfixtures"
 )
 
 func TestValidateForSigning_ValidCert(t *testing.T) {
-	data, err := os.ReadFile("../../../test/certs/user.crt")
-	if err != nil {
-		t.Fatalf("failed to read test cert: %v", err)
-	}
-	block, _ := pem.Decode(data)
-	if block == nil {
-		t.Fatal("failed to decode PEM block")
-	}
-	cert, err := x509.ParseCertificate(block.Bytes)
-	if err != nil {
-		t.Fatalf("failed to parse certificate: %v", err)
-	}
-	if err := ValidateForSigning(cert, nil); err != nil {
+	id := certfixtures.New(t, certfixtures.Options{})
+	if err := ValidateForSigning(id.Cert, nil); err != nil {
 		t.Fatalf("expected valid cert to pass, got: %v", err)
 	}
 }
@@ -0,0 +1,112 @@
+package jwsverify
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/canon"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+var fuzzRequestSeeds = []string{
+	`{"version":"1.0","requestId":"req-1"}`,
+	`{"version":"1.0","requestId":"req-2","issuedAt":"2026-01-01T00:00:00Z","expiresAt":"2026-12-31T23:59:59Z","nonce":"bm9uY2U",` +
+		`"proposal":{"title":"Iniciativa legislativa popular","promoter":"Comissió Promotora","jurisdiction":"Catalunya","summary":"<b>Resum</b> & més",` +
+		`"legalStatement":"Dono suport a la iniciativa","fullText":{"url":"https://example.com/text.pdf","sha256":"abc"},"documentVersion":2},` +
+		`"callback":{"url":"https://example.com/sign","method":"POST"},"policy":{"mode":"explicit","acknowledgement":{"text":"D'acord","initials":true}}}`,
+	`{"version":"1.0","requestId":"req-3","proposal":{"title":"line\u2028para\u2029","summary":"\u0000\t\n\"\\"}}`,
+	`{"requestId":"req-4","organizer":{"previousKids":["key-0"],"campaignIndexUrl":"https://example.com/index.json"},"duplicateCheck":{"url":"https://example.com/dup","salt":"s","prefixLength":5}}`,
+}
+
+func FuzzVerifyRoundTrip(f *testing.F) {
+	priv, jwk := testKey(f, "key-1")
+	jwks := &JWKS{Keys: []JWK{jwk}}
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	for _, seed := range fuzzRequestSeeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, in []byte) {
+		var req model.SignRequest
+		if err := json.Unmarshal(in, &req); err != nil {
+			return
+		}
+		req.Organizer.KID = "key-1"
+		req.Organizer.JWKSetURL = "https://example.com/jwks.json"
+
+		first, err := canon.Encode(req)
+		if err != nil {
+			t.Fatalf("canon.Encode: %v", err)
+		}
+		second, err := canon.Encode(req)
+		if err != nil {
+			t.Fatalf("canon.Encode: %v", err)
+		}
+		if !bytes.Equal(first, second) {
+			t.Fatalf("canon.Encode is not deterministic:\n first  %q\n second %q", first, second)
+		}
+
+		signRequest(t, &req, priv, map[string]string{"alg": "ES256", "kid": "key-1"})
+
+		// The client receives the signed request as JSON, not as the value
+		// the organizer signed.
+		wire, err := json.Marshal(req)
+		if err != nil {
+			t.Fatalf("json.Marshal: %v", err)
+		}
+		var received model.SignRequest
+		if err := json.Unmarshal(wire, &received); err != nil {
+			t.Fatalf("json.Unmarshal: %v", err)
+		}
+		res, err := verifyWithJWKS(&received, jwks, now)
+		if err != nil {
+			t.Fatalf("signed request does not verify after a JSON round trip: %v", err)
+		}
+		if res.KID != "key-1" {
+			t.Fatalf("KID = %q, want key-1", res.KID)
+		}
+	})
+}
+
+func FuzzVerifyJWS(f *testing.F) {
+	priv, jwk := testKey(f, "key-1")
+	jwks := &JWKS{Keys: []JWK{jwk}}
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	req := &model.SignRequest{
+		Version:   "1.0",
+		RequestID: "req-1",
+		Organizer: model.Organizer{KID: "key-1", JWKSetURL: "https://example.com/jwks.json"},
+	}
+	signRequest(f, req, priv, map[string]string{"alg": "ES256", "kid": "key-1"})
+	valid := req.OrganizerSignature.Value
+	unsigned := *req
+	unsigned.OrganizerSignature = nil
+	payload, err := canon.Encode(unsigned)
+	if err != nil {
+		f.Fatalf("canon.Encode: %v", err)
+	}
+
+	f.Add(valid)
+	f.Add("")
+	f.Add("..")
+	f.Add("e30.e30.")
+	f.Add(valid[:strings.LastIndex(valid, ".")] + ".AAAA")
+	f.Add(base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + base64.RawURLEncoding.EncodeToString(payload) + ".")
+
+	f.Fuzz(func(t *testing.T, value string) {
+		r := *req
+		r.OrganizerSignature = &model.OrganizerSignature{Format: "JWS", Value: value}
+		if _, err := verifyWithJWKS(&r, jwks, now); err != nil {
+			return
+		}
+		parts := strings.Split(value, ".")
+		got, err := base64.RawURLEncoding.DecodeString(parts[1])
+		if err != nil || !bytes.Equal(got, payload) {
+			t.Fatalf("accepted a JWS over a different payload: %q", value)
+		}
+	})
+}
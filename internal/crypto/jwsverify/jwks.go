@@ -5,6 +5,7 @@ import (
 	"crypto/ecdh"
 	"crypto/ecdsa"
 	"crypto/elliptic"
+	"crypto/rsa"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -12,6 +13,8 @@ import (
 	"math/big"
 	"net/http"
 	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
 )
 
 // Response body size limits.
@@ -31,6 +34,36 @@ type JWK struct {
 	CRV string `json:"crv"`
 	X   string `json:"x"`
 	Y   string `json:"y"`
+	// N and E are the modulus and exponent of an RSA key. RSA keys are not
+	// accepted for request signatures, but a collector may counter-sign
+	// receipts with one (see Holds).
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// Optional rotation window (RFC 3339). Keys outside their window are
+	// still accepted but reported as warnings.
+	NotBefore string `json:"notBefore,omitempty"`
+	NotAfter  string `json:"notAfter,omitempty"`
+}
+
+// rotationWarning reports whether the key is being used outside its
+// declared validity window.
+func (jwk *JWK) rotationWarning(now time.Time) string {
+	if jwk.NotBefore != "" {
+		if nbf, err := time.Parse(time.RFC3339, jwk.NotBefore); err != nil {
+			return fmt.Sprintf("organizer key %s has an invalid notBefore", jwk.KID)
+		} else if now.Before(nbf) {
+			return fmt.Sprintf("organizer key %s is not valid until %s", jwk.KID, jwk.NotBefore)
+		}
+	}
+	if jwk.NotAfter != "" {
+		if naf, err := time.Parse(time.RFC3339, jwk.NotAfter); err != nil {
+			return fmt.Sprintf("organizer key %s has an invalid notAfter", jwk.KID)
+		} else if now.After(naf) {
+			return fmt.Sprintf("organizer key %s was rotated out on %s", jwk.KID, jwk.NotAfter)
+		}
+	}
+	return ""
 }
 
 func FetchJWKS(url string) (*JWKS, error) {
@@ -40,63 +73,92 @@ func FetchJWKS(url string) (*JWKS, error) {
 	}
 	resp, err := client.Get(url)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+		return nil, errcode.Errorf(errcode.JWKSFetch, "failed to fetch JWKS: %w", errcode.Network(err))
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("JWKS fetch failed with status: %d", resp.StatusCode)
+		return nil, errcode.Errorf(errcode.JWKSFetch, "JWKS fetch failed with status: %d", resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes+1))
 	if err != nil {
-		return nil, fmt.Errorf("failed to read JWKS body: %w", err)
+		return nil, errcode.Errorf(errcode.JWKSFetch, "failed to read JWKS body: %w", err)
 	}
 	if int64(len(body)) > maxResponseBytes {
-		return nil, fmt.Errorf("JWKS response exceeds %d bytes", maxResponseBytes)
+		return nil, errcode.Errorf(errcode.ResponseTooLarge, "JWKS response exceeds %d bytes", maxResponseBytes)
 	}
 
 	var jwks JWKS
 	if err := json.Unmarshal(body, &jwks); err != nil {
-		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+		return nil, errcode.Errorf(errcode.JWKSFetch, "failed to decode JWKS: %w", err)
 	}
 	return &jwks, nil
 }
 
+// Holds reports whether pub is one of the keys of the set: an EC key valid
+// for request signatures, or an RSA key.
+func (j *JWKS) Holds(pub crypto.PublicKey) bool {
+	for i := range j.Keys {
+		jwk := &j.Keys[i]
+		switch jwk.KTY {
+		case "EC":
+			key, err := jwk.ToPublicKey()
+			if err != nil {
+				continue
+			}
+			if k, ok := pub.(*ecdsa.PublicKey); ok && k.Equal(key) {
+				return true
+			}
+		case "RSA":
+			k, ok := pub.(*rsa.PublicKey)
+			if !ok {
+				continue
+			}
+			n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+			e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+			if errN == nil && errE == nil && k.N.Cmp(new(big.Int).SetBytes(n)) == 0 && big.NewInt(int64(k.E)).Cmp(new(big.Int).SetBytes(e)) == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // jwksCheckRedirect rejects redirects that downgrade from HTTPS to HTTP
 // (unless the target is localhost/127.0.0.1).
 func jwksCheckRedirect(req *http.Request, via []*http.Request) error {
 	if len(via) >= 10 {
-		return fmt.Errorf("stopped after 10 redirects")
+		return errcode.Errorf(errcode.RedirectBlocked, "stopped after 10 redirects")
 	}
 	u := req.URL
 	if u.Scheme != "https" && u.Hostname() != "localhost" && u.Hostname() != "127.0.0.1" {
-		return fmt.Errorf("redirect to disallowed URL: %s", u.Redacted())
+		return errcode.Errorf(errcode.RedirectBlocked, "redirect to disallowed URL: %s", u.Redacted())
 	}
 	return nil
 }
 
 func (jwk *JWK) ToPublicKey() (crypto.PublicKey, error) {
 	if jwk.KTY != "EC" {
-		return nil, fmt.Errorf("unsupported key type: %s", jwk.KTY)
+		return nil, errcode.Errorf(errcode.InvalidJWK, "unsupported key type: %s", jwk.KTY)
 	}
 	if jwk.CRV != "P-256" {
-		return nil, fmt.Errorf("unsupported curve: %s", jwk.CRV)
+		return nil, errcode.Errorf(errcode.InvalidJWK, "unsupported curve: %s", jwk.CRV)
 	}
 	if jwk.ALG != "" && jwk.ALG != "ES256" {
-		return nil, fmt.Errorf("unsupported algorithm: %s", jwk.ALG)
+		return nil, errcode.Errorf(errcode.InvalidJWK, "unsupported algorithm: %s", jwk.ALG)
 	}
 	if jwk.USE != "" && jwk.USE != "sig" {
-		return nil, fmt.Errorf("key use %q is not valid for signature verification", jwk.USE)
+		return nil, errcode.Errorf(errcode.InvalidJWK, "key use %q is not valid for signature verification", jwk.USE)
 	}
 
 	xBytes, err := base64.RawURLEncoding.DecodeString(jwk.X)
 	if err != nil {
-		return nil, fmt.Errorf("invalid x coordinate: %w", err)
+		return nil, errcode.Errorf(errcode.InvalidJWK, "invalid x coordinate: %w", err)
 	}
 	yBytes, err := base64.RawURLEncoding.DecodeString(jwk.Y)
 	if err != nil {
-		return nil, fmt.Errorf("invalid y coordinate: %w", err)
+		return nil, errcode.Errorf(errcode.InvalidJWK, "invalid y coordinate: %w", err)
 	}
 
 	// Use crypto/ecdh for on-curve validation (replaces deprecated elliptic.IsOnCurve).
@@ -108,7 +170,7 @@ func (jwk *JWK) ToPublicKey() (crypto.PublicKey, error) {
 
 	// Validate the point is on the curve using the non-deprecated crypto/ecdh API.
 	if _, err := ecdh.P256().NewPublicKey(uncompressed); err != nil {
-		return nil, fmt.Errorf("invalid EC point: %w", err)
+		return nil, errcode.Errorf(errcode.InvalidJWK, "invalid EC point: %w", err)
 	}
 
 	// Construct ecdsa.PublicKey for signature verification.
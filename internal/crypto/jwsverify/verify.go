@@ -8,55 +8,106 @@ import (
 	"fmt"
 	"log"
 	"math/big"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/vocdoni/gofirma/vocsign/internal/canon"
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
 	"github.com/vocdoni/gofirma/vocsign/internal/model"
 )
 
-func Verify(req *model.SignRequest) error {
+// Result describes a successful verification. Warnings are non-fatal issues
+// (e.g. a rotated-out key) that should be shown to the user.
+type Result struct {
+	KID      string
+	Warnings []string
+}
+
+func Verify(req *model.SignRequest) (*Result, error) {
 	if req == nil {
-		return fmt.Errorf("nil request")
+		return nil, fmt.Errorf("nil request")
 	}
 	if req.OrganizerSignature == nil {
-		return fmt.Errorf("missing organizerSignature")
+		return nil, errcode.Errorf(errcode.MissingSignature, "missing organizerSignature")
 	}
 	if req.OrganizerSignature.Value == "" {
-		return fmt.Errorf("missing organizerSignature value")
+		return nil, errcode.Errorf(errcode.MissingSignature, "missing organizerSignature value")
 	}
 	if req.Organizer.JWKSetURL == "" {
-		return fmt.Errorf("missing organizer jwkSetUrl")
+		return nil, errcode.Errorf(errcode.MissingSignature, "missing organizer jwkSetUrl")
 	}
 	if req.Organizer.KID == "" {
-		return fmt.Errorf("missing organizer kid")
+		return nil, errcode.Errorf(errcode.MissingSignature, "missing organizer kid")
 	}
 
 	log.Printf("DEBUG: Verifying organizer signature for Request %s", req.RequestID)
 	log.Printf("DEBUG: Fetching JWKS from %s", req.Organizer.JWKSetURL)
 	jwks, err := FetchJWKS(req.Organizer.JWKSetURL)
 	if err != nil {
-		return fmt.Errorf("failed to fetch JWKS: %w", err)
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	return verifyWithJWKS(req, jwks, time.Now())
+}
+
+func verifyWithJWKS(req *model.SignRequest, jwks *JWKS, now time.Time) (*Result, error) {
+	parts := strings.Split(req.OrganizerSignature.Value, ".")
+	if len(parts) != 3 {
+		return nil, errcode.Errorf(errcode.InvalidJWS, "invalid JWS format")
+	}
+
+	headerB64 := parts[0]
+	payloadB64 := parts[1]
+	signatureB64 := parts[2]
+
+	headerBytes, err := base64.RawURLEncoding.DecodeString(headerB64)
+	if err != nil {
+		return nil, errcode.Errorf(errcode.InvalidJWS, "invalid JWS header encoding: %w", err)
+	}
+	var header map[string]interface{}
+	if err := json.Unmarshal(headerBytes, &header); err != nil {
+		return nil, errcode.Errorf(errcode.InvalidJWS, "invalid JWS header json: %w", err)
+	}
+	log.Printf("DEBUG: JWS Header: %v", header)
+	if alg, ok := header["alg"].(string); !ok || alg != "ES256" {
+		return nil, errcode.Errorf(errcode.UnsupportedJWSAlg, "unsupported algorithm: %v", header["alg"])
+	}
+
+	// The JWS header may name the signing key; otherwise the request's
+	// declared kid is used. Either way it must be a kid the request declares.
+	result := &Result{KID: req.Organizer.KID}
+	if headerKID, ok := header["kid"].(string); ok && headerKID != "" {
+		result.KID = headerKID
+	}
+	if result.KID != req.Organizer.KID {
+		if !slices.Contains(req.Organizer.PreviousKIDs, result.KID) {
+			return nil, errcode.Errorf(errcode.JWSKidUndeclared, "JWS kid %s is not declared by the request", result.KID)
+		}
+		result.Warnings = append(result.Warnings, fmt.Sprintf("request was signed with a previous organizer key (%s)", result.KID))
 	}
 
 	var pubKey *ecdsa.PublicKey
 	for _, key := range jwks.Keys {
-		if key.KID == req.Organizer.KID {
+		if key.KID == result.KID {
 			log.Printf("DEBUG: Found matching key in JWKS (KID: %s)", key.KID)
 			parsedKey, err := key.ToPublicKey()
 			if err != nil {
-				return fmt.Errorf("invalid key: %w", err)
+				return nil, errcode.Errorf(errcode.InvalidJWK, "invalid key: %w", err)
 			}
 			ecKey, ok := parsedKey.(*ecdsa.PublicKey)
 			if !ok {
-				return fmt.Errorf("unsupported key type for organizer signature")
+				return nil, errcode.Errorf(errcode.InvalidJWK, "unsupported key type for organizer signature")
 			}
 			pubKey = ecKey
+			if w := key.rotationWarning(now); w != "" {
+				result.Warnings = append(result.Warnings, w)
+			}
 			break
 		}
 	}
 	if pubKey == nil {
-		log.Printf("DEBUG: Key KID %s not found in JWKS", req.Organizer.KID)
-		return fmt.Errorf("key not found: %s", req.Organizer.KID)
+		log.Printf("DEBUG: Key KID %s not found in JWKS", result.KID)
+		return nil, errcode.Errorf(errcode.JWSKidNotFound, "key not found: %s", result.KID)
 	}
 
 	reqCopy := *req
@@ -64,49 +115,38 @@ func Verify(req *model.SignRequest) error {
 
 	canonicalBytes, err := canon.Encode(reqCopy)
 	if err != nil {
-		return fmt.Errorf("canonicalization failed: %w", err)
+		return nil, fmt.Errorf("canonicalization failed: %w", err)
 	}
 	log.Printf("DEBUG: Canonical Request Body (len: %d)", len(canonicalBytes))
 
-	parts := strings.Split(req.OrganizerSignature.Value, ".")
-	if len(parts) != 3 {
-		return fmt.Errorf("invalid JWS format")
-	}
-
-	headerB64 := parts[0]
-	payloadB64 := parts[1]
-	signatureB64 := parts[2]
-
-	headerBytes, err := base64.RawURLEncoding.DecodeString(headerB64)
-	if err != nil {
-		return fmt.Errorf("invalid JWS header encoding: %w", err)
-	}
-	var header map[string]interface{}
-	if err := json.Unmarshal(headerBytes, &header); err != nil {
-		return fmt.Errorf("invalid JWS header json: %w", err)
-	}
-	log.Printf("DEBUG: JWS Header: %v", header)
-	if alg, ok := header["alg"].(string); !ok || alg != "ES256" {
-		return fmt.Errorf("unsupported algorithm: %v", header["alg"])
-	}
-
 	payloadBytes, err := base64.RawURLEncoding.DecodeString(payloadB64)
 	if err != nil {
-		return fmt.Errorf("invalid JWS payload encoding: %w", err)
+		return nil, errcode.Errorf(errcode.InvalidJWS, "invalid JWS payload encoding: %w", err)
 	}
 	if string(payloadBytes) != string(canonicalBytes) {
-		log.Printf("DEBUG: Payload mismatch!")
-		log.Printf("DEBUG: Expected: %s", string(canonicalBytes))
-		log.Printf("DEBUG: Got:      %s", string(payloadBytes))
-		return fmt.Errorf("JWS payload does not match request body")
+		// A request written for a newer schema has signed fields this client
+		// drops when parsing it, so its canonical encoding cannot match the
+		// payload. Accept it, with a warning, if the payload is the same
+		// request once those fields are ignored.
+		unknown, err := model.UnknownFields(payloadBytes)
+		if err != nil {
+			return nil, errcode.Errorf(errcode.InvalidJWS, "invalid JWS payload: %w", err)
+		}
+		if len(unknown) == 0 || !sameKnownFields(payloadBytes, canonicalBytes) {
+			log.Printf("DEBUG: Payload mismatch!")
+			log.Printf("DEBUG: Expected: %s", string(canonicalBytes))
+			log.Printf("DEBUG: Got:      %s", string(payloadBytes))
+			return nil, errcode.Errorf(errcode.JWSPayloadMismatch, "JWS payload does not match request body")
+		}
+		result.Warnings = append(result.Warnings, model.UnknownFieldsWarning(unknown))
 	}
 
 	signatureBytes, err := base64.RawURLEncoding.DecodeString(signatureB64)
 	if err != nil {
-		return fmt.Errorf("invalid JWS signature encoding: %w", err)
+		return nil, errcode.Errorf(errcode.InvalidJWS, "invalid JWS signature encoding: %w", err)
 	}
 	if len(signatureBytes) != 64 {
-		return fmt.Errorf("invalid ES256 signature length: %d", len(signatureBytes))
+		return nil, errcode.Errorf(errcode.InvalidJWS, "invalid ES256 signature length: %d", len(signatureBytes))
 	}
 
 	signedContent := headerB64 + "." + payloadB64
@@ -116,9 +156,23 @@ func Verify(req *model.SignRequest) error {
 	s := new(big.Int).SetBytes(signatureBytes[32:])
 	if !ecdsa.Verify(pubKey, hashed[:], r, s) {
 		log.Printf("DEBUG: JWS Signature Verification FAILED")
-		return fmt.Errorf("signature verification failed")
+		return nil, errcode.Errorf(errcode.JWSSignatureInvalid, "signature verification failed")
 	}
 
+	for _, w := range result.Warnings {
+		log.Printf("WARNING: %s", w)
+	}
 	log.Printf("DEBUG: JWS Signature Verified Successfully")
-	return nil
+	return result, nil
+}
+
+// sameKnownFields reports whether payload parses to the request whose
+// canonical encoding is canonical.
+func sameKnownFields(payload, canonical []byte) bool {
+	var signed model.SignRequest
+	if err := json.Unmarshal(payload, &signed); err != nil {
+		return false
+	}
+	b, err := canon.Encode(signed)
+	return err == nil && string(b) == string(canonical)
 }
@@ -0,0 +1,282 @@
+package jwsverify
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/canon"
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+func testKey(t testing.TB, kid string) (*ecdsa.PrivateKey, JWK) {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	pub, err := priv.PublicKey.ECDH()
+	if err != nil {
+		t.Fatalf("ECDH: %v", err)
+	}
+	raw := pub.Bytes() // 0x04 || X || Y
+	return priv, JWK{
+		KID: kid, KTY: "EC", CRV: "P-256", ALG: "ES256", USE: "sig",
+		X: base64.RawURLEncoding.EncodeToString(raw[1:33]),
+		Y: base64.RawURLEncoding.EncodeToString(raw[33:]),
+	}
+}
+
+func signRequest(t testing.TB, req *model.SignRequest, priv *ecdsa.PrivateKey, header map[string]string) {
+	t.Helper()
+	req.OrganizerSignature = nil
+	payload, err := canon.Encode(*req)
+	if err != nil {
+		t.Fatalf("canon.Encode: %v", err)
+	}
+	req.OrganizerSignature = &model.OrganizerSignature{
+		Format: "JWS",
+		Value:  signPayload(t, payload, priv, header),
+	}
+}
+
+// signPayload returns the compact JWS of payload, which need not be a
+// canonical request.
+func signPayload(t testing.TB, payload []byte, priv *ecdsa.PrivateKey, header map[string]string) string {
+	t.Helper()
+	hb, _ := json.Marshal(header)
+	signingInput := base64.RawURLEncoding.EncodeToString(hb) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, priv, digest[:])
+	if err != nil {
+		t.Fatalf("ecdsa.Sign: %v", err)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestVerifyWithJWKS_Rotation(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	oldPriv, oldJWK := testKey(t, "key-1")
+	newPriv, newJWK := testKey(t, "key-2")
+	oldJWK.NotAfter = "2026-05-01T00:00:00Z"
+	newJWK.NotBefore = "2026-04-01T00:00:00Z"
+	jwks := &JWKS{Keys: []JWK{oldJWK, newJWK}}
+
+	tests := []struct {
+		name         string
+		declared     string
+		previous     []string
+		priv         *ecdsa.PrivateKey
+		header       map[string]string
+		wantKID      string
+		wantWarnings []string
+		wantErr      string
+		wantCode     errcode.Code
+	}{
+		{
+			name:     "current key without header kid",
+			declared: "key-2",
+			priv:     newPriv,
+			header:   map[string]string{"alg": "ES256"},
+			wantKID:  "key-2",
+		},
+		{
+			name:     "current key with header kid",
+			declared: "key-2",
+			previous: []string{"key-1"},
+			priv:     newPriv,
+			header:   map[string]string{"alg": "ES256", "kid": "key-2"},
+			wantKID:  "key-2",
+		},
+		{
+			name:         "previous key during rotation",
+			declared:     "key-2",
+			previous:     []string{"key-1"},
+			priv:         oldPriv,
+			header:       map[string]string{"alg": "ES256", "kid": "key-1"},
+			wantKID:      "key-1",
+			wantWarnings: []string{"previous organizer key", "rotated out"},
+		},
+		{
+			name:     "undeclared header kid rejected",
+			declared: "key-2",
+			priv:     oldPriv,
+			header:   map[string]string{"alg": "ES256", "kid": "key-1"},
+			wantErr:  "not declared by the request",
+			wantCode: errcode.JWSKidUndeclared,
+		},
+		{
+			name:     "wrong key for declared kid",
+			declared: "key-2",
+			priv:     oldPriv,
+			header:   map[string]string{"alg": "ES256"},
+			wantErr:  "signature verification failed",
+			wantCode: errcode.JWSSignatureInvalid,
+		},
+		{
+			name:     "unknown kid",
+			declared: "key-9",
+			priv:     newPriv,
+			header:   map[string]string{"alg": "ES256"},
+			wantErr:  "key not found",
+			wantCode: errcode.JWSKidNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &model.SignRequest{
+				Version:   "1.0",
+				RequestID: "req-1",
+				Organizer: model.Organizer{KID: tt.declared, JWKSetURL: "https://example.com/jwks.json", PreviousKIDs: tt.previous},
+			}
+			signRequest(t, req, tt.priv, tt.header)
+
+			res, err := verifyWithJWKS(req, jwks, now)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				if code := errcode.Of(err); code != tt.wantCode {
+					t.Errorf("code = %q, want %q", code, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("verifyWithJWKS: %v", err)
+			}
+			if res.KID != tt.wantKID {
+				t.Errorf("KID = %q, want %q", res.KID, tt.wantKID)
+			}
+			if len(res.Warnings) != len(tt.wantWarnings) {
+				t.Fatalf("warnings = %q, want %d", res.Warnings, len(tt.wantWarnings))
+			}
+			for i, w := range tt.wantWarnings {
+				if !strings.Contains(res.Warnings[i], w) {
+					t.Errorf("warning[%d] = %q, want substring %q", i, res.Warnings[i], w)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyWithJWKS_UnknownFields(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	priv, jwk := testKey(t, "key-1")
+	jwks := &JWKS{Keys: []JWK{jwk}}
+	header := map[string]string{"alg": "ES256"}
+
+	tests := []struct {
+		name        string
+		payload     string
+		wantWarning string
+		wantCode    errcode.Code
+	}{
+		{
+			name:        "unknown fields are ignored with a warning",
+			payload:     `{"version":"2.0","requestId":"req-1","video":{"url":"https://example.com/v.mp4"},"proposal":{"title":"Title","subtitle":"More","fullText":{"url":"","sha256":""},"legalStatement":"","promoter":"","jurisdiction":"","summary":""},"callback":{"url":"","method":""},"organizer":{"kid":"key-1","jwkSetUrl":"https://example.com/jwks.json"},"issuedAt":"","expiresAt":"","nonce":""}`,
+			wantWarning: "may not fully display (proposal.subtitle, video)",
+		},
+		{
+			name:     "known fields must still match",
+			payload:  `{"version":"2.0","requestId":"req-other","video":"v"}`,
+			wantCode: errcode.JWSPayloadMismatch,
+		},
+		{
+			name:     "reordered known fields without unknown ones",
+			payload:  `{"requestId":"req-1","version":"2.0","issuedAt":"","expiresAt":"","nonce":"","proposal":{"title":"Title","promoter":"","jurisdiction":"","summary":"","legalStatement":"","fullText":{"url":"","sha256":""}},"callback":{"url":"","method":""},"organizer":{"kid":"key-1","jwkSetUrl":"https://example.com/jwks.json"}}`,
+			wantCode: errcode.JWSPayloadMismatch,
+		},
+		{
+			name:     "member differing from a known one in case",
+			payload:  `{"version":"2.0","requestId":"req-1","REQUESTID":"req-2"}`,
+			wantCode: errcode.InvalidJWS,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &model.SignRequest{
+				Version:   "2.0",
+				RequestID: "req-1",
+				Proposal:  model.Proposal{Title: "Title"},
+				Organizer: model.Organizer{KID: "key-1", JWKSetURL: "https://example.com/jwks.json"},
+				OrganizerSignature: &model.OrganizerSignature{
+					Format: "JWS",
+					Value:  signPayload(t, []byte(tt.payload), priv, header),
+				},
+			}
+			res, err := verifyWithJWKS(req, jwks, now)
+			if tt.wantCode != "" {
+				if code := errcode.Of(err); code != tt.wantCode {
+					t.Fatalf("code = %q (%v), want %q", code, err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("verifyWithJWKS: %v", err)
+			}
+			if len(res.Warnings) != 1 || !strings.Contains(res.Warnings[0], tt.wantWarning) {
+				t.Fatalf("warnings = %q, want %q", res.Warnings, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestRotationWarning(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		key  JWK
+		want string
+	}{
+		{"no window", JWK{KID: "k"}, ""},
+		{"inside window", JWK{KID: "k", NotBefore: "2026-01-01T00:00:00Z", NotAfter: "2027-01-01T00:00:00Z"}, ""},
+		{"not yet valid", JWK{KID: "k", NotBefore: "2026-07-01T00:00:00Z"}, "not valid until"},
+		{"expired", JWK{KID: "k", NotAfter: "2026-05-01T00:00:00Z"}, "rotated out"},
+		{"malformed", JWK{KID: "k", NotAfter: "soon"}, "invalid notAfter"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.key.rotationWarning(now)
+			if tt.want == "" && got != "" || tt.want != "" && !strings.Contains(got, tt.want) {
+				t.Errorf("rotationWarning = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJWKS_Holds(t *testing.T) {
+	ecKey, ecJWK := testKey(t, "ec")
+	otherEC, _ := testKey(t, "other")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaJWK := JWK{
+		KID: "rsa", KTY: "RSA", ALG: "RS256",
+		N: base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+		E: base64.RawURLEncoding.EncodeToString([]byte{1, 0, 1}),
+	}
+	otherRSA, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks := &JWKS{Keys: []JWK{ecJWK, rsaJWK}}
+	if !jwks.Holds(&ecKey.PublicKey) || !jwks.Holds(&rsaKey.PublicKey) {
+		t.Error("Holds = false for a key of the set")
+	}
+	if jwks.Holds(&otherEC.PublicKey) || jwks.Holds(&otherRSA.PublicKey) {
+		t.Error("Holds = true for a key outside the set")
+	}
+}
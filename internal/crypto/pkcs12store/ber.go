@@ -15,7 +15,13 @@ import (
 // normalizeBER converts BER (including indefinite lengths and constructed
 // OCTET STRINGs) into DER so strict ASN.1 decoders can parse legacy PKCS#12.
 func normalizeBER(input []byte) ([]byte, error) {
-	p := &berParser{b: input}
+	return normalizeBERAt(input, 0)
+}
+
+// normalizeBERAt normalizes an element found depth levels down, so nested
+// OCTET STRING contents count towards maxBERDepth.
+func normalizeBERAt(input []byte, depth int) ([]byte, error) {
+	p := &berParser{b: input, depth: depth}
 	der, err := p.parseElement()
 	if err != nil {
 		return nil, err
@@ -27,10 +33,17 @@ func normalizeBER(input []byte) ([]byte, error) {
 }
 
 type berParser struct {
-	b   []byte
-	pos int
+	b     []byte
+	pos   int
+	depth int
 }
 
+// maxBERDepth bounds element nesting. PKCS#12 files nest about 25 levels
+// deep, counting the certificates inside them; the limit keeps crafted
+// files from exhausting the stack or renormalizing the same bytes over and
+// over.
+const maxBERDepth = 64
+
 const (
 	asn1ClassMask       = 0xC0
 	asn1ClassContext    = 0x80
@@ -40,6 +53,12 @@ const (
 )
 
 func (p *berParser) parseElement() ([]byte, error) {
+	if p.depth >= maxBERDepth {
+		return nil, errors.New("invalid BER: nesting too deep")
+	}
+	p.depth++
+	defer func() { p.depth-- }()
+
 	tag, tagBytes, err := p.readTag()
 	if err != nil {
 		return nil, err
@@ -81,7 +100,7 @@ func (p *berParser) parseElement() ([]byte, error) {
 			if err != nil {
 				return nil, err
 			}
-			content = maybeNormalizeASN1Bytes(flattened)
+			content = maybeNormalizeASN1Bytes(flattened, p.depth)
 			tagBytes = []byte{asn1TagOctetString}
 		} else if class == asn1ClassContext && tagNumber == 0 && len(chunks) > 1 {
 			if flattened, ok := flattenPrimitiveOctetChildren(chunks); ok {
@@ -101,7 +120,7 @@ func (p *berParser) parseElement() ([]byte, error) {
 		p.pos += length
 
 		if constructed {
-			childParser := &berParser{b: rawContent}
+			childParser := &berParser{b: rawContent, depth: p.depth}
 			var chunks [][]byte
 			for childParser.pos < len(childParser.b) {
 				child, err := childParser.parseElement()
@@ -116,7 +135,7 @@ func (p *berParser) parseElement() ([]byte, error) {
 				if err != nil {
 					return nil, err
 				}
-				content = maybeNormalizeASN1Bytes(flattened)
+				content = maybeNormalizeASN1Bytes(flattened, p.depth)
 				tagBytes = []byte{asn1TagOctetString}
 			} else if class == asn1ClassContext && tagNumber == 0 && len(chunks) > 1 {
 				if flattened, ok := flattenPrimitiveOctetChildren(chunks); ok {
@@ -181,6 +200,10 @@ func (p *berParser) readLength() (int, bool, error) {
 	if n == 0 {
 		return 0, false, errors.New("invalid BER: reserved length form")
 	}
+	// Four length bytes cover any file we accept and cannot overflow int.
+	if n > 4 {
+		return 0, false, errors.New("invalid BER: length too large")
+	}
 	if p.remaining() < n {
 		return 0, false, errors.New("invalid BER: truncated long-form length")
 	}
@@ -190,6 +213,9 @@ func (p *berParser) readLength() (int, bool, error) {
 		length = (length << 8) | int(p.b[p.pos])
 		p.pos++
 	}
+	if length > p.remaining() {
+		return 0, false, errors.New("invalid BER: content truncated")
+	}
 	return length, false, nil
 }
 
@@ -276,7 +302,7 @@ func decodeSingleDER(der []byte) (byte, []byte, error) {
 	}
 	tag := der[0]
 	pos := 1
-	if der[pos]&asn1TagMask == asn1TagMask {
+	if tag&asn1TagMask == asn1TagMask {
 		for {
 			if pos >= len(der) {
 				return 0, nil, errors.New("invalid DER: truncated long tag")
@@ -313,11 +339,11 @@ func decodeSingleDER(der []byte) (byte, []byte, error) {
 	return tag, der[pos : pos+length], nil
 }
 
-func maybeNormalizeASN1Bytes(content []byte) []byte {
+func maybeNormalizeASN1Bytes(content []byte, depth int) []byte {
 	if len(content) == 0 || content[0] != 0x30 {
 		return content
 	}
-	normalized, err := normalizeBER(content)
+	normalized, err := normalizeBERAt(content, depth)
 	if err != nil {
 		return content
 	}
@@ -0,0 +1,121 @@
+package pkcs12store
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+)
+
+// ErrPasswordCanceled means the user declined to enter the password of a
+// certificate file kept with deferred decryption.
+var ErrPasswordCanceled = errors.New("certificate password entry canceled")
+
+var (
+	passwordPromptMu sync.RWMutex
+	passwordPrompt   PINPromptFunc
+)
+
+// SetPasswordPrompt installs the function used to ask for the password of
+// a certificate file imported with ImportDeferred. It is called with the
+// friendly name of the certificate and returns ErrPasswordCanceled if the
+// user declines.
+func SetPasswordPrompt(fn PINPromptFunc) {
+	passwordPromptMu.Lock()
+	defer passwordPromptMu.Unlock()
+	passwordPrompt = fn
+}
+
+func promptPassword(name string) ([]byte, error) {
+	passwordPromptMu.RLock()
+	fn := passwordPrompt
+	passwordPromptMu.RUnlock()
+	if fn == nil {
+		return nil, ErrImportPasswordRequired
+	}
+	return fn(name)
+}
+
+// DeferredSigner signs with the key of a certificate file kept as it was
+// imported. Each signature decrypts the file with its password, asked from
+// the user unless it is in DefaultPINCache, and drops the key afterwards.
+type DeferredSigner struct {
+	name      string
+	data      []byte
+	publicKey crypto.PublicKey
+	cacheKey  string
+}
+
+func newDeferredSigner(ident *Identity, data []byte) *DeferredSigner {
+	name := ident.FriendlyName
+	if name == "" {
+		name = ident.Cert.Subject.CommonName
+	}
+	return &DeferredSigner{
+		name:      name,
+		data:      data,
+		publicKey: ident.Cert.PublicKey,
+		cacheKey:  fmt.Sprintf("p12/%x", ident.Fingerprint256),
+	}
+}
+
+func (s *DeferredSigner) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+func (s *DeferredSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	key, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	return key.Sign(rand, digest, opts)
+}
+
+// open decrypts the certificate file, first with the cached password and,
+// if there is none or it no longer works, with one asked from the user. A
+// password that works is cached like a token PIN.
+func (s *DeferredSigner) open() (crypto.Signer, error) {
+	pass, cached := DefaultPINCache.Get(s.cacheKey)
+	defer func() { wipe(pass) }()
+	if cached {
+		key, err := s.decrypt(pass)
+		if err == nil {
+			return key, nil
+		}
+		log.Printf("DEBUG: cached password rejected for %s, asking again", s.name)
+		DefaultPINCache.Forget(s.cacheKey)
+		wipe(pass)
+	}
+
+	pass, err := promptPassword(s.name)
+	if err != nil {
+		return nil, err
+	}
+	key, err := s.decrypt(pass)
+	if err != nil {
+		if errors.Is(err, ErrImportWrongPassword) {
+			reportAuthFailure(s.name)
+		}
+		return nil, err
+	}
+	DefaultPINCache.Put(s.cacheKey, pass)
+	return key, nil
+}
+
+func (s *DeferredSigner) decrypt(pass []byte) (crypto.Signer, error) {
+	key, _, _, err := ParsePKCS12(bytes.NewReader(s.data), string(pass))
+	if err != nil {
+		if errors.Is(err, ErrImportWrongPassword) || errors.Is(err, ErrImportPasswordRequired) {
+			return nil, ErrImportWrongPassword
+		}
+		return nil, fmt.Errorf("failed to open certificate file: %w", err)
+	}
+	pub, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(s.publicKey) {
+		return nil, ErrKeyMismatch
+	}
+	return key, nil
+}
@@ -0,0 +1,142 @@
+package pkcs12store
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStore_ImportDeferred(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewFileStore(dir, []byte("vault"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ImportDeferred(ctx, "Test", bytes.NewReader(userP12(t)), []byte("wrong")); !errors.Is(err, ErrImportWrongPassword) {
+		t.Fatalf("ImportDeferred with a wrong password = %v", err)
+	}
+	id, err := s.ImportDeferred(ctx, "Test", bytes.NewReader(userP12(t)), []byte("password"))
+	if err != nil {
+		t.Fatalf("ImportDeferred: %v", err)
+	}
+	if !id.Deferred {
+		t.Error("imported identity not marked deferred")
+	}
+
+	// The file is kept as imported, and no key rests under the vault.
+	stored, err := os.ReadFile(filepath.Join(dir, id.ID+".p12"))
+	if err != nil || !bytes.Equal(stored, userP12(t)) {
+		t.Fatalf("stored certificate file differs: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, id.ID+".key.enc")); !os.IsNotExist(err) {
+		t.Fatalf("vault key written: %v", err)
+	}
+	if ids, _ := s.List(ctx); len(ids) != 1 || !ids[0].Deferred {
+		t.Fatalf("List = %+v", ids)
+	}
+	if h := s.CheckHealth(ctx, id.ID); h.Status != HealthOK {
+		t.Errorf("CheckHealth = %+v", h)
+	}
+	if _, err := s.ImportDeferred(ctx, "Test", bytes.NewReader(userP12(t)), []byte("password")); !errors.Is(err, ErrImportDuplicate) {
+		t.Errorf("second ImportDeferred = %v, want ErrImportDuplicate", err)
+	}
+
+	DefaultPINCache.Clear()
+	t.Cleanup(func() {
+		SetPasswordPrompt(nil)
+		DefaultPINCache.Clear()
+	})
+	digest := sha256.Sum256([]byte("document"))
+	sign := func() error {
+		signer, err := s.Unlock(ctx, id.ID)
+		if err != nil {
+			t.Fatalf("Unlock: %v", err)
+		}
+		_, err = signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+		return err
+	}
+
+	if err := sign(); !errors.Is(err, ErrImportPasswordRequired) {
+		t.Fatalf("Sign without a prompt = %v", err)
+	}
+	SetPasswordPrompt(func(string) ([]byte, error) { return nil, ErrPasswordCanceled })
+	if err := sign(); !errors.Is(err, ErrPasswordCanceled) {
+		t.Fatalf("Sign with a canceled prompt = %v", err)
+	}
+	var failed []string
+	SetAuthFailureHook(func(label string) { failed = append(failed, label) })
+	t.Cleanup(func() { SetAuthFailureHook(nil) })
+	SetPasswordPrompt(func(string) ([]byte, error) { return []byte("wrong"), nil })
+	if err := sign(); !errors.Is(err, ErrImportWrongPassword) {
+		t.Fatalf("Sign with a wrong password = %v", err)
+	}
+	if len(failed) != 1 || failed[0] != "Test" {
+		t.Fatalf("reported failures = %v", failed)
+	}
+
+	var asked []string
+	SetPasswordPrompt(func(name string) ([]byte, error) {
+		asked = append(asked, name)
+		return []byte("password"), nil
+	})
+	for i := 0; i < 2; i++ {
+		if err := sign(); err != nil {
+			t.Fatalf("Sign %d: %v", i, err)
+		}
+	}
+	// The password is asked once and then cached like a token PIN.
+	if len(asked) != 1 || asked[0] != "Test" {
+		t.Errorf("password asked %v", asked)
+	}
+	DefaultPINCache.SetTTL(0)
+	defer DefaultPINCache.SetTTL(DefaultPINCacheTTL)
+	if err := sign(); err != nil || len(asked) != 2 {
+		t.Errorf("Sign without cache = %v, asked %d times", err, len(asked))
+	}
+}
+
+func TestFileStore_DeferredTrash(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewFileStore(dir, []byte("vault"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := s.ImportDeferred(ctx, "Test", bytes.NewReader(userP12(t)), []byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, id.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, trashDir, id.ID+".p12")); err != nil {
+		t.Fatalf("certificate file not in the trash: %v", err)
+	}
+	if err := s.Restore(ctx, id.ID); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, id.ID+".p12")); err != nil {
+		t.Fatalf("certificate file not restored: %v", err)
+	}
+
+	s.mu.Lock()
+	if err := s.moveToTrash(id.ID, time.Now().Add(-TrashRetention-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	s.invalidateLocked()
+	s.mu.Unlock()
+	if trashed, _ := s.ListTrash(ctx); len(trashed) != 0 {
+		t.Fatalf("expired entry listed: %+v", trashed)
+	}
+	if _, err := os.Stat(filepath.Join(dir, trashDir, id.ID+".p12")); !os.IsNotExist(err) {
+		t.Errorf("expired certificate file not purged: %v", err)
+	}
+}
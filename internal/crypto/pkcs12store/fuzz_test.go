@@ -0,0 +1,102 @@
+package pkcs12store
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"os"
+	"testing"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/testutil/certfixtures"
+)
+
+// fuzzSeeds returns the checked-in idCAT-like exports plus generated legacy,
+// modern and BER-encoded PKCS#12 files, with their passwords.
+func fuzzSeeds(f *testing.F) (data [][]byte, passwords []string) {
+	f.Helper()
+	for _, name := range []string{"test/certs/idcat_like_nopass.p12", "test/certs/idcat_full_nopass.p12"} {
+		b, err := os.ReadFile(fixturePath(name))
+		if err != nil {
+			f.Fatalf("failed to read seed: %v", err)
+		}
+		data = append(data, b)
+		passwords = append(passwords, "")
+	}
+	rsaID := certfixtures.New(f, certfixtures.Options{})
+	ecID := certfixtures.New(f, certfixtures.Options{Key: certfixtures.ECDSA})
+	data = append(data,
+		rsaID.LegacyPKCS12(f, "password"),
+		rsaID.BERPKCS12(f, "password"),
+		ecID.BERPKCS12(f, ""),
+		ecID.PKCS12(f, "password"),
+	)
+	passwords = append(passwords, "password", "password", "", "password")
+	return data, passwords
+}
+
+func FuzzNormalizeBER(f *testing.F) {
+	data, _ := fuzzSeeds(f)
+	for _, d := range data {
+		f.Add(d)
+	}
+	f.Add([]byte{0x30, 0x80, 0x04, 0x80, 0x04, 0x01, 0xAA, 0x00, 0x00, 0x00, 0x00})
+	f.Add([]byte{0xA0, 0x80, 0x04, 0x01, 0x01, 0x04, 0x01, 0x02, 0x00, 0x00})
+	f.Add([]byte{0x30, 0x88, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF})
+
+	f.Fuzz(func(t *testing.T, in []byte) {
+		der, err := normalizeBER(in)
+		if err != nil {
+			return
+		}
+		again, err := normalizeBER(der)
+		if err != nil {
+			t.Fatalf("normalized output does not parse: %v", err)
+		}
+		if !bytes.Equal(again, der) {
+			t.Fatal("normalization is not idempotent")
+		}
+	})
+}
+
+func FuzzRecomputePFXMAC(f *testing.F) {
+	data, passwords := fuzzSeeds(f)
+	for i, d := range data {
+		if der, err := normalizeBER(d); err == nil {
+			f.Add(der, passwords[i])
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, in []byte, password string) {
+		out, err := recomputePFXMAC(in, password)
+		if err != nil {
+			return
+		}
+		var pfx pfxForMAC
+		if rest, err := asn1.Unmarshal(out, &pfx); err != nil || len(rest) > 0 {
+			t.Fatalf("rewritten PFX does not parse: %v", err)
+		}
+		again, err := recomputePFXMAC(out, password)
+		if err != nil {
+			t.Fatalf("rewritten PFX rejected: %v", err)
+		}
+		if !bytes.Equal(again, out) {
+			t.Fatal("recomputed MAC is not stable")
+		}
+	})
+}
+
+func FuzzParsePKCS12(f *testing.F) {
+	data, passwords := fuzzSeeds(f)
+	for i, d := range data {
+		f.Add(d, passwords[i])
+	}
+
+	f.Fuzz(func(t *testing.T, in []byte, password string) {
+		signer, cert, _, err := ParsePKCS12(bytes.NewReader(in), password)
+		if err != nil {
+			return
+		}
+		if signer == nil || cert == nil {
+			t.Fatal("ParsePKCS12 succeeded without a key and certificate")
+		}
+	})
+}
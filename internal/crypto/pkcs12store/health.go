@@ -0,0 +1,231 @@
+package pkcs12store
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrKeyMismatch means a private key does not belong to the certificate it
+// is stored with, so every signature made with it would be rejected.
+var ErrKeyMismatch = errors.New("private key does not match certificate")
+
+// ErrStaleReference means the PKCS#11 library or browser profile a token
+// identity was imported from no longer exists.
+var ErrStaleReference = errors.New("PKCS#11 reference no longer resolves")
+
+type HealthStatus int
+
+const (
+	HealthUnknown HealthStatus = iota
+	HealthOK
+	// HealthKeyMismatch: the key and certificate do not form a pair.
+	HealthKeyMismatch
+	// HealthStaleReference: the PKCS#11 library or browser profile a token
+	// identity points to no longer exists.
+	HealthStaleReference
+	// HealthUnavailable: the key could not be reached (vault, keychain).
+	HealthUnavailable
+)
+
+func (h HealthStatus) String() string {
+	switch h {
+	case HealthOK:
+		return "OK"
+	case HealthKeyMismatch:
+		return "Key mismatch"
+	case HealthStaleReference:
+		return "Reference broken"
+	case HealthUnavailable:
+		return "Key unavailable"
+	default:
+		return "Not checked"
+	}
+}
+
+// Health is the result of checking one stored identity.
+type Health struct {
+	Status HealthStatus
+	Detail string
+}
+
+// VerifyKeyPair checks that signer holds the private key for cert by
+// comparing the public keys and signing and verifying a test digest.
+func VerifyKeyPair(signer crypto.Signer, cert *x509.Certificate) error {
+	if !publicKeyMatches(signer, cert) {
+		return ErrKeyMismatch
+	}
+
+	digest := sha256.Sum256([]byte("vocsign key pair check"))
+	sig, err := signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return fmt.Errorf("test signature failed: %w", err)
+	}
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+			return ErrKeyMismatch
+		}
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest[:], sig) {
+			return ErrKeyMismatch
+		}
+	}
+	return nil
+}
+
+func publicKeyMatches(signer crypto.Signer, cert *x509.Certificate) bool {
+	if signer == nil || cert == nil {
+		return false
+	}
+	pub, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	return ok && pub.Equal(cert.PublicKey)
+}
+
+func (s *FileStore) readMeta(id string) (IdentityMeta, error) {
+	var meta IdentityMeta
+	metaBytes, err := os.ReadFile(filepath.Join(s.dir, id+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return meta, ErrNotFound
+		}
+		return meta, fmt.Errorf("failed to read metadata: %w", err)
+	}
+	if err := json.Unmarshal(metaBytes, &meta); err != nil {
+		return meta, fmt.Errorf("failed to unmarshal metadata: %w", err)
+	}
+	return meta, nil
+}
+
+// CheckHealth verifies a stored identity. Vault keys are decrypted and
+// tested against the certificate. Token identities only have their PKCS#11
+// reference checked, since a test signature would need the PIN, certificate
+// files kept with deferred decryption only their presence, since opening
+// them needs the password, and OS keychain identities only have their
+// public key compared, since signing may show a system prompt.
+func (s *FileStore) CheckHealth(ctx context.Context, id string) Health {
+	s.mu.Lock()
+	meta, err := s.readMeta(id)
+	s.mu.Unlock()
+	if err != nil {
+		return Health{Status: HealthUnavailable, Detail: err.Error()}
+	}
+	ident, ok := identityFromMeta(meta)
+	if !ok {
+		return Health{Status: HealthUnavailable, Detail: "stored certificate cannot be parsed"}
+	}
+
+	if meta.PKCS11 != nil {
+		if problem := staleReason(meta.PKCS11); problem != "" {
+			return Health{Status: HealthStaleReference, Detail: problem}
+		}
+		return Health{Status: HealthOK, Detail: "Token reference resolves. The key is checked when signing."}
+	}
+	if meta.Deferred {
+		if _, err := os.Stat(filepath.Join(s.dir, id+".p12")); err != nil {
+			return Health{Status: HealthUnavailable, Detail: "The stored certificate file is missing. Delete it and import the certificate again."}
+		}
+		return Health{Status: HealthOK, Detail: "Certificate file stored with its own password. The key is checked when signing."}
+	}
+
+	signer, err := s.Unlock(ctx, id)
+	if errors.Is(err, ErrWalletLocked) {
+		return Health{Status: HealthUnknown, Detail: "The wallet is locked. Unlock it to check the key."}
+	}
+	if err != nil {
+		return Health{Status: HealthUnavailable, Detail: err.Error()}
+	}
+	if meta.OSNative != nil {
+		if !publicKeyMatches(signer, ident.Cert) {
+			return Health{Status: HealthKeyMismatch, Detail: "The system keychain key does not belong to this certificate."}
+		}
+		return Health{Status: HealthOK, Detail: "System keychain key matches the certificate."}
+	}
+	if err := VerifyKeyPair(signer, ident.Cert); err != nil {
+		if errors.Is(err, ErrKeyMismatch) {
+			return Health{Status: HealthKeyMismatch, Detail: "The private key does not belong to this certificate. Delete it and import the certificate again."}
+		}
+		return Health{Status: HealthUnavailable, Detail: err.Error()}
+	}
+	return Health{Status: HealthOK, Detail: "Private key matches the certificate."}
+}
+
+// staleReason describes why ref no longer resolves, or returns "".
+func staleReason(ref *PKCS11Ref) string {
+	if _, err := os.Stat(ref.LibPath); err != nil {
+		return "PKCS#11 library not found: " + ref.LibPath
+	}
+	if ref.ProfileDir != "" {
+		if st, err := os.Stat(ref.ProfileDir); err != nil || !st.IsDir() {
+			return "Browser profile not found: " + ref.ProfileDir
+		}
+	}
+	return ""
+}
+
+// StaleReferences returns the token identities whose PKCS#11 reference no
+// longer resolves. It only inspects the file system and is cheap to call.
+func (s *FileStore) StaleReferences(ctx context.Context) ([]Identity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read store dir: %w", err)
+	}
+	var stale []Identity
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		meta, err := s.readMeta(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil || meta.PKCS11 == nil || staleReason(meta.PKCS11) == "" {
+			continue
+		}
+		if id, ok := identityFromMeta(meta); ok {
+			stale = append(stale, id)
+		}
+	}
+	return stale, nil
+}
+
+// Relink points a token identity at a new PKCS#11 location, e.g. after the
+// browser profile holding it moved.
+func (s *FileStore) Relink(ctx context.Context, id string, ref PKCS11Ref) error {
+	defer s.notify()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	meta, err := s.readMeta(id)
+	if err != nil {
+		return err
+	}
+	if meta.PKCS11 == nil {
+		return fmt.Errorf("identity %s is not a PKCS#11 reference", id)
+	}
+	meta.PKCS11 = &ref
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	metaPath := filepath.Join(s.dir, id+".json")
+	tmp := metaPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write metadata: %w", err)
+	}
+	if err := os.Rename(tmp, metaPath); err != nil {
+		return err
+	}
+	s.invalidateLocked()
+	return nil
+}
@@ -0,0 +1,104 @@
+package pkcs12store
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func selfSigned(t *testing.T) (*ecdsa.PrivateKey, *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Health Test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, cert
+}
+
+func TestVerifyKeyPair(t *testing.T) {
+	key, cert := selfSigned(t)
+	if err := VerifyKeyPair(key, cert); err != nil {
+		t.Fatalf("matching pair: %v", err)
+	}
+	other, _ := selfSigned(t)
+	if err := VerifyKeyPair(other, cert); !errors.Is(err, ErrKeyMismatch) {
+		t.Fatalf("mismatched pair = %v, want ErrKeyMismatch", err)
+	}
+}
+
+func TestFileStore_CheckHealth(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewFileStore(dir, []byte("vault"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	soft := importFixture(t, s)
+	if h := s.CheckHealth(ctx, soft.ID); h.Status != HealthOK {
+		t.Fatalf("vault identity health = %+v", h)
+	}
+
+	_, cert := selfSigned(t)
+	tokenID := Identity{FriendlyName: "Token", Cert: cert, Fingerprint256: Fingerprint(cert)}
+	if err := s.ImportSystem(ctx, tokenID, filepath.Join(dir, "missing.so"), filepath.Join(dir, "gone-profile"), 1, []byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	ids, _ := s.List(ctx)
+	var tokenStoreID string
+	for _, id := range ids {
+		if id.ID != soft.ID {
+			tokenStoreID = id.ID
+		}
+	}
+	if h := s.CheckHealth(ctx, tokenStoreID); h.Status != HealthStaleReference {
+		t.Fatalf("stale token health = %+v", h)
+	}
+	if stale, err := s.StaleReferences(ctx); err != nil || len(stale) != 1 || stale[0].ID != tokenStoreID {
+		t.Fatalf("StaleReferences = %+v, %v", stale, err)
+	}
+	if _, err := s.Unlock(ctx, tokenStoreID); !errors.Is(err, ErrStaleReference) {
+		t.Fatalf("Unlock of stale token = %v, want ErrStaleReference", err)
+	}
+
+	lib := filepath.Join(dir, "libnss.so")
+	if err := os.WriteFile(lib, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	profile := t.TempDir()
+	if err := s.Relink(ctx, tokenStoreID, PKCS11Ref{LibPath: lib, ProfileDir: profile, Slot: 2, CKAIDHex: "01"}); err != nil {
+		t.Fatalf("Relink: %v", err)
+	}
+	if h := s.CheckHealth(ctx, tokenStoreID); h.Status != HealthOK {
+		t.Fatalf("relinked token health = %+v", h)
+	}
+	if stale, _ := s.StaleReferences(ctx); len(stale) != 0 {
+		t.Fatalf("StaleReferences after Relink = %+v", stale)
+	}
+	if err := s.Relink(ctx, soft.ID, PKCS11Ref{LibPath: lib}); err == nil {
+		t.Fatal("expected Relink of a vault identity to fail")
+	}
+}
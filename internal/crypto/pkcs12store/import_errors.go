@@ -27,6 +27,8 @@ func userImportError(err error) error {
 		return ErrImportInvalidFile
 	case errors.Is(err, ErrImportUnsupported):
 		return ErrImportUnsupported
+	case errors.Is(err, ErrKeyMismatch):
+		return ErrKeyMismatch
 	default:
 		return err
 	}
@@ -45,6 +47,8 @@ func FriendlyImportError(err error) string {
 		return "The selected file is not a valid .p12/.pfx certificate or is corrupted."
 	case ErrImportUnsupported:
 		return "The certificate uses an unsupported format or key type."
+	case ErrKeyMismatch:
+		return "The private key in this file does not belong to its certificate. Export the certificate again from the original browser or device."
 	default:
 		return "Certificate import failed. Please verify the file and password."
 	}
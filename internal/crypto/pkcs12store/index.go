@@ -0,0 +1,153 @@
+package pkcs12store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// storeIndex is the in-memory copy of the parsed identities in a FileStore.
+// It is rebuilt when the store mutates one of its files or when the store
+// directory's modification time changes, e.g. because another VocSign
+// process imported a certificate.
+type storeIndex struct {
+	loaded  bool
+	modTime time.Time
+	byID    map[string]Identity
+	sorted  []Identity
+
+	listeners []func()
+	// changed is set by mutations and cleared when listeners are notified.
+	changed bool
+}
+
+// OnChange registers fn to be called after an identity is imported,
+// deleted, restored or relinked. fn runs on the goroutine that made the
+// change, after the store lock is released, so it may call List.
+func (s *FileStore) OnChange(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.index.listeners = append(s.index.listeners, fn)
+}
+
+// invalidateLocked drops the cached index. Callers must hold s.mu and call
+// notify once they release it.
+func (s *FileStore) invalidateLocked() {
+	s.index.loaded = false
+	s.index.byID = nil
+	s.index.sorted = nil
+	s.index.changed = true
+}
+
+// invalidate is invalidateLocked for callers that do not hold s.mu.
+func (s *FileStore) invalidate() {
+	s.mu.Lock()
+	s.invalidateLocked()
+	s.mu.Unlock()
+	s.notify()
+}
+
+// notify calls the OnChange listeners if the store changed since the last
+// notification. It must be called without holding s.mu.
+func (s *FileStore) notify() {
+	s.mu.Lock()
+	if !s.index.changed {
+		s.mu.Unlock()
+		return
+	}
+	s.index.changed = false
+	listeners := append([]func(){}, s.index.listeners...)
+	s.mu.Unlock()
+	for _, fn := range listeners {
+		fn()
+	}
+}
+
+// loadIndexLocked makes sure the index reflects the store directory and
+// returns it. Callers must hold s.mu.
+func (s *FileStore) loadIndexLocked(ctx context.Context) (*storeIndex, error) {
+	st, err := os.Stat(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read store dir: %w", err)
+	}
+	if s.index.loaded && st.ModTime().Equal(s.index.modTime) {
+		return &s.index, nil
+	}
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read store dir: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) == ".json" {
+			names = append(names, entry.Name())
+		}
+	}
+
+	// Parsing certificates dominates the cost of a cold load, so large
+	// stores are read with one worker per CPU.
+	parsed := make([]*Identity, len(names))
+	work := make(chan int)
+	var wg sync.WaitGroup
+	workers := runtime.NumCPU()
+	if workers > len(names) {
+		workers = len(names)
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				if id, ok := s.parseMetaFile(names[i]); ok {
+					parsed[i] = &id
+				}
+			}
+		}()
+	}
+feed:
+	for i := range names {
+		select {
+		case work <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(work)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.index.byID = make(map[string]Identity, len(names))
+	s.index.sorted = s.index.sorted[:0]
+	for _, id := range parsed {
+		if id == nil {
+			continue
+		}
+		s.index.byID[id.ID] = *id
+		s.index.sorted = append(s.index.sorted, *id)
+	}
+	sort.Slice(s.index.sorted, func(i, j int) bool { return s.index.sorted[i].ID < s.index.sorted[j].ID })
+	s.index.modTime = st.ModTime()
+	s.index.loaded = true
+	return &s.index, nil
+}
+
+func (s *FileStore) parseMetaFile(name string) (Identity, bool) {
+	metaBytes, err := os.ReadFile(filepath.Join(s.dir, name))
+	if err != nil {
+		return Identity{}, false
+	}
+	var meta IdentityMeta
+	if err := json.Unmarshal(metaBytes, &meta); err != nil {
+		return Identity{}, false
+	}
+	return identityFromMeta(meta)
+}
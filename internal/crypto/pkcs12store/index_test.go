@@ -0,0 +1,102 @@
+package pkcs12store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStore_IndexInvalidation(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewFileStore(dir, []byte("vault"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := 0
+	s.OnChange(func() {
+		changes++
+		// Listeners run without the store lock held.
+		if _, err := s.List(ctx); err != nil {
+			t.Errorf("List from listener: %v", err)
+		}
+	})
+
+	if ids, err := s.List(ctx); err != nil || len(ids) != 0 {
+		t.Fatalf("List on empty store = %v, %v", ids, err)
+	}
+	id := importFixture(t, s)
+	if changes != 1 {
+		t.Fatalf("changes after Import = %d", changes)
+	}
+	if ids, _ := s.List(ctx); len(ids) != 1 || ids[0].ID != id.ID {
+		t.Fatalf("List after Import = %+v", ids)
+	}
+	if err := s.Delete(ctx, id.ID); err != nil {
+		t.Fatal(err)
+	}
+	if ids, _ := s.List(ctx); len(ids) != 0 || changes != 2 {
+		t.Fatalf("List after Delete = %d identities, %d changes", len(ids), changes)
+	}
+	if err := s.Restore(ctx, id.ID); err != nil {
+		t.Fatal(err)
+	}
+	if ids, _ := s.List(ctx); len(ids) != 1 || changes != 3 {
+		t.Fatalf("List after Restore = %d identities, %d changes", len(ids), changes)
+	}
+
+	// A second process writing into the directory is picked up through the
+	// directory modification time.
+	other, err := NewFileStore(dir, []byte("vault"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, cert := selfSigned(t)
+	if err := other.ImportSystem(ctx, Identity{FriendlyName: "Other", Cert: cert, Fingerprint256: Fingerprint(cert)}, "", "", 0, nil); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(dir, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if ids, _ := s.List(ctx); len(ids) != 2 {
+		t.Fatalf("List after external import = %d identities", len(ids))
+	}
+	if !s.Exists(Fingerprint(cert)) {
+		t.Fatal("Exists does not see the external import")
+	}
+}
+
+func TestFileStore_ListSkipsBrokenMeta(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewFileStore(dir, []byte("vault"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		_, cert := selfSigned(t)
+		if err := s.ImportSystem(ctx, Identity{Cert: cert, Fingerprint256: Fingerprint(cert)}, "", "", 0, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Unparseable metadata is skipped.
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(dir, future, future); err != nil {
+		t.Fatal(err)
+	}
+	all, err := s.List(ctx)
+	if err != nil || len(all) != 5 {
+		t.Fatalf("List = %d identities, %v; want 5", len(all), err)
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i-1].ID >= all[i].ID {
+			t.Errorf("List is not ordered by ID: %s before %s", all[i-1].ID, all[i].ID)
+		}
+	}
+}
@@ -25,13 +25,22 @@ func ParsePKCS12(r io.Reader, password string) (crypto.Signer, *x509.Certificate
 	}
 
 	attempts := newDefaultAttemptSource().Build(data, password)
-	priv, cert, chain, err := decodeWithAttempts(pkcs12.DecodeChain, attempts, password)
+	priv, cert, chain, err := decodeWithAttempts(decodeChainBounded, attempts, password)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 	return verifySigner(priv, cert, chain)
 }
 
+// decodeChainBounded is pkcs12.DecodeChain for files whose key derivation
+// work is within maxKDFIterations.
+func decodeChainBounded(pfxData []byte, password string) (interface{}, *x509.Certificate, []*x509.Certificate, error) {
+	if err := checkKDFIterations(pfxData); err != nil {
+		return nil, nil, nil, err
+	}
+	return pkcs12.DecodeChain(pfxData, password)
+}
+
 func verifySigner(priv interface{}, cert *x509.Certificate, chain []*x509.Certificate) (crypto.Signer, *x509.Certificate, []*x509.Certificate, error) {
 	signer, ok := priv.(crypto.Signer)
 	if !ok {
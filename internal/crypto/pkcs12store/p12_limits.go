@@ -0,0 +1,149 @@
+package pkcs12store
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+)
+
+// This file bounds the key derivation work a PKCS#12 file can demand.
+//
+// Iteration counts are attacker-controlled and neither go-pkcs12 nor the MAC
+// recomputation caps them, so a crafted file with a count near 2^31 would
+// hang the import. The counts visible before decryption (the MAC, the
+// encrypted safes and shrouded key bags) are checked before decoding.
+
+// maxKDFIterations is far above what exporters use (a few thousand) while
+// keeping the worst case to a fraction of a second per attempt.
+const maxKDFIterations = 1 << 20
+
+var (
+	oidDataContentType          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidEncryptedDataContentType = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 6}
+	oidPKCS8ShroudedKeyBag      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 2}
+	oidPBES2                    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+)
+
+type encryptedDataForLimits struct {
+	Version              int
+	EncryptedContentInfo struct {
+		ContentType                asn1.ObjectIdentifier
+		ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+		EncryptedContent           asn1.RawValue `asn1:"tag:0,optional"`
+	}
+}
+
+type safeBagForLimits struct {
+	ID         asn1.ObjectIdentifier
+	Value      asn1.RawValue   `asn1:"tag:0,explicit"`
+	Attributes []asn1.RawValue `asn1:"set,optional"`
+}
+
+type encryptedPrivateKeyInfoForLimits struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbeParamsForLimits struct {
+	Salt       []byte
+	Iterations int
+}
+
+type pbes2ParamsForLimits struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2ParamsForLimits struct {
+	Salt       asn1.RawValue
+	Iterations int
+	KeyLength  int                      `asn1:"optional"`
+	PRF        pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// checkKDFIterations returns an error if der declares an iteration count
+// above maxKDFIterations. Structures it cannot parse are left for the
+// decoder to reject.
+func checkKDFIterations(der []byte) error {
+	var pfx pfxForMAC
+	if _, err := asn1.Unmarshal(der, &pfx); err != nil {
+		return nil
+	}
+	if pfx.MacData.Iterations > maxKDFIterations {
+		return iterationsError(pfx.MacData.Iterations)
+	}
+
+	var authSafeBytes []byte
+	if _, err := asn1.Unmarshal(pfx.AuthSafe.Content.Bytes, &authSafeBytes); err != nil {
+		return nil
+	}
+	var authSafe []contentInfoForMAC
+	if _, err := asn1.Unmarshal(authSafeBytes, &authSafe); err != nil {
+		return nil
+	}
+	for _, ci := range authSafe {
+		switch {
+		case ci.ContentType.Equal(oidEncryptedDataContentType):
+			var ed encryptedDataForLimits
+			if _, err := asn1.Unmarshal(ci.Content.Bytes, &ed); err != nil {
+				continue
+			}
+			if err := checkAlgorithmIterations(ed.EncryptedContentInfo.ContentEncryptionAlgorithm); err != nil {
+				return err
+			}
+		case ci.ContentType.Equal(oidDataContentType):
+			var data []byte
+			if _, err := asn1.Unmarshal(ci.Content.Bytes, &data); err != nil {
+				continue
+			}
+			var bags []safeBagForLimits
+			if _, err := asn1.Unmarshal(data, &bags); err != nil {
+				continue
+			}
+			for _, bag := range bags {
+				if !bag.ID.Equal(oidPKCS8ShroudedKeyBag) {
+					continue
+				}
+				var info encryptedPrivateKeyInfoForLimits
+				if _, err := asn1.Unmarshal(bag.Value.Bytes, &info); err != nil {
+					continue
+				}
+				if err := checkAlgorithmIterations(info.Algorithm); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkAlgorithmIterations checks the iteration count of a PBES1 or PBES2
+// (PBKDF2) algorithm identifier.
+func checkAlgorithmIterations(alg pkix.AlgorithmIdentifier) error {
+	iterations := 0
+	if alg.Algorithm.Equal(oidPBES2) {
+		var params pbes2ParamsForLimits
+		if _, err := asn1.Unmarshal(alg.Parameters.FullBytes, &params); err != nil {
+			return nil
+		}
+		var kdf pbkdf2ParamsForLimits
+		if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+			return nil
+		}
+		iterations = kdf.Iterations
+	} else {
+		var params pbeParamsForLimits
+		if _, err := asn1.Unmarshal(alg.Parameters.FullBytes, &params); err != nil {
+			return nil
+		}
+		iterations = params.Iterations
+	}
+	if iterations > maxKDFIterations {
+		return iterationsError(iterations)
+	}
+	return nil
+}
+
+func iterationsError(n int) error {
+	return fmt.Errorf("pkcs12: iteration count %d exceeds the limit of %d", n, maxKDFIterations)
+}
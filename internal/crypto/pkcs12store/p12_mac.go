@@ -64,6 +64,9 @@ func recomputePFXMAC(der []byte, password string) ([]byte, error) {
 	if iters < 1 {
 		iters = 1
 	}
+	if iters > maxKDFIterations {
+		return nil, iterationsError(iters)
+	}
 	pfx.MacData.Mac.Digest = computePKCS12MACSHA1(authSafeBytes, pfx.MacData.MacSalt, encodedPassword, iters)
 	return asn1.Marshal(pfx)
 }
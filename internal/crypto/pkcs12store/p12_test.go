@@ -2,27 +2,67 @@ package pkcs12store
 
 import (
 	"bytes"
+	"encoding/asn1"
 	"errors"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/testutil/certfixtures"
+)
+
+var (
+	userOnce sync.Once
+	userData []byte
 )
 
+// userP12 returns a password-protected legacy PKCS#12 for DefaultPerson,
+// generated once so tests that import it twice get the same identity.
+func userP12(t *testing.T) []byte {
+	t.Helper()
+	userOnce.Do(func() {
+		userData = certfixtures.New(t, certfixtures.Options{}).LegacyPKCS12(t, "password")
+	})
+	return userData
+}
+
 func TestParsePKCS12IDCatNoPassword(t *testing.T) {
-	testParsePKCS12(t, fixturePath("test/certs/idcat_like_nopass.p12"), "")
+	data, err := os.ReadFile(fixturePath("test/certs/idcat_like_nopass.p12"))
+	if err != nil {
+		t.Fatalf("failed to read test file: %v", err)
+	}
+	testParsePKCS12(t, data, "")
 }
 
 func TestParsePKCS12PasswordProtected(t *testing.T) {
-	testParsePKCS12(t, fixturePath("test/certs/user.p12"), "password")
+	testParsePKCS12(t, userP12(t), "password")
 }
 
-func TestParsePKCS12WrongPassword(t *testing.T) {
-	data, err := os.ReadFile(fixturePath("test/certs/user.p12"))
-	if err != nil {
-		t.Fatalf("failed to read test file: %v", err)
+func TestParsePKCS12Generated(t *testing.T) {
+	rsaID := certfixtures.New(t, certfixtures.Options{})
+	ecID := certfixtures.New(t, certfixtures.Options{Key: certfixtures.ECDSA})
+	tests := []struct {
+		name     string
+		data     []byte
+		password string
+	}{
+		{"modern ECDSA", ecID.PKCS12(t, "password"), "password"},
+		{"legacy without password", rsaID.LegacyPKCS12(t, ""), ""},
+		{"BER", rsaID.BERPKCS12(t, "password"), "password"},
+		{"BER without password", ecID.BERPKCS12(t, ""), ""},
 	}
-	if _, _, _, err := ParsePKCS12(bytes.NewReader(data), "wrong-password"); err == nil {
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testParsePKCS12(t, tt.data, tt.password)
+		})
+	}
+}
+
+func TestParsePKCS12WrongPassword(t *testing.T) {
+	if _, _, _, err := ParsePKCS12(bytes.NewReader(userP12(t)), "wrong-password"); err == nil {
 		t.Fatal("expected parse error for wrong password")
 	} else if !errors.Is(err, ErrImportWrongPassword) {
 		t.Fatalf("expected ErrImportWrongPassword, got: %v", err)
@@ -30,17 +70,39 @@ func TestParsePKCS12WrongPassword(t *testing.T) {
 }
 
 func TestParsePKCS12PasswordRequired(t *testing.T) {
-	data, err := os.ReadFile(fixturePath("test/certs/user.p12"))
-	if err != nil {
-		t.Fatalf("failed to read test file: %v", err)
-	}
-	if _, _, _, err := ParsePKCS12(bytes.NewReader(data), ""); err == nil {
+	if _, _, _, err := ParsePKCS12(bytes.NewReader(userP12(t)), ""); err == nil {
 		t.Fatal("expected parse error for missing password")
 	} else if !errors.Is(err, ErrImportPasswordRequired) {
 		t.Fatalf("expected ErrImportPasswordRequired, got: %v", err)
 	}
 }
 
+func TestParsePKCS12IterationLimit(t *testing.T) {
+	var pfx pfxForMAC
+	if _, err := asn1.Unmarshal(userP12(t), &pfx); err != nil {
+		t.Fatal(err)
+	}
+	pfx.MacData.Iterations = 1 << 30
+	data, err := asn1.Marshal(pfx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, _, err := ParsePKCS12(bytes.NewReader(data), "password")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrImportUnsupported) {
+			t.Fatalf("expected ErrImportUnsupported, got: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("ParsePKCS12 did not reject a huge iteration count")
+	}
+}
+
 func TestParsePKCS12InvalidFile(t *testing.T) {
 	data := []byte("not-a-pkcs12")
 	if _, _, _, err := ParsePKCS12(bytes.NewReader(data), ""); err == nil {
@@ -50,14 +112,9 @@ func TestParsePKCS12InvalidFile(t *testing.T) {
 	}
 }
 
-func testParsePKCS12(t *testing.T, p12Path, password string) {
+func testParsePKCS12(t *testing.T, data []byte, password string) {
 	t.Helper()
 
-	data, err := os.ReadFile(p12Path)
-	if err != nil {
-		t.Fatalf("failed to read test file: %v", err)
-	}
-
 	signer, cert, chain, err := ParsePKCS12(bytes.NewReader(data), password)
 	if err != nil {
 		t.Fatalf("ParsePKCS12 failed: %v", err)
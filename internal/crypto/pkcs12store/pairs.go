@@ -0,0 +1,121 @@
+package pkcs12store
+
+import (
+	"crypto/x509"
+	"strings"
+	"time"
+)
+
+// pairIssuedWithin bounds how far apart the two certificates of a pair were
+// issued, so a renewed certificate is not paired with an older one.
+const pairIssuedWithin = 24 * time.Hour
+
+// pairCNSuffixes are the role markers some CAs append to the common name of
+// each certificate of a pair, e.g. the DNIe's "(AUTENTICACIÓN)" and
+// "(FIRMA)".
+var pairCNSuffixes = []string{"(AUTENTICACIÓN)", "(AUTENTICACION)", "(FIRMA)"}
+
+// Pair is a signature certificate and an authentication certificate issued
+// together to the same holder, as the DNIe and some CAs do. Signature has
+// nonRepudiation (contentCommitment) in its key usage and Authentication
+// only digitalSignature.
+type Pair struct {
+	Signature      Identity
+	Authentication Identity
+}
+
+// CollapsePairs returns ids without the authentication certificate of each
+// pair, so the pair shows as one identity, and the pairs by the ID of their
+// signature certificate. The order of ids is kept.
+func CollapsePairs(ids []Identity) ([]Identity, map[string]Pair) {
+	pairs := FindPairs(ids)
+	if len(pairs) == 0 {
+		return ids, nil
+	}
+	bySignature := make(map[string]Pair, len(pairs))
+	hidden := make(map[string]bool, len(pairs))
+	for _, p := range pairs {
+		bySignature[p.Signature.ID] = p
+		hidden[p.Authentication.ID] = true
+	}
+	out := make([]Identity, 0, len(ids)-len(pairs))
+	for _, id := range ids {
+		if !hidden[id.ID] {
+			out = append(out, id)
+		}
+	}
+	return out, bySignature
+}
+
+// FindPairs returns the signature and authentication pairs among ids. The
+// certificates of a pair have the same issuer and the same subject, apart
+// from a role marker in the common name, and were issued within a day of
+// each other.
+func FindPairs(ids []Identity) []Pair {
+	type group struct{ signature, authentication []Identity }
+	groups := make(map[string]*group)
+	var keys []string
+	for _, id := range ids {
+		if id.Cert == nil {
+			continue
+		}
+		key := string(id.Cert.RawIssuer) + "\x00" + holderName(id.Cert)
+		g := groups[key]
+		if g == nil {
+			g = &group{}
+			groups[key] = g
+			keys = append(keys, key)
+		}
+		switch {
+		case isSignatureCert(id.Cert):
+			g.signature = append(g.signature, id)
+		case isAuthenticationCert(id.Cert):
+			g.authentication = append(g.authentication, id)
+		}
+	}
+
+	var pairs []Pair
+	for _, key := range keys {
+		g := groups[key]
+		used := make([]bool, len(g.authentication))
+		for _, sig := range g.signature {
+			for i, auth := range g.authentication {
+				if used[i] || !issuedTogether(sig.Cert, auth.Cert) {
+					continue
+				}
+				used[i] = true
+				pairs = append(pairs, Pair{Signature: sig, Authentication: auth})
+				break
+			}
+		}
+	}
+	return pairs
+}
+
+// holderName is the subject of cert without a role marker in its common
+// name.
+func holderName(cert *x509.Certificate) string {
+	name := cert.Subject
+	cn := strings.TrimSpace(name.CommonName)
+	for _, suffix := range pairCNSuffixes {
+		if strings.HasSuffix(strings.ToUpper(cn), suffix) {
+			cn = strings.TrimSpace(cn[:len(cn)-len(suffix)])
+			break
+		}
+	}
+	name.CommonName = cn
+	return name.String()
+}
+
+func isSignatureCert(cert *x509.Certificate) bool {
+	return cert.KeyUsage&x509.KeyUsageContentCommitment != 0
+}
+
+func isAuthenticationCert(cert *x509.Certificate) bool {
+	return cert.KeyUsage&x509.KeyUsageDigitalSignature != 0 && cert.KeyUsage&x509.KeyUsageContentCommitment == 0
+}
+
+func issuedTogether(a, b *x509.Certificate) bool {
+	d := a.NotBefore.Sub(b.NotBefore)
+	return d >= -pairIssuedWithin && d <= pairIssuedWithin
+}
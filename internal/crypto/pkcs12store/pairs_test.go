@@ -0,0 +1,62 @@
+package pkcs12store
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"testing"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/testutil/certfixtures"
+)
+
+func pairSubject(role string) pkix.Name {
+	name := certfixtures.CitizenSubject(certfixtures.DefaultPerson)
+	for i, a := range name.ExtraNames {
+		if a.Type.Equal(asn1.ObjectIdentifier{2, 5, 4, 3}) && role != "" {
+			name.ExtraNames[i].Value = a.Value.(string) + " " + role
+		}
+	}
+	return name
+}
+
+func TestFindPairs(t *testing.T) {
+	ca := certfixtures.NewCA(t, "Test DNIe CA")
+	issued := time.Now().Add(-time.Hour)
+	identity := func(id, role string, usage x509.KeyUsage, notBefore time.Time) Identity {
+		c := ca.Issue(t, certfixtures.Options{Key: certfixtures.ECDSA, Subject: pairSubject(role), KeyUsage: usage, NotBefore: notBefore})
+		return Identity{ID: id, FriendlyName: id, Cert: c.Cert}
+	}
+	sign := identity("sign", "(FIRMA)", x509.KeyUsageContentCommitment, issued)
+	auth := identity("auth", "(AUTENTICACIÓN)", x509.KeyUsageDigitalSignature, issued.Add(-time.Minute))
+	// A renewed authentication certificate is not paired with the old
+	// signature certificate.
+	oldAuth := identity("old-auth", "(AUTENTICACIÓN)", x509.KeyUsageDigitalSignature, issued.Add(-2*365*24*time.Hour))
+	// Another holder's certificate of the same CA is not paired either.
+	otherHolder := certfixtures.Person{GivenName: "ANNA", Surname1: "ROCA", ID: "87654321X"}
+	other := Identity{ID: "other", Cert: ca.Issue(t, certfixtures.Options{Key: certfixtures.ECDSA, Subject: certfixtures.CitizenSubject(otherHolder), KeyUsage: x509.KeyUsageContentCommitment, NotBefore: issued}).Cert}
+	otherCA := certfixtures.NewCA(t, "Another CA").Issue(t, certfixtures.Options{Key: certfixtures.ECDSA, Subject: pairSubject("(AUTENTICACIÓN)"), KeyUsage: x509.KeyUsageDigitalSignature, NotBefore: issued})
+	foreign := Identity{ID: "foreign", Cert: otherCA.Cert}
+
+	ids := []Identity{other, oldAuth, auth, foreign, sign}
+	pairs := FindPairs(ids)
+	if len(pairs) != 1 || pairs[0].Signature.ID != "sign" || pairs[0].Authentication.ID != "auth" {
+		t.Fatalf("FindPairs = %+v", pairs)
+	}
+
+	collapsed, bySignature := CollapsePairs(ids)
+	var got []string
+	for _, id := range collapsed {
+		got = append(got, id.ID)
+	}
+	if want := []string{"other", "old-auth", "foreign", "sign"}; len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] || got[3] != want[3] {
+		t.Errorf("CollapsePairs = %v, want %v", got, want)
+	}
+	if p, ok := bySignature["sign"]; !ok || p.Authentication.ID != "auth" {
+		t.Errorf("pairs by signature = %+v", bySignature)
+	}
+
+	if _, bySignature := CollapsePairs([]Identity{sign, oldAuth}); bySignature != nil {
+		t.Errorf("pairs without an authentication certificate = %+v", bySignature)
+	}
+}
@@ -0,0 +1,176 @@
+package pkcs12store
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultPINCacheTTL is how long a hardware token PIN is remembered after a
+// successful login unless the user configures otherwise.
+const DefaultPINCacheTTL = 5 * time.Minute
+
+var (
+	ErrPINRequired  = errors.New("token PIN required")
+	ErrPINCanceled  = errors.New("token PIN entry canceled")
+	ErrPINIncorrect = errors.New("token PIN incorrect")
+)
+
+// DefaultPINCache is shared by every PKCS#11 signer so a PIN typed once can
+// be reused when signing several proposals in a row.
+var DefaultPINCache = NewPINCache(DefaultPINCacheTTL)
+
+// PINPromptFunc asks the user for the PIN of the token identified by label.
+// It returns ErrPINCanceled if the user declines.
+type PINPromptFunc func(label string) ([]byte, error)
+
+var (
+	pinPromptMu sync.RWMutex
+	pinPrompt   PINPromptFunc
+)
+
+// SetPINPrompt installs the function used to ask for token PINs.
+func SetPINPrompt(fn PINPromptFunc) {
+	pinPromptMu.Lock()
+	defer pinPromptMu.Unlock()
+	pinPrompt = fn
+}
+
+func promptPIN(label string) ([]byte, error) {
+	pinPromptMu.RLock()
+	fn := pinPrompt
+	pinPromptMu.RUnlock()
+	if fn == nil {
+		return nil, ErrPINRequired
+	}
+	return fn(label)
+}
+
+var (
+	authFailureMu   sync.RWMutex
+	authFailureHook func(label string)
+)
+
+// SetAuthFailureHook installs a function called with the token label or
+// certificate name each time the user types a PIN or password that is
+// rejected, so failed attempts can be recorded.
+func SetAuthFailureHook(fn func(label string)) {
+	authFailureMu.Lock()
+	defer authFailureMu.Unlock()
+	authFailureHook = fn
+}
+
+func reportAuthFailure(label string) {
+	authFailureMu.RLock()
+	fn := authFailureHook
+	authFailureMu.RUnlock()
+	if fn != nil {
+		fn(label)
+	}
+}
+
+// PINCache remembers token PINs in memory locked against swapping and wipes
+// them once the TTL elapses. A zero TTL disables caching.
+type PINCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*pinEntry
+}
+
+type pinEntry struct {
+	pin   []byte
+	timer *time.Timer
+}
+
+func NewPINCache(ttl time.Duration) *PINCache {
+	return &PINCache{
+		ttl:     ttl,
+		entries: make(map[string]*pinEntry),
+	}
+}
+
+// SetTTL changes the retention period for PINs stored from now on. Lowering
+// it to zero also forgets every cached PIN.
+func (c *PINCache) SetTTL(ttl time.Duration) {
+	c.mu.Lock()
+	c.ttl = ttl
+	c.mu.Unlock()
+	if ttl <= 0 {
+		c.Clear()
+	}
+}
+
+// Get returns a copy of the cached PIN for key. Callers should wipe the copy
+// once it is no longer needed.
+func (c *PINCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	out := make([]byte, len(e.pin))
+	copy(out, e.pin)
+	return out, true
+}
+
+// Put stores a copy of pin for key and restarts its expiry timer.
+func (c *PINCache) Put(key string, pin []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ttl <= 0 {
+		return
+	}
+	c.forgetLocked(key)
+
+	buf := make([]byte, len(pin))
+	if err := lockMemory(buf); err != nil {
+		log.Printf("WARNING: could not lock PIN memory: %v", err)
+	}
+	copy(buf, pin)
+	e := &pinEntry{pin: buf}
+	e.timer = time.AfterFunc(c.ttl, func() { c.expire(key, e) })
+	c.entries[key] = e
+}
+
+// Forget wipes the PIN cached for key, if any.
+func (c *PINCache) Forget(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.forgetLocked(key)
+}
+
+// Clear wipes every cached PIN.
+func (c *PINCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		c.forgetLocked(key)
+	}
+}
+
+func (c *PINCache) expire(key string, e *pinEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries[key] == e {
+		c.forgetLocked(key)
+	}
+}
+
+func (c *PINCache) forgetLocked(key string) {
+	e, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	e.timer.Stop()
+	wipe(e.pin)
+	_ = unlockMemory(e.pin)
+	delete(c.entries, key)
+}
+
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
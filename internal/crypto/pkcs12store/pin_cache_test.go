@@ -0,0 +1,60 @@
+package pkcs12store
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPINCache_PutGetForget(t *testing.T) {
+	c := NewPINCache(time.Minute)
+	pin := []byte("1234")
+	c.Put("token", pin)
+
+	// The cache must keep its own copy.
+	pin[0] = 'x'
+	got, ok := c.Get("token")
+	if !ok || string(got) != "1234" {
+		t.Fatalf("Get = %q, %v; want 1234, true", got, ok)
+	}
+
+	// Callers wiping their copy must not affect the cache.
+	wipe(got)
+	if again, _ := c.Get("token"); string(again) != "1234" {
+		t.Fatalf("cached PIN changed after wiping a copy: %q", again)
+	}
+
+	c.Forget("token")
+	if _, ok := c.Get("token"); ok {
+		t.Fatal("expected PIN to be forgotten")
+	}
+}
+
+func TestPINCache_Expiry(t *testing.T) {
+	c := NewPINCache(20 * time.Millisecond)
+	c.Put("token", []byte("1234"))
+	time.Sleep(100 * time.Millisecond)
+	if _, ok := c.Get("token"); ok {
+		t.Fatal("expected PIN to expire")
+	}
+}
+
+func TestPINCache_Disabled(t *testing.T) {
+	c := NewPINCache(time.Minute)
+	c.Put("token", []byte("1234"))
+	c.SetTTL(0)
+	if _, ok := c.Get("token"); ok {
+		t.Fatal("disabling the cache must forget stored PINs")
+	}
+	c.Put("token", []byte("1234"))
+	if _, ok := c.Get("token"); ok {
+		t.Fatal("expected Put to be a no-op with zero TTL")
+	}
+}
+
+func TestPromptPIN_NoPrompt(t *testing.T) {
+	SetPINPrompt(nil)
+	if _, err := promptPIN("token"); !errors.Is(err, ErrPINRequired) {
+		t.Fatalf("expected ErrPINRequired, got %v", err)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !unix && !windows
+
+package pkcs12store
+
+func lockMemory(b []byte) error { return nil }
+
+func unlockMemory(b []byte) error { return nil }
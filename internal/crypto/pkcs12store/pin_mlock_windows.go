@@ -0,0 +1,23 @@
+//go:build windows
+
+package pkcs12store
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+func lockMemory(b []byte) error {
+	if len(b) == 0 {
+		return nil
+	}
+	return windows.VirtualLock(uintptr(unsafe.Pointer(&b[0])), uintptr(len(b)))
+}
+
+func unlockMemory(b []byte) error {
+	if len(b) == 0 {
+		return nil
+	}
+	return windows.VirtualUnlock(uintptr(unsafe.Pointer(&b[0])), uintptr(len(b)))
+}
@@ -0,0 +1,106 @@
+package pkcs12store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/certs"
+	"golang.org/x/text/unicode/norm"
+)
+
+// SearchField is the identity field a search term was found in.
+type SearchField string
+
+const (
+	FieldName         SearchField = "Name"
+	FieldHolder       SearchField = "Holder"
+	FieldDNI          SearchField = "DNI/NIE"
+	FieldIssuer       SearchField = "Issuer"
+	FieldOrganization SearchField = "Organization"
+	FieldFingerprint  SearchField = "SHA-256"
+)
+
+// minFingerprintPrefix is the shortest term matched against fingerprints,
+// so a short number in a query does not match every fingerprint.
+const minFingerprintPrefix = 4
+
+// SearchMatch is a search term found in a field of an identity. Start and
+// End are byte offsets of the match in Value.
+type SearchMatch struct {
+	Field      SearchField
+	Value      string
+	Start, End int
+}
+
+// Search looks for every space-separated term of query in the friendly
+// name, holder name, DNI/NIE, issuer and organization of id, ignoring case
+// and accents, and at the start of its SHA-256 fingerprint. It returns the
+// matches, or nil if some term matches nothing. A blank query matches
+// nothing either.
+func Search(id Identity, query string) []SearchMatch {
+	terms := strings.Fields(query)
+	if len(terms) == 0 || id.Cert == nil {
+		return nil
+	}
+	info := certs.CachedSpanishIdentity(id.Cert)
+	fields := []struct {
+		field SearchField
+		value string
+	}{
+		{FieldName, id.FriendlyName},
+		{FieldHolder, strings.TrimSpace(info.Nom + " " + strings.Join(info.Cognoms, " "))},
+		{FieldDNI, info.DNI},
+		{FieldIssuer, info.Issuer},
+		{FieldOrganization, info.Organization},
+	}
+	sum := sha256.Sum256(id.Cert.Raw)
+	fingerprint := hex.EncodeToString(sum[:])
+
+	var matches []SearchMatch
+	for _, term := range terms {
+		found := false
+		needle, _ := fold(term)
+		for _, f := range fields {
+			if f.value == "" {
+				continue
+			}
+			hay, offsets := fold(f.value)
+			if i := strings.Index(hay, needle); i >= 0 {
+				matches = append(matches, SearchMatch{Field: f.field, Value: f.value, Start: offsets[i], End: offsets[i+len(needle)]})
+				found = true
+			}
+		}
+		prefix := strings.ToLower(strings.ReplaceAll(term, ":", ""))
+		if len(prefix) >= minFingerprintPrefix && strings.HasPrefix(fingerprint, prefix) {
+			matches = append(matches, SearchMatch{Field: FieldFingerprint, Value: fingerprint, Start: 0, End: len(prefix)})
+			found = true
+		}
+		if !found {
+			return nil
+		}
+	}
+	return matches
+}
+
+// fold lowercases s and strips its accents. offsets maps each byte offset
+// of the folded string, and its length, to the offset in s of the rune it
+// came from.
+func fold(s string) (string, []int) {
+	var b strings.Builder
+	offsets := make([]int, 0, len(s)+1)
+	for i, r := range s {
+		for _, d := range norm.NFD.String(string(r)) {
+			if unicode.Is(unicode.Mn, d) {
+				continue
+			}
+			n := b.Len()
+			b.WriteRune(unicode.ToLower(d))
+			for range b.Len() - n {
+				offsets = append(offsets, i)
+			}
+		}
+	}
+	return b.String(), append(offsets, len(s))
+}
@@ -0,0 +1,62 @@
+package pkcs12store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/testutil/certfixtures"
+)
+
+func TestSearch(t *testing.T) {
+	holder := certfixtures.Person{GivenName: "JOSÉ", Surname1: "NÚÑEZ", Surname2: "PUIG", ID: "12345678Z"}
+	c := certfixtures.NewCA(t, "AC FNMT Usuarios").Issue(t, certfixtures.Options{Key: certfixtures.ECDSA, Subject: certfixtures.CitizenSubject(holder)})
+	id := Identity{ID: "a", FriendlyName: "Josep (feina)", Cert: c.Cert}
+	sum := sha256.Sum256(c.Cert.Raw)
+	fingerprint := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		query string
+		field SearchField
+		match string // the matched text, "" if the query matches nothing
+	}{
+		{"feina", FieldName, "feina"},
+		{"jose", FieldHolder, "JOSÉ"},
+		{"nunez", FieldHolder, "NÚÑEZ"},
+		{"5678z", FieldDNI, "5678Z"},
+		{"fnmt", FieldIssuer, "FNMT"},
+		{fingerprint[:8], FieldFingerprint, fingerprint[:8]},
+		{fingerprint[:2] + ":" + fingerprint[2:6], FieldFingerprint, fingerprint[:6]},
+		{"fnmt garcia", "", ""},
+		{"   ", "", ""},
+	}
+	for _, tt := range tests {
+		matches := Search(id, tt.query)
+		if tt.match == "" {
+			if matches != nil {
+				t.Errorf("Search(%q) = %+v, want no match", tt.query, matches)
+			}
+			continue
+		}
+		found := false
+		for _, m := range matches {
+			if m.Field == tt.field && m.Value[m.Start:m.End] == tt.match {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Search(%q) = %+v, want %s %q", tt.query, matches, tt.field, tt.match)
+		}
+	}
+
+	// Every term must match, in any field.
+	if m := Search(id, "josé fnmt"); len(m) < 2 {
+		t.Errorf("Search with two terms = %+v", m)
+	}
+	// Short numbers do not match fingerprints.
+	for _, m := range Search(id, "1234") {
+		if m.Field == FieldFingerprint && fingerprint[:4] != "1234" {
+			t.Errorf("short term matched the fingerprint: %+v", m)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package pkcs12store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// signerDataFile holds the remembered signer data of every certificate,
+// encrypted with the vault password like the private keys.
+const signerDataFile = "signer_data.enc"
+
+// SignerData is personal data a signer typed or corrected when signing with
+// a certificate, remembered so later signings with it need no retyping.
+// Empty fields were not typed and come from the certificate.
+type SignerData struct {
+	Nom       string `json:"nom,omitempty"`
+	Cognom1   string `json:"cognom1,omitempty"`
+	Cognom2   string `json:"cognom2,omitempty"`
+	BirthDate string `json:"birthDate,omitempty"`
+}
+
+// IsZero reports whether d holds nothing to remember.
+func (d SignerData) IsZero() bool {
+	return d == SignerData{}
+}
+
+func (s *FileStore) readSignerDataLocked() (map[string]SignerData, error) {
+	all := make(map[string]SignerData)
+	enc, err := os.ReadFile(filepath.Join(s.dir, signerDataFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return all, nil
+		}
+		return nil, fmt.Errorf("failed to read signer data: %w", err)
+	}
+	data, err := s.decryptVault(enc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt signer data: %w", err)
+	}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("failed to decode signer data: %w", err)
+	}
+	return all, nil
+}
+
+func (s *FileStore) writeSignerDataLocked(all map[string]SignerData) error {
+	path := filepath.Join(s.dir, signerDataFile)
+	if len(all) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if s.locked {
+		return ErrWalletLocked
+	}
+	data, err := json.Marshal(all)
+	if err != nil {
+		return fmt.Errorf("failed to marshal signer data: %w", err)
+	}
+	enc, err := EncryptData(data, s.vaultPW)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt signer data: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, enc, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// SignerData returns the data remembered for the certificate with the given
+// fingerprint, and whether there is any.
+func (s *FileStore) SignerData(fingerprint [32]byte) (SignerData, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all, err := s.readSignerDataLocked()
+	if err != nil {
+		return SignerData{}, false, err
+	}
+	d, ok := all[fmt.Sprintf("%x", fingerprint)]
+	return d, ok, nil
+}
+
+// SaveSignerData remembers d for the certificate with the given
+// fingerprint, replacing what was stored. A zero d forgets it.
+func (s *FileStore) SaveSignerData(fingerprint [32]byte, d SignerData) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all, err := s.readSignerDataLocked()
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("%x", fingerprint)
+	if d.IsZero() {
+		delete(all, key)
+	} else {
+		all[key] = d
+	}
+	return s.writeSignerDataLocked(all)
+}
+
+// ClearSignerData forgets the signer data of every certificate.
+func (s *FileStore) ClearSignerData() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writeSignerDataLocked(nil)
+}
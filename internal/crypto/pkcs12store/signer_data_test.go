@@ -0,0 +1,69 @@
+package pkcs12store
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStore_SignerData(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewFileStore(dir, []byte("vault"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fp1 := [32]byte{1}
+	fp2 := [32]byte{2}
+
+	if _, ok, err := s.SignerData(fp1); err != nil || ok {
+		t.Fatalf("SignerData on empty store = %v, %v", ok, err)
+	}
+
+	want := SignerData{Nom: "Núria", BirthDate: "1990-05-15"}
+	if err := s.SaveSignerData(fp1, want); err != nil {
+		t.Fatalf("SaveSignerData: %v", err)
+	}
+	if err := s.SaveSignerData(fp2, SignerData{Cognom1: "Puig"}); err != nil {
+		t.Fatalf("SaveSignerData: %v", err)
+	}
+	if got, ok, err := s.SignerData(fp1); err != nil || !ok || got != want {
+		t.Fatalf("SignerData = %+v, %v, %v", got, ok, err)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, signerDataFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, plain := range []string{"1990-05-15", "Puig"} {
+		if bytes.Contains(raw, []byte(plain)) {
+			t.Fatalf("signer data file holds %q in clear", plain)
+		}
+	}
+
+	// Another store with the wrong vault password cannot read it.
+	other, _ := NewFileStore(dir, []byte("other"))
+	if _, _, err := other.SignerData(fp1); err == nil {
+		t.Fatal("SignerData with wrong vault password succeeded")
+	}
+
+	if err := s.SaveSignerData(fp1, SignerData{}); err != nil {
+		t.Fatalf("SaveSignerData zero: %v", err)
+	}
+	if _, ok, _ := s.SignerData(fp1); ok {
+		t.Fatal("zero SignerData was not forgotten")
+	}
+	if _, ok, _ := s.SignerData(fp2); !ok {
+		t.Fatal("forgetting one certificate dropped another")
+	}
+
+	if err := s.ClearSignerData(); err != nil {
+		t.Fatalf("ClearSignerData: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, signerDataFile)); !os.IsNotExist(err) {
+		t.Fatalf("signer data file still exists: %v", err)
+	}
+	if _, ok, err := s.SignerData(fp2); err != nil || ok {
+		t.Fatalf("SignerData after clear = %v, %v", ok, err)
+	}
+}
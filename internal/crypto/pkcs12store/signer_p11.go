@@ -67,6 +67,95 @@ type PKCS11Signer struct {
 	Slot       uint
 	ID         []byte
 	PublicKey  crypto.PublicKey
+	// TokenLabel names the token when asking the user for its PIN.
+	TokenLabel string
+}
+
+// pinCacheKey identifies the token this signer lives on.
+func (s *PKCS11Signer) pinCacheKey() string {
+	return fmt.Sprintf("%s#%d", s.ProfileDir, s.Slot)
+}
+
+// login authenticates the session as the token asks for in its flags: not
+// at all, through its own PIN pad, or with the cached PIN or, failing that,
+// one asked from the user. The PIN is never guessed, since every wrong try
+// counts against the token's retries; NSS soft tokens without a password
+// do not require a login. A PIN that works is cached for later signatures.
+func (s *PKCS11Signer) login(p *pkcs11.Ctx, session pkcs11.SessionHandle) error {
+	info, err := p.GetTokenInfo(s.Slot)
+	if err != nil {
+		return fmt.Errorf("failed to read token info: %w", err)
+	}
+	if info.Flags&pkcs11.CKF_LOGIN_REQUIRED == 0 {
+		return nil
+	}
+	label := s.TokenLabel
+	if label == "" {
+		label = fmt.Sprintf("slot %d", s.Slot)
+	}
+	if info.Flags&pkcs11.CKF_PROTECTED_AUTHENTICATION_PATH != 0 {
+		// The PIN is typed on the reader; the module takes no PIN.
+		if err := p.Login(session, pkcs11.CKU_USER, ""); err != nil && err != pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN) {
+			if isPINError(err) {
+				reportAuthFailure(label)
+				return ErrPINIncorrect
+			}
+			return fmt.Errorf("token login failed: %w", err)
+		}
+		return nil
+	}
+
+	key := s.pinCacheKey()
+	pin, cached := DefaultPINCache.Get(key)
+	defer func() { wipe(pin) }()
+	if cached {
+		err := p.Login(session, pkcs11.CKU_USER, pinString(pin))
+		if err == nil || err == pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN) {
+			return nil
+		}
+		if !isPINError(err) {
+			return fmt.Errorf("token login failed: %w", err)
+		}
+		log.Printf("DEBUG: cached PIN rejected for slot %d, asking again", s.Slot)
+		DefaultPINCache.Forget(key)
+	}
+
+	prompt := label
+	if info.Flags&pkcs11.CKF_USER_PIN_FINAL_TRY != 0 {
+		prompt += " (last try before the PIN is blocked)"
+	}
+	wipe(pin)
+	pin, err = promptPIN(prompt)
+	if err != nil {
+		return err
+	}
+	if err := p.Login(session, pkcs11.CKU_USER, pinString(pin)); err != nil && err != pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN) {
+		if isPINError(err) {
+			reportAuthFailure(label)
+			return ErrPINIncorrect
+		}
+		return fmt.Errorf("token login failed: %w", err)
+	}
+	DefaultPINCache.Put(key, pin)
+	return nil
+}
+
+// pinString returns pin as a string without copying it, so no copy is left
+// on the heap that cannot be wiped. The string must not outlive pin.
+func pinString(pin []byte) string {
+	return unsafe.String(unsafe.SliceData(pin), len(pin))
+}
+
+// isPINError reports whether a login failed because of the PIN the user
+// typed, as opposed to the token or the module.
+func isPINError(err error) bool {
+	switch err {
+	case pkcs11.Error(pkcs11.CKR_PIN_INCORRECT),
+		pkcs11.Error(pkcs11.CKR_PIN_INVALID),
+		pkcs11.Error(pkcs11.CKR_PIN_LEN_RANGE):
+		return true
+	}
+	return false
 }
 
 func (s *PKCS11Signer) Public() crypto.PublicKey {
@@ -110,8 +199,8 @@ func (s *PKCS11Signer) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpt
 		}
 	}()
 
-	if err := p.Login(session, pkcs11.CKU_USER, ""); err != nil {
-		log.Printf("DEBUG: PKCS#11 login (may be expected for NSS): %v", err)
+	if err := s.login(p, session); err != nil {
+		return nil, err
 	}
 
 	if err := p.FindObjectsInit(session, []*pkcs11.Attribute{
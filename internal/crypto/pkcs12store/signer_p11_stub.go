@@ -15,6 +15,7 @@ type PKCS11Signer struct {
 	Slot       uint
 	ID         []byte
 	PublicKey  crypto.PublicKey
+	TokenLabel string
 }
 
 func (s *PKCS11Signer) Public() crypto.PublicKey {
@@ -15,15 +15,37 @@ type Identity struct {
 	Chain          []*x509.Certificate
 	Fingerprint256 [32]byte
 	Signer         crypto.Signer
+	// Deferred is set when the certificate file is kept with its own
+	// password, asked at each signature.
+	Deferred bool
 }
 
 type Store interface {
 	List(ctx context.Context) ([]Identity, error)
 	Import(ctx context.Context, name string, r io.Reader, password []byte) (*Identity, error)
+	ImportDeferred(ctx context.Context, name string, r io.Reader, password []byte) (*Identity, error)
 	ImportSystem(ctx context.Context, id Identity, libPath, profileDir string, slot uint, ckaID []byte) error
 	Delete(ctx context.Context, id string) error
+	ListTrash(ctx context.Context) ([]TrashedIdentity, error)
+	Restore(ctx context.Context, id string) error
+	Purge(ctx context.Context, id string) error
+	EmptyTrash(ctx context.Context) error
+	PurgeExpiredTrash(ctx context.Context) (int, error)
+	CheckHealth(ctx context.Context, id string) Health
+	Relink(ctx context.Context, id string, ref PKCS11Ref) error
+	StaleReferences(ctx context.Context) ([]Identity, error)
 	Unlock(ctx context.Context, id string) (crypto.Signer, error)
 	Exists(fingerprint [32]byte) bool
+	SignerData(fingerprint [32]byte) (SignerData, bool, error)
+	SaveSignerData(fingerprint [32]byte, d SignerData) error
+	ClearSignerData() error
+	VaultStatus() VaultStatus
+	BindVault(sealer KeySealer) error
+	UnbindVault() error
+	ForgetVaultBinding() error
+	LockVault()
+	Locked() bool
+	UnlockVault(sealer KeySealer) VaultStatus
 }
 
 var ErrNotFound = errors.New("identity not found")
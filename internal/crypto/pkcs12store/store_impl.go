@@ -1,6 +1,7 @@
 package pkcs12store
 
 import (
+	"bytes"
 	"context"
 	"crypto"
 	"crypto/x509"
@@ -12,16 +13,21 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"strings"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/certs"
 )
 
 type FileStore struct {
 	mu      sync.Mutex
 	dir     string
 	vaultPW []byte // Session vault password
+	vault   VaultStatus
+	// locked is set by LockVault, which drops vaultPW.
+	locked bool
+	index  storeIndex
 }
 
 type PKCS11Ref struct {
@@ -43,6 +49,10 @@ type IdentityMeta struct {
 	FingerprintHex string       `json:"fingerprintHex"`
 	PKCS11         *PKCS11Ref   `json:"pkcs11,omitempty"`
 	OSNative       *OSNativeRef `json:"osNative,omitempty"`
+	// Deferred: the certificate file is stored as imported, in <id>.p12,
+	// and opened with its own password at each signature.
+	Deferred  bool   `json:"deferred,omitempty"`
+	DeletedAt string `json:"deletedAt,omitempty"` // set only in the trash
 }
 
 func NewFileStore(dir string, vaultPW []byte) (*FileStore, error) {
@@ -55,62 +65,50 @@ func NewFileStore(dir string, vaultPW []byte) (*FileStore, error) {
 	}, nil
 }
 
+// List returns the stored identities ordered by ID. It is served from an
+// in-memory index, so it is cheap to call from layout code.
 func (s *FileStore) List(ctx context.Context) ([]Identity, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	entries, err := os.ReadDir(s.dir)
+	idx, err := s.loadIndexLocked(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read store dir: %w", err)
+		return nil, err
 	}
+	return append([]Identity(nil), idx.sorted...), nil
+}
 
-	var identities []Identity
-	for _, entry := range entries {
-		if filepath.Ext(entry.Name()) == ".json" {
-			metaPath := filepath.Join(s.dir, entry.Name())
-			metaBytes, err := os.ReadFile(metaPath)
-			if err != nil {
-				continue
-			}
-			var meta IdentityMeta
-			if err := json.Unmarshal(metaBytes, &meta); err != nil {
-				continue
-			}
-
-			certBlock, _ := pem.Decode([]byte(meta.CertPEM))
-			if certBlock == nil {
-				continue
-			}
-			cert, err := x509.ParseCertificate(certBlock.Bytes)
-			if err != nil {
-				continue
-			}
-
-			var chain []*x509.Certificate
-			for _, pemStr := range meta.ChainPEM {
-				block, _ := pem.Decode([]byte(pemStr))
-				if block != nil {
-					c, _ := x509.ParseCertificate(block.Bytes)
-					if c != nil {
-						chain = append(chain, c)
-					}
-				}
-			}
-
-			fp := Fingerprint(cert)
+// identityFromMeta parses the certificates stored in meta. The signer is
+// left nil; it is only created by Unlock.
+func identityFromMeta(meta IdentityMeta) (Identity, bool) {
+	certBlock, _ := pem.Decode([]byte(meta.CertPEM))
+	if certBlock == nil {
+		return Identity{}, false
+	}
+	cert, err := certs.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return Identity{}, false
+	}
 
-			id := Identity{
-				ID:             meta.ID,
-				FriendlyName:   meta.FriendlyName,
-				Cert:           cert,
-				Chain:          chain,
-				Fingerprint256: fp,
+	var chain []*x509.Certificate
+	for _, pemStr := range meta.ChainPEM {
+		block, _ := pem.Decode([]byte(pemStr))
+		if block != nil {
+			c, _ := certs.ParseCertificate(block.Bytes)
+			if c != nil {
+				chain = append(chain, c)
 			}
-
-			identities = append(identities, id)
 		}
 	}
-	return identities, nil
+
+	return Identity{
+		ID:             meta.ID,
+		FriendlyName:   meta.FriendlyName,
+		Cert:           cert,
+		Chain:          chain,
+		Fingerprint256: Fingerprint(cert),
+		Deferred:       meta.Deferred,
+	}, true
 }
 
 func (s *FileStore) Import(ctx context.Context, name string, r io.Reader, password []byte) (*Identity, error) {
@@ -119,46 +117,109 @@ func (s *FileStore) Import(ctx context.Context, name string, r io.Reader, passwo
 		return nil, fmt.Errorf("import failed: %w", err)
 	}
 
+	if err := VerifyKeyPair(signer, cert); err != nil {
+		return nil, fmt.Errorf("import failed: %w", err)
+	}
+
 	fp := Fingerprint(cert)
 	if s.Exists(fp) {
 		return nil, fmt.Errorf("%w", ErrImportDuplicate)
 	}
 
-	id := uuid.New().String()
 	privKeyBytes, err := x509.MarshalPKCS8PrivateKey(signer)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal private key: %w", err)
 	}
 
-	encryptedKey, err := EncryptData(privKeyBytes, s.vaultPW)
+	s.mu.Lock()
+	vaultPW, locked := s.vaultPW, s.locked
+	s.mu.Unlock()
+	if locked {
+		return nil, ErrWalletLocked
+	}
+	encryptedKey, err := EncryptData(privKeyBytes, vaultPW)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encrypt private key: %w", err)
 	}
 
-	keyPath := filepath.Join(s.dir, id+".key.enc")
-	if err := os.WriteFile(keyPath, encryptedKey, 0o600); err != nil {
-		return nil, fmt.Errorf("failed to save encrypted key: %w", err)
+	ident := &Identity{
+		FriendlyName:   name,
+		Cert:           cert,
+		Chain:          chain,
+		Fingerprint256: fp,
+		Signer:         signer,
+	}
+	if err := s.saveImported(ident, ".key.enc", encryptedKey); err != nil {
+		return nil, err
+	}
+	return ident, nil
+}
+
+// ImportDeferred checks the certificate file in r with password and stores
+// it as it is, without the password. Its private key is only decrypted at
+// signing time, with the password asked again; the vault password never
+// protects it.
+func (s *FileStore) ImportDeferred(ctx context.Context, name string, r io.Reader, password []byte) (*Identity, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("import failed: %w", err)
+	}
+	signer, cert, chain, err := ParsePKCS12(bytes.NewReader(data), string(password))
+	if err != nil {
+		return nil, fmt.Errorf("import failed: %w", err)
+	}
+	if err := VerifyKeyPair(signer, cert); err != nil {
+		return nil, fmt.Errorf("import failed: %w", err)
+	}
+
+	fp := Fingerprint(cert)
+	if s.Exists(fp) {
+		return nil, fmt.Errorf("%w", ErrImportDuplicate)
+	}
+
+	ident := &Identity{
+		FriendlyName:   name,
+		Cert:           cert,
+		Chain:          chain,
+		Fingerprint256: fp,
+		Deferred:       true,
+	}
+	if err := s.saveImported(ident, ".p12", data); err != nil {
+		return nil, err
+	}
+	ident.Signer = newDeferredSigner(ident, data)
+	return ident, nil
+}
+
+// saveImported writes the key file of an imported identity, with extension
+// ext, and its metadata, and sets ident.ID.
+func (s *FileStore) saveImported(ident *Identity, ext string, key []byte) error {
+	id := uuid.New().String()
+	keyPath := filepath.Join(s.dir, id+ext)
+	if err := os.WriteFile(keyPath, key, 0o600); err != nil {
+		return fmt.Errorf("failed to save encrypted key: %w", err)
 	}
 
-	certPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+	certPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ident.Cert.Raw}))
 	var chainPEM []string
-	for _, c := range chain {
+	for _, c := range ident.Chain {
 		chainPEM = append(chainPEM, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})))
 	}
 
 	meta := IdentityMeta{
 		ID:             id,
-		FriendlyName:   name,
+		FriendlyName:   ident.FriendlyName,
 		CertPEM:        certPEM,
 		ChainPEM:       chainPEM,
-		FingerprintHex: fmt.Sprintf("%x", fp),
+		FingerprintHex: fmt.Sprintf("%x", ident.Fingerprint256),
+		Deferred:       ident.Deferred,
 	}
 	metaBytes, err := json.Marshal(meta)
 	if err != nil {
 		if rerr := os.Remove(keyPath); rerr != nil {
 			log.Printf("warning: failed to clean up key file %s: %v", keyPath, rerr)
 		}
-		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+		return fmt.Errorf("failed to marshal metadata: %w", err)
 	}
 
 	metaPath := filepath.Join(s.dir, id+".json")
@@ -166,17 +227,11 @@ func (s *FileStore) Import(ctx context.Context, name string, r io.Reader, passwo
 		if rerr := os.Remove(keyPath); rerr != nil {
 			log.Printf("warning: failed to clean up key file %s: %v", keyPath, rerr)
 		}
-		return nil, fmt.Errorf("failed to save metadata: %w", err)
+		return fmt.Errorf("failed to save metadata: %w", err)
 	}
-
-	return &Identity{
-		ID:             id,
-		FriendlyName:   meta.FriendlyName,
-		Cert:           cert,
-		Chain:          chain,
-		Fingerprint256: fp,
-		Signer:         signer,
-	}, nil
+	s.invalidate()
+	ident.ID = id
+	return nil
 }
 
 func (s *FileStore) ImportSystem(ctx context.Context, id Identity, libPath, profileDir string, slot uint, ckaID []byte) error {
@@ -216,45 +271,40 @@ func (s *FileStore) ImportSystem(ctx context.Context, id Identity, libPath, prof
 		return err
 	}
 
-	return os.WriteFile(filepath.Join(s.dir, metaID+".json"), metaBytes, 0o600)
+	if err := os.WriteFile(filepath.Join(s.dir, metaID+".json"), metaBytes, 0o600); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
 }
 
+// Delete moves the identity to the trash, where it can be restored for
+// TrashRetention. The key file stays encrypted as it was.
 func (s *FileStore) Delete(ctx context.Context, id string) error {
+	defer s.notify()
 	s.mu.Lock()
 	defer s.mu.Unlock()
-
-	metaPath := filepath.Join(s.dir, id+".json")
-	keyPath := filepath.Join(s.dir, id+".key.enc")
-
-	var errs []string
-	if err := os.Remove(metaPath); err != nil && !os.IsNotExist(err) {
-		errs = append(errs, fmt.Sprintf("metadata: %v", err))
-	}
-	if err := os.Remove(keyPath); err != nil && !os.IsNotExist(err) {
-		errs = append(errs, fmt.Sprintf("key: %v", err))
-	}
-	if len(errs) > 0 {
-		return fmt.Errorf("failed to delete: %s", strings.Join(errs, "; "))
+	if err := s.moveToTrash(id, time.Now()); err != nil {
+		return err
 	}
+	s.invalidateLocked()
 	return nil
 }
 
 func (s *FileStore) Exists(fingerprint [32]byte) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	return s.existsLocked(fmt.Sprintf("%x", fingerprint))
+}
 
-	entries, _ := os.ReadDir(s.dir)
-	fpHex := fmt.Sprintf("%x", fingerprint)
-	for _, entry := range entries {
-		if filepath.Ext(entry.Name()) == ".json" {
-			metaPath := filepath.Join(s.dir, entry.Name())
-			metaBytes, _ := os.ReadFile(metaPath)
-			var meta IdentityMeta
-			if err := json.Unmarshal(metaBytes, &meta); err == nil {
-				if meta.FingerprintHex == fpHex {
-					return true
-				}
-			}
+func (s *FileStore) existsLocked(fpHex string) bool {
+	idx, err := s.loadIndexLocked(context.Background())
+	if err != nil {
+		return false
+	}
+	for _, id := range idx.sorted {
+		if hex.EncodeToString(id.Fingerprint256[:]) == fpHex {
+			return true
 		}
 	}
 	return false
@@ -263,6 +313,9 @@ func (s *FileStore) Exists(fingerprint [32]byte) bool {
 func (s *FileStore) Unlock(ctx context.Context, id string) (crypto.Signer, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if s.locked {
+		return nil, ErrWalletLocked
+	}
 
 	metaPath := filepath.Join(s.dir, id+".json")
 	metaBytes, err := os.ReadFile(metaPath)
@@ -275,6 +328,9 @@ func (s *FileStore) Unlock(ctx context.Context, id string) (crypto.Signer, error
 	}
 
 	if meta.PKCS11 != nil {
+		if problem := staleReason(meta.PKCS11); problem != "" {
+			return nil, fmt.Errorf("%w: %s", ErrStaleReference, problem)
+		}
 		ckaID, err := hex.DecodeString(meta.PKCS11.CKAIDHex)
 		if err != nil {
 			return nil, fmt.Errorf("invalid CKA_ID hex: %w", err)
@@ -284,7 +340,7 @@ func (s *FileStore) Unlock(ctx context.Context, id string) (crypto.Signer, error
 		if certBlock == nil {
 			return nil, fmt.Errorf("missing certificate in metadata")
 		}
-		cert, err := x509.ParseCertificate(certBlock.Bytes)
+		cert, err := certs.ParseCertificate(certBlock.Bytes)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse certificate: %w", err)
 		}
@@ -300,6 +356,17 @@ func (s *FileStore) Unlock(ctx context.Context, id string) (crypto.Signer, error
 	if meta.OSNative != nil {
 		return unlockOSNative(meta)
 	}
+	if meta.Deferred {
+		data, err := os.ReadFile(filepath.Join(s.dir, id+".p12"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read certificate file: %w", err)
+		}
+		ident, ok := identityFromMeta(meta)
+		if !ok {
+			return nil, fmt.Errorf("failed to parse certificate")
+		}
+		return newDeferredSigner(&ident, data), nil
+	}
 
 	keyPath := filepath.Join(s.dir, id+".key.enc")
 	encryptedKey, err := os.ReadFile(keyPath)
@@ -307,7 +374,7 @@ func (s *FileStore) Unlock(ctx context.Context, id string) (crypto.Signer, error
 		return nil, fmt.Errorf("failed to read encrypted key: %w", err)
 	}
 
-	privKeyBytes, err := DecryptData(encryptedKey, s.vaultPW)
+	privKeyBytes, err := s.decryptVault(encryptedKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decrypt private key: %w", err)
 	}
@@ -0,0 +1,242 @@
+package pkcs12store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TrashRetention is how long deleted identities can be restored before they
+// are removed for good.
+const TrashRetention = 30 * 24 * time.Hour
+
+const trashDir = "trash"
+
+// keyExts are the extensions of the files holding a stored key: a key
+// encrypted with the vault password, or a certificate file kept with
+// deferred decryption.
+var keyExts = []string{".key.enc", ".p12"}
+
+// TrashedIdentity is an identity deleted from the wallet that can still be
+// restored until ExpiresAt.
+type TrashedIdentity struct {
+	Identity
+	DeletedAt time.Time
+	ExpiresAt time.Time
+}
+
+// moveToTrash moves the metadata and key file of id into the trash
+// directory. Callers must hold s.mu.
+func (s *FileStore) moveToTrash(id string, now time.Time) error {
+	metaPath := filepath.Join(s.dir, id+".json")
+	metaBytes, err := os.ReadFile(metaPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read metadata: %w", err)
+	}
+	var meta IdentityMeta
+	if err := json.Unmarshal(metaBytes, &meta); err != nil {
+		return fmt.Errorf("failed to unmarshal metadata: %w", err)
+	}
+
+	trash := filepath.Join(s.dir, trashDir)
+	if err := os.MkdirAll(trash, 0o700); err != nil {
+		return fmt.Errorf("failed to create trash dir: %w", err)
+	}
+	for _, ext := range keyExts {
+		if err := os.Rename(filepath.Join(s.dir, id+ext), filepath.Join(trash, id+ext)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to move key to trash: %w", err)
+		}
+	}
+	meta.DeletedAt = now.UTC().Format(time.RFC3339)
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(trash, id+".json"), data, 0o600); err != nil {
+		return fmt.Errorf("failed to write trash metadata: %w", err)
+	}
+	if err := os.Remove(metaPath); err != nil {
+		return fmt.Errorf("failed to delete metadata: %w", err)
+	}
+	return nil
+}
+
+// ListTrash returns the restorable identities, most recently deleted first.
+// Entries older than TrashRetention are removed permanently.
+func (s *FileStore) ListTrash(ctx context.Context) ([]TrashedIdentity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	trash := filepath.Join(s.dir, trashDir)
+	entries, err := os.ReadDir(trash)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read trash dir: %w", err)
+	}
+
+	now := time.Now()
+	var out []TrashedIdentity
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		meta, expired := s.readTrashed(strings.TrimSuffix(entry.Name(), ".json"), now)
+		if expired {
+			s.purgeTrashed(strings.TrimSuffix(entry.Name(), ".json"))
+			continue
+		}
+		if meta == nil {
+			continue
+		}
+		deletedAt, _ := time.Parse(time.RFC3339, meta.DeletedAt)
+		id, ok := identityFromMeta(*meta)
+		if !ok {
+			continue
+		}
+		out = append(out, TrashedIdentity{Identity: id, DeletedAt: deletedAt, ExpiresAt: deletedAt.Add(TrashRetention)})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].DeletedAt.After(out[j].DeletedAt) })
+	return out, nil
+}
+
+// Restore moves a trashed identity back into the wallet. It fails with
+// ErrImportDuplicate if the same certificate was imported again meanwhile.
+func (s *FileStore) Restore(ctx context.Context, id string) error {
+	defer s.notify()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	trash := filepath.Join(s.dir, trashDir)
+	metaBytes, err := os.ReadFile(filepath.Join(trash, id+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to read trash metadata: %w", err)
+	}
+	var meta IdentityMeta
+	if err := json.Unmarshal(metaBytes, &meta); err != nil {
+		return fmt.Errorf("failed to unmarshal metadata: %w", err)
+	}
+	if s.existsLocked(meta.FingerprintHex) {
+		return ErrImportDuplicate
+	}
+
+	for _, ext := range keyExts {
+		if err := os.Rename(filepath.Join(trash, id+ext), filepath.Join(s.dir, id+ext)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to restore key: %w", err)
+		}
+	}
+	meta.DeletedAt = ""
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.dir, id+".json"), data, 0o600); err != nil {
+		return fmt.Errorf("failed to restore metadata: %w", err)
+	}
+	s.invalidateLocked()
+	if err := os.Remove(filepath.Join(trash, id+".json")); err != nil {
+		log.Printf("WARNING: failed to remove restored identity %s from trash: %v", id, err)
+	}
+	return nil
+}
+
+// Purge removes a trashed identity for good. It fails with ErrNotFound if
+// id is not in the trash.
+func (s *FileStore) Purge(ctx context.Context, id string) error {
+	defer s.notify()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := os.Stat(filepath.Join(s.dir, trashDir, id+".json")); err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to read trash metadata: %w", err)
+	}
+	return s.removeTrashed(id)
+}
+
+// EmptyTrash removes every trashed identity for good.
+func (s *FileStore) EmptyTrash(ctx context.Context) error {
+	defer s.notify()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.RemoveAll(filepath.Join(s.dir, trashDir)); err != nil {
+		return fmt.Errorf("failed to empty trash: %w", err)
+	}
+	return nil
+}
+
+// PurgeExpiredTrash removes the trashed identities older than
+// TrashRetention and returns how many it removed. ListTrash does the same,
+// but only when the trash is shown.
+func (s *FileStore) PurgeExpiredTrash(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(filepath.Join(s.dir, trashDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read trash dir: %w", err)
+	}
+	now := time.Now()
+	n := 0
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		id := strings.TrimSuffix(entry.Name(), ".json")
+		if _, expired := s.readTrashed(id, now); expired {
+			s.purgeTrashed(id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// readTrashed reads the metadata of a trashed identity and reports whether
+// it has expired. Unreadable metadata returns nil and is not expired.
+func (s *FileStore) readTrashed(id string, now time.Time) (*IdentityMeta, bool) {
+	metaBytes, err := os.ReadFile(filepath.Join(s.dir, trashDir, id+".json"))
+	if err != nil {
+		return nil, false
+	}
+	var meta IdentityMeta
+	if err := json.Unmarshal(metaBytes, &meta); err != nil {
+		return nil, false
+	}
+	deletedAt, err := time.Parse(time.RFC3339, meta.DeletedAt)
+	return &meta, err != nil || now.Sub(deletedAt) > TrashRetention
+}
+
+func (s *FileStore) purgeTrashed(id string) {
+	if err := s.removeTrashed(id); err != nil {
+		log.Printf("WARNING: %v", err)
+	}
+}
+
+func (s *FileStore) removeTrashed(id string) error {
+	trash := filepath.Join(s.dir, trashDir)
+	for _, name := range []string{id + ".key.enc", id + ".p12", id + ".json"} {
+		if err := os.Remove(filepath.Join(trash, name)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to purge %s from trash: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package pkcs12store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func importFixture(t *testing.T, s *FileStore) *Identity {
+	t.Helper()
+	id, err := s.Import(context.Background(), "Test", bytes.NewReader(userP12(t)), []byte("password"))
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	return id
+}
+
+func TestFileStore_DeleteAndRestore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewFileStore(dir, []byte("vault"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := importFixture(t, s)
+
+	if err := s.Delete(ctx, id.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if ids, _ := s.List(ctx); len(ids) != 0 {
+		t.Fatalf("List after Delete = %d identities", len(ids))
+	}
+	trashed, err := s.ListTrash(ctx)
+	if err != nil || len(trashed) != 1 || trashed[0].ID != id.ID {
+		t.Fatalf("ListTrash = %+v, %v", trashed, err)
+	}
+	if got := trashed[0].ExpiresAt.Sub(trashed[0].DeletedAt); got != TrashRetention {
+		t.Fatalf("retention = %v", got)
+	}
+
+	if err := s.Restore(ctx, id.ID); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if ids, _ := s.List(ctx); len(ids) != 1 {
+		t.Fatalf("List after Restore = %d identities", len(ids))
+	}
+	if trashed, _ := s.ListTrash(ctx); len(trashed) != 0 {
+		t.Fatalf("ListTrash after Restore = %d entries", len(trashed))
+	}
+	if _, err := s.Unlock(ctx, id.ID); err != nil {
+		t.Fatalf("Unlock after Restore: %v", err)
+	}
+	if err := s.Restore(ctx, id.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("second Restore = %v, want ErrNotFound", err)
+	}
+}
+
+func TestFileStore_RestoreDuplicate(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewFileStore(t.TempDir(), []byte("vault"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := importFixture(t, s)
+	if err := s.Delete(ctx, id.ID); err != nil {
+		t.Fatal(err)
+	}
+	importFixture(t, s)
+	if err := s.Restore(ctx, id.ID); !errors.Is(err, ErrImportDuplicate) {
+		t.Fatalf("Restore = %v, want ErrImportDuplicate", err)
+	}
+}
+
+func TestFileStore_TrashExpiry(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewFileStore(dir, []byte("vault"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := importFixture(t, s)
+	if err := s.moveToTrash(id.ID, time.Now().Add(-TrashRetention-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if trashed, err := s.ListTrash(ctx); err != nil || len(trashed) != 0 {
+		t.Fatalf("ListTrash = %+v, %v", trashed, err)
+	}
+	for _, name := range []string{id.ID + ".json", id.ID + ".key.enc"} {
+		if _, err := os.Stat(filepath.Join(dir, trashDir, name)); !os.IsNotExist(err) {
+			t.Fatalf("%s was not purged: %v", name, err)
+		}
+	}
+}
+
+func TestFileStore_PurgeAndEmptyTrash(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewFileStore(dir, []byte("vault"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := importFixture(t, s)
+	if err := s.Delete(ctx, id.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Purge(ctx, id.ID); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	for _, name := range []string{id.ID + ".json", id.ID + ".key.enc"} {
+		if _, err := os.Stat(filepath.Join(dir, trashDir, name)); !os.IsNotExist(err) {
+			t.Fatalf("%s was not purged: %v", name, err)
+		}
+	}
+	if err := s.Purge(ctx, id.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("second Purge = %v, want ErrNotFound", err)
+	}
+
+	id = importFixture(t, s)
+	if err := s.Delete(ctx, id.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.EmptyTrash(ctx); err != nil {
+		t.Fatalf("EmptyTrash: %v", err)
+	}
+	if trashed, err := s.ListTrash(ctx); err != nil || len(trashed) != 0 {
+		t.Fatalf("ListTrash after EmptyTrash = %+v, %v", trashed, err)
+	}
+	if err := s.Restore(ctx, id.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Restore after EmptyTrash = %v, want ErrNotFound", err)
+	}
+}
+
+func TestFileStore_PurgeExpiredTrash(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewFileStore(dir, []byte("vault"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := importFixture(t, s)
+	if err := s.moveToTrash(old.ID, time.Now().Add(-TrashRetention-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	recent := importFixture(t, s)
+	if err := s.Delete(ctx, recent.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := s.PurgeExpiredTrash(ctx); err != nil || n != 1 {
+		t.Fatalf("PurgeExpiredTrash = %d, %v; want 1", n, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, trashDir, old.ID+".key.enc")); !os.IsNotExist(err) {
+		t.Fatalf("expired key was not purged: %v", err)
+	}
+	if trashed, err := s.ListTrash(ctx); err != nil || len(trashed) != 1 || trashed[0].ID != recent.ID {
+		t.Fatalf("ListTrash = %+v, %v", trashed, err)
+	}
+}
@@ -0,0 +1,335 @@
+package pkcs12store
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// PortableVaultKey is the vault password of a wallet not bound to this
+// computer's hardware. It is built in, so it only keeps the keys from
+// being read at a glance: anyone with the files can decrypt them.
+var PortableVaultKey = []byte("default-vault-password")
+
+// vaultBindingFile records the vault key sealed by the TPM or Secure
+// Enclave. Without it the vault key is PortableVaultKey.
+const vaultBindingFile = "vault.json"
+
+// KeySealer binds a key to this computer's hardware, such as a TPM.
+type KeySealer interface {
+	// Name is what the user knows the hardware as.
+	Name() string
+	Seal(key []byte) ([]byte, error)
+	Unseal(sealed []byte) ([]byte, error)
+}
+
+type vaultBinding struct {
+	Hardware  string `json:"hardware"`
+	SealedKey []byte `json:"sealedKey"`
+	BoundAt   string `json:"boundAt"`
+	// Migrated is set once every stored key is encrypted with the sealed
+	// key. Until then opening the vault finishes the migration.
+	Migrated bool `json:"migrated"`
+}
+
+// VaultStatus is how the vault key of a store is protected.
+type VaultStatus struct {
+	// Hardware names what the vault key is sealed by, "" if the vault is
+	// portable.
+	Hardware string
+	// Err is why the sealed vault key could not be unsealed, for example
+	// because the wallet was copied from another computer. Keys stored
+	// before cannot be used; new ones are stored with the portable key.
+	Err error
+}
+
+// Bound reports whether the vault key is sealed by hardware and usable.
+func (st VaultStatus) Bound() bool {
+	return st.Hardware != "" && st.Err == nil
+}
+
+// ErrVaultLocked means the vault key is sealed by hardware that cannot
+// unseal it here.
+var ErrVaultLocked = errors.New("vault key is sealed by another computer's hardware")
+
+// VaultStatus returns how the vault key is protected.
+func (s *FileStore) VaultStatus() VaultStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.vault
+}
+
+// OpenVault switches the store to its hardware-sealed vault key, if it has
+// one, and finishes a migration to it that was interrupted. sealer is nil
+// where there is no hardware to unseal with.
+func (s *FileStore) OpenVault(sealer KeySealer) VaultStatus {
+	defer s.notify()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.openVaultLocked(sealer)
+}
+
+func (s *FileStore) openVaultLocked(sealer KeySealer) VaultStatus {
+	b, err := s.readBindingLocked()
+	switch {
+	case err != nil:
+		s.vault = VaultStatus{Err: err}
+		return s.vault
+	case b == nil:
+		s.vault = VaultStatus{}
+		return s.vault
+	case sealer == nil:
+		s.vault = VaultStatus{Hardware: b.Hardware, Err: fmt.Errorf("%w: no %s available", ErrVaultLocked, b.Hardware)}
+		return s.vault
+	}
+	key, err := sealer.Unseal(b.SealedKey)
+	if err != nil {
+		s.vault = VaultStatus{Hardware: b.Hardware, Err: fmt.Errorf("%w: %v", ErrVaultLocked, err)}
+		return s.vault
+	}
+	s.vaultPW = key
+	s.vault = VaultStatus{Hardware: b.Hardware}
+	if !b.Migrated {
+		if err := s.rekeyLocked(PortableVaultKey, key); err != nil {
+			log.Printf("WARNING: failed to finish binding the vault key: %v", err)
+			return s.vault
+		}
+		b.Migrated = true
+		if err := s.writeBindingLocked(b); err != nil {
+			log.Printf("WARNING: failed to record the vault key binding: %v", err)
+		}
+	}
+	return s.vault
+}
+
+// BindVault seals a new random vault key with sealer and encrypts every
+// stored key with it, so copies of the wallet files are useless on another
+// computer. Copies made before, such as data directory backups, are still
+// encrypted with PortableVaultKey and are up to the caller. The store is
+// not held while sealer seals the key, which can take seconds.
+func (s *FileStore) BindVault(sealer KeySealer) error {
+	if err := s.checkBindable(); err != nil {
+		return err
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	sealed, err := sealer.Seal(key)
+	if err != nil {
+		return fmt.Errorf("failed to seal the vault key: %w", err)
+	}
+
+	defer s.notify()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// The store may have been locked or bound while sealing.
+	if err := s.checkBindableLocked(); err != nil {
+		return err
+	}
+	// The sealed key is recorded first, so an interrupted migration is
+	// finished by the next OpenVault.
+	b := &vaultBinding{Hardware: sealer.Name(), SealedKey: sealed, BoundAt: time.Now().UTC().Format(time.RFC3339)}
+	if err := s.writeBindingLocked(b); err != nil {
+		return err
+	}
+	old := s.vaultPW
+	s.vaultPW = key
+	s.vault = VaultStatus{Hardware: b.Hardware}
+	s.invalidateLocked()
+	if err := s.rekeyLocked(old, key); err != nil {
+		return err
+	}
+	b.Migrated = true
+	return s.writeBindingLocked(b)
+}
+
+func (s *FileStore) checkBindable() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.checkBindableLocked()
+}
+
+func (s *FileStore) checkBindableLocked() error {
+	if s.locked {
+		return ErrWalletLocked
+	}
+	if s.vault.Hardware != "" {
+		return fmt.Errorf("vault key already sealed by the %s", s.vault.Hardware)
+	}
+	return nil
+}
+
+// UnbindVault encrypts every stored key with PortableVaultKey again and
+// removes the binding, so the wallet can be copied to another computer.
+func (s *FileStore) UnbindVault() error {
+	defer s.notify()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.locked {
+		return ErrWalletLocked
+	}
+	if !s.vault.Bound() {
+		return fmt.Errorf("vault key is not sealed by hardware")
+	}
+	if err := s.rekeyLocked(s.vaultPW, PortableVaultKey); err != nil {
+		return err
+	}
+	s.vaultPW = PortableVaultKey
+	s.vault = VaultStatus{}
+	s.invalidateLocked()
+	return s.removeBindingLocked()
+}
+
+// ForgetVaultBinding removes a binding whose key cannot be unsealed, so a
+// new one can be made. Keys encrypted with the lost key stay unusable.
+func (s *FileStore) ForgetVaultBinding() error {
+	defer s.notify()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.locked {
+		return ErrWalletLocked
+	}
+	if s.vault.Err == nil {
+		return fmt.Errorf("vault key is not locked")
+	}
+	s.vaultPW = PortableVaultKey
+	s.vault = VaultStatus{}
+	s.invalidateLocked()
+	return s.removeBindingLocked()
+}
+
+// rekeyLocked encrypts the vault files encrypted with from with to. Files
+// already encrypted with to are left alone, so an interrupted run can be
+// repeated; files neither key opens are skipped, since they were already
+// unusable.
+func (s *FileStore) rekeyLocked(from, to []byte) error {
+	var paths []string
+	for _, dir := range []string{s.dir, filepath.Join(s.dir, trashDir)} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("failed to read store dir: %w", err)
+		}
+		for _, e := range entries {
+			if IsVaultFile(e.Name()) {
+				paths = append(paths, filepath.Join(dir, e.Name()))
+			}
+		}
+	}
+	for _, path := range paths {
+		enc, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+		}
+		if _, err := DecryptData(enc, to); err == nil {
+			continue
+		}
+		plain, err := DecryptData(enc, from)
+		if err != nil {
+			log.Printf("WARNING: skipping %s, which the vault key does not open", filepath.Base(path))
+			continue
+		}
+		enc, err = EncryptData(plain, to)
+		wipe(plain)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt %s: %w", filepath.Base(path), err)
+		}
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, enc, 0o600); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsVaultFile reports whether a file of the store, or of its trash, named
+// name is encrypted with the vault key.
+func IsVaultFile(name string) bool {
+	return strings.HasSuffix(name, ".key.enc") || name == signerDataFile
+}
+
+// decryptVault decrypts a vault file. A file still encrypted with
+// PortableVaultKey, left by an interrupted migration, is opened too.
+func (s *FileStore) decryptVault(enc []byte) ([]byte, error) {
+	if s.locked {
+		return nil, ErrWalletLocked
+	}
+	plain, err := DecryptData(enc, s.vaultPW)
+	if err == nil {
+		return plain, nil
+	}
+	if string(s.vaultPW) != string(PortableVaultKey) {
+		if plain, perr := DecryptData(enc, PortableVaultKey); perr == nil {
+			return plain, nil
+		}
+	}
+	if s.vault.Err != nil {
+		return nil, s.vault.Err
+	}
+	return nil, err
+}
+
+// EncryptVault encrypts data kept outside the store with the vault key, so
+// it is no easier to read than the private keys.
+func (s *FileStore) EncryptVault(data []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.locked {
+		return nil, ErrWalletLocked
+	}
+	return EncryptData(data, s.vaultPW)
+}
+
+// DecryptVault decrypts data encrypted by EncryptVault.
+func (s *FileStore) DecryptVault(enc []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.decryptVault(enc)
+}
+
+func (s *FileStore) readBindingLocked() (*vaultBinding, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, vaultBindingFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read vault binding: %w", err)
+	}
+	var b vaultBinding
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to decode vault binding: %w", err)
+	}
+	return &b, nil
+}
+
+func (s *FileStore) writeBindingLocked(b *vaultBinding) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal vault binding: %w", err)
+	}
+	path := filepath.Join(s.dir, vaultBindingFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *FileStore) removeBindingLocked() error {
+	if err := os.Remove(filepath.Join(s.dir, vaultBindingFile)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
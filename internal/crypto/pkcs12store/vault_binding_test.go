@@ -0,0 +1,193 @@
+package pkcs12store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/testutil/certfixtures"
+)
+
+// otherP12 returns a PKCS#12 of a certificate other than userP12's.
+func otherP12(t *testing.T) []byte {
+	t.Helper()
+	return certfixtures.New(t, certfixtures.Options{}).LegacyPKCS12(t, "password")
+}
+
+// fakeSealer seals by XOR with its machine secret and only unseals what it
+// sealed itself.
+type fakeSealer struct{ machine byte }
+
+func (f fakeSealer) Name() string { return "TPM" }
+
+func (f fakeSealer) Seal(key []byte) ([]byte, error) {
+	out := []byte{f.machine}
+	for _, b := range key {
+		out = append(out, b^f.machine)
+	}
+	return out, nil
+}
+
+func (f fakeSealer) Unseal(sealed []byte) ([]byte, error) {
+	if len(sealed) == 0 || sealed[0] != f.machine {
+		return nil, errors.New("sealed by another TPM")
+	}
+	var out []byte
+	for _, b := range sealed[1:] {
+		out = append(out, b^f.machine)
+	}
+	return out, nil
+}
+
+func TestFileStore_BindVault(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewFileStore(dir, PortableVaultKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st := s.OpenVault(fakeSealer{1}); st.Hardware != "" || st.Err != nil {
+		t.Fatalf("OpenVault without a binding = %+v", st)
+	}
+	id := importFixture(t, s)
+	fp := id.Fingerprint256
+	if err := s.SaveSignerData(fp, SignerData{Nom: "MARIA"}); err != nil {
+		t.Fatal(err)
+	}
+	trashed, err := s.Import(ctx, "Trashed", bytes.NewReader(otherP12(t)), []byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, trashed.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.BindVault(fakeSealer{1}); err != nil {
+		t.Fatalf("BindVault: %v", err)
+	}
+	if st := s.VaultStatus(); !st.Bound() || st.Hardware != "TPM" {
+		t.Fatalf("VaultStatus after binding = %+v", st)
+	}
+	// Every key, in the trash too, is now useless without the TPM.
+	for _, path := range []string{
+		filepath.Join(dir, id.ID+".key.enc"),
+		filepath.Join(dir, trashDir, trashed.ID+".key.enc"),
+		filepath.Join(dir, signerDataFile),
+	} {
+		enc, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := DecryptData(enc, PortableVaultKey); err == nil {
+			t.Errorf("%s still opens with the portable key", filepath.Base(path))
+		}
+	}
+	if _, err := s.Unlock(ctx, id.ID); err != nil {
+		t.Fatalf("Unlock after binding: %v", err)
+	}
+	if d, ok, err := s.SignerData(fp); err != nil || !ok || d.Nom != "MARIA" {
+		t.Fatalf("SignerData after binding = %+v, %v, %v", d, ok, err)
+	}
+
+	// The same computer opens it again; another one does not.
+	reopened, _ := NewFileStore(dir, PortableVaultKey)
+	if st := reopened.OpenVault(fakeSealer{1}); !st.Bound() {
+		t.Fatalf("OpenVault on the same computer = %+v", st)
+	}
+	if _, err := reopened.Unlock(ctx, id.ID); err != nil {
+		t.Fatalf("Unlock after reopening: %v", err)
+	}
+	copied, _ := NewFileStore(dir, PortableVaultKey)
+	st := copied.OpenVault(fakeSealer{2})
+	if !errors.Is(st.Err, ErrVaultLocked) || st.Bound() {
+		t.Fatalf("OpenVault on another computer = %+v", st)
+	}
+	if _, err := copied.Unlock(ctx, id.ID); !errors.Is(err, ErrVaultLocked) {
+		t.Fatalf("Unlock on another computer = %v", err)
+	}
+	if st := (&FileStore{dir: dir}).OpenVault(nil); !errors.Is(st.Err, ErrVaultLocked) {
+		t.Fatalf("OpenVault without hardware = %+v", st)
+	}
+
+	if err := reopened.UnbindVault(); err != nil {
+		t.Fatalf("UnbindVault: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, vaultBindingFile)); !os.IsNotExist(err) {
+		t.Fatalf("binding left after UnbindVault: %v", err)
+	}
+	portable, _ := NewFileStore(dir, PortableVaultKey)
+	if st := portable.OpenVault(nil); st.Hardware != "" || st.Err != nil {
+		t.Fatalf("OpenVault after unbinding = %+v", st)
+	}
+	if _, err := portable.Unlock(ctx, id.ID); err != nil {
+		t.Fatalf("Unlock after unbinding: %v", err)
+	}
+	if err := portable.Restore(ctx, trashed.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := portable.Unlock(ctx, trashed.ID); err != nil {
+		t.Fatalf("Unlock of the restored key after unbinding: %v", err)
+	}
+}
+
+func TestFileStore_OpenVaultFinishesMigration(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, _ := NewFileStore(dir, PortableVaultKey)
+	id := importFixture(t, s)
+
+	// A binding recorded before the keys were migrated, as left by an
+	// interrupted BindVault.
+	sealed, _ := fakeSealer{1}.Seal(bytes.Repeat([]byte{7}, 32))
+	if err := s.writeBindingLocked(&vaultBinding{Hardware: "TPM", SealedKey: sealed}); err != nil {
+		t.Fatal(err)
+	}
+	reopened, _ := NewFileStore(dir, PortableVaultKey)
+	if st := reopened.OpenVault(fakeSealer{1}); !st.Bound() {
+		t.Fatalf("OpenVault = %+v", st)
+	}
+	enc, _ := os.ReadFile(filepath.Join(dir, id.ID+".key.enc"))
+	if _, err := DecryptData(enc, bytes.Repeat([]byte{7}, 32)); err != nil {
+		t.Fatalf("key not migrated: %v", err)
+	}
+	b, err := reopened.readBindingLocked()
+	if err != nil || !b.Migrated {
+		t.Fatalf("binding = %+v, %v", b, err)
+	}
+	if _, err := reopened.Unlock(ctx, id.ID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileStore_ForgetVaultBinding(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, _ := NewFileStore(dir, PortableVaultKey)
+	importFixture(t, s)
+	if err := s.BindVault(fakeSealer{1}); err != nil {
+		t.Fatal(err)
+	}
+
+	copied, _ := NewFileStore(dir, PortableVaultKey)
+	copied.OpenVault(fakeSealer{2})
+	if err := copied.BindVault(fakeSealer{2}); err == nil {
+		t.Fatal("BindVault over a locked binding succeeded")
+	}
+	if err := copied.ForgetVaultBinding(); err != nil {
+		t.Fatalf("ForgetVaultBinding: %v", err)
+	}
+	// The old key stays unusable, and the vault can be bound again here.
+	if err := copied.BindVault(fakeSealer{2}); err != nil {
+		t.Fatalf("BindVault after forgetting: %v", err)
+	}
+	id, err := copied.Import(ctx, "New", bytes.NewReader(otherP12(t)), []byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := copied.Unlock(ctx, id.ID); err != nil {
+		t.Fatalf("Unlock of a key imported after rebinding: %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package pkcs12store
+
+import "errors"
+
+// ErrWalletLocked is returned while the wallet is locked: its vault key is
+// not in memory, so stored keys cannot be used nor new ones stored.
+var ErrWalletLocked = errors.New("wallet is locked")
+
+// LockVault drops the vault key, and every cached token PIN and certificate
+// password, from memory. Nothing stored in the vault can be used until
+// UnlockVault.
+func (s *FileStore) LockVault() {
+	s.mu.Lock()
+	if !s.locked {
+		// A sealed key was unsealed for this session only; the portable
+		// key is shared and stays as it is.
+		if s.vault.Bound() {
+			wipe(s.vaultPW)
+		}
+		s.vaultPW = nil
+		s.locked = true
+	}
+	s.mu.Unlock()
+	DefaultPINCache.Clear()
+}
+
+// Locked reports whether LockVault was called and the vault not unlocked
+// since.
+func (s *FileStore) Locked() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.locked
+}
+
+// UnlockVault loads the vault key again after LockVault, unsealing it with
+// sealer if the vault is bound to hardware. Checking that the user may
+// unlock the wallet is up to the caller. The lock does not encrypt
+// anything: a new FileStore opens the vault unlocked.
+func (s *FileStore) UnlockVault(sealer KeySealer) VaultStatus {
+	defer s.notify()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.locked {
+		return s.vault
+	}
+	s.locked = false
+	s.vaultPW = PortableVaultKey
+	return s.openVaultLocked(sealer)
+}
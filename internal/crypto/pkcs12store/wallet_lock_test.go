@@ -0,0 +1,105 @@
+package pkcs12store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFileStore_LockVault(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewFileStore(t.TempDir(), PortableVaultKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.OpenVault(nil)
+	id := importFixture(t, s)
+	if err := s.SaveSignerData(id.Fingerprint256, SignerData{Nom: "MARIA"}); err != nil {
+		t.Fatal(err)
+	}
+	enc, err := s.EncryptVault([]byte("12345678Z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	DefaultPINCache.Put("token", []byte("1234"))
+	defer DefaultPINCache.Clear()
+
+	s.LockVault()
+	if !s.Locked() {
+		t.Fatal("Locked after LockVault = false")
+	}
+	if _, ok := DefaultPINCache.Get("token"); ok {
+		t.Error("token PIN still cached after LockVault")
+	}
+	if _, err := s.Unlock(ctx, id.ID); !errors.Is(err, ErrWalletLocked) {
+		t.Errorf("Unlock while locked = %v", err)
+	}
+	if _, _, err := s.SignerData(id.Fingerprint256); !errors.Is(err, ErrWalletLocked) {
+		t.Errorf("SignerData while locked = %v", err)
+	}
+	if _, err := s.Import(ctx, "Other", bytes.NewReader(otherP12(t)), []byte("password")); !errors.Is(err, ErrWalletLocked) {
+		t.Errorf("Import while locked = %v", err)
+	}
+	if _, err := s.EncryptVault([]byte("x")); !errors.Is(err, ErrWalletLocked) {
+		t.Errorf("EncryptVault while locked = %v", err)
+	}
+	if _, err := s.DecryptVault(enc); !errors.Is(err, ErrWalletLocked) {
+		t.Errorf("DecryptVault while locked = %v", err)
+	}
+	if err := s.BindVault(fakeSealer{1}); !errors.Is(err, ErrWalletLocked) {
+		t.Errorf("BindVault while locked = %v", err)
+	}
+	if h := s.CheckHealth(ctx, id.ID); h.Status != HealthUnknown {
+		t.Errorf("CheckHealth while locked = %+v", h)
+	}
+	// The wallet is still listed.
+	if ids, err := s.List(ctx); err != nil || len(ids) != 1 {
+		t.Errorf("List while locked = %d, %v", len(ids), err)
+	}
+
+	if st := s.UnlockVault(nil); st.Err != nil {
+		t.Fatalf("UnlockVault = %+v", st)
+	}
+	if s.Locked() {
+		t.Fatal("Locked after UnlockVault = true")
+	}
+	if _, err := s.Unlock(ctx, id.ID); err != nil {
+		t.Fatalf("Unlock after UnlockVault: %v", err)
+	}
+	if d, ok, err := s.SignerData(id.Fingerprint256); err != nil || !ok || d.Nom != "MARIA" {
+		t.Fatalf("SignerData after UnlockVault = %+v, %v, %v", d, ok, err)
+	}
+	if plain, err := s.DecryptVault(enc); err != nil || string(plain) != "12345678Z" {
+		t.Fatalf("DecryptVault after UnlockVault = %q, %v", plain, err)
+	}
+}
+
+func TestFileStore_LockBoundVault(t *testing.T) {
+	ctx := context.Background()
+	s, _ := NewFileStore(t.TempDir(), PortableVaultKey)
+	id := importFixture(t, s)
+	if err := s.BindVault(fakeSealer{1}); err != nil {
+		t.Fatal(err)
+	}
+
+	s.LockVault()
+	if len(PortableVaultKey) == 0 || string(PortableVaultKey) != "default-vault-password" {
+		t.Fatalf("LockVault wiped the portable key: %q", PortableVaultKey)
+	}
+	// The sealed key is unsealed again, so the hardware must still agree.
+	if st := s.UnlockVault(fakeSealer{2}); !errors.Is(st.Err, ErrVaultLocked) {
+		t.Fatalf("UnlockVault with other hardware = %+v", st)
+	}
+	if _, err := s.Unlock(ctx, id.ID); !errors.Is(err, ErrVaultLocked) {
+		t.Fatalf("Unlock with the key not unsealed = %v", err)
+	}
+
+	s.LockVault()
+	if st := s.UnlockVault(fakeSealer{1}); !st.Bound() {
+		t.Fatalf("UnlockVault = %+v", st)
+	}
+	if _, err := s.Unlock(ctx, id.ID); err != nil {
+		t.Fatalf("Unlock after UnlockVault: %v", err)
+	}
+}
@@ -21,7 +21,9 @@ import (
 	"unsafe"
 
 	"github.com/miekg/pkcs11"
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/certs"
 	"github.com/vocdoni/gofirma/vocsign/internal/crypto/pkcs12store"
+	"github.com/vocdoni/gofirma/vocsign/internal/sandbox"
 )
 
 type NSSStore struct {
@@ -81,7 +83,7 @@ func DiscoverNSSStores(ctx context.Context) []*NSSStore {
 		})
 	}
 
-	home, _ := os.UserHomeDir()
+	home := sandbox.HomeDir()
 
 	// 1. Common NSS DB
 	nssDB := filepath.Join(home, ".pki", "nssdb")
@@ -150,6 +152,12 @@ func DiscoverNSSStores(ctx context.Context) []*NSSStore {
 	return stores
 }
 
+// NSSLibrary returns the NSS softoken library browser certificate databases
+// are read with, or "" if there is none.
+func NSSLibrary() string {
+	return findNSSLib()
+}
+
 func findNSSLib() string {
 	for _, envName := range []string{"VOCSIGN_NSS_LIB", "NSS_LIB_PATH"} {
 		if p := os.Getenv(envName); p != "" {
@@ -159,6 +167,16 @@ func findNSSLib() string {
 		}
 	}
 
+	// Inside a sandbox, libraries of the package itself come first: the
+	// host's may be built against another system.
+	for _, dir := range sandbox.Detect().LibraryDirs() {
+		for _, name := range []string{"libsoftokn3.so", "libnss3.so"} {
+			if p := filepath.Join(dir, name); fileExists(p) {
+				return p
+			}
+		}
+	}
+
 	if p := findNSSLibFromFirefoxCompatibility(); p != "" {
 		return p
 	}
@@ -217,7 +235,7 @@ func findNSSLib() string {
 			"/var/lib/flatpak/app/org.mozilla.firefox/current/active/files/lib/firefox/libnss3.so",
 		}
 		// Also check user-local flatpak
-		if home, err := os.UserHomeDir(); err == nil {
+		if home := sandbox.HomeDir(); home != "" {
 			paths = append(paths,
 				filepath.Join(home, ".local", "share", "flatpak", "app", "org.mozilla.firefox", "current", "active", "files", "lib", "firefox", "libsoftokn3.so"),
 				filepath.Join(home, ".local", "share", "flatpak", "app", "org.mozilla.firefox", "current", "active", "files", "lib", "firefox", "libnss3.so"),
@@ -336,7 +354,7 @@ func (s *NSSStore) listViaWorker(ctx context.Context) ([]pkcs12store.Identity, e
 		if block == nil || len(block.Bytes) == 0 {
 			continue
 		}
-		cert, err := x509.ParseCertificate(block.Bytes)
+		cert, err := certs.ParseCertificate(block.Bytes)
 		if err != nil {
 			continue
 		}
@@ -355,6 +373,7 @@ func (s *NSSStore) listViaWorker(ctx context.Context) ([]pkcs12store.Identity, e
 				Slot:       dto.Slot,
 				ID:         keyID,
 				PublicKey:  cert.PublicKey,
+				TokenLabel: s.Label,
 			},
 		})
 	}
@@ -466,7 +485,7 @@ func (s *NSSStore) listDirect(ctx context.Context) ([]pkcs12store.Identity, erro
 					continue
 				}
 
-				cert, err := x509.ParseCertificate(certDER)
+				cert, err := certs.ParseCertificate(certDER)
 				if err != nil {
 					continue
 				}
@@ -474,6 +493,7 @@ func (s *NSSStore) listDirect(ctx context.Context) ([]pkcs12store.Identity, erro
 					continue
 				}
 				if cert.KeyUsage != 0 && (cert.KeyUsage&x509.KeyUsageDigitalSignature == 0) && (cert.KeyUsage&x509.KeyUsageContentCommitment == 0) {
+					log.Printf("DEBUG: Skipping certificate in %s: %s (Subject: %s): key usage %v does not allow signing", s.Label, label, cert.Subject.CommonName, certs.KeyUsageNames(cert))
 					continue
 				}
 
@@ -507,6 +527,7 @@ func (s *NSSStore) listDirect(ctx context.Context) ([]pkcs12store.Identity, erro
 					Slot:       slot,
 					ID:         ckaID,
 					PublicKey:  cert.PublicKey,
+					TokenLabel: s.Label,
 				}
 
 				displayName := label
@@ -10,6 +10,8 @@ import (
 	"runtime"
 	"sort"
 	"strings"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/sandbox"
 )
 
 type firefoxProfile struct {
@@ -27,7 +29,7 @@ type firefoxInstallState struct {
 }
 
 func firefoxBaseDirs() []string {
-	home, _ := os.UserHomeDir()
+	home := sandbox.HomeDir()
 	switch runtime.GOOS {
 	case "windows":
 		appData := os.Getenv("APPDATA")
@@ -73,7 +75,7 @@ func firefoxBaseDirs() []string {
 
 // chromiumBaseDirs returns base config directories for all Chromium-family browsers.
 func chromiumBaseDirs() []string {
-	home, _ := os.UserHomeDir()
+	home := sandbox.HomeDir()
 	switch runtime.GOOS {
 	case "windows":
 		local := localAppDataDir()
@@ -19,6 +19,10 @@ func DiscoverNSSStores(ctx context.Context) []*NSSStore {
 	return nil
 }
 
+func NSSLibrary() string {
+	return ""
+}
+
 func (s *NSSStore) List(ctx context.Context) ([]pkcs12store.Identity, error) {
 	return nil, nil
 }
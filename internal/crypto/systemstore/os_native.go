@@ -6,9 +6,11 @@ import (
 	"context"
 	"crypto/x509"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/github/smimesign/certstore"
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/certs"
 	"github.com/vocdoni/gofirma/vocsign/internal/crypto/pkcs12store"
 )
 
@@ -40,6 +42,7 @@ func (s *OSStore) List(ctx context.Context) ([]pkcs12store.Identity, error) {
 		}
 
 		if cert.KeyUsage != 0 && (cert.KeyUsage&x509.KeyUsageDigitalSignature == 0) && (cert.KeyUsage&x509.KeyUsageContentCommitment == 0) {
+			log.Printf("DEBUG: Skipping OS certificate %s: key usage %v does not allow signing", cert.Subject.CommonName, certs.KeyUsageNames(cert))
 			continue
 		}
 
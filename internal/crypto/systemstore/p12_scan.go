@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/vocdoni/gofirma/vocsign/internal/crypto/pkcs12store"
+	"github.com/vocdoni/gofirma/vocsign/internal/sandbox"
 )
 
 // FindPKCS12Candidates walks common user locations to find .p12/.pfx files.
@@ -25,7 +26,7 @@ func FindPKCS12Candidates(ctx context.Context, maxDepth int, limit int) []string
 	}
 	maxAgeYears := envInt("VOCSIGN_P12_MAX_AGE_YEARS", 10)
 
-	home, _ := os.UserHomeDir()
+	home := sandbox.HomeDir()
 
 	roots := p12ScanRoots(home)
 
@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/sandbox"
 )
 
 // localAppDataDir returns the per-user local application data directory.
@@ -17,13 +19,13 @@ func localAppDataDir() string {
 		if v := os.Getenv("LOCALAPPDATA"); v != "" {
 			return v
 		}
-		home, _ := os.UserHomeDir()
+		home := sandbox.HomeDir()
 		return filepath.Join(home, "AppData", "Local")
 	case "darwin":
-		home, _ := os.UserHomeDir()
+		home := sandbox.HomeDir()
 		return filepath.Join(home, "Library", "Application Support")
 	default:
-		home, _ := os.UserHomeDir()
+		home := sandbox.HomeDir()
 		return filepath.Join(home, ".config")
 	}
 }
@@ -38,7 +40,7 @@ func appDataDir() string {
 		if v := os.Getenv("APPDATA"); v != "" {
 			return v
 		}
-		home, _ := os.UserHomeDir()
+		home := sandbox.HomeDir()
 		return filepath.Join(home, "AppData", "Roaming")
 	default:
 		return localAppDataDir()
@@ -0,0 +1,278 @@
+package systemstore
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Middleware is a PKCS#11 module that lets browsers and VocSign talk to a
+// smart card. Path is the first existing candidate, empty if not installed.
+type Middleware struct {
+	Name string
+	Path string
+}
+
+func (m Middleware) Installed() bool { return m.Path != "" }
+
+// TokenReport describes what is in place for signing with a smart card such
+// as the Spanish DNIe: the card readers the OS sees and the middleware found.
+type TokenReport struct {
+	Readers []string
+	// ReaderCheck is false when the platform offers no way to list readers
+	// without extra tools; Readers is then empty but not meaningful.
+	ReaderCheck bool
+	// ServiceMissing is set on Linux when the pcscd socket does not exist.
+	ServiceMissing bool
+	Middleware     []Middleware
+}
+
+// MiddlewareInstalled reports whether at least one known module is present.
+func (r TokenReport) MiddlewareInstalled() bool {
+	for _, m := range r.Middleware {
+		if m.Installed() {
+			return true
+		}
+	}
+	return false
+}
+
+// Ready reports whether a reader and middleware were both found.
+func (r TokenReport) Ready() bool {
+	if !r.MiddlewareInstalled() || r.ServiceMissing {
+		return false
+	}
+	return !r.ReaderCheck || len(r.Readers) > 0
+}
+
+// middlewareCandidates lists where OpenSC and the official DNIe module are
+// installed by their packages on each platform.
+func middlewareCandidates(goos string) []struct {
+	name  string
+	paths []string
+} {
+	switch goos {
+	case "windows":
+		pf := os.Getenv("ProgramFiles")
+		if pf == "" {
+			pf = `C:\Program Files`
+		}
+		sys := os.Getenv("SystemRoot")
+		if sys == "" {
+			sys = `C:\Windows`
+		}
+		return []struct {
+			name  string
+			paths []string
+		}{
+			{"OpenSC", []string{
+				filepath.Join(pf, "OpenSC Project", "OpenSC", "pkcs11", "opensc-pkcs11.dll"),
+				filepath.Join(sys, "System32", "opensc-pkcs11.dll"),
+			}},
+			{"DNIe", []string{
+				filepath.Join(sys, "System32", "UsrPkcs11.dll"),
+				filepath.Join(sys, "System32", "DNIe_P11_priv.dll"),
+				filepath.Join(pf, "DNIe", "UsrPkcs11.dll"),
+			}},
+		}
+	case "darwin":
+		return []struct {
+			name  string
+			paths []string
+		}{
+			{"OpenSC", []string{
+				"/Library/OpenSC/lib/opensc-pkcs11.so",
+				"/opt/homebrew/lib/opensc-pkcs11.so",
+				"/usr/local/lib/opensc-pkcs11.so",
+			}},
+			{"DNIe", []string{
+				"/Library/Libpkcs11-dnie/lib/libpkcs11-dnie.so",
+				"/usr/local/lib/libpkcs11-dnie.so",
+			}},
+		}
+	default:
+		libDirs := []string{"/usr/lib/x86_64-linux-gnu", "/usr/lib/aarch64-linux-gnu", "/usr/lib64", "/usr/lib", "/usr/local/lib"}
+		var opensc, dnie []string
+		for _, d := range libDirs {
+			opensc = append(opensc, filepath.Join(d, "opensc-pkcs11.so"), filepath.Join(d, "pkcs11", "opensc-pkcs11.so"))
+			dnie = append(dnie, filepath.Join(d, "libpkcs11-dnie.so"))
+		}
+		return []struct {
+			name  string
+			paths []string
+		}{
+			{"OpenSC", opensc},
+			{"DNIe", dnie},
+		}
+	}
+}
+
+func findMiddleware(goos string, exists func(string) bool) []Middleware {
+	var out []Middleware
+	for _, c := range middlewareCandidates(goos) {
+		m := Middleware{Name: c.name}
+		for _, p := range c.paths {
+			if exists(p) {
+				m.Path = p
+				break
+			}
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+func fileExists(path string) bool {
+	st, err := os.Stat(path)
+	return err == nil && !st.IsDir()
+}
+
+// DetectTokenSupport checks for card readers and smart card middleware. It
+// only inspects the system and never opens a session with a card.
+func DetectTokenSupport(ctx context.Context) TokenReport {
+	r := TokenReport{Middleware: findMiddleware(runtime.GOOS, fileExists)}
+	switch runtime.GOOS {
+	case "linux":
+		r.ReaderCheck = true
+		r.Readers = linuxCCIDReaders("/sys/bus/usb/devices")
+		r.ServiceMissing = true
+		for _, sock := range []string{"/run/pcscd/pcscd.comm", "/var/run/pcscd/pcscd.comm"} {
+			if _, err := os.Stat(sock); err == nil {
+				r.ServiceMissing = false
+				break
+			}
+		}
+	case "darwin":
+		out, err := runQuiet(ctx, "system_profiler", "SPSmartCardsDataType")
+		r.ReaderCheck = err == nil
+		r.Readers = parseMacReaders(out)
+	case "windows":
+		out, err := runQuiet(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command",
+			"Get-PnpDevice -Class SmartCardReader -PresentOnly | Select-Object -ExpandProperty FriendlyName")
+		r.ReaderCheck = err == nil
+		r.Readers = nonEmptyLines(out)
+	}
+	return r
+}
+
+func runQuiet(ctx context.Context, name string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	return exec.CommandContext(ctx, name, args...).Output()
+}
+
+// linuxCCIDReaders lists USB devices exposing a smart card (CCID, class 0x0b)
+// interface, by product name.
+func linuxCCIDReaders(sysRoot string) []string {
+	entries, err := os.ReadDir(sysRoot)
+	if err != nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	var readers []string
+	for _, e := range entries {
+		dev, _, isIface := strings.Cut(e.Name(), ":")
+		if !isIface {
+			continue
+		}
+		class, err := os.ReadFile(filepath.Join(sysRoot, e.Name(), "bInterfaceClass"))
+		if err != nil || strings.TrimSpace(string(class)) != "0b" || seen[dev] {
+			continue
+		}
+		seen[dev] = true
+		name := readSysAttr(sysRoot, dev, "product")
+		if name == "" {
+			name = "USB smart card reader " + readSysAttr(sysRoot, dev, "idVendor") + ":" + readSysAttr(sysRoot, dev, "idProduct")
+		}
+		if m := readSysAttr(sysRoot, dev, "manufacturer"); m != "" && !strings.HasPrefix(name, m) {
+			name = m + " " + name
+		}
+		readers = append(readers, name)
+	}
+	sort.Strings(readers)
+	return readers
+}
+
+func readSysAttr(sysRoot, dev, attr string) string {
+	b, err := os.ReadFile(filepath.Join(sysRoot, dev, attr))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// parseMacReaders extracts reader names from the "Readers:" section of
+// `system_profiler SPSmartCardsDataType`, e.g. "#01: ACS ACR39U (ATR:...)".
+func parseMacReaders(out []byte) []string {
+	var readers []string
+	inReaders := false
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if strings.HasSuffix(line, ":") && !strings.HasPrefix(line, "#") {
+			inReaders = line == "Readers:"
+			continue
+		}
+		if !inReaders || !strings.HasPrefix(line, "#") {
+			continue
+		}
+		_, name, ok := strings.Cut(line, ": ")
+		if !ok {
+			continue
+		}
+		if i := strings.Index(name, " (ATR"); i >= 0 {
+			name = name[:i]
+		}
+		if name = strings.TrimSpace(name); name != "" {
+			readers = append(readers, name)
+		}
+	}
+	return readers
+}
+
+func nonEmptyLines(out []byte) []string {
+	var lines []string
+	for _, l := range strings.Split(string(out), "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+// TokenSetupHints returns installation guidance for what the report found
+// missing on the given platform.
+func TokenSetupHints(goos string, r TokenReport) []string {
+	var hints []string
+	if r.ReaderCheck && len(r.Readers) == 0 {
+		hints = append(hints, "No card reader detected. Connect the reader, insert the card and press Check again.")
+	}
+	switch goos {
+	case "linux":
+		if r.ServiceMissing {
+			hints = append(hints, "The PC/SC smart card service is not running. Install it with 'sudo apt install pcscd' (Debian/Ubuntu) or 'sudo dnf install pcsc-lite' (Fedora) and start it with 'sudo systemctl enable --now pcscd.socket'.")
+		}
+		if !r.MiddlewareInstalled() {
+			hints = append(hints, "Install OpenSC with 'sudo apt install opensc' or 'sudo dnf install opensc', or the official libpkcs11-dnie package from https://www.dnielectronico.es/PortalDNIe/.")
+		}
+	case "darwin":
+		if !r.MiddlewareInstalled() {
+			hints = append(hints, "Install OpenSC from https://github.com/OpenSC/OpenSC/releases (or 'brew install --cask opensc'), or the official DNIe driver for macOS from https://www.dnielectronico.es/PortalDNIe/.")
+		}
+	case "windows":
+		if !r.MiddlewareInstalled() {
+			hints = append(hints, "Install the official DNIe driver from https://www.dnielectronico.es/PortalDNIe/ (Área de descargas), or OpenSC from https://github.com/OpenSC/OpenSC/releases.")
+		}
+	}
+	if r.MiddlewareInstalled() {
+		hints = append(hints, "Certificates on the card are found through your browser. If the scan does not list them, load the module above in Firefox under Settings > Privacy & Security > Security Devices > Load, then scan again.")
+	}
+	return hints
+}
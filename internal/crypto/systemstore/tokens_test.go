@@ -0,0 +1,88 @@
+package systemstore
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLinuxCCIDReaders(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) {
+		t.Helper()
+		p := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A CCID reader with two interfaces, a keyboard, and a nameless reader.
+	write("1-2/product", "ACR39U ICC Reader")
+	write("1-2/manufacturer", "ACS")
+	write("1-2:1.0/bInterfaceClass", "0b")
+	write("1-2:1.1/bInterfaceClass", "0b")
+	write("1-3/product", "USB Keyboard")
+	write("1-3:1.0/bInterfaceClass", "03")
+	write("2-1/idVendor", "076b")
+	write("2-1/idProduct", "3031")
+	write("2-1:1.0/bInterfaceClass", "0b")
+
+	got := linuxCCIDReaders(root)
+	want := []string{"ACS ACR39U ICC Reader", "USB smart card reader 076b:3031"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("readers = %q, want %q", got, want)
+	}
+	if got := linuxCCIDReaders(filepath.Join(root, "missing")); got != nil {
+		t.Fatalf("missing sysfs = %q", got)
+	}
+}
+
+func TestParseMacReaders(t *testing.T) {
+	out := []byte(`SmartCards:
+
+    Readers:
+
+      #01: Gemalto USB Shell Token V2 (ATR:{length = 20, bytes = 0x3b7f9600...})
+      #02: ACS ACR39U ICC Reader
+
+    Reader Drivers:
+
+      #01: fr.apdu.ccid.smartcardccid:1.5.0 (/usr/libexec/SmartCardServices/drivers/ifd-ccid.bundle)
+`)
+	got := parseMacReaders(out)
+	want := []string{"Gemalto USB Shell Token V2", "ACS ACR39U ICC Reader"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("readers = %q, want %q", got, want)
+	}
+}
+
+func TestFindMiddlewareAndHints(t *testing.T) {
+	installed := map[string]bool{"/usr/lib/x86_64-linux-gnu/opensc-pkcs11.so": true}
+	mw := findMiddleware("linux", func(p string) bool { return installed[p] })
+	if len(mw) != 2 || mw[0].Name != "OpenSC" || !mw[0].Installed() || mw[1].Installed() {
+		t.Fatalf("middleware = %+v", mw)
+	}
+
+	r := TokenReport{ReaderCheck: true, Readers: []string{"ACS ACR39U"}, Middleware: mw}
+	if !r.Ready() {
+		t.Fatalf("expected report to be ready: %+v", r)
+	}
+	r.ServiceMissing = true
+	if r.Ready() {
+		t.Fatal("expected missing pcscd to block readiness")
+	}
+	if hints := TokenSetupHints("linux", r); len(hints) != 2 {
+		t.Fatalf("hints = %q", hints)
+	}
+
+	empty := TokenReport{ReaderCheck: true, Middleware: findMiddleware("darwin", func(string) bool { return false })}
+	if empty.Ready() || empty.MiddlewareInstalled() {
+		t.Fatalf("expected empty report not to be ready: %+v", empty)
+	}
+	if hints := TokenSetupHints("darwin", empty); len(hints) != 2 {
+		t.Fatalf("hints = %q", hints)
+	}
+}
@@ -0,0 +1,180 @@
+package datadir
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/storage"
+)
+
+// Problem is something wrong found by an integrity check.
+type Problem struct {
+	Path   string // relative to the data directory
+	Detail string
+}
+
+func (p Problem) String() string {
+	return p.Path + ": " + p.Detail
+}
+
+// checkFunc inspects dir and returns the problems it found.
+type checkFunc func(dir string) []Problem
+
+var checks = []checkFunc{
+	checkPermissions,
+	checkJSONFiles,
+	checkStoreMetadata,
+	checkAuditLog,
+	checkSecurityLog,
+}
+
+// Check runs fns against dir and collects their problems.
+func Check(dir string, fns []checkFunc) []Problem {
+	var out []Problem
+	for _, fn := range fns {
+		out = append(out, fn(dir)...)
+	}
+	return out
+}
+
+// checkPermissions warns when other users can read the data directory.
+// Windows ACLs are not reflected in the mode bits, so it is skipped there.
+func checkPermissions(dir string) []Problem {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return []Problem{{Path: ".", Detail: err.Error()}}
+	}
+	if info.Mode().Perm()&0o077 != 0 {
+		return []Problem{{Path: ".", Detail: fmt.Sprintf("accessible by other users (mode %04o)", info.Mode().Perm())}}
+	}
+	return nil
+}
+
+// jsonFiles are the JSON documents VocSign keeps at the top of the data
+// directory. Missing files are fine; unreadable ones lose their contents.
+var jsonFiles = []string{
+	"settings.json",
+	"session.json",
+	"journal.json",
+	"window.json",
+	"organizers.json",
+	"audit_sync.json",
+	"cert_acks.json",
+	"wallet_pin.json",
+	layoutFile,
+}
+
+func checkJSONFiles(dir string) []Problem {
+	var out []Problem
+	for _, name := range jsonFiles {
+		if p, ok := checkJSON(dir, name, nil); !ok {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// checkJSON decodes the file at name, relative to dir, into v (or discards
+// it if v is nil).
+func checkJSON(dir, name string, v any) (Problem, bool) {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Problem{}, true
+		}
+		return Problem{Path: name, Detail: err.Error()}, false
+	}
+	if v == nil {
+		var discard any
+		v = &discard
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return Problem{Path: name, Detail: "not valid JSON: " + err.Error()}, false
+	}
+	return Problem{}, true
+}
+
+// checkStoreMetadata checks that every certificate in the vault has
+// readable metadata and, unless it lives on a token or in the OS store, its
+// encrypted key or, with deferred decryption, its certificate file.
+func checkStoreMetadata(dir string) []Problem {
+	entries, err := os.ReadDir(filepath.Join(dir, "store"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return []Problem{{Path: "store", Detail: err.Error()}}
+	}
+	var out []Problem
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		name := filepath.Join("store", e.Name())
+		if e.Name() == "vault.json" {
+			// The vault key binding, not a certificate.
+			if p, ok := checkJSON(dir, name, nil); !ok {
+				out = append(out, p)
+			}
+			continue
+		}
+		var meta struct {
+			CertPEM  string          `json:"certPem"`
+			PKCS11   json.RawMessage `json:"pkcs11"`
+			OSNative json.RawMessage `json:"osNative"`
+			Deferred bool            `json:"deferred"`
+		}
+		if p, ok := checkJSON(dir, name, &meta); !ok {
+			out = append(out, p)
+			continue
+		}
+		if meta.CertPEM == "" {
+			out = append(out, Problem{Path: name, Detail: "no certificate"})
+			continue
+		}
+		if meta.PKCS11 != nil || meta.OSNative != nil {
+			continue
+		}
+		ext := ".key.enc"
+		if meta.Deferred {
+			ext = ".p12"
+		}
+		key := filepath.Join("store", strings.TrimSuffix(e.Name(), ".json")+ext)
+		if _, err := os.Stat(filepath.Join(dir, key)); errors.Is(err, os.ErrNotExist) {
+			out = append(out, Problem{Path: key, Detail: "private key missing"})
+		}
+	}
+	return out
+}
+
+// checkAuditLog verifies the hash chain of the signing history.
+func checkAuditLog(dir string) []Problem {
+	logger, err := storage.NewAuditLogger(dir)
+	if err != nil {
+		return []Problem{{Path: "audit.jsonl", Detail: err.Error()}}
+	}
+	if _, err := logger.Verify(); err != nil {
+		return []Problem{{Path: "audit.jsonl", Detail: err.Error()}}
+	}
+	return nil
+}
+
+// checkSecurityLog verifies the hash chain of the security log.
+func checkSecurityLog(dir string) []Problem {
+	l, err := storage.NewSecurityLog(dir)
+	if err != nil {
+		return []Problem{{Path: "security.jsonl", Detail: err.Error()}}
+	}
+	if _, err := l.Verify(); err != nil {
+		return []Problem{{Path: "security.jsonl", Detail: err.Error()}}
+	}
+	return nil
+}
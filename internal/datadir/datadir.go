@@ -0,0 +1,331 @@
+// Package datadir versions the layout of the ~/.vocsign data directory. At
+// startup Prepare snapshots the directory, runs the migrations the installed
+// layout is missing and checks that the files VocSign depends on can still be
+// read, so a format change in a new release cannot leave an install unusable.
+package datadir
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// layoutFile records the layout version of the data directory.
+	layoutFile = "layout.json"
+	// BackupDir holds the snapshots taken before migrating. It is never
+	// included in a snapshot itself.
+	BackupDir = "backups"
+	// keepBackups is how many snapshots are kept; older ones are removed.
+	keepBackups = 3
+)
+
+// ErrNewerLayout means the data directory was written by a newer VocSign.
+// It is left untouched; files in formats this version does not know may
+// fail to load.
+var ErrNewerLayout = errors.New("data directory was written by a newer version of VocSign")
+
+// Migration upgrades the data directory to Version from Version-1.
+type Migration struct {
+	Version int
+	Name    string
+	Apply   func(dir string) error
+}
+
+// Layout is the contents of layoutFile.
+type Layout struct {
+	Version   int    `json:"version"`
+	UpdatedAt string `json:"updatedAt"` // RFC 3339
+}
+
+// Report is the outcome of Prepare.
+type Report struct {
+	From, To int
+	// Applied lists the names of the migrations that ran.
+	Applied []string
+	// Backup is the snapshot taken before migrating, if any.
+	Backup string
+	// Err is set when migrating failed. The directory was restored from
+	// Backup and the migrations are retried on the next launch.
+	Err      error
+	Problems []Problem
+}
+
+// OK reports whether there is nothing to tell the user.
+func (r Report) OK() bool {
+	return r.Err == nil && len(r.Problems) == 0
+}
+
+// Prepare brings dir to the current layout and checks its contents.
+func Prepare(dir string) Report {
+	r := Migrate(dir, migrations)
+	if r.Err != nil {
+		log.Printf("ERROR: data directory migration: %v", r.Err)
+	}
+	r.Problems = Check(dir, checks)
+	for _, p := range r.Problems {
+		log.Printf("WARNING: data directory check: %s", p)
+	}
+	return r
+}
+
+// Migrate applies the migrations in ms that are newer than the layout of
+// dir, in order, after taking a snapshot of it. If one fails, the snapshot
+// is restored and the layout version is left as it was.
+func Migrate(dir string, ms []Migration) Report {
+	target := 0
+	for _, m := range ms {
+		target = max(target, m.Version)
+	}
+	r := Report{To: target}
+
+	from, err := readLayout(dir)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	r.From = from
+	if from < 0 {
+		// A fresh install has nothing to migrate.
+		r.From = target
+		r.Err = writeLayout(dir, target)
+		return r
+	}
+	if from > target {
+		r.To = from
+		r.Err = ErrNewerLayout
+		return r
+	}
+
+	pending := make([]Migration, 0, len(ms))
+	for _, m := range ms {
+		if m.Version > from {
+			pending = append(pending, m)
+		}
+	}
+	if len(pending) == 0 {
+		return r
+	}
+	sort.SliceStable(pending, func(i, j int) bool { return pending[i].Version < pending[j].Version })
+
+	backup, err := snapshot(dir, from)
+	if err != nil {
+		r.Err = fmt.Errorf("failed to back up data directory: %w", err)
+		return r
+	}
+	r.Backup = backup
+	for _, m := range pending {
+		if err := m.Apply(dir); err != nil {
+			r.Err = fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+			if rerr := restore(dir, backup); rerr != nil {
+				r.Err = fmt.Errorf("%w; restoring %s failed: %v", r.Err, backup, rerr)
+			}
+			return r
+		}
+		r.Applied = append(r.Applied, m.Name)
+	}
+	if err := writeLayout(dir, target); err != nil {
+		r.Err = err
+	}
+	pruneBackups(dir)
+	return r
+}
+
+// readLayout returns the layout version of dir: 0 for a directory written
+// before layouts were versioned and -1 for an empty one.
+func readLayout(dir string) (int, error) {
+	data, err := os.ReadFile(filepath.Join(dir, layoutFile))
+	if err == nil {
+		var l Layout
+		if err := json.Unmarshal(data, &l); err != nil {
+			return 0, fmt.Errorf("failed to decode %s: %w", layoutFile, err)
+		}
+		return l.Version, nil
+	}
+	if !os.IsNotExist(err) {
+		return 0, fmt.Errorf("failed to read %s: %w", layoutFile, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read data directory: %w", err)
+	}
+	if len(entries) == 0 {
+		return -1, nil
+	}
+	return 0, nil
+}
+
+func writeLayout(dir string, version int) error {
+	data, err := json.MarshalIndent(Layout{Version: version, UpdatedAt: time.Now().UTC().Format(time.RFC3339)}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal layout: %w", err)
+	}
+	path := filepath.Join(dir, layoutFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write layout: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// snapshot copies everything in dir except the backups into a new folder
+// under BackupDir and returns its path.
+func snapshot(dir string, version int) (string, error) {
+	dst := filepath.Join(dir, BackupDir, fmt.Sprintf("%d-layout-v%d", time.Now().UnixNano(), version))
+	if err := os.MkdirAll(dst, 0o700); err != nil {
+		return "", err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, e := range entries {
+		if e.Name() == BackupDir {
+			continue
+		}
+		if err := copyTree(filepath.Join(dir, e.Name()), filepath.Join(dst, e.Name())); err != nil {
+			return "", err
+		}
+	}
+	return dst, nil
+}
+
+// restore replaces the contents of dir, except the backups, with the
+// snapshot in backup.
+func restore(dir, backup string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.Name() == BackupDir {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, e.Name())); err != nil {
+			return err
+		}
+	}
+	saved, err := os.ReadDir(backup)
+	if err != nil {
+		return err
+	}
+	for _, e := range saved {
+		if err := copyTree(filepath.Join(backup, e.Name()), filepath.Join(dir, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyTree(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	switch {
+	case info.IsDir():
+		if err := os.MkdirAll(dst, 0o700); err != nil {
+			return err
+		}
+		entries, err := os.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := copyTree(filepath.Join(src, e.Name()), filepath.Join(dst, e.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	case info.Mode().IsRegular():
+		return copyFile(src, dst)
+	default:
+		// Sockets, symlinks and the like are not VocSign data.
+		return nil
+	}
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// RemoveFromBackups deletes the files of every snapshot in dir for which
+// match, given the path relative to the snapshot with forward slashes,
+// returns true. It is used to drop copies of files that must not outlive
+// their originals, such as keys encrypted with a key since replaced.
+func RemoveFromBackups(dir string, match func(rel string) bool) error {
+	root := filepath.Join(dir, BackupDir)
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read backups: %w", err)
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		snap := filepath.Join(root, e.Name())
+		err := filepath.WalkDir(snap, func(path string, d os.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(snap, path)
+			if err != nil {
+				return err
+			}
+			if !match(filepath.ToSlash(rel)) {
+				return nil
+			}
+			return os.Remove(path)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to clean backup %s: %w", e.Name(), err)
+		}
+	}
+	return nil
+}
+
+// pruneBackups removes all but the newest keepBackups snapshots. Snapshot
+// names start with their creation time, so they sort by age.
+func pruneBackups(dir string) {
+	root := filepath.Join(dir, BackupDir)
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && strings.Contains(e.Name(), "-layout-v") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	for len(names) > keepBackups {
+		if err := os.RemoveAll(filepath.Join(root, names[0])); err != nil {
+			log.Printf("WARNING: failed to remove old data backup %s: %v", names[0], err)
+		}
+		names = names[1:]
+	}
+}
@@ -0,0 +1,274 @@
+package datadir
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestMigrate(t *testing.T) {
+	var ran []string
+	record := func(name string, err error) func(string) error {
+		return func(dir string) error {
+			ran = append(ran, name)
+			writeFile(t, dir, "settings.json", `{"migrated":"`+name+`"}`)
+			return err
+		}
+	}
+	boom := errors.New("boom")
+
+	tests := []struct {
+		name     string
+		existing map[string]string
+		ms       []Migration
+		wantFrom int
+		wantRan  []string
+		wantErr  error
+		// wantSettings is the settings.json left behind, "" if none.
+		wantSettings string
+		wantLayout   int
+		wantBackup   bool
+	}{
+		{
+			name:       "fresh install",
+			ms:         []Migration{{Version: 1, Name: "one", Apply: record("one", nil)}},
+			wantFrom:   1,
+			wantLayout: 1,
+		},
+		{
+			name:         "unversioned directory",
+			existing:     map[string]string{"settings.json": `{}`},
+			ms:           []Migration{{Version: 2, Name: "two", Apply: record("two", nil)}, {Version: 1, Name: "one", Apply: record("one", nil)}},
+			wantRan:      []string{"one", "two"},
+			wantSettings: `{"migrated":"two"}`,
+			wantLayout:   2,
+			wantBackup:   true,
+		},
+		{
+			name:         "only newer migrations run",
+			existing:     map[string]string{"settings.json": `{}`, layoutFile: `{"version":1}`},
+			ms:           []Migration{{Version: 1, Name: "one", Apply: record("one", nil)}, {Version: 2, Name: "two", Apply: record("two", nil)}},
+			wantFrom:     1,
+			wantRan:      []string{"two"},
+			wantSettings: `{"migrated":"two"}`,
+			wantLayout:   2,
+			wantBackup:   true,
+		},
+		{
+			name:         "up to date",
+			existing:     map[string]string{"settings.json": `{}`, layoutFile: `{"version":1}`},
+			ms:           []Migration{{Version: 1, Name: "one", Apply: record("one", nil)}},
+			wantFrom:     1,
+			wantSettings: `{}`,
+			wantLayout:   1,
+		},
+		{
+			name:         "failure restores the backup",
+			existing:     map[string]string{"settings.json": `{}`},
+			ms:           []Migration{{Version: 1, Name: "one", Apply: record("one", nil)}, {Version: 2, Name: "two", Apply: record("two", boom)}},
+			wantRan:      []string{"one", "two"},
+			wantErr:      boom,
+			wantSettings: `{}`,
+			wantLayout:   0,
+			wantBackup:   true,
+		},
+		{
+			name:         "newer layout is left alone",
+			existing:     map[string]string{"settings.json": `{}`, layoutFile: `{"version":5}`},
+			ms:           []Migration{{Version: 1, Name: "one", Apply: record("one", nil)}},
+			wantFrom:     5,
+			wantErr:      ErrNewerLayout,
+			wantSettings: `{}`,
+			wantLayout:   5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran = nil
+			dir := t.TempDir()
+			for name, content := range tt.existing {
+				writeFile(t, dir, name, content)
+			}
+
+			r := Migrate(dir, tt.ms)
+			if !errors.Is(r.Err, tt.wantErr) || (tt.wantErr == nil) != (r.Err == nil) {
+				t.Fatalf("err = %v, want %v", r.Err, tt.wantErr)
+			}
+			if r.From != tt.wantFrom {
+				t.Errorf("From = %d, want %d", r.From, tt.wantFrom)
+			}
+			if strings.Join(ran, ",") != strings.Join(tt.wantRan, ",") {
+				t.Errorf("ran %v, want %v", ran, tt.wantRan)
+			}
+			if (r.Backup != "") != tt.wantBackup {
+				t.Errorf("Backup = %q, want one: %v", r.Backup, tt.wantBackup)
+			}
+			if tt.wantSettings != "" {
+				if got := readFile(t, dir, "settings.json"); got != tt.wantSettings {
+					t.Errorf("settings.json = %s, want %s", got, tt.wantSettings)
+				}
+			}
+			got, err := readLayout(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.wantLayout {
+				t.Errorf("layout = %d, want %d", got, tt.wantLayout)
+			}
+		})
+	}
+}
+
+func TestMigrateBackupContents(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "settings.json", `{"a":1}`)
+	writeFile(t, dir, "store/id.json", `{"certPem":"x"}`)
+	writeFile(t, dir, BackupDir+"/old/keep", "x")
+
+	r := Migrate(dir, []Migration{{Version: 1, Name: "noop", Apply: func(string) error { return nil }}})
+	if r.Err != nil {
+		t.Fatal(r.Err)
+	}
+	if got := readFile(t, r.Backup, "settings.json"); got != `{"a":1}` {
+		t.Errorf("backed up settings = %s", got)
+	}
+	if got := readFile(t, r.Backup, "store/id.json"); got != `{"certPem":"x"}` {
+		t.Errorf("backed up store metadata = %s", got)
+	}
+	if _, err := os.Stat(filepath.Join(r.Backup, BackupDir)); !os.IsNotExist(err) {
+		t.Errorf("backup includes the backups folder: %v", err)
+	}
+}
+
+func TestPruneBackups(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"100-layout-v0", "200-layout-v0", "300-layout-v1", "400-layout-v1", "manual"} {
+		writeFile(t, dir, filepath.Join(BackupDir, name, "settings.json"), "{}")
+	}
+	pruneBackups(dir)
+
+	entries, err := os.ReadDir(filepath.Join(dir, BackupDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name())
+	}
+	if want := "200-layout-v0,300-layout-v1,400-layout-v1,manual"; strings.Join(got, ",") != want {
+		t.Errorf("backups = %v, want %s", got, want)
+	}
+}
+
+func TestRemoveFromBackups(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"100-layout-v0", "200-layout-v1"} {
+		writeFile(t, dir, filepath.Join(BackupDir, name, "store/id.key.enc"), "key")
+		writeFile(t, dir, filepath.Join(BackupDir, name, "store/id.json"), "{}")
+	}
+	writeFile(t, dir, "store/id.key.enc", "key")
+
+	err := RemoveFromBackups(dir, func(rel string) bool { return strings.HasSuffix(rel, ".key.enc") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"100-layout-v0", "200-layout-v1"} {
+		if _, err := os.Stat(filepath.Join(dir, BackupDir, name, "store/id.key.enc")); !os.IsNotExist(err) {
+			t.Errorf("%s: key still backed up: %v", name, err)
+		}
+		readFile(t, filepath.Join(dir, BackupDir, name), "store/id.json")
+	}
+	if got := readFile(t, dir, "store/id.key.enc"); got != "key" {
+		t.Errorf("live key = %q", got)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		fn    checkFunc
+		want  []string // problem paths
+	}{
+		{
+			name:  "valid JSON files",
+			files: map[string]string{"settings.json": `{}`, "window.json": `{"width":1}`},
+			fn:    checkJSONFiles,
+		},
+		{
+			name:  "corrupt settings",
+			files: map[string]string{"settings.json": `{"submitReview`},
+			fn:    checkJSONFiles,
+			want:  []string{"settings.json"},
+		},
+		{
+			name: "store metadata",
+			files: map[string]string{
+				"store/ok.json":      `{"certPem":"pem"}`,
+				"store/ok.key.enc":   "key",
+				"store/token.json":   `{"certPem":"pem","pkcs11":{"module":"x"}}`,
+				"store/nokey.json":   `{"certPem":"pem"}`,
+				"store/file.json":    `{"certPem":"pem","deferred":true}`,
+				"store/file.p12":     "p12",
+				"store/nofile.json":  `{"certPem":"pem","deferred":true}`,
+				"store/vault.json":   `{"hardware":"TPM"}`,
+				"store/nocert.json":  `{"id":"nocert"}`,
+				"store/broken.json":  `{`,
+				"store/trash/x.json": `{`,
+			},
+			fn:   checkStoreMetadata,
+			want: []string{"store/broken.json", "store/nocert.json", "store/nofile.p12", "store/nokey.key.enc"},
+		},
+		{
+			name:  "audit log chain",
+			files: map[string]string{"audit.jsonl": `{"schemaVersion":2,"prevHash":"bad"}` + "\n"},
+			fn:    checkAuditLog,
+			want:  []string{"audit.jsonl"},
+		},
+		{
+			name:  "security log chain",
+			files: map[string]string{"security.jsonl": `{"kind":"auth_failed","prevHash":"bad"}` + "\n"},
+			fn:    checkSecurityLog,
+			want:  []string{"security.jsonl"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				writeFile(t, dir, name, content)
+			}
+			var got []string
+			for _, p := range Check(dir, []checkFunc{tt.fn}) {
+				got = append(got, filepath.ToSlash(p.Path))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("problems in %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package datadir
+
+import "github.com/vocdoni/gofirma/vocsign/internal/storage"
+
+// migrations upgrade the data directory, one per layout version. A format
+// change registered here runs once, after the directory is backed up, and
+// is recorded in the layout version. Migrations must be idempotent: a failed
+// run is restored from the backup and retried on the next launch.
+var migrations = []Migration{
+	// Layout 0 is every directory written before layouts were versioned.
+	{Version: 1, Name: "audit log schema 2", Apply: storage.MigrateAuditLog},
+}
@@ -0,0 +1,281 @@
+// Package delta makes and applies binary patches between two releases of the
+// VocSign executable, so an update downloads only what changed.
+//
+// Diff follows Colin Percival's bsdiff: the old file is suffix-sorted, and
+// the new file is described as runs copied from similar regions of the old
+// one, stored as byte differences, plus bytes that are new. The differences
+// are mostly zeros, so the patch compresses well. Patches are compressed
+// with DEFLATE, the best compressor in the standard library.
+//
+// A patch starts with a fixed header:
+//
+//	magic    "VSDELTA1"
+//	oldSize  uint64, little endian
+//	newSize  uint64
+//	oldSHA   [32]byte, SHA-256 of the file the patch applies to
+//	newSHA   [32]byte, SHA-256 of the file it produces
+//
+// followed by a DEFLATE stream of records: three int64 (the length of the
+// difference run, the length of the new bytes and how far to move in the
+// old file), then the difference bytes and the new bytes.
+package delta
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+const magic = "VSDELTA1"
+
+// headerSize is the size of the patch header.
+const headerSize = len(magic) + 8 + 8 + 2*sha256.Size
+
+var (
+	// ErrOldMismatch means the patch was made for a different file than
+	// the one it is applied to.
+	ErrOldMismatch = errors.New("patch does not apply to this file")
+	// ErrCorrupt means the patch is malformed or produced a file with the
+	// wrong digest.
+	ErrCorrupt = errors.New("corrupt patch")
+)
+
+// Header describes a patch.
+type Header struct {
+	OldSize int64
+	NewSize int64
+	OldSHA  [sha256.Size]byte
+	NewSHA  [sha256.Size]byte
+}
+
+// ReadHeader reads the header at the start of a patch.
+func ReadHeader(r io.Reader) (Header, error) {
+	var buf [headerSize]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return Header{}, fmt.Errorf("%w: short header", ErrCorrupt)
+	}
+	if string(buf[:len(magic)]) != magic {
+		return Header{}, fmt.Errorf("%w: not a VocSign patch", ErrCorrupt)
+	}
+	b := buf[len(magic):]
+	oldSize, newSize := binary.LittleEndian.Uint64(b), binary.LittleEndian.Uint64(b[8:])
+	if oldSize > math.MaxInt32 || newSize > math.MaxInt32 {
+		return Header{}, fmt.Errorf("%w: file size out of range", ErrCorrupt)
+	}
+	h := Header{OldSize: int64(oldSize), NewSize: int64(newSize)}
+	copy(h.OldSHA[:], b[16:])
+	copy(h.NewSHA[:], b[16+sha256.Size:])
+	return h, nil
+}
+
+// Diff writes a patch that turns old into new.
+func Diff(w io.Writer, old, new []byte) error {
+	if len(old) >= math.MaxInt32 || len(new) >= math.MaxInt32 {
+		return errors.New("file too large to diff")
+	}
+	var hdr [headerSize]byte
+	copy(hdr[:], magic)
+	binary.LittleEndian.PutUint64(hdr[len(magic):], uint64(len(old)))
+	binary.LittleEndian.PutUint64(hdr[len(magic)+8:], uint64(len(new)))
+	oldSHA, newSHA := sha256.Sum256(old), sha256.Sum256(new)
+	copy(hdr[len(magic)+16:], oldSHA[:])
+	copy(hdr[len(magic)+16+sha256.Size:], newSHA[:])
+	if _, err := w.Write(hdr[:]); err != nil {
+		return err
+	}
+
+	zw, err := flate.NewWriter(w, flate.BestCompression)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(zw)
+	if err := diff(bw, old, new); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// diff is the bsdiff search. It writes one record each time it finds a
+// region of old that matches new better than the current alignment.
+func diff(w *bufio.Writer, old, new []byte) error {
+	sa := suffixSort(old)
+	oldSize, newSize := len(old), len(new)
+
+	var scan, pos, n int
+	var lastScan, lastPos, lastOffset int
+	for scan < newSize {
+		oldScore := 0
+		scan += n
+		for scsc := scan; scan < newSize; scan++ {
+			n, pos = search(sa, old, new[scan:], 0, oldSize)
+			for ; scsc < scan+n; scsc++ {
+				if scsc+lastOffset < oldSize && old[scsc+lastOffset] == new[scsc] {
+					oldScore++
+				}
+			}
+			if (n == oldScore && n != 0) || n > oldScore+8 {
+				break
+			}
+			if scan+lastOffset < oldSize && old[scan+lastOffset] == new[scan] {
+				oldScore--
+			}
+		}
+		if n == oldScore && scan != newSize {
+			continue
+		}
+
+		// Extend the previous match forwards and this one backwards as
+		// long as more than half the bytes agree.
+		var lenF, lenB int
+		for i, s, best := 0, 0, 0; lastScan+i < scan && lastPos+i < oldSize; {
+			if old[lastPos+i] == new[lastScan+i] {
+				s++
+			}
+			i++
+			if s*2-i > best*2-lenF {
+				best, lenF = s, i
+			}
+		}
+		if scan < newSize {
+			for i, s, best := 1, 0, 0; scan >= lastScan+i && pos >= i; i++ {
+				if old[pos-i] == new[scan-i] {
+					s++
+				}
+				if s*2-i > best*2-lenB {
+					best, lenB = s, i
+				}
+			}
+		}
+		if lastScan+lenF > scan-lenB {
+			overlap := (lastScan + lenF) - (scan - lenB)
+			s, best, lenS := 0, 0, 0
+			for i := 0; i < overlap; i++ {
+				if new[lastScan+lenF-overlap+i] == old[lastPos+lenF-overlap+i] {
+					s++
+				}
+				if new[scan-lenB+i] == old[pos-lenB+i] {
+					s--
+				}
+				if s > best {
+					best, lenS = s, i+1
+				}
+			}
+			lenF += lenS - overlap
+			lenB -= lenS
+		}
+
+		extra := (scan - lenB) - (lastScan + lenF)
+		var ctrl [24]byte
+		binary.LittleEndian.PutUint64(ctrl[0:], uint64(int64(lenF)))
+		binary.LittleEndian.PutUint64(ctrl[8:], uint64(int64(extra)))
+		binary.LittleEndian.PutUint64(ctrl[16:], uint64(int64((pos-lenB)-(lastPos+lenF))))
+		if _, err := w.Write(ctrl[:]); err != nil {
+			return err
+		}
+		for i := 0; i < lenF; i++ {
+			if err := w.WriteByte(new[lastScan+i] - old[lastPos+i]); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(new[lastScan+lenF : lastScan+lenF+extra]); err != nil {
+			return err
+		}
+
+		lastScan, lastPos, lastOffset = scan-lenB, pos-lenB, pos-scan
+	}
+	return nil
+}
+
+// Patch applies patch to old and returns the new file. The result is
+// checked against the digest in the patch header. A patch that claims to
+// produce more than maxSize bytes is rejected before anything is allocated.
+func Patch(old []byte, patch io.Reader, maxSize int64) ([]byte, error) {
+	h, err := ReadHeader(patch)
+	if err != nil {
+		return nil, err
+	}
+	if h.NewSize > maxSize {
+		return nil, fmt.Errorf("%w: new file is %d bytes, expected at most %d", ErrCorrupt, h.NewSize, maxSize)
+	}
+	if int64(len(old)) != h.OldSize || sha256.Sum256(old) != h.OldSHA {
+		return nil, ErrOldMismatch
+	}
+
+	zr := flate.NewReader(patch)
+	defer func() { _ = zr.Close() }()
+	r := bufio.NewReader(zr)
+	out := make([]byte, h.NewSize)
+	var newPos, oldPos int64
+	var ctrl [24]byte
+	for newPos < h.NewSize {
+		if _, err := io.ReadFull(r, ctrl[:]); err != nil {
+			return nil, fmt.Errorf("%w: truncated", ErrCorrupt)
+		}
+		diffLen := int64(binary.LittleEndian.Uint64(ctrl[0:]))
+		extraLen := int64(binary.LittleEndian.Uint64(ctrl[8:]))
+		seek := int64(binary.LittleEndian.Uint64(ctrl[16:]))
+		if diffLen < 0 || extraLen < 0 || diffLen > h.NewSize-newPos || extraLen > h.NewSize-newPos-diffLen ||
+			seek < -h.OldSize || seek > h.OldSize {
+			return nil, fmt.Errorf("%w: bad record", ErrCorrupt)
+		}
+
+		run := out[newPos : newPos+diffLen]
+		if _, err := io.ReadFull(r, run); err != nil {
+			return nil, fmt.Errorf("%w: truncated", ErrCorrupt)
+		}
+		for i := range run {
+			if p := oldPos + int64(i); p >= 0 && p < h.OldSize {
+				run[i] += old[p]
+			}
+		}
+		newPos += diffLen
+		oldPos += diffLen
+
+		if _, err := io.ReadFull(r, out[newPos:newPos+extraLen]); err != nil {
+			return nil, fmt.Errorf("%w: truncated", ErrCorrupt)
+		}
+		newPos += extraLen
+		oldPos += seek
+	}
+	if sha256.Sum256(out) != h.NewSHA {
+		return nil, fmt.Errorf("%w: digest mismatch", ErrCorrupt)
+	}
+	return out, nil
+}
+
+// search finds the longest prefix of new that occurs in old, looking
+// between suffixes st and en of the suffix array.
+func search(sa []int32, old, new []byte, st, en int) (n, pos int) {
+	for en-st >= 2 {
+		x := st + (en-st)/2
+		if bytes.Compare(old[sa[x]:], new[:min(len(old)-int(sa[x]), len(new))]) < 0 {
+			st = x
+		} else {
+			en = x
+		}
+	}
+	x, y := matchLen(old[sa[st]:], new), matchLen(old[sa[en]:], new)
+	if x > y {
+		return x, int(sa[st])
+	}
+	return y, int(sa[en])
+}
+
+func matchLen(a, b []byte) int {
+	n := min(len(a), len(b))
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
@@ -0,0 +1,138 @@
+package delta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSuffixSort(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	inputs := [][]byte{
+		nil,
+		[]byte("a"),
+		[]byte("banana"),
+		bytes.Repeat([]byte("ab"), 50),
+		bytes.Repeat([]byte{0}, 100),
+	}
+	random := make([]byte, 2000)
+	for i := range random {
+		random[i] = byte(rng.Intn(4))
+	}
+	inputs = append(inputs, random)
+
+	for _, in := range inputs {
+		got := suffixSort(in)
+		want := make([]int32, len(in)+1)
+		for i := range want {
+			want[i] = int32(i)
+		}
+		sort.Slice(want, func(a, b int) bool { return bytes.Compare(in[want[a]:], in[want[b]:]) < 0 })
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("suffixSort(%q)[%d] = %d, want %d", in, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+// release returns a pseudo-executable: mostly random bytes with repeated
+// structure, like code and tables.
+func release(rng *rand.Rand, n int) []byte {
+	b := make([]byte, n)
+	_, _ = rng.Read(b)
+	for i := 0; i+64 < n; i += 256 {
+		copy(b[i:], "\x48\x89\x5c\x24\x08\x57\x48\x83\xec\x20")
+	}
+	return b
+}
+
+func TestDiffPatch(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	old := release(rng, 200_000)
+
+	// The next release: a few edited bytes, an inserted function, a
+	// removed block and code shifted by a few bytes.
+	new := append([]byte{}, old[:50_000]...)
+	new = append(new, release(rng, 3000)...)
+	new = append(new, old[60_000:120_000]...)
+	for i := 0; i < len(new); i += 997 {
+		new[i] ^= 0x5A
+	}
+	new = append(new, old[121_000:]...)
+
+	var patch bytes.Buffer
+	if err := Diff(&patch, old, new); err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	if patch.Len() > len(new)/5 {
+		t.Errorf("patch is %d bytes for a %d byte file", patch.Len(), len(new))
+	}
+	h, err := ReadHeader(bytes.NewReader(patch.Bytes()))
+	if err != nil || h.OldSize != int64(len(old)) || h.NewSize != int64(len(new)) {
+		t.Fatalf("header = %+v, %v", h, err)
+	}
+
+	got, err := Patch(old, bytes.NewReader(patch.Bytes()), int64(len(new)))
+	if err != nil {
+		t.Fatalf("Patch: %v", err)
+	}
+	if !bytes.Equal(got, new) {
+		t.Fatal("patched file differs")
+	}
+}
+
+func TestDiffPatchEdgeCases(t *testing.T) {
+	for _, tc := range []struct{ old, new string }{
+		{"", ""},
+		{"", "brand new"},
+		{"everything removed", ""},
+		{"same", "same"},
+		{"abcdefghij", "jihgfedcba"},
+	} {
+		var patch bytes.Buffer
+		if err := Diff(&patch, []byte(tc.old), []byte(tc.new)); err != nil {
+			t.Fatalf("Diff(%q, %q): %v", tc.old, tc.new, err)
+		}
+		got, err := Patch([]byte(tc.old), &patch, int64(len(tc.new)))
+		if err != nil || string(got) != tc.new {
+			t.Fatalf("Patch(%q) = %q, %v; want %q", tc.old, got, err, tc.new)
+		}
+	}
+}
+
+func TestPatchRejects(t *testing.T) {
+	old, new := []byte("the old release binary"), []byte("the new release binary!")
+	var patch bytes.Buffer
+	if err := Diff(&patch, old, new); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Patch([]byte("another binary"), bytes.NewReader(patch.Bytes()), int64(len(new))); !errors.Is(err, ErrOldMismatch) {
+		t.Errorf("wrong old file: err = %v", err)
+	}
+	if _, err := Patch(old, bytes.NewReader([]byte("MZ\x90\x00")), int64(len(new))); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("not a patch: err = %v", err)
+	}
+	truncated := patch.Bytes()[:patch.Len()-4]
+	if _, err := Patch(old, bytes.NewReader(truncated), int64(len(new))); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("truncated patch: err = %v", err)
+	}
+	tampered := bytes.Clone(patch.Bytes())
+	tampered[len(magic)+16+32] ^= 1 // new digest
+	if _, err := Patch(old, bytes.NewReader(tampered), int64(len(new))); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("wrong digest: err = %v", err)
+	}
+	if _, err := Patch(old, bytes.NewReader(patch.Bytes()), int64(len(new))-1); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("new file larger than expected: err = %v", err)
+	}
+	huge := bytes.Clone(patch.Bytes())
+	binary.LittleEndian.PutUint64(huge[len(magic)+8:], math.MaxInt32)
+	if _, err := Patch(old, bytes.NewReader(huge), 1<<20); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("oversized header: err = %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package delta
+
+// suffixSort returns the suffix array of buf, with the empty suffix first,
+// using the Larsson–Sadakane qsufsort algorithm of bsdiff.
+func suffixSort(buf []byte) []int32 {
+	n := len(buf)
+	I := make([]int32, n+1)
+	V := make([]int32, n+1)
+
+	var buckets [256]int32
+	for _, c := range buf {
+		buckets[c]++
+	}
+	for i := 1; i < 256; i++ {
+		buckets[i] += buckets[i-1]
+	}
+	copy(buckets[1:], buckets[:255])
+	buckets[0] = 0
+	for i, c := range buf {
+		buckets[c]++
+		I[buckets[c]] = int32(i)
+	}
+	I[0] = int32(n)
+	for i, c := range buf {
+		V[i] = buckets[c]
+	}
+	V[n] = 0
+	for i := 1; i < 256; i++ {
+		if buckets[i] == buckets[i-1]+1 {
+			I[buckets[i]] = -1
+		}
+	}
+	I[0] = -1
+
+	for h := 1; I[0] != -int32(n+1); h += h {
+		var l int32
+		i := 0
+		for i < n+1 {
+			if I[i] < 0 {
+				l -= I[i]
+				i -= int(I[i])
+				continue
+			}
+			if l != 0 {
+				I[i-int(l)] = -l
+			}
+			l = V[I[i]] + 1 - int32(i)
+			split(I, V, i, int(l), h)
+			i += int(l)
+			l = 0
+		}
+		if l != 0 {
+			I[i-int(l)] = -l
+		}
+	}
+	for i := 0; i < n+1; i++ {
+		I[V[i]] = int32(i)
+	}
+	return I
+}
+
+// split sorts I[start:start+n] by the rank of the suffix h bytes further
+// on, a ternary quicksort that falls back to selection sort for short runs.
+func split(I, V []int32, start, n, h int) {
+	if n < 16 {
+		for k := start; k < start+n; {
+			j := 1
+			x := V[int(I[k])+h]
+			for i := 1; k+i < start+n; i++ {
+				if v := V[int(I[k+i])+h]; v < x {
+					x = v
+					j = 0
+				}
+				if V[int(I[k+i])+h] == x {
+					I[k+j], I[k+i] = I[k+i], I[k+j]
+					j++
+				}
+			}
+			for i := 0; i < j; i++ {
+				V[I[k+i]] = int32(k + j - 1)
+			}
+			if j == 1 {
+				I[k] = -1
+			}
+			k += j
+		}
+		return
+	}
+
+	x := V[int(I[start+n/2])+h]
+	jj, kk := 0, 0
+	for i := start; i < start+n; i++ {
+		if v := V[int(I[i])+h]; v < x {
+			jj++
+		} else if v == x {
+			kk++
+		}
+	}
+	jj += start
+	kk += jj
+
+	i, j, k := start, 0, 0
+	for i < jj {
+		if v := V[int(I[i])+h]; v < x {
+			i++
+		} else if v == x {
+			I[i], I[jj+j] = I[jj+j], I[i]
+			j++
+		} else {
+			I[i], I[kk+k] = I[kk+k], I[i]
+			k++
+		}
+	}
+	for jj+j < kk {
+		if V[int(I[jj+j])+h] == x {
+			j++
+		} else {
+			I[jj+j], I[kk+k] = I[kk+k], I[jj+j]
+			k++
+		}
+	}
+
+	if jj > start {
+		split(I, V, start, jj-start, h)
+	}
+	for i := 0; i < kk-jj; i++ {
+		V[I[jj+i]] = int32(kk - 1)
+	}
+	if jj == kk-1 {
+		I[jj] = -1
+	}
+	if start+n > kk {
+		split(I, V, kk, start+n-kk, h)
+	}
+}
@@ -0,0 +1,122 @@
+// Package demo issues the throwaway identity that signs the requests of demo
+// campaigns, which organizers run to rehearse a collection end to end with
+// volunteers who do not want to expose their real certificate or DNI.
+package demo
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	mrand "math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/certs"
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/pkcs12store"
+)
+
+// IDPrefix starts the ID of demo identities, which are never stored.
+const IDPrefix = "demo:"
+
+// Issuer is the common name of the CA that issues demo certificates, so a
+// demo signature is recognizable from its chain alone.
+const Issuer = "VocSign Demo CA - NOT A REAL CERTIFICATE"
+
+// validity bounds a demo certificate to the rehearsal it is made for.
+const validity = 24 * time.Hour
+
+var (
+	oidSerialNumber = asn1.ObjectIdentifier{2, 5, 4, 5}
+	oidCommonName   = asn1.ObjectIdentifier{2, 5, 4, 3}
+	oidGivenName    = asn1.ObjectIdentifier{2, 5, 4, 42}
+	oidSurname      = asn1.ObjectIdentifier{2, 5, 4, 4}
+)
+
+// IsIdentity reports whether id is the ID of a demo identity.
+func IsIdentity(id string) bool {
+	return strings.HasPrefix(id, IDPrefix)
+}
+
+// NewIdentity issues a certificate for a made-up citizen, "DEMO PARTICIPANT"
+// and a number, with a random DNI that has a valid control letter. It is
+// shaped like an FNMT citizen certificate so the signer data fills in as
+// usual. The key and the CA above it only live in memory.
+func NewIdentity() (pkcs12store.Identity, error) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return pkcs12store.Identity{}, err
+	}
+	now := time.Now()
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: Issuer, Organization: []string{"VocSign demo"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(validity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		return pkcs12store.Identity{}, fmt.Errorf("failed to create demo CA: %w", err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return pkcs12store.Identity{}, err
+	}
+
+	subject, name := madeUpSubject("DEMO", "PARTICIPANT")
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return pkcs12store.Identity{}, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      subject,
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(validity),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageEmailProtection},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return pkcs12store.Identity{}, fmt.Errorf("failed to create demo certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return pkcs12store.Identity{}, err
+	}
+	fp := pkcs12store.Fingerprint(cert)
+	return pkcs12store.Identity{
+		ID:             IDPrefix + hex.EncodeToString(fp[:8]),
+		FriendlyName:   "Demo certificate (" + name + ")",
+		Cert:           cert,
+		Chain:          []*x509.Certificate{ca},
+		Fingerprint256: fp,
+		Signer:         key,
+	}, nil
+}
+
+// madeUpSubject returns the subject of an FNMT-like citizen certificate for
+// a made-up person named given and surname with a number, with a random DNI
+// that has a valid control letter, and the person's full name.
+func madeUpSubject(given, surname string) (pkix.Name, string) {
+	n := mrand.IntN(100_000_000)
+	dni := fmt.Sprintf("%08d%c", n, certs.DNILetter(n))
+	surnames := fmt.Sprintf("%s %04d", surname, mrand.IntN(10_000))
+	return pkix.Name{
+		Country: []string{"ES"},
+		ExtraNames: []pkix.AttributeTypeAndValue{
+			{Type: oidSerialNumber, Value: "IDCES-" + dni},
+			{Type: oidGivenName, Value: given},
+			{Type: oidSurname, Value: surnames},
+			{Type: oidCommonName, Value: surnames + " " + given + " - " + dni},
+		},
+	}, given + " " + surnames
+}
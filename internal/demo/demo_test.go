@@ -0,0 +1,78 @@
+package demo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/certs"
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/pkcs12store"
+)
+
+func TestNewIdentity(t *testing.T) {
+	id, err := NewIdentity()
+	if err != nil {
+		t.Fatalf("NewIdentity: %v", err)
+	}
+	if !IsIdentity(id.ID) || id.Signer == nil {
+		t.Fatalf("identity = %+v", id)
+	}
+	if err := certs.ValidateForSigning(id.Cert, id.Chain); err != nil {
+		t.Fatalf("ValidateForSigning: %v", err)
+	}
+	if err := id.Cert.CheckSignatureFrom(id.Chain[0]); err != nil {
+		t.Fatalf("certificate not issued by the demo CA: %v", err)
+	}
+	if id.Cert.Issuer.CommonName != Issuer {
+		t.Errorf("Issuer = %q", id.Cert.Issuer.CommonName)
+	}
+
+	info := certs.ExtractSpanishIdentity(id.Cert)
+	if info.Nom != "DEMO" || len(info.Cognoms) != 2 || info.Cognoms[0] != "PARTICIPANT" || info.IsRepresentative {
+		t.Errorf("extracted identity = %+v", info)
+	}
+	if dni, idType := certs.ParsePersonalID(info.DNI); dni != info.DNI || idType != "DNI" {
+		t.Errorf("DNI %q is not valid: got %q, %q", info.DNI, dni, idType)
+	}
+
+	// Every identity is new.
+	other, err := NewIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.ID == id.ID {
+		t.Error("two demo identities share an ID")
+	}
+}
+
+func TestNewTestCertificate(t *testing.T) {
+	tc, err := NewTestCertificate()
+	if err != nil {
+		t.Fatalf("NewTestCertificate: %v", err)
+	}
+	signer, cert, chain, err := pkcs12store.ParsePKCS12(bytes.NewReader(tc.PKCS12), tc.Password)
+	if err != nil {
+		t.Fatalf("ParsePKCS12: %v", err)
+	}
+	if err := pkcs12store.VerifyKeyPair(signer, cert); err != nil {
+		t.Fatal(err)
+	}
+	if !IsTest(cert) {
+		t.Fatal("IsTest = false for a test certificate")
+	}
+	if err := certs.ValidateForSigning(cert, chain); err != nil {
+		t.Fatalf("ValidateForSigning: %v", err)
+	}
+	info := certs.ExtractSpanishIdentity(cert)
+	if got := info.Nom + " " + strings.Join(info.Cognoms, " "); got != tc.Name || info.DNI == "" || info.IsRepresentative {
+		t.Errorf("extracted identity = %+v, want %s", info, tc.Name)
+	}
+
+	demoID, err := NewIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if IsTest(demoID.Cert) || IsTest(nil) {
+		t.Error("IsTest = true for a certificate that is not a test one")
+	}
+}
@@ -0,0 +1,71 @@
+package demo
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"fmt"
+	"math/big"
+	"slices"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// TestMarker is the organizational unit of test certificates. Requests
+// that are not demo campaigns refuse certificates that carry it.
+const TestMarker = "VOCSIGN TEST CERTIFICATE - NOT VALID FOR SIGNING"
+
+// testValidity is how long a test certificate can be explored with.
+const testValidity = 90 * 24 * time.Hour
+
+// TestCertificate is a self-signed test certificate ready to import into
+// the wallet.
+type TestCertificate struct {
+	Name string // the made-up holder, e.g. "TEST USER 0421"
+	// PKCS12 holds the key and certificate, protected with Password.
+	PKCS12   []byte
+	Password string
+}
+
+// NewTestCertificate makes a self-signed certificate for a made-up citizen,
+// "TEST USER" and a number, so the app can be explored before an official
+// certificate is issued. Its subject carries TestMarker.
+func NewTestCertificate() (*TestCertificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	subject, name := madeUpSubject("TEST", "USER")
+	subject.OrganizationalUnit = []string{TestMarker}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(now.UnixNano()),
+		Subject:      subject,
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(testValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageEmailProtection},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create test certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	password := rand.Text()
+	p12, err := pkcs12.Modern2023.Encode(key, cert, nil, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode test certificate: %w", err)
+	}
+	return &TestCertificate{Name: name, PKCS12: p12, Password: password}, nil
+}
+
+// IsTest reports whether cert is a test certificate made by
+// NewTestCertificate.
+func IsTest(cert *x509.Certificate) bool {
+	return cert != nil && slices.Contains(cert.Subject.OrganizationalUnit, TestMarker)
+}
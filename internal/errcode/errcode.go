@@ -0,0 +1,175 @@
+// Package errcode attaches stable codes to the errors returned by the
+// network, request verification and signing packages. The UI maps codes to
+// actionable messages and the audit log records them, so neither depends on
+// the wording of an error string.
+package errcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// Code identifies a class of failure. Codes are part of the audit log
+// format and of translation keys: never rename one.
+type Code string
+
+const (
+	Unknown Code = ""
+
+	// Network
+	FetchTimeout     Code = "ERR_FETCH_TIMEOUT"
+	FetchFailed      Code = "ERR_FETCH_FAILED"
+	HTTPStatus       Code = "ERR_HTTP_STATUS"
+	ResponseTooLarge Code = "ERR_RESPONSE_TOO_LARGE"
+	RedirectBlocked  Code = "ERR_REDIRECT_BLOCKED"
+	InvalidResponse  Code = "ERR_INVALID_RESPONSE"
+	InvalidIPFSURI   Code = "ERR_IPFS_INVALID_URI"
+	// IPFSContentMismatch: every gateway returned content that does not
+	// match the CID.
+	IPFSContentMismatch Code = "ERR_IPFS_CONTENT_MISMATCH"
+	// UnsupportedVersion: the request uses a schema version newer than
+	// this client, or the server has none this client accepts.
+	UnsupportedVersion Code = "ERR_UNSUPPORTED_VERSION"
+
+	// Request authentication
+	MissingSignature    Code = "ERR_JWS_MISSING"
+	InvalidJWS          Code = "ERR_JWS_INVALID"
+	UnsupportedJWSAlg   Code = "ERR_JWS_UNSUPPORTED_ALG"
+	JWSKidNotFound      Code = "ERR_JWS_KID_NOT_FOUND"
+	JWSKidUndeclared    Code = "ERR_JWS_KID_UNDECLARED"
+	JWSPayloadMismatch  Code = "ERR_JWS_PAYLOAD_MISMATCH"
+	JWSSignatureInvalid Code = "ERR_JWS_SIGNATURE_INVALID"
+	JWKSFetch           Code = "ERR_JWKS_FETCH"
+	InvalidJWK          Code = "ERR_JWK_INVALID"
+
+	// Documents referenced by a request
+	DocumentMissing         Code = "ERR_DOCUMENT_MISSING"
+	DocumentHashMismatch    Code = "ERR_DOCUMENT_HASH_MISMATCH"
+	PolicyMissingURI        Code = "ERR_POLICY_MISSING_URI"
+	PolicyHashMismatch      Code = "ERR_POLICY_HASH_MISMATCH"
+	TranslationHashMismatch Code = "ERR_TRANSLATION_HASH_MISMATCH"
+	TransparencyLog         Code = "ERR_TRANSPARENCY_LOG"
+	TransparencyLogChanged  Code = "ERR_TRANSPARENCY_LOG_CHANGED"
+	DuplicateCheck          Code = "ERR_DUPLICATE_CHECK"
+	// ReceiptLookup: the collector could not tell whether an interrupted
+	// submission was accepted.
+	ReceiptLookup Code = "ERR_RECEIPT_LOOKUP"
+	// OrganizerNotAllowed: the administrator's policy does not allow
+	// requests of this organizer.
+	OrganizerNotAllowed Code = "ERR_ORGANIZER_NOT_ALLOWED"
+
+	// Signing and submission
+	SignatureBuild Code = "ERR_SIGNATURE_BUILD"
+	InvalidPolicy  Code = "ERR_POLICY_INVALID"
+	InvalidCMS     Code = "ERR_CMS_INVALID"
+	TSAFailed      Code = "ERR_TSA_FAILED"
+	SubmitRejected Code = "ERR_SUBMIT_REJECTED"
+	SignTimeout    Code = "ERR_SIGN_TIMEOUT"
+	// ProposalChanged: the collector amended the proposal text after the
+	// request was fetched and rejects signatures of the old version.
+	ProposalChanged Code = "ERR_PROPOSAL_CHANGED"
+)
+
+// Error is an error with a code. Its message is that of the wrapped error,
+// so adding a code does not change what is logged.
+type Error struct {
+	Code Code
+	Err  error
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Errorf is fmt.Errorf with a code attached.
+func Errorf(code Code, format string, args ...any) error {
+	return &Error{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// Wrap attaches code to err. It returns nil if err is nil.
+func Wrap(code Code, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, Err: err}
+}
+
+// Network classifies a failed HTTP round trip as a timeout or a generic
+// network failure.
+func Network(err error) error {
+	if err == nil {
+		return nil
+	}
+	var ne net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &ne) && ne.Timeout()) {
+		return Wrap(FetchTimeout, err)
+	}
+	return Wrap(FetchFailed, err)
+}
+
+// Of returns the most specific code in err's chain, i.e. the innermost one:
+// a JWKS fetch that timed out is reported as FetchTimeout, not JWKSFetch.
+func Of(err error) Code {
+	code := Unknown
+	for err != nil {
+		if e, ok := err.(*Error); ok {
+			code = e.Code
+		}
+		err = errors.Unwrap(err)
+	}
+	return code
+}
+
+// messages are the default English texts for each code. They tell the user
+// what happened and what to do about it; the error itself has the details.
+var messages = map[Code]string{
+	FetchTimeout:            "The server took too long to answer. Check your connection and try again.",
+	FetchFailed:             "The server could not be reached. Check your connection and the signing URL.",
+	HTTPStatus:              "The server returned an error. Try again later or contact the organizer.",
+	ResponseTooLarge:        "The server sent more data than allowed. Contact the organizer.",
+	RedirectBlocked:         "The server redirected to an insecure address, so the request was stopped.",
+	InvalidResponse:         "The server's answer is not a valid signing request. Check the signing URL.",
+	InvalidIPFSURI:          "The IPFS address is not valid. Check the signing URL.",
+	IPFSContentMismatch:     "The IPFS gateways returned content that differs from what the address names. Try other gateways in Settings.",
+	UnsupportedVersion:      "This request needs a newer version of VocSign. Update the app and open the request again.",
+	MissingSignature:        "This request is not signed by its organizer and cannot be trusted.",
+	InvalidJWS:              "The organizer signature of this request is malformed.",
+	UnsupportedJWSAlg:       "The organizer signed this request with an unsupported algorithm.",
+	JWSKidNotFound:          "The organizer's signing key was not found. The request may be forged or the organizer rotated its keys.",
+	JWSKidUndeclared:        "The request was signed with a key it does not declare and cannot be trusted.",
+	JWSPayloadMismatch:      "The request was changed after the organizer signed it. Do not sign it.",
+	JWSSignatureInvalid:     "The organizer signature does not match this request. Do not sign it.",
+	JWKSFetch:               "The organizer's signing keys could not be downloaded. Try again later.",
+	InvalidJWK:              "The organizer published an invalid signing key. Contact the organizer.",
+	DocumentMissing:         "The request does not reference the proposal text. Contact the organizer.",
+	DocumentHashMismatch:    "The proposal text differs from the one the organizer published. Do not sign it.",
+	PolicyMissingURI:        "The request does not say where its signature policy is published.",
+	PolicyHashMismatch:      "The signature policy document differs from the one the request refers to. Do not sign it.",
+	TranslationHashMismatch: "The campaign labels were changed after publication and were not loaded.",
+	TransparencyLog:         "The request is not listed in the organizer's transparency log. Do not sign it.",
+	TransparencyLogChanged:  "The organizer's transparency log was replaced since you trusted this organizer. Do not sign it.",
+	DuplicateCheck:          "Could not check whether you already signed this proposal.",
+	ReceiptLookup:           "Could not check whether the collector received your earlier signature.",
+	OrganizerNotAllowed:     "Your administrator does not allow signing requests of this organizer on this computer.",
+	SignatureBuild:          "The signature could not be built. Check your certificate and try again.",
+	InvalidPolicy:           "The request's signature policy is malformed. Contact the organizer.",
+	InvalidCMS:              "The signature file is not a valid CAdES signature.",
+	TSAFailed:               "The timestamp server did not answer. The signature was made without a trusted timestamp.",
+	SubmitRejected:          "The server rejected the signature. Your signature was not recorded.",
+	SignTimeout:             "The card or token did not finish the signature in time. Check the reader and try again.",
+	ProposalChanged:         "The proposal text changed — please review and sign again.",
+}
+
+// Message returns the user-facing text for err's code, or err's own message
+// if it has no code.
+func Message(err error) string {
+	if err == nil {
+		return ""
+	}
+	if msg, ok := messages[Of(err)]; ok {
+		return msg
+	}
+	return err.Error()
+}
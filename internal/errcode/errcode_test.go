@@ -0,0 +1,66 @@
+package errcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type timeoutErr struct{}
+
+func (timeoutErr) Error() string   { return "i/o timeout" }
+func (timeoutErr) Timeout() bool   { return true }
+func (timeoutErr) Temporary() bool { return true }
+
+func TestOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Code
+	}{
+		{"nil", nil, Unknown},
+		{"plain", errors.New("boom"), Unknown},
+		{"coded", Errorf(InvalidJWS, "invalid JWS format"), InvalidJWS},
+		{"wrapped", fmt.Errorf("fetch failed: %w", Errorf(HTTPStatus, "status %d", 500)), HTTPStatus},
+		{"innermost wins", Errorf(JWKSFetch, "failed to fetch JWKS: %w", Network(context.DeadlineExceeded)), FetchTimeout},
+		{"net timeout", Network(fmt.Errorf("dial: %w", timeoutErr{})), FetchTimeout},
+		{"network", Network(errors.New("connection refused")), FetchFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Of(tt.err); got != tt.want {
+				t.Errorf("Of = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorKeepsMessageAndChain(t *testing.T) {
+	sentinel := errors.New("sentinel")
+	err := Errorf(PolicyHashMismatch, "%w: expected a but got b", sentinel)
+	if err.Error() != "sentinel: expected a but got b" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+	if !errors.Is(err, sentinel) {
+		t.Error("errors.Is does not see the wrapped error")
+	}
+	if Wrap(FetchFailed, nil) != nil || Network(nil) != nil {
+		t.Error("wrapping nil must return nil")
+	}
+}
+
+func TestMessage(t *testing.T) {
+	err := Errorf(FetchTimeout, "timeout")
+	if got := Message(err); got != messages[FetchTimeout] {
+		t.Errorf("Message = %q", got)
+	}
+	if got := Message(errors.New("plain")); got != "plain" {
+		t.Errorf("Message without code = %q", got)
+	}
+	for code := range messages {
+		if code == Unknown || len(code) < 5 || code[:4] != "ERR_" {
+			t.Errorf("code %q does not follow the ERR_ convention", code)
+		}
+	}
+}
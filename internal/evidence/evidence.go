@@ -0,0 +1,161 @@
+// Package evidence bundles what proves a submitted signature into one ZIP
+// file the signer can keep or send: the request, the signed XML, the CAdES
+// signature with its certificates and timestamp, the collector's receipt
+// and countersignature, and a printable receipt.
+package evidence
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+	"github.com/vocdoni/gofirma/vocsign/internal/paper"
+)
+
+// Package is a submitted signature and the collector's answer to it.
+type Package struct {
+	Request *model.SignRequest
+	// RawRequest is the request byte for byte as fetched, if known.
+	RawRequest []byte
+	Response   *model.SignResponse
+	Submit     *model.SubmitReceipt
+	SignerName string
+}
+
+// FileName is the name the package is saved or shared as.
+func (p Package) FileName() string {
+	return "vocsign-evidence-" + safeName(p.Request.RequestID) + ".zip"
+}
+
+// ReceiptFileName is the name of the receipt PDF inside and outside the
+// package.
+func (p Package) ReceiptFileName() string {
+	return "vocsign-receipt-" + safeName(p.Request.RequestID) + ".pdf"
+}
+
+func safeName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '-'
+	}, s)
+}
+
+const readme = `VocSign signature evidence
+
+request.json          the sign request as received from the organizer
+request-original.json the same request byte for byte as fetched, if kept;
+                      its SHA-256 is the audit entry's rawRequestSha256
+response.json         the signature as submitted to the collector
+signer.xml            the signed ILP signer document
+signature.p7s         detached CAdES signature over signer.xml
+certificates.pem      the signing certificate and its chain
+timestamp.tsr         RFC 3161 timestamp over the signature, if any
+countersignature.p7s  the signature countersigned by the collector, if any
+receipt.json          the collector's receipt
+receipt.pdf           printable receipt
+SHA256SUMS            SHA-256 of every file above
+
+To check the signature:
+
+  openssl cms -verify -binary -inform DER -in signature.p7s \
+    -content signer.xml -certfile certificates.pem -noverify -purpose any
+`
+
+// Write writes the package as a ZIP archive.
+func Write(w io.Writer, p Package) error {
+	if p.Request == nil || p.Response == nil || p.Submit == nil {
+		return errors.New("incomplete evidence package")
+	}
+	type file struct {
+		name string
+		data []byte
+	}
+	var files []file
+	add := func(name string, data []byte) {
+		if len(data) > 0 {
+			files = append(files, file{name, data})
+		}
+	}
+	decode := func(name, b64 string) error {
+		if b64 == "" {
+			return nil
+		}
+		data, err := base64.StdEncoding.DecodeString(b64)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+		add(name, data)
+		return nil
+	}
+
+	req, err := json.MarshalIndent(p.Request, "", "  ")
+	if err != nil {
+		return err
+	}
+	add("request.json", req)
+	add("request-original.json", p.RawRequest)
+	// The signer's contact details travel outside the signature and are not
+	// evidence of it.
+	resp := *p.Response
+	resp.Extensions = nil
+	respJSON, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		return err
+	}
+	add("response.json", respJSON)
+	if err := decode("signer.xml", resp.SignerXMLBase64); err != nil {
+		return err
+	}
+	if err := decode("signature.p7s", resp.SignatureDerBase64); err != nil {
+		return err
+	}
+	add("certificates.pem", []byte(resp.SignerCertPEM+strings.Join(resp.ChainPEM, "")))
+	if err := decode("timestamp.tsr", resp.TimestampTokenBase64); err != nil {
+		return err
+	}
+	if err := decode("countersignature.p7s", p.Submit.CounterSignatureDerBase64); err != nil {
+		return err
+	}
+	receipt, err := json.MarshalIndent(p.Submit, "", "  ")
+	if err != nil {
+		return err
+	}
+	add("receipt.json", receipt)
+	var pdf bytes.Buffer
+	if err := paper.WriteReceiptPDF(&pdf, paper.Receipt{Request: p.Request, Response: p.Response, Submit: p.Submit, SignerName: p.SignerName}); err != nil {
+		return err
+	}
+	add("receipt.pdf", pdf.Bytes())
+
+	var sums strings.Builder
+	for _, f := range files {
+		sum := sha256.Sum256(f.data)
+		fmt.Fprintf(&sums, "%s  %s\n", hex.EncodeToString(sum[:]), f.name)
+	}
+	files = append([]file{{"README.txt", []byte(readme)}}, files...)
+	files = append(files, file{"SHA256SUMS", []byte(sums.String())})
+
+	zw := zip.NewWriter(w)
+	modified := time.Now()
+	for _, f := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(f.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
@@ -0,0 +1,124 @@
+package evidence
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+func testPackage() Package {
+	return Package{
+		Request: &model.SignRequest{
+			Version:   "1.0",
+			RequestID: "ILP/2026 01",
+			Proposal:  model.Proposal{Title: "Llei de prova", Promoter: "Comissió"},
+		},
+		Response: &model.SignResponse{
+			RequestID:              "ILP/2026 01",
+			SignedAt:               "2026-10-17T10:00:00Z",
+			SignatureFormat:        "CAdES-detached",
+			PayloadCanonicalSHA256: "abc",
+			SignatureDerBase64:     base64.StdEncoding.EncodeToString([]byte("signature")),
+			SignerXMLBase64:        base64.StdEncoding.EncodeToString([]byte("<Signant/>")),
+			SignerCertPEM:          "-----BEGIN CERTIFICATE-----\n-----END CERTIFICATE-----\n",
+			Extensions:             &model.ResponseExtensions{Contact: &model.SignerContact{}},
+		},
+		Submit: &model.SubmitReceipt{ReceiptID: "RCPT-1", Status: "accepted"},
+	}
+}
+
+func readZip(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(rc)
+		_ = rc.Close()
+		files[f.Name] = string(b)
+	}
+	return files
+}
+
+func TestWrite(t *testing.T) {
+	p := testPackage()
+	var buf bytes.Buffer
+	if err := Write(&buf, p); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	files := readZip(t, buf.Bytes())
+	for _, name := range []string{"README.txt", "request.json", "response.json", "signer.xml", "signature.p7s", "certificates.pem", "receipt.json", "receipt.pdf", "SHA256SUMS"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("missing %s", name)
+		}
+	}
+	for _, name := range []string{"timestamp.tsr", "countersignature.p7s", "request-original.json"} {
+		if _, ok := files[name]; ok {
+			t.Errorf("empty %s included", name)
+		}
+	}
+	if files["signature.p7s"] != "signature" || files["signer.xml"] != "<Signant/>" {
+		t.Fatal("signature or XML not decoded")
+	}
+	if strings.Contains(files["response.json"], "extensions") {
+		t.Fatal("signer contact included")
+	}
+	sum := sha256.Sum256([]byte("signature"))
+	if !strings.Contains(files["SHA256SUMS"], hex.EncodeToString(sum[:])+"  signature.p7s\n") {
+		t.Fatalf("SHA256SUMS = %q", files["SHA256SUMS"])
+	}
+	if p.Response.Extensions == nil {
+		t.Fatal("caller's response modified")
+	}
+}
+
+func TestWriteRawRequest(t *testing.T) {
+	p := testPackage()
+	p.RawRequest = []byte("{\n  \"requestId\": \"ILP/2026 01\"\n}\n")
+	var buf bytes.Buffer
+	if err := Write(&buf, p); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	files := readZip(t, buf.Bytes())
+	if files["request-original.json"] != string(p.RawRequest) {
+		t.Fatalf("request-original.json = %q", files["request-original.json"])
+	}
+	sum := sha256.Sum256(p.RawRequest)
+	if !strings.Contains(files["SHA256SUMS"], hex.EncodeToString(sum[:])+"  request-original.json\n") {
+		t.Fatalf("SHA256SUMS = %q", files["SHA256SUMS"])
+	}
+}
+
+func TestWriteInvalid(t *testing.T) {
+	p := testPackage()
+	p.Response.SignatureDerBase64 = "not base64!"
+	if err := Write(io.Discard, p); err == nil {
+		t.Fatal("invalid signature written")
+	}
+	if err := Write(io.Discard, Package{}); err == nil {
+		t.Fatal("empty package written")
+	}
+}
+
+func TestFileNames(t *testing.T) {
+	p := testPackage()
+	if got := p.FileName(); got != "vocsign-evidence-ILP-2026-01.zip" {
+		t.Fatalf("FileName = %q", got)
+	}
+	if got := p.ReceiptFileName(); got != "vocsign-receipt-ILP-2026-01.pdf" {
+		t.Fatalf("ReceiptFileName = %q", got)
+	}
+}
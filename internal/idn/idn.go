@@ -0,0 +1,186 @@
+// Package idn shows internationalized host names so that they cannot pass
+// for other hosts. A name such as "vοcdoni.io", with a Greek omicron,
+// reads as vocdoni.io but is a different domain; Inspect decodes punycode
+// labels, finds labels that mix scripts and names the ASCII host a name
+// imitates with lookalike characters.
+package idn
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Host is a host name as shown to the user.
+type Host struct {
+	// ASCII is the name as sent on the wire, with xn-- labels.
+	ASCII string
+	// Unicode is the name with its xn-- labels decoded.
+	Unicode string
+	// LooksLike is the ASCII name this one imitates, if it differs from
+	// it only in characters confusable with Latin letters.
+	LooksLike string
+	// MixedScripts is set if a label mixes letters of different scripts,
+	// e.g. Latin and Cyrillic.
+	MixedScripts bool
+	// Invalid is set if an xn-- label is not valid punycode.
+	Invalid bool
+}
+
+// Inspect normalizes host, in ASCII or Unicode form and without a port,
+// and checks it for homographs.
+func Inspect(host string) Host {
+	labels := strings.Split(strings.TrimSuffix(host, "."), ".")
+	ascii := make([]string, len(labels))
+	uni := make([]string, len(labels))
+	var h Host
+	for i, label := range labels {
+		label = strings.ToLower(norm.NFC.String(label))
+		if rest, ok := strings.CutPrefix(label, acePrefix); ok {
+			ascii[i] = label
+			decoded, err := decodePunycode(rest)
+			if err != nil {
+				h.Invalid = true
+				decoded = label
+			}
+			uni[i] = strings.ToLower(norm.NFC.String(decoded))
+		} else {
+			uni[i] = label
+			ascii[i] = label
+			if !isASCII(label) {
+				ascii[i] = acePrefix + encodePunycode(label)
+			}
+		}
+		if mixedScripts(uni[i]) {
+			h.MixedScripts = true
+		}
+	}
+	h.ASCII = strings.Join(ascii, ".")
+	h.Unicode = strings.Join(uni, ".")
+	if !isASCII(h.Unicode) {
+		if s := skeleton(h.Unicode); isASCII(s) {
+			h.LooksLike = s
+		}
+	}
+	return h
+}
+
+// Suspicious reports whether the name may imitate another one.
+func (h Host) Suspicious() bool {
+	return h.LooksLike != "" || h.MixedScripts || h.Invalid
+}
+
+// Display is the name to show: the Unicode form followed by the ASCII one
+// for an internationalized name, and only the ASCII form for a suspicious
+// one, as browsers do.
+func (h Host) Display() string {
+	switch {
+	case h.Unicode == h.ASCII || h.Suspicious():
+		return h.ASCII
+	default:
+		return h.Unicode + " (" + h.ASCII + ")"
+	}
+}
+
+// Warning explains why a suspicious name is shown in ASCII, or is empty.
+func (h Host) Warning() string {
+	switch {
+	case h.Invalid:
+		return h.ASCII + " is not a valid internationalized domain name"
+	case h.LooksLike != "":
+		return h.ASCII + " is shown as " + h.Unicode + ", which imitates " + h.LooksLike + " with lookalike characters"
+	case h.MixedScripts:
+		return h.ASCII + " is shown as " + h.Unicode + ", which mixes letters of different alphabets"
+	}
+	return ""
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// MixedScriptWords returns the words of text that mix letters of different
+// scripts, such as "Vοcdoni" with a Greek omicron, in order and without
+// repeats. Words written wholly in another script are not reported.
+func MixedScriptWords(text string) []string {
+	var words []string
+	seen := map[string]bool{}
+	for _, w := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r) }) {
+		w = norm.NFC.String(w)
+		if !seen[w] && mixedScripts(w) {
+			seen[w] = true
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+// cjk groups the scripts written together in Chinese, Japanese and Korean.
+var cjk = []*unicode.RangeTable{unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul, unicode.Bopomofo}
+
+// mixedScripts reports whether label has letters of more than one script.
+// Latin may be combined with the CJK scripts, which may be combined with
+// each other.
+func mixedScripts(label string) bool {
+	scripts := map[string]bool{}
+	hasCJK := false
+	for _, r := range label {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		if r < utf8.RuneSelf {
+			scripts["Latin"] = true
+			continue
+		}
+		if unicode.In(r, cjk...) {
+			hasCJK = true
+			continue
+		}
+		for name, table := range unicode.Scripts {
+			if unicode.Is(table, r) {
+				scripts[name] = true
+				break
+			}
+		}
+	}
+	if len(scripts) > 1 {
+		return true
+	}
+	return hasCJK && len(scripts) == 1 && !scripts["Latin"]
+}
+
+// confusables maps lowercase letters of other scripts to the Latin letters
+// they are mistaken for. Compatibility forms, such as fullwidth letters,
+// are folded by NFKC before the lookup.
+var confusables = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'в': 'b', 'е': 'e', 'һ': 'h', 'і': 'i', 'ј': 'j', 'к': 'k', 'ӏ': 'l',
+	'о': 'o', 'р': 'p', 'ԛ': 'q', 'ѕ': 's', 'у': 'y', 'х': 'x', 'ԁ': 'd', 'ԝ': 'w', 'с': 'c',
+	// Greek
+	'α': 'a', 'ε': 'e', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p', 'τ': 't',
+	'υ': 'u', 'χ': 'x', 'ϲ': 'c', 'ϳ': 'j',
+	// Armenian
+	'հ': 'h', 'ո': 'n', 'ս': 'u', 'օ': 'o', 'ց': 'g',
+	// Latin letters outside ASCII that read as ASCII ones
+	'ı': 'i', 'ɩ': 'i', 'ȷ': 'j', 'ɑ': 'a', 'ɡ': 'g', 'ǀ': 'l', 'ɒ': 'a', 'ɪ': 'i',
+}
+
+// skeleton is name with every character replaced by the Latin letter it
+// is confusable with, if any.
+func skeleton(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(norm.NFKC.String(name)) {
+		if c, ok := confusables[r]; ok {
+			r = c
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
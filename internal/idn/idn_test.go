@@ -0,0 +1,67 @@
+package idn
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	tests := []struct {
+		host       string
+		ascii      string
+		unicode    string
+		looksLike  string
+		mixed      bool
+		display    string
+		suspicious bool
+	}{
+		{host: "vocdoni.io", ascii: "vocdoni.io", unicode: "vocdoni.io", display: "vocdoni.io"},
+		{host: "VocDoni.IO.", ascii: "vocdoni.io", unicode: "vocdoni.io", display: "vocdoni.io"},
+		{host: "bücher.de", ascii: "xn--bcher-kva.de", unicode: "bücher.de", display: "bücher.de (xn--bcher-kva.de)"},
+		{host: "xn--bcher-kva.de", ascii: "xn--bcher-kva.de", unicode: "bücher.de", display: "bücher.de (xn--bcher-kva.de)"},
+		// Greek omicron among Latin letters.
+		{host: "vοcdoni.io", ascii: "xn--vcdoni-i0e.io", unicode: "vοcdoni.io", looksLike: "vocdoni.io", mixed: true, display: "xn--vcdoni-i0e.io", suspicious: true},
+		{host: "xn--vcdoni-i0e.io", ascii: "xn--vcdoni-i0e.io", unicode: "vοcdoni.io", looksLike: "vocdoni.io", mixed: true, display: "xn--vcdoni-i0e.io", suspicious: true},
+		// All Cyrillic, so no mixing, but it reads as apple.com.
+		{host: "xn--le-6kc8da.com", ascii: "xn--le-6kc8da.com", unicode: "аррle.com", looksLike: "apple.com", mixed: true, display: "xn--le-6kc8da.com", suspicious: true},
+		{host: "аррӏе.com", ascii: "xn--80ak6aa92e.com", unicode: "аррӏе.com", looksLike: "apple.com", display: "xn--80ak6aa92e.com", suspicious: true},
+		// Fullwidth letters fold to ASCII.
+		{host: "ｖｏｃｄｏｎｉ.io", ascii: "xn--oi7ccptcc9b.io", unicode: "ｖｏｃｄｏｎｉ.io", looksLike: "vocdoni.io", display: "xn--oi7ccptcc9b.io", suspicious: true},
+		{host: "例え.jp", ascii: "xn--r8jz45g.jp", unicode: "例え.jp", display: "例え.jp (xn--r8jz45g.jp)"},
+		{host: "ελλάδα.gr", ascii: "xn--hxakic4aa.gr", unicode: "ελλάδα.gr", display: "ελλάδα.gr (xn--hxakic4aa.gr)"},
+	}
+	for _, tt := range tests {
+		h := Inspect(tt.host)
+		if h.ASCII != tt.ascii || h.Unicode != tt.unicode || h.LooksLike != tt.looksLike || h.MixedScripts != tt.mixed {
+			t.Errorf("Inspect(%q) = %+v", tt.host, h)
+		}
+		if got := h.Display(); got != tt.display {
+			t.Errorf("Inspect(%q).Display() = %q, want %q", tt.host, got, tt.display)
+		}
+		if h.Suspicious() != tt.suspicious || (h.Warning() != "") != tt.suspicious {
+			t.Errorf("Inspect(%q): suspicious %v, warning %q", tt.host, h.Suspicious(), h.Warning())
+		}
+	}
+}
+
+func TestInspectInvalidPunycode(t *testing.T) {
+	h := Inspect("xn--abc-!.io")
+	if !h.Invalid || !strings.Contains(h.Warning(), "not a valid") {
+		t.Fatalf("Inspect of invalid punycode = %+v, warning %q", h, h.Warning())
+	}
+}
+
+func TestWarningNamesImitatedHost(t *testing.T) {
+	w := Inspect("vοcdoni.io").Warning()
+	if !strings.Contains(w, "imitates vocdoni.io") || !strings.Contains(w, "xn--vcdoni-i0e.io") {
+		t.Fatalf("Warning() = %q", w)
+	}
+}
+
+func TestMixedScriptWords(t *testing.T) {
+	got := MixedScriptWords("Iniciativa de Vοcdoni i Vοcdoni, per a l'educació pública. Ελλάδα 東京tokyo")
+	if !slices.Equal(got, []string{"Vοcdoni"}) {
+		t.Fatalf("MixedScriptWords = %q", got)
+	}
+}
@@ -0,0 +1,152 @@
+package idn
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// Punycode (RFC 3492) parameters for IDNA.
+const (
+	base        = 36
+	tMin        = 1
+	tMax        = 26
+	skew        = 38
+	damp        = 700
+	initialBias = 72
+	initialN    = 128
+	acePrefix   = "xn--"
+)
+
+var errPunycode = errors.New("invalid punycode")
+
+// decodePunycode decodes the part of an xn-- label after the prefix.
+func decodePunycode(s string) (string, error) {
+	var out []rune
+	if pos := strings.LastIndexByte(s, '-'); pos >= 0 {
+		for i := 0; i < pos; i++ {
+			if s[i] >= utf8.RuneSelf {
+				return "", errPunycode
+			}
+			out = append(out, rune(s[i]))
+		}
+		s = s[pos+1:]
+	}
+	n, bias, i := initialN, initialBias, 0
+	for len(s) > 0 {
+		oldi, w := i, 1
+		for k := base; ; k += base {
+			if len(s) == 0 {
+				return "", errPunycode
+			}
+			d := digitValue(s[0])
+			s = s[1:]
+			if d < 0 || d > (utf8.MaxRune-i)/w {
+				return "", errPunycode
+			}
+			i += d * w
+			t := min(max(k-bias, tMin), tMax)
+			if d < t {
+				break
+			}
+			w *= base - t
+			if w > utf8.MaxRune {
+				return "", errPunycode
+			}
+		}
+		bias = adapt(i-oldi, len(out)+1, oldi == 0)
+		n += i / (len(out) + 1)
+		i %= len(out) + 1
+		if n > utf8.MaxRune || !utf8.ValidRune(rune(n)) {
+			return "", errPunycode
+		}
+		out = append(out[:i], append([]rune{rune(n)}, out[i:]...)...)
+		i++
+	}
+	return string(out), nil
+}
+
+// encodePunycode returns the punycode of a label, without the xn-- prefix.
+func encodePunycode(label string) string {
+	runes := []rune(label)
+	var b strings.Builder
+	for _, r := range runes {
+		if r < utf8.RuneSelf {
+			b.WriteRune(r)
+		}
+	}
+	basic := b.Len()
+	handled := basic
+	if basic > 0 {
+		b.WriteByte('-')
+	}
+	n, bias, delta := initialN, initialBias, 0
+	for handled < len(runes) {
+		m := int(utf8.MaxRune)
+		for _, r := range runes {
+			if int(r) >= n && int(r) < m {
+				m = int(r)
+			}
+		}
+		delta += (m - n) * (handled + 1)
+		n = m
+		for _, r := range runes {
+			if int(r) < n {
+				delta++
+			}
+			if int(r) != n {
+				continue
+			}
+			q := delta
+			for k := base; ; k += base {
+				t := min(max(k-bias, tMin), tMax)
+				if q < t {
+					break
+				}
+				b.WriteByte(digitChar(t + (q-t)%(base-t)))
+				q = (q - t) / (base - t)
+			}
+			b.WriteByte(digitChar(q))
+			bias = adapt(delta, handled+1, handled == basic)
+			delta = 0
+			handled++
+		}
+		delta++
+		n++
+	}
+	return b.String()
+}
+
+func adapt(delta, numPoints int, first bool) int {
+	if first {
+		delta /= damp
+	} else {
+		delta /= 2
+	}
+	delta += delta / numPoints
+	k := 0
+	for delta > ((base-tMin)*tMax)/2 {
+		delta /= base - tMin
+		k += base
+	}
+	return k + (base-tMin+1)*delta/(delta+skew)
+}
+
+func digitValue(c byte) int {
+	switch {
+	case 'a' <= c && c <= 'z':
+		return int(c - 'a')
+	case 'A' <= c && c <= 'Z':
+		return int(c - 'A')
+	case '0' <= c && c <= '9':
+		return int(c-'0') + 26
+	}
+	return -1
+}
+
+func digitChar(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
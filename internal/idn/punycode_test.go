@@ -0,0 +1,31 @@
+package idn
+
+import "testing"
+
+func TestPunycode(t *testing.T) {
+	tests := []struct{ unicode, ascii string }{
+		{"vοcdoni", "vcdoni-i0e"},
+		{"аррle", "le-6kc8da"},
+		{"bücher", "bcher-kva"},
+		{"münchen", "mnchen-3ya"},
+		{"mañana", "maana-pta"},
+		{"例え", "r8jz45g"},
+	}
+	for _, tt := range tests {
+		if got := encodePunycode(tt.unicode); got != tt.ascii {
+			t.Errorf("encodePunycode(%q) = %q, want %q", tt.unicode, got, tt.ascii)
+		}
+		got, err := decodePunycode(tt.ascii)
+		if err != nil || got != tt.unicode {
+			t.Errorf("decodePunycode(%q) = %q, %v; want %q", tt.ascii, got, err, tt.unicode)
+		}
+	}
+}
+
+func TestDecodePunycodeInvalid(t *testing.T) {
+	for _, s := range []string{"abc-!", "bcher-kv", "zzzz", "a9999999999", "ü-kva"} {
+		if got, err := decodePunycode(s); err == nil {
+			t.Errorf("decodePunycode(%q) = %q, want an error", s, got)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// Package ilp describes the legal frameworks of popular legislative
+// initiatives (ILP): how many signatures each jurisdiction requires, when
+// they must be delivered and who may sign. The table is embedded from
+// frameworks.json and matched against a request's proposal.jurisdiction.
+package ilp
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+//go:embed frameworks.json
+var frameworksJSON []byte
+
+// Framework is the law that governs initiatives in a jurisdiction.
+type Framework struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Law cites the law, in its own language.
+	Law string `json:"law"`
+	// Signatures is the number of valid signatures required, zero when the
+	// requirement depends on the population (see Thresholds).
+	Signatures int `json:"signatures,omitempty"`
+	// Thresholds are the shares of the population required, by size of
+	// population, smallest first.
+	Thresholds  []Threshold `json:"thresholds,omitempty"`
+	Deadline    string      `json:"deadline"`
+	Eligibility string      `json:"eligibility"`
+	// Aliases are names of the jurisdiction, matched whole. Prefixes are
+	// followed by a place name, as in "Ajuntament de Girona".
+	Aliases  []string `json:"aliases"`
+	Prefixes []string `json:"prefixes,omitempty"`
+}
+
+// Threshold is the share of the population whose signatures are required
+// where the population is at most MaxPopulation, or any size if it is zero.
+type Threshold struct {
+	MaxPopulation int `json:"maxPopulation,omitempty"`
+	Percent       int `json:"percent"`
+}
+
+var frameworks = mustParse(frameworksJSON)
+
+func mustParse(data []byte) []Framework {
+	fs, err := parse(data)
+	if err != nil {
+		panic(err)
+	}
+	return fs
+}
+
+func parse(data []byte) ([]Framework, error) {
+	var fs []Framework
+	if err := json.Unmarshal(data, &fs); err != nil {
+		return nil, fmt.Errorf("failed to decode ILP frameworks: %w", err)
+	}
+	for _, f := range fs {
+		if f.ID == "" || f.Law == "" || (f.Signatures <= 0) == (len(f.Thresholds) == 0) {
+			return nil, fmt.Errorf("ILP framework %q needs a law and either signatures or thresholds", f.ID)
+		}
+		for i, t := range f.Thresholds {
+			last := i == len(f.Thresholds)-1
+			if t.Percent <= 0 || t.Percent > 100 || (t.MaxPopulation == 0) != last ||
+				(i > 0 && !last && t.MaxPopulation <= f.Thresholds[i-1].MaxPopulation) {
+				return nil, fmt.Errorf("ILP framework %q has invalid thresholds", f.ID)
+			}
+		}
+	}
+	return fs, nil
+}
+
+// Lookup returns the framework of jurisdiction, as written in a request's
+// proposal. Case, accents and surrounding spaces are ignored.
+func Lookup(jurisdiction string) (Framework, bool) {
+	name := normalize(jurisdiction)
+	if name == "" {
+		return Framework{}, false
+	}
+	for _, f := range frameworks {
+		for _, a := range f.Aliases {
+			if name == normalize(a) {
+				return f, true
+			}
+		}
+		for _, p := range f.Prefixes {
+			p = normalize(p)
+			if len(name) > len(p) && strings.HasPrefix(name, p) && (name[len(p)] == ' ' || strings.HasSuffix(p, "'")) {
+				return f, true
+			}
+		}
+	}
+	return Framework{}, false
+}
+
+// normalize folds case, accents, typographic apostrophes and runs of
+// spaces so that names typed differently compare equal.
+func normalize(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range norm.NFD.String(strings.TrimSpace(s)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case unicode.IsSpace(r):
+			space = true
+			continue
+		case r == '’':
+			r = '\''
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
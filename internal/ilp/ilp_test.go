@@ -0,0 +1,60 @@
+package ilp
+
+import "testing"
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		jurisdiction string
+		want         string // framework ID, "" for none
+	}{
+		{"Catalunya", "es-ct"},
+		{"  CATALUÑA ", "es-ct"},
+		{"Parlament  de Catalunya", "es-ct"},
+		{"España", "es"},
+		{"Espana", "es"},
+		{"Estat espanyol", "es"},
+		{"Ajuntament de Girona", "es-municipal"},
+		{"Ajuntament d’Igualada", "es-municipal"},
+		{"Ayuntamiento de Ávila", "es-municipal"},
+		{"Ajuntament de", ""},
+		{"Ajuntament dels Prats", ""},
+		{"Test Jurisdiction", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		f, ok := Lookup(tt.jurisdiction)
+		if ok != (tt.want != "") || f.ID != tt.want {
+			t.Errorf("Lookup(%q) = %q, %v, want %q", tt.jurisdiction, f.ID, ok, tt.want)
+		}
+	}
+}
+
+func TestFrameworks(t *testing.T) {
+	if len(frameworks) == 0 {
+		t.Fatal("no frameworks embedded")
+	}
+	es, _ := Lookup("Spain")
+	ct, _ := Lookup("Catalonia")
+	if es.Signatures != 500000 || ct.Signatures != 50000 {
+		t.Errorf("signatures required = %d statewide, %d in Catalonia", es.Signatures, ct.Signatures)
+	}
+	m, _ := Lookup("Municipal")
+	if len(m.Thresholds) != 3 || m.Thresholds[0].Percent != 20 || m.Thresholds[2].MaxPopulation != 0 {
+		t.Errorf("municipal thresholds = %+v", m.Thresholds)
+	}
+}
+
+func TestParseRejectsInvalidTable(t *testing.T) {
+	for _, data := range []string{
+		`{`,
+		`[{"id":"x","law":"L"}]`,
+		`[{"id":"x","law":"L","signatures":10,"thresholds":[{"percent":5}]}]`,
+		`[{"id":"x","law":"L","thresholds":[{"maxPopulation":100,"percent":5}]}]`,
+		`[{"id":"x","law":"L","thresholds":[{"maxPopulation":100,"percent":5},{"maxPopulation":50,"percent":5},{"percent":1}]}]`,
+		`[{"id":"x","law":"L","thresholds":[{"percent":150}]}]`,
+	} {
+		if _, err := parse([]byte(data)); err == nil {
+			t.Errorf("parse(%s) succeeded", data)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Package launch reads the command line the operating system starts VocSign
+// with: a vocsign:// link the user followed, a certificate file they opened,
+// or the autostart entry run at login. The installers register those
+// commands (see tools/package), so both use the names defined here.
+package launch
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	// Scheme is the URL scheme of links that open a request in VocSign.
+	Scheme = "vocsign"
+	// AutostartFlag marks the launch at login, which starts minimized.
+	AutostartFlag = "--autostart"
+	// BundleID identifies the application to macOS and is the prefix of its
+	// file type and LaunchAgent names.
+	BundleID = "org.vocdoni.vocsign"
+)
+
+// CertificateExtensions are the certificate files VocSign is registered to
+// open.
+var CertificateExtensions = []string{".p12", ".pfx"}
+
+// Args is what VocSign was asked to do at launch.
+type Args struct {
+	// RequestURL is the request a vocsign:// link points to.
+	RequestURL string
+	// CertificateFile is the absolute path of a certificate to import.
+	CertificateFile string
+	// Autostart is set when VocSign was started at login.
+	Autostart bool
+}
+
+// Parse reads the arguments after the program name.
+func Parse(args []string) (Args, error) {
+	var a Args
+	for _, arg := range args {
+		switch {
+		case arg == AutostartFlag:
+			a.Autostart = true
+		case strings.HasPrefix(arg, "-psn_"):
+			// Process serial number added by older macOS Finder launches.
+		case strings.HasPrefix(arg, "file://"):
+			// Desktop environments may pass an opened file as a URL.
+			u, err := url.Parse(arg)
+			if err != nil || !IsCertificateFile(u.Path) {
+				return Args{}, fmt.Errorf("unexpected argument %q", arg)
+			}
+			a.CertificateFile = filepath.FromSlash(u.Path)
+		case hasScheme(arg):
+			u, err := ParseLink(arg)
+			if err != nil {
+				return Args{}, err
+			}
+			a.RequestURL = u
+		case IsCertificateFile(arg):
+			path, err := filepath.Abs(arg)
+			if err != nil {
+				return Args{}, err
+			}
+			a.CertificateFile = path
+		default:
+			return Args{}, fmt.Errorf("unexpected argument %q", arg)
+		}
+	}
+	return a, nil
+}
+
+// ParseLink returns the request URL of a link such as
+// vocsign://open?url=https%3A%2F%2Fexample.org%2Frequest.json. The request
+// URL must be an http, https or ipfs URL; the fetch applies the same checks
+// as for a pasted URL.
+func ParseLink(link string) (string, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s link: %w", Scheme, err)
+	}
+	if !strings.EqualFold(u.Scheme, Scheme) || u.Host != "open" {
+		return "", fmt.Errorf("unsupported %s link %q", Scheme, link)
+	}
+	raw := u.Query().Get("url")
+	if raw == "" {
+		return "", fmt.Errorf("%s link has no request url", Scheme)
+	}
+	req, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid request url in %s link: %w", Scheme, err)
+	}
+	switch req.Scheme {
+	case "https", "http", "ipfs":
+	default:
+		return "", fmt.Errorf("request url in %s link must be https or ipfs, not %q", Scheme, req.Scheme)
+	}
+	if req.Host == "" {
+		return "", errors.New("request url in " + Scheme + " link has no host")
+	}
+	return raw, nil
+}
+
+// Link returns the vocsign:// link that opens requestURL.
+func Link(requestURL string) string {
+	return Scheme + "://open?" + url.Values{"url": {requestURL}}.Encode()
+}
+
+// IsCertificateFile reports whether path names a certificate file by its
+// extension.
+func IsCertificateFile(path string) bool {
+	return slices.Contains(CertificateExtensions, strings.ToLower(filepath.Ext(path)))
+}
+
+func hasScheme(arg string) bool {
+	return len(arg) > len(Scheme) && strings.EqualFold(arg[:len(Scheme)+1], Scheme+":")
+}
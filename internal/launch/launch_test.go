@@ -0,0 +1,65 @@
+package launch
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	link := Link("https://example.org/request.json?lang=ca")
+	a, err := Parse([]string{AutostartFlag, link, "-psn_0_12345"})
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !a.Autostart || a.RequestURL != "https://example.org/request.json?lang=ca" || a.CertificateFile != "" {
+		t.Fatalf("Parse = %+v", a)
+	}
+
+	a, err = Parse([]string{"certs/Signer.PFX"})
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !filepath.IsAbs(a.CertificateFile) || filepath.Base(a.CertificateFile) != "Signer.PFX" {
+		t.Fatalf("CertificateFile = %q", a.CertificateFile)
+	}
+
+	a, err = Parse([]string{"file:///home/ana/My%20Certificate.p12"})
+	if err != nil || a.CertificateFile != filepath.FromSlash("/home/ana/My Certificate.p12") {
+		t.Fatalf("Parse(file URL) = %+v, %v", a, err)
+	}
+
+	if a, err := Parse(nil); err != nil || a != (Args{}) {
+		t.Fatalf("Parse(nil) = %+v, %v", a, err)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := map[string]string{
+		"--nss-scan-worker": "unexpected argument",
+		"notes.txt":         "unexpected argument",
+		"vocsign://sign?url=https%3A%2F%2Fexample.org": "unsupported vocsign link",
+		"vocsign://open": "has no request url",
+		"vocsign://open?url=file%3A%2F%2F%2Fetc%2Fpasswd": "must be https or ipfs",
+		"vocsign://open?url=javascript%3Aalert(1)":        "must be https or ipfs",
+		"VOCSIGN://open?url=https%3A%2F%2F":               "has no host",
+	}
+	for arg, want := range tests {
+		if _, err := Parse([]string{arg}); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Parse(%q) = %v, want error containing %q", arg, err, want)
+		}
+	}
+}
+
+func TestLinkRoundTrip(t *testing.T) {
+	for _, u := range []string{
+		"https://example.org/r.json",
+		"https://example.org/r?a=1&b=2#frag",
+		"ipfs://bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi/request.json",
+	} {
+		got, err := ParseLink(Link(u))
+		if err != nil || got != u {
+			t.Errorf("ParseLink(Link(%q)) = %q, %v", u, got, err)
+		}
+	}
+}
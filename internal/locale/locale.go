@@ -0,0 +1,136 @@
+// Package locale formats dates, numbers and counts for the locale chosen in
+// Settings. The interface text stays in English; only how values are
+// written follows the locale.
+package locale
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Locale is how a region writes dates and numbers. The zero Locale is ISO:
+// ISO 8601 dates and numbers without separators, which VocSign used before
+// locales could be chosen.
+type Locale struct {
+	// Tag is the BCP 47 tag, empty for ISO.
+	Tag string
+	// Name is shown in Settings.
+	Name string
+
+	date     string // time layout of a date
+	dateTime string // time layout of a date and time
+	group    string // thousands separator; French uses a no-break space
+	// groupMin is the fewest digits a number needs to be grouped; Spanish
+	// and Catalan leave four-digit numbers ungrouped.
+	groupMin int
+	rtl      bool
+}
+
+// All are the locales offered in Settings, ISO first.
+var All = []Locale{
+	{Name: "ISO 8601 (2026-01-31, 1000)"},
+	{Tag: "en-US", Name: "English, United States (01/31/2026, 1,000)", date: "01/02/2006", dateTime: "01/02/2006 3:04 PM", group: ","},
+	{Tag: "en-GB", Name: "English, United Kingdom (31/01/2026, 1,000)", date: "02/01/2006", dateTime: "02/01/2006 15:04", group: ","},
+	{Tag: "ca", Name: "Català (31/01/2026, 10.000)", date: "02/01/2006", dateTime: "02/01/2006 15:04", group: ".", groupMin: 5},
+	{Tag: "es", Name: "Español (31/01/2026, 10.000)", date: "02/01/2006", dateTime: "02/01/2006 15:04", group: ".", groupMin: 5},
+	{Tag: "gl", Name: "Galego (31/01/2026, 10.000)", date: "02/01/2006", dateTime: "02/01/2006 15:04", group: ".", groupMin: 5},
+	{Tag: "eu", Name: "Euskara (2026/01/31, 1.000)", date: "2006/01/02", dateTime: "2006/01/02 15:04", group: "."},
+	{Tag: "fr", Name: "Français (31/01/2026, 1 000)", date: "02/01/2006", dateTime: "02/01/2006 15:04", group: "\u00a0"},
+	{Tag: "de", Name: "Deutsch (31.01.2026, 1.000)", date: "02.01.2006", dateTime: "02.01.2006 15:04", group: "."},
+	{Tag: "ar", Name: "العربية (31/01/2026, 1,000)", date: "02/01/2006", dateTime: "02/01/2006 15:04", group: ",", rtl: true},
+	{Tag: "he", Name: "עברית (31.01.2026, 1,000)", date: "02.01.2006", dateTime: "02.01.2006 15:04", group: ",", rtl: true},
+}
+
+// Get returns the locale with tag, or the one for its language, such as
+// "es" for "es-MX". Unknown tags get ISO.
+func Get(tag string) Locale {
+	if l, ok := lookup(tag); ok {
+		return l
+	}
+	if lang, _, ok := strings.Cut(tag, "-"); ok {
+		if l, ok := lookup(lang); ok {
+			return l
+		}
+	}
+	return All[0]
+}
+
+// Known reports whether tag is one of the locales in All.
+func Known(tag string) bool {
+	_, ok := lookup(tag)
+	return ok
+}
+
+func lookup(tag string) (Locale, bool) {
+	for _, l := range All {
+		if strings.EqualFold(l.Tag, tag) {
+			return l, true
+		}
+	}
+	return Locale{}, false
+}
+
+// RTL reports whether the locale's script is written right to left.
+func (l Locale) RTL() bool {
+	return l.rtl
+}
+
+// Date writes the date of t.
+func (l Locale) Date(t time.Time) string {
+	if l.date == "" {
+		return t.Format("2006-01-02")
+	}
+	return t.Format(l.date)
+}
+
+// DateTime writes the date and time of t to the minute.
+func (l Locale) DateTime(t time.Time) string {
+	if l.dateTime == "" {
+		return t.Format("2006-01-02 15:04")
+	}
+	return t.Format(l.dateTime)
+}
+
+// Timestamp writes an RFC 3339 timestamp, as kept in the audit log and
+// receipts, in local time with its UTC offset, so it stays unambiguous on
+// a printed receipt. ISO keeps the timestamp as is, and so does anything
+// that is not a timestamp.
+func (l Locale) Timestamp(ts string) string {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil || l.Tag == "" {
+		return ts
+	}
+	t = t.Local()
+	return l.DateTime(t) + " (UTC" + t.Format("-07:00") + ")"
+}
+
+// Int writes n with the locale's thousands separator.
+func (l Locale) Int(n int) string {
+	digits := strconv.Itoa(n)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	if l.group == "" || len(digits) < max(l.groupMin, 4) {
+		return sign + digits
+	}
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteString(l.group)
+		}
+		b.WriteRune(d)
+	}
+	return b.String()
+}
+
+// Count writes n followed by one or other, as English plurals go, since the
+// interface is in English: "1 row", "0 rows", "1,200 rows".
+func (l Locale) Count(n int, one, other string) string {
+	if n == 1 {
+		return l.Int(n) + " " + one
+	}
+	return l.Int(n) + " " + other
+}
@@ -0,0 +1,95 @@
+package locale
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDates(t *testing.T) {
+	at := time.Date(2026, 1, 31, 14, 5, 0, 0, time.UTC)
+	tests := []struct {
+		tag, date, dateTime string
+	}{
+		{"", "2026-01-31", "2026-01-31 14:05"},
+		{"en-US", "01/31/2026", "01/31/2026 2:05 PM"},
+		{"ca", "31/01/2026", "31/01/2026 14:05"},
+		{"de", "31.01.2026", "31.01.2026 14:05"},
+		{"eu", "2026/01/31", "2026/01/31 14:05"},
+	}
+	for _, tt := range tests {
+		l := Get(tt.tag)
+		if got := l.Date(at); got != tt.date {
+			t.Errorf("%q Date = %q, want %q", tt.tag, got, tt.date)
+		}
+		if got := l.DateTime(at); got != tt.dateTime {
+			t.Errorf("%q DateTime = %q, want %q", tt.tag, got, tt.dateTime)
+		}
+	}
+
+	// The zero Locale is ISO.
+	if got := (Locale{}).DateTime(at); got != "2026-01-31 14:05" {
+		t.Errorf("zero DateTime = %q", got)
+	}
+}
+
+func TestTimestamp(t *testing.T) {
+	l := Get("en-GB")
+	ts := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	if got, want := l.Timestamp(ts.Format(time.RFC3339)), l.DateTime(ts.Local())+" (UTC"+ts.Local().Format("-07:00")+")"; got != want {
+		t.Errorf("Timestamp = %q, want %q", got, want)
+	}
+	if got := (Locale{}).Timestamp("2026-10-17T10:00:00Z"); got != "2026-10-17T10:00:00Z" {
+		t.Errorf("ISO Timestamp = %q", got)
+	}
+	if got := l.Timestamp("yesterday"); got != "yesterday" {
+		t.Errorf("Timestamp of a non-timestamp = %q", got)
+	}
+}
+
+func TestInt(t *testing.T) {
+	tests := []struct {
+		tag  string
+		n    int
+		want string
+	}{
+		{"", 1234567, "1234567"},
+		{"en-US", 999, "999"},
+		{"en-US", 1000, "1,000"},
+		{"en-US", -1234567, "-1,234,567"},
+		{"es", 1000, "1000"},
+		{"es", 10000, "10.000"},
+		{"fr", 1234, "1\u00a0234"},
+	}
+	for _, tt := range tests {
+		if got := Get(tt.tag).Int(tt.n); got != tt.want {
+			t.Errorf("%q Int(%d) = %q, want %q", tt.tag, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestCount(t *testing.T) {
+	l := Get("en-US")
+	for n, want := range map[int]string{0: "0 rows", 1: "1 row", 2: "2 rows", 1500: "1,500 rows"} {
+		if got := l.Count(n, "row", "rows"); got != want {
+			t.Errorf("Count(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	if got := Get("es-MX").Tag; got != "es" {
+		t.Errorf("Get(es-MX) = %q, want es", got)
+	}
+	if got := Get("EN-gb").Tag; got != "en-GB" {
+		t.Errorf("Get(EN-gb) = %q, want en-GB", got)
+	}
+	if got := Get("xx"); got.Tag != "" {
+		t.Errorf("Get(xx) = %q, want ISO", got.Tag)
+	}
+	if !Get("ar").RTL() || Get("ca").RTL() {
+		t.Error("wrong text direction")
+	}
+	if !Known("") || !Known("he") || Known("es-MX") {
+		t.Error("Known")
+	}
+}
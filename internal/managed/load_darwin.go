@@ -0,0 +1,31 @@
+//go:build darwin
+
+package managed
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+)
+
+const (
+	// managedPrefs is where macOS installs the settings of an MDM
+	// configuration profile for the org.vocdoni.vocsign domain.
+	managedPrefs = "/Library/Managed Preferences/org.vocdoni.vocsign.plist"
+	policyFile   = "/Library/Application Support/VocSign/policy.json"
+)
+
+func load() ([]byte, string, error) {
+	if _, err := os.Stat(managedPrefs); err == nil {
+		// The keys of the profile are those of policy.json; plutil turns
+		// the property list into the same JSON.
+		data, err := exec.Command("/usr/bin/plutil", "-convert", "json", "-o", "-", managedPrefs).Output()
+		return data, managedPrefs, err
+	}
+	data, err := os.ReadFile(policyFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, "", nil
+	}
+	return data, policyFile, err
+}
@@ -0,0 +1,27 @@
+//go:build windows
+
+package managed
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+const policyKey = `SOFTWARE\Policies\Vocdoni\VocSign`
+
+func load() ([]byte, string, error) {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, policyKey, registry.QUERY_VALUE)
+	if errors.Is(err, registry.ErrNotExist) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() { _ = k.Close() }()
+	v, _, err := k.GetStringValue("Policy")
+	if errors.Is(err, registry.ErrNotExist) {
+		return nil, "", nil
+	}
+	return []byte(v), `HKLM\` + policyKey, err
+}
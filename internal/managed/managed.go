@@ -0,0 +1,191 @@
+// Package managed reads the policy an administrator provides to manage
+// VocSign installations, e.g. in municipal offices. The policy can lock
+// settings, restrict requests to allowed organizers, disable importing
+// files, force kiosk mode and set a proxy. It is read from the first of:
+//
+//   - Windows: the Policy value (REG_SZ, JSON) of
+//     HKLM\SOFTWARE\Policies\Vocdoni\VocSign
+//   - macOS: the managed preferences of org.vocdoni.vocsign installed by an
+//     MDM configuration profile, then /Library/Application Support/VocSign/policy.json
+//   - other systems: /etc/vocsign/policy.json
+//
+// A policy that cannot be read or parsed is an error: VocSign does not
+// start unmanaged on a computer meant to be managed.
+package managed
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+	"github.com/vocdoni/gofirma/vocsign/internal/idn"
+	"github.com/vocdoni/gofirma/vocsign/internal/settings"
+)
+
+// Policy is an administrator's policy. The zero value manages nothing.
+type Policy struct {
+	// AllowedOrganizerDomains restricts requests to organizers whose key
+	// set (organizer.jwkSetUrl) is served from one of these domains or
+	// their subdomains. Empty allows any organizer.
+	AllowedOrganizerDomains []string `json:"allowedOrganizerDomains,omitempty"`
+
+	// DisableFileImport hides choosing certificate files, configuration
+	// profiles and signer lists (CSV) to import.
+	DisableFileImport bool `json:"disableFileImport,omitempty"`
+
+	// Kiosk keeps the window full screen and hides the Settings and About
+	// screens and the links to the VocSign website.
+	Kiosk bool `json:"kiosk,omitempty"`
+
+	// Proxy is the HTTP(S) proxy all connections go through, e.g.
+	// "http://proxy.example:3128". Empty uses the system's.
+	Proxy string `json:"proxy,omitempty"`
+
+	// Settings are settings.json members whose values the policy sets. The
+	// user cannot change them.
+	Settings map[string]json.RawMessage `json:"settings,omitempty"`
+
+	// Source names where the policy was read from, empty if there is none.
+	Source string `json:"-"`
+}
+
+// Load reads the policy of this computer. Without one, it returns the zero
+// Policy.
+func Load() (Policy, error) {
+	data, source, err := load()
+	if err != nil {
+		return Policy{}, fmt.Errorf("failed to read policy: %w", err)
+	}
+	if data == nil {
+		return Policy{}, nil
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return Policy{}, fmt.Errorf("invalid policy %s: %w", source, err)
+	}
+	p.Source = source
+	return p, nil
+}
+
+// Parse decodes and checks a policy. Unknown members are errors, so that a
+// misspelled restriction is not silently ignored.
+func Parse(data []byte) (Policy, error) {
+	var p Policy
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return Policy{}, err
+	}
+	for i, d := range p.AllowedOrganizerDomains {
+		d = strings.TrimPrefix(strings.TrimSuffix(d, "."), "*.")
+		if d == "" || strings.ContainsAny(d, "/:@ ") {
+			return Policy{}, fmt.Errorf("invalid allowedOrganizerDomains entry %q", p.AllowedOrganizerDomains[i])
+		}
+		p.AllowedOrganizerDomains[i] = idn.Inspect(d).ASCII
+	}
+	if p.Proxy != "" {
+		u, err := url.Parse(p.Proxy)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return Policy{}, fmt.Errorf("invalid proxy %q: use an http:// or https:// address", p.Proxy)
+		}
+	}
+	if _, err := p.ApplySettings(settings.Default()); err != nil {
+		return Policy{}, err
+	}
+	return p, nil
+}
+
+// Managed reports whether a policy was found.
+func (p Policy) Managed() bool {
+	return p.Source != ""
+}
+
+// Locked reports whether the policy sets any of the settings.json members
+// names.
+func (p Policy) Locked(names ...string) bool {
+	for _, name := range names {
+		if _, ok := p.Settings[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplySettings returns st with the values the policy sets. It fails,
+// returning st, if the result is not valid settings.
+func (p Policy) ApplySettings(st settings.Settings) (settings.Settings, error) {
+	if len(p.Settings) == 0 {
+		return st, nil
+	}
+	data, err := json.Marshal(st)
+	if err != nil {
+		return st, err
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return st, err
+	}
+	known := settingsMembers()
+	for name, value := range p.Settings {
+		if !slices.Contains(known, name) {
+			return st, fmt.Errorf("unknown setting %q", name)
+		}
+		members[name] = value
+	}
+	if data, err = json.Marshal(members); err != nil {
+		return st, err
+	}
+	var out settings.Settings
+	if err := json.Unmarshal(data, &out); err != nil {
+		return st, fmt.Errorf("invalid settings: %w", err)
+	}
+	if err := (settings.Profile{Settings: out}).Validate(); err != nil {
+		return st, fmt.Errorf("invalid settings: %w", err)
+	}
+	return out, nil
+}
+
+// AllowsOrganizer reports whether requests signed under the key set at
+// jwksURL may be opened.
+func (p Policy) AllowsOrganizer(jwksURL string) bool {
+	if len(p.AllowedOrganizerDomains) == 0 {
+		return true
+	}
+	u, err := url.Parse(jwksURL)
+	if err != nil || u.Hostname() == "" {
+		return false
+	}
+	host := idn.Inspect(u.Hostname()).ASCII
+	for _, d := range p.AllowedOrganizerDomains {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckOrganizer returns an errcode.OrganizerNotAllowed error if requests
+// signed under the key set at jwksURL may not be opened.
+func (p Policy) CheckOrganizer(jwksURL string) error {
+	if p.AllowsOrganizer(jwksURL) {
+		return nil
+	}
+	return errcode.Errorf(errcode.OrganizerNotAllowed, "organizer %s is not in allowedOrganizerDomains", jwksURL)
+}
+
+// settingsMembers returns the JSON member names of settings.Settings.
+func settingsMembers() []string {
+	t := reflect.TypeFor[settings.Settings]()
+	names := make([]string, 0, t.NumField())
+	for i := range t.NumField() {
+		if name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ","); name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
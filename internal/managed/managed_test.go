@@ -0,0 +1,104 @@
+package managed
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+	"github.com/vocdoni/gofirma/vocsign/internal/settings"
+)
+
+func TestParse(t *testing.T) {
+	p, err := Parse([]byte(`{
+		"allowedOrganizerDomains": ["Vocdoni.io", "*.gencat.cat", "bücher.de."],
+		"disableFileImport": true,
+		"kiosk": true,
+		"proxy": "http://proxy.example:3128",
+		"settings": {"telemetryEnabled": false, "submitReviewSeconds": 30, "ipfsGateways": ["https://gw.example"]}
+	}`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got := strings.Join(p.AllowedOrganizerDomains, " "); got != "vocdoni.io gencat.cat xn--bcher-kva.de" {
+		t.Fatalf("AllowedOrganizerDomains = %q", got)
+	}
+	if !p.DisableFileImport || !p.Kiosk || p.Managed() {
+		t.Fatalf("Parse = %+v", p)
+	}
+	if !p.Locked("mode", "submitReviewSeconds") || p.Locked("mode") {
+		t.Fatal("Locked does not match the policy's settings")
+	}
+
+	st := settings.Default()
+	st.TelemetryEnabled = true
+	st.Mode = settings.ModeAgent
+	got, err := p.ApplySettings(st)
+	if err != nil {
+		t.Fatalf("ApplySettings: %v", err)
+	}
+	if got.TelemetryEnabled || got.SubmitReviewSeconds != 30 || len(got.IPFSGateways) != 1 || got.Mode != settings.ModeAgent {
+		t.Fatalf("ApplySettings = %+v", got)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := map[string]string{
+		`{"kiosk": true, "allowedOrganiserDomains": ["vocdoni.io"]}`: "unknown field",
+		`{"allowedOrganizerDomains": ["https://vocdoni.io"]}`:        "invalid allowedOrganizerDomains",
+		`{"proxy": "socks5://proxy.example"}`:                        "invalid proxy",
+		`{"settings": {"telemetry": false}}`:                         `unknown setting "telemetry"`,
+		`{"settings": {"pinCacheMinutes": "five"}}`:                  "invalid settings",
+		`{"settings": {"pinCacheMinutes": 7}}`:                       "invalid pinCacheMinutes 7",
+		`{"settings": {"ipfsGateways": ["ftp://gw.example"]}}`:       "ipfsGateways",
+		`{"settings": {"clipboardPattern": "("}}`:                    "invalid clipboardPattern",
+	}
+	for data, want := range tests {
+		if _, err := Parse([]byte(data)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Parse(%s) error = %v, want %q", data, err, want)
+		}
+	}
+}
+
+func TestApplySettingsInvalid(t *testing.T) {
+	p, err := Parse([]byte(`{"settings": {"telemetryEnabled": false}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := settings.Default()
+	st.AutoLockMinutes = 7
+	got, err := p.ApplySettings(st)
+	if err == nil || !strings.Contains(err.Error(), "autoLockMinutes") {
+		t.Fatalf("ApplySettings of invalid settings error = %v", err)
+	}
+	if got.AutoLockMinutes != 7 {
+		t.Fatalf("ApplySettings changed the settings it rejected: %+v", got)
+	}
+}
+
+func TestAllowsOrganizer(t *testing.T) {
+	var unmanaged Policy
+	if !unmanaged.AllowsOrganizer("https://any.example/jwks.json") {
+		t.Fatal("the zero policy must allow any organizer")
+	}
+	p, err := Parse([]byte(`{"allowedOrganizerDomains": ["vocdoni.io", "bücher.de"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]bool{
+		"https://vocdoni.io/jwks.json":              true,
+		"https://keys.VOCDONI.io:8443/jwks.json":    true,
+		"https://xn--bcher-kva.de/jwks.json":        true,
+		"https://evilvocdoni.io/jwks.json":          false,
+		"https://vocdoni.io.evil.example/jwks.json": false,
+		"https://xn--vcdoni-i0e.io/jwks.json":       false,
+		"not a url":                                 false,
+	}
+	for u, want := range tests {
+		if got := p.AllowsOrganizer(u); got != want {
+			t.Errorf("AllowsOrganizer(%q) = %v, want %v", u, got, want)
+		}
+	}
+	if err := p.CheckOrganizer("https://evil.example/jwks.json"); errcode.Of(err) != errcode.OrganizerNotAllowed {
+		t.Fatalf("CheckOrganizer = %v", err)
+	}
+}
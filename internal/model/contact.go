@@ -0,0 +1,98 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MaxContactPurposeLength bounds the purpose shown with the consent.
+const MaxContactPurposeLength = 500
+
+func (c *ContactRequest) validate() error {
+	if len(c.Fields) == 0 {
+		return errors.New("contactRequest must ask for email or phone")
+	}
+	for i, f := range c.Fields {
+		if f != ContactEmail && f != ContactPhone {
+			return fmt.Errorf("unknown contactRequest field %q", f)
+		}
+		if slices.Contains(c.Fields[:i], f) {
+			return fmt.Errorf("duplicate contactRequest field %q", f)
+		}
+	}
+	if strings.TrimSpace(c.Purpose) == "" {
+		return errors.New("missing contactRequest purpose")
+	}
+	if len(c.Purpose) > MaxContactPurposeLength {
+		return errors.New("contactRequest purpose too long")
+	}
+	if c.RetentionDays < 1 || c.RetentionDays > MaxContactRetentionDays {
+		return fmt.Errorf("contactRequest retentionDays must be between 1 and %d", MaxContactRetentionDays)
+	}
+	return nil
+}
+
+// Asks reports whether the request asks for field.
+func (c *ContactRequest) Asks(field string) bool {
+	return slices.Contains(c.Fields, field)
+}
+
+// ConsentWording is the text of the contact consent checkbox, which is
+// sent with the contact as the consent given.
+func (c *ContactRequest) ConsentWording() string {
+	return fmt.Sprintf("Optional, separate from my signature: I agree that the promoter may contact me for this purpose: %s. My contact details are deleted after %d days.",
+		strings.TrimSuffix(strings.TrimSpace(c.Purpose), "."), c.RetentionDays)
+}
+
+// Contact checks the email and phone typed by a signer who gave consent and
+// returns the contact to send. Fields the request does not ask for must be
+// empty, and at least one must be given.
+func (c *ContactRequest) Contact(email, phone string, consentedAt time.Time) (*SignerContact, error) {
+	email, phone = strings.TrimSpace(email), strings.TrimSpace(phone)
+	if email == "" && phone == "" {
+		return nil, errors.New("enter your contact details or untick the contact consent")
+	}
+	if email != "" {
+		if !c.Asks(ContactEmail) {
+			return nil, errors.New("this request does not ask for an email address")
+		}
+		if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+			return nil, errors.New("invalid email address")
+		}
+	}
+	if phone != "" {
+		if !c.Asks(ContactPhone) {
+			return nil, errors.New("this request does not ask for a phone number")
+		}
+		if !validPhone(phone) {
+			return nil, errors.New("invalid phone number")
+		}
+	}
+	return &SignerContact{
+		Email:       email,
+		Phone:       phone,
+		ConsentText: c.ConsentWording(),
+		ConsentedAt: consentedAt.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// validPhone accepts an optional leading "+" and 6 to 15 digits, which may
+// be grouped with spaces, dots, dashes or parentheses.
+func validPhone(s string) bool {
+	digits := 0
+	for i, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case r == '+' && i == 0:
+		case strings.ContainsRune(" .-()", r):
+		default:
+			return false
+		}
+	}
+	return digits >= 6 && digits <= 15
+}
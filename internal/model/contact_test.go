@@ -0,0 +1,49 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestContactRequest_Contact(t *testing.T) {
+	cr := &ContactRequest{Fields: []string{ContactEmail}, Purpose: "News about the initiative", RetentionDays: 180}
+	at := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	c, err := cr.Contact(" anna@example.com ", "", at)
+	if err != nil {
+		t.Fatalf("Contact: %v", err)
+	}
+	if c.Email != "anna@example.com" || c.ConsentedAt != "2026-10-16T12:00:00Z" {
+		t.Errorf("contact = %+v", c)
+	}
+	if c.ConsentText != cr.ConsentWording() || !strings.Contains(c.ConsentText, "180 days") {
+		t.Errorf("consent text = %q", c.ConsentText)
+	}
+
+	for _, tc := range []struct{ email, phone, wantErr string }{
+		{"", "", "enter your contact details"},
+		{"not an address", "", "invalid email address"},
+		{"Anna <anna@example.com>", "", "invalid email address"},
+		{"", "+34 600 000 000", "does not ask for a phone number"},
+	} {
+		if _, err := cr.Contact(tc.email, tc.phone, at); err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("Contact(%q, %q) error = %v, want %q", tc.email, tc.phone, err, tc.wantErr)
+		}
+	}
+}
+
+func TestValidPhone(t *testing.T) {
+	for s, want := range map[string]bool{
+		"+34 600 000 000": true,
+		"(93) 123-45-67":  true,
+		"600.000.000":     true,
+		"12345":           false,
+		"600 000 000 ext": false,
+		"34+600000000":    false,
+	} {
+		if got := validPhone(s); got != want {
+			t.Errorf("validPhone(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
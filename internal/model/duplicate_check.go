@@ -0,0 +1,26 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Bounds of the unused DuplicateCheck.PrefixLength, still enforced so a
+// request is valid or not as before.
+const (
+	MinDuplicatePrefixLength = 4
+	MaxDuplicatePrefixLength = 16
+)
+
+// SignerHash returns the salted hex SHA-256 that identifies a signer for a
+// given request. Both client and collector derive it the same way.
+func (d *DuplicateCheck) SignerHash(requestID, idNumber string) string {
+	h := sha256.New()
+	h.Write([]byte(d.Salt))
+	h.Write([]byte{0})
+	h.Write([]byte(requestID))
+	h.Write([]byte{0})
+	h.Write([]byte(strings.ToUpper(strings.TrimSpace(idNumber))))
+	return hex.EncodeToString(h.Sum(nil))
+}
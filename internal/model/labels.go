@@ -0,0 +1,66 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Keys of the UI labels an organizer may override through a translation
+// bundle. Any other key in a bundle is ignored.
+const (
+	LabelConsent      = "consent"
+	LabelSignNotice   = "signNotice"
+	LabelSignButton   = "signButton"
+	LabelConsentError = "consentRequired"
+)
+
+// MaxLabelLength bounds a single label so a bundle cannot flood the UI.
+const MaxLabelLength = 2000
+
+var knownLabels = map[string]bool{
+	LabelConsent:      true,
+	LabelSignNotice:   true,
+	LabelSignButton:   true,
+	LabelConsentError: true,
+}
+
+// Labels holds campaign-specific UI strings keyed by the Label* constants.
+type Labels map[string]string
+
+// Get returns the label for key, or fallback when the bundle has none.
+// It is safe to call on a nil Labels.
+func (l Labels) Get(key, fallback string) string {
+	if v, ok := l[key]; ok && v != "" {
+		return v
+	}
+	return fallback
+}
+
+type labelBundle struct {
+	Language string            `json:"language,omitempty"`
+	Labels   map[string]string `json:"labels"`
+}
+
+// ParseLabels decodes a translation bundle of the form
+// {"language": "ca", "labels": {"consent": "..."}}.
+func ParseLabels(data []byte) (Labels, error) {
+	var b labelBundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to decode translation bundle: %w", err)
+	}
+	out := Labels{}
+	for k, v := range b.Labels {
+		// Error messages are the app's own, so a campaign cannot reword
+		// a security or verification failure; ERR_* keys are dropped too.
+		if !knownLabels[k] {
+			continue
+		}
+		v = strings.TrimSpace(v)
+		if len(v) > MaxLabelLength {
+			return nil, fmt.Errorf("label %q exceeds %d bytes", k, MaxLabelLength)
+		}
+		out[k] = v
+	}
+	return out, nil
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseLabels(t *testing.T) {
+	data := []byte(`{"language":"ca","labels":{"consent":"  Declaro que...  ","signButton":"Signa","ERR_FETCH_TIMEOUT":"El servidor no respon","unknown":"ignored"}}`)
+	labels, err := ParseLabels(data)
+	if err != nil {
+		t.Fatalf("ParseLabels failed: %v", err)
+	}
+	if got := labels.Get(LabelConsent, "default"); got != "Declaro que..." {
+		t.Errorf("consent = %q", got)
+	}
+	if got := labels.Get(LabelSignButton, "default"); got != "Signa" {
+		t.Errorf("signButton = %q", got)
+	}
+	if got := labels.Get(LabelSignNotice, "default"); got != "default" {
+		t.Errorf("signNotice = %q, want fallback", got)
+	}
+	if _, ok := labels["ERR_FETCH_TIMEOUT"]; ok {
+		t.Error("error code keys must be dropped")
+	}
+	if _, ok := labels["unknown"]; ok {
+		t.Error("unknown keys must be dropped")
+	}
+}
+
+func TestParseLabels_Errors(t *testing.T) {
+	if _, err := ParseLabels([]byte("not json")); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+	long := `{"labels":{"consent":"` + strings.Repeat("a", MaxLabelLength+1) + `"}}`
+	if _, err := ParseLabels([]byte(long)); err == nil {
+		t.Error("expected error for oversized label")
+	}
+}
+
+func TestLabels_GetNil(t *testing.T) {
+	var l Labels
+	if got := l.Get(LabelConsent, "fallback"); got != "fallback" {
+		t.Errorf("Get on nil Labels = %q", got)
+	}
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrUnsupportedPolicyHashAlg is returned for policy hash algorithms the
+// client cannot reproduce in the signaturePolicyIdentifier attribute.
+var ErrUnsupportedPolicyHashAlg = errors.New("unsupported policy hash algorithm")
+
+// HashAlgorithm returns the algorithm of the policy document hash. An empty
+// HashAlg means SHA-256, which is also the only algorithm the CAdES
+// signaturePolicyIdentifier attribute is built with.
+func (p *SignPolicy) HashAlgorithm() (crypto.Hash, error) {
+	switch strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(p.HashAlg), "-", "")) {
+	case "", "SHA256", "2.16.840.1.101.3.4.2.1":
+		return crypto.SHA256, nil
+	default:
+		return 0, fmt.Errorf("%w: %q", ErrUnsupportedPolicyHashAlg, p.HashAlg)
+	}
+}
+
+// Digest decodes the base64 policy hash and checks its length.
+func (p *SignPolicy) Digest() ([]byte, error) {
+	alg, err := p.HashAlgorithm()
+	if err != nil {
+		return nil, err
+	}
+	sum, err := base64.StdEncoding.DecodeString(p.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy hash: %w", err)
+	}
+	if len(sum) != alg.Size() {
+		return nil, fmt.Errorf("invalid policy hash: expected %d bytes, got %d", alg.Size(), len(sum))
+	}
+	return sum, nil
+}
+
+// DefaultAcknowledgementText is the checkbox wording when the policy does
+// not provide its own.
+const DefaultAcknowledgementText = "I have read and agree with the legal statement"
+
+// MaxInitialsLength bounds the initials typed to acknowledge a statement.
+const MaxInitialsLength = 8
+
+// Wording returns the checkbox text, or DefaultAcknowledgementText.
+func (a *Acknowledgement) Wording() string {
+	if t := strings.TrimSpace(a.Text); t != "" {
+		return t
+	}
+	return DefaultAcknowledgementText
+}
+
+// Digest returns the hex SHA-256 of the wording and the legal statement
+// agreed to, as recorded in the audit log.
+func (a *Acknowledgement) Digest(legalStatement string) string {
+	sum := sha256.Sum256([]byte(a.Wording() + "\n" + legalStatement))
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidateInitials checks initials typed to acknowledge a statement: one to
+// MaxInitialsLength letters, optionally separated by dots or spaces.
+func ValidateInitials(initials string) error {
+	letters := 0
+	for _, r := range initials {
+		switch {
+		case unicode.IsLetter(r):
+			letters++
+		case r == '.' || r == ' ':
+		default:
+			return fmt.Errorf("initials may only contain letters")
+		}
+	}
+	if letters == 0 {
+		return errors.New("initials are required")
+	}
+	if letters > MaxInitialsLength || utf8.RuneCountInString(initials) > 2*MaxInitialsLength {
+		return fmt.Errorf("initials must be at most %d letters", MaxInitialsLength)
+	}
+	return nil
+}
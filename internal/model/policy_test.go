@@ -0,0 +1,76 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestSignPolicyDigest(t *testing.T) {
+	sum := sha256.Sum256([]byte("policy"))
+	valid := base64.StdEncoding.EncodeToString(sum[:])
+
+	tests := []struct {
+		name       string
+		policy     SignPolicy
+		wantErr    bool
+		wantUnsupp bool
+	}{
+		{"default alg", SignPolicy{Hash: valid}, false, false},
+		{"sha256", SignPolicy{HashAlg: "sha256", Hash: valid}, false, false},
+		{"SHA-256", SignPolicy{HashAlg: "SHA-256", Hash: valid}, false, false},
+		{"oid", SignPolicy{HashAlg: "2.16.840.1.101.3.4.2.1", Hash: valid}, false, false},
+		{"sha1 unsupported", SignPolicy{HashAlg: "sha1", Hash: valid}, true, true},
+		{"bad base64", SignPolicy{Hash: "%%%"}, true, false},
+		{"wrong length", SignPolicy{Hash: base64.StdEncoding.EncodeToString([]byte("short"))}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.policy.Digest()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Digest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrUnsupportedPolicyHashAlg) != tt.wantUnsupp {
+				t.Fatalf("unexpected unsupported-algorithm classification: %v", err)
+			}
+		})
+	}
+}
+
+func TestAcknowledgement(t *testing.T) {
+	a := &Acknowledgement{}
+	if a.Wording() != DefaultAcknowledgementText {
+		t.Fatalf("Wording() = %q, want default", a.Wording())
+	}
+	custom := &Acknowledgement{Text: "He llegit i accepto la declaració"}
+	if custom.Wording() != custom.Text {
+		t.Fatalf("Wording() = %q, want %q", custom.Wording(), custom.Text)
+	}
+	if a.Digest("statement") == custom.Digest("statement") {
+		t.Fatal("digest does not depend on the wording")
+	}
+	if a.Digest("statement") == a.Digest("other statement") {
+		t.Fatal("digest does not depend on the legal statement")
+	}
+}
+
+func TestValidateInitials(t *testing.T) {
+	tests := []struct {
+		in      string
+		wantErr bool
+	}{
+		{"JPM", false},
+		{"J.P.M.", false},
+		{"À G", false},
+		{"", true},
+		{" . ", true},
+		{"J1", true},
+		{"ABCDEFGHI", true},
+	}
+	for _, tt := range tests {
+		if err := ValidateInitials(tt.in); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateInitials(%q) = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+	}
+}
@@ -11,6 +11,18 @@ type SignRequest struct {
 	Organizer          Organizer           `json:"organizer"`
 	OrganizerSignature *OrganizerSignature `json:"organizerSignature,omitempty"` // Pointer to allow omitting in canonical encoding if needed
 	Policy             *SignPolicy         `json:"policy,omitempty"`
+	DuplicateCheck     *DuplicateCheck     `json:"duplicateCheck,omitempty"`
+	TransparencyLog    *TransparencyLog    `json:"transparencyLog,omitempty"`
+	Translations       *Translations       `json:"translations,omitempty"`
+	AuditSync          *AuditSync          `json:"auditSync,omitempty"`
+	PublicStats        *PublicStats        `json:"publicStats,omitempty"`
+	ReceiptLookup      *ReceiptLookup      `json:"receiptLookup,omitempty"`
+	ContactRequest     *ContactRequest     `json:"contactRequest,omitempty"`
+	// Localizations, keyed by BCP 47 language tag, and Policies are 2.0
+	// fields. Policies are the promoter's documents that govern the
+	// campaign, such as its privacy notice.
+	Localizations map[string]Localization `json:"localizations,omitempty"`
+	Policies      []Document              `json:"policies,omitempty"`
 }
 
 type Proposal struct {
@@ -20,6 +32,14 @@ type Proposal struct {
 	Summary        string   `json:"summary"`
 	LegalStatement string   `json:"legalStatement"` // Clear statement of what is being signed
 	FullText       FullText `json:"fullText"`
+	// DocumentVersion numbers the proposal text. Organizers that amend the
+	// text during a campaign publish the request again with a higher version
+	// and the new FullText hash; signatures of an older version are rejected.
+	// Zero means the request is not versioned.
+	DocumentVersion int `json:"documentVersion,omitempty"`
+	// Attachments are annexes to the full text (2.0), verified like it
+	// before signing.
+	Attachments []Document `json:"attachments,omitempty"`
 }
 
 type FullText struct {
@@ -35,6 +55,12 @@ type Callback struct {
 type Organizer struct {
 	KID       string `json:"kid"`
 	JWKSetURL string `json:"jwkSetUrl"`
+	// PreviousKIDs lists keys that may still have signed this request while
+	// the organizer is rotating from one key to the next.
+	PreviousKIDs []string `json:"previousKids,omitempty"`
+	// CampaignIndexURL lists the organizer's currently open requests so
+	// users who pin this organizer can browse them from the home screen.
+	CampaignIndexURL string `json:"campaignIndexUrl,omitempty"`
 }
 
 type OrganizerSignature struct {
@@ -48,8 +74,110 @@ type SignPolicy struct {
 	HashAlg string `json:"hashAlg,omitempty"`
 	Hash    string `json:"hash,omitempty"`
 	URI     string `json:"uri,omitempty"`
+	// Issuer names the authority that published the policy, for display.
+	Issuer string `json:"issuer,omitempty"`
+	// Acknowledgement, when set, requires the signer to explicitly agree
+	// with proposal.legalStatement before the sign button is enabled.
+	Acknowledgement *Acknowledgement `json:"acknowledgement,omitempty"`
+	// SignerFields, when set, declares which optional personal fields the
+	// campaign collects.
+	SignerFields *SignerFields `json:"signerFields,omitempty"`
+	// Demo marks a rehearsal campaign, signed with a throwaway certificate
+	// and made-up signer data. Its signatures have no legal value.
+	Demo bool `json:"demo,omitempty"`
 }
 
+// IsDemo reports whether the request belongs to a demo campaign.
+func (r *SignRequest) IsDemo() bool {
+	return r.Policy != nil && r.Policy.Demo
+}
+
+// Acknowledgement is the wording of the legal statement checkbox and
+// whether the signer must also type their initials.
+type Acknowledgement struct {
+	Text     string `json:"text,omitempty"`
+	Initials bool   `json:"initials,omitempty"`
+}
+
+// DuplicateCheck describes an optional endpoint the client can query before
+// signing to learn whether the signer already supported the proposal. The
+// client sends the salted signer hash, never the ID itself.
+type DuplicateCheck struct {
+	URL  string `json:"url"`
+	Salt string `json:"salt"`
+	// PrefixLength is unused. Clients once sent only this many leading
+	// characters of the signer hash; it is kept so requests that declare
+	// it still verify.
+	PrefixLength int `json:"prefixLength,omitempty"`
+}
+
+// TransparencyLog points to the organizer's public append-only log of issued
+// requests. When present, clients require the request to be logged.
+type TransparencyLog struct {
+	URL string `json:"url"`
+}
+
+// Translations points to a bundle of campaign-specific UI labels. SHA256 is
+// the base64-encoded hash of the bundle, as registered by the promoter.
+type Translations struct {
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
+}
+
+// AuditSync is the collector endpoint where certifying agents ("fedatari")
+// upload the audit log entries of the signatures they collected, so the
+// promoter can reconcile field-collected signatures with server records.
+type AuditSync struct {
+	URL string `json:"url"`
+}
+
+// PublicStats points to the collector's public signature count for the
+// request, which the client shows next to the proposal and campaign pages
+// can embed. Its presence is the promoter's consent to publish the count.
+// Days is the length of the daily histogram, DefaultPublicStatsDays if zero.
+type PublicStats struct {
+	URL  string `json:"url"`
+	Days int    `json:"days,omitempty"`
+}
+
+// ReceiptLookup points to the collector's endpoint that tells whether a
+// submission was accepted, so a client that stopped while submitting can
+// fetch the receipt instead of submitting again. Submissions are identified
+// by their idempotency key, the SHA-256 of the signature.
+type ReceiptLookup struct {
+	URL string `json:"url"`
+}
+
+const (
+	DefaultPublicStatsDays = 30
+	MaxPublicStatsDays     = 366
+)
+
+// HistogramDays returns Days, or DefaultPublicStatsDays when unset.
+func (p *PublicStats) HistogramDays() int {
+	if p.Days == 0 {
+		return DefaultPublicStatsDays
+	}
+	return p.Days
+}
+
+// ContactRequest asks the signer, optionally, for an email address or phone
+// number so the promoter can follow up on the campaign. Signing never
+// depends on it: the contact is sent only with its own consent, outside the
+// signed XML, and the collector deletes it after RetentionDays.
+type ContactRequest struct {
+	Fields        []string `json:"fields"` // ContactEmail and/or ContactPhone
+	Purpose       string   `json:"purpose"`
+	RetentionDays int      `json:"retentionDays"`
+}
+
+const (
+	ContactEmail = "email"
+	ContactPhone = "phone"
+
+	MaxContactRetentionDays = 3650
+)
+
 // Payload to be signed
 type SignPayload struct {
 	Version      string          `json:"v"`
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/canon"
+)
+
+// FieldChange is one differing leaf field between two versions of a request.
+// Field is a dotted JSON path such as "proposal.summary" or "organizer.previousKids.0".
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// DiffRequests compares two requests field by field. The organizer signature
+// is ignored since it changes whenever anything else does.
+func DiffRequests(old, new *SignRequest) ([]FieldChange, error) {
+	a, err := flattenRequest(old)
+	if err != nil {
+		return nil, err
+	}
+	b, err := flattenRequest(new)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]struct{}, len(a)+len(b))
+	for k := range a {
+		keys[k] = struct{}{}
+	}
+	for k := range b {
+		keys[k] = struct{}{}
+	}
+	var changes []FieldChange
+	for k := range keys {
+		if a[k] != b[k] {
+			changes = append(changes, FieldChange{Field: k, Old: a[k], New: b[k]})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
+
+func flattenRequest(r *SignRequest) (map[string]string, error) {
+	c := *r
+	c.OrganizerSignature = nil
+	b, err := canon.Encode(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize request: %w", err)
+	}
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, fmt.Errorf("failed to decode request: %w", err)
+	}
+	out := make(map[string]string)
+	flatten("", v, out)
+	return out, nil
+}
+
+func flatten(prefix string, v any, out map[string]string) {
+	join := func(k string) string {
+		if prefix == "" {
+			return k
+		}
+		return prefix + "." + k
+	}
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			flatten(join(k), child, out)
+		}
+	case []any:
+		for i, child := range t {
+			flatten(join(strconv.Itoa(i)), child, out)
+		}
+	case nil:
+	case string:
+		out[prefix] = t
+	default:
+		b, _ := json.Marshal(t)
+		out[prefix] = string(b)
+	}
+}
@@ -0,0 +1,61 @@
+package model
+
+import "testing"
+
+func TestDiffRequests(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(r *SignRequest)
+		want   []FieldChange
+	}{
+		{
+			name:   "identical",
+			modify: func(r *SignRequest) {},
+			want:   nil,
+		},
+		{
+			name:   "signature only",
+			modify: func(r *SignRequest) { r.OrganizerSignature.Value = "other" },
+			want:   nil,
+		},
+		{
+			name: "summary and callback changed",
+			modify: func(r *SignRequest) {
+				r.Proposal.Summary = "new summary"
+				r.Callback.URL = "https://evil.example.com/cb"
+			},
+			want: []FieldChange{
+				{Field: "callback.url", Old: "https://example.com/callback", New: "https://evil.example.com/cb"},
+				{Field: "proposal.summary", Old: "", New: "new summary"},
+			},
+		},
+		{
+			name:   "policy added",
+			modify: func(r *SignRequest) { r.Policy = &SignPolicy{Mode: "required"} },
+			want:   []FieldChange{{Field: "policy.mode", Old: "", New: "required"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := validSignRequest()
+			updated := old
+			sig := *old.OrganizerSignature
+			updated.OrganizerSignature = &sig
+			tt.modify(&updated)
+
+			got, err := DiffRequests(&old, &updated)
+			if err != nil {
+				t.Fatalf("DiffRequests: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d changes %+v, want %d", len(got), got, len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("change[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
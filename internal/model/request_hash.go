@@ -0,0 +1,22 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/canon"
+)
+
+// CanonicalHash returns the hex SHA-256 of the canonical request as signed by
+// the organizer (i.e. without organizerSignature).
+func (r *SignRequest) CanonicalHash() (string, error) {
+	c := *r
+	c.OrganizerSignature = nil
+	b, err := canon.Encode(c)
+	if err != nil {
+		return "", fmt.Errorf("failed to canonicalize request: %w", err)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
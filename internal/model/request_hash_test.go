@@ -0,0 +1,22 @@
+package model
+
+import "testing"
+
+func TestCanonicalHash_IgnoresSignature(t *testing.T) {
+	req := validSignRequest()
+	h1, err := req.CanonicalHash()
+	if err != nil {
+		t.Fatalf("CanonicalHash: %v", err)
+	}
+	req.OrganizerSignature = nil
+	h2, _ := req.CanonicalHash()
+	if h1 != h2 || len(h1) != 64 {
+		t.Fatalf("hashes differ or wrong length: %q vs %q", h1, h2)
+	}
+
+	req.Proposal.Title = "Changed"
+	h3, _ := req.CanonicalHash()
+	if h3 == h1 {
+		t.Fatal("hash should change with request content")
+	}
+}
@@ -13,6 +13,30 @@ type SignResponse struct {
 	SignerXMLBase64        string     `json:"signerXmlBase64,omitempty"`      // Legally required XML
 	TimestampTokenBase64   string     `json:"timestampTokenBase64,omitempty"` // RFC 3161 timestamp token over signature value
 	Client                 ClientInfo `json:"client"`
+
+	// DocumentVersion and DocumentSHA256 identify the proposal text the
+	// signer reviewed (Proposal.DocumentVersion and FullText.SHA256). They
+	// are not signed: collectors must check the copy in the signed XML,
+	// ILPInfo.VersioText and ResumText.
+	DocumentVersion int    `json:"documentVersion,omitempty"`
+	DocumentSHA256  string `json:"documentSha256,omitempty"`
+
+	// Extensions carries data that is not part of the signature, which the
+	// collector keeps apart from it.
+	Extensions *ResponseExtensions `json:"extensions,omitempty"`
+}
+
+type ResponseExtensions struct {
+	Contact *SignerContact `json:"contact,omitempty"`
+}
+
+// SignerContact is the contact the signer agreed to share in answer to the
+// request's contactRequest. ConsentText is the wording they agreed to.
+type SignerContact struct {
+	Email       string `json:"email,omitempty"`
+	Phone       string `json:"phone,omitempty"`
+	ConsentText string `json:"consentText"`
+	ConsentedAt string `json:"consentedAt"`
 }
 
 type ClientInfo struct {
@@ -25,4 +49,40 @@ type SubmitReceipt struct {
 	Status     string `json:"status"`
 	ReceiptID  string `json:"receiptId"`
 	ReceivedAt string `json:"receivedAt"`
+	// CounterSignatureDerBase64 is the submitted CAdES signature with the
+	// collector's countersignature added, as evidence of acceptance.
+	CounterSignatureDerBase64 string `json:"counterSignatureDerBase64,omitempty"`
+	// ReportToken authorizes reading the collector's verification report of
+	// the signature, as the token query parameter of its report URL.
+	ReportToken string `json:"reportToken,omitempty"`
+}
+
+// PublicStatsSummary is the document served at a request's publicStats url.
+// It holds aggregate counts only.
+type PublicStatsSummary struct {
+	RequestID string `json:"requestId"`
+	// Verified is the number of signatures the collector accepted, each
+	// verified on receipt.
+	Verified int `json:"verified"`
+	// Daily counts the signatures accepted on each of the last publicStats
+	// days days (UTC), oldest first.
+	Daily     []DailyCount `json:"daily"`
+	UpdatedAt string       `json:"updatedAt"`
+}
+
+type DailyCount struct {
+	Date  string `json:"date"` // YYYY-MM-DD
+	Count int    `json:"count"`
+}
+
+// SubmitError is the body a collector may send with a rejected submission
+// so the client can tell the signer what to do. Code is an errcode code,
+// e.g. "ERR_PROPOSAL_CHANGED".
+type SubmitError struct {
+	Status  string `json:"status"` // "error"
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+	// DocumentVersion is the collector's current proposal version when
+	// Code is ERR_PROPOSAL_CHANGED.
+	DocumentVersion int `json:"documentVersion,omitempty"`
 }
@@ -0,0 +1,96 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SignerFields declares how a request collects the personal fields a
+// campaign may not legally need, so no more personal data is collected than
+// required. Each is SignerFieldRequired, SignerFieldOptional or
+// SignerFieldOmitted. Name, first surname and ID number are always
+// required.
+//
+// Without signerFields the birth date is required and the second surname is
+// optional, and both are always present in the signed XML. With it, a field
+// left empty or omitted is left out of the XML altogether.
+type SignerFields struct {
+	BirthDate     string `json:"birthDate,omitempty"`     // default SignerFieldRequired
+	SecondSurname string `json:"secondSurname,omitempty"` // default SignerFieldOptional
+}
+
+const (
+	SignerFieldRequired = "required"
+	SignerFieldOptional = "optional"
+	// SignerFieldOmitted: the field is not asked for and never sent.
+	SignerFieldOmitted = "omitted"
+)
+
+func (f *SignerFields) validate() error {
+	for _, field := range []struct{ name, mode string }{
+		{"birthDate", f.BirthDate},
+		{"secondSurname", f.SecondSurname},
+	} {
+		switch field.mode {
+		case "", SignerFieldRequired, SignerFieldOptional, SignerFieldOmitted:
+		default:
+			return fmt.Errorf("invalid signerFields %s %q", field.name, field.mode)
+		}
+	}
+	return nil
+}
+
+// SignerFields returns the request's signer fields policy, nil if it uses
+// the defaults.
+func (r *SignRequest) SignerFields() *SignerFields {
+	if r.Policy == nil {
+		return nil
+	}
+	return r.Policy.SignerFields
+}
+
+// BirthDateMode returns how the birth date is collected. f may be nil.
+func (f *SignerFields) BirthDateMode() string {
+	if f == nil || f.BirthDate == "" {
+		return SignerFieldRequired
+	}
+	return f.BirthDate
+}
+
+// SecondSurnameMode returns how the second surname is collected. f may be
+// nil.
+func (f *SignerFields) SecondSurnameMode() string {
+	if f == nil || f.SecondSurname == "" {
+		return SignerFieldOptional
+	}
+	return f.SecondSurname
+}
+
+// Check validates data against the fields f collects and returns it with
+// the omitted fields cleared. f may be nil.
+func (f *SignerFields) Check(data Signant) (Signant, error) {
+	switch f.SecondSurnameMode() {
+	case SignerFieldOmitted:
+		data.Cognom2 = ""
+	case SignerFieldRequired:
+		if strings.TrimSpace(data.Cognom2) == "" {
+			return data, errors.New("second surname is required")
+		}
+	}
+	switch f.BirthDateMode() {
+	case SignerFieldOmitted:
+		data.DataNaixement = ""
+	case SignerFieldOptional:
+		if data.DataNaixement != "" {
+			if err := ValidateBirthDate(data.DataNaixement); err != nil {
+				return data, err
+			}
+		}
+	default:
+		if err := ValidateBirthDate(data.DataNaixement); err != nil {
+			return data, err
+		}
+	}
+	return data, nil
+}
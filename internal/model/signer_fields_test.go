@@ -0,0 +1,62 @@
+package model
+
+import "testing"
+
+func TestSignerFields_Check(t *testing.T) {
+	full := Signant{Nom: "Joan", Cognom1: "Garcia", Cognom2: "Lopez", DataNaixement: "1990-05-15", NumIdentifica: "12345678Z"}
+	noExtras := full
+	noExtras.Cognom2, noExtras.DataNaixement = "", ""
+
+	tests := []struct {
+		name    string
+		fields  *SignerFields
+		in      Signant
+		want    Signant
+		wantErr bool
+	}{
+		{name: "defaults keep everything", in: full, want: full},
+		{name: "defaults require a birth date", in: noExtras, wantErr: true},
+		{name: "defaults reject an invalid birth date", in: Signant{DataNaixement: "1990-02-30"}, wantErr: true},
+		{
+			name:   "optional fields may be empty",
+			fields: &SignerFields{BirthDate: SignerFieldOptional, SecondSurname: SignerFieldOptional},
+			in:     noExtras,
+			want:   noExtras,
+		},
+		{
+			name:    "optional birth date is still validated",
+			fields:  &SignerFields{BirthDate: SignerFieldOptional},
+			in:      Signant{DataNaixement: "15/05/1990"},
+			wantErr: true,
+		},
+		{
+			name:   "omitted fields are cleared",
+			fields: &SignerFields{BirthDate: SignerFieldOmitted, SecondSurname: SignerFieldOmitted},
+			in:     full,
+			want:   noExtras,
+		},
+		{
+			name:    "required second surname",
+			fields:  &SignerFields{SecondSurname: SignerFieldRequired},
+			in:      Signant{Cognom2: " ", DataNaixement: "1990-05-15"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fields.Check(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Check(%+v) succeeded", tt.in)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Check: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Check = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -9,11 +9,19 @@ type ILPSignerXML struct {
 	Versio  string   `xml:"versio,attr"`
 	ILP     ILPInfo  `xml:"ILP"`
 	Signant Signant  `xml:"Signant"`
+	// Certificacio is present when a certifying agent signed on behalf of
+	// the citizen in Signant.
+	Certificacio *Certificacio `xml:"Certificacio,omitempty"`
 }
 
 type ILPInfo struct {
 	Titol string `xml:"Titol"`
 	Codi  string `xml:"Codi"`
+	// VersioText and ResumText are the Proposal.DocumentVersion and the
+	// FullText hash of the text signed, set only for a versioned proposal.
+	// Being signed, they tell a collector which text the signer saw.
+	VersioText int    `xml:"VersioText,omitempty"`
+	ResumText  string `xml:"ResumText,omitempty"`
 }
 
 type Signant struct {
@@ -25,14 +33,79 @@ type Signant struct {
 	NumIdentifica   string `xml:"NumeroIdentificador"`
 }
 
+// Certificacio identifies the certifying agent ("fedatari") who collected
+// a signature and how it was collected.
+type Certificacio struct {
+	Fedatari      string `xml:"Fedatari"`
+	NumIdentifica string `xml:"NumeroIdentificador"`
+	Origen        string `xml:"Origen"` // OrigenPresencial or OrigenPaper
+}
+
+const (
+	// OrigenPresencial: the citizen's data was typed in with them present.
+	OrigenPresencial = "presencial"
+	// OrigenPaper: the data was transcribed from a signed paper form.
+	OrigenPaper = "paper"
+)
+
 func GenerateILPXML(req *SignRequest, data Signant) ([]byte, error) {
-	obj := ILPSignerXML{
-		Versio: "1.0",
-		ILP: ILPInfo{
-			Titol: req.Proposal.Title,
-			Codi:  req.RequestID, // Using RequestID as Code if not specified
-		},
-		Signant: data,
+	return generateILPXML(req, data, nil)
+}
+
+// GenerateCertifiedILPXML is GenerateILPXML for a signature collected and
+// signed by a certifying agent.
+func GenerateCertifiedILPXML(req *SignRequest, data Signant, cert Certificacio) ([]byte, error) {
+	return generateILPXML(req, data, &cert)
+}
+
+// minimalSignerXML is ILPSignerXML for requests that declare signerFields,
+// which leaves out the optional fields the signer left empty.
+type minimalSignerXML struct {
+	XMLName      xml.Name       `xml:"SignaturaILP"`
+	Versio       string         `xml:"versio,attr"`
+	ILP          ILPInfo        `xml:"ILP"`
+	Signant      minimalSignant `xml:"Signant"`
+	Certificacio *Certificacio  `xml:"Certificacio,omitempty"`
+}
+
+type minimalSignant struct {
+	Nom             string `xml:"Nom"`
+	Cognom1         string `xml:"Cognom1"`
+	Cognom2         string `xml:"Cognom2,omitempty"`
+	DataNaixement   string `xml:"DataNaixement,omitempty"`
+	TipusIdentifica string `xml:"TipusIdentificador"`
+	NumIdentifica   string `xml:"NumeroIdentificador"`
+}
+
+func generateILPXML(req *SignRequest, data Signant, cert *Certificacio) ([]byte, error) {
+	info := ILPInfo{
+		Titol: req.Proposal.Title,
+		Codi:  req.RequestID, // Using RequestID as Code if not specified
+	}
+	if v := req.Proposal.DocumentVersion; v > 0 {
+		info.VersioText = v
+		info.ResumText = req.Proposal.FullText.SHA256
+	}
+	var obj any = ILPSignerXML{
+		Versio:       "1.0",
+		ILP:          info,
+		Signant:      data,
+		Certificacio: cert,
+	}
+	if f := req.SignerFields(); f != nil {
+		// Omitted fields are never sent, whatever the caller filled in.
+		if f.SecondSurnameMode() == SignerFieldOmitted {
+			data.Cognom2 = ""
+		}
+		if f.BirthDateMode() == SignerFieldOmitted {
+			data.DataNaixement = ""
+		}
+		obj = minimalSignerXML{
+			Versio:       "1.0",
+			ILP:          info,
+			Signant:      minimalSignant(data),
+			Certificacio: cert,
+		}
 	}
 
 	output, err := xml.MarshalIndent(obj, "", "  ")
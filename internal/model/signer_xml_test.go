@@ -166,3 +166,91 @@ func TestGenerateILPXML_SpecialCharacters(t *testing.T) {
 		t.Errorf("round-trip title = %q, want %q", got.ILP.Titol, title)
 	}
 }
+
+func TestGenerateCertifiedILPXML(t *testing.T) {
+	req := testRequest("Certified")
+
+	plain, err := GenerateILPXML(req, testSignant())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(plain), "Certificacio") {
+		t.Error("uncertified XML contains a Certificacio element")
+	}
+
+	cert := Certificacio{Fedatari: "Maria Puig", NumIdentifica: "87654321X", Origen: OrigenPaper}
+	out, err := GenerateCertifiedILPXML(req, testSignant(), cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got ILPSignerXML
+	if err := xml.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Certificacio == nil || *got.Certificacio != cert {
+		t.Errorf("Certificacio = %+v, want %+v", got.Certificacio, cert)
+	}
+	if got.Signant.NumIdentifica != testSignant().NumIdentifica {
+		t.Errorf("Signant.NumIdentifica = %q", got.Signant.NumIdentifica)
+	}
+}
+
+func TestGenerateILPXML_SignerFields(t *testing.T) {
+	req := testRequest("Minimal")
+	req.Policy = &SignPolicy{SignerFields: &SignerFields{BirthDate: SignerFieldOmitted, SecondSurname: SignerFieldOptional}}
+
+	data := testSignant()
+	data.Cognom2 = ""
+	out, err := GenerateILPXML(req, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(out)
+	// The omitted birth date is left out even though the caller set it.
+	for _, field := range []string{"Cognom2", "DataNaixement"} {
+		if strings.Contains(s, field) {
+			t.Errorf("output contains %s:\n%s", field, s)
+		}
+	}
+	var got ILPSignerXML
+	if err := xml.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Signant.Nom != "Joan" || got.Signant.NumIdentifica != "12345678A" {
+		t.Errorf("Signant = %+v", got.Signant)
+	}
+
+	// Without signerFields both elements are always present.
+	out, err = GenerateILPXML(testRequest("Default"), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "<Cognom2></Cognom2>") {
+		t.Errorf("default output lost the empty Cognom2 element:\n%s", out)
+	}
+}
+
+func TestGenerateILPXML_DocumentVersion(t *testing.T) {
+	req := testRequest("Versioned")
+	out, err := GenerateILPXML(req, testSignant())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), "VersioText") {
+		t.Errorf("unversioned request has a text version:\n%s", out)
+	}
+
+	req.Proposal.DocumentVersion = 2
+	req.Proposal.FullText.SHA256 = "c2hhMjU2"
+	out, err = GenerateILPXML(req, testSignant())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got ILPSignerXML
+	if err := xml.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.ILP.VersioText != 2 || got.ILP.ResumText != "c2hhMjU2" {
+		t.Errorf("ILP = %+v, want version 2 and the full text hash", got.ILP)
+	}
+}
@@ -0,0 +1,109 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// UnknownFields returns the paths of the members of the JSON request data
+// that SignRequest does not define, such as "proposal.video" or
+// "policies[0].language", sorted. The client ignores them, so a request
+// that has any was written for a newer schema and may not display fully.
+// A member whose name differs from a known one only in case is an error:
+// encoding/json would silently read it as the known one.
+func UnknownFields(data []byte) ([]string, error) {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	var out []string
+	if err := unknownFields(v, reflect.TypeFor[SignRequest](), "", &out); err != nil {
+		return nil, err
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// UnknownFieldsWarning is the warning shown for a request with the given
+// unknown fields.
+func UnknownFieldsWarning(fields []string) string {
+	return "This request uses features your version may not fully display (" + strings.Join(fields, ", ") + "). Update VocSign to see them."
+}
+
+func unknownFields(v any, t reflect.Type, path string, out *[]string) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	// Values of the wrong JSON type are left to json.Unmarshal to report.
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		fields := jsonFields(t)
+		for key, val := range obj {
+			f, ok := fields[key]
+			if ok {
+				if err := unknownFields(val, f.Type, fieldPath(path, key), out); err != nil {
+					return err
+				}
+				continue
+			}
+			for name := range fields {
+				if strings.EqualFold(name, key) {
+					return fmt.Errorf("member %q is ambiguous with %q", fieldPath(path, key), fieldPath(path, name))
+				}
+			}
+			*out = append(*out, fieldPath(path, key))
+		}
+	case reflect.Slice:
+		arr, ok := v.([]any)
+		if !ok {
+			return nil
+		}
+		for i, item := range arr {
+			if err := unknownFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), out); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		for key, val := range obj {
+			if err := unknownFields(val, t.Elem(), fieldPath(path, key), out); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jsonFields maps the JSON member names of struct type t to its fields.
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f
+	}
+	return fields
+}
+
+func fieldPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestUnknownFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "known fields only",
+			data: `{"version":"2.0","requestId":"r","proposal":{"title":"T","fullText":{"url":"u","sha256":"h"},"attachments":[{"title":"A","url":"u","sha256":"h"}]},"localizations":{"es":{"title":"T"}},"organizerSignature":{"format":"JWS","value":"v"}}`,
+		},
+		{
+			name: "unknown members at every level",
+			data: `{"version":"2.0","video":"v","proposal":{"title":"T","fullText":{"url":"u","sha256":"h","pages":3},"attachments":[{"title":"A"},{"title":"B","language":"es"}]},"localizations":{"es":{"title":"T","audio":"a"}},"policy":{"mode":"required","expires":"x"}}`,
+			want: []string{"localizations.es.audio", "policy.expires", "proposal.attachments[1].language", "proposal.fullText.pages", "video"},
+		},
+		{
+			name: "wrong JSON types are not reported",
+			data: `{"proposal":"text","policies":{"title":"P"}}`,
+		},
+		{
+			name:    "member differing only in case",
+			data:    `{"proposal":{"title":"Real","TITLE":"Other"}}`,
+			wantErr: `member "proposal.TITLE" is ambiguous with "proposal.title"`,
+		},
+		{
+			name:    "invalid JSON",
+			data:    `{"version":`,
+			wantErr: "unexpected end of JSON input",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnknownFields([]byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UnknownFields: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("UnknownFields = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnknownFieldsOfEncodedRequest(t *testing.T) {
+	r := validSignRequest()
+	r.Version = Version2
+	r.Policy = &SignPolicy{Mode: "required", Acknowledgement: &Acknowledgement{Text: "ok"}}
+	r.ContactRequest = &ContactRequest{Fields: []string{ContactEmail}, Purpose: "p", RetentionDays: 1}
+	r.Policies = []Document{{Title: "Privacy notice"}}
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := UnknownFields(data)
+	if err != nil || len(got) != 0 {
+		t.Fatalf("UnknownFields of an encoded request = %q, %v", got, err)
+	}
+}
@@ -5,12 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"strings"
 	"time"
 )
 
 func (r *SignRequest) Validate() error {
-	if r.Version != "1.0" {
-		return errors.New("unsupported version: " + r.Version)
+	if err := CheckVersion(r.Version); err != nil {
+		return err
 	}
 	if r.RequestID == "" {
 		return errors.New("missing requestId")
@@ -52,6 +53,9 @@ func (r *SignRequest) Validate() error {
 	if len(hashBytes) != 32 {
 		return errors.New("proposal fullText sha256 must be 32 bytes")
 	}
+	if r.Proposal.DocumentVersion < 0 {
+		return errors.New("proposal documentVersion must not be negative")
+	}
 
 	u, err := url.Parse(r.Callback.URL)
 	if err != nil {
@@ -77,6 +81,20 @@ func (r *SignRequest) Validate() error {
 	if jwksURL.Scheme != "https" && jwksURL.Hostname() != "localhost" && jwksURL.Hostname() != "127.0.0.1" {
 		return errors.New("organizer jwkSetUrl must be https")
 	}
+	if r.Organizer.CampaignIndexURL != "" {
+		indexURL, err := url.Parse(r.Organizer.CampaignIndexURL)
+		if err != nil {
+			return fmt.Errorf("invalid organizer campaignIndexUrl: %w", err)
+		}
+		if indexURL.Scheme != "https" && indexURL.Hostname() != "localhost" && indexURL.Hostname() != "127.0.0.1" {
+			return errors.New("organizer campaignIndexUrl must be https")
+		}
+	}
+	for _, kid := range r.Organizer.PreviousKIDs {
+		if kid == "" {
+			return errors.New("empty organizer previousKids entry")
+		}
+	}
 
 	if r.OrganizerSignature == nil {
 		return errors.New("missing organizerSignature")
@@ -88,6 +106,109 @@ func (r *SignRequest) Validate() error {
 		return errors.New("missing organizerSignature value")
 	}
 
+	if p := r.Policy; p != nil && p.OID != "" {
+		if _, err := p.Digest(); err != nil {
+			return fmt.Errorf("invalid policy: %w", err)
+		}
+	}
+
+	if p := r.Policy; p != nil && p.Acknowledgement != nil {
+		if strings.TrimSpace(r.Proposal.LegalStatement) == "" {
+			return errors.New("policy acknowledgement requires a proposal legalStatement")
+		}
+		if len(p.Acknowledgement.Text) > MaxLabelLength {
+			return errors.New("policy acknowledgement text too long")
+		}
+	}
+
+	if p := r.Policy; p != nil && p.SignerFields != nil {
+		if err := p.SignerFields.validate(); err != nil {
+			return err
+		}
+	}
+
+	if d := r.DuplicateCheck; d != nil {
+		dupURL, err := url.Parse(d.URL)
+		if err != nil {
+			return fmt.Errorf("invalid duplicateCheck url: %w", err)
+		}
+		if dupURL.Scheme != "https" && dupURL.Hostname() != "localhost" && dupURL.Hostname() != "127.0.0.1" {
+			return errors.New("duplicateCheck url must be https")
+		}
+		if d.Salt == "" {
+			return errors.New("missing duplicateCheck salt")
+		}
+		if d.PrefixLength != 0 && (d.PrefixLength < MinDuplicatePrefixLength || d.PrefixLength > MaxDuplicatePrefixLength) {
+			return fmt.Errorf("duplicateCheck prefixLength must be between %d and %d", MinDuplicatePrefixLength, MaxDuplicatePrefixLength)
+		}
+	}
+
+	if t := r.TransparencyLog; t != nil {
+		logURL, err := url.Parse(t.URL)
+		if err != nil {
+			return fmt.Errorf("invalid transparencyLog url: %w", err)
+		}
+		if logURL.Scheme != "https" && logURL.Hostname() != "localhost" && logURL.Hostname() != "127.0.0.1" {
+			return errors.New("transparencyLog url must be https")
+		}
+	}
+
+	if a := r.AuditSync; a != nil {
+		syncURL, err := url.Parse(a.URL)
+		if err != nil {
+			return fmt.Errorf("invalid auditSync url: %w", err)
+		}
+		if syncURL.Scheme != "https" && syncURL.Hostname() != "localhost" && syncURL.Hostname() != "127.0.0.1" {
+			return errors.New("auditSync url must be https")
+		}
+	}
+
+	if p := r.PublicStats; p != nil {
+		statsURL, err := url.Parse(p.URL)
+		if err != nil {
+			return fmt.Errorf("invalid publicStats url: %w", err)
+		}
+		if statsURL.Scheme != "https" && statsURL.Hostname() != "localhost" && statsURL.Hostname() != "127.0.0.1" {
+			return errors.New("publicStats url must be https")
+		}
+		if p.Days < 0 || p.Days > MaxPublicStatsDays {
+			return fmt.Errorf("publicStats days must be between 1 and %d", MaxPublicStatsDays)
+		}
+	}
+
+	if l := r.ReceiptLookup; l != nil {
+		lookupURL, err := url.Parse(l.URL)
+		if err != nil {
+			return fmt.Errorf("invalid receiptLookup url: %w", err)
+		}
+		if lookupURL.Scheme != "https" && lookupURL.Hostname() != "localhost" && lookupURL.Hostname() != "127.0.0.1" {
+			return errors.New("receiptLookup url must be https")
+		}
+	}
+
+	if err := r.validateV2(); err != nil {
+		return err
+	}
+
+	if c := r.ContactRequest; c != nil {
+		if err := c.validate(); err != nil {
+			return err
+		}
+	}
+
+	if t := r.Translations; t != nil {
+		trURL, err := url.Parse(t.URL)
+		if err != nil {
+			return fmt.Errorf("invalid translations url: %w", err)
+		}
+		if trURL.Scheme != "https" && trURL.Scheme != "ipfs" && trURL.Hostname() != "localhost" && trURL.Hostname() != "127.0.0.1" {
+			return errors.New("translations url must be https or ipfs")
+		}
+		if t.SHA256 == "" {
+			return errors.New("missing translations sha256")
+		}
+	}
+
 	return nil
 }
 
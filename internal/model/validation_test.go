@@ -63,13 +63,13 @@ func TestValidate(t *testing.T) {
 		// --- version ---
 		{
 			name:    "wrong version",
-			modify:  func(r *SignRequest) { r.Version = "2.0" },
-			wantErr: "unsupported version",
+			modify:  func(r *SignRequest) { r.Version = "3.0" },
+			wantErr: "unsupported request version",
 		},
 		{
 			name:    "empty version",
 			modify:  func(r *SignRequest) { r.Version = "" },
-			wantErr: "unsupported version",
+			wantErr: "unsupported request version",
 		},
 
 		// --- requestId ---
@@ -171,6 +171,11 @@ func TestValidate(t *testing.T) {
 			},
 			wantErr: "invalid proposal fullText sha256 base64",
 		},
+		{
+			name:    "negative proposal documentVersion",
+			modify:  func(r *SignRequest) { r.Proposal.DocumentVersion = -1 },
+			wantErr: "proposal documentVersion must not be negative",
+		},
 		{
 			name: "proposal fullText sha256 wrong length (31 bytes)",
 			modify: func(r *SignRequest) {
@@ -251,6 +256,23 @@ func TestValidate(t *testing.T) {
 			wantErr: "",
 		},
 
+		{
+			name:    "organizer previousKids valid",
+			modify:  func(r *SignRequest) { r.Organizer.PreviousKIDs = []string{"key-0"} },
+			wantErr: "",
+		},
+		{
+			name:    "organizer previousKids empty entry",
+			modify:  func(r *SignRequest) { r.Organizer.PreviousKIDs = []string{"key-0", ""} },
+			wantErr: "empty organizer previousKids entry",
+		},
+
+		{
+			name:    "organizer campaignIndexUrl http on remote host",
+			modify:  func(r *SignRequest) { r.Organizer.CampaignIndexURL = "http://example.com/campaigns.json" },
+			wantErr: "organizer campaignIndexUrl must be https",
+		},
+
 		// --- organizerSignature ---
 		{
 			name:    "missing organizerSignature (nil)",
@@ -278,6 +300,278 @@ func TestValidate(t *testing.T) {
 			},
 			wantErr: "missing organizerSignature value",
 		},
+
+		// --- duplicateCheck ---
+		{
+			name: "duplicateCheck valid",
+			modify: func(r *SignRequest) {
+				r.DuplicateCheck = &DuplicateCheck{URL: "https://example.com/dup", Salt: "s", PrefixLength: 6}
+			},
+			wantErr: "",
+		},
+		{
+			name: "duplicateCheck http on remote host",
+			modify: func(r *SignRequest) {
+				r.DuplicateCheck = &DuplicateCheck{URL: "http://example.com/dup", Salt: "s"}
+			},
+			wantErr: "duplicateCheck url must be https",
+		},
+		{
+			name: "duplicateCheck missing salt",
+			modify: func(r *SignRequest) {
+				r.DuplicateCheck = &DuplicateCheck{URL: "https://example.com/dup"}
+			},
+			wantErr: "missing duplicateCheck salt",
+		},
+		{
+			name: "duplicateCheck prefix too long",
+			modify: func(r *SignRequest) {
+				r.DuplicateCheck = &DuplicateCheck{URL: "https://example.com/dup", Salt: "s", PrefixLength: 64}
+			},
+			wantErr: "duplicateCheck prefixLength must be between",
+		},
+
+		// --- transparencyLog ---
+		{
+			name:    "transparencyLog valid",
+			modify:  func(r *SignRequest) { r.TransparencyLog = &TransparencyLog{URL: "https://example.com/log"} },
+			wantErr: "",
+		},
+		{
+			name:    "transparencyLog http on remote host",
+			modify:  func(r *SignRequest) { r.TransparencyLog = &TransparencyLog{URL: "http://example.com/log"} },
+			wantErr: "transparencyLog url must be https",
+		},
+
+		// --- auditSync ---
+		{
+			name:    "auditSync valid",
+			modify:  func(r *SignRequest) { r.AuditSync = &AuditSync{URL: "https://example.com/audit"} },
+			wantErr: "",
+		},
+		{
+			name:    "auditSync http on remote host",
+			modify:  func(r *SignRequest) { r.AuditSync = &AuditSync{URL: "http://example.com/audit"} },
+			wantErr: "auditSync url must be https",
+		},
+
+		// --- publicStats ---
+		{
+			name:    "publicStats valid",
+			modify:  func(r *SignRequest) { r.PublicStats = &PublicStats{URL: "https://example.com/stats", Days: 90} },
+			wantErr: "",
+		},
+		{
+			name:    "publicStats http on remote host",
+			modify:  func(r *SignRequest) { r.PublicStats = &PublicStats{URL: "http://example.com/stats"} },
+			wantErr: "publicStats url must be https",
+		},
+		{
+			name:    "publicStats too many days",
+			modify:  func(r *SignRequest) { r.PublicStats = &PublicStats{URL: "https://example.com/stats", Days: 1000} },
+			wantErr: "publicStats days must be between",
+		},
+
+		// --- receiptLookup ---
+		{
+			name:    "receiptLookup valid",
+			modify:  func(r *SignRequest) { r.ReceiptLookup = &ReceiptLookup{URL: "https://example.com/receipts"} },
+			wantErr: "",
+		},
+		{
+			name:    "receiptLookup http on remote host",
+			modify:  func(r *SignRequest) { r.ReceiptLookup = &ReceiptLookup{URL: "http://example.com/receipts"} },
+			wantErr: "receiptLookup url must be https",
+		},
+
+		// --- contactRequest ---
+		{
+			name: "contactRequest valid",
+			modify: func(r *SignRequest) {
+				r.ContactRequest = &ContactRequest{Fields: []string{ContactEmail, ContactPhone}, Purpose: "Campaign news", RetentionDays: 365}
+			},
+			wantErr: "",
+		},
+		{
+			name: "contactRequest unknown field",
+			modify: func(r *SignRequest) {
+				r.ContactRequest = &ContactRequest{Fields: []string{"address"}, Purpose: "Campaign news", RetentionDays: 365}
+			},
+			wantErr: "unknown contactRequest field",
+		},
+		{
+			name: "contactRequest missing purpose",
+			modify: func(r *SignRequest) {
+				r.ContactRequest = &ContactRequest{Fields: []string{ContactEmail}, RetentionDays: 365}
+			},
+			wantErr: "missing contactRequest purpose",
+		},
+		{
+			name: "contactRequest without retention",
+			modify: func(r *SignRequest) {
+				r.ContactRequest = &ContactRequest{Fields: []string{ContactEmail}, Purpose: "Campaign news"}
+			},
+			wantErr: "contactRequest retentionDays must be between",
+		},
+
+		// --- policy ---
+		{
+			name: "policy sha256",
+			modify: func(r *SignRequest) {
+				r.Policy = &SignPolicy{OID: "2.16.724.1.3.1.1.2.1.9", HashAlg: "SHA-256", Hash: "Gvj/Kk/Jc+j8+j8+j8+j8+j8+j8+j8+j8+j8+j8+j88="}
+			},
+			wantErr: "",
+		},
+		{
+			name: "policy acknowledgement with legal statement",
+			modify: func(r *SignRequest) {
+				r.Proposal.LegalStatement = "I support this initiative."
+				r.Policy = &SignPolicy{Acknowledgement: &Acknowledgement{Initials: true}}
+			},
+			wantErr: "",
+		},
+		{
+			name: "policy acknowledgement without legal statement",
+			modify: func(r *SignRequest) {
+				r.Policy = &SignPolicy{Acknowledgement: &Acknowledgement{}}
+			},
+			wantErr: "policy acknowledgement requires a proposal legalStatement",
+		},
+		{
+			name: "policy unsupported hash algorithm",
+			modify: func(r *SignRequest) {
+				r.Policy = &SignPolicy{OID: "2.16.724.1.3.1.1.2.1.9", HashAlg: "SHA-1", Hash: "Gvj/Kk/Jc+j8+j8+j8+j8+j8+j8+j8+j8+j8+j8+j88="}
+			},
+			wantErr: "unsupported policy hash algorithm",
+		},
+		{
+			name: "policy signerFields valid",
+			modify: func(r *SignRequest) {
+				r.Policy = &SignPolicy{SignerFields: &SignerFields{BirthDate: SignerFieldOmitted, SecondSurname: SignerFieldRequired}}
+			},
+			wantErr: "",
+		},
+		{
+			name: "policy signerFields unknown mode",
+			modify: func(r *SignRequest) {
+				r.Policy = &SignPolicy{SignerFields: &SignerFields{BirthDate: "hidden"}}
+			},
+			wantErr: `invalid signerFields birthDate "hidden"`,
+		},
+
+		// --- translations ---
+		{
+			name: "translations valid",
+			modify: func(r *SignRequest) {
+				r.Translations = &Translations{URL: "https://example.com/labels.json", SHA256: "abc="}
+			},
+			wantErr: "",
+		},
+		{
+			name: "translations ipfs",
+			modify: func(r *SignRequest) {
+				r.Translations = &Translations{URL: "ipfs://bafylabels/ca.json", SHA256: "abc="}
+			},
+			wantErr: "",
+		},
+		{
+			name: "translations http on remote host",
+			modify: func(r *SignRequest) {
+				r.Translations = &Translations{URL: "http://example.com/labels.json", SHA256: "abc="}
+			},
+			wantErr: "translations url must be https",
+		},
+		{
+			name:    "translations missing hash",
+			modify:  func(r *SignRequest) { r.Translations = &Translations{URL: "https://example.com/labels.json"} },
+			wantErr: "missing translations sha256",
+		},
+
+		// --- version 2.0 ---
+		{
+			name:    "version 2.0",
+			modify:  func(r *SignRequest) { r.Version = Version2 },
+			wantErr: "",
+		},
+		{
+			name: "version 2.0 with attachments, localizations and policies",
+			modify: func(r *SignRequest) {
+				r.Version = Version2
+				r.Proposal.Attachments = []Document{{Title: "Economic report", URL: "ipfs://bafyreport", SHA256: r.Proposal.FullText.SHA256}}
+				r.Policies = []Document{{Title: "Privacy notice", URL: "https://example.com/privacy.pdf", SHA256: r.Proposal.FullText.SHA256}}
+				r.Localizations = map[string]Localization{"es": {Title: "Propuesta"}, "pt-BR": {Summary: "Resumo"}}
+			},
+			wantErr: "",
+		},
+		{
+			name: "attachments in a 1.0 request",
+			modify: func(r *SignRequest) {
+				r.Proposal.Attachments = []Document{{Title: "Annex", URL: "https://example.com/a.pdf", SHA256: r.Proposal.FullText.SHA256}}
+			},
+			wantErr: "proposal attachments require version 2.0",
+		},
+		{
+			name:    "localizations in a 1.0 request",
+			modify:  func(r *SignRequest) { r.Localizations = map[string]Localization{"es": {Title: "Propuesta"}} },
+			wantErr: "localizations require version 2.0",
+		},
+		{
+			name: "policies in a 1.0 request",
+			modify: func(r *SignRequest) {
+				r.Policies = []Document{{Title: "Privacy notice", URL: "https://example.com/p.pdf", SHA256: r.Proposal.FullText.SHA256}}
+			},
+			wantErr: "policies require version 2.0",
+		},
+		{
+			name: "attachment without title",
+			modify: func(r *SignRequest) {
+				r.Version = Version2
+				r.Proposal.Attachments = []Document{{URL: "https://example.com/a.pdf", SHA256: r.Proposal.FullText.SHA256}}
+			},
+			wantErr: "missing proposal attachment title",
+		},
+		{
+			name: "attachment http on remote host",
+			modify: func(r *SignRequest) {
+				r.Version = Version2
+				r.Proposal.Attachments = []Document{{Title: "Annex", URL: "http://example.com/a.pdf", SHA256: r.Proposal.FullText.SHA256}}
+			},
+			wantErr: "proposal attachment url must be https or ipfs",
+		},
+		{
+			name: "policy with short hash",
+			modify: func(r *SignRequest) {
+				r.Version = Version2
+				r.Policies = []Document{{Title: "Privacy notice", URL: "https://example.com/p.pdf", SHA256: "abc="}}
+			},
+			wantErr: "policy sha256 must be 32 bytes",
+		},
+		{
+			name: "too many policies",
+			modify: func(r *SignRequest) {
+				r.Version = Version2
+				for range MaxDocuments + 1 {
+					r.Policies = append(r.Policies, Document{Title: "Policy", URL: "https://example.com/p.pdf", SHA256: r.Proposal.FullText.SHA256})
+				}
+			},
+			wantErr: "at most 20 policies",
+		},
+		{
+			name: "localization with invalid language",
+			modify: func(r *SignRequest) {
+				r.Version = Version2
+				r.Localizations = map[string]Localization{"spanish!": {Title: "Propuesta"}}
+			},
+			wantErr: "invalid localization language",
+		},
+		{
+			name: "empty localization",
+			modify: func(r *SignRequest) {
+				r.Version = Version2
+				r.Localizations = map[string]Localization{"es": {}}
+			},
+			wantErr: "empty localization",
+		},
 	}
 
 	for _, tc := range tests {
@@ -0,0 +1,194 @@
+package model
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Versions of the SignRequest schema. 2.0 adds proposal attachments,
+// localized proposal texts and the promoter's policy documents; a 1.0
+// request must not use them.
+const (
+	Version1 = "1.0"
+	Version2 = "2.0"
+)
+
+// SupportedVersions are the request versions this client parses, newest
+// first.
+var SupportedVersions = []string{Version2, Version1}
+
+// ErrUnsupportedVersion is returned for requests of a schema version this
+// client does not know, typically one published after its release.
+var ErrUnsupportedVersion = errors.New("unsupported request version")
+
+// CheckVersion returns ErrUnsupportedVersion unless v is supported.
+func CheckVersion(v string) error {
+	if !slices.Contains(SupportedVersions, v) {
+		return fmt.Errorf("%w: %q", ErrUnsupportedVersion, v)
+	}
+	return nil
+}
+
+// VersionProfile is the profile URI that identifies a request version in
+// the Accept-Profile and Content-Profile headers.
+func VersionProfile(v string) string {
+	return "urn:vocsign:sign-request:" + v
+}
+
+// AcceptProfile is the Accept-Profile header sent when fetching a request,
+// listing SupportedVersions in order of preference.
+func AcceptProfile() string {
+	profiles := make([]string, len(SupportedVersions))
+	for i, v := range SupportedVersions {
+		profiles[i] = "<" + VersionProfile(v) + ">"
+	}
+	return strings.Join(profiles, ", ")
+}
+
+// Document is a file referenced by a 2.0 request and pinned by the base64
+// SHA-256 of its content, like the proposal full text.
+type Document struct {
+	Title     string `json:"title"`
+	URL       string `json:"url"`
+	SHA256    string `json:"sha256"`
+	MediaType string `json:"mediaType,omitempty"`
+}
+
+// Localization is the proposal title and summary in another language, shown
+// instead of the original at the signer's choice. The legal statement and
+// the signed document are always those of the original.
+type Localization struct {
+	Title   string `json:"title,omitempty"`
+	Summary string `json:"summary,omitempty"`
+}
+
+const (
+	// MaxDocuments bounds the attachments and, separately, the policies of a
+	// request.
+	MaxDocuments = 20
+	// MaxLocalizations bounds the languages of a request.
+	MaxLocalizations = 32
+)
+
+// Documents returns the proposal attachments followed by the policy
+// documents, every document besides the full text that signing verifies.
+func (r *SignRequest) Documents() []Document {
+	return append(slices.Clone(r.Proposal.Attachments), r.Policies...)
+}
+
+// Languages returns the languages the proposal is localized in, sorted.
+func (r *SignRequest) Languages() []string {
+	langs := make([]string, 0, len(r.Localizations))
+	for lang := range r.Localizations {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Localized returns the proposal title and summary in lang, falling back to
+// the original for an unknown language or a text the localization omits.
+func (r *SignRequest) Localized(lang string) (title, summary string) {
+	title, summary = r.Proposal.Title, r.Proposal.Summary
+	if l, ok := r.Localizations[lang]; ok {
+		if l.Title != "" {
+			title = l.Title
+		}
+		if l.Summary != "" {
+			summary = l.Summary
+		}
+	}
+	return title, summary
+}
+
+// validateV2 checks the fields a 2.0 request adds, and that a 1.0 request
+// does not use them.
+func (r *SignRequest) validateV2() error {
+	if r.Version == Version1 {
+		switch {
+		case len(r.Proposal.Attachments) > 0:
+			return errors.New("proposal attachments require version 2.0")
+		case len(r.Localizations) > 0:
+			return errors.New("localizations require version 2.0")
+		case len(r.Policies) > 0:
+			return errors.New("policies require version 2.0")
+		}
+		return nil
+	}
+	if len(r.Proposal.Attachments) > MaxDocuments {
+		return fmt.Errorf("at most %d proposal attachments", MaxDocuments)
+	}
+	for _, d := range r.Proposal.Attachments {
+		if err := d.validate("proposal attachment"); err != nil {
+			return err
+		}
+	}
+	if len(r.Policies) > MaxDocuments {
+		return fmt.Errorf("at most %d policies", MaxDocuments)
+	}
+	for _, d := range r.Policies {
+		if err := d.validate("policy"); err != nil {
+			return err
+		}
+	}
+	if len(r.Localizations) > MaxLocalizations {
+		return fmt.Errorf("at most %d localizations", MaxLocalizations)
+	}
+	for lang, l := range r.Localizations {
+		if !validLanguageTag(lang) {
+			return fmt.Errorf("invalid localization language %q", lang)
+		}
+		if l.Title == "" && l.Summary == "" {
+			return fmt.Errorf("empty localization %q", lang)
+		}
+	}
+	return nil
+}
+
+func (d *Document) validate(what string) error {
+	if strings.TrimSpace(d.Title) == "" {
+		return fmt.Errorf("missing %s title", what)
+	}
+	u, err := url.Parse(d.URL)
+	if err != nil {
+		return fmt.Errorf("invalid %s url: %w", what, err)
+	}
+	if u.Scheme != "https" && u.Scheme != "ipfs" && u.Hostname() != "localhost" && u.Hostname() != "127.0.0.1" {
+		return fmt.Errorf("%s url must be https or ipfs", what)
+	}
+	sum, err := base64.StdEncoding.DecodeString(d.SHA256)
+	if err != nil {
+		return fmt.Errorf("invalid %s sha256 base64: %w", what, err)
+	}
+	if len(sum) != 32 {
+		return fmt.Errorf("%s sha256 must be 32 bytes", what)
+	}
+	return nil
+}
+
+// validLanguageTag reports whether s looks like a BCP 47 tag such as "ca",
+// "es" or "pt-BR": alphanumeric subtags of at most eight characters
+// separated by hyphens, starting with a two or three letter language.
+func validLanguageTag(s string) bool {
+	subtags := strings.Split(s, "-")
+	if n := len(subtags[0]); n < 2 || n > 3 {
+		return false
+	}
+	for i, sub := range subtags {
+		if sub == "" || len(sub) > 8 {
+			return false
+		}
+		for _, c := range sub {
+			letter := 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+			if !letter && (i == 0 || c < '0' || c > '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestCheckVersion(t *testing.T) {
+	for _, v := range []string{Version1, Version2} {
+		if err := CheckVersion(v); err != nil {
+			t.Errorf("CheckVersion(%q) = %v", v, err)
+		}
+	}
+	for _, v := range []string{"", "1", "3.0"} {
+		if err := CheckVersion(v); !errors.Is(err, ErrUnsupportedVersion) {
+			t.Errorf("CheckVersion(%q) = %v, want ErrUnsupportedVersion", v, err)
+		}
+	}
+}
+
+func TestAcceptProfile(t *testing.T) {
+	want := "<urn:vocsign:sign-request:2.0>, <urn:vocsign:sign-request:1.0>"
+	if got := AcceptProfile(); got != want {
+		t.Fatalf("AcceptProfile() = %q, want %q", got, want)
+	}
+}
+
+func TestLocalized(t *testing.T) {
+	r := validSignRequest()
+	r.Proposal.Summary = "Original summary"
+	r.Localizations = map[string]Localization{
+		"es": {Title: "Propuesta", Summary: "Resumen"},
+		"en": {Title: "Proposal"},
+	}
+	tests := []struct {
+		lang, title, summary string
+	}{
+		{"", "Test proposal", "Original summary"},
+		{"es", "Propuesta", "Resumen"},
+		{"en", "Proposal", "Original summary"},
+		{"fr", "Test proposal", "Original summary"},
+	}
+	for _, tc := range tests {
+		title, summary := r.Localized(tc.lang)
+		if title != tc.title || summary != tc.summary {
+			t.Errorf("Localized(%q) = %q, %q; want %q, %q", tc.lang, title, summary, tc.title, tc.summary)
+		}
+	}
+	if got := r.Languages(); !slices.Equal(got, []string{"en", "es"}) {
+		t.Errorf("Languages() = %v", got)
+	}
+}
+
+func TestDocuments(t *testing.T) {
+	r := validSignRequest()
+	r.Proposal.Attachments = []Document{{Title: "Annex"}}
+	r.Policies = []Document{{Title: "Privacy notice"}}
+	docs := r.Documents()
+	if len(docs) != 2 || docs[0].Title != "Annex" || docs[1].Title != "Privacy notice" {
+		t.Fatalf("Documents() = %+v", docs)
+	}
+	docs[0].Title = "changed"
+	if r.Proposal.Attachments[0].Title != "Annex" {
+		t.Fatal("Documents() aliases the attachments")
+	}
+}
+
+func TestValidLanguageTag(t *testing.T) {
+	for _, s := range []string{"ca", "es", "pt-BR", "zh-Hant-TW", "ast", "es-419"} {
+		if !validLanguageTag(s) {
+			t.Errorf("validLanguageTag(%q) = false", s)
+		}
+	}
+	for _, s := range []string{"", "e", "catalan", "es-", "-es", "e1", "es_ES", "es-toolongsubtag"} {
+		if validLanguageTag(s) {
+			t.Errorf("validLanguageTag(%q) = true", s)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package net
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+)
+
+// AuditBundle is a certifying agent's audit manifest with the agent's CAdES
+// detached signature over its exact bytes. It is both the body uploaded to
+// a request's auditSync endpoint and the format of exported audit files.
+type AuditBundle struct {
+	Manifest  []byte `json:"manifest"`  // storage.AuditManifest as JSON
+	Signature []byte `json:"signature"` // DER CAdES detached signature
+}
+
+// AuditSyncReceipt is the collector's answer to an upload. Duplicates are
+// entries it already held from an earlier upload.
+type AuditSyncReceipt struct {
+	Accepted   int `json:"accepted"`
+	Duplicates int `json:"duplicates"`
+}
+
+// SyncAudit uploads bundle to a collector's audit sync endpoint.
+func SyncAudit(ctx context.Context, syncURL string, bundle *AuditBundle) (*AuditSyncReceipt, error) {
+	u, err := url.Parse(syncURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid audit sync url: %w", err)
+	}
+	if !isAllowedURL(u) {
+		return nil, fmt.Errorf("audit sync url must be https")
+	}
+
+	body, err := json.Marshal(bundle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit bundle: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", syncURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := newClient(60 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("audit sync failed: %w", errcode.Network(err))
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, errcode.Errorf(errcode.HTTPStatus, "unexpected status code: %d", resp.StatusCode)
+	}
+
+	data, err := readAll(resp.Body, maxResponseBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit sync body: %w", err)
+	}
+	var receipt AuditSyncReceipt
+	if err := json.Unmarshal(data, &receipt); err != nil {
+		return nil, errcode.Errorf(errcode.InvalidResponse, "failed to decode audit sync receipt: %w", err)
+	}
+	return &receipt, nil
+}
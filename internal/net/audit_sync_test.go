@@ -0,0 +1,52 @@
+package net
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+)
+
+func TestSyncAudit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var bundle AuditBundle
+		if err := json.NewDecoder(r.Body).Decode(&bundle); err != nil || string(bundle.Manifest) != "manifest" {
+			http.Error(w, "bad bundle", http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/garbage":
+			_, _ = w.Write([]byte("not json"))
+		default:
+			_, _ = w.Write([]byte(`{"accepted":2,"duplicates":1}`))
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		url      string
+		bundle   AuditBundle
+		wantCode errcode.Code
+		wantErr  bool
+	}{
+		{"accepted", srv.URL + "/audit", AuditBundle{Manifest: []byte("manifest")}, errcode.Unknown, false},
+		{"rejected", srv.URL + "/audit", AuditBundle{Manifest: []byte("other")}, errcode.HTTPStatus, true},
+		{"invalid receipt", srv.URL + "/garbage", AuditBundle{Manifest: []byte("manifest")}, errcode.InvalidResponse, true},
+		{"plain http", "http://collector.example/audit", AuditBundle{}, errcode.Unknown, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receipt, err := SyncAudit(context.Background(), tt.url, &tt.bundle)
+			if (err != nil) != tt.wantErr || errcode.Of(err) != tt.wantCode {
+				t.Fatalf("err = %v (code %q), want error %v with code %q", err, errcode.Of(err), tt.wantErr, tt.wantCode)
+			}
+			if err == nil && (receipt.Accepted != 2 || receipt.Duplicates != 1) {
+				t.Errorf("receipt = %+v", receipt)
+			}
+		})
+	}
+}
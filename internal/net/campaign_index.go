@@ -0,0 +1,59 @@
+package net
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+)
+
+// CampaignIndex is the document served at an organizer's campaignIndexUrl.
+type CampaignIndex struct {
+	Requests []string `json:"requests"`
+}
+
+const maxCampaignIndexEntries = 50
+
+// FetchCampaignIndex returns the request URLs an organizer currently lists.
+// Each URL must still be fetched and verified individually.
+func FetchCampaignIndex(ctx context.Context, indexURL string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", indexURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	client := newClient(10 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("campaign index fetch failed: %w", errcode.Network(err))
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errcode.Errorf(errcode.HTTPStatus, "unexpected status code: %d", resp.StatusCode)
+	}
+	body, err := readAll(resp.Body, maxResponseBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read campaign index: %w", err)
+	}
+	var index CampaignIndex
+	if err := json.Unmarshal(body, &index); err != nil {
+		return nil, errcode.Errorf(errcode.InvalidResponse, "failed to decode campaign index: %w", err)
+	}
+
+	var out []string
+	for _, raw := range index.Requests {
+		u, err := url.Parse(raw)
+		if err != nil || !isAllowedURL(u) {
+			continue
+		}
+		out = append(out, raw)
+		if len(out) == maxCampaignIndexEntries {
+			break
+		}
+	}
+	return out, nil
+}
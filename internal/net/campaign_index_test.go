@@ -0,0 +1,45 @@
+package net
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetchCampaignIndex(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"requests": [
+			"https://example.com/request/1",
+			"http://example.com/request/2",
+			"http://localhost:8080/request/3",
+			"::not a url"
+		]}`))
+	}))
+	defer srv.Close()
+
+	got, err := FetchCampaignIndex(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("FetchCampaignIndex: %v", err)
+	}
+	want := []string{"https://example.com/request/1", "http://localhost:8080/request/3"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestFetchCampaignIndex_BadStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	if _, err := FetchCampaignIndex(context.Background(), srv.URL); err == nil {
+		t.Fatal("expected error for 404")
+	}
+}
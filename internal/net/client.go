@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"net/url"
 	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
 )
 
 // Response body size limits.
@@ -27,10 +29,10 @@ func newClient(timeout time.Duration) *http.Client {
 // checkRedirect ensures every redirect target uses HTTPS or targets localhost.
 func checkRedirect(req *http.Request, via []*http.Request) error {
 	if len(via) >= 10 {
-		return fmt.Errorf("stopped after 10 redirects")
+		return errcode.Errorf(errcode.RedirectBlocked, "stopped after 10 redirects")
 	}
 	if !isAllowedURL(req.URL) {
-		return fmt.Errorf("redirect to disallowed URL: %s", req.URL.Redacted())
+		return errcode.Errorf(errcode.RedirectBlocked, "redirect to disallowed URL: %s", req.URL.Redacted())
 	}
 	return nil
 }
@@ -53,7 +55,22 @@ func readAll(r io.Reader, limit int64) ([]byte, error) {
 		return nil, err
 	}
 	if int64(len(data)) > limit {
-		return nil, fmt.Errorf("response body exceeds %d bytes", limit)
+		return nil, errcode.Errorf(errcode.ResponseTooLarge, "response body exceeds %d bytes", limit)
 	}
 	return data, nil
 }
+
+// SetProxy sends every HTTP(S) connection of the process through the proxy
+// at proxyURL instead of the one in the environment.
+func SetProxy(proxyURL string) error {
+	u, err := url.Parse(proxyURL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("invalid proxy %q", proxyURL)
+	}
+	tr, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("cannot set a proxy on %T", http.DefaultTransport)
+	}
+	tr.Proxy = http.ProxyURL(u)
+	return nil
+}
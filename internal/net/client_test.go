@@ -137,3 +137,21 @@ func TestNewClient(t *testing.T) {
 		t.Errorf("Expected timeout %v, got %v", timeout, client.Timeout)
 	}
 }
+
+func TestSetProxy(t *testing.T) {
+	tr := http.DefaultTransport.(*http.Transport)
+	prev := tr.Proxy
+	t.Cleanup(func() { tr.Proxy = prev })
+
+	if err := SetProxy("socks5://proxy.example:1080"); err == nil {
+		t.Fatal("expected error for a non-http proxy")
+	}
+	if err := SetProxy("http://proxy.example:3128"); err != nil {
+		t.Fatalf("SetProxy: %v", err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://vocdoni.io/request/1", nil)
+	u, err := tr.Proxy(req)
+	if err != nil || u == nil || u.Host != "proxy.example:3128" {
+		t.Fatalf("proxy for request = %v, %v", u, err)
+	}
+}
@@ -0,0 +1,96 @@
+package net
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRequestURLPattern matches the URLs organizers hand out for signing
+// requests: a path under /request/, a compact JWS file or an IPFS CID.
+const DefaultRequestURLPattern = `(?i)^(https?://\S*(/request/\S+|\.jws)|ipfs://\S+)$`
+
+var defaultRequestURLRe = regexp.MustCompile(DefaultRequestURLPattern)
+
+// requestURLRe keeps the last custom pattern compiled. The pattern only
+// changes with the settings, and the clipboard is checked on every focus.
+var requestURLRe struct {
+	sync.Mutex
+	pattern string
+	re      *regexp.Regexp
+}
+
+var errNotRequest = errors.New("not a sign request")
+
+// maxProbeBytes bounds how much of a clipboard URL is read when probing it.
+const maxProbeBytes int64 = 1 << 20
+
+// LooksLikeRequestURL reports whether clipboard text is a single URL that
+// matches pattern (DefaultRequestURLPattern when empty). Plain http is only
+// accepted for localhost, as everywhere else in the client, and ipfs://
+// only for a CID Fetch can check.
+func LooksLikeRequestURL(text, pattern string) bool {
+	text = strings.TrimSpace(text)
+	if text == "" || strings.ContainsAny(text, " \t\r\n") {
+		return false
+	}
+	u, err := url.Parse(text)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if u.Scheme != "ipfs" && !isAllowedURL(u) || u.Scheme == "ipfs" && !VerifiableIPFS(text) {
+		return false
+	}
+	re := requestURLPattern(pattern)
+	return re != nil && re.MatchString(text)
+}
+
+// requestURLPattern returns pattern compiled, the default pattern for "",
+// or nil if pattern is invalid.
+func requestURLPattern(pattern string) *regexp.Regexp {
+	if pattern == "" || pattern == DefaultRequestURLPattern {
+		return defaultRequestURLRe
+	}
+	requestURLRe.Lock()
+	defer requestURLRe.Unlock()
+	if requestURLRe.pattern != pattern {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			re = nil
+		}
+		requestURLRe.pattern, requestURLRe.re = pattern, re
+	}
+	return requestURLRe.re
+}
+
+// ProbeRequestURL downloads the start of the document at rawURL and reports
+// whether it is a sign request, as JSON or as a compact JWS. It is used for
+// clipboard URLs that do not match the configured pattern.
+func ProbeRequestURL(ctx context.Context, rawURL string) bool {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" || (u.Scheme != "ipfs" && !isAllowedURL(u)) {
+		return false
+	}
+	_, _, err = download(ctx, "request probe", u.String(), requestHeader(), 5*time.Second, maxProbeBytes, func(body []byte, contentType string) error {
+		if isJOSE(contentType, body) {
+			_, err := parseCompactJWS(body)
+			return err
+		}
+		var probe struct {
+			RequestID string `json:"requestId"`
+		}
+		if err := json.Unmarshal(body, &probe); err != nil {
+			return err
+		}
+		if probe.RequestID == "" {
+			return errNotRequest
+		}
+		return nil
+	})
+	return err == nil
+}
@@ -0,0 +1,70 @@
+package net
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLooksLikeRequestURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		pattern string
+		want    bool
+	}{
+		{"request path", "https://collector.example/request/ILP-2026-HABITATGE", "", true},
+		{"surrounding whitespace", "  https://collector.example/request/abc\n", "", true},
+		{"jws file", "https://bucket.example/campaigns/ilp.jws", "", true},
+		{"ipfs", "ipfs://" + testRawCID([]byte("request")), "", true},
+		{"ipfs unverifiable", "ipfs://bafyabc/request/ilp", "", false},
+		{"localhost http", "http://localhost:8080/request/abc", "", true},
+		{"remote http", "http://collector.example/request/abc", "", false},
+		{"unrelated url", "https://news.example/article/1", "", false},
+		{"text with url", "sign here https://collector.example/request/abc", "", false},
+		{"not a url", "12345678Z", "", false},
+		{"custom pattern", "https://signa.example/s/abc", `^https://signa\.example/s/`, true},
+		{"invalid pattern", "https://collector.example/request/abc", `(`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LooksLikeRequestURL(tt.text, tt.pattern); got != tt.want {
+				t.Fatalf("LooksLikeRequestURL(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestURLPattern_Cached(t *testing.T) {
+	if requestURLPattern("") != defaultRequestURLRe {
+		t.Error("empty pattern does not use the default")
+	}
+	custom := requestURLPattern(`^https://signa\.example/`)
+	if custom == nil || requestURLPattern(`^https://signa\.example/`) != custom {
+		t.Error("custom pattern compiled again")
+	}
+	if requestURLPattern(`(`) != nil {
+		t.Error("invalid pattern compiled")
+	}
+}
+
+func TestProbeRequestURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/req":
+			_, _ = w.Write([]byte(`{"version":"1.0","requestId":"abc"}`))
+		default:
+			_, _ = w.Write([]byte(`{"items":[]}`))
+		}
+	}))
+	defer srv.Close()
+
+	if !ProbeRequestURL(context.Background(), srv.URL+"/req") {
+		t.Error("expected sign request to be detected")
+	}
+	if ProbeRequestURL(context.Background(), srv.URL+"/other") {
+		t.Error("expected other JSON to be rejected")
+	}
+}
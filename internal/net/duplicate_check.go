@@ -0,0 +1,79 @@
+package net
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+// DuplicateCheckQuery is sent to the collector's duplicate-check endpoint.
+// The signer hash is salted per request, so the ID never leaves the client.
+type DuplicateCheckQuery struct {
+	RequestID  string `json:"requestId"`
+	SignerHash string `json:"signerHash"`
+}
+
+// DuplicateCheckResult tells whether the collector holds a signature of the
+// queried signer.
+type DuplicateCheckResult struct {
+	Signed bool `json:"signed"`
+}
+
+// CheckDuplicate asks the collector whether the signer identified by idNumber
+// has already signed the request.
+func CheckDuplicate(ctx context.Context, req *model.SignRequest, idNumber string) (bool, error) {
+	if req.DuplicateCheck == nil {
+		return false, nil
+	}
+	dc := req.DuplicateCheck
+	u, err := url.Parse(dc.URL)
+	if err != nil {
+		return false, errcode.Errorf(errcode.DuplicateCheck, "invalid duplicate check url: %w", err)
+	}
+	if !isAllowedURL(u) {
+		return false, errcode.Errorf(errcode.DuplicateCheck, "duplicate check url must be https")
+	}
+
+	body, err := json.Marshal(DuplicateCheckQuery{
+		RequestID:  req.RequestID,
+		SignerHash: dc.SignerHash(req.RequestID, idNumber),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal duplicate check query: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", dc.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	client := newClient(15 * time.Second)
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return false, fmt.Errorf("duplicate check failed: %w", errcode.Network(err))
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, errcode.Errorf(errcode.HTTPStatus, "unexpected status code: %d", resp.StatusCode)
+	}
+
+	data, err := readAll(resp.Body, maxResponseBytes)
+	if err != nil {
+		return false, fmt.Errorf("failed to read duplicate check body: %w", err)
+	}
+	var result DuplicateCheckResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return false, errcode.Errorf(errcode.DuplicateCheck, "failed to decode duplicate check result: %w", err)
+	}
+
+	return result.Signed, nil
+}
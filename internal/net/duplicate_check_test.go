@@ -0,0 +1,85 @@
+package net
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+func newDuplicateServer(t *testing.T, stored []string, gotHash *string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var q DuplicateCheckQuery
+		if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if gotHash != nil {
+			*gotHash = q.SignerHash
+		}
+		_ = json.NewEncoder(w).Encode(DuplicateCheckResult{Signed: slices.Contains(stored, q.SignerHash)})
+	}))
+}
+
+func TestCheckDuplicate(t *testing.T) {
+	dc := &model.DuplicateCheck{Salt: "campaign-salt"}
+	signed := dc.SignerHash("req-1", "12345678Z")
+	other := dc.SignerHash("req-1", "87654321X")
+
+	tests := []struct {
+		name   string
+		stored []string
+		id     string
+		want   bool
+	}{
+		{"already signed", []string{other, signed}, "12345678Z", true},
+		{"normalizes case and spaces", []string{signed}, " 12345678z ", true},
+		{"not signed", []string{other}, "12345678Z", false},
+		{"empty collector", nil, "12345678Z", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent string
+			srv := newDuplicateServer(t, tt.stored, &sent)
+			defer srv.Close()
+
+			req := &model.SignRequest{
+				RequestID:      "req-1",
+				DuplicateCheck: &model.DuplicateCheck{URL: srv.URL, Salt: "campaign-salt"},
+			}
+			got, err := CheckDuplicate(context.Background(), req, tt.id)
+			if err != nil {
+				t.Fatalf("CheckDuplicate: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CheckDuplicate = %v, want %v", got, tt.want)
+			}
+			if want := dc.SignerHash("req-1", tt.id); sent != want {
+				t.Errorf("signer hash sent = %q, want %q", sent, want)
+			}
+		})
+	}
+}
+
+func TestCheckDuplicate_NotConfigured(t *testing.T) {
+	got, err := CheckDuplicate(context.Background(), &model.SignRequest{RequestID: "req-1"}, "12345678Z")
+	if err != nil || got {
+		t.Fatalf("CheckDuplicate without config = %v, %v; want false, nil", got, err)
+	}
+}
+
+func TestCheckDuplicate_RejectsHTTP(t *testing.T) {
+	req := &model.SignRequest{
+		RequestID:      "req-1",
+		DuplicateCheck: &model.DuplicateCheck{URL: "http://example.com/dup", Salt: "s"},
+	}
+	if _, err := CheckDuplicate(context.Background(), req, "12345678Z"); err == nil {
+		t.Fatal("expected error for non-https URL")
+	}
+}
@@ -1,49 +1,122 @@
 package net
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"log"
+	"mime"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
 	"github.com/vocdoni/gofirma/vocsign/internal/model"
 )
 
-// Fetch retrieves and parses a SignRequest from a URL.
+// Fetch retrieves and parses a SignRequest from a URL. ipfs:// URLs are
+// resolved through the configured gateways and must name a raw block, whose
+// CID the content is checked against; the request itself is then
+// authenticated by its organizer signature. The versions this client
+// supports are advertised with Accept-Profile, and a request of any other
+// version fails with errcode.UnsupportedVersion.
 func Fetch(ctx context.Context, url string) (*model.SignRequest, []byte, error) {
 	log.Printf("DEBUG: Fetching request from %s", url)
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	if IsIPFS(url) && !VerifiableIPFS(url) {
+		return nil, nil, errcode.Errorf(errcode.InvalidIPFSURI, "ipfs request URI %q must be a CIDv1 of a raw block hashed with SHA-256, with no path, so its content can be checked", url)
 	}
-
-	client := newClient(10 * time.Second)
-	resp, err := client.Do(req)
+	raw, contentType, err := download(ctx, "request", url, requestHeader(), 10*time.Second, maxResponseBytes, func(body []byte, contentType string) error {
+		if !isJOSE(contentType, body) && !json.Valid(body) {
+			return errcode.Errorf(errcode.InvalidResponse, "response is neither JSON nor a compact JWS")
+		}
+		return nil
+	})
 	if err != nil {
 		log.Printf("DEBUG: Fetch failed: %v", err)
 		return nil, nil, fmt.Errorf("fetch failed: %w", err)
 	}
-	defer func() { _ = resp.Body.Close() }()
-
-	log.Printf("DEBUG: HTTP Response Status: %s", resp.Status)
-	if resp.StatusCode != http.StatusOK {
-		return nil, nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
+	log.Printf("DEBUG: Received %d bytes", len(raw))
 
-	raw, err := readAll(resp.Body, maxResponseBytes)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to read response body: %w", err)
+	if isJOSE(contentType, raw) {
+		signReq, err := parseCompactJWS(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Callers keep the raw request as JSON, so re-encode it with the
+		// compact JWS as its organizer signature.
+		raw, err = json.Marshal(signReq)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		log.Printf("DEBUG: Parsed JWS Request ID: %s", signReq.RequestID)
+		if err := checkVersion(signReq); err != nil {
+			return nil, nil, err
+		}
+		return signReq, raw, nil
 	}
-	log.Printf("DEBUG: Received %d bytes", len(raw))
 
 	var signReq model.SignRequest
 	if err := json.Unmarshal(raw, &signReq); err != nil {
 		log.Printf("DEBUG: JSON Unmarshal failed: %v", err)
-		return nil, nil, fmt.Errorf("failed to unmarshal json: %w", err)
+		return nil, nil, errcode.Errorf(errcode.InvalidResponse, "failed to unmarshal json: %w", err)
 	}
 
 	log.Printf("DEBUG: Parsed Request ID: %s", signReq.RequestID)
+	if err := checkVersion(&signReq); err != nil {
+		return nil, nil, err
+	}
 	return &signReq, raw, nil
 }
+
+// requestHeader advertises the request versions this client parses.
+func requestHeader() http.Header {
+	return http.Header{"Accept-Profile": {model.AcceptProfile()}}
+}
+
+// checkVersion rejects requests of a version this client does not support
+// before they are authenticated: their signed payload may carry fields the
+// client cannot parse, which would otherwise be reported as tampering.
+func checkVersion(req *model.SignRequest) error {
+	if err := model.CheckVersion(req.Version); err != nil {
+		log.Printf("DEBUG: request %s has unsupported version %q", req.RequestID, req.Version)
+		return errcode.Wrap(errcode.UnsupportedVersion, err)
+	}
+	return nil
+}
+
+// isJOSE reports whether a response carries a compact JWS rather than JSON.
+// Static hosts often serve files with a generic content type, so a body that
+// has the compact serialization shape is accepted too.
+func isJOSE(contentType string, body []byte) bool {
+	if mt, _, err := mime.ParseMediaType(contentType); err == nil && mt == "application/jose" {
+		return true
+	}
+	trimmed := bytes.TrimSpace(body)
+	return len(trimmed) > 0 && trimmed[0] != '{' && bytes.Count(trimmed, []byte(".")) == 2
+}
+
+// parseCompactJWS decodes a request served as a compact JWS. The payload is
+// the canonical request without its signature; the JWS itself becomes the
+// organizer signature so it is verified like any other request.
+func parseCompactJWS(body []byte) (*model.SignRequest, error) {
+	compact := string(bytes.TrimSpace(body))
+	parts := strings.Split(compact, ".")
+	if len(parts) != 3 || parts[1] == "" {
+		return nil, errcode.Errorf(errcode.InvalidJWS, "invalid compact JWS")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errcode.Errorf(errcode.InvalidJWS, "invalid JWS payload encoding: %w", err)
+	}
+	var signReq model.SignRequest
+	if err := json.Unmarshal(payload, &signReq); err != nil {
+		return nil, errcode.Errorf(errcode.InvalidJWS, "failed to unmarshal JWS payload: %w", err)
+	}
+	if signReq.OrganizerSignature != nil {
+		return nil, errcode.Errorf(errcode.InvalidJWS, "JWS payload must not embed an organizer signature")
+	}
+	signReq.OrganizerSignature = &model.OrganizerSignature{Format: "JWS", Value: compact}
+	return &signReq, nil
+}
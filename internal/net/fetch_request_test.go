@@ -0,0 +1,119 @@
+package net
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/canon"
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+func TestFetch_CompactJWS(t *testing.T) {
+	req := model.SignRequest{
+		Version:   "1.0",
+		RequestID: "req-jws",
+		Proposal:  model.Proposal{Title: "Static campaign"},
+		Callback:  model.Callback{URL: "https://example.org/callback", Method: "POST"},
+	}
+	payload, err := canon.Encode(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compact := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"ES256"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString(payload) + ".c2ln"
+
+	tests := []struct {
+		name        string
+		contentType string
+	}{
+		{"application/jose", "application/jose"},
+		{"generic content type", "application/octet-stream"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				_, _ = w.Write([]byte(compact + "\n"))
+			}))
+			defer srv.Close()
+
+			got, raw, err := Fetch(context.Background(), srv.URL)
+			if err != nil {
+				t.Fatalf("Fetch failed: %v", err)
+			}
+			if got.RequestID != "req-jws" || got.Proposal.Title != "Static campaign" {
+				t.Fatalf("unexpected request: %+v", got)
+			}
+			if got.OrganizerSignature == nil || got.OrganizerSignature.Value != compact {
+				t.Fatalf("expected the compact JWS as organizer signature, got %+v", got.OrganizerSignature)
+			}
+			// The JWS payload must match the canonical form verified later.
+			unsigned := *got
+			unsigned.OrganizerSignature = nil
+			if b, _ := canon.Encode(unsigned); string(b) != string(payload) {
+				t.Fatalf("canonical payload changed:\n%s\n%s", b, payload)
+			}
+			if !json.Valid(raw) {
+				t.Fatalf("raw request is not JSON: %s", raw)
+			}
+		})
+	}
+}
+
+func TestFetch_InvalidJWS(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/jose")
+		_, _ = w.Write([]byte("a.!!!.c"))
+	}))
+	defer srv.Close()
+
+	if _, _, err := Fetch(context.Background(), srv.URL); err == nil {
+		t.Fatal("expected error for malformed JWS payload")
+	}
+}
+
+func TestFetch_Versions(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr bool
+	}{
+		{"1.0", http.StatusOK, `{"version":"1.0","requestId":"req-1"}`, false},
+		{"2.0", http.StatusOK, `{"version":"2.0","requestId":"req-2","localizations":{"es":{"title":"Propuesta"}}}`, false},
+		{"newer version", http.StatusOK, `{"version":"3.0","requestId":"req-3"}`, true},
+		{"missing version", http.StatusOK, `{"requestId":"req-4"}`, true},
+		{"not acceptable", http.StatusNotAcceptable, `{"error":"no acceptable profile"}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var accept string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				accept = r.Header.Get("Accept-Profile")
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			_, _, err := Fetch(context.Background(), srv.URL)
+			if accept != model.AcceptProfile() {
+				t.Errorf("Accept-Profile = %q", accept)
+			}
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("Fetch failed: %v", err)
+				}
+				return
+			}
+			if code := errcode.Of(err); code != errcode.UnsupportedVersion {
+				t.Fatalf("error code = %q (%v), want %q", code, err, errcode.UnsupportedVersion)
+			}
+		})
+	}
+}
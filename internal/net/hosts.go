@@ -0,0 +1,102 @@
+package net
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/idn"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+// RequestHost is a server the client talks to for a request.
+type RequestHost struct {
+	Role string // e.g. "Signature sent to"
+	Host idn.Host
+}
+
+// RequestHosts returns the servers involved in req, fetched from
+// requestURL: where it came from, where the signature is sent, where the
+// organizer keys and the documents are downloaded from. IPFS URLs, which
+// name content rather than a server, are left out.
+func RequestHosts(requestURL string, req *model.SignRequest) []RequestHost {
+	urls := []struct{ role, url string }{
+		{"Request", requestURL},
+		{"Signature sent to", req.Callback.URL},
+		{"Organizer keys", req.Organizer.JWKSetURL},
+		{"Full text", req.Proposal.FullText.URL},
+	}
+	for _, d := range req.Documents() {
+		urls = append(urls, struct{ role, url string }{d.Title, d.URL})
+	}
+	var hosts []RequestHost
+	for _, u := range urls {
+		parsed, err := url.Parse(u.url)
+		if err != nil || parsed.Scheme == "ipfs" || parsed.Hostname() == "" {
+			continue
+		}
+		hosts = append(hosts, RequestHost{Role: u.role, Host: idn.Inspect(parsed.Hostname())})
+	}
+	return hosts
+}
+
+// HostWarnings returns a warning for every host that may imitate another.
+func HostWarnings(hosts []RequestHost) []string {
+	var warnings []string
+	for _, h := range hosts {
+		if h.Host.Suspicious() {
+			warnings = append(warnings, h.Role+": "+h.Host.Warning())
+		}
+	}
+	return warnings
+}
+
+// TextWarnings returns a warning for every text of req shown to the signer
+// with words that mix alphabets, such as a promoter name written with a
+// Greek omicron to pass for another.
+func TextWarnings(req *model.SignRequest) []string {
+	texts := []struct{ field, text string }{
+		{"Title", req.Proposal.Title},
+		{"Promoter", req.Proposal.Promoter},
+		{"Summary", req.Proposal.Summary},
+	}
+	for _, lang := range req.Languages() {
+		l := req.Localizations[lang]
+		texts = append(texts,
+			struct{ field, text string }{"Title (" + lang + ")", l.Title},
+			struct{ field, text string }{"Summary (" + lang + ")", l.Summary})
+	}
+	var warnings []string
+	for _, t := range texts {
+		if words := idn.MixedScriptWords(t.text); len(words) > 0 {
+			warnings = append(warnings, t.field+": "+strings.Join(words, ", ")+" mixes letters of different alphabets and may imitate other words")
+		}
+	}
+	return warnings
+}
+
+// DisplayHost returns hostport with the host shown as idn.Host.Display
+// does.
+func DisplayHost(hostport string) string {
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return idn.Inspect(hostport).Display()
+	}
+	return net.JoinHostPort(idn.Inspect(host).Display(), port)
+}
+
+// DisplayURL returns rawURL with its host in ASCII form, so that an
+// internationalized host is neither percent-encoded nor shown in
+// characters that imitate another host.
+func DisplayURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return rawURL
+	}
+	host := idn.Inspect(u.Hostname()).ASCII
+	if port := u.Port(); port != "" {
+		host = net.JoinHostPort(host, port)
+	}
+	u.Host = host
+	return u.String()
+}
@@ -0,0 +1,64 @@
+package net
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+func TestRequestHosts(t *testing.T) {
+	req := &model.SignRequest{
+		Callback:  model.Callback{URL: "https://xn--vcdoni-i0e.io/callback/ILP"},
+		Organizer: model.Organizer{JWKSetURL: "https://vocdoni.io/jwks.json"},
+		Proposal: model.Proposal{
+			FullText:    model.FullText{URL: "ipfs://bafytext"},
+			Attachments: []model.Document{{Title: "Annex", URL: "https://bücher.de/annex.pdf"}},
+		},
+	}
+	hosts := RequestHosts("https://vocdoni.io/request/ILP", req)
+	var got []string
+	for _, h := range hosts {
+		got = append(got, h.Role+"="+h.Host.Display())
+	}
+	want := "Request=vocdoni.io Signature sent to=xn--vcdoni-i0e.io Organizer keys=vocdoni.io Annex=bücher.de (xn--bcher-kva.de)"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("RequestHosts = %q", got)
+	}
+
+	warnings := HostWarnings(hosts)
+	if len(warnings) != 1 || !strings.HasPrefix(warnings[0], "Signature sent to: ") || !strings.Contains(warnings[0], "imitates vocdoni.io") {
+		t.Fatalf("HostWarnings = %q", warnings)
+	}
+}
+
+func TestDisplayURL(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"https://vocdoni.io/callback", "https://vocdoni.io/callback"},
+		{"https://vοcdoni.io/callback?id=1", "https://xn--vcdoni-i0e.io/callback?id=1"},
+		{"http://bücher.de:8080/x", "http://xn--bcher-kva.de:8080/x"},
+		{"not a url", "not a url"},
+	}
+	for _, tt := range tests {
+		if got := DisplayURL(tt.in); got != tt.want {
+			t.Errorf("DisplayURL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if got := DisplayHost("vοcdoni.io:443"); got != "xn--vcdoni-i0e.io:443" {
+		t.Errorf("DisplayHost = %q", got)
+	}
+}
+
+func TestTextWarnings(t *testing.T) {
+	req := &model.SignRequest{
+		Proposal: model.Proposal{Title: "Educació pública", Promoter: "Vοcdoni"},
+		Localizations: map[string]model.Localization{
+			"el": {Title: "Δημόσια παιδεία"},
+			"en": {Summary: "Signed by Vοcdoni"},
+		},
+	}
+	got := TextWarnings(req)
+	if len(got) != 2 || !strings.HasPrefix(got[0], "Promoter: Vοcdoni ") || !strings.HasPrefix(got[1], "Summary (en): Vοcdoni ") {
+		t.Fatalf("TextWarnings = %q", got)
+	}
+}
@@ -0,0 +1,228 @@
+package net
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+)
+
+// DefaultIPFSGateways are used to resolve ipfs:// URIs when none are
+// configured. Gateways are untrusted: everything fetched through them is
+// checked against a hash or signature from the request.
+var DefaultIPFSGateways = []string{
+	"https://ipfs.io",
+	"https://dweb.link",
+	"https://w3s.link",
+}
+
+var (
+	gatewaysMu   sync.RWMutex
+	ipfsGateways = DefaultIPFSGateways
+)
+
+// SetIPFSGateways replaces the gateways used to resolve ipfs:// URIs, tried
+// in order. Entries that are not HTTPS (or localhost) are ignored; an empty
+// list restores the defaults.
+func SetIPFSGateways(gateways []string) {
+	var valid []string
+	for _, g := range gateways {
+		g = strings.TrimRight(strings.TrimSpace(g), "/")
+		if g == "" {
+			continue
+		}
+		u, err := url.Parse(g)
+		if err != nil || u.Host == "" || !isAllowedURL(u) {
+			log.Printf("WARNING: ignoring invalid IPFS gateway %q", g)
+			continue
+		}
+		valid = append(valid, g)
+	}
+	if len(valid) == 0 {
+		valid = DefaultIPFSGateways
+	}
+	gatewaysMu.Lock()
+	ipfsGateways = valid
+	gatewaysMu.Unlock()
+}
+
+// IPFSGateways returns the gateways currently used to resolve ipfs:// URIs.
+func IPFSGateways() []string {
+	gatewaysMu.RLock()
+	defer gatewaysMu.RUnlock()
+	return append([]string(nil), ipfsGateways...)
+}
+
+// IsIPFS reports whether uri is an ipfs:// URI.
+func IsIPFS(uri string) bool {
+	return strings.HasPrefix(strings.ToLower(uri), "ipfs://")
+}
+
+// resolveURLs returns the HTTP URLs to try for uri: one per gateway for
+// ipfs://<cid>/<path> URIs, or uri itself otherwise.
+func resolveURLs(uri string) ([]string, error) {
+	if !IsIPFS(uri) {
+		return []string{uri}, nil
+	}
+	path := strings.TrimLeft(uri[len("ipfs://"):], "/")
+	cid, _, _ := strings.Cut(path, "/")
+	if cid == "" {
+		return nil, errcode.Errorf(errcode.InvalidIPFSURI, "invalid ipfs URI %q: missing CID", uri)
+	}
+	gateways := IPFSGateways()
+	urls := make([]string, 0, len(gateways))
+	for _, g := range gateways {
+		urls = append(urls, g+"/ipfs/"+path)
+	}
+	return urls, nil
+}
+
+// Multicodec codes of the CIDs whose content can be checked.
+const (
+	codecRaw  = 0x55
+	hashSHA2  = 0x12
+	sha2Bytes = 32
+)
+
+// rawCID returns the SHA-256 digest a CIDv1 of a raw block names, such as
+// "bafkrei...". Content stored as a UnixFS file, which covers every CIDv0
+// ("Qm...") and most CIDv1, is hashed as a tree of blocks, so its CID
+// cannot be checked against the file alone and ok is false.
+func rawCID(cid string) (digest []byte, ok bool) {
+	if len(cid) < 2 || (cid[0] != 'b' && cid[0] != 'B') {
+		return nil, false
+	}
+	data, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(cid[1:]))
+	if err != nil {
+		return nil, false
+	}
+	var fields [4]uint64
+	for i := range fields {
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, false
+		}
+		fields[i], data = v, data[n:]
+	}
+	version, codec, hash, size := fields[0], fields[1], fields[2], fields[3]
+	if version != 1 || codec != codecRaw || hash != hashSHA2 || size != sha2Bytes || len(data) != sha2Bytes {
+		return nil, false
+	}
+	return data, true
+}
+
+// VerifiableIPFS reports whether the content of the ipfs:// URI uri can be
+// checked against its CID: it names a raw block hashed with SHA-256, with
+// no path. A request has nothing else to be checked against, so it can
+// only be fetched from such an URI.
+func VerifiableIPFS(uri string) bool {
+	if !IsIPFS(uri) {
+		return false
+	}
+	cid := strings.Trim(uri[len("ipfs://"):], "/")
+	_, ok := rawCID(cid)
+	return ok
+}
+
+// checkCID fails if body is not the content the raw CID of uri names. URIs
+// whose CID cannot be checked pass; their content must be checked against
+// a hash from the request.
+func checkCID(uri string, body []byte) error {
+	if !VerifiableIPFS(uri) {
+		return nil
+	}
+	want, _ := rawCID(strings.Trim(uri[len("ipfs://"):], "/"))
+	if got := sha256.Sum256(body); !bytes.Equal(got[:], want) {
+		return errcode.Errorf(errcode.IPFSContentMismatch, "content does not match the CID of %s", uri)
+	}
+	return nil
+}
+
+// BrowsableURL returns a URL a web browser can open for uri, resolving
+// ipfs:// URIs through the first configured gateway.
+func BrowsableURL(uri string) string {
+	urls, err := resolveURLs(uri)
+	if err != nil || len(urls) == 0 {
+		return uri
+	}
+	return urls[0]
+}
+
+// download GETs uri and returns its body and content type. ipfs:// URIs are
+// tried against each gateway in turn until one returns a body that check
+// accepts, and whose raw CID, if it has one, matches, so a failing or
+// tampering gateway does not block the others.
+// what names the resource in error messages; header, if set, is sent with
+// every attempt.
+func download(ctx context.Context, what, uri string, header http.Header, timeout time.Duration, limit int64, check func(body []byte, contentType string) error) ([]byte, string, error) {
+	urls, err := resolveURLs(uri)
+	if err != nil {
+		return nil, "", err
+	}
+	var lastErr error
+	for _, u := range urls {
+		body, contentType, err := downloadOne(ctx, what, u, header, timeout, limit)
+		if err == nil {
+			err = checkCID(uri, body)
+		}
+		if err == nil && check != nil {
+			err = check(body, contentType)
+		}
+		if err == nil {
+			return body, contentType, nil
+		}
+		if len(urls) > 1 {
+			log.Printf("WARNING: %s via %s failed: %v", what, u, err)
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, "", lastErr
+}
+
+func downloadOne(ctx context.Context, what, u string, header http.Header, timeout time.Duration, limit int64) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request for %s: %w", what, err)
+	}
+	// Match the headers sent by the webapp's hash-document endpoint so that
+	// the document server returns identical content to both clients.  Without
+	// this, CDNs may flag the default Go-http-client User-Agent as a bot and
+	// serve a challenge page instead of the document, causing a hash mismatch.
+	req.Header.Set("Accept", "*/*")
+	req.Header.Set("User-Agent", "VocSign/1.0")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	client := newClient(timeout)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download %s: %w", what, errcode.Network(err))
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotAcceptable && header.Get("Accept-Profile") != "" {
+		return nil, "", errcode.Errorf(errcode.UnsupportedVersion, "%s is not available in a version this client supports (%s)", what, header.Get("Accept-Profile"))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", errcode.Errorf(errcode.HTTPStatus, "%s download returned status %d", what, resp.StatusCode)
+	}
+	body, err := readAll(resp.Body, limit)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s body: %w", what, err)
+	}
+	return body, resp.Header.Get("Content-Type"), nil
+}
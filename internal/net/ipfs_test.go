@@ -0,0 +1,130 @@
+package net
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+)
+
+func TestResolveURLs(t *testing.T) {
+	defer SetIPFSGateways(nil)
+	SetIPFSGateways([]string{"https://gw.example/", "http://evil.example", "http://localhost:8080"})
+
+	tests := []struct {
+		name    string
+		uri     string
+		want    []string
+		wantErr bool
+	}{
+		{"https", "https://example.org/doc.pdf", []string{"https://example.org/doc.pdf"}, false},
+		{"cid", "ipfs://bafyabc", []string{"https://gw.example/ipfs/bafyabc", "http://localhost:8080/ipfs/bafyabc"}, false},
+		{"cid with path", "ipfs://bafyabc/docs/ilp.pdf", []string{"https://gw.example/ipfs/bafyabc/docs/ilp.pdf", "http://localhost:8080/ipfs/bafyabc/docs/ilp.pdf"}, false},
+		{"missing cid", "ipfs://", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveURLs(tt.uri)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetIPFSGateways_DefaultsWhenEmpty(t *testing.T) {
+	defer SetIPFSGateways(nil)
+	SetIPFSGateways([]string{"ftp://gw.example"})
+	if got := IPFSGateways(); !reflect.DeepEqual(got, DefaultIPFSGateways) {
+		t.Fatalf("expected defaults, got %v", got)
+	}
+}
+
+func TestVerifyDocumentHash_IPFSFallsBackOnTamperedGateway(t *testing.T) {
+	doc := []byte("proposal text")
+	sum := sha256.Sum256(doc)
+	hash := base64.StdEncoding.EncodeToString(sum[:])
+
+	var path string
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("tampered"))
+	}))
+	defer bad.Close()
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		_, _ = w.Write(doc)
+	}))
+	defer good.Close()
+
+	defer SetIPFSGateways(nil)
+	SetIPFSGateways([]string{bad.URL, good.URL})
+
+	if err := VerifyDocumentHash(context.Background(), "ipfs://bafydoc/ilp.txt", hash); err != nil {
+		t.Fatalf("VerifyDocumentHash failed: %v", err)
+	}
+	if path != "/ipfs/bafydoc/ilp.txt" {
+		t.Fatalf("unexpected gateway path %q", path)
+	}
+
+	SetIPFSGateways([]string{bad.URL})
+	if err := VerifyDocumentHash(context.Background(), "ipfs://bafydoc/ilp.txt", hash); err == nil {
+		t.Fatal("expected hash mismatch when only the tampering gateway is available")
+	}
+}
+
+// testRawCID returns the CIDv1 of data stored as a raw block.
+func testRawCID(data []byte) string {
+	sum := sha256.Sum256(data)
+	b := append([]byte{1, codecRaw, hashSHA2, sha2Bytes}, sum[:]...)
+	return "b" + strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))
+}
+
+func TestVerifiableIPFS(t *testing.T) {
+	cid := testRawCID([]byte("x"))
+	tests := []struct {
+		uri  string
+		want bool
+	}{
+		{"ipfs://" + cid, true},
+		{"ipfs://" + strings.ToUpper(cid), true},
+		{"ipfs://" + cid + "/request.json", false},
+		{"ipfs://QmT78zSuBmuS4z925WZfrqQ1qHaJ56DQaTfyMUF7F8ff5o", false},
+		{"ipfs://bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi", false},
+		{"ipfs://bafydoc", false},
+		{"https://example.org/" + cid, false},
+	}
+	for _, tt := range tests {
+		if got := VerifiableIPFS(tt.uri); got != tt.want {
+			t.Errorf("VerifiableIPFS(%q) = %v, want %v", tt.uri, got, tt.want)
+		}
+	}
+}
+
+func TestFetch_IPFSChecksCID(t *testing.T) {
+	body := []byte(`{"version":"1.0"}`)
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"version":"1.0","requestId":"forged"}`))
+	}))
+	defer bad.Close()
+	defer SetIPFSGateways(nil)
+	SetIPFSGateways([]string{bad.URL})
+
+	_, _, err := Fetch(context.Background(), "ipfs://"+testRawCID(body))
+	if errcode.Of(err) != errcode.IPFSContentMismatch {
+		t.Fatalf("Fetch from a tampering gateway = %v, want %s", err, errcode.IPFSContentMismatch)
+	}
+	_, _, err = Fetch(context.Background(), "ipfs://QmT78zSuBmuS4z925WZfrqQ1qHaJ56DQaTfyMUF7F8ff5o")
+	if errcode.Of(err) != errcode.InvalidIPFSURI {
+		t.Fatalf("Fetch of an unverifiable CID = %v, want %s", err, errcode.InvalidIPFSURI)
+	}
+}
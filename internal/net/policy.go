@@ -0,0 +1,46 @@
+package net
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+// ErrPolicyHashMismatch means the policy document at SignPolicy.URI is not
+// the one SignPolicy.Hash refers to.
+var ErrPolicyHashMismatch = errcode.Wrap(errcode.PolicyHashMismatch, errors.New("signature policy hash mismatch"))
+
+// FetchPolicyDocument downloads the signature policy document and checks it
+// against the hash declared in the request.
+func FetchPolicyDocument(ctx context.Context, p *model.SignPolicy) ([]byte, error) {
+	if p == nil || p.URI == "" {
+		return nil, errcode.Errorf(errcode.PolicyMissingURI, "policy has no document URI")
+	}
+	alg, err := p.HashAlgorithm()
+	if err != nil {
+		return nil, err
+	}
+	want, err := p.Digest()
+	if err != nil {
+		return nil, err
+	}
+
+	body, _, err := download(ctx, "policy", p.URI, nil, 30*time.Second, maxResponseBytes, func(body []byte, _ string) error {
+		h := alg.New()
+		h.Write(body)
+		if got := h.Sum(nil); !bytes.Equal(got, want) {
+			return fmt.Errorf("%w: expected %s but got %s", ErrPolicyHashMismatch, p.Hash, base64.StdEncoding.EncodeToString(got))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return body, nil
+}
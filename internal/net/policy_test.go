@@ -0,0 +1,58 @@
+package net
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+func TestFetchPolicyDocument(t *testing.T) {
+	doc := []byte("%PDF-1.4 signature policy")
+	sum := sha256.Sum256(doc)
+	hash := base64.StdEncoding.EncodeToString(sum[:])
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(doc)
+	}))
+	defer srv.Close()
+
+	t.Run("match", func(t *testing.T) {
+		got, err := FetchPolicyDocument(context.Background(), &model.SignPolicy{URI: srv.URL, Hash: hash})
+		if err != nil {
+			t.Fatalf("FetchPolicyDocument failed: %v", err)
+		}
+		if string(got) != string(doc) {
+			t.Fatalf("unexpected body %q", got)
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		other := sha256.Sum256([]byte("other policy"))
+		_, err := FetchPolicyDocument(context.Background(), &model.SignPolicy{
+			URI:  srv.URL,
+			Hash: base64.StdEncoding.EncodeToString(other[:]),
+		})
+		if !errors.Is(err, ErrPolicyHashMismatch) {
+			t.Fatalf("expected ErrPolicyHashMismatch, got %v", err)
+		}
+	})
+
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		_, err := FetchPolicyDocument(context.Background(), &model.SignPolicy{URI: srv.URL, HashAlg: "md5", Hash: hash})
+		if !errors.Is(err, model.ErrUnsupportedPolicyHashAlg) {
+			t.Fatalf("expected ErrUnsupportedPolicyHashAlg, got %v", err)
+		}
+	})
+
+	t.Run("missing uri", func(t *testing.T) {
+		if _, err := FetchPolicyDocument(context.Background(), &model.SignPolicy{Hash: hash}); err == nil {
+			t.Fatal("expected error without URI")
+		}
+	})
+}
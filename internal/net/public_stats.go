@@ -0,0 +1,55 @@
+package net
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+// FetchPublicStats returns the collector's public signature count for req,
+// or nil if the request does not publish one.
+func FetchPublicStats(ctx context.Context, req *model.SignRequest) (*model.PublicStatsSummary, error) {
+	if req.PublicStats == nil {
+		return nil, nil
+	}
+	u, err := url.Parse(req.PublicStats.URL)
+	if err != nil || !isAllowedURL(u) {
+		return nil, errcode.Errorf(errcode.InvalidResponse, "public stats url must be https")
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", req.PublicStats.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	client := newClient(10 * time.Second)
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("public stats fetch failed: %w", errcode.Network(err))
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errcode.Errorf(errcode.HTTPStatus, "unexpected status code: %d", resp.StatusCode)
+	}
+	body, err := readAll(resp.Body, maxResponseBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public stats: %w", err)
+	}
+	var stats model.PublicStatsSummary
+	if err := json.Unmarshal(body, &stats); err != nil {
+		return nil, errcode.Errorf(errcode.InvalidResponse, "failed to decode public stats: %w", err)
+	}
+	if stats.RequestID != req.RequestID {
+		return nil, errcode.Errorf(errcode.InvalidResponse, "public stats are for request %q", stats.RequestID)
+	}
+	if n := req.PublicStats.HistogramDays(); len(stats.Daily) > n {
+		stats.Daily = stats.Daily[len(stats.Daily)-n:]
+	}
+	return &stats, nil
+}
@@ -0,0 +1,45 @@
+package net
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+func TestFetchPublicStats(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"requestId": "ILP-1", "verified": 7, "updatedAt": "2026-10-16T12:00:00Z", "daily": [
+			{"date": "2026-10-14", "count": 1},
+			{"date": "2026-10-15", "count": 2},
+			{"date": "2026-10-16", "count": 4}
+		]}`))
+	}))
+	defer srv.Close()
+
+	req := &model.SignRequest{RequestID: "ILP-1", PublicStats: &model.PublicStats{URL: srv.URL, Days: 2}}
+	stats, err := FetchPublicStats(context.Background(), req)
+	if err != nil {
+		t.Fatalf("FetchPublicStats: %v", err)
+	}
+	if stats.Verified != 7 {
+		t.Errorf("verified = %d, want 7", stats.Verified)
+	}
+	if len(stats.Daily) != 2 || stats.Daily[0].Date != "2026-10-15" {
+		t.Errorf("daily = %v, want the last 2 days", stats.Daily)
+	}
+
+	req.RequestID = "ILP-2"
+	if _, err := FetchPublicStats(context.Background(), req); err == nil {
+		t.Error("expected error for stats of another request")
+	}
+}
+
+func TestFetchPublicStats_NotConfigured(t *testing.T) {
+	stats, err := FetchPublicStats(context.Background(), &model.SignRequest{RequestID: "ILP-1"})
+	if stats != nil || err != nil {
+		t.Fatalf("got %v, %v; want nil, nil", stats, err)
+	}
+}
@@ -0,0 +1,111 @@
+package net
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+// IdempotencyKeyHeader carries the idempotency key of a submission, so a
+// collector can recognize a signature it already accepted.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// ReceiptIDHeader is where a collector answering a HEAD lookup of its
+// callback url puts the receipt ID of the accepted submission.
+const ReceiptIDHeader = "Receipt-Id"
+
+// SubmissionKey returns the idempotency key of resp: the hex SHA-256 of its
+// signature, the same hash the audit log records.
+func SubmissionKey(resp *model.SignResponse) string {
+	der, err := base64.StdEncoding.DecodeString(resp.SignatureDerBase64)
+	if err != nil || len(der) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
+
+// LookupReceipt asks the collector whether it accepted the submission with
+// the idempotency key. It returns the receipt, or nil if the collector has
+// no such submission.
+//
+// Requests with a receiptLookup url are looked up there with
+// GET ?requestId=...&key=..., answered with the receipt or 404. Otherwise the
+// callback url is asked with a HEAD carrying the key in Idempotency-Key,
+// answered with 200 and the receipt ID in Receipt-Id, or 404. Any other
+// answer, including a 200 without a receipt ID, means the collector cannot
+// tell, and is an ERR_RECEIPT_LOOKUP error.
+func LookupReceipt(ctx context.Context, req *model.SignRequest, key string) (*model.SubmitReceipt, error) {
+	if key == "" {
+		return nil, errcode.Errorf(errcode.ReceiptLookup, "missing idempotency key")
+	}
+	method, rawURL := http.MethodHead, req.Callback.URL
+	if l := req.ReceiptLookup; l != nil {
+		method, rawURL = http.MethodGet, l.URL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errcode.Errorf(errcode.ReceiptLookup, "invalid receipt lookup url: %w", err)
+	}
+	if !isAllowedURL(u) {
+		return nil, errcode.Errorf(errcode.ReceiptLookup, "receipt lookup url must be https")
+	}
+	if method == http.MethodGet {
+		q := u.Query()
+		q.Set("requestId", req.RequestID)
+		q.Set("key", key)
+		u.RawQuery = q.Encode()
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set(IdempotencyKeyHeader, key)
+
+	client := newClient(15 * time.Second)
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("receipt lookup failed: %w", errcode.Network(err))
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, errcode.Errorf(errcode.ReceiptLookup, "unexpected status code: %d", resp.StatusCode)
+	}
+
+	if method == http.MethodHead {
+		// A server that answers any HEAD with 200 must not pass for one
+		// that accepted the submission.
+		id := resp.Header.Get(ReceiptIDHeader)
+		if id == "" {
+			return nil, errcode.Errorf(errcode.ReceiptLookup, "missing %s header", ReceiptIDHeader)
+		}
+		return &model.SubmitReceipt{Status: "accepted", ReceiptID: id}, nil
+	}
+	data, err := readAll(resp.Body, maxResponseBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read receipt body: %w", err)
+	}
+	var receipt model.SubmitReceipt
+	if err := json.Unmarshal(data, &receipt); err != nil {
+		return nil, errcode.Errorf(errcode.ReceiptLookup, "failed to decode receipt: %w", err)
+	}
+	if receipt.ReceiptID == "" {
+		return nil, errcode.Errorf(errcode.ReceiptLookup, "receipt has no receipt ID")
+	}
+	return &receipt, nil
+}
@@ -0,0 +1,109 @@
+package net
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+func TestSubmissionKey(t *testing.T) {
+	// sha256("abc")
+	want := "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	if got := SubmissionKey(&model.SignResponse{SignatureDerBase64: base64.StdEncoding.EncodeToString([]byte("abc"))}); got != want {
+		t.Fatalf("SubmissionKey = %q, want %q", got, want)
+	}
+	if got := SubmissionKey(&model.SignResponse{}); got != "" {
+		t.Fatalf("SubmissionKey of an unsigned response = %q", got)
+	}
+}
+
+func TestLookupReceipt(t *testing.T) {
+	const accepted = "k-accepted"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/callback":
+			if r.Method != http.MethodHead {
+				http.Error(w, "method", http.StatusMethodNotAllowed)
+				return
+			}
+			if r.Header.Get(IdempotencyKeyHeader) != accepted {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set(ReceiptIDHeader, "r-head")
+		case "/receipts":
+			if r.URL.Query().Get("requestId") != "req-1" || r.URL.Query().Get("key") != accepted {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write([]byte(`{"status":"accepted","receiptId":"r-get","receivedAt":"2026-01-02T03:04:05Z"}`))
+		case "/broken/callback":
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		case "/any/callback", "/any/receipts":
+			// Answers every request with an empty 200.
+		}
+	}))
+	defer srv.Close()
+
+	head := &model.SignRequest{RequestID: "req-1", Callback: model.Callback{URL: srv.URL + "/callback"}}
+	lookup := &model.SignRequest{RequestID: "req-1", Callback: model.Callback{URL: srv.URL + "/callback"}, ReceiptLookup: &model.ReceiptLookup{URL: srv.URL + "/receipts"}}
+	unsupported := &model.SignRequest{RequestID: "req-1", Callback: model.Callback{URL: srv.URL + "/broken/callback"}}
+	anyHead := &model.SignRequest{RequestID: "req-1", Callback: model.Callback{URL: srv.URL + "/any/callback"}}
+	anyLookup := &model.SignRequest{RequestID: "req-1", Callback: model.Callback{URL: srv.URL + "/any/callback"}, ReceiptLookup: &model.ReceiptLookup{URL: srv.URL + "/any/receipts"}}
+
+	tests := []struct {
+		name     string
+		req      *model.SignRequest
+		key      string
+		want     string
+		wantCode errcode.Code
+	}{
+		{"head accepted", head, accepted, "r-head", errcode.Unknown},
+		{"head not found", head, "k-other", "", errcode.Unknown},
+		{"lookup endpoint accepted", lookup, accepted, "r-get", errcode.Unknown},
+		{"lookup endpoint not found", lookup, "k-other", "", errcode.Unknown},
+		{"collector cannot tell", unsupported, accepted, "", errcode.ReceiptLookup},
+		{"head 200 without receipt ID", anyHead, accepted, "", errcode.ReceiptLookup},
+		{"lookup 200 without receipt", anyLookup, accepted, "", errcode.ReceiptLookup},
+		{"no key", head, "", "", errcode.ReceiptLookup},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receipt, err := LookupReceipt(context.Background(), tt.req, tt.key)
+			if code := errcode.Of(err); code != tt.wantCode || (tt.wantCode != errcode.Unknown) != (err != nil) {
+				t.Fatalf("err = %v (code %q), want code %q", err, code, tt.wantCode)
+			}
+			if tt.want == "" {
+				if receipt != nil {
+					t.Fatalf("receipt = %+v, want none", receipt)
+				}
+				return
+			}
+			if receipt == nil || receipt.ReceiptID != tt.want {
+				t.Fatalf("receipt = %+v, want ID %q", receipt, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubmitTraced_IdempotencyKey(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(IdempotencyKeyHeader)
+		_, _ = w.Write([]byte(`{"status":"accepted","receiptId":"r-1"}`))
+	}))
+	defer srv.Close()
+
+	resp := &model.SignResponse{SignatureDerBase64: base64.StdEncoding.EncodeToString([]byte("abc"))}
+	if _, _, err := SubmitTraced(context.Background(), srv.URL, resp); err != nil {
+		t.Fatal(err)
+	}
+	if want := SubmissionKey(resp); got != want {
+		t.Fatalf("%s = %q, want %q", IdempotencyKeyHeader, got, want)
+	}
+}
@@ -9,23 +9,96 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/changelog"
+	"github.com/vocdoni/gofirma/vocsign/internal/update"
+	"github.com/vocdoni/gofirma/vocsign/internal/version"
 )
 
 const (
 	latestReleaseAPIURL  = "https://api.github.com/repos/vocdoni/vocsign/releases/latest"
+	releasesAPIURL       = "https://api.github.com/repos/vocdoni/vocsign/releases?per_page=30"
 	LatestReleasePageURL = "https://github.com/vocdoni/vocsign/releases/latest"
 )
 
-type latestReleaseResponse struct {
-	TagName string `json:"tag_name"`
-	HTMLURL string `json:"html_url"`
+type releaseResponse struct {
+	TagName     string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	HTMLURL     string    `json:"html_url"`
+	Body        string    `json:"body"`
+	Draft       bool      `json:"draft"`
+	Prerelease  bool      `json:"prerelease"`
+	PublishedAt time.Time `json:"published_at"`
+	Assets      []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+		Size int64  `json:"size"`
+	} `json:"assets"`
+}
+
+// Release is the latest published VocSign release.
+type Release struct {
+	Tag         string
+	Name        string
+	URL         string
+	PublishedAt time.Time
+	// Prerelease marks a beta, offered only on the beta channel.
+	Prerelease bool
+	// Notes are the release notes, parsed from the Markdown body.
+	Notes changelog.Notes
+	// Assets are the executables, manifests and patches of the release.
+	Assets []update.Asset
 }
 
-func FetchLatestRelease(ctx context.Context) (string, string, error) {
-	log.Printf("DEBUG: FetchLatestRelease request url=%s", latestReleaseAPIURL)
-	req, err := http.NewRequestWithContext(ctx, "GET", latestReleaseAPIURL, nil)
+// FetchLatestRelease returns the newest release. With prereleases, for the
+// beta channel, pre-releases count too: the newest by version among the
+// recent releases is returned, whether a beta or a stable release.
+func FetchLatestRelease(ctx context.Context, prereleases bool) (*Release, error) {
+	if prereleases {
+		return fetchNewestRelease(ctx, releasesAPIURL)
+	}
+	return fetchRelease(ctx, latestReleaseAPIURL)
+}
+
+func fetchRelease(ctx context.Context, apiURL string) (*Release, error) {
+	var out releaseResponse
+	if err := getRelease(ctx, apiURL, &out); err != nil {
+		return nil, err
+	}
+	return newRelease(out)
+}
+
+// fetchNewestRelease picks the newest published release from the list at
+// apiURL.
+func fetchNewestRelease(ctx context.Context, apiURL string) (*Release, error) {
+	var list []releaseResponse
+	if err := getRelease(ctx, apiURL, &list); err != nil {
+		return nil, err
+	}
+	var newest *releaseResponse
+	for i, r := range list {
+		if r.Draft || r.TagName == "" {
+			continue
+		}
+		if newest == nil || version.Compare(r.TagName, newest.TagName) > 0 {
+			newest = &list[i]
+		}
+	}
+	if newest == nil {
+		return nil, fmt.Errorf("no published releases")
+	}
+	return newRelease(*newest)
+}
+
+// getRelease decodes the GitHub API response at apiURL into out. A list
+// of releases with long notes and many assets runs to megabytes, so the
+// response is read whole up to maxResponseBytes and rejected beyond, rather
+// than cut short and failing to decode.
+func getRelease(ctx context.Context, apiURL string, out any) error {
+	log.Printf("DEBUG: FetchLatestRelease request url=%s", apiURL)
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 	if err != nil {
-		return "", "", fmt.Errorf("build latest release request: %w", err)
+		return fmt.Errorf("build latest release request: %w", err)
 	}
 	req.Header.Set("Accept", "application/vnd.github+json")
 	req.Header.Set("User-Agent", "vocsign-version-check")
@@ -33,7 +106,7 @@ func FetchLatestRelease(ctx context.Context) (string, string, error) {
 	client := &http.Client{Timeout: 8 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", "", fmt.Errorf("fetch latest release: %w", err)
+		return fmt.Errorf("fetch latest release: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 	log.Printf("DEBUG: FetchLatestRelease response status=%s", resp.Status)
@@ -44,19 +117,43 @@ func FetchLatestRelease(ctx context.Context) (string, string, error) {
 		if msg == "" {
 			msg = resp.Status
 		}
-		return "", "", fmt.Errorf("latest release request failed: %s", msg)
+		return fmt.Errorf("latest release request failed: %s", msg)
 	}
-
-	var out latestReleaseResponse
-	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-		return "", "", fmt.Errorf("decode latest release response: %w", err)
+	data, err := readAll(resp.Body, maxResponseBytes)
+	if err != nil {
+		return fmt.Errorf("read latest release response: %w", err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decode latest release response: %w", err)
 	}
+	return nil
+}
+
+func newRelease(out releaseResponse) (*Release, error) {
 	if out.TagName == "" {
-		return "", "", fmt.Errorf("latest release response missing tag_name")
+		return nil, fmt.Errorf("latest release response missing tag_name")
 	}
 	if out.HTMLURL == "" {
 		out.HTMLURL = LatestReleasePageURL
 	}
-	log.Printf("DEBUG: FetchLatestRelease parsed tag=%s url=%s", out.TagName, out.HTMLURL)
-	return out.TagName, out.HTMLURL, nil
+	rel := &Release{
+		Tag:         out.TagName,
+		Name:        strings.TrimSpace(out.Name),
+		URL:         out.HTMLURL,
+		PublishedAt: out.PublishedAt,
+		Prerelease:  out.Prerelease,
+		Notes:       changelog.Parse(out.Body),
+	}
+	for _, a := range out.Assets {
+		rel.Assets = append(rel.Assets, update.Asset{Name: a.Name, URL: a.URL, Size: a.Size})
+	}
+	log.Printf("DEBUG: FetchLatestRelease parsed tag=%s url=%s prerelease=%v notes=%d security=%v assets=%d", rel.Tag, rel.URL, rel.Prerelease, len(rel.Notes.Blocks), rel.Notes.Security(), len(rel.Assets))
+	return rel, nil
+}
+
+// NewDownloadClient returns the client release downloads use. It has no
+// overall timeout, since a release can take long to download over a slow
+// link; cancel the request context instead.
+func NewDownloadClient() *http.Client {
+	return newClient(0)
 }
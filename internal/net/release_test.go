@@ -0,0 +1,125 @@
+package net
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/changelog"
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+)
+
+func TestFetchRelease(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{
+			"tag_name": "v1.4.0",
+			"name": " VocSign 1.4 ",
+			"html_url": "https://github.com/vocdoni/vocsign/releases/tag/v1.4.0",
+			"published_at": "2026-09-30T10:00:00Z",
+			"body": "## Security\r\n\r\n- Fix CVE-2026-0001\r\n",
+			"assets": [{"name": "vocsign-linux-amd64.from-v1.3.0.delta", "size": 41230,
+				"browser_download_url": "https://github.com/vocdoni/vocsign/releases/download/v1.4.0/vocsign-linux-amd64.from-v1.3.0.delta"}]
+		}`))
+	}))
+	defer srv.Close()
+
+	rel, err := fetchRelease(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("fetchRelease: %v", err)
+	}
+	if rel.Tag != "v1.4.0" || rel.Name != "VocSign 1.4" || rel.PublishedAt.Year() != 2026 {
+		t.Fatalf("release = %+v", rel)
+	}
+	if len(rel.Notes.Blocks) != 2 || !rel.Notes.Security() {
+		t.Fatalf("notes = %+v", rel.Notes)
+	}
+	if len(rel.Assets) != 1 || rel.Assets[0].Size != 41230 || rel.Assets[0].Name != "vocsign-linux-amd64.from-v1.3.0.delta" {
+		t.Fatalf("assets = %+v", rel.Assets)
+	}
+}
+
+func TestFetchRelease_Errors(t *testing.T) {
+	for name, body := range map[string]string{
+		"missing tag": `{"html_url": "https://example.org"}`,
+		"bad json":    `{`,
+	} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(body))
+		}))
+		if _, err := fetchRelease(context.Background(), srv.URL); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+		srv.Close()
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusForbidden)
+	}))
+	defer srv.Close()
+	if _, err := fetchRelease(context.Background(), srv.URL); err == nil {
+		t.Fatal("expected error for bad status")
+	}
+}
+
+func TestFetchNewestRelease(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[
+			{"tag_name": "v1.6.0", "draft": true},
+			{"tag_name": "v1.5.0-beta.10", "prerelease": true, "html_url": "https://example.org/b10"},
+			{"tag_name": "v1.4.1", "html_url": "https://example.org/141"},
+			{"tag_name": "v1.5.0-beta.9", "prerelease": true}
+		]`))
+	}))
+	defer srv.Close()
+
+	rel, err := fetchNewestRelease(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("fetchNewestRelease: %v", err)
+	}
+	if rel.Tag != "v1.5.0-beta.10" || !rel.Prerelease || rel.URL != "https://example.org/b10" {
+		t.Fatalf("release = %+v", rel)
+	}
+
+	empty := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"tag_name": "v2.0.0", "draft": true}]`))
+	}))
+	defer empty.Close()
+	if _, err := fetchNewestRelease(context.Background(), empty.URL); err == nil {
+		t.Fatal("expected an error without published releases")
+	}
+}
+
+func TestFetchNewestRelease_LongList(t *testing.T) {
+	// Thirty releases with notes at the changelog cap, past what a fixed
+	// multiple of it allowed.
+	var list []map[string]any
+	for range 30 {
+		list = append(list, map[string]any{"tag_name": "v1.0.0", "body": strings.Repeat("x", changelog.MaxBytes)})
+	}
+	list[7]["tag_name"] = "v1.2.0"
+	data, err := json.Marshal(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(data)
+	}))
+	defer srv.Close()
+	rel, err := fetchNewestRelease(context.Background(), srv.URL)
+	if err != nil || rel.Tag != "v1.2.0" {
+		t.Fatalf("fetchNewestRelease = %+v, %v", rel, err)
+	}
+
+	huge := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"tag_name": "v1.0.0", "body": "`))
+		_, _ = w.Write([]byte(strings.Repeat("x", int(maxResponseBytes))))
+		_, _ = w.Write([]byte(`"}]`))
+	}))
+	defer huge.Close()
+	if _, err := fetchNewestRelease(context.Background(), huge.URL); errcode.Of(err) != errcode.ResponseTooLarge {
+		t.Fatalf("oversized response = %v, want %s", err, errcode.ResponseTooLarge)
+	}
+}
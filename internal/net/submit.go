@@ -4,51 +4,96 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
 	"github.com/vocdoni/gofirma/vocsign/internal/model"
 )
 
 func Submit(ctx context.Context, callbackURL string, resp *model.SignResponse) (*model.SubmitReceipt, error) {
+	receipt, _, err := SubmitTraced(ctx, callbackURL, resp)
+	return receipt, err
+}
+
+// SubmitTraced is Submit that also returns the URL the signature was
+// actually delivered to after redirects. It is set whenever a request was
+// attempted, even if the submission failed, since the server at that URL
+// may have received the signer's personal data.
+func SubmitTraced(ctx context.Context, callbackURL string, resp *model.SignResponse) (*model.SubmitReceipt, string, error) {
 	jsonBytes, err := json.Marshal(resp)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal response: %w", err)
+		return nil, "", fmt.Errorf("failed to marshal response: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", callbackURL, bytes.NewBuffer(jsonBytes))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if key := SubmissionKey(resp); key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
 
 	client := newClient(30 * time.Second)
 	httpResp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("submit failed: %w", err)
+		finalURL := callbackURL
+		var uerr *url.Error
+		if errors.As(err, &uerr) && uerr.URL != "" {
+			finalURL = uerr.URL
+		}
+		return nil, finalURL, fmt.Errorf("submit failed: %w", errcode.Network(err))
 	}
 	defer func() { _ = httpResp.Body.Close() }()
+	finalURL := httpResp.Request.URL.String()
+	if finalURL != callbackURL {
+		log.Printf("WARNING: submission to %s was redirected to %s", callbackURL, httpResp.Request.URL.Redacted())
+	}
 
 	if httpResp.StatusCode != http.StatusOK && httpResp.StatusCode != http.StatusCreated {
 		body, _ := io.ReadAll(io.LimitReader(httpResp.Body, 4096))
+		if err := submitError(body); err != nil {
+			return nil, finalURL, err
+		}
 		if len(body) > 0 {
-			return nil, fmt.Errorf("unexpected status code: %d: %s", httpResp.StatusCode, strings.TrimSpace(string(body)))
+			return nil, finalURL, errcode.Errorf(errcode.SubmitRejected, "unexpected status code: %d: %s", httpResp.StatusCode, strings.TrimSpace(string(body)))
 		}
-		return nil, fmt.Errorf("unexpected status code: %d", httpResp.StatusCode)
+		return nil, finalURL, errcode.Errorf(errcode.SubmitRejected, "unexpected status code: %d", httpResp.StatusCode)
 	}
 
 	body, err := readAll(httpResp.Body, maxResponseBytes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read receipt body: %w", err)
+		return nil, finalURL, fmt.Errorf("failed to read receipt body: %w", err)
 	}
 
 	var receipt model.SubmitReceipt
 	if err := json.Unmarshal(body, &receipt); err != nil {
-		return nil, fmt.Errorf("failed to decode receipt: %w", err)
+		return nil, finalURL, errcode.Errorf(errcode.InvalidResponse, "failed to decode receipt: %w", err)
 	}
 
-	return &receipt, nil
+	return &receipt, finalURL, nil
+}
+
+// submitError returns the error a collector described in a structured
+// rejection body, or nil if body is not one the client acts on.
+func submitError(body []byte) error {
+	var se model.SubmitError
+	if json.Unmarshal(body, &se) != nil {
+		return nil
+	}
+	switch errcode.Code(se.Code) {
+	case errcode.ProposalChanged:
+		if se.DocumentVersion > 0 {
+			return errcode.Errorf(errcode.ProposalChanged, "proposal text changed: the collector expects document version %d", se.DocumentVersion)
+		}
+		return errcode.Errorf(errcode.ProposalChanged, "proposal text changed: %s", se.Message)
+	}
+	return nil
 }
@@ -0,0 +1,64 @@
+package net
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+func TestSubmitTraced(t *testing.T) {
+	final := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/reject":
+			http.Error(w, "no", http.StatusBadRequest)
+			return
+		case "/changed":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"status":"error","code":"ERR_PROPOSAL_CHANGED","documentVersion":2}`))
+			return
+		case "/other-code":
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"status":"error","code":"ERR_SOMETHING_ELSE"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"accepted","receiptId":"r-1"}`))
+	}))
+	defer final.Close()
+	redirector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, final.URL+r.URL.Path, http.StatusTemporaryRedirect)
+	}))
+	defer redirector.Close()
+
+	tests := []struct {
+		name      string
+		url       string
+		wantFinal string
+		wantCode  errcode.Code
+	}{
+		{"direct", final.URL + "/ok", final.URL + "/ok", errcode.Unknown},
+		{"redirected", redirector.URL + "/ok", final.URL + "/ok", errcode.Unknown},
+		{"redirected and rejected", redirector.URL + "/reject", final.URL + "/reject", errcode.SubmitRejected},
+		{"proposal changed", final.URL + "/changed", final.URL + "/changed", errcode.ProposalChanged},
+		{"unknown structured code", final.URL + "/other-code", final.URL + "/other-code", errcode.SubmitRejected},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receipt, finalURL, err := SubmitTraced(context.Background(), tt.url, &model.SignResponse{})
+			if finalURL != tt.wantFinal {
+				t.Errorf("finalURL = %q, want %q", finalURL, tt.wantFinal)
+			}
+			if code := errcode.Of(err); code != tt.wantCode {
+				t.Fatalf("err = %v (code %q), want code %q", err, code, tt.wantCode)
+			}
+			if err == nil && receipt.ReceiptID != "r-1" {
+				t.Errorf("receipt = %+v", receipt)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package net
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+// maxTranslationBytes bounds the size of a translation bundle.
+const maxTranslationBytes int64 = 256 << 10
+
+// FetchTranslations downloads the request's translation bundle and checks it
+// against the hash registered in the request before parsing it, so the labels
+// shown while signing are exactly the ones the promoter published.
+func FetchTranslations(ctx context.Context, t *model.Translations) (model.Labels, error) {
+	if t == nil {
+		return nil, nil
+	}
+
+	body, _, err := download(ctx, "translation bundle", t.URL, nil, 15*time.Second, maxTranslationBytes, func(body []byte, _ string) error {
+		sum := sha256.Sum256(body)
+		if got := base64.StdEncoding.EncodeToString(sum[:]); got != t.SHA256 {
+			return errcode.Errorf(errcode.TranslationHashMismatch, "translation bundle hash mismatch: expected %s but got %s", t.SHA256, got)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return model.ParseLabels(body)
+}
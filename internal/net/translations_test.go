@@ -0,0 +1,52 @@
+package net
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+func TestFetchTranslations(t *testing.T) {
+	bundle := []byte(`{"language":"ca","labels":{"consent":"Dono suport a aquesta iniciativa"}}`)
+	sum := sha256.Sum256(bundle)
+	hash := base64.StdEncoding.EncodeToString(sum[:])
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(bundle)
+	}))
+	defer srv.Close()
+
+	t.Run("match", func(t *testing.T) {
+		labels, err := FetchTranslations(context.Background(), &model.Translations{URL: srv.URL, SHA256: hash})
+		if err != nil {
+			t.Fatalf("FetchTranslations failed: %v", err)
+		}
+		if got := labels.Get(model.LabelConsent, ""); got != "Dono suport a aquesta iniciativa" {
+			t.Fatalf("consent = %q", got)
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		other := sha256.Sum256([]byte("other"))
+		_, err := FetchTranslations(context.Background(), &model.Translations{
+			URL:    srv.URL,
+			SHA256: base64.StdEncoding.EncodeToString(other[:]),
+		})
+		if err == nil || !strings.Contains(err.Error(), "hash mismatch") {
+			t.Fatalf("expected hash mismatch error, got %v", err)
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		labels, err := FetchTranslations(context.Background(), nil)
+		if err != nil || labels != nil {
+			t.Fatalf("expected nil labels and no error, got %v, %v", labels, err)
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package net
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+	"github.com/vocdoni/gofirma/vocsign/internal/translog"
+)
+
+// CheckTransparencyLog fetches the organizer's public request log, verifies
+// its hash chain and checks that req is logged with exactly this content.
+// With a pin, the checkpoint of the log last verified for this organizer,
+// the log must also extend it. It returns the log's current checkpoint for
+// the caller to pin.
+func CheckTransparencyLog(ctx context.Context, req *model.SignRequest, pin *translog.Checkpoint) (*translog.Entry, translog.Checkpoint, error) {
+	if req.TransparencyLog == nil {
+		return nil, translog.Checkpoint{}, nil
+	}
+	requestHash, err := req.CanonicalHash()
+	if err != nil {
+		return nil, translog.Checkpoint{}, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", req.TransparencyLog.URL, nil)
+	if err != nil {
+		return nil, translog.Checkpoint{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	client := newClient(15 * time.Second)
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, translog.Checkpoint{}, fmt.Errorf("transparency log fetch failed: %w", errcode.Network(err))
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, translog.Checkpoint{}, errcode.Errorf(errcode.HTTPStatus, "unexpected status code: %d", resp.StatusCode)
+	}
+	body, err := readAll(resp.Body, maxResponseBytes)
+	if err != nil {
+		return nil, translog.Checkpoint{}, fmt.Errorf("failed to read transparency log: %w", err)
+	}
+
+	var snap translog.Snapshot
+	if err := json.Unmarshal(body, &snap); err != nil {
+		return nil, translog.Checkpoint{}, errcode.Errorf(errcode.TransparencyLog, "failed to decode transparency log: %w", err)
+	}
+	if err := snap.Verify(); err != nil {
+		return nil, translog.Checkpoint{}, errcode.Errorf(errcode.TransparencyLog, "invalid transparency log: %w", err)
+	}
+	if pin != nil {
+		if err := snap.Extends(*pin); err != nil {
+			return nil, translog.Checkpoint{}, errcode.Wrap(errcode.TransparencyLogChanged, err)
+		}
+	}
+	entry, err := snap.CheckInclusion(req.RequestID, requestHash)
+	if err != nil {
+		return nil, translog.Checkpoint{}, err
+	}
+	return entry, snap.Checkpoint(), nil
+}
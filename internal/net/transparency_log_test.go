@@ -0,0 +1,81 @@
+package net
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+	"github.com/vocdoni/gofirma/vocsign/internal/translog"
+)
+
+func TestCheckTransparencyLog(t *testing.T) {
+	var l translog.Log
+	var tamper bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		snap := l.Snapshot()
+		if tamper && len(snap.Entries) > 0 {
+			snap.Entries[0].IssuedAt = "1999-01-01T00:00:00Z"
+		}
+		_ = json.NewEncoder(w).Encode(snap)
+	}))
+	defer srv.Close()
+
+	req := &model.SignRequest{
+		Version:         "1.0",
+		RequestID:       "req-1",
+		IssuedAt:        "2026-01-01T00:00:00Z",
+		TransparencyLog: &model.TransparencyLog{URL: srv.URL},
+	}
+
+	if _, _, err := CheckTransparencyLog(context.Background(), req, nil); !errors.Is(err, translog.ErrNotLogged) {
+		t.Fatalf("expected ErrNotLogged on empty log, got %v", err)
+	}
+
+	hash, _ := req.CanonicalHash()
+	if _, err := l.Append(req.RequestID, hash, req.IssuedAt); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	entry, head, err := CheckTransparencyLog(context.Background(), req, nil)
+	if err != nil {
+		t.Fatalf("CheckTransparencyLog: %v", err)
+	}
+	if entry.RequestHash != hash {
+		t.Errorf("entry hash = %q, want %q", entry.RequestHash, hash)
+	}
+	if head.Size != 1 || head.Head != entry.Hash {
+		t.Errorf("checkpoint = %+v, want the entry", head)
+	}
+
+	// The log must extend the checkpoint pinned for the organizer.
+	if _, _, err := CheckTransparencyLog(context.Background(), req, &head); err != nil {
+		t.Fatalf("CheckTransparencyLog with its own checkpoint: %v", err)
+	}
+	other := translog.Checkpoint{Size: 1, Head: "0000"}
+	if _, _, err := CheckTransparencyLog(context.Background(), req, &other); errcode.Of(err) != errcode.TransparencyLogChanged {
+		t.Fatalf("expected %s for a rewritten log, got %v", errcode.TransparencyLogChanged, err)
+	}
+
+	variant := *req
+	variant.Proposal.Summary = "served to a different user"
+	if _, _, err := CheckTransparencyLog(context.Background(), &variant, nil); !errors.Is(err, translog.ErrVariant) {
+		t.Fatalf("expected ErrVariant for modified request, got %v", err)
+	}
+
+	tamper = true
+	if _, _, err := CheckTransparencyLog(context.Background(), req, nil); err == nil || !strings.Contains(err.Error(), "invalid transparency log") {
+		t.Fatalf("expected chain verification error, got %v", err)
+	}
+}
+
+func TestCheckTransparencyLog_NotConfigured(t *testing.T) {
+	entry, _, err := CheckTransparencyLog(context.Background(), &model.SignRequest{RequestID: "req-1"}, nil)
+	if entry != nil || err != nil {
+		t.Fatalf("expected nil, nil without log; got %v, %v", entry, err)
+	}
+}
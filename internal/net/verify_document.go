@@ -5,59 +5,49 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
-	"net/http"
 	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
 )
 
 // VerifyDocumentHash downloads the document at docURL, computes its SHA-256
 // hash, and verifies it matches expectedHashBase64 (the base64-encoded hash
 // from the sign request manifest). This prevents proposal creators from
-// changing the document after people start signing.
+// changing the document after people start signing. ipfs:// URLs are resolved
+// through the configured gateways.
 func VerifyDocumentHash(ctx context.Context, docURL string, expectedHashBase64 string) error {
 	if docURL == "" {
-		return fmt.Errorf("document URL is empty")
+		return errcode.Errorf(errcode.DocumentMissing, "document URL is empty")
 	}
 	if expectedHashBase64 == "" {
-		return fmt.Errorf("expected document hash is empty")
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "GET", docURL, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request for document: %w", err)
-	}
-	// Match the headers sent by the webapp's hash-document endpoint so that
-	// the document server returns identical content to both clients.  Without
-	// this, CDNs may flag the default Go-http-client User-Agent as a bot and
-	// serve a challenge page instead of the document, causing a hash mismatch.
-	req.Header.Set("Accept", "*/*")
-	req.Header.Set("User-Agent", "VocSign/1.0")
-
-	client := newClient(30 * time.Second)
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to download document: %w", err)
+		return errcode.Errorf(errcode.DocumentMissing, "expected document hash is empty")
 	}
-	defer func() { _ = resp.Body.Close() }()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("document download returned status %d", resp.StatusCode)
-	}
+	_, _, err := download(ctx, "document", docURL, nil, 30*time.Second, maxResponseBytes, func(body []byte, contentType string) error {
+		actualHash := sha256.Sum256(body)
+		actualHashBase64 := base64.StdEncoding.EncodeToString(actualHash[:])
+		if actualHashBase64 != expectedHashBase64 {
+			return errcode.Errorf(errcode.DocumentHashMismatch,
+				"document hash mismatch: expected %s but got %s (content-type: %s, size: %d bytes)",
+				expectedHashBase64, actualHashBase64, contentType, len(body),
+			)
+		}
+		return nil
+	})
+	return err
+}
 
-	body, err := readAll(resp.Body, maxResponseBytes)
-	if err != nil {
-		return fmt.Errorf("failed to read document body: %w", err)
+// VerifyDocuments verifies the proposal full text and every other document
+// req pins by hash: the proposal attachments and the promoter's policies.
+func VerifyDocuments(ctx context.Context, req *model.SignRequest) error {
+	if err := VerifyDocumentHash(ctx, req.Proposal.FullText.URL, req.Proposal.FullText.SHA256); err != nil {
+		return err
 	}
-
-	actualHash := sha256.Sum256(body)
-	actualHashBase64 := base64.StdEncoding.EncodeToString(actualHash[:])
-
-	if actualHashBase64 != expectedHashBase64 {
-		ct := resp.Header.Get("Content-Type")
-		return fmt.Errorf(
-			"document hash mismatch: expected %s but got %s (content-type: %s, size: %d bytes)",
-			expectedHashBase64, actualHashBase64, ct, len(body),
-		)
+	for _, d := range req.Documents() {
+		if err := VerifyDocumentHash(ctx, d.URL, d.SHA256); err != nil {
+			return fmt.Errorf("%s: %w", d.Title, err)
+		}
 	}
-
 	return nil
 }
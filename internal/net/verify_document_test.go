@@ -8,6 +8,9 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
 )
 
 func TestVerifyDocumentHash_Match(t *testing.T) {
@@ -56,3 +59,37 @@ func TestVerifyDocumentHash_Unreachable(t *testing.T) {
 		t.Errorf("Expected error about download failure, got: %v", err)
 	}
 }
+
+func TestVerifyDocuments(t *testing.T) {
+	docs := map[string][]byte{
+		"/text":    []byte("full text"),
+		"/annex":   []byte("economic report"),
+		"/privacy": []byte("privacy notice"),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(docs[r.URL.Path])
+	}))
+	defer srv.Close()
+	hash := func(path string) string {
+		sum := sha256.Sum256(docs[path])
+		return base64.StdEncoding.EncodeToString(sum[:])
+	}
+
+	req := &model.SignRequest{
+		Version: model.Version2,
+		Proposal: model.Proposal{
+			FullText:    model.FullText{URL: srv.URL + "/text", SHA256: hash("/text")},
+			Attachments: []model.Document{{Title: "Economic report", URL: srv.URL + "/annex", SHA256: hash("/annex")}},
+		},
+		Policies: []model.Document{{Title: "Privacy notice", URL: srv.URL + "/privacy", SHA256: hash("/privacy")}},
+	}
+	if err := VerifyDocuments(context.Background(), req); err != nil {
+		t.Fatalf("VerifyDocuments failed: %v", err)
+	}
+
+	req.Policies[0].SHA256 = hash("/annex")
+	err := VerifyDocuments(context.Background(), req)
+	if errcode.Of(err) != errcode.DocumentHashMismatch || !strings.HasPrefix(err.Error(), "Privacy notice: ") {
+		t.Fatalf("expected a hash mismatch of the privacy notice, got %v", err)
+	}
+}
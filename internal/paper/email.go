@@ -0,0 +1,80 @@
+package paper
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+)
+
+// ReceiptEmailSubject is the subject of the email that sends the receipt.
+func ReceiptEmailSubject(r Receipt) string {
+	return fmt.Sprintf("Justificant de signatura: %s (%s)", r.Request.Proposal.Title, r.Submit.ReceiptID)
+}
+
+// ReceiptEmailBody is the text of the email that sends the receipt.
+func ReceiptEmailBody(r Receipt) string {
+	return fmt.Sprintf("Adjunt el justificant de la signatura de «%s».\n\nIdentificador del justificant: %s\nCodi de la sol·licitud: %s\nData de la signatura: %s\n",
+		r.Request.Proposal.Title, r.Submit.ReceiptID, r.Request.RequestID, r.Response.SignedAt)
+}
+
+// WriteReceiptEmail writes an unsent email with the receipt PDF attached
+// as pdfName. Apple Mail and Outlook open it as a new draft, since it is
+// marked X-Unsent.
+func WriteReceiptEmail(w io.Writer, r Receipt, pdfName string) error {
+	var pdf bytes.Buffer
+	if err := WriteReceiptPDF(&pdf, r); err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	text, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	qp := quotedprintable.NewWriter(text)
+	if _, err := io.WriteString(qp, ReceiptEmailBody(r)); err != nil {
+		return err
+	}
+	if err := qp.Close(); err != nil {
+		return err
+	}
+	attachment, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {mime.FormatMediaType("application/pdf", map[string]string{"name": pdfName})},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": pdfName})},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return err
+	}
+	enc := base64.StdEncoding.EncodeToString(pdf.Bytes())
+	for len(enc) > 76 {
+		if _, err := io.WriteString(attachment, enc[:76]+"\r\n"); err != nil {
+			return err
+		}
+		enc = enc[76:]
+	}
+	if _, err := io.WriteString(attachment, enc+"\r\n"); err != nil {
+		return err
+	}
+	if err := mw.Close(); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "X-Unsent: 1\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: %s\r\n\r\n",
+		mime.QEncoding.Encode("utf-8", ReceiptEmailSubject(r)),
+		mime.FormatMediaType("multipart/mixed", map[string]string{"boundary": mw.Boundary()}))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(body.Bytes())
+	return err
+}
@@ -0,0 +1,58 @@
+package paper
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestWriteReceiptEmail(t *testing.T) {
+	r := testReceipt()
+	var buf bytes.Buffer
+	if err := WriteReceiptEmail(&buf, r, "vocsign-receipt-ILP-TEST.pdf"); err != nil {
+		t.Fatalf("WriteReceiptEmail: %v", err)
+	}
+	msg, err := mail.ReadMessage(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Header.Get("X-Unsent") != "1" {
+		t.Fatal("not marked unsent")
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "Justificant de signatura: Llei de prova (RCPT-42)" {
+		t.Fatalf("Subject = %q, %v", subject, err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("Content-Type = %q, %v", mediaType, err)
+	}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	text, err := mr.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(text)
+	if !strings.Contains(string(body), "RCPT-42") || !strings.Contains(string(body), "sol·licitud") {
+		t.Fatalf("body = %q", body)
+	}
+	pdf, err := mr.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pdf.FileName() != "vocsign-receipt-ILP-TEST.pdf" {
+		t.Fatalf("attachment = %q", pdf.FileName())
+	}
+	data, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, pdf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "%PDF-") {
+		t.Fatalf("attachment is not the PDF: %.20q", data)
+	}
+}
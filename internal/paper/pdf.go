@@ -0,0 +1,191 @@
+package paper
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/qr"
+)
+
+// A4 in points, and the page margin.
+const (
+	pdfWidth  = 595
+	pdfHeight = 842
+	pdfMargin = 56
+)
+
+// pdfLine is a line of text in the receipt PDF.
+type pdfLine struct {
+	text string
+	bold bool
+	size float64
+	// gap is the space before the line, in points.
+	gap float64
+}
+
+// WriteReceiptPDF writes r as a PDF, for sharing or archiving where the
+// HTML receipt cannot be opened. It uses the standard Helvetica fonts, so
+// it only needs the Windows-1252 characters of the Catalan and Spanish
+// texts; others are written as '?'.
+func WriteReceiptPDF(w io.Writer, r Receipt) error {
+	if r.Request == nil || r.Response == nil || r.Submit == nil {
+		return errors.New("incomplete receipt")
+	}
+	code, err := qr.Encode([]byte(ReceiptQRPayload(r.Request.RequestID, r.Submit.ReceiptID, r.Response.PayloadCanonicalSHA256)))
+	if err != nil {
+		return fmt.Errorf("failed to encode QR: %w", err)
+	}
+
+	lines := []pdfLine{
+		{text: "Justificant de signatura", bold: true, size: 16},
+		{text: r.Request.Proposal.Title, size: 11, gap: 6},
+		{text: "Comissió promotora: " + r.Request.Proposal.Promoter, size: 9},
+		{text: "Codi: " + r.Request.RequestID, size: 9},
+	}
+	field := func(label, value string) {
+		if value == "" {
+			return
+		}
+		lines = append(lines, pdfLine{text: strings.ToUpper(label), bold: true, size: 8, gap: 8}, pdfLine{text: value, size: 10})
+	}
+	// The QR code is drawn to the right of the heading; keep the fields
+	// below it.
+	lines = append(lines, pdfLine{gap: 56})
+	field("Signant", r.SignerName)
+	field("Identificador del justificant", r.Submit.ReceiptID)
+	field("Estat", r.Submit.Status)
+	field("Data de la signatura", r.SignedAt())
+	field("Data de recepció", r.ReceivedAt())
+	field("Format", r.Response.SignatureFormat)
+	field("Resum de les dades signades (SHA-256)", r.Response.PayloadCanonicalSHA256)
+	field("Text íntegre (SHA-256)", r.Response.DocumentSHA256)
+	field("Declaració", r.Request.Proposal.LegalStatement)
+	lines = append(lines, pdfLine{text: "Imprès: " + r.Locale.DateTime(time.Now()), size: 8, gap: 16})
+
+	return writePDF(w, lines, code)
+}
+
+// writePDF lays lines out on as many A4 pages as they need, with code in
+// the top right corner of the first page.
+func writePDF(w io.Writer, lines []pdfLine, code *qr.Code) error {
+	var pages []bytes.Buffer
+	var page *bytes.Buffer
+	y := 0.0
+	newPage := func() {
+		pages = append(pages, bytes.Buffer{})
+		page = &pages[len(pages)-1]
+		y = pdfHeight - pdfMargin
+	}
+	newPage()
+	if code != nil {
+		writeQR(page, code)
+	}
+	enc := charmap.Windows1252.NewEncoder()
+	enc = encoding.ReplaceUnsupported(enc)
+	for _, l := range lines {
+		y -= l.gap
+		if l.text == "" {
+			continue
+		}
+		font := "F1"
+		if l.bold {
+			font = "F2"
+		}
+		// Helvetica averages about half an em per character.
+		width := int((pdfWidth - 2*pdfMargin) / (l.size * 0.5))
+		for _, part := range wrap(l.text, width) {
+			leading := l.size * 1.35
+			if y-leading < pdfMargin {
+				newPage()
+			}
+			y -= leading
+			text, _ := enc.String(part)
+			fmt.Fprintf(page, "BT /%s %.1f Tf %d %.1f Td (%s) Tj ET\n", font, l.size, pdfMargin, y, pdfEscape(text))
+		}
+	}
+
+	var out bytes.Buffer
+	var offsets []int
+	obj := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+	out.WriteString("%PDF-1.4\n")
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i := range pages {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", pdfWidth, pdfHeight, 6+2*i))
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", pages[i].Len(), pages[i].String()))
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// writeQR draws code, two points per module, in the top right corner.
+func writeQR(page *bytes.Buffer, code *qr.Code) {
+	const module = 2.0
+	x0 := pdfWidth - pdfMargin - float64(code.Size)*module
+	y0 := float64(pdfHeight - pdfMargin)
+	page.WriteString("0 g\n")
+	for y, row := range code.Modules {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(page, "%.1f %.1f %.1f %.1f re\n", x0+float64(x)*module, y0-float64(y+1)*module, module, module)
+			}
+		}
+	}
+	page.WriteString("f\n")
+}
+
+// wrap splits text into lines of at most width characters, at spaces
+// where it can and within words, such as hashes, that are longer.
+func wrap(text string, width int) []string {
+	var lines []string
+	for _, para := range strings.Split(text, "\n") {
+		var cur []rune
+		for _, word := range strings.Fields(para) {
+			rw := []rune(word)
+			if len(cur) > 0 && len(cur)+1+len(rw) > width {
+				lines = append(lines, string(cur))
+				cur = nil
+			}
+			for len(rw) > width {
+				if len(cur) > 0 {
+					lines = append(lines, string(cur))
+					cur = nil
+				}
+				lines = append(lines, string(rw[:width]))
+				rw = rw[width:]
+			}
+			if len(cur) > 0 {
+				cur = append(cur, ' ')
+			}
+			cur = append(cur, rw...)
+		}
+		lines = append(lines, string(cur))
+	}
+	return lines
+}
+
+func pdfEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`, "\r", " ").Replace(s)
+}
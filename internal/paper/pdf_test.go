@@ -0,0 +1,75 @@
+package paper
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+func testReceipt() Receipt {
+	return Receipt{
+		Request:    testRequest(),
+		Response:   &model.SignResponse{SignedAt: "2026-10-17T10:00:00Z", SignatureFormat: "CAdES-detached", PayloadCanonicalSHA256: "abc123"},
+		Submit:     &model.SubmitReceipt{ReceiptID: "RCPT-42", Status: "accepted"},
+		SignerName: "Maria (Puig)",
+	}
+}
+
+func TestWriteReceiptPDF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteReceiptPDF(&buf, testReceipt()); err != nil {
+		t.Fatalf("WriteReceiptPDF: %v", err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "%PDF-1.4\n") || !strings.HasSuffix(out, "%%EOF\n") {
+		t.Fatal("not a PDF")
+	}
+	for _, want := range []string{"(RCPT-42)", "(Maria \\(Puig\\))", "(accepted)", " re\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("PDF missing %q", want)
+		}
+	}
+	// "Comissió" is written in Windows-1252.
+	if !strings.Contains(out, "Comissi\xf3") {
+		t.Error("accented text not encoded as WinAnsi")
+	}
+
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(out)
+	if m == nil {
+		t.Fatal("no startxref")
+	}
+	xref, _ := strconv.Atoi(m[1])
+	if !strings.HasPrefix(out[xref:], "xref\n") {
+		t.Fatalf("startxref %d does not point to the xref table", xref)
+	}
+	for i, off := range regexp.MustCompile(`(\d{10}) 00000 n`).FindAllStringSubmatch(out, -1) {
+		n, _ := strconv.Atoi(off[1])
+		if want := strconv.Itoa(i+1) + " 0 obj"; !strings.HasPrefix(out[n:], want) {
+			t.Fatalf("xref entry %d points to %q", i+1, out[n:n+10])
+		}
+	}
+}
+
+func TestWriteReceiptPDFPages(t *testing.T) {
+	r := testReceipt()
+	r.Request.Proposal.LegalStatement = strings.Repeat("Dono suport a aquesta iniciativa legislativa popular. ", 200)
+	var buf bytes.Buffer
+	if err := WriteReceiptPDF(&buf, r); err != nil {
+		t.Fatalf("WriteReceiptPDF: %v", err)
+	}
+	if n := strings.Count(buf.String(), "/Type /Page "); n < 2 {
+		t.Fatalf("long statement on %d pages", n)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	got := wrap("one two three "+strings.Repeat("x", 12), 10)
+	want := []string{"one two", "three", "xxxxxxxxxx", "xx"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("wrap = %q", got)
+	}
+}
@@ -0,0 +1,175 @@
+package paper
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/locale"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+	"github.com/vocdoni/gofirma/vocsign/internal/qr"
+)
+
+// ReceiptQRPayload is the text encoded in the receipt QR code, which lets a
+// collection point look the signature up at the collector.
+func ReceiptQRPayload(requestID, receiptID, payloadSHA256 string) string {
+	return "VOCSIGN-RECEIPT:1:" + requestID + ":" + receiptID + ":" + payloadSHA256
+}
+
+type detailsData struct {
+	Req         *model.SignRequest
+	Title       string
+	Summary     string
+	RequestHash string
+	QR          template.HTML
+	Generated   string
+}
+
+// WriteDetails writes the proposal details and legal statement of req as a
+// print-ready HTML page that opens the browser's print dialog, where it can
+// also be saved as PDF. lang picks a localization of the title and summary,
+// empty for the original.
+func WriteDetails(w io.Writer, req *model.SignRequest, lang string) error {
+	hash, err := req.CanonicalHash()
+	if err != nil {
+		return err
+	}
+	code, err := qr.Encode([]byte(QRPayload(req.RequestID, hash)))
+	if err != nil {
+		return fmt.Errorf("failed to encode QR: %w", err)
+	}
+	data := detailsData{
+		Req:         req,
+		RequestHash: hash,
+		QR:          template.HTML(code.SVG(3)),
+		Generated:   time.Now().Format("2006-01-02 15:04"),
+	}
+	data.Title, data.Summary = req.Localized(lang)
+	return detailsTemplate.Execute(w, data)
+}
+
+// Receipt is a submitted signature, printed for the signer to keep.
+type Receipt struct {
+	Request  *model.SignRequest
+	Response *model.SignResponse
+	// Submit is the collector's acknowledgement.
+	Submit     *model.SubmitReceipt
+	SignerName string
+	// Locale writes the dates, ISO 8601 when zero.
+	Locale locale.Locale
+}
+
+// SignedAt is when the signature was made, in r.Locale.
+func (r Receipt) SignedAt() string {
+	return r.Locale.Timestamp(r.Response.SignedAt)
+}
+
+// ReceivedAt is when the collector received the signature, in r.Locale;
+// empty if it did not say.
+func (r Receipt) ReceivedAt() string {
+	if r.Submit.ReceivedAt == "" {
+		return ""
+	}
+	return r.Locale.Timestamp(r.Submit.ReceivedAt)
+}
+
+type receiptData struct {
+	Receipt
+	QR        template.HTML
+	Generated string
+}
+
+// WriteReceipt writes r as a print-ready HTML page that opens the browser's
+// print dialog.
+func WriteReceipt(w io.Writer, r Receipt) error {
+	if r.Request == nil || r.Response == nil || r.Submit == nil {
+		return errors.New("incomplete receipt")
+	}
+	code, err := qr.Encode([]byte(ReceiptQRPayload(r.Request.RequestID, r.Submit.ReceiptID, r.Response.PayloadCanonicalSHA256)))
+	if err != nil {
+		return fmt.Errorf("failed to encode QR: %w", err)
+	}
+	return receiptTemplate.Execute(w, receiptData{
+		Receipt:   r,
+		QR:        template.HTML(code.SVG(3)),
+		Generated: r.Locale.DateTime(time.Now()),
+	})
+}
+
+// printPage is the layout shared by the printed documents. The print
+// button is only shown on screen, for when the dialog was dismissed.
+const printPage = `{{define "page"}}<!DOCTYPE html>
+<html lang="ca">
+<head>
+<meta charset="utf-8">
+<title>{{template "title" .}}</title>
+<style>
+  @page { size: A4 portrait; margin: 16mm; }
+  body { font-family: Helvetica, Arial, sans-serif; font-size: 11pt; color: #000; max-width: 180mm; margin: 0 auto; }
+  .head { display: flex; justify-content: space-between; gap: 16px; align-items: flex-start; }
+  h1 { font-size: 15pt; margin: 0 0 8px 0; }
+  .meta { font-size: 9pt; margin: 2px 0; }
+  .legal { border: 1px solid #000; padding: 8px; margin: 12px 0; }
+  .hash { font-family: monospace; font-size: 8pt; word-break: break-all; }
+  dl { display: grid; grid-template-columns: max-content 1fr; gap: 4px 12px; }
+  dt { font-size: 9pt; font-weight: bold; text-transform: uppercase; }
+  dd { margin: 0; word-break: break-all; }
+  .toolbar { margin: 12px 0; }
+  @media print { .toolbar { display: none; } }
+</style>
+</head>
+<body>
+<div class="toolbar"><button onclick="window.print()">Imprimeix / Desa com a PDF</button></div>
+{{template "body" .}}
+<script>window.addEventListener("load", function () { window.print(); });</script>
+</body>
+</html>
+{{end}}`
+
+var detailsTemplate = template.Must(template.Must(template.New("details").Parse(printPage)).Parse(`
+{{define "title"}}{{.Title}}{{end}}
+{{define "body"}}
+<div class="head">
+  <div>
+    <h1>{{.Title}}</h1>
+    <div class="meta">Comissió promotora: <b>{{.Req.Proposal.Promoter}}</b></div>
+    <div class="meta">Àmbit: {{.Req.Proposal.Jurisdiction}} · Codi: {{.Req.RequestID}}{{if .Req.Proposal.DocumentVersion}} · Versió del text: {{.Req.Proposal.DocumentVersion}}{{end}}</div>
+    <div class="meta">Text íntegre: {{.Req.Proposal.FullText.URL}}</div>
+  </div>
+  <div>{{.QR}}</div>
+</div>
+<p>{{.Summary}}</p>
+{{if .Req.Proposal.LegalStatement}}<h2>Declaració</h2>
+<div class="legal">{{.Req.Proposal.LegalStatement}}</div>{{end}}
+<p class="hash">Sol·licitud SHA-256: {{.RequestHash}}<br>Text íntegre SHA-256: {{.Req.Proposal.FullText.SHA256}}</p>
+<p class="meta">Imprès: {{.Generated}}</p>
+{{end}}
+{{template "page" .}}`))
+
+var receiptTemplate = template.Must(template.Must(template.New("receipt").Parse(printPage)).Parse(`
+{{define "title"}}Justificant de signatura - {{.Request.Proposal.Title}}{{end}}
+{{define "body"}}
+<div class="head">
+  <div>
+    <h1>Justificant de signatura</h1>
+    <div class="meta">{{.Request.Proposal.Title}}</div>
+    <div class="meta">Comissió promotora: <b>{{.Request.Proposal.Promoter}}</b> · Codi: {{.Request.RequestID}}</div>
+  </div>
+  <div>{{.QR}}</div>
+</div>
+<dl>
+  {{if .SignerName}}<dt>Signant</dt><dd>{{.SignerName}}</dd>{{end}}
+  <dt>Identificador del justificant</dt><dd>{{.Submit.ReceiptID}}</dd>
+  <dt>Estat</dt><dd>{{.Submit.Status}}</dd>
+  <dt>Data de la signatura</dt><dd>{{.SignedAt}}</dd>
+  {{with .ReceivedAt}}<dt>Data de recepció</dt><dd>{{.}}</dd>{{end}}
+  <dt>Format</dt><dd>{{.Response.SignatureFormat}}</dd>
+  <dt>Resum de les dades signades (SHA-256)</dt><dd class="hash">{{.Response.PayloadCanonicalSHA256}}</dd>
+  {{if .Response.DocumentSHA256}}<dt>Text íntegre (SHA-256)</dt><dd class="hash">{{.Response.DocumentSHA256}}</dd>{{end}}
+</dl>
+{{if .Request.Proposal.LegalStatement}}<div class="legal">{{.Request.Proposal.LegalStatement}}</div>{{end}}
+<p class="meta">Imprès: {{.Generated}}</p>
+{{end}}
+{{template "page" .}}`))
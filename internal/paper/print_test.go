@@ -0,0 +1,68 @@
+package paper
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/locale"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+func TestWriteDetails(t *testing.T) {
+	req := testRequest()
+	req.Proposal.Summary = "Resum original"
+	req.Localizations = map[string]model.Localization{"es": {Title: "Ley de prueba"}}
+	var buf bytes.Buffer
+	if err := WriteDetails(&buf, req, "es"); err != nil {
+		t.Fatalf("WriteDetails: %v", err)
+	}
+	out := buf.String()
+	hash, _ := req.CanonicalHash()
+	for _, want := range []string{"Ley de prueba", "Resum original", "Comissió de prova", "Dono suport", hash, "<svg", "window.print()"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("details missing %q", want)
+		}
+	}
+	if strings.Contains(out, "Llei de prova") {
+		t.Error("details show the original title instead of the localized one")
+	}
+}
+
+func TestWriteReceipt(t *testing.T) {
+	r := Receipt{
+		Request:    testRequest(),
+		Response:   &model.SignResponse{SignedAt: "2026-10-17T10:00:00Z", SignatureFormat: "CAdES-BES", PayloadCanonicalSHA256: "abc123"},
+		Submit:     &model.SubmitReceipt{ReceiptID: "RCPT-42", Status: "accepted"},
+		SignerName: "Maria <Puig>",
+	}
+	var buf bytes.Buffer
+	if err := WriteReceipt(&buf, r); err != nil {
+		t.Fatalf("WriteReceipt: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"RCPT-42", "accepted", "CAdES-BES", "abc123", "Maria &lt;Puig&gt;", "Llei de prova", "<svg"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("receipt missing %q", want)
+		}
+	}
+	if strings.Contains(out, "Data de recepció") {
+		t.Error("empty received time printed")
+	}
+
+	buf.Reset()
+	r.Locale = locale.Get("en-GB")
+	r.Response.SignedAt = time.Date(2026, 10, 17, 10, 0, 0, 0, time.Local).Format(time.RFC3339)
+	if err := WriteReceipt(&buf, r); err != nil {
+		t.Fatalf("WriteReceipt: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "17/10/2026 10:00") {
+		t.Error("signing time not written in the receipt's locale")
+	}
+
+	r.Submit = nil
+	if err := WriteReceipt(&buf, r); err == nil {
+		t.Fatal("receipt without acknowledgement written")
+	}
+}
@@ -0,0 +1,102 @@
+// Package paper renders printable ILP signature sheets for signers who do not
+// have a digital certificate. Each sheet carries the proposal data and a QR
+// code binding it to the exact sign request used for digital collection.
+package paper
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+	"github.com/vocdoni/gofirma/vocsign/internal/qr"
+)
+
+// DefaultRows is the number of signer rows printed per sheet.
+const DefaultRows = 10
+
+// MaxRows bounds the signer rows of a sheet, which may be asked for by
+// anyone who can reach the collector.
+const MaxRows = 1000
+
+// QRPayload is the text encoded in the sheet QR code.
+func QRPayload(requestID, requestHash string) string {
+	return "VOCSIGN:1:" + requestID + ":" + requestHash
+}
+
+type sheetData struct {
+	Req         *model.SignRequest
+	RequestHash string
+	QR          template.HTML
+	Rows        []int
+	Generated   string
+}
+
+// WriteSheet writes a self-contained, print-ready HTML signature sheet with
+// rows signer rows, DefaultRows if not positive and at most MaxRows.
+func WriteSheet(w io.Writer, req *model.SignRequest, rows int) error {
+	if rows <= 0 {
+		rows = DefaultRows
+	}
+	rows = min(rows, MaxRows)
+	hash, err := req.CanonicalHash()
+	if err != nil {
+		return err
+	}
+	code, err := qr.Encode([]byte(QRPayload(req.RequestID, hash)))
+	if err != nil {
+		return fmt.Errorf("failed to encode QR: %w", err)
+	}
+
+	data := sheetData{
+		Req:         req,
+		RequestHash: hash,
+		QR:          template.HTML(code.SVG(3)),
+		Generated:   time.Now().Format("2006-01-02"),
+	}
+	for i := 1; i <= rows; i++ {
+		data.Rows = append(data.Rows, i)
+	}
+	return sheetTemplate.Execute(w, data)
+}
+
+var sheetTemplate = template.Must(template.New("sheet").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Req.Proposal.Title}} - Full de signatures</title>
+<style>
+  @page { size: A4 landscape; margin: 12mm; }
+  body { font-family: Helvetica, Arial, sans-serif; font-size: 10pt; color: #000; }
+  .head { display: flex; justify-content: space-between; gap: 16px; }
+  h1 { font-size: 13pt; margin: 0 0 6px 0; }
+  .meta { font-size: 9pt; }
+  .legal { border: 1px solid #000; padding: 6px; margin: 8px 0; font-size: 9pt; }
+  .hash { font-family: monospace; font-size: 7pt; word-break: break-all; }
+  table { width: 100%; border-collapse: collapse; }
+  th, td { border: 1px solid #000; padding: 4px; text-align: left; }
+  td { height: 28px; }
+  th { font-size: 8pt; background: #eee; }
+</style>
+</head>
+<body>
+<div class="head">
+  <div>
+    <h1>{{.Req.Proposal.Title}}</h1>
+    <div class="meta">Comissió promotora: <b>{{.Req.Proposal.Promoter}}</b></div>
+    <div class="meta">Àmbit: {{.Req.Proposal.Jurisdiction}} · Codi: {{.Req.RequestID}} · Imprès: {{.Generated}}</div>
+    <div class="meta">Text íntegre: {{.Req.Proposal.FullText.URL}}</div>
+  </div>
+  <div>{{.QR}}</div>
+</div>
+{{if .Req.Proposal.LegalStatement}}<div class="legal">{{.Req.Proposal.LegalStatement}}</div>{{end}}
+<table>
+  <tr><th>#</th><th>Nom</th><th>Primer cognom</th><th>Segon cognom</th><th>Data de naixement</th><th>DNI / NIE</th><th>Signatura</th></tr>
+  {{range .Rows}}<tr><td>{{.}}</td><td></td><td></td><td></td><td></td><td></td><td></td></tr>
+  {{end}}
+</table>
+<p class="hash">Sol·licitud SHA-256: {{.RequestHash}}</p>
+</body>
+</html>
+`))
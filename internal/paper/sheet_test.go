@@ -0,0 +1,53 @@
+package paper
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+func testRequest() *model.SignRequest {
+	return &model.SignRequest{
+		Version:   "1.0",
+		RequestID: "ILP-TEST",
+		Proposal: model.Proposal{
+			Title:          "Llei de prova",
+			Promoter:       "Comissió de prova",
+			LegalStatement: "Dono suport a aquesta iniciativa.",
+		},
+		OrganizerSignature: &model.OrganizerSignature{Format: "JWS", Value: "a.b.c"},
+	}
+}
+
+func TestWriteSheet(t *testing.T) {
+	req := testRequest()
+	var buf bytes.Buffer
+	if err := WriteSheet(&buf, req, 3); err != nil {
+		t.Fatalf("WriteSheet: %v", err)
+	}
+	out := buf.String()
+	hash, _ := req.CanonicalHash()
+
+	for _, want := range []string{"Llei de prova", "Comissió de prova", "Dono suport", hash, "<svg"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("sheet missing %q", want)
+		}
+	}
+	if got := strings.Count(out, "<tr><td>"); got != 3 {
+		t.Errorf("rows = %d, want 3", got)
+	}
+}
+
+func TestWriteSheet_Rows(t *testing.T) {
+	for rows, want := range map[int]int{0: DefaultRows, -5: DefaultRows, MaxRows + 1: MaxRows, 1 << 30: MaxRows} {
+		var buf bytes.Buffer
+		if err := WriteSheet(&buf, testRequest(), rows); err != nil {
+			t.Fatalf("WriteSheet(%d): %v", rows, err)
+		}
+		if got := strings.Count(buf.String(), "<tr><td>"); got != want {
+			t.Errorf("WriteSheet(%d) rows = %d, want %d", rows, got, want)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Package perf measures how long the UI takes to produce its frames and
+// where that time goes, for the developer overlay and jank reports.
+//
+// Frame times are always recorded; it costs two clock reads per frame.
+// Sections, the named parts of a frame such as a screen or the rows of a
+// list, are only timed while detail is enabled, since a long list times
+// every row.
+package perf
+
+import (
+	"slices"
+	"sort"
+	"time"
+)
+
+const (
+	// Window is the number of recent frames the statistics cover.
+	Window = 120
+	// JankThreshold is the frame time above which a frame is a jank: the
+	// UI missed three refreshes of a 60 Hz display.
+	JankThreshold = 50 * time.Millisecond
+	// warmupFrames are not checked for jank; the first frames also shape
+	// the fonts and load images.
+	warmupFrames = 3
+)
+
+// Jank is a frame that took longer than JankThreshold.
+type Jank struct {
+	Duration time.Duration
+	// Screen is the screen the frame laid out.
+	Screen string
+	// Hot is the section that took longest in the frame, if detail was
+	// enabled, and HotDuration its time.
+	Hot         string
+	HotDuration time.Duration
+}
+
+// Hot is the time a section takes per frame over the window.
+type Hot struct {
+	Name string
+	// Avg is the average time per frame the section was laid out in, and
+	// Max the longest in a single frame.
+	Avg   time.Duration
+	Max   time.Duration
+	Calls int
+}
+
+// Stats summarizes the recent frames.
+type Stats struct {
+	Frames int
+	Avg    time.Duration
+	P95    time.Duration
+	Max    time.Duration
+	Janks  int
+	// LastJank is the most recent jank, zero if there was none.
+	LastJank Jank
+	// Hot are the sections by average time, longest first.
+	Hot []Hot
+}
+
+type sectionStat struct {
+	total  time.Duration
+	max    time.Duration
+	frames int
+	calls  int
+}
+
+// Recorder collects the timings of the frames of one window. It is used
+// from the UI goroutine only.
+type Recorder struct {
+	// OnJank, if set, is called with each jank after warm-up.
+	OnJank func(Jank)
+
+	now    func() time.Time
+	detail bool
+	frames [Window]time.Duration
+	count  int // frames recorded, not capped at Window
+	start  time.Time
+
+	frame    map[string]time.Duration // section times of the current frame
+	calls    map[string]int
+	sections map[string]*sectionStat
+	janks    int
+	lastJank Jank
+}
+
+// New returns a Recorder with section detail disabled.
+func New() *Recorder {
+	return &Recorder{
+		now:      time.Now,
+		frame:    make(map[string]time.Duration),
+		calls:    make(map[string]int),
+		sections: make(map[string]*sectionStat),
+	}
+}
+
+// SetDetail enables or disables timing sections. Disabling it drops the
+// section statistics.
+func (r *Recorder) SetDetail(on bool) {
+	r.detail = on
+	if !on {
+		clear(r.sections)
+	}
+}
+
+// Detail reports whether sections are timed.
+func (r *Recorder) Detail() bool {
+	return r != nil && r.detail
+}
+
+// BeginFrame marks the start of a frame.
+func (r *Recorder) BeginFrame(now time.Time) {
+	r.start = now
+	clear(r.frame)
+	clear(r.calls)
+}
+
+// Section starts timing the named part of the frame and returns the
+// function that stops it, meant to be deferred. It does nothing unless
+// detail is enabled, and a nil Recorder is allowed.
+func (r *Recorder) Section(name string) func() {
+	if !r.Detail() {
+		return func() {}
+	}
+	start := r.now()
+	return func() {
+		r.frame[name] += r.now().Sub(start)
+		r.calls[name]++
+	}
+}
+
+// EndFrame records the frame that began with BeginFrame, laid out for
+// screen, and reports it if it was a jank.
+func (r *Recorder) EndFrame(now time.Time, screen string) {
+	d := now.Sub(r.start)
+	r.frames[r.count%Window] = d
+	r.count++
+
+	if r.count%Window == 0 {
+		// Start a new window, so hot spots reflect what is shown now.
+		clear(r.sections)
+	}
+	var hot string
+	var hotDur time.Duration
+	for name, t := range r.frame {
+		if r.detail {
+			st := r.sections[name]
+			if st == nil {
+				st = &sectionStat{}
+				r.sections[name] = st
+			}
+			st.total += t
+			st.max = max(st.max, t)
+			st.frames++
+			st.calls += r.calls[name]
+		}
+		if t > hotDur || (t == hotDur && name < hot) {
+			hot, hotDur = name, t
+		}
+	}
+
+	if d < JankThreshold || r.count <= warmupFrames {
+		return
+	}
+	j := Jank{Duration: d, Screen: screen, Hot: hot, HotDuration: hotDur}
+	r.janks++
+	r.lastJank = j
+	if r.OnJank != nil {
+		r.OnJank(j)
+	}
+}
+
+// Stats summarizes the frames in the window and the sections timed in it.
+func (r *Recorder) Stats() Stats {
+	n := min(r.count, Window)
+	st := Stats{Frames: n, Janks: r.janks, LastJank: r.lastJank}
+	if n == 0 {
+		return st
+	}
+	durs := slices.Clone(r.frames[:n])
+	slices.Sort(durs)
+	var total time.Duration
+	for _, d := range durs {
+		total += d
+	}
+	st.Avg = total / time.Duration(n)
+	st.P95 = durs[(n*95-1)/100]
+	st.Max = durs[n-1]
+
+	for name, s := range r.sections {
+		st.Hot = append(st.Hot, Hot{Name: name, Avg: s.total / time.Duration(s.frames), Max: s.max, Calls: s.calls})
+	}
+	sort.Slice(st.Hot, func(i, j int) bool {
+		if st.Hot[i].Avg != st.Hot[j].Avg {
+			return st.Hot[i].Avg > st.Hot[j].Avg
+		}
+		return st.Hot[i].Name < st.Hot[j].Name
+	})
+	return st
+}
@@ -0,0 +1,86 @@
+package perf
+
+import (
+	"testing"
+	"time"
+)
+
+// frame records a frame of total length d whose sections took the given
+// times, using a fake clock.
+func frame(r *Recorder, clock *time.Time, d time.Duration, sections map[string]time.Duration) {
+	r.BeginFrame(*clock)
+	for name, t := range sections {
+		stop := r.Section(name)
+		*clock = clock.Add(t)
+		stop()
+	}
+	r.EndFrame(r.start.Add(d), "certificates")
+	*clock = r.start.Add(d)
+}
+
+func TestStats(t *testing.T) {
+	clock := time.Unix(0, 0)
+	r := New()
+	r.now = func() time.Time { return clock }
+	for i := 1; i <= 20; i++ {
+		frame(r, &clock, time.Duration(i)*time.Millisecond, nil)
+	}
+	st := r.Stats()
+	if st.Frames != 20 || st.Max != 20*time.Millisecond || st.P95 != 19*time.Millisecond {
+		t.Fatalf("Stats = %+v", st)
+	}
+	if st.Avg != 10500*time.Microsecond {
+		t.Fatalf("Avg = %v", st.Avg)
+	}
+	if len(st.Hot) != 0 {
+		t.Fatalf("sections timed without detail: %+v", st.Hot)
+	}
+
+	// The window keeps the most recent frames only.
+	for range Window {
+		frame(r, &clock, time.Millisecond, nil)
+	}
+	if st := r.Stats(); st.Frames != Window || st.Max != time.Millisecond {
+		t.Fatalf("Stats after a full window = %+v", st)
+	}
+}
+
+func TestSectionsAndJank(t *testing.T) {
+	clock := time.Unix(0, 0)
+	r := New()
+	r.now = func() time.Time { return clock }
+	var janks []Jank
+	r.OnJank = func(j Jank) { janks = append(janks, j) }
+	r.SetDetail(true)
+
+	// Slow first frames are warm-up.
+	for range warmupFrames {
+		frame(r, &clock, time.Second, nil)
+	}
+	frame(r, &clock, 80*time.Millisecond, map[string]time.Duration{"certificates/row": 60 * time.Millisecond, "header": 2 * time.Millisecond})
+	frame(r, &clock, 10*time.Millisecond, map[string]time.Duration{"certificates/row": 4 * time.Millisecond})
+
+	if len(janks) != 1 {
+		t.Fatalf("janks = %+v", janks)
+	}
+	if j := janks[0]; j.Duration != 80*time.Millisecond || j.Screen != "certificates" || j.Hot != "certificates/row" || j.HotDuration != 60*time.Millisecond {
+		t.Fatalf("jank = %+v", j)
+	}
+	st := r.Stats()
+	if len(st.Hot) != 2 || st.Hot[0].Name != "certificates/row" || st.Hot[0].Avg != 32*time.Millisecond || st.Hot[0].Max != 60*time.Millisecond {
+		t.Fatalf("Hot = %+v", st.Hot)
+	}
+	if st.Janks != 1 || st.LastJank != janks[0] {
+		t.Fatalf("Stats = %+v", st)
+	}
+
+	r.SetDetail(false)
+	if st := r.Stats(); len(st.Hot) != 0 {
+		t.Fatalf("Hot kept after disabling detail: %+v", st.Hot)
+	}
+}
+
+func TestNilRecorderSection(t *testing.T) {
+	var r *Recorder
+	r.Section("rows")()
+}
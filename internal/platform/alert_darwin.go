@@ -0,0 +1,13 @@
+//go:build darwin
+
+package platform
+
+import (
+	"os/exec"
+	"strconv"
+)
+
+func showError(title, message string) error {
+	script := "display alert " + strconv.Quote(title) + " message " + strconv.Quote(message) + " as critical"
+	return exec.Command("osascript", "-e", script).Run()
+}
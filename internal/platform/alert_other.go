@@ -0,0 +1,18 @@
+//go:build !windows && !darwin
+
+package platform
+
+import (
+	"errors"
+	"os/exec"
+)
+
+func showError(title, message string) error {
+	if path, err := exec.LookPath("zenity"); err == nil {
+		return exec.Command(path, "--error", "--title", title, "--text", message, "--no-markup").Run()
+	}
+	if path, err := exec.LookPath("kdialog"); err == nil {
+		return exec.Command(path, "--title", title, "--error", message).Run()
+	}
+	return errors.New("no zenity or kdialog to show the error with")
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package platform
+
+import "golang.org/x/sys/windows"
+
+func showError(title, message string) error {
+	t, err := windows.UTF16PtrFromString(title)
+	if err != nil {
+		return err
+	}
+	m, err := windows.UTF16PtrFromString(message)
+	if err != nil {
+		return err
+	}
+	_, err = windows.MessageBox(0, m, t, windows.MB_OK|windows.MB_ICONERROR)
+	return err
+}
@@ -0,0 +1,27 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"runtime"
+)
+
+// ErrNoComposer is returned by ComposeEmail where no command can start a
+// message with an attachment.
+var ErrNoComposer = errors.New("no mail composer")
+
+// ComposeEmail opens a new message in the user's mail client with subject,
+// body and the file attached. mailto: links cannot carry attachments, so
+// this needs xdg-email, which attaches files for Thunderbird, Evolution and
+// KMail on Linux. Elsewhere, or without it, it returns ErrNoComposer.
+func ComposeEmail(ctx context.Context, subject, body, attachment string) error {
+	if runtime.GOOS != "linux" {
+		return ErrNoComposer
+	}
+	path, err := exec.LookPath("xdg-email")
+	if err != nil {
+		return ErrNoComposer
+	}
+	return exec.CommandContext(ctx, path, "--utf8", "--subject", subject, "--body", body, "--attach", attachment).Run()
+}
@@ -0,0 +1,63 @@
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// keyringService groups the secrets VocSign stores in the OS keyring.
+const keyringService = "VocSign certificate passwords"
+
+var (
+	// ErrNoKeyring is returned where there is no OS keyring to use.
+	ErrNoKeyring = errors.New("no system keyring")
+	// ErrNoPassword is returned by LoadPassword when nothing is stored
+	// for the account.
+	ErrNoPassword = errors.New("no password stored")
+)
+
+// KeyringName is what the user knows the OS keyring as, "" if there is
+// none: the Windows Credential Manager, the macOS Keychain, or the Secret
+// Service (GNOME Keyring, KWallet) through secret-tool on Linux.
+func KeyringName() string {
+	return keyringName()
+}
+
+// SavePassword stores password in the OS keyring under account, replacing
+// what was stored for it.
+func SavePassword(account, password string) error {
+	if keyringName() == "" {
+		return ErrNoKeyring
+	}
+	return savePassword(account, password)
+}
+
+// LoadPassword returns the password stored under account, or ErrNoPassword.
+func LoadPassword(account string) (string, error) {
+	if keyringName() == "" {
+		return "", ErrNoKeyring
+	}
+	return loadPassword(account)
+}
+
+// ForgetPassword removes the password stored under account, if any.
+func ForgetPassword(account string) error {
+	if keyringName() == "" {
+		return ErrNoKeyring
+	}
+	return forgetPassword(account)
+}
+
+// quoteSecurityArg quotes s for a command line read by "security -i",
+// which splits on spaces and takes backslash escapes inside double quotes.
+// A control character is refused: a line break would end the command and
+// start another with the rest of s.
+func quoteSecurityArg(s string) (string, error) {
+	if i := strings.IndexFunc(s, unicode.IsControl); i >= 0 {
+		return "", fmt.Errorf("cannot store a value with control character %U in the keychain", []rune(s[i:])[0])
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + r.Replace(s) + `"`, nil
+}
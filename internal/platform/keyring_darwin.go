@@ -0,0 +1,57 @@
+//go:build darwin
+
+package platform
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// errSecItemNotFound is the exit status of security(1) when no keychain
+// item matches.
+const errSecItemNotFound = 44
+
+func keyringName() string {
+	return "Keychain"
+}
+
+// The command is written to "security -i" so the password is not in the
+// process list.
+func savePassword(account, password string) error {
+	line := "add-generic-password -U"
+	for _, arg := range [][2]string{{"-s", keyringService}, {"-a", account}, {"-w", password}} {
+		quoted, err := quoteSecurityArg(arg[1])
+		if err != nil {
+			return err
+		}
+		line += " " + arg[0] + " " + quoted
+	}
+	cmd := exec.Command("/usr/bin/security", "-i")
+	cmd.Stdin = strings.NewReader(line + "\n")
+	return cmd.Run()
+}
+
+func loadPassword(account string) (string, error) {
+	var out bytes.Buffer
+	cmd := exec.Command("/usr/bin/security", "find-generic-password", "-s", keyringService, "-a", account, "-w")
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) && exit.ExitCode() == errSecItemNotFound {
+			return "", ErrNoPassword
+		}
+		return "", err
+	}
+	return strings.TrimSuffix(out.String(), "\n"), nil
+}
+
+func forgetPassword(account string) error {
+	err := exec.Command("/usr/bin/security", "delete-generic-password", "-s", keyringService, "-a", account).Run()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && exit.ExitCode() == errSecItemNotFound {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,51 @@
+//go:build !windows && !darwin
+
+package platform
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+func keyringName() string {
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return ""
+	}
+	return "system keyring"
+}
+
+// secret-tool reads the secret from stdin, so it never shows in the
+// process list.
+func savePassword(account, password string) error {
+	cmd := exec.Command("secret-tool", "store", "--label="+keyringService+": "+account, "service", keyringService, "account", account)
+	cmd.Stdin = strings.NewReader(password)
+	return cmd.Run()
+}
+
+func loadPassword(account string) (string, error) {
+	var out, stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", "lookup", "service", keyringService, "account", account)
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// secret-tool exits with 1 and says nothing when no item matches.
+		// It also exits with 1 when the Secret Service cannot be reached,
+		// but then says why.
+		var exit *exec.ExitError
+		if errors.As(err, &exit) && exit.ExitCode() == 1 && stderr.Len() == 0 {
+			return "", ErrNoPassword
+		}
+		return "", fmt.Errorf("secret-tool lookup failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if out.Len() == 0 {
+		return "", ErrNoPassword
+	}
+	return out.String(), nil
+}
+
+func forgetPassword(account string) error {
+	return exec.Command("secret-tool", "clear", "service", keyringService, "account", account).Run()
+}
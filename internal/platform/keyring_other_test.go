@@ -0,0 +1,78 @@
+//go:build !windows && !darwin
+
+package platform
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeSecretTool puts a secret-tool on PATH, before path, that keeps the
+// secrets read from stdin as files named after the account.
+func fakeSecretTool(t *testing.T, path string) {
+	dir := t.TempDir()
+	script := `#!/bin/sh
+store=` + dir + `/store
+mkdir -p "$store"
+cmd=$1; shift
+[ "$cmd" = store ] && shift
+account=$4
+case "$account" in
+unreachable) echo "Cannot autolaunch D-Bus without X11 \$DISPLAY" >&2; exit 1 ;;
+crash) exit 2 ;;
+esac
+case "$cmd" in
+store) cat > "$store/$account" ;;
+lookup) [ -f "$store/$account" ] || exit 1; cat "$store/$account" ;;
+clear) rm -f "$store/$account" ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(dir, "secret-tool"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+}
+
+func TestKeyringSecretTool(t *testing.T) {
+	path := os.Getenv("PATH")
+	t.Setenv("PATH", t.TempDir())
+	if KeyringName() != "" {
+		t.Fatal("keyring found without secret-tool")
+	}
+	if err := SavePassword("a", "p"); !errors.Is(err, ErrNoKeyring) {
+		t.Fatalf("SavePassword without keyring = %v", err)
+	}
+
+	fakeSecretTool(t, path)
+	if KeyringName() == "" {
+		t.Fatal("secret-tool not found")
+	}
+	if _, err := LoadPassword("abc"); !errors.Is(err, ErrNoPassword) {
+		t.Fatalf("LoadPassword before saving = %v", err)
+	}
+	if err := SavePassword("abc", "s3cret pass"); err != nil {
+		t.Fatalf("SavePassword: %v", err)
+	}
+	if got, err := LoadPassword("abc"); err != nil || got != "s3cret pass" {
+		t.Fatalf("LoadPassword = %q, %v", got, err)
+	}
+	if err := ForgetPassword("abc"); err != nil {
+		t.Fatalf("ForgetPassword: %v", err)
+	}
+	if _, err := LoadPassword("abc"); !errors.Is(err, ErrNoPassword) {
+		t.Fatalf("LoadPassword after forgetting = %v", err)
+	}
+
+	// Failures other than a missing item are not taken for one.
+	for _, account := range []string{"unreachable", "crash"} {
+		if _, err := LoadPassword(account); err == nil || errors.Is(err, ErrNoPassword) {
+			t.Errorf("LoadPassword(%q) = %v, want a failure", account, err)
+		}
+	}
+	if _, err := LoadPassword("unreachable"); err == nil || !strings.Contains(err.Error(), "D-Bus") {
+		t.Errorf("LoadPassword error does not say why: %v", err)
+	}
+}
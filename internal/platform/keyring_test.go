@@ -0,0 +1,21 @@
+package platform
+
+import "testing"
+
+func TestQuoteSecurityArg(t *testing.T) {
+	for in, want := range map[string]string{
+		"plain":         `"plain"`,
+		`with "quotes"`: `"with \"quotes\""`,
+		`back\slash`:    `"back\\slash"`,
+		"":              `""`,
+	} {
+		if got, err := quoteSecurityArg(in); err != nil || got != want {
+			t.Errorf("quoteSecurityArg(%q) = %s, %v, want %s", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"pass\nadd-generic-password -s evil", "tab\there", "nul\x00", "del\x7f", "next line\u0085"} {
+		if got, err := quoteSecurityArg(in); err == nil {
+			t.Errorf("quoteSecurityArg(%q) = %s, want an error", in, got)
+		}
+	}
+}
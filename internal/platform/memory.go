@@ -0,0 +1,113 @@
+package platform
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MemoryBudget is the soft limit for the memory the Go runtime uses,
+	// unless GOMEMLIMIT sets one. The garbage collector works harder as
+	// VocSign gets near it; it is not a hard cap.
+	MemoryBudget = 384 << 20
+	// minMemoryBudget is the smallest budget on machines with little RAM.
+	minMemoryBudget = 128 << 20
+	// lowMemory is the available system memory below which the machine is
+	// reported as short of memory.
+	lowMemory = 512 << 20
+)
+
+// Memory is a snapshot of the memory VocSign uses and the machine has.
+type Memory struct {
+	// HeapInUse is the memory taken by live and not yet collected objects.
+	HeapInUse uint64
+	// FromSystem is all the memory the Go runtime obtained from the system.
+	FromSystem uint64
+	// Budget is the soft memory limit in bytes.
+	Budget int64
+	// SystemTotal and SystemAvailable are the machine's memory, 0 where it
+	// is not known.
+	SystemTotal     uint64
+	SystemAvailable uint64
+}
+
+// ApplyMemoryBudget sets the soft memory limit: MemoryBudget, or an eighth
+// of the machine's memory on machines with less than 3 GiB. A limit set
+// with GOMEMLIMIT is kept. It returns the limit in effect.
+func ApplyMemoryBudget() int64 {
+	if os.Getenv("GOMEMLIMIT") != "" {
+		return debug.SetMemoryLimit(-1)
+	}
+	total, _ := systemMemory()
+	budget := budgetFor(total)
+	debug.SetMemoryLimit(budget)
+	return budget
+}
+
+func budgetFor(total uint64) int64 {
+	if total == 0 || total/8 >= MemoryBudget {
+		return MemoryBudget
+	}
+	return max(int64(total/8), minMemoryBudget)
+}
+
+// ReadMemory returns the current memory use. It briefly stops the program,
+// so it should not run on every frame.
+func ReadMemory() Memory {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	total, avail := systemMemory()
+	return Memory{
+		HeapInUse:       ms.HeapAlloc,
+		FromSystem:      ms.Sys,
+		Budget:          debug.SetMemoryLimit(-1),
+		SystemTotal:     total,
+		SystemAvailable: avail,
+	}
+}
+
+// Low reports whether the machine is short of memory.
+func (m Memory) Low() bool {
+	return m.SystemAvailable > 0 && m.SystemAvailable < lowMemory
+}
+
+// systemMemory reads the machine's total and available memory. It is only
+// known on Linux.
+func systemMemory() (total, available uint64) {
+	if runtime.GOOS != "linux" {
+		return 0, 0
+	}
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, 0
+	}
+	defer func() { _ = f.Close() }()
+	return parseMeminfo(f)
+}
+
+// parseMeminfo reads MemTotal and MemAvailable, given in KiB.
+func parseMeminfo(r io.Reader) (total, available uint64) {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		key, val, ok := strings.Cut(sc.Text(), ":")
+		if !ok {
+			continue
+		}
+		kib, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimSpace(val), " kB"), 10, 64)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "MemTotal":
+			total = kib << 10
+		case "MemAvailable":
+			available = kib << 10
+		}
+	}
+	return total, available
+}
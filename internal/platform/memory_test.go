@@ -0,0 +1,37 @@
+package platform
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBudgetFor(t *testing.T) {
+	tests := []struct {
+		total uint64
+		want  int64
+	}{
+		{0, MemoryBudget},
+		{16 << 30, MemoryBudget},
+		{2 << 30, 256 << 20},
+		{512 << 20, minMemoryBudget},
+	}
+	for _, tt := range tests {
+		if got := budgetFor(tt.total); got != tt.want {
+			t.Errorf("budgetFor(%d) = %d, want %d", tt.total, got, tt.want)
+		}
+	}
+}
+
+func TestParseMeminfo(t *testing.T) {
+	info := "MemTotal:        3944164 kB\nMemFree:          201408 kB\nMemAvailable:     402780 kB\nHugePages_Total:       0\n"
+	total, avail := parseMeminfo(strings.NewReader(info))
+	if total != 3944164<<10 || avail != 402780<<10 {
+		t.Fatalf("parseMeminfo = %d, %d", total, avail)
+	}
+	if m := (Memory{SystemAvailable: avail}); !m.Low() {
+		t.Fatal("400 MiB available is not reported as low")
+	}
+	if (Memory{}).Low() {
+		t.Fatal("unknown memory reported as low")
+	}
+}
@@ -0,0 +1,265 @@
+// Package platform detects the Linux display session VocSign runs in and
+// adjusts for what Gio does not handle well there.
+//
+// Gio uses Wayland when it can and X11 otherwise. On Wayland it renders at
+// the integer scale of the output and lets the compositor resample it to a
+// fractional scale, which blurs text. Its Wayland clipboard reader also
+// stops answering for the rest of the session once it is asked while the
+// clipboard holds no text, such as when the window gains focus with an
+// empty clipboard. Detect finds the session, the configured monitor scale
+// and a clipboard command to read with instead.
+//
+// Gio also has no renderer of its own without a GPU. When the GPU cannot be
+// initialized, VocSign starts again with Mesa's software rasterizers on
+// Linux (see RelaunchSoftware) and shows the error elsewhere. The memory
+// budget that keeps VocSign usable on machines with little RAM is set here
+// too (see ApplyMemoryBudget), Share opens the macOS share sheet, and
+// SavePassword keeps certificate passwords in the OS keyring.
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Session is a display protocol.
+type Session string
+
+const (
+	Wayland Session = "wayland"
+	X11     Session = "x11"
+)
+
+// BackendEnv overrides the display backend: "wayland" or "x11".
+const BackendEnv = "VOCSIGN_DISPLAY"
+
+// maxClipboardBytes caps what is read from a clipboard command; a signing
+// URL is far shorter.
+const maxClipboardBytes = 64 << 10
+
+// Caps is what the display session offers. It is zero outside Linux.
+type Caps struct {
+	// Session is the protocol of the desktop session.
+	Session Session
+	// Desktop is XDG_CURRENT_DESKTOP, e.g. "KDE" or "ubuntu:GNOME".
+	Desktop string
+	// XWayland reports whether X11 applications can run in a Wayland
+	// session.
+	XWayland bool
+	// Scale is the largest scale configured for a monitor, 0 if unknown.
+	Scale float64
+	// Backend is the protocol the window uses, and BackendReason why it
+	// differs from Session.
+	Backend       Session
+	BackendReason string
+	// ClipboardReader is the command that prints the clipboard text, nil
+	// to use Gio's reader.
+	ClipboardReader []string
+	// ServerDecorations reports whether the compositor draws the title bar.
+	// Where it does not, Gio draws one itself.
+	ServerDecorations bool
+}
+
+var detected = sync.OnceValue(func() Caps {
+	if runtime.GOOS != "linux" {
+		return Caps{}
+	}
+	cfg, _ := os.UserConfigDir()
+	return detect(os.Getenv, cfg, exec.LookPath)
+})
+
+// Detect returns the capabilities of the current session.
+func Detect() Caps {
+	return detected()
+}
+
+func detect(getenv func(string) string, configDir string, lookPath func(string) (string, error)) Caps {
+	var c Caps
+	switch {
+	case getenv("WAYLAND_DISPLAY") != "" || getenv("XDG_SESSION_TYPE") == "wayland":
+		c.Session = Wayland
+		c.XWayland = getenv("DISPLAY") != ""
+	case getenv("DISPLAY") != "":
+		c.Session = X11
+	default:
+		return c
+	}
+	c.Desktop = getenv("XDG_CURRENT_DESKTOP")
+	if configDir != "" {
+		c.Scale = monitorScale(configDir)
+	}
+
+	c.Backend = c.Session
+	switch override := Session(strings.ToLower(getenv(BackendEnv))); {
+	case override == X11 && c.XWayland:
+		c.Backend, c.BackendReason = X11, BackendEnv+"=x11"
+	case override == Wayland || override == X11:
+		// Already the session's protocol, or no X server to switch to.
+	case c.Session == Wayland && c.Fractional() && c.XWayland && c.desktopIs("KDE"):
+		// Plasma lets X11 applications render at the exact scale, which
+		// Gio reads from Xft.dpi; on Wayland it resamples an integer one.
+		c.Backend, c.BackendReason = X11, "sharper text at a fractional scale"
+	}
+
+	switch c.Backend {
+	case Wayland:
+		if _, err := lookPath("wl-paste"); err == nil {
+			c.ClipboardReader = []string{"wl-paste", "--no-newline", "--type", "text"}
+		}
+		// Mutter implements no server-side decorations.
+		c.ServerDecorations = !c.desktopIs("GNOME")
+	case X11:
+		c.ServerDecorations = true
+	}
+	return c
+}
+
+// Fractional reports whether a monitor is scaled by a fraction, e.g. 125%.
+func (c Caps) Fractional() bool {
+	return c.Scale > 0 && c.Scale != math.Trunc(c.Scale)
+}
+
+// ClipboardOnFocus reports whether the clipboard can be read when the
+// window gains focus, which Gio's Wayland reader does not survive when the
+// clipboard holds no text.
+func (c Caps) ClipboardOnFocus() bool {
+	return c.Backend != Wayland || c.ClipboardReader != nil
+}
+
+func (c Caps) desktopIs(name string) bool {
+	for _, d := range strings.Split(c.Desktop, ":") {
+		if strings.EqualFold(d, name) {
+			return true
+		}
+	}
+	return false
+}
+
+var savedWaylandDisplay *string
+
+// Apply prepares the process for the chosen backend before the window is
+// created. Gio has no option to pick one, so to use X11 in a Wayland
+// session WAYLAND_DISPLAY names a socket that does not exist until
+// RestoreEnv, letting Gio's Wayland connection fail and fall back to X11.
+// Unsetting it would not do: libwayland then connects to wayland-0.
+func (c Caps) Apply() {
+	if c.Backend != X11 || c.Session != Wayland || savedWaylandDisplay != nil {
+		return
+	}
+	v := os.Getenv("WAYLAND_DISPLAY")
+	savedWaylandDisplay = &v
+	_ = os.Setenv("WAYLAND_DISPLAY", "vocsign-uses-x11")
+}
+
+// RestoreEnv undoes Apply once the window exists, so programs VocSign
+// starts, such as the browser, still use Wayland.
+func RestoreEnv() {
+	if savedWaylandDisplay == nil {
+		return
+	}
+	if *savedWaylandDisplay == "" {
+		_ = os.Unsetenv("WAYLAND_DISPLAY")
+	} else {
+		_ = os.Setenv("WAYLAND_DISPLAY", *savedWaylandDisplay)
+	}
+	savedWaylandDisplay = nil
+}
+
+// ReadClipboard returns the clipboard text read with c.ClipboardReader. An
+// empty clipboard, or one without text, reads as "".
+func (c Caps) ReadClipboard(ctx context.Context) (string, error) {
+	if len(c.ClipboardReader) == 0 {
+		return "", errors.New("no clipboard command")
+	}
+	cmd := exec.CommandContext(ctx, c.ClipboardReader[0], c.ClipboardReader[1:]...)
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+	if err := cmd.Start(); err != nil {
+		return "", err
+	}
+	data, readErr := io.ReadAll(io.LimitReader(out, maxClipboardBytes))
+	_, _ = io.Copy(io.Discard, out)
+	err = cmd.Wait()
+	if readErr != nil {
+		return "", readErr
+	}
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		// wl-paste exits with an error when nothing, or no text, is copied.
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// monitorScale returns the largest monitor scale in the KDE Plasma or
+// GNOME display configuration, 0 if there is none.
+func monitorScale(configDir string) float64 {
+	var scale float64
+	if data, err := os.ReadFile(filepath.Join(configDir, "kwinoutputconfig.json")); err == nil {
+		scale = max(scale, kwinScale(data))
+	}
+	if data, err := os.ReadFile(filepath.Join(configDir, "monitors.xml")); err == nil {
+		scale = max(scale, mutterScale(data))
+	}
+	return scale
+}
+
+// kwinScale reads the output scales of Plasma 6's kwinoutputconfig.json.
+func kwinScale(data []byte) float64 {
+	var sections []struct {
+		Name string `json:"name"`
+		Data []struct {
+			Scale float64 `json:"scale"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &sections); err != nil {
+		return 0
+	}
+	var scale float64
+	for _, s := range sections {
+		if s.Name != "outputs" {
+			continue
+		}
+		for _, o := range s.Data {
+			scale = max(scale, o.Scale)
+		}
+	}
+	return scale
+}
+
+// mutterScale reads the logical monitor scales of GNOME's monitors.xml.
+func mutterScale(data []byte) float64 {
+	var doc struct {
+		Configurations []struct {
+			Logical []struct {
+				Scale float64 `xml:"scale"`
+			} `xml:"logicalmonitor"`
+		} `xml:"configuration"`
+	}
+	if err := xml.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil {
+		return 0
+	}
+	var scale float64
+	for _, c := range doc.Configurations {
+		for _, l := range c.Logical {
+			scale = max(scale, l.Scale)
+		}
+	}
+	return scale
+}
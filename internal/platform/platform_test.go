@@ -0,0 +1,158 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func env(vars map[string]string) func(string) string {
+	return func(name string) string { return vars[name] }
+}
+
+func found(string) (string, error) { return "/usr/bin/wl-paste", nil }
+
+func missing(string) (string, error) { return "", errors.New("not found") }
+
+func writeConfig(t *testing.T, name, data string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+const kwinConfig = `[
+  {"name": "outputs", "data": [{"connectorName": "eDP-1", "scale": 1.25}, {"connectorName": "HDMI-A-1", "scale": 1}]},
+  {"name": "setups", "data": [{"outputs": [{"outputIndex": 0, "enabled": true}]}]}
+]`
+
+const mutterConfig = `<monitors version="2">
+  <configuration>
+    <logicalmonitor><x>0</x><y>0</y><scale>2</scale><primary>yes</primary></logicalmonitor>
+  </configuration>
+  <configuration>
+    <logicalmonitor><x>0</x><y>0</y><scale>1.5</scale></logicalmonitor>
+  </configuration>
+</monitors>`
+
+func TestDetectWayland(t *testing.T) {
+	c := detect(env(map[string]string{
+		"WAYLAND_DISPLAY":     "wayland-0",
+		"DISPLAY":             ":0",
+		"XDG_CURRENT_DESKTOP": "ubuntu:GNOME",
+	}), writeConfig(t, "monitors.xml", mutterConfig), found)
+	if c.Session != Wayland || c.Backend != Wayland || !c.XWayland || c.Scale != 2 {
+		t.Fatalf("detect = %+v", c)
+	}
+	if c.ServerDecorations {
+		t.Fatal("GNOME reported with server-side decorations")
+	}
+	if !slices.Equal(c.ClipboardReader, []string{"wl-paste", "--no-newline", "--type", "text"}) {
+		t.Fatalf("ClipboardReader = %q", c.ClipboardReader)
+	}
+	if !c.ClipboardOnFocus() {
+		t.Fatal("clipboard not read on focus with wl-paste")
+	}
+
+	c = detect(env(map[string]string{"WAYLAND_DISPLAY": "wayland-0"}), "", missing)
+	if c.ClipboardReader != nil || c.ClipboardOnFocus() {
+		t.Fatalf("Gio's Wayland reader used on focus: %+v", c)
+	}
+}
+
+func TestDetectKDEFractionalUsesX11(t *testing.T) {
+	vars := map[string]string{
+		"WAYLAND_DISPLAY":     "wayland-0",
+		"DISPLAY":             ":1",
+		"XDG_CURRENT_DESKTOP": "KDE",
+	}
+	dir := writeConfig(t, "kwinoutputconfig.json", kwinConfig)
+	c := detect(env(vars), dir, found)
+	if c.Scale != 1.25 || !c.Fractional() {
+		t.Fatalf("Scale = %v", c.Scale)
+	}
+	if c.Backend != X11 || c.BackendReason == "" || !c.ServerDecorations || c.ClipboardReader != nil {
+		t.Fatalf("detect = %+v", c)
+	}
+
+	vars[BackendEnv] = "wayland"
+	if c := detect(env(vars), dir, found); c.Backend != Wayland {
+		t.Fatalf("override ignored: %+v", c)
+	}
+
+	// Without XWayland there is no X server to switch to.
+	delete(vars, "DISPLAY")
+	delete(vars, BackendEnv)
+	if c := detect(env(vars), dir, found); c.Backend != Wayland {
+		t.Fatalf("X11 chosen without XWayland: %+v", c)
+	}
+}
+
+func TestDetectOverrideX11(t *testing.T) {
+	c := detect(env(map[string]string{
+		"WAYLAND_DISPLAY": "wayland-0",
+		"DISPLAY":         ":0",
+		BackendEnv:        "X11",
+	}), "", found)
+	if c.Backend != X11 || c.BackendReason != "VOCSIGN_DISPLAY=x11" {
+		t.Fatalf("detect = %+v", c)
+	}
+}
+
+func TestDetectX11AndNone(t *testing.T) {
+	c := detect(env(map[string]string{"DISPLAY": ":0"}), "", found)
+	if c.Session != X11 || c.Backend != X11 || !c.ServerDecorations || !c.ClipboardOnFocus() {
+		t.Fatalf("detect = %+v", c)
+	}
+	if c := detect(env(nil), "", found); c.Session != "" || c.Backend != "" {
+		t.Fatalf("detect without display = %+v", c)
+	}
+}
+
+func TestScaleParsers(t *testing.T) {
+	if got := kwinScale([]byte("not json")); got != 0 {
+		t.Fatalf("kwinScale(invalid) = %v", got)
+	}
+	if got := mutterScale([]byte(mutterConfig)); got != 2 {
+		t.Fatalf("mutterScale = %v", got)
+	}
+	if got := monitorScale(t.TempDir()); got != 0 {
+		t.Fatalf("monitorScale(empty) = %v", got)
+	}
+}
+
+func TestApplyRestoresEnv(t *testing.T) {
+	t.Setenv("WAYLAND_DISPLAY", "wayland-1")
+	Caps{Session: Wayland, Backend: X11}.Apply()
+	if got := os.Getenv("WAYLAND_DISPLAY"); got == "wayland-1" {
+		t.Fatal("WAYLAND_DISPLAY not hidden")
+	}
+	RestoreEnv()
+	if got := os.Getenv("WAYLAND_DISPLAY"); got != "wayland-1" {
+		t.Fatalf("WAYLAND_DISPLAY = %q", got)
+	}
+
+	Caps{Session: Wayland, Backend: Wayland}.Apply()
+	if got := os.Getenv("WAYLAND_DISPLAY"); got != "wayland-1" {
+		t.Fatalf("Wayland backend changed WAYLAND_DISPLAY to %q", got)
+	}
+}
+
+func TestReadClipboard(t *testing.T) {
+	if _, err := (Caps{}).ReadClipboard(context.Background()); err == nil {
+		t.Fatal("read without a command")
+	}
+	c := Caps{ClipboardReader: []string{"sh", "-c", "printf https://example.org/request.json"}}
+	if got, err := c.ReadClipboard(context.Background()); err != nil || got != "https://example.org/request.json" {
+		t.Fatalf("ReadClipboard = %q, %v", got, err)
+	}
+	c.ClipboardReader = []string{"sh", "-c", "echo 'Nothing is copied' >&2; exit 1"}
+	if got, err := c.ReadClipboard(context.Background()); err != nil || got != "" {
+		t.Fatalf("ReadClipboard(empty) = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,106 @@
+package platform
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Renderer is how the window is drawn.
+type Renderer string
+
+const (
+	// GPU is Gio's default: Vulkan or OpenGL on Linux, Direct3D 11 on
+	// Windows, Metal on macOS.
+	GPU Renderer = "gpu"
+	// Software draws with Mesa's CPU rasterizers, llvmpipe for OpenGL and
+	// lavapipe for Vulkan. It is only available on Linux.
+	Software Renderer = "software"
+)
+
+const (
+	// RendererEnv forces a renderer: "software" or "gpu".
+	RendererEnv = "VOCSIGN_RENDERER"
+	// gpuErrorEnv carries the GPU failure to the software relaunch, for the
+	// diagnostics.
+	gpuErrorEnv = "VOCSIGN_GPU_ERROR"
+)
+
+// mesaEGLVendor is the glvnd vendor file of Mesa's EGL, which the software
+// rasterizers are part of.
+const mesaEGLVendor = "/usr/share/glvnd/egl_vendor.d/50_mesa.json"
+
+// RenderMode is the renderer in use and, after a fallback, why.
+type RenderMode struct {
+	Renderer Renderer
+	// GPUError is the error the GPU failed with before VocSign fell back to
+	// software rendering.
+	GPUError string
+}
+
+// CurrentRenderMode returns the renderer this process draws with.
+func CurrentRenderMode() RenderMode {
+	return renderMode(os.Getenv, runtime.GOOS)
+}
+
+func renderMode(getenv func(string) string, goos string) RenderMode {
+	if goos == "linux" && strings.EqualFold(getenv(RendererEnv), string(Software)) {
+		return RenderMode{Renderer: Software, GPUError: getenv(gpuErrorEnv)}
+	}
+	return RenderMode{Renderer: GPU}
+}
+
+// CanFallBack reports whether a failed GPU can be replaced with software
+// rendering, which needs Mesa on Linux and is not already in use.
+func (m RenderMode) CanFallBack() bool {
+	return runtime.GOOS == "linux" && m.Renderer == GPU
+}
+
+// softwareEnv returns the environment that makes Mesa render on the CPU.
+// Only the Mesa vendor is selected when glvnd would otherwise load another
+// driver, and Vulkan is limited to lavapipe; without it Gio uses OpenGL.
+func softwareEnv(gpuErr error, vendorExists bool) []string {
+	env := []string{
+		RendererEnv + "=" + string(Software),
+		"LIBGL_ALWAYS_SOFTWARE=1",
+		"GALLIUM_DRIVER=llvmpipe",
+		"VK_LOADER_DRIVERS_SELECT=*lvp*",
+	}
+	if vendorExists {
+		env = append(env, "__EGL_VENDOR_LIBRARY_FILENAMES="+mesaEGLVendor)
+	}
+	if gpuErr != nil {
+		env = append(env, gpuErrorEnv+"="+gpuErr.Error())
+	}
+	return env
+}
+
+// RelaunchSoftware starts VocSign again with the same arguments in software
+// rendering mode, waits for it and returns its exit code.
+func RelaunchSoftware(gpuErr error) (int, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return 1, err
+	}
+	_, statErr := os.Stat(mesaEGLVendor)
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Env = append(os.Environ(), softwareEnv(gpuErr, statErr == nil)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err = cmd.Run()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		return exit.ExitCode(), nil
+	}
+	if err != nil {
+		return 1, err
+	}
+	return 0, nil
+}
+
+// ShowError tells the user about a failure that leaves VocSign without a
+// window, with a system dialog rather than only on the console.
+func ShowError(title, message string) error {
+	return showError(title, message)
+}
@@ -0,0 +1,43 @@
+package platform
+
+import (
+	"errors"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRenderMode(t *testing.T) {
+	if m := renderMode(env(nil), "linux"); m.Renderer != GPU || m.CanFallBack() != (runtime.GOOS == "linux") {
+		t.Fatalf("default mode = %+v", m)
+	}
+	m := renderMode(env(map[string]string{RendererEnv: "Software", gpuErrorEnv: "eglInitialize failed: 0x3001"}), "linux")
+	if m.Renderer != Software || m.GPUError != "eglInitialize failed: 0x3001" || m.CanFallBack() {
+		t.Fatalf("software mode = %+v", m)
+	}
+	// Mesa's rasterizers are only used on Linux.
+	if m := renderMode(env(map[string]string{RendererEnv: "software"}), "windows"); m.Renderer != GPU {
+		t.Fatalf("windows mode = %+v", m)
+	}
+}
+
+func TestSoftwareEnv(t *testing.T) {
+	env := softwareEnv(errors.New("vk: no devices"), true)
+	for _, want := range []string{
+		"VOCSIGN_RENDERER=software",
+		"LIBGL_ALWAYS_SOFTWARE=1",
+		"__EGL_VENDOR_LIBRARY_FILENAMES=" + mesaEGLVendor,
+		"VOCSIGN_GPU_ERROR=vk: no devices",
+	} {
+		if !slices.Contains(env, want) {
+			t.Errorf("softwareEnv lacks %q: %q", want, env)
+		}
+	}
+	env = softwareEnv(nil, false)
+	for _, v := range env {
+		if v == "__EGL_VENDOR_LIBRARY_FILENAMES="+mesaEGLVendor || strings.HasPrefix(v, gpuErrorEnv) {
+			t.Errorf("unexpected %q", v)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+package platform
+
+// WorkArea returns the size, in Dp, of the primary monitor less the task
+// bar or dock, for sizing the main window. It returns false where the
+// size cannot be read before a window is open, as on Wayland, whose
+// compositor fits windows to the output itself.
+func WorkArea() (width, height int, ok bool) {
+	return workArea()
+}
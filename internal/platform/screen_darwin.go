@@ -0,0 +1,19 @@
+//go:build darwin && cgo
+
+package platform
+
+/*
+#cgo LDFLAGS: -framework CoreGraphics
+
+#include <CoreGraphics/CoreGraphics.h>
+*/
+import "C"
+
+// workArea reads the bounds of the main display, in points, which are Dp.
+// CoreGraphics, unlike NSScreen, may be asked off the main thread; the
+// menu bar and the Dock are not left out.
+func workArea() (int, int, bool) {
+	b := C.CGDisplayBounds(C.CGMainDisplayID())
+	w, h := int(b.size.width), int(b.size.height)
+	return w, h, w > 0 && h > 0
+}
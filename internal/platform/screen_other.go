@@ -0,0 +1,5 @@
+//go:build !windows && (!darwin || !cgo)
+
+package platform
+
+func workArea() (int, int, bool) { return 0, 0, false }
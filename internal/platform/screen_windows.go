@@ -0,0 +1,38 @@
+//go:build windows
+
+package platform
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	user32                    = windows.NewLazySystemDLL("user32.dll")
+	procSystemParametersInfoW = user32.NewProc("SystemParametersInfoW")
+	procGetDpiForSystem       = user32.NewProc("GetDpiForSystem")
+)
+
+const spiGetWorkArea = 0x0030
+
+// workArea reads the work area in pixels and scales it by the system DPI.
+// GetDpiForSystem is missing before Windows 10 1607, where the process is
+// not DPI aware and pixels are Dp.
+func workArea() (int, int, bool) {
+	if procSystemParametersInfoW.Find() != nil {
+		return 0, 0, false
+	}
+	var r windows.Rect
+	if ok, _, _ := procSystemParametersInfoW.Call(spiGetWorkArea, 0, uintptr(unsafe.Pointer(&r)), 0); ok == 0 {
+		return 0, 0, false
+	}
+	dpi := uintptr(96)
+	if procGetDpiForSystem.Find() == nil {
+		if d, _, _ := procGetDpiForSystem.Call(); d > 0 {
+			dpi = d
+		}
+	}
+	w, h := int(r.Right-r.Left), int(r.Bottom-r.Top)
+	return w * 96 / int(dpi), h * 96 / int(dpi), w > 0 && h > 0
+}
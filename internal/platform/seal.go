@@ -0,0 +1,31 @@
+package platform
+
+import "errors"
+
+// ErrNoSealingHardware is returned where there is no TPM or Secure Enclave
+// VocSign can use.
+var ErrNoSealingHardware = errors.New("no TPM or Secure Enclave available")
+
+// SealingHardware is what the user knows the chip that can bind a secret
+// to this computer as, "" if there is none usable: the TPM 2.0 on Windows
+// and Linux (through tpm2-tools), or the Secure Enclave on macOS.
+func SealingHardware() string {
+	return sealerName()
+}
+
+// SealKey encrypts key so that only this computer's TPM or Secure Enclave
+// can decrypt it. The result is useless on another computer.
+func SealKey(key []byte) ([]byte, error) {
+	if sealerName() == "" {
+		return nil, ErrNoSealingHardware
+	}
+	return sealKey(key)
+}
+
+// UnsealKey decrypts a key sealed by SealKey on this computer.
+func UnsealKey(sealed []byte) ([]byte, error) {
+	if sealerName() == "" {
+		return nil, ErrNoSealingHardware
+	}
+	return unsealKey(sealed)
+}
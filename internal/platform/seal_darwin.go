@@ -0,0 +1,170 @@
+//go:build darwin && cgo
+
+package platform
+
+/*
+#cgo LDFLAGS: -framework Security -framework CoreFoundation
+
+#include <stdlib.h>
+#include <string.h>
+#include <Security/Security.h>
+
+// vocsign_enclave_tag names the Secure Enclave key the vault key is
+// encrypted with.
+static const char *vocsign_enclave_tag = "org.vocdoni.vocsign.vault";
+
+static CFDataRef vocsign_enclave_tag_data(void) {
+	return CFDataCreate(NULL, (const UInt8 *)vocsign_enclave_tag, strlen(vocsign_enclave_tag));
+}
+
+// vocsign_enclave_key returns the Secure Enclave key, creating it if create
+// is set and there is none yet. On failure it returns NULL and the
+// OSStatus or CFError code in status.
+static SecKeyRef vocsign_enclave_key(int create, long *status) {
+	CFDataRef tag = vocsign_enclave_tag_data();
+	const void *qkeys[] = {kSecClass, kSecAttrApplicationTag, kSecAttrKeyType, kSecAttrTokenID, kSecReturnRef};
+	const void *qvals[] = {kSecClassKey, tag, kSecAttrKeyTypeECSECPrimeRandom, kSecAttrTokenIDSecureEnclave, kCFBooleanTrue};
+	CFDictionaryRef query = CFDictionaryCreate(NULL, qkeys, qvals, 5, &kCFTypeDictionaryKeyCallBacks, &kCFTypeDictionaryValueCallBacks);
+	CFTypeRef item = NULL;
+	OSStatus st = SecItemCopyMatching(query, &item);
+	CFRelease(query);
+	if (st == errSecSuccess) {
+		CFRelease(tag);
+		*status = 0;
+		return (SecKeyRef)item;
+	}
+	if (st != errSecItemNotFound || !create) {
+		CFRelease(tag);
+		*status = st;
+		return NULL;
+	}
+
+	CFErrorRef err = NULL;
+	SecAccessControlRef access = SecAccessControlCreateWithFlags(NULL, kSecAttrAccessibleWhenUnlockedThisDeviceOnly, kSecAccessControlPrivateKeyUsage, &err);
+	if (access == NULL) {
+		CFRelease(tag);
+		*status = err ? CFErrorGetCode(err) : -1;
+		if (err) CFRelease(err);
+		return NULL;
+	}
+	const void *pkeys[] = {kSecAttrIsPermanent, kSecAttrApplicationTag, kSecAttrAccessControl};
+	const void *pvals[] = {kCFBooleanTrue, tag, access};
+	CFDictionaryRef priv = CFDictionaryCreate(NULL, pkeys, pvals, 3, &kCFTypeDictionaryKeyCallBacks, &kCFTypeDictionaryValueCallBacks);
+	int bits = 256;
+	CFNumberRef size = CFNumberCreate(NULL, kCFNumberIntType, &bits);
+	const void *akeys[] = {kSecAttrKeyType, kSecAttrKeySizeInBits, kSecAttrTokenID, kSecPrivateKeyAttrs};
+	const void *avals[] = {kSecAttrKeyTypeECSECPrimeRandom, size, kSecAttrTokenIDSecureEnclave, priv};
+	CFDictionaryRef attrs = CFDictionaryCreate(NULL, akeys, avals, 4, &kCFTypeDictionaryKeyCallBacks, &kCFTypeDictionaryValueCallBacks);
+	SecKeyRef key = SecKeyCreateRandomKey(attrs, &err);
+	CFRelease(attrs);
+	CFRelease(size);
+	CFRelease(priv);
+	CFRelease(access);
+	CFRelease(tag);
+	if (key == NULL) {
+		*status = err ? CFErrorGetCode(err) : -1;
+		if (err) CFRelease(err);
+		return NULL;
+	}
+	*status = 0;
+	return key;
+}
+
+// vocsign_enclave_crypt encrypts in with the public half of the Secure
+// Enclave key, or decrypts it with the private half, which never leaves
+// the enclave. The result is returned in a malloc'd buffer.
+static void *vocsign_enclave_crypt(int decrypt, const void *in, int inLen, int *outLen, long *status) {
+	SecKeyRef key = vocsign_enclave_key(!decrypt, status);
+	if (key == NULL) {
+		return NULL;
+	}
+	SecKeyAlgorithm alg = kSecKeyAlgorithmECIESEncryptionCofactorVariableIVX963SHA256AESGCM;
+	CFDataRef data = CFDataCreate(NULL, in, inLen);
+	CFErrorRef err = NULL;
+	CFDataRef result = NULL;
+	if (decrypt) {
+		result = SecKeyCreateDecryptedData(key, alg, data, &err);
+	} else {
+		SecKeyRef pub = SecKeyCopyPublicKey(key);
+		if (pub != NULL) {
+			result = SecKeyCreateEncryptedData(pub, alg, data, &err);
+			CFRelease(pub);
+		}
+	}
+	CFRelease(data);
+	CFRelease(key);
+	if (result == NULL) {
+		*status = err ? CFErrorGetCode(err) : -1;
+		if (err) CFRelease(err);
+		return NULL;
+	}
+	*outLen = (int)CFDataGetLength(result);
+	void *out = malloc(*outLen > 0 ? *outLen : 1);
+	memcpy(out, CFDataGetBytePtr(result), *outLen);
+	CFRelease(result);
+	*status = 0;
+	return out;
+}
+
+// vocsign_enclave_available creates the Secure Enclave key if needed.
+// Macs without a Secure Enclave, and unsigned builds the keychain refuses
+// (errSecMissingEntitlement), fail here.
+static long vocsign_enclave_available(void) {
+	long status = 0;
+	SecKeyRef key = vocsign_enclave_key(1, &status);
+	if (key != NULL) {
+		CFRelease(key);
+	}
+	return status;
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"unsafe"
+)
+
+var enclaveAvailable = sync.OnceValue(func() bool {
+	if status := C.vocsign_enclave_available(); status != 0 {
+		log.Printf("DEBUG: Secure Enclave unavailable: status %d", int(status))
+		return false
+	}
+	return true
+})
+
+func sealerName() string {
+	if !enclaveAvailable() {
+		return ""
+	}
+	return "Secure Enclave"
+}
+
+func sealKey(key []byte) ([]byte, error) {
+	return enclaveCrypt(false, key)
+}
+
+func unsealKey(sealed []byte) ([]byte, error) {
+	return enclaveCrypt(true, sealed)
+}
+
+func enclaveCrypt(decrypt bool, in []byte) ([]byte, error) {
+	if len(in) == 0 {
+		return nil, errors.New("nothing to encrypt or decrypt")
+	}
+	var flag C.int
+	if decrypt {
+		flag = 1
+	}
+	var n C.int
+	var status C.long
+	out := C.vocsign_enclave_crypt(flag, unsafe.Pointer(&in[0]), C.int(len(in)), &n, &status)
+	if out == nil {
+		return nil, fmt.Errorf("Secure Enclave: status %d", int(status))
+	}
+	defer C.free(out)
+	return C.GoBytes(out, n), nil
+}
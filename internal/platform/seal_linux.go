@@ -0,0 +1,126 @@
+//go:build linux
+
+package platform
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// tpmDevice is the kernel's TPM resource manager. Opening it needs the
+// tss group on most distributions.
+var tpmDevice = "/dev/tpmrm0"
+
+func sealerName() string {
+	if _, err := exec.LookPath("tpm2_createprimary"); err != nil {
+		return ""
+	}
+	f, err := os.Open(tpmDevice)
+	if err != nil {
+		return ""
+	}
+	f.Close()
+	return "TPM"
+}
+
+// The key is sealed in a TPM object under a primary key of the owner
+// hierarchy. The primary key is derived again from the TPM's seed on each
+// use, so only the sealed object, which only this TPM can load, is kept:
+// its public part, prefixed by its length, then its private part.
+
+func sealKey(key []byte) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "vocsign-tpm")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	primary, pub, priv := tpmFiles(dir)
+
+	if err := runTPM(nil, nil, "tpm2_createprimary", "-Q", "-C", "o", "-G", "ecc", "-c", primary); err != nil {
+		return nil, err
+	}
+	if err := runTPM(bytes.NewReader(key), nil, "tpm2_create", "-Q", "-C", primary, "-i", "-", "-u", pub, "-r", priv); err != nil {
+		return nil, err
+	}
+	pubData, err := os.ReadFile(pub)
+	if err != nil {
+		return nil, err
+	}
+	privData, err := os.ReadFile(priv)
+	if err != nil {
+		return nil, err
+	}
+	if len(pubData) > 0xffff {
+		return nil, errors.New("sealed TPM object too large")
+	}
+	out := binary.BigEndian.AppendUint16(nil, uint16(len(pubData)))
+	out = append(out, pubData...)
+	return append(out, privData...), nil
+}
+
+func unsealKey(sealed []byte) ([]byte, error) {
+	if len(sealed) < 2 || len(sealed)-2 < int(binary.BigEndian.Uint16(sealed)) {
+		return nil, errors.New("sealed key is corrupt")
+	}
+	n := 2 + int(binary.BigEndian.Uint16(sealed))
+
+	dir, err := os.MkdirTemp("", "vocsign-tpm")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	primary, pub, priv := tpmFiles(dir)
+	object := filepath.Join(dir, "sealed.ctx")
+	if err := os.WriteFile(pub, sealed[2:n], 0o600); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(priv, sealed[n:], 0o600); err != nil {
+		return nil, err
+	}
+
+	if err := runTPM(nil, nil, "tpm2_createprimary", "-Q", "-C", "o", "-G", "ecc", "-c", primary); err != nil {
+		return nil, err
+	}
+	if err := runTPM(nil, nil, "tpm2_load", "-Q", "-C", primary, "-u", pub, "-r", priv, "-c", object); err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := runTPM(nil, &out, "tpm2_unseal", "-Q", "-c", object); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func tpmFiles(dir string) (primary, pub, priv string) {
+	return filepath.Join(dir, "primary.ctx"), filepath.Join(dir, "sealed.pub"), filepath.Join(dir, "sealed.priv")
+}
+
+// runTPM runs a tpm2-tools command against tpmDevice, unless the user
+// chose another TCTI. The key goes through stdin and stdout only.
+func runTPM(stdin *bytes.Reader, stdout *bytes.Buffer, name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	if os.Getenv("TPM2TOOLS_TCTI") == "" {
+		cmd.Env = append(os.Environ(), "TPM2TOOLS_TCTI=device:"+tpmDevice)
+	}
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	if stdout != nil {
+		cmd.Stdout = stdout
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+//go:build linux
+
+package platform
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeTPMTools puts tpm2-tools on PATH, before path, that "seal" by
+// writing the secret as the object's public part and check the TCTI they
+// are given.
+func fakeTPMTools(t *testing.T, path string) {
+	dir := t.TempDir()
+	tools := map[string]string{
+		"tpm2_createprimary": `out=; while [ $# -gt 0 ]; do [ "$1" = -c ] && out=$2; shift; done; echo primary > "$out"`,
+		"tpm2_create":        `while [ $# -gt 0 ]; do case "$1" in -u) pub=$2;; -r) priv=$2;; esac; shift; done; cat > "$pub"; echo priv > "$priv"`,
+		"tpm2_load":          `while [ $# -gt 0 ]; do case "$1" in -u) pub=$2;; -c) out=$2;; esac; shift; done; cp "$pub" "$out"`,
+		"tpm2_unseal":        `cat "$3"`,
+	}
+	for name, body := range tools {
+		script := "#!/bin/sh\ncase \"$TPM2TOOLS_TCTI\" in device:*) ;; *) echo bad tcti >&2; exit 1;; esac\n" + body + "\n"
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+}
+
+func TestSealKeyTPMTools(t *testing.T) {
+	path := os.Getenv("PATH")
+	t.Setenv("PATH", t.TempDir())
+	t.Setenv("TPM2TOOLS_TCTI", "")
+	if SealingHardware() != "" {
+		t.Fatal("TPM found without tpm2-tools")
+	}
+	if _, err := SealKey([]byte("k")); !errors.Is(err, ErrNoSealingHardware) {
+		t.Fatalf("SealKey without a TPM = %v", err)
+	}
+
+	fakeTPMTools(t, path)
+	device := tpmDevice
+	defer func() { tpmDevice = device }()
+	tpmDevice = filepath.Join(t.TempDir(), "missing")
+	if SealingHardware() != "" {
+		t.Fatal("TPM found without its device")
+	}
+	tpmDevice = filepath.Join(t.TempDir(), "tpmrm0")
+	if err := os.WriteFile(tpmDevice, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if SealingHardware() != "TPM" {
+		t.Fatalf("SealingHardware = %q", SealingHardware())
+	}
+
+	key := []byte("0123456789abcdef0123456789abcdef")
+	sealed, err := SealKey(key)
+	if err != nil {
+		t.Fatalf("SealKey: %v", err)
+	}
+	if !bytes.Contains(sealed, []byte("priv")) {
+		t.Errorf("sealed blob lacks the private part: %q", sealed)
+	}
+	got, err := UnsealKey(sealed)
+	if err != nil || !bytes.Equal(got, key) {
+		t.Fatalf("UnsealKey = %q, %v", got, err)
+	}
+	if _, err := UnsealKey(sealed[:1]); err == nil || !strings.Contains(err.Error(), "corrupt") {
+		t.Errorf("UnsealKey of a truncated blob = %v", err)
+	}
+}
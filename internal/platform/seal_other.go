@@ -0,0 +1,9 @@
+//go:build !linux && !windows && (!darwin || !cgo)
+
+package platform
+
+func sealerName() string { return "" }
+
+func sealKey([]byte) ([]byte, error) { return nil, ErrNoSealingHardware }
+
+func unsealKey([]byte) ([]byte, error) { return nil, ErrNoSealingHardware }
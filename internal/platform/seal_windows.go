@@ -0,0 +1,152 @@
+//go:build windows
+
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	ncrypt                        = windows.NewLazySystemDLL("ncrypt.dll")
+	procNCryptOpenStorageProvider = ncrypt.NewProc("NCryptOpenStorageProvider")
+	procNCryptOpenKey             = ncrypt.NewProc("NCryptOpenKey")
+	procNCryptCreatePersistedKey  = ncrypt.NewProc("NCryptCreatePersistedKey")
+	procNCryptFinalizeKey         = ncrypt.NewProc("NCryptFinalizeKey")
+	procNCryptEncrypt             = ncrypt.NewProc("NCryptEncrypt")
+	procNCryptDecrypt             = ncrypt.NewProc("NCryptDecrypt")
+	procNCryptFreeObject          = ncrypt.NewProc("NCryptFreeObject")
+)
+
+const (
+	// platformCryptoProvider is the key storage provider backed by the
+	// TPM. Its keys cannot be exported from the chip.
+	platformCryptoProvider = "Microsoft Platform Crypto Provider"
+	// tpmKeyName names the RSA key, kept in the TPM for the current user,
+	// that the vault key is encrypted with.
+	tpmKeyName = "VocSign vault key"
+
+	ncryptPadPKCS1 = 0x2
+	nteBadKeyset   = 0x80090016
+)
+
+var tpmAvailable = sync.OnceValue(func() bool {
+	if procNCryptOpenStorageProvider.Find() != nil {
+		return false
+	}
+	prov, err := openTPMProvider()
+	if err != nil {
+		return false
+	}
+	freeNCrypt(prov)
+	return true
+})
+
+func sealerName() string {
+	if !tpmAvailable() {
+		return ""
+	}
+	return "TPM"
+}
+
+func sealKey(key []byte) ([]byte, error) {
+	h, err := openTPMKey(true)
+	if err != nil {
+		return nil, err
+	}
+	defer freeNCrypt(h)
+	return ncryptCrypt(procNCryptEncrypt, h, key)
+}
+
+func unsealKey(sealed []byte) ([]byte, error) {
+	h, err := openTPMKey(false)
+	if err != nil {
+		return nil, err
+	}
+	defer freeNCrypt(h)
+	return ncryptCrypt(procNCryptDecrypt, h, sealed)
+}
+
+func openTPMProvider() (uintptr, error) {
+	name, err := windows.UTF16PtrFromString(platformCryptoProvider)
+	if err != nil {
+		return 0, err
+	}
+	var prov uintptr
+	r, _, _ := procNCryptOpenStorageProvider.Call(uintptr(unsafe.Pointer(&prov)), uintptr(unsafe.Pointer(name)), 0)
+	if r != 0 {
+		return 0, ncryptError("NCryptOpenStorageProvider", r)
+	}
+	return prov, nil
+}
+
+// openTPMKey opens the vault sealing key, creating it in the TPM if create
+// is set and there is none yet.
+func openTPMKey(create bool) (uintptr, error) {
+	prov, err := openTPMProvider()
+	if err != nil {
+		return 0, err
+	}
+	defer freeNCrypt(prov)
+	name, err := windows.UTF16PtrFromString(tpmKeyName)
+	if err != nil {
+		return 0, err
+	}
+
+	var key uintptr
+	r, _, _ := procNCryptOpenKey.Call(prov, uintptr(unsafe.Pointer(&key)), uintptr(unsafe.Pointer(name)), 0, 0)
+	if r == 0 {
+		return key, nil
+	}
+	if uint32(r) != nteBadKeyset || !create {
+		return 0, ncryptError("NCryptOpenKey", r)
+	}
+
+	alg, err := windows.UTF16PtrFromString("RSA")
+	if err != nil {
+		return 0, err
+	}
+	r, _, _ = procNCryptCreatePersistedKey.Call(prov, uintptr(unsafe.Pointer(&key)), uintptr(unsafe.Pointer(alg)), uintptr(unsafe.Pointer(name)), 0, 0)
+	if r != 0 {
+		return 0, ncryptError("NCryptCreatePersistedKey", r)
+	}
+	if r, _, _ := procNCryptFinalizeKey.Call(key, 0); r != 0 {
+		freeNCrypt(key)
+		return 0, ncryptError("NCryptFinalizeKey", r)
+	}
+	return key, nil
+}
+
+// ncryptCrypt runs NCryptEncrypt or NCryptDecrypt on in, asking for the
+// output size first.
+func ncryptCrypt(proc *windows.LazyProc, key uintptr, in []byte) ([]byte, error) {
+	if len(in) == 0 {
+		return nil, errors.New("nothing to encrypt or decrypt")
+	}
+	var n uint32
+	r, _, _ := proc.Call(key, uintptr(unsafe.Pointer(&in[0])), uintptr(len(in)), 0, 0, 0, uintptr(unsafe.Pointer(&n)), ncryptPadPKCS1)
+	if r != 0 {
+		return nil, ncryptError(proc.Name, r)
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("%s: empty result", proc.Name)
+	}
+	out := make([]byte, n)
+	r, _, _ = proc.Call(key, uintptr(unsafe.Pointer(&in[0])), uintptr(len(in)), 0, uintptr(unsafe.Pointer(&out[0])), uintptr(n), uintptr(unsafe.Pointer(&n)), ncryptPadPKCS1)
+	if r != 0 {
+		return nil, ncryptError(proc.Name, r)
+	}
+	return out[:n], nil
+}
+
+func freeNCrypt(h uintptr) {
+	_, _, _ = procNCryptFreeObject.Call(h)
+}
+
+func ncryptError(name string, status uintptr) error {
+	return fmt.Errorf("%s: %w", name, windows.Errno(uint32(status)))
+}
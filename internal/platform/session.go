@@ -0,0 +1,8 @@
+package platform
+
+// SessionLocked reports whether the user's desktop session is locked, for
+// example by the screen locker. It returns errors.ErrUnsupported where
+// VocSign cannot tell, so callers should poll it and ignore that error.
+func SessionLocked() (bool, error) {
+	return sessionLocked()
+}
@@ -0,0 +1,40 @@
+//go:build darwin && cgo
+
+package platform
+
+/*
+#cgo LDFLAGS: -framework CoreGraphics -framework CoreFoundation
+
+#include <CoreFoundation/CoreFoundation.h>
+#include <CoreGraphics/CoreGraphics.h>
+
+// vocsign_screen_locked returns 1 if the login window covers the session,
+// 0 if not, and -1 if there is no window server session to ask.
+static int vocsign_screen_locked(void) {
+	CFDictionaryRef session = CGSessionCopyCurrentDictionary();
+	if (session == NULL) {
+		return -1;
+	}
+	int locked = 0;
+	CFBooleanRef value = CFDictionaryGetValue(session, CFSTR("CGSSessionScreenIsLocked"));
+	if (value != NULL && CFGetTypeID(value) == CFBooleanGetTypeID()) {
+		locked = CFBooleanGetValue(value) ? 1 : 0;
+	}
+	CFRelease(session);
+	return locked;
+}
+*/
+import "C"
+
+import "errors"
+
+func sessionLocked() (bool, error) {
+	switch C.vocsign_screen_locked() {
+	case 1:
+		return true, nil
+	case 0:
+		return false, nil
+	default:
+		return false, errors.ErrUnsupported
+	}
+}
@@ -0,0 +1,37 @@
+//go:build linux
+
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// sessionLocked asks logind for the LockedHint of the session, which the
+// desktop's screen locker sets. Desktops that do not set it always read as
+// unlocked.
+func sessionLocked() (bool, error) {
+	if _, err := exec.LookPath("loginctl"); err != nil {
+		return false, errors.ErrUnsupported
+	}
+	id := os.Getenv("XDG_SESSION_ID")
+	if id == "" {
+		// The session of the calling process, on systemd 243 and later.
+		id = "auto"
+	}
+	out, err := exec.Command("loginctl", "show-session", id, "-p", "LockedHint", "--value").Output()
+	if err != nil {
+		return false, fmt.Errorf("loginctl: %w", err)
+	}
+	switch strings.TrimSpace(string(out)) {
+	case "yes":
+		return true, nil
+	case "no":
+		return false, nil
+	default:
+		return false, errors.ErrUnsupported
+	}
+}
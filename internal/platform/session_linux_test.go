@@ -0,0 +1,47 @@
+//go:build linux
+
+package platform
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeLoginctl puts a loginctl on PATH that prints hint for the session
+// named in XDG_SESSION_ID and fails for any other.
+func fakeLoginctl(t *testing.T, hint string) {
+	dir := t.TempDir()
+	script := "#!/bin/sh\n[ \"$2\" = \"$XDG_SESSION_ID\" ] || exit 1\necho " + hint + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "loginctl"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestSessionLockedLoginctl(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	if _, err := SessionLocked(); !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("SessionLocked without loginctl = %v", err)
+	}
+
+	t.Setenv("XDG_SESSION_ID", "7")
+	for hint, want := range map[string]bool{"yes": true, "no": false} {
+		fakeLoginctl(t, hint)
+		locked, err := SessionLocked()
+		if err != nil || locked != want {
+			t.Errorf("SessionLocked with LockedHint=%s = %v, %v", hint, locked, err)
+		}
+	}
+	fakeLoginctl(t, "")
+	if _, err := SessionLocked(); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("SessionLocked without a LockedHint = %v", err)
+	}
+
+	t.Setenv("XDG_SESSION_ID", "")
+	fakeLoginctl(t, "yes")
+	if _, err := SessionLocked(); err == nil || errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("SessionLocked for an unknown session = %v", err)
+	}
+}
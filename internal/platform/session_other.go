@@ -0,0 +1,7 @@
+//go:build !linux && !windows && (!darwin || !cgo)
+
+package platform
+
+import "errors"
+
+func sessionLocked() (bool, error) { return false, errors.ErrUnsupported }
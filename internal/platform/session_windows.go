@@ -0,0 +1,50 @@
+//go:build windows
+
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	wtsapi32                        = windows.NewLazySystemDLL("wtsapi32.dll")
+	procWTSQuerySessionInformationW = wtsapi32.NewProc("WTSQuerySessionInformationW")
+)
+
+const (
+	wtsCurrentSession = 0xFFFFFFFF
+	wtsSessionInfoEx  = 25
+
+	wtsSessionStateLock = 0
+)
+
+// wtsInfoExLevel1 is the start of WTSINFOEXW: its Level, then the first
+// fields of WTSINFOEX_LEVEL1_W.
+type wtsInfoExLevel1 struct {
+	Level        uint32
+	SessionID    uint32
+	SessionState int32
+	SessionFlags int32
+}
+
+func sessionLocked() (bool, error) {
+	if procWTSQuerySessionInformationW.Find() != nil {
+		return false, errors.ErrUnsupported
+	}
+	var buf *byte
+	var n uint32
+	r, _, err := procWTSQuerySessionInformationW.Call(0, wtsCurrentSession, wtsSessionInfoEx, uintptr(unsafe.Pointer(&buf)), uintptr(unsafe.Pointer(&n)))
+	if r == 0 {
+		return false, fmt.Errorf("WTSQuerySessionInformation: %w", err)
+	}
+	defer windows.WTSFreeMemory(uintptr(unsafe.Pointer(buf)))
+	if uintptr(n) < unsafe.Sizeof(wtsInfoExLevel1{}) {
+		return false, errors.New("WTSQuerySessionInformation: short result")
+	}
+	info := (*wtsInfoExLevel1)(unsafe.Pointer(buf))
+	return info.SessionFlags == wtsSessionStateLock, nil
+}
@@ -0,0 +1,19 @@
+package platform
+
+import "errors"
+
+// CanShare reports whether Share shows a system share sheet, which only
+// macOS has: AirDrop, Mail, Messages and the sharing extensions installed.
+func CanShare() bool {
+	return canShare()
+}
+
+// Share offers the files in the system share sheet, shown over the app
+// window. It returns once the sheet is requested; the files must exist
+// until the user has picked a service and it has read them.
+func Share(paths ...string) error {
+	if len(paths) == 0 {
+		return errors.New("nothing to share")
+	}
+	return share(paths)
+}
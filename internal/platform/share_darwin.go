@@ -0,0 +1,59 @@
+//go:build darwin && cgo
+
+package platform
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework AppKit
+
+#include <stdlib.h>
+#import <AppKit/AppKit.h>
+
+// vocsign_share shows the share sheet for the files over the middle of the
+// key window. It is shown later on the main thread, as AppKit requires:
+// waiting for it could deadlock, since the main thread waits for Gio's
+// frames while they are laid out.
+static void vocsign_share(const char **paths, int n) {
+	@autoreleasepool {
+		NSMutableArray *urls = [NSMutableArray arrayWithCapacity:n];
+		for (int i = 0; i < n; i++) {
+			NSString *p = [NSString stringWithUTF8String:paths[i]];
+			[urls addObject:[NSURL fileURLWithPath:p]];
+		}
+		dispatch_async(dispatch_get_main_queue(), ^{
+			NSWindow *win = [NSApp keyWindow];
+			if (win == nil) {
+				win = [NSApp mainWindow];
+			}
+			NSView *view = win.contentView;
+			if (view == nil) {
+				return;
+			}
+			// The picker is not released: AppKit does not retain it while
+			// the sheet is shown.
+			NSSharingServicePicker *picker = [[NSSharingServicePicker alloc] initWithItems:urls];
+			NSRect b = view.bounds;
+			[picker showRelativeToRect:NSMakeRect(NSMidX(b), NSMidY(b), 1, 1) ofView:view preferredEdge:NSRectEdgeMinY];
+		});
+	}
+}
+*/
+import "C"
+
+import "unsafe"
+
+func canShare() bool { return true }
+
+func share(paths []string) error {
+	cpaths := make([]*C.char, len(paths))
+	for i, p := range paths {
+		cpaths[i] = C.CString(p)
+	}
+	defer func() {
+		for _, p := range cpaths {
+			C.free(unsafe.Pointer(p))
+		}
+	}()
+	C.vocsign_share((**C.char)(unsafe.Pointer(&cpaths[0])), C.int(len(cpaths)))
+	return nil
+}
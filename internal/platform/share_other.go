@@ -0,0 +1,11 @@
+//go:build !darwin || !cgo
+
+package platform
+
+import "errors"
+
+func canShare() bool { return false }
+
+func share([]string) error {
+	return errors.New("sharing is only available on macOS")
+}
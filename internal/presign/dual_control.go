@@ -0,0 +1,334 @@
+package presign
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Dual-control confirmation methods.
+const (
+	// MethodTOTP verifies an RFC 6238 code generated by a second person
+	// holding the organization's shared secret. The secret is stored on
+	// the signer's computer, so TOTP rules are only honored when they come
+	// from an administrator's policy the signer cannot edit.
+	MethodTOTP = "totp"
+	// MethodRemote asks the organization's server to send a one-time code
+	// (by e-mail or SMS) and to verify it.
+	MethodRemote = "remote"
+)
+
+// DualControlRule requires a second confirmation when signing with a
+// representation certificate of OrganizationID ("*" matches any).
+type DualControlRule struct {
+	OrganizationID string `json:"organizationId"`
+	Method         string `json:"method"`
+	Secret         string `json:"secret,omitempty"` // base32, for MethodTOTP
+	URL            string `json:"url,omitempty"`    // for MethodRemote
+}
+
+// Validate checks that the rule is usable.
+func (r DualControlRule) Validate() error {
+	if r.OrganizationID == "" {
+		return errors.New("dual control rule is missing organizationId")
+	}
+	switch r.Method {
+	case MethodTOTP:
+		if _, err := decodeSecret(r.Secret); err != nil {
+			return fmt.Errorf("dual control rule for %s: invalid secret: %w", r.OrganizationID, err)
+		}
+	case MethodRemote:
+		u, err := url.Parse(r.URL)
+		if err != nil {
+			return fmt.Errorf("dual control rule for %s: invalid url: %w", r.OrganizationID, err)
+		}
+		if u.Scheme != "https" && u.Hostname() != "localhost" && u.Hostname() != "127.0.0.1" {
+			return fmt.Errorf("dual control rule for %s: url must be https", r.OrganizationID)
+		}
+	default:
+		return fmt.Errorf("dual control rule for %s: unknown method %q", r.OrganizationID, r.Method)
+	}
+	return nil
+}
+
+// DualControl is a Hook that requires a confirmation code before a
+// representation certificate may sign, to prevent misuse of shared
+// organization certificates.
+type DualControl struct {
+	Rules  []DualControlRule
+	Now    func() time.Time
+	Client *http.Client
+	// Limit counts wrong codes across checks. Nil does not limit them.
+	Limit *Limiter
+}
+
+// After MaxAttempts wrong confirmation codes in a row for an organization,
+// codes are refused for LockoutPeriod, doubled for every further wrong code
+// up to MaxLockout.
+const (
+	MaxAttempts   = 5
+	LockoutPeriod = 5 * time.Minute
+	MaxLockout    = 24 * time.Hour
+)
+
+// Limiter counts wrong codes per key, an organization for confirmation
+// codes, so that a short code cannot be guessed by trying them all. It is
+// kept in memory. The zero value is ready to use, and a nil Limiter does not
+// limit anything.
+type Limiter struct {
+	mu       sync.Mutex
+	failures map[string]int
+	until    map[string]time.Time
+}
+
+// Wait returns how long codes for org are still refused.
+func (l *Limiter) Wait(org string, now time.Time) time.Duration {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return max(l.until[org].Sub(now), 0)
+}
+
+// Fail records a wrong code for org.
+func (l *Limiter) Fail(org string, now time.Time) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.failures == nil {
+		l.failures = make(map[string]int)
+		l.until = make(map[string]time.Time)
+	}
+	l.failures[org]++
+	if n := l.failures[org] - MaxAttempts; n >= 0 {
+		lockout := MaxLockout
+		if n < 20 {
+			lockout = min(LockoutPeriod<<n, MaxLockout)
+		}
+		l.until[org] = now.Add(lockout)
+	}
+}
+
+// Reset forgets the wrong codes for org after a right one.
+func (l *Limiter) Reset(org string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.failures, org)
+	delete(l.until, org)
+}
+
+func (d *DualControl) Name() string { return "dual control" }
+
+func (d *DualControl) rule(orgID string) *DualControlRule {
+	var wildcard *DualControlRule
+	for i := range d.Rules {
+		r := &d.Rules[i]
+		if orgID != "" && strings.EqualFold(r.OrganizationID, orgID) {
+			return r
+		}
+		if r.OrganizationID == "*" {
+			wildcard = r
+		}
+	}
+	return wildcard
+}
+
+func (d *DualControl) Check(ctx context.Context, in *Input, ask Prompt) error {
+	if !in.Identity.IsRepresentative {
+		return nil
+	}
+	r := d.rule(in.Identity.OrganizationID)
+	if r == nil {
+		return nil
+	}
+	if err := r.Validate(); err != nil {
+		return err
+	}
+
+	org := in.Identity.Organization
+	if org == "" {
+		org = in.Identity.OrganizationID
+	}
+	now := time.Now
+	if d.Now != nil {
+		now = d.Now
+	}
+	key := strings.ToLower(r.OrganizationID)
+	if wait := d.Limit.Wait(key, now()); wait > 0 {
+		return fmt.Errorf("%w: too many incorrect confirmation codes, try again in %s", ErrRejected, wait.Round(time.Second))
+	}
+
+	var err error
+	switch r.Method {
+	case MethodTOTP:
+		err = d.checkTOTP(r, ask, org, now)
+	default:
+		err = d.checkRemote(ctx, r, in, ask, org)
+	}
+	switch {
+	case errors.Is(err, ErrRejected):
+		d.Limit.Fail(key, now())
+	case err == nil:
+		d.Limit.Reset(key)
+	}
+	return err
+}
+
+// checkTOTP verifies the code the user types against the rule's secret.
+func (d *DualControl) checkTOTP(r *DualControlRule, ask Prompt, org string, now func() time.Time) error {
+	code, err := ask("Enter the confirmation code from your organization's second approver for " + org)
+	if err != nil {
+		return err
+	}
+	ok, err := verifyTOTP(r.Secret, string(code), now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: confirmation code incorrect", ErrRejected)
+	}
+	return nil
+}
+
+type remoteChallengeRequest struct {
+	OrganizationID  string `json:"organizationId"`
+	RequestID       string `json:"requestId"`
+	CertFingerprint string `json:"certFingerprint"`
+}
+
+type remoteChallengeResponse struct {
+	ChallengeID string `json:"challengeId"`
+}
+
+type remoteVerifyRequest struct {
+	ChallengeID string `json:"challengeId"`
+	Code        string `json:"code"`
+}
+
+// checkRemote asks the organization's server to deliver a one-time code to
+// the second approver and then verifies the code the user types.
+func (d *DualControl) checkRemote(ctx context.Context, r *DualControlRule, in *Input, ask Prompt, org string) error {
+	fp := sha256.Sum256(in.Cert.Raw)
+	var ch remoteChallengeResponse
+	if err := d.post(ctx, strings.TrimRight(r.URL, "/")+"/challenge", remoteChallengeRequest{
+		OrganizationID:  in.Identity.OrganizationID,
+		RequestID:       in.RequestID,
+		CertFingerprint: hex.EncodeToString(fp[:]),
+	}, &ch); err != nil {
+		return fmt.Errorf("failed to request confirmation code: %w", err)
+	}
+	if ch.ChallengeID == "" {
+		return errors.New("confirmation server returned no challenge")
+	}
+
+	code, err := ask("Enter the confirmation code sent to the approver of " + org)
+	if err != nil {
+		return err
+	}
+	err = d.post(ctx, strings.TrimRight(r.URL, "/")+"/verify", remoteVerifyRequest{
+		ChallengeID: ch.ChallengeID,
+		Code:        strings.TrimSpace(string(code)),
+	}, nil)
+	if errors.Is(err, errForbidden) {
+		return fmt.Errorf("%w: confirmation code incorrect", ErrRejected)
+	}
+	return err
+}
+
+var errForbidden = errors.New("forbidden")
+
+func (d *DualControl) post(ctx context.Context, endpoint string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := d.Client
+	if client == nil {
+		client = &http.Client{Timeout: 15 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	switch {
+	case resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusUnauthorized:
+		return errForbidden
+	case resp.StatusCode/100 != 2:
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+	s := strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	s = strings.TrimRight(s, "=")
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) < 10 {
+		return nil, errors.New("secret is too short")
+	}
+	return key, nil
+}
+
+// verifyTOTP checks a 6-digit RFC 6238 code, allowing one 30-second step of
+// clock skew in either direction.
+func verifyTOTP(secret, code string, now time.Time) (bool, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return false, fmt.Errorf("invalid dual control secret: %w", err)
+	}
+	code = strings.TrimSpace(code)
+	step := now.Unix() / 30
+	for _, delta := range []int64{0, -1, 1} {
+		if hmac.Equal([]byte(hotp(key, uint64(step+delta), 6)), []byte(code)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// hotp implements RFC 4226 with HMAC-SHA1.
+func hotp(key []byte, counter uint64, digits int) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	off := sum[len(sum)-1] & 0x0f
+	bin := binary.BigEndian.Uint32(sum[off:off+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, bin%mod)
+}
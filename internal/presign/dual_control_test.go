@@ -0,0 +1,201 @@
+package presign
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/certs"
+)
+
+// RFC 4226 / RFC 6238 test secret "12345678901234567890".
+const testSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestHOTP_RFC4226Vectors(t *testing.T) {
+	key := []byte("12345678901234567890")
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for i, w := range want {
+		if got := hotp(key, uint64(i), 6); got != w {
+			t.Errorf("hotp(%d) = %s, want %s", i, got, w)
+		}
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	now := time.Unix(59, 0)
+	tests := []struct {
+		name string
+		code string
+		want bool
+	}{
+		{"current step", "287082", true},
+		{"previous step", "755224", true},
+		{"next step", "359152", true},
+		{"two steps ahead", "969429", false},
+		{"garbage", "abc", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verifyTOTP(testSecret, tt.code, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("verifyTOTP(%s) = %v, want %v", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func representative(orgID string) *Input {
+	return &Input{
+		RequestID: "req-1",
+		Cert:      &x509.Certificate{Raw: []byte("cert")},
+		Identity:  certs.ExtractedInfo{IsRepresentative: true, OrganizationID: orgID, Organization: "ACME"},
+	}
+}
+
+func answer(code string) (Prompt, *int) {
+	calls := 0
+	return func(string) ([]byte, error) {
+		calls++
+		return []byte(code), nil
+	}, &calls
+}
+
+func TestDualControl_TOTP(t *testing.T) {
+	d := &DualControl{
+		Rules: []DualControlRule{{OrganizationID: "B12345678", Method: MethodTOTP, Secret: testSecret}},
+		Now:   func() time.Time { return time.Unix(59, 0) },
+	}
+
+	t.Run("personal certificate skips", func(t *testing.T) {
+		ask, calls := answer("000000")
+		if err := d.Check(context.Background(), &Input{}, ask); err != nil || *calls != 0 {
+			t.Fatalf("err=%v calls=%d", err, *calls)
+		}
+	})
+	t.Run("other organization skips", func(t *testing.T) {
+		ask, calls := answer("000000")
+		if err := d.Check(context.Background(), representative("B99999999"), ask); err != nil || *calls != 0 {
+			t.Fatalf("err=%v calls=%d", err, *calls)
+		}
+	})
+	t.Run("correct code", func(t *testing.T) {
+		ask, calls := answer("287082")
+		if err := d.Check(context.Background(), representative("B12345678"), ask); err != nil || *calls != 1 {
+			t.Fatalf("err=%v calls=%d", err, *calls)
+		}
+	})
+	t.Run("wrong code", func(t *testing.T) {
+		ask, _ := answer("000000")
+		err := Run(context.Background(), []Hook{d}, representative("B12345678"), ask)
+		if !errors.Is(err, ErrRejected) {
+			t.Fatalf("expected ErrRejected, got %v", err)
+		}
+	})
+}
+
+func TestDualControl_Limit(t *testing.T) {
+	clock := time.Unix(59, 0)
+	d := &DualControl{
+		Rules: []DualControlRule{{OrganizationID: "B12345678", Method: MethodTOTP, Secret: testSecret}},
+		Now:   func() time.Time { return clock },
+		Limit: &Limiter{},
+	}
+	for i := range MaxAttempts {
+		ask, _ := answer("000000")
+		if err := d.Check(context.Background(), representative("B12345678"), ask); !errors.Is(err, ErrRejected) {
+			t.Fatalf("attempt %d: expected ErrRejected, got %v", i+1, err)
+		}
+	}
+
+	// Locked out: not even the right code is asked for.
+	ask, calls := answer("287082")
+	if err := d.Check(context.Background(), representative("b12345678"), ask); !errors.Is(err, ErrRejected) || *calls != 0 {
+		t.Fatalf("locked out: err=%v calls=%d", err, *calls)
+	}
+	// Other organizations are not affected.
+	d.Rules = append(d.Rules, DualControlRule{OrganizationID: "B99999999", Method: MethodTOTP, Secret: testSecret})
+	if err := d.Check(context.Background(), representative("B99999999"), ask); err != nil {
+		t.Fatalf("other organization: %v", err)
+	}
+
+	// Once the lockout ends, the right code is accepted and clears the
+	// count.
+	clock = clock.Add(LockoutPeriod)
+	key, err := decodeSecret(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ask, _ = answer(hotp(key, uint64(clock.Unix()/30), 6))
+	if err := d.Check(context.Background(), representative("B12345678"), ask); err != nil {
+		t.Fatalf("after lockout: %v", err)
+	}
+	if n := d.Limit.failures["b12345678"]; n != 0 {
+		t.Fatalf("failures after a right code = %d", n)
+	}
+}
+
+func TestDualControl_Remote(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/challenge":
+			var req remoteChallengeRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			if req.OrganizationID != "B12345678" || req.RequestID != "req-1" || req.CertFingerprint == "" {
+				http.Error(w, "bad challenge", http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(remoteChallengeResponse{ChallengeID: "ch-1"})
+		case "/verify":
+			var req remoteVerifyRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			if req.ChallengeID != "ch-1" || req.Code != "4242" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	d := &DualControl{Rules: []DualControlRule{{OrganizationID: "*", Method: MethodRemote, URL: srv.URL}}}
+
+	ask, _ := answer("4242")
+	if err := d.Check(context.Background(), representative("B12345678"), ask); err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	ask, _ = answer("0000")
+	if err := d.Check(context.Background(), representative("B12345678"), ask); !errors.Is(err, ErrRejected) {
+		t.Fatalf("expected ErrRejected, got %v", err)
+	}
+}
+
+func TestDualControlRule_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    DualControlRule
+		wantErr bool
+	}{
+		{"totp", DualControlRule{OrganizationID: "*", Method: MethodTOTP, Secret: testSecret}, false},
+		{"totp bad secret", DualControlRule{OrganizationID: "*", Method: MethodTOTP, Secret: "!!"}, true},
+		{"remote http", DualControlRule{OrganizationID: "*", Method: MethodRemote, URL: "http://example.com"}, true},
+		{"unknown method", DualControlRule{OrganizationID: "*", Method: "sms"}, true},
+		{"missing org", DualControlRule{Method: MethodTOTP, Secret: testSecret}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.rule.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+// Package presign runs pluggable checks right before a signature is
+// produced. A hook may ask the user for extra input (such as a confirmation
+// code) and aborts signing by returning an error.
+package presign
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/certs"
+)
+
+// ErrRejected is wrapped by hooks that refuse to let signing continue.
+var ErrRejected = errors.New("pre-sign check rejected")
+
+// Input describes the signature about to be produced.
+type Input struct {
+	RequestID     string
+	ProposalTitle string
+	Cert          *x509.Certificate
+	Identity      certs.ExtractedInfo
+}
+
+// Prompt asks the user for a short secret. message explains what is needed.
+type Prompt func(message string) ([]byte, error)
+
+// Hook is a single pre-sign check.
+type Hook interface {
+	Name() string
+	Check(ctx context.Context, in *Input, ask Prompt) error
+}
+
+// Run executes hooks in order and stops at the first failure.
+func Run(ctx context.Context, hooks []Hook, in *Input, ask Prompt) error {
+	for _, h := range hooks {
+		if err := h.Check(ctx, in, ask); err != nil {
+			return fmt.Errorf("%s: %w", h.Name(), err)
+		}
+	}
+	return nil
+}
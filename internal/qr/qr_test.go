@@ -0,0 +1,94 @@
+package qr
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRSRemainder(t *testing.T) {
+	// Worked example from ISO/IEC 18004 Annex I ("01234567", version 1-M).
+	data := []byte{0x10, 0x20, 0x0C, 0x56, 0x61, 0x80, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11}
+	want := []byte{0xA5, 0x24, 0xD4, 0xC1, 0xED, 0x36, 0xC7, 0x87, 0x2C, 0x55}
+	got := rsRemainder(data, rsDivisor(10))
+	if !bytes.Equal(got, want) {
+		t.Fatalf("rsRemainder = % X, want % X", got, want)
+	}
+}
+
+func TestEncodeVersionSelection(t *testing.T) {
+	tests := []struct {
+		name    string
+		length  int
+		version int
+	}{
+		{"tiny", 1, 1},
+		{"v1 capacity", 14, 1},
+		{"v2", 15, 2},
+		{"request id and hash", 100, 6},
+		{"v10 capacity", 213, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Encode(bytes.Repeat([]byte("a"), tt.length))
+			if err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+			if c.Version != tt.version {
+				t.Errorf("Version = %d, want %d", c.Version, tt.version)
+			}
+			if c.Size != 17+4*tt.version || len(c.Modules) != c.Size {
+				t.Errorf("Size = %d, want %d", c.Size, 17+4*tt.version)
+			}
+		})
+	}
+
+	if _, err := Encode(bytes.Repeat([]byte("a"), 214)); err != ErrTooLong {
+		t.Fatalf("expected ErrTooLong, got %v", err)
+	}
+}
+
+func TestEncodeFunctionPatterns(t *testing.T) {
+	c, err := Encode([]byte("VOCSIGN:1:ILP-2026-HABITATGE"))
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	// Finder pattern centres and the always-dark module.
+	for _, p := range [][2]int{{3, 3}, {c.Size - 4, 3}, {3, c.Size - 4}, {8, c.Size - 8}} {
+		if !c.Modules[p[1]][p[0]] {
+			t.Errorf("module (%d,%d) should be dark", p[0], p[1])
+		}
+	}
+	// Both format info copies must agree.
+	var a, b int
+	for i := 0; i < 8; i++ {
+		if c.Modules[8][c.Size-1-i] {
+			b |= 1 << i
+		}
+	}
+	for i := 0; i <= 5; i++ {
+		if c.Modules[i][8] {
+			a |= 1 << i
+		}
+	}
+	if c.Modules[7][8] {
+		a |= 1 << 6
+	}
+	if c.Modules[8][8] {
+		a |= 1 << 7
+	}
+	if a != b {
+		t.Errorf("format info copies differ: %08b vs %08b", a, b)
+	}
+}
+
+func TestSVG(t *testing.T) {
+	c, err := Encode([]byte("hello"))
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	svg := c.SVG(4)
+	if !strings.HasPrefix(svg, "<svg") || !strings.HasSuffix(svg, "</svg>") {
+		t.Fatalf("unexpected SVG output: %.60s", svg)
+	}
+}
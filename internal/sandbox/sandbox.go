@@ -0,0 +1,210 @@
+// Package sandbox detects whether VocSign runs confined in a Flatpak or Snap
+// package, and where it should look for the user's files from there.
+//
+// Inside a snap $HOME is a per-snap folder, so the real home folder has to
+// be taken from SNAP_REAL_HOME. Inside a Flatpak $HOME is the real home
+// folder, but only the parts the permissions grant can be read. Either way
+// files are chosen through the XDG desktop portal, and libraries are loaded
+// from the package itself rather than from the host.
+package sandbox
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Kind is the packaging format confining the process.
+type Kind string
+
+const (
+	Flatpak Kind = "Flatpak"
+	Snap    Kind = "Snap"
+)
+
+// flatpakInfoPath is the file Flatpak places at the root of every sandbox.
+const flatpakInfoPath = "/.flatpak-info"
+
+// Info describes the sandbox. Kind is empty when the process is not
+// confined.
+type Info struct {
+	Kind Kind
+	// AppID is the Flatpak application ID or the snap name.
+	AppID string
+	// Home is the user's real home folder.
+	Home string
+	// Bundle is where the package's own files are: /app or $SNAP.
+	Bundle string
+	// Filesystems are the Flatpak filesystem permissions, such as "home" or
+	// "xdg-download:ro".
+	Filesystems []string
+}
+
+var detected = sync.OnceValue(func() Info {
+	home, _ := os.UserHomeDir()
+	if runtime.GOOS != "linux" {
+		return Info{Home: home}
+	}
+	info := detect(os.Getenv, flatpakInfoPath)
+	if info.Home == "" {
+		info.Home = home
+	}
+	return info
+})
+
+// Detect returns the sandbox of the running process.
+func Detect() Info {
+	return detected()
+}
+
+// HomeDir returns the user's real home folder, also inside a snap.
+func HomeDir() string {
+	return Detect().Home
+}
+
+func detect(getenv func(string) string, infoPath string) Info {
+	if f, err := os.Open(infoPath); err == nil {
+		defer func() { _ = f.Close() }()
+		info := parseFlatpakInfo(f)
+		info.Kind = Flatpak
+		info.Bundle = "/app"
+		info.Home = getenv("HOME")
+		if info.AppID == "" {
+			info.AppID = getenv("FLATPAK_ID")
+		}
+		return info
+	}
+	if snap, name := getenv("SNAP"), getenv("SNAP_NAME"); snap != "" && name != "" {
+		home := getenv("SNAP_REAL_HOME")
+		if home == "" {
+			// snapd before 2.46 only sets HOME, to ~/snap/<name>/<revision>.
+			home = getenv("HOME")
+			if i := strings.Index(home, "/snap/"+name+"/"); i > 0 {
+				home = home[:i]
+			}
+		}
+		return Info{Kind: Snap, AppID: name, Home: home, Bundle: snap}
+	}
+	return Info{Home: getenv("HOME")}
+}
+
+// parseFlatpakInfo reads the application ID and filesystem permissions of
+// a .flatpak-info key file.
+func parseFlatpakInfo(r io.Reader) Info {
+	var info Info
+	section := ""
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = line[1 : len(line)-1]
+			continue
+		}
+		key, val, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		switch {
+		case section == "Application" && key == "name":
+			info.AppID = val
+		case section == "Context" && key == "filesystems":
+			for _, fs := range strings.Split(val, ";") {
+				if fs != "" {
+					info.Filesystems = append(info.Filesystems, fs)
+				}
+			}
+		}
+	}
+	return info
+}
+
+// Confined reports whether the process runs in a sandbox.
+func (i Info) Confined() bool {
+	return i.Kind != ""
+}
+
+// String names the sandbox for diagnostics, e.g. "Flatpak (org.vocdoni.VocSign)".
+func (i Info) String() string {
+	if !i.Confined() {
+		return "none"
+	}
+	if i.AppID == "" {
+		return string(i.Kind)
+	}
+	return string(i.Kind) + " (" + i.AppID + ")"
+}
+
+// LibraryDirs returns the folders of libraries shipped inside the package,
+// which are preferred over host libraries built against another system.
+func (i Info) LibraryDirs() []string {
+	switch i.Kind {
+	case Flatpak:
+		return []string{"/app/lib", "/app/lib64"}
+	case Snap:
+		var dirs []string
+		if triplet := multiarchTriplet(); triplet != "" {
+			dirs = append(dirs, filepath.Join(i.Bundle, "usr", "lib", triplet), filepath.Join(i.Bundle, "lib", triplet))
+		}
+		return append(dirs, filepath.Join(i.Bundle, "usr", "lib"), filepath.Join(i.Bundle, "lib"))
+	}
+	return nil
+}
+
+// GrantHint returns the command that lets the sandbox read dir, or "" if
+// the user cannot grant it.
+func (i Info) GrantHint(dir string) string {
+	switch i.Kind {
+	case Flatpak:
+		path := dir
+		if rel, err := filepath.Rel(i.Home, dir); err == nil && rel != ".." && !strings.HasPrefix(rel, "../") {
+			path = "~/" + filepath.ToSlash(rel)
+			if rel == "." {
+				path = "home"
+			}
+		}
+		return "flatpak override --user --filesystem=" + path + ":ro " + i.AppID
+	case Snap:
+		// The home interface covers the visible files of the home folder;
+		// hidden folders need a plug declared by the snap itself.
+		rel, err := filepath.Rel(i.Home, dir)
+		if err != nil || (strings.HasPrefix(rel, ".") && rel != ".") {
+			return ""
+		}
+		return "snap connect " + i.AppID + ":home"
+	}
+	return ""
+}
+
+// Readable reports whether dir can be listed. A sandbox hides what its
+// permissions do not grant, either as missing or as denied.
+func Readable(dir string) bool {
+	f, err := os.Open(dir)
+	if err != nil {
+		return false
+	}
+	defer func() { _ = f.Close() }()
+	_, err = f.Readdirnames(1)
+	return err == nil || errors.Is(err, io.EOF)
+}
+
+func multiarchTriplet() string {
+	switch runtime.GOARCH {
+	case "amd64":
+		return "x86_64-linux-gnu"
+	case "arm64":
+		return "aarch64-linux-gnu"
+	case "arm":
+		return "arm-linux-gnueabihf"
+	case "386":
+		return "i386-linux-gnu"
+	}
+	return ""
+}
@@ -0,0 +1,89 @@
+package sandbox
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func env(vars map[string]string) func(string) string {
+	return func(name string) string { return vars[name] }
+}
+
+func TestDetectFlatpak(t *testing.T) {
+	infoPath := filepath.Join(t.TempDir(), ".flatpak-info")
+	data := "[Application]\nname=org.vocdoni.VocSign\nruntime=runtime/org.freedesktop.Platform/x86_64/24.08\n\n" +
+		"[Context]\nshared=network;ipc;\nfilesystems=xdg-download:ro;~/.mozilla:ro;\n"
+	if err := os.WriteFile(infoPath, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	info := detect(env(map[string]string{"HOME": "/home/ana"}), infoPath)
+	if info.Kind != Flatpak || info.AppID != "org.vocdoni.VocSign" || info.Home != "/home/ana" || info.Bundle != "/app" {
+		t.Fatalf("detect = %+v", info)
+	}
+	if !slices.Equal(info.Filesystems, []string{"xdg-download:ro", "~/.mozilla:ro"}) {
+		t.Fatalf("Filesystems = %q", info.Filesystems)
+	}
+	if got := info.String(); got != "Flatpak (org.vocdoni.VocSign)" {
+		t.Fatalf("String = %q", got)
+	}
+	if got := info.GrantHint("/home/ana/.pki/nssdb"); got != "flatpak override --user --filesystem=~/.pki/nssdb:ro org.vocdoni.VocSign" {
+		t.Fatalf("GrantHint = %q", got)
+	}
+	if got := info.GrantHint("/home/ana"); got != "flatpak override --user --filesystem=home:ro org.vocdoni.VocSign" {
+		t.Fatalf("GrantHint(home) = %q", got)
+	}
+}
+
+func TestDetectSnap(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), ".flatpak-info")
+	info := detect(env(map[string]string{
+		"SNAP":           "/snap/vocsign/12",
+		"SNAP_NAME":      "vocsign",
+		"HOME":           "/home/ana/snap/vocsign/12",
+		"SNAP_REAL_HOME": "/home/ana",
+	}), missing)
+	if info.Kind != Snap || info.AppID != "vocsign" || info.Home != "/home/ana" || info.Bundle != "/snap/vocsign/12" {
+		t.Fatalf("detect = %+v", info)
+	}
+	if got := info.GrantHint("/home/ana/Documents"); got != "snap connect vocsign:home" {
+		t.Fatalf("GrantHint = %q", got)
+	}
+	if got := info.GrantHint("/home/ana/.mozilla"); got != "" {
+		t.Fatalf("GrantHint(hidden) = %q", got)
+	}
+	if dirs := info.LibraryDirs(); !slices.Contains(dirs, "/snap/vocsign/12/usr/lib") {
+		t.Fatalf("LibraryDirs = %q", dirs)
+	}
+
+	// Older snapd does not set SNAP_REAL_HOME.
+	info = detect(env(map[string]string{
+		"SNAP":      "/snap/vocsign/12",
+		"SNAP_NAME": "vocsign",
+		"HOME":      "/home/ana/snap/vocsign/12",
+	}), missing)
+	if info.Home != "/home/ana" {
+		t.Fatalf("Home = %q", info.Home)
+	}
+}
+
+func TestDetectNone(t *testing.T) {
+	info := detect(env(map[string]string{"HOME": "/home/ana"}), filepath.Join(t.TempDir(), ".flatpak-info"))
+	if info.Confined() || info.Home != "/home/ana" || info.String() != "none" {
+		t.Fatalf("detect = %+v", info)
+	}
+	if info.LibraryDirs() != nil || info.GrantHint("/home/ana") != "" {
+		t.Fatal("unconfined process has sandbox paths")
+	}
+}
+
+func TestReadable(t *testing.T) {
+	dir := t.TempDir()
+	if !Readable(dir) {
+		t.Fatal("empty folder is not readable")
+	}
+	if Readable(filepath.Join(dir, "missing")) {
+		t.Fatal("missing folder is readable")
+	}
+}
@@ -0,0 +1,187 @@
+// Package selfcheck verifies the running executable against the release
+// manifest signed by Vocdoni. Signatures made with VocSign have legal effect,
+// so a binary that does not match its published release must be reported.
+package selfcheck
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/canon"
+)
+
+// releasePublicKey is the base64 Ed25519 key release manifests are signed
+// with. Release builds set it with -ldflags -X; development builds leave it
+// empty and skip verification.
+var releasePublicKey = ""
+
+// SignatureSuffix is appended to the executable path to find its manifest.
+const SignatureSuffix = ".sig"
+
+// Status is the outcome of a self-check.
+type Status string
+
+const (
+	StatusVerified Status = "verified"
+	StatusUnsigned Status = "unsigned" // development build without a release key
+	StatusMissing  Status = "missing"  // no manifest next to the executable
+	StatusInvalid  Status = "invalid"  // manifest signature or hash mismatch
+	StatusError    Status = "error"    // the executable could not be read
+)
+
+// ErrTampered means the executable or its manifest was modified after the
+// release was signed.
+var ErrTampered = errors.New("binary does not match its signed release manifest")
+
+// Manifest describes a released executable.
+type Manifest struct {
+	File    string `json:"file"`
+	Version string `json:"version"`
+	SHA256  string `json:"sha256"` // hex
+}
+
+// SignedManifest is the content of the .sig file shipped next to the binary.
+// Signature is a base64 Ed25519 signature over the canonical Manifest.
+type SignedManifest struct {
+	Manifest  Manifest `json:"manifest"`
+	Signature string   `json:"signature"`
+}
+
+// Result reports what the self-check found.
+type Result struct {
+	Status Status
+	SHA256 string // hex digest of the running executable
+	Detail string
+}
+
+// Warning reports whether the user should be warned about this result.
+func (r Result) Warning() bool {
+	return r.Status == StatusMissing || r.Status == StatusInvalid || r.Status == StatusError
+}
+
+// Signed reports whether a release key is built in. Without one nothing
+// downloaded can be verified, so the updater must not install it.
+func Signed() bool {
+	return releasePublicKey != ""
+}
+
+// Check verifies the running executable against its manifest. version is
+// the build version, which the manifest must also declare.
+func Check(version string) Result {
+	exe, err := os.Executable()
+	if err != nil {
+		return Result{Status: StatusError, Detail: fmt.Sprintf("cannot locate executable: %v", err)}
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	return CheckFile(exe, version)
+}
+
+// CheckFile verifies the executable at path against the manifest next to
+// it with the embedded release key. The updater uses it on a downloaded
+// release before installing it.
+func CheckFile(path, version string) Result {
+	if releasePublicKey == "" {
+		return Result{Status: StatusUnsigned, Detail: "development build, release signature not checked"}
+	}
+	pub, err := base64.StdEncoding.DecodeString(releasePublicKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return Result{Status: StatusError, Detail: "invalid embedded release key"}
+	}
+	return Verify(path, path+SignatureSuffix, version, ed25519.PublicKey(pub))
+}
+
+// Verify checks the executable at exePath against the signed manifest at
+// sigPath.
+func Verify(exePath, sigPath, version string, pub ed25519.PublicKey) Result {
+	sum, err := fileSHA256(exePath)
+	if err != nil {
+		return Result{Status: StatusError, Detail: fmt.Sprintf("cannot read executable: %v", err)}
+	}
+	res := Result{SHA256: sum}
+
+	data, err := os.ReadFile(sigPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			res.Status = StatusMissing
+			res.Detail = "release signature file " + filepath.Base(sigPath) + " not found"
+			return res
+		}
+		res.Status = StatusError
+		res.Detail = fmt.Sprintf("cannot read release signature: %v", err)
+		return res
+	}
+	if err := verifyManifest(data, sum, version, pub); err != nil {
+		res.Status = StatusInvalid
+		res.Detail = err.Error()
+		return res
+	}
+	res.Status = StatusVerified
+	res.Detail = "matches the signed release manifest"
+	return res
+}
+
+func verifyManifest(data []byte, sum, version string, pub ed25519.PublicKey) error {
+	var sm SignedManifest
+	if err := json.Unmarshal(data, &sm); err != nil {
+		return fmt.Errorf("%w: malformed manifest: %v", ErrTampered, err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(sm.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: malformed manifest signature", ErrTampered)
+	}
+	msg, err := canon.Encode(sm.Manifest)
+	if err != nil {
+		return fmt.Errorf("failed to canonicalize manifest: %w", err)
+	}
+	if !ed25519.Verify(pub, msg, sig) {
+		return fmt.Errorf("%w: manifest signature is not valid", ErrTampered)
+	}
+	if sm.Manifest.SHA256 != sum {
+		return fmt.Errorf("%w: executable hash %s, manifest %s", ErrTampered, sum, sm.Manifest.SHA256)
+	}
+	if version != "" && sm.Manifest.Version != version {
+		return fmt.Errorf("%w: manifest is for version %s, running %s", ErrTampered, sm.Manifest.Version, version)
+	}
+	return nil
+}
+
+// Sign produces the .sig content for the executable at exePath. It is used
+// by the release tooling.
+func Sign(exePath, version string, priv ed25519.PrivateKey) ([]byte, error) {
+	sum, err := fileSHA256(exePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash executable: %w", err)
+	}
+	m := Manifest{File: filepath.Base(exePath), Version: version, SHA256: sum}
+	msg, err := canon.Encode(m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize manifest: %w", err)
+	}
+	return json.MarshalIndent(SignedManifest{
+		Manifest:  m,
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(priv, msg)),
+	}, "", "  ")
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,114 @@
+package selfcheck
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "vocsign")
+	if err := os.WriteFile(exe, []byte("release binary"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	sig, err := Sign(exe, "1.4.0", priv)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	sigPath := exe + SignatureSuffix
+	if err := os.WriteFile(sigPath, sig, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		version string
+		pub     ed25519.PublicKey
+		tamper  bool
+		noSig   bool
+		want    Status
+	}{
+		{"verified", "1.4.0", pub, false, false, StatusVerified},
+		{"other key", "1.4.0", otherPub, false, false, StatusInvalid},
+		{"other version", "1.3.9", pub, false, false, StatusInvalid},
+		{"tampered binary", "1.4.0", pub, true, false, StatusInvalid},
+		{"missing signature", "1.4.0", pub, false, true, StatusMissing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := exe
+			if tt.tamper {
+				path = filepath.Join(t.TempDir(), "vocsign")
+				if err := os.WriteFile(path, []byte("patched binary"), 0o700); err != nil {
+					t.Fatal(err)
+				}
+			}
+			sp := sigPath
+			if tt.noSig {
+				sp = filepath.Join(t.TempDir(), "vocsign.sig")
+			}
+			got := Verify(path, sp, tt.version, tt.pub)
+			if got.Status != tt.want {
+				t.Fatalf("Status = %s (%s), want %s", got.Status, got.Detail, tt.want)
+			}
+			if got.Warning() != (tt.want != StatusVerified) {
+				t.Fatalf("Warning() = %v for %s", got.Warning(), got.Status)
+			}
+		})
+	}
+}
+
+func TestCheck_Unsigned(t *testing.T) {
+	if got := Check("dev"); got.Status != StatusUnsigned || got.Warning() {
+		t.Fatalf("expected unsigned development build without warning, got %+v", got)
+	}
+	if Signed() {
+		t.Fatal("Signed() without a release key")
+	}
+}
+
+func TestCheckFile(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exe := filepath.Join(t.TempDir(), "vocsign-linux-amd64")
+	if err := os.WriteFile(exe, []byte("downloaded release"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if got := CheckFile(exe, "1.5.0"); got.Status != StatusUnsigned {
+		t.Fatalf("without a release key: %+v", got)
+	}
+
+	defer func(k string) { releasePublicKey = k }(releasePublicKey)
+	releasePublicKey = base64.StdEncoding.EncodeToString(pub)
+	if !Signed() {
+		t.Fatal("Signed() with a release key")
+	}
+	if got := CheckFile(exe, "1.5.0"); got.Status != StatusMissing {
+		t.Fatalf("without a manifest: %+v", got)
+	}
+	sig, err := Sign(exe, "1.5.0", priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(exe+SignatureSuffix, sig, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := CheckFile(exe, "1.5.0"); got.Status != StatusVerified {
+		t.Fatalf("signed release: %+v", got)
+	}
+}
@@ -0,0 +1,201 @@
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/locale"
+	"github.com/vocdoni/gofirma/vocsign/internal/presign"
+	"github.com/vocdoni/gofirma/vocsign/internal/storage"
+)
+
+const (
+	// ProfileFormat identifies a configuration profile file.
+	ProfileFormat = "vocsign-profile"
+	// ProfileVersion is the profile layout written by this version.
+	ProfileVersion = 1
+
+	maxProfileSize = 1 << 20
+)
+
+// Profile is a configuration exported from one installation to set up
+// others the same way, e.g. the laptops of a collection point. It carries
+// the settings, the pinned organizers and the servers request links were
+// allowed to open. It does not carry what only makes sense on the computer
+// it was exported from: the agent certificate and a pending rescan
+// reminder.
+type Profile struct {
+	Format     string                    `json:"format"`
+	Version    int                       `json:"version"`
+	ExportedAt string                    `json:"exportedAt"`
+	Settings   Settings                  `json:"settings"`
+	Organizers []storage.PinnedOrganizer `json:"organizers,omitempty"`
+}
+
+// NewProfile returns the profile of st and organizers. TOTP dual-control
+// rules are left out: their secret must only reach a computer through an
+// administrator's policy.
+func NewProfile(st Settings, organizers []storage.PinnedOrganizer, now time.Time) Profile {
+	st.AgentCertID = ""
+	st.RescanReminderAt = ""
+	st.DualControl = slices.DeleteFunc(slices.Clone(st.DualControl), func(r presign.DualControlRule) bool {
+		return r.Method == presign.MethodTOTP
+	})
+	return Profile{
+		Format:     ProfileFormat,
+		Version:    ProfileVersion,
+		ExportedAt: now.UTC().Format(time.RFC3339),
+		Settings:   st,
+		Organizers: organizers,
+	}
+}
+
+// WriteProfile writes p to w as indented JSON.
+func WriteProfile(w io.Writer, p Profile) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+// ReadProfile decodes and validates a profile.
+func ReadProfile(r io.Reader) (Profile, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxProfileSize+1))
+	if err != nil {
+		return Profile{}, err
+	}
+	if len(data) > maxProfileSize {
+		return Profile{}, errors.New("profile is too large")
+	}
+	// An option this version does not know would be dropped silently, so
+	// the profile would not be applied whole.
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var p Profile
+	if err := dec.Decode(&p); err != nil {
+		return Profile{}, fmt.Errorf("not a VocSign profile: %w", err)
+	}
+	if p.Format != ProfileFormat {
+		return Profile{}, errors.New("not a VocSign profile")
+	}
+	if p.Version != ProfileVersion {
+		return Profile{}, fmt.Errorf("unsupported profile version %d", p.Version)
+	}
+	if err := p.Validate(); err != nil {
+		return Profile{}, err
+	}
+	for _, r := range p.Settings.DualControl {
+		if r.Method == presign.MethodTOTP {
+			return Profile{}, fmt.Errorf("dual control rule for %s: TOTP rules can only be set by an administrator's policy", r.OrganizationID)
+		}
+	}
+	return p, nil
+}
+
+// Validate checks that the profile can be applied as is, so a profile is
+// imported whole or not at all.
+func (p Profile) Validate() error {
+	st := p.Settings
+	if !slices.Contains(SubmitReviewOptions, st.SubmitReviewSeconds) {
+		return fmt.Errorf("invalid submitReviewSeconds %d", st.SubmitReviewSeconds)
+	}
+	if !slices.Contains(PINCacheOptions, st.PINCacheMinutes) {
+		return fmt.Errorf("invalid pinCacheMinutes %d", st.PINCacheMinutes)
+	}
+	if !slices.Contains(AutoLockOptions, st.AutoLockMinutes) {
+		return fmt.Errorf("invalid autoLockMinutes %d", st.AutoLockMinutes)
+	}
+	if st.Mode != "" && st.Mode != ModeCitizen && st.Mode != ModeAgent {
+		return fmt.Errorf("invalid mode %q", st.Mode)
+	}
+	if st.UpdateChannel != "" && st.UpdateChannel != ChannelStable && st.UpdateChannel != ChannelBeta {
+		return fmt.Errorf("invalid updateChannel %q", st.UpdateChannel)
+	}
+	if st.Palette != "" && st.Palette != PaletteStandard && st.Palette != PaletteColorblind {
+		return fmt.Errorf("invalid palette %q", st.Palette)
+	}
+	if !locale.Known(st.Locale) {
+		return fmt.Errorf("invalid locale %q", st.Locale)
+	}
+	for _, r := range st.DualControl {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, g := range st.IPFSGateways {
+		if err := checkURL(g, "ipfsGateways"); err != nil {
+			return err
+		}
+	}
+	if st.ClipboardPattern != "" {
+		if _, err := regexp.Compile(st.ClipboardPattern); err != nil {
+			return fmt.Errorf("invalid clipboardPattern: %w", err)
+		}
+	}
+	if err := ValidateTSAURL(st.AuditAnchorTSAURL); err != nil {
+		return fmt.Errorf("invalid auditAnchorTsaUrl: %w", err)
+	}
+	for _, h := range st.LinkHosts {
+		if h == "" || h != strings.ToLower(h) || strings.ContainsAny(h, "/@ ") {
+			return fmt.Errorf("invalid linkHosts entry %q", h)
+		}
+	}
+	for _, o := range p.Organizers {
+		if err := checkURL(o.JWKSetURL, "organizer jwkSetUrl"); err != nil {
+			return err
+		}
+		if err := checkURL(o.CampaignIndexURL, "organizer campaignIndexUrl"); err != nil {
+			return err
+		}
+		if o.TransparencyLogURL != "" {
+			if err := checkURL(o.TransparencyLogURL, "organizer transparencyLogUrl"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Apply returns current with the profile's settings, keeping the ones the
+// profile does not carry.
+func (p Profile) Apply(current Settings) Settings {
+	st := p.Settings
+	st.AgentCertID = current.AgentCertID
+	st.RescanReminderAt = current.RescanReminderAt
+	return st
+}
+
+// ValidateTSAURL accepts an empty URL, which disables audit anchoring, or
+// an absolute http(s) URL.
+func ValidateTSAURL(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("use an http:// or https:// address")
+	}
+	return nil
+}
+
+// checkURL requires an https URL, or http for localhost.
+func checkURL(raw, what string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid %s %q", what, raw)
+	}
+	if u.Scheme != "https" && u.Hostname() != "localhost" && u.Hostname() != "127.0.0.1" {
+		return fmt.Errorf("%s must be https: %q", what, raw)
+	}
+	return nil
+}
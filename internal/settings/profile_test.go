@@ -0,0 +1,112 @@
+package settings
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/presign"
+	"github.com/vocdoni/gofirma/vocsign/internal/storage"
+)
+
+func TestProfileRoundTrip(t *testing.T) {
+	st := Default()
+	st.Mode = ModeAgent
+	st.AgentCertID = "cert-1"
+	st.RescanReminderAt = "2026-10-20T09:00:00Z"
+	st.IPFSGateways = []string{"https://gw.example"}
+	st.LinkHosts = []string{"vocdoni.io"}
+	remote := presign.DualControlRule{OrganizationID: "*", Method: presign.MethodRemote, URL: "https://approve.example"}
+	st.DualControl = []presign.DualControlRule{
+		{OrganizationID: "B12345678", Method: presign.MethodTOTP, Secret: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"},
+		remote,
+	}
+	organizers := []storage.PinnedOrganizer{{Name: "A", JWKSetURL: "https://a.example/jwks.json", CampaignIndexURL: "https://a.example/campaigns"}}
+
+	var buf bytes.Buffer
+	if err := WriteProfile(&buf, NewProfile(st, organizers, time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC))); err != nil {
+		t.Fatalf("WriteProfile: %v", err)
+	}
+	if strings.Contains(buf.String(), "cert-1") || strings.Contains(buf.String(), "2026-10-20") || strings.Contains(buf.String(), "GEZDGNBV") {
+		t.Fatalf("profile carries settings of this computer: %s", buf.String())
+	}
+	p, err := ReadProfile(&buf)
+	if err != nil {
+		t.Fatalf("ReadProfile: %v", err)
+	}
+	if !reflect.DeepEqual(p.Organizers, organizers) || p.ExportedAt != "2026-10-17T12:00:00Z" {
+		t.Fatalf("ReadProfile = %+v", p)
+	}
+
+	other := Default()
+	other.AgentCertID = "cert-2"
+	got := p.Apply(other)
+	want := st
+	want.AgentCertID = "cert-2"
+	want.RescanReminderAt = ""
+	want.DualControl = []presign.DualControlRule{remote}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Apply = %+v, want %+v", got, want)
+	}
+}
+
+func TestReadProfile_Invalid(t *testing.T) {
+	valid := func() Profile { return NewProfile(Default(), nil, time.Now()) }
+	tests := []struct {
+		name    string
+		edit    func(*Profile)
+		wantErr string
+	}{
+		{"format", func(p *Profile) { p.Format = "other" }, "not a VocSign profile"},
+		{"version", func(p *Profile) { p.Version = 2 }, "unsupported profile version 2"},
+		{"review window", func(p *Profile) { p.Settings.SubmitReviewSeconds = 7 }, "submitReviewSeconds"},
+		{"mode", func(p *Profile) { p.Settings.Mode = "admin" }, "invalid mode"},
+		{"channel", func(p *Profile) { p.Settings.UpdateChannel = "nightly" }, "invalid updateChannel"},
+		{"palette", func(p *Profile) { p.Settings.Palette = "sepia" }, "invalid palette"},
+		{"locale", func(p *Profile) { p.Settings.Locale = "tlh" }, "invalid locale"},
+		{"auto-lock", func(p *Profile) { p.Settings.AutoLockMinutes = 7 }, "invalid autoLockMinutes"},
+		{"gateway", func(p *Profile) { p.Settings.IPFSGateways = []string{"http://gw.example"} }, "ipfsGateways must be https"},
+		{"pattern", func(p *Profile) { p.Settings.ClipboardPattern = "(" }, "clipboardPattern"},
+		{"tsa", func(p *Profile) { p.Settings.AuditAnchorTSAURL = "ftp://tsa.example" }, "auditAnchorTsaUrl"},
+		{"link host", func(p *Profile) { p.Settings.LinkHosts = []string{"evil.example/path"} }, "linkHosts"},
+		{"dual control", func(p *Profile) {
+			p.Settings.DualControl = []presign.DualControlRule{{Method: presign.MethodTOTP}}
+		}, "organizationId"},
+		{"totp dual control", func(p *Profile) {
+			p.Settings.DualControl = []presign.DualControlRule{{OrganizationID: "*", Method: presign.MethodTOTP, Secret: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"}}
+		}, "administrator's policy"},
+		{"organizer", func(p *Profile) {
+			p.Organizers = []storage.PinnedOrganizer{{JWKSetURL: "http://a.example/jwks.json", CampaignIndexURL: "https://a.example/c"}}
+		}, "jwkSetUrl must be https"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := valid()
+			tt.edit(&p)
+			var buf bytes.Buffer
+			if err := WriteProfile(&buf, p); err != nil {
+				t.Fatal(err)
+			}
+			_, err := ReadProfile(&buf)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ReadProfile error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+	if _, err := ReadProfile(strings.NewReader("<xml/>")); err == nil || !strings.Contains(err.Error(), "not a VocSign profile") {
+		t.Fatalf("ReadProfile of non-JSON = %v", err)
+	}
+
+	// Options this version does not know reject the whole profile.
+	for _, doc := range []string{
+		`{"format":"vocsign-profile","version":1,"telemetry":true,"settings":{}}`,
+		`{"format":"vocsign-profile","version":1,"settings":{"clipboardDetec":true}}`,
+		`{"format":"vocsign-profile","version":1,"settings":{},"organizers":[{"jwkSetUrl":"https://a.example/jwks.json","campaignIndexUrl":"https://a.example/c","trusted":true}]}`,
+	} {
+		if _, err := ReadProfile(strings.NewReader(doc)); err == nil || !strings.Contains(err.Error(), "unknown field") {
+			t.Errorf("ReadProfile(%s) = %v, want an unknown field error", doc, err)
+		}
+	}
+}
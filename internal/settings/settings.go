@@ -0,0 +1,288 @@
+// Package settings persists user preferences in ~/.vocsign/settings.json.
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/presign"
+)
+
+// Settings holds user preferences. Zero values of new fields must be safe
+// defaults so older settings files keep loading.
+type Settings struct {
+	// SubmitReviewSeconds is the review window between signing and sending
+	// the signature to the organizer. Zero submits immediately.
+	SubmitReviewSeconds int `json:"submitReviewSeconds"`
+
+	// TelemetryEnabled opts in to anonymous aggregate usage events. Off
+	// unless the user explicitly enables it.
+	TelemetryEnabled bool `json:"telemetryEnabled"`
+
+	// PINCacheMinutes is how long a hardware token PIN is remembered after
+	// a successful login. Zero asks for the PIN every time.
+	PINCacheMinutes int `json:"pinCacheMinutes"`
+
+	// AutoLockMinutes locks the wallet after this many minutes without
+	// using VocSign. Zero never locks it for being idle. Locking needs a
+	// wallet PIN to unlock with.
+	AutoLockMinutes int `json:"autoLockMinutes"`
+
+	// LockOnSessionLock locks the wallet when the computer's session is
+	// locked.
+	LockOnSessionLock bool `json:"lockOnSessionLock"`
+
+	// DualControl requires a second confirmation code before signing with
+	// representation certificates of the listed organizations. It is
+	// provisioned by the organization rather than edited in the UI.
+	DualControl []presign.DualControlRule `json:"dualControl,omitempty"`
+
+	// IPFSGateways resolve ipfs:// request and document URIs, tried in
+	// order. Empty uses the built-in gateways.
+	IPFSGateways []string `json:"ipfsGateways,omitempty"`
+
+	// ClipboardDetect offers to open a signing URL found in the clipboard
+	// when the window gains focus. Off by default, since it reads the
+	// clipboard without the user asking.
+	ClipboardDetect bool `json:"clipboardDetect"`
+
+	// ClipboardPattern is the regular expression clipboard URLs must match.
+	// Empty uses the built-in pattern for /request/ paths and .jws files.
+	ClipboardPattern string `json:"clipboardPattern,omitempty"`
+
+	// ClipboardProbe also downloads clipboard URLs that do not match the
+	// pattern to check whether they are sign requests. Off by default since
+	// it contacts whatever server the copied URL points to.
+	ClipboardProbe bool `json:"clipboardProbe"`
+
+	// RescanReminderAt is when to remind a user who was waiting for a
+	// certificate to be issued to scan again (RFC 3339). Empty means none.
+	RescanReminderAt string `json:"rescanReminderAt,omitempty"`
+
+	// RememberSignerData keeps the name corrections and birth date typed
+	// when signing, encrypted in the certificate vault, to fill them in the
+	// next time the same certificate is used. Off by default.
+	RememberSignerData bool `json:"rememberSignerData"`
+
+	// PortableVault keeps the vault key unbound from this computer's TPM or
+	// Secure Enclave, so the wallet files can be copied to another
+	// computer. Off by default: the key is bound where the hardware is
+	// available.
+	PortableVault bool `json:"portableVault"`
+
+	// Mode is ModeCitizen or ModeAgent. Empty is citizen mode.
+	Mode string `json:"mode,omitempty"`
+
+	// AgentCertID is the certificate a certifying agent signs with. Agent
+	// mode does not sign until one is chosen.
+	AgentCertID string `json:"agentCertId,omitempty"`
+
+	// LinkHosts are the servers, in ASCII form, the user agreed to open
+	// links from requests to. Links to other servers ask first.
+	LinkHosts []string `json:"linkHosts,omitempty"`
+
+	// AuditAnchorTSAURL is the RFC 3161 timestamp server the audit history
+	// and the security log are anchored with once a day. Empty disables
+	// anchoring.
+	AuditAnchorTSAURL string `json:"auditAnchorTsaUrl,omitempty"`
+
+	// UpdateChannel is ChannelStable or ChannelBeta, the releases the
+	// update check offers. Empty is the stable channel.
+	UpdateChannel string `json:"updateChannel,omitempty"`
+
+	// Palette is PaletteStandard or PaletteColorblind, the status colors
+	// of the interface. Empty is the standard palette.
+	Palette string `json:"palette,omitempty"`
+
+	// Locale is the BCP 47 tag of the locale dates and numbers are written
+	// in, one of locale.All. Empty is ISO 8601.
+	Locale string `json:"locale,omitempty"`
+}
+
+const (
+	// ModeCitizen signs once with the user's own certificate and data.
+	ModeCitizen = "citizen"
+	// ModeAgent is for certifying agents ("fedatari") who collect the
+	// signatures of many citizens at a table and certify each one with
+	// their own certificate.
+	ModeAgent = "agent"
+)
+
+const (
+	// ChannelStable offers published releases only.
+	ChannelStable = "stable"
+	// ChannelBeta also offers pre-releases, for testers.
+	ChannelBeta = "beta"
+)
+
+const (
+	// PaletteStandard shows status in green, orange and red.
+	PaletteStandard = "standard"
+	// PaletteColorblind shows status in colors that stay distinct with the
+	// common forms of color blindness.
+	PaletteColorblind = "colorblind"
+)
+
+// ColorblindPalette reports whether the colorblind-safe palette is chosen.
+func (s Settings) ColorblindPalette() bool {
+	return s.Palette == PaletteColorblind
+}
+
+// Channel returns the update channel, ChannelStable when unset.
+func (s Settings) Channel() string {
+	if s.UpdateChannel == ChannelBeta {
+		return ChannelBeta
+	}
+	return ChannelStable
+}
+
+// AgentMode reports whether the certifying agent workflow is enabled.
+func (s Settings) AgentMode() bool {
+	return s.Mode == ModeAgent
+}
+
+// SubmitReviewOptions are the choices offered in the settings screen.
+var SubmitReviewOptions = []int{0, 5, 10, 30}
+
+// PINCacheOptions are the PIN retention choices, in minutes.
+var PINCacheOptions = []int{0, 1, 5, 15}
+
+// AutoLockOptions are the idle times, in minutes, after which the wallet
+// can lock itself.
+var AutoLockOptions = []int{0, 5, 15, 30, 60}
+
+func Default() Settings {
+	return Settings{
+		SubmitReviewSeconds: 10,
+		PINCacheMinutes:     5,
+	}
+}
+
+type Store struct {
+	mu       sync.RWMutex
+	filePath string
+	current  Settings
+	enforce  func(*Settings)
+
+	listeners []func(old, next Settings)
+}
+
+// NewStore loads settings from dir, falling back to defaults when the file
+// does not exist yet.
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	s := &Store{
+		filePath: filepath.Join(dir, "settings.json"),
+		current:  Default(),
+	}
+	data, err := os.ReadFile(s.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("failed to read settings: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.current); err != nil {
+		return nil, fmt.Errorf("failed to decode settings: %w", err)
+	}
+	return s, nil
+}
+
+func (s *Store) Get() Settings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+// Update applies fn to a copy of the current settings and persists the result.
+func (s *Store) Update(fn func(*Settings)) error {
+	prev, next, err := s.update(fn)
+	if err != nil {
+		return err
+	}
+	if len(ChangedFields(prev, next)) > 0 {
+		s.mu.RLock()
+		listeners := s.listeners
+		s.mu.RUnlock()
+		for _, fn := range listeners {
+			fn(prev, next)
+		}
+	}
+	return nil
+}
+
+func (s *Store) update(fn func(*Settings)) (prev, next Settings, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next = s.current
+	fn(&next)
+	if s.enforce != nil {
+		s.enforce(&next)
+	}
+	data, err := json.MarshalIndent(next, "", "  ")
+	if err != nil {
+		return prev, next, fmt.Errorf("failed to marshal settings: %w", err)
+	}
+	tmp := s.filePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return prev, next, fmt.Errorf("failed to write settings: %w", err)
+	}
+	if err := os.Rename(tmp, s.filePath); err != nil {
+		return prev, next, fmt.Errorf("failed to write settings: %w", err)
+	}
+	prev, s.current = s.current, next
+	return prev, next, nil
+}
+
+// OnChange registers fn to be called after an Update changes the settings.
+// fn runs on the goroutine that made the change, without the store lock
+// held, so it may call Get.
+func (s *Store) OnChange(fn func(old, next Settings)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+// ChangedFields returns the JSON names of the settings that differ between
+// old and next, including those set in one and omitted from the other.
+func ChangedFields(old, next Settings) []string {
+	a, b := fieldsOf(old), fieldsOf(next)
+	var out []string
+	for name, v := range b {
+		if w, ok := a[name]; !ok || !bytes.Equal(w, v) {
+			out = append(out, name)
+		}
+	}
+	for name := range a {
+		if _, ok := b[name]; !ok {
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func fieldsOf(s Settings) map[string]json.RawMessage {
+	data, _ := json.Marshal(s)
+	var m map[string]json.RawMessage
+	_ = json.Unmarshal(data, &m)
+	return m
+}
+
+// Enforce applies fn, such as an administrator's policy, to the current
+// settings and after every later Update, so the values it sets cannot be
+// changed.
+func (s *Store) Enforce(fn func(*Settings)) error {
+	s.mu.Lock()
+	s.enforce = fn
+	s.mu.Unlock()
+	return s.Update(func(*Settings) {})
+}
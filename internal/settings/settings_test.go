@@ -0,0 +1,133 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/presign"
+)
+
+func TestStore_DefaultsAndPersistence(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewStore(dir)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	if got := s.Get(); !reflect.DeepEqual(got, Default()) {
+		t.Fatalf("Get on fresh store = %+v, want defaults", got)
+	}
+	if s.Get().TelemetryEnabled {
+		t.Fatal("telemetry must be off by default")
+	}
+	if s.Get().ClipboardDetect {
+		t.Fatal("clipboard detection must be off by default")
+	}
+	if s.Get().AgentMode() {
+		t.Fatal("citizen mode must be the default")
+	}
+	if s.Get().RememberSignerData {
+		t.Fatal("remembering signer data must be off by default")
+	}
+	if s.Get().Channel() != ChannelStable {
+		t.Fatal("the stable update channel must be the default")
+	}
+
+	if err := s.Update(func(st *Settings) { st.SubmitReviewSeconds = 0 }); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got := s.Get().SubmitReviewSeconds; got != 0 {
+		t.Fatalf("SubmitReviewSeconds = %d, want 0", got)
+	}
+
+	reopened, err := NewStore(dir)
+	if err != nil {
+		t.Fatalf("NewStore (reopen): %v", err)
+	}
+	if got := reopened.Get().SubmitReviewSeconds; got != 0 {
+		t.Fatalf("persisted SubmitReviewSeconds = %d, want 0", got)
+	}
+}
+
+func TestStore_MissingFieldsKeepDefaults(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "settings.json"), []byte(`{}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewStore(dir)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	if got := s.Get(); !reflect.DeepEqual(got, Default()) {
+		t.Fatalf("Get = %+v, want defaults", got)
+	}
+}
+
+func TestStore_CorruptFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "settings.json"), []byte(`{`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewStore(dir); err == nil {
+		t.Fatal("expected error for corrupt settings file")
+	}
+}
+
+func TestStore_Enforce(t *testing.T) {
+	s, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	if err := s.Update(func(st *Settings) { st.TelemetryEnabled = true }); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := s.Enforce(func(st *Settings) { st.TelemetryEnabled = false }); err != nil {
+		t.Fatalf("Enforce: %v", err)
+	}
+	if s.Get().TelemetryEnabled {
+		t.Fatal("Enforce did not apply to the current settings")
+	}
+	if err := s.Update(func(st *Settings) { st.TelemetryEnabled = true; st.PINCacheMinutes = 15 }); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got := s.Get(); got.TelemetryEnabled || got.PINCacheMinutes != 15 {
+		t.Fatalf("Update overrode an enforced setting or lost another: %+v", got)
+	}
+}
+
+func TestStore_OnChange(t *testing.T) {
+	s, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	var changed [][]string
+	s.OnChange(func(old, next Settings) {
+		changed = append(changed, ChangedFields(old, next))
+	})
+	if err := s.Update(func(st *Settings) { st.AutoLockMinutes = 5; st.TelemetryEnabled = true }); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	// An update that changes nothing is not reported.
+	if err := s.Update(func(st *Settings) { st.AutoLockMinutes = 5 }); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if len(changed) != 1 || strings.Join(changed[0], ",") != "autoLockMinutes,telemetryEnabled" {
+		t.Fatalf("changes = %v", changed)
+	}
+}
+
+func TestChangedFields_Cleared(t *testing.T) {
+	set := Default()
+	set.Mode = ModeAgent
+	set.UpdateChannel = ChannelBeta
+	set.DualControl = []presign.DualControlRule{{OrganizationID: "*", Method: presign.MethodRemote, URL: "https://approve.example"}}
+	// Clearing omitempty settings drops them from the JSON.
+	if got := strings.Join(ChangedFields(set, Default()), ","); got != "dualControl,mode,updateChannel" {
+		t.Fatalf("ChangedFields after clearing = %q", got)
+	}
+	if got := strings.Join(ChangedFields(Default(), set), ","); got != "dualControl,mode,updateChannel" {
+		t.Fatalf("ChangedFields after setting = %q", got)
+	}
+}
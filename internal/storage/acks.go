@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CertAck records that the user acknowledged a warning about a certificate,
+// such as signing with a representative certificate. Digest identifies what
+// was acknowledged, typically the warning wording and the signature policy
+// of the request, so a change to either asks again.
+type CertAck struct {
+	CertFingerprint string `json:"certFingerprint"`
+	Kind            string `json:"kind"`
+	Digest          string `json:"digest"`
+	AcknowledgedAt  string `json:"acknowledgedAt"`
+}
+
+func (a CertAck) key() string {
+	return a.CertFingerprint + "/" + a.Kind
+}
+
+// CertAckStore remembers certificate warning acknowledgements, one per
+// certificate and kind of warning.
+type CertAckStore struct {
+	mu       sync.Mutex
+	filePath string
+}
+
+func NewCertAckStore(dir string) (*CertAckStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	return &CertAckStore{filePath: filepath.Join(dir, "cert_acks.json")}, nil
+}
+
+// Acknowledged returns the acknowledgement of the warning of kind about the
+// certificate, if it was given for the same digest.
+func (s *CertAckStore) Acknowledged(fingerprint, kind, digest string) (CertAck, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acks, err := s.load()
+	if err != nil {
+		return CertAck{}, false, err
+	}
+	a, ok := acks[CertAck{CertFingerprint: fingerprint, Kind: kind}.key()]
+	if !ok || a.Digest != digest {
+		return CertAck{}, false, nil
+	}
+	return a, true, nil
+}
+
+// Record stores a, replacing an earlier acknowledgement of the same kind
+// for the same certificate.
+func (s *CertAckStore) Record(a CertAck) error {
+	a.AcknowledgedAt = time.Now().UTC().Format(time.RFC3339)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acks, err := s.load()
+	if err != nil {
+		return err
+	}
+	acks[a.key()] = a
+	return s.save(acks)
+}
+
+// Clear forgets every acknowledgement, so every warning is shown again.
+func (s *CertAckStore) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.filePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *CertAckStore) load() (map[string]CertAck, error) {
+	acks := make(map[string]CertAck)
+	data, err := os.ReadFile(s.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return acks, nil
+		}
+		return nil, err
+	}
+	var list []CertAck
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to decode acknowledgements: %w", err)
+	}
+	for _, a := range list {
+		acks[a.key()] = a
+	}
+	return acks, nil
+}
+
+func (s *CertAckStore) save(acks map[string]CertAck) error {
+	list := make([]CertAck, 0, len(acks))
+	for _, a := range acks {
+		list = append(list, a)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].key() < list[j].key() })
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal acknowledgements: %w", err)
+	}
+	tmp := s.filePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.filePath)
+}
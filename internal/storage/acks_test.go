@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCertAckStore(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewCertAckStore(dir)
+	if err != nil {
+		t.Fatalf("NewCertAckStore: %v", err)
+	}
+
+	if _, ok, err := s.Acknowledged("f1", "representative", "d1"); err != nil || ok {
+		t.Fatalf("Acknowledged on empty store = %v, %v", ok, err)
+	}
+	for _, a := range []CertAck{
+		{CertFingerprint: "f1", Kind: "representative", Digest: "d1"},
+		{CertFingerprint: "f2", Kind: "representative", Digest: "d1"},
+	} {
+		if err := s.Record(a); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	got, ok, err := s.Acknowledged("f1", "representative", "d1")
+	if err != nil || !ok || got.AcknowledgedAt == "" {
+		t.Fatalf("Acknowledged = %+v, %v, %v", got, ok, err)
+	}
+	// A changed warning or policy, another kind or another certificate is
+	// not covered.
+	for _, c := range [][3]string{
+		{"f1", "representative", "d2"},
+		{"f1", "expiring", "d1"},
+		{"f3", "representative", "d1"},
+	} {
+		if _, ok, _ := s.Acknowledged(c[0], c[1], c[2]); ok {
+			t.Errorf("Acknowledged(%v) = true", c)
+		}
+	}
+
+	// Acknowledging the changed warning replaces the earlier one.
+	if err := s.Record(CertAck{CertFingerprint: "f1", Kind: "representative", Digest: "d2"}); err != nil {
+		t.Fatal(err)
+	}
+	reopened, _ := NewCertAckStore(dir)
+	if _, ok, _ := reopened.Acknowledged("f1", "representative", "d2"); !ok {
+		t.Error("new acknowledgement not found after reopening")
+	}
+	if _, ok, _ := reopened.Acknowledged("f1", "representative", "d1"); ok {
+		t.Error("replaced acknowledgement still found")
+	}
+
+	if err := s.Clear(); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if _, ok, _ := s.Acknowledged("f2", "representative", "d1"); ok {
+		t.Error("acknowledgement found after Clear")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "cert_acks.json")); !os.IsNotExist(err) {
+		t.Errorf("cert_acks.json still exists: %v", err)
+	}
+}
+
+func TestCertAckStore_Corrupt(t *testing.T) {
+	dir := t.TempDir()
+	s, _ := NewCertAckStore(dir)
+	if err := os.WriteFile(filepath.Join(dir, "cert_acks.json"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Acknowledged("f1", "representative", "d1"); err == nil {
+		t.Fatal("expected an error for a corrupt file")
+	}
+}
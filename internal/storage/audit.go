@@ -5,32 +5,78 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
 
+// AuditSchemaVersion is written to every new audit entry. Entries without
+// a version are schema 1 and are migrated when the log is opened.
+const AuditSchemaVersion = 2
+
 type AuditEntry struct {
-	Timestamp       string `json:"timestamp"`
-	RequestID       string `json:"requestId"`
-	ProposalTitle   string `json:"proposalTitle,omitempty"`
-	SignerName      string `json:"signerName,omitempty"`
-	SignerDNI       string `json:"signerDni,omitempty"`
-	CallbackHost    string `json:"callbackHost"`
+	SchemaVersion int    `json:"schemaVersion"`
+	Timestamp     string `json:"timestamp"`
+	RequestID     string `json:"requestId"`
+	ProposalTitle string `json:"proposalTitle,omitempty"`
+	SignerName    string `json:"signerName,omitempty"`
+	SignerDNI     string `json:"signerDni,omitempty"`
+	CallbackHost  string `json:"callbackHost"`
+	// FinalHost is the host that received the submission after redirects,
+	// set when it differs from CallbackHost.
+	FinalHost       string `json:"finalHost,omitempty"`
 	CertFingerprint string `json:"certFingerprint"`
 	Status          string `json:"status"`
 	Error           string `json:"error,omitempty"`
+	ErrorCode       string `json:"errorCode,omitempty"` // errcode.Code of Error
 	ServerAckID     string `json:"serverAckId,omitempty"`
-	PrevHash        string `json:"prevHash"`
+	// AgentCertified is set when a certifying agent signed on the citizen's
+	// behalf; CertFingerprint is then the agent's certificate.
+	AgentCertified bool `json:"agentCertified,omitempty"`
+	// Demo is set for the rehearsal signatures of a demo campaign, made
+	// with a throwaway certificate.
+	Demo bool `json:"demo,omitempty"`
+	// LegalAck is the model.Acknowledgement digest of the wording and legal
+	// statement the signer agreed to, when the request policy required an
+	// acknowledgement, and LegalAckInitials the initials they typed.
+	LegalAck         string `json:"legalAck,omitempty"`
+	LegalAckInitials string `json:"legalAckInitials,omitempty"`
+
+	// Schema 2: digests that tie the entry to the exact request, payload
+	// and signature, so it can be used as evidence on its own.
+	RequestHash     string `json:"requestHash,omitempty"`     // hex SHA-256 of the canonical request
+	PayloadSHA256   string `json:"payloadSha256,omitempty"`   // hex SHA-256 of the signed XML
+	SignatureSHA256 string `json:"signatureSha256,omitempty"` // hex SHA-256 of the CAdES signature
+	PolicyOID       string `json:"policyOid,omitempty"`
+	ReceiptStatus   string `json:"receiptStatus,omitempty"`
+	// RawRequestSHA256 is the hex SHA-256 of the request bytes as fetched,
+	// under which RequestStore.PutRaw keeps them for re-verification.
+	RawRequestSHA256 string `json:"rawRequestSha256,omitempty"`
+	// CounterSignerFingerprint is the certificate fingerprint of the
+	// collector that counter-signed the signature in its receipt. The
+	// counter-signed CMS is kept in the receipt store.
+	CounterSignerFingerprint string `json:"counterSignerFingerprint,omitempty"`
+	// CoSignerFingerprint is the certificate fingerprint of a second person
+	// who signed the same XML in parallel, e.g. a legal guardian.
+	CoSignerFingerprint string `json:"coSignerFingerprint,omitempty"`
+	// LegacyHash is the hex SHA-256 of the schema 1 line this entry was
+	// migrated from, as kept in the backup written by the migration.
+	LegacyHash string `json:"legacyHash,omitempty"`
+
+	PrevHash string `json:"prevHash"`
 }
 
 type AuditLogger struct {
 	mu       sync.Mutex
 	filePath string
+	syncPath string
 	lastHash string
+	entries  int
 }
 
 func NewAuditLogger(dir string) (*AuditLogger, error) {
@@ -39,6 +85,11 @@ func NewAuditLogger(dir string) (*AuditLogger, error) {
 	}
 	l := &AuditLogger{
 		filePath: filepath.Join(dir, "audit.jsonl"),
+		syncPath: filepath.Join(dir, "audit_sync.json"),
+	}
+	if err := l.migrate(); err != nil {
+		// The log stays valid as schema 1; new entries are appended to it.
+		log.Printf("WARNING: audit log migration failed: %v", err)
 	}
 	if err := l.loadLastHash(); err != nil {
 		return nil, fmt.Errorf("failed to load last hash: %w", err)
@@ -70,6 +121,7 @@ func (l *AuditLogger) loadLastHash() error {
 		line := scanner.Text()
 		if line != "" {
 			lastLine = line
+			l.entries++
 		}
 	}
 	if err := scanner.Err(); err != nil {
@@ -87,6 +139,7 @@ func (l *AuditLogger) Log(entry AuditEntry) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
+	entry.SchemaVersion = AuditSchemaVersion
 	entry.Timestamp = time.Now().Format(time.RFC3339)
 	entry.PrevHash = l.lastHash
 	log.Printf("DEBUG: Audit log entry: RequestID=%s Status=%s", entry.RequestID, entry.Status)
@@ -117,6 +170,7 @@ func (l *AuditLogger) Log(entry AuditEntry) error {
 	if err := f.Close(); err != nil {
 		return fmt.Errorf("failed to close audit file: %w", err)
 	}
+	l.entries++
 	return nil
 }
 
@@ -197,3 +251,101 @@ func (l *AuditLogger) ReadAll() ([]AuditEntry, error) {
 	}
 	return entries, nil
 }
+
+// migrate upgrades schema 1 entries to the current schema. Rewriting
+// entries changes their hashes, so the original file is kept next to the
+// log as audit.v1.jsonl, each migrated entry records the hash of its
+// original line in LegacyHash, and the chain is recomputed.
+func (l *AuditLogger) migrate() error {
+	data, err := os.ReadFile(l.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var lines []string
+	needed := false
+	chainHash := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		var probe struct {
+			SchemaVersion int    `json:"schemaVersion"`
+			PrevHash      string `json:"prevHash"`
+		}
+		if err := json.Unmarshal([]byte(line), &probe); err != nil {
+			return fmt.Errorf("%w: entry %d: failed to unmarshal: %v", errAuditUnmigratable, len(lines), err)
+		}
+		// Re-chaining a tampered log would hide the tampering.
+		if probe.PrevHash != chainHash {
+			return fmt.Errorf("%w: entry %d: hash chain broken", errAuditUnmigratable, len(lines))
+		}
+		h := sha256.Sum256([]byte(line))
+		chainHash = hex.EncodeToString(h[:])
+		if probe.SchemaVersion < AuditSchemaVersion {
+			needed = true
+		}
+		lines = append(lines, line)
+	}
+	if !needed {
+		return nil
+	}
+
+	backup := strings.TrimSuffix(l.filePath, ".jsonl") + ".v1.jsonl"
+	if _, err := os.Stat(backup); err == nil {
+		backup = fmt.Sprintf("%s.v1-%d.jsonl", strings.TrimSuffix(l.filePath, ".jsonl"), time.Now().Unix())
+	}
+	if err := os.WriteFile(backup, data, 0o600); err != nil {
+		return fmt.Errorf("failed to back up audit log: %w", err)
+	}
+
+	var out strings.Builder
+	prevHash := ""
+	for _, line := range lines {
+		var entry AuditEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return fmt.Errorf("failed to unmarshal entry: %w", err)
+		}
+		if entry.SchemaVersion < AuditSchemaVersion {
+			legacy := sha256.Sum256([]byte(line))
+			entry.LegacyHash = hex.EncodeToString(legacy[:])
+			entry.SchemaVersion = AuditSchemaVersion
+		}
+		entry.PrevHash = prevHash
+		b, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to marshal entry: %w", err)
+		}
+		h := sha256.Sum256(b)
+		prevHash = hex.EncodeToString(h[:])
+		out.Write(b)
+		out.WriteString("\n")
+	}
+
+	tmp := l.filePath + ".tmp"
+	if err := os.WriteFile(tmp, []byte(out.String()), 0o600); err != nil {
+		return fmt.Errorf("failed to write migrated audit log: %w", err)
+	}
+	if err := os.Rename(tmp, l.filePath); err != nil {
+		return fmt.Errorf("failed to replace audit log: %w", err)
+	}
+	log.Printf("DEBUG: migrated %d audit entries to schema %d (backup %s)", len(lines), AuditSchemaVersion, backup)
+	return nil
+}
+
+// errAuditUnmigratable means the log is damaged and is kept as it is.
+var errAuditUnmigratable = errors.New("audit log not migrated")
+
+// MigrateAuditLog upgrades the audit log in dir to AuditSchemaVersion,
+// keeping the original next to it. A damaged log is left as it is, since
+// rewriting it would hide the damage; Verify reports it.
+func MigrateAuditLog(dir string) error {
+	l := &AuditLogger{filePath: filepath.Join(dir, "audit.jsonl")}
+	if err := l.migrate(); err != nil && !errors.Is(err, errAuditUnmigratable) {
+		return err
+	}
+	return nil
+}
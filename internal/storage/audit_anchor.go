@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// AuditAnchor is an RFC 3161 timestamp over the head of the audit hash
+// chain, or of the security log's. The head is the SHA-256 of the last
+// entry, so the token proves that entry, and through the chain every one
+// before it, existed at the time the TSA asserts, whatever the local clock
+// said.
+type AuditAnchor struct {
+	Head        string `json:"head"`    // hex SHA-256 of the last entry line
+	Entries     int    `json:"entries"` // number of entries up to Head
+	TSAURL      string `json:"tsaUrl"`
+	Time        string `json:"time"` // as asserted by the TSA, RFC 3339
+	TokenBase64 string `json:"tokenBase64"`
+}
+
+func (l *AuditLogger) anchorPath() string {
+	return filepath.Join(filepath.Dir(l.filePath), "audit_anchors.jsonl")
+}
+
+// Head returns the current head of the hash chain and the number of
+// entries it covers. The head is empty when the log is.
+func (l *AuditLogger) Head() (string, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lastHash, l.entries
+}
+
+// AddAnchor appends a to the anchors kept next to the audit log.
+func (l *AuditLogger) AddAnchor(a AuditAnchor) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return appendAnchor(l.anchorPath(), a)
+}
+
+// Anchors returns the stored anchors, oldest first.
+func (l *AuditLogger) Anchors() ([]AuditAnchor, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return readAnchors(l.anchorPath())
+}
+
+// appendAnchor appends a to the anchor file at path.
+func appendAnchor(path string, a AuditAnchor) error {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("failed to marshal anchor: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open anchor file: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close() // best-effort on error path; the write error is already being returned
+		return fmt.Errorf("failed to write anchor: %w", err)
+	}
+	return f.Close()
+}
+
+// readAnchors returns the anchors in the file at path, oldest first, and
+// none if there is no file.
+func readAnchors(path string) ([]AuditAnchor, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open anchor file: %w", err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Printf("warning: failed to close anchor file: %v", err)
+		}
+	}()
+
+	var anchors []AuditAnchor
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var a AuditAnchor
+		if err := json.Unmarshal(line, &a); err != nil {
+			return nil, fmt.Errorf("anchor %d: failed to unmarshal: %w", len(anchors), err)
+		}
+		anchors = append(anchors, a)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read anchor file: %w", err)
+	}
+	return anchors, nil
+}
+
+// CheckAnchor reports whether a's head is entry a.Entries of the current
+// log, i.e. the anchored part of the history has not been rewritten since.
+func (l *AuditLogger) CheckAnchor(a AuditAnchor) error {
+	records, _, err := l.Records()
+	if err != nil {
+		return err
+	}
+	if a.Entries < 1 || a.Entries > len(records) {
+		return fmt.Errorf("anchor covers %d entries, the log has %d", a.Entries, len(records))
+	}
+	if got := records[a.Entries-1].Hash; got != a.Head {
+		return fmt.Errorf("entry %d hashes to %s, anchored head is %s", a.Entries-1, got, a.Head)
+	}
+	return nil
+}
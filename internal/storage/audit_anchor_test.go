@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuditAnchors(t *testing.T) {
+	dir := t.TempDir()
+	logger, err := NewAuditLogger(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head, n := logger.Head(); head != "" || n != 0 {
+		t.Fatalf("Head of an empty log = %q, %d", head, n)
+	}
+	for _, id := range []string{"req-a", "req-b"} {
+		if err := logger.Log(AuditEntry{RequestID: id, Status: "success"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	head, n := logger.Head()
+	if n != 2 {
+		t.Fatalf("Head covers %d entries, want 2", n)
+	}
+	anchor := AuditAnchor{Head: head, Entries: n, TSAURL: "https://tsa.example", Time: "2026-10-16T00:00:00Z", TokenBase64: "dG9rZW4="}
+	if err := logger.AddAnchor(anchor); err != nil {
+		t.Fatal(err)
+	}
+	if err := logger.Log(AuditEntry{RequestID: "req-c", Status: "success"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Anchors and the entry count survive a restart.
+	logger, err = NewAuditLogger(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, n := logger.Head(); n != 3 {
+		t.Errorf("Head after restart covers %d entries, want 3", n)
+	}
+	anchors, err := logger.Anchors()
+	if err != nil || len(anchors) != 1 || anchors[0] != anchor {
+		t.Fatalf("Anchors = %+v, %v", anchors, err)
+	}
+	if err := logger.CheckAnchor(anchors[0]); err != nil {
+		t.Errorf("CheckAnchor: %v", err)
+	}
+
+	// Rewriting anchored history is detected even if the chain is rebuilt.
+	path := filepath.Join(dir, "audit.jsonl")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitN(string(data), "\n", 2)
+	if err := os.WriteFile(path, []byte(strings.Replace(lines[0], "req-a", "req-x", 1)+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	logger, err = NewAuditLogger(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"req-b", "req-c"} {
+		if err := logger.Log(AuditEntry{RequestID: id, Status: "success"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := logger.CheckAnchor(anchors[0]); err == nil {
+		t.Error("CheckAnchor accepted a rewritten history")
+	}
+}
@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+)
+
+// AuditManifestVersion is the format version of AuditManifest.
+const AuditManifestVersion = 1
+
+// AuditRecord is an audit log line together with its hex SHA-256, the hash
+// the next entry chains to. The hash identifies the entry when it is
+// exported, so collectors can deduplicate uploads and check each line.
+type AuditRecord struct {
+	Hash  string          `json:"hash"`
+	Line  json.RawMessage `json:"line"`
+	Entry AuditEntry      `json:"-"`
+}
+
+// AuditManifest is the document a certifying agent signs to hand over the
+// audit entries of the signatures they collected.
+type AuditManifest struct {
+	Version    int    `json:"version"`
+	ExportedAt string `json:"exportedAt"`
+	// RequestID is set when the manifest only holds the entries of one
+	// request, as for uploads to that request's collector.
+	RequestID            string `json:"requestId,omitempty"`
+	AgentCertFingerprint string `json:"agentCertFingerprint"`
+	// ChainHead is the hash of the last entry in the log at export time, so
+	// the collector can tell whether later uploads extend the same log.
+	ChainHead string        `json:"chainHead"`
+	Records   []AuditRecord `json:"records"`
+}
+
+// NewAuditManifest builds a manifest for records, dropping repeated hashes.
+func NewAuditManifest(records []AuditRecord, requestID, agentFingerprint, chainHead string) AuditManifest {
+	m := AuditManifest{
+		Version:              AuditManifestVersion,
+		ExportedAt:           time.Now().UTC().Format(time.RFC3339),
+		RequestID:            requestID,
+		AgentCertFingerprint: agentFingerprint,
+		ChainHead:            chainHead,
+		Records:              []AuditRecord{},
+	}
+	seen := make(map[string]bool, len(records))
+	for _, r := range records {
+		if seen[r.Hash] {
+			continue
+		}
+		seen[r.Hash] = true
+		m.Records = append(m.Records, r)
+	}
+	return m
+}
+
+// Records returns every entry in the log with its hash, oldest first, and
+// the hash of the last one. Lines that do not parse are skipped, as in
+// ReadAll.
+func (l *AuditLogger) Records() ([]AuditRecord, string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Open(l.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []AuditRecord{}, "", nil
+		}
+		return nil, "", fmt.Errorf("failed to open audit file: %w", err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Printf("warning: failed to close audit file: %v", err)
+		}
+	}()
+
+	records := []AuditRecord{}
+	head := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		h := sha256.Sum256(line)
+		head = hex.EncodeToString(h[:])
+		var entry AuditEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			continue
+		}
+		records = append(records, AuditRecord{
+			Hash:  head,
+			Line:  append(json.RawMessage(nil), line...),
+			Entry: entry,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, "", fmt.Errorf("failed to read audit file: %w", err)
+	}
+	return records, head, nil
+}
+
+// auditSyncState records which entries were already uploaded to each
+// collector endpoint, by entry hash.
+type auditSyncState struct {
+	Synced map[string][]string `json:"synced"`
+}
+
+func (l *AuditLogger) readSyncState() (auditSyncState, error) {
+	state := auditSyncState{Synced: make(map[string][]string)}
+	data, err := os.ReadFile(l.syncPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return state, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("failed to decode audit sync state: %w", err)
+	}
+	if state.Synced == nil {
+		state.Synced = make(map[string][]string)
+	}
+	return state, nil
+}
+
+// PendingSync returns the entries for requestID that were not uploaded to
+// endpoint yet, and the current chain head.
+func (l *AuditLogger) PendingSync(endpoint, requestID string) ([]AuditRecord, string, error) {
+	records, head, err := l.Records()
+	if err != nil {
+		return nil, "", err
+	}
+
+	l.mu.Lock()
+	state, err := l.readSyncState()
+	l.mu.Unlock()
+	if err != nil {
+		return nil, "", err
+	}
+	done := make(map[string]bool, len(state.Synced[endpoint]))
+	for _, h := range state.Synced[endpoint] {
+		done[h] = true
+	}
+
+	var pending []AuditRecord
+	for _, r := range records {
+		if r.Entry.RequestID == requestID && !done[r.Hash] {
+			pending = append(pending, r)
+		}
+	}
+	return pending, head, nil
+}
+
+// MarkSynced records that the entries with the given hashes were accepted
+// by endpoint, so they are not uploaded there again.
+func (l *AuditLogger) MarkSynced(endpoint string, hashes []string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state, err := l.readSyncState()
+	if err != nil {
+		return err
+	}
+	set := make(map[string]bool, len(state.Synced[endpoint])+len(hashes))
+	for _, h := range state.Synced[endpoint] {
+		set[h] = true
+	}
+	for _, h := range hashes {
+		set[h] = true
+	}
+	merged := make([]string, 0, len(set))
+	for h := range set {
+		merged = append(merged, h)
+	}
+	sort.Strings(merged)
+	state.Synced[endpoint] = merged
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit sync state: %w", err)
+	}
+	tmp := l.syncPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.syncPath)
+}
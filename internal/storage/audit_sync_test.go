@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestAuditPendingSync(t *testing.T) {
+	dir := t.TempDir()
+	logger, err := NewAuditLogger(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"req-a", "req-b", "req-a"} {
+		if err := logger.Log(AuditEntry{RequestID: id, Status: "success"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records, head, err := logger.Records()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || head != records[2].Hash {
+		t.Fatalf("Records = %d records, head %q", len(records), head)
+	}
+	for i, r := range records {
+		sum := sha256.Sum256(r.Line)
+		if hex.EncodeToString(sum[:]) != r.Hash {
+			t.Errorf("record %d: hash does not match its line", i)
+		}
+		if i > 0 && r.Entry.PrevHash != records[i-1].Hash {
+			t.Errorf("record %d: prevHash does not chain to the previous record", i)
+		}
+	}
+
+	const endpoint = "https://collector.example/audit"
+	pending, _, err := logger.PendingSync(endpoint, "req-a")
+	if err != nil || len(pending) != 2 {
+		t.Fatalf("PendingSync = %d records, %v", len(pending), err)
+	}
+	if err := logger.MarkSynced(endpoint, []string{pending[0].Hash}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Sync state survives a restart and is kept per endpoint.
+	logger, err = NewAuditLogger(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		endpoint  string
+		requestID string
+		want      int
+	}{
+		{endpoint, "req-a", 1},
+		{endpoint, "req-b", 1},
+		{"https://other.example/audit", "req-a", 2},
+		{endpoint, "req-c", 0},
+	}
+	for _, tt := range tests {
+		got, _, err := logger.PendingSync(tt.endpoint, tt.requestID)
+		if err != nil || len(got) != tt.want {
+			t.Errorf("PendingSync(%s, %s) = %d records, %v; want %d", tt.endpoint, tt.requestID, len(got), err, tt.want)
+		}
+	}
+
+	m := NewAuditManifest(append(records, records[0]), "", "agent", head)
+	if len(m.Records) != 3 || m.Version != AuditManifestVersion || m.ChainHead != head {
+		t.Errorf("NewAuditManifest = %d records, version %d, head %q", len(m.Records), m.Version, m.ChainHead)
+	}
+}
@@ -2,6 +2,8 @@ package storage
 
 import (
 	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -376,3 +378,92 @@ func TestAuditHashChainTampered(t *testing.T) {
 		t.Fatalf("expected failure at index 2, got index %d (err: %v)", verified, err)
 	}
 }
+
+// writeV1Log writes schema 1 entries (no schemaVersion) with a valid chain.
+func writeV1Log(t *testing.T, dir string, statuses ...string) []byte {
+	t.Helper()
+	var buf strings.Builder
+	prev := ""
+	for i, status := range statuses {
+		line := fmt.Sprintf(`{"timestamp":"2024-01-0%dT10:00:00Z","requestId":"v1-%d","callbackHost":"server","certFingerprint":"AA","status":%q,"prevHash":%q}`, i+1, i, status, prev)
+		h := sha256.Sum256([]byte(line))
+		prev = hex.EncodeToString(h[:])
+		buf.WriteString(line + "\n")
+	}
+	data := []byte(buf.String())
+	if err := os.WriteFile(filepath.Join(dir, "audit.jsonl"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestAuditMigrationV1(t *testing.T) {
+	dir := t.TempDir()
+	original := writeV1Log(t, dir, "success", "fail")
+
+	logger, err := NewAuditLogger(dir)
+	if err != nil {
+		t.Fatalf("NewAuditLogger: %v", err)
+	}
+	if err := logger.Log(AuditEntry{RequestID: "v2-0", Status: "success", PayloadSHA256: "abcd"}); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := logger.Verify(); err != nil || n != 3 {
+		t.Fatalf("Verify = %d, %v", n, err)
+	}
+
+	entries, err := logger.ReadAll()
+	if err != nil || len(entries) != 3 {
+		t.Fatalf("ReadAll = %d entries, %v", len(entries), err)
+	}
+	origLines := strings.Split(strings.TrimSpace(string(original)), "\n")
+	for i, e := range entries {
+		if e.SchemaVersion != AuditSchemaVersion {
+			t.Errorf("entry %d schemaVersion = %d", i, e.SchemaVersion)
+		}
+		if i < 2 {
+			h := sha256.Sum256([]byte(origLines[i]))
+			if e.LegacyHash != hex.EncodeToString(h[:]) {
+				t.Errorf("entry %d legacyHash does not match the original line", i)
+			}
+			if e.Timestamp != fmt.Sprintf("2024-01-0%dT10:00:00Z", i+1) {
+				t.Errorf("entry %d timestamp = %q", i, e.Timestamp)
+			}
+		} else if e.LegacyHash != "" || e.PayloadSHA256 != "abcd" {
+			t.Errorf("new entry = %+v", e)
+		}
+	}
+
+	backup, err := os.ReadFile(filepath.Join(dir, "audit.v1.jsonl"))
+	if err != nil || string(backup) != string(original) {
+		t.Fatalf("backup differs from the original log: %v", err)
+	}
+
+	// Opening the migrated log again is a no-op.
+	if _, err := NewAuditLogger(dir); err != nil {
+		t.Fatal(err)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, "audit.v1*")); len(matches) != 1 {
+		t.Fatalf("backups = %v", matches)
+	}
+}
+
+func TestAuditMigrationSkipsBrokenChain(t *testing.T) {
+	dir := t.TempDir()
+	original := writeV1Log(t, dir, "success", "success")
+	tampered := strings.Replace(string(original), `"requestId":"v1-0"`, `"requestId":"v1-X"`, 1)
+	if err := os.WriteFile(filepath.Join(dir, "audit.jsonl"), []byte(tampered), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	logger, err := NewAuditLogger(dir)
+	if err != nil {
+		t.Fatalf("NewAuditLogger: %v", err)
+	}
+	if _, err := logger.Verify(); err == nil {
+		t.Fatal("tampered log verifies after opening; migration must not re-chain it")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "audit.v1.jsonl")); !os.IsNotExist(err) {
+		t.Fatalf("backup written for a log that was not migrated: %v", err)
+	}
+}
@@ -0,0 +1,259 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// JournalStage is how far a signing had got when its journal entry was last
+// written.
+type JournalStage string
+
+const (
+	// StageSigning is written before the signature is made. Nothing has
+	// been sent yet, so an entry left at this stage is at worst an orphaned
+	// signature that was never submitted.
+	StageSigning JournalStage = "signing"
+	// StageSubmitting is written before the signature is sent. An entry left
+	// at this stage means the collector may have received the signature.
+	StageSubmitting JournalStage = "submitting"
+)
+
+// JournalEntry records a signing in progress. Entries are removed when the
+// signing finishes, so any entry found at startup belongs to a signing
+// interrupted by a crash or a forced close.
+type JournalEntry struct {
+	RequestID       string `json:"requestId"`
+	RequestHash     string `json:"requestHash,omitempty"`
+	Title           string `json:"title,omitempty"`
+	CallbackHost    string `json:"callbackHost,omitempty"`
+	CertFingerprint string `json:"certFingerprint"`
+	// SignerID tells apart the citizens an agent signs for with the same
+	// certificate. It is written encrypted, see VaultCipher.
+	SignerID        string       `json:"-"`
+	Stage           JournalStage `json:"stage"`
+	PayloadSHA256   string       `json:"payloadSha256,omitempty"`
+	SignatureSHA256 string       `json:"signatureSha256,omitempty"`
+	// Response is the submitted callback payload, kept from the submitting
+	// stage on so the receipt can be shown if the collector turns out to
+	// have accepted it. It is written encrypted, see VaultCipher.
+	Response  json.RawMessage `json:"-"`
+	UpdatedAt string          `json:"updatedAt"`
+}
+
+// journalPrivate is the part of an entry that identifies the citizen.
+type journalPrivate struct {
+	SignerID string          `json:"signerId,omitempty"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// journalRecord is an entry as written to disk. Private holds its
+// journalPrivate encrypted. SignerID and Response are only read, from
+// journals written before they were encrypted.
+type journalRecord struct {
+	JournalEntry
+	Private  []byte          `json:"private,omitempty"`
+	SignerID string          `json:"signerId,omitempty"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// VaultCipher encrypts with the wallet's vault key.
+type VaultCipher interface {
+	EncryptVault(data []byte) ([]byte, error)
+	DecryptVault(enc []byte) ([]byte, error)
+}
+
+// Same reports whether e and o journal the same signing: the same request
+// signed with the same certificate for the same signer.
+func (e JournalEntry) Same(o JournalEntry) bool {
+	return e.RequestID == o.RequestID && e.RequestHash == o.RequestHash &&
+		e.CertFingerprint == o.CertFingerprint && e.SignerID == o.SignerID
+}
+
+// Journal is the write-ahead record of signings in progress. Every write is
+// flushed to disk before it returns, so it survives a crash right after.
+//
+// The signer's ID and the submitted response are written encrypted with the
+// vault cipher. Without one, or while the wallet is locked, they are not
+// written at all, and an entry whose private part cannot be decrypted is
+// returned without them.
+type Journal struct {
+	mu       sync.Mutex
+	filePath string
+	cipher   VaultCipher
+}
+
+func NewJournal(dir string) (*Journal, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	return &Journal{filePath: filepath.Join(dir, "journal.json")}, nil
+}
+
+// SetVaultCipher sets the cipher the private part of entries is written
+// with.
+func (j *Journal) SetVaultCipher(c VaultCipher) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.cipher = c
+}
+
+// Entries returns the signings in progress, oldest first.
+func (j *Journal) Entries() ([]JournalEntry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	records, err := j.load()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]JournalEntry, 0, len(records))
+	for _, r := range records {
+		entries = append(entries, r.JournalEntry)
+	}
+	return entries, nil
+}
+
+// Record writes e, replacing the entry of the same signing if there is one.
+func (j *Journal) Record(e JournalEntry) error {
+	e.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	records, err := j.load()
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i := range records {
+		if records[i].Same(e) {
+			records[i] = journalRecord{JournalEntry: e}
+			replaced = true
+		}
+	}
+	if !replaced {
+		records = append(records, journalRecord{JournalEntry: e})
+	}
+	return j.save(records)
+}
+
+// Remove forgets the entry of the same signing as e once it finished or
+// the user dealt with it.
+func (j *Journal) Remove(e JournalEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	records, err := j.load()
+	if err != nil {
+		return err
+	}
+	kept := records[:0]
+	for _, o := range records {
+		if !o.Same(e) {
+			kept = append(kept, o)
+		}
+	}
+	if len(kept) == len(records) {
+		return nil
+	}
+	return j.save(kept)
+}
+
+// load reads the journal and decrypts the private part of its entries. A
+// record whose private part cannot be decrypted keeps it as read, so saving
+// it back loses nothing.
+func (j *Journal) load() ([]journalRecord, error) {
+	data, err := os.ReadFile(j.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var records []journalRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode journal: %w", err)
+	}
+	for i := range records {
+		r := &records[i]
+		r.JournalEntry.SignerID, r.JournalEntry.Response = r.SignerID, r.Response
+		r.SignerID, r.Response = "", nil
+		if len(r.Private) == 0 || j.cipher == nil {
+			continue
+		}
+		plain, err := j.cipher.DecryptVault(r.Private)
+		if err != nil {
+			continue
+		}
+		var p journalPrivate
+		if err := json.Unmarshal(plain, &p); err != nil {
+			continue
+		}
+		r.JournalEntry.SignerID, r.JournalEntry.Response = p.SignerID, p.Response
+		r.Private = nil
+	}
+	return records, nil
+}
+
+// seal encrypts the private part of r's entry into r.Private, or drops it
+// if there is no cipher to encrypt it with.
+func (j *Journal) seal(r *journalRecord) error {
+	p := journalPrivate{SignerID: r.JournalEntry.SignerID, Response: r.JournalEntry.Response}
+	if p.SignerID == "" && len(p.Response) == 0 {
+		return nil
+	}
+	if j.cipher == nil {
+		return nil
+	}
+	plain, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to marshal journal entry: %w", err)
+	}
+	enc, err := j.cipher.EncryptVault(plain)
+	if err != nil {
+		// A locked wallet leaves the entry without its private part, which
+		// is better than not journaling the signing at all.
+		return nil
+	}
+	r.Private = enc
+	return nil
+}
+
+func (j *Journal) save(records []journalRecord) error {
+	if len(records) == 0 {
+		if err := os.Remove(j.filePath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	out := make([]journalRecord, len(records))
+	for i, r := range records {
+		if err := j.seal(&r); err != nil {
+			return err
+		}
+		out[i] = r
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal journal: %w", err)
+	}
+	tmp := j.filePath + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, j.filePath)
+}
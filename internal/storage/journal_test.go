@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJournal(t *testing.T) {
+	dir := t.TempDir()
+	j, err := NewJournal(dir)
+	if err != nil {
+		t.Fatalf("NewJournal: %v", err)
+	}
+	j.SetVaultCipher(xorCipher{})
+
+	if entries, err := j.Entries(); err != nil || len(entries) != 0 {
+		t.Fatalf("Entries on empty journal = %+v, %v", entries, err)
+	}
+
+	first := JournalEntry{RequestID: "ILP-1", RequestHash: "aa", CertFingerprint: "f1", Stage: StageSigning, PayloadSHA256: "p1"}
+	second := JournalEntry{RequestID: "ILP-1", RequestHash: "aa", CertFingerprint: "f1", SignerID: "12345678Z", Stage: StageSigning}
+	for _, e := range []JournalEntry{first, second} {
+		if err := j.Record(e); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	first.Stage = StageSubmitting
+	first.SignatureSHA256 = "s1"
+	if err := j.Record(first); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	entries, err := j.Entries()
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("Entries = %+v, %v", entries, err)
+	}
+	if got := entries[0]; got.Stage != StageSubmitting || got.SignatureSHA256 != "s1" || got.PayloadSHA256 != "p1" || got.UpdatedAt == "" {
+		t.Fatalf("advanced entry = %+v", got)
+	}
+	if got := entries[1]; got.SignerID != "12345678Z" || got.Stage != StageSigning {
+		t.Fatalf("second entry = %+v", got)
+	}
+
+	// A fresh journal over the same directory sees what was written, as
+	// after a restart.
+	reopened, err := NewJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reopened.SetVaultCipher(xorCipher{})
+	if entries, _ := reopened.Entries(); len(entries) != 2 || entries[1].SignerID != "12345678Z" {
+		t.Fatalf("Entries after reopen = %+v", entries)
+	}
+
+	if err := j.Remove(first); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if entries, _ := j.Entries(); len(entries) != 1 || !entries[0].Same(second) {
+		t.Fatalf("Entries after Remove = %+v", entries)
+	}
+	if err := j.Remove(first); err != nil {
+		t.Fatalf("Remove of a missing entry: %v", err)
+	}
+	if err := j.Remove(second); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "journal.json")); !os.IsNotExist(err) {
+		t.Fatalf("expected the empty journal to be deleted, stat err = %v", err)
+	}
+}
+
+func TestJournal_Corrupt(t *testing.T) {
+	dir := t.TempDir()
+	j, err := NewJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "journal.json"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := j.Entries(); err == nil {
+		t.Fatal("expected an error for a corrupt journal")
+	}
+	if err := j.Record(JournalEntry{RequestID: "r"}); err == nil {
+		t.Fatal("expected Record to refuse overwriting a corrupt journal")
+	}
+}
+
+// xorCipher stands in for the vault key. It fails while locked.
+type xorCipher struct{ locked bool }
+
+func (c xorCipher) EncryptVault(data []byte) ([]byte, error) {
+	if c.locked {
+		return nil, errors.New("locked")
+	}
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[i] = b ^ 0xa5
+	}
+	return out, nil
+}
+
+func (c xorCipher) DecryptVault(enc []byte) ([]byte, error) { return c.EncryptVault(enc) }
+
+func TestJournal_EncryptsPrivate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "journal.json")
+	j, err := NewJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.SetVaultCipher(xorCipher{})
+
+	e := JournalEntry{RequestID: "ILP-1", CertFingerprint: "f1", SignerID: "12345678Z", Stage: StageSubmitting, Response: json.RawMessage(`{"signerXmlBase64":"secret"}`)}
+	if err := j.Record(e); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("12345678Z")) || bytes.Contains(data, []byte("secret")) {
+		t.Fatalf("journal holds the signer in plaintext:\n%s", data)
+	}
+	entries, err := j.Entries()
+	if err != nil || len(entries) != 1 || entries[0].SignerID != "12345678Z" || string(entries[0].Response) != string(e.Response) {
+		t.Fatalf("Entries = %+v, %v", entries, err)
+	}
+
+	// Without the key the entry is still listed, and writing another entry
+	// keeps its encrypted part for when the key is back.
+	locked, err := NewJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	locked.SetVaultCipher(xorCipher{locked: true})
+	if entries, _ := locked.Entries(); len(entries) != 1 || entries[0].SignerID != "" || entries[0].Response != nil {
+		t.Fatalf("Entries while locked = %+v", entries)
+	}
+	other := JournalEntry{RequestID: "ILP-2", CertFingerprint: "f1", SignerID: "87654321X", Stage: StageSigning}
+	if err := locked.Record(other); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); bytes.Contains(data, []byte("87654321X")) {
+		t.Fatalf("journal holds the signer in plaintext while locked:\n%s", data)
+	}
+	if entries, _ := j.Entries(); len(entries) != 2 || entries[0].SignerID != "12345678Z" || entries[1].SignerID != "" {
+		t.Fatalf("Entries after a locked write = %+v", entries)
+	}
+}
+
+func TestJournal_EncryptsLegacyEntries(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "journal.json")
+	legacy := `[{"requestId":"ILP-1","certFingerprint":"f1","signerId":"12345678Z","stage":"signing","updatedAt":"2026-01-01T00:00:00Z"}]`
+	if err := os.WriteFile(path, []byte(legacy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	j, err := NewJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.SetVaultCipher(xorCipher{})
+	entries, err := j.Entries()
+	if err != nil || len(entries) != 1 || entries[0].SignerID != "12345678Z" {
+		t.Fatalf("Entries of a legacy journal = %+v, %v", entries, err)
+	}
+	if err := j.Record(JournalEntry{RequestID: "ILP-2", CertFingerprint: "f1", Stage: StageSigning}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); bytes.Contains(data, []byte("12345678Z")) {
+		t.Fatalf("legacy signer ID still in plaintext:\n%s", data)
+	}
+	if entries, _ := j.Entries(); len(entries) != 2 || entries[0].SignerID != "12345678Z" {
+		t.Fatalf("Entries after rewrite = %+v", entries)
+	}
+}
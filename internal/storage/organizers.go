@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/translog"
+)
+
+// PinnedOrganizer is an organizer the user trusts enough to list its open
+// campaigns on the home screen. Organizers are identified by their JWKS URL.
+// TransparencyLogURL is the organizer's transparency log, which every later
+// request of the organizer must name, and TransparencyLogHead the checkpoint
+// of that log as last verified.
+type PinnedOrganizer struct {
+	Name                string               `json:"name"`
+	JWKSetURL           string               `json:"jwkSetUrl"`
+	CampaignIndexURL    string               `json:"campaignIndexUrl"`
+	TransparencyLogURL  string               `json:"transparencyLogUrl,omitempty"`
+	TransparencyLogHead *translog.Checkpoint `json:"transparencyLogHead,omitempty"`
+	PinnedAt            string               `json:"pinnedAt"`
+}
+
+// OrganizerStore keeps the pinned organizers. The list is read from disk
+// once and kept in memory, since screens ask IsPinned on every frame;
+// every write replaces the kept copy.
+type OrganizerStore struct {
+	mu       sync.Mutex
+	filePath string
+	loaded   bool
+	cache    []PinnedOrganizer
+}
+
+func NewOrganizerStore(dir string) (*OrganizerStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	return &OrganizerStore{filePath: filepath.Join(dir, "organizers.json")}, nil
+}
+
+// read returns a copy of the pinned organizers, which the caller may
+// modify.
+func (s *OrganizerStore) read() ([]PinnedOrganizer, error) {
+	if s.loaded {
+		return slices.Clone(s.cache), nil
+	}
+	data, err := os.ReadFile(s.filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	var out []PinnedOrganizer
+	if err == nil {
+		if err := json.Unmarshal(data, &out); err != nil {
+			return nil, fmt.Errorf("failed to decode pinned organizers: %w", err)
+		}
+	}
+	s.cache, s.loaded = out, true
+	return slices.Clone(out), nil
+}
+
+func (s *OrganizerStore) write(list []PinnedOrganizer) error {
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal pinned organizers: %w", err)
+	}
+	tmp := s.filePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.filePath); err != nil {
+		return err
+	}
+	s.cache, s.loaded = slices.Clone(list), true
+	return nil
+}
+
+func (s *OrganizerStore) List() ([]PinnedOrganizer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read()
+}
+
+func (s *OrganizerStore) IsPinned(jwkSetURL string) bool {
+	_, ok := s.Pinned(jwkSetURL)
+	return ok
+}
+
+// Pinned returns the pinned organizer with this JWKS URL.
+func (s *OrganizerStore) Pinned(jwkSetURL string) (PinnedOrganizer, bool) {
+	list, err := s.List()
+	if err != nil {
+		return PinnedOrganizer{}, false
+	}
+	for _, o := range list {
+		if o.JWKSetURL == jwkSetURL {
+			return o, true
+		}
+	}
+	return PinnedOrganizer{}, false
+}
+
+// SetTransparencyLog records the transparency log of a pinned organizer and
+// the checkpoint of it just verified. It does nothing for an organizer that
+// is not pinned.
+func (s *OrganizerStore) SetTransparencyLog(jwkSetURL, logURL string, head translog.Checkpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, err := s.read()
+	if err != nil {
+		return err
+	}
+	found := false
+	for i := range list {
+		if list[i].JWKSetURL == jwkSetURL {
+			list[i].TransparencyLogURL = logURL
+			list[i].TransparencyLogHead = &head
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+	return s.write(list)
+}
+
+// Pin adds or updates an organizer.
+func (s *OrganizerStore) Pin(o PinnedOrganizer) error {
+	if o.JWKSetURL == "" || o.CampaignIndexURL == "" {
+		return fmt.Errorf("organizer requires jwkSetUrl and campaignIndexUrl")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, err := s.read()
+	if err != nil {
+		return err
+	}
+	if o.PinnedAt == "" {
+		o.PinnedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	replaced := false
+	for i := range list {
+		if list[i].JWKSetURL == o.JWKSetURL {
+			list[i] = o
+			replaced = true
+		}
+	}
+	if !replaced {
+		list = append(list, o)
+	}
+	return s.write(list)
+}
+
+func (s *OrganizerStore) Unpin(jwkSetURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, err := s.read()
+	if err != nil {
+		return err
+	}
+	out := list[:0]
+	for _, o := range list {
+		if o.JWKSetURL != jwkSetURL {
+			out = append(out, o)
+		}
+	}
+	return s.write(out)
+}
+
+// Replace pins exactly the organizers in list, e.g. those of an imported
+// configuration profile.
+func (s *OrganizerStore) Replace(list []PinnedOrganizer) error {
+	for _, o := range list {
+		if o.JWKSetURL == "" || o.CampaignIndexURL == "" {
+			return fmt.Errorf("organizer requires jwkSetUrl and campaignIndexUrl")
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.write(list)
+}
@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/translog"
+)
+
+func TestOrganizerStore(t *testing.T) {
+	s, err := NewOrganizerStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewOrganizerStore: %v", err)
+	}
+
+	if s.IsPinned("https://a.example/jwks.json") {
+		t.Fatal("empty store reports pinned organizer")
+	}
+	if err := s.Pin(PinnedOrganizer{Name: "A", JWKSetURL: "https://a.example/jwks.json"}); err == nil {
+		t.Fatal("expected error without campaignIndexUrl")
+	}
+
+	a := PinnedOrganizer{Name: "A", JWKSetURL: "https://a.example/jwks.json", CampaignIndexURL: "https://a.example/campaigns"}
+	b := PinnedOrganizer{Name: "B", JWKSetURL: "https://b.example/jwks.json", CampaignIndexURL: "https://b.example/campaigns"}
+	for _, o := range []PinnedOrganizer{a, b} {
+		if err := s.Pin(o); err != nil {
+			t.Fatalf("Pin: %v", err)
+		}
+	}
+	// Re-pinning updates in place.
+	a.Name = "A renamed"
+	if err := s.Pin(a); err != nil {
+		t.Fatalf("Pin: %v", err)
+	}
+
+	list, err := s.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list) != 2 || list[0].Name != "A renamed" || list[0].PinnedAt == "" {
+		t.Fatalf("List = %+v", list)
+	}
+	if !s.IsPinned(b.JWKSetURL) {
+		t.Error("IsPinned(b) = false")
+	}
+
+	if err := s.Unpin(a.JWKSetURL); err != nil {
+		t.Fatalf("Unpin: %v", err)
+	}
+	list, _ = s.List()
+	if len(list) != 1 || list[0].JWKSetURL != b.JWKSetURL {
+		t.Fatalf("after Unpin = %+v", list)
+	}
+}
+
+func TestOrganizerStore_Replace(t *testing.T) {
+	s, err := NewOrganizerStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewOrganizerStore: %v", err)
+	}
+	a := PinnedOrganizer{Name: "A", JWKSetURL: "https://a.example/jwks.json", CampaignIndexURL: "https://a.example/campaigns"}
+	b := PinnedOrganizer{Name: "B", JWKSetURL: "https://b.example/jwks.json", CampaignIndexURL: "https://b.example/campaigns"}
+	if err := s.Pin(a); err != nil {
+		t.Fatalf("Pin: %v", err)
+	}
+	if err := s.Replace([]PinnedOrganizer{{Name: "C", JWKSetURL: "https://c.example/jwks.json"}}); err == nil {
+		t.Fatal("expected error without campaignIndexUrl")
+	}
+	if err := s.Replace([]PinnedOrganizer{b}); err != nil {
+		t.Fatalf("Replace: %v", err)
+	}
+	if s.IsPinned(a.JWKSetURL) || !s.IsPinned(b.JWKSetURL) {
+		t.Fatal("Replace did not pin exactly the given organizers")
+	}
+	if err := s.Replace(nil); err != nil {
+		t.Fatalf("Replace: %v", err)
+	}
+	if list, err := s.List(); err != nil || len(list) != 0 {
+		t.Fatalf("List after Replace(nil) = %v, %v", list, err)
+	}
+}
+
+func TestOrganizerStore_SetTransparencyLog(t *testing.T) {
+	s, err := NewOrganizerStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewOrganizerStore: %v", err)
+	}
+	a := PinnedOrganizer{Name: "A", JWKSetURL: "https://a.example/jwks.json", CampaignIndexURL: "https://a.example/campaigns"}
+	if err := s.Pin(a); err != nil {
+		t.Fatalf("Pin: %v", err)
+	}
+	head := translog.Checkpoint{Size: 3, Head: "abcd"}
+	if err := s.SetTransparencyLog(a.JWKSetURL, "https://a.example/log", head); err != nil {
+		t.Fatalf("SetTransparencyLog: %v", err)
+	}
+	got, ok := s.Pinned(a.JWKSetURL)
+	if !ok || got.TransparencyLogURL != "https://a.example/log" || got.TransparencyLogHead == nil || *got.TransparencyLogHead != head {
+		t.Fatalf("Pinned = %+v, %v", got, ok)
+	}
+
+	// Organizers that are not pinned are not added.
+	if err := s.SetTransparencyLog("https://b.example/jwks.json", "https://b.example/log", head); err != nil {
+		t.Fatalf("SetTransparencyLog: %v", err)
+	}
+	if _, ok := s.Pinned("https://b.example/jwks.json"); ok {
+		t.Fatal("SetTransparencyLog pinned an organizer")
+	}
+}
+
+func TestOrganizerStore_Cache(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewOrganizerStore(dir)
+	if err != nil {
+		t.Fatalf("NewOrganizerStore: %v", err)
+	}
+	a := PinnedOrganizer{Name: "A", JWKSetURL: "https://a.example/jwks.json", CampaignIndexURL: "https://a.example/campaigns"}
+	if err := s.Pin(a); err != nil {
+		t.Fatalf("Pin: %v", err)
+	}
+
+	// Lookups are answered from memory once the list is loaded.
+	if err := os.WriteFile(filepath.Join(dir, "organizers.json"), []byte("not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if !s.IsPinned(a.JWKSetURL) {
+		t.Fatal("IsPinned read the file again")
+	}
+	list, _ := s.List()
+	list[0].Name = "changed"
+	if o, _ := s.Pinned(a.JWKSetURL); o.Name != "A" {
+		t.Fatal("List returned the kept copy")
+	}
+
+	// A new store reads the file.
+	if err := s.Unpin(a.JWKSetURL); err != nil {
+		t.Fatalf("Unpin: %v", err)
+	}
+	if err := s.Pin(a); err != nil {
+		t.Fatalf("Pin: %v", err)
+	}
+	fresh, err := NewOrganizerStore(dir)
+	if err != nil {
+		t.Fatalf("NewOrganizerStore: %v", err)
+	}
+	if !fresh.IsPinned(a.JWKSetURL) {
+		t.Fatal("pinned organizer not written to disk")
+	}
+}
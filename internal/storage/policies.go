@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// PolicyCache keeps downloaded signature policy documents so each one is
+// fetched and verified only once. Documents are stored under the hex of
+// their SHA-256 hash, so a cached file always matches its name.
+type PolicyCache struct {
+	dir string
+}
+
+func NewPolicyCache(dir string) (*PolicyCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	return &PolicyCache{dir: dir}, nil
+}
+
+// policyExts are the extensions a cached policy document may keep. The
+// cached file is handed to the OS to open, so extensions that would run it,
+// such as .exe, .hta or .html, are never taken from the URI.
+var policyExts = map[string]bool{
+	".pdf": true, ".txt": true, ".odt": true, ".doc": true, ".docx": true, ".rtf": true,
+}
+
+// policyExt keeps a known document extension from uri so the OS can open
+// the cached file with the right viewer.
+func policyExt(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return ""
+	}
+	ext := strings.ToLower(path.Ext(u.Path))
+	if !policyExts[ext] {
+		return ""
+	}
+	return ext
+}
+
+func (c *PolicyCache) path(sum []byte, uri string) string {
+	return filepath.Join(c.dir, hex.EncodeToString(sum)+policyExt(uri))
+}
+
+// Lookup returns the path of the cached document with the given SHA-256
+// hash, checking that its content still matches.
+func (c *PolicyCache) Lookup(sum []byte, uri string) (string, bool) {
+	p := c.path(sum, uri)
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return "", false
+	}
+	actual := sha256.Sum256(data)
+	if !bytes.Equal(actual[:], sum) {
+		_ = os.Remove(p)
+		return "", false
+	}
+	return p, true
+}
+
+// Store writes a verified document and returns its path.
+func (c *PolicyCache) Store(data []byte, uri string) (string, error) {
+	sum := sha256.Sum256(data)
+	p := c.path(sum[:], uri)
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write policy document: %w", err)
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		return "", fmt.Errorf("failed to write policy document: %w", err)
+	}
+	return p, nil
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPolicyCache(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewPolicyCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := []byte("%PDF-1.4 policy")
+	sum := sha256.Sum256(doc)
+	uri := "https://sede.example/politica_firma.PDF"
+
+	if _, ok := c.Lookup(sum[:], uri); ok {
+		t.Fatal("expected cache miss")
+	}
+	p, err := c.Store(doc, uri)
+	if err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	if filepath.Ext(p) != ".pdf" {
+		t.Errorf("expected .pdf extension, got %s", p)
+	}
+	got, ok := c.Lookup(sum[:], uri)
+	if !ok || got != p {
+		t.Fatalf("Lookup = %q, %v; want %q, true", got, ok, p)
+	}
+
+	// A tampered file must not be served from the cache.
+	if err := os.WriteFile(p, []byte("tampered"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Lookup(sum[:], uri); ok {
+		t.Fatal("expected tampered document to be rejected")
+	}
+}
+
+func TestPolicyExt(t *testing.T) {
+	tests := map[string]string{
+		"https://x/policy.pdf":      ".pdf",
+		"https://x/policy":          "",
+		"https://x/p.toolongext":    "",
+		"https://x/p.p$f":           "",
+		"https://x/policy.pdf?dl=1": ".pdf",
+		"https://x/Policy.PDF":      ".pdf",
+		"https://x/setup.exe":       "",
+		"https://x/policy.hta":      "",
+		"https://x/policy.html":     "",
+	}
+	for uri, want := range tests {
+		if got := policyExt(uri); got != want {
+			t.Errorf("policyExt(%q) = %q, want %q", uri, got, want)
+		}
+	}
+}
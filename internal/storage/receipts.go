@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ReceiptStore keeps the counter-signed signatures returned by collectors
+// as evidence that a submission was accepted. Each one is stored under the
+// hex SHA-256 of the original signature, as recorded in the audit log's
+// signatureSha256.
+type ReceiptStore struct {
+	dir string
+}
+
+func NewReceiptStore(dir string) (*ReceiptStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	return &ReceiptStore{dir: dir}, nil
+}
+
+func (s *ReceiptStore) path(signatureDER []byte) string {
+	sum := sha256.Sum256(signatureDER)
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".p7s")
+}
+
+// Save stores countersigned, the counter-signed form of signatureDER.
+func (s *ReceiptStore) Save(signatureDER, countersigned []byte) error {
+	p := s.path(signatureDER)
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, countersigned, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
+
+// Load returns the counter-signed form of signatureDER, or nil if none was
+// stored.
+func (s *ReceiptStore) Load(signatureDER []byte) ([]byte, error) {
+	data, err := os.ReadFile(s.path(signatureDER))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReceiptStore_SaveLoad(t *testing.T) {
+	s, err := NewReceiptStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewReceiptStore: %v", err)
+	}
+
+	sig := []byte("signature")
+	got, err := s.Load(sig)
+	if err != nil || got != nil {
+		t.Fatalf("Load on empty store = %v, %v; want nil, nil", got, err)
+	}
+
+	if err := s.Save(sig, []byte("counter-signed")); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	got, err = s.Load(sig)
+	if err != nil || !bytes.Equal(got, []byte("counter-signed")) {
+		t.Fatalf("Load = %q, %v", got, err)
+	}
+	if got, _ := s.Load([]byte("other")); got != nil {
+		t.Errorf("Load of another signature = %q, want nil", got)
+	}
+}
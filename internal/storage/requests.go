@@ -0,0 +1,306 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StoredRequest is a sign request as fetched from the organizer, kept so a
+// later fetch of the same requestId can be compared against it.
+type StoredRequest struct {
+	RequestID string          `json:"requestId"`
+	URL       string          `json:"url"`
+	FetchedAt string          `json:"fetchedAt"`
+	Raw       json.RawMessage `json:"raw"`
+	// RawSHA256 names the bytes as fetched in the content-addressed store,
+	// which Raw (re-encoded as part of this file) may not match exactly.
+	RawSHA256 string `json:"rawSha256,omitempty"`
+}
+
+type RequestStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+func NewRequestStore(dir string) (*RequestStore, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "raw"), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	return &RequestStore{dir: dir}, nil
+}
+
+// path derives the file name from a hash so arbitrary requestIds cannot
+// escape the store directory.
+func (s *RequestStore) path(requestID string) string {
+	sum := sha256.Sum256([]byte(requestID))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Load returns the stored request for requestID, or nil if it was never seen.
+func (s *RequestStore) Load(requestID string) (*StoredRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.path(requestID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var sr StoredRequest
+	if err := json.Unmarshal(data, &sr); err != nil {
+		return nil, fmt.Errorf("failed to decode stored request: %w", err)
+	}
+	return &sr, nil
+}
+
+func (s *RequestStore) Save(requestID, url string, raw []byte) error {
+	if !json.Valid(raw) {
+		return fmt.Errorf("raw request is not valid JSON")
+	}
+	sum, err := s.PutRaw(raw)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(StoredRequest{
+		RequestID: requestID,
+		URL:       url,
+		FetchedAt: time.Now().UTC().Format(time.RFC3339),
+		Raw:       raw,
+		RawSHA256: sum,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal stored request: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tmp := s.path(requestID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path(requestID))
+}
+
+// ErrRawMismatch is returned for a stored raw request whose bytes no longer
+// hash to the name they are stored under.
+var ErrRawMismatch = errors.New("stored request does not match its SHA-256")
+
+// RawSHA256 returns the hex SHA-256 a raw request is stored under.
+func RawSHA256(raw []byte) string {
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// rawPath returns the file of the raw request stored under sum, and false
+// if sum is not a hex SHA-256.
+func (s *RequestStore) rawPath(sum string) (string, bool) {
+	if len(sum) != sha256.Size*2 {
+		return "", false
+	}
+	if _, err := hex.DecodeString(sum); err != nil || strings.ToLower(sum) != sum {
+		return "", false
+	}
+	return filepath.Join(s.dir, "raw", sum+".json"), true
+}
+
+// PutRaw stores a request byte for byte as fetched, under its SHA-256, and
+// returns the hash. Every version is kept, so an audit entry that records
+// the hash can later be checked against exactly what was signed.
+func (s *RequestStore) PutRaw(raw []byte) (string, error) {
+	if len(raw) == 0 {
+		return "", errors.New("empty raw request")
+	}
+	sum := RawSHA256(raw)
+	path, _ := s.rawPath(sum)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := os.Stat(path); err == nil {
+		return sum, nil
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "", err
+	}
+	return sum, nil
+}
+
+// Raw returns the request stored under sum, or nil if there is none. The
+// bytes are checked against sum, and ErrRawMismatch is returned if the file
+// was altered.
+func (s *RequestStore) Raw(sum string) ([]byte, error) {
+	path, ok := s.rawPath(sum)
+	if !ok {
+		return nil, fmt.Errorf("invalid request hash %q", sum)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if RawSHA256(data) != sum {
+		return nil, ErrRawMismatch
+	}
+	return data, nil
+}
+
+// PurgeRaw removes the raw requests that neither an audit entry (keep) nor
+// a comparison baseline refers to, such as the versions a baseline was
+// taken from before it was replaced, and returns how many it removed.
+func (s *RequestStore) PurgeRaw(keep map[string]bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	baselines, err := os.ReadDir(s.dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read request store: %w", err)
+	}
+	inUse := make(map[string]bool, len(keep))
+	for sum := range keep {
+		inUse[sum] = true
+	}
+	for _, e := range baselines {
+		if e.IsDir() || e.Name() == "recent.json" || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, e.Name()))
+		if err != nil {
+			return 0, fmt.Errorf("failed to read stored request: %w", err)
+		}
+		var sr StoredRequest
+		if err := json.Unmarshal(data, &sr); err != nil {
+			// Without knowing what it refers to, nothing can go.
+			return 0, fmt.Errorf("failed to decode stored request %s: %w", e.Name(), err)
+		}
+		if sr.RawSHA256 != "" {
+			inUse[sr.RawSHA256] = true
+		}
+	}
+
+	raws, err := os.ReadDir(filepath.Join(s.dir, "raw"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read raw requests: %w", err)
+	}
+	removed := 0
+	for _, e := range raws {
+		if e.IsDir() || inUse[strings.TrimSuffix(e.Name(), ".json")] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, "raw", e.Name())); err != nil {
+			return removed, fmt.Errorf("failed to remove raw request: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// RecentRequest is an entry in the "recent requests" list shown on the
+// Open Request screen, most recently opened first.
+type RecentRequest struct {
+	RequestID string `json:"requestId"`
+	URL       string `json:"url"`
+	Title     string `json:"title"`
+	Promoter  string `json:"promoter,omitempty"`
+	ExpiresAt string `json:"expiresAt,omitempty"`
+	OpenedAt  string `json:"openedAt"`
+}
+
+const maxRecentRequests = 20
+
+func (s *RequestStore) recentPath() string {
+	return filepath.Join(s.dir, "recent.json")
+}
+
+func (s *RequestStore) readRecent() ([]RecentRequest, error) {
+	data, err := os.ReadFile(s.recentPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []RecentRequest
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to decode recent requests: %w", err)
+	}
+	return out, nil
+}
+
+func (s *RequestStore) writeRecent(list []RecentRequest) error {
+	data, err := json.Marshal(list)
+	if err != nil {
+		return fmt.Errorf("failed to marshal recent requests: %w", err)
+	}
+	tmp := s.recentPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.recentPath())
+}
+
+// Recent returns the recently opened requests, newest first.
+func (s *RequestStore) Recent() ([]RecentRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.readRecent()
+}
+
+// Touch records r as just opened, moving it to the front of the list.
+func (s *RequestStore) Touch(r RecentRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, err := s.readRecent()
+	if err != nil {
+		return err
+	}
+	if r.OpenedAt == "" {
+		r.OpenedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	out := []RecentRequest{r}
+	for _, e := range list {
+		if e.RequestID != r.RequestID {
+			out = append(out, e)
+		}
+	}
+	if len(out) > maxRecentRequests {
+		out = out[:maxRecentRequests]
+	}
+	return s.writeRecent(out)
+}
+
+// Forget removes requestID from the recent list. The stored copy used for
+// change detection is kept.
+func (s *RequestStore) Forget(requestID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, err := s.readRecent()
+	if err != nil {
+		return err
+	}
+	out := list[:0]
+	for _, e := range list {
+		if e.RequestID != requestID {
+			out = append(out, e)
+		}
+	}
+	return s.writeRecent(out)
+}
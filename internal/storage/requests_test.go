@@ -0,0 +1,240 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRequestStore_SaveLoad(t *testing.T) {
+	s, err := NewRequestStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewRequestStore: %v", err)
+	}
+
+	got, err := s.Load("req-1")
+	if err != nil || got != nil {
+		t.Fatalf("Load on empty store = %v, %v; want nil, nil", got, err)
+	}
+
+	raw := []byte(`{"requestId":"req-1"}`)
+	if err := s.Save("req-1", "https://example.com/r/1", raw); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	got, err = s.Load("req-1")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got.RequestID != "req-1" || got.URL != "https://example.com/r/1" || string(got.Raw) != string(raw) {
+		t.Errorf("Load = %+v", got)
+	}
+	if got.FetchedAt == "" {
+		t.Error("FetchedAt not set")
+	}
+
+	// Saving again replaces the previous copy.
+	raw2 := []byte(`{"requestId":"req-1","v":2}`)
+	if err := s.Save("req-1", "https://example.com/r/1", raw2); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	got, _ = s.Load("req-1")
+	if string(got.Raw) != string(raw2) {
+		t.Errorf("Raw = %s, want %s", got.Raw, raw2)
+	}
+}
+
+func TestRequestStore_PathTraversal(t *testing.T) {
+	dir := t.TempDir()
+	s, _ := NewRequestStore(dir)
+	if err := s.Save("../../etc/passwd", "u", []byte(`{}`)); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	var files []string
+	for _, e := range entries(t, dir) {
+		if e != "raw" {
+			files = append(files, e)
+		}
+	}
+	if len(files) != 1 {
+		t.Fatalf("expected file inside store dir, found %v", files)
+	}
+	if raw := entries(t, filepath.Join(dir, "raw")); len(raw) != 1 {
+		t.Fatalf("expected raw copy inside store dir, found %v", raw)
+	}
+}
+
+func entries(t *testing.T, dir string) []string {
+	t.Helper()
+	list, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range list {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestRequestStore_RejectsInvalidJSON(t *testing.T) {
+	s, _ := NewRequestStore(t.TempDir())
+	if err := s.Save("req-1", "u", []byte("not json")); err == nil {
+		t.Fatal("expected error for invalid JSON")
+	}
+}
+
+func TestRequestStore_Recent(t *testing.T) {
+	s, _ := NewRequestStore(t.TempDir())
+
+	list, err := s.Recent()
+	if err != nil || len(list) != 0 {
+		t.Fatalf("Recent on empty store = %v, %v", list, err)
+	}
+
+	for _, id := range []string{"a", "b", "c"} {
+		if err := s.Touch(RecentRequest{RequestID: id, URL: "https://example.com/" + id}); err != nil {
+			t.Fatalf("Touch(%s): %v", id, err)
+		}
+	}
+	// Re-opening "a" moves it to the front without duplicating it.
+	if err := s.Touch(RecentRequest{RequestID: "a", URL: "https://example.com/a", Title: "A"}); err != nil {
+		t.Fatalf("Touch: %v", err)
+	}
+
+	list, _ = s.Recent()
+	var ids []string
+	for _, r := range list {
+		ids = append(ids, r.RequestID)
+	}
+	if got := strings.Join(ids, ","); got != "a,c,b" {
+		t.Fatalf("order = %s, want a,c,b", got)
+	}
+	if list[0].Title != "A" || list[0].OpenedAt == "" {
+		t.Errorf("front entry = %+v", list[0])
+	}
+
+	if err := s.Forget("c"); err != nil {
+		t.Fatalf("Forget: %v", err)
+	}
+	list, _ = s.Recent()
+	if len(list) != 2 || list[1].RequestID != "b" {
+		t.Fatalf("after Forget = %+v", list)
+	}
+}
+
+func TestRequestStore_RecentCapped(t *testing.T) {
+	s, _ := NewRequestStore(t.TempDir())
+	for i := range maxRecentRequests + 5 {
+		if err := s.Touch(RecentRequest{RequestID: fmt.Sprintf("r%d", i)}); err != nil {
+			t.Fatalf("Touch: %v", err)
+		}
+	}
+	list, _ := s.Recent()
+	if len(list) != maxRecentRequests {
+		t.Fatalf("len = %d, want %d", len(list), maxRecentRequests)
+	}
+}
+
+func TestRequestStore_Raw(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewRequestStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Formatting is kept: the store is byte-exact, not re-encoded JSON.
+	raw := []byte("{\n  \"requestId\": \"req-1\"\n}\n")
+	sum, err := s.PutRaw(raw)
+	if err != nil {
+		t.Fatalf("PutRaw: %v", err)
+	}
+	if sum != RawSHA256(raw) {
+		t.Fatalf("PutRaw = %s, want %s", sum, RawSHA256(raw))
+	}
+	if again, err := s.PutRaw(raw); err != nil || again != sum {
+		t.Fatalf("PutRaw again = %s, %v", again, err)
+	}
+	got, err := s.Raw(sum)
+	if err != nil || string(got) != string(raw) {
+		t.Fatalf("Raw = %q, %v", got, err)
+	}
+
+	if got, err := s.Raw(RawSHA256([]byte("other"))); err != nil || got != nil {
+		t.Fatalf("Raw of an unknown hash = %q, %v", got, err)
+	}
+	for _, bad := range []string{"", "../recent", strings.ToUpper(sum), sum[:10]} {
+		if _, err := s.Raw(bad); err == nil {
+			t.Errorf("Raw(%q) succeeded", bad)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "raw", sum+".json"), []byte(`{"requestId":"forged"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Raw(sum); !errors.Is(err, ErrRawMismatch) {
+		t.Fatalf("Raw of an altered file: err = %v, want ErrRawMismatch", err)
+	}
+}
+
+func TestRequestStore_SaveKeepsRaw(t *testing.T) {
+	s, err := NewRequestStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	v1 := []byte(`{"requestId": "req-1", "v": 1}`)
+	v2 := []byte(`{"requestId": "req-1", "v": 2}`)
+	for _, raw := range [][]byte{v1, v2} {
+		if err := s.Save("req-1", "https://example.com/r/1", raw); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+	got, _ := s.Load("req-1")
+	if got.RawSHA256 != RawSHA256(v2) {
+		t.Fatalf("RawSHA256 = %s, want the latest version", got.RawSHA256)
+	}
+	// The baseline moved on, but the earlier version is still on file.
+	if raw, err := s.Raw(RawSHA256(v1)); err != nil || string(raw) != string(v1) {
+		t.Fatalf("Raw of the first version = %q, %v", raw, err)
+	}
+}
+
+func TestRequestStore_PurgeRaw(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewRequestStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v1 := []byte(`{"requestId": "req-1", "v": 1}`)
+	v2 := []byte(`{"requestId": "req-1", "v": 2}`)
+	signed := []byte(`{"requestId": "req-2"}`)
+	for _, raw := range [][]byte{v1, v2} {
+		if err := s.Save("req-1", "https://example.com/r/1", raw); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.PutRaw(signed); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "raw", RawSHA256(v1)+".json.tmp"), v1, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := s.PurgeRaw(map[string]bool{RawSHA256(signed): true})
+	if err != nil || n != 2 {
+		t.Fatalf("PurgeRaw = %d, %v; want the replaced version and the stray temp file", n, err)
+	}
+	if raw, _ := s.Raw(RawSHA256(v1)); raw != nil {
+		t.Error("version no longer referenced was kept")
+	}
+	for _, want := range [][]byte{v2, signed} {
+		if raw, err := s.Raw(RawSHA256(want)); err != nil || string(raw) != string(want) {
+			t.Errorf("Raw(%s) = %q, %v", want, raw, err)
+		}
+	}
+	if n, err := s.PurgeRaw(map[string]bool{RawSHA256(signed): true}); err != nil || n != 0 {
+		t.Errorf("second PurgeRaw = %d, %v", n, err)
+	}
+}
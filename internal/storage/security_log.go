@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Kinds of SecurityEvent.
+const (
+	SecurityWalletLocked   = "wallet_locked"
+	SecurityWalletUnlocked = "wallet_unlocked"
+	SecurityAuthFailed     = "auth_failed"
+	SecuritySettings       = "settings_changed"
+	SecurityTrust          = "trust_decision"
+	SecurityVault          = "vault_changed"
+	SecurityUpdate         = "update_installed"
+)
+
+// SecurityEvent is an entry of the security log: something that changed
+// how, or by whom, the wallet can be used, as opposed to the signatures
+// made with it, which go to the audit log.
+type SecurityEvent struct {
+	Timestamp string `json:"timestamp"`
+	Kind      string `json:"kind"`
+	Detail    string `json:"detail"`
+	PrevHash  string `json:"prevHash"`
+}
+
+// SecurityLog appends SecurityEvents to security.jsonl, chained by hash
+// like the audit log, and anchored the same way. The chain alone shows
+// events edited or removed in the middle of the log; the anchors, which
+// an attacker cannot forge, also show the newest ones removed or the whole
+// file rewritten, up to the last anchor.
+type SecurityLog struct {
+	mu       sync.Mutex
+	filePath string
+	lastHash string
+	entries  int
+}
+
+func NewSecurityLog(dir string) (*SecurityLog, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	l := &SecurityLog{filePath: filepath.Join(dir, "security.jsonl")}
+	lines, err := l.readLinesLocked()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load last hash: %w", err)
+	}
+	if len(lines) > 0 {
+		h := sha256.Sum256([]byte(lines[len(lines)-1]))
+		l.lastHash = hex.EncodeToString(h[:])
+	}
+	l.entries = len(lines)
+	return l, nil
+}
+
+// Record appends an event of the given kind.
+func (l *SecurityLog) Record(kind, detail string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	data, err := json.Marshal(SecurityEvent{
+		Timestamp: time.Now().Format(time.RFC3339),
+		Kind:      kind,
+		Detail:    detail,
+		PrevHash:  l.lastHash,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	f, err := os.OpenFile(l.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open security log: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close() // best-effort on error path; the write error is already being returned
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close security log: %w", err)
+	}
+	h := sha256.Sum256(data)
+	l.lastHash = hex.EncodeToString(h[:])
+	l.entries++
+	return nil
+}
+
+func (l *SecurityLog) anchorPath() string {
+	return filepath.Join(filepath.Dir(l.filePath), "security_anchors.jsonl")
+}
+
+// Head returns the current head of the hash chain and the number of
+// events it covers. The head is empty when the log is.
+func (l *SecurityLog) Head() (string, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lastHash, l.entries
+}
+
+// AddAnchor appends a to the anchors kept next to the security log.
+func (l *SecurityLog) AddAnchor(a AuditAnchor) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return appendAnchor(l.anchorPath(), a)
+}
+
+// Anchors returns the stored anchors, oldest first.
+func (l *SecurityLog) Anchors() ([]AuditAnchor, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return readAnchors(l.anchorPath())
+}
+
+// Verify checks the hash chain, and that the head of every anchor is still
+// the event it covers. It returns the number of verified events, or the
+// index of the first event that cannot be trusted with an error describing
+// why.
+func (l *SecurityLog) Verify() (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lines, err := l.readLinesLocked()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read security log: %w", err)
+	}
+	hashes := make([]string, len(lines))
+	prevHash := ""
+	for i, line := range lines {
+		var ev SecurityEvent
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			return i, fmt.Errorf("event %d: failed to unmarshal: %w", i, err)
+		}
+		if ev.PrevHash != prevHash {
+			return i, fmt.Errorf("event %d: hash chain broken: expected prevHash %q, got %q", i, prevHash, ev.PrevHash)
+		}
+		h := sha256.Sum256([]byte(line))
+		prevHash = hex.EncodeToString(h[:])
+		hashes[i] = prevHash
+	}
+
+	// Events up to the last anchor that still matches can be trusted.
+	anchors, err := readAnchors(l.anchorPath())
+	if err != nil {
+		return 0, err
+	}
+	trusted := 0
+	for i, a := range anchors {
+		if a.Entries < 1 || a.Entries > len(lines) {
+			return trusted, fmt.Errorf("anchor %d covers %d events, the log has %d: events were removed", i, a.Entries, len(lines))
+		}
+		if hashes[a.Entries-1] != a.Head {
+			return trusted, fmt.Errorf("anchor %d: event %d hashes to %s, anchored head is %s: the log was rewritten", i, a.Entries-1, hashes[a.Entries-1], a.Head)
+		}
+		trusted = a.Entries
+	}
+	return len(lines), nil
+}
+
+// ReadAll returns the events, oldest first. Lines that do not decode are
+// skipped; Verify reports them.
+func (l *SecurityLog) ReadAll() ([]SecurityEvent, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lines, err := l.readLinesLocked()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read security log: %w", err)
+	}
+	events := make([]SecurityEvent, 0, len(lines))
+	for _, line := range lines {
+		var ev SecurityEvent
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			continue
+		}
+		events = append(events, ev)
+	}
+	return events, nil
+}
+
+func (l *SecurityLog) readLinesLocked() ([]string, error) {
+	f, err := os.Open(l.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Printf("WARNING: failed to close security log: %v", err)
+		}
+	}()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
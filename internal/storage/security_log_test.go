@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSecurityLog(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewSecurityLog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := l.Verify(); n != 0 || err != nil {
+		t.Fatalf("Verify of an empty log = %d, %v", n, err)
+	}
+	if err := l.Record(SecurityWalletUnlocked, "Unlocked with the wallet PIN"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Record(SecurityAuthFailed, "Wrong wallet PIN"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The chain continues across restarts.
+	reopened, err := NewSecurityLog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.Record(SecuritySettings, "Changed: autoLockMinutes"); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := reopened.Verify(); n != 3 || err != nil {
+		t.Fatalf("Verify = %d, %v", n, err)
+	}
+	events, err := reopened.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 || events[0].Kind != SecurityWalletUnlocked || events[2].Detail != "Changed: autoLockMinutes" {
+		t.Fatalf("ReadAll = %+v", events)
+	}
+	if events[0].PrevHash != "" || events[1].PrevHash == "" {
+		t.Fatalf("PrevHash = %q, %q", events[0].PrevHash, events[1].PrevHash)
+	}
+
+	// Removing the failed attempt breaks the chain.
+	path := filepath.Join(dir, "security.jsonl")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	if err := os.WriteFile(path, []byte(lines[0]+lines[2]), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := reopened.Verify(); n != 1 || err == nil {
+		t.Fatalf("Verify of a tampered log = %d, %v", n, err)
+	}
+}
+
+func TestSecurityLog_Anchors(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewSecurityLog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, detail := range []string{"Wrong wallet PIN", "Wrong wallet PIN", "Unlocked with the wallet PIN"} {
+		if err := l.Record(SecurityAuthFailed, detail); err != nil {
+			t.Fatal(err)
+		}
+	}
+	head, entries := l.Head()
+	if head == "" || entries != 3 {
+		t.Fatalf("Head = %q, %d", head, entries)
+	}
+	if err := l.AddAnchor(AuditAnchor{Head: head, Entries: entries, TSAURL: "https://tsa.example", Time: "2026-01-01T00:00:00Z"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Record(SecuritySettings, "Changed: autoLockMinutes"); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := l.Verify(); n != 4 || err != nil {
+		t.Fatalf("Verify of an anchored log = %d, %v", n, err)
+	}
+	if anchors, err := l.Anchors(); err != nil || len(anchors) != 1 || anchors[0].Head != head {
+		t.Fatalf("Anchors = %+v, %v", anchors, err)
+	}
+
+	path := filepath.Join(dir, "security.jsonl")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+
+	// Removing the newest events leaves a valid chain, but not the
+	// anchored one.
+	if err := os.WriteFile(path, []byte(lines[0]+lines[1]), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := l.Verify(); n != 0 || err == nil {
+		t.Fatalf("Verify of a truncated log = %d, %v", n, err)
+	}
+
+	// So does rewriting the whole file with a new chain.
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	rewritten, err := NewSecurityLog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 4 {
+		if err := rewritten.Record(SecuritySettings, "Changed: theme"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n, err := rewritten.Verify(); n != 0 || err == nil {
+		t.Fatalf("Verify of a rewritten log = %d, %v", n, err)
+	}
+}
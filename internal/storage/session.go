@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Session is the minimal UI state kept so that a signing interrupted by a
+// crash or an accidental close can be resumed after a restart. It never
+// holds secrets nor personal data: no passwords, PINs or consent, and no
+// birth date. Signer names and ID numbers are re-read from the selected
+// certificate.
+type Session struct {
+	Screen     int    `json:"screen"`
+	RequestURL string `json:"requestUrl"`
+	RequestID  string `json:"requestId"`
+	Title      string `json:"title"`
+	CertID     string `json:"certId,omitempty"`
+	SavedAt    string `json:"savedAt"`
+}
+
+// legacySession is a Session as older versions wrote it, with the birth
+// date the user typed.
+type legacySession struct {
+	Session
+	BirthDate string `json:"birthDate,omitempty"`
+}
+
+// MaxSessionAge is how long an interrupted session is offered for resuming.
+const MaxSessionAge = 7 * 24 * time.Hour
+
+type SessionStore struct {
+	mu       sync.Mutex
+	filePath string
+}
+
+func NewSessionStore(dir string) (*SessionStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	return &SessionStore{filePath: filepath.Join(dir, "session.json")}, nil
+}
+
+// Load returns the saved session, or nil if there is none or it is older
+// than MaxSessionAge. A birth date saved by an older version is removed
+// from the file.
+func (s *SessionStore) Load() (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var legacy legacySession
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return nil, fmt.Errorf("failed to decode session: %w", err)
+	}
+	sess := legacy.Session
+	if legacy.BirthDate != "" {
+		if err := s.writeLocked(sess); err != nil {
+			return nil, fmt.Errorf("failed to remove birth date from session: %w", err)
+		}
+	}
+	if sess.RequestURL == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, sess.SavedAt); err != nil || time.Since(t) > MaxSessionAge {
+		return nil, nil
+	}
+	return &sess, nil
+}
+
+func (s *SessionStore) Save(sess Session) error {
+	sess.SavedAt = time.Now().UTC().Format(time.RFC3339)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writeLocked(sess)
+}
+
+func (s *SessionStore) writeLocked(sess Session) error {
+	data, err := json.MarshalIndent(sess, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+	tmp := s.filePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.filePath)
+}
+
+// Clear removes the saved session once signing finished or was abandoned.
+func (s *SessionStore) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.filePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
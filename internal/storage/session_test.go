@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionStore(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewSessionStore(dir)
+	if err != nil {
+		t.Fatalf("NewSessionStore: %v", err)
+	}
+
+	if sess, err := s.Load(); err != nil || sess != nil {
+		t.Fatalf("Load on empty store = %+v, %v", sess, err)
+	}
+
+	want := Session{Screen: 2, RequestURL: "https://example.org/request/ILP-1", RequestID: "ILP-1", Title: "Habitatge", CertID: "p12:abc"}
+	if err := s.Save(want); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	got, err := s.Load()
+	if err != nil || got == nil {
+		t.Fatalf("Load = %+v, %v", got, err)
+	}
+	if got.RequestID != want.RequestID || got.CertID != want.CertID || got.SavedAt == "" {
+		t.Fatalf("Load = %+v", got)
+	}
+
+	if err := s.Clear(); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if sess, _ := s.Load(); sess != nil {
+		t.Fatalf("Load after Clear = %+v", sess)
+	}
+	if err := s.Clear(); err != nil {
+		t.Fatalf("Clear on empty store: %v", err)
+	}
+}
+
+func TestSessionStore_Stale(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewSessionStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-MaxSessionAge - time.Hour).UTC().Format(time.RFC3339)
+	data := []byte(`{"requestUrl":"https://example.org/r","requestId":"r","savedAt":"` + old + `"}`)
+	if err := os.WriteFile(filepath.Join(dir, "session.json"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if sess, err := s.Load(); err != nil || sess != nil {
+		t.Fatalf("expected stale session to be ignored, got %+v, %v", sess, err)
+	}
+}
+
+func TestSessionStore_DropsLegacyBirthDate(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewSessionStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := time.Now().UTC().Format(time.RFC3339)
+	data := []byte(`{"requestUrl":"https://example.org/r","requestId":"r","birthDate":"1990-05-04","savedAt":"` + saved + `"}`)
+	path := filepath.Join(dir, "session.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sess, err := s.Load()
+	if err != nil || sess == nil || sess.RequestID != "r" || sess.SavedAt != saved {
+		t.Fatalf("Load = %+v, %v", sess, err)
+	}
+	data, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "1990") {
+		t.Errorf("birth date still saved:\n%s", data)
+	}
+}
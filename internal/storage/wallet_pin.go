@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// MinWalletPINLength is the shortest PIN accepted to unlock the wallet.
+const MinWalletPINLength = 4
+
+var (
+	// ErrWalletPINTooShort is returned by Set for a PIN shorter than
+	// MinWalletPINLength.
+	ErrWalletPINTooShort = fmt.Errorf("the PIN must have at least %d characters", MinWalletPINLength)
+	// ErrNoWalletPIN is returned by Verify when no PIN was set.
+	ErrNoWalletPIN = errors.New("no wallet PIN set")
+)
+
+// walletPINIterations is the PBKDF2 cost of new PIN hashes. Each hash
+// records its own, so raising it does not invalidate existing PINs.
+var walletPINIterations = 600_000
+
+// walletPIN is the salted hash of the PIN that unlocks the wallet after it
+// locks itself. The PIN itself is never stored.
+type walletPIN struct {
+	Salt       []byte `json:"salt"`
+	Hash       []byte `json:"hash"`
+	Iterations int    `json:"iterations"`
+}
+
+type WalletPINStore struct {
+	mu       sync.Mutex
+	filePath string
+	// set caches whether the file holds a PIN, since the navigation bar
+	// asks on every frame.
+	set bool
+}
+
+func NewWalletPINStore(dir string) (*WalletPINStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	s := &WalletPINStore{filePath: filepath.Join(dir, "wallet_pin.json")}
+	p, err := s.loadLocked()
+	s.set = err == nil && p != nil
+	return s, nil
+}
+
+// IsSet reports whether a wallet PIN was set.
+func (s *WalletPINStore) IsSet() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.set
+}
+
+// Set replaces the wallet PIN.
+func (s *WalletPINStore) Set(pin []byte) error {
+	if len([]rune(string(pin))) < MinWalletPINLength {
+		return ErrWalletPINTooShort
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	p := walletPIN{
+		Salt:       salt,
+		Hash:       pbkdf2.Key(pin, salt, walletPINIterations, 32, sha256.New),
+		Iterations: walletPINIterations,
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal wallet PIN: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tmp := s.filePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.filePath); err != nil {
+		return err
+	}
+	s.set = true
+	return nil
+}
+
+// Verify reports whether pin is the wallet PIN.
+func (s *WalletPINStore) Verify(pin []byte) (bool, error) {
+	s.mu.Lock()
+	p, err := s.loadLocked()
+	s.mu.Unlock()
+	if err != nil {
+		return false, err
+	}
+	if p == nil {
+		return false, ErrNoWalletPIN
+	}
+	hash := pbkdf2.Key(pin, p.Salt, p.Iterations, len(p.Hash), sha256.New)
+	return subtle.ConstantTimeCompare(hash, p.Hash) == 1, nil
+}
+
+// Clear removes the wallet PIN.
+func (s *WalletPINStore) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.filePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	s.set = false
+	return nil
+}
+
+func (s *WalletPINStore) loadLocked() (*walletPIN, error) {
+	data, err := os.ReadFile(s.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var p walletPIN
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to decode wallet PIN: %w", err)
+	}
+	if len(p.Salt) == 0 || len(p.Hash) == 0 || p.Iterations <= 0 {
+		return nil, errors.New("wallet PIN file is incomplete")
+	}
+	return &p, nil
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWalletPINStore(t *testing.T) {
+	iterations := walletPINIterations
+	walletPINIterations = 1000
+	defer func() { walletPINIterations = iterations }()
+
+	dir := t.TempDir()
+	s, err := NewWalletPINStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.IsSet() {
+		t.Fatal("IsSet before Set = true")
+	}
+	if _, err := s.Verify([]byte("1234")); !errors.Is(err, ErrNoWalletPIN) {
+		t.Fatalf("Verify without a PIN = %v", err)
+	}
+	if err := s.Set([]byte("123")); !errors.Is(err, ErrWalletPINTooShort) {
+		t.Fatalf("Set of a short PIN = %v", err)
+	}
+
+	if err := s.Set([]byte("4821")); err != nil {
+		t.Fatal(err)
+	}
+	if !s.IsSet() {
+		t.Fatal("IsSet after Set = false")
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "wallet_pin.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "4821") {
+		t.Fatal("the PIN is stored in clear")
+	}
+
+	// A PIN hashed with another cost still verifies.
+	walletPINIterations = 2000
+	reopened, _ := NewWalletPINStore(dir)
+	for pin, want := range map[string]bool{"4821": true, "4822": false, "": false} {
+		ok, err := reopened.Verify([]byte(pin))
+		if err != nil || ok != want {
+			t.Errorf("Verify(%q) = %v, %v, want %v", pin, ok, err, want)
+		}
+	}
+
+	if err := s.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if s.IsSet() {
+		t.Fatal("IsSet after Clear = true")
+	}
+	if err := s.Clear(); err != nil {
+		t.Fatalf("Clear without a PIN: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "wallet_pin.json"), []byte(`{"salt":""}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Verify([]byte("4821")); err == nil {
+		t.Fatal("Verify of an incomplete file succeeded")
+	}
+}
@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Main window sizes, in Dp. A saved size is clamped to the minimum and
+// maximum so a corrupt file or a size from a much larger display cannot
+// open an unusable window.
+const (
+	DefaultWindowWidth  = 1280
+	DefaultWindowHeight = 920
+	MinWindowWidth      = 720
+	MinWindowHeight     = 560
+	MaxWindowWidth      = 3840
+	MaxWindowHeight     = 2160
+)
+
+// WindowState is the size of the main window when it was last closed, and
+// whether it was maximized. Width and Height are those of the window in
+// its normal (not maximized) state.
+type WindowState struct {
+	Width     int  `json:"width"`
+	Height    int  `json:"height"`
+	Maximized bool `json:"maximized,omitempty"`
+}
+
+// DefaultWindowState is the state of the first launch.
+func DefaultWindowState() WindowState {
+	return WindowState{Width: DefaultWindowWidth, Height: DefaultWindowHeight}
+}
+
+// Clamped returns ws with its size within the window bounds. A missing
+// size is replaced by the default.
+func (ws WindowState) Clamped() WindowState {
+	if ws.Width <= 0 || ws.Height <= 0 {
+		ws.Width, ws.Height = DefaultWindowWidth, DefaultWindowHeight
+	}
+	ws.Width = min(max(ws.Width, MinWindowWidth), MaxWindowWidth)
+	ws.Height = min(max(ws.Height, MinWindowHeight), MaxWindowHeight)
+	return ws
+}
+
+// Fit returns ws clamped, with its size at most width by height, the work
+// area of the monitor it opens on, so a size saved on a larger display
+// does not open partly off screen. It never goes below the minimum size.
+func (ws WindowState) Fit(width, height int) WindowState {
+	ws = ws.Clamped()
+	ws.Width = max(min(ws.Width, width), MinWindowWidth)
+	ws.Height = max(min(ws.Height, height), MinWindowHeight)
+	return ws
+}
+
+type WindowStore struct {
+	mu       sync.Mutex
+	filePath string
+}
+
+func NewWindowStore(dir string) (*WindowStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	return &WindowStore{filePath: filepath.Join(dir, "window.json")}, nil
+}
+
+// Load returns the saved window state, clamped, or the default state if
+// none was saved or it cannot be read.
+func (s *WindowStore) Load() (WindowState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return DefaultWindowState(), nil
+		}
+		return DefaultWindowState(), err
+	}
+	var ws WindowState
+	if err := json.Unmarshal(data, &ws); err != nil {
+		return DefaultWindowState(), fmt.Errorf("failed to decode window state: %w", err)
+	}
+	return ws.Clamped(), nil
+}
+
+func (s *WindowStore) Save(ws WindowState) error {
+	data, err := json.MarshalIndent(ws.Clamped(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal window state: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tmp := s.filePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.filePath)
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWindowStore(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewWindowStore(dir)
+	if err != nil {
+		t.Fatalf("NewWindowStore: %v", err)
+	}
+
+	if ws, err := s.Load(); err != nil || ws != DefaultWindowState() {
+		t.Fatalf("Load on empty store = %+v, %v", ws, err)
+	}
+
+	want := WindowState{Width: 1600, Height: 1000, Maximized: true}
+	if err := s.Save(want); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if got, err := s.Load(); err != nil || got != want {
+		t.Fatalf("Load = %+v, %v, want %+v", got, err, want)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "window.json"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if ws, err := s.Load(); err == nil || ws != DefaultWindowState() {
+		t.Fatalf("Load of corrupt file = %+v, %v", ws, err)
+	}
+}
+
+func TestWindowStateClamped(t *testing.T) {
+	tests := []struct {
+		name string
+		in   WindowState
+		want WindowState
+	}{
+		{"unchanged", WindowState{Width: 1000, Height: 700}, WindowState{Width: 1000, Height: 700}},
+		{"missing size", WindowState{Maximized: true}, WindowState{Width: DefaultWindowWidth, Height: DefaultWindowHeight, Maximized: true}},
+		{"too small", WindowState{Width: 100, Height: 50}, WindowState{Width: MinWindowWidth, Height: MinWindowHeight}},
+		{"too large", WindowState{Width: 20000, Height: 9000}, WindowState{Width: MaxWindowWidth, Height: MaxWindowHeight}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.in.Clamped(); got != tt.want {
+				t.Errorf("Clamped() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindowStateFit(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            WindowState
+		width, height int
+		want          WindowState
+	}{
+		{"fits", WindowState{Width: 1280, Height: 920}, 1920, 1040, WindowState{Width: 1280, Height: 920}},
+		{"from a larger display", WindowState{Width: 2400, Height: 1300, Maximized: true}, 1366, 728, WindowState{Width: 1366, Height: 728, Maximized: true}},
+		{"smaller than the minimum", WindowState{Width: 1280, Height: 920}, 640, 480, WindowState{Width: MinWindowWidth, Height: MinWindowHeight}},
+		{"missing size", WindowState{}, 1024, 700, WindowState{Width: 1024, Height: 700}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.in.Fit(tt.width, tt.height); got != tt.want {
+				t.Errorf("Fit(%d, %d) = %+v, want %+v", tt.width, tt.height, got, tt.want)
+			}
+		})
+	}
+}
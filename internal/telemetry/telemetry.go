@@ -0,0 +1,169 @@
+// Package telemetry sends opt-in, anonymous usage events. Events only carry
+// coarse, enumerated values (app version, OS, event name and outcome) so no
+// personal data, identifiers, URLs or error messages can ever be reported.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"runtime"
+	"time"
+)
+
+// DefaultEndpoint receives telemetry events. VOCSIGN_TELEMETRY_URL overrides it.
+const DefaultEndpoint = "https://telemetry.vocdoni.io/vocsign/v1/events"
+
+// Event names.
+const (
+	EventAppStart = "app_start"
+	EventScan     = "store_scan"
+	EventSign     = "sign"
+	EventJank     = "ui_jank"
+)
+
+// Outcomes. Failures are reported by category only.
+const (
+	OutcomeSuccess          = "success"
+	OutcomeEmpty            = "empty"
+	OutcomeFailCertificate  = "fail_certificate"
+	OutcomeFailDocument     = "fail_document"
+	OutcomeFailUnlock       = "fail_unlock"
+	OutcomeFailPreSign      = "fail_presign"
+	OutcomeFailSigning      = "fail_signing"
+	OutcomeFailSubmission   = "fail_submission"
+	OutcomeCanceled         = "canceled"
+	OutcomeAlreadySigned    = "already_signed"
+	OutcomeFailStoreTimeout = "fail_timeout"
+	OutcomeFailStoreError   = "fail_error"
+	// A jank is reported by how long the UI stopped responding only.
+	OutcomeJankSlow   = "slow"
+	OutcomeJankFrozen = "frozen"
+)
+
+// Certificate store kinds reported with scan events.
+const (
+	StoreOS     = "os"
+	StoreNSS    = "nss"
+	StorePKCS12 = "pkcs12"
+)
+
+var (
+	allowedNames    = map[string]bool{EventAppStart: true, EventScan: true, EventSign: true, EventJank: true}
+	allowedOutcomes = map[string]bool{
+		"": true, OutcomeSuccess: true, OutcomeEmpty: true,
+		OutcomeFailCertificate: true, OutcomeFailDocument: true, OutcomeFailUnlock: true, OutcomeFailPreSign: true,
+		OutcomeFailSigning: true, OutcomeFailSubmission: true, OutcomeCanceled: true,
+		OutcomeAlreadySigned: true, OutcomeFailStoreTimeout: true, OutcomeFailStoreError: true,
+		OutcomeJankSlow: true, OutcomeJankFrozen: true,
+	}
+	allowedStores = map[string]bool{"": true, StoreOS: true, StoreNSS: true, StorePKCS12: true}
+)
+
+// ErrInvalidEvent is returned for events outside the enumerated vocabulary.
+var ErrInvalidEvent = errors.New("invalid telemetry event")
+
+// Event is the complete payload sent for a single occurrence.
+type Event struct {
+	App     string `json:"app"`
+	Version string `json:"version"`
+	OS      string `json:"os"`
+	Arch    string `json:"arch"`
+	Name    string `json:"event"`
+	Outcome string `json:"outcome,omitempty"`
+	Store   string `json:"store,omitempty"`
+}
+
+// Validate checks that the event only uses enumerated values.
+func (e Event) Validate() error {
+	if !allowedNames[e.Name] {
+		return fmt.Errorf("%w: unknown name %q", ErrInvalidEvent, e.Name)
+	}
+	if !allowedOutcomes[e.Outcome] {
+		return fmt.Errorf("%w: unknown outcome %q", ErrInvalidEvent, e.Outcome)
+	}
+	if !allowedStores[e.Store] {
+		return fmt.Errorf("%w: unknown store %q", ErrInvalidEvent, e.Store)
+	}
+	return nil
+}
+
+// Client reports events when the user has opted in.
+type Client struct {
+	endpoint string
+	version  string
+	enabled  func() bool
+	http     *http.Client
+}
+
+// New returns a client posting to endpoint. enabled is consulted on every
+// event so toggling the setting takes effect immediately.
+func New(endpoint, version string, enabled func() bool) *Client {
+	if v := os.Getenv("VOCSIGN_TELEMETRY_URL"); v != "" {
+		endpoint = v
+	}
+	return &Client{
+		endpoint: endpoint,
+		version:  version,
+		enabled:  enabled,
+		http:     &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Record sends an event in the background. It is a no-op unless the user
+// opted in, and failures are only logged.
+func (c *Client) Record(name, outcome, store string) {
+	if c == nil || !c.enabled() {
+		return
+	}
+	ev := c.event(name, outcome, store)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := c.Send(ctx, ev); err != nil {
+			log.Printf("DEBUG: telemetry event %s not sent: %v", name, err)
+		}
+	}()
+}
+
+func (c *Client) event(name, outcome, store string) Event {
+	return Event{
+		App:     "vocsign",
+		Version: c.version,
+		OS:      runtime.GOOS,
+		Arch:    runtime.GOARCH,
+		Name:    name,
+		Outcome: outcome,
+		Store:   store,
+	}
+}
+
+// Send posts a single event synchronously.
+func (c *Client) Send(ctx context.Context, ev Event) error {
+	if err := ev.Validate(); err != nil {
+		return err
+	}
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("send event: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("telemetry endpoint returned %s", resp.Status)
+	}
+	return nil
+}
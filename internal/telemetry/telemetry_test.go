@@ -0,0 +1,79 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEventValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		ev      Event
+		wantErr bool
+	}{
+		{"app start", Event{Name: EventAppStart}, false},
+		{"sign success", Event{Name: EventSign, Outcome: OutcomeSuccess}, false},
+		{"scan nss", Event{Name: EventScan, Outcome: OutcomeEmpty, Store: StoreNSS}, false},
+		{"ui jank", Event{Name: EventJank, Outcome: OutcomeJankFrozen}, false},
+		{"unknown name", Event{Name: "signer_dni"}, true},
+		{"free-form outcome", Event{Name: EventSign, Outcome: "fail: 12345678Z"}, true},
+		{"unknown store", Event{Name: EventScan, Store: "/home/user/cert.p12"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.ev.Validate()
+			if tt.wantErr && !errors.Is(err, ErrInvalidEvent) {
+				t.Fatalf("expected ErrInvalidEvent, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestSend(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "1.2.3", func() bool { return true })
+	if err := c.Send(context.Background(), c.event(EventSign, OutcomeFailUnlock, "")); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if got["event"] != EventSign || got["outcome"] != OutcomeFailUnlock || got["version"] != "1.2.3" {
+		t.Fatalf("unexpected payload: %v", got)
+	}
+	allowed := map[string]bool{"app": true, "version": true, "os": true, "arch": true, "event": true, "outcome": true}
+	for k := range got {
+		if !allowed[k] {
+			t.Errorf("unexpected field %q in payload", k)
+		}
+	}
+}
+
+func TestRecordDisabled(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "1.2.3", func() bool { return false })
+	c.Record(EventAppStart, "", "")
+	time.Sleep(50 * time.Millisecond)
+	if hits.Load() != 0 {
+		t.Fatalf("expected no requests while disabled, got %d", hits.Load())
+	}
+}
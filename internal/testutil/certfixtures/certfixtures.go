@@ -0,0 +1,271 @@
+// Package certfixtures generates test certificates and PKCS#12 files on the
+// fly, so tests need neither openssl nor checked-in key material. It covers
+// the shapes VocSign meets in the wild: FNMT and idCAT citizen subjects,
+// company representatives, RSA and ECDSA keys, expired certificates and
+// legacy BER-encoded PKCS#12 exports.
+package certfixtures
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// KeyType is the algorithm of a generated key.
+type KeyType int
+
+const (
+	RSA   KeyType = iota // RSA 2048
+	ECDSA                // ECDSA P-256
+)
+
+var (
+	oidSerialNumber           = asn1.ObjectIdentifier{2, 5, 4, 5}
+	oidCountry                = asn1.ObjectIdentifier{2, 5, 4, 6}
+	oidOrganization           = asn1.ObjectIdentifier{2, 5, 4, 10}
+	oidDescription            = asn1.ObjectIdentifier{2, 5, 4, 13}
+	oidCommonName             = asn1.ObjectIdentifier{2, 5, 4, 3}
+	oidGivenName              = asn1.ObjectIdentifier{2, 5, 4, 42}
+	oidSurname                = asn1.ObjectIdentifier{2, 5, 4, 4}
+	oidOrganizationIdentifier = asn1.ObjectIdentifier{2, 5, 4, 97}
+
+	oidSubjectDirectoryAttributes = asn1.ObjectIdentifier{2, 5, 29, 9}
+	oidDateOfBirth                = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 9, 1}
+)
+
+// Person is the holder of a citizen or representative certificate.
+type Person struct {
+	GivenName string
+	Surname1  string
+	Surname2  string
+	ID        string // DNI or NIE
+}
+
+// DefaultPerson is the holder used when Options.Subject is empty.
+var DefaultPerson = Person{GivenName: "JUAN", Surname1: "PEREZ", Surname2: "GARCIA", ID: "12345678Z"}
+
+func (p Person) surnames() string {
+	return strings.TrimSpace(p.Surname1 + " " + p.Surname2)
+}
+
+func attr(oid asn1.ObjectIdentifier, value string) pkix.AttributeTypeAndValue {
+	return pkix.AttributeTypeAndValue{Type: oid, Value: value}
+}
+
+// CitizenSubject is the subject of an FNMT "persona física" certificate.
+func CitizenSubject(p Person) pkix.Name {
+	return pkix.Name{ExtraNames: []pkix.AttributeTypeAndValue{
+		attr(oidCountry, "ES"),
+		attr(oidSerialNumber, "IDCES-"+p.ID),
+		attr(oidGivenName, p.GivenName),
+		attr(oidSurname, p.surnames()),
+		attr(oidCommonName, p.surnames()+" "+p.GivenName+" - "+p.ID),
+	}}
+}
+
+// IDCatSubject is the subject of an idCAT (EC-Ciutadania) certificate:
+// serialNumber, GN, SN and CN, without a country.
+func IDCatSubject(p Person) pkix.Name {
+	return pkix.Name{ExtraNames: []pkix.AttributeTypeAndValue{
+		attr(oidSerialNumber, "IDCES-"+p.ID),
+		attr(oidGivenName, p.GivenName),
+		attr(oidSurname, p.surnames()),
+		attr(oidCommonName, p.GivenName+" "+p.surnames()+" - DNI "+p.ID),
+	}}
+}
+
+// RepresentativeSubject is the subject of an FNMT certificate for p acting
+// on behalf of the company org with tax ID cif.
+func RepresentativeSubject(p Person, org, cif string) pkix.Name {
+	return pkix.Name{ExtraNames: []pkix.AttributeTypeAndValue{
+		attr(oidCountry, "ES"),
+		attr(oidOrganization, org),
+		attr(oidOrganizationIdentifier, "VATES-"+cif),
+		attr(oidDescription, "Ref:AEAT/AEAT0000/PUESTO 1/00000/01012026000000"),
+		attr(oidSerialNumber, "IDCES-"+p.ID),
+		attr(oidGivenName, p.GivenName),
+		attr(oidSurname, p.surnames()),
+		attr(oidCommonName, fmt.Sprintf("%s %s %s (R: %s)", p.ID, p.GivenName, p.surnames(), cif)),
+	}}
+}
+
+// Options describe a certificate to issue. The zero value is a valid RSA
+// citizen certificate for DefaultPerson.
+type Options struct {
+	Key     KeyType
+	Subject pkix.Name // CitizenSubject(DefaultPerson) if empty
+	// NotBefore and NotAfter default to an hour ago and a year from now.
+	NotBefore, NotAfter time.Time
+	// Expired sets a validity period that ended a year ago.
+	Expired     bool
+	KeyUsage    x509.KeyUsage      // digitalSignature and nonRepudiation if zero
+	ExtKeyUsage []x509.ExtKeyUsage // clientAuth and emailProtection if nil
+	// BirthDate, as YYYY-MM-DD, is added as a Subject Directory Attributes
+	// dateOfBirth, as idCAT does.
+	BirthDate string
+	// CRLDistributionPoints and OCSPServer are copied to the certificate.
+	CRLDistributionPoints []string
+	OCSPServer            []string
+}
+
+// Identity is a private key with its certificate and the chain above it.
+type Identity struct {
+	Key   crypto.Signer
+	Cert  *x509.Certificate
+	Chain []*x509.Certificate
+}
+
+// CA is a certificate authority that issues test certificates.
+type CA struct {
+	Cert *x509.Certificate
+	Key  crypto.Signer
+}
+
+var serial atomic.Int64
+
+func nextSerial() *big.Int {
+	return big.NewInt(serial.Add(1) + 1000)
+}
+
+func newKey(t testing.TB, kt KeyType) crypto.Signer {
+	t.Helper()
+	var key crypto.Signer
+	var err error
+	switch kt {
+	case ECDSA:
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	}
+	if err != nil {
+		t.Fatalf("certfixtures: failed to generate key: %v", err)
+	}
+	return key
+}
+
+// NewCA creates a self-signed RSA root named name.
+func NewCA(t testing.TB, name string) *CA {
+	t.Helper()
+	key := newKey(t, RSA)
+	tmpl := &x509.Certificate{
+		SerialNumber:          nextSerial(),
+		Subject:               pkix.Name{Country: []string{"ES"}, Organization: []string{"VocSign test"}, CommonName: name},
+		NotBefore:             time.Now().Add(-24 * time.Hour),
+		NotAfter:              time.Now().Add(10 * 365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatalf("certfixtures: failed to create CA: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("certfixtures: failed to parse CA: %v", err)
+	}
+	return &CA{Cert: cert, Key: key}
+}
+
+// New issues a certificate described by opts from a fresh CA.
+func New(t testing.TB, opts Options) *Identity {
+	t.Helper()
+	return NewCA(t, "VocSign Test Root CA").Issue(t, opts)
+}
+
+// Issue issues a certificate described by opts.
+func (ca *CA) Issue(t testing.TB, opts Options) *Identity {
+	t.Helper()
+	key := newKey(t, opts.Key)
+
+	subject := opts.Subject
+	if subject.String() == "" {
+		subject = CitizenSubject(DefaultPerson)
+	}
+	notBefore, notAfter := opts.NotBefore, opts.NotAfter
+	if notBefore.IsZero() {
+		notBefore = time.Now().Add(-time.Hour)
+	}
+	if notAfter.IsZero() {
+		notAfter = time.Now().Add(365 * 24 * time.Hour)
+	}
+	if opts.Expired {
+		notBefore = time.Now().Add(-2 * 365 * 24 * time.Hour)
+		notAfter = time.Now().Add(-365 * 24 * time.Hour)
+	}
+	ku := opts.KeyUsage
+	if ku == 0 {
+		ku = x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment
+	}
+	eku := opts.ExtKeyUsage
+	if eku == nil {
+		eku = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageEmailProtection}
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          nextSerial(),
+		Subject:               subject,
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              ku,
+		ExtKeyUsage:           eku,
+		CRLDistributionPoints: opts.CRLDistributionPoints,
+		OCSPServer:            opts.OCSPServer,
+		BasicConstraintsValid: true,
+	}
+	if opts.BirthDate != "" {
+		ext, err := dateOfBirthExtension(opts.BirthDate)
+		if err != nil {
+			t.Fatalf("certfixtures: %v", err)
+		}
+		tmpl.ExtraExtensions = append(tmpl.ExtraExtensions, ext)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.Cert, key.Public(), ca.Key)
+	if err != nil {
+		t.Fatalf("certfixtures: failed to issue certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("certfixtures: failed to parse certificate: %v", err)
+	}
+	return &Identity{Key: key, Cert: cert, Chain: []*x509.Certificate{ca.Cert}}
+}
+
+// dateOfBirthExtension encodes date (YYYY-MM-DD) as a Subject Directory
+// Attributes extension with a dateOfBirth attribute.
+func dateOfBirthExtension(date string) (pkix.Extension, error) {
+	d, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return pkix.Extension{}, fmt.Errorf("invalid birth date %q: %w", date, err)
+	}
+	gt, err := asn1.MarshalWithParams(d, "generalized")
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+	type attribute struct {
+		Type   asn1.ObjectIdentifier
+		Values []asn1.RawValue `asn1:"set"`
+	}
+	value, err := asn1.Marshal([]attribute{{Type: oidDateOfBirth, Values: []asn1.RawValue{{FullBytes: gt}}}})
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+	return pkix.Extension{Id: oidSubjectDirectoryAttributes, Value: value}, nil
+}
+
+// CertPEM returns the certificate in PEM form.
+func (id *Identity) CertPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: id.Cert.Raw})
+}
@@ -0,0 +1,112 @@
+package certfixtures_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/certs"
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/pkcs12store"
+	"github.com/vocdoni/gofirma/vocsign/internal/testutil/certfixtures"
+	gopkcs12 "software.sslmate.com/src/go-pkcs12"
+)
+
+func TestPKCS12Formats(t *testing.T) {
+	rsaID := certfixtures.New(t, certfixtures.Options{})
+	ecID := certfixtures.New(t, certfixtures.Options{Key: certfixtures.ECDSA})
+
+	tests := []struct {
+		name     string
+		id       *certfixtures.Identity
+		encode   func(*certfixtures.Identity, testing.TB, string) []byte
+		password string
+		// strictDER is whether a DER-only decoder accepts the file as is.
+		strictDER bool
+	}{
+		{"modern RSA", rsaID, (*certfixtures.Identity).PKCS12, "password", true},
+		{"modern ECDSA", ecID, (*certfixtures.Identity).PKCS12, "password", true},
+		{"legacy RSA", rsaID, (*certfixtures.Identity).LegacyPKCS12, "password", true},
+		{"legacy without password", rsaID, (*certfixtures.Identity).LegacyPKCS12, "", true},
+		{"BER RSA", rsaID, (*certfixtures.Identity).BERPKCS12, "password", false},
+		{"BER ECDSA without password", ecID, (*certfixtures.Identity).BERPKCS12, "", false},
+		{"BER non-ASCII password", rsaID, (*certfixtures.Identity).BERPKCS12, "contrasenya·ñ", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.encode(tt.id, t, tt.password)
+
+			_, _, _, err := gopkcs12.DecodeChain(data, tt.password)
+			if (err == nil) != tt.strictDER {
+				t.Errorf("strict DER decode error = %v, want accepted: %v", err, tt.strictDER)
+			}
+
+			signer, cert, chain, err := pkcs12store.ParsePKCS12(bytes.NewReader(data), tt.password)
+			if err != nil {
+				t.Fatalf("ParsePKCS12 failed: %v", err)
+			}
+			if !cert.Equal(tt.id.Cert) {
+				t.Error("parsed certificate differs")
+			}
+			if len(chain) != 1 || !chain[0].Equal(tt.id.Chain[0]) {
+				t.Errorf("parsed chain has %d certificates, want the CA", len(chain))
+			}
+			if err := pkcs12store.VerifyKeyPair(signer, cert); err != nil {
+				t.Errorf("key does not match certificate: %v", err)
+			}
+		})
+	}
+}
+
+func TestSubjects(t *testing.T) {
+	ca := certfixtures.NewCA(t, "Test CA")
+	p := certfixtures.Person{GivenName: "ALBA", Surname1: "TESTER", Surname2: "DEMO", ID: "87654321X"}
+
+	tests := []struct {
+		name      string
+		opts      certfixtures.Options
+		wantNom   string
+		wantRep   bool
+		wantBirth string
+	}{
+		{
+			name:    "FNMT citizen",
+			opts:    certfixtures.Options{Subject: certfixtures.CitizenSubject(p)},
+			wantNom: "ALBA",
+		},
+		{
+			name:      "idCAT with birth date",
+			opts:      certfixtures.Options{Subject: certfixtures.IDCatSubject(p), BirthDate: "1990-05-15"},
+			wantNom:   "ALBA",
+			wantBirth: "1990-05-15",
+		},
+		{
+			name:    "representative",
+			opts:    certfixtures.Options{Subject: certfixtures.RepresentativeSubject(p, "EMPRESA DEMO SL", "B12345674")},
+			wantNom: "ALBA",
+			wantRep: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := certs.ExtractSpanishIdentity(ca.Issue(t, tt.opts).Cert)
+			if info.DNI != p.ID || info.Nom != tt.wantNom {
+				t.Errorf("DNI %q, Nom %q; want %q, %q", info.DNI, info.Nom, p.ID, tt.wantNom)
+			}
+			if info.IsRepresentative != tt.wantRep {
+				t.Errorf("IsRepresentative = %v, want %v", info.IsRepresentative, tt.wantRep)
+			}
+			if tt.wantRep && info.OrganizationID != "B12345674" {
+				t.Errorf("OrganizationID = %q", info.OrganizationID)
+			}
+			if info.BirthDate != tt.wantBirth {
+				t.Errorf("BirthDate = %q, want %q", info.BirthDate, tt.wantBirth)
+			}
+		})
+	}
+}
+
+func TestExpired(t *testing.T) {
+	id := certfixtures.New(t, certfixtures.Options{Expired: true})
+	if err := certs.ValidateForSigning(id.Cert, id.Chain); err == nil {
+		t.Fatal("expected an expired certificate to be rejected")
+	}
+}
@@ -0,0 +1,228 @@
+package certfixtures
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"testing"
+	"unicode/utf16"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// PKCS12 encodes id the way current browsers and OS key stores export it
+// (AES-256 and a SHA-256 MAC).
+func (id *Identity) PKCS12(t testing.TB, password string) []byte {
+	t.Helper()
+	return id.encode(t, pkcs12.Modern2023, password)
+}
+
+// LegacyPKCS12 encodes id with RC2/3DES and a SHA-1 MAC, as openssl
+// -legacy and older certificate authority tools do.
+func (id *Identity) LegacyPKCS12(t testing.TB, password string) []byte {
+	t.Helper()
+	return id.encode(t, pkcs12.LegacyRC2, password)
+}
+
+// BERPKCS12 encodes id like legacy idCAT exports: LegacyPKCS12 re-encoded
+// in BER with indefinite lengths and chunked OCTET STRINGs throughout,
+// including inside the authenticated safe, with the MAC computed over the
+// BER bytes. Strict DER decoders reject it, and normalizing it to DER
+// invalidates the MAC.
+func (id *Identity) BERPKCS12(t testing.TB, password string) []byte {
+	t.Helper()
+	ber, err := toBERPFX(id.encode(t, pkcs12.LegacyRC2, password), password)
+	if err != nil {
+		t.Fatalf("certfixtures: failed to BER-encode PKCS#12: %v", err)
+	}
+	return ber
+}
+
+func (id *Identity) encode(t testing.TB, enc *pkcs12.Encoder, password string) []byte {
+	t.Helper()
+	data, err := enc.Encode(id.Key, id.Cert, id.Chain, password)
+	if err != nil {
+		t.Fatalf("certfixtures: failed to encode PKCS#12: %v", err)
+	}
+	return data
+}
+
+type pfx struct {
+	Version  int
+	AuthSafe contentInfo
+	MacData  macData
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"tag:0,explicit"`
+}
+
+type macData struct {
+	Mac struct {
+		Algorithm pkix.AlgorithmIdentifier
+		Digest    []byte
+	}
+	MacSalt    []byte
+	Iterations int `asn1:"optional,default:1"`
+}
+
+// berChunk is the size of the pieces OCTET STRINGs are split into.
+const berChunk = 512
+
+// toBERPFX re-encodes a DER PFX with a SHA-1 MAC as BER.
+func toBERPFX(der []byte, password string) ([]byte, error) {
+	var p pfx
+	if _, err := asn1.Unmarshal(der, &p); err != nil {
+		return nil, err
+	}
+	var authSafe []byte
+	if _, err := asn1.Unmarshal(p.AuthSafe.Content.Bytes, &authSafe); err != nil {
+		return nil, err
+	}
+	berAuthSafe, err := toBER(authSafe)
+	if err != nil {
+		return nil, err
+	}
+
+	pw, err := bmpString(password)
+	if err != nil {
+		return nil, err
+	}
+	p.MacData.Mac.Digest = macSHA1(berAuthSafe, p.MacData.MacSalt, pw, max(p.MacData.Iterations, 1))
+	version, err := asn1.Marshal(p.Version)
+	if err != nil {
+		return nil, err
+	}
+	oid, err := asn1.Marshal(p.AuthSafe.ContentType)
+	if err != nil {
+		return nil, err
+	}
+	mac, err := asn1.Marshal(p.MacData)
+	if err != nil {
+		return nil, err
+	}
+
+	content := indefinite(0xA0, indefinite(0x24, chunked(berAuthSafe)))
+	return indefinite(0x30, version, indefinite(0x30, oid, content), mac), nil
+}
+
+// toBER re-encodes a sequence of DER elements with indefinite lengths for
+// every constructed value. OCTET STRINGs holding DER are converted too and
+// split into chunks, as are long [0] IMPLICIT OCTET STRINGs (encrypted
+// content).
+func toBER(der []byte) ([]byte, error) {
+	var out []byte
+	for len(der) > 0 {
+		var v asn1.RawValue
+		rest, err := asn1.Unmarshal(der, &v)
+		if err != nil {
+			return nil, err
+		}
+		der = rest
+		if v.FullBytes[0]&0x1F == 0x1F {
+			return nil, errors.New("multi-byte tags are not supported")
+		}
+		tag := v.FullBytes[0]
+
+		switch {
+		case v.IsCompound:
+			inner, err := toBER(v.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, indefinite(tag, inner)...)
+		case v.Class == asn1.ClassUniversal && v.Tag == asn1.TagOctetString && isDER(v.Bytes):
+			// Salts and key IDs can look like DER by chance; keep them as
+			// they are when their content does not parse.
+			inner, err := toBER(v.Bytes)
+			if err != nil {
+				out = append(out, v.FullBytes...)
+				break
+			}
+			out = append(out, indefinite(0x24, chunked(inner))...)
+		case v.Class == asn1.ClassContextSpecific && v.Tag == 0 && len(v.Bytes) > berChunk:
+			out = append(out, indefinite(0xA0, chunked(v.Bytes))...)
+		default:
+			out = append(out, v.FullBytes...)
+		}
+	}
+	return out, nil
+}
+
+// isDER reports whether b is exactly one constructed DER element.
+func isDER(b []byte) bool {
+	var v asn1.RawValue
+	rest, err := asn1.Unmarshal(b, &v)
+	return err == nil && len(rest) == 0 && v.IsCompound
+}
+
+// indefinite encodes parts as the content of a constructed element with an
+// indefinite length.
+func indefinite(tag byte, parts ...[]byte) []byte {
+	out := []byte{tag, 0x80}
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return append(out, 0x00, 0x00)
+}
+
+// chunked splits b into primitive OCTET STRINGs of at most berChunk bytes.
+func chunked(b []byte) []byte {
+	var out []byte
+	for len(b) > 0 {
+		n := min(len(b), berChunk)
+		chunk, _ := asn1.Marshal(b[:n])
+		out = append(out, chunk...)
+		b = b[n:]
+	}
+	return out
+}
+
+// macSHA1 computes a PKCS#12 HMAC-SHA1 over message (RFC 7292, B.2). With
+// a 20-byte key the KDF needs a single hash block.
+func macSHA1(message, salt, password []byte, iterations int) []byte {
+	const v = 64
+	fill := func(src []byte) []byte {
+		if len(src) == 0 {
+			return nil
+		}
+		out := make([]byte, v*((len(src)+v-1)/v))
+		for i := range out {
+			out[i] = src[i%len(src)]
+		}
+		return out
+	}
+	d := make([]byte, v)
+	for i := range d {
+		d[i] = 3 // MAC key
+	}
+	h := sha1.New()
+	h.Write(d)
+	h.Write(fill(salt))
+	h.Write(fill(password))
+	key := h.Sum(nil)
+	for range iterations - 1 {
+		sum := sha1.Sum(key)
+		key = sum[:]
+	}
+	m := hmac.New(sha1.New, key)
+	m.Write(message)
+	return m.Sum(nil)
+}
+
+// bmpString encodes s as a NUL-terminated big-endian UTF-16 password.
+func bmpString(s string) ([]byte, error) {
+	for _, r := range s {
+		if r > 0xFFFF {
+			return nil, errors.New("password contains a character outside the BMP")
+		}
+	}
+	var out []byte
+	for _, r := range utf16.Encode([]rune(s)) {
+		out = append(out, byte(r>>8), byte(r))
+	}
+	return append(out, 0, 0), nil
+}
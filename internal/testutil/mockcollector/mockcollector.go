@@ -0,0 +1,432 @@
+// Package mockcollector runs an in-process HTTPS collector for end-to-end
+// tests of the client's fetch, verify and submit flows. It serves one sign
+// request signed by its own organizer key, the key set, the proposal text,
+// the callback and the receipt lookup, and can be told to misbehave like a real collector under
+// load or attack.
+//
+// New makes the default HTTP transport trust the collector's certificate
+// for the duration of the test, so the client packages reach it unchanged.
+// Tests that use it must not run in parallel.
+package mockcollector
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vocdoni/gofirma/vocsign/internal/canon"
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/jwsverify"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+	"github.com/vocdoni/gofirma/vocsign/internal/net"
+	"github.com/vocdoni/gofirma/vocsign/pkg/cadesverify"
+)
+
+const (
+	// RequestID is the ID of the request the collector serves.
+	RequestID = "MOCK-ILP-1"
+	// KID is the organizer key ID in the served request and key set.
+	KID = "mock-key-1"
+	// Document is the proposal text served at the request's fullText URL.
+	Document = "Proposició de llei de prova.\n"
+	// CodeNonceReplayed is the error code of a submission rejected because
+	// its nonce was already used.
+	CodeNonceReplayed = "ERR_NONCE_REPLAYED"
+)
+
+// Behavior sets how the collector misbehaves. The zero value is a
+// well-behaved collector.
+type Behavior struct {
+	// Delay is added before every response, or until the client gives up.
+	Delay time.Duration
+	// InvalidJWS serves the request signed with a key that is not in the
+	// key set, so organizer verification fails.
+	InvalidJWS bool
+	// ReplayedNonce serves the request with a nonce the collector already
+	// used, as a replayed or stale cached request would carry. Every
+	// submission is then rejected with HTTP 409 and CodeNonceReplayed.
+	ReplayedNonce bool
+	// FailSubmits answers the first FailSubmits submissions with HTTP 500.
+	FailSubmits int
+	// DropReceipts closes the connection instead of answering the first
+	// DropReceipts valid submissions, which are accepted all the same, as
+	// if their receipts were lost on the way back.
+	DropReceipts int
+}
+
+// Collector is a running mock collector.
+type Collector struct {
+	// URL is the base URL of the server, e.g. https://127.0.0.1:34567.
+	URL string
+
+	srv         *httptest.Server
+	key         *ecdsa.PrivateKey
+	nonce       string
+	replayNonce string
+
+	mu        sync.Mutex
+	behavior  Behavior
+	attempts  int
+	dropped   int
+	accepted  []model.SignResponse
+	usedNonce map[string]bool
+	// receipts are the receipts of accepted submissions by idempotency
+	// key.
+	receipts map[string]model.SubmitReceipt
+}
+
+// New starts a collector with behavior b. It is stopped, and the default
+// transport restored, when the test ends.
+func New(t testing.TB, b Behavior) *Collector {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("mockcollector: failed to generate organizer key: %v", err)
+	}
+	c := &Collector{
+		key:         key,
+		nonce:       randomNonce(t),
+		replayNonce: randomNonce(t),
+		behavior:    b,
+		receipts:    make(map[string]model.SubmitReceipt),
+	}
+	c.usedNonce = map[string]bool{c.replayNonce: true}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /request/{id}", c.handleRequest)
+	mux.HandleFunc("GET /jwks.json", c.handleJWKS)
+	mux.HandleFunc("GET /document", c.handleDocument)
+	mux.HandleFunc("POST /callback/{id}", c.handleCallback)
+	mux.HandleFunc("HEAD /callback/{id}", c.handleCallbackLookup)
+	mux.HandleFunc("GET /receipts", c.handleReceiptLookup)
+	c.srv = httptest.NewTLSServer(c.delayed(mux))
+	c.URL = c.srv.URL
+	t.Cleanup(c.srv.Close)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(c.srv.Certificate())
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig.RootCAs = pool
+	prev := http.DefaultTransport
+	http.DefaultTransport = tr
+	t.Cleanup(func() { http.DefaultTransport = prev })
+	return c
+}
+
+func randomNonce(t testing.TB) string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatalf("mockcollector: failed to generate nonce: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// RequestURL is the URL the request is served at.
+func (c *Collector) RequestURL() string {
+	return c.URL + "/request/" + RequestID
+}
+
+// SetBehavior changes how the collector behaves from the next request on.
+func (c *Collector) SetBehavior(b Behavior) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.behavior = b
+}
+
+// Attempts returns how many submissions the collector received, including
+// rejected ones.
+func (c *Collector) Attempts() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.attempts
+}
+
+// Accepted returns the signatures the collector accepted.
+func (c *Collector) Accepted() []model.SignResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]model.SignResponse(nil), c.accepted...)
+}
+
+// Request returns the request as it is currently served, signed.
+func (c *Collector) Request() (*model.SignRequest, error) {
+	c.mu.Lock()
+	b := c.behavior
+	c.mu.Unlock()
+
+	nonce := c.nonce
+	if b.ReplayedNonce {
+		nonce = c.replayNonce
+	}
+	docHash := sha256.Sum256([]byte(Document))
+	now := time.Now().UTC()
+	req := &model.SignRequest{
+		Version:   "1.0",
+		RequestID: RequestID,
+		IssuedAt:  now.Add(-time.Minute).Format(time.RFC3339),
+		ExpiresAt: now.Add(24 * time.Hour).Format(time.RFC3339),
+		Nonce:     nonce,
+		Proposal: model.Proposal{
+			Title:          "Mock popular initiative",
+			Promoter:       "Mock promoters",
+			Jurisdiction:   "Catalunya",
+			Summary:        "A request served by the mock collector.",
+			LegalStatement: "I support this proposal.",
+			FullText: model.FullText{
+				URL:    c.URL + "/document",
+				SHA256: base64.StdEncoding.EncodeToString(docHash[:]),
+			},
+		},
+		Callback: model.Callback{
+			URL:    c.URL + "/callback/" + RequestID,
+			Method: "POST",
+		},
+		ReceiptLookup: &model.ReceiptLookup{URL: c.URL + "/receipts"},
+		Organizer: model.Organizer{
+			KID:       KID,
+			JWKSetURL: c.URL + "/jwks.json",
+		},
+	}
+
+	key := c.key
+	if b.InvalidJWS {
+		var err error
+		if key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+			return nil, err
+		}
+	}
+	if err := sign(req, key); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// sign sets req's organizer signature to an ES256 JWS over its canonical
+// form.
+func sign(req *model.SignRequest, key *ecdsa.PrivateKey) error {
+	req.OrganizerSignature = nil
+	payload, err := canon.Encode(*req)
+	if err != nil {
+		return fmt.Errorf("failed to canonicalize request: %w", err)
+	}
+	header, err := json.Marshal(map[string]string{"alg": "ES256", "kid": KID})
+	if err != nil {
+		return err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	req.OrganizerSignature = &model.OrganizerSignature{
+		Format: "JWS",
+		Value:  signingInput + "." + base64.RawURLEncoding.EncodeToString(sig),
+	}
+	return nil
+}
+
+// delayed holds every response for the configured delay.
+func (c *Collector) delayed(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.mu.Lock()
+		d := c.behavior.Delay
+		c.mu.Unlock()
+		if d > 0 {
+			select {
+			case <-time.After(d):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (c *Collector) handleRequest(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("id") != RequestID {
+		http.Error(w, "Proposal not found", http.StatusNotFound)
+		return
+	}
+	req, err := c.Request()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(req)
+}
+
+func (c *Collector) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	raw, err := c.key.PublicKey.ECDH()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	point := raw.Bytes() // 0x04 || X || Y
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(jwsverify.JWKS{Keys: []jwsverify.JWK{{
+		KID: KID, KTY: "EC", CRV: "P-256", ALG: "ES256", USE: "sig",
+		X: base64.RawURLEncoding.EncodeToString(point[1:33]),
+		Y: base64.RawURLEncoding.EncodeToString(point[33:]),
+	}}})
+}
+
+func (c *Collector) handleDocument(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte(Document))
+}
+
+// handleCallback verifies a submitted signature like a real collector and
+// answers with a receipt or, depending on the behavior, an error. A
+// signature submitted again is answered with the receipt it got the first
+// time, and not accepted twice.
+func (c *Collector) handleCallback(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	c.attempts++
+	fail := c.attempts <= c.behavior.FailSubmits
+	c.mu.Unlock()
+	if fail {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if r.PathValue("id") != RequestID {
+		http.Error(w, "Proposal not found", http.StatusNotFound)
+		return
+	}
+
+	var resp model.SignResponse
+	if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if err := verify(&resp); err != nil {
+		http.Error(w, "Verification failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := submissionKey(&resp)
+	if receipt, ok := c.receipts[key]; ok {
+		c.writeReceipt(w, receipt)
+		return
+	}
+	if c.usedNonce[resp.Nonce] {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(model.SubmitError{
+			Status:  "error",
+			Code:    CodeNonceReplayed,
+			Message: "This request nonce was already used.",
+		})
+		return
+	}
+	receipt := model.SubmitReceipt{
+		Status:     "ok",
+		ReceiptID:  uuid.New().String(),
+		ReceivedAt: time.Now().Format(time.RFC3339),
+	}
+	c.accepted = append(c.accepted, resp)
+	c.receipts[key] = receipt
+	c.writeReceipt(w, receipt)
+}
+
+// writeReceipt answers a submission with receipt, or drops the connection
+// if the behavior says so. c.mu must be held.
+func (c *Collector) writeReceipt(w http.ResponseWriter, receipt model.SubmitReceipt) {
+	if c.dropped < c.behavior.DropReceipts {
+		c.dropped++
+		if hj, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hj.Hijack(); err == nil {
+				_ = conn.Close()
+				return
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(receipt)
+}
+
+// handleCallbackLookup answers a HEAD of the callback url with the receipt
+// ID of the submission whose idempotency key is in the Idempotency-Key
+// header, or 404.
+func (c *Collector) handleCallbackLookup(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("id") != RequestID {
+		http.NotFound(w, r)
+		return
+	}
+	c.mu.Lock()
+	receipt, ok := c.receipts[r.Header.Get(net.IdempotencyKeyHeader)]
+	c.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set(net.ReceiptIDHeader, receipt.ReceiptID)
+}
+
+// handleReceiptLookup answers GET /receipts?requestId=...&key=... with the
+// receipt of the submission with that idempotency key, or 404.
+func (c *Collector) handleReceiptLookup(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("requestId") != RequestID {
+		http.NotFound(w, r)
+		return
+	}
+	c.mu.Lock()
+	receipt, ok := c.receipts[q.Get("key")]
+	c.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(receipt)
+}
+
+// submissionKey is the idempotency key of resp, computed from its
+// signature rather than taken from the request header so a client cannot
+// claim another submission's receipt.
+func submissionKey(resp *model.SignResponse) string {
+	der, _ := base64.StdEncoding.DecodeString(resp.SignatureDerBase64)
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
+
+// verify checks the CAdES signature of resp over its signer XML.
+func verify(resp *model.SignResponse) error {
+	if resp.RequestID != RequestID {
+		return fmt.Errorf("requestId %q", resp.RequestID)
+	}
+	sig, err := base64.StdEncoding.DecodeString(resp.SignatureDerBase64)
+	if err != nil {
+		return fmt.Errorf("signature encoding: %w", err)
+	}
+	xmlBytes, err := base64.StdEncoding.DecodeString(resp.SignerXMLBase64)
+	if err != nil {
+		return fmt.Errorf("signer XML encoding: %w", err)
+	}
+	if _, err := cadesverify.VerifyDetached(xmlBytes, sig, cadesverify.Options{}); err != nil {
+		return fmt.Errorf("signature: %w", err)
+	}
+	if !strings.Contains(string(xmlBytes), RequestID) {
+		return fmt.Errorf("signer XML is not for %s", RequestID)
+	}
+	return nil
+}
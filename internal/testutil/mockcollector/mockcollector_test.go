@@ -0,0 +1,199 @@
+package mockcollector
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/cades"
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/jwsverify"
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+	"github.com/vocdoni/gofirma/vocsign/internal/net"
+	"github.com/vocdoni/gofirma/vocsign/internal/testutil/certfixtures"
+)
+
+// signResponse signs req as a citizen with a throwaway certificate.
+func signResponse(t *testing.T, req *model.SignRequest) *model.SignResponse {
+	t.Helper()
+	id := certfixtures.New(t, certfixtures.Options{
+		Key:     certfixtures.ECDSA,
+		Subject: certfixtures.CitizenSubject(certfixtures.Person{GivenName: "MARIA", Surname1: "PUIG", ID: "12345678Z"}),
+	})
+	xmlBytes, err := model.GenerateILPXML(req, model.Signant{
+		Nom: "MARIA", Cognom1: "PUIG", DataNaixement: "1980-05-04", TipusIdentifica: "DNI", NumIdentifica: "12345678Z",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := cades.SignDetached(context.Background(), id.Key, id.Cert, nil, xmlBytes, cades.SignOpts{SigningTime: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(xmlBytes)
+	return &model.SignResponse{
+		Version:                "1.0",
+		RequestID:              req.RequestID,
+		Nonce:                  req.Nonce,
+		SignedAt:               time.Now().Format(time.RFC3339),
+		PayloadCanonicalSHA256: base64.StdEncoding.EncodeToString(sum[:]),
+		SignatureFormat:        "CAdES-detached",
+		SignatureDerBase64:     base64.StdEncoding.EncodeToString(sig),
+		SignerXMLBase64:        base64.StdEncoding.EncodeToString(xmlBytes),
+	}
+}
+
+func TestFlow(t *testing.T) {
+	tests := []struct {
+		name       string
+		behavior   Behavior
+		timeout    time.Duration
+		wantVerify errcode.Code // "" for success
+		wantSubmit errcode.Code // "" for success
+	}{
+		{
+			name: "well behaved",
+		},
+		{
+			name:       "invalid JWS",
+			behavior:   Behavior{InvalidJWS: true},
+			wantVerify: errcode.JWSSignatureInvalid,
+		},
+		{
+			name:       "replayed nonce",
+			behavior:   Behavior{ReplayedNonce: true},
+			wantSubmit: errcode.SubmitRejected,
+		},
+		{
+			name:       "server error",
+			behavior:   Behavior{FailSubmits: 1},
+			wantSubmit: errcode.SubmitRejected,
+		},
+		{
+			name:       "slow collector",
+			behavior:   Behavior{Delay: time.Second},
+			timeout:    100 * time.Millisecond,
+			wantSubmit: errcode.FetchTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(t, Behavior{InvalidJWS: tt.behavior.InvalidJWS, ReplayedNonce: tt.behavior.ReplayedNonce})
+
+			req, _, err := net.Fetch(context.Background(), c.RequestURL())
+			if err != nil {
+				t.Fatalf("Fetch failed: %v", err)
+			}
+			if err := req.Validate(); err != nil {
+				t.Fatalf("served request is invalid: %v", err)
+			}
+			if err := net.VerifyDocumentHash(context.Background(), req.Proposal.FullText.URL, req.Proposal.FullText.SHA256); err != nil {
+				t.Fatalf("VerifyDocumentHash failed: %v", err)
+			}
+			_, err = jwsverify.Verify(req)
+			if got := errcode.Of(err); got != tt.wantVerify {
+				t.Fatalf("Verify error code = %q (%v), want %q", got, err, tt.wantVerify)
+			}
+			if err != nil {
+				return
+			}
+
+			c.SetBehavior(tt.behavior)
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			receipt, err := net.Submit(ctx, req.Callback.URL, signResponse(t, req))
+			if got := errcode.Of(err); got != tt.wantSubmit {
+				t.Fatalf("Submit error code = %q (%v), want %q", got, err, tt.wantSubmit)
+			}
+			if err == nil && (receipt.Status != "ok" || receipt.ReceiptID == "") {
+				t.Errorf("unexpected receipt: %+v", receipt)
+			}
+			want := 0
+			if err == nil {
+				want = 1
+			}
+			if got := len(c.Accepted()); got != want {
+				t.Errorf("accepted %d signatures, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestRetryAfterServerErrors(t *testing.T) {
+	c := New(t, Behavior{FailSubmits: 2})
+	req, err := c.Request()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := signResponse(t, req)
+
+	var receipt *model.SubmitReceipt
+	for range 3 {
+		if receipt, err = net.Submit(context.Background(), req.Callback.URL, resp); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatalf("Submit failed after retries: %v", err)
+	}
+	if receipt.ReceiptID == "" {
+		t.Error("missing receipt ID")
+	}
+	if c.Attempts() != 3 || len(c.Accepted()) != 1 {
+		t.Errorf("attempts = %d, accepted = %d; want 3 and 1", c.Attempts(), len(c.Accepted()))
+	}
+}
+
+func TestLostReceipt(t *testing.T) {
+	// Receipts are dropped until the behavior is reset, including those of
+	// the retries the transport makes on its own.
+	c := New(t, Behavior{DropReceipts: 10})
+	req, _, err := net.Fetch(context.Background(), c.RequestURL())
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	resp := signResponse(t, req)
+	key := net.SubmissionKey(resp)
+
+	if _, err := net.Submit(context.Background(), req.Callback.URL, resp); err == nil {
+		t.Fatal("Submit succeeded although the receipt was dropped")
+	}
+	c.SetBehavior(Behavior{})
+
+	// Both lookups find the submission the collector accepted.
+	viaHead := *req
+	viaHead.ReceiptLookup = nil
+	for name, r := range map[string]*model.SignRequest{"lookup endpoint": req, "callback HEAD": &viaHead} {
+		receipt, err := net.LookupReceipt(context.Background(), r, key)
+		if err != nil || receipt == nil || receipt.ReceiptID == "" {
+			t.Fatalf("%s: LookupReceipt = %+v, %v", name, receipt, err)
+		}
+		other, err := net.LookupReceipt(context.Background(), r, net.SubmissionKey(signResponse(t, req)))
+		if err != nil || other != nil {
+			t.Fatalf("%s: LookupReceipt of an unsent signature = %+v, %v", name, other, err)
+		}
+	}
+
+	// Submitting again returns the same receipt and is not counted twice.
+	first, err := net.LookupReceipt(context.Background(), req, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := net.Submit(context.Background(), req.Callback.URL, resp)
+	if err != nil {
+		t.Fatalf("resubmit failed: %v", err)
+	}
+	if again.ReceiptID != first.ReceiptID {
+		t.Errorf("resubmit receipt = %q, want %q", again.ReceiptID, first.ReceiptID)
+	}
+	if n := len(c.Accepted()); n != 1 {
+		t.Errorf("accepted %d signatures, want 1", n)
+	}
+}
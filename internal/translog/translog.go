@@ -0,0 +1,179 @@
+// Package translog implements a small append-only, hash-chained public log of
+// issued sign requests. Organizers publish every (requestId, canonical hash,
+// issuance time) they serve; clients check that the request they fetched is
+// logged and that no other variant of the same requestId exists.
+package translog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/canon"
+)
+
+var (
+	ErrNotLogged = errors.New("request is not in the transparency log")
+	ErrVariant   = errors.New("transparency log contains a different variant of this request")
+	// ErrRewritten is returned by Extends for a log that does not contain
+	// an earlier checkpoint unchanged.
+	ErrRewritten = errors.New("transparency log was rewritten since it was last checked")
+)
+
+type Entry struct {
+	Index       int    `json:"index"`
+	RequestID   string `json:"requestId"`
+	RequestHash string `json:"requestHash"`
+	IssuedAt    string `json:"issuedAt"`
+	PrevHash    string `json:"prevHash"`
+	Hash        string `json:"hash"`
+}
+
+// Checkpoint identifies a log as seen at some point: its size and the hash
+// of its last entry. A client keeps the checkpoint of the last log it
+// verified, so a log that is later rewritten rather than appended to is
+// noticed.
+type Checkpoint struct {
+	Size int    `json:"size"`
+	Head string `json:"head"`
+}
+
+// Snapshot is the JSON document served by the log endpoint.
+type Snapshot struct {
+	Size    int     `json:"size"`
+	Head    string  `json:"head"`
+	Entries []Entry `json:"entries"`
+}
+
+func (e Entry) computeHash() (string, error) {
+	e.Hash = ""
+	b, err := canon.Encode(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Log is an in-memory append-only log, safe for concurrent use.
+type Log struct {
+	mu      sync.RWMutex
+	entries []Entry
+}
+
+func (l *Log) Append(requestID, requestHash, issuedAt string) (Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var prev *Entry
+	if n := len(l.entries); n > 0 {
+		prev = &l.entries[n-1]
+	}
+	e, err := Next(prev, requestID, requestHash, issuedAt)
+	if err != nil {
+		return Entry{}, err
+	}
+	l.entries = append(l.entries, e)
+	return e, nil
+}
+
+// Next returns the entry that follows prev, or the first entry of a log if
+// prev is nil. It lets logs kept outside this package, e.g. in a database
+// shared by several collectors, chain entries the same way Log does.
+func Next(prev *Entry, requestID, requestHash, issuedAt string) (Entry, error) {
+	e := Entry{
+		RequestID:   requestID,
+		RequestHash: requestHash,
+		IssuedAt:    issuedAt,
+	}
+	if prev != nil {
+		e.Index = prev.Index + 1
+		e.PrevHash = prev.Hash
+	}
+	h, err := e.computeHash()
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to hash log entry: %w", err)
+	}
+	e.Hash = h
+	return e, nil
+}
+
+func (l *Log) Snapshot() Snapshot {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	out := Snapshot{Size: len(l.entries), Entries: make([]Entry, len(l.entries))}
+	copy(out.Entries, l.entries)
+	if out.Size > 0 {
+		out.Head = l.entries[out.Size-1].Hash
+	}
+	return out
+}
+
+// Verify checks indices, the hash chain and that Head matches the last entry.
+func (s *Snapshot) Verify() error {
+	if s.Size != len(s.Entries) {
+		return fmt.Errorf("log size %d does not match %d entries", s.Size, len(s.Entries))
+	}
+	prev := ""
+	for i, e := range s.Entries {
+		if e.Index != i {
+			return fmt.Errorf("log entry %d has index %d", i, e.Index)
+		}
+		if e.PrevHash != prev {
+			return fmt.Errorf("log chain broken at entry %d", i)
+		}
+		h, err := e.computeHash()
+		if err != nil {
+			return fmt.Errorf("failed to hash log entry %d: %w", i, err)
+		}
+		if h != e.Hash {
+			return fmt.Errorf("log entry %d hash mismatch", i)
+		}
+		prev = e.Hash
+	}
+	if s.Head != prev {
+		return errors.New("log head does not match last entry")
+	}
+	return nil
+}
+
+// Checkpoint returns the checkpoint of s.
+func (s *Snapshot) Checkpoint() Checkpoint {
+	return Checkpoint{Size: s.Size, Head: s.Head}
+}
+
+// Extends checks that s, already verified, starts with the log c was taken
+// from: it is at least as long and its entry at c's head is c's head.
+func (s *Snapshot) Extends(c Checkpoint) error {
+	if c.Size == 0 {
+		return nil
+	}
+	if s.Size < c.Size || s.Entries[c.Size-1].Hash != c.Head {
+		return ErrRewritten
+	}
+	return nil
+}
+
+// CheckInclusion returns the log entry for requestID. It fails if the request
+// is absent or if any entry for requestID carries a different hash.
+func (s *Snapshot) CheckInclusion(requestID, requestHash string) (*Entry, error) {
+	var found *Entry
+	for i := range s.Entries {
+		e := &s.Entries[i]
+		if e.RequestID != requestID {
+			continue
+		}
+		if e.RequestHash != requestHash {
+			return nil, ErrVariant
+		}
+		if found == nil {
+			found = e
+		}
+	}
+	if found == nil {
+		return nil, ErrNotLogged
+	}
+	return found, nil
+}
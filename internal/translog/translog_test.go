@@ -0,0 +1,129 @@
+package translog
+
+import (
+	"errors"
+	"testing"
+)
+
+func buildLog(t *testing.T) *Log {
+	t.Helper()
+	var l Log
+	for _, e := range [][3]string{
+		{"req-1", "aaaa", "2026-01-01T00:00:00Z"},
+		{"req-2", "bbbb", "2026-01-02T00:00:00Z"},
+		{"req-3", "cccc", "2026-01-03T00:00:00Z"},
+	} {
+		if _, err := l.Append(e[0], e[1], e[2]); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	return &l
+}
+
+func TestSnapshotVerify(t *testing.T) {
+	snap := buildLog(t).Snapshot()
+	if err := snap.Verify(); err != nil {
+		t.Fatalf("Verify on untouched log: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(s *Snapshot)
+	}{
+		{"modified hash field", func(s *Snapshot) { s.Entries[1].RequestHash = "ffff" }},
+		{"dropped entry", func(s *Snapshot) { s.Entries = append(s.Entries[:1], s.Entries[2:]...); s.Size-- }},
+		{"reordered", func(s *Snapshot) { s.Entries[0], s.Entries[1] = s.Entries[1], s.Entries[0] }},
+		{"wrong head", func(s *Snapshot) { s.Head = s.Entries[0].Hash }},
+		{"size mismatch", func(s *Snapshot) { s.Size = 7 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := buildLog(t).Snapshot()
+			tt.tamper(&s)
+			if err := s.Verify(); err == nil {
+				t.Fatal("expected verification error")
+			}
+		})
+	}
+}
+
+func TestCheckInclusion(t *testing.T) {
+	l := buildLog(t)
+	snap := l.Snapshot()
+
+	e, err := snap.CheckInclusion("req-2", "bbbb")
+	if err != nil || e.Index != 1 {
+		t.Fatalf("CheckInclusion(req-2) = %v, %v", e, err)
+	}
+	if _, err := snap.CheckInclusion("req-9", "bbbb"); !errors.Is(err, ErrNotLogged) {
+		t.Fatalf("expected ErrNotLogged, got %v", err)
+	}
+	if _, err := snap.CheckInclusion("req-2", "dddd"); !errors.Is(err, ErrVariant) {
+		t.Fatalf("expected ErrVariant for wrong hash, got %v", err)
+	}
+
+	// A second, different variant logged for the same request is detected
+	// even when the client holds the first one.
+	if _, err := l.Append("req-2", "eeee", "2026-01-04T00:00:00Z"); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	snap = l.Snapshot()
+	if _, err := snap.CheckInclusion("req-2", "bbbb"); !errors.Is(err, ErrVariant) {
+		t.Fatalf("expected ErrVariant with two variants, got %v", err)
+	}
+}
+
+func TestNextMatchesAppend(t *testing.T) {
+	want := buildLog(t).Snapshot()
+	var prev *Entry
+	for i, w := range want.Entries {
+		e, err := Next(prev, w.RequestID, w.RequestHash, w.IssuedAt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e != w {
+			t.Fatalf("entry %d = %+v, want %+v", i, e, w)
+		}
+		prev = &e
+	}
+}
+
+func TestSnapshotExtends(t *testing.T) {
+	var l Log
+	for _, id := range []string{"a", "b"} {
+		if _, err := l.Append(id, "h-"+id, "2026-01-01T00:00:00Z"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := l.Snapshot()
+	pin := old.Checkpoint()
+	if _, err := l.Append("c", "h-c", "2026-01-02T00:00:00Z"); err != nil {
+		t.Fatal(err)
+	}
+	grown := l.Snapshot()
+	if err := grown.Extends(pin); err != nil {
+		t.Fatalf("appended log does not extend its checkpoint: %v", err)
+	}
+	if err := old.Extends(pin); err != nil {
+		t.Fatalf("log does not extend its own checkpoint: %v", err)
+	}
+	if err := grown.Extends(Checkpoint{}); err != nil {
+		t.Fatalf("Extends of the empty checkpoint: %v", err)
+	}
+
+	// A log rebuilt without an entry, or cut short, does not extend it.
+	var rebuilt Log
+	for _, id := range []string{"a", "c", "d"} {
+		if _, err := rebuilt.Append(id, "h-"+id, "2026-01-01T00:00:00Z"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	other := rebuilt.Snapshot()
+	if err := other.Extends(pin); !errors.Is(err, ErrRewritten) {
+		t.Fatalf("rewritten log Extends = %v", err)
+	}
+	short := Snapshot{}
+	if err := short.Extends(pin); !errors.Is(err, ErrRewritten) {
+		t.Fatalf("shorter log Extends = %v", err)
+	}
+}
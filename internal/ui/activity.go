@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"gioui.org/io/event"
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/app"
+)
+
+// activityWatcher tells the app when the pointer moves or clicks anywhere
+// in the window, or a key is pressed, which restarts the inactivity
+// auto-lock. Its area covers the window above everything else and passes
+// every event through. Keys are read by updateKeys after every widget has
+// read its own, so it only sees the ones no widget asked for, which
+// include the letters typed into an editor.
+type activityWatcher struct {
+	app *app.App
+}
+
+func (w *activityWatcher) update(gtx layout.Context) {
+	for {
+		if _, ok := gtx.Event(pointer.Filter{Target: w, Kinds: pointer.Move | pointer.Press}); !ok {
+			return
+		}
+		w.app.NoteActivity()
+	}
+}
+
+// updateKeys must be called at the end of the frame, after the widgets
+// have handled their events.
+func (w *activityWatcher) updateKeys(gtx layout.Context) {
+	for {
+		if _, ok := gtx.Event(key.Filter{Optional: key.ModShift}); !ok {
+			return
+		}
+		w.app.NoteActivity()
+	}
+}
+
+func (w *activityWatcher) layout(gtx layout.Context) {
+	defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+	defer pointer.PassOp{}.Push(gtx.Ops).Pop()
+	event.Op(gtx.Ops, w)
+}
@@ -1,8 +1,93 @@
 package assets
 
 import (
+	"bytes"
 	_ "embed"
+	"image"
+	_ "image/png"
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"gioui.org/layout"
+	"gioui.org/op/paint"
+	"gioui.org/widget"
 )
 
 //go:embed logo.png
 var LogoPNG []byte
+
+// Logo is the VocSign logo shown in the footer.
+var Logo = NewImage(LogoPNG, "logo.png")
+
+// Image is an embedded image decoded off the UI goroutine, so that the
+// first frame does not wait for it.
+type Image struct {
+	data []byte
+	name string
+	// size is read from the header, to keep the space for the image while
+	// it is decoded.
+	size image.Point
+	once sync.Once
+	op   atomic.Pointer[paint.ImageOp]
+	// failed is set when the image cannot be decoded; it then takes no
+	// space.
+	failed atomic.Bool
+}
+
+// NewImage returns an Image for the encoded data; name is used in logs.
+func NewImage(data []byte, name string) *Image {
+	im := &Image{data: data, name: name}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		log.Printf("ERROR: decoding %s: %v", name, err)
+		im.failed.Store(true)
+		return im
+	}
+	im.size = image.Pt(cfg.Width, cfg.Height)
+	return im
+}
+
+// Load starts decoding the image in the background, once, and calls
+// invalidate when it is ready.
+func (im *Image) Load(invalidate func()) {
+	im.once.Do(func() {
+		if im.failed.Load() {
+			return
+		}
+		go func() {
+			img, _, err := image.Decode(bytes.NewReader(im.data))
+			if err != nil {
+				log.Printf("ERROR: decoding %s: %v", im.name, err)
+				im.failed.Store(true)
+			} else {
+				op := paint.NewImageOp(img)
+				im.op.Store(&op)
+			}
+			if invalidate != nil {
+				invalidate()
+			}
+		}()
+	})
+}
+
+// Layout draws the image scaled to fit the constraints, keeping its aspect
+// ratio. Until it is decoded the space it will take is left empty.
+func (im *Image) Layout(gtx layout.Context) layout.Dimensions {
+	if im.failed.Load() {
+		return layout.Dimensions{}
+	}
+	if op := im.op.Load(); op != nil {
+		return widget.Image{Src: *op, Fit: widget.Contain}.Layout(gtx)
+	}
+	return layout.Dimensions{Size: containSize(im.size, gtx.Constraints)}
+}
+
+// containSize is the size widget.Contain scales an image of size sz to.
+func containSize(sz image.Point, cs layout.Constraints) image.Point {
+	if sz.X == 0 || sz.Y == 0 {
+		return cs.Min
+	}
+	scale := min(float32(cs.Max.X)/float32(sz.X), float32(cs.Max.Y)/float32(sz.Y))
+	return cs.Constrain(image.Pt(int(float32(sz.X)*scale), int(float32(sz.Y)*scale)))
+}
@@ -0,0 +1,37 @@
+package assets
+
+import (
+	"image"
+	"testing"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+)
+
+func TestImageLoad(t *testing.T) {
+	im := NewImage(LogoPNG, "logo.png")
+	if im.size.X == 0 || im.size.Y == 0 {
+		t.Fatalf("size = %v", im.size)
+	}
+	gtx := layout.Context{Ops: new(op.Ops), Constraints: layout.Constraints{Max: image.Pt(288, 84)}}
+	placeholder := im.Layout(gtx)
+
+	done := make(chan struct{})
+	im.Load(func() { close(done) })
+	<-done
+	if im.op.Load() == nil {
+		t.Fatal("image not decoded")
+	}
+	if got := im.Layout(gtx); got.Size != placeholder.Size {
+		t.Fatalf("decoded image takes %v, placeholder %v", got.Size, placeholder.Size)
+	}
+}
+
+func TestImageInvalid(t *testing.T) {
+	im := NewImage([]byte("not an image"), "broken.png")
+	im.Load(nil)
+	gtx := layout.Context{Ops: new(op.Ops), Constraints: layout.Constraints{Max: image.Pt(100, 100)}}
+	if dims := im.Layout(gtx); dims.Size != (image.Point{}) {
+		t.Fatalf("broken image takes %v", dims.Size)
+	}
+}
@@ -0,0 +1,138 @@
+package icons
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+)
+
+const (
+	// pageSize is the side of an atlas page in pixels; a page holds a few
+	// hundred icons at the sizes the UI uses.
+	pageSize = 512
+	// padding separates the icons on a page, so that filtering at their
+	// edges never samples a neighbour.
+	padding = 1
+	// maxEntries bounds the atlas; past it, such as when an animation draws
+	// an icon in many colors, it starts over.
+	maxEntries = 1024
+)
+
+// atlasKey is an icon rasterized at one size and color.
+type atlasKey struct {
+	icon  *Icon
+	size  image.Point
+	color color.NRGBA
+}
+
+// atlasEntry is where an icon is on a page.
+type atlasEntry struct {
+	page *atlasPage
+	rect image.Rectangle
+}
+
+// atlasPage is one texture holding many icons, packed in rows.
+type atlasPage struct {
+	img *image.RGBA
+	op  paint.ImageOp
+	// uploaded reports whether op was made from img. Gio reads img when it
+	// draws the frame, so it is copied before another icon is added.
+	uploaded bool
+	// x, y and rowH are the free position on the current row and its
+	// height.
+	x, y, rowH int
+}
+
+// atlas caches rasterized icons in pages, so that an icon shown at several
+// sizes or colors is rasterized and uploaded once rather than every frame.
+// It is used from the UI goroutine only.
+type atlas struct {
+	pageSize int
+	pages    []*atlasPage
+	entries  map[atlasKey]atlasEntry
+}
+
+func newAtlas(pageSize int) *atlas {
+	return &atlas{pageSize: pageSize, entries: make(map[atlasKey]atlasEntry)}
+}
+
+var iconAtlas = newAtlas(pageSize)
+
+// insert reserves space for k on a page, ready to rasterize into.
+func (a *atlas) insert(k atlasKey) atlasEntry {
+	if len(a.entries) >= maxEntries {
+		a.pages = nil
+		clear(a.entries)
+	}
+	w, h := k.size.X+padding, k.size.Y+padding
+	var p *atlasPage
+	if w > a.pageSize || h > a.pageSize {
+		// Too large to share a page; it gets one of its own that nothing
+		// else is packed into.
+		p = &atlasPage{img: image.NewRGBA(image.Rectangle{Max: k.size})}
+		a.pages = append([]*atlasPage{p}, a.pages...)
+		e := atlasEntry{page: p, rect: p.img.Rect}
+		a.entries[k] = e
+		return e
+	}
+	if n := len(a.pages); n > 0 {
+		p = a.pages[n-1]
+		if p.x+w > a.pageSize {
+			p.x, p.y, p.rowH = 0, p.y+p.rowH, 0
+		}
+		if p.y+h > a.pageSize {
+			p = nil
+		}
+	}
+	if p == nil {
+		p = &atlasPage{img: image.NewRGBA(image.Rect(0, 0, a.pageSize, a.pageSize))}
+		a.pages = append(a.pages, p)
+	}
+	if p.uploaded {
+		img := image.NewRGBA(p.img.Rect)
+		copy(img.Pix, p.img.Pix)
+		p.img, p.uploaded = img, false
+	}
+	e := atlasEntry{page: p, rect: image.Rectangle{Min: image.Pt(p.x, p.y), Max: image.Pt(p.x, p.y).Add(k.size)}}
+	p.x += w
+	p.rowH = max(p.rowH, h)
+	a.entries[k] = e
+	return e
+}
+
+// add draws the entry at the origin.
+func (e atlasEntry) add(ops *op.Ops) {
+	p := e.page
+	if !p.uploaded {
+		p.op = paint.NewImageOp(p.img)
+		p.uploaded = true
+	}
+	defer clip.Rect{Max: e.rect.Size()}.Push(ops).Pop()
+	defer op.Offset(e.rect.Min.Mul(-1)).Push(ops).Pop()
+	p.op.Add(ops)
+	paint.PaintOp{}.Add(ops)
+}
+
+// linearRGBA converts c to the premultiplied linear color IconVG draws
+// with, as widget.Icon does.
+func linearRGBA(c color.NRGBA) color.RGBA {
+	if c.A == 0xFF {
+		return color.RGBA(c)
+	}
+	a := float64(c.A) / 0xFF
+	conv := func(v uint8) uint8 {
+		return uint8(srgbToLinear(float64(v)/0xFF)*a*0xFF + .5)
+	}
+	return color.RGBA{R: conv(c.R), G: conv(c.G), B: conv(c.B), A: c.A}
+}
+
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
@@ -0,0 +1,110 @@
+package icons
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/unit"
+)
+
+func TestAtlasPacking(t *testing.T) {
+	a := newAtlas(64)
+	for i := range 12 {
+		e := a.insert(atlasKey{icon: &Icon{}, size: image.Pt(24, 24), color: color.NRGBA{A: uint8(i)}})
+		if !e.rect.In(e.page.img.Rect) {
+			t.Fatalf("entry %d at %v outside its page", i, e.rect)
+		}
+	}
+	// Two 24px icons and their padding fit in a row of a 64px page, and two
+	// rows in a page.
+	if len(a.pages) != 3 || len(a.entries) != 12 {
+		t.Fatalf("%d pages, %d entries", len(a.pages), len(a.entries))
+	}
+
+	big := a.insert(atlasKey{icon: &Icon{}, size: image.Pt(100, 100)})
+	if big.page.img.Rect.Size() != image.Pt(100, 100) || a.pages[len(a.pages)-1] == big.page {
+		t.Fatalf("large icon packed into a shared page: %v", big.page.img.Rect)
+	}
+}
+
+func TestAtlasNoOverlap(t *testing.T) {
+	a := newAtlas(pageSize)
+	type placed struct {
+		page *atlasPage
+		rect image.Rectangle
+	}
+	var all []placed
+	for i := range 200 {
+		sz := 16 + i%5*8
+		e := a.insert(atlasKey{icon: &Icon{}, size: image.Pt(sz, sz)})
+		for _, p := range all {
+			if p.page == e.page && p.rect.Overlaps(e.rect.Inset(-padding)) {
+				t.Fatalf("%v overlaps %v", e.rect, p.rect)
+			}
+		}
+		all = append(all, placed{e.page, e.rect})
+	}
+}
+
+func TestAtlasCopiesUploadedPage(t *testing.T) {
+	a := newAtlas(pageSize)
+	e := a.insert(atlasKey{icon: &Icon{}, size: image.Pt(8, 8)})
+	e.add(new(op.Ops))
+	drawn := e.page.img
+	a.insert(atlasKey{icon: &Icon{}, size: image.Pt(8, 8)})
+	if e.page.img == drawn || e.page.uploaded {
+		t.Fatal("page modified after it was handed to Gio")
+	}
+}
+
+func TestAtlasBounded(t *testing.T) {
+	a := newAtlas(pageSize)
+	for i := range maxEntries + 1 {
+		a.insert(atlasKey{icon: &Icon{}, size: image.Pt(4, 4), color: color.NRGBA{R: uint8(i), G: uint8(i >> 8)}})
+	}
+	if len(a.entries) != 1 || len(a.pages) != 1 {
+		t.Fatalf("%d entries in %d pages after the limit", len(a.entries), len(a.pages))
+	}
+}
+
+func TestIconLayoutCaches(t *testing.T) {
+	iconAtlas = newAtlas(pageSize)
+	gtx := layout.Context{
+		Ops:         new(op.Ops),
+		Metric:      unit.Metric{PxPerDp: 1, PxPerSp: 1},
+		Constraints: layout.Exact(image.Pt(24, 24)),
+	}
+	black := color.NRGBA{A: 0xFF}
+	white := color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+	for range 3 {
+		if dims := IconCheck.Layout(gtx, black); dims.Size != image.Pt(24, 24) {
+			t.Fatalf("Layout = %v", dims.Size)
+		}
+		IconCheck.Layout(gtx, white)
+	}
+	if len(iconAtlas.entries) != 2 {
+		t.Fatalf("%d atlas entries for two colors", len(iconAtlas.entries))
+	}
+	e := iconAtlas.entries[atlasKey{icon: IconCheck, size: image.Pt(24, 24), color: black}]
+	var painted bool
+	for y := e.rect.Min.Y; y < e.rect.Max.Y; y++ {
+		for x := e.rect.Min.X; x < e.rect.Max.X; x++ {
+			painted = painted || e.page.img.RGBAAt(x, y).A != 0
+		}
+	}
+	if !painted {
+		t.Fatal("icon not rasterized")
+	}
+}
+
+func TestLinearRGBA(t *testing.T) {
+	if got := linearRGBA(color.NRGBA{R: 0x12, G: 0x34, B: 0x56, A: 0xFF}); got != (color.RGBA{R: 0x12, G: 0x34, B: 0x56, A: 0xFF}) {
+		t.Fatalf("opaque color changed: %v", got)
+	}
+	if got := linearRGBA(color.NRGBA{R: 0xFF, A: 0x80}); got.R != 0x80 || got.A != 0x80 {
+		t.Fatalf("linearRGBA = %v", got)
+	}
+}
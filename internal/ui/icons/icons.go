@@ -1,33 +1,91 @@
 package icons
 
 import (
+	"image"
+	"image/color"
+	"image/draw"
 	"log"
 
-	"gioui.org/widget"
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/unit"
+	"golang.org/x/exp/shiny/iconvg"
 	"golang.org/x/exp/shiny/materialdesign/icons"
 )
 
 var (
-	IconVocSign      *widget.Icon
-	IconOpenRequest  *widget.Icon
-	IconCertificates *widget.Icon
-	IconAudit        *widget.Icon
-	IconScan         *widget.Icon
-	IconImport       *widget.Icon
-	IconCheck        *widget.Icon
-	IconError        *widget.Icon
-	IconWarning      *widget.Icon
-	IconLaunch       *widget.Icon
-	IconAbout        *widget.Icon
+	IconVocSign      *Icon
+	IconOpenRequest  *Icon
+	IconCertificates *Icon
+	IconAudit        *Icon
+	IconScan         *Icon
+	IconImport       *Icon
+	IconCheck        *Icon
+	IconError        *Icon
+	IconWarning      *Icon
+	IconLaunch       *Icon
+	IconAbout        *Icon
+	IconSettings     *Icon
+	IconSmartCard    *Icon
+	IconLock         *Icon
+	IconLockOpen     *Icon
+	IconSecurity     *Icon
 )
 
+const defaultSize = unit.Dp(24)
+
+// Icon is an IconVG icon. It lays out like widget.Icon, which keeps only
+// the last size and color it drew and rasterizes again for any other, but
+// every size and color it is drawn in stays in the icon atlas.
+type Icon struct {
+	src  []byte
+	meta iconvg.Metadata
+}
+
+// NewIcon returns an Icon for the IconVG data.
+func NewIcon(data []byte) (*Icon, error) {
+	m, err := iconvg.DecodeMetadata(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Icon{src: data, meta: m}, nil
+}
+
+// Layout displays the icon with its size set to the X minimum constraint.
+func (ic *Icon) Layout(gtx layout.Context, clr color.NRGBA) layout.Dimensions {
+	sz := gtx.Constraints.Min.X
+	if sz == 0 {
+		sz = gtx.Dp(defaultSize)
+	}
+	size := gtx.Constraints.Constrain(image.Pt(sz, sz))
+	defer clip.Rect{Max: size}.Push(gtx.Ops).Pop()
+
+	dx, dy := ic.meta.ViewBox.AspectRatio()
+	k := atlasKey{icon: ic, size: image.Pt(size.X, int(float32(size.X)*dy/dx)), color: clr}
+	e, ok := iconAtlas.entries[k]
+	if !ok {
+		e = iconAtlas.insert(k)
+		ic.rasterize(e.page.img, e.rect, clr)
+	}
+	e.add(gtx.Ops)
+	return layout.Dimensions{Size: k.size}
+}
+
+func (ic *Icon) rasterize(dst draw.Image, r image.Rectangle, clr color.NRGBA) {
+	palette := ic.meta.Palette
+	palette[0] = linearRGBA(clr)
+	var z iconvg.Rasterizer
+	z.SetDstImage(dst, r, draw.Src)
+	_ = iconvg.Decode(&z, ic.src, &iconvg.DecodeOptions{Palette: &palette})
+}
+
 func init() {
-	loadIcon := func(data []byte, name string) *widget.Icon {
+	loadIcon := func(data []byte, name string) *Icon {
 		if len(data) == 0 {
 			log.Printf("Icon data for %s is empty!", name)
 			return nil
 		}
-		ic, err := widget.NewIcon(data)
+		ic, err := NewIcon(data)
 		if err != nil {
 			log.Printf("Failed to load %s: %v", name, err)
 		}
@@ -45,4 +103,9 @@ func init() {
 	IconWarning = loadIcon(icons.AlertWarning, "IconWarning")
 	IconLaunch = loadIcon(icons.ActionLaunch, "IconLaunch")
 	IconAbout = loadIcon(icons.ActionInfo, "IconAbout")
+	IconSettings = loadIcon(icons.ActionSettings, "IconSettings")
+	IconSmartCard = loadIcon(icons.ActionCreditCard, "IconSmartCard")
+	IconLock = loadIcon(icons.ActionLock, "IconLock")
+	IconLockOpen = loadIcon(icons.ActionLockOpen, "IconLockOpen")
+	IconSecurity = loadIcon(icons.HardwareSecurity, "IconSecurity")
 }
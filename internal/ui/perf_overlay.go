@@ -0,0 +1,107 @@
+package ui
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"runtime"
+	"time"
+
+	"gioui.org/io/key"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/perf"
+)
+
+// perfToggle opens and closes the developer overlay: Ctrl+Shift+F12, or
+// Cmd+Shift+F12 on macOS.
+var perfToggle = key.Filter{Name: key.NameF12, Required: key.ModShortcut | key.ModShift}
+
+const (
+	// perfRefresh is how often the open overlay redraws, so its numbers
+	// stay current while nothing else changes.
+	perfRefresh = 500 * time.Millisecond
+	// perfHotSpots is how many sections the overlay lists.
+	perfHotSpots = 6
+)
+
+// perfOverlay is the hidden developer overlay: frame times, the sections
+// of the UI that take longest and the goroutine count. Sections are only
+// timed while it is open.
+type perfOverlay struct {
+	rec  *perf.Recorder
+	open bool
+}
+
+func (o *perfOverlay) update(gtx layout.Context) {
+	for {
+		ev, ok := gtx.Event(perfToggle)
+		if !ok {
+			return
+		}
+		if e, ok := ev.(key.Event); ok && e.State == key.Press {
+			o.open = !o.open
+			o.rec.SetDetail(o.open)
+		}
+	}
+}
+
+func (o *perfOverlay) layout(gtx layout.Context, th *material.Theme) layout.Dimensions {
+	if !o.open {
+		return layout.Dimensions{}
+	}
+	gtx.Execute(op.InvalidateCmd{At: gtx.Now.Add(perfRefresh)})
+
+	st := o.rec.Stats()
+	lines := []string{
+		fmt.Sprintf("frames %d  avg %s  p95 %s  max %s", st.Frames, ms(st.Avg), ms(st.P95), ms(st.Max)),
+		fmt.Sprintf("goroutines %d  janks %d (> %s)", runtime.NumGoroutine(), st.Janks, ms(perf.JankThreshold)),
+	}
+	if j := st.LastJank; j.Duration > 0 {
+		last := fmt.Sprintf("last jank %s on %s", ms(j.Duration), j.Screen)
+		if j.Hot != "" {
+			last += fmt.Sprintf(", %s %s", j.Hot, ms(j.HotDuration))
+		}
+		lines = append(lines, last)
+	}
+	lines = append(lines, "hot spots (avg / max per frame, calls)")
+	for i, h := range st.Hot {
+		if i == perfHotSpots {
+			break
+		}
+		lines = append(lines, fmt.Sprintf("  %-22s %s / %s  %d", h.Name, ms(h.Avg), ms(h.Max), h.Calls))
+	}
+
+	return layout.NE.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.UniformInset(unit.Dp(12)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			m := op.Record(gtx.Ops)
+			dims := layout.UniformInset(unit.Dp(8)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				children := make([]layout.FlexChild, len(lines))
+				for i, line := range lines {
+					children[i] = layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						l := material.Caption(th, line)
+						l.Font.Typeface = "Go Mono"
+						l.Color = color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+						return l.Layout(gtx)
+					})
+				}
+				return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+			})
+			call := m.Stop()
+			r := gtx.Dp(6)
+			bg := clip.RRect{Rect: image.Rectangle{Max: dims.Size}, NE: r, NW: r, SE: r, SW: r}
+			paint.FillShape(gtx.Ops, color.NRGBA{R: 0x11, G: 0x18, B: 0x27, A: 0xE0}, bg.Op(gtx.Ops))
+			call.Add(gtx.Ops)
+			return dims
+		})
+	})
+}
+
+func ms(d time.Duration) string {
+	return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+}
@@ -1,10 +1,13 @@
 package ui
 
 import (
-	"bytes"
+	"errors"
+	"fmt"
 	"image"
 	"image/color"
-	_ "image/png"
+	"log"
+	"sync"
+	"time"
 
 	gioapp "gioui.org/app"
 	"gioui.org/layout"
@@ -17,60 +20,118 @@ import (
 
 	"gioui.org/x/explorer"
 	"github.com/vocdoni/gofirma/vocsign/internal/app"
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/pkcs12store"
+	"github.com/vocdoni/gofirma/vocsign/internal/perf"
+	"github.com/vocdoni/gofirma/vocsign/internal/platform"
 	"github.com/vocdoni/gofirma/vocsign/internal/ui/assets"
 	"github.com/vocdoni/gofirma/vocsign/internal/ui/icons"
 	"github.com/vocdoni/gofirma/vocsign/internal/ui/screens"
 	"github.com/vocdoni/gofirma/vocsign/internal/ui/widgets"
 )
 
+// ErrRender is returned by Run when the window opened but could not be
+// drawn, which Gio reports when no GPU API can be initialized.
+var ErrRender = errors.New("window could not be drawn")
+
 func Run(w *gioapp.Window, a *app.App) error {
 	a.Explorer = explorer.NewExplorer(w)
 	a.Invalidate = w.Invalidate
 	a.StartUpdateCheck()
+	a.StartSelfCheck()
+	a.StartRelinkCheck()
+	a.StartAuditAnchoring()
+	a.StartAutoLock()
 	th := NewTheme()
 	var ops op.Ops
 
-	// Decode Logo
-	img, _, err := image.Decode(bytes.NewReader(assets.LogoPNG))
-	var logoOp paint.ImageOp
-	if err == nil {
-		logoOp = paint.NewImageOp(img)
-	}
+	// The logo is decoded while the first frames are drawn.
+	assets.Logo.Load(w.Invalidate)
 
-	// Initialize screens
-	certScreen := screens.NewCertificatesScreen(a, th)
-	openReqScreen := screens.NewOpenRequestScreen(a, th)
-	reqDetailsScreen := screens.NewRequestDetailsScreen(a, th)
-	auditScreen := screens.NewAuditScreen(a, th)
-	aboutScreen := screens.NewAboutScreen(a, th)
-	wizardScreen := screens.NewWizardScreen(a, th)
+	// Screens are built the first time they are shown, so the ones a
+	// session never opens cost no memory and read nothing from disk.
+	certScreen := sync.OnceValue(func() *screens.CertificatesScreen { return screens.NewCertificatesScreen(a, th) })
+	openReqScreen := sync.OnceValue(func() *screens.OpenRequestScreen { return screens.NewOpenRequestScreen(a, th) })
+	reqDetailsScreen := sync.OnceValue(func() *screens.RequestDetailsScreen { return screens.NewRequestDetailsScreen(a, th) })
+	auditScreen := sync.OnceValue(func() *screens.AuditScreen { return screens.NewAuditScreen(a, th) })
+	aboutScreen := sync.OnceValue(func() *screens.AboutScreen { return screens.NewAboutScreen(a, th) })
+	securityScreen := sync.OnceValue(func() *screens.SecurityScreen { return screens.NewSecurityScreen(a, th) })
+	settingsScreen := sync.OnceValue(func() *screens.SettingsScreen { return screens.NewSettingsScreen(a, th) })
+	wizardScreen := sync.OnceValue(func() *screens.WizardScreen { return screens.NewWizardScreen(a, th) })
+	relinkPrompt := screens.NewRelinkPrompt(a, th)
+	unlockDialog := screens.NewUnlockDialog(a, th)
+	overlay := &perfOverlay{rec: a.Perf}
+	go screens.RemoveStaleTemp(time.Now())
+	activity := &activityWatcher{app: a}
 
 	// Navigation state
 	var (
 		tabCert     widget.Clickable
 		tabOpen     widget.Clickable
 		tabAudit    widget.Clickable
+		tabSecurity widget.Clickable
 		tabAbout    widget.Clickable
+		tabSettings widget.Clickable
 		logoClick   widget.Clickable
 		updateClick widget.Clickable
 		checkNow    widget.Clickable
+		lockClick   widget.Clickable
 	)
 
 	lastScreen := a.CurrentScreen
+	focused := false
+	viewed := false
+
+	// The window state saved on close. The size is only taken while the
+	// window is in its normal mode, so a maximized window restores to the
+	// size it had before.
+	winState := a.WindowState()
+	winMode := gioapp.Windowed
 
 	for {
 		e := w.Event()
 		// if _, ok := e.(gioapp.FrameEvent); !ok { fmt.Printf("DEBUG: UI Event: %T\n", e) }
 		a.Explorer.ListenEvents(e)
 		switch e := e.(type) {
+		case gioapp.ViewEvent:
+			// The window is connected to the display server.
+			platform.RestoreEnv()
+			viewed = viewed || e.Valid()
 		case gioapp.DestroyEvent:
+			a.SaveWindowState(winState)
+			if e.Err != nil && viewed {
+				return fmt.Errorf("%w: %v", ErrRender, e.Err)
+			}
 			return e.Err
+		case gioapp.ConfigEvent:
+			winMode = e.Config.Mode
+			if winMode != gioapp.Fullscreen {
+				winState.Maximized = winMode == gioapp.Maximized
+			}
+			if a.Managed.Kiosk && winMode != gioapp.Fullscreen {
+				w.Option(gioapp.Fullscreen.Option())
+			}
+			if e.Config.Focused && !focused {
+				a.RequestClipboardCheck()
+				a.NoteActivity()
+			}
+			focused = e.Config.Focused
 		case gioapp.FrameEvent:
 			// log.Printf("DEBUG: FrameEvent received")
+			a.Perf.BeginFrame(time.Now())
+			st := a.Settings.Get()
+			applyPalette(st)
 			gtx := gioapp.NewContext(&ops, e)
+			applyLocale(&gtx, st)
+			overlay.update(gtx)
+			activity.update(gtx)
+			if winMode == gioapp.Windowed && e.Metric.PxPerDp > 0 {
+				winState.Width = int(float32(e.Size.X)/e.Metric.PxPerDp + 0.5)
+				winState.Height = int(float32(e.Size.Y)/e.Metric.PxPerDp + 0.5)
+			}
 			paint.FillShape(gtx.Ops, th.Bg, clip.Rect{Max: gtx.Constraints.Max}.Op())
 
 			// Handle Navigation
+			shown := a.CurrentScreen
 			if tabCert.Clicked(gtx) {
 				a.CurrentScreen = app.ScreenCertificates
 			}
@@ -80,13 +141,28 @@ func Run(w *gioapp.Window, a *app.App) error {
 			if tabAudit.Clicked(gtx) {
 				a.CurrentScreen = app.ScreenAudit
 			}
+			if tabSecurity.Clicked(gtx) {
+				a.CurrentScreen = app.ScreenSecurity
+			}
 			if tabAbout.Clicked(gtx) {
 				a.CurrentScreen = app.ScreenAbout
 			}
-			if logoClick.Clicked(gtx) {
+			if tabSettings.Clicked(gtx) {
+				a.CurrentScreen = app.ScreenSettings
+			}
+			// Kiosk mode keeps the user in the signing screens.
+			if a.Managed.Kiosk && (a.CurrentScreen == app.ScreenSettings || a.CurrentScreen == app.ScreenSecurity || a.CurrentScreen == app.ScreenAbout) {
+				a.CurrentScreen = app.ScreenOpenRequest
+			}
+			// A signature is never submitted by a countdown the user can no
+			// longer see.
+			if shown == app.ScreenRequestDetails && a.CurrentScreen != shown {
+				reqDetailsScreen().CancelSubmitReview()
+			}
+			if logoClick.Clicked(gtx) && !a.Managed.Kiosk {
 				widgets.OpenURL("https://vocdoni.io")
 			}
-			if updateClick.Clicked(gtx) {
+			if updateClick.Clicked(gtx) && !a.Managed.Kiosk {
 				st := a.UpdateStatusSnapshot()
 				if st.ReleasePageURL != "" {
 					widgets.OpenURL(st.ReleasePageURL)
@@ -95,11 +171,25 @@ func Run(w *gioapp.Window, a *app.App) error {
 			if checkNow.Clicked(gtx) {
 				a.CheckUpdatesNow()
 			}
+			if lockClick.Clicked(gtx) {
+				if !a.WalletLocked() {
+					a.LockWallet("locked by the user")
+				} else if a.PendingUnlockRequest() == nil {
+					go func() {
+						if err := a.UnlockWallet(); err != nil && !errors.Is(err, pkcs12store.ErrWalletLocked) {
+							log.Printf("ERROR: failed to unlock the wallet: %v", err)
+						}
+					}()
+				}
+			}
 
 			// Screen transition logic
 			if a.CurrentScreen != lastScreen {
 				if a.CurrentScreen == app.ScreenWizard {
-					wizardScreen.Reset()
+					wizardScreen().Reset()
+				}
+				if a.CurrentScreen == app.ScreenSecurity {
+					securityScreen().RefreshEvents()
 				}
 				// Clear stale signing state when navigating away from request details.
 				if lastScreen == app.ScreenRequestDetails && a.CurrentScreen != app.ScreenRequestDetails {
@@ -112,20 +202,25 @@ func Run(w *gioapp.Window, a *app.App) error {
 			var current layout.Widget
 			switch a.CurrentScreen {
 			case app.ScreenCertificates:
-				current = certScreen.Layout
+				current = certScreen().Layout
 			case app.ScreenOpenRequest:
-				current = openReqScreen.Layout
+				current = openReqScreen().Layout
 			case app.ScreenRequestDetails:
-				current = reqDetailsScreen.Layout
+				current = reqDetailsScreen().Layout
 			case app.ScreenAudit:
-				current = auditScreen.Layout
+				current = auditScreen().Layout
+			case app.ScreenSecurity:
+				current = securityScreen().Layout
 			case app.ScreenAbout:
-				current = aboutScreen.Layout
+				current = aboutScreen().Layout
+			case app.ScreenSettings:
+				current = settingsScreen().Layout
 			case app.ScreenWizard:
-				current = wizardScreen.Layout
+				current = wizardScreen().Layout
 			default:
-				current = openReqScreen.Layout
+				current = openReqScreen().Layout
 			}
+			current = timed(a.Perf, "screen/"+a.CurrentScreen.String(), current)
 
 			// Main Background & App Border
 			layout.UniformInset(unit.Dp(8)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
@@ -136,6 +231,7 @@ func Run(w *gioapp.Window, a *app.App) error {
 						if a.CurrentScreen == app.ScreenWizard {
 							return layout.Dimensions{}
 						}
+						defer a.Perf.Section("header")()
 						return layout.UniformInset(unit.Dp(12)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 							return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 								return widgets.ConstrainMaxWidth(gtx, widgets.DefaultPageMaxWidth, func(gtx layout.Context) layout.Dimensions {
@@ -146,21 +242,47 @@ func Run(w *gioapp.Window, a *app.App) error {
 										layout.Rigid(layout.Spacer{Width: unit.Dp(24)}.Layout),
 										layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 											active := a.CurrentScreen == app.ScreenOpenRequest || a.CurrentScreen == app.ScreenRequestDetails
-											return navTab(gtx, th, &tabOpen, icons.IconOpenRequest, "Open Request", active)
+											return navTab(gtx, th, &tabOpen, icons.IconOpenRequest, "Open Request", active, tabBadge{})
 										}),
 										layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
 										layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-											return navTab(gtx, th, &tabCert, icons.IconCertificates, "Certificates", a.CurrentScreen == app.ScreenCertificates)
+											return navTab(gtx, th, &tabCert, icons.IconCertificates, "Certificates", a.CurrentScreen == app.ScreenCertificates, tabBadge{})
 										}),
 										layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
 										layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-											return navTab(gtx, th, &tabAudit, icons.IconAudit, "Audit", a.CurrentScreen == app.ScreenAudit)
+											return navTab(gtx, th, &tabAudit, icons.IconAudit, "Audit", a.CurrentScreen == app.ScreenAudit, tabBadge{})
+										}),
+										layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+											if a.Managed.Kiosk {
+												return layout.Dimensions{}
+											}
+											return layout.Inset{Left: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+												return navTab(gtx, th, &tabSettings, icons.IconSettings, "Settings", a.CurrentScreen == app.ScreenSettings, tabBadge{})
+											})
+										}),
+										layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+											if a.Managed.Kiosk {
+												return layout.Dimensions{}
+											}
+											return layout.Inset{Left: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+												return navTab(gtx, th, &tabSecurity, icons.IconSecurity, "Security", a.CurrentScreen == app.ScreenSecurity, tabBadge{})
+											})
 										}),
-										layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
 										layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-											return navTab(gtx, th, &tabAbout, icons.IconAbout, "About", a.CurrentScreen == app.ScreenAbout)
+											if a.Managed.Kiosk {
+												return layout.Dimensions{}
+											}
+											return layout.Inset{Left: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+												return navTab(gtx, th, &tabAbout, icons.IconAbout, "About", a.CurrentScreen == app.ScreenAbout, updateBadge(a.UpdateStatusSnapshot()))
+											})
 										}),
 										layout.Flexed(1, func(gtx layout.Context) layout.Dimensions { return layout.Dimensions{} }),
+										layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+											if !a.WalletPIN.IsSet() {
+												return layout.Dimensions{}
+											}
+											return walletLockButton(gtx, th, &lockClick, a.WalletLocked())
+										}),
 									)
 								})
 							})
@@ -172,6 +294,30 @@ func Run(w *gioapp.Window, a *app.App) error {
 						}
 						return widgets.VerticalDivider(gtx, color.NRGBA{R: 0xE5, G: 0xEB, B: 0xF5, A: 0xFF})
 					}),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						integrity := a.IntegritySnapshot()
+						if !integrity.Warning() {
+							return layout.Dimensions{}
+						}
+						return layout.Inset{Top: unit.Dp(8), Left: unit.Dp(12), Right: unit.Dp(12)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+							return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+								return widgets.ConstrainMaxWidth(gtx, widgets.DefaultPageMaxWidth, func(gtx layout.Context) layout.Dimensions {
+									msg := "This copy of VocSign could not be verified against its signed release (" + integrity.Detail + "). It may have been modified; download it again from the official releases page before signing."
+									return widgets.Banner(gtx, th, widgets.BannerError, msg)
+								})
+							})
+						})
+					}),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						if a.CurrentScreen == app.ScreenWizard {
+							return layout.Dimensions{}
+						}
+						return layout.Inset{Top: unit.Dp(8), Left: unit.Dp(12), Right: unit.Dp(12)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+							return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+								return widgets.ConstrainMaxWidth(gtx, widgets.DefaultPageMaxWidth, relinkPrompt.Layout)
+							})
+						})
+					}),
 					layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
 						if a.CurrentScreen == app.ScreenWizard {
 							gtx.Constraints.Min = gtx.Constraints.Max
@@ -187,6 +333,7 @@ func Run(w *gioapp.Window, a *app.App) error {
 						if a.CurrentScreen == app.ScreenWizard {
 							return layout.Dimensions{}
 						}
+						defer a.Perf.Section("footer")()
 						return layout.UniformInset(unit.Dp(12)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 							return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 								return widgets.ConstrainMaxWidth(gtx, widgets.DefaultPageMaxWidth, func(gtx layout.Context) layout.Dimensions {
@@ -194,13 +341,10 @@ func Run(w *gioapp.Window, a *app.App) error {
 									logoAndStatement := func(gtx layout.Context) layout.Dimensions {
 										return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
 											layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-												if err != nil {
-													return layout.Dimensions{}
-												}
 												return material.Clickable(gtx, &logoClick, func(gtx layout.Context) layout.Dimensions {
 													gtx.Constraints.Max.X = gtx.Dp(288)
 													gtx.Constraints.Max.Y = gtx.Dp(84)
-													return widget.Image{Src: logoOp, Fit: widget.Contain}.Layout(gtx)
+													return assets.Logo.Layout(gtx)
 												})
 											}),
 											layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
@@ -235,12 +379,25 @@ func Run(w *gioapp.Window, a *app.App) error {
 					}),
 				)
 			})
+			unlockDialog.Layout(gtx)
+			overlay.layout(gtx, th)
+			activity.layout(gtx)
+			activity.updateKeys(gtx)
 
 			e.Frame(gtx.Ops)
+			a.Perf.EndFrame(time.Now(), a.CurrentScreen.String())
 		}
 	}
 }
 
+// timed records the layout of w as the named section of the frame.
+func timed(r *perf.Recorder, name string, w layout.Widget) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		defer r.Section(name)()
+		return w(gtx)
+	}
+}
+
 func footerVersionStatus(gtx layout.Context, th *material.Theme, a *app.App, updateClick, checkNow *widget.Clickable) layout.Dimensions {
 	status := a.UpdateStatusSnapshot()
 	msg := status.Message
@@ -288,7 +445,7 @@ func footerVersionStatus(gtx layout.Context, th *material.Theme, a *app.App, upd
 			return layout.E.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 				l := material.Caption(th, msg)
 				l.Color = color.NRGBA{R: 0x16, G: 0x65, B: 0x34, A: 0xFF}
-				if status.Error != "" {
+				if status.Error != "" || status.Available && status.Security {
 					l.Color = color.NRGBA{R: 0xB9, G: 0x1C, B: 0x1C, A: 0xFF}
 				} else if status.Available {
 					l.Color = color.NRGBA{R: 0x9A, G: 0x34, B: 0x12, A: 0xFF}
@@ -301,7 +458,44 @@ func footerVersionStatus(gtx layout.Context, th *material.Theme, a *app.App, upd
 	)
 }
 
-func navTab(gtx layout.Context, th *material.Theme, click *widget.Clickable, icon *widget.Icon, label string, active bool) layout.Dimensions {
+// tabBadge marks a navigation tab: a dot, or the icon when there is one,
+// in the given color. A zero alpha draws nothing.
+type tabBadge struct {
+	Color color.NRGBA
+	Icon  *icons.Icon
+}
+
+// updateBadge is the badge on the About tab: a red warning sign when the
+// available update fixes security issues, an orange dot for any other
+// update. The shapes differ so the two do not depend on telling the colors
+// apart.
+func updateBadge(status app.UpdateStatus) tabBadge {
+	switch {
+	case !status.Available:
+		return tabBadge{}
+	case status.Security:
+		return tabBadge{Color: widgets.ColorError, Icon: icons.IconWarning}
+	default:
+		return tabBadge{Color: widgets.ColorWarning}
+	}
+}
+
+// walletLockButton shows whether the wallet is locked. Clicking it locks
+// the wallet, or asks for its PIN when it is locked.
+func walletLockButton(gtx layout.Context, th *material.Theme, click *widget.Clickable, locked bool) layout.Dimensions {
+	icon, label, fg := icons.IconLockOpen, "Lock", th.Fg
+	if locked {
+		icon, label, fg = icons.IconLock, "Locked", widgets.ColorWarning
+	}
+	return material.Clickable(gtx, click, func(gtx layout.Context) layout.Dimensions {
+		return widgets.CustomCard(gtx, color.NRGBA{A: 0}, unit.Dp(8), func(gtx layout.Context) layout.Dimensions {
+			return widgets.IconLabel(gtx, th, icon, label, fg, unit.Sp(16))
+		})
+	})
+}
+
+// navTab lays out a navigation tab, with the badge in its top right corner.
+func navTab(gtx layout.Context, th *material.Theme, click *widget.Clickable, icon *icons.Icon, label string, active bool, badge tabBadge) layout.Dimensions {
 	bg := color.NRGBA{A: 0}
 	fg := th.Fg
 	if active {
@@ -309,9 +503,35 @@ func navTab(gtx layout.Context, th *material.Theme, click *widget.Clickable, ico
 		fg = th.ContrastFg
 	}
 	return material.Clickable(gtx, click, func(gtx layout.Context) layout.Dimensions {
-		return widgets.CustomCard(gtx, bg, unit.Dp(8), func(gtx layout.Context) layout.Dimensions {
-			gtx.Constraints.Min.X = gtx.Dp(164)
-			return widgets.IconLabel(gtx, th, icon, label, fg, unit.Sp(16))
-		})
+		return layout.Stack{Alignment: layout.NE}.Layout(gtx,
+			layout.Stacked(func(gtx layout.Context) layout.Dimensions {
+				return widgets.CustomCard(gtx, bg, unit.Dp(8), func(gtx layout.Context) layout.Dimensions {
+					gtx.Constraints.Min.X = gtx.Dp(164)
+					return widgets.IconLabel(gtx, th, icon, label, fg, unit.Sp(16))
+				})
+			}),
+			layout.Expanded(func(gtx layout.Context) layout.Dimensions {
+				if badge.Color.A == 0 {
+					return layout.Dimensions{}
+				}
+				return layout.UniformInset(unit.Dp(6)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					sz := gtx.Dp(unit.Dp(10))
+					ring := gtx.Dp(unit.Dp(2))
+					if badge.Icon != nil {
+						sz = gtx.Dp(unit.Dp(16))
+					}
+					paint.FillShape(gtx.Ops, widgets.ColorSurface, clip.Ellipse{Max: image.Pt(sz+2*ring, sz+2*ring)}.Op(gtx.Ops))
+					if badge.Icon != nil {
+						defer op.Offset(image.Pt(ring, ring)).Push(gtx.Ops).Pop()
+						gtx.Constraints.Min = image.Pt(sz, sz)
+						gtx.Constraints.Max = gtx.Constraints.Min
+						badge.Icon.Layout(gtx, badge.Color)
+						return layout.Dimensions{Size: image.Pt(sz+2*ring, sz+2*ring)}
+					}
+					paint.FillShape(gtx.Ops, badge.Color, clip.Ellipse{Min: image.Pt(ring, ring), Max: image.Pt(sz+ring, sz+ring)}.Op(gtx.Ops))
+					return layout.Dimensions{Size: image.Pt(sz+2*ring, sz+2*ring)}
+				})
+			}),
+		)
 	})
 }
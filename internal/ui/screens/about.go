@@ -1,11 +1,20 @@
 package screens
 
 import (
+	"fmt"
 	"image"
 	"image/color"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"gioui.org/font"
 	"gioui.org/layout"
+	"gioui.org/op"
 	"gioui.org/op/clip"
 	"gioui.org/op/paint"
 	"gioui.org/text"
@@ -14,7 +23,10 @@ import (
 	"gioui.org/widget/material"
 
 	"github.com/vocdoni/gofirma/vocsign/internal/app"
+	"github.com/vocdoni/gofirma/vocsign/internal/changelog"
 	"github.com/vocdoni/gofirma/vocsign/internal/net"
+	"github.com/vocdoni/gofirma/vocsign/internal/selfcheck"
+	"github.com/vocdoni/gofirma/vocsign/internal/settings"
 	"github.com/vocdoni/gofirma/vocsign/internal/ui/icons"
 	"github.com/vocdoni/gofirma/vocsign/internal/ui/widgets"
 )
@@ -22,22 +34,42 @@ import (
 const (
 	sourceCodeURL = "https://github.com/vocdoni/vocsign"
 	vocdoniURL    = "https://vocdoni.io"
+	// memoryRefresh is how often the memory readout is updated.
+	memoryRefresh = 5 * time.Second
 )
 
 type AboutScreen struct {
 	App   *app.App
 	Theme *material.Theme
 
-	OpenReleases widget.Clickable
-	OpenSource   widget.Clickable
-	OpenVocdoni  widget.Clickable
+	OpenReleases   widget.Clickable
+	DownloadUpdate widget.Clickable
+	CancelDownload widget.Clickable
+	InstallUpdate  widget.Clickable
+	ShowDownload   widget.Clickable
+	OpenSource     widget.Clickable
+	OpenVocdoni    widget.Clickable
+	ExportSBOM     widget.Clickable
+	List           widget.List
+
+	sbomStatus string
+
+	// Environment report, gathered in the background the first time the
+	// screen is shown.
+	envMu      sync.Mutex
+	env        []app.Diagnostic
+	envLoading bool
+	memory     app.Diagnostic
+	memoryAt   time.Time
 }
 
 func NewAboutScreen(a *app.App, th *material.Theme) *AboutScreen {
-	return &AboutScreen{
+	s := &AboutScreen{
 		App:   a,
 		Theme: th,
 	}
+	s.List.Axis = layout.Vertical
+	return s
 }
 
 func (s *AboutScreen) Layout(gtx layout.Context) layout.Dimensions {
@@ -50,51 +82,110 @@ func (s *AboutScreen) Layout(gtx layout.Context) layout.Dimensions {
 	if s.OpenVocdoni.Clicked(gtx) {
 		widgets.OpenURL(vocdoniURL)
 	}
+	if s.ExportSBOM.Clicked(gtx) {
+		s.exportSBOM()
+	}
 
 	status := s.App.UpdateStatusSnapshot()
+	if s.DownloadUpdate.Clicked(gtx) {
+		if status.CanDownload {
+			s.App.DownloadUpdate()
+		} else {
+			widgets.OpenURL(nonEmptyText(status.ReleasePageURL, net.LatestReleasePageURL))
+		}
+	}
+	if s.CancelDownload.Clicked(gtx) {
+		s.App.CancelUpdateDownload()
+	}
+	if s.InstallUpdate.Clicked(gtx) {
+		s.App.InstallUpdate()
+	}
+	if s.ShowDownload.Clicked(gtx) && status.Downloaded != nil {
+		widgets.OpenFile(filepath.Dir(status.Downloaded.Path))
+	}
+	status = s.App.UpdateStatusSnapshot()
+	s.loadEnvironment()
 
-	return widgets.CenterInAvailable(gtx, func(gtx layout.Context) layout.Dimensions {
-		return widgets.ConstrainMaxWidth(gtx, unit.Dp(680), func(gtx layout.Context) layout.Dimensions {
-			return layout.Flex{Axis: layout.Vertical, Alignment: layout.Middle}.Layout(gtx,
+	// Build details make the page taller than small windows, so it scrolls.
+	return material.List(s.Theme, &s.List).Layout(gtx, 1, func(gtx layout.Context, _ int) layout.Dimensions {
+		gtx.Constraints.Min.X = gtx.Constraints.Max.X
+		return layout.N.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return widgets.ConstrainMaxWidth(gtx, unit.Dp(680), func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Vertical, Alignment: layout.Middle}.Layout(gtx,
 
-				// Hero icon
-				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-					return s.layoutHeroIcon(gtx)
-				}),
-				layout.Rigid(layout.Spacer{Height: unit.Dp(20)}.Layout),
+					// Hero icon
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return s.layoutHeroIcon(gtx)
+					}),
+					layout.Rigid(layout.Spacer{Height: unit.Dp(20)}.Layout),
 
-				// App name + version
-				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-					return s.layoutTitle(gtx, status.CurrentVersion)
-				}),
-				layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+					// App name + version
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return s.layoutTitle(gtx, status.CurrentVersion)
+					}),
+					layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
 
-				// Tagline
-				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-					l := material.Label(s.Theme, unit.Sp(15), "Open-source desktop signer built by Vocdoni Global")
-					l.Color = color.NRGBA{R: 0x5F, G: 0x6E, B: 0x84, A: 0xFF}
-					l.Alignment = text.Middle
-					return l.Layout(gtx)
-				}),
-				layout.Rigid(layout.Spacer{Height: unit.Dp(6)}.Layout),
+					// Tagline
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						l := material.Label(s.Theme, unit.Sp(15), "Open-source desktop signer built by Vocdoni Global")
+						l.Color = color.NRGBA{R: 0x5F, G: 0x6E, B: 0x84, A: 0xFF}
+						l.Alignment = text.Middle
+						return l.Layout(gtx)
+					}),
+					layout.Rigid(layout.Spacer{Height: unit.Dp(6)}.Layout),
 
-				// License badge
-				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-					return s.layoutBadge(gtx, "GNU AGPLv3 — source code is public and auditable")
-				}),
-				layout.Rigid(layout.Spacer{Height: unit.Dp(32)}.Layout),
+					// License badge
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return s.layoutBadge(gtx, "GNU AGPLv3 — source code is public and auditable")
+					}),
+					layout.Rigid(layout.Spacer{Height: unit.Dp(6)}.Layout),
 
-				// Link buttons row
-				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-					return s.layoutLinkButtons(gtx)
-				}),
-				layout.Rigid(layout.Spacer{Height: unit.Dp(36)}.Layout),
+					// Binary integrity
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return s.layoutBadge(gtx, integrityText(s.App.IntegritySnapshot()))
+					}),
+					layout.Rigid(layout.Spacer{Height: unit.Dp(6)}.Layout),
 
-				// Vocdoni info card
-				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-					return s.layoutInfoCard(gtx)
-				}),
-			)
+					// Update channel
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return s.layoutBadge(gtx, channelText(status))
+					}),
+					layout.Rigid(layout.Spacer{Height: unit.Dp(32)}.Layout),
+
+					// Link buttons row
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return s.layoutLinkButtons(gtx)
+					}),
+					layout.Rigid(layout.Spacer{Height: unit.Dp(36)}.Layout),
+
+					// Release notes of the latest version
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						if len(status.Notes.Blocks) == 0 {
+							return layout.Dimensions{}
+						}
+						return layout.Inset{Bottom: unit.Dp(24)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+							return s.layoutChangelogCard(gtx, status)
+						})
+					}),
+
+					// Reproducible build details
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return s.layoutBuildCard(gtx)
+					}),
+					layout.Rigid(layout.Spacer{Height: unit.Dp(24)}.Layout),
+
+					// Sandbox and certificate access
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return s.layoutEnvironmentCard(gtx)
+					}),
+					layout.Rigid(layout.Spacer{Height: unit.Dp(24)}.Layout),
+
+					// Vocdoni info card
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return s.layoutInfoCard(gtx)
+					}),
+				)
+			})
 		})
 	})
 }
@@ -142,6 +233,32 @@ func (s *AboutScreen) layoutTitle(gtx layout.Context, version string) layout.Dim
 	})
 }
 
+func integrityText(r selfcheck.Result) string {
+	switch r.Status {
+	case "":
+		return "Verifying release signature..."
+	case selfcheck.StatusVerified:
+		return "Release signature verified — " + r.Detail
+	case selfcheck.StatusUnsigned:
+		return "Release signature not checked — " + r.Detail
+	default:
+		return "Release signature NOT verified — " + r.Detail
+	}
+}
+
+// channelText says which releases this client is offered, and whether it
+// runs one that was not published as stable.
+func channelText(status app.UpdateStatus) string {
+	text := "Stable update channel — published releases only"
+	if status.Channel == settings.ChannelBeta {
+		text = "Beta update channel — pre-releases are offered"
+	}
+	if status.CurrentPrerelease {
+		text += " · this build is a pre-release"
+	}
+	return text
+}
+
 func (s *AboutScreen) layoutBadge(gtx layout.Context, text string) layout.Dimensions {
 	return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 		gtx.Constraints.Min.X = 0
@@ -175,7 +292,7 @@ func (s *AboutScreen) layoutLinkButtons(gtx layout.Context) layout.Dimensions {
 	})
 }
 
-func (s *AboutScreen) linkButton(gtx layout.Context, click *widget.Clickable, icon *widget.Icon, label string) layout.Dimensions {
+func (s *AboutScreen) linkButton(gtx layout.Context, click *widget.Clickable, icon *icons.Icon, label string) layout.Dimensions {
 	return widgets.Border(gtx, widgets.ColorBorder, func(gtx layout.Context) layout.Dimensions {
 		return material.Clickable(gtx, click, func(gtx layout.Context) layout.Dimensions {
 			return widgets.CustomCard(gtx, widgets.ColorSurface, unit.Dp(10), func(gtx layout.Context) layout.Dimensions {
@@ -249,3 +366,335 @@ func (s *AboutScreen) layoutInfoCard(gtx layout.Context) layout.Dimensions {
 		})
 	})
 }
+
+// layoutBuildCard shows what auditors need to match this binary to a
+// published reproducible build.
+func (s *AboutScreen) layoutBuildCard(gtx layout.Context) layout.Dimensions {
+	b := s.App.BuildInfo
+	d := b.Details
+	revision := d.VCSRevision
+	if revision == "" {
+		revision = b.Commit
+	}
+	modified := d.VCSModified
+	if modified == "" {
+		modified = b.VCSModified
+	}
+	moduleSum := d.Main.Sum
+	if moduleSum == "" {
+		moduleSum = "not recorded (built from a source checkout)"
+	}
+	var settingsText []string
+	for _, k := range []string{"-trimpath", "CGO_ENABLED", "-tags", "-buildmode"} {
+		if v, ok := d.Settings[k]; ok {
+			settingsText = append(settingsText, k+"="+v)
+		}
+	}
+	rows := []struct{ label, value string }{
+		{"VERSION", b.Version},
+		{"BUILD DATE", b.BuildDate},
+		{"VCS REVISION", revision},
+		{"UNCOMMITTED CHANGES", modified},
+		{"MODULE", d.Main.Path + " " + d.Main.Version},
+		{"MODULE CHECKSUM", moduleSum},
+		{"TOOLCHAIN", d.GoVersion + " " + d.Platform},
+		{"BUILD SETTINGS", strings.Join(settingsText, " ")},
+		{"DEPENDENCIES", fmt.Sprintf("%d modules", len(d.Deps))},
+	}
+	return widgets.Border(gtx, widgets.ColorBorder, func(gtx layout.Context) layout.Dimensions {
+		return widgets.CustomCard(gtx, widgets.ColorSurface, unit.Dp(20), func(gtx layout.Context) layout.Dimensions {
+			children := []layout.FlexChild{
+				layout.Rigid(material.Subtitle2(s.Theme, "Build details").Layout),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+			}
+			for _, row := range rows {
+				row := row
+				children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return layout.Inset{Bottom: unit.Dp(4)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+							layout.Rigid(material.Caption(s.Theme, row.label).Layout),
+							layout.Rigid(material.Body2(s.Theme, nonEmptyText(row.value, "—")).Layout),
+						)
+					})
+				}))
+			}
+			children = append(children,
+				layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+				layout.Rigid(widgets.SecondaryButton(s.Theme, &s.ExportSBOM, "Open SBOM (CycloneDX)").Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					if s.sbomStatus == "" {
+						return layout.Dimensions{}
+					}
+					return layout.Inset{Top: unit.Dp(6)}.Layout(gtx, material.Caption(s.Theme, s.sbomStatus).Layout)
+				}),
+			)
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+		})
+	})
+}
+
+// layoutChangelogCard renders the release notes of the latest version, with
+// the security fixes in red so they stand out from the rest.
+func (s *AboutScreen) layoutChangelogCard(gtx layout.Context, status app.UpdateStatus) layout.Dimensions {
+	title := "Release notes for " + status.LatestVersion
+	if status.Available {
+		title = "What's new in " + status.LatestVersion
+	}
+	if status.Prerelease {
+		title += " (pre-release, beta channel)"
+	}
+	return widgets.Border(gtx, widgets.ColorBorder, func(gtx layout.Context) layout.Dimensions {
+		return widgets.CustomCard(gtx, widgets.ColorSurface, unit.Dp(20), func(gtx layout.Context) layout.Dimensions {
+			children := []layout.FlexChild{
+				layout.Rigid(material.Subtitle2(s.Theme, title).Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					if status.PublishedAt.IsZero() {
+						return layout.Dimensions{}
+					}
+					return material.Caption(s.Theme, "Published "+status.PublishedAt.Local().Format("2 Jan 2006")).Layout(gtx)
+				}),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+			}
+			if status.Available && status.Security {
+				children = append(children,
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return widgets.Banner(gtx, s.Theme, widgets.BannerError, "This update fixes security issues. Install it as soon as possible.")
+					}),
+					layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+				)
+			}
+			for _, b := range status.Notes.Blocks {
+				children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return s.layoutChangelogBlock(gtx, b)
+				}))
+			}
+			if status.Notes.Truncated {
+				children = append(children, layout.Rigid(material.Caption(s.Theme, "The full notes are on the release page.").Layout))
+			}
+			if status.Available {
+				children = append(children,
+					layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return s.layoutUpdateDownload(gtx, status)
+					}),
+				)
+			}
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+		})
+	})
+}
+
+// layoutUpdateDownload shows the in-app download of the latest version:
+// the button that starts it, its progress, and installing the result.
+func (s *AboutScreen) layoutUpdateDownload(gtx layout.Context, status app.UpdateStatus) layout.Dimensions {
+	var children []layout.FlexChild
+	caption := func(text string, clr color.NRGBA) {
+		children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			l := material.Caption(s.Theme, text)
+			if clr.A != 0 {
+				l.Color = clr
+			}
+			return layout.Inset{Bottom: unit.Dp(6)}.Layout(gtx, l.Layout)
+		}))
+	}
+	button := func(w layout.Widget) {
+		children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Inset{Bottom: unit.Dp(6)}.Layout(gtx, w)
+		}))
+	}
+
+	switch r := status.Downloaded; {
+	case status.Installed:
+		children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return widgets.Banner(gtx, s.Theme, widgets.BannerSuccess, status.LatestVersion+" is installed. Restart VocSign to use it.")
+		}))
+	case status.Downloading:
+		text := "Downloading " + status.LatestVersion + "..."
+		if status.DownloadTotal > 0 {
+			text = fmt.Sprintf("Downloading %s: %s of %s", status.LatestVersion, formatSize(status.DownloadDone), formatSize(status.DownloadTotal))
+		}
+		caption(text, color.NRGBA{})
+		children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			var p float32
+			if status.DownloadTotal > 0 {
+				p = float32(status.DownloadDone) / float32(status.DownloadTotal)
+			}
+			return layout.Inset{Bottom: unit.Dp(8)}.Layout(gtx, material.ProgressBar(s.Theme, p).Layout)
+		}))
+		button(widgets.SecondaryButton(s.Theme, &s.CancelDownload, "Cancel").Layout)
+	case r != nil:
+		how := "the full release"
+		if r.Delta {
+			how = "a patch of this version"
+		}
+		caption(fmt.Sprintf("%s downloaded as %s (%s) and checked against its release signature.", r.Version, how, formatSize(r.Downloaded)), color.NRGBA{})
+		if status.InstallBlocked != "" {
+			caption("It cannot be installed from here: "+status.InstallBlocked+". The new executable is in "+filepath.Dir(r.Path)+".", widgets.ColorWarning)
+			button(widgets.SecondaryButton(s.Theme, &s.ShowDownload, "Open Folder").Layout)
+			break
+		}
+		if status.InstallErr != "" {
+			caption("Could not install the update: "+status.InstallErr, widgets.ColorError)
+		}
+		button(widgets.PrimaryButton(s.Theme, &s.InstallUpdate, "Install Update").Layout)
+	default:
+		label := "Download " + status.LatestVersion
+		if status.DownloadErr != "" {
+			caption("Download failed: "+status.DownloadErr, widgets.ColorError)
+			label = "Retry Download"
+		}
+		button(widgets.PrimaryButton(s.Theme, &s.DownloadUpdate, label).Layout)
+	}
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+}
+
+// formatSize formats a byte count for the download progress.
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%d KB", n>>10)
+	default:
+		return fmt.Sprintf("%d bytes", n)
+	}
+}
+
+func (s *AboutScreen) layoutChangelogBlock(gtx layout.Context, b changelog.Block) layout.Dimensions {
+	fg := s.Theme.Fg
+	if b.Security {
+		fg = widgets.ColorError
+	}
+	switch b.Kind {
+	case changelog.Heading:
+		size := unit.Sp(15)
+		if b.Level > 2 {
+			size = unit.Sp(14)
+		}
+		l := material.Label(s.Theme, size, b.Text)
+		l.Font.Weight = font.Bold
+		l.Color = fg
+		return layout.Inset{Top: unit.Dp(8), Bottom: unit.Dp(4)}.Layout(gtx, l.Layout)
+	case changelog.Bullet:
+		indent := unit.Dp(4 + 16*min(b.Level, 4))
+		return layout.Inset{Left: indent, Bottom: unit.Dp(3)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					l := material.Body2(s.Theme, "•")
+					l.Color = fg
+					return l.Layout(gtx)
+				}),
+				layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+				layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+					l := material.Body2(s.Theme, b.Text)
+					l.Color = fg
+					if b.Security {
+						l.Font.Weight = font.Medium
+					}
+					return l.Layout(gtx)
+				}),
+			)
+		})
+	case changelog.Code:
+		return layout.Inset{Bottom: unit.Dp(6)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return widgets.CustomCard(gtx, color.NRGBA{R: 0xF6, G: 0xF8, B: 0xFC, A: 0xFF}, unit.Dp(8), func(gtx layout.Context) layout.Dimensions {
+				l := material.Body2(s.Theme, b.Text)
+				l.Font.Typeface = "Go Mono"
+				l.Color = fg
+				return l.Layout(gtx)
+			})
+		})
+	default:
+		l := material.Body2(s.Theme, b.Text)
+		l.Color = fg
+		return layout.Inset{Bottom: unit.Dp(6)}.Layout(gtx, l.Layout)
+	}
+}
+
+func (s *AboutScreen) loadEnvironment() {
+	s.envMu.Lock()
+	defer s.envMu.Unlock()
+	if s.env != nil || s.envLoading {
+		return
+	}
+	s.envLoading = true
+	go func() {
+		env := s.App.Diagnostics()
+		s.envMu.Lock()
+		s.env, s.envLoading = env, false
+		s.envMu.Unlock()
+		s.App.Invalidate()
+	}()
+}
+
+// layoutEnvironmentCard shows where VocSign can look for certificates from
+// where it runs, which is what breaks inside a Flatpak or snap.
+func (s *AboutScreen) layoutEnvironmentCard(gtx layout.Context) layout.Dimensions {
+	s.envMu.Lock()
+	rows := slices.Clone(s.env)
+	s.envMu.Unlock()
+	picker, problem := filePickerStatus(s.App)
+	rows = append(rows, app.Diagnostic{Label: "FILE SELECTION", Value: picker, Problem: problem})
+	if gtx.Now.Sub(s.memoryAt) >= memoryRefresh {
+		s.memory, s.memoryAt = s.App.MemoryDiagnostic(), gtx.Now
+	}
+	gtx.Execute(op.InvalidateCmd{At: s.memoryAt.Add(memoryRefresh)})
+	rows = append(rows, s.memory)
+
+	return widgets.Border(gtx, widgets.ColorBorder, func(gtx layout.Context) layout.Dimensions {
+		return widgets.CustomCard(gtx, widgets.ColorSurface, unit.Dp(20), func(gtx layout.Context) layout.Dimensions {
+			children := []layout.FlexChild{
+				layout.Rigid(material.Subtitle2(s.Theme, "Environment").Layout),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+			}
+			for _, row := range rows {
+				children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return layout.Inset{Bottom: unit.Dp(4)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						value := material.Body2(s.Theme, nonEmptyText(row.Value, "—")).Layout
+						if row.Problem {
+							value = func(gtx layout.Context) layout.Dimensions {
+								return widgets.IconLabel(gtx, s.Theme, icons.IconError, nonEmptyText(row.Value, "—"), widgets.ColorError, s.Theme.TextSize*14/16)
+							}
+						}
+						return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+							layout.Rigid(material.Caption(s.Theme, row.Label).Layout),
+							layout.Rigid(value),
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								if row.Hint == "" {
+									return layout.Dimensions{}
+								}
+								return material.Caption(s.Theme, row.Hint).Layout(gtx)
+							}),
+						)
+					})
+				}))
+			}
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+		})
+	})
+}
+
+// exportSBOM writes the embedded dependency list as a CycloneDX document to
+// a temporary file and opens it.
+func (s *AboutScreen) exportSBOM() {
+	data, err := s.App.BuildInfo.Details.SBOM(s.App.BuildInfo.Version)
+	if err != nil {
+		log.Printf("ERROR: failed to render SBOM: %v", err)
+		s.sbomStatus = "Could not generate SBOM: " + err.Error()
+		return
+	}
+	f, err := os.CreateTemp("", "vocsign-sbom-*.cdx.json")
+	if err != nil {
+		log.Printf("ERROR: failed to create SBOM file: %v", err)
+		s.sbomStatus = "Could not write SBOM: " + err.Error()
+		return
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := f.Write(data); err != nil {
+		log.Printf("ERROR: failed to write SBOM: %v", err)
+		s.sbomStatus = "Could not write SBOM: " + err.Error()
+		return
+	}
+	s.sbomStatus = "SBOM written to " + f.Name()
+	widgets.OpenFile(f.Name())
+}
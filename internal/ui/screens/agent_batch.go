@@ -0,0 +1,546 @@
+package screens
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/app"
+	"github.com/vocdoni/gofirma/vocsign/internal/batch"
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/cades"
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/certs"
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/pkcs12store"
+	"github.com/vocdoni/gofirma/vocsign/internal/demo"
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+	"github.com/vocdoni/gofirma/vocsign/internal/net"
+	"github.com/vocdoni/gofirma/vocsign/internal/presign"
+	"github.com/vocdoni/gofirma/vocsign/internal/storage"
+	"github.com/vocdoni/gofirma/vocsign/internal/telemetry"
+	"github.com/vocdoni/gofirma/vocsign/internal/ui/widgets"
+)
+
+// Row states in a batch.
+const (
+	rowPending = ""
+	rowInvalid = "invalid"
+	rowSigning = "signing"
+	rowSigned  = "signed"
+	rowFailed  = "failed"
+	rowSkipped = "skipped"
+)
+
+type batchRow struct {
+	batch.Row
+	State  string
+	Detail string
+}
+
+// BatchPanel lets a certifying agent import signer rows transcribed from
+// paper forms, then sign each one with the agent certificate and submit it.
+type BatchPanel struct {
+	App   *app.App
+	Theme *material.Theme
+
+	ImportButton widget.Clickable
+	SignButton   widget.Clickable
+	StopButton   widget.Clickable
+	ClearButton  widget.Clickable
+	ConsentCheck widget.Bool
+	PINPrompt    PINPrompt
+
+	// browser is the built-in file chooser, open when the native dialog
+	// is unavailable.
+	browser *widgets.FileBrowser
+
+	mu      sync.Mutex
+	rows    []batchRow
+	status  string
+	running bool
+	stop    atomic.Bool
+}
+
+func NewBatchPanel(a *app.App, th *material.Theme) *BatchPanel {
+	p := &BatchPanel{App: a, Theme: th}
+	p.PINPrompt.init()
+	return p
+}
+
+// Running reports whether a batch is being signed.
+func (p *BatchPanel) Running() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.running
+}
+
+func (p *BatchPanel) Layout(gtx layout.Context, req *model.SignRequest) layout.Dimensions {
+	p.mu.Lock()
+	running := p.running
+	rows := append([]batchRow(nil), p.rows...)
+	status := p.status
+	p.mu.Unlock()
+
+	if p.ImportButton.Clicked(gtx) && !running && p.browser == nil && !p.App.Managed.DisableFileImport {
+		p.importCSV(req.SignerFields())
+	}
+	if b := p.browser; b != nil {
+		if path, canceled := b.Update(gtx); canceled {
+			p.browser = nil
+		} else if path != "" {
+			p.browser = nil
+			if f, err := os.Open(path); err != nil {
+				p.setStatus("Import failed: " + err.Error())
+			} else {
+				go p.readCSV(f, req.SignerFields())
+			}
+		}
+	}
+	if p.ClearButton.Clicked(gtx) && !running {
+		p.mu.Lock()
+		p.rows, p.status = nil, ""
+		p.mu.Unlock()
+		p.ConsentCheck.Value = false
+	}
+	if p.StopButton.Clicked(gtx) && running {
+		p.stop.Store(true)
+	}
+	if p.SignButton.Clicked(gtx) && !running {
+		switch identity, ok := p.App.AgentIdentity(); {
+		case !ok:
+			p.setStatus("Choose your agent certificate in Settings first")
+		case demo.IsTest(identity.Cert):
+			p.setStatus("Validation failed: test certificates can only sign demo campaigns")
+		case !p.ConsentCheck.Value:
+			p.setStatus("Validation failed: confirm that every listed citizen signed a paper form in your presence")
+		case countRows(rows, rowPending) == 0:
+			p.setStatus("There are no valid rows left to sign")
+		default:
+			p.start(*req, identity)
+		}
+	}
+
+	pending := countRows(rows, rowPending)
+	children := []layout.FlexChild{
+		layout.Rigid(material.Subtitle2(p.Theme, "Paper forms").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+		layout.Rigid(material.Body2(p.Theme, "Import a CSV file with the citizens who signed on paper: name, surname 1, surname 2, DNI/NIE and birth date, one per line. Each row is signed with your agent certificate and submitted.").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			if running {
+				return widgets.SecondaryButton(p.Theme, &p.StopButton, "Stop After Current Row").Layout(gtx)
+			}
+			if p.App.Managed.DisableFileImport && len(rows) == 0 {
+				l := material.Body2(p.Theme, "Your administrator has disabled importing files.")
+				l.Color = widgets.ColorWarning
+				return l.Layout(gtx)
+			}
+			var buttons []layout.FlexChild
+			if !p.App.Managed.DisableFileImport {
+				buttons = append(buttons, layout.Rigid(widgets.SecondaryButton(p.Theme, &p.ImportButton, "Import CSV").Layout))
+			}
+			if len(rows) > 0 {
+				buttons = append(buttons,
+					layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+					layout.Rigid(widgets.SecondaryButton(p.Theme, &p.ClearButton, "Clear").Layout),
+					layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+					layout.Rigid(widgets.PrimaryButton(p.Theme, &p.SignButton, "Sign and Submit "+p.App.Locale().Count(pending, "Row", "Rows")).Layout),
+				)
+			}
+			return layout.Flex{Axis: layout.Horizontal}.Layout(gtx, buttons...)
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			if p.browser == nil {
+				return layout.Dimensions{}
+			}
+			return layout.Inset{Top: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return p.browser.Layout(gtx, p.Theme)
+			})
+		}),
+	}
+	if len(rows) > 0 {
+		children = append(children,
+			layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+			layout.Rigid(material.CheckBox(p.Theme, &p.ConsentCheck, "I certify that every citizen listed signed a paper form in my presence and consented to supporting this legislative initiative").Layout),
+		)
+	}
+	children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+		if pr := p.App.PendingPINRequest(); pr != nil && running {
+			return layout.Inset{Top: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return p.PINPrompt.Layout(gtx, p.Theme, pr)
+			})
+		}
+		if status == "" {
+			return layout.Dimensions{}
+		}
+		return layout.Inset{Top: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return widgets.Banner(gtx, p.Theme, statusTone(status), status)
+		})
+	}))
+	if len(rows) > 0 {
+		children = append(children, layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout))
+	}
+	for _, r := range rows {
+		children = append(children, layout.Rigid(p.rowWidget(r)))
+	}
+
+	return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	})
+}
+
+func (p *BatchPanel) rowWidget(r batchRow) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		tag, tone := "PENDING", widgets.BannerNeutral
+		switch r.State {
+		case rowInvalid:
+			tag, tone = "INVALID", widgets.BannerError
+		case rowSigning:
+			tag, tone = "SIGNING", widgets.BannerWarning
+		case rowSigned:
+			tag, tone = "SUBMITTED", widgets.BannerSuccess
+		case rowFailed:
+			tag, tone = "FAILED", widgets.BannerError
+		case rowSkipped:
+			tag, tone = "SKIPPED", widgets.BannerWarning
+		}
+		s := r.Signer
+		text := fmt.Sprintf("Line %d: %s %s %s, %s, %s", r.Line, s.Nom, s.Cognom1, s.Cognom2, s.NumIdentifica, s.DataNaixement)
+		return layout.Inset{Bottom: unit.Dp(4)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return widgets.StatusTag(gtx, p.Theme, tone, tag)
+				}),
+				layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+				layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+					if r.Detail == "" {
+						return material.Body2(p.Theme, text).Layout(gtx)
+					}
+					return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+						layout.Rigid(material.Body2(p.Theme, text).Layout),
+						layout.Rigid(material.Caption(p.Theme, r.Detail).Layout),
+					)
+				}),
+			)
+		})
+	}
+}
+
+func countRows(rows []batchRow, state string) int {
+	n := 0
+	for _, r := range rows {
+		if r.State == state {
+			n++
+		}
+	}
+	return n
+}
+
+func (p *BatchPanel) setStatus(msg string) {
+	p.mu.Lock()
+	p.status = msg
+	p.mu.Unlock()
+	p.App.Invalidate()
+}
+
+func (p *BatchPanel) setRow(i int, state, detail string) {
+	p.mu.Lock()
+	p.rows[i].State = state
+	p.rows[i].Detail = detail
+	p.mu.Unlock()
+	p.App.Invalidate()
+}
+
+func (p *BatchPanel) importCSV(fields *model.SignerFields) {
+	go func() {
+		rc, err := chooseFile(p.App, ".csv", ".txt")
+		if errors.Is(err, errNoFilePicker) {
+			p.browser = widgets.NewFileBrowser(".csv", ".txt")
+			p.App.Invalidate()
+			return
+		}
+		if err != nil {
+			return
+		}
+		p.readCSV(rc, fields)
+	}()
+}
+
+// readCSV loads the signer rows from rc, checked against the request's
+// signer fields, and closes it.
+func (p *BatchPanel) readCSV(rc io.ReadCloser, fields *model.SignerFields) {
+	parsed, err := batch.ParseCSV(rc, fields)
+	_ = rc.Close()
+	if err != nil {
+		p.setStatus("Import failed: " + err.Error())
+		return
+	}
+	rows := make([]batchRow, len(parsed))
+	invalid := 0
+	for i, r := range parsed {
+		rows[i] = batchRow{Row: r}
+		if r.Err != nil {
+			rows[i].State = rowInvalid
+			rows[i].Detail = r.Err.Error()
+			invalid++
+		}
+	}
+	p.mu.Lock()
+	p.rows = rows
+	p.mu.Unlock()
+	p.ConsentCheck.Value = false
+	loc := p.App.Locale()
+	msg := "Imported " + loc.Count(len(rows), "row", "rows") + ", ready to sign"
+	if invalid > 0 {
+		msg = fmt.Sprintf("Imported %s. %s errors and will not be signed: fix them in the file and import it again.", loc.Count(len(rows), "row", "rows"), loc.Count(invalid, "has", "have"))
+	}
+	p.setStatus(msg)
+}
+
+// start signs and submits every pending row in a goroutine. The checks
+// that do not depend on the citizen run once for the whole batch.
+func (p *BatchPanel) start(req model.SignRequest, agent pkcs12store.Identity) {
+	p.mu.Lock()
+	p.running = true
+	p.mu.Unlock()
+	p.stop.Store(false)
+	rawRef := p.App.KeepRawRequest()
+	endBusy := p.App.BeginBusy()
+
+	go func() {
+		ctx := context.Background()
+		defer func() {
+			p.mu.Lock()
+			p.running = false
+			p.mu.Unlock()
+			endBusy()
+			p.App.Invalidate()
+		}()
+
+		if err := certs.ValidateForSigning(agent.Cert, agent.Chain); err != nil {
+			p.setStatus("Certificate validation failed: " + err.Error())
+			return
+		}
+		p.setStatus("Verifying proposal document integrity...")
+		if err := net.VerifyDocuments(ctx, &req); err != nil {
+			p.setStatus(errorStatus("Document verification failed", err))
+			return
+		}
+		if pol := req.Policy; pol != nil && pol.OID != "" {
+			_, err := pol.Digest()
+			if err == nil && pol.URI != "" {
+				_, err = p.App.PolicyDocument(ctx, pol)
+			}
+			if err != nil {
+				p.setStatus(errorStatus("Security error: signature policy verification failed", err))
+				return
+			}
+		}
+
+		agentInfo := certs.CachedSpanishIdentity(agent.Cert)
+		if hooks := p.App.PreSignHooks(); len(hooks) > 0 {
+			p.setStatus("Waiting for confirmation...")
+			in := presign.Input{RequestID: req.RequestID, ProposalTitle: req.Proposal.Title, Cert: agent.Cert, Identity: agentInfo}
+			if err := presign.Run(ctx, hooks, &in, p.App.PromptCode); err != nil {
+				p.setStatus("Confirmation failed: " + err.Error())
+				return
+			}
+		}
+
+		if err := p.App.UnlockWallet(); err != nil {
+			p.setStatus("Unlock failed: " + err.Error())
+			p.App.Telemetry.Record(telemetry.EventSign, telemetry.OutcomeFailUnlock, "")
+			return
+		}
+		signer := agent.Signer
+		if signer == nil {
+			var err error
+			if signer, err = p.App.Store.Unlock(ctx, agent.ID); err != nil {
+				p.setStatus("Unlock failed: " + err.Error())
+				p.App.Telemetry.Record(telemetry.EventSign, telemetry.OutcomeFailUnlock, "")
+				return
+			}
+		}
+
+		certification := agentCertification(agentInfo, model.OrigenPaper)
+		p.mu.Lock()
+		total := len(p.rows)
+		p.mu.Unlock()
+		submitted, failed, skipped := 0, 0, 0
+		for i := 0; i < total; i++ {
+			p.mu.Lock()
+			row := p.rows[i]
+			p.mu.Unlock()
+			if row.State != rowPending {
+				continue
+			}
+			if p.stop.Load() {
+				break
+			}
+			p.setStatus(fmt.Sprintf("Signing line %d...", row.Line))
+			p.setRow(i, rowSigning, "")
+			state, detail := p.signRow(ctx, &req, row.Signer, agent, signer, certification, rawRef)
+			p.setRow(i, state, detail)
+			switch state {
+			case rowSigned:
+				submitted++
+			case rowSkipped:
+				skipped++
+			default:
+				failed++
+			}
+		}
+
+		msg := fmt.Sprintf("Batch finished: %d submitted, %d failed, %d skipped", submitted, failed, skipped)
+		if p.stop.Load() {
+			msg = fmt.Sprintf("Batch stopped: %d submitted, %d failed, %d skipped", submitted, failed, skipped)
+		}
+		p.setStatus(msg)
+	}()
+}
+
+// signRow signs and submits one citizen's signature and records it in the
+// audit log. It returns the row's new state and a detail for display.
+func (p *BatchPanel) signRow(ctx context.Context, req *model.SignRequest, citizen model.Signant, agent pkcs12store.Identity, signer crypto.Signer, certification model.Certificacio, rawRef string) (string, string) {
+	if req.DuplicateCheck != nil {
+		dup, err := net.CheckDuplicate(ctx, req, citizen.NumIdentifica)
+		if err != nil {
+			log.Printf("WARNING: duplicate check failed: %v", err)
+		} else if dup {
+			p.App.Telemetry.Record(telemetry.EventSign, telemetry.OutcomeAlreadySigned, "")
+			return rowSkipped, "Already signed this proposal"
+		}
+	}
+
+	// A citizen whose earlier submission was interrupted is signed again
+	// only if the collector says it did not receive it. Unattended batches
+	// skip them when it cannot tell; the agent signs them one by one.
+	journal := journalEntry(req, agent.Cert, citizen.NumIdentifica)
+	if prior, ok := p.App.InterruptedSubmission(journal); ok {
+		receipt, err := net.LookupReceipt(ctx, req, prior.SignatureSHA256)
+		if err != nil {
+			log.Printf("WARNING: receipt lookup failed: %v", err)
+			return rowSkipped, "A previous submission was interrupted and may have been received"
+		}
+		if receipt != nil {
+			p.earlierReceipt(req, prior, receipt, citizen, rawRef)
+			return rowSigned, "Already accepted earlier, receipt " + nonEmptyText(receipt.ReceiptID, "not given")
+		}
+	}
+
+	xmlBytes, err := model.GenerateCertifiedILPXML(req, citizen, certification)
+	if err != nil {
+		p.App.Telemetry.Record(telemetry.EventSign, telemetry.OutcomeFailSigning, "")
+		return rowFailed, "XML generation failed: " + err.Error()
+	}
+	payloadHash := sha256.Sum256(xmlBytes)
+	journal.Stage = storage.StageSigning
+	journal.PayloadSHA256 = hex.EncodeToString(payloadHash[:])
+	p.App.RecordSigning(journal)
+	defer p.App.FinishSigning(journal)
+
+	signatureDER, err := cades.SignDetached(ctx, signer, agent.Cert, agent.Chain, xmlBytes, cades.SignOpts{
+		SigningTime: time.Now(),
+		Policy:      req.Policy,
+	})
+	if err != nil {
+		p.App.Telemetry.Record(telemetry.EventSign, telemetry.OutcomeFailSigning, "")
+		if errors.Is(err, pkcs12store.ErrPINCanceled) || errors.Is(err, pkcs12store.ErrPasswordCanceled) {
+			p.stop.Store(true)
+		}
+		return rowFailed, errorStatus("Signing failed", err)
+	}
+	var timestampTokenB64 string
+	if tsaURL := os.Getenv("VOCSIGN_TSA_URL"); tsaURL != "" {
+		if tsToken, err := cades.RequestTimestamp(tsaURL, signatureDER); err != nil {
+			log.Printf("WARNING: timestamp request failed: %v", err)
+		} else {
+			timestampTokenB64 = base64.StdEncoding.EncodeToString(tsToken)
+		}
+	}
+
+	signatureHash := sha256.Sum256(signatureDER)
+	resp := newSignResponse(req, xmlBytes, signatureDER, agent.Cert, agent.Chain, timestampTokenB64)
+	auditEntry := storage.AuditEntry{
+		RequestID:       req.RequestID,
+		ProposalTitle:   req.Proposal.Title,
+		SignerName:      citizen.Nom + " " + citizen.Cognom1 + " " + citizen.Cognom2,
+		SignerDNI:       citizen.NumIdentifica,
+		CallbackHost:    urlHost(req.Callback.URL),
+		CertFingerprint: fmt.Sprintf("%x", pkcs12store.Fingerprint(agent.Cert)),
+		PayloadSHA256:   hex.EncodeToString(payloadHash[:]),
+		SignatureSHA256: hex.EncodeToString(signatureHash[:]),
+		AgentCertified:  true,
+	}
+	if req.Policy != nil {
+		auditEntry.PolicyOID = req.Policy.OID
+	}
+	if h, err := req.CanonicalHash(); err == nil {
+		auditEntry.RequestHash = h
+	}
+	auditEntry.RawRequestSHA256 = rawRef
+
+	journal.Stage = storage.StageSubmitting
+	journal.SignatureSHA256 = auditEntry.SignatureSHA256
+	journal.Response, _ = json.Marshal(resp)
+	p.App.RecordSigning(journal)
+
+	receipt, finalURL, err := net.SubmitTraced(ctx, req.Callback.URL, resp)
+	if host := urlHost(finalURL); finalURL != "" && host != auditEntry.CallbackHost {
+		auditEntry.FinalHost = host
+	}
+	state, detail := rowSigned, ""
+	if err != nil {
+		p.App.Telemetry.Record(telemetry.EventSign, telemetry.OutcomeFailSubmission, "")
+		auditEntry.Status = "fail"
+		auditEntry.Error = err.Error()
+		auditEntry.ErrorCode = string(errcode.Of(err))
+		state, detail = rowFailed, errorStatus("Submission failed", err)
+	} else {
+		p.App.Telemetry.Record(telemetry.EventSign, telemetry.OutcomeSuccess, "")
+		auditEntry.Status = "success"
+		auditEntry.ServerAckID = receipt.ReceiptID
+		auditEntry.ReceiptStatus = receipt.Status
+		if fp, err := p.App.KeepCounterSignature(req, resp, receipt); err != nil {
+			log.Printf("WARNING: collector countersignature not kept: %v", err)
+		} else {
+			auditEntry.CounterSignerFingerprint = fp
+		}
+		if receipt.ReceiptID != "" {
+			detail = "Receipt " + receipt.ReceiptID
+		}
+	}
+	if err := p.App.AuditLogger.Log(auditEntry); err != nil {
+		log.Printf("ERROR: failed to write audit log: %v", err)
+	}
+	p.App.RecordBatchResult(req.RequestID, auditEntry.Status)
+	return state, detail
+}
+
+// earlierReceipt records in the audit log a citizen's interrupted submission
+// prior that the collector says it accepted.
+func (p *BatchPanel) earlierReceipt(req *model.SignRequest, prior storage.JournalEntry, receipt *model.SubmitReceipt, citizen model.Signant, rawRef string) {
+	auditEntry := earlierAuditEntry(req, prior, receipt, citizen, true)
+	auditEntry.RawRequestSHA256 = rawRef
+	if err := p.App.AuditLogger.Log(auditEntry); err != nil {
+		log.Printf("ERROR: failed to write audit log: %v", err)
+	}
+	p.App.DismissInterrupted(prior)
+	p.App.Telemetry.Record(telemetry.EventSign, telemetry.OutcomeAlreadySigned, "")
+	p.App.RecordBatchResult(req.RequestID, auditEntry.Status)
+}
@@ -1,13 +1,22 @@
 package screens
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
 	"gioui.org/font"
 	"gioui.org/layout"
 	"gioui.org/unit"
 	"gioui.org/widget"
 	"gioui.org/widget/material"
+	"gioui.org/x/explorer"
 
 	"github.com/vocdoni/gofirma/vocsign/internal/app"
+	"github.com/vocdoni/gofirma/vocsign/internal/net"
 	"github.com/vocdoni/gofirma/vocsign/internal/storage"
 	"github.com/vocdoni/gofirma/vocsign/internal/ui/icons"
 	"github.com/vocdoni/gofirma/vocsign/internal/ui/widgets"
@@ -17,20 +26,39 @@ type AuditScreen struct {
 	App   *app.App
 	Theme *material.Theme
 
-	List    widget.List
+	List    widgets.VirtualList
 	Entries []storage.AuditEntry
 	Refresh widget.Clickable
 
-	Editors map[string]*widget.Editor
+	// Editors make the request IDs of the rows laid out selectable.
+	Editors *widgets.Cache[widget.Editor]
+	// SaveRequest buttons save the request bytes an entry was signed
+	// against, as kept under its rawRequestSha256.
+	SaveRequest   *widgets.Cache[widget.Clickable]
+	requestMu     sync.Mutex
+	requestStatus string
+	requestFailed bool
+
+	// Certifying agents send their history to the organizers, signed with
+	// the certificate chosen here.
+	AgentEnum  widget.Enum
+	SyncButton widget.Clickable
+	ExportBtn  widget.Clickable
+	PINPrompt  PINPrompt
+
+	agentBusy   bool
+	agentStatus string
 }
 
 func NewAuditScreen(a *app.App, th *material.Theme) *AuditScreen {
 	s := &AuditScreen{
-		App:     a,
-		Theme:   th,
-		Editors: make(map[string]*widget.Editor),
+		App:         a,
+		Theme:       th,
+		Editors:     widgets.NewCache[widget.Editor](rowCacheSize),
+		SaveRequest: widgets.NewCache[widget.Clickable](rowCacheSize),
 	}
 	s.List.Axis = layout.Vertical
+	s.PINPrompt.init()
 	s.RefreshEntries()
 	return s
 }
@@ -48,10 +76,32 @@ func (s *AuditScreen) RefreshEntries() {
 	}()
 }
 
+// entryKey identifies the entry at index i across refreshes.
+func (s *AuditScreen) entryKey(i int) string {
+	return s.Entries[i].RequestID + s.Entries[i].Timestamp
+}
+
 func (s *AuditScreen) Layout(gtx layout.Context) layout.Dimensions {
 	if s.Refresh.Clicked(gtx) {
 		s.RefreshEntries()
 	}
+	if s.SyncButton.Clicked(gtx) && !s.agentBusy {
+		s.syncWithOrganizers()
+	}
+	if s.ExportBtn.Clicked(gtx) && !s.agentBusy {
+		s.exportSigned()
+	}
+	for i, e := range s.Entries {
+		if btn, ok := s.SaveRequest.Peek(s.entryKey(i)); ok && btn.Clicked(gtx) {
+			s.saveRequest(e)
+		}
+	}
+	s.requestMu.Lock()
+	requestStatus, requestTone := s.requestStatus, widgets.BannerSuccess
+	if s.requestFailed {
+		requestTone = widgets.BannerError
+	}
+	s.requestMu.Unlock()
 
 	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
 		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
@@ -66,6 +116,20 @@ func (s *AuditScreen) Layout(gtx layout.Context) layout.Dimensions {
 			)
 		}),
 		layout.Rigid(layout.Spacer{Height: unit.Dp(24)}.Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			if requestStatus == "" {
+				return layout.Dimensions{}
+			}
+			return layout.Inset{Bottom: unit.Dp(12)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return widgets.Banner(gtx, s.Theme, requestTone, requestStatus)
+			})
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			if len(s.Entries) == 0 || !s.App.Settings.Get().AgentMode() {
+				return layout.Dimensions{}
+			}
+			return layout.Inset{Bottom: unit.Dp(12)}.Layout(gtx, s.layoutAgentSync)
+		}),
 
 		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
 			if len(s.Entries) == 0 {
@@ -76,15 +140,14 @@ func (s *AuditScreen) Layout(gtx layout.Context) layout.Dimensions {
 					})
 				})
 			}
-			return material.List(s.Theme, &s.List).Layout(gtx, len(s.Entries), func(gtx layout.Context, index int) layout.Dimensions {
+			return s.List.Layout(gtx, s.Theme, len(s.Entries), s.entryKey, func(gtx layout.Context, index int) layout.Dimensions {
+				defer s.App.Perf.Section("audit/row")()
 				entry := s.Entries[index]
 
-				key := entry.RequestID + entry.Timestamp
-				if _, ok := s.Editors[key]; !ok {
-					e := &widget.Editor{ReadOnly: true}
+				requestID := s.Editors.Get(s.entryKey(index), func(e *widget.Editor) {
+					e.ReadOnly = true
 					e.SetText(entry.RequestID)
-					s.Editors[key] = e
-				}
+				})
 
 				return layout.Inset{Bottom: unit.Dp(12)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 					return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
@@ -93,16 +156,29 @@ func (s *AuditScreen) Layout(gtx layout.Context) layout.Dimensions {
 								return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
 									layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 										statusTxt := "SUCCESS"
-										statusClr := widgets.ColorSuccess
-										if entry.Status != "success" {
+										statusTone := widgets.BannerSuccess
+										switch entry.Status {
+										case "success":
+										case "canceled":
+											statusTxt = "CANCELED"
+											statusTone = widgets.BannerWarning
+										default:
 											statusTxt = "FAILED"
-											statusClr = widgets.ColorError
+											statusTone = widgets.BannerError
 										}
 
-										return widgets.Tag(gtx, s.Theme, statusTxt, statusClr)
+										return widgets.StatusTag(gtx, s.Theme, statusTone, statusTxt)
+									}),
+									layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+										if !entry.AgentCertified {
+											return layout.Dimensions{}
+										}
+										return layout.Inset{Left: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+											return widgets.Tag(gtx, s.Theme, "AGENT", s.Theme.ContrastBg)
+										})
 									}),
 									layout.Rigid(layout.Spacer{Width: unit.Dp(12)}.Layout),
-									layout.Rigid(material.Caption(s.Theme, entry.Timestamp).Layout),
+									layout.Rigid(material.Caption(s.Theme, s.App.Locale().Timestamp(entry.Timestamp)).Layout),
 								)
 							}),
 							layout.Rigid(layout.Spacer{Height: unit.Dp(12)}.Layout),
@@ -123,10 +199,35 @@ func (s *AuditScreen) Layout(gtx layout.Context) layout.Dimensions {
 							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 								return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
 									layout.Rigid(material.Caption(s.Theme, "Request ID: ").Layout),
-									layout.Flexed(1, material.Editor(s.Theme, s.Editors[key], "").Layout),
+									layout.Flexed(1, material.Editor(s.Theme, requestID, "").Layout),
 								)
 							}),
-							layout.Rigid(material.Caption(s.Theme, "Target Host: "+entry.CallbackHost).Layout),
+							layout.Rigid(material.Caption(s.Theme, "Target Host: "+net.DisplayHost(entry.CallbackHost)).Layout),
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								if entry.FinalHost == "" {
+									return layout.Dimensions{}
+								}
+								l := material.Caption(s.Theme, "Redirected to: "+net.DisplayHost(entry.FinalHost)+" (this server received the signature)")
+								l.Color = widgets.ColorWarning
+								return l.Layout(gtx)
+							}),
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								if entry.LegalAck == "" {
+									return layout.Dimensions{}
+								}
+								txt := "Legal statement acknowledged"
+								if entry.LegalAckInitials != "" {
+									txt += " (initials " + entry.LegalAckInitials + ")"
+								}
+								return material.Caption(s.Theme, txt).Layout(gtx)
+							}),
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								if entry.RawRequestSHA256 == "" {
+									return layout.Dimensions{}
+								}
+								btn := s.SaveRequest.Get(s.entryKey(index), nil)
+								return layout.Inset{Top: unit.Dp(8)}.Layout(gtx, widgets.SecondaryButton(s.Theme, btn, "Save signed request").Layout)
+							}),
 							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 								if entry.Error != "" {
 									return layout.Inset{Top: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
@@ -142,3 +243,171 @@ func (s *AuditScreen) Layout(gtx layout.Context) layout.Dimensions {
 		}),
 	)
 }
+
+// layoutAgentSync lets certifying agents upload or export the history of the
+// signatures they collected, signed with their own certificate.
+func (s *AuditScreen) layoutAgentSync(gtx layout.Context) layout.Dimensions {
+	identities := append(s.App.IdentitiesSnapshot(), s.App.SystemIdentitiesSnapshot()...)
+	if len(identities) == 0 {
+		return layout.Dimensions{}
+	}
+	if s.AgentEnum.Value == "" {
+		s.AgentEnum.Value = identities[0].ID
+		if id, ok := s.App.AgentIdentity(); ok {
+			s.AgentEnum.Value = id.ID
+		}
+	}
+
+	children := []layout.FlexChild{
+		layout.Rigid(material.Subtitle2(s.Theme, "Certifying agent").Layout),
+		layout.Rigid(material.Caption(s.Theme, "If you collect signatures on behalf of the promoters, send this history to the organizers so they can reconcile it with their records. It is signed with the certificate chosen below.").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+	}
+	for _, id := range identities {
+		children = append(children, layout.Rigid(material.RadioButton(s.Theme, &s.AgentEnum, id.ID, id.FriendlyName).Layout))
+	}
+	children = append(children,
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			syncLabel := "Sync with organizers"
+			if s.agentBusy {
+				syncLabel = "Working..."
+			}
+			return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+				layout.Rigid(widgets.PrimaryButton(s.Theme, &s.SyncButton, syncLabel).Layout),
+				layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+				layout.Rigid(widgets.SecondaryButton(s.Theme, &s.ExportBtn, "Export signed history").Layout),
+			)
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			if pr := s.App.PendingPINRequest(); pr != nil && s.agentBusy {
+				return layout.Inset{Top: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return s.PINPrompt.Layout(gtx, s.Theme, pr)
+				})
+			}
+			if s.agentStatus == "" {
+				return layout.Dimensions{}
+			}
+			return layout.Inset{Top: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return widgets.Banner(gtx, s.Theme, statusTone(s.agentStatus), s.agentStatus)
+			})
+		}),
+	)
+
+	return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	})
+}
+
+func (s *AuditScreen) syncWithOrganizers() {
+	agentID := s.AgentEnum.Value
+	s.agentBusy = true
+	s.agentStatus = ""
+	go func() {
+		defer func() {
+			s.agentBusy = false
+			s.App.Invalidate()
+		}()
+		res, err := s.App.SyncAuditLog(context.Background(), agentID)
+		if err != nil {
+			log.Printf("ERROR: audit sync failed: %v", err)
+			s.agentStatus = "Sync failed: " + err.Error()
+			return
+		}
+		switch {
+		case res.Requests == 0 && res.Unsupported > 0:
+			s.agentStatus = "Nothing was sent: the organizers of these requests do not accept history uploads. Use Export signed history instead."
+		case res.Requests == 0:
+			s.agentStatus = "Everything is already synced"
+		default:
+			loc := s.App.Locale()
+			s.agentStatus = fmt.Sprintf("Synced %s: %s accepted, %s already on the server", loc.Count(res.Requests, "request", "requests"), loc.Count(res.Accepted, "entry", "entries"), loc.Int(res.Duplicates))
+		}
+	}()
+}
+
+func (s *AuditScreen) exportSigned() {
+	agentID := s.AgentEnum.Value
+	s.agentBusy = true
+	s.agentStatus = ""
+	go func() {
+		defer func() {
+			s.agentBusy = false
+			s.App.Invalidate()
+		}()
+		if s.App.Explorer == nil {
+			s.agentStatus = "Export failed: the system file dialog is not available"
+			return
+		}
+		w, err := s.App.Explorer.CreateFile("vocsign-audit-" + time.Now().Format("20060102") + ".json")
+		if err != nil {
+			log.Printf("WARNING: audit export canceled: %v", err)
+			if !errors.Is(err, explorer.ErrUserDecline) {
+				s.agentStatus = "Export failed: the system file dialog could not be opened"
+			}
+			return
+		}
+		err = s.App.ExportAuditLog(context.Background(), agentID, w)
+		if cerr := w.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			log.Printf("ERROR: audit export failed: %v", err)
+			s.agentStatus = "Export failed: " + err.Error()
+			return
+		}
+		s.agentStatus = "Signed history exported and ready to send to the organizer"
+	}()
+}
+
+// setRequestStatus reports the outcome of saving a signed request.
+func (s *AuditScreen) setRequestStatus(status string, failed bool) {
+	s.requestMu.Lock()
+	s.requestStatus, s.requestFailed = status, failed
+	s.requestMu.Unlock()
+	s.App.Invalidate()
+}
+
+// saveRequest lets the user save the request entry was signed against,
+// byte for byte as fetched, after checking it still hashes to the
+// rawRequestSha256 the entry recorded.
+func (s *AuditScreen) saveRequest(entry storage.AuditEntry) {
+	go func() {
+		raw, err := s.App.RawRequest(entry.RawRequestSHA256)
+		switch {
+		case errors.Is(err, storage.ErrRawMismatch):
+			log.Printf("WARNING: stored request %s does not match its hash", entry.RawRequestSHA256)
+			s.setRequestStatus("The stored copy of this request was changed after signing and cannot be trusted", true)
+			return
+		case err != nil:
+			log.Printf("ERROR: failed to read stored request %s: %v", entry.RawRequestSHA256, err)
+			s.setRequestStatus("The stored request could not be read: "+err.Error(), true)
+			return
+		case raw == nil:
+			s.setRequestStatus("The request this signature was made against is no longer on file", true)
+			return
+		}
+		if s.App.Explorer == nil {
+			s.setRequestStatus("Saving failed: the system file dialog is not available", true)
+			return
+		}
+		w, err := s.App.Explorer.CreateFile("vocsign-request-" + entry.RawRequestSHA256[:12] + ".json")
+		if err != nil {
+			log.Printf("WARNING: request save canceled: %v", err)
+			if !errors.Is(err, explorer.ErrUserDecline) {
+				s.setRequestStatus("Saving failed: the system file dialog could not be opened", true)
+			}
+			return
+		}
+		_, err = w.Write(raw)
+		if cerr := w.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			log.Printf("ERROR: failed to save request: %v", err)
+			s.setRequestStatus("Saving failed: "+err.Error(), true)
+			return
+		}
+		s.setRequestStatus("Request saved, exactly as it was when signed (SHA-256 "+entry.RawRequestSHA256+")", false)
+	}()
+}
@@ -0,0 +1,51 @@
+package screens
+
+import (
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/pkcs12store"
+)
+
+// collapseCertPairs shows each signature and authentication pair in ids as
+// its signature certificate, and moves a selection of the authentication
+// certificate to it.
+func (s *RequestDetailsScreen) collapseCertPairs(ids []pkcs12store.Identity) []pkcs12store.Identity {
+	ids, s.certPairs = pkcs12store.CollapsePairs(ids)
+	for _, p := range s.certPairs {
+		if s.CertEnum.Value == p.Authentication.ID {
+			s.CertEnum.Value = p.Signature.ID
+		}
+	}
+	return ids
+}
+
+// layoutCertPair explains that the selected certificate has an
+// authentication twin, and lets advanced users sign with it instead.
+func (s *RequestDetailsScreen) layoutCertPair(gtx layout.Context) layout.Dimensions {
+	p, ok := s.certPairs[s.CertEnum.Value]
+	if !ok {
+		return layout.Dimensions{}
+	}
+	return layout.Inset{Top: unit.Dp(6)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(material.Caption(s.Theme, "This certificate comes with an authentication certificate for the same holder ("+p.Authentication.FriendlyName+"). VocSign signs with the signature certificate.").Layout),
+			layout.Rigid(material.CheckBox(s.Theme, &s.PairAuthCheck, "Advanced: sign with the authentication certificate instead").Layout),
+		)
+	})
+}
+
+// pairedSigningIdentity returns the certificate to sign with when identity
+// is selected: the authentication certificate of its pair if the user chose
+// it, otherwise identity itself.
+func (s *RequestDetailsScreen) pairedSigningIdentity(identity *pkcs12store.Identity) *pkcs12store.Identity {
+	p, ok := s.certPairs[identity.ID]
+	if !ok || !s.PairAuthCheck.Value {
+		return identity
+	}
+	if auth := s.findIdentity(p.Authentication.ID); auth != nil {
+		return auth
+	}
+	return identity
+}
@@ -2,20 +2,27 @@ package screens
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"image/color"
+	"io"
 	"log"
 	"strings"
+	"sync"
 	"time"
 
 	"gioui.org/font"
+	"gioui.org/io/clipboard"
 	"gioui.org/layout"
 	"gioui.org/unit"
 	"gioui.org/widget"
 	"gioui.org/widget/material"
+	"gioui.org/x/explorer"
 
 	"github.com/vocdoni/gofirma/vocsign/internal/app"
 	"github.com/vocdoni/gofirma/vocsign/internal/crypto/certs"
 	"github.com/vocdoni/gofirma/vocsign/internal/crypto/pkcs12store"
+	"github.com/vocdoni/gofirma/vocsign/internal/demo"
 	"github.com/vocdoni/gofirma/vocsign/internal/ui/icons"
 	"github.com/vocdoni/gofirma/vocsign/internal/ui/widgets"
 )
@@ -24,32 +31,70 @@ type CertificatesScreen struct {
 	App   *app.App
 	Theme *material.Theme
 
-	List         widget.List
+	List         widgets.VirtualList
 	DetailsList  widget.List
 	WizardButton widget.Clickable
 
-	DeleteButtons   map[string]*widget.Clickable
-	Clickables      map[string]*widget.Clickable
+	DeleteButtons   *widgets.Cache[widget.Clickable]
+	Clickables      *widgets.Cache[widget.Clickable]
 	ConfirmDelete   widget.Clickable
 	CancelDelete    widget.Clickable
 	pendingDeleteID string
 
+	trash          []pkcs12store.TrashedIdentity
+	rows           []walletRow
+	trashLoaded    bool
+	RestoreButtons *widgets.Cache[widget.Clickable]
+	status         string
+	// PurgeButtons delete a trashed identity for good, and EmptyTrash all
+	// of them, after confirming with ConfirmPurge.
+	PurgeButtons *widgets.Cache[widget.Clickable]
+	EmptyTrash   widget.Clickable
+	ConfirmPurge widget.Clickable
+	CancelPurge  widget.Clickable
+	pendingPurge *pkcs12store.TrashedIdentity
+	pendingEmpty bool
+
+	healthMu      sync.Mutex
+	health        map[string]pkcs12store.Health
+	healthPending map[string]bool
+	CheckHealth   widget.Clickable
+	RepairButton  widget.Clickable
+	repairing     bool
+	// CopyDetails and ExportDetails hand the selected certificate's
+	// details to the user, e.g. for the CA's support.
+	CopyDetails   widget.Clickable
+	ExportDetails widget.Clickable
+
 	selectedID   string
 	selectedInfo certs.ExtractedInfo
 
-	SubjectEditor widget.Editor
+	// SearchEditor filters the wallet; matches are the search results by
+	// identity ID, for highlighting.
+	SearchEditor widget.Editor
+	query        string
+	matches      map[string][]pkcs12store.SearchMatch
+
+	// subjectText presents the raw subject of the selected certificate.
+	subjectText widgets.TextPresenter
 }
 
 func NewCertificatesScreen(a *app.App, th *material.Theme) *CertificatesScreen {
 	s := &CertificatesScreen{
 		App:           a,
 		Theme:         th,
-		DeleteButtons: make(map[string]*widget.Clickable),
-		Clickables:    make(map[string]*widget.Clickable),
+		DeleteButtons: widgets.NewCache[widget.Clickable](rowCacheSize),
+		Clickables:    widgets.NewCache[widget.Clickable](rowCacheSize),
+
+		RestoreButtons: widgets.NewCache[widget.Clickable](rowCacheSize),
+		PurgeButtons:   widgets.NewCache[widget.Clickable](rowCacheSize),
+		health:         make(map[string]pkcs12store.Health),
+		healthPending:  make(map[string]bool),
 	}
 	s.List.Axis = layout.Vertical
 	s.DetailsList.Axis = layout.Vertical
-	s.SubjectEditor.ReadOnly = true
+	s.subjectText = widgets.TextPresenter{Lines: 3, Mono: true}
+	s.SearchEditor.SingleLine = true
 	return s
 }
 
@@ -61,7 +106,7 @@ func (s *CertificatesScreen) Layout(gtx layout.Context) layout.Dimensions {
 	}
 
 	for _, id := range identities {
-		if btn, ok := s.DeleteButtons[id.ID]; ok && btn.Clicked(gtx) {
+		if btn, ok := s.DeleteButtons.Peek(id.ID); ok && btn.Clicked(gtx) {
 			s.pendingDeleteID = id.ID
 		}
 	}
@@ -73,11 +118,10 @@ func (s *CertificatesScreen) Layout(gtx layout.Context) layout.Dimensions {
 			if err := s.App.Store.Delete(ctx, targetID); err != nil {
 				log.Printf("ERROR: failed to delete identity %s: %v", targetID, err)
 			}
-			ids, _ := s.App.Store.List(ctx)
-			s.App.SetIdentities(ids)
 			if s.selectedID == targetID {
 				s.selectedID = ""
 			}
+			s.trashLoaded = false
 			s.App.Invalidate()
 		}()
 	}
@@ -85,6 +129,53 @@ func (s *CertificatesScreen) Layout(gtx layout.Context) layout.Dimensions {
 		s.pendingDeleteID = ""
 	}
 
+	for i, t := range s.trash {
+		if btn, ok := s.RestoreButtons.Peek(t.ID); ok && btn.Clicked(gtx) {
+			s.restore(t)
+		}
+		if btn, ok := s.PurgeButtons.Peek(t.ID); ok && btn.Clicked(gtx) {
+			s.pendingPurge, s.pendingEmpty = &s.trash[i], false
+		}
+	}
+	if s.EmptyTrash.Clicked(gtx) {
+		s.pendingPurge, s.pendingEmpty = nil, true
+	}
+	if s.ConfirmPurge.Clicked(gtx) && (s.pendingPurge != nil || s.pendingEmpty) {
+		s.purge(s.pendingPurge)
+		s.pendingPurge, s.pendingEmpty = nil, false
+	}
+	if s.CancelPurge.Clicked(gtx) {
+		s.pendingPurge, s.pendingEmpty = nil, false
+	}
+	if !s.trashLoaded {
+		s.loadTrash()
+	}
+
+	s.checkHealth(identities, false)
+	if s.CheckHealth.Clicked(gtx) && s.selectedID != "" {
+		if id := s.findIdentity(s.selectedID); id != nil {
+			s.checkHealth([]pkcs12store.Identity{*id}, true)
+		}
+	}
+	if s.RepairButton.Clicked(gtx) && s.selectedID != "" && !s.repairing {
+		if id := s.findIdentity(s.selectedID); id != nil {
+			s.repairReference(*id)
+		}
+	}
+
+	if s.CopyDetails.Clicked(gtx) && s.selectedID != "" {
+		if id := s.findIdentity(s.selectedID); id != nil {
+			text := certs.Describe(id.Cert, id.Chain).Text()
+			gtx.Execute(clipboard.WriteCmd{Type: "application/text", Data: io.NopCloser(strings.NewReader(text))})
+			s.status = "Certificate details copied to the clipboard"
+		}
+	}
+	if s.ExportDetails.Clicked(gtx) && s.selectedID != "" {
+		if id := s.findIdentity(s.selectedID); id != nil {
+			s.exportDetails(*id)
+		}
+	}
+
 	var pendingName string
 	if s.pendingDeleteID != "" {
 		for _, id := range identities {
@@ -95,10 +186,29 @@ func (s *CertificatesScreen) Layout(gtx layout.Context) layout.Dimensions {
 		}
 	}
 
+	s.query = strings.TrimSpace(s.SearchEditor.Text())
+	s.matches = nil
+	if s.query != "" {
+		s.matches = make(map[string][]pkcs12store.SearchMatch)
+		for _, id := range identities {
+			if m := pkcs12store.Search(id, s.query); m != nil {
+				s.matches[id.ID] = m
+			}
+		}
+		for _, t := range s.trash {
+			if m := pkcs12store.Search(t.Identity, s.query); m != nil {
+				s.matches["trash/"+t.ID] = m
+			}
+		}
+	}
+
 	// Group identities
 	groups := groupedIdentities{}
 	for _, id := range identities {
-		info := certs.ExtractSpanishIdentity(id.Cert)
+		if s.query != "" && s.matches[id.ID] == nil {
+			continue
+		}
+		info := certs.CachedSpanishIdentity(id.Cert)
 		if info.IsRepresentative {
 			groups.Representation = append(groups.Representation, id)
 		} else {
@@ -119,6 +229,14 @@ func (s *CertificatesScreen) Layout(gtx layout.Context) layout.Dimensions {
 			)
 		}),
 		layout.Rigid(layout.Spacer{Height: unit.Dp(24)}.Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			if s.status == "" {
+				return layout.Dimensions{}
+			}
+			return layout.Inset{Bottom: unit.Dp(16)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return widgets.Banner(gtx, s.Theme, statusTone(s.status), s.status)
+			})
+		}),
 		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 			if s.pendingDeleteID == "" {
 				return layout.Dimensions{}
@@ -127,7 +245,7 @@ func (s *CertificatesScreen) Layout(gtx layout.Context) layout.Dimensions {
 				return widgets.Border(gtx, widgets.ColorWarning, func(gtx layout.Context) layout.Dimensions {
 					return layout.UniformInset(unit.Dp(8)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 						return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
-							layout.Flexed(1, material.Body2(s.Theme, "Delete certificate: "+pendingName+" ?").Layout),
+							layout.Flexed(1, material.Body2(s.Theme, "Delete certificate: "+pendingName+"? It can be restored for 30 days.").Layout),
 							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 								btn := material.Button(s.Theme, &s.ConfirmDelete, "Delete")
 								btn.Background = widgets.ColorError
@@ -145,6 +263,7 @@ func (s *CertificatesScreen) Layout(gtx layout.Context) layout.Dimensions {
 				})
 			})
 		}),
+		layout.Rigid(s.layoutConfirmPurge),
 
 		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
 			return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
@@ -152,40 +271,32 @@ func (s *CertificatesScreen) Layout(gtx layout.Context) layout.Dimensions {
 				layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
 					gtx.Constraints.Min.Y = gtx.Constraints.Max.Y
 					return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
-						var widgetsToRender []layout.Widget
-						if len(groups.Personal) > 0 {
-							widgetsToRender = append(widgetsToRender, func(gtx layout.Context) layout.Dimensions {
-								return material.Caption(s.Theme, "PERSONAL CERTIFICATES").Layout(gtx)
-							})
-							for _, id := range groups.Personal {
-								widgetsToRender = append(widgetsToRender, s.certificateRow(id))
-							}
-						}
-						if len(groups.Representation) > 0 {
-							if len(groups.Personal) > 0 {
-								widgetsToRender = append(widgetsToRender, func(gtx layout.Context) layout.Dimensions {
-									return layout.Spacer{Height: unit.Dp(16)}.Layout(gtx)
+						s.rows = s.walletRows(s.rows[:0], groups)
+						return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								if len(identities) == 0 && len(s.trash) == 0 {
+									return layout.Dimensions{}
+								}
+								return layout.Inset{Bottom: unit.Dp(12)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+									return widgets.Border(gtx, widgets.ColorBorder, func(gtx layout.Context) layout.Dimensions {
+										return layout.UniformInset(unit.Dp(8)).Layout(gtx, material.Editor(s.Theme, &s.SearchEditor, "Search by name, DNI, issuer, organization or fingerprint").Layout)
+									})
 								})
-							}
-							widgetsToRender = append(widgetsToRender, func(gtx layout.Context) layout.Dimensions {
-								l := material.Caption(s.Theme, "REPRESENTATION CERTIFICATES")
-								l.Color = widgets.ColorWarning
-								return layout.Inset{Bottom: unit.Dp(8)}.Layout(gtx, l.Layout)
-							})
-							for _, id := range groups.Representation {
-								widgetsToRender = append(widgetsToRender, s.certificateRow(id))
-							}
-						}
-
-						if len(widgetsToRender) == 0 {
-							return widgets.CenterInAvailable(gtx, func(gtx layout.Context) layout.Dimensions {
-								return widgets.EmptyState(gtx, s.Theme, "Wallet is empty", "Import a certificate to start signing.")
-							})
-						}
-
-						return material.List(s.Theme, &s.List).Layout(gtx, len(widgetsToRender), func(gtx layout.Context, index int) layout.Dimensions {
-							return widgetsToRender[index](gtx)
-						})
+							}),
+							layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+								if len(s.rows) == 0 && s.query != "" {
+									return widgets.CenterInAvailable(gtx, func(gtx layout.Context) layout.Dimensions {
+										return widgets.EmptyState(gtx, s.Theme, "No matches", "No certificate matches \""+s.query+"\".")
+									})
+								}
+								if len(s.rows) == 0 {
+									return widgets.CenterInAvailable(gtx, func(gtx layout.Context) layout.Dimensions {
+										return widgets.EmptyState(gtx, s.Theme, "Wallet is empty", "Import a certificate to start signing.")
+									})
+								}
+								return s.List.Layout(gtx, s.Theme, len(s.rows), s.rowKey, s.layoutRow)
+							}),
+						)
 					})
 				}),
 				layout.Rigid(layout.Spacer{Width: unit.Dp(24)}.Layout),
@@ -229,12 +340,20 @@ func (s *CertificatesScreen) Layout(gtx layout.Context) layout.Dimensions {
 								layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 									return s.propertySection(gtx, "VALIDITY", []property{
 										{"Issuer", s.selectedInfo.Issuer},
-										{"Expires", s.selectedInfo.ValidUntil},
+										{"Expires", s.expiryDate()},
 										{"Status", certStatusLabel(s.findIdentity(s.selectedID))},
 									})
 								}),
 								layout.Rigid(layout.Spacer{Height: unit.Dp(16)}.Layout),
 
+								// Key Usage Section
+								layout.Rigid(s.layoutKeyUsage),
+								layout.Rigid(layout.Spacer{Height: unit.Dp(16)}.Layout),
+
+								// Health Section
+								layout.Rigid(s.layoutHealth),
+								layout.Rigid(layout.Spacer{Height: unit.Dp(16)}.Layout),
+
 								// Type Section
 								layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 									txt := "Personal Identity"
@@ -248,6 +367,11 @@ func (s *CertificatesScreen) Layout(gtx layout.Context) layout.Dimensions {
 										clr = widgets.ColorWarning
 										icon = icons.IconWarning
 									}
+									if id := s.findIdentity(s.selectedID); id != nil && demo.IsTest(id.Cert) {
+										txt = "TEST certificate, only for demo campaigns"
+										clr = widgets.ColorWarning
+										icon = icons.IconWarning
+									}
 									return widgets.Border(gtx, clr, func(gtx layout.Context) layout.Dimensions {
 										return layout.UniformInset(unit.Dp(8)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 											return widgets.IconLabel(gtx, s.Theme, icon, txt, clr, unit.Sp(14))
@@ -258,8 +382,17 @@ func (s *CertificatesScreen) Layout(gtx layout.Context) layout.Dimensions {
 								layout.Rigid(layout.Spacer{Height: unit.Dp(24)}.Layout),
 								layout.Rigid(material.Caption(s.Theme, "RAW SUBJECT:").Layout),
 								layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-									s.SubjectEditor.SetText(s.selectedInfo.RawSubject)
-									return material.Editor(s.Theme, &s.SubjectEditor, "").Layout(gtx)
+									return s.subjectText.Layout(gtx, s.Theme, s.Theme.TextSize*12/16, s.selectedInfo.RawSubject)
+								}),
+								layout.Rigid(layout.Spacer{Height: unit.Dp(16)}.Layout),
+								layout.Rigid(material.Caption(s.Theme, "Sharing these details with your certificate authority's support helps them diagnose a certificate that does not work. They contain your name and ID number but not your private key.").Layout),
+								layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+								layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+									return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+										layout.Rigid(widgets.SecondaryButton(s.Theme, &s.CopyDetails, "Copy Details").Layout),
+										layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+										layout.Rigid(widgets.SecondaryButton(s.Theme, &s.ExportDetails, "Export JSON").Layout),
+									)
 								}),
 							)
 						})
@@ -270,15 +403,114 @@ func (s *CertificatesScreen) Layout(gtx layout.Context) layout.Dimensions {
 	)
 }
 
+// walletRowKind is what a row of the wallet list shows.
+type walletRowKind int
+
+const (
+	rowPersonalCaption walletRowKind = iota
+	rowRepresentationCaption
+	rowTrashCaption
+	rowSpacer
+	rowIdentity
+	rowTrash
+)
+
+// walletRow is a row of the wallet list. Only the rows in view are laid
+// out, so the list is kept as data rather than a widget per row.
+type walletRow struct {
+	kind  walletRowKind
+	id    *pkcs12store.Identity
+	trash *pkcs12store.TrashedIdentity
+}
+
+// walletRows appends the rows of the wallet list to rows: the personal and
+// representation certificates, then the recently deleted ones.
+func (s *CertificatesScreen) walletRows(rows []walletRow, groups groupedIdentities) []walletRow {
+	if len(groups.Personal) > 0 {
+		rows = append(rows, walletRow{kind: rowPersonalCaption})
+		for i := range groups.Personal {
+			rows = append(rows, walletRow{kind: rowIdentity, id: &groups.Personal[i]})
+		}
+	}
+	if len(groups.Representation) > 0 {
+		if len(rows) > 0 {
+			rows = append(rows, walletRow{kind: rowSpacer})
+		}
+		rows = append(rows, walletRow{kind: rowRepresentationCaption})
+		for i := range groups.Representation {
+			rows = append(rows, walletRow{kind: rowIdentity, id: &groups.Representation[i]})
+		}
+	}
+	var trash []walletRow
+	for i := range s.trash {
+		if s.query == "" || s.matches["trash/"+s.trash[i].ID] != nil {
+			trash = append(trash, walletRow{kind: rowTrash, trash: &s.trash[i]})
+		}
+	}
+	if len(trash) > 0 {
+		if len(rows) > 0 {
+			rows = append(rows, walletRow{kind: rowSpacer})
+		}
+		rows = append(rows, walletRow{kind: rowTrashCaption})
+		rows = append(rows, trash...)
+	}
+	return rows
+}
+
+func (s *CertificatesScreen) rowKey(i int) string {
+	switch r := s.rows[i]; r.kind {
+	case rowIdentity:
+		return r.id.ID
+	case rowTrash:
+		return "trash/" + r.trash.ID
+	case rowSpacer:
+		// Spacers precede a caption; key them by it.
+		return "spacer/" + s.rowKey(i+1)
+	case rowPersonalCaption:
+		return "caption/personal"
+	case rowRepresentationCaption:
+		return "caption/representation"
+	default:
+		return "caption/trash"
+	}
+}
+
+func (s *CertificatesScreen) layoutRow(gtx layout.Context, i int) layout.Dimensions {
+	switch r := s.rows[i]; r.kind {
+	case rowPersonalCaption:
+		return material.Caption(s.Theme, "PERSONAL CERTIFICATES").Layout(gtx)
+	case rowRepresentationCaption:
+		l := material.Caption(s.Theme, "REPRESENTATION CERTIFICATES")
+		l.Color = widgets.ColorWarning
+		return layout.Inset{Bottom: unit.Dp(8)}.Layout(gtx, l.Layout)
+	case rowTrashCaption:
+		return layout.Inset{Bottom: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(1, material.Caption(s.Theme, "RECENTLY DELETED").Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					btn := widgets.SecondaryButton(s.Theme, &s.EmptyTrash, "Empty trash")
+					btn.TextSize = unit.Sp(11)
+					return btn.Layout(gtx)
+				}),
+			)
+		})
+	case rowSpacer:
+		return layout.Spacer{Height: unit.Dp(16)}.Layout(gtx)
+	case rowIdentity:
+		return s.certificateRow(*r.id)(gtx)
+	default:
+		return s.trashRow(*r.trash)(gtx)
+	}
+}
+
 func (s *CertificatesScreen) certificateRow(id pkcs12store.Identity) layout.Widget {
 	return func(gtx layout.Context) layout.Dimensions {
-		if _, ok := s.Clickables[id.ID]; !ok {
-			s.Clickables[id.ID] = &widget.Clickable{}
-		}
-		btn := s.Clickables[id.ID]
+		defer s.App.Perf.Section("certificates/row")()
+		btn := s.Clickables.Get(id.ID, nil)
+		matches := s.matches[id.ID]
 		if btn.Clicked(gtx) {
 			s.selectedID = id.ID
-			s.selectedInfo = certs.ExtractSpanishIdentity(id.Cert)
+			s.selectedInfo = certs.CachedSpanishIdentity(id.Cert)
 		}
 
 		return layout.Inset{Bottom: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
@@ -296,6 +528,9 @@ func (s *CertificatesScreen) certificateRow(id pkcs12store.Identity) layout.Widg
 									layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 										return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
 											layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+												if ranges := matchRanges(matches, pkcs12store.FieldName); ranges != nil {
+													return widgets.HighlightLabel(gtx, s.Theme, s.Theme.TextSize, s.Theme.Fg, id.FriendlyName, ranges)
+												}
 												l := material.Body1(s.Theme, id.FriendlyName)
 												l.Font.Weight = font.Bold
 												return l.Layout(gtx)
@@ -305,33 +540,42 @@ func (s *CertificatesScreen) certificateRow(id pkcs12store.Identity) layout.Widg
 												if !isExpired(id.Cert.NotAfter) {
 													return layout.Dimensions{}
 												}
-												return widgets.Tag(gtx, s.Theme, "Expired", widgets.ColorWarning)
+												return widgets.StatusTag(gtx, s.Theme, widgets.BannerWarning, "Expired")
+											}),
+											layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+												h := s.healthOf(id.ID)
+												if h.Status == pkcs12store.HealthOK || h.Status == pkcs12store.HealthUnknown {
+													return layout.Dimensions{}
+												}
+												return layout.Inset{Left: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+													return widgets.StatusTag(gtx, s.Theme, healthTone(h.Status), h.Status.String())
+												})
 											}),
 										)
 									}),
-									layout.Rigid(material.Caption(s.Theme, "Issuer: "+id.Cert.Issuer.CommonName).Layout),
 									layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-										info := certs.ExtractSpanishIdentity(id.Cert)
+										return s.issuerCaption(gtx, id.Cert.Issuer.CommonName, matches)
+									}),
+									layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+										return s.otherMatches(gtx, matches)
+									}),
+									layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+										info := certs.CachedSpanishIdentity(id.Cert)
 										txt := "Personal"
-										clr := widgets.ColorSuccess
+										tone := widgets.BannerSuccess
 										if info.IsRepresentative {
 											txt = "Representative"
 											if info.OrganizationID != "" {
 												txt = "Representative (Org ID: " + info.OrganizationID + ")"
 											}
-											clr = widgets.ColorWarning
+											tone = widgets.BannerWarning
 										}
-										l := material.Caption(s.Theme, txt)
-										l.Color = clr
-										return l.Layout(gtx)
+										return widgets.IconLabel(gtx, s.Theme, widgets.ToneIcon(tone), txt, widgets.ToneColor(tone), unit.Sp(12))
 									}),
 								)
 							}),
 							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-								if _, ok := s.DeleteButtons[id.ID]; !ok {
-									s.DeleteButtons[id.ID] = &widget.Clickable{}
-								}
-								btn := widgets.DangerButton(s.Theme, s.DeleteButtons[id.ID], "X")
+								btn := widgets.DangerButton(s.Theme, s.DeleteButtons.Get(id.ID, nil), "X")
 								btn.TextSize = unit.Sp(11)
 								return layout.Inset{Top: unit.Dp(2), Bottom: unit.Dp(2), Left: unit.Dp(2), Right: unit.Dp(2)}.Layout(gtx, btn.Layout)
 							}),
@@ -343,10 +587,391 @@ func (s *CertificatesScreen) certificateRow(id pkcs12store.Identity) layout.Widg
 	}
 }
 
+// trashRow shows a deleted identity that can still be restored.
+func (s *CertificatesScreen) trashRow(t pkcs12store.TrashedIdentity) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		restore := s.RestoreButtons.Get(t.ID, nil)
+		matches := s.matches["trash/"+t.ID]
+		return layout.Inset{Bottom: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return widgets.Border(gtx, widgets.ColorBorder, func(gtx layout.Context) layout.Dimensions {
+				return widgets.Card(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
+					return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+						layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+							return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+								layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+									return widgets.HighlightLabel(gtx, s.Theme, s.Theme.TextSize, s.Theme.Fg, t.FriendlyName, matchRanges(matches, pkcs12store.FieldName))
+								}),
+								layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+									return s.issuerCaption(gtx, t.Cert.Issuer.CommonName, matches)
+								}),
+								layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+									return s.otherMatches(gtx, matches)
+								}),
+								layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+									l := material.Caption(s.Theme, "Deleted "+s.App.Locale().Date(t.DeletedAt.Local())+" · restorable until "+s.App.Locale().Date(t.ExpiresAt.Local()))
+									l.Color = widgets.ColorWarning
+									return l.Layout(gtx)
+								}),
+							)
+						}),
+						layout.Rigid(widgets.SecondaryButton(s.Theme, restore, "Restore").Layout),
+						layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+							btn := widgets.DangerButton(s.Theme, s.PurgeButtons.Get(t.ID, nil), "Delete forever")
+							btn.TextSize = unit.Sp(11)
+							return btn.Layout(gtx)
+						}),
+					)
+				})
+			})
+		})
+	}
+}
+
+// matchRanges returns the byte ranges of the search matches in field.
+func matchRanges(matches []pkcs12store.SearchMatch, field pkcs12store.SearchField) [][2]int {
+	var ranges [][2]int
+	for _, m := range matches {
+		if m.Field == field {
+			ranges = append(ranges, [2]int{m.Start, m.End})
+		}
+	}
+	return ranges
+}
+
+// issuerCaption shows the issuer of a wallet row, with the search matches
+// in it highlighted.
+func (s *CertificatesScreen) issuerCaption(gtx layout.Context, issuer string, matches []pkcs12store.SearchMatch) layout.Dimensions {
+	const prefix = "Issuer: "
+	var ranges [][2]int
+	for _, r := range matchRanges(matches, pkcs12store.FieldIssuer) {
+		ranges = append(ranges, [2]int{len(prefix) + r[0], len(prefix) + r[1]})
+	}
+	return widgets.HighlightLabel(gtx, s.Theme, s.Theme.TextSize*12/16, s.Theme.Fg, prefix+issuer, ranges)
+}
+
+// otherMatches shows the search matches of a wallet row in fields the row
+// does not otherwise show, such as the DNI or the fingerprint, one line per
+// field.
+func (s *CertificatesScreen) otherMatches(gtx layout.Context, matches []pkcs12store.SearchMatch) layout.Dimensions {
+	var children []layout.FlexChild
+	for _, field := range []pkcs12store.SearchField{pkcs12store.FieldHolder, pkcs12store.FieldDNI, pkcs12store.FieldOrganization, pkcs12store.FieldFingerprint} {
+		ranges := matchRanges(matches, field)
+		if ranges == nil {
+			continue
+		}
+		var value string
+		for _, m := range matches {
+			if m.Field == field {
+				value = m.Value
+			}
+		}
+		if field == pkcs12store.FieldFingerprint {
+			// Only the start of a fingerprint matches; the rest is noise.
+			if n := max(ranges[0][1], 16); n < len(value) {
+				value = value[:n] + "…"
+			}
+		}
+		prefix := string(field) + ": "
+		for i := range ranges {
+			ranges[i][0] += len(prefix)
+			ranges[i][1] += len(prefix)
+		}
+		children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return widgets.HighlightLabel(gtx, s.Theme, s.Theme.TextSize*12/16, widgets.ColorMuted, prefix+value, ranges)
+		}))
+	}
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+}
+
+func (s *CertificatesScreen) loadTrash() {
+	s.trashLoaded = true
+	trash, err := s.App.Store.ListTrash(context.Background())
+	if err != nil {
+		log.Printf("WARNING: failed to list deleted identities: %v", err)
+	}
+	s.trash = trash
+}
+
+// layoutConfirmPurge asks before deleting one trashed identity, or all of
+// them, for good.
+func (s *CertificatesScreen) layoutConfirmPurge(gtx layout.Context) layout.Dimensions {
+	var msg string
+	switch {
+	case s.pendingPurge != nil:
+		msg = "Delete " + s.pendingPurge.FriendlyName + " forever? Its key is erased and it cannot be restored."
+	case s.pendingEmpty:
+		msg = "Empty the trash? Every deleted certificate is erased and cannot be restored."
+	default:
+		return layout.Dimensions{}
+	}
+	return layout.Inset{Bottom: unit.Dp(16)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return widgets.Border(gtx, widgets.ColorError, func(gtx layout.Context) layout.Dimensions {
+			return layout.UniformInset(unit.Dp(8)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+					layout.Flexed(1, material.Body2(s.Theme, msg).Layout),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						btn := material.Button(s.Theme, &s.ConfirmPurge, "Delete forever")
+						btn.Background = widgets.ColorError
+						btn.TextSize = unit.Sp(12)
+						return btn.Layout(gtx)
+					}),
+					layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						btn := material.Button(s.Theme, &s.CancelPurge, "Cancel")
+						btn.TextSize = unit.Sp(12)
+						return btn.Layout(gtx)
+					}),
+				)
+			})
+		})
+	})
+}
+
+// purge deletes t for good, or every trashed identity if t is nil.
+func (s *CertificatesScreen) purge(t *pkcs12store.TrashedIdentity) {
+	var id, name string
+	if t != nil {
+		id, name = t.ID, t.FriendlyName
+	}
+	go func() {
+		ctx := context.Background()
+		if id == "" {
+			if err := s.App.Store.EmptyTrash(ctx); err != nil {
+				log.Printf("ERROR: failed to empty the trash: %v", err)
+				s.status = "Emptying the trash failed: " + err.Error()
+			} else {
+				s.status = "Trash emptied"
+			}
+		} else if err := s.App.Store.Purge(ctx, id); err != nil {
+			log.Printf("ERROR: failed to purge identity %s: %v", id, err)
+			s.status = "Deleting failed: " + err.Error()
+		} else {
+			s.status = "Deleted " + name + " forever"
+		}
+		s.trashLoaded = false
+		s.App.Invalidate()
+	}()
+}
+
+func (s *CertificatesScreen) restore(t pkcs12store.TrashedIdentity) {
+	go func() {
+		ctx := context.Background()
+		switch err := s.App.Store.Restore(ctx, t.ID); {
+		case errors.Is(err, pkcs12store.ErrImportDuplicate):
+			s.status = "Restore failed: " + t.FriendlyName + " is already in the wallet"
+		case err != nil:
+			log.Printf("ERROR: failed to restore identity %s: %v", t.ID, err)
+			s.status = "Restore failed: " + err.Error()
+		default:
+			s.status = "Restored " + t.FriendlyName
+		}
+		s.trashLoaded = false
+		s.App.Invalidate()
+	}()
+}
+
+func (s *CertificatesScreen) healthOf(id string) pkcs12store.Health {
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+	return s.health[id]
+}
+
+// checkHealth verifies identities in the background. Without force only
+// identities that were never checked are looked at.
+func (s *CertificatesScreen) checkHealth(identities []pkcs12store.Identity, force bool) {
+	var todo []string
+	s.healthMu.Lock()
+	for _, id := range identities {
+		if s.healthPending[id.ID] {
+			continue
+		}
+		if _, done := s.health[id.ID]; done && !force {
+			continue
+		}
+		s.healthPending[id.ID] = true
+		todo = append(todo, id.ID)
+	}
+	s.healthMu.Unlock()
+	if len(todo) == 0 {
+		return
+	}
+
+	go func() {
+		ctx := context.Background()
+		for _, id := range todo {
+			h := s.App.Store.CheckHealth(ctx, id)
+			if h.Status != pkcs12store.HealthOK {
+				log.Printf("WARNING: identity %s health: %s: %s", id, h.Status, h.Detail)
+			}
+			s.healthMu.Lock()
+			s.health[id] = h
+			delete(s.healthPending, id)
+			s.healthMu.Unlock()
+			s.App.Invalidate()
+		}
+	}()
+}
+
+// repairReference looks for a token certificate whose browser profile moved
+// and points the stored identity at its new location.
+func (s *CertificatesScreen) repairReference(id pkcs12store.Identity) {
+	s.repairing = true
+	s.status = "Searching browser profiles for " + id.FriendlyName + "..."
+	go func() {
+		defer func() {
+			s.repairing = false
+			s.App.Invalidate()
+		}()
+		ctx := context.Background()
+		ref, err := s.App.FindPKCS11Reference(ctx, id.Fingerprint256)
+		if err == nil {
+			err = s.App.Store.Relink(ctx, id.ID, *ref)
+		}
+		if err != nil {
+			log.Printf("WARNING: failed to repair reference for %s: %v", id.ID, err)
+			s.status = "Repair failed: " + err.Error()
+			return
+		}
+		s.status = "Reference repaired: " + id.FriendlyName + " now uses " + ref.ProfileDir
+		s.checkHealth([]pkcs12store.Identity{id}, true)
+	}()
+}
+
+func (s *CertificatesScreen) layoutHealth(gtx layout.Context) layout.Dimensions {
+	h := s.healthOf(s.selectedID)
+	status := h.Status.String()
+	s.healthMu.Lock()
+	if s.healthPending[s.selectedID] {
+		status = "Checking..."
+	}
+	s.healthMu.Unlock()
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return s.propertySection(gtx, "HEALTH", []property{
+				{"Status", status},
+				{"Details", h.Detail},
+			})
+		}),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+				layout.Rigid(widgets.SecondaryButton(s.Theme, &s.CheckHealth, "Check Again").Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					if h.Status != pkcs12store.HealthStaleReference {
+						return layout.Dimensions{}
+					}
+					label := "Repair Reference"
+					if s.repairing {
+						label = "Searching..."
+					}
+					return layout.Inset{Left: unit.Dp(8)}.Layout(gtx, widgets.PrimaryButton(s.Theme, &s.RepairButton, label).Layout)
+				}),
+			)
+		}),
+	)
+}
+
+func healthTone(status pkcs12store.HealthStatus) widgets.BannerTone {
+	switch status {
+	case pkcs12store.HealthOK:
+		return widgets.BannerSuccess
+	case pkcs12store.HealthKeyMismatch:
+		return widgets.BannerError
+	default:
+		return widgets.BannerWarning
+	}
+}
+
 func isExpired(notAfter time.Time) bool {
 	return time.Now().After(notAfter)
 }
 
+// expiryDate is when the selected certificate expires, in the chosen
+// locale.
+func (s *CertificatesScreen) expiryDate() string {
+	id := s.findIdentity(s.selectedID)
+	if id == nil || id.Cert == nil {
+		return s.selectedInfo.ValidUntil
+	}
+	return s.App.Locale().Date(id.Cert.NotAfter)
+}
+
+// exportDetails saves the details of id as JSON where the user chooses.
+func (s *CertificatesScreen) exportDetails(id pkcs12store.Identity) {
+	details := certs.Describe(id.Cert, id.Chain)
+	go func() {
+		defer s.App.Invalidate()
+		if s.App.Explorer == nil {
+			s.status = "Export failed: the system file dialog is not available"
+			return
+		}
+		w, err := s.App.Explorer.CreateFile("vocsign-certificate-" + details.SHA256[:12] + ".json")
+		if err != nil {
+			log.Printf("WARNING: certificate details export canceled: %v", err)
+			if !errors.Is(err, explorer.ErrUserDecline) {
+				s.status = "Export failed: the system file dialog could not be opened"
+			}
+			return
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(details)
+		if cerr := w.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			log.Printf("ERROR: certificate details export failed: %v", err)
+			s.status = "Export failed: " + err.Error()
+			return
+		}
+		s.status = "Certificate details exported"
+	}()
+}
+
+// layoutKeyUsage shows what the selected certificate's key may be used for,
+// and whether that covers legal signatures.
+func (s *CertificatesScreen) layoutKeyUsage(gtx layout.Context) layout.Dimensions {
+	id := s.findIdentity(s.selectedID)
+	if id == nil {
+		return layout.Dimensions{}
+	}
+	ku := strings.Join(certs.KeyUsageNames(id.Cert), ", ")
+	if ku == "" {
+		ku = "Not restricted"
+	}
+	eku := strings.Join(certs.ExtKeyUsageNames(id.Cert), ", ")
+	if eku == "" {
+		eku = "Not restricted"
+	}
+	var paired string
+	for _, p := range pkcs12store.FindPairs(s.App.IdentitiesSnapshot()) {
+		switch id.ID {
+		case p.Signature.ID:
+			paired = p.Authentication.FriendlyName + ", its authentication certificate"
+		case p.Authentication.ID:
+			paired = p.Signature.FriendlyName + ", its signature certificate, which VocSign signs with"
+		}
+	}
+	tone, txt := widgets.BannerSuccess, "Suitable for legal signatures"
+	if err := certs.LegalSignatureUsage(id.Cert); err != nil {
+		tone, txt = widgets.BannerWarning, "Not suitable for legal signatures: its "+err.Error()
+	}
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return s.propertySection(gtx, "KEY USAGE", []property{
+				{"Key usage", ku},
+				{"Extended", eku},
+				{"Paired with", paired},
+			})
+		}),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return widgets.IconLabel(gtx, s.Theme, widgets.ToneIcon(tone), txt, widgets.ToneColor(tone), unit.Sp(13))
+		}),
+	)
+}
+
 func certStatusLabel(id *pkcs12store.Identity) string {
 	if id == nil || id.Cert == nil {
 		return ""
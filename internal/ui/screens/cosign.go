@@ -0,0 +1,102 @@
+package screens
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"strings"
+	"time"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/cades"
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/certs"
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/pkcs12store"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+	"github.com/vocdoni/gofirma/vocsign/internal/ui/widgets"
+)
+
+// layoutCoSigner lets the user choose a second certificate that signs the
+// same XML in parallel, e.g. a legal guardian signing along with a minor or
+// two joint representatives of an organization.
+func (s *RequestDetailsScreen) layoutCoSigner(gtx layout.Context, identities []pkcs12store.Identity) layout.Dimensions {
+	children := []layout.FlexChild{
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return material.CheckBox(s.Theme, &s.CoSignCheck, "A second person signs too (legal guardian or joint representative)").Layout(gtx)
+		}),
+	}
+	if !s.CoSignCheck.Value {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	}
+	children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+		return layout.Inset{Top: unit.Dp(6), Bottom: unit.Dp(4)}.Layout(gtx, material.Caption(s.Theme, "SECOND SIGNER").Layout)
+	}))
+	others := 0
+	for i := range identities {
+		if identities[i].ID == s.CertEnum.Value {
+			continue
+		}
+		others++
+		children = append(children, layout.Rigid(s.certPickerRow(&s.CoSignEnum, identities[i])))
+	}
+	if others == 0 {
+		children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			l := material.Caption(s.Theme, "Import the second signer's certificate first.")
+			l.Color = widgets.ColorWarning
+			return l.Layout(gtx)
+		}))
+	}
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+}
+
+// selectedCoSigner returns the second signer chosen for the signature, or
+// nil if only the citizen signs.
+func (s *RequestDetailsScreen) selectedCoSigner(primaryID string) (*pkcs12store.Identity, error) {
+	if !s.CoSignCheck.Value {
+		return nil, nil
+	}
+	if s.CoSignEnum.Value == "" {
+		return nil, fmt.Errorf("choose the second signer's certificate")
+	}
+	if s.CoSignEnum.Value == primaryID {
+		return nil, fmt.Errorf("the second signer must use a different certificate")
+	}
+	identity := s.findIdentity(s.CoSignEnum.Value)
+	if identity == nil {
+		return nil, fmt.Errorf("the second signer's certificate is no longer available")
+	}
+	if err := certs.ValidateForSigning(identity.Cert, identity.Chain); err != nil {
+		return nil, fmt.Errorf("second signer's certificate: %w", err)
+	}
+	return identity, nil
+}
+
+// addCoSignature unlocks identity and adds its signature over xmlBytes to
+// signatureDER, so both signers are in a single CMS structure.
+func (s *RequestDetailsScreen) addCoSignature(ctx context.Context, identity *pkcs12store.Identity, signatureDER, xmlBytes []byte, policy *model.SignPolicy) ([]byte, error) {
+	var signer crypto.Signer
+	var err error
+	if strings.HasPrefix(identity.ID, "nss:") || strings.HasPrefix(identity.ID, "os:") {
+		signer = identity.Signer
+	} else {
+		signer, err = s.App.Store.Unlock(ctx, identity.ID)
+	}
+	if err == nil && signer == nil {
+		err = fmt.Errorf("signer is nil")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unlock failed: %w", err)
+	}
+
+	progress := newSignProgress()
+	s.signing = progress
+	defer func() { s.signing = nil }()
+	return progress.run(func() ([]byte, error) {
+		return cades.AddSigner(ctx, signer, identity.Cert, identity.Chain, signatureDER, xmlBytes, cades.SignOpts{
+			SigningTime: time.Now(),
+			Policy:      policy,
+		})
+	}, func() bool { return s.App.PendingPINRequest() != nil }, s.signAbandoned)
+}
@@ -0,0 +1,82 @@
+package screens
+
+import (
+	"errors"
+	"io"
+	"log"
+	"runtime"
+	"sync/atomic"
+
+	"gioui.org/x/explorer"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/app"
+	"github.com/vocdoni/gofirma/vocsign/internal/sandbox"
+)
+
+// errNoFilePicker means the native file dialog is unavailable and the
+// built-in widgets.FileBrowser should be shown instead.
+var errNoFilePicker = errors.New("native file picker unavailable")
+
+// nativePickerFailed is set once the native dialog failed, so later picks
+// go straight to the built-in browser. pickerFailure is the last error, for
+// the About screen.
+var (
+	nativePickerFailed atomic.Bool
+	pickerFailure      atomic.Value
+)
+
+// chooseFile opens the native file dialog. It returns errNoFilePicker when
+// there is none or it fails, and explorer.ErrUserDecline when the user
+// closed it.
+func chooseFile(a *app.App, extensions ...string) (io.ReadCloser, error) {
+	if a.Explorer == nil || nativePickerFailed.Load() {
+		return nil, errNoFilePicker
+	}
+	rc, err := a.Explorer.ChooseFile(extensions...)
+	return rc, pickerError(err)
+}
+
+// chooseFiles is chooseFile letting the user select several files.
+func chooseFiles(a *app.App, extensions ...string) ([]io.ReadCloser, error) {
+	if a.Explorer == nil || nativePickerFailed.Load() {
+		return nil, errNoFilePicker
+	}
+	rcs, err := a.Explorer.ChooseFiles(extensions...)
+	return rcs, pickerError(err)
+}
+
+// pickerError returns errNoFilePicker for a failure of the native dialog
+// and records it; nil and explorer.ErrUserDecline are returned as is.
+func pickerError(err error) error {
+	if err == nil || errors.Is(err, explorer.ErrUserDecline) {
+		return err
+	}
+	pickerFailure.Store(err.Error())
+	if sandbox.Detect().Confined() {
+		// Inside a sandbox the desktop portal is the only way to files the
+		// permissions do not grant, so it is tried again next time.
+		log.Printf("WARNING: file chooser portal failed, using the built-in browser: %v", err)
+		return errNoFilePicker
+	}
+	log.Printf("WARNING: native file picker failed, using the built-in browser: %v", err)
+	nativePickerFailed.Store(true)
+	return errNoFilePicker
+}
+
+// filePickerStatus describes how files are chosen, for the About screen.
+func filePickerStatus(a *app.App) (status string, problem bool) {
+	native := "System dialog"
+	if runtime.GOOS == "linux" {
+		native = "Desktop portal (xdg-desktop-portal)"
+	}
+	failure, _ := pickerFailure.Load().(string)
+	switch {
+	case a.Explorer == nil:
+		return "Built-in browser", false
+	case nativePickerFailed.Load():
+		return "Built-in browser, the system dialog failed: " + failure, true
+	case failure != "":
+		return native + ", last attempt failed: " + failure, true
+	}
+	return native, false
+}
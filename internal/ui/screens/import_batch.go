@@ -0,0 +1,342 @@
+package screens
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+
+	"gioui.org/font"
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/pkcs12store"
+	"github.com/vocdoni/gofirma/vocsign/internal/platform"
+	"github.com/vocdoni/gofirma/vocsign/internal/storage"
+	"github.com/vocdoni/gofirma/vocsign/internal/ui/widgets"
+)
+
+// importState is where a file of a batch import stands.
+type importState int
+
+const (
+	importPending importState = iota
+	importRunning
+	importDone
+	importWrongPassword
+	importDuplicate
+	importFailed
+)
+
+func (st importState) String() string {
+	switch st {
+	case importRunning:
+		return "Importing…"
+	case importDone:
+		return "Imported"
+	case importWrongPassword:
+		return "Wrong password"
+	case importDuplicate:
+		return "Already in wallet"
+	case importFailed:
+		return "Failed"
+	default:
+		return "Waiting"
+	}
+}
+
+func (st importState) tone() widgets.BannerTone {
+	switch st {
+	case importDone:
+		return widgets.BannerSuccess
+	case importWrongPassword, importDuplicate:
+		return widgets.BannerWarning
+	case importFailed:
+		return widgets.BannerError
+	default:
+		return widgets.BannerNeutral
+	}
+}
+
+// importFile is a certificate file of a batch import, with its own
+// password. Its state and message are written by the import goroutine.
+type importFile struct {
+	name   string // base name of the file, "" if unknown
+	data   []byte
+	pass   widget.Editor
+	remove widget.Clickable
+
+	// account is the hex SHA-256 of the file, which its password is
+	// stored under in the OS keyring; saved is that password, if any.
+	account  string
+	saved    string
+	remember widget.Bool
+	forget   widget.Clickable
+
+	state importState
+	msg   string
+}
+
+func newImportFile(name string, data []byte) *importFile {
+	sum := sha256.Sum256(data)
+	f := &importFile{name: name, data: data, account: hex.EncodeToString(sum[:])}
+	f.pass.SingleLine = true
+	f.pass.Mask = '*'
+	return f
+}
+
+// finished reports whether importing f again would not change anything.
+func (f *importFile) finished() bool {
+	return f.state == importDone || f.state == importDuplicate
+}
+
+// addImportFiles reads the files chosen to import and closes them, and
+// looks up their saved passwords.
+func (s *WizardScreen) addImportFiles(rcs []io.ReadCloser) {
+	s.keyring = platform.KeyringName()
+	for i, rc := range rcs {
+		var name string
+		if f, ok := rc.(interface{ Name() string }); ok {
+			name = filepath.Base(f.Name())
+		}
+		data, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			s.ConfirmationMsg = fmt.Sprintf("Could not read selected file %d", i+1)
+			continue
+		}
+		f := newImportFile(name, data)
+		if saved, err := platform.LoadPassword(f.account); err == nil {
+			f.saved = saved
+		} else if !errors.Is(err, platform.ErrNoPassword) && !errors.Is(err, platform.ErrNoKeyring) {
+			log.Printf("WARNING: could not read a saved certificate password: %v", err)
+		}
+		s.imports = append(s.imports, f)
+	}
+	s.App.Invalidate()
+}
+
+// runImports imports every file not imported yet, one after the other,
+// each with its own password, else the one saved in the OS keyring, else
+// the shared one. Passwords the user asked to remember are saved once they
+// open their file. With DeferredImport the files are stored as they are.
+func (s *WizardScreen) runImports(shared string) {
+	var files []*importFile
+	passwords := make(map[*importFile]string)
+	for _, f := range s.imports {
+		if f.finished() {
+			continue
+		}
+		pass := f.pass.Text()
+		if pass == "" && f.saved != "" {
+			pass = f.saved
+		}
+		if pass == "" {
+			pass = shared
+		}
+		files = append(files, f)
+		passwords[f] = pass
+		f.state = importPending
+		f.msg = ""
+	}
+	if len(files) == 0 {
+		s.ConfirmationMsg = "Select a .p12 or .pfx file first"
+		return
+	}
+	s.importing = true
+	store := s.App.Store.Import
+	if s.DeferredImport.Value {
+		store = s.App.Store.ImportDeferred
+	}
+	go func() {
+		ctx := context.Background()
+		if err := s.App.UnlockWallet(); err != nil {
+			s.importing = false
+			s.ConfirmationMsg = "The wallet is locked. Unlock it to import certificates."
+			s.App.Invalidate()
+			return
+		}
+		imported := 0
+		for _, f := range files {
+			f.state = importRunning
+			s.App.Invalidate()
+			_, err := store(ctx, importName(f.name), bytes.NewReader(f.data), []byte(passwords[f]))
+			switch {
+			case err == nil:
+				f.state = importDone
+				f.data = nil
+				imported++
+				if pass := passwords[f]; f.remember.Value && pass != "" && pass != f.saved {
+					if err := platform.SavePassword(f.account, pass); err != nil {
+						log.Printf("WARNING: could not save the certificate password: %v", err)
+						f.msg = "Imported, but the password could not be saved in the " + platform.KeyringName() + "."
+					} else {
+						f.saved = pass
+						f.msg = "Password saved in the " + platform.KeyringName() + "."
+					}
+				}
+			case errors.Is(err, pkcs12store.ErrImportWrongPassword), errors.Is(err, pkcs12store.ErrImportPasswordRequired):
+				f.state = importWrongPassword
+				if passwords[f] != "" {
+					s.App.RecordSecurityEvent(storage.SecurityAuthFailed, "Wrong password for certificate file "+f.name)
+				}
+			case errors.Is(err, pkcs12store.ErrImportDuplicate):
+				f.state = importDuplicate
+				f.data = nil
+			default:
+				f.state = importFailed
+			}
+			if err != nil {
+				f.msg = pkcs12store.FriendlyImportError(err)
+				if f.state == importWrongPassword && passwords[f] == f.saved && f.saved != "" {
+					f.msg += " The password saved in the " + platform.KeyringName() + " no longer opens it."
+				}
+			}
+		}
+		s.importing = false
+		s.PassEditor.SetText("")
+		switch {
+		case imported == len(files):
+			s.ConfirmationMsg = importSuccessMessage(imported)
+		case imported == 0:
+			s.ConfirmationMsg = "No certificate was imported; see the files below."
+		default:
+			s.ConfirmationMsg = importSuccessMessage(imported) + fmt.Sprintf(" %d could not be imported; see the files below.", len(files)-imported)
+		}
+		allDone := true
+		for _, f := range s.imports {
+			allDone = allDone && f.state == importDone
+		}
+		if allDone {
+			s.imports = nil
+			s.Step = StepChoice
+		}
+		s.App.Invalidate()
+	}()
+}
+
+// importName is the friendly name of an imported file: its name without
+// the extension, or "Imported Certificate" when it has none.
+func importName(file string) string {
+	if name := file[:len(file)-len(filepath.Ext(file))]; name != "" {
+		return name
+	}
+	return "Imported Certificate"
+}
+
+// layoutImportFiles shows the files of the batch with their password and
+// result.
+func (s *WizardScreen) layoutImportFiles(gtx layout.Context) layout.Dimensions {
+	for _, f := range s.imports {
+		if f.forget.Clicked(gtx) && f.saved != "" {
+			f.saved = ""
+			go func(account string) {
+				if err := platform.ForgetPassword(account); err != nil {
+					log.Printf("WARNING: could not remove the saved certificate password: %v", err)
+				}
+			}(f.account)
+		}
+	}
+	keyring := s.keyring
+	for i := 0; i < len(s.imports); i++ {
+		if !s.importing && s.imports[i].remove.Clicked(gtx) {
+			s.imports = append(s.imports[:i], s.imports[i+1:]...)
+			i--
+		}
+	}
+	if len(s.imports) == 0 {
+		l := material.Body2(s.Theme, "No file selected")
+		l.Color = widgets.ColorMuted
+		return l.Layout(gtx)
+	}
+	gtx.Constraints.Max.Y = min(gtx.Constraints.Max.Y, gtx.Dp(unit.Dp(320)))
+	s.ImportList.Axis = layout.Vertical
+	return material.List(s.Theme, &s.ImportList).Layout(gtx, len(s.imports), func(gtx layout.Context, i int) layout.Dimensions {
+		f := s.imports[i]
+		return layout.Inset{Bottom: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return widgets.Border(gtx, widgets.ColorBorder, func(gtx layout.Context) layout.Dimensions {
+				return layout.UniformInset(unit.Dp(8)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+							return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+								layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+									name := f.name
+									if name == "" {
+										name = fmt.Sprintf("File %d", i+1)
+									}
+									l := material.Body2(s.Theme, name)
+									l.Font.Weight = font.Medium
+									l.MaxLines = 1
+									return l.Layout(gtx)
+								}),
+								layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+									return widgets.StatusTag(gtx, s.Theme, f.state.tone(), f.state.String())
+								}),
+								layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+									if s.importing || f.state == importDone {
+										return layout.Dimensions{}
+									}
+									return layout.Inset{Left: unit.Dp(8)}.Layout(gtx, widgets.SecondaryButton(s.Theme, &f.remove, "Remove").Layout)
+								}),
+							)
+						}),
+						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+							if f.finished() {
+								return layout.Dimensions{}
+							}
+							return layout.Inset{Top: unit.Dp(6)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+								clr := widgets.ColorBorder
+								if f.state == importWrongPassword {
+									clr = widgets.ColorWarning
+								}
+								return widgets.Border(gtx, clr, func(gtx layout.Context) layout.Dimensions {
+									hint := "Password for this file (blank: the one below)"
+									if f.saved != "" {
+										hint = "Password for this file (blank: the saved one)"
+									}
+									return layout.UniformInset(unit.Dp(6)).Layout(gtx, material.Editor(s.Theme, &f.pass, hint).Layout)
+								})
+							})
+						}),
+						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+							switch {
+							case keyring == "":
+								return layout.Dimensions{}
+							case f.saved != "" && f.state != importWrongPassword:
+								return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+									layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+										return widgets.IconLabel(gtx, s.Theme, widgets.ToneIcon(widgets.BannerSuccess), "Password saved in the "+keyring, widgets.ToneColor(widgets.BannerSuccess), unit.Sp(12))
+									}),
+									layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+										if s.importing {
+											return layout.Dimensions{}
+										}
+										return widgets.SecondaryButton(s.Theme, &f.forget, "Forget").Layout(gtx)
+									}),
+								)
+							case f.finished():
+								return layout.Dimensions{}
+							}
+							return material.CheckBox(s.Theme, &f.remember, "Remember this password in the "+keyring).Layout(gtx)
+						}),
+						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+							if f.msg == "" {
+								return layout.Dimensions{}
+							}
+							l := material.Caption(s.Theme, f.msg)
+							l.Color = widgets.ToneColor(f.state.tone())
+							return layout.Inset{Top: unit.Dp(4)}.Layout(gtx, l.Layout)
+						}),
+					)
+				})
+			})
+		})
+	})
+}
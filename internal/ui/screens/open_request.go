@@ -2,22 +2,31 @@ package screens
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"runtime/debug"
 	"strings"
+	"time"
 
 	"gioui.org/io/clipboard"
 	"gioui.org/io/transfer"
 	"gioui.org/layout"
+	"gioui.org/op"
 	"gioui.org/unit"
 	"gioui.org/widget"
 	"gioui.org/widget/material"
 
 	"github.com/vocdoni/gofirma/vocsign/internal/app"
 	"github.com/vocdoni/gofirma/vocsign/internal/crypto/jwsverify"
+	"github.com/vocdoni/gofirma/vocsign/internal/datadir"
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+	"github.com/vocdoni/gofirma/vocsign/internal/locale"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
 	"github.com/vocdoni/gofirma/vocsign/internal/net"
+	"github.com/vocdoni/gofirma/vocsign/internal/platform"
+	"github.com/vocdoni/gofirma/vocsign/internal/storage"
 	"github.com/vocdoni/gofirma/vocsign/internal/ui/icons"
 	"github.com/vocdoni/gofirma/vocsign/internal/ui/widgets"
 )
@@ -29,12 +38,60 @@ type OpenRequestScreen struct {
 	URLEditor   widget.Editor
 	FetchButton widget.Clickable
 	PasteButton widget.Clickable
+
+	recent       []storage.RecentRequest
+	recentLoaded bool
+	recentOpen   []widget.Clickable
+	recentForget []widget.Clickable
+
+	ResumeButton  widget.Clickable
+	DismissResume widget.Clickable
+
+	DismissDataDir widget.Clickable
+
+	DismissInterrupted widget.Clickable
+
+	// Signing URL found in the clipboard when the window gained focus. The
+	// last dismissed URL is remembered so it is not offered again.
+	clipboardURL     string
+	clipboardIgnored string
+	clipboardTag     bool
+	OpenClipboard    widget.Clickable
+	DismissClipboard widget.Clickable
+
+	// Text read with the platform's clipboard command, which runs outside
+	// the frame loop. pasteAsked is when a paste was asked of Gio's Wayland
+	// reader, to tell the user when it does not answer.
+	clipboardRead chan clipboardText
+	pasteAsked    time.Time
+
+	// Request URL of the vocsign:// link VocSign was started with. It is
+	// fetched only once the user confirms it, like a clipboard URL.
+	launchURL     string
+	OpenLaunch    widget.Clickable
+	DismissLaunch widget.Clickable
+
+	campaignsRequested bool
+	campaignOpen       []widget.Clickable
+	refreshCampaigns   widget.Clickable
+}
+
+type clipboardText struct {
+	text string
+	err  error
+	// paste is set for the Paste button, unset for the check on focus.
+	paste bool
 }
 
+// pasteTimeout is how long Gio's Wayland reader may take before the user is
+// told the clipboard did not answer.
+const pasteTimeout = 3 * time.Second
+
 func NewOpenRequestScreen(a *app.App, th *material.Theme) *OpenRequestScreen {
 	s := &OpenRequestScreen{
-		App:   a,
-		Theme: th,
+		App:           a,
+		Theme:         th,
+		clipboardRead: make(chan clipboardText, 4),
 	}
 	s.URLEditor.SingleLine = true
 	return s
@@ -42,47 +99,92 @@ func NewOpenRequestScreen(a *app.App, th *material.Theme) *OpenRequestScreen {
 
 func (s *OpenRequestScreen) Layout(gtx layout.Context) layout.Dimensions {
 	if s.FetchButton.Clicked(gtx) {
-		url := strings.TrimSpace(s.URLEditor.Text())
-		if url != "" {
-			s.App.FetchStatus = "Connecting to server..."
-			s.App.ReqError = nil
+		if url := strings.TrimSpace(s.URLEditor.Text()); url != "" {
+			s.startFetch(url)
+		}
+	}
 
-			go func() {
-				defer func() {
-					if r := recover(); r != nil {
-						log.Printf("ERROR: panic while fetching request: %v\n%s", r, string(debug.Stack()))
-						s.App.FetchStatus = "Unexpected Error: could not process request"
-						s.App.ReqError = fmt.Errorf("panic while processing request: %v", r)
-						s.App.Invalidate()
-					}
-				}()
-
-				ctx := context.Background()
-				req, raw, err := net.Fetch(ctx, url)
-				if err != nil {
-					s.App.FetchStatus = "Connection Error: " + err.Error()
-					s.App.ReqError = err
-					return
-				}
-
-				s.App.FetchStatus = "Authenticating Request..."
-				if err := jwsverify.Verify(req); err != nil {
-					s.App.FetchStatus = "Security Validation Failed: " + err.Error()
-					s.App.ReqError = err
-				} else {
-					s.App.FetchStatus = "Ready"
-					s.App.CurrentReq = req
-					s.App.RawReq = raw
-					s.App.RequestURL = url
-					s.App.CurrentScreen = app.ScreenRequestDetails
-				}
-				s.App.Invalidate()
-			}()
+	for i := range s.recent {
+		if s.recentOpen[i].Clicked(gtx) {
+			s.URLEditor.SetText(s.recent[i].URL)
+			s.startFetch(s.recent[i].URL)
+		}
+		if s.recentForget[i].Clicked(gtx) {
+			if err := s.App.Requests.Forget(s.recent[i].RequestID); err != nil {
+				log.Printf("WARNING: failed to forget request: %v", err)
+			}
+			s.recentLoaded = false
+		}
+	}
+	if !s.recentLoaded {
+		s.loadRecent()
+	}
+
+	if !s.campaignsRequested || s.refreshCampaigns.Clicked(gtx) {
+		s.campaignsRequested = true
+		s.App.RefreshPinnedCampaigns()
+	}
+	campaigns, campaignsLoading := s.App.PinnedCampaignsSnapshot()
+	if len(s.campaignOpen) < len(campaigns) {
+		s.campaignOpen = make([]widget.Clickable, len(campaigns))
+	}
+	for i := range campaigns {
+		if s.campaignOpen[i].Clicked(gtx) {
+			s.URLEditor.SetText(campaigns[i].URL)
+			s.startFetch(campaigns[i].URL)
+		}
+	}
+
+	if s.ResumeButton.Clicked(gtx) {
+		if url := s.App.BeginResume(); url != "" {
+			s.URLEditor.SetText(url)
+			s.startFetch(url)
+		}
+	}
+	if s.DismissResume.Clicked(gtx) {
+		s.App.ClearSession()
+	}
+	if s.DismissDataDir.Clicked(gtx) {
+		s.App.DataDirDismissed = true
+	}
+	if s.DismissInterrupted.Clicked(gtx) {
+		if entries := s.App.InterruptedSignings(); len(entries) > 0 {
+			s.App.DismissInterrupted(entries[0])
 		}
 	}
+	if url := s.App.TakeReload(); url != "" {
+		s.URLEditor.SetText(url)
+		s.startFetch(url)
+	}
+
+	if s.App.TakeClipboardCheck() && s.App.CurrentReq == nil {
+		s.readClipboard(gtx, false)
+	}
+	s.readClipboardSuggestion(gtx)
+	if s.OpenClipboard.Clicked(gtx) && s.clipboardURL != "" {
+		url := s.clipboardURL
+		s.clipboardIgnored, s.clipboardURL = url, ""
+		s.URLEditor.SetText(url)
+		s.startFetch(url)
+	}
+	if s.DismissClipboard.Clicked(gtx) {
+		s.clipboardIgnored, s.clipboardURL = s.clipboardURL, ""
+	}
+	if url := s.App.TakeLaunchURL(); url != "" {
+		s.launchURL = url
+	}
+	if s.OpenLaunch.Clicked(gtx) && s.launchURL != "" {
+		url := s.launchURL
+		s.launchURL = ""
+		s.URLEditor.SetText(url)
+		s.startFetch(url)
+	}
+	if s.DismissLaunch.Clicked(gtx) {
+		s.launchURL = ""
+	}
 
 	if s.PasteButton.Clicked(gtx) {
-		gtx.Execute(clipboard.ReadCmd{Tag: s})
+		s.readClipboard(gtx, true)
 	}
 
 	for {
@@ -90,30 +192,40 @@ func (s *OpenRequestScreen) Layout(gtx layout.Context) layout.Dimensions {
 		if !ok {
 			break
 		}
+		s.pasteAsked = time.Time{}
 		switch ev := ev.(type) {
 		case transfer.DataEvent:
 			rc := ev.Open()
 			data, err := io.ReadAll(rc)
 			_ = rc.Close()
-			if err != nil {
-				s.App.FetchStatus = "Clipboard Error: could not read clipboard text"
-				s.App.ReqError = err
-				break
-			}
-			txt := strings.TrimSpace(string(data))
-			if txt == "" {
-				s.App.FetchStatus = "Clipboard is empty"
-				s.App.ReqError = nil
-				break
-			}
-			s.URLEditor.SetText(txt)
-			s.App.FetchStatus = "Signing URL pasted from clipboard"
-			s.App.ReqError = nil
+			s.paste(string(data), err)
 		case transfer.CancelEvent:
 			s.App.FetchStatus = "Clipboard paste canceled"
 			s.App.ReqError = nil
 		}
 	}
+	for drained := false; !drained; {
+		select {
+		case r := <-s.clipboardRead:
+			switch {
+			case r.paste:
+				s.paste(r.text, r.err)
+			case r.err == nil:
+				s.suggestClipboard(r.text)
+			}
+		default:
+			drained = true
+		}
+	}
+	if !s.pasteAsked.IsZero() {
+		if gtx.Now.Sub(s.pasteAsked) >= pasteTimeout {
+			s.pasteAsked = time.Time{}
+			s.App.FetchStatus = "Clipboard did not answer. Type the URL, or install wl-clipboard (wl-paste) and restart VocSign."
+			s.App.ReqError = nil
+		} else {
+			gtx.Execute(op.InvalidateCmd{At: s.pasteAsked.Add(pasteTimeout)})
+		}
+	}
 
 	return layout.UniformInset(unit.Dp(6)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 		return widgets.CenterInAvailable(gtx, func(gtx layout.Context) layout.Dimensions {
@@ -125,6 +237,46 @@ func (s *OpenRequestScreen) Layout(gtx layout.Context) layout.Dimensions {
 							return widgets.IconLabel(gtx, s.Theme, icons.IconOpenRequest, "Open Signing Request", s.Theme.ContrastBg, unit.Sp(24))
 						})
 					}),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						if s.App.DataDir.OK() || s.App.DataDirDismissed {
+							return layout.Dimensions{}
+						}
+						return layout.Inset{Bottom: unit.Dp(12)}.Layout(gtx, s.layoutDataDir)
+					}),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						entries := s.App.InterruptedSignings()
+						if len(entries) == 0 {
+							return layout.Dimensions{}
+						}
+						return layout.Inset{Bottom: unit.Dp(12)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+							return s.layoutInterrupted(gtx, entries[0])
+						})
+					}),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						sess := s.App.ResumeSession
+						if sess == nil || s.App.CurrentReq != nil {
+							return layout.Dimensions{}
+						}
+						return layout.Inset{Bottom: unit.Dp(12)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+							return s.layoutResume(gtx, sess)
+						})
+					}),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						if s.launchURL == "" {
+							return layout.Dimensions{}
+						}
+						return layout.Inset{Bottom: unit.Dp(12)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+							return s.layoutSuggestion(gtx, "Open request from link?", s.launchURL, &s.DismissLaunch, &s.OpenLaunch)
+						})
+					}),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						if s.clipboardURL == "" {
+							return layout.Dimensions{}
+						}
+						return layout.Inset{Bottom: unit.Dp(12)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+							return s.layoutSuggestion(gtx, "Open request from clipboard?", s.clipboardURL, &s.DismissClipboard, &s.OpenClipboard)
+						})
+					}),
 					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 						return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
 							return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
@@ -157,12 +309,437 @@ func (s *OpenRequestScreen) Layout(gtx layout.Context) layout.Dimensions {
 							return widgets.Banner(gtx, s.Theme, tone, s.App.FetchStatus)
 						})
 					}),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						if len(campaigns) == 0 && !campaignsLoading {
+							return layout.Dimensions{}
+						}
+						return layout.Inset{Top: unit.Dp(16)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+							return s.layoutCampaigns(gtx, campaigns, campaignsLoading)
+						})
+					}),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						if len(s.recent) == 0 {
+							return layout.Dimensions{}
+						}
+						return layout.Inset{Top: unit.Dp(16)}.Layout(gtx, s.layoutRecent)
+					}),
 				)
 			})
 		})
 	})
 }
 
+// layoutResume offers to continue a signing interrupted by a crash or an
+// accidental close.
+func (s *OpenRequestScreen) layoutResume(gtx layout.Context, sess *storage.Session) layout.Dimensions {
+	name := sess.RequestID
+	if name == "" {
+		name = sess.Title
+	}
+	return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+			layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+					layout.Rigid(material.Subtitle2(s.Theme, "Resume signing "+name+"?").Layout),
+					layout.Rigid(material.Caption(s.Theme, "VocSign was closed while this request was open. "+sess.Title).Layout),
+				)
+			}),
+			layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+			layout.Rigid(widgets.SecondaryButton(s.Theme, &s.DismissResume, "Dismiss").Layout),
+			layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+			layout.Rigid(widgets.PrimaryButton(s.Theme, &s.ResumeButton, "Resume").Layout),
+		)
+	})
+}
+
+// layoutInterrupted reports a signing the journal shows was cut short by a
+// crash, telling whether the signature may have been submitted.
+func (s *OpenRequestScreen) layoutInterrupted(gtx layout.Context, e storage.JournalEntry) layout.Dimensions {
+	name := e.Title
+	if name == "" {
+		name = e.RequestID
+	}
+	tone := widgets.BannerInfo
+	msg := "VocSign closed while signing " + name + ". The signature was never sent, so it does not count: open the request again to sign it."
+	if e.Stage == storage.StageSubmitting {
+		tone = widgets.BannerWarning
+		msg = "VocSign closed while submitting your signature of " + name + ". The collector may have received it: check with the organizer before signing it again, or it may be counted twice."
+		if e.CallbackHost != "" {
+			msg += " It was being sent to " + e.CallbackHost + "."
+		}
+	}
+	if e.SignerID != "" {
+		msg += " Citizen: " + e.SignerID + "."
+	}
+	return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return widgets.Banner(gtx, s.Theme, tone, msg)
+			}),
+			layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+			layout.Rigid(widgets.SecondaryButton(s.Theme, &s.DismissInterrupted, "Dismiss").Layout),
+		)
+	})
+}
+
+// layoutDataDir reports a failed data directory migration or files that
+// failed the startup checks.
+func (s *OpenRequestScreen) layoutDataDir(gtx layout.Context) layout.Dimensions {
+	r := s.App.DataDir
+	var msg string
+	switch {
+	case errors.Is(r.Err, datadir.ErrNewerLayout):
+		msg = "Your data folder was last used by a newer version of VocSign. Some certificates or settings may not load until you update."
+	case r.Err != nil:
+		msg = "Your data folder could not be updated to this version of VocSign and was left as it was: " + r.Err.Error()
+		if r.Backup != "" {
+			msg += ". A copy was kept in " + r.Backup
+		}
+	default:
+		msg = "Some files in your data folder could not be read and their contents may be lost."
+	}
+	children := []layout.FlexChild{
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return widgets.Banner(gtx, s.Theme, widgets.BannerWarning, msg)
+		}),
+	}
+	for _, p := range r.Problems {
+		children = append(children,
+			layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+			layout.Rigid(material.Caption(s.Theme, "• "+p.String()).Layout),
+		)
+	}
+	children = append(children,
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		layout.Rigid(widgets.SecondaryButton(s.Theme, &s.DismissDataDir, "Dismiss").Layout),
+	)
+	return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	})
+}
+
+// readClipboard asks for the clipboard text, for the Paste button or for the
+// check on focus. The platform's clipboard command is preferred where there
+// is one; Gio's reader answers with events on the screen.
+func (s *OpenRequestScreen) readClipboard(gtx layout.Context, paste bool) {
+	p := s.App.Platform
+	if p.ClipboardReader == nil {
+		if !paste {
+			gtx.Execute(clipboard.ReadCmd{Tag: &s.clipboardTag})
+			return
+		}
+		if p.Backend == platform.Wayland {
+			s.pasteAsked = gtx.Now
+		}
+		gtx.Execute(clipboard.ReadCmd{Tag: s})
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		txt, err := p.ReadClipboard(ctx)
+		if err != nil {
+			log.Printf("WARNING: failed to read clipboard with %s: %v", p.ClipboardReader[0], err)
+		}
+		s.clipboardRead <- clipboardText{text: txt, err: err, paste: paste}
+		s.App.Invalidate()
+	}()
+}
+
+// paste puts the clipboard text in the URL field.
+func (s *OpenRequestScreen) paste(data string, err error) {
+	if err != nil {
+		s.App.FetchStatus = "Clipboard Error: could not read clipboard text"
+		s.App.ReqError = err
+		return
+	}
+	txt := strings.TrimSpace(data)
+	if txt == "" {
+		s.App.FetchStatus = "Clipboard is empty"
+		s.App.ReqError = nil
+		return
+	}
+	s.URLEditor.SetText(txt)
+	s.App.FetchStatus = "Signing URL pasted from clipboard"
+	s.App.ReqError = nil
+}
+
+// readClipboardSuggestion handles the clipboard contents Gio read on focus.
+func (s *OpenRequestScreen) readClipboardSuggestion(gtx layout.Context) {
+	for {
+		ev, ok := gtx.Event(transfer.TargetFilter{Target: &s.clipboardTag, Type: "application/text"})
+		if !ok {
+			return
+		}
+		de, ok := ev.(transfer.DataEvent)
+		if !ok {
+			continue
+		}
+		rc := de.Open()
+		data, err := io.ReadAll(io.LimitReader(rc, 4096))
+		_ = rc.Close()
+		if err != nil {
+			continue
+		}
+		s.suggestClipboard(string(data))
+	}
+}
+
+// suggestClipboard offers the clipboard text read on focus. Text matching
+// the request URL pattern is offered right away; other links are only
+// downloaded to check them when the user enabled probing.
+func (s *OpenRequestScreen) suggestClipboard(data string) {
+	txt := strings.TrimSpace(data)
+	if txt == "" || txt == s.clipboardIgnored || txt == strings.TrimSpace(s.URLEditor.Text()) {
+		return
+	}
+	cfg := s.App.Settings.Get()
+	if net.LooksLikeRequestURL(txt, cfg.ClipboardPattern) {
+		s.clipboardURL = txt
+		return
+	}
+	if !cfg.ClipboardProbe || strings.ContainsAny(txt, " \t\r\n") {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if net.ProbeRequestURL(ctx, txt) {
+			s.clipboardURL = txt
+			s.App.Invalidate()
+		}
+	}()
+}
+
+// layoutSuggestion offers a request URL that did not come from the URL
+// field, from the clipboard or a vocsign:// link, to be opened or dismissed.
+func (s *OpenRequestScreen) layoutSuggestion(gtx layout.Context, title, url string, dismiss, open *widget.Clickable) layout.Dimensions {
+	return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+			layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+					layout.Rigid(material.Subtitle2(s.Theme, title).Layout),
+					layout.Rigid(material.Caption(s.Theme, net.DisplayURL(url)).Layout),
+				)
+			}),
+			layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+			layout.Rigid(widgets.SecondaryButton(s.Theme, dismiss, "Dismiss").Layout),
+			layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+			layout.Rigid(widgets.PrimaryButton(s.Theme, open, "Open").Layout),
+		)
+	})
+}
+
+func (s *OpenRequestScreen) layoutRecent(gtx layout.Context) layout.Dimensions {
+	return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
+		children := []layout.FlexChild{
+			layout.Rigid(material.Subtitle2(s.Theme, "Recent requests").Layout),
+			layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		}
+		now := time.Now()
+		for i := range s.recent {
+			r := s.recent[i]
+			open, forget := &s.recentOpen[i], &s.recentForget[i]
+			expired := false
+			if t, err := time.Parse(time.RFC3339, r.ExpiresAt); err == nil && t.Before(now) {
+				expired = true
+			}
+			children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.Inset{Bottom: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+						layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+							return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+								layout.Rigid(material.Body1(s.Theme, nonEmptyText(r.Title, r.RequestID)).Layout),
+								layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+									return material.Caption(s.Theme, r.Promoter+" · opened "+formatOpenedAt(s.App.Locale(), r.OpenedAt)).Layout(gtx)
+								}),
+							)
+						}),
+						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+							if !expired {
+								return layout.Dimensions{}
+							}
+							return layout.Inset{Right: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+								return widgets.StatusTag(gtx, s.Theme, widgets.BannerError, "EXPIRED")
+							})
+						}),
+						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+							if expired {
+								return layout.Dimensions{}
+							}
+							return widgets.PrimaryButton(s.Theme, open, "Resume").Layout(gtx)
+						}),
+						layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+						layout.Rigid(widgets.SecondaryButton(s.Theme, forget, "Remove").Layout),
+					)
+				})
+			}))
+		}
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	})
+}
+
+func (s *OpenRequestScreen) layoutCampaigns(gtx layout.Context, campaigns []app.PinnedCampaign, loading bool) layout.Dimensions {
+	return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
+		children := []layout.FlexChild{
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+					layout.Flexed(1, material.Subtitle2(s.Theme, "Open proposals from pinned organizers").Layout),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						if loading {
+							return material.Caption(s.Theme, "Refreshing...").Layout(gtx)
+						}
+						return widgets.SecondaryButton(s.Theme, &s.refreshCampaigns, "Refresh").Layout(gtx)
+					}),
+				)
+			}),
+			layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		}
+		for i := range campaigns {
+			c := campaigns[i]
+			open := &s.campaignOpen[i]
+			children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.Inset{Bottom: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+						layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+							return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+								layout.Rigid(material.Body1(s.Theme, c.Title).Layout),
+								layout.Rigid(material.Caption(s.Theme, c.Organizer+" · "+c.RequestID).Layout),
+							)
+						}),
+						layout.Rigid(widgets.PrimaryButton(s.Theme, open, "Open").Layout),
+					)
+				})
+			}))
+		}
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	})
+}
+
+func formatOpenedAt(loc locale.Locale, ts string) string {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return ts
+	}
+	return loc.DateTime(t.Local())
+}
+
+// startFetch downloads, authenticates and opens the request at url.
+func (s *OpenRequestScreen) startFetch(url string) {
+	s.App.FetchStatus = "Connecting to server..."
+	s.App.ReqError = nil
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("ERROR: panic while fetching request: %v\n%s", r, string(debug.Stack()))
+				s.App.FetchStatus = "Unexpected Error: could not process request"
+				s.App.ReqError = fmt.Errorf("panic while processing request: %v", r)
+				s.App.Invalidate()
+			}
+		}()
+
+		ctx := context.Background()
+		req, raw, err := net.Fetch(ctx, url)
+		if errcode.Of(err) == errcode.UnsupportedVersion {
+			s.App.FetchStatus = errorStatus("Version Error", err) + upgradeHint(s.App.UpdateStatusSnapshot())
+			s.App.ReqError = err
+			return
+		}
+		if err != nil {
+			s.App.FetchStatus = errorStatus("Connection Error", err)
+			s.App.ReqError = err
+			return
+		}
+		if err := s.App.Managed.CheckOrganizer(req.Organizer.JWKSetURL); err != nil {
+			s.App.FetchStatus = errorStatus("Policy Error", err)
+			s.App.ReqError = err
+			return
+		}
+
+		s.App.FetchStatus = "Authenticating Request..."
+		res, err := jwsverify.Verify(req)
+		var logWarning string
+		if err == nil {
+			if req.TransparencyLog != nil {
+				s.App.FetchStatus = "Checking transparency log..."
+			}
+			logWarning, err = s.App.CheckTransparencyLog(ctx, req)
+		}
+		var labels model.Labels
+		if err == nil && req.Translations != nil {
+			s.App.FetchStatus = "Loading campaign labels..."
+			labels, err = net.FetchTranslations(ctx, req.Translations)
+		}
+		if err != nil {
+			s.App.FetchStatus = errorStatus("Security Validation Failed", err)
+			s.App.ReqError = err
+		} else {
+			s.App.FetchStatus = "Ready"
+			s.App.CurrentReq = req
+			s.App.RawReq = raw
+			s.App.RequestURL = url
+			warnings := append(net.HostWarnings(net.RequestHosts(url, req)), net.TextWarnings(req)...)
+			s.App.ReqWarnings = append(warnings, res.Warnings...)
+			if logWarning != "" {
+				s.App.ReqWarnings = append(s.App.ReqWarnings, logWarning)
+			}
+			s.App.ReqLabels = labels
+			s.App.ReqDiff = s.App.DiffWithPrevious(req)
+			if len(s.App.ReqDiff) == 0 {
+				s.App.RememberCurrentRequest()
+			}
+			s.App.CurrentScreen = app.ScreenRequestDetails
+			s.App.SaveSession("")
+			if err := s.App.Requests.Touch(storage.RecentRequest{
+				RequestID: req.RequestID,
+				URL:       url,
+				Title:     req.Proposal.Title,
+				Promoter:  req.Proposal.Promoter,
+				ExpiresAt: req.ExpiresAt,
+			}); err != nil {
+				log.Printf("WARNING: failed to record recent request: %v", err)
+			}
+			s.recentLoaded = false
+		}
+		s.App.Invalidate()
+	}()
+}
+
+func (s *OpenRequestScreen) loadRecent() {
+	s.recentLoaded = true
+	list, err := s.App.Requests.Recent()
+	if err != nil {
+		log.Printf("WARNING: failed to load recent requests: %v", err)
+	}
+	s.recent = list
+	if len(s.recentOpen) < len(list) {
+		s.recentOpen = make([]widget.Clickable, len(list))
+		s.recentForget = make([]widget.Clickable, len(list))
+	}
+}
+
+// errorStatus formats err for a status banner. Errors with a code get the
+// actionable message for that code, followed by the code for support
+// requests. The full error is logged.
+func errorStatus(prefix string, err error) string {
+	code := errcode.Of(err)
+	if code == errcode.Unknown {
+		return prefix + ": " + err.Error()
+	}
+	log.Printf("WARNING: %s: %v", prefix, err)
+	return prefix + ": " + errcode.Message(err) + " [" + string(code) + "]"
+}
+
+// upgradeHint points to the release that may open a request of a newer
+// version, when the update check found one.
+func upgradeHint(status app.UpdateStatus) string {
+	if !status.Available || status.LatestVersion == "" {
+		return ""
+	}
+	return " VocSign " + status.LatestVersion + " is available: use Download update at the bottom of the window."
+}
+
 func statusTone(status string) widgets.BannerTone {
 	lower := strings.ToLower(status)
 	switch {
@@ -0,0 +1,93 @@
+package screens
+
+import (
+	"io"
+
+	"gioui.org/io/key"
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/app"
+	"github.com/vocdoni/gofirma/vocsign/internal/ui/widgets"
+)
+
+// PINPrompt asks for the token PIN or confirmation code a signing goroutine
+// is waiting on. Screens that sign show it while App.PendingPINRequest is
+// set.
+type PINPrompt struct {
+	Editor       widget.Editor
+	SubmitButton widget.Clickable
+	CancelButton widget.Clickable
+}
+
+func (p *PINPrompt) init() {
+	p.Editor.SingleLine = true
+	p.Editor.Submit = true
+	p.Editor.Mask = '•'
+}
+
+func (p *PINPrompt) Layout(gtx layout.Context, th *material.Theme, pr *app.PINRequest) layout.Dimensions {
+	submitted := false
+	for {
+		ev, ok := p.Editor.Update(gtx)
+		if !ok {
+			break
+		}
+		if _, ok := ev.(widget.SubmitEvent); ok {
+			submitted = true
+		}
+	}
+	if p.SubmitButton.Clicked(gtx) {
+		submitted = true
+	}
+	if submitted && p.Editor.Len() > 0 {
+		pr.Respond(takeSecret(&p.Editor))
+	}
+	if p.CancelButton.Clicked(gtx) {
+		p.Editor.SetText("")
+		pr.Respond(nil)
+	}
+	gtx.Execute(key.FocusCmd{Tag: &p.Editor})
+
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return widgets.Banner(gtx, th, widgets.BannerWarning, pr.Message)
+		}),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return widgets.Border(gtx, widgets.ColorBorder, func(gtx layout.Context) layout.Dimensions {
+				return layout.UniformInset(unit.Dp(8)).Layout(gtx, material.Editor(th, &p.Editor, pr.Hint).Layout)
+			})
+		}),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+				layout.Rigid(widgets.SecondaryButton(th, &p.CancelButton, "Cancel").Layout),
+				layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+				layout.Rigid(widgets.PrimaryButton(th, &p.SubmitButton, "Unlock").Layout),
+			)
+		}),
+	)
+}
+
+// takeSecret returns the text of e as bytes and empties e. The text is read
+// into the returned slice directly, without the string copy Text makes, so
+// the caller can wipe the only copy it gets.
+func takeSecret(e *widget.Editor) []byte {
+	n, err := e.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil
+	}
+	secret := make([]byte, n)
+	if _, err := e.Seek(0, io.SeekStart); err == nil {
+		_, err = io.ReadFull(e, secret)
+	}
+	e.SetText("")
+	if err != nil {
+		clear(secret)
+		return nil
+	}
+	return secret
+}
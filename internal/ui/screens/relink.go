@@ -0,0 +1,99 @@
+package screens
+
+import (
+	"context"
+	"log"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/app"
+	"github.com/vocdoni/gofirma/vocsign/internal/ui/widgets"
+)
+
+// RelinkPrompt offers to point token identities whose browser profile moved
+// at the profile that holds the same certificate now. It is shown above
+// every screen until the user relinks or dismisses each offer.
+type RelinkPrompt struct {
+	App   *app.App
+	Theme *material.Theme
+
+	Relink  widget.Clickable
+	Dismiss widget.Clickable
+
+	relinking bool
+	status    string
+	// The result banner is dropped once the user leaves this screen.
+	statusScreen app.Screen
+}
+
+func NewRelinkPrompt(a *app.App, th *material.Theme) *RelinkPrompt {
+	return &RelinkPrompt{App: a, Theme: th}
+}
+
+func (p *RelinkPrompt) Layout(gtx layout.Context) layout.Dimensions {
+	offers := p.App.RelinkOffersSnapshot()
+	if len(offers) == 0 {
+		if p.status == "" || p.App.CurrentScreen != p.statusScreen {
+			p.status = ""
+			return layout.Dimensions{}
+		}
+		return widgets.Banner(gtx, p.Theme, statusTone(p.status), p.status)
+	}
+	offer := offers[0]
+
+	if p.Dismiss.Clicked(gtx) {
+		p.App.DismissRelink(offer.Identity.ID)
+		p.status = ""
+	}
+	if p.Relink.Clicked(gtx) && !p.relinking {
+		p.relink(offer)
+	}
+
+	return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+			layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+					layout.Rigid(material.Subtitle2(p.Theme, "The browser profile holding "+offer.Identity.FriendlyName+" moved").Layout),
+					layout.Rigid(material.Caption(p.Theme, "The same certificate was found in "+offer.Ref.ProfileDir+". Relink it to keep signing with it.").Layout),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						if p.status == "" {
+							return layout.Dimensions{}
+						}
+						return material.Caption(p.Theme, p.status).Layout(gtx)
+					}),
+				)
+			}),
+			layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+			layout.Rigid(widgets.SecondaryButton(p.Theme, &p.Dismiss, "Dismiss").Layout),
+			layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				label := "Relink"
+				if p.relinking {
+					label = "Relinking..."
+				}
+				return widgets.PrimaryButton(p.Theme, &p.Relink, label).Layout(gtx)
+			}),
+		)
+	})
+}
+
+func (p *RelinkPrompt) relink(offer app.RelinkOffer) {
+	p.relinking = true
+	p.statusScreen = p.App.CurrentScreen
+	go func() {
+		defer func() {
+			p.relinking = false
+			p.App.Invalidate()
+		}()
+		ctx := context.Background()
+		if err := p.App.AcceptRelink(ctx, offer.Identity.ID); err != nil {
+			log.Printf("ERROR: failed to relink %s: %v", offer.Identity.ID, err)
+			p.status = "Relink failed: " + err.Error()
+			return
+		}
+		p.status = offer.Identity.FriendlyName + " relinked and ready to sign"
+	}()
+}
@@ -4,29 +4,47 @@ import (
 	"context"
 	"crypto"
 	"crypto/sha256"
+	"crypto/x509"
 	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"image/color"
+	"io"
 	"log"
+	"net/url"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"gioui.org/font"
 	"gioui.org/layout"
+	"gioui.org/op"
 	"gioui.org/unit"
 	"gioui.org/widget"
 	"gioui.org/widget/material"
+	"gioui.org/x/explorer"
 
 	"github.com/vocdoni/gofirma/vocsign/internal/app"
 	"github.com/vocdoni/gofirma/vocsign/internal/crypto/cades"
 	"github.com/vocdoni/gofirma/vocsign/internal/crypto/certs"
 	"github.com/vocdoni/gofirma/vocsign/internal/crypto/pkcs12store"
+	"github.com/vocdoni/gofirma/vocsign/internal/demo"
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+	"github.com/vocdoni/gofirma/vocsign/internal/evidence"
+	"github.com/vocdoni/gofirma/vocsign/internal/ilp"
 	"github.com/vocdoni/gofirma/vocsign/internal/model"
 	"github.com/vocdoni/gofirma/vocsign/internal/net"
+	"github.com/vocdoni/gofirma/vocsign/internal/paper"
+	"github.com/vocdoni/gofirma/vocsign/internal/platform"
+	"github.com/vocdoni/gofirma/vocsign/internal/presign"
 	"github.com/vocdoni/gofirma/vocsign/internal/storage"
+	"github.com/vocdoni/gofirma/vocsign/internal/telemetry"
 	"github.com/vocdoni/gofirma/vocsign/internal/ui/icons"
 	"github.com/vocdoni/gofirma/vocsign/internal/ui/widgets"
 )
@@ -38,6 +56,15 @@ type RequestDetailsScreen struct {
 	SignButton widget.Clickable
 	CertList   widget.List
 	CertEnum   widget.Enum
+	// CoSignCheck and CoSignEnum choose a second certificate that signs the
+	// same XML in parallel.
+	CoSignCheck widget.Bool
+	CoSignEnum  widget.Enum
+	// PairAuthCheck signs with the authentication certificate of a
+	// signature and authentication pair; certPairs are the pairs in the
+	// picker by signature certificate ID.
+	PairAuthCheck widget.Bool
+	certPairs     map[string]pkcs12store.Pair
 
 	IDEditor widget.Editor
 
@@ -47,12 +74,39 @@ type RequestDetailsScreen struct {
 	DNIEditor     widget.Editor
 	BirthEditor   widget.Editor
 	ConsentCheck  widget.Bool
+	LegalAckCheck widget.Bool
+	InitialsEdit  widget.Editor
+	DiffAckCheck  widget.Bool
+	// legalText and summaryText present the legal statement and the
+	// summary, which may be long.
+	legalText   widgets.TextPresenter
+	summaryText widgets.TextPresenter
+	// RetryAckCheck confirms signing again after a submission of this
+	// request was interrupted.
+	RetryAckCheck widget.Bool
+	// RepAckCheck acknowledges signing with a representative certificate.
+	RepAckCheck widget.Bool
+	PINPrompt   PINPrompt
+	batch       *BatchPanel
+
+	// EmailEditor, PhoneEditor and ContactConsentCheck answer the request's
+	// optional contactRequest.
+	EmailEditor         widget.Editor
+	PhoneEditor         widget.Editor
+	ContactConsentCheck widget.Bool
 
 	birthDateErr  string
 	lastBirthText string
 
 	DocLinkButton    widget.Clickable
 	PolicyLinkButton widget.Clickable
+	PaperSheetButton widget.Clickable
+	PrintButton      widget.Clickable
+	PrintReceiptBtn  widget.Clickable
+	ReloadButton     widget.Clickable
+	PinButton        widget.Clickable
+	NewBatchButton   widget.Clickable
+	AgentSettingsBtn widget.Clickable
 
 	MainList     widget.List
 	LeftList     widget.List
@@ -60,10 +114,63 @@ type RequestDetailsScreen struct {
 	PostSignList widget.List
 
 	lastSelectedCert string
+	savedSession     string // cert ID last persisted
+	policyStatus     string
+	policyLoading    bool
+	policyFor        *model.SignPolicy
+	diffReq          *model.SignRequest
 	selectedInfo     certs.ExtractedInfo
-	IsSigning        bool
+	// repAckFor is the certificate fingerprint and warning digest
+	// RepAckCheck refers to; repAckNeeded is set when the user has not
+	// acknowledged that warning before.
+	repAckFor    [2]string
+	repAckNeeded bool
+	IsSigning    bool
+	review       *submitReview
+	signing      *signProgress
+	// receipt is the last signature the collector acknowledged, for
+	// printing; agents print one for each citizen. receiptRaw is its
+	// request as fetched, for the evidence package.
+	receipt    *paper.Receipt
+	receiptRaw []byte
+	// changedReq is the request the collector rejected a signature of
+	// because its proposal text was amended since it was fetched.
+	changedReq *model.SignRequest
+
+	// agentMode is the mode the signer fields were last set up for.
+	// clearSigner is set by the signing goroutine when an agent's signature
+	// was submitted, so the form is emptied for the next citizen.
+	agentMode   bool
+	clearSigner bool
+
+	// publicStats is the collector's public count for statsFor, fetched in
+	// the background when the request is shown.
+	statsMu     sync.Mutex
+	statsFor    *model.SignRequest
+	publicStats *model.PublicStatsSummary
+
+	// language is the localization the proposal is shown in, empty for the
+	// original. langButtons choose it, "Original" first; docButtons open the
+	// request's attachments and policies.
+	language    string
+	langButtons []widget.Clickable
+	docButtons  []widget.Clickable
+
+	// links opens the full text and documents, which the request names.
+	links widgets.LinkGuard
 
 	backButton widget.Clickable
+
+	// ShareReceiptBtn and ShareEvidenceBtn offer the receipt PDF and the
+	// evidence package in the macOS share sheet; elsewhere
+	// SaveEvidenceBtn saves the package.
+	ShareReceiptBtn  widget.Clickable
+	ShareEvidenceBtn widget.Clickable
+	SaveEvidenceBtn  widget.Clickable
+	EmailReceiptBtn  widget.Clickable
+	// shareStatus is set by the goroutines that share, email or save.
+	shareMu     sync.Mutex
+	shareStatus string
 }
 
 func NewRequestDetailsScreen(a *app.App, th *material.Theme) *RequestDetailsScreen {
@@ -76,15 +183,22 @@ func NewRequestDetailsScreen(a *app.App, th *material.Theme) *RequestDetailsScre
 	s.LeftList.Axis = layout.Vertical
 	s.RightList.Axis = layout.Vertical
 	s.PostSignList.Axis = layout.Vertical
+	s.summaryText.Lines = 4
 
+	// Names read from the certificate may be corrected, e.g. for accents
+	// the issuer dropped; the ID number may not.
 	s.IDEditor.ReadOnly = true
-	s.NomEditor.ReadOnly = true
-	s.Cognom1Editor.ReadOnly = true
-	s.Cognom2Editor.ReadOnly = true
 	s.DNIEditor.ReadOnly = true
 
 	s.BirthEditor.SetText("1980-01-01")
 	s.BirthEditor.SingleLine = true
+	s.InitialsEdit.SingleLine = true
+	s.EmailEditor.SingleLine = true
+	s.PhoneEditor.SingleLine = true
+
+	s.links = widgets.LinkGuard{Known: a.LinkHostKnown, Remember: a.RememberLinkHost}
+	s.PINPrompt.init()
+	s.batch = NewBatchPanel(a, th)
 	return s
 }
 
@@ -105,21 +219,101 @@ func (s *RequestDetailsScreen) Layout(gtx layout.Context) layout.Dimensions {
 		})
 	}
 
+	if s.diffReq != req {
+		s.diffReq = req
+		s.DiffAckCheck.Value = false
+		s.RetryAckCheck.Value = false
+		s.savedSession = ""
+		s.LegalAckCheck.Value = false
+		s.InitialsEdit.SetText("")
+		s.EmailEditor.SetText("")
+		s.PhoneEditor.SetText("")
+		s.ContactConsentCheck.Value = false
+		s.language = ""
+	}
+	s.loadPublicStats(req)
+	if s.DiffAckCheck.Update(gtx) && s.DiffAckCheck.Value {
+		s.App.RememberCurrentRequest()
+	}
+
+	// Demo campaigns are signed one volunteer at a time, with the throwaway
+	// demo certificate and never with a real one.
+	demoMode := req.IsDemo()
+	agent := s.App.Settings.Get().AgentMode() && !demoMode
+	if agent != s.agentMode {
+		s.setAgentMode(agent)
+	}
+	if agent {
+		// Agents always sign with the certificate chosen in Settings.
+		s.CertEnum.Value = ""
+		if id, ok := s.App.AgentIdentity(); ok {
+			s.CertEnum.Value = id.ID
+		}
+		if s.clearSigner {
+			s.clearSigner = false
+			s.resetSignerFields()
+		}
+		if s.NewBatchButton.Clicked(gtx) {
+			s.App.ResetBatch()
+		}
+		if s.AgentSettingsBtn.Clicked(gtx) {
+			s.App.CurrentScreen = app.ScreenSettings
+		}
+	}
+
+	if demoMode {
+		// The throwaway demo certificate is the default; a test certificate
+		// from the wallet may be chosen instead.
+		if id := s.findIdentity(s.CertEnum.Value); id == nil || !demo.IsIdentity(id.ID) && !demo.IsTest(id.Cert) {
+			s.CertEnum.Value = ""
+			if id, err := s.App.DemoIdentity(); err != nil {
+				s.App.SignStatus = "Could not create the demo certificate: " + err.Error()
+			} else {
+				s.CertEnum.Value = id.ID
+			}
+		}
+	}
+
 	if s.IDEditor.Text() != req.RequestID {
 		s.IDEditor.SetText(req.RequestID)
 	}
 
 	if s.DocLinkButton.Clicked(gtx) {
-		widgets.OpenURL(req.Proposal.FullText.URL)
+		s.links.Open(net.BrowsableURL(req.Proposal.FullText.URL))
+	}
+	if s.PolicyLinkButton.Clicked(gtx) && req.Policy != nil && !s.policyLoading {
+		s.viewPolicyDocument(req.Policy)
+	}
+	if s.PaperSheetButton.Clicked(gtx) {
+		s.openPaperSheet(req)
 	}
-	if s.PolicyLinkButton.Clicked(gtx) && req.Policy != nil {
-		widgets.OpenURL(req.Policy.URI)
+	if s.PrintButton.Clicked(gtx) {
+		lang := s.language
+		s.openPrintable("vocsign-details-*.html", false, func(w io.Writer) error {
+			return paper.WriteDetails(w, req, lang)
+		})
+	}
+	if s.PrintReceiptBtn.Clicked(gtx) && s.receipt != nil {
+		s.printReceipt(*s.receipt)
+	}
+	if s.PinButton.Clicked(gtx) && req.Organizer.CampaignIndexURL != "" {
+		s.togglePinnedOrganizer(req)
+	}
+
+	if sess := s.App.TakeSessionRestore(req.RequestID); sess != nil {
+		if sess.CertID != "" && s.findIdentity(sess.CertID) != nil {
+			s.CertEnum.Value = sess.CertID
+		}
 	}
 
 	if s.CertEnum.Value != s.lastSelectedCert {
 		s.lastSelectedCert = s.CertEnum.Value
-		if identity := s.findIdentity(s.CertEnum.Value); identity != nil {
-			s.selectedInfo = certs.ExtractSpanishIdentity(identity.Cert)
+		s.PairAuthCheck.Value = false
+		if identity := s.findIdentity(s.CertEnum.Value); identity != nil && agent {
+			// The certificate is the agent's; the citizen's data is typed in.
+			s.selectedInfo = certs.CachedSpanishIdentity(identity.Cert)
+		} else if identity != nil {
+			s.selectedInfo = certs.CachedSpanishIdentity(identity.Cert)
 			s.NomEditor.SetText(s.selectedInfo.Nom)
 			if len(s.selectedInfo.Cognoms) >= 1 {
 				s.Cognom1Editor.SetText(s.selectedInfo.Cognoms[0])
@@ -139,58 +333,127 @@ func (s *RequestDetailsScreen) Layout(gtx layout.Context) layout.Dimensions {
 				s.BirthEditor.ReadOnly = true
 				s.birthDateErr = ""
 			} else {
-				s.BirthEditor.SetText("1980-01-01")
+				s.BirthEditor.SetText(defaultBirthDate(req.SignerFields()))
 				s.BirthEditor.ReadOnly = false
 			}
+			if saved, ok := s.App.RememberedSignerData(identity.Cert); ok {
+				s.applySignerData(saved)
+			}
 		} else {
 			s.selectedInfo = certs.ExtractedInfo{}
 		}
 	}
 
+	// Persist the selected certificate so an interrupted signing can be
+	// resumed. The birth date is personal data and stays in memory, and
+	// nothing is saved in agent mode, which signs for other citizens.
+	if current := s.CertEnum.Value; !agent && !demoMode && current != s.savedSession {
+		s.savedSession = current
+		s.App.SaveSession(current)
+	}
+
+	s.updateRepresentativeAck(req)
+
 	// Real-time birth date validation
+	fields := req.SignerFields()
 	if text := strings.TrimSpace(s.BirthEditor.Text()); text != s.lastBirthText {
 		s.lastBirthText = text
-		if err := model.ValidateBirthDate(text); err != nil {
-			s.birthDateErr = err.Error()
-		} else {
-			s.birthDateErr = ""
+		s.birthDateErr = ""
+		if mode := fields.BirthDateMode(); mode == model.SignerFieldRequired || mode == model.SignerFieldOptional && text != "" {
+			if err := model.ValidateBirthDate(text); err != nil {
+				s.birthDateErr = err.Error()
+			}
 		}
 	}
 
-	if s.SignButton.Clicked(gtx) && !s.IsSigning {
+	if s.SignButton.Clicked(gtx) && !s.IsSigning && !s.batch.Running() {
 		certID := s.CertEnum.Value
 		if certID != "" {
 			identity := s.findIdentity(certID)
 			if identity != nil {
+				identity = s.pairedSigningIdentity(identity)
 				nom := strings.TrimSpace(s.NomEditor.Text())
 				cognom1 := strings.TrimSpace(s.Cognom1Editor.Text())
 				cognom2 := strings.TrimSpace(s.Cognom2Editor.Text())
 				dni := strings.TrimSpace(s.DNIEditor.Text())
 				birthDate := strings.TrimSpace(s.BirthEditor.Text())
-				if dni == "" {
+				idType := s.selectedInfo.IDType
+				var coSigner *pkcs12store.Identity
+				var coSignErr error
+				if !agent && !demoMode {
+					coSigner, coSignErr = s.selectedCoSigner(certID)
+				}
+				var journalSigner string
+				if agent {
+					// The ID is typed in, so check its shape and control
+					// letter rather than trust it.
+					dni, idType = certs.ParsePersonalID(dni)
+					journalSigner = dni
+				}
+				if demo.IsTest(identity.Cert) && !demoMode {
+					s.App.SignStatus = "Validation failed: test certificates can only sign demo campaigns"
+				} else if dni == "" && agent {
+					s.App.SignStatus = "Validation failed: the citizen's ID is not a valid DNI or NIE"
+				} else if agent && !certs.ValidControlLetter(dni) {
+					s.App.SignStatus = "Validation failed: the letter of the citizen's DNI/NIE does not match its number, check the digits"
+				} else if dni == "" {
 					s.App.SignStatus = "Validation failed: signer ID/DNI is required"
 				} else if nom == "" && cognom1 == "" && cognom2 == "" {
 					s.App.SignStatus = "Validation failed: signer name is required"
-				} else if err := model.ValidateBirthDate(birthDate); err != nil {
+				} else if _, err := fields.Check(model.Signant{Cognom2: cognom2, DataNaixement: birthDate}); err != nil {
+					s.App.SignStatus = "Validation failed: " + err.Error()
+				} else if len(s.App.ReqDiff) > 0 && !s.DiffAckCheck.Value {
+					s.App.SignStatus = "This request changed since you last opened it: review and acknowledge the changes first"
+				} else if s.repAckNeeded && !s.RepAckCheck.Value {
+					s.App.SignStatus = "Validation failed: confirm that you understand this is a representative certificate"
+				} else if ack := requiredAck(req); ack != nil && !s.LegalAckCheck.Value {
+					s.App.SignStatus = "Validation failed: confirm that you have read and agree with the legal statement"
+				} else if err := validateAckInitials(ack, s.InitialsEdit.Text()); err != nil {
+					s.App.SignStatus = "Validation failed: " + err.Error()
+				} else if coSignErr != nil {
+					s.App.SignStatus = "Validation failed: " + coSignErr.Error()
+				} else if contact, err := s.signerContact(req, agent); err != nil {
 					s.App.SignStatus = "Validation failed: " + err.Error()
 				} else if !s.ConsentCheck.Value {
-					s.App.SignStatus = "You must confirm you have read and accept the data protection notice and consent to signing this initiative"
+					s.App.SignStatus = s.App.ReqLabels.Get(model.LabelConsentError, "You must confirm you have read and accept the data protection notice and consent to signing this initiative")
 				} else {
+					if s.repAckNeeded {
+						s.App.AcknowledgeCertWarning(s.repAckFor[0], representativeAckKind, s.repAckFor[1])
+						s.repAckNeeded = false
+					}
 					s.IsSigning = true
+					s.receipt, s.receiptRaw = nil, nil
 					s.App.SignStatus = "Preparing legally compliant XML..."
 
 					reqCopy := *req
+					agentMode := agent
+					prior, hasPrior := s.App.InterruptedSubmission(journalEntry(req, identity.Cert, journalSigner))
+					retryAck := s.RetryAckCheck.Value
+					rawReq, rawRef := s.App.RawReq, s.App.KeepRawRequest()
+					var legalAck, legalAckInitials string
+					if ack := requiredAck(req); ack != nil {
+						legalAck = ack.Digest(req.Proposal.LegalStatement)
+						if ack.Initials {
+							legalAckInitials = strings.TrimSpace(s.InitialsEdit.Text())
+						}
+					}
+					var certification *model.Certificacio
+					if agentMode {
+						c := agentCertification(s.selectedInfo, model.OrigenPresencial)
+						certification = &c
+					}
 					identityID := identity.ID
 					identityCert := identity.Cert
 					identityChain := identity.Chain
-					isSystem := strings.HasPrefix(identityID, "nss:") || strings.HasPrefix(identityID, "os:")
+					// System and demo identities come with their signer.
+					isSystem := strings.HasPrefix(identityID, "nss:") || strings.HasPrefix(identityID, "os:") || demo.IsIdentity(identityID)
 					identitySigner := identity.Signer
 
 					if err := certs.ValidateForSigning(identityCert, identityChain); err != nil {
 						s.App.SignStatus = "Certificate validation failed: " + err.Error()
+						s.App.Telemetry.Record(telemetry.EventSign, telemetry.OutcomeFailCertificate, "")
 						s.IsSigning = false
 					} else {
-						idType := s.selectedInfo.IDType
 						if idType == "" {
 							idType = "DNI"
 						}
@@ -202,14 +465,89 @@ func (s *RequestDetailsScreen) Layout(gtx layout.Context) layout.Dimensions {
 							NumIdentifica:   dni,
 							DataNaixement:   strings.TrimSpace(s.BirthEditor.Text()),
 						}
+						// Checked above; this drops the fields the request omits.
+						signerData, _ = fields.Check(signerData)
+
+						remember := correctedSignerData(s.selectedInfo, signerData)
+
+						presignInput := presign.Input{
+							RequestID:     reqCopy.RequestID,
+							ProposalTitle: reqCopy.Proposal.Title,
+							Cert:          identityCert,
+							Identity:      s.selectedInfo,
+						}
 
+						endBusy := s.App.BeginBusy()
 						go func() {
 							ctx := context.Background()
+							defer endBusy()
 							defer func() { s.IsSigning = false }()
 
+							// An earlier submission of this signature was interrupted:
+							// ask the collector before sending it again.
+							if hasPrior {
+								s.App.SignStatus = "Checking whether your earlier signature was received..."
+								s.App.Invalidate()
+								receipt, err := net.LookupReceipt(ctx, &reqCopy, prior.SignatureSHA256)
+								if err != nil && !retryAck {
+									s.App.SignStatus = errorStatus("Your earlier signature may have been received", err) + ". Check with the organizer, then confirm above to sign again."
+									return
+								}
+								if err != nil {
+									log.Printf("WARNING: receipt lookup failed, signing again as confirmed: %v", err)
+								}
+								if receipt != nil {
+									s.showEarlierReceipt(&reqCopy, prior, receipt, signerData, agentMode, rawReq, rawRef)
+									return
+								}
+							}
+
 							s.App.SignStatus = "Verifying proposal document integrity..."
-							if err := net.VerifyDocumentHash(ctx, reqCopy.Proposal.FullText.URL, reqCopy.Proposal.FullText.SHA256); err != nil {
-								s.App.SignStatus = "Document verification failed: " + err.Error()
+							if err := net.VerifyDocuments(ctx, &reqCopy); err != nil {
+								s.App.SignStatus = errorStatus("Document verification failed", err)
+								s.App.Telemetry.Record(telemetry.EventSign, telemetry.OutcomeFailDocument, "")
+								return
+							}
+
+							// The signature will reference this policy by hash, so a policy
+							// document that does not match undermines the EPES semantics.
+							if p := reqCopy.Policy; p != nil && p.OID != "" {
+								s.App.SignStatus = "Verifying signature policy document..."
+								_, err := p.Digest()
+								if err == nil && p.URI != "" {
+									_, err = s.App.PolicyDocument(ctx, p)
+								}
+								if err != nil {
+									s.App.SignStatus = errorStatus("Security error: signature policy verification failed", err)
+									s.App.Telemetry.Record(telemetry.EventSign, telemetry.OutcomeFailDocument, "")
+									return
+								}
+							}
+
+							if reqCopy.DuplicateCheck != nil {
+								s.App.SignStatus = "Checking for a previous signature..."
+								dup, err := net.CheckDuplicate(ctx, &reqCopy, signerData.NumIdentifica)
+								if err != nil {
+									log.Printf("WARNING: duplicate check failed: %v", err)
+								} else if dup {
+									s.App.SignStatus = "You have already signed this proposal"
+									s.App.Telemetry.Record(telemetry.EventSign, telemetry.OutcomeAlreadySigned, "")
+									return
+								}
+							}
+
+							if hooks := s.App.PreSignHooks(); len(hooks) > 0 {
+								s.App.SignStatus = "Waiting for confirmation..."
+								if err := presign.Run(ctx, hooks, &presignInput, s.App.PromptCode); err != nil {
+									s.App.SignStatus = "Confirmation failed: " + err.Error()
+									s.App.Telemetry.Record(telemetry.EventSign, telemetry.OutcomeFailPreSign, "")
+									return
+								}
+							}
+
+							if err := s.App.UnlockWallet(); err != nil {
+								s.App.SignStatus = "Unlock failed: " + err.Error()
+								s.App.Telemetry.Record(telemetry.EventSign, telemetry.OutcomeFailUnlock, "")
 								return
 							}
 
@@ -226,25 +564,79 @@ func (s *RequestDetailsScreen) Layout(gtx layout.Context) layout.Dimensions {
 									err = fmt.Errorf("signer is nil")
 								}
 								s.App.SignStatus = "Unlock failed: " + err.Error()
+								if errors.Is(err, pkcs12store.ErrStaleReference) {
+									s.App.SignStatus = "Searching browser profiles for this certificate..."
+									if _, findErr := s.App.OfferRelink(ctx, *identity); findErr != nil {
+										log.Printf("WARNING: stale token reference for %s: %v", identityID, findErr)
+										s.App.SignStatus = "Unlock failed: the browser profile holding this certificate no longer exists and it was not found in any other profile. Scan for certificates again."
+									} else {
+										s.App.SignStatus = "Unlock failed: the browser profile holding this certificate moved. Relink it above and sign again."
+									}
+								}
+								s.App.Telemetry.Record(telemetry.EventSign, telemetry.OutcomeFailUnlock, "")
 								return
 							}
 
-							xmlBytes, err := model.GenerateILPXML(&reqCopy, signerData)
+							var xmlBytes []byte
+							if certification != nil {
+								xmlBytes, err = model.GenerateCertifiedILPXML(&reqCopy, signerData, *certification)
+							} else {
+								xmlBytes, err = model.GenerateILPXML(&reqCopy, signerData)
+							}
 							if err != nil {
 								s.App.SignStatus = "XML generation failed: " + err.Error()
+								s.App.Telemetry.Record(telemetry.EventSign, telemetry.OutcomeFailSigning, "")
 								return
 							}
 
+							// Journal each irreversible step first, so that after a crash
+							// the app knows whether this signature may have been sent.
+							payloadHash := sha256.Sum256(xmlBytes)
+							journal := journalEntry(&reqCopy, identityCert, journalSigner)
+							journal.Stage = storage.StageSigning
+							journal.PayloadSHA256 = hex.EncodeToString(payloadHash[:])
+							s.App.RecordSigning(journal)
+							defer s.App.FinishSigning(journal)
+
 							s.App.SignStatus = "Signing XML payload..."
-							signatureDER, err := cades.SignDetached(ctx, signer, identityCert, identityChain, xmlBytes, cades.SignOpts{
-								SigningTime: time.Now(),
-								Policy:      reqCopy.Policy,
-							})
+							progress := newSignProgress()
+							s.signing = progress
+							signatureDER, err := progress.run(func() ([]byte, error) {
+								return cades.SignDetached(ctx, signer, identityCert, identityChain, xmlBytes, cades.SignOpts{
+									SigningTime: time.Now(),
+									Policy:      reqCopy.Policy,
+								})
+							}, func() bool { return s.App.PendingPINRequest() != nil }, s.signAbandoned)
+							s.signing = nil
+							if errors.Is(err, errSignCanceled) {
+								s.App.SignStatus = "Signing canceled. Nothing was sent. If the card finished a signature, it was discarded."
+								s.App.Telemetry.Record(telemetry.EventSign, telemetry.OutcomeCanceled, "")
+								return
+							}
 							if err != nil {
-								s.App.SignStatus = "Signing failed: " + err.Error()
+								s.App.SignStatus = errorStatus("Signing failed", err)
+								s.App.Telemetry.Record(telemetry.EventSign, telemetry.OutcomeFailSigning, "")
 								return
 							}
 
+							// A second signer signs the same XML in parallel, so the
+							// organizer receives a single multi-signer signature.
+							if coSigner != nil {
+								s.App.SignStatus = "Signing as second signer " + coSigner.Cert.Subject.CommonName + "..."
+								s.App.Invalidate()
+								signatureDER, err = s.addCoSignature(ctx, coSigner, signatureDER, xmlBytes, reqCopy.Policy)
+								if errors.Is(err, errSignCanceled) {
+									s.App.SignStatus = "Signing canceled. Nothing was sent. If the card finished a signature, it was discarded."
+									s.App.Telemetry.Record(telemetry.EventSign, telemetry.OutcomeCanceled, "")
+									return
+								}
+								if err != nil {
+									s.App.SignStatus = errorStatus("Second signature failed", err)
+									s.App.Telemetry.Record(telemetry.EventSign, telemetry.OutcomeFailSigning, "")
+									return
+								}
+							}
+
 							// Request trusted timestamp (CAdES-T) if TSA URL is configured.
 							var timestampTokenB64 string
 							if tsaURL := os.Getenv("VOCSIGN_TSA_URL"); tsaURL != "" {
@@ -257,57 +649,123 @@ func (s *RequestDetailsScreen) Layout(gtx layout.Context) layout.Dimensions {
 								}
 							}
 
-							payloadHash := sha256.Sum256(xmlBytes)
-							certPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: identityCert.Raw}))
-							var chainPEM []string
-							for _, c := range identityChain {
-								chainPEM = append(chainPEM, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})))
-							}
-
-							resp := &model.SignResponse{
-								Version:                "1.0",
-								RequestID:              reqCopy.RequestID,
-								Nonce:                  reqCopy.Nonce,
-								SignedAt:               time.Now().Format(time.RFC3339),
-								PayloadCanonicalSHA256: base64.StdEncoding.EncodeToString(payloadHash[:]),
-								SignatureFormat:        "CAdES-detached",
-								SignatureDerBase64:     base64.StdEncoding.EncodeToString(signatureDER),
-								SignerCertPEM:          certPEM,
-								ChainPEM:               chainPEM,
-								SignerXMLBase64:        base64.StdEncoding.EncodeToString(xmlBytes),
-								TimestampTokenBase64:   timestampTokenB64,
-								Client: model.ClientInfo{
-									App:     "vocsign",
-									Version: "0.1.0",
-									OS:      runtime.GOOS,
-								},
+							signatureHash := sha256.Sum256(signatureDER)
+							resp := newSignResponse(&reqCopy, xmlBytes, signatureDER, identityCert, identityChain, timestampTokenB64)
+							if contact != nil {
+								resp.Extensions = &model.ResponseExtensions{Contact: contact}
 							}
 
-							s.App.SignStatus = "Submitting signature..."
-							receipt, err := net.Submit(ctx, reqCopy.Callback.URL, resp)
-
 							auditEntry := storage.AuditEntry{
 								RequestID:       reqCopy.RequestID,
 								ProposalTitle:   reqCopy.Proposal.Title,
 								SignerName:      signerData.Nom + " " + signerData.Cognom1 + " " + signerData.Cognom2,
 								SignerDNI:       signerData.NumIdentifica,
-								CallbackHost:    "server",
+								CallbackHost:    urlHost(reqCopy.Callback.URL),
 								CertFingerprint: fmt.Sprintf("%x", pkcs12store.Fingerprint(identityCert)),
+								PayloadSHA256:   hex.EncodeToString(payloadHash[:]),
+								SignatureSHA256: hex.EncodeToString(signatureHash[:]),
+								AgentCertified:  agentMode,
+								Demo:            demoMode,
+
+								LegalAck:         legalAck,
+								LegalAckInitials: legalAckInitials,
+							}
+							if coSigner != nil {
+								auditEntry.CoSignerFingerprint = fmt.Sprintf("%x", pkcs12store.Fingerprint(coSigner.Cert))
+							}
+							if reqCopy.Policy != nil {
+								auditEntry.PolicyOID = reqCopy.Policy.OID
+							}
+							if h, err := reqCopy.CanonicalHash(); err == nil {
+								auditEntry.RequestHash = h
+							}
+							auditEntry.RawRequestSHA256 = rawRef
+
+							// Submission is irreversible, so give the user a last chance to
+							// review exactly what will be sent and cancel.
+							if secs := s.App.Settings.Get().SubmitReviewSeconds; secs > 0 {
+								review := newSubmitReview(resp, reqCopy.Callback.URL, signerData, identityCert.Subject.String(), signatureDER, time.Duration(secs)*time.Second)
+								s.review = review
+								s.App.SignStatus = "Review your signature before it is submitted"
+								s.App.Invalidate()
+								submit := review.wait()
+								s.review = nil
+								if !submit {
+									s.App.SignStatus = "Submission canceled. Nothing was sent."
+									s.App.Telemetry.Record(telemetry.EventSign, telemetry.OutcomeCanceled, "")
+									auditEntry.Status = "canceled"
+									if err := s.App.AuditLogger.Log(auditEntry); err != nil {
+										log.Printf("ERROR: failed to write audit log: %v", err)
+									}
+									if agentMode {
+										s.App.RecordBatchResult(reqCopy.RequestID, auditEntry.Status)
+									}
+									s.App.Invalidate()
+									return
+								}
+							}
+
+							journal.Stage = storage.StageSubmitting
+							journal.SignatureSHA256 = auditEntry.SignatureSHA256
+							journal.Response, _ = json.Marshal(resp)
+							s.App.RecordSigning(journal)
+
+							s.App.SignStatus = "Submitting signature..."
+							s.App.Invalidate()
+							receipt, finalURL, err := net.SubmitTraced(ctx, reqCopy.Callback.URL, resp)
+							if host := urlHost(finalURL); finalURL != "" && host != auditEntry.CallbackHost {
+								auditEntry.FinalHost = host
 							}
 
 							if err != nil {
-								s.App.SignStatus = "Submission failed: " + err.Error()
+								s.App.SignStatus = errorStatus("Submission failed", err)
+								if errcode.Of(err) == errcode.ProposalChanged {
+									s.changedReq = req
+								}
+								s.App.Telemetry.Record(telemetry.EventSign, telemetry.OutcomeFailSubmission, "")
 								auditEntry.Status = "fail"
 								auditEntry.Error = err.Error()
+								auditEntry.ErrorCode = string(errcode.Of(err))
+								if err := s.App.AuditLogger.Log(auditEntry); err != nil {
+									log.Printf("ERROR: failed to write audit log: %v", err)
+								}
+								if agentMode {
+									s.App.RecordBatchResult(reqCopy.RequestID, auditEntry.Status)
+								}
+								return
+							}
+
+							if agentMode {
+								// Stay on the form so the next citizen can sign.
+								s.App.Telemetry.Record(telemetry.EventSign, telemetry.OutcomeSuccess, "")
+								auditEntry.Status = "success"
+								auditEntry.ServerAckID = receipt.ReceiptID
+								auditEntry.ReceiptStatus = receipt.Status
+								auditEntry.CounterSignerFingerprint = s.keepCounterSignature(&reqCopy, resp, receipt)
 								if err := s.App.AuditLogger.Log(auditEntry); err != nil {
 									log.Printf("ERROR: failed to write audit log: %v", err)
 								}
+								s.App.RecordBatchResult(reqCopy.RequestID, auditEntry.Status)
+								s.receipt = &paper.Receipt{Request: &reqCopy, Response: resp, Submit: receipt, SignerName: strings.TrimSpace(auditEntry.SignerName), Locale: s.App.Locale()}
+								s.receiptRaw = rawReq
+								s.App.SignStatus = "Signature of " + auditEntry.SignerName + " submitted. Ready for the next citizen."
+								s.clearSigner = true
+								s.App.Invalidate()
 								return
 							}
 
+							s.receipt = &paper.Receipt{Request: &reqCopy, Response: resp, Submit: receipt, SignerName: strings.TrimSpace(auditEntry.SignerName), Locale: s.App.Locale()}
+							s.receiptRaw = rawReq
 							s.App.SignResponse = resp
+							s.App.Telemetry.Record(telemetry.EventSign, telemetry.OutcomeSuccess, "")
+							s.App.ClearSession()
+							if !demoMode {
+								s.App.RememberSignerData(identityCert, remember)
+							}
 							auditEntry.Status = "success"
 							auditEntry.ServerAckID = receipt.ReceiptID
+							auditEntry.ReceiptStatus = receipt.Status
+							auditEntry.CounterSignerFingerprint = s.keepCounterSignature(&reqCopy, resp, receipt)
 							if err := s.App.AuditLogger.Log(auditEntry); err != nil {
 								log.Printf("ERROR: failed to write audit log: %v", err)
 							}
@@ -319,11 +777,21 @@ func (s *RequestDetailsScreen) Layout(gtx layout.Context) layout.Dimensions {
 		}
 	}
 
+	// Test certificates are only offered for demo campaigns, and demo
+	// campaigns only offer test certificates.
 	groups := groupedIdentities{}
-	allIdentities := append([]pkcs12store.Identity{}, s.App.IdentitiesSnapshot()...)
-	allIdentities = append(allIdentities, s.App.SystemIdentitiesSnapshot()...)
+	var allIdentities []pkcs12store.Identity
+	for _, id := range append(s.App.IdentitiesSnapshot(), s.App.SystemIdentitiesSnapshot()...) {
+		if demo.IsTest(id.Cert) == demoMode {
+			allIdentities = append(allIdentities, id)
+		}
+	}
+	s.certPairs = nil
+	if !agent && !demoMode {
+		allIdentities = s.collapseCertPairs(allIdentities)
+	}
 	for _, id := range allIdentities {
-		info := certs.ExtractSpanishIdentity(id.Cert)
+		info := certs.CachedSpanishIdentity(id.Cert)
 		if info.IsRepresentative {
 			groups.Representation = append(groups.Representation, id)
 		} else {
@@ -342,7 +810,15 @@ func (s *RequestDetailsScreen) Layout(gtx layout.Context) layout.Dimensions {
 						}),
 						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 							if s.backButton.Clicked(gtx) {
+								if r := s.review; r != nil {
+									// Leaving the screen must never submit behind the user's back.
+									r.decide(false)
+								}
+								if pr := s.App.PendingPINRequest(); pr != nil {
+									pr.Respond(nil)
+								}
 								s.App.SignStatus = ""
+								s.App.ClearSession()
 								s.App.CurrentReq = nil
 								s.App.CurrentScreen = app.ScreenOpenRequest
 							}
@@ -352,17 +828,54 @@ func (s *RequestDetailsScreen) Layout(gtx layout.Context) layout.Dimensions {
 					)
 				}),
 				layout.Rigid(layout.Spacer{Height: unit.Dp(14)}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					if !demoMode {
+						return layout.Dimensions{}
+					}
+					return layout.Inset{Bottom: unit.Dp(14)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						return widgets.Banner(gtx, s.Theme, widgets.BannerWarning, "DEMO CAMPAIGN: this is a rehearsal. Signatures are made with a throwaway demo certificate and have no legal value.")
+					})
+				}),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					if len(s.App.ReqWarnings) == 0 {
+						return layout.Dimensions{}
+					}
+					return layout.Inset{Bottom: unit.Dp(14)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						return widgets.Banner(gtx, s.Theme, widgets.BannerWarning, strings.Join(s.App.ReqWarnings, "\n"))
+					})
+				}),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					if len(s.App.ReqDiff) == 0 {
+						return layout.Dimensions{}
+					}
+					return layout.Inset{Bottom: unit.Dp(14)}.Layout(gtx, s.layoutRequestDiff)
+				}),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					e, ok := interruptedSubmission(s.App.InterruptedSignings(), req.RequestID)
+					if !ok {
+						return layout.Dimensions{}
+					}
+					return layout.Inset{Bottom: unit.Dp(14)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						return s.layoutInterrupted(gtx, e)
+					})
+				}),
 
 				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					title, summary := req.Localized(s.language)
 					return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
 						return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
 							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-								l := material.H6(s.Theme, req.Proposal.Title)
+								return s.layoutLanguages(gtx, req)
+							}),
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								l := material.H6(s.Theme, title)
 								l.Color = s.Theme.ContrastBg
 								return l.Layout(gtx)
 							}),
 							layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
-							layout.Rigid(material.Body1(s.Theme, req.Proposal.Summary).Layout),
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								return s.summaryText.Layout(gtx, s.Theme, s.Theme.TextSize, summary)
+							}),
 							layout.Rigid(layout.Spacer{Height: unit.Dp(12)}.Layout),
 							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 								return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
@@ -372,6 +885,12 @@ func (s *RequestDetailsScreen) Layout(gtx layout.Context) layout.Dimensions {
 										l.Font.Weight = font.Bold
 										return l.Layout(gtx)
 									}),
+									layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+										if req.Proposal.DocumentVersion == 0 {
+											return layout.Dimensions{}
+										}
+										return material.Caption(s.Theme, fmt.Sprintf(" · Text version %d", req.Proposal.DocumentVersion)).Layout(gtx)
+									}),
 									layout.Flexed(1, layout.Spacer{Width: unit.Dp(1)}.Layout),
 									layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 										btn := material.Button(s.Theme, &s.DocLinkButton, "View Full Text")
@@ -380,16 +899,54 @@ func (s *RequestDetailsScreen) Layout(gtx layout.Context) layout.Dimensions {
 									}),
 									layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
 									layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-										if req.Policy == nil {
+										if req.Organizer.CampaignIndexURL == "" {
 											return layout.Dimensions{}
 										}
-										btn := material.Button(s.Theme, &s.PolicyLinkButton, "Policy")
+										label := "Pin Organizer"
+										if s.App.Organizers.IsPinned(req.Organizer.JWKSetURL) {
+											label = "Unpin Organizer"
+										}
+										return layout.Inset{Right: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+											btn := widgets.SecondaryButton(s.Theme, &s.PinButton, label)
+											btn.TextSize = unit.Sp(12)
+											return btn.Layout(gtx)
+										})
+									}),
+									layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+										btn := widgets.SecondaryButton(s.Theme, &s.PrintButton, "Print")
+										btn.TextSize = unit.Sp(12)
+										return layout.Inset{Right: unit.Dp(8)}.Layout(gtx, btn.Layout)
+									}),
+									layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+										btn := material.Button(s.Theme, &s.PaperSheetButton, "Paper Sheet")
 										btn.TextSize = unit.Sp(12)
-										btn.Background = widgets.ColorWarning
 										return btn.Layout(gtx)
 									}),
 								)
 							}),
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								return s.layoutPublicStats(gtx, req)
+							}),
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								return s.layoutJurisdiction(gtx, req)
+							}),
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								if req.Policy == nil {
+									return layout.Dimensions{}
+								}
+								return layout.Inset{Top: unit.Dp(12)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+									return s.layoutPolicy(gtx, req.Policy)
+								})
+							}),
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								return s.layoutDocuments(gtx, req)
+							}),
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								return s.links.Layout(gtx, s.Theme)
+							}),
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								return s.layoutHosts(gtx, req)
+							}),
 						)
 					})
 				}),
@@ -410,13 +967,51 @@ func (s *RequestDetailsScreen) Layout(gtx layout.Context) layout.Dimensions {
 								return layout.Inset{Bottom: unit.Dp(14)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 									return widgets.Border(gtx, widgets.ColorWarning, func(gtx layout.Context) layout.Dimensions {
 										return layout.UniformInset(unit.Dp(10)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
-											return material.Body2(s.Theme, req.Proposal.LegalStatement).Layout(gtx)
+											// A statement the signer must agree to is shown whole.
+											ack := requiredAck(req)
+											s.legalText.Expanded = ack != nil
+											if ack == nil {
+												return s.legalText.Layout(gtx, s.Theme, s.Theme.TextSize*14/16, req.Proposal.LegalStatement)
+											}
+											return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+												layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+													return s.legalText.Layout(gtx, s.Theme, s.Theme.TextSize*14/16, req.Proposal.LegalStatement)
+												}),
+												layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+												layout.Rigid(material.CheckBox(s.Theme, &s.LegalAckCheck, ack.Wording()).Layout),
+												layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+													if !ack.Initials {
+														return layout.Dimensions{}
+													}
+													return layout.Inset{Top: unit.Dp(6)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+														return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+															layout.Rigid(material.Body2(s.Theme, "Your initials: ").Layout),
+															layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+																gtx.Constraints.Max.X = gtx.Dp(120)
+																return widgets.Border(gtx, widgets.ColorBorder, func(gtx layout.Context) layout.Dimensions {
+																	return layout.UniformInset(unit.Dp(6)).Layout(gtx, material.Editor(s.Theme, &s.InitialsEdit, "e.g. J.P.M.").Layout)
+																})
+															}),
+														)
+													})
+												}),
+											)
 										})
 									})
 								})
 							}),
 							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 								leftPane := func(gtx layout.Context) layout.Dimensions {
+									if agent {
+										return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
+											return s.layoutAgentPane(gtx, req.RequestID)
+										})
+									}
+									if demoMode {
+										return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
+											return s.layoutDemoCertificate(gtx, allIdentities)
+										})
+									}
 									return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
 										return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
 											layout.Rigid(material.Subtitle2(s.Theme, "1. Choose Certificate").Layout),
@@ -428,7 +1023,7 @@ func (s *RequestDetailsScreen) Layout(gtx layout.Context) layout.Dimensions {
 														return material.Caption(s.Theme, "PERSONAL").Layout(gtx)
 													}))
 													for i := range groups.Personal {
-														children = append(children, layout.Rigid(s.certPickerRow(groups.Personal[i])))
+														children = append(children, layout.Rigid(s.certPickerRow(&s.CertEnum, groups.Personal[i])))
 													}
 												}
 												if len(groups.Representation) > 0 {
@@ -438,23 +1033,36 @@ func (s *RequestDetailsScreen) Layout(gtx layout.Context) layout.Dimensions {
 														return layout.Inset{Top: unit.Dp(10)}.Layout(gtx, l.Layout)
 													}))
 													for i := range groups.Representation {
-														children = append(children, layout.Rigid(s.certPickerRow(groups.Representation[i])))
+														children = append(children, layout.Rigid(s.certPickerRow(&s.CertEnum, groups.Representation[i])))
 													}
 												}
 												return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
 											}),
+											layout.Rigid(s.layoutCertPair),
+											layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+											layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+												return s.layoutCoSigner(gtx, allIdentities)
+											}),
 										)
 									})
 								}
 								rightPane := func(gtx layout.Context) layout.Dimensions {
 									return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
 										if s.CertEnum.Value == "" {
+											hint := "Select a certificate to review signer data."
+											if agent {
+												hint = "Choose your agent certificate to start collecting signatures."
+											}
 											return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
-												return material.Body2(s.Theme, "Select a certificate to review signer data.").Layout(gtx)
+												return material.Body2(s.Theme, hint).Layout(gtx)
 											})
 										}
+										dataTitle := "2. Verify Signer Data"
+										if agent {
+											dataTitle = "2. Citizen Data"
+										}
 										return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
-											layout.Rigid(material.Subtitle2(s.Theme, "2. Verify Signer Data").Layout),
+											layout.Rigid(material.Subtitle2(s.Theme, dataTitle).Layout),
 											layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
 											layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 												return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
@@ -468,18 +1076,33 @@ func (s *RequestDetailsScreen) Layout(gtx layout.Context) layout.Dimensions {
 												return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
 													layout.Flexed(1, material.Editor(s.Theme, &s.Cognom1Editor, "Surname 1").Layout),
 													layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
-													layout.Flexed(1, material.Editor(s.Theme, &s.Cognom2Editor, "Surname 2").Layout),
+													layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+														switch fields.SecondSurnameMode() {
+														case model.SignerFieldOmitted:
+															return layout.Dimensions{}
+														case model.SignerFieldOptional:
+															return material.Editor(s.Theme, &s.Cognom2Editor, "Surname 2 (optional)").Layout(gtx)
+														}
+														return material.Editor(s.Theme, &s.Cognom2Editor, "Surname 2").Layout(gtx)
+													}),
 												)
 											}),
 											layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
 											layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+												if fields.BirthDateMode() == model.SignerFieldOmitted {
+													return layout.Dimensions{}
+												}
 												return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
 													layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 														source := widgets.FieldManual
-														if s.selectedInfo.BirthDate != "" {
+														if s.selectedInfo.BirthDate != "" && !agent {
 															source = widgets.FieldFromCert
 														}
-														return widgets.FieldLabel(gtx, s.Theme, "Birth Date", source)
+														label := "Birth Date"
+														if fields.BirthDateMode() == model.SignerFieldOptional {
+															label = "Birth Date (optional)"
+														}
+														return widgets.FieldLabel(gtx, s.Theme, label, source)
 													}),
 													layout.Rigid(layout.Spacer{Height: unit.Dp(2)}.Layout),
 													layout.Rigid(func(gtx layout.Context) layout.Dimensions {
@@ -498,7 +1121,19 @@ func (s *RequestDetailsScreen) Layout(gtx layout.Context) layout.Dimensions {
 											layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
 											layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
 											layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-												return material.CheckBox(s.Theme, &s.ConsentCheck, "I confirm I have read the proposal, accept the data protection notice, and consent to supporting this legislative initiative").Layout(gtx)
+												label := s.App.ReqLabels.Get(model.LabelConsent, "I confirm I have read the proposal, accept the data protection notice, and consent to supporting this legislative initiative")
+												if agent {
+													label = "I certify that this citizen has read the proposal, accepted the data protection notice and consented in my presence to supporting this legislative initiative"
+												}
+												return material.CheckBox(s.Theme, &s.ConsentCheck, label).Layout(gtx)
+											}),
+											layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+												if agent || req.ContactRequest == nil {
+													return layout.Dimensions{}
+												}
+												return layout.Inset{Top: unit.Dp(10)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+													return s.layoutContactRequest(gtx, req.ContactRequest)
+												})
 											}),
 											layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
 											layout.Rigid(func(gtx layout.Context) layout.Dimensions {
@@ -519,6 +1154,12 @@ func (s *RequestDetailsScreen) Layout(gtx layout.Context) layout.Dimensions {
 													})
 												})
 											}),
+											layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+												if !s.repAckNeeded {
+													return layout.Dimensions{}
+												}
+												return layout.Inset{Top: unit.Dp(8)}.Layout(gtx, s.layoutRepresentativeAck)
+											}),
 										)
 									})
 								}
@@ -546,7 +1187,7 @@ func (s *RequestDetailsScreen) Layout(gtx layout.Context) layout.Dimensions {
 										layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 											msg := s.App.SignStatus
 											if msg == "" {
-												msg = "Please verify all details. Your signature will be legally binding."
+												msg = s.App.ReqLabels.Get(model.LabelSignNotice, "Please verify all details. Your signature will be legally binding.")
 											}
 											tone := widgets.BannerInfo
 											if strings.Contains(strings.ToLower(msg), "failed") || strings.Contains(strings.ToLower(msg), "error") {
@@ -556,11 +1197,42 @@ func (s *RequestDetailsScreen) Layout(gtx layout.Context) layout.Dimensions {
 											}
 											return widgets.Banner(gtx, s.Theme, tone, msg)
 										}),
+										layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+											if s.changedReq != req || s.IsSigning {
+												return layout.Dimensions{}
+											}
+											if s.ReloadButton.Clicked(gtx) {
+												s.changedReq = nil
+												s.App.ClearSession()
+												s.App.ReloadRequest()
+											}
+											return layout.Inset{Top: unit.Dp(8)}.Layout(gtx, widgets.PrimaryButton(s.Theme, &s.ReloadButton, "Review the new version").Layout)
+										}),
+										layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+											if !agent || s.receipt == nil || s.IsSigning {
+												return layout.Dimensions{}
+											}
+											btn := widgets.SecondaryButton(s.Theme, &s.PrintReceiptBtn, "Print Receipt for "+s.receipt.SignerName)
+											return layout.Inset{Top: unit.Dp(8)}.Layout(gtx, btn.Layout)
+										}),
 										layout.Rigid(layout.Spacer{Height: unit.Dp(12)}.Layout),
 										layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-											btn := widgets.PrimaryButton(s.Theme, &s.SignButton, "Confirm and Sign")
-											if s.IsSigning || s.CertEnum.Value == "" {
-												btn = widgets.SecondaryButton(s.Theme, &s.SignButton, "Confirm and Sign")
+											if pr := s.App.PendingPINRequest(); pr != nil && !s.batch.Running() {
+												return s.PINPrompt.Layout(gtx, s.Theme, pr)
+											}
+											if p := s.signing; p != nil {
+												if p.slow(gtx.Now) {
+													return s.layoutSignProgress(gtx, p)
+												}
+												gtx.Execute(op.InvalidateCmd{At: p.started.Add(slowSignThreshold)})
+											}
+											if r := s.review; r != nil {
+												return s.layoutSubmitReview(gtx, r)
+											}
+											signLabel := s.App.ReqLabels.Get(model.LabelSignButton, "Confirm and Sign")
+											btn := widgets.PrimaryButton(s.Theme, &s.SignButton, signLabel)
+											if s.IsSigning || s.CertEnum.Value == "" || (requiredAck(req) != nil && !s.LegalAckCheck.Value) {
+												btn = widgets.SecondaryButton(s.Theme, &s.SignButton, signLabel)
 											}
 											btn.TextSize = unit.Sp(16)
 											return btn.Layout(gtx)
@@ -568,6 +1240,14 @@ func (s *RequestDetailsScreen) Layout(gtx layout.Context) layout.Dimensions {
 									)
 								})
 							}),
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								if !agent {
+									return layout.Dimensions{}
+								}
+								return layout.Inset{Top: unit.Dp(18)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+									return s.batch.Layout(gtx, req)
+								})
+							}),
 						)
 					})
 				}),
@@ -576,14 +1256,271 @@ func (s *RequestDetailsScreen) Layout(gtx layout.Context) layout.Dimensions {
 	})
 }
 
-func (s *RequestDetailsScreen) certPickerRow(id pkcs12store.Identity) layout.Widget {
+// layoutAgentPane shows the certificate a certifying agent signs with and
+// the tally of the current batch, in place of the certificate picker.
+func (s *RequestDetailsScreen) layoutAgentPane(gtx layout.Context, requestID string) layout.Dimensions {
+	identity, ok := s.App.AgentIdentity()
+	batch := s.App.BatchSnapshot(requestID)
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(material.Subtitle2(s.Theme, "1. Agent Certificate").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			if !ok {
+				return widgets.Banner(gtx, s.Theme, widgets.BannerWarning, "Agent mode needs your agent certificate. Choose it in Settings before collecting signatures.")
+			}
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					l := material.Body1(s.Theme, identity.FriendlyName)
+					l.Font.Weight = font.Bold
+					return l.Layout(gtx)
+				}),
+				layout.Rigid(material.Caption(s.Theme, "Issuer: "+identity.Cert.Issuer.CommonName).Layout),
+			)
+		}),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		layout.Rigid(widgets.SecondaryButton(s.Theme, &s.AgentSettingsBtn, "Change in Settings").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(16)}.Layout),
+		layout.Rigid(material.Subtitle2(s.Theme, "This batch").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			if batch.Started.IsZero() {
+				return material.Body2(s.Theme, "No signatures collected yet.").Layout(gtx)
+			}
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+				layout.Rigid(material.Body2(s.Theme, fmt.Sprintf("%d signed, %d failed, %d canceled", batch.Signed, batch.Failed, batch.Canceled)).Layout),
+				layout.Rigid(material.Caption(s.Theme, "Started "+batch.Started.Format("15:04")).Layout),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+				layout.Rigid(widgets.SecondaryButton(s.Theme, &s.NewBatchButton, "Start New Batch").Layout),
+			)
+		}),
+	)
+}
+
+// setAgentMode switches the signer fields between data read from the
+// user's certificate and data typed in for each citizen.
+func (s *RequestDetailsScreen) setAgentMode(agent bool) {
+	s.agentMode = agent
+	for _, e := range []*widget.Editor{&s.NomEditor, &s.Cognom1Editor, &s.Cognom2Editor, &s.DNIEditor} {
+		e.SingleLine = agent
+	}
+	s.DNIEditor.ReadOnly = !agent
+	s.BirthEditor.ReadOnly = false
+	s.resetSignerFields()
+	// Refill the fields from the selected certificate in citizen mode.
+	s.lastSelectedCert = ""
+	s.CertEnum.Value = ""
+}
+
+// applySignerData fills in the data remembered for the selected
+// certificate. A birth date read from the certificate is kept.
+func (s *RequestDetailsScreen) applySignerData(d pkcs12store.SignerData) {
+	if d.Nom != "" {
+		s.NomEditor.SetText(d.Nom)
+	}
+	if d.Cognom1 != "" {
+		s.Cognom1Editor.SetText(d.Cognom1)
+	}
+	if d.Cognom2 != "" {
+		s.Cognom2Editor.SetText(d.Cognom2)
+	}
+	if d.BirthDate != "" && !s.BirthEditor.ReadOnly {
+		s.BirthEditor.SetText(d.BirthDate)
+	}
+}
+
+// defaultBirthDate is the birth date filled in when the certificate has
+// none. A sample date is only filled in when the request requires one, so
+// an optional birth date is not sent unless the signer types it.
+func defaultBirthDate(fields *model.SignerFields) string {
+	if fields.BirthDateMode() != model.SignerFieldRequired {
+		return ""
+	}
+	return "1980-01-01"
+}
+
+// correctedSignerData returns the parts of signer that were typed rather
+// than read from the certificate, which are the ones worth remembering.
+func correctedSignerData(info certs.ExtractedInfo, signer model.Signant) pkcs12store.SignerData {
+	var d pkcs12store.SignerData
+	cognoms := append(append([]string(nil), info.Cognoms...), "", "")
+	if signer.Nom != info.Nom {
+		d.Nom = signer.Nom
+	}
+	if signer.Cognom1 != cognoms[0] {
+		d.Cognom1 = signer.Cognom1
+	}
+	if signer.Cognom2 != cognoms[1] {
+		d.Cognom2 = signer.Cognom2
+	}
+	if info.BirthDate == "" {
+		d.BirthDate = signer.DataNaixement
+	}
+	return d
+}
+
+// resetSignerFields empties the signer data and consent.
+func (s *RequestDetailsScreen) resetSignerFields() {
+	for _, e := range []*widget.Editor{&s.NomEditor, &s.Cognom1Editor, &s.Cognom2Editor, &s.DNIEditor, &s.BirthEditor} {
+		e.SetText("")
+	}
+	s.ConsentCheck.Value = false
+	s.LegalAckCheck.Value = false
+	s.CoSignCheck.Value = false
+	s.CoSignEnum.Value = ""
+	s.InitialsEdit.SetText("")
+	s.birthDateErr = ""
+}
+
+// layoutContactRequest asks for the optional contact details, with a consent
+// checkbox separate from the signing consent.
+func (s *RequestDetailsScreen) layoutContactRequest(gtx layout.Context, cr *model.ContactRequest) layout.Dimensions {
+	children := []layout.FlexChild{
+		layout.Rigid(material.Caption(s.Theme, "OPTIONAL: STAY IN TOUCH").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(6)}.Layout),
+	}
+	for _, f := range []struct {
+		field, hint string
+		editor      *widget.Editor
+	}{
+		{model.ContactEmail, "Email", &s.EmailEditor},
+		{model.ContactPhone, "Phone", &s.PhoneEditor},
+	} {
+		if !cr.Asks(f.field) {
+			continue
+		}
+		children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Inset{Bottom: unit.Dp(6)}.Layout(gtx, material.Editor(s.Theme, f.editor, f.hint).Layout)
+		}))
+	}
+	children = append(children, layout.Rigid(material.CheckBox(s.Theme, &s.ContactConsentCheck, cr.ConsentWording()).Layout))
+	return widgets.Border(gtx, widgets.ColorBorder, func(gtx layout.Context) layout.Dimensions {
+		return layout.UniformInset(unit.Dp(10)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+		})
+	})
+}
+
+// signerContact returns the contact the signer agreed to share, or nil if
+// the request asks for none or the signer did not consent. Agents collect
+// signatures only.
+func (s *RequestDetailsScreen) signerContact(req *model.SignRequest, agent bool) (*model.SignerContact, error) {
+	cr := req.ContactRequest
+	if cr == nil || agent {
+		return nil, nil
+	}
+	email, phone := s.EmailEditor.Text(), s.PhoneEditor.Text()
+	if !s.ContactConsentCheck.Value {
+		if strings.TrimSpace(email) != "" || strings.TrimSpace(phone) != "" {
+			return nil, errors.New("tick the contact consent to share your contact details, or clear them")
+		}
+		return nil, nil
+	}
+	return cr.Contact(email, phone, time.Now())
+}
+
+// requiredAck returns the legal statement acknowledgement req's policy
+// requires before signing, or nil.
+func requiredAck(req *model.SignRequest) *model.Acknowledgement {
+	if req.Policy == nil {
+		return nil
+	}
+	return req.Policy.Acknowledgement
+}
+
+// The warning shown when signing with a representative certificate, its
+// acknowledgement, and the kind it is remembered under.
+const (
+	representativeAckKind    = "representative"
+	representativeWarning    = "This certificate represents an organization. Your signature may be attributed to the organization rather than only to you, and some campaigns do not accept it."
+	representativeAckWording = "I understand this is a representative certificate"
+)
+
+// representativeAckDigest identifies the representative warning as shown for
+// req: its wording and the request's signature policy, so a change to
+// either warns again.
+func representativeAckDigest(req *model.SignRequest) string {
+	text := representativeWarning + "\n" + representativeAckWording
+	if p := req.Policy; p != nil {
+		text += "\n" + p.OID + "\n" + p.HashAlg + "\n" + p.Hash
+	}
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// updateRepresentativeAck asks for the representative warning to be
+// acknowledged when a representative certificate is selected, unless the
+// user already acknowledged it for that certificate and policy. The
+// checkbox starts unticked whenever the certificate or the digest changes.
+func (s *RequestDetailsScreen) updateRepresentativeAck(req *model.SignRequest) {
+	var key [2]string
+	if s.selectedInfo.IsRepresentative {
+		if identity := s.findIdentity(s.CertEnum.Value); identity != nil {
+			fp := pkcs12store.Fingerprint(identity.Cert)
+			key = [2]string{hex.EncodeToString(fp[:]), representativeAckDigest(req)}
+		}
+	}
+	if key == s.repAckFor {
+		return
+	}
+	s.repAckFor = key
+	s.RepAckCheck.Value = false
+	s.repAckNeeded = key[0] != "" && !s.App.CertWarningAcknowledged(key[0], representativeAckKind, key[1])
+}
+
+// layoutRepresentativeAck warns about signing with a representative
+// certificate and asks for acknowledgement, which is remembered for the
+// certificate.
+func (s *RequestDetailsScreen) layoutRepresentativeAck(gtx layout.Context) layout.Dimensions {
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return widgets.Banner(gtx, s.Theme, widgets.BannerWarning, representativeWarning)
+		}),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(6)}.Layout),
+		layout.Rigid(material.CheckBox(s.Theme, &s.RepAckCheck, representativeAckWording).Layout),
+	)
+}
+
+// validateAckInitials checks the typed initials when ack asks for them.
+func validateAckInitials(ack *model.Acknowledgement, initials string) error {
+	if ack == nil || !ack.Initials {
+		return nil
+	}
+	return model.ValidateInitials(strings.TrimSpace(initials))
+}
+
+// layoutDemoCertificate replaces the certificate picker in demo campaigns
+// with the demo certificate the volunteer signs with, and the test
+// certificates in the wallet, if any.
+func (s *RequestDetailsScreen) layoutDemoCertificate(gtx layout.Context, tests []pkcs12store.Identity) layout.Dimensions {
+	children := []layout.FlexChild{
+		layout.Rigid(material.Subtitle2(s.Theme, "1. Demo Certificate").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+	}
+	if id, err := s.App.DemoIdentity(); err == nil {
+		if len(tests) == 0 {
+			children = append(children, layout.Rigid(material.Body1(s.Theme, id.FriendlyName).Layout))
+		} else {
+			children = append(children, layout.Rigid(s.certPickerRow(&s.CertEnum, id)))
+			for i := range tests {
+				children = append(children, layout.Rigid(s.certPickerRow(&s.CertEnum, tests[i])))
+			}
+		}
+	}
+	children = append(children,
+		layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+		layout.Rigid(material.Caption(s.Theme, "The demo certificate is made for this session with a made-up name and DNI, and deleted when VocSign closes. Your own certificates are not used. You may keep the demo data or type any other made-up data.").Layout),
+	)
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+}
+
+func (s *RequestDetailsScreen) certPickerRow(enum *widget.Enum, id pkcs12store.Identity) layout.Widget {
 	return func(gtx layout.Context) layout.Dimensions {
 		return layout.Inset{Bottom: unit.Dp(4)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 			return widgets.Border(gtx, widgets.ColorBorder, func(gtx layout.Context) layout.Dimensions {
 				return layout.UniformInset(unit.Dp(4)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 					return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
 						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-							return material.RadioButton(s.Theme, &s.CertEnum, id.ID, id.FriendlyName).Layout(gtx)
+							return material.RadioButton(s.Theme, enum, id.ID, id.FriendlyName).Layout(gtx)
 						}),
 						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 							return layout.Inset{Left: unit.Dp(35)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
@@ -602,6 +1539,24 @@ func (s *RequestDetailsScreen) certPickerRow(id pkcs12store.Identity) layout.Wid
 										l.Font.Weight = font.Bold
 										return l.Layout(gtx)
 									}),
+									layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+										if _, ok := s.certPairs[id.ID]; !ok || enum != &s.CertEnum {
+											return layout.Dimensions{}
+										}
+										return layout.Inset{Left: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+											return widgets.StatusTag(gtx, s.Theme, widgets.BannerNeutral, "Signature + authentication")
+										})
+									}),
+									layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+										// Signing is not blocked: whether such a signature
+										// is accepted is the collector's decision.
+										if certs.LegalSignatureUsage(id.Cert) == nil {
+											return layout.Dimensions{}
+										}
+										return layout.Inset{Left: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+											return widgets.StatusTag(gtx, s.Theme, widgets.BannerWarning, "Not suitable for legal signatures")
+										})
+									}),
 								)
 							})
 						}),
@@ -620,6 +1575,14 @@ func (s *RequestDetailsScreen) layoutPostSign(gtx layout.Context) layout.Dimensi
 			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 				return widgets.IconLabel(gtx, s.Theme, icons.IconCheck, "Signature Successfully Processed", widgets.ColorSuccess, unit.Sp(28))
 			}),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				if req := s.App.CurrentReq; req == nil || !req.IsDemo() {
+					return layout.Dimensions{}
+				}
+				return layout.Inset{Top: unit.Dp(12)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return widgets.Banner(gtx, s.Theme, widgets.BannerWarning, "Demo signature: it was made with a throwaway certificate for a demo campaign and has no legal value.")
+				})
+			}),
 			layout.Rigid(layout.Spacer{Height: unit.Dp(20)}.Layout),
 			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 				return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
@@ -634,7 +1597,11 @@ func (s *RequestDetailsScreen) layoutPostSign(gtx layout.Context) layout.Dimensi
 							}),
 							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 								e := &widget.Editor{ReadOnly: true}
-								e.SetText(s.App.SignStatus)
+								if s.receipt != nil {
+									e.SetText(s.receipt.Submit.ReceiptID)
+								} else {
+									e.SetText(s.App.SignStatus)
+								}
 								return material.Editor(s.Theme, e, "").Layout(gtx)
 							}),
 							layout.Rigid(layout.Spacer{Height: unit.Dp(16)}.Layout),
@@ -666,17 +1633,815 @@ func (s *RequestDetailsScreen) layoutPostSign(gtx layout.Context) layout.Dimensi
 			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 				if s.backButton.Clicked(gtx) {
 					s.App.SignResponse = nil
+					s.receipt, s.receiptRaw = nil, nil
+					s.setShareStatus("")
 					s.App.SignStatus = ""
 					s.App.CurrentScreen = app.ScreenOpenRequest
 				}
 				btn := widgets.SecondaryButton(s.Theme, &s.backButton, "Done - Back to Home")
-				return btn.Layout(gtx)
+				if s.receipt == nil {
+					return btn.Layout(gtx)
+				}
+				r := *s.receipt
+				if s.ShareReceiptBtn.Clicked(gtx) {
+					s.shareEvidence(r, false)
+				}
+				if s.ShareEvidenceBtn.Clicked(gtx) {
+					s.shareEvidence(r, true)
+				}
+				if s.SaveEvidenceBtn.Clicked(gtx) {
+					s.saveEvidence(r)
+				}
+				if s.EmailReceiptBtn.Clicked(gtx) {
+					s.emailReceipt(r)
+				}
+				buttons := []layout.FlexChild{
+					layout.Rigid(widgets.PrimaryButton(s.Theme, &s.PrintReceiptBtn, "Print Receipt").Layout),
+					layout.Rigid(layout.Spacer{Width: unit.Dp(12)}.Layout),
+					layout.Rigid(widgets.SecondaryButton(s.Theme, &s.EmailReceiptBtn, "Send by Email").Layout),
+					layout.Rigid(layout.Spacer{Width: unit.Dp(12)}.Layout),
+				}
+				if platform.CanShare() {
+					buttons = append(buttons,
+						layout.Rigid(widgets.SecondaryButton(s.Theme, &s.ShareReceiptBtn, "Share Receipt").Layout),
+						layout.Rigid(layout.Spacer{Width: unit.Dp(12)}.Layout),
+						layout.Rigid(widgets.SecondaryButton(s.Theme, &s.ShareEvidenceBtn, "Share Evidence").Layout),
+					)
+				} else {
+					buttons = append(buttons, layout.Rigid(widgets.SecondaryButton(s.Theme, &s.SaveEvidenceBtn, "Save Evidence").Layout))
+				}
+				buttons = append(buttons,
+					layout.Rigid(layout.Spacer{Width: unit.Dp(12)}.Layout),
+					layout.Rigid(btn.Layout),
+				)
+				return layout.Flex{Axis: layout.Horizontal}.Layout(gtx, buttons...)
+			}),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				status := s.shareStatusText()
+				if status == "" {
+					return layout.Dimensions{}
+				}
+				return layout.Inset{Top: unit.Dp(8)}.Layout(gtx, material.Caption(s.Theme, status).Layout)
 			}),
 		)
 	})
 }
 
+// layoutRequestDiff lists the fields that changed since the user last opened
+// this request and asks for explicit acknowledgement.
+func (s *RequestDetailsScreen) layoutRequestDiff(gtx layout.Context) layout.Dimensions {
+	return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
+		children := []layout.FlexChild{
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return widgets.Banner(gtx, s.Theme, widgets.BannerWarning, "This request has changed since you last opened it. Review every change before signing.")
+			}),
+			layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		}
+		for _, c := range s.App.ReqDiff {
+			c := c
+			children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.Inset{Bottom: unit.Dp(6)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+							l := material.Caption(s.Theme, c.Field)
+							l.Font.Weight = font.Bold
+							return l.Layout(gtx)
+						}),
+						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+							l := material.Body2(s.Theme, "− "+nonEmptyText(c.Old, "(empty)"))
+							l.Color = widgets.ColorError
+							return l.Layout(gtx)
+						}),
+						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+							l := material.Body2(s.Theme, "+ "+nonEmptyText(c.New, "(empty)"))
+							l.Color = widgets.ColorSuccess
+							return l.Layout(gtx)
+						}),
+					)
+				})
+			}))
+		}
+		children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return material.CheckBox(s.Theme, &s.DiffAckCheck, "I have reviewed these changes").Layout(gtx)
+		}))
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	})
+}
+
+// layoutInterrupted warns that VocSign stopped while submitting a signature
+// of this request, so it may already count, and asks for confirmation
+// before signing again.
+func (s *RequestDetailsScreen) layoutInterrupted(gtx layout.Context, e storage.JournalEntry) layout.Dimensions {
+	msg := "VocSign closed while submitting a signature of this request"
+	if t, err := time.Parse(time.RFC3339, e.UpdatedAt); err == nil {
+		msg += " on " + s.App.Locale().DateTime(t.Local())
+	}
+	msg += ". The collector may have received it. Signing again first asks the collector, and shows the receipt if it did."
+	if e.SignerID != "" {
+		msg += " Citizen: " + e.SignerID + "."
+	}
+	return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return widgets.Banner(gtx, s.Theme, widgets.BannerWarning, msg)
+			}),
+			layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return material.CheckBox(s.Theme, &s.RetryAckCheck, "If the collector cannot tell, I checked that it was not received; sign again").Layout(gtx)
+			}),
+		)
+	})
+}
+
+// showEarlierReceipt records and shows the receipt of the interrupted
+// submission prior, which the collector says it accepted, instead of
+// signing again.
+func (s *RequestDetailsScreen) showEarlierReceipt(req *model.SignRequest, prior storage.JournalEntry, receipt *model.SubmitReceipt, signer model.Signant, agentMode bool, rawReq []byte, rawRef string) {
+	signerName := strings.TrimSpace(signer.Nom + " " + signer.Cognom1 + " " + signer.Cognom2)
+	auditEntry := earlierAuditEntry(req, prior, receipt, signer, agentMode)
+	auditEntry.RawRequestSHA256 = rawRef
+	if err := s.App.AuditLogger.Log(auditEntry); err != nil {
+		log.Printf("ERROR: failed to write audit log: %v", err)
+	}
+	s.App.DismissInterrupted(prior)
+	s.App.Telemetry.Record(telemetry.EventSign, telemetry.OutcomeAlreadySigned, "")
+	if agentMode {
+		s.App.RecordBatchResult(req.RequestID, auditEntry.Status)
+	}
+
+	var resp model.SignResponse
+	if err := json.Unmarshal(prior.Response, &resp); err != nil {
+		// Without the submitted payload there is no receipt to show, only
+		// its ID.
+		s.App.SignStatus = "Your earlier signature was already accepted (receipt " + nonEmptyText(receipt.ReceiptID, "not given") + "). It was not submitted again."
+		s.App.Invalidate()
+		return
+	}
+	s.receipt = &paper.Receipt{Request: req, Response: &resp, Submit: receipt, SignerName: signerName, Locale: s.App.Locale()}
+	s.receiptRaw = rawReq
+	if agentMode {
+		s.App.SignStatus = "The earlier signature of " + signerName + " was already accepted and was not submitted again. Ready for the next citizen."
+		s.clearSigner = true
+	} else {
+		s.App.SignStatus = "Your earlier signature was already accepted and was not submitted again"
+		s.App.SignResponse = &resp
+		s.App.ClearSession()
+	}
+	s.App.Invalidate()
+}
+
+// earlierAuditEntry is the audit log entry of the interrupted submission
+// prior, which the collector says it accepted.
+func earlierAuditEntry(req *model.SignRequest, prior storage.JournalEntry, receipt *model.SubmitReceipt, signer model.Signant, agentMode bool) storage.AuditEntry {
+	e := storage.AuditEntry{
+		RequestID:       req.RequestID,
+		ProposalTitle:   req.Proposal.Title,
+		SignerName:      signer.Nom + " " + signer.Cognom1 + " " + signer.Cognom2,
+		SignerDNI:       signer.NumIdentifica,
+		CallbackHost:    urlHost(req.Callback.URL),
+		CertFingerprint: prior.CertFingerprint,
+		PayloadSHA256:   prior.PayloadSHA256,
+		SignatureSHA256: prior.SignatureSHA256,
+		RequestHash:     prior.RequestHash,
+		AgentCertified:  agentMode,
+		Status:          "success",
+		ServerAckID:     receipt.ReceiptID,
+		ReceiptStatus:   receipt.Status,
+	}
+	if req.Policy != nil {
+		e.PolicyOID = req.Policy.OID
+	}
+	return e
+}
+
+// interruptedSubmission returns the first interrupted signing of requestID
+// that may have reached the collector.
+func interruptedSubmission(entries []storage.JournalEntry, requestID string) (storage.JournalEntry, bool) {
+	for _, e := range entries {
+		if e.RequestID == requestID && e.Stage == storage.StageSubmitting {
+			return e, true
+		}
+	}
+	return storage.JournalEntry{}, false
+}
+
+// journalEntry identifies the signing of req with cert in the signing
+// journal. signerID is the citizen's ID when an agent signs for them.
+func journalEntry(req *model.SignRequest, cert *x509.Certificate, signerID string) storage.JournalEntry {
+	e := storage.JournalEntry{
+		RequestID:       req.RequestID,
+		Title:           req.Proposal.Title,
+		CallbackHost:    urlHost(req.Callback.URL),
+		CertFingerprint: fmt.Sprintf("%x", pkcs12store.Fingerprint(cert)),
+		SignerID:        signerID,
+	}
+	if h, err := req.CanonicalHash(); err == nil {
+		e.RequestHash = h
+	}
+	return e
+}
+
+func nonEmptyText(v, fallback string) string {
+	if v == "" {
+		return fallback
+	}
+	return v
+}
+
+func (s *RequestDetailsScreen) togglePinnedOrganizer(req *model.SignRequest) {
+	var err error
+	event := "Pinned organizer "
+	if s.App.Organizers.IsPinned(req.Organizer.JWKSetURL) {
+		event = "Unpinned organizer "
+		err = s.App.Organizers.Unpin(req.Organizer.JWKSetURL)
+	} else {
+		o := storage.PinnedOrganizer{
+			Name:             req.Proposal.Promoter,
+			JWKSetURL:        req.Organizer.JWKSetURL,
+			CampaignIndexURL: req.Organizer.CampaignIndexURL,
+		}
+		// The request's log was verified when it was opened; later
+		// requests of the organizer must name the same one.
+		if req.TransparencyLog != nil {
+			o.TransparencyLogURL = req.TransparencyLog.URL
+		}
+		err = s.App.Organizers.Pin(o)
+	}
+	if err != nil {
+		log.Printf("ERROR: failed to update pinned organizers: %v", err)
+		return
+	}
+	s.App.RecordSecurityEvent(storage.SecurityTrust, event+req.Organizer.JWKSetURL)
+	s.App.RefreshPinnedCampaigns()
+}
+
+// layoutPolicy summarizes the signature policy the signature will reference.
+func (s *RequestDetailsScreen) layoutPolicy(gtx layout.Context, p *model.SignPolicy) layout.Dimensions {
+	if s.policyFor != p {
+		s.policyFor = p
+		s.policyStatus = ""
+	}
+	issuer := p.Issuer
+	if issuer == "" {
+		if u, err := url.Parse(p.URI); err == nil {
+			issuer = u.Hostname()
+		}
+	}
+	rows := []struct{ label, value string }{
+		{"POLICY OID", p.OID},
+		{"POLICY HASH", strings.TrimSpace(p.HashAlg + " " + p.Hash)},
+		{"ISSUER", issuer},
+	}
+	return widgets.Border(gtx, widgets.ColorWarning, func(gtx layout.Context) layout.Dimensions {
+		return layout.UniformInset(unit.Dp(10)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			children := []layout.FlexChild{
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return widgets.IconLabel(gtx, s.Theme, icons.IconVocSign, "Signature policy ("+nonEmptyText(p.Mode, "EPES")+")", s.Theme.Fg, unit.Sp(13))
+				}),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(6)}.Layout),
+			}
+			for _, row := range rows {
+				row := row
+				children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return layout.Inset{Bottom: unit.Dp(4)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+							layout.Rigid(material.Caption(s.Theme, row.label).Layout),
+							layout.Rigid(material.Body2(s.Theme, nonEmptyText(row.value, "—")).Layout),
+						)
+					})
+				}))
+			}
+			children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				if p.URI == "" {
+					return layout.Dimensions{}
+				}
+				return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						label := "View Policy Document"
+						if s.policyLoading {
+							label = "Downloading..."
+						}
+						btn := widgets.SecondaryButton(s.Theme, &s.PolicyLinkButton, label)
+						btn.TextSize = unit.Sp(12)
+						return btn.Layout(gtx)
+					}),
+					layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+					layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+						if s.policyStatus == "" {
+							return layout.Dimensions{}
+						}
+						l := material.Caption(s.Theme, s.policyStatus)
+						if strings.Contains(s.policyStatus, "failed") {
+							l.Color = widgets.ColorError
+						}
+						return l.Layout(gtx)
+					}),
+				)
+			}))
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+		})
+	})
+}
+
+// layoutLanguages lets the signer read the proposal title and summary in
+// one of the request's localizations. Nothing is shown for a request
+// without any.
+func (s *RequestDetailsScreen) layoutLanguages(gtx layout.Context, req *model.SignRequest) layout.Dimensions {
+	langs := req.Languages()
+	if len(langs) == 0 {
+		return layout.Dimensions{}
+	}
+	options := append([]string{""}, langs...)
+	if len(s.langButtons) < len(options) {
+		s.langButtons = make([]widget.Clickable, len(options))
+	}
+	children := []layout.FlexChild{
+		layout.Rigid(material.Caption(s.Theme, "Language: ").Layout),
+	}
+	for i, lang := range options {
+		if s.langButtons[i].Clicked(gtx) {
+			s.language = lang
+		}
+		label := "Original"
+		if lang != "" {
+			label = strings.ToUpper(lang)
+		}
+		btn := widgets.SecondaryButton(s.Theme, &s.langButtons[i], label)
+		if lang == s.language {
+			btn = widgets.PrimaryButton(s.Theme, &s.langButtons[i], label)
+		}
+		btn.TextSize = unit.Sp(12)
+		children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Inset{Right: unit.Dp(6)}.Layout(gtx, btn.Layout)
+		}))
+	}
+	return layout.Inset{Bottom: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx, children...)
+	})
+}
+
+// layoutDocuments lists the proposal attachments and the promoter's
+// policies, each opened in the browser. They are verified against their
+// hashes with the full text when signing.
+func (s *RequestDetailsScreen) layoutDocuments(gtx layout.Context, req *model.SignRequest) layout.Dimensions {
+	docs := req.Documents()
+	if len(docs) == 0 {
+		return layout.Dimensions{}
+	}
+	if len(s.docButtons) < len(docs) {
+		s.docButtons = make([]widget.Clickable, len(docs))
+	}
+	var children []layout.FlexChild
+	for i, d := range docs {
+		if s.docButtons[i].Clicked(gtx) {
+			s.links.Open(net.BrowsableURL(d.URL))
+		}
+		if i == 0 || i == len(req.Proposal.Attachments) {
+			heading := "ATTACHMENTS"
+			if i >= len(req.Proposal.Attachments) {
+				heading = "PROMOTER POLICIES"
+			}
+			children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.Inset{Top: unit.Dp(6), Bottom: unit.Dp(4)}.Layout(gtx, material.Caption(s.Theme, heading).Layout)
+			}))
+		}
+		btn := widgets.SecondaryButton(s.Theme, &s.docButtons[i], d.Title)
+		btn.TextSize = unit.Sp(12)
+		children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Inset{Bottom: unit.Dp(4)}.Layout(gtx, btn.Layout)
+		}))
+	}
+	return layout.Inset{Top: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	})
+}
+
+// layoutHosts lists the servers the request involves. Internationalized
+// names are shown with their ASCII form, and names that may imitate
+// another only in it, highlighted.
+func (s *RequestDetailsScreen) layoutHosts(gtx layout.Context, req *model.SignRequest) layout.Dimensions {
+	hosts := net.RequestHosts(s.App.RequestURL, req)
+	if len(hosts) == 0 {
+		return layout.Dimensions{}
+	}
+	children := []layout.FlexChild{
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Inset{Bottom: unit.Dp(4)}.Layout(gtx, material.Caption(s.Theme, "SERVERS").Layout)
+		}),
+	}
+	for _, h := range hosts {
+		children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			l := material.Caption(s.Theme, h.Role+": "+h.Host.Display())
+			if h.Host.Suspicious() {
+				return widgets.IconLabel(gtx, s.Theme, icons.IconWarning, l.Text+" (check this name carefully)", widgets.ColorError, l.TextSize)
+			}
+			return l.Layout(gtx)
+		}))
+	}
+	return layout.Inset{Top: unit.Dp(10)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	})
+}
+
+// loadPublicStats fetches the public signature count of req once it is
+// shown. Without one, or if it cannot be fetched, no count is shown.
+func (s *RequestDetailsScreen) loadPublicStats(req *model.SignRequest) {
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+	if s.statsFor == req {
+		return
+	}
+	s.statsFor = req
+	s.publicStats = nil
+	if req.PublicStats == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		stats, err := net.FetchPublicStats(ctx, req)
+		if err != nil {
+			log.Printf("WARNING: public stats for %s: %v", req.RequestID, err)
+			return
+		}
+		s.statsMu.Lock()
+		if s.statsFor == req {
+			s.publicStats = stats
+		}
+		s.statsMu.Unlock()
+		s.App.Invalidate()
+	}()
+}
+
+// layoutPublicStats shows the collector's public count for req, with a
+// histogram of the signatures per day.
+func (s *RequestDetailsScreen) layoutPublicStats(gtx layout.Context, req *model.SignRequest) layout.Dimensions {
+	s.statsMu.Lock()
+	stats := s.publicStats
+	if s.statsFor != req {
+		stats = nil
+	}
+	s.statsMu.Unlock()
+	if stats == nil {
+		return layout.Dimensions{}
+	}
+	counts := make([]int, len(stats.Daily))
+	for i, d := range stats.Daily {
+		counts[i] = d.Count
+	}
+	return layout.Inset{Top: unit.Dp(12)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Horizontal, Alignment: layout.End}.Layout(gtx,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						l := material.H6(s.Theme, fmt.Sprintf("%d", stats.Verified))
+						l.Color = widgets.ColorSuccess
+						return l.Layout(gtx)
+					}),
+					layout.Rigid(material.Caption(s.Theme, "verified signatures so far").Layout),
+				)
+			}),
+			layout.Rigid(layout.Spacer{Width: unit.Dp(16)}.Layout),
+			layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+				if len(counts) == 0 {
+					return layout.Dimensions{}
+				}
+				return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return widgets.Histogram(gtx, s.Theme.ContrastBg, counts, unit.Dp(32))
+					}),
+					layout.Rigid(material.Caption(s.Theme, fmt.Sprintf("Signatures per day, last %d days", len(counts))).Layout),
+				)
+			}),
+		)
+	})
+}
+
+// layoutJurisdiction names the proposal's jurisdiction and, when it is in
+// the ILP table, the signatures its law requires, the deadline rules and
+// who may sign.
+func (s *RequestDetailsScreen) layoutJurisdiction(gtx layout.Context, req *model.SignRequest) layout.Dimensions {
+	jurisdiction := strings.TrimSpace(req.Proposal.Jurisdiction)
+	if jurisdiction == "" {
+		return layout.Dimensions{}
+	}
+	f, ok := ilp.Lookup(jurisdiction)
+	if !ok {
+		return layout.Inset{Top: unit.Dp(12)}.Layout(gtx, material.Caption(s.Theme, "Jurisdiction: "+jurisdiction).Layout)
+	}
+	loc := s.App.Locale()
+	rows := []struct{ label, value string }{
+		{"REQUIRED", requiredSignatures(f, loc.Int)},
+	}
+	s.statsMu.Lock()
+	stats := s.publicStats
+	if s.statsFor != req {
+		stats = nil
+	}
+	s.statsMu.Unlock()
+	if stats != nil && f.Signatures > 0 {
+		rows = append(rows, struct{ label, value string }{"SO FAR", fmt.Sprintf("%s verified, %d%% of the signatures required", loc.Int(stats.Verified), stats.Verified*100/f.Signatures)})
+	}
+	rows = append(rows,
+		struct{ label, value string }{"DEADLINE", f.Deadline},
+		struct{ label, value string }{"WHO CAN SIGN", f.Eligibility},
+		struct{ label, value string }{"LAW", f.Law},
+	)
+	return layout.Inset{Top: unit.Dp(12)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return widgets.Border(gtx, widgets.ColorBorder, func(gtx layout.Context) layout.Dimensions {
+			return layout.UniformInset(unit.Dp(10)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				children := []layout.FlexChild{
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return widgets.IconLabel(gtx, s.Theme, icons.IconAbout, f.Name+" · "+jurisdiction, s.Theme.Fg, unit.Sp(13))
+					}),
+					layout.Rigid(layout.Spacer{Height: unit.Dp(6)}.Layout),
+				}
+				for _, row := range rows {
+					children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return layout.Inset{Bottom: unit.Dp(4)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+							return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+								layout.Rigid(material.Caption(s.Theme, row.label).Layout),
+								layout.Rigid(material.Body2(s.Theme, row.value).Layout),
+							)
+						})
+					}))
+				}
+				return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+			})
+		})
+	})
+}
+
+// requiredSignatures describes the signatures f requires, with numbers
+// written by formatInt.
+func requiredSignatures(f ilp.Framework, formatInt func(int) string) string {
+	if f.Signatures > 0 {
+		return formatInt(f.Signatures) + " valid signatures"
+	}
+	parts := make([]string, len(f.Thresholds))
+	for i, t := range f.Thresholds {
+		switch {
+		case t.MaxPopulation > 0 && i == 0:
+			parts[i] = fmt.Sprintf("%d%% of the voters where the population is up to %s", t.Percent, formatInt(t.MaxPopulation))
+		case t.MaxPopulation > 0:
+			parts[i] = fmt.Sprintf("%d%% up to %s", t.Percent, formatInt(t.MaxPopulation))
+		case i == 0:
+			parts[i] = fmt.Sprintf("%d%% of the voters", t.Percent)
+		default:
+			parts[i] = fmt.Sprintf("%d%% above %s", t.Percent, formatInt(f.Thresholds[i-1].MaxPopulation))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// viewPolicyDocument opens the cached policy document, downloading and
+// verifying it first if needed.
+func (s *RequestDetailsScreen) viewPolicyDocument(p *model.SignPolicy) {
+	s.policyLoading = true
+	s.policyStatus = ""
+	go func() {
+		defer func() {
+			s.policyLoading = false
+			s.App.Invalidate()
+		}()
+		path, err := s.App.PolicyDocument(context.Background(), p)
+		if err != nil {
+			log.Printf("ERROR: policy document: %v", err)
+			s.policyStatus = "Policy verification failed: " + err.Error()
+			return
+		}
+		s.policyStatus = "Hash verified, cached copy opened"
+		widgets.OpenFile(path)
+	}()
+}
+
+// openPaperSheet writes a printable signature sheet for the request to a
+// temporary file and opens it in the system browser for printing.
+func (s *RequestDetailsScreen) openPaperSheet(req *model.SignRequest) {
+	s.openPrintable("vocsign-sheet-*.html", false, func(w io.Writer) error {
+		return paper.WriteSheet(w, req, paper.DefaultRows)
+	})
+}
+
+// printReceipt opens the receipt of a submitted signature for printing.
+// It holds the signer's name, so the file is removed once the browser has
+// had time to load it.
+func (s *RequestDetailsScreen) printReceipt(r paper.Receipt) {
+	s.openPrintable("vocsign-receipt-*.html", true, func(w io.Writer) error {
+		return paper.WriteReceipt(w, r)
+	})
+}
+
+// shareLifetime is how long the files offered in the share sheet are
+// kept; the service the user picks reads them well before.
+const shareLifetime = 10 * time.Minute
+
+// setShareStatus reports the outcome of sharing, emailing or saving the
+// receipt.
+func (s *RequestDetailsScreen) setShareStatus(status string) {
+	s.shareMu.Lock()
+	s.shareStatus = status
+	s.shareMu.Unlock()
+}
+
+func (s *RequestDetailsScreen) shareStatusText() string {
+	s.shareMu.Lock()
+	defer s.shareMu.Unlock()
+	return s.shareStatus
+}
+
+// evidencePackage returns the evidence package of the signature r
+// acknowledges.
+func (s *RequestDetailsScreen) evidencePackage(r paper.Receipt) evidence.Package {
+	return evidence.Package{Request: r.Request, RawRequest: s.receiptRaw, Response: r.Response, Submit: r.Submit, SignerName: r.SignerName}
+}
+
+// shareEvidence offers the receipt PDF, or with full the whole evidence
+// package, in the macOS share sheet.
+func (s *RequestDetailsScreen) shareEvidence(r paper.Receipt, full bool) {
+	p := s.evidencePackage(r)
+	name, write := p.ReceiptFileName(), func(w io.Writer) error { return paper.WriteReceiptPDF(w, r) }
+	if full {
+		name, write = p.FileName(), func(w io.Writer) error { return evidence.Write(w, p) }
+	}
+	s.setShareStatus("")
+	go func() {
+		defer s.App.Invalidate()
+		dir, err := os.MkdirTemp("", "vocsign-share-*")
+		if err != nil {
+			log.Printf("ERROR: failed to create share directory: %v", err)
+			s.setShareStatus("Sharing failed: " + err.Error())
+			return
+		}
+		time.AfterFunc(shareLifetime, func() { _ = os.RemoveAll(dir) })
+		path := filepath.Join(dir, name)
+		err = writeNewFile(path, write)
+		if err == nil {
+			err = platform.Share(path)
+		}
+		if err != nil {
+			log.Printf("ERROR: failed to share %s: %v", name, err)
+			s.setShareStatus("Sharing failed: " + err.Error())
+		}
+	}()
+}
+
+// emailLifetime is how long the receipt attached to an email is kept. Mail
+// clients may only read it when the message is sent.
+const emailLifetime = time.Hour
+
+// emailReceipt starts an email with the receipt PDF attached in the user's
+// mail client, or opens an unsent EML draft with it where no client can be
+// asked to attach a file.
+func (s *RequestDetailsScreen) emailReceipt(r paper.Receipt) {
+	p := s.evidencePackage(r)
+	s.setShareStatus("Opening your mail client...")
+	go func() {
+		defer s.App.Invalidate()
+		dir, err := os.MkdirTemp("", "vocsign-email-*")
+		if err != nil {
+			log.Printf("ERROR: failed to create email directory: %v", err)
+			s.setShareStatus("Email failed: " + err.Error())
+			return
+		}
+		time.AfterFunc(emailLifetime, func() { _ = os.RemoveAll(dir) })
+		pdf := filepath.Join(dir, p.ReceiptFileName())
+		if err := writeNewFile(pdf, func(w io.Writer) error { return paper.WriteReceiptPDF(w, r) }); err != nil {
+			log.Printf("ERROR: failed to write receipt PDF: %v", err)
+			s.setShareStatus("Email failed: " + err.Error())
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		err = platform.ComposeEmail(ctx, paper.ReceiptEmailSubject(r), paper.ReceiptEmailBody(r), pdf)
+		if err == nil {
+			s.setShareStatus("")
+			return
+		}
+		if !errors.Is(err, platform.ErrNoComposer) {
+			log.Printf("WARNING: xdg-email failed, opening an email draft instead: %v", err)
+		}
+		eml := strings.TrimSuffix(pdf, ".pdf") + ".eml"
+		if err := writeNewFile(eml, func(w io.Writer) error { return paper.WriteReceiptEmail(w, r, p.ReceiptFileName()) }); err != nil {
+			log.Printf("ERROR: failed to write receipt email: %v", err)
+			s.setShareStatus("Email failed: " + err.Error())
+			return
+		}
+		widgets.OpenFile(eml)
+		s.setShareStatus("")
+	}()
+}
+
+// writeNewFile creates path, readable by the user only, with write.
+func writeNewFile(path string, write func(io.Writer) error) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// saveEvidence saves the evidence package where the user chooses, on
+// systems without a share sheet.
+func (s *RequestDetailsScreen) saveEvidence(r paper.Receipt) {
+	p := s.evidencePackage(r)
+	s.setShareStatus("")
+	go func() {
+		defer s.App.Invalidate()
+		if s.App.Explorer == nil {
+			s.setShareStatus("Saving failed: the system file dialog is not available")
+			return
+		}
+		w, err := s.App.Explorer.CreateFile(p.FileName())
+		if err != nil {
+			log.Printf("WARNING: evidence export canceled: %v", err)
+			if !errors.Is(err, explorer.ErrUserDecline) {
+				s.setShareStatus("Saving failed: the system file dialog could not be opened")
+			}
+			return
+		}
+		err = evidence.Write(w, p)
+		if cerr := w.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			log.Printf("ERROR: evidence export failed: %v", err)
+			s.setShareStatus("Saving failed: " + err.Error())
+			return
+		}
+		s.setShareStatus("Evidence package saved")
+	}()
+}
+
+// printableLifetime is how long a printed page holding personal data is
+// kept on disk.
+const printableLifetime = 2 * time.Minute
+
+// openPrintable writes a print-ready page to a temporary file and opens it
+// in the browser, which shows its print dialog. Personal pages are removed
+// after printableLifetime.
+func (s *RequestDetailsScreen) openPrintable(pattern string, personal bool, write func(io.Writer) error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		log.Printf("ERROR: failed to create printable page: %v", err)
+		return
+	}
+	defer func() { _ = f.Close() }()
+	if err := write(f); err != nil {
+		log.Printf("ERROR: failed to render printable page: %v", err)
+		_ = os.Remove(f.Name())
+		return
+	}
+	if personal {
+		name := f.Name()
+		time.AfterFunc(printableLifetime, func() { _ = os.Remove(name) })
+	}
+	widgets.OpenFile(f.Name())
+}
+
+// staleTemp are the temporary files handed to other programs, by name
+// pattern, with how long they are kept.
+var staleTemp = []struct {
+	pattern  string
+	lifetime time.Duration
+}{
+	{"vocsign-share-*", shareLifetime},
+	{"vocsign-email-*", emailLifetime},
+	{"vocsign-receipt-*.html", printableLifetime},
+}
+
+// RemoveStaleTemp removes the temporary files a previous run left behind
+// when it quit before their removal was due. Those of a run still going
+// are younger than their lifetime and stay.
+func RemoveStaleTemp(now time.Time) {
+	for _, t := range staleTemp {
+		matches, err := filepath.Glob(filepath.Join(os.TempDir(), t.pattern))
+		if err != nil {
+			continue
+		}
+		for _, m := range matches {
+			info, err := os.Lstat(m)
+			if err != nil || now.Sub(info.ModTime()) < t.lifetime {
+				continue
+			}
+			if err := os.RemoveAll(m); err != nil {
+				log.Printf("WARNING: failed to remove stale temporary file: %v", err)
+			}
+		}
+	}
+}
+
 func (s *RequestDetailsScreen) findIdentity(id string) *pkcs12store.Identity {
+	if demo.IsIdentity(id) {
+		if identity, err := s.App.DemoIdentity(); err == nil && identity.ID == id {
+			return &identity
+		}
+		return nil
+	}
 	for _, identity := range s.App.IdentitiesSnapshot() {
 		if identity.ID == id {
 			idCopy := identity
@@ -691,3 +2456,66 @@ func (s *RequestDetailsScreen) findIdentity(id string) *pkcs12store.Identity {
 	}
 	return nil
 }
+
+// keepCounterSignature stores the collector's countersignature from
+// receipt, if any, and returns the countersigner's fingerprint for the
+// audit log. A missing or invalid countersignature, or one not made with an
+// organizer key, does not fail the submission, which the collector already
+// accepted.
+func (s *RequestDetailsScreen) keepCounterSignature(req *model.SignRequest, resp *model.SignResponse, receipt *model.SubmitReceipt) string {
+	fp, err := s.App.KeepCounterSignature(req, resp, receipt)
+	if err != nil {
+		log.Printf("WARNING: collector countersignature not kept: %v", err)
+	}
+	return fp
+}
+
+// newSignResponse builds the callback payload for a signature over xmlBytes.
+func newSignResponse(req *model.SignRequest, xmlBytes, signatureDER []byte, cert *x509.Certificate, chain []*x509.Certificate, timestampTokenB64 string) *model.SignResponse {
+	payloadHash := sha256.Sum256(xmlBytes)
+	certPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+	var chainPEM []string
+	for _, c := range chain {
+		chainPEM = append(chainPEM, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})))
+	}
+	return &model.SignResponse{
+		Version:                "1.0",
+		RequestID:              req.RequestID,
+		Nonce:                  req.Nonce,
+		SignedAt:               time.Now().Format(time.RFC3339),
+		PayloadCanonicalSHA256: base64.StdEncoding.EncodeToString(payloadHash[:]),
+		SignatureFormat:        "CAdES-detached",
+		SignatureDerBase64:     base64.StdEncoding.EncodeToString(signatureDER),
+		SignerCertPEM:          certPEM,
+		ChainPEM:               chainPEM,
+		SignerXMLBase64:        base64.StdEncoding.EncodeToString(xmlBytes),
+		TimestampTokenBase64:   timestampTokenB64,
+		DocumentVersion:        req.Proposal.DocumentVersion,
+		DocumentSHA256:         req.Proposal.FullText.SHA256,
+		Client: model.ClientInfo{
+			App:     "vocsign",
+			Version: "0.1.0",
+			OS:      runtime.GOOS,
+		},
+	}
+}
+
+// agentCertification describes the certifying agent whose certificate info
+// was read from, for signatures collected in agent mode.
+func agentCertification(info certs.ExtractedInfo, origen string) model.Certificacio {
+	return model.Certificacio{
+		Fedatari:      strings.TrimSpace(info.Nom + " " + strings.Join(info.Cognoms, " ")),
+		NumIdentifica: info.DNI,
+		Origen:        origen,
+	}
+}
+
+// urlHost returns the host (and port, if any) of rawURL, or rawURL itself
+// if it cannot be parsed.
+func urlHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	return u.Host
+}
@@ -0,0 +1,155 @@
+package screens
+
+import (
+	"fmt"
+	"log"
+
+	"gioui.org/font"
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/app"
+	"github.com/vocdoni/gofirma/vocsign/internal/storage"
+	"github.com/vocdoni/gofirma/vocsign/internal/ui/icons"
+	"github.com/vocdoni/gofirma/vocsign/internal/ui/widgets"
+)
+
+// SecurityScreen lists the security log: unlocks, failed PINs and
+// passwords, settings changes, trust decisions and update installs. It is
+// kept apart from the signing history of the Audit screen.
+type SecurityScreen struct {
+	App   *app.App
+	Theme *material.Theme
+
+	List    widgets.VirtualList
+	Events  []storage.SecurityEvent
+	Refresh widget.Clickable
+
+	// chainErr is set when the hash chain of the log is broken.
+	chainErr string
+}
+
+func NewSecurityScreen(a *app.App, th *material.Theme) *SecurityScreen {
+	s := &SecurityScreen{App: a, Theme: th}
+	s.List.Axis = layout.Vertical
+	s.RefreshEvents()
+	return s
+}
+
+// RefreshEvents reloads the log, newest event first, and verifies its hash
+// chain.
+func (s *SecurityScreen) RefreshEvents() {
+	go func() {
+		events, err := s.App.SecurityLog.ReadAll()
+		if err != nil {
+			log.Printf("ERROR: failed to read security log: %v", err)
+			return
+		}
+		for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+			events[i], events[j] = events[j], events[i]
+		}
+		chainErr := ""
+		if n, err := s.App.SecurityLog.Verify(); err != nil {
+			log.Printf("WARNING: security log does not verify: %v", err)
+			chainErr = fmt.Sprintf("The security log is damaged or was edited: event %d and later cannot be trusted.", n+1)
+		}
+		s.Events, s.chainErr = events, chainErr
+		s.App.Invalidate()
+	}()
+}
+
+// eventKey identifies the event at index i across refreshes.
+func (s *SecurityScreen) eventKey(i int) string {
+	return s.Events[i].Timestamp + s.Events[i].PrevHash
+}
+
+// securityKindLabel returns the tag text and tone of an event kind.
+func securityKindLabel(kind string) (string, widgets.BannerTone) {
+	switch kind {
+	case storage.SecurityWalletUnlocked:
+		return "UNLOCKED", widgets.BannerSuccess
+	case storage.SecurityWalletLocked:
+		return "LOCKED", widgets.BannerInfo
+	case storage.SecurityAuthFailed:
+		return "FAILED ATTEMPT", widgets.BannerError
+	case storage.SecuritySettings:
+		return "SETTINGS", widgets.BannerInfo
+	case storage.SecurityTrust:
+		return "TRUST", widgets.BannerWarning
+	case storage.SecurityVault:
+		return "VAULT", widgets.BannerWarning
+	case storage.SecurityUpdate:
+		return "UPDATE", widgets.BannerInfo
+	default:
+		return kind, widgets.BannerInfo
+	}
+}
+
+func (s *SecurityScreen) Layout(gtx layout.Context) layout.Dimensions {
+	if s.Refresh.Clicked(gtx) {
+		s.RefreshEvents()
+	}
+
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+					return widgets.IconLabel(gtx, s.Theme, icons.IconSecurity, "Security Log", s.Theme.ContrastBg, unit.Sp(24))
+				}),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					btn := widgets.SecondaryButton(s.Theme, &s.Refresh, "Refresh")
+					return btn.Layout(gtx)
+				}),
+			)
+		}),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		layout.Rigid(material.Caption(s.Theme, "Unlocks, failed PIN and password attempts, settings changes, trust decisions and update installs. Signatures are listed under Audit.").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(16)}.Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			if s.chainErr == "" {
+				return layout.Dimensions{}
+			}
+			return layout.Inset{Bottom: unit.Dp(12)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return widgets.Banner(gtx, s.Theme, widgets.BannerError, s.chainErr)
+			})
+		}),
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			if len(s.Events) == 0 {
+				gtx.Constraints.Min.Y = gtx.Constraints.Max.Y
+				return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
+					return widgets.CenterInAvailable(gtx, func(gtx layout.Context) layout.Dimensions {
+						return widgets.EmptyState(gtx, s.Theme, "No security events yet", "Unlocks, failed attempts and changes to your settings will appear here.")
+					})
+				})
+			}
+			return s.List.Layout(gtx, s.Theme, len(s.Events), s.eventKey, func(gtx layout.Context, index int) layout.Dimensions {
+				defer s.App.Perf.Section("security/row")()
+				ev := s.Events[index]
+				tag, tone := securityKindLabel(ev.Kind)
+				return layout.Inset{Bottom: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
+						return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+									layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+										return widgets.StatusTag(gtx, s.Theme, tone, tag)
+									}),
+									layout.Rigid(layout.Spacer{Width: unit.Dp(12)}.Layout),
+									layout.Rigid(material.Caption(s.Theme, s.App.Locale().Timestamp(ev.Timestamp)).Layout),
+								)
+							}),
+							layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								l := material.Body2(s.Theme, ev.Detail)
+								l.Font.Weight = font.Medium
+								return l.Layout(gtx)
+							}),
+						)
+					})
+				})
+			})
+		}),
+	)
+}
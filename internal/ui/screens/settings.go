@@ -0,0 +1,834 @@
+package screens
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+	"gioui.org/x/explorer"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/app"
+	"github.com/vocdoni/gofirma/vocsign/internal/locale"
+	"github.com/vocdoni/gofirma/vocsign/internal/net"
+	"github.com/vocdoni/gofirma/vocsign/internal/presign"
+	"github.com/vocdoni/gofirma/vocsign/internal/settings"
+	"github.com/vocdoni/gofirma/vocsign/internal/storage"
+	"github.com/vocdoni/gofirma/vocsign/internal/ui/icons"
+	"github.com/vocdoni/gofirma/vocsign/internal/ui/widgets"
+)
+
+type SettingsScreen struct {
+	App   *app.App
+	Theme *material.Theme
+
+	ModeEnum       widget.Enum
+	AgentCertEnum  widget.Enum
+	ReviewEnum     widget.Enum
+	PINCacheEnum   widget.Enum
+	ChannelEnum    widget.Enum
+	TelemetryCheck widget.Bool
+	ClipboardCheck widget.Bool
+	ProbeCheck     widget.Bool
+	RememberCheck  widget.Bool
+	VaultCheck     widget.Bool
+	VaultForget    widget.Clickable
+	AutoLockEnum   widget.Enum
+	SessionLock    widget.Bool
+	LockPINEditor  widget.Editor
+	LockPINSave    widget.Clickable
+	LockPINRemove  widget.Clickable
+	PaletteCheck   widget.Bool
+	LocaleEnum     widget.Enum
+	ForgetButton   widget.Clickable
+	RewarnButton   widget.Clickable
+	GatewayEditor  widget.Editor
+	GatewaySave    widget.Clickable
+	AnchorEditor   widget.Editor
+	AnchorSave     widget.Clickable
+	ExportButton   widget.Clickable
+	ImportButton   widget.Clickable
+	List           widget.List
+
+	// browser is the built-in file chooser, open when the native dialog
+	// is unavailable.
+	browser *widgets.FileBrowser
+	// reload asks Layout to show the settings again after an import.
+	reload atomic.Bool
+	// vaultBusy is set while the stored keys are encrypted again.
+	vaultBusy atomic.Bool
+	// lockPINBusy is set while the wallet PIN is changed, which may wait
+	// for the wallet to be unlocked.
+	lockPINBusy atomic.Bool
+	status      string
+}
+
+func NewSettingsScreen(a *app.App, th *material.Theme) *SettingsScreen {
+	s := &SettingsScreen{
+		App:   a,
+		Theme: th,
+	}
+	s.List.Axis = layout.Vertical
+	s.AnchorEditor.SingleLine = true
+	s.LockPINEditor.SingleLine = true
+	s.LockPINEditor.Mask = '•'
+	s.load()
+	return s
+}
+
+// load shows the current settings.
+func (s *SettingsScreen) load() {
+	current := s.App.Settings.Get()
+	s.ModeEnum.Value = settings.ModeCitizen
+	if current.AgentMode() {
+		s.ModeEnum.Value = settings.ModeAgent
+	}
+	s.AgentCertEnum.Value = current.AgentCertID
+	s.ReviewEnum.Value = strconv.Itoa(current.SubmitReviewSeconds)
+	s.TelemetryCheck.Value = current.TelemetryEnabled
+	s.PINCacheEnum.Value = strconv.Itoa(current.PINCacheMinutes)
+	s.ChannelEnum.Value = current.Channel()
+	s.ClipboardCheck.Value = current.ClipboardDetect
+	s.ProbeCheck.Value = current.ClipboardProbe
+	s.RememberCheck.Value = current.RememberSignerData
+	s.VaultCheck.Value = !current.PortableVault
+	s.AutoLockEnum.Value = strconv.Itoa(current.AutoLockMinutes)
+	s.SessionLock.Value = current.LockOnSessionLock
+	s.PaletteCheck.Value = current.ColorblindPalette()
+	s.LocaleEnum.Value = locale.Get(current.Locale).Tag
+	s.GatewayEditor.SetText(strings.Join(current.IPFSGateways, "\n"))
+	s.AnchorEditor.SetText(current.AuditAnchorTSAURL)
+}
+
+func (s *SettingsScreen) Layout(gtx layout.Context) layout.Dimensions {
+	if s.reload.Swap(false) {
+		s.load()
+	}
+	if s.ModeEnum.Update(gtx) {
+		mode := s.ModeEnum.Value
+		s.save(func(st *settings.Settings) { st.Mode = mode })
+	}
+	if s.AgentCertEnum.Update(gtx) {
+		id := s.AgentCertEnum.Value
+		s.save(func(st *settings.Settings) { st.AgentCertID = id })
+	}
+	if s.ReviewEnum.Update(gtx) {
+		secs, _ := strconv.Atoi(s.ReviewEnum.Value)
+		s.save(func(st *settings.Settings) { st.SubmitReviewSeconds = secs })
+	}
+	if s.PINCacheEnum.Update(gtx) {
+		mins, _ := strconv.Atoi(s.PINCacheEnum.Value)
+		s.save(func(st *settings.Settings) { st.PINCacheMinutes = mins })
+		s.App.ApplyPINCacheTTL()
+	}
+	if s.ChannelEnum.Update(gtx) {
+		channel := s.ChannelEnum.Value
+		s.save(func(st *settings.Settings) { st.UpdateChannel = channel })
+		s.App.CheckUpdatesNow()
+	}
+	if s.TelemetryCheck.Update(gtx) {
+		enabled := s.TelemetryCheck.Value
+		s.save(func(st *settings.Settings) { st.TelemetryEnabled = enabled })
+	}
+	if s.ClipboardCheck.Update(gtx) {
+		enabled := s.ClipboardCheck.Value
+		s.save(func(st *settings.Settings) { st.ClipboardDetect = enabled })
+	}
+	if s.ProbeCheck.Update(gtx) {
+		enabled := s.ProbeCheck.Value
+		s.save(func(st *settings.Settings) { st.ClipboardProbe = enabled })
+	}
+	if s.RememberCheck.Update(gtx) {
+		enabled := s.RememberCheck.Value
+		s.save(func(st *settings.Settings) { st.RememberSignerData = enabled })
+	}
+	if s.VaultCheck.Update(gtx) && !s.vaultBusy.Load() {
+		s.setPortableVault(!s.VaultCheck.Value)
+	}
+	if s.VaultForget.Clicked(gtx) && !s.vaultBusy.Load() {
+		s.forgetVaultBinding()
+	}
+	if s.AutoLockEnum.Update(gtx) {
+		mins, _ := strconv.Atoi(s.AutoLockEnum.Value)
+		s.save(func(st *settings.Settings) { st.AutoLockMinutes = mins })
+	}
+	if s.SessionLock.Update(gtx) {
+		enabled := s.SessionLock.Value
+		s.save(func(st *settings.Settings) { st.LockOnSessionLock = enabled })
+	}
+	if s.LockPINSave.Clicked(gtx) && !s.lockPINBusy.Load() {
+		s.setWalletPIN(takeSecret(&s.LockPINEditor))
+	}
+	if s.LockPINRemove.Clicked(gtx) && !s.lockPINBusy.Load() {
+		s.removeWalletPIN()
+	}
+	if s.PaletteCheck.Update(gtx) {
+		palette := settings.PaletteStandard
+		if s.PaletteCheck.Value {
+			palette = settings.PaletteColorblind
+		}
+		s.save(func(st *settings.Settings) { st.Palette = palette })
+	}
+	if s.LocaleEnum.Update(gtx) {
+		tag := s.LocaleEnum.Value
+		s.save(func(st *settings.Settings) { st.Locale = tag })
+	}
+	if s.ForgetButton.Clicked(gtx) {
+		if err := s.App.ClearSignerData(); err != nil {
+			log.Printf("ERROR: failed to clear signer data: %v", err)
+			s.status = "Could not clear personal data: " + err.Error()
+		} else {
+			s.status = "Remembered personal data cleared"
+		}
+	}
+	if s.RewarnButton.Clicked(gtx) {
+		if err := s.App.CertAcks.Clear(); err != nil {
+			log.Printf("ERROR: failed to clear certificate acknowledgements: %v", err)
+			s.status = "Could not reset certificate warnings: " + err.Error()
+		} else {
+			s.status = "Certificate warnings will be shown again"
+		}
+	}
+	if s.GatewaySave.Clicked(gtx) {
+		var gateways []string
+		for _, line := range strings.Split(s.GatewayEditor.Text(), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				gateways = append(gateways, line)
+			}
+		}
+		s.save(func(st *settings.Settings) { st.IPFSGateways = gateways })
+		s.App.ApplyIPFSGateways()
+	}
+	if s.AnchorSave.Clicked(gtx) {
+		tsaURL := strings.TrimSpace(s.AnchorEditor.Text())
+		if err := settings.ValidateTSAURL(tsaURL); err != nil {
+			s.status = "Invalid timestamp server: " + err.Error()
+		} else {
+			s.save(func(st *settings.Settings) { st.AuditAnchorTSAURL = tsaURL })
+		}
+	}
+	if s.ExportButton.Clicked(gtx) {
+		s.exportProfile()
+	}
+	if s.ImportButton.Clicked(gtx) && s.browser == nil && !s.App.Managed.DisableFileImport {
+		s.chooseProfile()
+	}
+	if b := s.browser; b != nil {
+		if path, canceled := b.Update(gtx); canceled {
+			s.browser = nil
+		} else if path != "" {
+			s.browser = nil
+			if f, err := os.Open(path); err != nil {
+				s.status = "Import failed: " + err.Error()
+			} else {
+				go s.importProfile(f)
+			}
+		}
+	}
+
+	return material.List(s.Theme, &s.List).Layout(gtx, 1, func(gtx layout.Context, _ int) layout.Dimensions {
+		return layout.UniformInset(unit.Dp(12)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return widgets.IconLabel(gtx, s.Theme, icons.IconSettings, "Settings", s.Theme.ContrastBg, unit.Sp(22))
+				}),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(14)}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return widgets.Section(gtx, widgets.ColorSurface, s.managedSection(s.layoutMode, "mode", "agentCertId"))
+				}),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(12)}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return widgets.Section(gtx, widgets.ColorSurface, s.managedSection(s.layoutSubmitReview, "submitReviewSeconds"))
+				}),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(12)}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return widgets.Section(gtx, widgets.ColorSurface, s.managedSection(s.layoutPINCache, "pinCacheMinutes"))
+				}),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(12)}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return widgets.Section(gtx, widgets.ColorSurface, s.layoutPersonalData)
+				}),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(12)}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return widgets.Section(gtx, widgets.ColorSurface, s.managedSection(s.layoutVault, "portableVault"))
+				}),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(12)}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return widgets.Section(gtx, widgets.ColorSurface, s.layoutAutoLock)
+				}),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(12)}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return widgets.Section(gtx, widgets.ColorSurface, s.managedSection(s.layoutTelemetry, "telemetryEnabled"))
+				}),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(12)}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return widgets.Section(gtx, widgets.ColorSurface, s.managedSection(s.layoutClipboard, "clipboardDetect", "clipboardProbe"))
+				}),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(12)}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return widgets.Section(gtx, widgets.ColorSurface, s.managedSection(s.layoutIPFSGateways, "ipfsGateways"))
+				}),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(12)}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return widgets.Section(gtx, widgets.ColorSurface, s.managedSection(s.layoutAuditAnchoring, "auditAnchorTsaUrl"))
+				}),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(12)}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return widgets.Section(gtx, widgets.ColorSurface, s.managedSection(s.layoutChannel, "updateChannel"))
+				}),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(12)}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return widgets.Section(gtx, widgets.ColorSurface, s.managedSection(s.layoutAppearance, "palette", "locale"))
+				}),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(12)}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return widgets.Section(gtx, widgets.ColorSurface, s.layoutProfile)
+				}),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					if len(s.App.Settings.Get().DualControl) == 0 {
+						return layout.Dimensions{}
+					}
+					return layout.Inset{Top: unit.Dp(12)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						return widgets.Section(gtx, widgets.ColorSurface, s.layoutDualControl)
+					})
+				}),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					if s.status == "" {
+						return layout.Dimensions{}
+					}
+					return layout.Inset{Top: unit.Dp(12)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						return widgets.Banner(gtx, s.Theme, statusTone(s.status), s.status)
+					})
+				}),
+			)
+		})
+	})
+}
+
+func (s *SettingsScreen) layoutMode(gtx layout.Context) layout.Dimensions {
+	children := []layout.FlexChild{
+		layout.Rigid(material.Subtitle2(s.Theme, "How you use VocSign").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+		layout.Rigid(material.RadioButton(s.Theme, &s.ModeEnum, settings.ModeCitizen, "Citizen: sign proposals with my own certificate").Layout),
+		layout.Rigid(material.RadioButton(s.Theme, &s.ModeEnum, settings.ModeAgent, "Certifying agent: collect and certify the signatures of other citizens").Layout),
+	}
+	if s.ModeEnum.Value != settings.ModeAgent {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	}
+
+	children = append(children,
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		layout.Rigid(material.Body2(s.Theme, "Each citizen's data is typed in at the table and signed with your agent certificate. Choose it before collecting signatures.").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+	)
+	identities := append(s.App.IdentitiesSnapshot(), s.App.SystemIdentitiesSnapshot()...)
+	if len(identities) == 0 {
+		l := material.Body2(s.Theme, "No certificates yet. Import your agent certificate first.")
+		l.Color = widgets.ColorWarning
+		children = append(children, layout.Rigid(l.Layout))
+	}
+	for _, id := range identities {
+		children = append(children, layout.Rigid(material.RadioButton(s.Theme, &s.AgentCertEnum, id.ID, id.FriendlyName).Layout))
+	}
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+}
+
+func (s *SettingsScreen) layoutSubmitReview(gtx layout.Context) layout.Dimensions {
+	children := []layout.FlexChild{
+		layout.Rigid(material.Subtitle2(s.Theme, "Review before submission").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+		layout.Rigid(material.Body2(s.Theme, "After signing, show a summary of what will be sent and wait before submitting so you can still cancel.").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+	}
+	for _, secs := range settings.SubmitReviewOptions {
+		label := "Submit immediately"
+		if secs > 0 {
+			label = fmt.Sprintf("Wait %d seconds", secs)
+		}
+		key := strconv.Itoa(secs)
+		children = append(children, layout.Rigid(material.RadioButton(s.Theme, &s.ReviewEnum, key, label).Layout))
+	}
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+}
+
+func (s *SettingsScreen) layoutPINCache(gtx layout.Context) layout.Dimensions {
+	children := []layout.FlexChild{
+		layout.Rigid(material.Subtitle2(s.Theme, "Hardware token PIN").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+		layout.Rigid(material.Body2(s.Theme, "Remember the PIN of a smart card or token in protected memory after it is entered, so several proposals can be signed in a row.").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+	}
+	for _, mins := range settings.PINCacheOptions {
+		label := "Ask every time"
+		if mins == 1 {
+			label = "Remember for 1 minute"
+		} else if mins > 1 {
+			label = fmt.Sprintf("Remember for %d minutes", mins)
+		}
+		children = append(children, layout.Rigid(material.RadioButton(s.Theme, &s.PINCacheEnum, strconv.Itoa(mins), label).Layout))
+	}
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+}
+
+func (s *SettingsScreen) layoutChannel(gtx layout.Context) layout.Dimensions {
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(material.Subtitle2(s.Theme, "Updates").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+		layout.Rigid(material.Body2(s.Theme, "Pre-releases let testers try new versions before they are published. Do not collect legally binding signatures with a pre-release unless your promoter asked you to test it.").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		layout.Rigid(material.RadioButton(s.Theme, &s.ChannelEnum, settings.ChannelStable, "Stable: published releases only").Layout),
+		layout.Rigid(material.RadioButton(s.Theme, &s.ChannelEnum, settings.ChannelBeta, "Beta: also offer pre-releases").Layout),
+	)
+}
+
+func (s *SettingsScreen) layoutPersonalData(gtx layout.Context) layout.Dimensions {
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(material.Subtitle2(s.Theme, "Personal data").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+		layout.Rigid(material.Body2(s.Theme, "Remember the birth date and name corrections you type when signing, so they are filled in the next time you use the same certificate. They are stored encrypted on this device only and never sent anywhere else.").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			if s.App.Managed.Locked("rememberSignerData") {
+				gtx = gtx.Disabled()
+			}
+			return material.CheckBox(s.Theme, &s.RememberCheck, "Remember my signer data").Layout(gtx)
+		}),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		layout.Rigid(widgets.SecondaryButton(s.Theme, &s.ForgetButton, "Clear personal data").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(12)}.Layout),
+		layout.Rigid(material.Body2(s.Theme, "Warnings about a certificate you acknowledged, such as signing with a representative certificate, are not shown again for that certificate until their wording or the campaign's signature policy changes.").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		layout.Rigid(widgets.SecondaryButton(s.Theme, &s.RewarnButton, "Show certificate warnings again").Layout),
+	)
+}
+
+func (s *SettingsScreen) layoutVault(gtx layout.Context) layout.Dimensions {
+	hw := s.App.VaultHardware()
+	st := s.App.Store.VaultStatus()
+	busy := s.vaultBusy.Load()
+
+	tone, text := widgets.BannerNeutral, "This computer has no TPM or Secure Enclave VocSign can use, so the wallet is not bound to it."
+	switch {
+	case busy:
+		text = "Encrypting the stored keys again…"
+	case st.Err != nil:
+		tone, text = widgets.BannerError, "The wallet key is sealed by the "+nonEmptyText(st.Hardware, "TPM")+" of another computer, or it was reset. Certificates stored before cannot be used here: delete them and import them again."
+	case st.Bound():
+		tone, text = widgets.BannerSuccess, "Bound to this computer's "+st.Hardware+"."
+	case hw != "":
+		tone, text = widgets.BannerWarning, "Not bound: copies of the wallet files can be used on another computer."
+	}
+	children := []layout.FlexChild{
+		layout.Rigid(material.Subtitle2(s.Theme, "Wallet protection").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+		layout.Rigid(material.Body2(s.Theme, "The private keys of your certificates are stored encrypted. Where this computer has a TPM or a Secure Enclave, the key they are encrypted with is sealed by it, so copies of the wallet files are useless on another computer. Certificates kept with their own password are not affected.").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return widgets.IconLabel(gtx, s.Theme, widgets.ToneIcon(tone), text, widgets.ToneColor(tone), unit.Sp(13))
+		}),
+	}
+	if hw == "" && st.Err == nil && runtime.GOOS == "linux" {
+		children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			l := material.Caption(s.Theme, "On Linux this needs tpm2-tools and access to /dev/tpmrm0, usually by being in the tss group.")
+			l.Color = widgets.ColorMuted
+			return layout.Inset{Top: unit.Dp(4)}.Layout(gtx, l.Layout)
+		}))
+	}
+	switch {
+	case st.Err != nil:
+		children = append(children,
+			layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				if busy {
+					gtx = gtx.Disabled()
+				}
+				return widgets.SecondaryButton(s.Theme, &s.VaultForget, "Start a new wallet key").Layout(gtx)
+			}),
+		)
+	case hw != "" || st.Bound():
+		children = append(children,
+			layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				if busy {
+					gtx = gtx.Disabled()
+				}
+				return material.CheckBox(s.Theme, &s.VaultCheck, "Bind the wallet to this computer").Layout(gtx)
+			}),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				l := material.Caption(s.Theme, "Unbind it before copying the wallet to a new computer, and bind it again there.")
+				l.Color = widgets.ColorMuted
+				return l.Layout(gtx)
+			}),
+		)
+	}
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+}
+
+// setPortableVault binds or unbinds the vault key in the background.
+func (s *SettingsScreen) setPortableVault(portable bool) {
+	s.vaultBusy.Store(true)
+	go func() {
+		defer s.App.Invalidate()
+		defer s.vaultBusy.Store(false)
+		if err := s.App.SetPortableVault(portable); err != nil {
+			log.Printf("ERROR: failed to change the wallet binding: %v", err)
+			s.status = "Could not change the wallet protection: " + err.Error()
+			s.reload.Store(true)
+			return
+		}
+		if portable {
+			s.status = "Wallet unbound: it can now be copied to another computer"
+		} else {
+			s.status = "Wallet bound to this computer's " + s.App.Store.VaultStatus().Hardware
+		}
+	}()
+}
+
+// forgetVaultBinding replaces a vault key that cannot be unsealed here.
+func (s *SettingsScreen) forgetVaultBinding() {
+	s.vaultBusy.Store(true)
+	go func() {
+		defer s.App.Invalidate()
+		defer s.vaultBusy.Store(false)
+		if err := s.App.ForgetVaultBinding(); err != nil {
+			log.Printf("ERROR: failed to replace the wallet key: %v", err)
+			s.status = "Could not start a new wallet key: " + err.Error()
+			return
+		}
+		s.status = "New wallet key created. Import your certificates again."
+	}()
+}
+
+func (s *SettingsScreen) layoutAutoLock(gtx layout.Context) layout.Dimensions {
+	set := s.App.WalletPIN.IsSet()
+	busy := s.lockPINBusy.Load()
+	children := []layout.FlexChild{
+		layout.Rigid(material.Subtitle2(s.Theme, "Auto-lock").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+		layout.Rigid(material.Body2(s.Theme, "Lock the wallet after a while without using VocSign, or when you lock your computer. A locked wallet forgets the wallet key and the PINs and passwords it remembers, and asks for the wallet PIN before signing again. You can also lock it from the navigation bar.").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+	}
+	if !set {
+		children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return widgets.IconLabel(gtx, s.Theme, widgets.ToneIcon(widgets.BannerNeutral), "Set a wallet PIN to turn the auto-lock on.", widgets.ToneColor(widgets.BannerNeutral), unit.Sp(13))
+		}))
+	} else {
+		options := []layout.FlexChild{}
+		for _, mins := range settings.AutoLockOptions {
+			label := "Do not lock when idle"
+			if mins > 0 {
+				label = fmt.Sprintf("Lock after %d minutes without use", mins)
+			}
+			options = append(options, layout.Rigid(material.RadioButton(s.Theme, &s.AutoLockEnum, strconv.Itoa(mins), label).Layout))
+		}
+		options = append(options,
+			layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+			layout.Rigid(material.CheckBox(s.Theme, &s.SessionLock, "Lock when this computer's session is locked").Layout),
+		)
+		children = append(children, layout.Rigid(s.managedSection(func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx, options...)
+		}, "autoLockMinutes", "lockOnSessionLock")))
+	}
+
+	save, hint := "Set PIN", "Wallet PIN"
+	if set {
+		save, hint = "Change PIN", "New wallet PIN"
+	}
+	children = append(children,
+		layout.Rigid(layout.Spacer{Height: unit.Dp(12)}.Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			if busy {
+				gtx = gtx.Disabled()
+			}
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+					return widgets.Border(gtx, widgets.ColorBorder, func(gtx layout.Context) layout.Dimensions {
+						return layout.UniformInset(unit.Dp(8)).Layout(gtx, material.Editor(s.Theme, &s.LockPINEditor, hint).Layout)
+					})
+				}),
+				layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+				layout.Rigid(widgets.SecondaryButton(s.Theme, &s.LockPINSave, save).Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					if !set {
+						return layout.Dimensions{}
+					}
+					return layout.Inset{Left: unit.Dp(8)}.Layout(gtx, widgets.SecondaryButton(s.Theme, &s.LockPINRemove, "Remove PIN").Layout)
+				}),
+			)
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			l := material.Caption(s.Theme, fmt.Sprintf("At least %d characters. It locks the wallet only while VocSign runs and does not encrypt it. It cannot be recovered: if you forget it, close VocSign and delete wallet_pin.json from the .vocsign folder in your home folder.", storage.MinWalletPINLength))
+			l.Color = widgets.ColorMuted
+			return layout.Inset{Top: unit.Dp(4)}.Layout(gtx, l.Layout)
+		}),
+	)
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+}
+
+// setWalletPIN sets or changes the wallet PIN in the background, since a
+// locked wallet is unlocked with the old PIN first.
+func (s *SettingsScreen) setWalletPIN(pin []byte) {
+	s.lockPINBusy.Store(true)
+	go func() {
+		defer s.App.Invalidate()
+		defer s.lockPINBusy.Store(false)
+		if err := s.App.SetWalletPIN(pin); err != nil {
+			s.status = "Could not set the wallet PIN: " + err.Error()
+			return
+		}
+		s.status = "Wallet PIN saved"
+	}()
+}
+
+// removeWalletPIN removes the wallet PIN, which turns the auto-lock off.
+func (s *SettingsScreen) removeWalletPIN() {
+	s.lockPINBusy.Store(true)
+	go func() {
+		defer s.App.Invalidate()
+		defer s.lockPINBusy.Store(false)
+		if err := s.App.ClearWalletPIN(); err != nil {
+			s.status = "Could not remove the wallet PIN: " + err.Error()
+			return
+		}
+		s.status = "Wallet PIN removed: the wallet no longer locks itself"
+	}()
+}
+
+func (s *SettingsScreen) layoutAppearance(gtx layout.Context) layout.Dimensions {
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(material.Subtitle2(s.Theme, "Appearance").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+		layout.Rigid(material.Body2(s.Theme, "Statuses always come with an icon and a label. The colorblind-safe palette also shows them in blue, amber and purple instead of green, orange and red.").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		layout.Rigid(material.CheckBox(s.Theme, &s.PaletteCheck, "Use colorblind-safe colors").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(12)}.Layout),
+		layout.Rigid(material.Body2(s.Theme, "Dates and numbers in the audit history, certificate details and printed receipts are written the way your region writes them. Right-to-left locales also lay text out right to left.").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			children := make([]layout.FlexChild, 0, len(locale.All))
+			for _, l := range locale.All {
+				children = append(children, layout.Rigid(material.RadioButton(s.Theme, &s.LocaleEnum, l.Tag, l.Name).Layout))
+			}
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+		}),
+	)
+}
+
+func (s *SettingsScreen) layoutTelemetry(gtx layout.Context) layout.Dimensions {
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(material.Subtitle2(s.Theme, "Anonymous usage statistics").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+		layout.Rigid(material.Body2(s.Theme, "Help Vocdoni prioritize compatibility work by sending coarse events: app version, operating system, whether certificate scans and signatures succeeded, and a failure category. No names, ID numbers, certificates, URLs or request details are ever sent.").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		layout.Rigid(material.CheckBox(s.Theme, &s.TelemetryCheck, "Send anonymous usage statistics").Layout),
+	)
+}
+
+func (s *SettingsScreen) layoutClipboard(gtx layout.Context) layout.Dimensions {
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(material.Subtitle2(s.Theme, "Clipboard").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+		layout.Rigid(material.Body2(s.Theme, "When VocSign comes to the front, look at the clipboard for a signing request URL and offer to open it. Nothing is opened without your confirmation.").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		layout.Rigid(material.CheckBox(s.Theme, &s.ClipboardCheck, "Offer to open signing URLs from the clipboard").Layout),
+		layout.Rigid(material.CheckBox(s.Theme, &s.ProbeCheck, "Also check other copied links by downloading them").Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			if s.App.Platform.ClipboardOnFocus() {
+				return layout.Dimensions{}
+			}
+			l := material.Caption(s.Theme, "On Wayland this needs wl-clipboard (wl-paste), which is not installed.")
+			l.Color = widgets.ColorError
+			return layout.Inset{Top: unit.Dp(4)}.Layout(gtx, l.Layout)
+		}),
+	)
+}
+
+func (s *SettingsScreen) layoutIPFSGateways(gtx layout.Context) layout.Dimensions {
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(material.Subtitle2(s.Theme, "IPFS gateways").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+		layout.Rigid(material.Body2(s.Theme, "Requests and documents published at ipfs:// addresses are downloaded through these gateways, one per line, tried in order. Documents are checked against the hashes in the request, and requests against their CID. Leave empty to use the defaults.").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return widgets.Border(gtx, widgets.ColorBorder, func(gtx layout.Context) layout.Dimensions {
+				return layout.UniformInset(unit.Dp(8)).Layout(gtx, material.Editor(s.Theme, &s.GatewayEditor, strings.Join(net.DefaultIPFSGateways, "\n")).Layout)
+			})
+		}),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		layout.Rigid(widgets.SecondaryButton(s.Theme, &s.GatewaySave, "Save gateways").Layout),
+	)
+}
+
+func (s *SettingsScreen) layoutAuditAnchoring(gtx layout.Context) layout.Dimensions {
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(material.Subtitle2(s.Theme, "Signing history timestamps").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+		layout.Rigid(material.Body2(s.Theme, "Once a day, have a trusted timestamp server (RFC 3161) certify the latest entry of your signing history and of the security log, so when each signature was made can be proven independently of this computer's clock, and events removed from the security log show. Only hashes of the logs are sent. Leave empty to disable.").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return widgets.Border(gtx, widgets.ColorBorder, func(gtx layout.Context) layout.Dimensions {
+				return layout.UniformInset(unit.Dp(8)).Layout(gtx, material.Editor(s.Theme, &s.AnchorEditor, "https://tsa.example/tsr").Layout)
+			})
+		}),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		layout.Rigid(widgets.SecondaryButton(s.Theme, &s.AnchorSave, "Save timestamp server").Layout),
+	)
+}
+
+// managedSection lays out w read-only, with a note, if the administrator's
+// policy sets any of the settings.json members names.
+func (s *SettingsScreen) managedSection(w layout.Widget, names ...string) layout.Widget {
+	if !s.App.Managed.Locked(names...) {
+		return w
+	}
+	return func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return w(gtx.Disabled())
+			}),
+			layout.Rigid(layout.Spacer{Height: unit.Dp(6)}.Layout),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				l := material.Caption(s.Theme, "Set by your administrator")
+				l.Color = widgets.ColorWarning
+				return l.Layout(gtx)
+			}),
+		)
+	}
+}
+
+func (s *SettingsScreen) layoutProfile(gtx layout.Context) layout.Dimensions {
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(material.Subtitle2(s.Theme, "Configuration profile").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+		layout.Rigid(material.Body2(s.Theme, "Save these settings, the pinned organizers and the servers you allowed request links to open to a file, and load it on another computer to set it up the same way. Importing replaces the settings and pinned organizers of this computer; the agent certificate is kept. The file includes dual-control secrets, so keep it safe.").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+				layout.Rigid(widgets.SecondaryButton(s.Theme, &s.ExportButton, "Export profile").Layout),
+				layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					if s.App.Managed.DisableFileImport {
+						return layout.Dimensions{}
+					}
+					return widgets.SecondaryButton(s.Theme, &s.ImportButton, "Import profile").Layout(gtx)
+				}),
+			)
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			if s.browser == nil {
+				return layout.Dimensions{}
+			}
+			return layout.Inset{Top: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return s.browser.Layout(gtx, s.Theme)
+			})
+		}),
+	)
+}
+
+func (s *SettingsScreen) exportProfile() {
+	go func() {
+		defer s.App.Invalidate()
+		if s.App.Explorer == nil {
+			s.status = "Export failed: the system file dialog is not available"
+			return
+		}
+		w, err := s.App.Explorer.CreateFile("vocsign-profile.json")
+		if err != nil {
+			log.Printf("WARNING: profile export canceled: %v", err)
+			if !errors.Is(err, explorer.ErrUserDecline) {
+				s.status = "Export failed: the system file dialog could not be opened"
+			}
+			return
+		}
+		err = s.App.ExportProfile(w)
+		if cerr := w.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			log.Printf("ERROR: profile export failed: %v", err)
+			s.status = "Export failed: " + err.Error()
+			return
+		}
+		s.status = "Configuration profile exported"
+	}()
+}
+
+func (s *SettingsScreen) chooseProfile() {
+	go func() {
+		rc, err := chooseFile(s.App, ".json")
+		if errors.Is(err, errNoFilePicker) {
+			s.browser = widgets.NewFileBrowser(".json")
+			s.App.Invalidate()
+			return
+		}
+		if err != nil {
+			return
+		}
+		s.importProfile(rc)
+	}()
+}
+
+// importProfile applies the profile read from rc and closes it.
+func (s *SettingsScreen) importProfile(rc io.ReadCloser) {
+	defer s.App.Invalidate()
+	p, err := s.App.ImportProfile(rc)
+	_ = rc.Close()
+	if err != nil {
+		log.Printf("ERROR: profile import failed: %v", err)
+		s.status = "Import failed: " + err.Error()
+		return
+	}
+	s.reload.Store(true)
+	s.status = fmt.Sprintf("Configuration profile imported: settings and %d pinned organizers", len(p.Organizers))
+}
+
+// layoutDualControl lists the dual-control rules provisioned by the user's
+// organization. They are read-only here. TOTP rules not set by the
+// administrator's policy are listed as ignored.
+func (s *SettingsScreen) layoutDualControl(gtx layout.Context) layout.Dimensions {
+	source := "These rules come from an imported configuration profile."
+	if s.App.Managed.Locked("dualControl") {
+		source = "These rules are set by your administrator and cannot be changed."
+	}
+	children := []layout.FlexChild{
+		layout.Rigid(material.Subtitle2(s.Theme, "Dual control").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+		layout.Rigid(material.Body2(s.Theme, "Signing with these representation certificates requires a confirmation code from a second approver. "+source).Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+	}
+	inForce := s.App.DualControlRules()
+	for _, r := range s.App.Settings.Get().DualControl {
+		org := r.OrganizationID
+		if org == "*" {
+			org = "Any organization"
+		}
+		method := "authenticator code"
+		if r.Method == presign.MethodRemote {
+			method = "code sent by the organization"
+		}
+		if !slices.Contains(inForce, r) {
+			method += " (ignored: authenticator codes must be set by an administrator's policy)"
+		}
+		children = append(children, layout.Rigid(material.Body2(s.Theme, "• "+org+": "+method).Layout))
+	}
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+}
+
+func (s *SettingsScreen) save(fn func(*settings.Settings)) {
+	if err := s.App.Settings.Update(fn); err != nil {
+		log.Printf("ERROR: failed to save settings: %v", err)
+		s.status = "Could not save settings: " + err.Error()
+		return
+	}
+	s.status = "Settings saved"
+}
@@ -0,0 +1,150 @@
+package screens
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+	"github.com/vocdoni/gofirma/vocsign/internal/ui/icons"
+	"github.com/vocdoni/gofirma/vocsign/internal/ui/widgets"
+)
+
+const (
+	// slowSignThreshold is how long a signature may take before the
+	// progress panel replaces the status line.
+	slowSignThreshold = 3 * time.Second
+	// signTimeout is how long the token may take, not counting time spent
+	// in VocSign's own PIN prompt, before signing is given up on.
+	signTimeout = 2 * time.Minute
+)
+
+// errSignCanceled is returned when the user stops waiting for the token.
+var errSignCanceled = errors.New("signing canceled")
+
+// signProgress tracks a signature that may be waiting on the user at the
+// token, e.g. for a PIN typed on the reader's pinpad.
+type signProgress struct {
+	started time.Time
+	cancel  chan struct{}
+	once    sync.Once
+
+	mu sync.Mutex
+	// waited is the time spent on the token so far, without the time the
+	// PIN prompt was open, and since when it is being counted.
+	waited    time.Duration
+	countFrom time.Time
+
+	CancelButton widget.Clickable
+}
+
+func newSignProgress() *signProgress {
+	now := time.Now()
+	return &signProgress{started: now, countFrom: now, cancel: make(chan struct{})}
+}
+
+// run calls sign in a goroutine and waits for it to return, for the user
+// to cancel or for signTimeout. pinPending reports whether the PIN prompt
+// is open, which does not count against the timeout. A PKCS#11 call cannot
+// be interrupted, so on cancel or timeout abandoned is called and run still
+// waits for sign to return, discarding its result, so that the caller stays
+// busy while the token works and nothing else uses it meanwhile.
+func (p *signProgress) run(sign func() ([]byte, error), pinPending func() bool, abandoned func()) ([]byte, error) {
+	type result struct {
+		sig []byte
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		sig, err := sign()
+		done <- result{sig, err}
+	}()
+
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+	for {
+		select {
+		case r := <-done:
+			return r.sig, r.err
+		case <-p.cancel:
+			abandoned()
+			<-done
+			return nil, errSignCanceled
+		case now := <-tick.C:
+			if p.update(now, pinPending()) >= signTimeout {
+				abandoned()
+				<-done
+				return nil, errcode.Errorf(errcode.SignTimeout, "the token did not sign within %s", signTimeout)
+			}
+		}
+	}
+}
+
+// signAbandoned tells the user that VocSign stopped waiting for the token,
+// which may still be signing.
+func (s *RequestDetailsScreen) signAbandoned() {
+	s.signing = nil
+	s.App.SignStatus = "Stopped waiting for the card, which may still be working. Leave it in the reader until VocSign is ready again; anything it signs now is discarded and not sent."
+	s.App.Invalidate()
+}
+
+// update adds the time since the last call to the time waited on the token
+// unless the PIN prompt is open, and returns the total.
+func (p *signProgress) update(now time.Time, pinPending bool) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !pinPending {
+		p.waited += now.Sub(p.countFrom)
+	}
+	p.countFrom = now
+	return p.waited
+}
+
+func (p *signProgress) abort() {
+	p.once.Do(func() { close(p.cancel) })
+}
+
+// slow reports whether the progress panel should be shown.
+func (p *signProgress) slow(now time.Time) bool {
+	return now.Sub(p.started) >= slowSignThreshold
+}
+
+func (s *RequestDetailsScreen) layoutSignProgress(gtx layout.Context, p *signProgress) layout.Dimensions {
+	if p.CancelButton.Clicked(gtx) {
+		p.abort()
+	}
+	gtx.Execute(op.InvalidateCmd{At: gtx.Now.Add(time.Second)})
+
+	elapsed := gtx.Now.Sub(p.started).Round(time.Second)
+	hints := []string{
+		"If your card reader has a PIN pad, type the PIN on the reader now.",
+		"Check that the card is fully inserted and the reader's light is on.",
+		"Some cards take up to a minute to sign. Do not remove the card while it works.",
+	}
+	children := []layout.FlexChild{
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return widgets.IconLabel(gtx, s.Theme, icons.IconWarning, "Waiting for your card or token", widgets.ColorWarning, unit.Sp(16))
+		}),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			msg := fmt.Sprintf("Signing for %d s. VocSign gives up after %d minutes.", int(elapsed/time.Second), int(signTimeout/time.Minute))
+			return widgets.Banner(gtx, s.Theme, widgets.BannerWarning, msg)
+		}),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+	}
+	for _, h := range hints {
+		children = append(children, layout.Rigid(material.Body2(s.Theme, "• "+h).Layout))
+	}
+	children = append(children,
+		layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+		layout.Rigid(widgets.DangerButton(s.Theme, &p.CancelButton, "Cancel").Layout),
+	)
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+}
@@ -0,0 +1,236 @@
+package screens
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/cades"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+	"github.com/vocdoni/gofirma/vocsign/internal/net"
+	"github.com/vocdoni/gofirma/vocsign/internal/ui/icons"
+	"github.com/vocdoni/gofirma/vocsign/internal/ui/widgets"
+)
+
+// submitReview holds a signed response that is waiting out the cancel window
+// before it is submitted to the organizer.
+type submitReview struct {
+	Response    *model.SignResponse
+	CallbackURL string
+	Signer      model.Signant
+	CertSubject string
+	SignatureSz int
+	Deadline    time.Time
+
+	// Details is the decoded CMS structure; nil if it could not be decoded.
+	Details *cades.Summary
+
+	// decision receives true to submit immediately and false to cancel.
+	decision chan bool
+
+	CancelButton    widget.Clickable
+	SubmitNowButton widget.Clickable
+	DetailsButton   widget.Clickable
+	showDetails     bool
+}
+
+func newSubmitReview(resp *model.SignResponse, callbackURL string, signer model.Signant, certSubject string, signatureDER []byte, wait time.Duration) *submitReview {
+	details, err := cades.Inspect(signatureDER)
+	if err != nil {
+		log.Printf("WARNING: failed to decode signature for preview: %v", err)
+	}
+	return &submitReview{
+		Response:    resp,
+		CallbackURL: callbackURL,
+		Signer:      signer,
+		CertSubject: certSubject,
+		SignatureSz: len(signatureDER),
+		Deadline:    time.Now().Add(wait),
+		Details:     details,
+		decision:    make(chan bool, 1),
+	}
+}
+
+// wait blocks until the deadline passes or the user decides. It reports
+// whether the response should be submitted.
+func (r *submitReview) wait() bool {
+	timer := time.NewTimer(time.Until(r.Deadline))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case submit := <-r.decision:
+		return submit
+	}
+}
+
+func (r *submitReview) decide(submit bool) {
+	select {
+	case r.decision <- submit:
+	default:
+	}
+}
+
+// CancelSubmitReview cancels the submission waiting out its review window,
+// if any, as when the user leaves the screen that shows the countdown.
+func (s *RequestDetailsScreen) CancelSubmitReview() {
+	if r := s.review; r != nil {
+		r.decide(false)
+	}
+}
+
+func (s *RequestDetailsScreen) layoutSubmitReview(gtx layout.Context, r *submitReview) layout.Dimensions {
+	if r.CancelButton.Clicked(gtx) {
+		r.decide(false)
+	}
+	if r.SubmitNowButton.Clicked(gtx) {
+		r.decide(true)
+	}
+	if r.DetailsButton.Clicked(gtx) {
+		r.showDetails = !r.showDetails
+	}
+
+	remaining := time.Until(r.Deadline)
+	if remaining < 0 {
+		remaining = 0
+	}
+	gtx.Execute(op.InvalidateCmd{At: gtx.Now.Add(time.Second)})
+
+	timestamp := "No"
+	if r.Response.TimestampTokenBase64 != "" {
+		timestamp = "Yes"
+	}
+	birthDate := r.Signer.DataNaixement
+	if birthDate == "" {
+		birthDate = "Not collected"
+	}
+	rows := []struct{ label, value string }{
+		{"REQUEST ID", r.Response.RequestID},
+		{"SENT TO", net.DisplayURL(r.CallbackURL)},
+		{"SIGNER", fmt.Sprintf("%s %s %s", r.Signer.Nom, r.Signer.Cognom1, r.Signer.Cognom2)},
+		{"ID DOCUMENT", r.Signer.TipusIdentifica + " " + r.Signer.NumIdentifica},
+		{"BIRTH DATE", birthDate},
+		{"CERTIFICATE", r.CertSubject},
+		{"SIGNATURE", fmt.Sprintf("%s, %d bytes", r.Response.SignatureFormat, r.SignatureSz)},
+		{"TRUSTED TIMESTAMP", timestamp},
+		{"PAYLOAD DIGEST (SHA256)", r.Response.PayloadCanonicalSHA256},
+	}
+	if ext := r.Response.Extensions; ext != nil && ext.Contact != nil {
+		contact := strings.Trim(ext.Contact.Email+" · "+ext.Contact.Phone, " ·")
+		rows = append(rows, struct{ label, value string }{"CONTACT (SENT APART FROM THE SIGNATURE)", contact})
+	}
+
+	children := []layout.FlexChild{
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			msg := fmt.Sprintf("Your signature is ready. It will be submitted in %d s unless you cancel.", int(remaining.Round(time.Second)/time.Second))
+			return widgets.Banner(gtx, s.Theme, widgets.BannerWarning, msg)
+		}),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+	}
+	for _, row := range rows {
+		row := row
+		children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Inset{Bottom: unit.Dp(6)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+					layout.Rigid(material.Caption(s.Theme, row.label).Layout),
+					layout.Rigid(material.Body2(s.Theme, nonEmptyText(row.value, "—")).Layout),
+				)
+			})
+		}))
+	}
+	if r.Details != nil {
+		children = append(children,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				label := "Show technical details"
+				if r.showDetails {
+					label = "Hide technical details"
+				}
+				return material.Clickable(gtx, &r.DetailsButton, func(gtx layout.Context) layout.Dimensions {
+					return layout.UniformInset(unit.Dp(4)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						return widgets.IconLabel(gtx, s.Theme, icons.IconAbout, label, s.Theme.ContrastBg, unit.Sp(13))
+					})
+				})
+			}),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				if !r.showDetails {
+					return layout.Dimensions{}
+				}
+				return layout.Inset{Top: unit.Dp(6), Bottom: unit.Dp(6)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return widgets.Border(gtx, widgets.ColorBorder, func(gtx layout.Context) layout.Dimensions {
+						return layout.UniformInset(unit.Dp(8)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+							return s.layoutSignatureDetails(gtx, r.Details)
+						})
+					})
+				})
+			}),
+		)
+	}
+	children = append(children,
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return widgets.DangerButton(s.Theme, &r.CancelButton, "Cancel").Layout(gtx)
+				}),
+				layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return widgets.PrimaryButton(s.Theme, &r.SubmitNowButton, "Submit now").Layout(gtx)
+				}),
+			)
+		}),
+	)
+
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return widgets.IconLabel(gtx, s.Theme, icons.IconWarning, "Review before submission", widgets.ColorWarning, unit.Sp(16))
+		}),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+		}),
+	)
+}
+
+// layoutSignatureDetails renders the decoded CMS structure of the signature.
+func (s *RequestDetailsScreen) layoutSignatureDetails(gtx layout.Context, d *cades.Summary) layout.Dimensions {
+	signingTime := ""
+	if !d.SigningTime.IsZero() {
+		signingTime = d.SigningTime.UTC().Format(time.RFC3339)
+	}
+	policy := ""
+	if d.PolicyOID != "" {
+		policy = d.PolicyOID + " (hash " + d.PolicyHash + ")"
+	}
+	rows := []struct{ label, value string }{
+		{"DIGEST ALGORITHMS", strings.Join(d.DigestAlgorithms, ", ")},
+		{"SIGNATURE ALGORITHM", d.SignatureAlgorithm},
+		{"SIGNING TIME", signingTime},
+		{"SIGNATURE POLICY", policy},
+		{"SIGNING CERTIFICATE V2 HASH (SHA256)", d.SigningCertHash},
+		{"SIGNER", strings.Join(d.SignerSubjects, "; ")},
+	}
+	children := make([]layout.FlexChild, 0, len(rows)+len(d.CertificateSubjects)+1)
+	for _, row := range rows {
+		row := row
+		children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Inset{Bottom: unit.Dp(4)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+					layout.Rigid(material.Caption(s.Theme, row.label).Layout),
+					layout.Rigid(material.Body2(s.Theme, nonEmptyText(row.value, "—")).Layout),
+				)
+			})
+		}))
+	}
+	children = append(children, layout.Rigid(material.Caption(s.Theme, "CERTIFICATE CHAIN").Layout))
+	for _, subject := range d.CertificateSubjects {
+		children = append(children, layout.Rigid(material.Body2(s.Theme, subject).Layout))
+	}
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+}
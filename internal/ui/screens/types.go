@@ -2,6 +2,10 @@ package screens
 
 import "github.com/vocdoni/gofirma/vocsign/internal/crypto/pkcs12store"
 
+// rowCacheSize bounds the widget state kept for the rows of a list, far
+// more rows than fit in a window at once.
+const rowCacheSize = 200
+
 type groupedIdentities struct {
 	Personal       []pkcs12store.Identity
 	Representation []pkcs12store.Identity
@@ -0,0 +1,61 @@
+package screens
+
+import (
+	"image"
+	"image/color"
+
+	"gioui.org/io/event"
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/app"
+	"github.com/vocdoni/gofirma/vocsign/internal/ui/icons"
+	"github.com/vocdoni/gofirma/vocsign/internal/ui/widgets"
+)
+
+// UnlockDialog is the quick unlock dialog. It asks for the wallet PIN over
+// every screen while App.UnlockWallet waits for it, whether the user
+// clicked the lock in the navigation bar or started to sign.
+type UnlockDialog struct {
+	App   *app.App
+	Theme *material.Theme
+
+	prompt PINPrompt
+}
+
+func NewUnlockDialog(a *app.App, th *material.Theme) *UnlockDialog {
+	d := &UnlockDialog{App: a, Theme: th}
+	d.prompt.init()
+	return d
+}
+
+func (d *UnlockDialog) Layout(gtx layout.Context) layout.Dimensions {
+	pr := d.App.PendingUnlockRequest()
+	if pr == nil {
+		return layout.Dimensions{}
+	}
+	// The dimmed backdrop takes the pointer, so the screen below waits.
+	paint.FillShape(gtx.Ops, color.NRGBA{R: 0x0F, G: 0x17, B: 0x2A, A: 0x66}, clip.Rect{Max: gtx.Constraints.Max}.Op())
+	area := clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops)
+	event.Op(gtx.Ops, d)
+	area.Pop()
+
+	return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		gtx.Constraints.Min = image.Point{}
+		gtx.Constraints.Max.X = min(gtx.Constraints.Max.X, gtx.Dp(440))
+		return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return widgets.IconLabel(gtx, d.Theme, icons.IconLock, "Wallet locked", d.Theme.ContrastBg, unit.Sp(18))
+				}),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return d.prompt.Layout(gtx, d.Theme, pr)
+				}),
+			)
+		})
+	})
+}
@@ -1,14 +1,15 @@
 package screens
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
 	"io"
 	"log"
 	"os"
+	"runtime"
 	"runtime/debug"
 	"strings"
 	"time"
@@ -24,6 +25,8 @@ import (
 
 	"github.com/vocdoni/gofirma/vocsign/internal/app"
 	"github.com/vocdoni/gofirma/vocsign/internal/crypto/pkcs12store"
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/systemstore"
+	"github.com/vocdoni/gofirma/vocsign/internal/sandbox"
 	"github.com/vocdoni/gofirma/vocsign/internal/ui/icons"
 	"github.com/vocdoni/gofirma/vocsign/internal/ui/widgets"
 )
@@ -41,6 +44,8 @@ const (
 	StepChoice WizardStep = iota
 	StepScanResults
 	StepImportFile
+	StepHardwareToken
+	StepIssuanceGuide
 )
 
 type WizardScreen struct {
@@ -49,25 +54,52 @@ type WizardScreen struct {
 
 	Step WizardStep
 
-	ResultsList widget.List
+	ResultsList widgets.VirtualList
 
-	ScanModeButton widget.Clickable
-	FileModeButton widget.Clickable
-	FinishButton   widget.Clickable
+	ScanModeButton  widget.Clickable
+	FileModeButton  widget.Clickable
+	TokenModeButton widget.Clickable
+	FinishButton    widget.Clickable
 
 	ImportSelects map[string]*widget.Bool
 	ImportButton  widget.Clickable
 	BackToChoice  widget.Clickable
 
-	LockedOpenFile widget.Clickable
+	// LockedOpen adds a password-protected file found by the scan to the
+	// files to import, by path.
+	LockedOpen *widgets.Cache[widget.Clickable]
 
 	BrowseButton widget.Clickable
 	PassEditor   widget.Editor
 	FileImport   widget.Clickable
 	FileBack     widget.Clickable
 
-	selectedFile string
-	importData   []byte
+	NoCertificateButton widget.Clickable
+	ReminderScan        widget.Clickable
+	ReminderLater       widget.Clickable
+	ReminderDismiss     widget.Clickable
+	guide               issuanceGuide
+
+	TokenRecheck widget.Clickable
+	TokenScan    widget.Clickable
+	TokenList    widget.List
+
+	// imports are the files chosen to import, each with its password and
+	// result; importing is set while they are imported.
+	imports    []*importFile
+	importing  bool
+	ImportList widget.List
+	// DeferredImport keeps the imported files as they are, with their own
+	// password asked at each signature.
+	DeferredImport widget.Bool
+	// keyring names the OS keyring passwords can be saved in, "" if none.
+	keyring string
+	// browser is the built-in file chooser, open when the native dialog
+	// is unavailable.
+	browser *widgets.FileBrowser
+
+	tokenReport   *systemstore.TokenReport
+	tokenChecking bool
 
 	ConfirmationMsg string
 	ScanInProgress  bool
@@ -79,22 +111,36 @@ func NewWizardScreen(a *app.App, th *material.Theme) *WizardScreen {
 		App:           a,
 		Theme:         th,
 		ImportSelects: make(map[string]*widget.Bool),
+		LockedOpen:    widgets.NewCache[widget.Clickable](rowCacheSize),
 	}
 	s.ResultsList.Axis = layout.Vertical
+	s.TokenList.Axis = layout.Vertical
 	s.PassEditor.SingleLine = true
 	s.PassEditor.Mask = '*'
 	return s
 }
 
+// openImportFile adds the certificate file at path to the files to import.
+func (s *WizardScreen) openImportFile(path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		s.ConfirmationMsg = "Could not read selected file"
+		return
+	}
+	go s.addImportFiles([]io.ReadCloser{f})
+}
+
 func (s *WizardScreen) Reset() {
 	s.Step = StepChoice
-	s.importData = nil
-	s.selectedFile = ""
+	s.imports = nil
+	s.browser = nil
 	s.ConfirmationMsg = ""
 	s.PassEditor.SetText("")
 	s.ImportSelects = make(map[string]*widget.Bool)
 	s.ScanInProgress = false
 	s.ScanError = ""
+	s.tokenReport = nil
+	s.guide.reminded = ""
 }
 
 func (s *WizardScreen) Layout(gtx layout.Context) layout.Dimensions {
@@ -117,6 +163,10 @@ func (s *WizardScreen) Layout(gtx layout.Context) layout.Dimensions {
 				return s.layoutImportPanel(gtx)
 			case StepScanResults:
 				return s.layoutScanResults(gtx)
+			case StepHardwareToken:
+				return s.layoutTokenPanel(gtx)
+			case StepIssuanceGuide:
+				return s.layoutIssuanceGuide(gtx)
 			default:
 				return layout.Dimensions{}
 			}
@@ -150,31 +200,33 @@ func (s *WizardScreen) layoutHeader(gtx layout.Context) layout.Dimensions {
 }
 
 func (s *WizardScreen) handleActions(gtx layout.Context) {
-	if s.ScanModeButton.Clicked(gtx) {
-		s.ScanInProgress = true
-		s.ScanError = ""
-		s.Step = StepScanResults
-		go func() {
-			defer func() {
-				if r := recover(); r != nil {
-					log.Printf("ERROR: panic while scanning system stores: %v\n%s", r, string(debug.Stack()))
-					s.ScanError = fmt.Sprintf("Scan failed unexpectedly: %v", r)
-				}
-				s.ScanInProgress = false
-				s.App.Invalidate()
-			}()
-			ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-			defer cancel()
-			s.App.ScanSystemStores(ctx)
-		}()
+	if s.ScanModeButton.Clicked(gtx) || s.TokenScan.Clicked(gtx) {
+		s.startScan()
 	}
 
-	if s.FileModeButton.Clicked(gtx) {
+	if s.FileModeButton.Clicked(gtx) && !s.App.Managed.DisableFileImport {
 		s.Step = StepImportFile
 	}
 
-	if s.LockedOpenFile.Clicked(gtx) {
-		s.Step = StepImportFile
+	if s.TokenModeButton.Clicked(gtx) {
+		s.Step = StepHardwareToken
+		s.checkToken()
+	}
+
+	if s.TokenRecheck.Clicked(gtx) {
+		s.checkToken()
+	}
+
+	if s.NoCertificateButton.Clicked(gtx) {
+		s.Step = StepIssuanceGuide
+	}
+	s.handleGuideActions(gtx)
+
+	for _, path := range s.App.LockedP12Snapshot() {
+		if btn, ok := s.LockedOpen.Peek(path); ok && btn.Clicked(gtx) && !s.App.Managed.DisableFileImport {
+			s.Step = StepImportFile
+			s.openImportFile(path)
+		}
 	}
 
 	if s.FinishButton.Clicked(gtx) {
@@ -186,56 +238,41 @@ func (s *WizardScreen) handleActions(gtx layout.Context) {
 		s.ScanInProgress = false
 	}
 
-	if s.BrowseButton.Clicked(gtx) {
+	if s.BrowseButton.Clicked(gtx) && s.browser == nil && !s.importing && !s.App.Managed.DisableFileImport {
 		go func() {
-			if s.App.Explorer == nil {
-				s.ConfirmationMsg = "File picker is unavailable"
+			rcs, err := chooseFiles(s.App, ".p12", ".pfx")
+			if errors.Is(err, errNoFilePicker) {
+				s.browser = widgets.NewFileBrowser(".p12", ".pfx")
 				s.App.Invalidate()
 				return
 			}
-			rc, err := s.App.Explorer.ChooseFile()
 			if err != nil {
 				return
 			}
-			data, err := io.ReadAll(rc)
-			_ = rc.Close()
-			if err != nil {
-				s.ConfirmationMsg = "Could not read selected file"
-				s.App.Invalidate()
-				return
-			}
-			s.importData = data
-			s.selectedFile = "File selected"
 			s.ConfirmationMsg = ""
-			s.App.Invalidate()
+			s.addImportFiles(rcs)
 		}()
 	}
-
-	if s.FileImport.Clicked(gtx) {
-		pass := s.PassEditor.Text()
-		if len(s.importData) == 0 {
-			s.ConfirmationMsg = "Select a .p12 or .pfx file first"
-		} else {
-			go func() {
-				ctx := context.Background()
-				if _, err := s.App.Store.Import(ctx, "Imported Certificate", bytes.NewReader(s.importData), []byte(pass)); err == nil {
-					s.importData = nil
-					s.selectedFile = ""
-					s.PassEditor.SetText("")
-					s.ConfirmationMsg = importSuccessMessage(1)
-					ids, _ := s.App.Store.List(ctx)
-					s.App.SetIdentities(ids)
-					s.Step = StepChoice
-				} else {
-					s.ConfirmationMsg = pkcs12store.FriendlyImportError(err)
-				}
-				s.App.Invalidate()
-			}()
+	if path := s.App.TakeLaunchCertificate(); path != "" {
+		s.Step = StepImportFile
+		s.openImportFile(path)
+	}
+	if b := s.browser; b != nil {
+		if path, canceled := b.Update(gtx); canceled {
+			s.browser = nil
+		} else if path != "" {
+			s.browser = nil
+			s.openImportFile(path)
 		}
 	}
 
+	if s.FileImport.Clicked(gtx) && !s.importing {
+		s.runImports(s.PassEditor.Text())
+	}
+
 	if s.FileBack.Clicked(gtx) {
 		s.Step = StepChoice
+		s.browser = nil
 	}
 
 	if s.ImportButton.Clicked(gtx) {
@@ -273,8 +310,6 @@ func (s *WizardScreen) handleActions(gtx layout.Context) {
 					}
 				}
 			}
-			ids, _ := s.App.Store.List(ctx)
-			s.App.SetIdentities(ids)
 			s.ConfirmationMsg = importSuccessMessage(count)
 			s.Step = StepChoice
 			s.App.Invalidate()
@@ -282,6 +317,46 @@ func (s *WizardScreen) handleActions(gtx layout.Context) {
 	}
 }
 
+func (s *WizardScreen) startScan() {
+	s.ScanInProgress = true
+	s.ScanError = ""
+	s.Step = StepScanResults
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("ERROR: panic while scanning system stores: %v\n%s", r, string(debug.Stack()))
+				s.ScanError = fmt.Sprintf("Scan failed unexpectedly: %v", r)
+			}
+			s.ScanInProgress = false
+			s.App.Invalidate()
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+		s.App.ScanSystemStores(ctx)
+	}()
+}
+
+// checkToken looks for card readers and smart card middleware in the
+// background. It is run when the hardware token step opens and on "Check again".
+func (s *WizardScreen) checkToken() {
+	if s.tokenChecking {
+		return
+	}
+	s.tokenChecking = true
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("ERROR: panic while checking smart card support: %v\n%s", r, string(debug.Stack()))
+			}
+			s.tokenChecking = false
+			s.App.Invalidate()
+		}()
+		report := systemstore.DetectTokenSupport(context.Background())
+		log.Printf("DEBUG: smart card check: readers=%d middleware=%v ready=%v", len(report.Readers), report.MiddlewareInstalled(), report.Ready())
+		s.tokenReport = &report
+	}()
+}
+
 // layoutChoicePanel renders the initial step where the user picks scan or file import.
 func (s *WizardScreen) layoutChoicePanel(gtx layout.Context) layout.Dimensions {
 	isWide := gtx.Constraints.Max.X >= gtx.Dp(760)
@@ -308,6 +383,7 @@ func (s *WizardScreen) layoutChoicePanel(gtx layout.Context) layout.Dimensions {
 						return widgets.Banner(gtx, s.Theme, widgets.BannerSuccess, s.ConfirmationMsg)
 					})
 				}),
+				layout.Rigid(s.layoutReminder),
 				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 					return s.layoutModeCards(gtx, isWide)
 				}),
@@ -356,57 +432,62 @@ func (s *WizardScreen) layoutChoiceHeading(gtx layout.Context) layout.Dimensions
 }
 
 func (s *WizardScreen) layoutModeCards(gtx layout.Context, wide bool) layout.Dimensions {
-	if wide {
-		// Side-by-side cards
-		cardW := (gtx.Constraints.Max.X - gtx.Dp(unit.Dp(24))) / 2
-		return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Start}.Layout(gtx,
-			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-				return s.modeCard(gtx, cardW,
-					icons.IconScan,
-					"Automatic Scan",
-					"Search your operating system, browser profiles (Firefox, Chrome), and PKCS#11 hardware tokens for installed certificates.",
-					true,
-					&s.ScanModeButton, "Scan System Now",
-				)
-			}),
-			layout.Rigid(layout.Spacer{Width: unit.Dp(24)}.Layout),
-			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-				return s.modeCard(gtx, cardW,
-					icons.IconImport,
-					"Open Certificate File",
-					"Manually select a .p12 or .pfx certificate file stored on your computer. You will need the file password.",
-					false,
-					&s.FileModeButton, "Choose File",
-				)
-			}),
-		)
+	type modeCard struct {
+		icon               *icons.Icon
+		title, description string
+		recommended        bool
+		click              *widget.Clickable
+		action             string
 	}
+	cards := []modeCard{{
+		icons.IconScan,
+		"Automatic Scan",
+		"Search your operating system, browser profiles (Firefox, Chrome), and PKCS#11 hardware tokens for installed certificates.",
+		true,
+		&s.ScanModeButton, "Scan System Now",
+	}}
+	if !s.App.Managed.DisableFileImport {
+		cards = append(cards, modeCard{
+			icons.IconImport,
+			"Open Certificate File",
+			"Manually select a .p12 or .pfx certificate file stored on your computer. You will need the file password.",
+			false,
+			&s.FileModeButton, "Choose File",
+		})
+	}
+	cards = append(cards, modeCard{
+		icons.IconSmartCard,
+		tokenCardTitle,
+		tokenCardDescription,
+		false,
+		&s.TokenModeButton, "Set Up Card Reader",
+	})
 
-	// Stacked cards for narrow screens
-	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
-		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-			return s.modeCard(gtx, gtx.Constraints.Max.X,
-				icons.IconScan,
-				"Automatic Scan",
-				"Search your operating system, browser profiles (Firefox, Chrome), and PKCS#11 hardware tokens for installed certificates.",
-				true,
-				&s.ScanModeButton, "Scan System Now",
-			)
-		}),
-		layout.Rigid(layout.Spacer{Height: unit.Dp(16)}.Layout),
-		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-			return s.modeCard(gtx, gtx.Constraints.Max.X,
-				icons.IconImport,
-				"Open Certificate File",
-				"Manually select a .p12 or .pfx certificate file stored on your computer. You will need the file password.",
-				false,
-				&s.FileModeButton, "Choose File",
-			)
-		}),
-	)
+	// Side-by-side cards, or stacked cards for narrow screens.
+	axis, gap := layout.Vertical, layout.Spacer{Height: unit.Dp(16)}
+	cardW := gtx.Constraints.Max.X
+	if wide {
+		axis, gap = layout.Horizontal, layout.Spacer{Width: unit.Dp(24)}
+		cardW = (gtx.Constraints.Max.X - (len(cards)-1)*gtx.Dp(unit.Dp(24))) / len(cards)
+	}
+	var children []layout.FlexChild
+	for i, c := range cards {
+		if i > 0 {
+			children = append(children, layout.Rigid(gap.Layout))
+		}
+		children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return s.modeCard(gtx, cardW, c.icon, c.title, c.description, c.recommended, c.click, c.action)
+		}))
+	}
+	return layout.Flex{Axis: axis, Alignment: layout.Start}.Layout(gtx, children...)
 }
 
-func (s *WizardScreen) modeCard(gtx layout.Context, cardWidthPx int, icon *widget.Icon, title, description string, recommended bool, click *widget.Clickable, actionLabel string) layout.Dimensions {
+const (
+	tokenCardTitle       = "Smart Card or DNIe"
+	tokenCardDescription = "Check that your card reader and its driver (OpenSC or the DNIe middleware) are installed, with step-by-step help if something is missing."
+)
+
+func (s *WizardScreen) modeCard(gtx layout.Context, cardWidthPx int, icon *icons.Icon, title, description string, recommended bool, click *widget.Clickable, actionLabel string) layout.Dimensions {
 	if cardWidthPx > gtx.Constraints.Max.X {
 		cardWidthPx = gtx.Constraints.Max.X
 	}
@@ -507,8 +588,7 @@ func (s *WizardScreen) layoutScanResults(gtx layout.Context) layout.Dimensions {
 						return s.layoutCenteredState(gtx, "Scan failed", s.ScanError, "Back")
 					}
 					if noResults {
-						return s.layoutCenteredState(gtx, "No new certificates found",
-							"No additional certificates were found in browser or system stores.\nTry importing a .p12 file manually.", "Back")
+						return s.layoutNoResults(gtx)
 					}
 					return s.layoutScanResultsList(gtx, systemIDs)
 				}),
@@ -534,7 +614,9 @@ func (s *WizardScreen) layoutScanResults(gtx layout.Context) layout.Dimensions {
 
 func (s *WizardScreen) layoutScanResultsList(gtx layout.Context, systemIDs []pkcs12store.Identity) layout.Dimensions {
 	return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
-		return material.List(s.Theme, &s.ResultsList).Layout(gtx, len(systemIDs), func(gtx layout.Context, index int) layout.Dimensions {
+		key := func(i int) string { return systemIDs[i].ID }
+		return s.ResultsList.Layout(gtx, s.Theme, len(systemIDs), key, func(gtx layout.Context, index int) layout.Dimensions {
+			defer s.App.Perf.Section("wizard/scan_result")()
 			id := systemIDs[index]
 			if _, ok := s.ImportSelects[id.ID]; !ok {
 				s.ImportSelects[id.ID] = &widget.Bool{Value: true}
@@ -554,7 +636,7 @@ func (s *WizardScreen) layoutScanResultsList(gtx layout.Context, systemIDs []pkc
 									}),
 									layout.Rigid(layout.Spacer{Height: unit.Dp(2)}.Layout),
 									layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-										txt := fmt.Sprintf("Issuer: %s  ·  Expires: %s", id.Cert.Issuer.CommonName, id.Cert.NotAfter.Format("2006-01-02"))
+										txt := fmt.Sprintf("Issuer: %s  ·  Expires: %s", id.Cert.Issuer.CommonName, s.App.Locale().Date(id.Cert.NotAfter))
 										l := material.Caption(s.Theme, txt)
 										l.Color = color.NRGBA{R: 0x5F, G: 0x6E, B: 0x84, A: 0xFF}
 										return l.Layout(gtx)
@@ -602,7 +684,11 @@ func (s *WizardScreen) layoutLockedSection(gtx layout.Context, locked []string)
 										return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
 											layout.Rigid(material.Body2(s.Theme, locked[i]).Layout),
 											layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-												l := material.Caption(s.Theme, "Requires a password — import manually using Open Certificate File")
+												hint := "Requires a password — import manually using Open Certificate File"
+												if s.App.Managed.DisableFileImport {
+													hint = "Requires a password. Your administrator has disabled importing certificate files."
+												}
+												l := material.Caption(s.Theme, hint)
 												l.Color = color.NRGBA{R: 0x5F, G: 0x6E, B: 0x84, A: 0xFF}
 												return l.Layout(gtx)
 											}),
@@ -610,7 +696,10 @@ func (s *WizardScreen) layoutLockedSection(gtx layout.Context, locked []string)
 									}),
 									layout.Rigid(layout.Spacer{Width: unit.Dp(12)}.Layout),
 									layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-										btn := widgets.SecondaryButton(s.Theme, &s.LockedOpenFile, "Open File")
+										if s.App.Managed.DisableFileImport {
+											return layout.Dimensions{}
+										}
+										btn := widgets.SecondaryButton(s.Theme, s.LockedOpen.Get(locked[i], nil), "Open File")
 										btn.TextSize = unit.Sp(12)
 										return btn.Layout(gtx)
 									}),
@@ -638,6 +727,143 @@ func (s *WizardScreen) layoutScanActions(gtx layout.Context) layout.Dimensions {
 	)
 }
 
+// layoutTokenPanel renders the hardware token step: detected readers, the
+// middleware found on disk and platform-specific installation hints.
+func (s *WizardScreen) layoutTokenPanel(gtx layout.Context) layout.Dimensions {
+	return layout.Inset{Top: unit.Dp(24), Bottom: unit.Dp(24), Left: unit.Dp(32), Right: unit.Dp(32)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return widgets.ConstrainMaxWidth(gtx, widgets.DefaultPageMaxWidth, func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return s.layoutStepHeading(gtx, icons.IconSmartCard, "Smart Card and DNIe Setup",
+						"VocSign signs with your card through a card reader and a PKCS#11 driver. Missing drivers are the most common reason a DNIe is not found.")
+				}),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(16)}.Layout),
+				layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+					report := s.tokenReport
+					if report == nil {
+						return s.layoutCenteredState(gtx, "Checking card readers and drivers…", "", "")
+					}
+					return material.List(s.Theme, &s.TokenList).Layout(gtx, 1, func(gtx layout.Context, _ int) layout.Dimensions {
+						return s.layoutTokenReport(gtx, *report)
+					})
+				}),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(16)}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					recheck := "Check Again"
+					if s.tokenChecking {
+						recheck = "Checking…"
+					}
+					return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+						layout.Rigid(widgets.PrimaryButton(s.Theme, &s.TokenScan, "Scan for Certificates").Layout),
+						layout.Rigid(layout.Spacer{Width: unit.Dp(12)}.Layout),
+						layout.Rigid(widgets.SecondaryButton(s.Theme, &s.TokenRecheck, recheck).Layout),
+						layout.Rigid(layout.Spacer{Width: unit.Dp(12)}.Layout),
+						layout.Rigid(widgets.SecondaryButton(s.Theme, &s.BackToChoice, "Back").Layout),
+					)
+				}),
+			)
+		})
+	})
+}
+
+func (s *WizardScreen) layoutTokenReport(gtx layout.Context, report systemstore.TokenReport) layout.Dimensions {
+	muted := color.NRGBA{R: 0x5F, G: 0x6E, B: 0x84, A: 0xFF}
+	row := func(label, detail, tag string, tone widgets.BannerTone) layout.FlexChild {
+		return layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Inset{Bottom: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+					layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+						return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+							layout.Rigid(material.Body2(s.Theme, label).Layout),
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								if detail == "" {
+									return layout.Dimensions{}
+								}
+								l := material.Caption(s.Theme, detail)
+								l.Color = muted
+								return l.Layout(gtx)
+							}),
+						)
+					}),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return widgets.StatusTag(gtx, s.Theme, tone, tag)
+					}),
+				)
+			})
+		})
+	}
+
+	status := []layout.FlexChild{
+		layout.Rigid(material.Subtitle2(s.Theme, "Card readers").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+	}
+	switch {
+	case !report.ReaderCheck:
+		status = append(status, row("Readers could not be listed on this system", "", "UNKNOWN", widgets.BannerWarning))
+	case len(report.Readers) == 0:
+		status = append(status, row("No card reader detected", "", "MISSING", widgets.BannerError))
+	default:
+		for _, r := range report.Readers {
+			status = append(status, row(r, "", "CONNECTED", widgets.BannerSuccess))
+		}
+	}
+	if runtime.GOOS == "linux" {
+		if report.ServiceMissing {
+			status = append(status, row("Smart card service (pcscd)", "", "NOT RUNNING", widgets.BannerError))
+		} else {
+			status = append(status, row("Smart card service (pcscd)", "", "RUNNING", widgets.BannerSuccess))
+		}
+	}
+	status = append(status,
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+		layout.Rigid(material.Subtitle2(s.Theme, "Drivers (PKCS#11 middleware)").Layout),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+	)
+	for _, m := range report.Middleware {
+		if m.Installed() {
+			status = append(status, row(m.Name, m.Path, "INSTALLED", widgets.BannerSuccess))
+		} else {
+			status = append(status, row(m.Name, "", "NOT FOUND", widgets.BannerNeutral))
+		}
+	}
+
+	hints := systemstore.TokenSetupHints(runtime.GOOS, report)
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			if !report.Ready() {
+				return layout.Dimensions{}
+			}
+			return layout.Inset{Bottom: unit.Dp(12)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return widgets.Banner(gtx, s.Theme, widgets.BannerSuccess, "Your card reader and driver are ready. Insert the card and scan for certificates.")
+			})
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Vertical}.Layout(gtx, status...)
+			})
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			if len(hints) == 0 {
+				return layout.Dimensions{}
+			}
+			children := []layout.FlexChild{
+				layout.Rigid(material.Subtitle2(s.Theme, "What to do").Layout),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+			}
+			for _, h := range hints {
+				children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return layout.Inset{Bottom: unit.Dp(6)}.Layout(gtx, material.Body2(s.Theme, "• "+h).Layout)
+				}))
+			}
+			return layout.Inset{Top: unit.Dp(12)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
+					return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+				})
+			})
+		}),
+	)
+}
+
 // layoutImportPanel renders the file import step.
 func (s *WizardScreen) layoutImportPanel(gtx layout.Context) layout.Dimensions {
 	return widgets.CenterInAvailable(gtx, func(gtx layout.Context) layout.Dimensions {
@@ -651,8 +877,8 @@ func (s *WizardScreen) layoutImportPanel(gtx layout.Context) layout.Dimensions {
 
 			return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
 				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-					return s.layoutStepHeading(gtx, icons.IconImport, "Import Certificate File",
-						"Select a .p12 or .pfx file and enter its password to add it to VocSign.")
+					return s.layoutStepHeading(gtx, icons.IconImport, "Import Certificate Files",
+						"Select one or more .p12 or .pfx files and enter their passwords to add them to VocSign.")
 				}),
 				layout.Rigid(layout.Spacer{Height: unit.Dp(24)}.Layout),
 				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
@@ -660,7 +886,9 @@ func (s *WizardScreen) layoutImportPanel(gtx layout.Context) layout.Dimensions {
 						return layout.Dimensions{}
 					}
 					tone := widgets.BannerError
-					if strings.Contains(strings.ToLower(s.ConfirmationMsg), "correctly") {
+					if msg := strings.ToLower(s.ConfirmationMsg); strings.Contains(msg, "could not be imported") {
+						tone = widgets.BannerWarning
+					} else if strings.Contains(msg, "correctly") {
 						tone = widgets.BannerSuccess
 					}
 					return layout.Inset{Bottom: unit.Dp(16)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
@@ -671,7 +899,7 @@ func (s *WizardScreen) layoutImportPanel(gtx layout.Context) layout.Dimensions {
 					return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
 						return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
 							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-								l := material.Body2(s.Theme, "Certificate file (.p12 / .pfx)")
+								l := material.Body2(s.Theme, "Certificate files (.p12 / .pfx)")
 								l.Font.Weight = font.Medium
 								return l.Layout(gtx)
 							}),
@@ -679,23 +907,21 @@ func (s *WizardScreen) layoutImportPanel(gtx layout.Context) layout.Dimensions {
 							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 								return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
 									layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-										btn := widgets.SecondaryButton(s.Theme, &s.BrowseButton, "Browse…")
+										btn := widgets.SecondaryButton(s.Theme, &s.BrowseButton, "Add Files…")
 										return btn.Layout(gtx)
 									}),
-									layout.Rigid(layout.Spacer{Width: unit.Dp(12)}.Layout),
-									layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
-										label := s.selectedFile
-										if label == "" {
-											label = "No file selected"
-										}
-										l := material.Body2(s.Theme, label)
-										if label == "No file selected" {
-											l.Color = color.NRGBA{R: 0x9E, G: 0xA3, B: 0xB0, A: 0xFF}
-										}
-										return l.Layout(gtx)
-									}),
 								)
 							}),
+							layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+							layout.Rigid(s.layoutImportFiles),
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								if s.browser == nil {
+									return layout.Dimensions{}
+								}
+								return layout.Inset{Top: unit.Dp(12)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+									return s.browser.Layout(gtx, s.Theme)
+								})
+							}),
 							layout.Rigid(layout.Spacer{Height: unit.Dp(20)}.Layout),
 							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 								l := material.Body2(s.Theme, "Certificate password")
@@ -706,7 +932,14 @@ func (s *WizardScreen) layoutImportPanel(gtx layout.Context) layout.Dimensions {
 							layout.Rigid(material.Editor(s.Theme, &s.PassEditor, "Enter password…").Layout),
 							layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
 							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-								l := material.Caption(s.Theme, "Leave blank if the file has no password.")
+								l := material.Caption(s.Theme, "Used for every file without its own password. Leave blank if the files have no password.")
+								l.Color = color.NRGBA{R: 0x9E, G: 0xA3, B: 0xB0, A: 0xFF}
+								return l.Layout(gtx)
+							}),
+							layout.Rigid(layout.Spacer{Height: unit.Dp(12)}.Layout),
+							layout.Rigid(material.CheckBox(s.Theme, &s.DeferredImport, "Ask for the file's password at every signature").Layout),
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								l := material.Caption(s.Theme, "The files are kept as they are, protected only by their own password, and the private key is decrypted just to sign. Otherwise it is stored encrypted with the wallet's key.")
 								l.Color = color.NRGBA{R: 0x9E, G: 0xA3, B: 0xB0, A: 0xFF}
 								return l.Layout(gtx)
 							}),
@@ -733,7 +966,7 @@ func (s *WizardScreen) layoutImportPanel(gtx layout.Context) layout.Dimensions {
 }
 
 // layoutStepHeading renders a consistent section title used across scan and import steps.
-func (s *WizardScreen) layoutStepHeading(gtx layout.Context, icon *widget.Icon, title, subtitle string) layout.Dimensions {
+func (s *WizardScreen) layoutStepHeading(gtx layout.Context, icon *icons.Icon, title, subtitle string) layout.Dimensions {
 	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
 		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
@@ -764,6 +997,35 @@ func (s *WizardScreen) layoutStepHeading(gtx layout.Context, icon *widget.Icon,
 	)
 }
 
+// layoutNoResults is the empty scan state. Besides going back, it points
+// users who have no certificate yet to the issuance guide.
+func (s *WizardScreen) layoutNoResults(gtx layout.Context) layout.Dimensions {
+	gtx.Constraints.Min.Y = gtx.Constraints.Max.Y
+	message := "No additional certificates were found in browser or system stores.\nTry importing a .p12 file manually."
+	if sb := sandbox.Detect(); sb.Confined() {
+		// The scan only sees the folders the sandbox permissions grant.
+		message = "No additional certificates were found in the folders " + string(sb.Kind) + " lets VocSign read.\nImport the .p12 file through the file chooser, or see About for the permissions to grant."
+	}
+	return widgets.CenterInAvailable(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Vertical, Alignment: layout.Middle}.Layout(gtx,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return widgets.EmptyState(gtx, s.Theme, "No new certificates found", message)
+			}),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.Inset{Top: unit.Dp(16)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+							layout.Rigid(widgets.PrimaryButton(s.Theme, &s.NoCertificateButton, "I Don't Have a Certificate Yet").Layout),
+							layout.Rigid(layout.Spacer{Width: unit.Dp(12)}.Layout),
+							layout.Rigid(widgets.SecondaryButton(s.Theme, &s.BackToChoice, "Back").Layout),
+						)
+					})
+				})
+			}),
+		)
+	})
+}
+
 func (s *WizardScreen) layoutCenteredState(gtx layout.Context, title, subtitle, backLabel string) layout.Dimensions {
 	gtx.Constraints.Min.Y = gtx.Constraints.Max.Y
 	return widgets.CenterInAvailable(gtx, func(gtx layout.Context) layout.Dimensions {
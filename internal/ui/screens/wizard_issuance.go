@@ -0,0 +1,330 @@
+package screens
+
+import (
+	"bytes"
+	"context"
+	"image/color"
+	"log"
+	"time"
+
+	"gioui.org/font"
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/demo"
+	"github.com/vocdoni/gofirma/vocsign/internal/ui/icons"
+	"github.com/vocdoni/gofirma/vocsign/internal/ui/widgets"
+)
+
+// issuancePath is an official way for a citizen to obtain a certificate that
+// VocSign accepts, with the steps the issuer requires.
+type issuancePath struct {
+	Title       string
+	Description string
+	URL         string
+	Steps       []string
+	// TokenSetup offers the smart card setup step instead of a file import.
+	TokenSetup bool
+}
+
+var issuancePaths = []issuancePath{
+	{
+		Title:       "FNMT certificate (Persona Física)",
+		Description: "Free software certificate issued by the Spanish Royal Mint to anyone with a DNI or NIE.",
+		URL:         "https://www.sede.fnmt.gob.es/certificados/persona-fisica",
+		Steps: []string{
+			"Request the certificate on the FNMT website and keep the request code you receive by email.",
+			"Prove your identity at a registration office, by video call, or with your DNIe.",
+			"Download the certificate from the same computer and browser you used for the request.",
+			"Export it as a .p12 file with a password and import it here.",
+		},
+	},
+	{
+		Title:       "idCAT Certificat",
+		Description: "Free certificate from the Catalan administration, issued at many town halls and public offices.",
+		URL:         "https://www.idcat.cat/",
+		Steps: []string{
+			"Request the idCAT Certificat online with your DNI or NIE.",
+			"Confirm your identity at an idCAT registration office.",
+			"Download the certificate and export it as a .p12 file.",
+			"Import the file here.",
+		},
+	},
+	{
+		Title:       "Electronic ID card (DNIe)",
+		Description: "Your DNIe already carries signing certificates. You need a card reader and its driver.",
+		URL:         "https://www.dnielectronico.es/PortalDNIe/",
+		Steps: []string{
+			"Make sure the certificates on your DNIe are active. Renew them at a police station update point if they expired.",
+			"Connect a card reader and install the DNIe or OpenSC driver.",
+			"Scan for certificates with the card inserted.",
+		},
+		TokenSetup: true,
+	},
+}
+
+// rescanReminderOptions are the delays offered after requesting a certificate.
+var rescanReminderOptions = []struct {
+	Label string
+	After time.Duration
+}{
+	{"Tomorrow", 24 * time.Hour},
+	{"In 3 days", 72 * time.Hour},
+	{"In a week", 7 * 24 * time.Hour},
+}
+
+type issuanceGuide struct {
+	open     []widget.Clickable
+	setup    []widget.Clickable
+	checks   [][]widget.Bool
+	remind   []widget.Clickable
+	list     widget.List
+	reminded string
+	failed   bool
+
+	// testCert creates a test certificate in the wallet.
+	testCert     widget.Clickable
+	testCreating bool
+	testErr      string
+}
+
+func (g *issuanceGuide) init() {
+	if g.open != nil {
+		return
+	}
+	g.list.Axis = layout.Vertical
+	g.open = make([]widget.Clickable, len(issuancePaths))
+	g.setup = make([]widget.Clickable, len(issuancePaths))
+	g.checks = make([][]widget.Bool, len(issuancePaths))
+	for i, p := range issuancePaths {
+		g.checks[i] = make([]widget.Bool, len(p.Steps))
+	}
+	g.remind = make([]widget.Clickable, len(rescanReminderOptions))
+}
+
+func (s *WizardScreen) handleGuideActions(gtx layout.Context) {
+	g := &s.guide
+	g.init()
+	for i, p := range issuancePaths {
+		if g.open[i].Clicked(gtx) {
+			widgets.OpenURL(p.URL)
+		}
+		if g.setup[i].Clicked(gtx) {
+			s.Step = StepHardwareToken
+			s.checkToken()
+		}
+	}
+	for i, opt := range rescanReminderOptions {
+		if g.remind[i].Clicked(gtx) {
+			if err := s.App.ScheduleRescanReminder(opt.After); err != nil {
+				log.Printf("WARNING: failed to schedule rescan reminder: %v", err)
+				g.reminded, g.failed = "Could not save the reminder: "+err.Error(), true
+				continue
+			}
+			g.failed = false
+			at, _ := s.App.RescanReminder()
+			g.reminded = "VocSign will remind you to scan again on " + at.Local().Format("Mon 2 Jan 15:04") + "."
+		}
+	}
+	if g.testCert.Clicked(gtx) && !g.testCreating {
+		g.testCreating, g.testErr = true, ""
+		go s.createTestCertificate()
+	}
+	if s.ReminderScan.Clicked(gtx) {
+		s.App.ClearRescanReminder()
+		s.startScan()
+	}
+	if s.ReminderLater.Clicked(gtx) {
+		if err := s.App.ScheduleRescanReminder(24 * time.Hour); err != nil {
+			log.Printf("WARNING: failed to snooze rescan reminder: %v", err)
+		}
+	}
+	if s.ReminderDismiss.Clicked(gtx) {
+		s.App.ClearRescanReminder()
+	}
+}
+
+// createTestCertificate makes a self-signed test certificate and imports it
+// into the wallet, so the app can be explored before an official
+// certificate is issued.
+func (s *WizardScreen) createTestCertificate() {
+	g := &s.guide
+	defer func() {
+		g.testCreating = false
+		s.App.Invalidate()
+	}()
+	tc, err := demo.NewTestCertificate()
+	if err == nil {
+		_, err = s.App.Store.Import(context.Background(), "TEST certificate ("+tc.Name+")", bytes.NewReader(tc.PKCS12), []byte(tc.Password))
+	}
+	if err != nil {
+		log.Printf("WARNING: failed to create test certificate: %v", err)
+		g.testErr = "Could not create the test certificate: " + err.Error()
+		return
+	}
+	s.ConfirmationMsg = "Test certificate for " + tc.Name + " created. It can only sign demo campaigns."
+	s.Step = StepChoice
+}
+
+// layoutReminder is shown on the choice step once a scheduled rescan is due.
+func (s *WizardScreen) layoutReminder(gtx layout.Context) layout.Dimensions {
+	if _, due := s.App.RescanReminder(); !due {
+		return layout.Dimensions{}
+	}
+	return layout.Inset{Bottom: unit.Dp(16)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+					return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+						layout.Rigid(material.Subtitle2(s.Theme, "Has your certificate been issued?").Layout),
+						layout.Rigid(material.Caption(s.Theme, "You asked to be reminded to scan again after requesting a certificate.").Layout),
+					)
+				}),
+				layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+				layout.Rigid(widgets.SecondaryButton(s.Theme, &s.ReminderDismiss, "Dismiss").Layout),
+				layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+				layout.Rigid(widgets.SecondaryButton(s.Theme, &s.ReminderLater, "Tomorrow").Layout),
+				layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+				layout.Rigid(widgets.PrimaryButton(s.Theme, &s.ReminderScan, "Scan Now").Layout),
+			)
+		})
+	})
+}
+
+// layoutIssuanceGuide renders the "I don't have a certificate yet" step:
+// official issuance paths with links, a checklist per path and a reminder
+// to scan again once the certificate is issued.
+func (s *WizardScreen) layoutIssuanceGuide(gtx layout.Context) layout.Dimensions {
+	g := &s.guide
+	muted := color.NRGBA{R: 0x5F, G: 0x6E, B: 0x84, A: 0xFF}
+
+	return layout.Inset{Top: unit.Dp(24), Bottom: unit.Dp(24), Left: unit.Dp(32), Right: unit.Dp(32)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return widgets.ConstrainMaxWidth(gtx, widgets.DefaultPageMaxWidth, func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return s.layoutStepHeading(gtx, icons.IconCertificates, "Get a Digital Certificate",
+						"Signing a popular legislative initiative requires a qualified certificate. These are the official, free ways to get one.")
+				}),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(16)}.Layout),
+				layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+					return material.List(s.Theme, &g.list).Layout(gtx, len(issuancePaths)+2, func(gtx layout.Context, i int) layout.Dimensions {
+						switch i {
+						case len(issuancePaths):
+							return s.layoutReminderChoice(gtx)
+						case len(issuancePaths) + 1:
+							return layout.Inset{Top: unit.Dp(12)}.Layout(gtx, s.layoutTestCertificate)
+						}
+						p := issuancePaths[i]
+						return layout.Inset{Bottom: unit.Dp(12)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+							return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
+								children := []layout.FlexChild{
+									layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+										l := material.Body1(s.Theme, p.Title)
+										l.Font.Weight = font.Bold
+										return l.Layout(gtx)
+									}),
+									layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+										l := material.Body2(s.Theme, p.Description)
+										l.Color = muted
+										return l.Layout(gtx)
+									}),
+									layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+								}
+								for j, step := range p.Steps {
+									check := &g.checks[i][j]
+									children = append(children, layout.Rigid(material.CheckBox(s.Theme, check, step).Layout))
+								}
+								children = append(children,
+									layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+									layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+										return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+											layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+												return widgets.SecondaryButton(s.Theme, &g.open[i], "Open official website").Layout(gtx)
+											}),
+											layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+												if !p.TokenSetup {
+													return layout.Dimensions{}
+												}
+												return layout.Inset{Left: unit.Dp(12)}.Layout(gtx, widgets.SecondaryButton(s.Theme, &g.setup[i], "Set Up Card Reader").Layout)
+											}),
+										)
+									}),
+								)
+								return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+							})
+						})
+					})
+				}),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(16)}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+						layout.Rigid(widgets.PrimaryButton(s.Theme, &s.TokenScan, "Scan for Certificates").Layout),
+						layout.Rigid(layout.Spacer{Width: unit.Dp(12)}.Layout),
+						layout.Rigid(widgets.SecondaryButton(s.Theme, &s.BackToChoice, "Back").Layout),
+					)
+				}),
+			)
+		})
+	})
+}
+
+func (s *WizardScreen) layoutReminderChoice(gtx layout.Context) layout.Dimensions {
+	g := &s.guide
+	return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
+		buttons := []layout.FlexChild{}
+		for i, opt := range rescanReminderOptions {
+			if i > 0 {
+				buttons = append(buttons, layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout))
+			}
+			buttons = append(buttons, layout.Rigid(widgets.SecondaryButton(s.Theme, &g.remind[i], opt.Label).Layout))
+		}
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(material.Subtitle2(s.Theme, "Remind me to scan again").Layout),
+			layout.Rigid(material.Body2(s.Theme, "Issuance can take from a few hours to several days. VocSign will ask you to scan for the new certificate when you next open it after this time.").Layout),
+			layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx, buttons...)
+			}),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				if g.reminded == "" {
+					return layout.Dimensions{}
+				}
+				return layout.Inset{Top: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					tone := widgets.BannerSuccess
+					if g.failed {
+						tone = widgets.BannerError
+					}
+					return widgets.Banner(gtx, s.Theme, tone, g.reminded)
+				})
+			}),
+		)
+	})
+}
+
+// layoutTestCertificate offers a test certificate to explore the app with
+// while the official one is being issued.
+func (s *WizardScreen) layoutTestCertificate(gtx layout.Context) layout.Dimensions {
+	g := &s.guide
+	return widgets.Section(gtx, widgets.ColorSurface, func(gtx layout.Context) layout.Dimensions {
+		label := "Create a Test Certificate"
+		if g.testCreating {
+			label = "Creating..."
+		}
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(material.Subtitle2(s.Theme, "Just exploring?").Layout),
+			layout.Rigid(material.Body2(s.Theme, "Create a test certificate for a made-up citizen to try VocSign now. It is kept in your wallet marked TEST and can only sign demo campaigns, never a real initiative.").Layout),
+			layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+			layout.Rigid(widgets.SecondaryButton(s.Theme, &g.testCert, label).Layout),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				if g.testErr == "" {
+					return layout.Dimensions{}
+				}
+				return layout.Inset{Top: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return widgets.Banner(gtx, s.Theme, widgets.BannerError, g.testErr)
+				})
+			}),
+		)
+	})
+}
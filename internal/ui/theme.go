@@ -3,8 +3,14 @@ package ui
 import (
 	"image/color"
 
+	"gioui.org/io/system"
+	"gioui.org/layout"
 	"gioui.org/unit"
 	"gioui.org/widget/material"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/locale"
+	"github.com/vocdoni/gofirma/vocsign/internal/settings"
+	"github.com/vocdoni/gofirma/vocsign/internal/ui/widgets"
 )
 
 func NewTheme() *material.Theme {
@@ -19,3 +25,22 @@ func NewTheme() *material.Theme {
 
 	return th
 }
+
+// applyPalette sets the status colors chosen in st. It runs before each
+// frame, so a change in Settings shows at once.
+func applyPalette(st settings.Settings) {
+	widgets.UsePalette(st.ColorblindPalette())
+}
+
+// applyLocale lays text out in the direction of the locale chosen in st.
+// Without one, the system's locale is kept.
+func applyLocale(gtx *layout.Context, st settings.Settings) {
+	if st.Locale == "" {
+		return
+	}
+	l := locale.Get(st.Locale)
+	gtx.Locale = system.Locale{Language: l.Tag, Direction: system.LTR}
+	if l.RTL() {
+		gtx.Locale.Direction = system.RTL
+	}
+}
@@ -0,0 +1,63 @@
+package widgets
+
+import "container/list"
+
+// Cache holds the widget state of list rows, such as a row's button or
+// read-only editor, keyed by what the row shows. Only the rows laid out
+// recently are kept: beyond its capacity the least recently used entry is
+// dropped, and a row scrolled back into view starts with fresh state. The
+// capacity must exceed the rows visible at once.
+type Cache[T any] struct {
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // most recently used first
+}
+
+type cacheEntry[T any] struct {
+	key   string
+	value *T
+}
+
+// NewCache returns a cache of at most capacity entries.
+func NewCache[T any](capacity int) *Cache[T] {
+	return &Cache[T]{
+		capacity: max(capacity, 1),
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get returns the state for key, creating it with init, which may be nil,
+// if there is none, and marks it as recently used.
+func (c *Cache[T]) Get(key string, init func(*T)) *T {
+	if el, ok := c.entries[key]; ok {
+		c.order.MoveToFront(el)
+		return el.Value.(*cacheEntry[T]).value
+	}
+	v := new(T)
+	if init != nil {
+		init(v)
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry[T]{key: key, value: v})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry[T]).key)
+	}
+	return v
+}
+
+// Peek returns the state for key without creating it or marking it as
+// used, e.g. to check a button for clicks before the rows are laid out.
+func (c *Cache[T]) Peek(key string) (*T, bool) {
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	return el.Value.(*cacheEntry[T]).value, true
+}
+
+// Len returns the number of entries.
+func (c *Cache[T]) Len() int {
+	return c.order.Len()
+}
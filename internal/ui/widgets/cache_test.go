@@ -0,0 +1,40 @@
+package widgets
+
+import (
+	"testing"
+
+	"gioui.org/widget"
+)
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewCache[widget.Editor](2)
+	a := c.Get("a", func(e *widget.Editor) { e.SetText("a") })
+	c.Get("b", nil)
+	if again := c.Get("a", nil); again != a || again.Text() != "a" {
+		t.Fatal("Get did not return the cached state")
+	}
+	c.Get("c", nil)
+	if c.Len() != 2 {
+		t.Fatalf("Len = %d", c.Len())
+	}
+	if _, ok := c.Peek("b"); ok {
+		t.Fatal("least recently used entry was kept")
+	}
+	if got, ok := c.Peek("a"); !ok || got != a {
+		t.Fatal("recently used entry was evicted")
+	}
+}
+
+func TestCachePeekDoesNotCreateOrPromote(t *testing.T) {
+	c := NewCache[widget.Clickable](2)
+	if _, ok := c.Peek("a"); ok || c.Len() != 0 {
+		t.Fatal("Peek created an entry")
+	}
+	c.Get("a", nil)
+	c.Get("b", nil)
+	c.Peek("a")
+	c.Get("c", nil)
+	if _, ok := c.Peek("a"); ok {
+		t.Fatal("Peek marked the entry as used")
+	}
+}
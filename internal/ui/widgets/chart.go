@@ -0,0 +1,35 @@
+package widgets
+
+import (
+	"image"
+	"image/color"
+
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+)
+
+// Histogram draws counts as columns across the available width, the
+// largest one height tall.
+func Histogram(gtx layout.Context, clr color.NRGBA, counts []int, height unit.Dp) layout.Dimensions {
+	size := image.Point{X: gtx.Constraints.Max.X, Y: gtx.Dp(height)}
+	most := 0
+	for _, n := range counts {
+		most = max(most, n)
+	}
+	if most == 0 {
+		return layout.Dimensions{Size: size}
+	}
+	width := size.X / len(counts)
+	gap := min(gtx.Dp(2), width/4)
+	for i, n := range counts {
+		h := n * size.Y / most
+		if n > 0 {
+			h = max(h, gtx.Dp(1))
+		}
+		x := i * width
+		paint.FillShape(gtx.Ops, clr, clip.Rect(image.Rect(x, size.Y-h, x+width-gap, size.Y)).Op())
+	}
+	return layout.Dimensions{Size: size}
+}
@@ -10,4 +10,54 @@ var (
 	ColorWarning = color.NRGBA{R: 0xED, G: 0x6C, B: 0x02, A: 0xFF} // Orange 800
 	ColorSurface = color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
 	ColorBorder  = color.NRGBA{R: 0xDA, G: 0xDE, B: 0xE0, A: 0xFF}
+	// ColorMuted is for secondary text and statuses that are neither good
+	// nor bad.
+	ColorMuted = color.NRGBA{R: 0x5F, G: 0x6E, B: 0x84, A: 0xFF}
 )
+
+// Banner backgrounds of each tone, tinted to match the status colors.
+var (
+	bgInfo    = color.NRGBA{R: 0xEE, G: 0xF3, B: 0xFF, A: 0xFF}
+	bgSuccess = color.NRGBA{R: 0xE8, G: 0xF5, B: 0xE9, A: 0xFF}
+	bgWarning = color.NRGBA{R: 0xFF, G: 0xF4, B: 0xE5, A: 0xFF}
+	bgError   = color.NRGBA{R: 0xFD, G: 0xEA, B: 0xEA, A: 0xFF}
+	bgNeutral = color.NRGBA{R: 0xF1, G: 0xF3, B: 0xF6, A: 0xFF}
+)
+
+// palette is a set of status colors and the banner backgrounds that go with
+// them.
+type palette struct {
+	success, warning, err       color.NRGBA
+	bgSuccess, bgWarning, bgErr color.NRGBA
+}
+
+var (
+	standardPalette = palette{
+		success: ColorSuccess, warning: ColorWarning, err: ColorError,
+		bgSuccess: bgSuccess, bgWarning: bgWarning, bgErr: bgError,
+	}
+	// colorblindPalette avoids telling statuses apart by red and green
+	// alone: success is blue, warnings are dark amber and errors are
+	// purple, from the Okabe-Ito and Tol palettes, all dark enough to read
+	// as text on white.
+	colorblindPalette = palette{
+		success:   color.NRGBA{R: 0x00, G: 0x5A, B: 0x9C, A: 0xFF},
+		warning:   color.NRGBA{R: 0x8F, G: 0x5B, B: 0x00, A: 0xFF},
+		err:       color.NRGBA{R: 0x88, G: 0x22, B: 0x55, A: 0xFF},
+		bgSuccess: color.NRGBA{R: 0xE3, G: 0xF0, B: 0xFA, A: 0xFF},
+		bgWarning: color.NRGBA{R: 0xFD, G: 0xF3, B: 0xD9, A: 0xFF},
+		bgErr:     color.NRGBA{R: 0xF6, G: 0xE6, B: 0xEF, A: 0xFF},
+	}
+)
+
+// UsePalette switches the status colors to the colorblind-safe palette, or
+// back to the standard one. It must be called from the UI goroutine, before
+// laying out a frame.
+func UsePalette(colorblind bool) {
+	p := standardPalette
+	if colorblind {
+		p = colorblindPalette
+	}
+	ColorSuccess, ColorWarning, ColorError = p.success, p.warning, p.err
+	bgSuccess, bgWarning, bgError = p.bgSuccess, p.bgWarning, p.bgErr
+}
@@ -0,0 +1,38 @@
+package widgets
+
+import "testing"
+
+func TestUsePalette(t *testing.T) {
+	t.Cleanup(func() { UsePalette(false) })
+
+	UsePalette(true)
+	if ColorSuccess != colorblindPalette.success || ColorWarning != colorblindPalette.warning || ColorError != colorblindPalette.err {
+		t.Fatal("colorblind palette not applied")
+	}
+	if toneBackground(BannerError) != colorblindPalette.bgErr {
+		t.Error("banner background not switched")
+	}
+	UsePalette(false)
+	if ColorSuccess != standardPalette.success || ColorError != standardPalette.err || bgWarning != standardPalette.bgWarning {
+		t.Fatal("standard palette not restored")
+	}
+}
+
+func TestToneIcons(t *testing.T) {
+	// Every tone that judges a status has its own shape, so it does not
+	// rely on color alone.
+	seen := map[any]BannerTone{}
+	for _, tone := range []BannerTone{BannerInfo, BannerSuccess, BannerWarning, BannerError} {
+		icon := ToneIcon(tone)
+		if icon == nil {
+			t.Fatalf("tone %d has no icon", tone)
+		}
+		if prev, ok := seen[icon]; ok {
+			t.Errorf("tones %d and %d share an icon", prev, tone)
+		}
+		seen[icon] = tone
+	}
+	if ToneIcon(BannerNeutral) != nil {
+		t.Error("neutral tone has an icon")
+	}
+}
@@ -0,0 +1,221 @@
+package widgets
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/sandbox"
+)
+
+// FileBrowser is a minimal built-in file chooser: a path editor and a
+// listing of the current folder. It stands in for the native file dialog
+// where the platform has none or it fails to open.
+type FileBrowser struct {
+	PathEditor   widget.Editor
+	UpButton     widget.Clickable
+	OpenButton   widget.Clickable
+	CancelButton widget.Clickable
+
+	extensions []string
+	dir        string
+	entries    []fileEntry
+	list       widget.List
+	err        string
+}
+
+type fileEntry struct {
+	name  string
+	isDir bool
+	click widget.Clickable
+}
+
+// NewFileBrowser returns a browser that starts in the user's home folder and
+// lists folders and the files with one of extensions (e.g. ".p12"), or all
+// files if none are given.
+func NewFileBrowser(extensions ...string) *FileBrowser {
+	b := &FileBrowser{extensions: extensions}
+	b.PathEditor.SingleLine = true
+	b.PathEditor.Submit = true
+	b.list.Axis = layout.Vertical
+	// Inside a sandbox the home folder may not be readable; the sandbox's
+	// own home is.
+	own, _ := os.UserHomeDir()
+	for _, dir := range []string{sandbox.HomeDir(), own, string(filepath.Separator)} {
+		if dir == "" {
+			continue
+		}
+		if b.setDir(dir); b.dir != "" {
+			break
+		}
+	}
+	return b
+}
+
+func (b *FileBrowser) setDir(dir string) {
+	listing, err := os.ReadDir(dir)
+	if err != nil {
+		b.err = "Cannot open folder: " + err.Error()
+		return
+	}
+	b.dir = filepath.Clean(dir)
+	b.err = ""
+	b.PathEditor.SetText(b.dir)
+	b.entries = b.entries[:0]
+	for _, e := range listing {
+		name := e.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		isDir := e.IsDir()
+		if !isDir {
+			// Follow symlinks so linked folders can be entered.
+			if info, err := os.Stat(filepath.Join(b.dir, name)); err == nil {
+				isDir = info.IsDir()
+			}
+		}
+		if !isDir && !b.matches(name) {
+			continue
+		}
+		b.entries = append(b.entries, fileEntry{name: name, isDir: isDir})
+	}
+	sort.SliceStable(b.entries, func(i, j int) bool {
+		if b.entries[i].isDir != b.entries[j].isDir {
+			return b.entries[i].isDir
+		}
+		return strings.ToLower(b.entries[i].name) < strings.ToLower(b.entries[j].name)
+	})
+	b.list.Position = layout.Position{}
+}
+
+func (b *FileBrowser) matches(name string) bool {
+	if len(b.extensions) == 0 {
+		return true
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, want := range b.extensions {
+		if ext == strings.ToLower(want) {
+			return true
+		}
+	}
+	return false
+}
+
+// Update handles input. It returns the path of the file the user opened,
+// or canceled when they closed the browser.
+func (b *FileBrowser) Update(gtx layout.Context) (path string, canceled bool) {
+	if b.CancelButton.Clicked(gtx) {
+		return "", true
+	}
+	if b.UpButton.Clicked(gtx) {
+		b.setDir(filepath.Dir(b.dir))
+	}
+	for i := range b.entries {
+		e := &b.entries[i]
+		if !e.click.Clicked(gtx) {
+			continue
+		}
+		full := filepath.Join(b.dir, e.name)
+		if e.isDir {
+			b.setDir(full)
+			break
+		}
+		b.PathEditor.SetText(full)
+	}
+	submit := b.OpenButton.Clicked(gtx)
+	for {
+		ev, ok := b.PathEditor.Update(gtx)
+		if !ok {
+			break
+		}
+		if _, ok := ev.(widget.SubmitEvent); ok {
+			submit = true
+		}
+	}
+	if submit {
+		return b.open(strings.TrimSpace(b.PathEditor.Text())), false
+	}
+	return "", false
+}
+
+// open enters p if it is a folder and returns it if it is a file.
+func (b *FileBrowser) open(p string) string {
+	if p == "" {
+		return ""
+	}
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(b.dir, p)
+	}
+	info, err := os.Stat(p)
+	if err != nil {
+		b.err = "Not found: " + p
+		return ""
+	}
+	if info.IsDir() {
+		b.setDir(p)
+		return ""
+	}
+	return p
+}
+
+func (b *FileBrowser) Layout(gtx layout.Context, th *material.Theme) layout.Dimensions {
+	return Border(gtx, ColorBorder, func(gtx layout.Context) layout.Dimensions {
+		return layout.UniformInset(unit.Dp(10)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+				layout.Rigid(material.Caption(th, "The system file dialog is not available. Type a path or pick a file below.").Layout),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+						layout.Rigid(SecondaryButton(th, &b.UpButton, "Up").Layout),
+						layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+						layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+							return Border(gtx, ColorBorder, func(gtx layout.Context) layout.Dimensions {
+								return layout.UniformInset(unit.Dp(6)).Layout(gtx, material.Editor(th, &b.PathEditor, "Path").Layout)
+							})
+						}),
+					)
+				}),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					gtx.Constraints.Max.Y = gtx.Dp(240)
+					if len(b.entries) == 0 {
+						return material.Body2(th, "No matching files in this folder").Layout(gtx)
+					}
+					return material.List(th, &b.list).Layout(gtx, len(b.entries), func(gtx layout.Context, i int) layout.Dimensions {
+						e := &b.entries[i]
+						name := e.name
+						if e.isDir {
+							name += string(filepath.Separator)
+						}
+						return material.Clickable(gtx, &e.click, func(gtx layout.Context) layout.Dimensions {
+							gtx.Constraints.Min.X = gtx.Constraints.Max.X
+							return layout.UniformInset(unit.Dp(4)).Layout(gtx, material.Body2(th, name).Layout)
+						})
+					})
+				}),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					if b.err == "" {
+						return layout.Dimensions{}
+					}
+					l := material.Caption(th, b.err)
+					l.Color = ColorError
+					return layout.Inset{Top: unit.Dp(6)}.Layout(gtx, l.Layout)
+				}),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+						layout.Rigid(SecondaryButton(th, &b.CancelButton, "Cancel").Layout),
+						layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+						layout.Rigid(PrimaryButton(th, &b.OpenButton, "Open").Layout),
+					)
+				}),
+			)
+		})
+	})
+}
@@ -0,0 +1,57 @@
+package widgets
+
+import (
+	"image/color"
+	"sort"
+
+	"gioui.org/font"
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+	"gioui.org/x/styledtext"
+)
+
+// highlightSpan is a run of text, highlighted or not.
+type highlightSpan struct {
+	text string
+	hit  bool
+}
+
+// highlightSpans splits text at the byte ranges to highlight. Ranges may
+// overlap, come in any order and run past the end of text.
+func highlightSpans(text string, ranges [][2]int) []highlightSpan {
+	rs := append([][2]int(nil), ranges...)
+	sort.Slice(rs, func(i, j int) bool { return rs[i][0] < rs[j][0] })
+	var spans []highlightSpan
+	pos := 0
+	for _, r := range rs {
+		start, end := max(r[0], pos), min(r[1], len(text))
+		if start >= end {
+			continue
+		}
+		if start > pos {
+			spans = append(spans, highlightSpan{text: text[pos:start]})
+		}
+		spans = append(spans, highlightSpan{text: text[start:end], hit: true})
+		pos = end
+	}
+	if pos < len(text) || len(spans) == 0 {
+		spans = append(spans, highlightSpan{text: text[pos:]})
+	}
+	return spans
+}
+
+// HighlightLabel lays out text in clr with the byte ranges given in bold
+// and the theme's accent color, e.g. the matches of a search.
+func HighlightLabel(gtx layout.Context, th *material.Theme, size unit.Sp, clr color.NRGBA, text string, ranges [][2]int) layout.Dimensions {
+	var styles []styledtext.SpanStyle
+	for _, sp := range highlightSpans(text, ranges) {
+		style := styledtext.SpanStyle{Content: sp.text, Size: size, Color: clr, Font: font.Font{Typeface: th.Face}}
+		if sp.hit {
+			style.Color = th.ContrastBg
+			style.Font.Weight = font.Bold
+		}
+		styles = append(styles, style)
+	}
+	return styledtext.Text(th.Shaper, styles...).Layout(gtx, nil)
+}
@@ -0,0 +1,26 @@
+package widgets
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHighlightSpans(t *testing.T) {
+	tests := []struct {
+		text   string
+		ranges [][2]int
+		want   []highlightSpan
+	}{
+		{"abc", nil, []highlightSpan{{text: "abc"}}},
+		{"abcdef", [][2]int{{2, 4}}, []highlightSpan{{text: "ab"}, {text: "cd", hit: true}, {text: "ef"}}},
+		{"abcdef", [][2]int{{0, 6}}, []highlightSpan{{text: "abcdef", hit: true}}},
+		// Unsorted, overlapping and out of bounds ranges.
+		{"abcdef", [][2]int{{4, 9}, {1, 3}, {2, 5}}, []highlightSpan{{text: "a"}, {text: "bc", hit: true}, {text: "de", hit: true}, {text: "f", hit: true}}},
+		{"", [][2]int{{0, 2}}, []highlightSpan{{text: ""}}},
+	}
+	for _, tt := range tests {
+		if got := highlightSpans(tt.text, tt.ranges); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("highlightSpans(%q, %v) = %+v, want %+v", tt.text, tt.ranges, got, tt.want)
+		}
+	}
+}
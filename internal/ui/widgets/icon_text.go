@@ -6,11 +6,12 @@ import (
 
 	"gioui.org/layout"
 	"gioui.org/unit"
-	"gioui.org/widget"
 	"gioui.org/widget/material"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/ui/icons"
 )
 
-func IconText(th *material.Theme, icon *widget.Icon, text string, iconColor color.NRGBA) layout.Widget {
+func IconText(th *material.Theme, icon *icons.Icon, text string, iconColor color.NRGBA) layout.Widget {
 	return func(gtx layout.Context) layout.Dimensions {
 		return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
 			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
@@ -30,7 +31,7 @@ func IconText(th *material.Theme, icon *widget.Icon, text string, iconColor colo
 }
 
 // IconLabel renders an icon followed by a label
-func IconLabel(gtx layout.Context, th *material.Theme, icon *widget.Icon, text string, clr color.NRGBA, size unit.Sp) layout.Dimensions {
+func IconLabel(gtx layout.Context, th *material.Theme, icon *icons.Icon, text string, clr color.NRGBA, size unit.Sp) layout.Dimensions {
 	return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
 		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 			if icon == nil { return layout.Dimensions{} }
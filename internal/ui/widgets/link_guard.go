@@ -0,0 +1,135 @@
+package widgets
+
+import (
+	"log"
+	"net"
+	"net/url"
+	"strings"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/idn"
+)
+
+// LinkGuard opens links that come from a request, which whoever wrote the
+// request controls. Only http and https links are opened, and a link to a
+// server the user has not opened links to before waits for them to confirm
+// it in Layout.
+type LinkGuard struct {
+	// Known reports whether the user already opened links to host, given
+	// in ASCII form; Remember records that they did.
+	Known    func(host string) bool
+	Remember func(host string) error
+
+	pending *url.URL
+	refused string
+	open    widget.Clickable
+	cancel  widget.Clickable
+}
+
+// Open opens rawURL if its server is known, or asks the user to confirm
+// it. A link that ExternalURL refuses is not opened, and the reason is
+// shown instead.
+func (g *LinkGuard) Open(rawURL string) {
+	u, err := ExternalURL(rawURL)
+	if err != nil {
+		log.Printf("WARNING: refusing to open request link %q: %v", rawURL, err)
+		g.pending = nil
+		g.refused = "This link was not opened: " + err.Error() + "."
+		return
+	}
+	g.refused = ""
+	u.Host = linkHost(u)
+	if g.Known != nil && g.Known(u.Hostname()) {
+		g.pending = nil
+		OpenURL(u.String())
+		return
+	}
+	g.pending = u
+}
+
+// Pending returns the link waiting for confirmation, if any.
+func (g *LinkGuard) Pending() string {
+	if g.pending == nil {
+		return ""
+	}
+	return g.pending.String()
+}
+
+// confirm opens the pending link and remembers its server.
+func (g *LinkGuard) confirm() {
+	u := g.pending
+	g.pending = nil
+	if g.Remember != nil {
+		if err := g.Remember(u.Hostname()); err != nil {
+			log.Printf("WARNING: failed to remember link host: %v", err)
+		}
+	}
+	OpenURL(u.String())
+}
+
+// Layout shows the confirmation for a pending link, or why the last link
+// was refused.
+func (g *LinkGuard) Layout(gtx layout.Context, th *material.Theme) layout.Dimensions {
+	if g.open.Clicked(gtx) && g.pending != nil {
+		g.confirm()
+	}
+	if g.cancel.Clicked(gtx) {
+		g.pending = nil
+	}
+	if g.refused != "" && g.pending == nil {
+		return layout.Inset{Top: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return Banner(gtx, th, BannerError, g.refused)
+		})
+	}
+	if g.pending == nil {
+		return layout.Dimensions{}
+	}
+	host := idn.Inspect(g.pending.Hostname())
+	text := "Open a link to " + host.Display() + "? The request provides it and you have not opened links to this server before."
+	if host.Suspicious() {
+		text += " " + host.Warning() + "."
+	}
+	return layout.Inset{Top: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return Border(gtx, ColorWarning, func(gtx layout.Context) layout.Dimensions {
+			return layout.UniformInset(unit.Dp(8)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+					layout.Rigid(material.Body2(th, text).Layout),
+					layout.Rigid(material.Caption(th, g.pending.String()).Layout),
+					layout.Rigid(layout.Spacer{Height: unit.Dp(6)}.Layout),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								btn := PrimaryButton(th, &g.open, "Open Link")
+								btn.TextSize = unit.Sp(12)
+								return btn.Layout(gtx)
+							}),
+							layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								btn := SecondaryButton(th, &g.cancel, "Cancel")
+								btn.TextSize = unit.Sp(12)
+								return btn.Layout(gtx)
+							}),
+						)
+					}),
+				)
+			})
+		})
+	})
+}
+
+// linkHost is the host of u, with its port, in ASCII form, so that an
+// internationalized name is neither percent-encoded nor remembered twice.
+func linkHost(u *url.URL) string {
+	host := idn.Inspect(u.Hostname()).ASCII
+	if port := u.Port(); port != "" {
+		return net.JoinHostPort(host, port)
+	}
+	if strings.Contains(host, ":") {
+		return "[" + host + "]"
+	}
+	return host
+}
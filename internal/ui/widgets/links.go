@@ -1,22 +1,67 @@
 package widgets
 
 import (
+	"errors"
 	"fmt"
+	"log"
+	"net/url"
 	"os/exec"
 	"runtime"
 )
 
-func OpenURL(url string) {
-	var err error
+// launch hands target to the system's default handler. Tests replace it.
+var launch = func(target string) error {
 	switch runtime.GOOS {
 	case "linux":
-		err = exec.Command("xdg-open", url).Start()
+		return exec.Command("xdg-open", target).Start()
 	case "windows":
-		err = exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", target).Start()
 	case "darwin":
-		err = exec.Command("open", url).Start()
+		return exec.Command("open", target).Start()
 	}
+	return nil
+}
+
+// ExternalURL checks that rawURL may be handed to the system browser: an
+// absolute http or https URL with a host. Other schemes, such as file:// or
+// ones registered by other applications, would let a request open local
+// files or launch programs. User info is refused as well, since
+// https://vocdoni.io@example.com reads as a link to vocdoni.io.
+func ExternalURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
 	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("only http and https links can be opened, not %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, errors.New("link has no host")
+	}
+	if u.User != nil {
+		return nil, errors.New("link has user info before its host")
+	}
+	return u, nil
+}
+
+// OpenURL opens an http or https URL in the system browser. Any other URL
+// is refused and logged; links that come from a request should go through
+// a LinkGuard instead.
+func OpenURL(rawURL string) {
+	u, err := ExternalURL(rawURL)
+	if err != nil {
+		log.Printf("WARNING: refusing to open %q: %v", rawURL, err)
+		return
+	}
+	if err := launch(u.String()); err != nil {
 		fmt.Printf("DEBUG: Failed to open URL: %v\n", err)
 	}
 }
+
+// OpenFile opens a file the application wrote itself, such as a paper
+// sheet or a verified policy document, with its default viewer.
+func OpenFile(path string) {
+	if err := launch(path); err != nil {
+		fmt.Printf("DEBUG: Failed to open file: %v\n", err)
+	}
+}
@@ -0,0 +1,70 @@
+package widgets
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExternalURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr string
+	}{
+		{url: "https://vocdoni.io/text.pdf"},
+		{url: "HTTP://localhost:8080/x"},
+		{url: "file:///etc/passwd", wantErr: `not "file"`},
+		{url: "ms-settings:privacy", wantErr: `not "ms-settings"`},
+		{url: "javascript:alert(1)", wantErr: `not "javascript"`},
+		{url: "/relative/path", wantErr: `not ""`},
+		{url: "https:///no-host", wantErr: "no host"},
+		{url: "https://vocdoni.io@example.com/", wantErr: "user info"},
+	}
+	for _, tt := range tests {
+		_, err := ExternalURL(tt.url)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("ExternalURL(%q): %v", tt.url, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("ExternalURL(%q) = %v, want error containing %q", tt.url, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLinkGuard(t *testing.T) {
+	var launched []string
+	orig := launch
+	t.Cleanup(func() { launch = orig })
+	launch = func(target string) error {
+		launched = append(launched, target)
+		return nil
+	}
+
+	known := map[string]bool{"vocdoni.io": true}
+	g := &LinkGuard{
+		Known:    func(host string) bool { return known[host] },
+		Remember: func(host string) error { known[host] = true; return nil },
+	}
+
+	g.Open("https://vocdoni.io/text.pdf")
+	if len(launched) != 1 || g.Pending() != "" {
+		t.Fatalf("known host: launched %q, pending %q", launched, g.Pending())
+	}
+
+	g.Open("https://bücher.de/annex.pdf")
+	if len(launched) != 1 || g.Pending() != "https://xn--bcher-kva.de/annex.pdf" {
+		t.Fatalf("new host: launched %q, pending %q", launched, g.Pending())
+	}
+	g.confirm()
+	if len(launched) != 2 || !known["xn--bcher-kva.de"] {
+		t.Fatalf("confirmed: launched %q, known %v", launched, known)
+	}
+	g.Open("https://BÜCHER.de/other.pdf")
+	if len(launched) != 3 || g.Pending() != "" {
+		t.Fatalf("remembered host: launched %q, pending %q", launched, g.Pending())
+	}
+
+	g.Open("file:///etc/passwd")
+	if len(launched) != 3 || g.Pending() != "" || !strings.Contains(g.refused, "not opened") {
+		t.Fatalf("file link: launched %q, pending %q, refused %q", launched, g.Pending(), g.refused)
+	}
+}
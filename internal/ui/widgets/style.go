@@ -1,6 +1,7 @@
 package widgets
 
 import (
+	"image"
 	"image/color"
 
 	"gioui.org/font"
@@ -8,12 +9,16 @@ import (
 	"gioui.org/unit"
 	"gioui.org/widget"
 	"gioui.org/widget/material"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/ui/icons"
 )
 
 const (
 	DefaultPageMaxWidth = unit.Dp(1180)
 )
 
+// BannerTone is the kind of status a banner or tag conveys. Each tone has an
+// icon as well as a color, so status never depends on color alone.
 type BannerTone int
 
 const (
@@ -21,8 +26,55 @@ const (
 	BannerSuccess
 	BannerWarning
 	BannerError
+	// BannerNeutral is for statuses that are neither good nor bad, such as
+	// pending; it has no icon.
+	BannerNeutral
 )
 
+// ToneColor returns the foreground color of tone in the current palette.
+func ToneColor(tone BannerTone) color.NRGBA {
+	switch tone {
+	case BannerSuccess:
+		return ColorSuccess
+	case BannerWarning:
+		return ColorWarning
+	case BannerError:
+		return ColorError
+	case BannerNeutral:
+		return ColorMuted
+	}
+	return color.NRGBA{R: 0x1E, G: 0x40, B: 0xAF, A: 0xFF}
+}
+
+// ToneIcon returns the icon shape of tone, nil for BannerNeutral.
+func ToneIcon(tone BannerTone) *icons.Icon {
+	switch tone {
+	case BannerSuccess:
+		return icons.IconCheck
+	case BannerWarning:
+		return icons.IconWarning
+	case BannerError:
+		return icons.IconError
+	case BannerNeutral:
+		return nil
+	}
+	return icons.IconAbout
+}
+
+func toneBackground(tone BannerTone) color.NRGBA {
+	switch tone {
+	case BannerSuccess:
+		return bgSuccess
+	case BannerWarning:
+		return bgWarning
+	case BannerError:
+		return bgError
+	case BannerNeutral:
+		return bgNeutral
+	}
+	return bgInfo
+}
+
 func ConstrainMaxWidth(gtx layout.Context, max unit.Dp, w layout.Widget) layout.Dimensions {
 	return layout.N.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 		maxPx := gtx.Dp(max)
@@ -55,26 +107,28 @@ func Banner(gtx layout.Context, th *material.Theme, tone BannerTone, text string
 	if text == "" {
 		return layout.Dimensions{}
 	}
-	var (
-		bg = color.NRGBA{R: 0xEE, G: 0xF3, B: 0xFF, A: 0xFF}
-		fg = color.NRGBA{R: 0x1E, G: 0x40, B: 0xAF, A: 0xFF}
-	)
-	switch tone {
-	case BannerSuccess:
-		bg = color.NRGBA{R: 0xE8, G: 0xF5, B: 0xE9, A: 0xFF}
-		fg = ColorSuccess
-	case BannerWarning:
-		bg = color.NRGBA{R: 0xFF, G: 0xF4, B: 0xE5, A: 0xFF}
-		fg = ColorWarning
-	case BannerError:
-		bg = color.NRGBA{R: 0xFD, G: 0xEA, B: 0xEA, A: 0xFF}
-		fg = ColorError
-	}
+	fg := ToneColor(tone)
+	icon := ToneIcon(tone)
 	return Border(gtx, fg, func(gtx layout.Context) layout.Dimensions {
-		return CustomCard(gtx, bg, unit.Dp(10), func(gtx layout.Context) layout.Dimensions {
-			l := material.Body2(th, text)
-			l.Color = fg
-			return l.Layout(gtx)
+		return CustomCard(gtx, toneBackground(tone), unit.Dp(10), func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					if icon == nil {
+						return layout.Dimensions{}
+					}
+					return layout.Inset{Right: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						sz := gtx.Dp(unit.Dp(18))
+						gtx.Constraints.Min = image.Pt(sz, sz)
+						gtx.Constraints.Max = gtx.Constraints.Min
+						return icon.Layout(gtx, fg)
+					})
+				}),
+				layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+					l := material.Body2(th, text)
+					l.Color = fg
+					return l.Layout(gtx)
+				}),
+			)
 		})
 	})
 }
@@ -90,6 +144,36 @@ func Tag(gtx layout.Context, th *material.Theme, text string, fg color.NRGBA) la
 	})
 }
 
+// StatusTag is a Tag for a status, drawn in the color of tone with its icon
+// before the text.
+func StatusTag(gtx layout.Context, th *material.Theme, tone BannerTone, text string) layout.Dimensions {
+	fg := ToneColor(tone)
+	icon := ToneIcon(tone)
+	return Border(gtx, fg, func(gtx layout.Context) layout.Dimensions {
+		return CustomCard(gtx, ColorSurface, unit.Dp(4), func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					if icon == nil {
+						return layout.Dimensions{}
+					}
+					return layout.Inset{Right: unit.Dp(4)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						sz := gtx.Sp(th.TextSize * 0.75)
+						gtx.Constraints.Min = image.Pt(sz, sz)
+						gtx.Constraints.Max = gtx.Constraints.Min
+						return icon.Layout(gtx, fg)
+					})
+				}),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					l := material.Caption(th, text)
+					l.Color = fg
+					l.Font.Weight = font.Bold
+					return l.Layout(gtx)
+				}),
+			)
+		})
+	})
+}
+
 func PrimaryButton(th *material.Theme, c *widget.Clickable, text string) material.ButtonStyle {
 	btn := material.Button(th, c, text)
 	btn.Background = th.ContrastBg
@@ -126,7 +210,7 @@ func EmptyState(gtx layout.Context, th *material.Theme, title, subtitle string)
 			layout.Rigid(layout.Spacer{Height: unit.Dp(6)}.Layout),
 			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 				l := material.Body2(th, subtitle)
-				l.Color = color.NRGBA{R: 0x5F, G: 0x6E, B: 0x84, A: 0xFF}
+				l.Color = ColorMuted
 				return l.Layout(gtx)
 			}),
 		)
@@ -0,0 +1,155 @@
+package widgets
+
+import (
+	"gioui.org/font"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+// Zoom steps of a TextPresenter, each 15% of the base text size.
+const (
+	minZoomStep = -2
+	maxZoomStep = 6
+)
+
+// TextPresenter shows a long text, such as a legal statement or a
+// certificate subject, wrapped to the available width and selectable for
+// copying. Text longer than Lines starts collapsed and can be expanded;
+// expanded text taller than MaxHeight scrolls. Its own buttons zoom the
+// text in and out.
+type TextPresenter struct {
+	// Lines is how many lines show while collapsed, 6 if zero.
+	Lines int
+	// MaxHeight bounds the expanded text, 320dp if zero.
+	MaxHeight unit.Dp
+	// Mono shows the text in a monospace font.
+	Mono bool
+	// Expanded always shows the whole text, for text the user must read
+	// before agreeing to it.
+	Expanded bool
+
+	expandBtn  widget.Clickable
+	zoomInBtn  widget.Clickable
+	zoomOutBtn widget.Clickable
+	sel        widget.Selectable
+	list       widget.List
+	expanded   bool
+	zoom       int
+
+	// The heights of the whole and of the collapsed text, measured for
+	// measuredFor.
+	measuredFor       measureKey
+	fullY, collapsedY int
+}
+
+// measureKey is what the measured heights of a TextPresenter depend on.
+type measureKey struct {
+	text  string
+	size  unit.Sp
+	zoom  int
+	width int
+	lines int
+	mono  bool
+	scale float32
+}
+
+// zoomScale is the text size factor of zoom step.
+func zoomScale(step int) float32 {
+	return 1 + 0.15*float32(step)
+}
+
+// Layout lays out text at size, scaled by the zoom.
+func (p *TextPresenter) Layout(gtx layout.Context, th *material.Theme, size unit.Sp, text string) layout.Dimensions {
+	if p.zoomInBtn.Clicked(gtx) && p.zoom < maxZoomStep {
+		p.zoom++
+	}
+	if p.zoomOutBtn.Clicked(gtx) && p.zoom > minZoomStep {
+		p.zoom--
+	}
+	if p.expandBtn.Clicked(gtx) {
+		p.expanded = !p.expanded
+	}
+	lines := p.Lines
+	if lines <= 0 {
+		lines = 6
+	}
+	maxHeight := p.MaxHeight
+	if maxHeight <= 0 {
+		maxHeight = 320
+	}
+
+	label := material.Label(th, size*unit.Sp(zoomScale(p.zoom)), text)
+	if p.Mono {
+		label.Font.Typeface = "monospace"
+	}
+
+	// Measure the whole text to know whether collapsing hides any of it,
+	// again only when the text or its layout changed.
+	key := measureKey{text, size, p.zoom, gtx.Constraints.Max.X, lines, p.Mono, gtx.Metric.PxPerSp}
+	if key != p.measuredFor {
+		measure := gtx
+		measure.Constraints.Min.Y = 0
+		measure.Constraints.Max.Y = 1 << 24
+		macro := op.Record(gtx.Ops)
+		full := label.Layout(measure)
+		short := label
+		short.MaxLines = lines
+		collapsed := short.Layout(measure)
+		macro.Stop()
+		p.measuredFor, p.fullY, p.collapsedY = key, full.Size.Y, collapsed.Size.Y
+	}
+	overflows := p.fullY > p.collapsedY
+	expanded := p.expanded || p.Expanded
+
+	body := func(gtx layout.Context) layout.Dimensions {
+		l := label
+		l.State = &p.sel
+		if !expanded || !overflows {
+			l.MaxLines = lines
+			return l.Layout(gtx)
+		}
+		if p.fullY <= gtx.Dp(maxHeight) {
+			return l.Layout(gtx)
+		}
+		gtx.Constraints.Max.Y = gtx.Dp(maxHeight)
+		p.list.Axis = layout.Vertical
+		return material.List(th, &p.list).Layout(gtx, 1, func(gtx layout.Context, _ int) layout.Dimensions {
+			return l.Layout(gtx)
+		})
+	}
+
+	button := func(c *widget.Clickable, txt string) layout.FlexChild {
+		return layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Inset{Left: unit.Dp(6)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return material.Clickable(gtx, c, func(gtx layout.Context) layout.Dimensions {
+					return layout.UniformInset(unit.Dp(2)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						l := material.Caption(th, txt)
+						l.Color = th.ContrastBg
+						l.Font.Weight = font.Bold
+						return l.Layout(gtx)
+					})
+				})
+			})
+		})
+	}
+	toolbar := []layout.FlexChild{layout.Flexed(1, layout.Spacer{}.Layout)}
+	if overflows && !p.Expanded {
+		txt := "Show more"
+		if p.expanded {
+			txt = "Show less"
+		}
+		toolbar = append(toolbar, button(&p.expandBtn, txt))
+	}
+	toolbar = append(toolbar, button(&p.zoomOutBtn, "A−"), button(&p.zoomInBtn, "A+"))
+
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(body),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min.X = gtx.Constraints.Max.X
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx, toolbar...)
+		}),
+	)
+}
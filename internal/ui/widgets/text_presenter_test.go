@@ -0,0 +1,53 @@
+package widgets
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+)
+
+func TestTextPresenter(t *testing.T) {
+	th := material.NewTheme()
+	gtx := layout.Context{
+		Ops:         new(op.Ops),
+		Constraints: layout.Exact(image.Pt(400, 2000)),
+	}
+	gtx.Constraints.Min.Y = 0
+	long := strings.Repeat("The signatory declares that the data given is true. ", 80)
+
+	p := &TextPresenter{MaxHeight: 200}
+	collapsed := p.Layout(gtx, th, unit.Sp(14), long)
+	p.expanded = true
+	expanded := p.Layout(gtx, th, unit.Sp(14), long)
+	if expanded.Size.Y <= collapsed.Size.Y {
+		t.Fatalf("expanded height %d, collapsed %d", expanded.Size.Y, collapsed.Size.Y)
+	}
+	// The expanded text scrolls instead of growing past MaxHeight; the
+	// toolbar comes on top.
+	if expanded.Size.Y > 200+collapsed.Size.Y {
+		t.Errorf("expanded height %d not bounded", expanded.Size.Y)
+	}
+	for _, dim := range []layout.Dimensions{collapsed, expanded} {
+		if dim.Size.X > 400 {
+			t.Errorf("width %d overflows the 400px available", dim.Size.X)
+		}
+	}
+
+	// Zooming in makes the same collapsed text taller.
+	p.expanded = false
+	p.zoom = maxZoomStep
+	if zoomed := p.Layout(gtx, th, unit.Sp(14), long); zoomed.Size.Y <= collapsed.Size.Y {
+		t.Errorf("zoomed height %d, unzoomed %d", zoomed.Size.Y, collapsed.Size.Y)
+	}
+}
+
+func TestZoomScale(t *testing.T) {
+	if zoomScale(0) != 1 || zoomScale(minZoomStep) <= 0 || zoomScale(maxZoomStep) <= zoomScale(maxZoomStep-1) {
+		t.Error("zoom steps out of order")
+	}
+}
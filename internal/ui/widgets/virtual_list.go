@@ -0,0 +1,53 @@
+package widgets
+
+import (
+	"gioui.org/layout"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+// VirtualList is a scrolling list of rows laid out by index: only the rows
+// in view are laid out, and nothing is built for the others, so lists of
+// thousands of rows cost the same per frame as short ones.
+//
+// Rows are identified by a key. When rows are added or removed before the
+// first visible one, such as a new audit entry at the top or a deleted
+// certificate, that row stays in place instead of the list jumping. A list
+// scrolled to the very top stays at the top, so new rows there are seen.
+type VirtualList struct {
+	widget.List
+	anchor string
+}
+
+// Layout lays out the n rows in view with row. key returns the stable
+// identifier of row i.
+func (v *VirtualList) Layout(gtx layout.Context, th *material.Theme, n int, key func(i int) string, row layout.ListElement) layout.Dimensions {
+	atTop := v.Position.First == 0 && v.Position.Offset == 0
+	if v.anchor != "" && !atTop {
+		v.Position.First = anchoredFirst(v.Position.First, n, key, v.anchor)
+	}
+	dims := material.List(th, &v.List).Layout(gtx, n, row)
+	v.anchor = ""
+	if v.Position.First < n {
+		v.anchor = key(v.Position.First)
+	}
+	return dims
+}
+
+// anchoredFirst returns the index the row with key anchor moved to,
+// searching outwards from its previous index first, or first if the row is
+// gone.
+func anchoredFirst(first, n int, key func(int) string, anchor string) int {
+	if first < n && key(first) == anchor {
+		return first
+	}
+	for d := 1; first-d >= 0 || first+d < n; d++ {
+		if i := first - d; i >= 0 && i < n && key(i) == anchor {
+			return i
+		}
+		if i := first + d; i >= 0 && i < n && key(i) == anchor {
+			return i
+		}
+	}
+	return first
+}
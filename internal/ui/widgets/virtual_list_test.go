@@ -0,0 +1,36 @@
+package widgets
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestAnchoredFirst(t *testing.T) {
+	keys := func(ks ...string) func(int) string {
+		return func(i int) string { return ks[i] }
+	}
+	tests := []struct {
+		name  string
+		first int
+		keys  []string
+		want  int
+	}{
+		{"unchanged", 1, []string{"a", "b", "c"}, 1},
+		{"row added before", 1, []string{"new", "a", "b", "c"}, 2},
+		{"rows removed before", 3, []string{"b", "c"}, 0},
+		{"anchor removed", 1, []string{"a", "c"}, 1},
+	}
+	for _, tt := range tests {
+		if got := anchoredFirst(tt.first, len(tt.keys), keys(tt.keys...), "b"); got != tt.want {
+			t.Errorf("%s: anchoredFirst = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAnchoredFirstLongList(t *testing.T) {
+	const n = 5000
+	key := func(i int) string { return strconv.Itoa(i) }
+	if got := anchoredFirst(10, n, key, "4990"); got != 4990 {
+		t.Fatalf("anchoredFirst = %d", got)
+	}
+}
@@ -0,0 +1,382 @@
+// Package update downloads and installs a new release of the VocSign
+// executable.
+//
+// Each release publishes the executables (vocsign-<os>-<arch>) and, for the
+// release before it, binary patches named after the version they apply to
+// (vocsign-linux-amd64.from-v1.3.0.delta, see tools/mkdelta). A client one
+// release behind downloads the patch, usually a few hundred kilobytes, and
+// applies it to its own executable. Otherwise, or when the patch does not
+// apply, it downloads the full executable, resuming an interrupted download
+// where it stopped. Either way the result is checked against its signed
+// manifest before it replaces the running executable.
+package update
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/delta"
+	"github.com/vocdoni/gofirma/vocsign/internal/selfcheck"
+)
+
+const (
+	// DeltaSuffix ends the name of a patch asset.
+	DeltaSuffix = ".delta"
+	// partSuffix ends an interrupted full download.
+	partSuffix = ".part"
+	// maxManifestBytes bounds the .sig download.
+	maxManifestBytes = 64 << 10
+)
+
+// ErrNoBuild means the release has no executable for this platform.
+var ErrNoBuild = errors.New("the release has no build for this platform")
+
+// Asset is a file attached to a release.
+type Asset struct {
+	Name string
+	URL  string
+	Size int64
+}
+
+// BinaryName is the name of the release executable for a platform.
+func BinaryName(goos, goarch string) string {
+	name := "vocsign-" + goos + "-" + goarch
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// DeltaName is the name of the patch that turns the binary of release
+// fromVersion into the binary of the release it is attached to.
+func DeltaName(binary, fromVersion string) string {
+	return binary + ".from-" + fromVersion + DeltaSuffix
+}
+
+// Progress reports how many bytes of total were downloaded.
+type Progress func(done, total int64)
+
+// Downloader fetches a release into Dir.
+type Downloader struct {
+	Client *http.Client
+	// Dir holds downloads until they are installed.
+	Dir string
+	// Binary is the name of the executable asset for this platform.
+	Binary string
+	// Verify checks the downloaded executable at path, with its manifest
+	// next to it when the release has one. Nil accepts any executable.
+	Verify func(path, version string) error
+}
+
+// Result is a downloaded release.
+type Result struct {
+	Version string
+	// Path is the downloaded executable; its manifest, when the release
+	// has one, is Path+selfcheck.SignatureSuffix.
+	Path string
+	// Delta reports whether the executable was patched from the running
+	// one rather than downloaded whole.
+	Delta bool
+	// Downloaded is the number of bytes transferred.
+	Downloaded int64
+}
+
+// Download fetches version, given its assets, for a client running
+// currentVersion from the executable at exePath.
+func (d *Downloader) Download(ctx context.Context, version string, assets []Asset, currentVersion, exePath string, progress Progress) (*Result, error) {
+	find := func(name string) (Asset, bool) {
+		for _, a := range assets {
+			if a.Name == name {
+				return a, true
+			}
+		}
+		return Asset{}, false
+	}
+	full, ok := find(d.Binary)
+	if !ok {
+		return nil, ErrNoBuild
+	}
+	if progress == nil {
+		progress = func(int64, int64) {}
+	}
+	dir := filepath.Join(d.Dir, version)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create download directory: %w", err)
+	}
+	res := &Result{Version: version, Path: filepath.Join(dir, d.Binary)}
+
+	patched := false
+	if patch, ok := find(DeltaName(d.Binary, currentVersion)); ok && patch.Size < full.Size {
+		n, err := d.applyDelta(ctx, patch, full.Size, exePath, res.Path, progress)
+		res.Downloaded += n
+		switch {
+		case err == nil:
+			patched = true
+		case ctx.Err() != nil:
+			return nil, ctx.Err()
+		default:
+			log.Printf("WARNING: delta update from %s failed, downloading the full release: %v", currentVersion, err)
+		}
+	}
+	if !patched {
+		n, err := d.downloadFull(ctx, full, res.Path, progress)
+		res.Downloaded += n
+		if err != nil {
+			return nil, err
+		}
+	}
+	res.Delta = patched
+
+	sigPath := res.Path + selfcheck.SignatureSuffix
+	_ = os.Remove(sigPath)
+	if sig, ok := find(d.Binary + selfcheck.SignatureSuffix); ok {
+		data, err := d.get(ctx, sig.URL, maxManifestBytes)
+		if err != nil {
+			return nil, fmt.Errorf("download release manifest: %w", err)
+		}
+		res.Downloaded += int64(len(data))
+		if err := os.WriteFile(sigPath, data, 0o600); err != nil {
+			return nil, fmt.Errorf("save release manifest: %w", err)
+		}
+	}
+	if d.Verify != nil {
+		if err := d.Verify(res.Path, version); err != nil {
+			_ = os.Remove(res.Path)
+			return nil, fmt.Errorf("downloaded release rejected: %w", err)
+		}
+	}
+	log.Printf("DEBUG: update %s downloaded to %s (delta=%v, %d bytes)", version, res.Path, res.Delta, res.Downloaded)
+	return res, nil
+}
+
+// applyDelta downloads a patch and applies it to the running executable.
+// The patched executable may not be larger than size, the size of the full
+// release asset.
+func (d *Downloader) applyDelta(ctx context.Context, patch Asset, size int64, exePath, dst string, progress Progress) (int64, error) {
+	old, err := os.ReadFile(exePath)
+	if err != nil {
+		return 0, fmt.Errorf("read running executable: %w", err)
+	}
+	var buf bytes.Buffer
+	n, err := d.fetch(ctx, &buf, patch, 0, progress)
+	if err != nil {
+		return n, err
+	}
+	out, err := delta.Patch(old, &buf, size)
+	if err != nil {
+		return n, err
+	}
+	return n, writeExecutable(dst, out)
+}
+
+// downloadFull downloads the executable, resuming from an earlier partial
+// download of the same release.
+func (d *Downloader) downloadFull(ctx context.Context, full Asset, dst string, progress Progress) (int64, error) {
+	part := dst + partSuffix
+	var offset int64
+	if fi, err := os.Stat(part); err == nil && fi.Size() < full.Size {
+		offset = fi.Size()
+	} else {
+		_ = os.Remove(part)
+	}
+	f, err := os.OpenFile(part, os.O_WRONLY|os.O_CREATE, 0o700)
+	if err != nil {
+		return 0, fmt.Errorf("create download: %w", err)
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		_ = f.Close()
+		return 0, err
+	}
+	w := &offsetWriter{f: f, offset: offset}
+	n, err := d.fetch(ctx, w, full, offset, progress)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		// The partial file is kept so the next attempt resumes.
+		return n, err
+	}
+	if w.offset != full.Size {
+		_ = os.Remove(part)
+		return n, fmt.Errorf("download is %d bytes, expected %d", w.offset, full.Size)
+	}
+	return n, os.Rename(part, dst)
+}
+
+// offsetWriter writes to a file, restarting it when the server ignores the
+// range request and sends the whole asset.
+type offsetWriter struct {
+	f      *os.File
+	offset int64
+}
+
+func (w *offsetWriter) restart() error {
+	w.offset = 0
+	if err := w.f.Truncate(0); err != nil {
+		return err
+	}
+	_, err := w.f.Seek(0, io.SeekStart)
+	return err
+}
+
+func (w *offsetWriter) Write(p []byte) (int, error) {
+	n, err := w.f.Write(p)
+	w.offset += int64(n)
+	return n, err
+}
+
+// fetch downloads an asset into w from offset, reporting progress.
+func (d *Downloader) fetch(ctx context.Context, w io.Writer, a Asset, offset int64, progress Progress) (int64, error) {
+	resp, err := d.request(ctx, a.URL, offset)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if offset > 0 && resp.StatusCode == http.StatusOK {
+		ow, ok := w.(*offsetWriter)
+		if !ok {
+			return 0, errors.New("server ignored the range request")
+		}
+		if err := ow.restart(); err != nil {
+			return 0, err
+		}
+		offset = 0
+	}
+
+	var n int64
+	buf := make([]byte, 32<<10)
+	progress(offset, a.Size)
+	for {
+		m, rerr := resp.Body.Read(buf)
+		if m > 0 {
+			if offset+n+int64(m) > a.Size {
+				return n, fmt.Errorf("%s is larger than announced", a.Name)
+			}
+			if _, err := w.Write(buf[:m]); err != nil {
+				return n, err
+			}
+			n += int64(m)
+			progress(offset+n, a.Size)
+		}
+		if rerr == io.EOF {
+			return n, nil
+		}
+		if rerr != nil {
+			return n, fmt.Errorf("download %s: %w", a.Name, rerr)
+		}
+	}
+}
+
+// get downloads a small file whole.
+func (d *Downloader) get(ctx context.Context, url string, limit int64) ([]byte, error) {
+	resp, err := d.request(ctx, url, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("response exceeds %d bytes", limit)
+	}
+	return data, nil
+}
+
+func (d *Downloader) request(ctx context.Context, url string, offset int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build download request: %w", err)
+	}
+	req.Header.Set("Accept", "application/octet-stream")
+	req.Header.Set("User-Agent", "vocsign-updater")
+	if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("download failed: %s", resp.Status)
+	}
+	return resp, nil
+}
+
+func writeExecutable(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o700); err != nil {
+		return fmt.Errorf("save update: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("save update: %w", err)
+	}
+	return nil
+}
+
+// InstallBlocked returns why the executable at exePath cannot be replaced
+// in place, or "" when Install can replace it.
+func InstallBlocked(exePath string) string {
+	if strings.Contains(filepath.ToSlash(exePath), ".app/Contents/MacOS/") {
+		return "VocSign runs from a macOS app bundle, whose code signature covers the executable"
+	}
+	return ""
+}
+
+// Install replaces the executable at exePath, and its manifest, with the
+// downloaded release. The running process keeps using the old file, renamed
+// to exePath+".old", until VocSign is restarted.
+func Install(res *Result, exePath string) error {
+	if why := InstallBlocked(exePath); why != "" {
+		return errors.New(why)
+	}
+	data, err := os.ReadFile(res.Path)
+	if err != nil {
+		return fmt.Errorf("read downloaded release: %w", err)
+	}
+	fi, err := os.Stat(exePath)
+	if err != nil {
+		return err
+	}
+	// Write next to the executable, so the renames below stay on one
+	// file system.
+	next := filepath.Join(filepath.Dir(exePath), "."+filepath.Base(exePath)+".new")
+	if err := os.WriteFile(next, data, fi.Mode().Perm()|0o700); err != nil {
+		return fmt.Errorf("write new executable: %w", err)
+	}
+	old := exePath + ".old"
+	_ = os.Remove(old)
+	if err := os.Rename(exePath, old); err != nil {
+		_ = os.Remove(next)
+		return fmt.Errorf("move running executable aside: %w", err)
+	}
+	if err := os.Rename(next, exePath); err != nil {
+		_ = os.Rename(old, exePath)
+		_ = os.Remove(next)
+		return fmt.Errorf("install new executable: %w", err)
+	}
+
+	// A manifest left from the old release would fail the self-check.
+	sig := exePath + selfcheck.SignatureSuffix
+	if data, err := os.ReadFile(res.Path + selfcheck.SignatureSuffix); err == nil {
+		if err := os.WriteFile(sig, data, 0o644); err != nil {
+			return fmt.Errorf("install release manifest: %w", err)
+		}
+	} else if err := os.Remove(sig); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove old release manifest: %w", err)
+	}
+	log.Printf("DEBUG: update %s installed at %s", res.Version, exePath)
+	return nil
+}
@@ -0,0 +1,226 @@
+package update
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/delta"
+	"github.com/vocdoni/gofirma/vocsign/internal/selfcheck"
+)
+
+func TestNames(t *testing.T) {
+	if got := BinaryName("windows", "amd64"); got != "vocsign-windows-amd64.exe" {
+		t.Errorf("BinaryName = %q", got)
+	}
+	if got := DeltaName(BinaryName("linux", "amd64"), "v1.3.0"); got != "vocsign-linux-amd64.from-v1.3.0.delta" {
+		t.Errorf("DeltaName = %q", got)
+	}
+}
+
+// release serves the assets of a release and counts the requests per file.
+type release struct {
+	files map[string][]byte
+	hits  map[string]int
+	// cut, when set, stops the full binary after that many bytes once.
+	cut int
+}
+
+func (r *release) server(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		name := strings.TrimPrefix(req.URL.Path, "/")
+		data, ok := r.files[name]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		r.hits[name]++
+		if r.cut > 0 && !strings.Contains(name, ".") {
+			cut := r.cut
+			r.cut = 0
+			w.Header().Set("Content-Length", "100000")
+			_, _ = w.Write(data[:cut])
+			return
+		}
+		http.ServeContent(w, req, name, time.Time{}, bytes.NewReader(data))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func (r *release) assets(base string) []Asset {
+	var out []Asset
+	for name, data := range r.files {
+		out = append(out, Asset{Name: name, URL: base + "/" + name, Size: int64(len(data))})
+	}
+	return out
+}
+
+func setup(t *testing.T) (old, new []byte, exe string, rel *release) {
+	old = bytes.Repeat([]byte("vocsign v1.3.0 machine code "), 2000)
+	new = bytes.Replace(old, []byte("v1.3.0"), []byte("v1.4.0"), 3)
+	exe = filepath.Join(t.TempDir(), "vocsign-linux-amd64")
+	if err := os.WriteFile(exe, old, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	var patch bytes.Buffer
+	if err := delta.Diff(&patch, old, new); err != nil {
+		t.Fatal(err)
+	}
+	rel = &release{
+		files: map[string][]byte{
+			"vocsign-linux-amd64":                   new,
+			"vocsign-linux-amd64.from-v1.3.0.delta": patch.Bytes(),
+			"vocsign-linux-amd64.sig":               []byte(`{"manifest":{}}`),
+			"vocsign-windows-amd64.exe":             []byte("MZ"),
+		},
+		hits: map[string]int{},
+	}
+	return old, new, exe, rel
+}
+
+func TestDownloadDelta(t *testing.T) {
+	_, new, exe, rel := setup(t)
+	srv := rel.server(t)
+	var verified string
+	d := &Downloader{Client: srv.Client(), Dir: t.TempDir(), Binary: "vocsign-linux-amd64",
+		Verify: func(path, version string) error { verified = version; return nil }}
+
+	res, err := d.Download(context.Background(), "v1.4.0", rel.assets(srv.URL), "v1.3.0", exe, nil)
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if !res.Delta || rel.hits["vocsign-linux-amd64"] != 0 || verified != "v1.4.0" {
+		t.Fatalf("res = %+v, hits = %v", res, rel.hits)
+	}
+	if res.Downloaded >= int64(len(new)) {
+		t.Errorf("downloaded %d bytes for a %d byte binary", res.Downloaded, len(new))
+	}
+	if got, _ := os.ReadFile(res.Path); !bytes.Equal(got, new) {
+		t.Fatal("patched binary differs")
+	}
+	if _, err := os.Stat(res.Path + selfcheck.SignatureSuffix); err != nil {
+		t.Fatalf("manifest not downloaded: %v", err)
+	}
+}
+
+func TestDownloadFallsBackToFull(t *testing.T) {
+	_, new, exe, rel := setup(t)
+	// The running executable is not the one the patch was made from.
+	if err := os.WriteFile(exe, []byte("locally re-signed binary"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	srv := rel.server(t)
+	d := &Downloader{Client: srv.Client(), Dir: t.TempDir(), Binary: "vocsign-linux-amd64"}
+
+	res, err := d.Download(context.Background(), "v1.4.0", rel.assets(srv.URL), "v1.3.0", exe, nil)
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if res.Delta || rel.hits["vocsign-linux-amd64"] != 1 {
+		t.Fatalf("res = %+v, hits = %v", res, rel.hits)
+	}
+	if got, _ := os.ReadFile(res.Path); !bytes.Equal(got, new) {
+		t.Fatal("downloaded binary differs")
+	}
+
+	// No patch from an older release: full download straight away.
+	rel.hits = map[string]int{}
+	if res, err := d.Download(context.Background(), "v1.4.0", rel.assets(srv.URL), "v1.2.0", exe, nil); err != nil || res.Delta {
+		t.Fatalf("Download from v1.2.0 = %+v, %v", res, err)
+	}
+	if rel.hits["vocsign-linux-amd64.from-v1.3.0.delta"] != 0 {
+		t.Fatal("fetched a patch for another version")
+	}
+}
+
+func TestDownloadResumes(t *testing.T) {
+	_, new, exe, rel := setup(t)
+	delete(rel.files, "vocsign-linux-amd64.from-v1.3.0.delta")
+	rel.cut = 20000
+	srv := rel.server(t)
+	d := &Downloader{Client: srv.Client(), Dir: t.TempDir(), Binary: "vocsign-linux-amd64"}
+
+	if _, err := d.Download(context.Background(), "v1.4.0", rel.assets(srv.URL), "v1.3.0", exe, nil); err == nil {
+		t.Fatal("expected the interrupted download to fail")
+	}
+	var first int64 = -1
+	res, err := d.Download(context.Background(), "v1.4.0", rel.assets(srv.URL), "v1.3.0", exe, func(done, total int64) {
+		if first < 0 {
+			first = done
+		}
+	})
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if first != 20000 || res.Downloaded != int64(len(new))-20000+int64(len(rel.files["vocsign-linux-amd64.sig"])) {
+		t.Fatalf("resumed at %d, downloaded %d", first, res.Downloaded)
+	}
+	if got, _ := os.ReadFile(res.Path); !bytes.Equal(got, new) {
+		t.Fatal("resumed binary differs")
+	}
+}
+
+func TestDownloadRejected(t *testing.T) {
+	_, _, exe, rel := setup(t)
+	srv := rel.server(t)
+	d := &Downloader{Client: srv.Client(), Dir: t.TempDir(), Binary: "vocsign-linux-amd64",
+		Verify: func(string, string) error { return selfcheck.ErrTampered }}
+	if _, err := d.Download(context.Background(), "v1.4.0", rel.assets(srv.URL), "v1.3.0", exe, nil); !errors.Is(err, selfcheck.ErrTampered) {
+		t.Fatalf("err = %v", err)
+	}
+	d.Binary = "vocsign-freebsd-amd64"
+	if _, err := d.Download(context.Background(), "v1.4.0", rel.assets(srv.URL), "v1.3.0", exe, nil); !errors.Is(err, ErrNoBuild) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestInstall(t *testing.T) {
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "vocsign")
+	if err := os.WriteFile(exe, []byte("old"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(exe+selfcheck.SignatureSuffix, []byte("old manifest"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	staged := filepath.Join(t.TempDir(), "vocsign-linux-amd64")
+	if err := os.WriteFile(staged, []byte("new"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	// A release without a manifest removes the stale one.
+	if err := Install(&Result{Version: "v1.4.0", Path: staged}, exe); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	if got, _ := os.ReadFile(exe); string(got) != "new" {
+		t.Fatalf("executable = %q", got)
+	}
+	if got, _ := os.ReadFile(exe + ".old"); string(got) != "old" {
+		t.Fatalf("old executable = %q", got)
+	}
+	if _, err := os.Stat(exe + selfcheck.SignatureSuffix); !os.IsNotExist(err) {
+		t.Fatalf("stale manifest left: %v", err)
+	}
+
+	if err := os.WriteFile(staged+selfcheck.SignatureSuffix, []byte("new manifest"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Install(&Result{Version: "v1.4.0", Path: staged}, exe); err != nil {
+		t.Fatalf("second Install: %v", err)
+	}
+	if got, _ := os.ReadFile(exe + selfcheck.SignatureSuffix); string(got) != "new manifest" {
+		t.Fatalf("manifest = %q", got)
+	}
+
+	if InstallBlocked("/Applications/VocSign.app/Contents/MacOS/vocsign") == "" {
+		t.Fatal("app bundle not blocked")
+	}
+}
@@ -1,6 +1,7 @@
 package version
 
 import (
+	"cmp"
 	"strconv"
 	"strings"
 )
@@ -9,6 +10,8 @@ type semver struct {
 	major int
 	minor int
 	patch int
+	// pre is the pre-release part, such as "beta.1". Empty for releases.
+	pre string
 }
 
 func IsOutdated(current, latest string) bool {
@@ -17,13 +20,70 @@ func IsOutdated(current, latest string) bool {
 	if !okCur || !okLat {
 		return false
 	}
-	if cur.major != lat.major {
-		return cur.major < lat.major
+	return compare(cur, lat) < 0
+}
+
+// Compare orders two versions by semantic version precedence: -1 if a is
+// older than b, 1 if newer and 0 if equal. A version that does not parse
+// is older than any that does.
+func Compare(a, b string) int {
+	va, okA := parseSemver(a)
+	vb, okB := parseSemver(b)
+	switch {
+	case !okA && !okB:
+		return 0
+	case !okA:
+		return -1
+	case !okB:
+		return 1
+	}
+	return compare(va, vb)
+}
+
+// IsPrerelease reports whether v is a pre-release, such as v1.5.0-beta.1.
+func IsPrerelease(v string) bool {
+	s, ok := parseSemver(v)
+	return ok && s.pre != ""
+}
+
+func compare(a, b semver) int {
+	if c := cmp.Or(cmp.Compare(a.major, b.major), cmp.Compare(a.minor, b.minor), cmp.Compare(a.patch, b.patch)); c != 0 {
+		return c
+	}
+	return comparePre(a.pre, b.pre)
+}
+
+// comparePre orders pre-release parts: a release is newer than its
+// pre-releases, and identifiers compare numerically when both are numbers.
+func comparePre(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
 	}
-	if cur.minor != lat.minor {
-		return cur.minor < lat.minor
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		na, errA := strconv.Atoi(as[i])
+		nb, errB := strconv.Atoi(bs[i])
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				return cmp.Compare(na, nb)
+			}
+		case errA == nil:
+			return -1
+		case errB == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
 	}
-	return cur.patch < lat.patch
+	return cmp.Compare(len(as), len(bs))
 }
 
 func parseSemver(v string) (semver, bool) {
@@ -33,9 +93,13 @@ func parseSemver(v string) (semver, bool) {
 	if s == "" {
 		return semver{}, false
 	}
-	if i := strings.IndexAny(s, "-+"); i >= 0 {
+	if i := strings.IndexByte(s, '+'); i >= 0 {
 		s = s[:i]
 	}
+	var pre string
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		s, pre = s[:i], s[i+1:]
+	}
 	parts := strings.Split(s, ".")
 	if len(parts) == 0 {
 		return semver{}, false
@@ -48,5 +112,5 @@ func parseSemver(v string) (semver, bool) {
 		}
 		num[i] = n
 	}
-	return semver{major: num[0], minor: num[1], patch: num[2]}, true
+	return semver{major: num[0], minor: num[1], patch: num[2], pre: pre}, true
 }
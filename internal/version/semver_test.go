@@ -42,3 +42,38 @@ func TestIsOutdated(t *testing.T) {
 		}
 	}
 }
+
+func TestCompare(t *testing.T) {
+	// In increasing precedence, from the semantic versioning spec.
+	ordered := []string{
+		"v1.0.0-alpha", "v1.0.0-alpha.1", "v1.0.0-alpha.beta", "v1.0.0-beta",
+		"v1.0.0-beta.2", "v1.0.0-beta.11", "v1.0.0-rc.1", "v1.0.0", "v1.0.1-beta.1", "v1.0.1",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			if got := Compare(ordered[i], ordered[j]); got != want {
+				t.Errorf("Compare(%q, %q) = %d, want %d", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+	if Compare("dev", "v0.0.1") != -1 || Compare("v0.0.1", "dev") != 1 || Compare("dev", "latest") != 0 {
+		t.Error("unparsable versions must sort first")
+	}
+	if !IsOutdated("v1.5.0-beta.2", "v1.5.0") || IsOutdated("v1.5.0", "v1.5.0-beta.2") {
+		t.Error("a release must supersede its pre-releases")
+	}
+}
+
+func TestIsPrerelease(t *testing.T) {
+	for v, want := range map[string]bool{"v1.5.0-beta.1": true, "1.5.0-rc1+build": true, "v1.5.0": false, "v1.5.0+build": false, "dev": false} {
+		if got := IsPrerelease(v); got != want {
+			t.Errorf("IsPrerelease(%q) = %v", v, got)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+// Package cadesverify verifies the detached CAdES signatures VocSign
+// submits to a collector. It is meant for promoters building their own
+// backends: VerifyDetached performs every check a collector needs, and
+// VerifySignature, VerifyChain and VerifyPolicy perform them one at a time
+// for callers that report on each.
+package cadesverify
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/smallstep/pkcs7"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/cades"
+)
+
+// Errors returned by the verification functions wrap one of these, so
+// callers can tell which check failed.
+var (
+	ErrSignature = errors.New("signature does not verify")
+	ErrSigner    = errors.New("unexpected signer")
+	ErrChain     = errors.New("certificate chain is not valid")
+	ErrPolicy    = errors.New("signature policy does not match")
+)
+
+// Policy identifies the signature policy a signature must reference.
+type Policy struct {
+	OID  string
+	Hash []byte // SHA-256 of the policy document
+}
+
+// Options configure VerifyDetached. The zero value verifies the signature
+// and checks that each signer's chain is consistent and valid at signing
+// time, without anchoring it to a trust list.
+type Options struct {
+	// Roots anchors signer chains. If nil, chains are only checked for
+	// consistency.
+	Roots *x509.CertPool
+	// Intermediates are used to build chains in addition to the
+	// certificates the signature carries.
+	Intermediates []*x509.Certificate
+	// Policy, if set, must be the policy the signature references.
+	Policy *Policy
+	// Signer, if set, must be the certificate of the first signer, e.g. the
+	// certificate submitted along with the signature.
+	Signer *x509.Certificate
+	// Time is when chains must be valid. If zero, each signer's claimed
+	// signing time is used, or the current time if there is none.
+	Time time.Time
+}
+
+// Signer is a verified signer of a signature.
+type Signer struct {
+	Certificate *x509.Certificate
+	// Chain runs from Certificate up to a trusted root, or up to the last
+	// certificate found when Options.Roots is nil.
+	Chain       []*x509.Certificate
+	SigningTime time.Time // zero if the signer did not claim one
+	Trusted     bool      // Chain ends at one of Options.Roots
+}
+
+// Result describes a verified signature.
+type Result struct {
+	// Signers are in SignerInfo order: the citizen first, then any
+	// parallel signers such as a legal guardian.
+	Signers    []Signer
+	PolicyOID  string // empty if the signature references no policy
+	PolicyHash []byte
+}
+
+// VerifyDetached verifies der, a DER-encoded detached CAdES signature,
+// over content according to opts.
+func VerifyDetached(content, der []byte, opts Options) (*Result, error) {
+	certs, err := VerifySignature(content, der)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Signer != nil && !certs[0].Equal(opts.Signer) {
+		return nil, fmt.Errorf("%w: signed by %s, not %s", ErrSigner, certs[0].Subject, opts.Signer.Subject)
+	}
+	p7, err := pkcs7.Parse(der)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSignature, err)
+	}
+	pool := append(append([]*x509.Certificate{}, p7.Certificates...), opts.Intermediates...)
+
+	res := &Result{}
+	for i, cert := range certs {
+		s := Signer{Certificate: cert, SigningTime: signingTime(p7, i)}
+		at := opts.Time
+		if at.IsZero() {
+			at = s.SigningTime
+		}
+		s.Chain, err = VerifyChain(buildChain(cert, pool), opts.Roots, at)
+		if err != nil {
+			return nil, fmt.Errorf("signer %s: %w", cert.Subject, err)
+		}
+		s.Trusted = opts.Roots != nil
+		res.Signers = append(res.Signers, s)
+	}
+
+	sum, err := cades.Inspect(der)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSignature, err)
+	}
+	res.PolicyOID = sum.PolicyOID
+	res.PolicyHash, _ = hex.DecodeString(sum.PolicyHash)
+	if opts.Policy != nil {
+		if err := VerifyPolicy(der, *opts.Policy); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// VerifySignature checks that every signer of der signed content and
+// returns their certificates in SignerInfo order. It does not look at the
+// certificates themselves.
+func VerifySignature(content, der []byte) ([]*x509.Certificate, error) {
+	p7, err := pkcs7.Parse(der)
+	if err != nil {
+		return nil, fmt.Errorf("%w: not a CMS signature: %v", ErrSignature, err)
+	}
+	if len(p7.Signers) == 0 {
+		return nil, fmt.Errorf("%w: no signers", ErrSignature)
+	}
+	p7.Content = content
+	if err := p7.Verify(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSignature, err)
+	}
+	certs := cades.SignerCertificates(p7)
+	for i, c := range certs {
+		if c == nil {
+			return nil, fmt.Errorf("%w: certificate of signer %d is missing", ErrSignature, i+1)
+		}
+	}
+	return certs, nil
+}
+
+// VerifyChain checks chain, a signer certificate followed by its issuers,
+// at time at (now if zero). Every certificate must be valid at that time and
+// issue the one before it. If roots is non-nil the chain must also lead to
+// one of them, and the chain up to that root is returned; otherwise chain is
+// returned as is.
+func VerifyChain(chain []*x509.Certificate, roots *x509.CertPool, at time.Time) ([]*x509.Certificate, error) {
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("%w: no certificates", ErrChain)
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+	for i, cert := range chain {
+		if at.Before(cert.NotBefore) || at.After(cert.NotAfter) {
+			return nil, fmt.Errorf("%w: %s was not valid at signing time (valid %s to %s)", ErrChain,
+				cert.Subject.CommonName, cert.NotBefore.UTC().Format(time.RFC3339), cert.NotAfter.UTC().Format(time.RFC3339))
+		}
+		if i+1 < len(chain) {
+			if err := cert.CheckSignatureFrom(chain[i+1]); err != nil {
+				return nil, fmt.Errorf("%w: %s is not issued by %s: %v", ErrChain, cert.Subject.CommonName, chain[i+1].Subject.CommonName, err)
+			}
+		}
+	}
+	if roots == nil {
+		return chain, nil
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	chains, err := chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   at,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: chain does not lead to a trusted root: %v", ErrChain, err)
+	}
+	return chains[0], nil
+}
+
+// VerifyPolicy checks that der references policy p with its expected hash.
+func VerifyPolicy(der []byte, p Policy) error {
+	sum, err := cades.Inspect(der)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSignature, err)
+	}
+	if sum.PolicyOID == "" {
+		return fmt.Errorf("%w: the signature carries no policy identifier", ErrPolicy)
+	}
+	if sum.PolicyOID != p.OID {
+		return fmt.Errorf("%w: policy %s is not %s", ErrPolicy, sum.PolicyOID, p.OID)
+	}
+	if sum.PolicyHash != hex.EncodeToString(p.Hash) {
+		return fmt.Errorf("%w: policy hash does not match", ErrPolicy)
+	}
+	return nil
+}
+
+// buildChain returns cert followed by its issuers found in pool, up to a
+// self-signed certificate or one whose issuer is not in pool.
+func buildChain(cert *x509.Certificate, pool []*x509.Certificate) []*x509.Certificate {
+	chain := []*x509.Certificate{cert}
+	for len(chain) <= len(pool) {
+		last := chain[len(chain)-1]
+		if bytes.Equal(last.RawIssuer, last.RawSubject) {
+			break
+		}
+		var issuer *x509.Certificate
+		for _, c := range pool {
+			if bytes.Equal(c.RawSubject, last.RawIssuer) && last.CheckSignatureFrom(c) == nil {
+				issuer = c
+				break
+			}
+		}
+		if issuer == nil {
+			break
+		}
+		chain = append(chain, issuer)
+	}
+	return chain
+}
+
+// signingTime returns the signing-time attribute of the i-th signer of p7.
+func signingTime(p7 *pkcs7.PKCS7, i int) time.Time {
+	for _, attr := range p7.Signers[i].AuthenticatedAttributes {
+		if attr.Type.Equal(pkcs7.OIDAttributeSigningTime) {
+			var t time.Time
+			if _, err := asn1.Unmarshal(attr.Value.Bytes, &t); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
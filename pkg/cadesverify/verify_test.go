@@ -0,0 +1,108 @@
+package cadesverify
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/cades"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+	"github.com/vocdoni/gofirma/vocsign/internal/testutil/certfixtures"
+)
+
+func TestVerifyDetached(t *testing.T) {
+	ca := certfixtures.NewCA(t, "VocSign Test Root CA")
+	citizen := ca.Issue(t, certfixtures.Options{Key: certfixtures.ECDSA})
+	content := []byte("<SignaturaILP/>")
+	policyHash := sha256.Sum256([]byte("policy document"))
+	policy := &model.SignPolicy{OID: "2.16.724.1.3.1.1.2.1.9", Hash: base64.StdEncoding.EncodeToString(policyHash[:])}
+
+	der, err := cades.SignDetached(context.Background(), citizen.Key, citizen.Cert, citizen.Chain, content, cades.SignOpts{
+		SigningTime: time.Now(),
+		Policy:      policy,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+	otherRoots := x509.NewCertPool()
+	otherRoots.AddCert(certfixtures.NewCA(t, "Other Root CA").Cert)
+
+	res, err := VerifyDetached(content, der, Options{
+		Roots:  roots,
+		Signer: citizen.Cert,
+		Policy: &Policy{OID: policy.OID, Hash: policyHash[:]},
+	})
+	if err != nil {
+		t.Fatalf("VerifyDetached failed: %v", err)
+	}
+	if len(res.Signers) != 1 || !res.Signers[0].Trusted || len(res.Signers[0].Chain) != 2 {
+		t.Errorf("unexpected signers: %+v", res.Signers)
+	}
+	if res.PolicyOID != policy.OID {
+		t.Errorf("PolicyOID = %q", res.PolicyOID)
+	}
+
+	t.Run("untrusted chain is consistent", func(t *testing.T) {
+		res, err := VerifyDetached(content, der, Options{})
+		if err != nil {
+			t.Fatalf("VerifyDetached failed: %v", err)
+		}
+		if res.Signers[0].Trusted || len(res.Signers[0].Chain) != 2 {
+			t.Errorf("unexpected signer: %+v", res.Signers[0])
+		}
+	})
+
+	failures := []struct {
+		name    string
+		content []byte
+		opts    Options
+		want    error
+	}{
+		{"other content", []byte("<other/>"), Options{}, ErrSignature},
+		{"other signer", content, Options{Signer: ca.Cert}, ErrSigner},
+		{"other root", content, Options{Roots: otherRoots}, ErrChain},
+		{"after expiry", content, Options{Time: citizen.Cert.NotAfter.Add(time.Hour)}, ErrChain},
+		{"other policy", content, Options{Policy: &Policy{OID: "1.2.3", Hash: policyHash[:]}}, ErrPolicy},
+		{"other policy hash", content, Options{Policy: &Policy{OID: policy.OID, Hash: make([]byte, 32)}}, ErrPolicy},
+	}
+	for _, tc := range failures {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := VerifyDetached(tc.content, der, tc.opts)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("err = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestVerifyDetachedParallelSigners(t *testing.T) {
+	ca := certfixtures.NewCA(t, "VocSign Test Root CA")
+	citizen := ca.Issue(t, certfixtures.Options{})
+	guardian := ca.Issue(t, certfixtures.Options{Subject: certfixtures.CitizenSubject(certfixtures.Person{ID: "87654321X", GivenName: "ANNA", Surname1: "PUIG"})})
+	content := []byte("<SignaturaILP/>")
+
+	der, err := cades.SignDetached(context.Background(), citizen.Key, citizen.Cert, citizen.Chain, content, cades.SignOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err = cades.AddSigner(context.Background(), guardian.Key, guardian.Cert, guardian.Chain, der, content, cades.SignOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+
+	res, err := VerifyDetached(content, der, Options{Roots: roots, Signer: citizen.Cert})
+	if err != nil {
+		t.Fatalf("VerifyDetached failed: %v", err)
+	}
+	if len(res.Signers) != 2 || !res.Signers[1].Certificate.Equal(guardian.Cert) {
+		t.Errorf("unexpected signers: %+v", res.Signers)
+	}
+}
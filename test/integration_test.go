@@ -1,6 +1,7 @@
 package test
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/base64"
@@ -8,7 +9,6 @@ import (
 	"encoding/pem"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -18,32 +18,17 @@ import (
 	"github.com/vocdoni/gofirma/vocsign/internal/crypto/pkcs12store"
 	"github.com/vocdoni/gofirma/vocsign/internal/model"
 	"github.com/vocdoni/gofirma/vocsign/internal/net"
+	"github.com/vocdoni/gofirma/vocsign/internal/testutil/certfixtures"
 )
 
 func TestEndToEnd(t *testing.T) {
-	// Generate Key/Cert/P12 using OpenSSL
+	// Spanish-style DN including serialNumber for identity cross-check
 	tmpDir := t.TempDir()
-	keyPath := filepath.Join(tmpDir, "key.pem")
-	certPath := filepath.Join(tmpDir, "cert.pem")
-	p12Path := filepath.Join(tmpDir, "identity.p12")
-
-	// Generate Key/Cert with Spanish-style DN including serialNumber for identity cross-check
-	cmd := exec.Command("openssl", "req", "-x509", "-newkey", "rsa:2048", "-keyout", keyPath, "-out", certPath, "-days", "365", "-nodes",
-		"-subj", "/CN=USER INTEGRATION TEST - 12345678Z/serialNumber=IDCES-12345678Z/GN=TEST/SN=USER INTEGRATION")
-	if out, err := cmd.CombinedOutput(); err != nil {
-		t.Fatalf("openssl req: %v\n%s", err, out)
-	}
-
-	// Generate P12 (Legacy for compatibility)
-	// We try with -legacy flag first, if fails (old openssl), try without
-	cmd = exec.Command("openssl", "pkcs12", "-export", "-out", p12Path, "-inkey", keyPath, "-in", certPath, "-passout", "pass:password", "-legacy")
-	if out, err := cmd.CombinedOutput(); err != nil {
-		// Try without -legacy
-		cmd = exec.Command("openssl", "pkcs12", "-export", "-out", p12Path, "-inkey", keyPath, "-in", certPath, "-passout", "pass:password")
-		if out2, err2 := cmd.CombinedOutput(); err2 != nil {
-			t.Fatalf("openssl pkcs12: %v\n%s\nRetry: %v\n%s", err, out, err2, out2)
-		}
-	}
+	p12 := certfixtures.New(t, certfixtures.Options{
+		Subject: certfixtures.CitizenSubject(certfixtures.Person{
+			GivenName: "TEST", Surname1: "USER", Surname2: "INTEGRATION", ID: "12345678Z",
+		}),
+	}).LegacyPKCS12(t, "password")
 
 	// Setup Store
 	storeDir := filepath.Join(tmpDir, "store")
@@ -54,17 +39,7 @@ func TestEndToEnd(t *testing.T) {
 
 	// Import Identity
 	ctx := context.Background()
-	p12File, err := os.Open(p12Path)
-	if err != nil {
-		t.Fatalf("Open: %v", err)
-	}
-	defer func() {
-		if err := p12File.Close(); err != nil {
-			t.Logf("warning: failed to close p12 file: %v", err)
-		}
-	}()
-
-	identity, err := store.Import(ctx, filepath.Base(p12Path), p12File, []byte("password"))
+	identity, err := store.Import(ctx, "identity.p12", bytes.NewReader(p12), []byte("password"))
 	if err != nil {
 		t.Fatalf("Import: %v", err)
 	}
@@ -1,31 +1,34 @@
 package test
 
 import (
+	"bytes"
 	"context"
-	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
-	"github.com/smallstep/pkcs7"
 	"github.com/vocdoni/gofirma/vocsign/internal/crypto/cades"
 	"github.com/vocdoni/gofirma/vocsign/internal/crypto/pkcs12store"
 	"github.com/vocdoni/gofirma/vocsign/internal/model"
+	"github.com/vocdoni/gofirma/vocsign/internal/testutil/certfixtures"
+	"github.com/vocdoni/gofirma/vocsign/pkg/cadesverify"
 )
 
 func TestLegalComplianceXML(t *testing.T) {
 	// Setup Identity
-	certsDir := "certs"
-	p12Path := filepath.Join(certsDir, "user.p12")
-	if _, err := os.Stat(p12Path); os.IsNotExist(err) {
-		t.Skip("Certificate not found. Run gen_certs.sh first.")
+	p12 := certfixtures.New(t, certfixtures.Options{}).LegacyPKCS12(t, "password")
+	store, err := pkcs12store.NewFileStore(filepath.Join(t.TempDir(), "store"), []byte("vaultpw"))
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	identity, err := store.Import(context.Background(), "Test", bytes.NewReader(p12), []byte("password"))
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	signer, err := store.Unlock(context.Background(), identity.ID)
+	if err != nil {
+		t.Fatalf("Unlock: %v", err)
 	}
-
-	tmpDir := t.TempDir()
-	store, _ := pkcs12store.NewFileStore(filepath.Join(tmpDir, "store"), []byte("vaultpw"))
-	p12File, _ := os.Open(p12Path)
-	identity, _ := store.Import(context.Background(), "Test", p12File, []byte("password"))
-	signer, _ := store.Unlock(context.Background(), identity.ID)
 
 	// Mock Request
 	req := &model.SignRequest{
@@ -67,12 +70,7 @@ func TestLegalComplianceXML(t *testing.T) {
 	t.Logf("Signature size: %d bytes", len(sig))
 
 	// 3. Verify Signature (Server-side logic)
-	p7, err := pkcs7.Parse(sig)
-	if err != nil {
-		t.Fatalf("Parse CMS failed: %v", err)
-	}
-	p7.Content = xmlBytes
-	if err := p7.Verify(); err != nil {
+	if _, err := cadesverify.VerifyDetached(xmlBytes, sig, cadesverify.Options{Signer: identity.Cert}); err != nil {
 		t.Errorf("Signature verification failed: %v", err)
 	} else {
 		t.Log("Signature verified successfully in test")
@@ -1,12 +1,12 @@
 package test
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/pem"
 	"net/url"
-	"os"
 	"path/filepath"
 	"testing"
 	"time"
@@ -15,15 +15,12 @@ import (
 	"github.com/vocdoni/gofirma/vocsign/internal/crypto/cades"
 	"github.com/vocdoni/gofirma/vocsign/internal/crypto/pkcs12store"
 	"github.com/vocdoni/gofirma/vocsign/internal/model"
+	"github.com/vocdoni/gofirma/vocsign/internal/testutil/certfixtures"
 )
 
 func TestEndToEndWithGeneratedCert(t *testing.T) {
 	// Setup
-	certsDir := "certs"
-	p12Path := filepath.Join(certsDir, "user.p12")
-	if _, err := os.Stat(p12Path); os.IsNotExist(err) {
-		t.Fatalf("Certificate not found. Run gen_certs.sh first.")
-	}
+	p12 := certfixtures.New(t, certfixtures.Options{}).LegacyPKCS12(t, "password")
 
 	// Setup Store
 	tmpDir := t.TempDir()
@@ -35,17 +32,7 @@ func TestEndToEndWithGeneratedCert(t *testing.T) {
 
 	// Import Identity
 	ctx := context.Background()
-	p12File, err := os.Open(p12Path)
-	if err != nil {
-		t.Fatalf("Open: %v", err)
-	}
-	defer func() {
-		if err := p12File.Close(); err != nil {
-			t.Logf("warning: failed to close p12 file: %v", err)
-		}
-	}()
-
-	identity, err := store.Import(ctx, "Test User", p12File, []byte("password"))
+	identity, err := store.Import(ctx, "Test User", bytes.NewReader(p12), []byte("password"))
 	if err != nil {
 		t.Fatalf("Import: %v", err)
 	}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// Admin endpoints change proposals or hand out signer data, so they answer
+// only requests that carry the admin token: as "Authorization: Bearer
+// <token>", or as the password of HTTP Basic authentication, which lets a
+// browser open the dashboard's download links.
+
+// adminToken is the token admin endpoints require, set with -admin-token.
+var adminToken string
+
+// newAdminToken returns a random token, for a collector started without
+// -admin-token.
+func newAdminToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// authorizeAdmin reports whether r carries the admin token, and answers 401
+// if it does not.
+func authorizeAdmin(w http.ResponseWriter, r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if _, password, basic := r.BasicAuth(); basic {
+		token, ok = password, true
+	}
+	if ok && adminToken != "" && hmac.Equal([]byte(token), []byte(adminToken)) {
+		return true
+	}
+	w.Header().Set("WWW-Authenticate", `Basic realm="VocSign collector", charset="UTF-8"`)
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	return false
+}
+
+// requireAdmin lets only requests with the admin token through to h.
+func requireAdmin(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if authorizeAdmin(w, r) {
+			h(w, r)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+// errUnchanged rejects an amendment that keeps the full text.
+var errUnchanged = errors.New("the full text is unchanged")
+
+// handleAmend replaces the full text of a proposal mid-campaign. The request
+// is published again with the next document version, the new hash and a new
+// nonce, and signatures of earlier versions are rejected from then on. It is
+// served behind requireAdmin.
+func handleAmend(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/amend/")
+
+	var amend struct {
+		URL    string `json:"url"`
+		SHA256 string `json:"sha256"` // base64
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&amend); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if sum, err := base64.StdEncoding.DecodeString(amend.SHA256); err != nil || len(sum) != 32 {
+		http.Error(w, "sha256 must be a base64 SHA-256 digest", http.StatusBadRequest)
+		return
+	}
+
+	req, err := db.AmendProposal(r.Context(), id, func(req *model.SignRequest) error {
+		if amend.SHA256 == req.Proposal.FullText.SHA256 {
+			return errUnchanged
+		}
+		if amend.URL != "" {
+			req.Proposal.FullText.URL = amend.URL
+		}
+		req.Proposal.FullText.SHA256 = amend.SHA256
+		req.Proposal.DocumentVersion = max(req.Proposal.DocumentVersion, 1) + 1
+		req.IssuedAt = time.Now().Format(time.RFC3339)
+		req.Nonce = base64.StdEncoding.EncodeToString([]byte(uuid.New().String()))
+		return signRequest(req)
+	})
+	switch {
+	case errors.Is(err, errNotFound):
+		http.Error(w, "Proposal not found", http.StatusNotFound)
+		return
+	case errors.Is(err, errUnchanged):
+		http.Error(w, "The full text is unchanged", http.StatusConflict)
+		return
+	case err != nil:
+		log.Printf("ERROR: failed to publish amended request %s: %v", id, err)
+		http.Error(w, "Failed to publish the amended request", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Proposal %s amended to document version %d", id, req.Proposal.DocumentVersion)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(req); err != nil {
+		log.Printf("ERROR: failed to encode request: %v", err)
+	}
+}
+
+// checkDocumentVersion returns the error to send when resp signs a proposal
+// text other than the current one of req. The version and hash are read
+// from the signed XML, since the response fields are not signed and a
+// client could set them to anything. Signatures without them, from clients
+// that predate versioning or of an unversioned request, are accepted until
+// the text is first amended.
+func checkDocumentVersion(req *model.SignRequest, resp *model.SignResponse) *model.SubmitError {
+	current := req.Proposal.DocumentVersion
+	version, hash := signedDocumentVersion(resp)
+	if version == 0 && hash == "" && current <= 1 {
+		return nil
+	}
+	if version == current && hash == req.Proposal.FullText.SHA256 {
+		return nil
+	}
+	return &model.SubmitError{
+		Status:          "error",
+		Code:            string(errcode.ProposalChanged),
+		Message:         fmt.Sprintf("signature is for document version %d, current version is %d", version, current),
+		DocumentVersion: current,
+	}
+}
+
+// signedDocumentVersion returns the proposal text version and hash in the
+// signed XML of resp, zero if it has none.
+func signedDocumentVersion(resp *model.SignResponse) (int, string) {
+	var signed model.ILPSignerXML
+	if xmlBytes, err := base64.StdEncoding.DecodeString(resp.SignerXMLBase64); err == nil {
+		_ = xml.Unmarshal(xmlBytes, &signed)
+	}
+	return signed.ILP.VersioText, signed.ILP.ResumText
+}
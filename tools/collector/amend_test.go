@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+)
+
+func TestCheckDocumentVersion(t *testing.T) {
+	newTestCollector(t)
+	v1 := testRequest(t)
+	old := signTestResponse(t, v1)
+
+	v2 := *v1
+	v2.Proposal.DocumentVersion = 2
+	v2.Proposal.FullText.SHA256 = "bmV3IHRleHQ="
+	current := signTestResponse(t, &v2)
+
+	if se := checkDocumentVersion(v1, old); se != nil {
+		t.Errorf("unversioned signature of version 1 rejected: %s", se.Message)
+	}
+	if se := checkDocumentVersion(&v2, current); se != nil {
+		t.Errorf("signature of the current version rejected: %s", se.Message)
+	}
+
+	// The response fields are not signed, so claiming the current version
+	// in them does not help a signature of the old text.
+	old.DocumentVersion = 2
+	old.DocumentSHA256 = v2.Proposal.FullText.SHA256
+	se := checkDocumentVersion(&v2, old)
+	if se == nil || se.Code != string(errcode.ProposalChanged) || se.DocumentVersion != 2 {
+		t.Errorf("signature of the old text = %+v, want %s with version 2", se, errcode.ProposalChanged)
+	}
+
+	v3 := v2
+	v3.Proposal.DocumentVersion = 3
+	if se := checkDocumentVersion(&v3, current); se == nil {
+		t.Error("signature of version 2 accepted at version 3")
+	}
+}
+
+func TestHandleAmend_RequiresAdminToken(t *testing.T) {
+	srv := newTestCollector(t)
+	body := `{"sha256":"bmV3IHRleHQgb2YgdGhlIHByb3Bvc2FsIDMyIGJ5dGU="}`
+
+	resp, err := srv.Client().Post(srv.URL+"/amend/"+testProposal, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized || resp.Header.Get("WWW-Authenticate") == "" {
+		t.Fatalf("amend without token = %d, want 401 with a challenge", resp.StatusCode)
+	}
+	if got := testRequest(t).Proposal.DocumentVersion; got > 1 {
+		t.Fatalf("proposal amended to version %d without the token", got)
+	}
+
+	wrong := adminRequest(t, http.MethodPost, srv.URL+"/amend/"+testProposal, strings.NewReader(body))
+	wrong.Header.Set("Authorization", "Bearer wrong")
+	if resp, err = srv.Client().Do(wrong); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("amend with a wrong token = %d, want 401", resp.StatusCode)
+	}
+
+	req := adminRequest(t, http.MethodPost, srv.URL+"/amend/"+testProposal, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if resp, err = srv.Client().Do(req); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("amend with the token = %d, want 200", resp.StatusCode)
+	}
+	if got := testRequest(t).Proposal.DocumentVersion; got != 2 {
+		t.Errorf("document version after amend = %d, want 2", got)
+	}
+}
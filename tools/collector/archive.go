@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Archival of raw signature artifacts. Started with -archive-bucket, the
+// collector writes every accepted signature to S3-compatible object storage
+// as it arrives:
+//
+//	<prefix>/<requestId>/<receiptId>/response.json  the callback body as received, less any signer contact
+//	<prefix>/<requestId>/<receiptId>/signer.xml     the signed XML
+//	<prefix>/<requestId>/<receiptId>/signature.der  the detached CAdES signature
+//	<prefix>/<requestId>/<receiptId>/timestamp.tsr  the RFC 3161 token, if any
+//
+// The store then keeps only the signature's index (receipt, proposal,
+// time, duplicate check hash and report). Reports and exports read the
+// response back from the archive, and the objects can be re-verified later
+// without the collector.
+
+// archiveConfig configures archival; it is off without a Bucket.
+type archiveConfig struct {
+	Endpoint string // e.g. https://s3.eu-west-1.amazonaws.com or https://storage.googleapis.com
+	Bucket   string
+	Prefix   string
+	Region   string
+	// SSE is the server-side encryption to request for every object,
+	// "AES256" or "aws:kms", with KMSKey the key for the latter.
+	SSE    string
+	KMSKey string
+	// Lifecycle rule for the prefix, applied at startup if either is set.
+	TransitionDays  int
+	TransitionClass string
+	ExpireDays      int
+}
+
+type archive struct {
+	client *s3Client
+	prefix string
+	header http.Header // sent with every object
+}
+
+// signatureArchive is nil when archival is off.
+var signatureArchive *archive
+
+// newArchive connects to the bucket in cfg with the credentials in the
+// standard AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+// variables (HMAC keys for Google Cloud Storage).
+func newArchive(cfg archiveConfig) (*archive, error) {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid archive endpoint %q", cfg.Endpoint)
+	}
+	c := &s3Client{
+		endpoint:     endpoint,
+		bucket:       cfg.Bucket,
+		region:       cfg.Region,
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		http:         &http.Client{Timeout: 30 * time.Second},
+	}
+	if c.accessKey == "" || c.secretKey == "" {
+		return nil, errors.New("archival requires AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	a := &archive{client: c, prefix: strings.Trim(cfg.Prefix, "/"), header: http.Header{}}
+	switch cfg.SSE {
+	case "":
+	case "AES256", "aws:kms":
+		a.header.Set("X-Amz-Server-Side-Encryption", cfg.SSE)
+	default:
+		return nil, fmt.Errorf("unknown server-side encryption %q", cfg.SSE)
+	}
+	if cfg.KMSKey != "" {
+		if cfg.SSE != "aws:kms" {
+			return nil, errors.New("a KMS key requires aws:kms server-side encryption")
+		}
+		a.header.Set("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id", cfg.KMSKey)
+	}
+	return a, nil
+}
+
+// storeSignature writes the artifacts of an accepted signature and returns
+// the key they are stored under.
+func (a *archive) storeSignature(ctx context.Context, requestID, receiptID string, raw, xmlBytes, sigBytes, token []byte) (string, error) {
+	key := path.Join(a.prefix, requestID, receiptID)
+	objects := map[string][]byte{
+		"response.json": raw,
+		"signer.xml":    xmlBytes,
+		"signature.der": sigBytes,
+	}
+	if len(token) > 0 {
+		objects["timestamp.tsr"] = token
+	}
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for name, data := range objects {
+		wg.Go(func() {
+			if err := a.client.put(ctx, key+"/"+name, bytes.NewReader(data), int64(len(data)), a.header); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	return key, errors.Join(errs...)
+}
+
+// loadResponse reads rec.Response back from the archive if the store only
+// keeps the signature's index.
+func loadResponse(ctx context.Context, rec *signatureRecord) error {
+	if rec.ArchiveKey == "" || rec.Response.SignatureDerBase64 != "" {
+		return nil
+	}
+	if signatureArchive == nil {
+		return fmt.Errorf("signature %s is archived at %s, but archival is not configured", rec.ReceiptID, rec.ArchiveKey)
+	}
+	body, err := signatureArchive.client.get(ctx, rec.ArchiveKey+"/response.json")
+	if err != nil {
+		return fmt.Errorf("failed to read archived response: %w", err)
+	}
+	defer func() { _ = body.Close() }()
+	if err := json.NewDecoder(io.LimitReader(body, maxCallbackBody)).Decode(&rec.Response); err != nil {
+		return fmt.Errorf("invalid archived response %s: %w", rec.ArchiveKey, err)
+	}
+	return nil
+}
+
+// lifecycleRuleID identifies the collector's rule among the bucket's.
+const lifecycleRuleID = "vocsign-signature-archive"
+
+type lifecycleRule struct {
+	XMLName    xml.Name             `xml:"Rule"`
+	ID         string               `xml:"ID"`
+	Prefix     string               `xml:"Filter>Prefix"`
+	Status     string               `xml:"Status"`
+	Transition *lifecycleTransition `xml:"Transition,omitempty"`
+	Expiration *lifecycleExpiration `xml:"Expiration,omitempty"`
+}
+
+type lifecycleTransition struct {
+	Days         int    `xml:"Days"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+type lifecycleExpiration struct {
+	Days int `xml:"Days"`
+}
+
+// rawLifecycleRule is a rule of the bucket kept as is.
+type rawLifecycleRule struct {
+	XMLName xml.Name `xml:"Rule"`
+	Inner   string   `xml:",innerxml"`
+}
+
+// applyLifecycle sets the bucket's lifecycle rule for the archive prefix:
+// objects move to cfg.TransitionClass after cfg.TransitionDays and are
+// deleted after cfg.ExpireDays. Rules for other prefixes are kept. Google
+// Cloud Storage uses its own lifecycle format, so set it there with gcloud.
+func (a *archive) applyLifecycle(ctx context.Context, cfg archiveConfig) error {
+	rule := lifecycleRule{ID: lifecycleRuleID, Prefix: a.prefix + "/", Status: "Enabled"}
+	if cfg.TransitionDays > 0 {
+		if cfg.TransitionClass == "" {
+			return errors.New("a transition requires a storage class")
+		}
+		rule.Transition = &lifecycleTransition{Days: cfg.TransitionDays, StorageClass: cfg.TransitionClass}
+	}
+	if cfg.ExpireDays > 0 {
+		rule.Expiration = &lifecycleExpiration{Days: cfg.ExpireDays}
+	}
+
+	var current struct {
+		Rules []struct {
+			ID    string `xml:"ID"`
+			Inner string `xml:",innerxml"`
+		} `xml:"Rule"`
+	}
+	resp, err := a.client.do(ctx, http.MethodGet, "", "lifecycle", nil, nil)
+	switch {
+	case err == nil:
+		err = xml.NewDecoder(resp.Body).Decode(&current)
+		_ = resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("invalid lifecycle configuration: %w", err)
+		}
+	case !errors.Is(err, errNotFound):
+		return err
+	}
+
+	config := struct {
+		XMLName xml.Name `xml:"LifecycleConfiguration"`
+		Xmlns   string   `xml:"xmlns,attr"`
+		Rules   []any
+	}{Xmlns: "http://s3.amazonaws.com/doc/2006-03-01/"}
+	for _, r := range current.Rules {
+		if r.ID != lifecycleRuleID {
+			config.Rules = append(config.Rules, rawLifecycleRule{Inner: r.Inner})
+		}
+	}
+	config.Rules = append(config.Rules, rule)
+	body, err := xml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	sum := md5.Sum(body)
+	header := http.Header{}
+	header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	resp, err = a.client.do(ctx, http.MethodPut, "", "lifecycle", body, header)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
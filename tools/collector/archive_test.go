@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	vnet "github.com/vocdoni/gofirma/vocsign/internal/net"
+)
+
+// fakeS3 is an object store that keeps objects in memory by their escaped
+// path, as sent on the wire.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	headers map[string]http.Header
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=test-key/") {
+		http.Error(w, "unsigned request", http.StatusForbidden)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil || int64(len(body)) != r.ContentLength {
+			http.Error(w, "short body", http.StatusBadRequest)
+			return
+		}
+		f.objects[r.URL.EscapedPath()] = body
+		f.headers[r.URL.EscapedPath()] = r.Header.Clone()
+	case http.MethodGet:
+		body, ok := f.objects[r.URL.EscapedPath()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(body)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func TestArchive_Callback(t *testing.T) {
+	newTestCollector(t)
+	s3 := &fakeS3{objects: make(map[string][]byte), headers: make(map[string]http.Header)}
+	s3srv := httptest.NewServer(s3)
+	defer s3srv.Close()
+	t.Setenv("AWS_ACCESS_KEY_ID", "test-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test-secret")
+	a, err := newArchive(archiveConfig{Endpoint: s3srv.URL, Bucket: "archive", Prefix: "/sigs/", Region: "eu-west-1", SSE: "AES256"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signatureArchive = a
+	defer func() {
+		reports.Wait()
+		signatureArchive = nil
+	}()
+
+	ctx := context.Background()
+	req := testRequest(t)
+	resp := signTestResponse(t, req)
+	receipt, err := vnet.Submit(ctx, req.Callback.URL, resp)
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	rec, err := db.Receipt(ctx, receipt.ReceiptID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantKey := "sigs/" + testProposal + "/" + receipt.ReceiptID
+	if rec.ArchiveKey != wantKey {
+		t.Fatalf("ArchiveKey = %q, want %q", rec.ArchiveKey, wantKey)
+	}
+	if rec.Response.SignatureDerBase64 != "" {
+		t.Error("store kept the archived response")
+	}
+	for _, name := range []string{"response.json", "signer.xml", "signature.der"} {
+		path := "/archive/" + wantKey + "/" + name
+		if len(s3.objects[path]) == 0 {
+			t.Errorf("%s not archived", path)
+			continue
+		}
+		h := s3.headers[path]
+		if h.Get("X-Amz-Server-Side-Encryption") != "AES256" || h.Get("X-Amz-Content-Sha256") != unsignedPayload {
+			t.Errorf("%s headers = %v", path, h)
+		}
+	}
+
+	// Reports read the response back from the archive.
+	if err := loadResponse(ctx, rec); err != nil {
+		t.Fatalf("loadResponse: %v", err)
+	}
+	if rec.Response.SignatureDerBase64 != resp.SignatureDerBase64 {
+		t.Error("archived response differs from the submitted one")
+	}
+	reports.Wait()
+	if rec, err := db.Receipt(ctx, receipt.ReceiptID); err != nil || rec.Report == nil || rec.Report.ReceiptID != receipt.ReceiptID {
+		t.Errorf("stored report = %+v, %v", rec, err)
+	}
+}
+
+func TestS3Client_EscapedKey(t *testing.T) {
+	s3 := &fakeS3{objects: make(map[string][]byte), headers: make(map[string]http.Header)}
+	srv := httptest.NewServer(s3)
+	defer srv.Close()
+	t.Setenv("AWS_ACCESS_KEY_ID", "test-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test-secret")
+	a, err := newArchive(archiveConfig{Endpoint: srv.URL, Bucket: "archive", Region: "us-east-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The path on the wire is the one signed, so keys with characters Go
+	// leaves unescaped in paths still verify.
+	ctx := context.Background()
+	key := "ILP $1/r+1=a&b.json"
+	if err := a.client.put(ctx, key, strings.NewReader("{}"), 2, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s3.objects["/archive/ILP%20%241/r%2B1%3Da%26b.json"]; !ok {
+		t.Fatalf("stored paths = %v", s3.objects)
+	}
+	body, err := a.client.get(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+	if data, _ := io.ReadAll(body); string(data) != "{}" {
+		t.Errorf("get = %q", data)
+	}
+	if _, err := a.client.get(ctx, "missing"); err == nil || !strings.Contains(err.Error(), errNotFound.Error()) {
+		t.Errorf("get of a missing object = %v", err)
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/smallstep/pkcs7"
+	"github.com/vocdoni/gofirma/vocsign/pkg/cadesverify"
+)
+
+// Audit sync. A request's auditSync endpoint receives the audit log entries
+// of the signatures a certifying agent ("fedatari") collected for it, in a
+// manifest the agent signs with their certificate. Entries are identified by
+// the hash of their line, so uploading the same entries again only counts
+// them as duplicates. Promoters reconcile the stored entries with the
+// accepted signatures.
+
+// maxAuditSyncBody bounds an uploaded audit bundle.
+const maxAuditSyncBody = 16 << 20
+
+// auditManifestVersion is the manifest format the collector reads.
+const auditManifestVersion = 1
+
+// auditBundle is an upload, as the client sends it: the manifest JSON and
+// the agent's CAdES detached signature over its exact bytes.
+type auditBundle struct {
+	Manifest  []byte `json:"manifest"`
+	Signature []byte `json:"signature"`
+}
+
+// auditManifest is the signed manifest, as the client writes it.
+type auditManifest struct {
+	Version              int    `json:"version"`
+	RequestID            string `json:"requestId"`
+	AgentCertFingerprint string `json:"agentCertFingerprint"`
+	ChainHead            string `json:"chainHead"`
+	Records              []struct {
+		Hash string          `json:"hash"`
+		Line json.RawMessage `json:"line"`
+	} `json:"records"`
+}
+
+// auditSyncReceipt is the answer to an upload. Duplicates are entries
+// already stored by an earlier upload.
+type auditSyncReceipt struct {
+	Accepted   int `json:"accepted"`
+	Duplicates int `json:"duplicates"`
+}
+
+// auditRecord is an audit log entry a certifying agent uploaded.
+type auditRecord struct {
+	RequestID        string
+	Hash             string
+	Line             json.RawMessage
+	AgentFingerprint string
+	ReceivedAt       time.Time
+}
+
+// handleAuditSync stores the audit entries a certifying agent uploads to
+// POST /audit/{id}, after checking the agent's signature over the manifest
+// and the hash of every entry. GET exports the stored entries for the
+// promoter, one JSON line each, and needs the admin token.
+func handleAuditSync(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/audit/")
+	if r.Method != http.MethodPost && r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if _, ok := loadProposal(w, r, id); !ok {
+		return
+	}
+	if r.Method == http.MethodGet {
+		if authorizeAdmin(w, r) {
+			exportAuditRecords(w, r, id)
+		}
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxAuditSyncBody))
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	var bundle auditBundle
+	if err := json.Unmarshal(body, &bundle); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	records, err := checkAuditBundle(id, &bundle, time.Now())
+	if err != nil {
+		log.Printf("WARNING: rejected audit upload for %s: %v", id, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	accepted, duplicates, err := db.AddAuditRecords(r.Context(), id, records)
+	if err != nil {
+		log.Printf("ERROR: failed to store audit entries for %s: %v", id, err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Stored %d audit entries for %s from agent %s (%d duplicates)", accepted, id, records[0].AgentFingerprint, duplicates)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(auditSyncReceipt{Accepted: accepted, Duplicates: duplicates}); err != nil {
+		log.Printf("ERROR: failed to encode audit sync receipt: %v", err)
+	}
+}
+
+// checkAuditBundle checks an upload for proposal id received at now and
+// returns its entries.
+func checkAuditBundle(id string, bundle *auditBundle, now time.Time) ([]auditRecord, error) {
+	var m auditManifest
+	if err := json.Unmarshal(bundle.Manifest, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if m.Version != auditManifestVersion {
+		return nil, fmt.Errorf("unsupported manifest version %d", m.Version)
+	}
+	if m.RequestID != id {
+		return nil, fmt.Errorf("manifest is for %q", m.RequestID)
+	}
+	if len(m.Records) == 0 {
+		return nil, errors.New("manifest holds no entries")
+	}
+
+	agent, err := verifyAuditAgent(bundle, now)
+	if err != nil {
+		return nil, err
+	}
+	fp := sha256.Sum256(agent.Raw)
+	if !strings.EqualFold(m.AgentCertFingerprint, hex.EncodeToString(fp[:])) {
+		return nil, errors.New("manifest names another agent certificate than the one that signed it")
+	}
+
+	records := make([]auditRecord, 0, len(m.Records))
+	for i, rec := range m.Records {
+		sum := sha256.Sum256(rec.Line)
+		if !strings.EqualFold(rec.Hash, hex.EncodeToString(sum[:])) {
+			return nil, fmt.Errorf("entry %d does not match its hash", i)
+		}
+		var entry struct {
+			RequestID string `json:"requestId"`
+		}
+		if err := json.Unmarshal(rec.Line, &entry); err != nil {
+			return nil, fmt.Errorf("entry %d is not JSON: %w", i, err)
+		}
+		if entry.RequestID != id {
+			return nil, fmt.Errorf("entry %d is for %q", i, entry.RequestID)
+		}
+		records = append(records, auditRecord{
+			RequestID:        id,
+			Hash:             strings.ToLower(rec.Hash),
+			Line:             rec.Line,
+			AgentFingerprint: hex.EncodeToString(fp[:]),
+			ReceivedAt:       now,
+		})
+	}
+	return records, nil
+}
+
+// verifyAuditAgent checks the agent's signature over the manifest and
+// returns the agent's certificate. Started with -trust-roots, the
+// certificate must also chain to one of them; otherwise any certificate is
+// accepted, as for signatures, and promoters judge the agent by its
+// fingerprint.
+func verifyAuditAgent(bundle *auditBundle, now time.Time) (*x509.Certificate, error) {
+	signers, err := cadesverify.VerifySignature(bundle.Manifest, bundle.Signature)
+	if err != nil {
+		return nil, err
+	}
+	if len(signers) != 1 {
+		return nil, fmt.Errorf("manifest has %d signers, want 1", len(signers))
+	}
+	agent := signers[0]
+	if trustRoots == nil {
+		return agent, nil
+	}
+	p7, err := pkcs7.Parse(bundle.Signature)
+	if err != nil {
+		return nil, err
+	}
+	intermediates := x509.NewCertPool()
+	for _, c := range p7.Certificates {
+		intermediates.AddCert(c)
+	}
+	_, err = agent.Verify(x509.VerifyOptions{
+		Roots:         trustRoots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("agent certificate does not lead to a trusted root: %w", err)
+	}
+	return agent, nil
+}
+
+// exportAuditRecords writes the audit entries stored for proposal id, each
+// with the agent that uploaded it.
+func exportAuditRecords(w http.ResponseWriter, r *http.Request, id string) {
+	records, err := db.AuditRecords(r.Context(), id)
+	if err != nil {
+		log.Printf("ERROR: failed to load audit entries for %s: %v", id, err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-audit.jsonl"`, id))
+	enc := json.NewEncoder(w)
+	for _, rec := range records {
+		err := enc.Encode(struct {
+			Hash             string          `json:"hash"`
+			AgentFingerprint string          `json:"agentCertFingerprint"`
+			ReceivedAt       string          `json:"receivedAt"`
+			Line             json.RawMessage `json:"line"`
+		}{rec.Hash, rec.AgentFingerprint, rec.ReceivedAt.UTC().Format(time.RFC3339), rec.Line})
+		if err != nil {
+			log.Printf("ERROR: failed to write audit entries for %s: %v", id, err)
+			return
+		}
+	}
+}
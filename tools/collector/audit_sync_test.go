@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/cades"
+	vnet "github.com/vocdoni/gofirma/vocsign/internal/net"
+	"github.com/vocdoni/gofirma/vocsign/internal/storage"
+	"github.com/vocdoni/gofirma/vocsign/internal/testutil/certfixtures"
+)
+
+// testAuditBundle signs a manifest of records as a certifying agent with a
+// throwaway certificate, letting edit change the manifest first.
+func testAuditBundle(t *testing.T, records []storage.AuditRecord, head string, edit func(m *storage.AuditManifest)) *vnet.AuditBundle {
+	t.Helper()
+	id := certfixtures.New(t, certfixtures.Options{Key: certfixtures.ECDSA})
+	fp := sha256.Sum256(id.Cert.Raw)
+	m := storage.NewAuditManifest(records, testProposal, hex.EncodeToString(fp[:]), head)
+	if edit != nil {
+		edit(&m)
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := cades.SignDetached(context.Background(), id.Key, id.Cert, nil, data, cades.SignOpts{SigningTime: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &vnet.AuditBundle{Manifest: data, Signature: sig}
+}
+
+func TestAuditSync(t *testing.T) {
+	srv := newTestCollector(t)
+	req := testRequest(t)
+	if req.AuditSync == nil {
+		t.Fatal("published request has no auditSync endpoint")
+	}
+	ctx := context.Background()
+
+	logger, err := storage.NewAuditLogger(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range []string{"success", "error"} {
+		if err := logger.Log(storage.AuditEntry{RequestID: testProposal, CallbackHost: "collector", CertFingerprint: "f1", Status: status, AgentCertified: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	records, head, err := logger.Records()
+	if err != nil || len(records) != 2 {
+		t.Fatalf("Records = %d, %v", len(records), err)
+	}
+
+	receipt, err := vnet.SyncAudit(ctx, req.AuditSync.URL, testAuditBundle(t, records, head, nil))
+	if err != nil {
+		t.Fatalf("SyncAudit: %v", err)
+	}
+	if receipt.Accepted != 2 || receipt.Duplicates != 0 {
+		t.Errorf("first upload = %+v, want 2 accepted", receipt)
+	}
+
+	// Uploading the same entries again, even from another agent, stores
+	// nothing new.
+	receipt, err = vnet.SyncAudit(ctx, req.AuditSync.URL, testAuditBundle(t, records, head, nil))
+	if err != nil {
+		t.Fatalf("SyncAudit again: %v", err)
+	}
+	if receipt.Accepted != 0 || receipt.Duplicates != 2 {
+		t.Errorf("second upload = %+v, want 2 duplicates", receipt)
+	}
+	stored, err := db.AuditRecords(ctx, testProposal)
+	if err != nil || len(stored) != 2 || stored[0].Hash != records[0].Hash {
+		t.Fatalf("AuditRecords = %+v, %v", stored, err)
+	}
+
+	rejected := map[string]func(m *storage.AuditManifest){
+		"tampered entry": func(m *storage.AuditManifest) {
+			m.Records[0].Line = json.RawMessage(`{"requestId":"ILP-TEST","status":"success","signerDni":"00000000T"}`)
+		},
+		"other request": func(m *storage.AuditManifest) { m.RequestID = "ILP-OTHER" },
+		"other agent":   func(m *storage.AuditManifest) { m.AgentCertFingerprint = "00" },
+		"no entries":    func(m *storage.AuditManifest) { m.Records = nil },
+	}
+	for name, edit := range rejected {
+		if _, err := vnet.SyncAudit(ctx, req.AuditSync.URL, testAuditBundle(t, records, head, edit)); err == nil {
+			t.Errorf("%s: upload accepted", name)
+		}
+	}
+	// A manifest changed after it was signed does not verify.
+	bundle := testAuditBundle(t, records, head, nil)
+	bundle.Manifest = append(bundle.Manifest[:len(bundle.Manifest)-1], ' ', '}')
+	if _, err := vnet.SyncAudit(ctx, req.AuditSync.URL, bundle); err == nil {
+		t.Error("upload with a manifest changed after signing accepted")
+	}
+
+	resp, err := srv.Client().Get(req.AuditSync.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("export without token = %d, want 401", resp.StatusCode)
+	}
+	if resp, err = srv.Client().Do(adminRequest(t, http.MethodGet, req.AuditSync.URL, nil)); err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("export status = %d", resp.StatusCode)
+	}
+	lines := 0
+	for sc := bufio.NewScanner(resp.Body); sc.Scan(); lines++ {
+		var rec struct {
+			Hash string `json:"hash"`
+		}
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil || rec.Hash != records[lines].Hash {
+			t.Errorf("export line %d = %s, %v", lines, sc.Bytes(), err)
+		}
+	}
+	if lines != 2 {
+		t.Errorf("export has %d lines, want 2", lines)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+// Signer contacts. A request with a contactRequest lets signers share an
+// email address or phone number for campaign follow-up, with a consent of
+// its own. The contact arrives in the extensions of the signature response
+// and is taken out before the response is archived or stored: it is kept
+// apart from the signatures, with no reference to any of them, and deleted
+// once the request's retentionDays have passed since it was received.
+
+// contactPurgeInterval is how often expired contacts are deleted.
+const contactPurgeInterval = time.Hour
+
+// contactRecord is a contact shared by a signer of a proposal.
+type contactRecord struct {
+	RequestID  string
+	Contact    model.SignerContact
+	ReceivedAt time.Time
+	ExpiresAt  time.Time
+}
+
+// takeContact removes the contact from resp and returns it, or nil.
+func takeContact(resp *model.SignResponse) *model.SignerContact {
+	ext := resp.Extensions
+	resp.Extensions = nil
+	if ext == nil {
+		return nil
+	}
+	return ext.Contact
+}
+
+// storeContact keeps a contact shared with a signature of req accepted at
+// receivedAt. A contact that req did not ask for, or that does not answer
+// it, is dropped; the signature stands either way.
+func storeContact(ctx context.Context, req *model.SignRequest, c *model.SignerContact, receivedAt time.Time) {
+	cr := req.ContactRequest
+	if cr == nil {
+		log.Printf("WARNING: dropped a contact for %s, which does not ask for one", req.RequestID)
+		return
+	}
+	if _, err := cr.Contact(c.Email, c.Phone, receivedAt); err != nil {
+		log.Printf("WARNING: dropped an invalid contact for %s: %v", req.RequestID, err)
+		return
+	}
+	if c.ConsentText != cr.ConsentWording() {
+		log.Printf("WARNING: dropped a contact for %s consented with different wording", req.RequestID)
+		return
+	}
+	err := db.AddContact(ctx, &contactRecord{
+		RequestID:  req.RequestID,
+		Contact:    *c,
+		ReceivedAt: receivedAt,
+		ExpiresAt:  receivedAt.AddDate(0, 0, cr.RetentionDays),
+	})
+	if err != nil {
+		log.Printf("ERROR: failed to store contact for %s: %v", req.RequestID, err)
+	}
+}
+
+// purgeContacts deletes expired contacts until ctx is done.
+func purgeContacts(ctx context.Context) {
+	ticker := time.NewTicker(contactPurgeInterval)
+	defer ticker.Stop()
+	for {
+		n, err := db.PurgeContacts(ctx, time.Now())
+		if err != nil && ctx.Err() == nil {
+			log.Printf("ERROR: failed to delete expired contacts: %v", err)
+		} else if n > 0 {
+			log.Printf("Deleted %d expired signer contacts", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// handleContacts exports the unexpired contacts of a proposal as CSV for
+// the promoter, behind requireAdmin.
+func handleContacts(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/contacts/")
+	if _, ok := loadProposal(w, r, id); !ok {
+		return
+	}
+	contacts, err := db.Contacts(r.Context(), id, time.Now())
+	if err != nil {
+		log.Printf("ERROR: failed to load contacts for %s: %v", id, err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-contacts.csv"`, id))
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"email", "phone", "consentedAt", "expiresAt", "consentText"})
+	for _, c := range contacts {
+		_ = cw.Write([]string{c.Contact.Email, c.Contact.Phone, c.Contact.ConsentedAt, c.ExpiresAt.UTC().Format(time.RFC3339), c.Contact.ConsentText})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		log.Printf("ERROR: failed to write contacts for %s: %v", id, err)
+	}
+}
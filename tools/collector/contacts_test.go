@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+	vnet "github.com/vocdoni/gofirma/vocsign/internal/net"
+)
+
+func TestCallback_Contact(t *testing.T) {
+	srv := newTestCollector(t)
+	ctx := context.Background()
+	const id = "ILP-CONTACT"
+	cr := &model.ContactRequest{Fields: []string{model.ContactEmail}, Purpose: "Campaign news", RetentionDays: 30}
+	addProposal(ctx, id, "Contact proposal", "Test promoters", "A proposal that asks for a contact.", proposalOptions{Contact: cr})
+	req, err := db.Proposal(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	submit := func(c *model.SignerContact) *model.SubmitReceipt {
+		t.Helper()
+		resp := signTestResponse(t, req)
+		resp.Extensions = &model.ResponseExtensions{Contact: c}
+		receipt, err := vnet.Submit(ctx, req.Callback.URL, resp)
+		if err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+		return receipt
+	}
+	now := time.Now().Format(time.RFC3339)
+	receipt := submit(&model.SignerContact{Email: "maria@example.org", ConsentText: cr.ConsentWording(), ConsentedAt: now})
+	// A contact consented with other wording, or with a field the request
+	// does not ask for, is dropped, and the signature still accepted.
+	submit(&model.SignerContact{Email: "pere@example.org", ConsentText: "I agree", ConsentedAt: now})
+	submit(&model.SignerContact{Phone: "+34600000000", ConsentText: cr.ConsentWording(), ConsentedAt: now})
+
+	contacts, err := db.Contacts(ctx, id, time.Now())
+	if err != nil || len(contacts) != 1 || contacts[0].Contact.Email != "maria@example.org" {
+		t.Fatalf("Contacts = %+v, %v", contacts, err)
+	}
+	if got := contacts[0].ExpiresAt.Sub(contacts[0].ReceivedAt); got != 30*24*time.Hour {
+		t.Errorf("contact kept for %v, want 30 days", got)
+	}
+	sigs, err := db.Signatures(ctx, id)
+	if err != nil || len(sigs) != 3 {
+		t.Fatalf("Signatures = %d, %v", len(sigs), err)
+	}
+	rec, err := db.Receipt(ctx, receipt.ReceiptID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Response.Extensions != nil {
+		t.Error("the stored signature keeps the contact")
+	}
+
+	resp, err := srv.Client().Get(srv.URL + "/contacts/" + id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("contacts export without token = %d, want 401", resp.StatusCode)
+	}
+	if resp, err = srv.Client().Do(adminRequest(t, http.MethodGet, srv.URL+"/contacts/"+id, nil)); err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	rows, err := csv.NewReader(resp.Body).ReadAll()
+	if resp.StatusCode != http.StatusOK || err != nil || len(rows) != 2 || rows[1][0] != "maria@example.org" {
+		t.Fatalf("contacts export = %d %v, %v", resp.StatusCode, rows, err)
+	}
+
+	// Expired contacts are purged.
+	if n, err := db.PurgeContacts(ctx, time.Now().AddDate(0, 0, 31)); err != nil || n != 1 {
+		t.Errorf("PurgeContacts = %d, %v", n, err)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/cades"
+)
+
+// collectorCert is the self-signed certificate for organizerKey that the
+// collector counter-signs received signatures with.
+var collectorCert *x509.Certificate
+
+// collectorCertNotBefore starts the validity of every collector
+// certificate.
+var collectorCertNotBefore = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// newCollectorCert issues a self-signed certificate for key. The
+// certificate depends on the key alone, so replicas sharing the organizer
+// key counter-sign with the same certificate.
+func newCollectorCert(key *rsa.PrivateKey) (*x509.Certificate, error) {
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(pub)
+	tmpl := &x509.Certificate{
+		SerialNumber: new(big.Int).SetBytes(sum[:16]),
+		Subject:      pkix.Name{CommonName: "VocSign Collector", Organization: []string{"VocSign"}},
+		NotBefore:    collectorCertNotBefore,
+		NotAfter:     collectorCertNotBefore.AddDate(10, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	// PKCS#1 v1.5 signatures are deterministic, so rand is not used.
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(der)
+}
+
+// counterSign returns the base64 countersignature acknowledging sigBytes.
+func counterSign(sigBytes []byte, receivedAt time.Time) (string, error) {
+	der, err := cades.CounterSign(organizerKey, collectorCert, nil, sigBytes, receivedAt)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(der), nil
+}
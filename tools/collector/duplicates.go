@@ -0,0 +1,107 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The signer hash of the duplicate check is salted with a value published
+// in the request, so anyone could hash every possible DNI and ask which
+// ones signed. The collector therefore stores only a tag of the hash under
+// a key derived from the organizer key, answers only whether a given hash
+// signed, and caps how many questions each address asks.
+
+// duplicateTag returns the stored form of a duplicate check signer hash.
+func duplicateTag(signerHash string) string {
+	key := sha256.Sum256(append([]byte("vocsign duplicate pepper\x00"), organizerKey.D.Bytes()...))
+	mac := hmac.New(sha256.New, key[:])
+	mac.Write([]byte(signerHash))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// duplicateLimit caps the duplicate checks of each client address.
+var duplicateLimit = &rateLimit{max: 30, window: time.Minute}
+
+// rateLimit lets each client address make at most max requests per window.
+type rateLimit struct {
+	max    int
+	window time.Duration
+
+	mu    sync.Mutex
+	start time.Time
+	count map[string]int
+}
+
+// allow counts a request from addr and reports whether it is within the
+// limit.
+func (l *rateLimit) allow(addr string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.start) >= l.window {
+		l.start = now
+		l.count = make(map[string]int)
+	}
+	l.count[addr]++
+	return l.count[addr] <= l.max
+}
+
+// wrap answers 429 to clients over the limit and passes the rest to h.
+func (l *rateLimit) wrap(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		addr, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			addr = r.RemoteAddr
+		}
+		if !l.allow(addr, time.Now()) {
+			w.Header().Set("Retry-After", "60")
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// handleDuplicates answers duplicate checks: given the salted signer hash
+// of a proposal it tells whether that signer already signed.
+func handleDuplicates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/duplicates/")
+	if _, ok := loadProposal(w, r, id); !ok {
+		return
+	}
+
+	var q struct {
+		SignerHash string `json:"signerHash"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&q); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if sum, err := hex.DecodeString(q.SignerHash); err != nil || len(sum) != sha256.Size {
+		http.Error(w, "signerHash must be a hex SHA-256 digest", http.StatusBadRequest)
+		return
+	}
+
+	signed, err := db.HasSigner(r.Context(), id, duplicateTag(strings.ToLower(q.SignerHash)))
+	if err != nil {
+		log.Printf("ERROR: duplicate check failed for %s: %v", id, err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]bool{"signed": signed}); err != nil {
+		log.Printf("ERROR: failed to encode duplicate check result: %v", err)
+	}
+}
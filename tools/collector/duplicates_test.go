@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	vnet "github.com/vocdoni/gofirma/vocsign/internal/net"
+)
+
+func TestDuplicateCheck(t *testing.T) {
+	newTestCollector(t)
+	ctx := context.Background()
+	req := testRequest(t)
+
+	if dup, err := vnet.CheckDuplicate(ctx, req, "12345678Z"); err != nil || dup {
+		t.Fatalf("CheckDuplicate before signing = %v, %v; want false", dup, err)
+	}
+	if _, err := vnet.Submit(ctx, req.Callback.URL, signTestResponse(t, req)); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if dup, err := vnet.CheckDuplicate(ctx, req, "12345678z"); err != nil || !dup {
+		t.Fatalf("CheckDuplicate after signing = %v, %v; want true", dup, err)
+	}
+	if dup, err := vnet.CheckDuplicate(ctx, req, "87654321X"); err != nil || dup {
+		t.Fatalf("CheckDuplicate of another signer = %v, %v; want false", dup, err)
+	}
+
+	// Only the keyed tag is stored, not the hash anyone can compute.
+	p := db.(*memStore).proposals[testProposal]
+	if hash := req.DuplicateCheck.SignerHash(testProposal, "12345678Z"); p.signerTags[hash] {
+		t.Error("the bare signer hash is stored")
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	l := &rateLimit{max: 2, window: time.Minute}
+	now := time.Now()
+	for i := range 2 {
+		if !l.allow("192.0.2.1", now) {
+			t.Fatalf("request %d refused", i+1)
+		}
+	}
+	if l.allow("192.0.2.1", now) {
+		t.Error("request over the limit allowed")
+	}
+	if !l.allow("192.0.2.2", now) {
+		t.Error("another address refused")
+	}
+	if !l.allow("192.0.2.1", now.Add(time.Minute)) {
+		t.Error("request of the next window refused")
+	}
+}
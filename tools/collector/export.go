@@ -0,0 +1,206 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+// Batch export for formal submission to the Junta Electoral / Parlament.
+// The archive contains one XML document per signer (signer data, the
+// detached CAdES signature and the signing certificate) plus a manifest
+// listing every file with its SHA-256.
+
+const exportFormatVersion = "1.0"
+
+type exportSignature struct {
+	XMLName          xml.Name        `xml:"ExpedientSignatura"`
+	Versio           string          `xml:"versio,attr"`
+	Ordre            int             `xml:"ordre,attr"`
+	Signant          model.Signant   `xml:"Signant"`
+	ILP              model.ILPInfo   `xml:"ILP"`
+	DataSignatura    string          `xml:"DataSignatura"`
+	DocumentSignat   exportEmbedded  `xml:"DocumentSignat"`
+	SignaturaCAdES   exportEmbedded  `xml:"SignaturaCAdES"`
+	SegellTemps      *exportEmbedded `xml:"SegellTemps,omitempty"`
+	CertificatSignat string          `xml:"CertificatSignant"`
+	Cadena           []string        `xml:"CadenaCertificats>Certificat,omitempty"`
+}
+
+type exportEmbedded struct {
+	Codificacio string `xml:"codificacio,attr"`
+	SHA256      string `xml:"sha256,attr"`
+	Valor       string `xml:",chardata"`
+}
+
+type exportManifest struct {
+	XMLName         xml.Name             `xml:"ManifestLliurament"`
+	Versio          string               `xml:"versio,attr"`
+	ILP             model.ILPInfo        `xml:"ILP"`
+	Promotor        string               `xml:"Promotor"`
+	Ambit           string               `xml:"Ambit"`
+	DataGeneracio   string               `xml:"DataGeneracio"`
+	TotalSignatures int                  `xml:"TotalSignatures"`
+	Fitxers         []exportManifestFile `xml:"Fitxers>Fitxer"`
+}
+
+type exportManifestFile struct {
+	Nom    string `xml:"nom,attr"`
+	Mida   int    `xml:"mida,attr"`
+	SHA256 string `xml:"sha256,attr"`
+}
+
+func embed(raw []byte) exportEmbedded {
+	sum := sha256.Sum256(raw)
+	return exportEmbedded{
+		Codificacio: "base64",
+		SHA256:      hex.EncodeToString(sum[:]),
+		Valor:       base64.StdEncoding.EncodeToString(raw),
+	}
+}
+
+func buildSignatureDocument(req model.SignRequest, order int, resp model.SignResponse) ([]byte, error) {
+	xmlBytes, err := base64.StdEncoding.DecodeString(resp.SignerXMLBase64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signer xml: %w", err)
+	}
+	sigBytes, err := base64.StdEncoding.DecodeString(resp.SignatureDerBase64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+	var signer model.ILPSignerXML
+	if err := xml.Unmarshal(xmlBytes, &signer); err != nil {
+		return nil, fmt.Errorf("failed to parse signer xml: %w", err)
+	}
+
+	doc := exportSignature{
+		Versio:           exportFormatVersion,
+		Ordre:            order,
+		Signant:          signer.Signant,
+		ILP:              model.ILPInfo{Titol: req.Proposal.Title, Codi: req.RequestID},
+		DataSignatura:    resp.SignedAt,
+		DocumentSignat:   embed(xmlBytes),
+		SignaturaCAdES:   embed(sigBytes),
+		CertificatSignat: strings.TrimSpace(resp.SignerCertPEM),
+	}
+	if resp.TimestampTokenBase64 != "" {
+		ts, err := base64.StdEncoding.DecodeString(resp.TimestampTokenBase64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp token: %w", err)
+		}
+		tsEmbed := embed(ts)
+		doc.SegellTemps = &tsEmbed
+	}
+	for _, c := range resp.ChainPEM {
+		doc.Cadena = append(doc.Cadena, strings.TrimSpace(c))
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// writeExport writes the interchange archive for a proposal to w.
+func writeExport(w io.Writer, req model.SignRequest, signatures []model.SignResponse, now time.Time) error {
+	zw := zip.NewWriter(w)
+	manifest := exportManifest{
+		Versio:          exportFormatVersion,
+		ILP:             model.ILPInfo{Titol: req.Proposal.Title, Codi: req.RequestID},
+		Promotor:        req.Proposal.Promoter,
+		Ambit:           req.Proposal.Jurisdiction,
+		DataGeneracio:   now.UTC().Format(time.RFC3339),
+		TotalSignatures: len(signatures),
+	}
+
+	add := func(name string, data []byte) error {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(data)
+		return err
+	}
+
+	for i, resp := range signatures {
+		doc, err := buildSignatureDocument(req, i+1, resp)
+		if err != nil {
+			return fmt.Errorf("signature %d: %w", i+1, err)
+		}
+		name := fmt.Sprintf("signatures/%06d.xml", i+1)
+		if err := add(name, doc); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		sum := sha256.Sum256(doc)
+		manifest.Fitxers = append(manifest.Fitxers, exportManifestFile{
+			Nom:    name,
+			Mida:   len(doc),
+			SHA256: hex.EncodeToString(sum[:]),
+		})
+	}
+
+	manifestBytes, err := xml.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	manifestBytes = append([]byte(xml.Header), manifestBytes...)
+	if err := add("manifest.xml", manifestBytes); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	manifestSum := sha256.Sum256(manifestBytes)
+	if err := add("manifest.xml.sha256", []byte(hex.EncodeToString(manifestSum[:])+"  manifest.xml\n")); err != nil {
+		return fmt.Errorf("failed to write manifest digest: %w", err)
+	}
+
+	return zw.Close()
+}
+
+// handleExport serves the interchange archive of a proposal, with every
+// signer's personal data, behind requireAdmin.
+func handleExport(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/export/")
+	req, ok := loadProposal(w, r, id)
+	if !ok {
+		return
+	}
+	recs, err := db.Signatures(r.Context(), id)
+	if err != nil {
+		log.Printf("ERROR: failed to load signatures of %s: %v", id, err)
+		http.Error(w, "Export failed", http.StatusInternalServerError)
+		return
+	}
+	signatures := make([]model.SignResponse, len(recs))
+	for i := range recs {
+		if err := loadResponse(r.Context(), &recs[i]); err != nil {
+			log.Printf("ERROR: failed to load signature %s: %v", recs[i].ReceiptID, err)
+			http.Error(w, "Export failed", http.StatusInternalServerError)
+			return
+		}
+		signatures[i] = recs[i].Response
+	}
+
+	var buf bytes.Buffer
+	if err := writeExport(&buf, *req, signatures, time.Now()); err != nil {
+		log.Printf("ERROR: export failed for %s: %v", id, err)
+		http.Error(w, "Export failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", id+"-lliurament.zip"))
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Printf("ERROR: failed to write export: %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	vnet "github.com/vocdoni/gofirma/vocsign/internal/net"
+)
+
+func TestHandleExport_RequiresAdminToken(t *testing.T) {
+	srv := newTestCollector(t)
+	req := testRequest(t)
+	if _, err := vnet.Submit(context.Background(), req.Callback.URL, signTestResponse(t, req)); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	resp, err := srv.Client().Get(srv.URL + "/export/" + testProposal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("export without token = %d, want 401", resp.StatusCode)
+	}
+
+	// Browsers following the dashboard link send the token as a Basic
+	// password.
+	get := adminRequest(t, http.MethodGet, srv.URL+"/export/"+testProposal, nil)
+	get.Header.Del("Authorization")
+	get.SetBasicAuth("promoter", testAdminToken)
+	if resp, err = srv.Client().Do(get); err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || err != nil {
+		t.Fatalf("export with token = %d, %v", resp.StatusCode, err)
+	}
+	if _, err := zip.NewReader(bytes.NewReader(body), int64(len(body))); err != nil {
+		t.Errorf("export is not a ZIP: %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+)
+
+// loadOrganizerKey reads the organizer's RSA key from a PEM file in PKCS#1
+// or PKCS#8 form. Replicas of a collector must share it: every request they
+// publish is signed with it and checked against the same JWKS.
+func loadOrganizerKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not PEM", path)
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%s holds a %T, not an RSA key", path, key)
+		}
+		return rsaKey, nil
+	default:
+		return nil, fmt.Errorf("%s holds a %q block, not a private key", path, block.Type)
+	}
+}
@@ -1,42 +1,44 @@
 package main
 
 import (
+	"context"
 	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
+	"crypto/x509"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"flag"
 	"fmt"
 	"html/template"
+	"io"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/smallstep/pkcs7"
 	"github.com/vocdoni/gofirma/vocsign/internal/canon"
 	"github.com/vocdoni/gofirma/vocsign/internal/model"
+	"github.com/vocdoni/gofirma/vocsign/internal/paper"
+	"github.com/vocdoni/gofirma/vocsign/pkg/cadesverify"
 )
 
-type ProposalState struct {
-	Request    model.SignRequest
-	Signatures int
-	Audit      []model.SignResponse
-	mu         sync.Mutex
-}
-
 var (
 	organizerKey *rsa.PrivateKey
 	organizerPub *rsa.PublicKey
 	kid          = "vocsign-key-1"
 
-	proposals = make(map[string]*ProposalState)
-	pMu       sync.Mutex
-
 	port   int
 	domain string
 )
@@ -44,52 +46,204 @@ var (
 func main() {
 	flag.IntVar(&port, "port", 8080, "Port to listen on")
 	flag.StringVar(&domain, "domain", "localhost:8080", "Domain for proposal links")
+	rootsFile := flag.String("trust-roots", "", "PEM bundle of trusted CA certificates for signature reports")
+	keyFile := flag.String("organizer-key", "", "PEM file with the organizer's RSA key (default: a new key on every start)")
+	databaseURL := flag.String("database-url", os.Getenv("DATABASE_URL"), "Postgres URL to keep state in, shared by every replica (default: in memory)")
+	maxConns := flag.Int("db-max-conns", 20, "Maximum number of open database connections")
+	var archiveCfg archiveConfig
+	flag.StringVar(&archiveCfg.Bucket, "archive-bucket", "", "Bucket to archive raw signature artifacts in, keeping only their index in the store (default: no archive)")
+	flag.StringVar(&archiveCfg.Endpoint, "archive-endpoint", "https://s3.amazonaws.com", "S3-compatible endpoint, e.g. https://storage.googleapis.com")
+	flag.StringVar(&archiveCfg.Region, "archive-region", "us-east-1", "Region of the archive bucket (auto for Google Cloud Storage)")
+	flag.StringVar(&archiveCfg.Prefix, "archive-prefix", "signatures", "Key prefix of archived signatures")
+	flag.StringVar(&archiveCfg.SSE, "archive-sse", "AES256", "Server-side encryption of archived objects: AES256, aws:kms or empty")
+	flag.StringVar(&archiveCfg.KMSKey, "archive-kms-key", "", "KMS key for aws:kms server-side encryption")
+	flag.IntVar(&archiveCfg.TransitionDays, "archive-transition-days", 0, "Move archived signatures to -archive-transition-class after this many days")
+	flag.StringVar(&archiveCfg.TransitionClass, "archive-transition-class", "GLACIER_IR", "Storage class archived signatures move to")
+	flag.IntVar(&archiveCfg.ExpireDays, "archive-expire-days", 0, "Delete archived signatures after this many days")
+	flag.StringVar(&adminToken, "admin-token", os.Getenv("COLLECTOR_ADMIN_TOKEN"), "Token that authorizes amendments and downloads of signer data (default: a random token, logged at startup)")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 30*time.Second, "How long to wait for in-flight requests on SIGTERM")
 	flag.Parse()
 
+	if *databaseURL != "" && *keyFile == "" {
+		log.Fatalf("-database-url requires -organizer-key: every replica must sign with the same key")
+	}
+	if *databaseURL != "" && adminToken == "" {
+		log.Fatalf("-database-url requires -admin-token: every replica must accept the same token")
+	}
+	if adminToken == "" {
+		var err error
+		if adminToken, err = newAdminToken(); err != nil {
+			log.Fatalf("Failed to create admin token: %v", err)
+		}
+		log.Printf("Admin token for this run: %s", adminToken)
+	}
+
+	if *rootsFile != "" {
+		pemBytes, err := os.ReadFile(*rootsFile)
+		if err != nil {
+			log.Fatalf("Failed to read trust roots: %v", err)
+		}
+		trustRoots = x509.NewCertPool()
+		if !trustRoots.AppendCertsFromPEM(pemBytes) {
+			log.Fatalf("No certificates found in %s", *rootsFile)
+		}
+	}
+
 	var err error
-	organizerKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	if *keyFile != "" {
+		organizerKey, err = loadOrganizerKey(*keyFile)
+	} else {
+		organizerKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	}
 	if err != nil {
-		log.Fatalf("Failed to generate key: %v", err)
+		log.Fatalf("Failed to load organizer key: %v", err)
 	}
 	organizerPub = &organizerKey.PublicKey
+	collectorCert, err = newCollectorCert(organizerKey)
+	if err != nil {
+		log.Fatalf("Failed to create collector certificate: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if archiveCfg.Bucket != "" {
+		signatureArchive, err = newArchive(archiveCfg)
+		if err != nil {
+			log.Fatalf("Failed to configure the signature archive: %v", err)
+		}
+		if archiveCfg.TransitionDays > 0 || archiveCfg.ExpireDays > 0 {
+			if err := signatureArchive.applyLifecycle(ctx, archiveCfg); err != nil {
+				log.Fatalf("Failed to apply the archive lifecycle rule: %v", err)
+			}
+		}
+	}
+
+	if *databaseURL != "" {
+		db, err = openPGStore(ctx, *databaseURL, *maxConns)
+		if err != nil {
+			log.Fatalf("Failed to open database: %v", err)
+		}
+	} else {
+		db = newMemStore()
+	}
 
 	// Initialize 3 realistic proposals
-	initProposals()
+	initProposals(ctx)
+
+	var purger sync.WaitGroup
+	purger.Go(func() { purgeContacts(ctx) })
 
-	http.HandleFunc("/", handleDashboard)
-	http.HandleFunc("/jwks.json", handleJWKS)
-	http.HandleFunc("/request/", handleGetRequest)
-	http.HandleFunc("/callback/", handleCallback)
+	srv := &http.Server{
+		Addr:              fmt.Sprintf("0.0.0.0:%d", port),
+		Handler:           newMux(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	log.Printf("VocSign Collector listening on %s (domain: %s)", srv.Addr, domain)
 
-	addr := fmt.Sprintf("0.0.0.0:%d", port)
-	log.Printf("VocSign Collector listening on %s (domain: %s)", addr, domain)
-	if err := http.ListenAndServe(addr, nil); err != nil {
+	select {
+	case err := <-errc:
 		log.Fatalf("Server failed: %v", err)
+	case <-ctx.Done():
 	}
+	stop()
+
+	// Finish the requests in flight and the reports being built before
+	// closing the database, so a load balancer can drain this replica.
+	log.Printf("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("WARNING: failed to drain connections: %v", err)
+	}
+	reports.Wait()
+	purger.Wait()
+	db.Close()
+}
+
+// newMux routes the collector's endpoints.
+func newMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", handleDashboard)
+	mux.HandleFunc("/jwks.json", handleJWKS)
+	mux.HandleFunc("/request/", handleGetRequest)
+	mux.HandleFunc("/callback/", handleCallback)
+	mux.HandleFunc("/receipts", handleReceiptLookup)
+	mux.HandleFunc("/amend/", requireAdmin(handleAmend))
+	mux.HandleFunc("/signatures/", handleSignatureReport)
+	mux.HandleFunc("/duplicates/", duplicateLimit.wrap(handleDuplicates))
+	mux.HandleFunc("/export/", requireAdmin(handleExport))
+	mux.HandleFunc("/sheet/", handleSheet)
+	mux.HandleFunc("/log", handleLog)
+	mux.HandleFunc("/campaigns.json", handleCampaigns)
+	mux.HandleFunc("/policy.txt", handlePolicy)
+	mux.HandleFunc("/privacy.txt", handlePrivacyNotice)
+	mux.HandleFunc("/healthz", handleHealth)
+	mux.HandleFunc("/stats", handleStats)
+	mux.HandleFunc("/stats/", handleStats)
+	mux.HandleFunc("/public-stats/", handlePublicStats)
+	mux.HandleFunc("/contacts/", requireAdmin(handleContacts))
+	mux.HandleFunc("/audit/", handleAuditSync)
+	return mux
 }
 
-func initProposals() {
-	addProposal("ILP-2026-HABITATGE", "PROPOSICIÓ DE LLEI DE MESURES URGENTS PER A L'HABITATGE DIGNE",
+func initProposals(ctx context.Context) {
+	addProposal(ctx, "ILP-2026-HABITATGE", "PROPOSICIÓ DE LLEI DE MESURES URGENTS PER A L'HABITATGE DIGNE",
 		"Comissió Promotora de la ILP per l'Habitatge Digne",
-		"Aquesta iniciativa proposa regular els preus del lloguer, augmentar el parc d'habitatge social i garantir el dret a un sostre digne.")
+		"Aquesta iniciativa proposa regular els preus del lloguer, augmentar el parc d'habitatge social i garantir el dret a un sostre digne.", proposalOptions{
+			PublicStatsDays: 30,
+			Contact: &model.ContactRequest{
+				Fields:        []string{model.ContactEmail, model.ContactPhone},
+				Purpose:       "Informar-vos de la tramitació de la ILP i de les properes mobilitzacions",
+				RetentionDays: 180,
+			},
+		})
 
-	addProposal("ILP-2026-EDUCACIO", "LLEI DE FINANÇAMENT DEL SISTEMA EDUCATIU PÚBLIC (6%)",
+	addProposal(ctx, "ILP-2026-EDUCACIO", "LLEI DE FINANÇAMENT DEL SISTEMA EDUCATIU PÚBLIC (6%)",
 		"Plataforma per una Educació Pública de Qualitat",
-		"Garantir per llei un mínim del 6% del PIB per a l'educació pública a Catalunya per revertir les retallades i millorar ràtios.")
+		"Garantir per llei un mínim del 6% del PIB per a l'educació pública a Catalunya per revertir les retallades i millorar ràtios.", proposalOptions{
+			Localizations: map[string]model.Localization{
+				"es": {
+					Title:   "LEY DE FINANCIACIÓN DEL SISTEMA EDUCATIVO PÚBLICO (6%)",
+					Summary: "Garantizar por ley un mínimo del 6% del PIB para la educación pública en Cataluña para revertir los recortes y mejorar las ratios.",
+				},
+				"en": {
+					Title:   "PUBLIC EDUCATION FUNDING ACT (6%)",
+					Summary: "Guarantee by law at least 6% of GDP for public education in Catalonia, to reverse the cuts and improve class sizes.",
+				},
+			},
+			PrivacyNotice: true,
+		})
 
-	addProposal("ILP-2026-CLIMA", "PROPOSICIÓ DE LLEI DE PROTECCIÓ DELS ESPAIS NATURALS LITORALS",
+	addProposal(ctx, "ILP-2026-CLIMA", "PROPOSICIÓ DE LLEI DE PROTECCIÓ DELS ESPAIS NATURALS LITORALS",
 		"SOS Costa Catalana",
-		"Protecció efectiva dels darrers espais verds a la costa, moratòria de noves urbanitzacions i plans de restauració d'ecosistemes.")
+		"Protecció efectiva dels darrers espais verds a la costa, moratòria de noves urbanitzacions i plans de restauració d'ecosistemes.", proposalOptions{PublicStatsDays: 90})
 }
 
-func addProposal(id, title, promoter, summary string) {
+// proposalOptions are the optional features of a demo proposal.
+type proposalOptions struct {
+	// PublicStatsDays above zero makes the signature count public, with a
+	// histogram of that many days.
+	PublicStatsDays int
+	Contact         *model.ContactRequest
+	// Localizations and PrivacyNotice publish the proposal as a 2.0
+	// request, which clients that only know 1.0 cannot open.
+	Localizations map[string]model.Localization
+	PrivacyNotice bool
+}
+
+// addProposal publishes a proposal unless it is already stored, e.g. by
+// another replica.
+func addProposal(ctx context.Context, id, title, promoter, summary string, opts proposalOptions) {
 	baseURL := domain
 	if !strings.HasPrefix(baseURL, "http") {
 		baseURL = "http://" + baseURL
 	}
 
 	req := model.SignRequest{
-		Version:   "1.0",
+		Version:   model.Version1,
 		RequestID: id,
 		IssuedAt:  time.Now().Format(time.RFC3339),
 		ExpiresAt: time.Now().Add(365 * 24 * time.Hour).Format(time.RFC3339),
@@ -104,41 +258,110 @@ func addProposal(id, title, promoter, summary string) {
 				URL:    "https://vocdoni.io/docs/ilp-example.pdf",
 				SHA256: "Gvj/Kk/Jc+j8+j8+j8+j8+j8+j8+j8+j8+j8+j8+j88=",
 			},
+			DocumentVersion: 1,
 		},
 		Callback: model.Callback{
 			URL:    fmt.Sprintf("%s/callback/%s", baseURL, id),
 			Method: "POST",
 		},
+		ReceiptLookup: &model.ReceiptLookup{
+			URL: fmt.Sprintf("%s/receipts", baseURL),
+		},
 		Organizer: model.Organizer{
-			KID:       kid,
-			JWKSetURL: fmt.Sprintf("%s/jwks.json", baseURL),
+			KID:              kid,
+			JWKSetURL:        fmt.Sprintf("%s/jwks.json", baseURL),
+			CampaignIndexURL: fmt.Sprintf("%s/campaigns.json", baseURL),
 		},
 		Policy: &model.SignPolicy{
 			Mode:    "required",
 			OID:     "1.3.6.1.4.1.47443.8.1.1",
 			HashAlg: "sha256",
-			Hash:    "Gvj/Kk/Jc+j8+j8+j8+j8+j8+j8+j8+j8+j8+j8+j88=",
-			URI:     "https://vocdoni.io/legal/policy-ilp-v1.pdf",
+			Hash:    documentHash(policyDocument),
+			URI:     fmt.Sprintf("%s/policy.txt", baseURL),
+		},
+		DuplicateCheck: &model.DuplicateCheck{
+			URL:  fmt.Sprintf("%s/duplicates/%s", baseURL, id),
+			Salt: uuid.New().String(),
+		},
+		TransparencyLog: &model.TransparencyLog{
+			URL: fmt.Sprintf("%s/log", baseURL),
+		},
+		AuditSync: &model.AuditSync{
+			URL: fmt.Sprintf("%s/audit/%s", baseURL, id),
 		},
 	}
+	if opts.PublicStatsDays > 0 {
+		req.PublicStats = &model.PublicStats{
+			URL:  fmt.Sprintf("%s/public-stats/%s", baseURL, id),
+			Days: opts.PublicStatsDays,
+		}
+	}
+	req.ContactRequest = opts.Contact
+	req.Localizations = opts.Localizations
+	if opts.PrivacyNotice {
+		req.Policies = []model.Document{{
+			Title:     "Avís de privacitat",
+			URL:       fmt.Sprintf("%s/privacy.txt", baseURL),
+			SHA256:    documentHash(privacyNotice),
+			MediaType: "text/plain",
+		}}
+	}
+	if len(req.Localizations) > 0 || len(req.Policies) > 0 {
+		req.Version = model.Version2
+	}
+
+	if err := signRequest(&req); err != nil {
+		log.Fatalf("Failed to sign request %s: %v", id, err)
+	}
+	created, err := db.CreateProposal(ctx, &req)
+	if err != nil {
+		log.Fatalf("Failed to publish request %s: %v", id, err)
+	}
+	if !created {
+		log.Printf("Proposal %s already published", id)
+	}
+}
 
-	// Sign the request
-	reqCopy := req
+// signRequest signs req with the organizer key. The store appends it to the
+// transparency log when it is published.
+func signRequest(req *model.SignRequest) error {
+	reqCopy := *req
 	reqCopy.OrganizerSignature = nil
-	canonicalBytes, _ := canon.Encode(reqCopy)
+	canonicalBytes, err := canon.Encode(reqCopy)
+	if err != nil {
+		return fmt.Errorf("failed to canonicalize request: %w", err)
+	}
 	header := map[string]string{"alg": "RS256", "typ": "JWS"}
 	headerBytes, _ := json.Marshal(header)
 	headerB64 := base64.RawURLEncoding.EncodeToString(headerBytes)
 	payloadB64 := base64.RawURLEncoding.EncodeToString(canonicalBytes)
 	hashed := sha256.Sum256([]byte(headerB64 + "." + payloadB64))
-	sig, _ := rsa.SignPKCS1v15(rand.Reader, organizerKey, crypto.SHA256, hashed[:])
+	sig, err := rsa.SignPKCS1v15(rand.Reader, organizerKey, crypto.SHA256, hashed[:])
+	if err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
 
 	req.OrganizerSignature = &model.OrganizerSignature{
 		Format: "JWS",
 		Value:  headerB64 + "." + payloadB64 + "." + base64.RawURLEncoding.EncodeToString(sig),
 	}
+	return nil
+}
 
-	proposals[id] = &ProposalState{Request: req}
+// loadProposal returns the current request of proposal id, answering the
+// request itself if there is none or it cannot be read.
+func loadProposal(w http.ResponseWriter, r *http.Request, id string) (*model.SignRequest, bool) {
+	req, err := db.Proposal(r.Context(), id)
+	if errors.Is(err, errNotFound) {
+		http.Error(w, "Proposal not found", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("ERROR: failed to load proposal %s: %v", id, err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return nil, false
+	}
+	return req, true
 }
 
 func handleDashboard(w http.ResponseWriter, r *http.Request) {
@@ -161,6 +384,15 @@ func handleDashboard(w http.ResponseWriter, r *http.Request) {
         .stat-value { font-size: 1.5rem; font-weight: bold; color: #2e7d32; }
         .link-box { background: #f1f3f9; padding: 12px; border-radius: 6px; font-family: monospace; font-size: 0.9rem; border: 1px dashed #3f51b5; word-break: break-all; }
         .badge { background: #e8f5e9; color: #2e7d32; padding: 4px 12px; border-radius: 20px; font-size: 0.8rem; font-weight: bold; }
+        .charts { display: flex; gap: 24px; }
+        .chart { flex: 1; min-width: 0; }
+        .bar-row { display: flex; align-items: center; gap: 8px; font-size: 0.85rem; margin: 6px 0; }
+        .bar-label { width: 40%; overflow: hidden; text-overflow: ellipsis; white-space: nowrap; }
+        .bar-track { flex: 1; background: #edf1f5; border-radius: 4px; height: 12px; }
+        .bar-fill { background: #3f51b5; border-radius: 4px; height: 100%; }
+        .bar-fill.fail { background: #c62828; }
+        .columns { display: flex; align-items: flex-end; gap: 2px; height: 80px; margin-bottom: 16px; }
+        .column { flex: 1; background: #3f51b5; min-height: 1px; }
     </style>
 </head>
 <body>
@@ -169,6 +401,47 @@ func handleDashboard(w http.ResponseWriter, r *http.Request) {
             <h1>🛡️ VocSign Collector</h1>
         </div>
         
+        {{with .Stats}}
+        <div class="card">
+            <div class="title">Campaign Analytics</div>
+            <div class="stats">
+                <div class="stat-item">
+                    <div class="stat-label">Signatures</div>
+                    <div class="stat-value">{{.Total}}</div>
+                </div>
+                <div class="stat-item">
+                    <div class="stat-label">Valid</div>
+                    <div class="stat-value">{{.Verification.Valid}}</div>
+                </div>
+                <div class="stat-item">
+                    <div class="stat-label">Invalid</div>
+                    <div class="stat-value" style="color: #c62828;">{{.Verification.Invalid}}</div>
+                </div>
+                <div class="stat-item">
+                    <div class="stat-label">Pending Verification</div>
+                    <div class="stat-value" style="color: #888;">{{.Verification.Pending}}</div>
+                </div>
+            </div>
+            <div class="charts">
+                <div class="chart">
+                    <div class="stat-label">By Jurisdiction</div>
+                    {{range bars .PerJurisdiction}}{{template "bar" .}}{{end}}
+                </div>
+                <div class="chart">
+                    <div class="stat-label">By Certificate Authority</div>
+                    {{range bars .PerCA}}{{template "bar" .}}{{end}}
+                </div>
+                <div class="chart">
+                    <div class="stat-label">Verification Failures</div>
+                    {{range bars .Verification.Failures}}
+                    <div class="bar-row"><span class="bar-label" title="{{.Label}}">{{.Label}}</span><span class="bar-track"><div class="bar-fill fail" style="width: {{.Percent}}%"></div></span>{{.Count}}</div>
+                    {{else}}<p class="promoter">None</p>{{end}}
+                </div>
+            </div>
+            <p><a href="{{$.BaseURL}}/stats">Statistics as JSON</a></p>
+        </div>
+        {{end}}
+
         <h2>Ongoing Legislative Initiatives</h2>
         {{range .Proposals}}
         <div class="card">
@@ -186,99 +459,442 @@ func handleDashboard(w http.ResponseWriter, r *http.Request) {
                     <div class="stat-label">Verified Signatures</div>
                     <div class="stat-value">{{.Signatures}}</div>
                 </div>
+                <div class="stat-item">
+                    <div class="stat-label">Text Version</div>
+                    <div style="font-weight: 500;">{{.Request.Proposal.DocumentVersion}}</div>
+                </div>
                 <div class="stat-item">
                     <div class="stat-label">Jurisdiction</div>
                     <div style="font-weight: 500;">{{.Request.Proposal.Jurisdiction}}</div>
                 </div>
             </div>
 
+            <div class="stat-label" style="margin-bottom: 8px;">Signatures per Day (last {{len .Daily}} days)</div>
+            <div class="columns">
+                {{range .Daily}}<div class="column" style="height: {{.Percent}}%" title="{{.Label}}: {{.Count}}"></div>{{end}}
+            </div>
+
             <div class="stat-label" style="margin-bottom: 8px;">VocSign Signing URL</div>
             <div class="link-box">{{$.BaseURL}}/request/{{.Request.RequestID}}</div>
+            <p>Signed JWS variant: <a href="{{$.BaseURL}}/request/{{.Request.RequestID}}.jws">{{$.BaseURL}}/request/{{.Request.RequestID}}.jws</a></p>
+            <p><a href="{{$.BaseURL}}/export/{{.Request.RequestID}}">Download signature batch (electoral board format)</a>
+               · <a href="{{$.BaseURL}}/sheet/{{.Request.RequestID}}">Printable paper sheet</a>
+               · <a href="{{$.BaseURL}}/stats/{{.Request.RequestID}}">Statistics</a>
+               {{if .Request.ContactRequest}}· <a href="{{$.BaseURL}}/contacts/{{.Request.RequestID}}">Signer contacts (CSV)</a>{{end}}</p>
         </div>
         {{end}}
     </div>
 </body>
-</html>`
+</html>
+{{define "bar"}}<div class="bar-row"><span class="bar-label" title="{{.Label}}">{{.Label}}</span><span class="bar-track"><div class="bar-fill" style="width: {{.Percent}}%"></div></span>{{.Count}}</div>{{end}}`
+
+	// dashboardDays is how many days the per-proposal chart covers.
+	const dashboardDays = 30
 
-	pMu.Lock()
-	props := make([]*ProposalState, 0, len(proposals))
-	for _, p := range proposals {
-		props = append(props, p)
+	props, err := db.Proposals(r.Context())
+	if err != nil {
+		log.Printf("ERROR: failed to list proposals: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
 	}
-	pMu.Unlock()
 
 	baseURL := domain
 	if !strings.HasPrefix(baseURL, "http") {
 		baseURL = "http://" + baseURL
 	}
 
+	type dashboardProposal struct {
+		proposalSummary
+		Daily []chartBar
+	}
 	data := struct {
-		Proposals []*ProposalState
+		Proposals []dashboardProposal
+		Stats     *CampaignStats
 		BaseURL   string
 	}{
-		Proposals: props,
-		BaseURL:   baseURL,
+		BaseURL: baseURL,
+	}
+	if data.Stats, err = db.Stats(r.Context(), statsFilter{}); err != nil {
+		log.Printf("ERROR: failed to compute statistics: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	today := time.Now().UTC()
+	from, _ := time.Parse(time.DateOnly, statsDay(today.AddDate(0, 0, 1-dashboardDays)))
+	for _, p := range props {
+		st, err := db.Stats(r.Context(), statsFilter{RequestID: p.Request.RequestID, From: from})
+		if err != nil {
+			log.Printf("ERROR: failed to compute statistics for %s: %v", p.Request.RequestID, err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+		data.Proposals = append(data.Proposals, dashboardProposal{p, dailyBars(st.PerDay, dashboardDays, today)})
 	}
 
-	t := template.Must(template.New("dashboard").Parse(tpl))
+	t := template.Must(template.New("dashboard").Funcs(template.FuncMap{"bars": chartBars}).Parse(tpl))
 	if err := t.Execute(w, data); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
 
+// handleGetRequest serves a request as JSON, or at /request/<id>.jws as a
+// compact JWS whose payload is the canonical request. The JWS form can be
+// published as a single static file (e.g. on IPFS or S3). Content-Profile
+// names the request's version; a client whose Accept-Profile does not list
+// it is answered 406 so it can tell the signer to update.
 func handleGetRequest(w http.ResponseWriter, r *http.Request) {
-	id := strings.TrimPrefix(r.URL.Path, "/request/")
-	p, ok := proposals[id]
+	id, compact := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/request/"), ".jws")
+	req, ok := loadProposal(w, r, id)
 	if !ok {
-		http.Error(w, "Proposal not found", http.StatusNotFound)
+		return
+	}
+	profile := model.VersionProfile(req.Version)
+	w.Header().Set("Content-Profile", "<"+profile+">")
+	w.Header().Add("Vary", "Accept-Profile")
+	if !acceptsProfile(r.Header.Get("Accept-Profile"), profile) {
+		http.Error(w, fmt.Sprintf("Request %s is version %s, which this client does not accept. Update the client.", id, req.Version), http.StatusNotAcceptable)
+		return
+	}
+	if compact {
+		w.Header().Set("Content-Type", "application/jose")
+		_, _ = w.Write([]byte(req.OrganizerSignature.Value))
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(p.Request); err != nil {
+	if err := json.NewEncoder(w).Encode(req); err != nil {
 		log.Printf("ERROR: failed to encode request: %v", err)
 	}
 }
 
+// acceptsProfile reports whether an Accept-Profile header value, a list of
+// <uri> entries with optional parameters, accepts profile. Clients that send
+// none accept any.
+func acceptsProfile(header, profile string) bool {
+	if strings.TrimSpace(header) == "" {
+		return true
+	}
+	for _, entry := range strings.Split(header, ",") {
+		uri, params, _ := strings.Cut(strings.TrimSpace(entry), ";")
+		if strings.Trim(strings.TrimSpace(uri), "<>") != profile {
+			continue
+		}
+		q, found := strings.CutPrefix(strings.ReplaceAll(params, " ", ""), "q=")
+		if !found || strings.Trim(q, "0.") != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// maxCallbackBody bounds a submitted signature: signed XML, CAdES signature,
+// certificate chain and timestamp token.
+const maxCallbackBody = 16 << 20
+
+// handleCallback accepts a signature POSTed for a proposal. A HEAD answers
+// whether the submission with the key in Idempotency-Key was accepted, for
+// clients that lost the receipt.
 func handleCallback(w http.ResponseWriter, r *http.Request) {
 	id := strings.TrimPrefix(r.URL.Path, "/callback/")
-	p, ok := proposals[id]
+	switch r.Method {
+	case http.MethodPost:
+	case http.MethodHead:
+		rec, ok := lookupSubmission(w, r, id, r.Header.Get(idempotencyKeyHeader))
+		if ok {
+			w.Header().Set(receiptIDHeader, rec.ReceiptID)
+		}
+		return
+	default:
+		w.Header().Set("Allow", "POST, HEAD")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	req, ok := loadProposal(w, r, id)
 	if !ok {
-		http.Error(w, "Proposal not found", http.StatusNotFound)
 		return
 	}
 
+	// The body is kept as received for the archive, without any contact.
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxCallbackBody))
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
 	var resp model.SignResponse
-	if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+	if err := json.Unmarshal(body, &resp); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
+	// A signer contact is kept apart from the signature, so neither the
+	// store nor the archive sees it.
+	contact := takeContact(&resp)
+	if contact != nil {
+		if body, err = json.Marshal(resp); err != nil {
+			log.Printf("ERROR: failed to encode response for %s: %v", id, err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+	}
 
 	sigBytes, _ := base64.StdEncoding.DecodeString(resp.SignatureDerBase64)
-	p7, _ := pkcs7.Parse(sigBytes)
 	xmlBytes, _ := base64.StdEncoding.DecodeString(resp.SignerXMLBase64)
 
-	p7.Content = xmlBytes
-	if err := p7.Verify(); err != nil {
+	// A signature submitted again, e.g. by a client that lost the receipt,
+	// gets the receipt it got the first time. The key is computed from the
+	// signature rather than taken from Idempotency-Key, so a client cannot
+	// claim the receipt of another submission.
+	key := submissionKey(sigBytes)
+	if prior, err := db.SignatureByKey(r.Context(), id, key); err == nil {
+		writeReceipt(w, id, prior, sigBytes)
+		return
+	} else if !errors.Is(err, errNotFound) {
+		log.Printf("ERROR: failed to look up submission for %s: %v", id, err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	signers, err := cadesverify.VerifySignature(xmlBytes, sigBytes)
+	if err != nil {
 		log.Printf("ERROR: Signature verification failed for %s: %v", id, err)
 		http.Error(w, "Verification failed", http.StatusBadRequest)
 		return
 	}
 
-	p.mu.Lock()
-	p.Signatures++
-	p.Audit = append(p.Audit, resp)
-	p.mu.Unlock()
+	var signerXML model.ILPSignerXML
+	if err := xml.Unmarshal(xmlBytes, &signerXML); err != nil {
+		log.Printf("WARNING: could not parse signer XML for %s: %v", id, err)
+	}
 
-	if err := json.NewEncoder(w).Encode(model.SubmitReceipt{
-		Status:     "ok",
+	var signerTag string
+	if dc := req.DuplicateCheck; dc != nil && signerXML.Signant.NumIdentifica != "" {
+		signerTag = duplicateTag(dc.SignerHash(id, signerXML.Signant.NumIdentifica))
+	}
+	rec := &signatureRecord{
 		ReceiptID:  uuid.New().String(),
-		ReceivedAt: time.Now().Format(time.RFC3339),
+		ReceivedAt: time.Now(),
+		Response:   resp,
+		SignerCA:   issuerName(signers[0]),
+		Key:        key,
+	}
+	if signatureArchive != nil {
+		token, _ := base64.StdEncoding.DecodeString(resp.TimestampTokenBase64)
+		rec.ArchiveKey, err = signatureArchive.storeSignature(r.Context(), id, rec.ReceiptID, body, xmlBytes, sigBytes, token)
+		if err != nil {
+			log.Printf("ERROR: failed to archive signature for %s: %v", id, err)
+			http.Error(w, "Failed to store the signature", http.StatusServiceUnavailable)
+			return
+		}
+	}
+	se, err := db.AddSignature(r.Context(), id, rec, signerTag)
+	if errors.Is(err, errAlreadyAccepted) {
+		// Another replica accepted the same signature meanwhile.
+		if prior, err := db.SignatureByKey(r.Context(), id, key); err == nil {
+			writeReceipt(w, id, prior, sigBytes)
+			return
+		}
+	}
+	if err != nil {
+		log.Printf("ERROR: failed to store signature for %s: %v", id, err)
+		http.Error(w, "Failed to store the signature", http.StatusInternalServerError)
+		return
+	}
+	if se != nil {
+		log.Printf("WARNING: rejected signature for %s: %s", id, se.Message)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		if err := json.NewEncoder(w).Encode(se); err != nil {
+			log.Printf("ERROR: failed to encode submit error: %v", err)
+		}
+		return
+	}
+	recordSignature(rec)
+	if contact != nil {
+		storeContact(r.Context(), req, contact, rec.ReceivedAt)
+	}
+
+	writeReceipt(w, id, rec, sigBytes)
+}
+
+// writeReceipt answers a submission of proposal id with the receipt of
+// rec, counter-signing sigBytes.
+func writeReceipt(w http.ResponseWriter, id string, rec *signatureRecord, sigBytes []byte) {
+	receipt := model.SubmitReceipt{
+		Status:      "ok",
+		ReceiptID:   rec.ReceiptID,
+		ReceivedAt:  rec.ReceivedAt.Format(time.RFC3339),
+		ReportToken: reportToken(rec.ReceiptID),
+	}
+	if cs, err := counterSign(sigBytes, rec.ReceivedAt); err != nil {
+		log.Printf("WARNING: failed to counter-sign signature for %s: %v", id, err)
+	} else {
+		receipt.CounterSignatureDerBase64 = cs
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(receipt); err != nil {
+		log.Printf("ERROR: failed to encode receipt: %v", err)
+	}
+}
+
+// Receipt lookup headers, as the client sends and expects them.
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	receiptIDHeader      = "Receipt-Id"
+)
+
+// submissionKey returns the idempotency key of a signature: its hex
+// SHA-256.
+func submissionKey(sigBytes []byte) string {
+	sum := sha256.Sum256(sigBytes)
+	return hex.EncodeToString(sum[:])
+}
+
+// lookupSubmission returns the accepted signature of proposal id with the
+// idempotency key, answering the request itself with 404 if there is none
+// or it cannot be read.
+func lookupSubmission(w http.ResponseWriter, r *http.Request, id, key string) (*signatureRecord, bool) {
+	if key == "" {
+		http.Error(w, "Missing idempotency key", http.StatusBadRequest)
+		return nil, false
+	}
+	rec, err := db.SignatureByKey(r.Context(), id, key)
+	if errors.Is(err, errNotFound) {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("ERROR: failed to look up submission for %s: %v", id, err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return nil, false
+	}
+	return rec, true
+}
+
+// handleReceiptLookup answers GET /receipts?requestId=...&key=... with the
+// receipt of the submission with that idempotency key, or 404. Unlike the
+// answer to the submission it carries no counter-signature, which needs the
+// signature itself.
+func handleReceiptLookup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	rec, ok := lookupSubmission(w, r, q.Get("requestId"), q.Get("key"))
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(model.SubmitReceipt{
+		Status:      "ok",
+		ReceiptID:   rec.ReceiptID,
+		ReceivedAt:  rec.ReceivedAt.Format(time.RFC3339),
+		ReportToken: reportToken(rec.ReceiptID),
 	}); err != nil {
 		log.Printf("ERROR: failed to encode receipt: %v", err)
 	}
 }
 
+// handleSheet serves the printable paper signature sheet of a proposal,
+// with the number of rows in the rows parameter, up to paper.MaxRows.
+func handleSheet(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/sheet/")
+	req, ok := loadProposal(w, r, id)
+	if !ok {
+		return
+	}
+	rows, _ := strconv.Atoi(r.URL.Query().Get("rows"))
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := paper.WriteSheet(w, req, rows); err != nil {
+		log.Printf("ERROR: failed to render paper sheet: %v", err)
+	}
+}
+
+// handleCampaigns lists the request URLs of every open proposal so clients
+// that pinned this organizer can show them on their home screen.
+func handleCampaigns(w http.ResponseWriter, r *http.Request) {
+	baseURL := domain
+	if !strings.HasPrefix(baseURL, "http") {
+		baseURL = "http://" + baseURL
+	}
+
+	props, err := db.Proposals(r.Context())
+	if err != nil {
+		log.Printf("ERROR: failed to list proposals: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	urls := make([]string, 0, len(props))
+	for _, p := range props {
+		urls = append(urls, fmt.Sprintf("%s/request/%s", baseURL, p.Request.RequestID))
+	}
+	sort.Strings(urls)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{"requests": urls}); err != nil {
+		log.Printf("ERROR: failed to encode campaign index: %v", err)
+	}
+}
+
+// handleLog serves the append-only public log of every request issued by
+// this collector.
+// policyDocument is the signature policy served by the collector. Clients
+// verify it against the hash embedded in each request before signing.
+const policyDocument = `VocSign demo signature policy (1.3.6.1.4.1.47443.8.1.1)
+
+Signatures produced under this policy support a popular legislative
+initiative (ILP) as defined by Llei 1/2006, de 16 de febrer. Signers must use
+a qualified certificate and sign the canonical payload with CAdES-EPES.
+`
+
+// privacyNotice is the promoter policy some demo proposals reference.
+const privacyNotice = `Avís de privacitat de la campanya (demostració)
+
+Les dades de la signatura es tracten només per acreditar el suport a la
+iniciativa davant la Junta Electoral i s'eliminen en acabar la tramitació.
+`
+
+// documentHash is the base64 SHA-256 a request pins doc with.
+func documentHash(doc string) string {
+	sum := sha256.Sum256([]byte(doc))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func handlePolicy(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte(policyDocument))
+}
+
+func handlePrivacyNotice(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte(privacyNotice))
+}
+
+func handleLog(w http.ResponseWriter, r *http.Request) {
+	snap, err := db.TransparencyLog(r.Context())
+	if err != nil {
+		log.Printf("ERROR: failed to read transparency log: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(snap); err != nil {
+		log.Printf("ERROR: failed to encode transparency log: %v", err)
+	}
+}
+
+// handleHealth answers load balancer health checks: a replica is healthy
+// while it can reach its store.
+func handleHealth(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+	if err := db.Ping(ctx); err != nil {
+		log.Printf("WARNING: health check failed: %v", err)
+		http.Error(w, "Store unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	_, _ = w.Write([]byte("ok\n"))
+}
+
 func handleJWKS(w http.ResponseWriter, r *http.Request) {
 	nBytes := organizerPub.N.Bytes()
 	eBytes := make([]byte, 4)
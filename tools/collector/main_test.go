@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/cades"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+	vnet "github.com/vocdoni/gofirma/vocsign/internal/net"
+)
+
+// testProposal is the ID of the proposal newTestCollector publishes.
+const testProposal = "ILP-TEST"
+
+// newTestCollector starts the collector over HTTPS with an in-memory store
+// and one proposal, and makes the default transport trust it until the test
+// ends. Tests that use it must not run in parallel.
+func newTestCollector(t *testing.T) *httptest.Server {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	organizerKey, organizerPub = key, &key.PublicKey
+	if collectorCert, err = newCollectorCert(key); err != nil {
+		t.Fatal(err)
+	}
+	db = newMemStore()
+	adminToken = testAdminToken
+
+	srv := httptest.NewTLSServer(newMux())
+	t.Cleanup(func() {
+		srv.Close()
+		reports.Wait()
+	})
+	domain = srv.URL
+
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig.RootCAs = pool
+	prev := http.DefaultTransport
+	http.DefaultTransport = tr
+	t.Cleanup(func() { http.DefaultTransport = prev })
+
+	addProposal(context.Background(), testProposal, "Test proposal", "Test promoters", "A proposal for tests.", proposalOptions{})
+	return srv
+}
+
+// testAdminToken is the admin token of the test collector.
+const testAdminToken = "test-admin-token"
+
+// adminRequest returns a request to url that carries the admin token.
+func adminRequest(t *testing.T, method, url string, body io.Reader) *http.Request {
+	t.Helper()
+	r, err := http.NewRequest(method, url, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set("Authorization", "Bearer "+testAdminToken)
+	return r
+}
+
+// testRequest returns the published request of the test proposal.
+func testRequest(t *testing.T) *model.SignRequest {
+	t.Helper()
+	req, err := db.Proposal(context.Background(), testProposal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+// signTestResponse signs req as a citizen with a throwaway certificate.
+func signTestResponse(t *testing.T, req *model.SignRequest) *model.SignResponse {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Test Signer"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xmlBytes, err := model.GenerateILPXML(req, model.Signant{
+		Nom: "MARIA", Cognom1: "PUIG", DataNaixement: "1980-05-04", TipusIdentifica: "DNI", NumIdentifica: "12345678Z",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := cades.SignDetached(context.Background(), key, cert, nil, xmlBytes, cades.SignOpts{SigningTime: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(xmlBytes)
+	return &model.SignResponse{
+		Version:                "1.0",
+		RequestID:              req.RequestID,
+		Nonce:                  req.Nonce,
+		SignedAt:               time.Now().Format(time.RFC3339),
+		PayloadCanonicalSHA256: base64.StdEncoding.EncodeToString(sum[:]),
+		SignatureFormat:        "CAdES-detached",
+		SignatureDerBase64:     base64.StdEncoding.EncodeToString(sig),
+		SignerXMLBase64:        base64.StdEncoding.EncodeToString(xmlBytes),
+	}
+}
+
+func TestCallback_ReceiptLookup(t *testing.T) {
+	newTestCollector(t)
+	req := testRequest(t)
+	viaHead := *req
+	viaHead.ReceiptLookup = nil
+	resp := signTestResponse(t, req)
+	key := vnet.SubmissionKey(resp)
+	ctx := context.Background()
+
+	// Nothing is found before the signature is submitted.
+	for name, r := range map[string]*model.SignRequest{"lookup endpoint": req, "callback HEAD": &viaHead} {
+		if receipt, err := vnet.LookupReceipt(ctx, r, key); receipt != nil || err != nil {
+			t.Fatalf("%s: LookupReceipt before submitting = %+v, %v", name, receipt, err)
+		}
+	}
+
+	receipt, err := vnet.Submit(ctx, req.Callback.URL, resp)
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	for name, r := range map[string]*model.SignRequest{"lookup endpoint": req, "callback HEAD": &viaHead} {
+		found, err := vnet.LookupReceipt(ctx, r, key)
+		if err != nil || found == nil || found.ReceiptID != receipt.ReceiptID {
+			t.Fatalf("%s: LookupReceipt = %+v, %v; want receipt %q", name, found, err, receipt.ReceiptID)
+		}
+	}
+
+	// The same signature submitted again gets the same receipt and is
+	// stored once.
+	again, err := vnet.Submit(ctx, req.Callback.URL, resp)
+	if err != nil {
+		t.Fatalf("resubmit failed: %v", err)
+	}
+	if again.ReceiptID != receipt.ReceiptID {
+		t.Errorf("resubmit receipt = %q, want %q", again.ReceiptID, receipt.ReceiptID)
+	}
+	sigs, err := db.Signatures(ctx, testProposal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sigs) != 1 {
+		t.Errorf("stored %d signatures, want 1", len(sigs))
+	}
+}
+
+func TestCallback_Method(t *testing.T) {
+	srv := newTestCollector(t)
+	tests := []struct {
+		method string
+		key    string
+		want   int
+	}{
+		{http.MethodGet, "", http.StatusMethodNotAllowed},
+		{http.MethodPut, "", http.StatusMethodNotAllowed},
+		{http.MethodHead, "", http.StatusBadRequest},
+		{http.MethodHead, "unknown", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		r, err := http.NewRequest(tt.method, srv.URL+"/callback/"+testProposal, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.key != "" {
+			r.Header.Set(vnet.IdempotencyKeyHeader, tt.key)
+		}
+		resp, err := srv.Client().Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s with key %q = %d, want %d", tt.method, tt.key, resp.StatusCode, tt.want)
+		}
+	}
+}
+
+func TestHandleHealth(t *testing.T) {
+	srv := newTestCollector(t)
+	resp, err := srv.Client().Get(srv.URL + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("healthz = %d, want 200", resp.StatusCode)
+	}
+}
+
+// TestReplicas_ShareState checks that collectors over the same store serve
+// the same campaigns: a signature accepted by one is known to the other.
+func TestReplicas_ShareState(t *testing.T) {
+	newTestCollector(t)
+	other := httptest.NewTLSServer(newMux())
+	defer other.Close()
+
+	req := testRequest(t)
+	resp := signTestResponse(t, req)
+	receipt, err := vnet.Submit(context.Background(), req.Callback.URL, resp)
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	get, err := http.NewRequest(http.MethodGet, other.URL+"/request/"+testProposal, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := http.NewRequest(http.MethodHead, other.URL+"/callback/"+testProposal, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head.Header.Set(vnet.IdempotencyKeyHeader, vnet.SubmissionKey(resp))
+	for _, r := range []*http.Request{get, head} {
+		res, err := other.Client().Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("%s %s on the other replica = %d", r.Method, r.URL.Path, res.StatusCode)
+		}
+		if r.Method == http.MethodHead && res.Header.Get(vnet.ReceiptIDHeader) != receipt.ReceiptID {
+			t.Errorf("other replica receipt = %q, want %q", res.Header.Get(vnet.ReceiptIDHeader), receipt.ReceiptID)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// s3Client talks to an S3-compatible object store (AWS S3, MinIO, or Google
+// Cloud Storage through its XML API with HMAC keys) using path-style URLs
+// and Signature Version 4.
+type s3Client struct {
+	endpoint     *url.URL
+	bucket       string
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	http         *http.Client
+}
+
+// unsignedPayload is the payload hash of a streamed body, which is sent
+// without hashing it first. TLS protects it on the way.
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// put streams size bytes from body to key. header carries extra x-amz-*
+// headers, e.g. for server-side encryption.
+func (c *s3Client) put(ctx context.Context, key string, body io.Reader, size int64, header http.Header) error {
+	resp, err := c.send(ctx, http.MethodPut, key, "", body, size, unsignedPayload, header)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
+// get opens the object at key for reading, or returns errNotFound. The
+// caller closes it.
+func (c *s3Client) get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := c.send(ctx, http.MethodGet, key, "", nil, 0, emptyPayloadHash, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// emptyPayloadHash is the hex SHA-256 of an empty body.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// do sends a signed request with a small body held in memory, such as a
+// bucket configuration, for key (the bucket itself if empty) with the given
+// raw query and fails unless the store answers 2xx.
+func (c *s3Client) do(ctx context.Context, method, key, query string, body []byte, header http.Header) (*http.Response, error) {
+	sum := sha256.Sum256(body)
+	return c.send(ctx, method, key, query, bytes.NewReader(body), int64(len(body)), hex.EncodeToString(sum[:]), header)
+}
+
+// send sends a signed request for key whose body has size bytes and the
+// given payload hash, and fails unless the store answers 2xx.
+func (c *s3Client) send(ctx context.Context, method, key, query string, body io.Reader, size int64, payloadHash string, header http.Header) (*http.Response, error) {
+	// The path is sent exactly as it is signed: every byte of the bucket
+	// and key but unreserved characters and '/' escaped.
+	u := *c.endpoint
+	u.Path = "/" + c.bucket
+	u.RawPath = "/" + uriEncode(c.bucket, false)
+	if key != "" {
+		u.Path += "/" + key
+		u.RawPath += "/" + uriEncode(key, false)
+	}
+	u.RawQuery = query
+	if body == nil {
+		body = http.NoBody
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	c.sign(req, u.RawPath, payloadHash, time.Now())
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 == 2 {
+		return resp, nil
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode == http.StatusNotFound && method == http.MethodGet {
+		return nil, fmt.Errorf("%s: %w", key, errNotFound)
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return nil, fmt.Errorf("%s %s: object store returned %s: %s", method, u.Path, resp.Status, strings.TrimSpace(string(msg)))
+}
+
+// sign adds AWS Signature Version 4 headers to req. canonicalURI is its
+// path encoded as Signature Version 4 requires, and payloadHash the hex
+// SHA-256 of its body or unsignedPayload.
+func (c *s3Client) sign(req *http.Request, canonicalURI, payloadHash string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if c.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.sessionToken)
+	}
+
+	// Sign the host and every header set by the caller.
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + c.region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + c.secretKey)
+	for _, part := range []string{day, c.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// canonicalQuery encodes q as Signature Version 4 requires: sorted by key,
+// every key with an "=", everything but unreserved characters escaped.
+func canonicalQuery(q url.Values) string {
+	var pairs []string
+	for k, vs := range q {
+		for _, v := range vs {
+			pairs = append(pairs, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode escapes every byte of s except unreserved characters and, unless
+// encodeSlash is set, '/'.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
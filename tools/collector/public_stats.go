@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+// Public campaign counts. GET /public-stats/{requestId} serves the number
+// of accepted signatures and a daily histogram as JSON, for the client and
+// for campaign pages to fetch from any origin, and
+// /public-stats/{requestId}.html the same as a page to embed in an iframe.
+// Only proposals whose request declares publicStats are served; the block
+// is the promoter's consent and sets the histogram length.
+
+// publicStatsMaxAge is how long browsers and caches may reuse a count.
+const publicStatsMaxAge = 5 * time.Minute
+
+func handlePublicStats(w http.ResponseWriter, r *http.Request) {
+	id, page := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/public-stats/"), ".html")
+	req, ok := loadProposal(w, r, id)
+	if !ok {
+		return
+	}
+	if req.PublicStats == nil {
+		http.Error(w, "Proposal does not publish statistics", http.StatusNotFound)
+		return
+	}
+	st, err := db.Stats(r.Context(), statsFilter{RequestID: id})
+	if err != nil {
+		log.Printf("ERROR: failed to compute statistics: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	now := time.Now().UTC()
+	summary := model.PublicStatsSummary{
+		RequestID: id,
+		Verified:  st.Total,
+		UpdatedAt: now.Format(time.RFC3339),
+	}
+	for _, d := range lastDays(st.PerDay, req.PublicStats.HistogramDays(), now) {
+		summary.Daily = append(summary.Daily, model.DailyCount{Date: d.Key, Count: d.Count})
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(publicStatsMaxAge.Seconds())))
+	if page {
+		renderPublicStats(w, req, &summary)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(summary); err != nil {
+		log.Printf("ERROR: failed to encode public statistics: %v", err)
+	}
+}
+
+var publicStatsPage = template.Must(template.New("public-stats").Parse(`<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="utf-8">
+    <meta http-equiv="refresh" content="{{.Refresh}}">
+    <title>{{.Request.Proposal.Title}}</title>
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, Helvetica, Arial, sans-serif; margin: 0; padding: 16px; color: #1a1c1e; background: white; }
+        .count { font-size: 2.5rem; font-weight: bold; color: #2e7d32; }
+        .label { font-size: 0.75rem; color: #888; text-transform: uppercase; letter-spacing: 0.5px; }
+        .columns { display: flex; align-items: flex-end; gap: 2px; height: 60px; margin-top: 12px; }
+        .column { flex: 1; background: #3f51b5; min-height: 1px; }
+    </style>
+</head>
+<body>
+    <div class="count">{{.Summary.Verified}}</div>
+    <div class="label">verified signatures · {{.Request.Proposal.Title}}</div>
+    <div class="columns">
+        {{range .Bars}}<div class="column" style="height: {{.Percent}}%" title="{{.Label}}: {{.Count}}"></div>{{end}}
+    </div>
+    <div class="label">Signatures per day, last {{len .Bars}} days</div>
+</body>
+</html>`))
+
+func renderPublicStats(w http.ResponseWriter, req *model.SignRequest, summary *model.PublicStatsSummary) {
+	counts := make([]StatCount, len(summary.Daily))
+	for i, d := range summary.Daily {
+		counts[i] = StatCount{Key: d.Date, Count: d.Count}
+	}
+	data := struct {
+		Request *model.SignRequest
+		Summary *model.PublicStatsSummary
+		Bars    []chartBar
+		Refresh int
+	}{req, summary, chartBars(counts), int(publicStatsMaxAge.Seconds())}
+	if err := publicStatsPage.Execute(w, data); err != nil {
+		log.Printf("ERROR: failed to render public statistics: %v", err)
+	}
+}
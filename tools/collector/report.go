@@ -0,0 +1,481 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/cades"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+	"github.com/vocdoni/gofirma/vocsign/pkg/cadesverify"
+)
+
+// Per-signature verification reports. Every accepted signature is kept by
+// receipt ID, and GET /signatures/{receiptId}/report?token=... returns the
+// detailed result of validating it, as JSON or (with a .pdf suffix or an
+// Accept: application/pdf header) as a printable PDF, so promoters can answer
+// a challenge from the electoral board about a specific signature. The token
+// comes with the receipt; without it a report, which names the signer, is
+// not served.
+
+// Check statuses. A report is valid when no check failed.
+const (
+	checkPass    = "pass"
+	checkFail    = "fail"
+	checkWarning = "warning"
+	checkSkipped = "skipped"
+)
+
+// ReportCheck is the outcome of one validation step.
+type ReportCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+}
+
+// SignatureReport is the validation result of one accepted signature.
+type SignatureReport struct {
+	ReceiptID       string        `json:"receiptId"`
+	RequestID       string        `json:"requestId"`
+	DocumentVersion int           `json:"documentVersion,omitempty"`
+	ReceivedAt      string        `json:"receivedAt"`
+	CheckedAt       string        `json:"checkedAt"`
+	SigningTime     string        `json:"signingTime,omitempty"`
+	SignerSubject   string        `json:"signerSubject,omitempty"`
+	SignerSerial    string        `json:"signerSerial,omitempty"`
+	CertFingerprint string        `json:"certFingerprint,omitempty"` // hex SHA-256
+	Valid           bool          `json:"valid"`
+	Checks          []ReportCheck `json:"checks"`
+}
+
+// signatureRecord is an accepted signature with the request it was
+// accepted for. The report is built in the background right after the
+// signature is accepted, or on the first request for it if that did not
+// finish, and stored with the signature.
+type signatureRecord struct {
+	ReceiptID  string
+	ReceivedAt time.Time
+	Request    model.SignRequest
+	Response   model.SignResponse
+	Report     *SignatureReport
+	// ArchiveKey is where the signature's artifacts are archived. The store
+	// does not keep Response then; loadResponse reads it back.
+	ArchiveKey string
+	SignerCA   string // issuer of the signer certificate, for statistics
+	// Key is the idempotency key of the submission: the hex SHA-256 of its
+	// signature, as clients send in Idempotency-Key.
+	Key string
+}
+
+var (
+	// reports tracks the reports being built in the background, so a
+	// collector shutting down can wait for them.
+	reports sync.WaitGroup
+
+	// trustRoots anchors signer chains. Without it chains are only checked
+	// for consistency.
+	trustRoots *x509.CertPool
+)
+
+// recordSignature starts validating an accepted signature.
+func recordSignature(rec *signatureRecord) {
+	reports.Go(func() {
+		if _, err := reportFor(context.Background(), rec); err != nil {
+			log.Printf("WARNING: failed to store report for %s: %v", rec.ReceiptID, err)
+		}
+	})
+}
+
+// reportFor returns the verification report of rec, building and storing it
+// if none is stored yet. If several replicas build it at once, the first one
+// stored is kept.
+func reportFor(ctx context.Context, rec *signatureRecord) (*SignatureReport, error) {
+	if rec.Report != nil {
+		return rec.Report, nil
+	}
+	if err := loadResponse(ctx, rec); err != nil {
+		return nil, err
+	}
+	return db.SetReport(ctx, rec.ReceiptID, buildReport(rec, time.Now()))
+}
+
+// reportToken returns the token that authorizes reading the report of a
+// receipt: an HMAC of the receipt ID under a key derived from the organizer
+// key, so every replica computes the same token and the organizer can
+// recompute it for any receipt.
+func reportToken(receiptID string) string {
+	key := sha256.Sum256(append([]byte("vocsign report token\x00"), organizerKey.D.Bytes()...))
+	mac := hmac.New(sha256.New, key[:])
+	mac.Write([]byte(receiptID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func handleSignatureReport(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/signatures/")
+	id, format, ok := strings.Cut(rest, "/")
+	if !ok || (format != "report" && format != "report.pdf") {
+		http.NotFound(w, r)
+		return
+	}
+	if !hmac.Equal([]byte(r.URL.Query().Get("token")), []byte(reportToken(id))) {
+		http.Error(w, "Invalid report token", http.StatusForbidden)
+		return
+	}
+	rec, err := db.Receipt(r.Context(), id)
+	if errors.Is(err, errNotFound) {
+		http.Error(w, "Signature not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: failed to load signature %s: %v", id, err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	report, err := reportFor(r.Context(), rec)
+	if err != nil {
+		log.Printf("ERROR: failed to store report for %s: %v", id, err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	if format == "report.pdf" || strings.Contains(r.Header.Get("Accept"), "application/pdf") {
+		var buf bytes.Buffer
+		if err := writeReportPDF(&buf, report); err != nil {
+			log.Printf("ERROR: failed to render report for %s: %v", id, err)
+			http.Error(w, "Failed to render report", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", "signature-"+id+".pdf"))
+		_, _ = w.Write(buf.Bytes())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		log.Printf("ERROR: failed to encode report: %v", err)
+	}
+}
+
+func buildReport(rec *signatureRecord, now time.Time) *SignatureReport {
+	resp := &rec.Response
+	req := &rec.Request
+	version, _ := signedDocumentVersion(resp)
+	r := &SignatureReport{
+		ReceiptID:       rec.ReceiptID,
+		RequestID:       req.RequestID,
+		DocumentVersion: version,
+		ReceivedAt:      rec.ReceivedAt.UTC().Format(time.RFC3339),
+		CheckedAt:       now.UTC().Format(time.RFC3339),
+	}
+	add := func(name, status, format string, args ...any) {
+		r.Checks = append(r.Checks, ReportCheck{Name: name, Status: status, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	sigDER, err := base64.StdEncoding.DecodeString(resp.SignatureDerBase64)
+	if err != nil {
+		add("signature", checkFail, "signature is not valid base64: %v", err)
+		return finishReport(r)
+	}
+	xmlBytes, err := base64.StdEncoding.DecodeString(resp.SignerXMLBase64)
+	if err != nil {
+		add("signature", checkFail, "signed XML is not valid base64: %v", err)
+		return finishReport(r)
+	}
+	chain, err := parseChain(resp)
+	if err != nil {
+		add("signature", checkFail, "%v", err)
+		return finishReport(r)
+	}
+	cert := chain[0]
+	fp := sha256.Sum256(cert.Raw)
+	r.SignerSubject = cert.Subject.String()
+	r.SignerSerial = cert.SerialNumber.String()
+	r.CertFingerprint = hex.EncodeToString(fp[:])
+
+	summary, err := cades.Inspect(sigDER)
+	if err != nil {
+		add("signature", checkFail, "%v", err)
+		return finishReport(r)
+	}
+	signingTime := summary.SigningTime
+	if signingTime.IsZero() {
+		signingTime, _ = time.Parse(time.RFC3339, resp.SignedAt)
+	}
+	if !signingTime.IsZero() {
+		r.SigningTime = signingTime.UTC().Format(time.RFC3339)
+	}
+
+	r.Checks = append(r.Checks,
+		checkSignature(sigDER, xmlBytes, cert),
+		checkChain(chain, signingTime),
+		checkOCSP(chain, signingTime),
+		checkPolicy(req, sigDER, summary),
+		checkTimestamp(resp, sigDER, summary, signingTime),
+		checkSignerXML(req, resp, xmlBytes),
+	)
+	return finishReport(r)
+}
+
+func finishReport(r *SignatureReport) *SignatureReport {
+	r.Valid = true
+	for _, c := range r.Checks {
+		if c.Status == checkFail {
+			r.Valid = false
+		}
+	}
+	return r
+}
+
+// parseChain returns the signer certificate followed by the chain the
+// client sent.
+func parseChain(resp *model.SignResponse) ([]*x509.Certificate, error) {
+	var chain []*x509.Certificate
+	for i, p := range append([]string{resp.SignerCertPEM}, resp.ChainPEM...) {
+		block, _ := pem.Decode([]byte(p))
+		if block == nil {
+			return nil, fmt.Errorf("certificate %d is not PEM", i)
+		}
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("certificate %d: %w", i, err)
+		}
+		chain = append(chain, c)
+	}
+	return chain, nil
+}
+
+func checkSignature(sigDER, xmlBytes []byte, cert *x509.Certificate) ReportCheck {
+	c := ReportCheck{Name: "signature"}
+	signers, err := cadesverify.VerifySignature(xmlBytes, sigDER)
+	if err != nil {
+		c.Status, c.Detail = checkFail, "CAdES signature does not verify over the signed XML: "+err.Error()
+		return c
+	}
+	if !signers[0].Equal(cert) {
+		c.Status, c.Detail = checkFail, "the signature was made with a different certificate than the one submitted"
+		return c
+	}
+	c.Status, c.Detail = checkPass, "CAdES detached signature verifies over the signed XML"
+	var coSigners []string
+	for _, s := range signers[1:] {
+		coSigners = append(coSigners, s.Subject.CommonName)
+	}
+	if len(coSigners) > 0 {
+		c.Detail += ", also signed by " + strings.Join(coSigners, ", ")
+	}
+	return c
+}
+
+func checkChain(chain []*x509.Certificate, at time.Time) ReportCheck {
+	c := ReportCheck{Name: "chain"}
+	verified, err := cadesverify.VerifyChain(chain, trustRoots, at)
+	if err != nil {
+		c.Status, c.Detail = checkFail, err.Error()
+		return c
+	}
+	if trustRoots == nil {
+		c.Status, c.Detail = checkWarning, fmt.Sprintf("%d certificates form a consistent chain valid at signing time; not anchored to a trust list (start the collector with -trust-roots)", len(chain))
+		return c
+	}
+	root := verified[len(verified)-1]
+	c.Status, c.Detail = checkPass, "chain valid at signing time up to trusted root "+root.Subject.String()
+	return c
+}
+
+// checkOCSP asks the issuer's OCSP responder for the signer certificate's
+// status. The answer is the status when the report was built, so a
+// revocation is only a failure if it predates the signature.
+func checkOCSP(chain []*x509.Certificate, signingTime time.Time) ReportCheck {
+	c := ReportCheck{Name: "ocsp"}
+	cert := chain[0]
+	if len(cert.OCSPServer) == 0 {
+		c.Status, c.Detail = checkSkipped, "the certificate names no OCSP responder"
+		return c
+	}
+	if len(chain) < 2 {
+		c.Status, c.Detail = checkSkipped, "the issuer certificate was not submitted"
+		return c
+	}
+	issuer := chain[1]
+	ocspReq, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		c.Status, c.Detail = checkWarning, "could not build OCSP request: "+err.Error()
+		return c
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, cert.OCSPServer[0], bytes.NewReader(ocspReq))
+	if err != nil {
+		c.Status, c.Detail = checkWarning, "invalid OCSP responder URL: "+err.Error()
+		return c
+	}
+	httpReq.Header.Set("Content-Type", "application/ocsp-request")
+	httpResp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		c.Status, c.Detail = checkWarning, "OCSP responder unreachable: "+err.Error()
+		return c
+	}
+	defer func() { _ = httpResp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(httpResp.Body, 1<<20))
+	if err != nil {
+		c.Status, c.Detail = checkWarning, "failed to read OCSP response: "+err.Error()
+		return c
+	}
+	res, err := ocsp.ParseResponseForCert(body, cert, issuer)
+	if err != nil {
+		c.Status, c.Detail = checkWarning, "invalid OCSP response: "+err.Error()
+		return c
+	}
+	switch res.Status {
+	case ocsp.Good:
+		c.Status, c.Detail = checkPass, "good according to "+cert.OCSPServer[0]+" at "+res.ThisUpdate.UTC().Format(time.RFC3339)
+	case ocsp.Revoked:
+		if !signingTime.IsZero() && res.RevokedAt.After(signingTime) {
+			c.Status, c.Detail = checkWarning, "revoked at "+res.RevokedAt.UTC().Format(time.RFC3339)+", after the signature was made"
+		} else {
+			c.Status, c.Detail = checkFail, "revoked at "+res.RevokedAt.UTC().Format(time.RFC3339)
+		}
+	default:
+		c.Status, c.Detail = checkWarning, "the responder does not know this certificate"
+	}
+	return c
+}
+
+func checkPolicy(req *model.SignRequest, sigDER []byte, sum *cades.Summary) ReportCheck {
+	c := ReportCheck{Name: "policy"}
+	pol := req.Policy
+	if pol == nil {
+		c.Status, c.Detail = checkSkipped, "the request has no signature policy"
+		return c
+	}
+	missing := checkWarning
+	if pol.Mode == "required" {
+		missing = checkFail
+	}
+	if sum.PolicyOID == "" {
+		c.Status, c.Detail = missing, "the signature carries no policy identifier"
+		return c
+	}
+	want, err := base64.StdEncoding.DecodeString(pol.Hash)
+	if err != nil {
+		c.Status, c.Detail = checkFail, "the request's policy hash is not valid base64"
+		return c
+	}
+	if err := cadesverify.VerifyPolicy(sigDER, cadesverify.Policy{OID: pol.OID, Hash: want}); err != nil {
+		c.Status, c.Detail = checkFail, err.Error()
+		return c
+	}
+	c.Status, c.Detail = checkPass, "signed under policy "+pol.OID+" with the expected hash"
+	return c
+}
+
+func checkTimestamp(resp *model.SignResponse, sigDER []byte, sum *cades.Summary, signingTime time.Time) ReportCheck {
+	c := ReportCheck{Name: "timestamp"}
+	if resp.TimestampTokenBase64 == "" {
+		if sum.HasTimestamp {
+			c.Status, c.Detail = checkWarning, "a timestamp is embedded in the signature but was not submitted separately, so it was not checked"
+		} else {
+			c.Status, c.Detail = checkWarning, "no timestamp; the signing time is the signer's own clock"
+		}
+		return c
+	}
+	token, err := base64.StdEncoding.DecodeString(resp.TimestampTokenBase64)
+	if err != nil {
+		c.Status, c.Detail = checkFail, "timestamp token is not valid base64"
+		return c
+	}
+	at, err := cades.VerifyTimestamp(token, sigDER)
+	if err != nil {
+		c.Status, c.Detail = checkFail, err.Error()
+		return c
+	}
+	c.Status, c.Detail = checkPass, "RFC 3161 timestamp over the signature value at "+at.UTC().Format(time.RFC3339)
+	if d := at.Sub(signingTime); !signingTime.IsZero() && (d > 24*time.Hour || d < -24*time.Hour) {
+		c.Status = checkWarning
+		c.Detail += ", far from the claimed signing time " + signingTime.UTC().Format(time.RFC3339)
+	}
+	return c
+}
+
+// checkSignerXML checks the signed XML against the ILP signer schema and
+// the request it was submitted for.
+func checkSignerXML(req *model.SignRequest, resp *model.SignResponse, xmlBytes []byte) ReportCheck {
+	c := ReportCheck{Name: "xml"}
+	var doc model.ILPSignerXML
+	if err := xml.Unmarshal(xmlBytes, &doc); err != nil {
+		c.Status, c.Detail = checkFail, "not a SignaturaILP document: "+err.Error()
+		return c
+	}
+	var problems []string
+	sum := sha256.Sum256(xmlBytes)
+	if resp.PayloadCanonicalSHA256 != base64.StdEncoding.EncodeToString(sum[:]) {
+		problems = append(problems, "payloadCanonicalSha256 does not match the signed XML")
+	}
+	if doc.Versio != "1.0" {
+		problems = append(problems, fmt.Sprintf("unknown version %q", doc.Versio))
+	}
+	if doc.ILP.Codi != req.RequestID {
+		problems = append(problems, fmt.Sprintf("ILP code %q is not the request %q", doc.ILP.Codi, req.RequestID))
+	}
+	s := doc.Signant
+	for _, f := range []struct{ name, value string }{
+		{"Nom", s.Nom},
+		{"Cognom1", s.Cognom1},
+		{"TipusIdentificador", s.TipusIdentifica},
+		{"NumeroIdentificador", s.NumIdentifica},
+	} {
+		if strings.TrimSpace(f.value) == "" {
+			problems = append(problems, f.name+" is empty")
+		}
+	}
+	fields := req.SignerFields()
+	switch mode := fields.BirthDateMode(); {
+	case mode == model.SignerFieldOmitted && s.DataNaixement != "":
+		problems = append(problems, "DataNaixement is present but the request omits it")
+	case mode == model.SignerFieldRequired || s.DataNaixement != "":
+		if _, err := time.Parse("2006-01-02", s.DataNaixement); err != nil {
+			problems = append(problems, fmt.Sprintf("DataNaixement %q is not a YYYY-MM-DD date", s.DataNaixement))
+		}
+	}
+	switch fields.SecondSurnameMode() {
+	case model.SignerFieldOmitted:
+		if s.Cognom2 != "" {
+			problems = append(problems, "Cognom2 is present but the request omits it")
+		}
+	case model.SignerFieldRequired:
+		if strings.TrimSpace(s.Cognom2) == "" {
+			problems = append(problems, "Cognom2 is empty")
+		}
+	}
+	if cert := doc.Certificacio; cert != nil && cert.Origen != model.OrigenPresencial && cert.Origen != model.OrigenPaper {
+		problems = append(problems, fmt.Sprintf("unknown Certificacio origin %q", cert.Origen))
+	}
+	if len(problems) > 0 {
+		c.Status, c.Detail = checkFail, strings.Join(problems, "; ")
+		return c
+	}
+	c.Status, c.Detail = checkPass, "well-formed SignaturaILP 1.0 for "+req.RequestID
+	if doc.Certificacio != nil {
+		c.Detail += ", certified by " + doc.Certificacio.Fedatari + " (" + doc.Certificacio.Origen + ")"
+	}
+	return c
+}
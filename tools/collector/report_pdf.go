@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// writeReportPDF renders r as a plain A4 text document.
+func writeReportPDF(w io.Writer, r *SignatureReport) error {
+	verdict := "VALID: no check failed"
+	if !r.Valid {
+		verdict = "NOT VALID: at least one check failed"
+	}
+	lines := []string{
+		"Receipt:           " + r.ReceiptID,
+		"Request:           " + r.RequestID,
+	}
+	if r.DocumentVersion > 0 {
+		lines = append(lines, fmt.Sprintf("Proposal version:  %d", r.DocumentVersion))
+	}
+	lines = append(lines,
+		"Received:          "+r.ReceivedAt,
+		"Signing time:      "+r.SigningTime,
+		"Checked:           "+r.CheckedAt,
+		"Signer:            "+r.SignerSubject,
+		"Serial number:     "+r.SignerSerial,
+		"Cert SHA-256:      "+r.CertFingerprint,
+		"",
+		"Result: "+verdict,
+		"",
+	)
+	for _, c := range r.Checks {
+		lines = append(lines, fmt.Sprintf("[%s] %s", strings.ToUpper(c.Status), c.Name))
+		for _, l := range wrapText(c.Detail, 86) {
+			lines = append(lines, "    "+l)
+		}
+		lines = append(lines, "")
+	}
+	return writeTextPDF(w, "Signature verification report", lines)
+}
+
+// wrapText splits s into lines of at most width characters at spaces.
+func wrapText(s string, width int) []string {
+	var out []string
+	rs := []rune(s)
+	for len(rs) > width {
+		cut := width
+		for i := width; i > 0; i-- {
+			if rs[i] == ' ' {
+				cut = i
+				break
+			}
+		}
+		out = append(out, string(rs[:cut]))
+		rs = []rune(strings.TrimLeft(string(rs[cut:]), " "))
+	}
+	return append(out, string(rs))
+}
+
+// PDF page geometry, in points.
+const (
+	pdfPageWidth    = 595 // A4
+	pdfPageHeight   = 842
+	pdfMargin       = 50
+	pdfLeading      = 14
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin) / pdfLeading
+)
+
+// writeTextPDF writes a minimal PDF with a bold title and lines of
+// Helvetica text, starting a new page when one is full. Text is encoded as
+// WinAnsi; characters outside it are replaced.
+func writeTextPDF(w io.Writer, title string, lines []string) error {
+	pages := [][]string{}
+	for first := true; first || len(lines) > 0; first = false {
+		n := pdfLinesPerPage
+		if first {
+			n -= 2 // the title
+		}
+		n = min(n, len(lines))
+		pages = append(pages, lines[:n])
+		lines = lines[n:]
+	}
+
+	var buf bytes.Buffer
+	var offsets []int
+	obj := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+	// Objects 1-4 are the catalog, page tree and fonts; each page then
+	// takes two objects, the page and its content stream.
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range pages {
+		var content strings.Builder
+		fmt.Fprintf(&content, "BT\n%d TL\n%d %d Td\n", pdfLeading, pdfMargin, pdfPageHeight-pdfMargin)
+		if i == 0 {
+			fmt.Fprintf(&content, "/F2 14 Tf\n(%s) Tj T* T*\n", pdfString(title))
+		}
+		content.WriteString("/F1 10 Tf\n")
+		for _, l := range page {
+			fmt.Fprintf(&content, "(%s) Tj T*\n", pdfString(l))
+		}
+		content.WriteString("ET")
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 6+2*i))
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// pdfString encodes s as the body of a PDF literal string.
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		ch, ok := charmap.Windows1252.EncodeRune(r)
+		if !ok {
+			ch = '?'
+		}
+		if ch == '(' || ch == ')' || ch == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(ch)
+	}
+	return b.String()
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	vnet "github.com/vocdoni/gofirma/vocsign/internal/net"
+)
+
+func TestSignatureReport_Token(t *testing.T) {
+	srv := newTestCollector(t)
+	req := testRequest(t)
+	receipt, err := vnet.Submit(context.Background(), req.Callback.URL, signTestResponse(t, req))
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if receipt.ReportToken == "" {
+		t.Fatal("receipt carries no report token")
+	}
+
+	reportURL := srv.URL + "/signatures/" + receipt.ReceiptID + "/report"
+	for _, token := range []string{"", "0000", reportToken("other-receipt")} {
+		resp, err := srv.Client().Get(reportURL + "?token=" + token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("report with token %q: status %d, want 403", token, resp.StatusCode)
+		}
+	}
+
+	resp, err := srv.Client().Get(reportURL + "?token=" + receipt.ReportToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var report SignatureReport
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("report with the receipt's token: status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil || report.ReceiptID != receipt.ReceiptID {
+		t.Fatalf("report = %+v, %v", report, err)
+	}
+}
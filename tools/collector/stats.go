@@ -0,0 +1,246 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Campaign analytics. GET /stats covers every proposal and
+// GET /stats/{requestId} one of them. Both return aggregate counts only:
+// signatures per day, per jurisdiction and per issuing CA, and the outcome
+// of their verification reports. Promoters can follow a campaign without
+// exporting personal data. ?from= and ?to= (YYYY-MM-DD, both inclusive)
+// restrict the counts to signatures received on those days (UTC).
+
+// statsFilter selects the signatures counted.
+type statsFilter struct {
+	RequestID string    // empty for every proposal
+	From      time.Time // zero for no lower bound
+	To        time.Time // exclusive; zero for no upper bound
+}
+
+// includes reports whether a signature received at t is counted.
+func (f statsFilter) includes(t time.Time) bool {
+	return (f.From.IsZero() || !t.Before(f.From)) && (f.To.IsZero() || t.Before(f.To))
+}
+
+// StatCount is the number of signatures with a given key.
+type StatCount struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+// CampaignStats are the aggregate statistics served at /stats.
+type CampaignStats struct {
+	RequestID       string            `json:"requestId,omitempty"`
+	Total           int               `json:"total"`
+	PerDay          []StatCount       `json:"perDay"`          // by day, oldest first
+	PerJurisdiction []StatCount       `json:"perJurisdiction"` // most signatures first
+	PerCA           []StatCount       `json:"perCa"`           // issuer of the signer certificate
+	Verification    VerificationStats `json:"verification"`
+}
+
+// VerificationStats summarize the verification reports of the signatures.
+type VerificationStats struct {
+	Valid   int `json:"valid"`
+	Invalid int `json:"invalid"`
+	Pending int `json:"pending"` // no report built yet
+	// Failures and Warnings count, per check, the reports where the check
+	// failed or warned.
+	Failures []StatCount `json:"failures"`
+	Warnings []StatCount `json:"warnings"`
+}
+
+// statsCounts collects the counts of one dimension.
+type statsCounts map[string]int
+
+// byCount returns the counts, most signatures first.
+func (c statsCounts) byCount() []StatCount {
+	out := c.byKey()
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Count > out[j].Count })
+	return out
+}
+
+// byKey returns the counts ordered by key.
+func (c statsCounts) byKey() []StatCount {
+	out := make([]StatCount, 0, len(c))
+	for k, n := range c {
+		out = append(out, StatCount{Key: k, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// statsAccumulator builds CampaignStats from counts a store gathers.
+type statsAccumulator struct {
+	days, jurisdictions, cas statsCounts
+	verdicts                 statsCounts // "valid", "invalid" or "pending"
+	failures, warnings       statsCounts
+}
+
+func newStatsAccumulator() *statsAccumulator {
+	return &statsAccumulator{
+		days: statsCounts{}, jurisdictions: statsCounts{}, cas: statsCounts{},
+		verdicts: statsCounts{}, failures: statsCounts{}, warnings: statsCounts{},
+	}
+}
+
+// addReport counts the outcome of report, which is nil if not built yet.
+func (a *statsAccumulator) addReport(report *SignatureReport) {
+	switch {
+	case report == nil:
+		a.verdicts["pending"]++
+		return
+	case report.Valid:
+		a.verdicts["valid"]++
+	default:
+		a.verdicts["invalid"]++
+	}
+	for _, c := range report.Checks {
+		switch c.Status {
+		case checkFail:
+			a.failures[c.Name]++
+		case checkWarning:
+			a.warnings[c.Name]++
+		}
+	}
+}
+
+func (a *statsAccumulator) stats(requestID string) *CampaignStats {
+	st := &CampaignStats{
+		RequestID:       requestID,
+		PerDay:          a.days.byKey(),
+		PerJurisdiction: a.jurisdictions.byCount(),
+		PerCA:           a.cas.byCount(),
+		Verification: VerificationStats{
+			Valid:    a.verdicts["valid"],
+			Invalid:  a.verdicts["invalid"],
+			Pending:  a.verdicts["pending"],
+			Failures: a.failures.byCount(),
+			Warnings: a.warnings.byCount(),
+		},
+	}
+	for _, d := range st.PerDay {
+		st.Total += d.Count
+	}
+	return st
+}
+
+// statsDay is the day, in UTC, a signature received at t is counted in.
+func statsDay(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
+
+// unknownCA labels signatures whose issuer was not recorded.
+const unknownCA = "unknown"
+
+// issuerName is the CA a signer certificate is counted under.
+func issuerName(cert *x509.Certificate) string {
+	switch {
+	case cert.Issuer.CommonName != "":
+		return cert.Issuer.CommonName
+	case len(cert.Issuer.Organization) > 0:
+		return cert.Issuer.Organization[0]
+	default:
+		return cert.Issuer.String()
+	}
+}
+
+// parseStatsFilter reads the proposal from the path and the day range from
+// the query of r.
+func parseStatsFilter(r *http.Request) (statsFilter, error) {
+	f := statsFilter{RequestID: strings.Trim(strings.TrimPrefix(r.URL.Path, "/stats"), "/")}
+	q := r.URL.Query()
+	if s := q.Get("from"); s != "" {
+		d, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			return f, fmt.Errorf("from must be a YYYY-MM-DD date")
+		}
+		f.From = d
+	}
+	if s := q.Get("to"); s != "" {
+		d, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			return f, fmt.Errorf("to must be a YYYY-MM-DD date")
+		}
+		f.To = d.AddDate(0, 0, 1)
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return f, fmt.Errorf("from is after to")
+	}
+	return f, nil
+}
+
+func handleStats(w http.ResponseWriter, r *http.Request) {
+	f, err := parseStatsFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if f.RequestID != "" {
+		if _, ok := loadProposal(w, r, f.RequestID); !ok {
+			return
+		}
+	}
+	st, err := db.Stats(r.Context(), f)
+	if err != nil {
+		log.Printf("ERROR: failed to compute statistics: %v", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(st); err != nil {
+		log.Printf("ERROR: failed to encode statistics: %v", err)
+	}
+}
+
+// chartBar is one bar of a dashboard chart.
+type chartBar struct {
+	Label   string
+	Count   int
+	Percent int // of the largest bar
+}
+
+// chartBars scales counts for a horizontal bar chart.
+func chartBars(counts []StatCount) []chartBar {
+	most := 0
+	for _, c := range counts {
+		most = max(most, c.Count)
+	}
+	bars := make([]chartBar, len(counts))
+	for i, c := range counts {
+		bars[i] = chartBar{Label: c.Key, Count: c.Count}
+		if most > 0 {
+			bars[i].Percent = c.Count * 100 / most
+		}
+	}
+	return bars
+}
+
+// dailyBars returns one bar per day for the days days up to today.
+func dailyBars(perDay []StatCount, days int, today time.Time) []chartBar {
+	return chartBars(lastDays(perDay, days, today))
+}
+
+// lastDays returns the counts of the days days up to today from perDay,
+// with the days without signatures at zero.
+func lastDays(perDay []StatCount, days int, today time.Time) []StatCount {
+	byDay := statsCounts{}
+	for _, d := range perDay {
+		byDay[d.Key] = d.Count
+	}
+	counts := make([]StatCount, days)
+	for i := range counts {
+		day := statsDay(today.AddDate(0, 0, i-days+1))
+		counts[i] = StatCount{Key: day, Count: byDay[day]}
+	}
+	return counts
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	vnet "github.com/vocdoni/gofirma/vocsign/internal/net"
+)
+
+func TestHandleStats(t *testing.T) {
+	srv := newTestCollector(t)
+	req := testRequest(t)
+	for range 2 {
+		if _, err := vnet.Submit(context.Background(), req.Callback.URL, signTestResponse(t, req)); err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+	}
+	reports.Wait()
+
+	get := func(path string) (*http.Response, CampaignStats) {
+		t.Helper()
+		resp, err := srv.Client().Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var st CampaignStats
+		if resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(&st); err != nil {
+				t.Fatalf("%s: %v", path, err)
+			}
+		}
+		return resp, st
+	}
+
+	today := time.Now().UTC().Format(time.DateOnly)
+	for _, path := range []string{"/stats", "/stats/" + testProposal, "/stats/" + testProposal + "?from=" + today + "&to=" + today} {
+		resp, st := get(path)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s = %d", path, resp.StatusCode)
+		}
+		if st.Total != 2 {
+			t.Errorf("%s total = %d, want 2", path, st.Total)
+		}
+		if len(st.PerDay) != 1 || st.PerDay[0].Key != today || st.PerDay[0].Count != 2 {
+			t.Errorf("%s perDay = %+v", path, st.PerDay)
+		}
+		if len(st.PerCA) != 1 || st.PerCA[0].Count != 2 {
+			t.Errorf("%s perCa = %+v", path, st.PerCA)
+		}
+		if v := st.Verification; v.Valid+v.Invalid != 2 || v.Pending != 0 {
+			t.Errorf("%s verification = %+v", path, v)
+		}
+	}
+
+	// Days before the signatures count nothing.
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format(time.DateOnly)
+	if _, st := get("/stats/" + testProposal + "?to=" + yesterday); st.Total != 0 {
+		t.Errorf("total before today = %d", st.Total)
+	}
+
+	for path, want := range map[string]int{
+		"/stats?from=yesterday":                     http.StatusBadRequest,
+		"/stats?from=" + today + "&to=" + yesterday: http.StatusBadRequest,
+		"/stats/ILP-UNKNOWN":                        http.StatusNotFound,
+	} {
+		if resp, _ := get(path); resp.StatusCode != want {
+			t.Errorf("%s = %d, want %d", path, resp.StatusCode, want)
+		}
+	}
+}
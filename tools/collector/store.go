@@ -0,0 +1,365 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+	"github.com/vocdoni/gofirma/vocsign/internal/translog"
+)
+
+// Collector state. Handlers keep nothing between requests: proposals,
+// accepted signatures, their reports and the transparency log live in a
+// store. Started with -database-url, the store is a Postgres database shared
+// by every replica, so any number of collectors behind a load balancer can
+// serve the same campaigns. Without it state is kept in memory and lost on
+// restart.
+
+var errNotFound = errors.New("not found")
+
+// errAlreadyAccepted is returned by AddSignature for a signature the
+// proposal already accepted.
+var errAlreadyAccepted = errors.New("signature already accepted")
+
+// proposalSummary is a proposal as listed on the dashboard.
+type proposalSummary struct {
+	Request    model.SignRequest
+	Signatures int
+}
+
+type store interface {
+	// CreateProposal stores req, already signed, and appends it to the
+	// transparency log. It stores nothing and returns false if a proposal
+	// with the same ID exists.
+	CreateProposal(ctx context.Context, req *model.SignRequest) (bool, error)
+	// Proposal returns the current request of proposal id, or errNotFound.
+	Proposal(ctx context.Context, id string) (*model.SignRequest, error)
+	// Proposals returns every proposal, ordered by ID.
+	Proposals(ctx context.Context) ([]proposalSummary, error)
+	// AmendProposal calls amend on a copy of the current request of
+	// proposal id and, if it succeeds, stores and logs the result. No
+	// signature is accepted for the proposal while amend runs.
+	AmendProposal(ctx context.Context, id string, amend func(req *model.SignRequest) error) (*model.SignRequest, error)
+	// AddSignature accepts rec.Response for proposal id, unless
+	// checkDocumentVersion rejects it against the current request, and sets
+	// rec.Request to that request. signerTag is the duplicateTag of the
+	// signer, or empty. The response is not kept if rec.ArchiveKey
+	// is set. It returns errAlreadyAccepted, and stores nothing, if a
+	// signature with rec.Key was already accepted for the proposal.
+	AddSignature(ctx context.Context, id string, rec *signatureRecord, signerTag string) (*model.SubmitError, error)
+	// SignatureByKey returns the receipt ID and reception time of the
+	// signature of proposal id with the idempotency key, or errNotFound.
+	SignatureByKey(ctx context.Context, id, key string) (*signatureRecord, error)
+	// Signatures returns the accepted signatures of proposal id in the
+	// order they were received, without their Request and Report.
+	Signatures(ctx context.Context, id string) ([]signatureRecord, error)
+	// HasSigner reports whether a signature of proposal id was stored with
+	// signerTag.
+	HasSigner(ctx context.Context, id, signerTag string) (bool, error)
+	// Receipt returns the accepted signature with the given receipt ID, or
+	// errNotFound. Its Report is nil until one is stored.
+	Receipt(ctx context.Context, receiptID string) (*signatureRecord, error)
+	// SetReport stores report for a receipt unless one is already stored,
+	// and returns the stored one.
+	SetReport(ctx context.Context, receiptID string, report *SignatureReport) (*SignatureReport, error)
+	// Stats aggregates the signatures f selects.
+	Stats(ctx context.Context, f statsFilter) (*CampaignStats, error)
+	// AddContact stores a signer contact, apart from the signatures.
+	AddContact(ctx context.Context, c *contactRecord) error
+	// Contacts returns the contacts of proposal id that have not expired at
+	// now, in the order they were received.
+	Contacts(ctx context.Context, id string, now time.Time) ([]contactRecord, error)
+	// PurgeContacts deletes the contacts expired at now and returns how many.
+	PurgeContacts(ctx context.Context, now time.Time) (int, error)
+	// AddAuditRecords stores the audit entries a certifying agent uploaded
+	// for proposal id, and returns how many were new and how many were
+	// already stored, whoever uploaded them.
+	AddAuditRecords(ctx context.Context, id string, records []auditRecord) (accepted, duplicates int, err error)
+	// AuditRecords returns the audit entries stored for proposal id, in the
+	// order they were received.
+	AuditRecords(ctx context.Context, id string) ([]auditRecord, error)
+	// TransparencyLog returns the log of every request published.
+	TransparencyLog(ctx context.Context) (translog.Snapshot, error)
+	// Ping reports whether the store can serve requests.
+	Ping(ctx context.Context) error
+	Close()
+}
+
+// db is the collector's state.
+var db store
+
+// memStore keeps the state of a single collector in memory.
+type memStore struct {
+	mu        sync.Mutex
+	proposals map[string]*memProposal
+	receipts  map[string]*signatureRecord
+	contacts  []contactRecord
+	log       translog.Log
+}
+
+type memProposal struct {
+	req         model.SignRequest
+	receipts    []*signatureRecord
+	byKey       map[string]*signatureRecord
+	signerTags  map[string]bool
+	audit       []auditRecord
+	auditHashes map[string]bool
+}
+
+func newMemStore() *memStore {
+	return &memStore{
+		proposals: make(map[string]*memProposal),
+		receipts:  make(map[string]*signatureRecord),
+	}
+}
+
+// logRequest appends req to the transparency log.
+func (s *memStore) logRequest(req *model.SignRequest) error {
+	requestHash, err := req.CanonicalHash()
+	if err != nil {
+		return fmt.Errorf("failed to hash request: %w", err)
+	}
+	if _, err := s.log.Append(req.RequestID, requestHash, req.IssuedAt); err != nil {
+		return fmt.Errorf("failed to log request: %w", err)
+	}
+	return nil
+}
+
+func (s *memStore) CreateProposal(ctx context.Context, req *model.SignRequest) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.proposals[req.RequestID]; ok {
+		return false, nil
+	}
+	if err := s.logRequest(req); err != nil {
+		return false, err
+	}
+	s.proposals[req.RequestID] = &memProposal{req: *req, byKey: make(map[string]*signatureRecord), signerTags: make(map[string]bool)}
+	return true, nil
+}
+
+func (s *memStore) Proposal(ctx context.Context, id string) (*model.SignRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.proposals[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	req := p.req
+	return &req, nil
+}
+
+func (s *memStore) Proposals(ctx context.Context) ([]proposalSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]proposalSummary, 0, len(s.proposals))
+	for _, p := range s.proposals {
+		out = append(out, proposalSummary{Request: p.req, Signatures: len(p.receipts)})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Request.RequestID < out[j].Request.RequestID })
+	return out, nil
+}
+
+func (s *memStore) AmendProposal(ctx context.Context, id string, amend func(req *model.SignRequest) error) (*model.SignRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.proposals[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	req := p.req
+	if err := amend(&req); err != nil {
+		return nil, err
+	}
+	if err := s.logRequest(&req); err != nil {
+		return nil, err
+	}
+	p.req = req
+	return &req, nil
+}
+
+func (s *memStore) AddSignature(ctx context.Context, id string, rec *signatureRecord, signerTag string) (*model.SubmitError, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.proposals[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	if _, ok := p.byKey[rec.Key]; ok && rec.Key != "" {
+		return nil, errAlreadyAccepted
+	}
+	if se := checkDocumentVersion(&p.req, &rec.Response); se != nil {
+		return se, nil
+	}
+	rec.Request = p.req
+	stored := *rec
+	if stored.ArchiveKey != "" {
+		stored.Response = model.SignResponse{}
+	}
+	p.receipts = append(p.receipts, &stored)
+	if stored.Key != "" {
+		p.byKey[stored.Key] = &stored
+	}
+	if signerTag != "" {
+		p.signerTags[signerTag] = true
+	}
+	s.receipts[rec.ReceiptID] = &stored
+	return nil, nil
+}
+
+func (s *memStore) SignatureByKey(ctx context.Context, id, key string) (*signatureRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.proposals[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	rec, ok := p.byKey[key]
+	if !ok || key == "" {
+		return nil, errNotFound
+	}
+	return &signatureRecord{ReceiptID: rec.ReceiptID, ReceivedAt: rec.ReceivedAt, Key: rec.Key}, nil
+}
+
+func (s *memStore) Signatures(ctx context.Context, id string) ([]signatureRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.proposals[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	out := make([]signatureRecord, len(p.receipts))
+	for i, rec := range p.receipts {
+		out[i] = signatureRecord{
+			ReceiptID:  rec.ReceiptID,
+			ReceivedAt: rec.ReceivedAt,
+			Response:   rec.Response,
+			ArchiveKey: rec.ArchiveKey,
+		}
+	}
+	return out, nil
+}
+
+func (s *memStore) HasSigner(ctx context.Context, id, signerTag string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.proposals[id]
+	if !ok {
+		return false, errNotFound
+	}
+	return p.signerTags[signerTag], nil
+}
+
+func (s *memStore) Receipt(ctx context.Context, receiptID string) (*signatureRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.receipts[receiptID]
+	if !ok {
+		return nil, errNotFound
+	}
+	out := *rec
+	return &out, nil
+}
+
+func (s *memStore) SetReport(ctx context.Context, receiptID string, report *SignatureReport) (*SignatureReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.receipts[receiptID]
+	if !ok {
+		return nil, errNotFound
+	}
+	if rec.Report == nil {
+		rec.Report = report
+	}
+	return rec.Report, nil
+}
+
+func (s *memStore) Stats(ctx context.Context, f statsFilter) (*CampaignStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := newStatsAccumulator()
+	for _, rec := range s.receipts {
+		if (f.RequestID != "" && rec.Request.RequestID != f.RequestID) || !f.includes(rec.ReceivedAt) {
+			continue
+		}
+		a.days[statsDay(rec.ReceivedAt)]++
+		a.jurisdictions[rec.Request.Proposal.Jurisdiction]++
+		a.cas[cmp.Or(rec.SignerCA, unknownCA)]++
+		a.addReport(rec.Report)
+	}
+	return a.stats(f.RequestID), nil
+}
+
+func (s *memStore) AddAuditRecords(ctx context.Context, id string, records []auditRecord) (int, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.proposals[id]
+	if !ok {
+		return 0, 0, errNotFound
+	}
+	if p.auditHashes == nil {
+		p.auditHashes = make(map[string]bool)
+	}
+	accepted, duplicates := 0, 0
+	for _, r := range records {
+		if p.auditHashes[r.Hash] {
+			duplicates++
+			continue
+		}
+		p.auditHashes[r.Hash] = true
+		p.audit = append(p.audit, r)
+		accepted++
+	}
+	return accepted, duplicates, nil
+}
+
+func (s *memStore) AuditRecords(ctx context.Context, id string) ([]auditRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.proposals[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	return slices.Clone(p.audit), nil
+}
+
+func (s *memStore) AddContact(ctx context.Context, c *contactRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.contacts = append(s.contacts, *c)
+	return nil
+}
+
+func (s *memStore) Contacts(ctx context.Context, id string, now time.Time) ([]contactRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []contactRecord
+	for _, c := range s.contacts {
+		if c.RequestID == id && now.Before(c.ExpiresAt) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (s *memStore) PurgeContacts(ctx context.Context, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.contacts)
+	s.contacts = slices.DeleteFunc(s.contacts, func(c contactRecord) bool { return !now.Before(c.ExpiresAt) })
+	return n - len(s.contacts), nil
+}
+
+func (s *memStore) TransparencyLog(ctx context.Context) (translog.Snapshot, error) {
+	return s.log.Snapshot(), nil
+}
+
+func (s *memStore) Ping(ctx context.Context) error { return nil }
+
+func (s *memStore) Close() {}
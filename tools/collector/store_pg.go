@@ -0,0 +1,509 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+	"github.com/vocdoni/gofirma/vocsign/internal/translog"
+)
+
+// pgSchema is applied when a collector starts. Every published version of a
+// request is kept, so a signature's report is checked against the request
+// it was accepted for even after the proposal is amended.
+const pgSchema = `
+CREATE TABLE IF NOT EXISTS requests (
+	request_id       TEXT    NOT NULL,
+	document_version INTEGER NOT NULL,
+	request          JSONB   NOT NULL,
+	PRIMARY KEY (request_id, document_version)
+);
+CREATE TABLE IF NOT EXISTS proposals (
+	request_id       TEXT    PRIMARY KEY,
+	document_version INTEGER NOT NULL,
+	FOREIGN KEY (request_id, document_version) REFERENCES requests DEFERRABLE INITIALLY DEFERRED
+);
+CREATE TABLE IF NOT EXISTS signatures (
+	seq              BIGSERIAL   PRIMARY KEY,
+	receipt_id       TEXT        NOT NULL UNIQUE,
+	request_id       TEXT        NOT NULL,
+	document_version INTEGER     NOT NULL,
+	received_at      TIMESTAMPTZ NOT NULL,
+	response         JSONB       NOT NULL,
+	signer_hash      TEXT,
+	report           JSONB,
+	FOREIGN KEY (request_id, document_version) REFERENCES requests
+);
+-- With archival the response is in object storage, not here.
+ALTER TABLE signatures ADD COLUMN IF NOT EXISTS archive_key TEXT;
+ALTER TABLE signatures ALTER COLUMN response DROP NOT NULL;
+ALTER TABLE signatures ADD COLUMN IF NOT EXISTS signer_ca TEXT;
+ALTER TABLE signatures ADD COLUMN IF NOT EXISTS idempotency_key TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS signatures_idempotency_key ON signatures (request_id, idempotency_key);
+CREATE INDEX IF NOT EXISTS signatures_received_at ON signatures (request_id, received_at);
+-- Duplicate checks match a keyed tag of the signer hash; signer_hash held
+-- the bare hash before, and migrateSignerHashes replaces it.
+ALTER TABLE signatures ADD COLUMN IF NOT EXISTS signer_tag TEXT;
+DROP INDEX IF EXISTS signatures_signer_hash;
+CREATE INDEX IF NOT EXISTS signatures_signer_tag ON signatures (request_id, signer_tag);
+-- Signer contacts are kept apart from the signatures, with no link to them.
+CREATE TABLE IF NOT EXISTS signer_contacts (
+	id          BIGSERIAL   PRIMARY KEY,
+	request_id  TEXT        NOT NULL,
+	contact     JSONB       NOT NULL,
+	received_at TIMESTAMPTZ NOT NULL,
+	expires_at  TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS signer_contacts_request ON signer_contacts (request_id, expires_at);
+CREATE INDEX IF NOT EXISTS signer_contacts_expires_at ON signer_contacts (expires_at);
+-- Audit entries uploaded by certifying agents, identified by their hash.
+CREATE TABLE IF NOT EXISTS audit_records (
+	seq               BIGSERIAL   PRIMARY KEY,
+	request_id        TEXT        NOT NULL,
+	hash              TEXT        NOT NULL,
+	line              JSONB       NOT NULL,
+	agent_fingerprint TEXT        NOT NULL,
+	received_at       TIMESTAMPTZ NOT NULL,
+	UNIQUE (request_id, hash)
+);
+CREATE TABLE IF NOT EXISTS transparency_log (
+	idx   INTEGER PRIMARY KEY,
+	entry JSONB   NOT NULL
+);
+`
+
+// pgSchemaLock is the advisory lock held while the schema is applied, so
+// replicas starting together do not race creating it.
+const pgSchemaLock = 0x766f6373 // "vocs"
+
+// pgStore keeps the collector state in Postgres.
+type pgStore struct {
+	pool *pgxpool.Pool
+}
+
+// openPGStore connects to the database at url with a pool of at most
+// maxConns connections (the pgx default if zero) and applies the schema.
+func openPGStore(ctx context.Context, url string, maxConns int) (*pgStore, error) {
+	cfg, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database URL: %w", err)
+	}
+	if maxConns > 0 {
+		cfg.MaxConns = int32(maxConns)
+	}
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connection pool: %w", err)
+	}
+	err = pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", pgSchemaLock); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, pgSchema); err != nil {
+			return err
+		}
+		return migrateSignerHashes(ctx, tx)
+	})
+	if err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to apply schema: %w", err)
+	}
+	return &pgStore{pool: pool}, nil
+}
+
+// migrateSignerHashes replaces the duplicate check hashes stored by earlier
+// collectors with their duplicateTag.
+func migrateSignerHashes(ctx context.Context, tx pgx.Tx) error {
+	rows, err := tx.Query(ctx, "SELECT seq, signer_hash FROM signatures WHERE signer_hash IS NOT NULL")
+	if err != nil {
+		return err
+	}
+	type hashRow struct {
+		Seq        int64
+		SignerHash string
+	}
+	hashes, err := pgx.CollectRows(rows, pgx.RowToStructByPos[hashRow])
+	if err != nil {
+		return err
+	}
+	for _, h := range hashes {
+		if _, err := tx.Exec(ctx, "UPDATE signatures SET signer_tag = $2, signer_hash = NULL WHERE seq = $1", h.Seq, duplicateTag(h.SignerHash)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertRequest stores req as a version of its proposal and appends it to
+// the transparency log.
+func insertRequest(ctx context.Context, tx pgx.Tx, req *model.SignRequest) error {
+	if _, err := tx.Exec(ctx, "INSERT INTO requests (request_id, document_version, request) VALUES ($1, $2, $3)",
+		req.RequestID, req.Proposal.DocumentVersion, req); err != nil {
+		return fmt.Errorf("failed to store request: %w", err)
+	}
+	requestHash, err := req.CanonicalHash()
+	if err != nil {
+		return fmt.Errorf("failed to hash request: %w", err)
+	}
+	// Appends are serialized so the chain has no forks; reads go on.
+	if _, err := tx.Exec(ctx, "LOCK TABLE transparency_log IN EXCLUSIVE MODE"); err != nil {
+		return fmt.Errorf("failed to lock transparency log: %w", err)
+	}
+	var last translog.Entry
+	var prev *translog.Entry
+	switch err := tx.QueryRow(ctx, "SELECT entry FROM transparency_log ORDER BY idx DESC LIMIT 1").Scan(&last); {
+	case err == nil:
+		prev = &last
+	case !errors.Is(err, pgx.ErrNoRows):
+		return fmt.Errorf("failed to read transparency log: %w", err)
+	}
+	e, err := translog.Next(prev, req.RequestID, requestHash, req.IssuedAt)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, "INSERT INTO transparency_log (idx, entry) VALUES ($1, $2)", e.Index, e); err != nil {
+		return fmt.Errorf("failed to log request: %w", err)
+	}
+	return nil
+}
+
+// pgQuerier is a pool or a transaction.
+type pgQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// currentRequest reads the current request of proposal id. lock is a row
+// lock clause for the proposal, or empty. The row is locked in a statement
+// of its own: locked in the join, a row that was waiting for an amendment
+// would be rechecked against the request it joined before, and not found.
+func currentRequest(ctx context.Context, q pgQuerier, id, lock string) (*model.SignRequest, error) {
+	if lock != "" {
+		var locked string
+		err := q.QueryRow(ctx, "SELECT request_id FROM proposals WHERE request_id = $1 "+lock, id).Scan(&locked)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	var req model.SignRequest
+	err := q.QueryRow(ctx, `SELECT r.request FROM proposals p JOIN requests r USING (request_id, document_version)
+		WHERE p.request_id = $1`, id).Scan(&req)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func (s *pgStore) CreateProposal(ctx context.Context, req *model.SignRequest) (bool, error) {
+	created := false
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, "INSERT INTO proposals (request_id, document_version) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+			req.RequestID, req.Proposal.DocumentVersion)
+		if err != nil || tag.RowsAffected() == 0 {
+			return err
+		}
+		created = true
+		return insertRequest(ctx, tx, req)
+	})
+	if err != nil {
+		return false, err
+	}
+	return created, nil
+}
+
+func (s *pgStore) Proposal(ctx context.Context, id string) (*model.SignRequest, error) {
+	return currentRequest(ctx, s.pool, id, "")
+}
+
+func (s *pgStore) Proposals(ctx context.Context) ([]proposalSummary, error) {
+	rows, err := s.pool.Query(ctx, `SELECT r.request, (SELECT count(*) FROM signatures s WHERE s.request_id = p.request_id)
+		FROM proposals p JOIN requests r USING (request_id, document_version) ORDER BY p.request_id`)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (proposalSummary, error) {
+		var p proposalSummary
+		err := row.Scan(&p.Request, &p.Signatures)
+		return p, err
+	})
+}
+
+func (s *pgStore) AmendProposal(ctx context.Context, id string, amend func(req *model.SignRequest) error) (*model.SignRequest, error) {
+	var req *model.SignRequest
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		var err error
+		if req, err = currentRequest(ctx, tx, id, "FOR UPDATE"); err != nil {
+			return err
+		}
+		if err := amend(req); err != nil {
+			return err
+		}
+		if err := insertRequest(ctx, tx, req); err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, "UPDATE proposals SET document_version = $2 WHERE request_id = $1", id, req.Proposal.DocumentVersion)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (s *pgStore) AddSignature(ctx context.Context, id string, rec *signatureRecord, signerTag string) (*model.SubmitError, error) {
+	var se *model.SubmitError
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		// The share lock keeps the proposal from being amended until the
+		// signature is stored.
+		req, err := currentRequest(ctx, tx, id, "FOR SHARE")
+		if err != nil {
+			return err
+		}
+		if se = checkDocumentVersion(req, &rec.Response); se != nil {
+			return nil
+		}
+		rec.Request = *req
+		var response *model.SignResponse
+		if rec.ArchiveKey == "" {
+			response = &rec.Response
+		}
+		tag, err := tx.Exec(ctx, `INSERT INTO signatures (receipt_id, request_id, document_version, received_at, response, signer_tag, archive_key, signer_ca, idempotency_key)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, ''))
+			ON CONFLICT (request_id, idempotency_key) DO NOTHING`,
+			rec.ReceiptID, id, req.Proposal.DocumentVersion, rec.ReceivedAt, response, signerTag, rec.ArchiveKey, rec.SignerCA, rec.Key)
+		if err == nil && tag.RowsAffected() == 0 {
+			return errAlreadyAccepted
+		}
+		return err
+	})
+	return se, err
+}
+
+func (s *pgStore) SignatureByKey(ctx context.Context, id, key string) (*signatureRecord, error) {
+	rec := &signatureRecord{Key: key}
+	err := s.pool.QueryRow(ctx, "SELECT receipt_id, received_at FROM signatures WHERE request_id = $1 AND idempotency_key = $2", id, key).
+		Scan(&rec.ReceiptID, &rec.ReceivedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+func (s *pgStore) Signatures(ctx context.Context, id string) ([]signatureRecord, error) {
+	rows, err := s.pool.Query(ctx, `SELECT receipt_id, received_at, response, COALESCE(archive_key, '')
+		FROM signatures WHERE request_id = $1 ORDER BY seq`, id)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, scanSignature)
+}
+
+// scanSignature scans receipt_id, received_at, response and archive_key.
+func scanSignature(row pgx.CollectableRow) (signatureRecord, error) {
+	var rec signatureRecord
+	var response *model.SignResponse
+	if err := row.Scan(&rec.ReceiptID, &rec.ReceivedAt, &response, &rec.ArchiveKey); err != nil {
+		return rec, err
+	}
+	if response != nil {
+		rec.Response = *response
+	}
+	return rec, nil
+}
+
+func (s *pgStore) HasSigner(ctx context.Context, id, signerTag string) (bool, error) {
+	var signed bool
+	err := s.pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM signatures WHERE request_id = $1 AND signer_tag = $2)", id, signerTag).Scan(&signed)
+	return signed, err
+}
+
+func (s *pgStore) Receipt(ctx context.Context, receiptID string) (*signatureRecord, error) {
+	rec := &signatureRecord{}
+	var response *model.SignResponse
+	err := s.pool.QueryRow(ctx, `SELECT s.receipt_id, s.received_at, r.request, s.response, s.report, COALESCE(s.archive_key, '')
+		FROM signatures s JOIN requests r USING (request_id, document_version) WHERE s.receipt_id = $1`, receiptID).
+		Scan(&rec.ReceiptID, &rec.ReceivedAt, &rec.Request, &response, &rec.Report, &rec.ArchiveKey)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if response != nil {
+		rec.Response = *response
+	}
+	return rec, nil
+}
+
+func (s *pgStore) SetReport(ctx context.Context, receiptID string, report *SignatureReport) (*SignatureReport, error) {
+	var stored *SignatureReport
+	err := s.pool.QueryRow(ctx, "UPDATE signatures SET report = COALESCE(report, $2) WHERE receipt_id = $1 RETURNING report",
+		receiptID, report).Scan(&stored)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errNotFound
+	}
+	return stored, err
+}
+
+func (s *pgStore) Stats(ctx context.Context, f statsFilter) (*CampaignStats, error) {
+	where, args := "TRUE", []any{}
+	if f.RequestID != "" {
+		args = append(args, f.RequestID)
+		where += fmt.Sprintf(" AND s.request_id = $%d", len(args))
+	}
+	if !f.From.IsZero() {
+		args = append(args, f.From)
+		where += fmt.Sprintf(" AND s.received_at >= $%d", len(args))
+	}
+	if !f.To.IsZero() {
+		args = append(args, f.To)
+		where += fmt.Sprintf(" AND s.received_at < $%d", len(args))
+	}
+
+	a := newStatsAccumulator()
+	dimensions := []struct {
+		counts statsCounts
+		key    string
+		from   string
+	}{
+		{a.days, "to_char(s.received_at AT TIME ZONE 'UTC', 'YYYY-MM-DD')", "signatures s"},
+		{a.jurisdictions, "COALESCE(r.request->'proposal'->>'jurisdiction', '')", "signatures s JOIN requests r USING (request_id, document_version)"},
+		{a.cas, "COALESCE(s.signer_ca, '" + unknownCA + "')", "signatures s"},
+		{a.verdicts, "CASE WHEN s.report IS NULL THEN 'pending' WHEN (s.report->>'valid')::boolean THEN 'valid' ELSE 'invalid' END", "signatures s"},
+	}
+	// One snapshot, so the dimensions add up to the same total.
+	err := pgx.BeginTxFunc(ctx, s.pool, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}, func(tx pgx.Tx) error {
+		for _, d := range dimensions {
+			rows, err := tx.Query(ctx, "SELECT "+d.key+", count(*) FROM "+d.from+" WHERE "+where+" GROUP BY 1", args...)
+			if err != nil {
+				return err
+			}
+			var key string
+			var n int
+			if _, err := pgx.ForEachRow(rows, []any{&key, &n}, func() error {
+				d.counts[key] += n
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+		rows, err := tx.Query(ctx, `SELECT c->>'status', c->>'name', count(*)
+			FROM signatures s CROSS JOIN LATERAL jsonb_array_elements(s.report->'checks') c
+			WHERE `+where+` AND c->>'status' IN ('fail', 'warning') GROUP BY 1, 2`, args...)
+		if err != nil {
+			return err
+		}
+		var status, name string
+		var n int
+		_, err = pgx.ForEachRow(rows, []any{&status, &name, &n}, func() error {
+			if status == checkFail {
+				a.failures[name] += n
+			} else {
+				a.warnings[name] += n
+			}
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a.stats(f.RequestID), nil
+}
+
+func (s *pgStore) AddContact(ctx context.Context, c *contactRecord) error {
+	_, err := s.pool.Exec(ctx, "INSERT INTO signer_contacts (request_id, contact, received_at, expires_at) VALUES ($1, $2, $3, $4)",
+		c.RequestID, c.Contact, c.ReceivedAt, c.ExpiresAt)
+	return err
+}
+
+func (s *pgStore) Contacts(ctx context.Context, id string, now time.Time) ([]contactRecord, error) {
+	rows, err := s.pool.Query(ctx, `SELECT request_id, contact, received_at, expires_at
+		FROM signer_contacts WHERE request_id = $1 AND expires_at > $2 ORDER BY id`, id, now)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (contactRecord, error) {
+		var c contactRecord
+		err := row.Scan(&c.RequestID, &c.Contact, &c.ReceivedAt, &c.ExpiresAt)
+		return c, err
+	})
+}
+
+func (s *pgStore) PurgeContacts(ctx context.Context, now time.Time) (int, error) {
+	tag, err := s.pool.Exec(ctx, "DELETE FROM signer_contacts WHERE expires_at <= $1", now)
+	return int(tag.RowsAffected()), err
+}
+
+func (s *pgStore) AddAuditRecords(ctx context.Context, id string, records []auditRecord) (int, int, error) {
+	accepted := 0
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		accepted = 0
+		for _, r := range records {
+			tag, err := tx.Exec(ctx, `INSERT INTO audit_records (request_id, hash, line, agent_fingerprint, received_at)
+				VALUES ($1, $2, $3, $4, $5) ON CONFLICT (request_id, hash) DO NOTHING`,
+				id, r.Hash, string(r.Line), r.AgentFingerprint, r.ReceivedAt)
+			if err != nil {
+				return err
+			}
+			accepted += int(tag.RowsAffected())
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return accepted, len(records) - accepted, nil
+}
+
+func (s *pgStore) AuditRecords(ctx context.Context, id string) ([]auditRecord, error) {
+	rows, err := s.pool.Query(ctx, `SELECT request_id, hash, line, agent_fingerprint, received_at
+		FROM audit_records WHERE request_id = $1 ORDER BY seq`, id)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (auditRecord, error) {
+		var r auditRecord
+		var line string
+		err := row.Scan(&r.RequestID, &r.Hash, &line, &r.AgentFingerprint, &r.ReceivedAt)
+		r.Line = json.RawMessage(line)
+		return r, err
+	})
+}
+
+func (s *pgStore) TransparencyLog(ctx context.Context) (translog.Snapshot, error) {
+	rows, err := s.pool.Query(ctx, "SELECT entry FROM transparency_log ORDER BY idx")
+	if err != nil {
+		return translog.Snapshot{}, err
+	}
+	entries, err := pgx.CollectRows(rows, pgx.RowTo[translog.Entry])
+	if err != nil {
+		return translog.Snapshot{}, err
+	}
+	snap := translog.Snapshot{Size: len(entries), Entries: entries}
+	if snap.Entries == nil {
+		snap.Entries = []translog.Entry{}
+	}
+	if snap.Size > 0 {
+		snap.Head = entries[snap.Size-1].Hash
+	}
+	return snap, nil
+}
+
+func (s *pgStore) Ping(ctx context.Context) error { return s.pool.Ping(ctx) }
+
+func (s *pgStore) Close() { s.pool.Close() }
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/errcode"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+// forEachStore runs test against a memStore and, when DATABASE_URL points
+// to a Postgres database, against a pgStore in a schema of its own.
+func forEachStore(t *testing.T, test func(t *testing.T, s store)) {
+	t.Run("memory", func(t *testing.T) { test(t, newMemStore()) })
+	t.Run("postgres", func(t *testing.T) {
+		s, _ := newPGTestStore(t)
+		test(t, s)
+	})
+}
+
+// newPGTestStore opens a pgStore in a new schema of the DATABASE_URL
+// database, dropped when the test ends, and returns it with the URL that
+// opens the same schema. The test is skipped without DATABASE_URL.
+func newPGTestStore(t *testing.T) (*pgStore, string) {
+	t.Helper()
+	url := os.Getenv("DATABASE_URL")
+	if url == "" {
+		t.Skip("DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := fmt.Sprintf("vocsign_test_%d", time.Now().UnixNano())
+	if _, err := conn.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if _, err := conn.Exec(ctx, "DROP SCHEMA "+schema+" CASCADE"); err != nil {
+			t.Errorf("failed to drop schema %s: %v", schema, err)
+		}
+		conn.Close(ctx)
+	})
+
+	// Unknown parameters are sent to the server as run-time settings.
+	switch {
+	case !strings.Contains(url, "://"):
+		url += " search_path=" + schema
+	case strings.Contains(url, "?"):
+		url += "&search_path=" + schema
+	default:
+		url += "?search_path=" + schema
+	}
+	s, err := openPGStore(ctx, url, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+	return s, url
+}
+
+// storeRequest returns an unsigned request for proposal id.
+func storeRequest(id string) *model.SignRequest {
+	return &model.SignRequest{
+		Version:   model.Version1,
+		RequestID: id,
+		IssuedAt:  time.Now().Format(time.RFC3339),
+		Proposal: model.Proposal{
+			Title:           "Store test",
+			Jurisdiction:    "Catalunya",
+			FullText:        model.FullText{SHA256: "dGV4dA=="},
+			DocumentVersion: 1,
+		},
+	}
+}
+
+// createStoreProposal stores a request for proposal id.
+func createStoreProposal(t *testing.T, s store, id string) *model.SignRequest {
+	t.Helper()
+	req := storeRequest(id)
+	created, err := s.CreateProposal(context.Background(), req)
+	if err != nil || !created {
+		t.Fatalf("CreateProposal(%s) = %v, %v", id, created, err)
+	}
+	return req
+}
+
+// newStoreSignature returns a record of an unversioned signature, which is
+// accepted while the proposal is at version 1. Times are in microseconds,
+// as Postgres keeps them.
+func newStoreSignature(key string) *signatureRecord {
+	return &signatureRecord{
+		ReceiptID:  uuid.New().String(),
+		ReceivedAt: time.Now().UTC().Truncate(time.Microsecond),
+		SignerCA:   "Test CA",
+		Key:        key,
+	}
+}
+
+// bumpVersion amends a request to its next document version.
+func bumpVersion(req *model.SignRequest) error {
+	req.Proposal.DocumentVersion++
+	req.Proposal.FullText.SHA256 = "bmV3IHRleHQ="
+	return nil
+}
+
+func TestStore_AddSignature(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s store) {
+		ctx := context.Background()
+		createStoreProposal(t, s, "ILP-A")
+		if created, err := s.CreateProposal(ctx, &model.SignRequest{RequestID: "ILP-A"}); err != nil || created {
+			t.Errorf("second CreateProposal = %v, %v; want false", created, err)
+		}
+
+		first := newStoreSignature("key-1")
+		if se, err := s.AddSignature(ctx, "ILP-A", first, "tag-1"); se != nil || err != nil {
+			t.Fatalf("AddSignature = %+v, %v", se, err)
+		}
+		if first.Request.Proposal.DocumentVersion != 1 {
+			t.Errorf("accepted against version %d, want 1", first.Request.Proposal.DocumentVersion)
+		}
+		// A retry with the same idempotency key stores nothing.
+		if _, err := s.AddSignature(ctx, "ILP-A", newStoreSignature("key-1"), "tag-1"); !errors.Is(err, errAlreadyAccepted) {
+			t.Errorf("retry: err = %v, want errAlreadyAccepted", err)
+		}
+		// Submissions without a key are never taken for retries.
+		for range 2 {
+			if se, err := s.AddSignature(ctx, "ILP-A", newStoreSignature(""), ""); se != nil || err != nil {
+				t.Fatalf("AddSignature without key = %+v, %v", se, err)
+			}
+		}
+		if _, err := s.AddSignature(ctx, "ILP-MISSING", newStoreSignature("key-2"), ""); !errors.Is(err, errNotFound) {
+			t.Errorf("unknown proposal: err = %v, want errNotFound", err)
+		}
+
+		sigs, err := s.Signatures(ctx, "ILP-A")
+		if err != nil || len(sigs) != 3 || sigs[0].ReceiptID != first.ReceiptID {
+			t.Fatalf("Signatures = %+v, %v", sigs, err)
+		}
+		rec, err := s.SignatureByKey(ctx, "ILP-A", "key-1")
+		if err != nil || rec.ReceiptID != first.ReceiptID || !rec.ReceivedAt.Equal(first.ReceivedAt) {
+			t.Errorf("SignatureByKey = %+v, %v", rec, err)
+		}
+		if _, err := s.SignatureByKey(ctx, "ILP-A", "key-2"); !errors.Is(err, errNotFound) {
+			t.Errorf("SignatureByKey of an unknown key: err = %v", err)
+		}
+		if signed, err := s.HasSigner(ctx, "ILP-A", "tag-1"); err != nil || !signed {
+			t.Errorf("HasSigner = %v, %v; want true", signed, err)
+		}
+		if signed, err := s.HasSigner(ctx, "ILP-A", "tag-2"); err != nil || signed {
+			t.Errorf("HasSigner of another tag = %v, %v; want false", signed, err)
+		}
+
+		// With archival the response is not kept.
+		archived := newStoreSignature("key-3")
+		archived.ArchiveKey = "signatures/ILP-A/" + archived.ReceiptID
+		archived.Response.SignerXMLBase64 = "eG1s"
+		if _, err := s.AddSignature(ctx, "ILP-A", archived, ""); err != nil {
+			t.Fatal(err)
+		}
+		rec, err = s.Receipt(ctx, archived.ReceiptID)
+		if err != nil || rec.ArchiveKey != archived.ArchiveKey || rec.Response.SignerXMLBase64 != "" || rec.Report != nil {
+			t.Errorf("Receipt = %+v, %v", rec, err)
+		}
+		if _, err := s.Receipt(ctx, "receipt-missing"); !errors.Is(err, errNotFound) {
+			t.Errorf("Receipt of an unknown ID: err = %v", err)
+		}
+	})
+}
+
+func TestStore_AmendProposal(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s store) {
+		ctx := context.Background()
+		createStoreProposal(t, s, "ILP-A")
+		before := newStoreSignature("key-1")
+		if _, err := s.AddSignature(ctx, "ILP-A", before, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		failed := errors.New("amend failed")
+		if _, err := s.AmendProposal(ctx, "ILP-A", func(*model.SignRequest) error { return failed }); !errors.Is(err, failed) {
+			t.Fatalf("failed amend: err = %v", err)
+		}
+		if req, err := s.Proposal(ctx, "ILP-A"); err != nil || req.Proposal.DocumentVersion != 1 {
+			t.Fatalf("Proposal after a failed amend = %+v, %v", req, err)
+		}
+
+		// A signature that arrives while the text is replaced waits, and
+		// is then checked against the new version.
+		type result struct {
+			se  *model.SubmitError
+			err error
+		}
+		done := make(chan result, 1)
+		req, err := s.AmendProposal(ctx, "ILP-A", func(req *model.SignRequest) error {
+			go func() {
+				se, err := s.AddSignature(ctx, "ILP-A", newStoreSignature("key-2"), "")
+				done <- result{se, err}
+			}()
+			select {
+			case r := <-done:
+				t.Errorf("signature accepted during the amendment: %+v, %v", r.se, r.err)
+			case <-time.After(200 * time.Millisecond):
+			}
+			return bumpVersion(req)
+		})
+		if err != nil || req.Proposal.DocumentVersion != 2 {
+			t.Fatalf("AmendProposal = %+v, %v", req, err)
+		}
+		select {
+		case r := <-done:
+			if r.err != nil || r.se == nil || r.se.Code != string(errcode.ProposalChanged) {
+				t.Errorf("signature during the amendment = %+v, %v; want %s", r.se, r.err, errcode.ProposalChanged)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatal("signature still waiting after the amendment")
+		}
+
+		if cur, err := s.Proposal(ctx, "ILP-A"); err != nil || cur.Proposal.DocumentVersion != 2 {
+			t.Errorf("Proposal = %+v, %v", cur, err)
+		}
+		// Earlier signatures keep the request they were accepted for.
+		rec, err := s.Receipt(ctx, before.ReceiptID)
+		if err != nil || rec.Request.Proposal.DocumentVersion != 1 {
+			t.Errorf("Receipt of an earlier signature = %+v, %v", rec, err)
+		}
+		list, err := s.Proposals(ctx)
+		if err != nil || len(list) != 1 || list[0].Request.Proposal.DocumentVersion != 2 || list[0].Signatures != 1 {
+			t.Errorf("Proposals = %+v, %v", list, err)
+		}
+		if _, err := s.AmendProposal(ctx, "ILP-MISSING", bumpVersion); !errors.Is(err, errNotFound) {
+			t.Errorf("amend of an unknown proposal: err = %v", err)
+		}
+	})
+}
+
+func TestStore_Stats(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s store) {
+		ctx := context.Background()
+		createStoreProposal(t, s, "ILP-A")
+		createStoreProposal(t, s, "ILP-B")
+		a1, a2, b1 := newStoreSignature("a1"), newStoreSignature("a2"), newStoreSignature("b1")
+		b1.SignerCA = ""
+		for _, sig := range []struct {
+			id  string
+			rec *signatureRecord
+		}{{"ILP-A", a1}, {"ILP-A", a2}, {"ILP-B", b1}} {
+			if _, err := s.AddSignature(ctx, sig.id, sig.rec, ""); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		report := &SignatureReport{ReceiptID: a1.ReceiptID, Valid: true, Checks: []ReportCheck{
+			{Name: "signature", Status: checkPass},
+			{Name: "ocsp", Status: checkWarning},
+		}}
+		if got, err := s.SetReport(ctx, a1.ReceiptID, report); err != nil || !got.Valid {
+			t.Fatalf("SetReport = %+v, %v", got, err)
+		}
+		// The first report stored stays.
+		if got, err := s.SetReport(ctx, a1.ReceiptID, &SignatureReport{ReceiptID: a1.ReceiptID}); err != nil || !got.Valid {
+			t.Errorf("second SetReport = %+v, %v; want the first report", got, err)
+		}
+		if _, err := s.SetReport(ctx, "receipt-missing", report); !errors.Is(err, errNotFound) {
+			t.Errorf("SetReport of an unknown receipt: err = %v", err)
+		}
+
+		st, err := s.Stats(ctx, statsFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st.Total != 3 || len(st.PerDay) != 1 || st.PerDay[0].Count != 3 {
+			t.Errorf("total = %d, perDay = %+v", st.Total, st.PerDay)
+		}
+		if len(st.PerJurisdiction) != 1 || st.PerJurisdiction[0] != (StatCount{Key: "Catalunya", Count: 3}) {
+			t.Errorf("perJurisdiction = %+v", st.PerJurisdiction)
+		}
+		if len(st.PerCA) != 2 || st.PerCA[0] != (StatCount{Key: "Test CA", Count: 2}) || st.PerCA[1] != (StatCount{Key: unknownCA, Count: 1}) {
+			t.Errorf("perCa = %+v", st.PerCA)
+		}
+		if v := st.Verification; v.Valid != 1 || v.Invalid != 0 || v.Pending != 2 || len(v.Failures) != 0 ||
+			len(v.Warnings) != 1 || v.Warnings[0] != (StatCount{Key: "ocsp", Count: 1}) {
+			t.Errorf("verification = %+v", v)
+		}
+
+		if st, err := s.Stats(ctx, statsFilter{RequestID: "ILP-B"}); err != nil || st.Total != 1 || st.RequestID != "ILP-B" {
+			t.Errorf("Stats of ILP-B = %+v, %v", st, err)
+		}
+		if st, err := s.Stats(ctx, statsFilter{To: a1.ReceivedAt}); err != nil || st.Total != 0 {
+			t.Errorf("Stats before the signatures = %+v, %v", st, err)
+		}
+		if st, err := s.Stats(ctx, statsFilter{From: a1.ReceivedAt}); err != nil || st.Total != 3 {
+			t.Errorf("Stats from the first signature = %+v, %v", st, err)
+		}
+	})
+}
+
+func TestStore_TransparencyLog(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s store) {
+		ctx := context.Background()
+		// Proposals published at once still make one chain.
+		var wg sync.WaitGroup
+		for i := range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if created, err := s.CreateProposal(ctx, storeRequest(fmt.Sprintf("ILP-%d", i))); err != nil || !created {
+					t.Errorf("CreateProposal(ILP-%d) = %v, %v", i, created, err)
+				}
+			}()
+		}
+		wg.Wait()
+		amended, err := s.AmendProposal(ctx, "ILP-0", bumpVersion)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		snap, err := s.TransparencyLog(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if snap.Size != 9 || len(snap.Entries) != 9 {
+			t.Fatalf("log has %d entries, want 9", snap.Size)
+		}
+		if err := snap.Verify(); err != nil {
+			t.Errorf("Verify: %v", err)
+		}
+		hash, err := amended.CanonicalHash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if last := snap.Entries[8]; last.RequestID != "ILP-0" || last.RequestHash != hash {
+			t.Errorf("last entry = %+v, want the amended request", last)
+		}
+	})
+}
+
+func TestPGStore_MigratesSignerHashes(t *testing.T) {
+	s, url := newPGTestStore(t)
+	ctx := context.Background()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	organizerKey = key
+
+	// A signature stored by a collector that kept the bare signer hash.
+	createStoreProposal(t, s, "ILP-A")
+	rec := newStoreSignature("key-1")
+	if _, err := s.AddSignature(ctx, "ILP-A", rec, ""); err != nil {
+		t.Fatal(err)
+	}
+	const hash = "4f0a1c3d"
+	if _, err := s.pool.Exec(ctx, "UPDATE signatures SET signer_hash = $2 WHERE receipt_id = $1", rec.ReceiptID, hash); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := openPGStore(ctx, url, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if signed, err := reopened.HasSigner(ctx, "ILP-A", duplicateTag(hash)); err != nil || !signed {
+		t.Errorf("HasSigner after the migration = %v, %v; want true", signed, err)
+	}
+	var left int
+	if err := reopened.pool.QueryRow(ctx, "SELECT count(*) FROM signatures WHERE signer_hash IS NOT NULL").Scan(&left); err != nil || left != 0 {
+		t.Errorf("%d bare signer hashes left, %v", left, err)
+	}
+}
@@ -0,0 +1,249 @@
+// Command loadgen sizes collector infrastructure before a campaign. It
+// creates synthetic citizens with certificates from a throwaway CA, signs
+// the request as the client would, and submits the responses to the
+// request's callback at a fixed rate, reporting throughput, latency and
+// errors.
+//
+//	loadgen -request http://localhost:8080/request/ILP-2026-HABITATGE -rate 200 -duration 1m
+//
+// Signing happens before the run starts, so the rate measures the collector
+// alone. The CA is created afresh on every run; -ca-out saves it for
+// collectors that check chains (the Go collector's -trust-roots).
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+func main() {
+	var (
+		requestURL string
+		rate       float64
+		duration   time.Duration
+		workers    int
+		signers    int
+		timeout    time.Duration
+		insecure   bool
+		caOut      string
+		verbose    bool
+	)
+	flag.StringVar(&requestURL, "request", "", "URL of the sign request JSON to load test")
+	flag.Float64Var(&rate, "rate", 50, "Submissions per second")
+	flag.DurationVar(&duration, "duration", 30*time.Second, "How long to submit for")
+	flag.IntVar(&workers, "workers", 64, "Maximum concurrent submissions")
+	flag.IntVar(&signers, "signers", 200, "Number of synthetic signers; their responses are submitted in turn")
+	flag.DurationVar(&timeout, "timeout", 30*time.Second, "Timeout for each submission")
+	flag.BoolVar(&insecure, "insecure", false, "Skip TLS certificate verification")
+	flag.StringVar(&caOut, "ca-out", "", "Write the synthetic CA certificate to this PEM file")
+	flag.BoolVar(&verbose, "v", false, "Keep the signing library's debug logging")
+	flag.Parse()
+
+	if requestURL == "" || rate <= 0 || signers <= 0 || workers <= 0 {
+		log.Fatal("usage: loadgen -request URL [-rate N] [-duration D] [-workers N] [-signers N]")
+	}
+
+	client := &http.Client{Timeout: timeout}
+	if insecure {
+		client.Transport = &http.Transport{
+			TLSClientConfig:     &tls.Config{InsecureSkipVerify: true},
+			MaxIdleConnsPerHost: workers,
+		}
+	} else {
+		client.Transport = &http.Transport{MaxIdleConnsPerHost: workers}
+	}
+
+	req, err := fetchRequest(client, requestURL)
+	if err != nil {
+		log.Fatalf("Failed to fetch request: %v", err)
+	}
+	log.Printf("Request %s, callback %s", req.RequestID, req.Callback.URL)
+
+	log.Printf("Creating %d synthetic signers...", signers)
+	start := time.Now()
+	if !verbose {
+		// The CAdES signer logs every signature.
+		log.SetOutput(io.Discard)
+	}
+	ca, bodies, err := buildBodies(req, signers)
+	log.SetOutput(os.Stderr)
+	if err != nil {
+		log.Fatalf("Failed to build signatures: %v", err)
+	}
+	if caOut != "" {
+		if err := os.WriteFile(caOut, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}), 0o644); err != nil {
+			log.Fatalf("Failed to write CA: %v", err)
+		}
+	}
+	log.Printf("Signed %d responses in %s", len(bodies), time.Since(start).Round(time.Millisecond))
+
+	log.Printf("Submitting %.0f/s for %s with up to %d workers", rate, duration, workers)
+	st := run(client, req.Callback.URL, bodies, rate, duration, workers)
+	st.print(os.Stdout)
+}
+
+func fetchRequest(client *http.Client, url string) (*model.SignRequest, error) {
+	httpReq, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	var req model.SignRequest
+	if err := json.NewDecoder(resp.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("invalid request JSON: %w", err)
+	}
+	if req.Callback.URL == "" {
+		return nil, errors.New("request has no callback URL")
+	}
+	return &req, nil
+}
+
+// stats accumulates the outcome of every submission.
+type stats struct {
+	mu        sync.Mutex
+	start     time.Time
+	elapsed   time.Duration
+	ok        int
+	outcomes  map[string]int // failures by HTTP status, error code or transport error
+	latencies []time.Duration
+	// missed counts ticks dropped because every worker was busy; the
+	// target rate was not reached.
+	missed int
+}
+
+func (s *stats) record(outcome string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latencies = append(s.latencies, d)
+	if outcome == "" {
+		s.ok++
+		return
+	}
+	s.outcomes[outcome]++
+}
+
+// run submits bodies in turn to callbackURL at rate per second until
+// duration has passed and every submission has finished.
+func run(client *http.Client, callbackURL string, bodies [][]byte, rate float64, duration time.Duration, workers int) *stats {
+	st := &stats{start: time.Now(), outcomes: make(map[string]int)}
+	jobs := make(chan []byte)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for body := range jobs {
+				t := time.Now()
+				outcome := submit(client, callbackURL, body)
+				st.record(outcome, time.Since(t))
+			}
+		})
+	}
+
+	tick := time.NewTicker(time.Duration(float64(time.Second) / rate))
+	defer tick.Stop()
+	progress := time.NewTicker(5 * time.Second)
+	defer progress.Stop()
+	deadline := time.After(duration)
+	for i := 0; ; {
+		select {
+		case <-tick.C:
+			select {
+			case jobs <- bodies[i%len(bodies)]:
+				i++
+			default:
+				st.mu.Lock()
+				st.missed++
+				st.mu.Unlock()
+			}
+		case <-progress.C:
+			st.mu.Lock()
+			log.Printf("%d sent, %d ok, %d failed, %d missed", len(st.latencies), st.ok, len(st.latencies)-st.ok, st.missed)
+			st.mu.Unlock()
+		case <-deadline:
+			close(jobs)
+			wg.Wait()
+			st.elapsed = time.Since(st.start)
+			return st
+		}
+	}
+}
+
+// submit posts body to callbackURL and returns "" on success or a short
+// description of the failure.
+func submit(client *http.Client, callbackURL string, body []byte) string {
+	resp, err := client.Post(callbackURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		var uerr interface{ Timeout() bool }
+		if errors.As(err, &uerr) && uerr.Timeout() {
+			return "timeout"
+		}
+		return "transport error"
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return ""
+	}
+	var se model.SubmitError
+	if json.Unmarshal(data, &se) == nil && se.Code != "" {
+		return fmt.Sprintf("HTTP %d %s", resp.StatusCode, se.Code)
+	}
+	return fmt.Sprintf("HTTP %d", resp.StatusCode)
+}
+
+func (s *stats) print(w io.Writer) {
+	total := len(s.latencies)
+	secs := s.elapsed.Seconds()
+	_, _ = fmt.Fprintf(w, "\nDuration:     %s\n", s.elapsed.Round(time.Millisecond))
+	_, _ = fmt.Fprintf(w, "Submitted:    %d (%.1f/s)\n", total, float64(total)/secs)
+	_, _ = fmt.Fprintf(w, "Accepted:     %d (%.1f/s)\n", s.ok, float64(s.ok)/secs)
+	if total > 0 {
+		_, _ = fmt.Fprintf(w, "Error rate:   %.2f%%\n", 100*float64(total-s.ok)/float64(total))
+	}
+	if s.missed > 0 {
+		_, _ = fmt.Fprintf(w, "Missed ticks: %d (all workers busy; raise -workers or lower -rate)\n", s.missed)
+	}
+
+	if total > 0 {
+		lat := slices.Clone(s.latencies)
+		slices.Sort(lat)
+		pct := func(p float64) time.Duration {
+			return lat[min(len(lat)-1, int(p*float64(len(lat))))].Round(time.Millisecond)
+		}
+		_, _ = fmt.Fprintf(w, "Latency:      p50 %s, p90 %s, p99 %s, max %s\n", pct(0.50), pct(0.90), pct(0.99), lat[len(lat)-1].Round(time.Millisecond))
+	}
+
+	if len(s.outcomes) > 0 {
+		names := make([]string, 0, len(s.outcomes))
+		for k := range s.outcomes {
+			names = append(names, k)
+		}
+		sort.Slice(names, func(i, j int) bool { return s.outcomes[names[i]] > s.outcomes[names[j]] })
+		_, _ = fmt.Fprintln(w, "Failures:")
+		for _, k := range names {
+			_, _ = fmt.Fprintf(w, "  %-28s %d\n", k, s.outcomes[k])
+		}
+	}
+}
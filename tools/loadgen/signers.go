@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	mrand "math/rand/v2"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/cades"
+	"github.com/vocdoni/gofirma/vocsign/internal/crypto/certs"
+	"github.com/vocdoni/gofirma/vocsign/internal/model"
+)
+
+var (
+	givenNames = []string{"MARIA", "JORDI", "NÚRIA", "PAU", "LAIA", "MARC", "MONTSERRAT", "JOAN", "CARME", "ORIOL", "ANNA", "ALBERT"}
+	surnames   = []string{"GARCIA", "PUIG", "MARTÍNEZ", "FERRER", "SOLER", "VILA", "FONT", "SERRA", "ROCA", "MOLINA", "CASAS", "PONS"}
+)
+
+// syntheticSigner is a made-up citizen with a certificate issued by the
+// load test CA.
+type syntheticSigner struct {
+	data model.Signant
+	key  *rsa.PrivateKey
+	cert *x509.Certificate
+}
+
+// newCA returns a self-signed CA for the synthetic signer certificates.
+func newCA() (*x509.Certificate, *rsa.PrivateKey, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "VocSign Load Test CA", Organization: []string{"VocSign load test"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(7 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	return cert, key, err
+}
+
+// newSigner issues a certificate shaped like an FNMT citizen certificate
+// for a random person.
+func newSigner(serial int64, ca *x509.Certificate, caKey *rsa.PrivateKey) (*syntheticSigner, error) {
+	n := mrand.IntN(100_000_000)
+	dni := fmt.Sprintf("%08d%c", n, certs.DNILetter(n))
+	birth := time.Date(1940, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, mrand.IntN(66*365))
+	data := model.Signant{
+		Nom:             givenNames[mrand.IntN(len(givenNames))],
+		Cognom1:         surnames[mrand.IntN(len(surnames))],
+		Cognom2:         surnames[mrand.IntN(len(surnames))],
+		DataNaixement:   birth.Format("2006-01-02"),
+		TipusIdentifica: "DNI",
+		NumIdentifica:   dni,
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject: pkix.Name{
+			CommonName:   fmt.Sprintf("%s %s %s - %s", data.Cognom1, data.Cognom2, data.Nom, dni),
+			SerialNumber: "IDCES-" + dni,
+			Country:      []string{"ES"},
+		},
+		NotBefore:   time.Now().Add(-time.Hour),
+		NotAfter:    time.Now().Add(7 * 24 * time.Hour),
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageEmailProtection},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &syntheticSigner{data: data, key: key, cert: cert}, nil
+}
+
+// sign builds the SignResponse the client would submit for s, encoded as
+// the request body.
+func (s *syntheticSigner) sign(req *model.SignRequest, ca *x509.Certificate) ([]byte, error) {
+	xmlBytes, err := model.GenerateILPXML(req, s.data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signer XML: %w", err)
+	}
+	sig, err := cades.SignDetached(context.Background(), s.key, s.cert, []*x509.Certificate{ca}, xmlBytes, cades.SignOpts{
+		SigningTime: time.Now(),
+		Policy:      req.Policy,
+	})
+	if err != nil {
+		return nil, err
+	}
+	payloadHash := sha256.Sum256(xmlBytes)
+	resp := model.SignResponse{
+		Version:                "1.0",
+		RequestID:              req.RequestID,
+		Nonce:                  req.Nonce,
+		SignedAt:               time.Now().Format(time.RFC3339),
+		PayloadCanonicalSHA256: base64.StdEncoding.EncodeToString(payloadHash[:]),
+		SignatureFormat:        "CAdES-detached",
+		SignatureDerBase64:     base64.StdEncoding.EncodeToString(sig),
+		SignerCertPEM:          string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.cert.Raw})),
+		ChainPEM:               []string{string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}))},
+		SignerXMLBase64:        base64.StdEncoding.EncodeToString(xmlBytes),
+		DocumentVersion:        req.Proposal.DocumentVersion,
+		DocumentSHA256:         req.Proposal.FullText.SHA256,
+		Client: model.ClientInfo{
+			App:     "vocsign-loadgen",
+			Version: "0.1.0",
+			OS:      runtime.GOOS,
+		},
+	}
+	return json.Marshal(resp)
+}
+
+// buildBodies creates a CA, n synthetic signers and their signed responses
+// to req, using every CPU since RSA key generation dominates.
+func buildBodies(req *model.SignRequest, n int) (*x509.Certificate, [][]byte, error) {
+	ca, caKey, err := newCA()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create CA: %w", err)
+	}
+	bodies := make([][]byte, n)
+	errs := make([]error, n)
+	sem := make(chan struct{}, runtime.NumCPU())
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			s, err := newSigner(int64(i+2), ca, caKey)
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to create signer %d: %w", i, err)
+				return
+			}
+			if bodies[i], err = s.sign(req, ca); err != nil {
+				errs[i] = fmt.Errorf("failed to sign as signer %d: %w", i, err)
+			}
+		})
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return ca, bodies, nil
+}
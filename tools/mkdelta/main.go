@@ -0,0 +1,75 @@
+// Command mkdelta writes the binary patches that let clients of the previous
+// release update without downloading the whole executable.
+//
+//	mkdelta -from-version v1.3.0 -old prev-release -out release-assets release-assets/vocsign-*
+//
+// For every new executable given, the one with the same name in -old is the
+// previous release; the patch is written to -out as
+// <name>.from-<version>.delta. Executables without a previous release are
+// skipped.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/delta"
+	"github.com/vocdoni/gofirma/vocsign/internal/selfcheck"
+	"github.com/vocdoni/gofirma/vocsign/internal/update"
+)
+
+func main() {
+	var (
+		fromVersion string
+		oldDir      string
+		outDir      string
+	)
+	flag.StringVar(&fromVersion, "from-version", "", "Version of the previous release")
+	flag.StringVar(&oldDir, "old", "", "Directory with the executables of the previous release")
+	flag.StringVar(&outDir, "out", ".", "Directory to write the patches to")
+	flag.Parse()
+
+	if fromVersion == "" || oldDir == "" || flag.NArg() == 0 {
+		log.Fatal("usage: mkdelta -from-version VERSION -old DIR [-out DIR] BINARY...")
+	}
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		log.Fatalf("Failed to create %s: %v", outDir, err)
+	}
+
+	for _, bin := range flag.Args() {
+		name := filepath.Base(bin)
+		if strings.HasSuffix(name, selfcheck.SignatureSuffix) || strings.HasSuffix(name, update.DeltaSuffix) {
+			continue
+		}
+		old, err := os.ReadFile(filepath.Join(oldDir, name))
+		if os.IsNotExist(err) {
+			log.Printf("Skipping %s: not in the previous release", name)
+			continue
+		}
+		if err != nil {
+			log.Fatalf("Failed to read previous %s: %v", name, err)
+		}
+		cur, err := os.ReadFile(bin)
+		if err != nil {
+			log.Fatalf("Failed to read %s: %v", bin, err)
+		}
+
+		var patch bytes.Buffer
+		if err := delta.Diff(&patch, old, cur); err != nil {
+			log.Fatalf("Failed to diff %s: %v", name, err)
+		}
+		// Check the patch before publishing it.
+		if _, err := delta.Patch(old, bytes.NewReader(patch.Bytes()), int64(len(cur))); err != nil {
+			log.Fatalf("Patch for %s does not apply: %v", name, err)
+		}
+		out := filepath.Join(outDir, update.DeltaName(name, fromVersion))
+		if err := os.WriteFile(out, patch.Bytes(), 0o644); err != nil {
+			log.Fatalf("Failed to write %s: %v", out, err)
+		}
+		log.Printf("Wrote %s (%d bytes, %.1f%% of %d)", out, patch.Len(), 100*float64(patch.Len())/float64(len(cur)), len(cur))
+	}
+}
@@ -0,0 +1,146 @@
+// Command package writes the installer metadata that registers VocSign with
+// the desktop: the vocsign:// link handler, the .p12/.pfx file association
+// and, with -autostart, the launch at login. Every platform gets the same
+// registrations, with the command lines internal/launch parses.
+//
+//	package -version v1.4.0 -out build/package
+//	package -version v1.4.0 -out build/package -autostart
+//
+// It writes:
+//
+//	linux/vocsign.desktop            /usr/share/applications
+//	linux/vocsign-autostart.desktop  /etc/xdg/autostart (-autostart)
+//	linux/postinst, linux/postrm     deb/rpm maintainer scripts
+//	windows/vocsign.wxs              WiX v4 source for the MSI
+//	darwin/Info.plist                VocSign.app/Contents
+//	darwin/<bundle id>.autostart.plist  /Library/LaunchAgents (-autostart)
+//
+// The file association only adds VocSign to the "Open with" choices; it
+// does not replace the system's certificate import for those files.
+package main
+
+import (
+	"encoding/xml"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/launch"
+)
+
+// upgradeCode identifies VocSign to Windows Installer across versions, so
+// a new MSI replaces the installed one. It must never change.
+const upgradeCode = "{6B0E3C52-9D7A-4F1E-8C3B-2A5D4E7F9013}"
+
+var numericVersion = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)`)
+
+// params is what the templates are executed with.
+type params struct {
+	Version        string // as given, e.g. v1.4.0-rc1
+	NumericVersion string // e.g. 1.4.0, for MSI and bundle versions
+	Scheme         string
+	AutostartFlag  string
+	BundleID       string
+	Extensions     []string // without the dot
+	LinuxExec      string
+	MacExec        string
+	WindowsBinary  string
+	UpgradeCode    string
+	Autostart      bool
+}
+
+func main() {
+	var p params
+	var out string
+	flag.StringVar(&p.Version, "version", "", "Release version, e.g. v1.4.0")
+	flag.StringVar(&out, "out", "build/package", "Directory to write the metadata to")
+	flag.BoolVar(&p.Autostart, "autostart", false, "Also start VocSign minimized at login")
+	flag.StringVar(&p.LinuxExec, "linux-exec", "/usr/bin/vocsign", "Installed path of the Linux binary")
+	flag.StringVar(&p.MacExec, "mac-exec", "/Applications/VocSign.app/Contents/MacOS/vocsign", "Installed path of the macOS binary")
+	flag.StringVar(&p.WindowsBinary, "windows-binary", "vocsign-windows-amd64.exe", "Windows binary the MSI is built from, relative to the .wxs")
+	flag.Parse()
+
+	if p.Version == "" {
+		log.Fatal("usage: package -version VERSION [-out DIR] [-autostart]")
+	}
+	m := numericVersion.FindStringSubmatch(p.Version)
+	if m == nil {
+		log.Fatalf("Version %q does not start with MAJOR.MINOR.PATCH", p.Version)
+	}
+	p.NumericVersion = m[1] + "." + m[2] + "." + m[3]
+	p.Scheme = launch.Scheme
+	p.AutostartFlag = launch.AutostartFlag
+	p.BundleID = launch.BundleID
+	for _, ext := range launch.CertificateExtensions {
+		p.Extensions = append(p.Extensions, strings.TrimPrefix(ext, "."))
+	}
+	p.UpgradeCode = upgradeCode
+
+	files := []struct {
+		name string
+		tmpl string
+		mode os.FileMode
+		skip bool
+	}{
+		{"linux/vocsign.desktop", desktopEntry, 0o644, false},
+		{"linux/vocsign-autostart.desktop", autostartEntry, 0o644, !p.Autostart},
+		{"linux/postinst", desktopDatabaseHook, 0o755, false},
+		{"linux/postrm", desktopDatabaseHook, 0o755, false},
+		{"windows/vocsign.wxs", wixSource, 0o644, false},
+		{"darwin/Info.plist", infoPlist, 0o644, false},
+		{"darwin/" + launch.BundleID + ".autostart.plist", launchAgent, 0o644, !p.Autostart},
+	}
+	for _, f := range files {
+		if f.skip {
+			continue
+		}
+		path := filepath.Join(out, filepath.FromSlash(f.name))
+		if err := write(path, f.tmpl, f.mode, p); err != nil {
+			log.Fatalf("Failed to write %s: %v", path, err)
+		}
+		log.Printf("Wrote %s", path)
+	}
+}
+
+func write(path, text string, mode os.FileMode, p params) error {
+	tmpl, err := template.New(filepath.Base(path)).Funcs(template.FuncMap{
+		"xml":  escapeXML,
+		"exec": desktopExec,
+	}).Parse(text)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if err := tmpl.Execute(f, p); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func escapeXML(s string) (string, error) {
+	var b strings.Builder
+	if err := xml.EscapeText(&b, []byte(s)); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// desktopExec quotes path for the Exec key of a desktop entry.
+func desktopExec(path string) string {
+	if !strings.ContainsAny(path, " \t\"'\\$`") {
+		return path
+	}
+	r := strings.NewReplacer(`\`, `\\\\`, `"`, `\\"`, "`", "\\\\`", "$", `\\$`)
+	return `"` + r.Replace(path) + `"`
+}
@@ -0,0 +1,158 @@
+package main
+
+// desktopEntry is the application entry. %u passes the vocsign:// link or
+// the opened file.
+const desktopEntry = `[Desktop Entry]
+Type=Application
+Version=1.5
+Name=VocSign
+Comment=Sign citizen proposals with your digital certificate
+Exec={{exec .LinuxExec}} %u
+Icon=vocsign
+Terminal=false
+Categories=Office;Security;
+MimeType=x-scheme-handler/{{.Scheme}};application/x-pkcs12;
+StartupNotify=true
+X-VocSign-Version={{.Version}}
+`
+
+const autostartEntry = `[Desktop Entry]
+Type=Application
+Version=1.5
+Name=VocSign
+Comment=Start VocSign minimized at login
+Exec={{exec .LinuxExec}} {{.AutostartFlag}}
+Icon=vocsign
+Terminal=false
+NoDisplay=true
+X-GNOME-Autostart-enabled=true
+`
+
+// desktopDatabaseHook refreshes the handler cache after the desktop entry
+// is installed or removed. It is both the postinst and the postrm script.
+const desktopDatabaseHook = `#!/bin/sh
+# Registers or unregisters the {{.Scheme}}:// link handler and the
+# certificate file association of the VocSign desktop entry.
+set -e
+if command -v update-desktop-database >/dev/null 2>&1; then
+	update-desktop-database -q /usr/share/applications || true
+fi
+exit 0
+`
+
+// wixSource registers the handlers per machine. Certificate files get an
+// OpenWithProgids entry, so the default handler stays the system's import.
+const wixSource = `<?xml version="1.0" encoding="UTF-8"?>
+<!-- VocSign {{xml .Version}} -->
+<Wix xmlns="http://wixtoolset.org/schemas/v4/wxs">
+  <Package Name="VocSign" Manufacturer="Vocdoni" Version="{{.NumericVersion}}" UpgradeCode="{{.UpgradeCode}}" Scope="perMachine">
+    <MajorUpgrade DowngradeErrorMessage="A newer version of VocSign is already installed." />
+    <MediaTemplate EmbedCab="yes" />
+
+    <StandardDirectory Id="ProgramFiles64Folder">
+      <Directory Id="INSTALLFOLDER" Name="VocSign" />
+    </StandardDirectory>
+
+    <Feature Id="Main">
+      <Component Directory="INSTALLFOLDER">
+        <File Id="VocSignExe" Name="vocsign.exe" Source="{{xml .WindowsBinary}}" KeyPath="yes" />
+
+        <RegistryKey Root="HKLM" Key="SOFTWARE\Classes\{{.Scheme}}">
+          <RegistryValue Type="string" Value="URL:VocSign request" />
+          <RegistryValue Name="URL Protocol" Type="string" Value="" />
+          <RegistryValue Key="DefaultIcon" Type="string" Value="&quot;[#VocSignExe]&quot;,0" />
+          <RegistryValue Key="shell\open\command" Type="string" Value="&quot;[#VocSignExe]&quot; &quot;%1&quot;" />
+        </RegistryKey>
+
+        <RegistryKey Root="HKLM" Key="SOFTWARE\Classes\{{.BundleID}}.certificate">
+          <RegistryValue Type="string" Value="PKCS#12 certificate" />
+          <RegistryValue Key="DefaultIcon" Type="string" Value="&quot;[#VocSignExe]&quot;,0" />
+          <RegistryValue Key="shell\open\command" Type="string" Value="&quot;[#VocSignExe]&quot; &quot;%1&quot;" />
+        </RegistryKey>
+{{- range .Extensions}}
+        <RegistryValue Root="HKLM" Key="SOFTWARE\Classes\.{{.}}\OpenWithProgids" Name="{{$.BundleID}}.certificate" Type="string" Value="" />
+{{- end}}
+{{- if .Autostart}}
+
+        <RegistryValue Root="HKLM" Key="SOFTWARE\Microsoft\Windows\CurrentVersion\Run" Name="VocSign" Type="string" Value="&quot;[#VocSignExe]&quot; {{.AutostartFlag}}" />
+{{- end}}
+      </Component>
+    </Feature>
+  </Package>
+</Wix>
+`
+
+// infoPlist is the bundle's Info.plist. com.rsa.pkcs-12 is the system type
+// of .p12 and .pfx files; Alternate keeps Keychain Access their default.
+const infoPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>CFBundleName</key>
+	<string>VocSign</string>
+	<key>CFBundleDisplayName</key>
+	<string>VocSign</string>
+	<key>CFBundleIdentifier</key>
+	<string>{{.BundleID}}</string>
+	<key>CFBundleExecutable</key>
+	<string>vocsign</string>
+	<key>CFBundleIconFile</key>
+	<string>vocsign</string>
+	<key>CFBundlePackageType</key>
+	<string>APPL</string>
+	<key>CFBundleShortVersionString</key>
+	<string>{{.NumericVersion}}</string>
+	<key>CFBundleVersion</key>
+	<string>{{.NumericVersion}}</string>
+	<key>LSMinimumSystemVersion</key>
+	<string>11.0</string>
+	<key>NSHighResolutionCapable</key>
+	<true/>
+	<key>CFBundleURLTypes</key>
+	<array>
+		<dict>
+			<key>CFBundleURLName</key>
+			<string>{{.BundleID}}</string>
+			<key>CFBundleURLSchemes</key>
+			<array>
+				<string>{{.Scheme}}</string>
+			</array>
+		</dict>
+	</array>
+	<key>CFBundleDocumentTypes</key>
+	<array>
+		<dict>
+			<key>CFBundleTypeName</key>
+			<string>PKCS#12 certificate</string>
+			<key>CFBundleTypeRole</key>
+			<string>Viewer</string>
+			<key>LSHandlerRank</key>
+			<string>Alternate</string>
+			<key>LSItemContentTypes</key>
+			<array>
+				<string>com.rsa.pkcs-12</string>
+			</array>
+		</dict>
+	</array>
+</dict>
+</plist>
+`
+
+const launchAgent = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>{{.BundleID}}.autostart</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>{{xml .MacExec}}</string>
+		<string>{{.AutostartFlag}}</string>
+	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>LimitLoadToSessionType</key>
+	<string>Aqua</string>
+</dict>
+</plist>
+`
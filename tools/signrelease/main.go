@@ -0,0 +1,78 @@
+// Command signrelease writes the signed manifest (.sig) that VocSign checks
+// against its own executable at startup.
+//
+//	signrelease -genkey -key release.key          # prints the public key
+//	signrelease -key release.key -version v1.4.0 build/vocsign-linux-amd64
+//
+// With -pubkey, the key must match the public key the binaries were built
+// with, so a release is never signed with a key its clients do not hold.
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/vocdoni/gofirma/vocsign/internal/selfcheck"
+)
+
+func main() {
+	var (
+		keyPath string
+		pubKey  string
+		version string
+		genKey  bool
+	)
+	flag.StringVar(&keyPath, "key", "", "Path to the base64 Ed25519 private key")
+	flag.StringVar(&pubKey, "pubkey", "", "Base64 public key the binaries embed, checked against -key")
+	flag.StringVar(&version, "version", "", "Release version the binaries were built with")
+	flag.BoolVar(&genKey, "genkey", false, "Generate a new key pair at -key and print the public key")
+	flag.Parse()
+
+	if keyPath == "" {
+		log.Fatal("-key is required")
+	}
+	if genKey {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			log.Fatalf("Failed to generate key: %v", err)
+		}
+		if err := os.WriteFile(keyPath, []byte(base64.StdEncoding.EncodeToString(priv)+"\n"), 0o600); err != nil {
+			log.Fatalf("Failed to write key: %v", err)
+		}
+		fmt.Println(base64.StdEncoding.EncodeToString(pub))
+		return
+	}
+
+	if version == "" || flag.NArg() == 0 {
+		log.Fatal("usage: signrelease -key release.key -version VERSION BINARY...")
+	}
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		log.Fatalf("Failed to read key: %v", err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(raw) != ed25519.PrivateKeySize {
+		log.Fatalf("Invalid private key in %s", keyPath)
+	}
+	priv := ed25519.PrivateKey(raw)
+	if pubKey != "" && base64.StdEncoding.EncodeToString(priv.Public().(ed25519.PublicKey)) != pubKey {
+		log.Fatalf("The key in %s does not match -pubkey", keyPath)
+	}
+
+	for _, bin := range flag.Args() {
+		sig, err := selfcheck.Sign(bin, version, priv)
+		if err != nil {
+			log.Fatalf("Failed to sign %s: %v", bin, err)
+		}
+		if err := os.WriteFile(bin+selfcheck.SignatureSuffix, sig, 0o644); err != nil {
+			log.Fatalf("Failed to write signature for %s: %v", bin, err)
+		}
+		log.Printf("Signed %s", bin)
+	}
+}